@@ -0,0 +1,215 @@
+// Package agent runs pod operations (file copies, execs, and log tailing)
+// from inside the cluster, on behalf of a Tilt that can reach the apiserver
+// but not the pods themselves.
+//
+// Network policies on some clusters block the connections that `kubectl exec`
+// and friends need. When that happens, Tilt installs a small agent deployment
+// in the pod's namespace, and talks to it through the apiserver's service
+// proxy instead.
+//
+// The agent can exec into any pod in its namespace, so it only serves
+// requests that carry the token that Tilt generated when it installed it.
+package agent
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// The name of all the objects that make up the agent.
+const Name = "tilt-agent"
+
+// The port that the agent serves on, inside the cluster.
+const Port = 10351
+
+// The agent runs the Tilt release image.
+const imageRepo = "docker.io/tiltdev/tilt"
+
+// Records which version of Tilt the agent is running.
+const VersionAnnotation = "tilt.dev/agent-version"
+
+// Records a hash of the agent's token, so that the agent restarts
+// when Tilt installs it with a new token.
+const TokenHashAnnotation = "tilt.dev/agent-token-hash"
+
+// The env var that the agent reads its token from.
+const TokenEnv = "TILT_AGENT_TOKEN"
+
+// The key of the token in the agent's Secret.
+const tokenSecretKey = "token"
+
+// Generates a random token for a new install of the agent.
+func NewToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("generating in-cluster agent token: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:8])
+}
+
+// The image for the agent, pinned to the version of the Tilt that installs it,
+// so that both ends always speak the same protocol.
+func Image(build model.TiltBuild) (string, error) {
+	if build.Dev || build.Version == "" {
+		return "", fmt.Errorf("the in-cluster agent is only available in released versions of Tilt, not v%s", build.AnalyticsVersion())
+	}
+	return fmt.Sprintf("%s:v%s", imageRepo, build.Version), nil
+}
+
+// The objects to apply to run the agent in a namespace, accepting requests
+// with the given token.
+func Entities(ns k8s.Namespace, build model.TiltBuild, token string) ([]k8s.K8sEntity, error) {
+	image, err := Image(build)
+	if err != nil {
+		return nil, err
+	}
+	return entities(ns, image, build.Version, token), nil
+}
+
+func entities(ns k8s.Namespace, image string, version string, token string) []k8s.K8sEntity {
+	labels := map[string]string{
+		"app.kubernetes.io/name": Name,
+		k8s.ManagedByLabel:       k8s.ManagedByValue,
+	}
+	meta := metav1.ObjectMeta{
+		Name:      Name,
+		Namespace: ns.String(),
+		Labels:    labels,
+	}
+	replicas := int32(1)
+
+	sa := &v1.ServiceAccount{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+		ObjectMeta: meta,
+	}
+	secret := &v1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: meta,
+		Type:       v1.SecretTypeOpaque,
+		StringData: map[string]string{tokenSecretKey: token},
+	}
+	role := &rbacv1.Role{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+		ObjectMeta: meta,
+		Rules: []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list"}},
+			{APIGroups: []string{""}, Resources: []string{"pods/exec"}, Verbs: []string{"create"}},
+			{APIGroups: []string{""}, Resources: []string{"pods/log"}, Verbs: []string{"get"}},
+		},
+	}
+	binding := &rbacv1.RoleBinding{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding"},
+		ObjectMeta: meta,
+		RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "Role", Name: Name},
+		Subjects:   []rbacv1.Subject{{Kind: "ServiceAccount", Name: Name, Namespace: ns.String()}},
+	}
+	deployment := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: meta,
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			// Never leave an agent with an old token serving next to a new one.
+			Strategy: appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+					Annotations: map[string]string{
+						VersionAnnotation:   version,
+						TokenHashAnnotation: tokenHash(token),
+					},
+				},
+				Spec: v1.PodSpec{
+					ServiceAccountName: Name,
+					Containers: []v1.Container{
+						{
+							Name:    "agent",
+							Image:   image,
+							Command: []string{"tilt", "agent", fmt.Sprintf("--port=%d", Port)},
+							Ports:   []v1.ContainerPort{{ContainerPort: Port}},
+							Env: []v1.EnvVar{
+								{
+									Name: TokenEnv,
+									ValueFrom: &v1.EnvVarSource{
+										SecretKeyRef: &v1.SecretKeySelector{
+											LocalObjectReference: v1.LocalObjectReference{Name: Name},
+											Key:                  tokenSecretKey,
+										},
+									},
+								},
+							},
+							ReadinessProbe: &v1.Probe{
+								Handler: v1.Handler{
+									HTTPGet: &v1.HTTPGetAction{Path: healthzPath, Port: intstr.FromInt(Port)},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	service := &v1.Service{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: meta,
+		Spec: v1.ServiceSpec{
+			Selector: labels,
+			Ports:    []v1.ServicePort{{Name: "http", Port: Port, TargetPort: intstr.FromInt(Port)}},
+		},
+	}
+
+	result := []k8s.K8sEntity{}
+	for _, obj := range []runtime.Object{sa, secret, role, binding, deployment, service} {
+		result = append(result, k8s.NewK8sEntity(obj))
+	}
+	return result
+}
+
+// Whether an error means that we couldn't reach a pod over the network,
+// as opposed to the pod running our command and failing.
+func IsNetworkError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	// The apiserver reports its own failures to reach the kubelet as strings.
+	msg := strings.ToLower(err.Error())
+	for _, s := range []string{
+		"error dialing backend",
+		"unable to upgrade connection",
+		"i/o timeout",
+		"connection refused",
+		"no route to host",
+	} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
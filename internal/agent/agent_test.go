@@ -0,0 +1,106 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestImagePinnedToVersion(t *testing.T) {
+	image, err := Image(model.TiltBuild{Version: "0.30.0"})
+	require.NoError(t, err)
+	assert.Equal(t, "docker.io/tiltdev/tilt:v0.30.0", image)
+}
+
+func TestImageDevBuild(t *testing.T) {
+	_, err := Image(model.TiltBuild{Version: "0.30.0", Dev: true})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only available in released versions of Tilt, not v0.30.0-dev")
+}
+
+func TestEntities(t *testing.T) {
+	entities, err := Entities("dev", model.TiltBuild{Version: "0.30.0"}, "secret-token")
+	require.NoError(t, err)
+
+	var kinds []string
+	for _, e := range entities {
+		kinds = append(kinds, e.GVK().Kind)
+		assert.Equal(t, k8s.Namespace("dev"), e.Namespace())
+		assert.Equal(t, Name, e.Name())
+	}
+	assert.Equal(t, []string{"ServiceAccount", "Secret", "Role", "RoleBinding", "Deployment", "Service"}, kinds)
+
+	secret := entities[1].Obj.(*v1.Secret)
+	assert.Equal(t, "secret-token", secret.StringData["token"])
+
+	deployment := entities[4].Obj.(*appsv1.Deployment)
+	ctr := deployment.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "docker.io/tiltdev/tilt:v0.30.0", ctr.Image)
+	assert.Equal(t, "0.30.0", deployment.Spec.Template.Annotations[VersionAnnotation])
+	assert.Equal(t, TokenEnv, ctr.Env[0].Name)
+	assert.Equal(t, Name, ctr.Env[0].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, healthzPath, ctr.ReadinessProbe.HTTPGet.Path)
+	assert.Equal(t, appsv1.RecreateDeploymentStrategyType, deployment.Spec.Strategy.Type)
+
+	// A new token restarts the agent.
+	other, err := Entities("dev", model.TiltBuild{Version: "0.30.0"}, "other-token")
+	require.NoError(t, err)
+	assert.NotEqual(t,
+		deployment.Spec.Template.Annotations[TokenHashAnnotation],
+		other[4].Obj.(*appsv1.Deployment).Spec.Template.Annotations[TokenHashAnnotation])
+	assert.NotContains(t, deployment.Spec.Template.Annotations[TokenHashAnnotation], "secret-token")
+}
+
+func TestServerRequiresToken(t *testing.T) {
+	s := NewServer(k8s.NewFakeK8sClient(t), "0.30.0", "secret-token")
+
+	for _, path := range []string{versionPath, execPath + "?cmd=ls", logsPath} {
+		for _, token := range []string{"", "wrong-token"} {
+			req := httptest.NewRequest(http.MethodPost, path, nil)
+			if token != "" {
+				req.Header.Set(TokenHeader, token)
+			}
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusUnauthorized, w.Code, "%s with token %q", path, token)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, versionPath, nil)
+	req.Header.Set(TokenHeader, "secret-token")
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// The readiness probe doesn't know the token.
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, healthzPath, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestServerWithoutTokenRejectsEverything(t *testing.T) {
+	s := NewServer(k8s.NewFakeK8sClient(t), "0.30.0", "")
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, versionPath, nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestIsNetworkError(t *testing.T) {
+	assert.True(t, IsNetworkError(&net.OpError{Op: "dial", Err: errors.New("connection refused")}))
+	assert.True(t, IsNetworkError(fmt.Errorf("copying changed files: %w",
+		errors.New("error dialing backend: dial tcp 10.0.0.5:10250: i/o timeout"))))
+	assert.True(t, IsNetworkError(errors.New("unable to upgrade connection: Forbidden")))
+	assert.False(t, IsNetworkError(errors.New("tar: permission denied")))
+	assert.False(t, IsNetworkError(nil))
+}
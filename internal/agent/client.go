@@ -0,0 +1,158 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/exec"
+
+	"github.com/tilt-dev/tilt/internal/container"
+	"github.com/tilt-dev/tilt/internal/k8s"
+)
+
+// Talks to the agent in one namespace.
+//
+// Runs pod operations the same way the k8s client does, so that
+// the two are interchangeable.
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+var _ k8s.PodOps = &Client{}
+
+// Creates a client for an agent at baseURL that accepts the given token.
+// The transport decides how we get there (e.g., through the apiserver's proxy).
+func NewClient(baseURL string, token string, transport http.RoundTripper) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Transport: transport},
+	}
+}
+
+// Connects to the agent in a namespace, with the agent's token.
+type Dialer func(ns k8s.Namespace, token string) (*Client, error)
+
+// Connects to agents through the apiserver's service proxy, which works
+// even when the cluster network is closed to us.
+func ProvideDialer(restConfig k8s.RESTConfigOrError) Dialer {
+	return func(ns k8s.Namespace, token string) (*Client, error) {
+		if restConfig.Error != nil {
+			return nil, restConfig.Error
+		}
+
+		transport, err := rest.TransportFor(restConfig.Config)
+		if err != nil {
+			return nil, errors.Wrap(err, "connecting to in-cluster agent")
+		}
+
+		baseURL := fmt.Sprintf("%s/api/v1/namespaces/%s/services/%s:%d/proxy",
+			strings.TrimSuffix(restConfig.Config.Host, "/"), ns, Name, Port)
+		return NewClient(baseURL, token, transport), nil
+	}
+}
+
+// The version of Tilt that the agent is running.
+func (c *Client) Version(ctx context.Context) (string, error) {
+	body, err := c.do(ctx, http.MethodGet, versionPath, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = body.Close()
+	}()
+
+	var resp versionResponse
+	err = json.NewDecoder(body).Decode(&resp)
+	if err != nil {
+		return "", errors.Wrap(err, "reading agent version")
+	}
+	return resp.Version, nil
+}
+
+func (c *Client) Exec(ctx context.Context, podID k8s.PodID, cName container.Name, n k8s.Namespace, cmd []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	q := url.Values{}
+	q.Set("namespace", n.String())
+	q.Set("pod", podID.String())
+	q.Set("container", cName.String())
+	q["cmd"] = cmd
+	if stdin != nil {
+		q.Set("stdin", "true")
+	}
+
+	body, err := c.do(ctx, http.MethodPost, execPath, q, stdin)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = body.Close()
+	}()
+
+	var resp execResponse
+	err = json.NewDecoder(body).Decode(&resp)
+	if err != nil {
+		return errors.Wrap(err, "reading agent exec result")
+	}
+
+	if stdout != nil {
+		_, _ = io.WriteString(stdout, resp.Stdout)
+	}
+	if stderr != nil {
+		_, _ = io.WriteString(stderr, resp.Stderr)
+	}
+
+	if resp.ExitCode != 0 {
+		return exec.CodeExitError{Err: errors.New(resp.Error), Code: resp.ExitCode}
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
+
+func (c *Client) ContainerLogs(ctx context.Context, podID k8s.PodID, cName container.Name, n k8s.Namespace, startTime time.Time) (io.ReadCloser, error) {
+	q := url.Values{}
+	q.Set("namespace", n.String())
+	q.Set("pod", podID.String())
+	q.Set("container", cName.String())
+	if !startTime.IsZero() {
+		q.Set("since", startTime.Format(time.RFC3339Nano))
+	}
+	return c.do(ctx, http.MethodGet, logsPath, q, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, q url.Values, body io.Reader) (io.ReadCloser, error) {
+	u := c.baseURL + path
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, errors.Wrap(err, "in-cluster agent")
+	}
+	req.Header.Set(TokenHeader, c.token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "in-cluster agent")
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("in-cluster agent: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/util/exec"
+
+	"github.com/tilt-dev/tilt/internal/k8s"
+)
+
+func TestClientVersion(t *testing.T) {
+	f := newClientFixture(t)
+
+	version, err := f.client.Version(f.ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "0.30.0", version)
+	assert.Equal(t, []string{"GET tilt-agent.default/version"}, f.transport.Requests())
+}
+
+func TestClientExecCopiesStdin(t *testing.T) {
+	f := newClientFixture(t)
+	f.kCli.ExecOutputs = []io.Reader{strings.NewReader("extracted\n")}
+
+	stdout := bytes.NewBuffer(nil)
+	err := f.client.Exec(f.ctx, "pod-a", "main", "default",
+		[]string{"tar", "-C", "/", "-x", "-f", "-"}, strings.NewReader("archive-bytes"), stdout, stdout)
+	require.NoError(t, err)
+	assert.Equal(t, "extracted\n", stdout.String())
+
+	require.Len(t, f.kCli.ExecCalls, 1)
+	call := f.kCli.ExecCalls[0]
+	assert.Equal(t, k8s.PodID("pod-a"), call.PID)
+	assert.Equal(t, "main", call.CName.String())
+	assert.Equal(t, k8s.Namespace("default"), call.Ns)
+	assert.Equal(t, []string{"tar", "-C", "/", "-x", "-f", "-"}, call.Cmd)
+	assert.Equal(t, "archive-bytes", string(call.Stdin))
+}
+
+func TestClientExecExitCode(t *testing.T) {
+	f := newClientFixture(t)
+	f.kCli.ExecErrors = []error{exec.CodeExitError{Err: errors.New("command terminated with exit code 2"), Code: 2}}
+
+	err := f.client.Exec(f.ctx, "pod-a", "main", "default", []string{"make"}, nil, nil, nil)
+	require.Error(t, err)
+
+	// Exit codes come back the same way they do from the k8s client,
+	// so that run step failures are reported the same way.
+	exitErr, ok := err.(exec.CodeExitError)
+	require.True(t, ok, "expected a CodeExitError, got %T", err)
+	assert.Equal(t, 2, exitErr.Code)
+	assert.Equal(t, "command terminated with exit code 2", exitErr.Error())
+}
+
+func TestClientExecError(t *testing.T) {
+	f := newClientFixture(t)
+	f.kCli.ExecErrors = []error{errors.New("pod not found")}
+
+	err := f.client.Exec(f.ctx, "pod-a", "main", "default", []string{"ls"}, nil, nil, nil)
+	require.Error(t, err)
+	_, isExitErr := err.(exec.CodeExitError)
+	assert.False(t, isExitErr)
+	assert.Equal(t, "pod not found", err.Error())
+}
+
+func TestClientContainerLogs(t *testing.T) {
+	f := newClientFixture(t)
+	f.kCli.SetLogsForPodContainer("pod-a", "main", "hello\nworld\n")
+
+	logs, err := f.client.ContainerLogs(f.ctx, "pod-a", "main", "default", time.Now())
+	require.NoError(t, err)
+	defer func() {
+		_ = logs.Close()
+	}()
+
+	contents, err := ioutil.ReadAll(logs)
+	require.NoError(t, err)
+	assert.Equal(t, "hello\nworld\n", string(contents))
+}
+
+func TestClientUnreachable(t *testing.T) {
+	f := newClientFixture(t)
+	f.transport.SetError(errors.New("connection refused"))
+
+	_, err := f.client.Version(f.ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "in-cluster agent")
+	assert.True(t, IsNetworkError(err))
+}
+
+type clientFixture struct {
+	ctx       context.Context
+	kCli      *k8s.FakeK8sClient
+	transport *FakeTransport
+	client    *Client
+}
+
+func newClientFixture(t *testing.T) *clientFixture {
+	kCli := k8s.NewFakeK8sClient(t)
+	transport := NewFakeTransport(kCli, "0.30.0")
+	client, err := FakeDialer(transport)("default", testToken)
+	require.NoError(t, err)
+	return &clientFixture{
+		ctx:       context.Background(),
+		kCli:      kCli,
+		transport: transport,
+		client:    client,
+	}
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/tilt-dev/tilt/internal/k8s"
+)
+
+// Hands requests straight to an agent server, as if through the
+// apiserver's proxy.
+//
+// The agent on the other end accepts the token from the first dial,
+// like an agent that Tilt just installed.
+type FakeTransport struct {
+	mu       sync.Mutex
+	pods     k8s.PodOps
+	version  string
+	server   http.Handler
+	err      error
+	requests []string
+}
+
+var _ http.RoundTripper = &FakeTransport{}
+
+func NewFakeTransport(pods k8s.PodOps, version string) *FakeTransport {
+	return &FakeTransport{pods: pods, version: version}
+}
+
+// Replaces the agent on the other end (e.g., with an upgraded one).
+func (t *FakeTransport) SetServer(server http.Handler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.server = server
+}
+
+// Makes every request fail, as if the agent were unreachable.
+func (t *FakeTransport) SetError(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.err = err
+}
+
+// The method and path of each request, in order.
+func (t *FakeTransport) Requests() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string{}, t.requests...)
+}
+
+func (t *FakeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.requests = append(t.requests, fmt.Sprintf("%s %s%s", req.Method, req.URL.Host, req.URL.Path))
+	server := t.server
+	err := t.err
+	t.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	if server == nil {
+		return nil, fmt.Errorf("no agent installed")
+	}
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	return w.Result(), nil
+}
+
+// Connects to the agent in each namespace through the same fake transport.
+func FakeDialer(t *FakeTransport) Dialer {
+	return func(ns k8s.Namespace, token string) (*Client, error) {
+		t.mu.Lock()
+		if t.server == nil {
+			t.server = NewServer(t.pods, t.version, token)
+		}
+		t.mu.Unlock()
+		return NewClient(fmt.Sprintf("http://%s.%s", Name, ns), token, t), nil
+	}
+}
+
+// Adds an agent deployment to the fake cluster, as if Tilt had installed it.
+func InjectFakeAgent(kCli *k8s.FakeK8sClient, ns k8s.Namespace) {
+	for _, e := range entities(ns, "", "", "") {
+		if e.GVK().Kind == "Deployment" {
+			e.SetUID(fmt.Sprintf("%s-%s", Name, ns))
+			kCli.Inject(e)
+		}
+	}
+}
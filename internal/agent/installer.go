@@ -0,0 +1,194 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+const upsertTimeout = 30 * time.Second
+
+// Returned when the agent in the cluster isn't running the same version of
+// Tilt as we are, and didn't get upgraded in time.
+type VersionMismatchError struct {
+	Namespace    k8s.Namespace
+	AgentVersion string
+	TiltVersion  string
+}
+
+func (e VersionMismatchError) Error() string {
+	return fmt.Sprintf("in-cluster agent in namespace %s is running Tilt v%s, but this is Tilt v%s",
+		e.Namespace, e.AgentVersion, e.TiltVersion)
+}
+
+// Installs agents on demand, one per namespace.
+//
+// Also remembers whether Tilt has needed an agent yet, so that once direct
+// access to pods fails, we stop trying it.
+type Installer struct {
+	kCli  k8s.Client
+	dial  Dialer
+	build model.TiltBuild
+
+	readyTimeout time.Duration
+	pollInterval time.Duration
+
+	mu        sync.Mutex
+	clients   map[k8s.Namespace]*Client
+	preferred bool
+
+	// The token for every agent that this Tilt installs.
+	token string
+}
+
+func NewInstaller(kCli k8s.Client, dial Dialer, build model.TiltBuild) *Installer {
+	return &Installer{
+		kCli:         kCli,
+		dial:         dial,
+		build:        build,
+		readyTimeout: 2 * time.Minute,
+		pollInterval: time.Second,
+		clients:      make(map[k8s.Namespace]*Client),
+	}
+}
+
+// Whether we've already found that we can't reach pods directly.
+func (i *Installer) Preferred() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.preferred
+}
+
+// Records that we couldn't reach a pod directly, so that later pod operations
+// go straight to the agent.
+func (i *Installer) SetPreferred() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.preferred = true
+}
+
+// Returns a client for the agent in a namespace.
+//
+// The first time we ask for a namespace, applies the agent (which upgrades any
+// agent left behind by a different version of Tilt) and waits for it to
+// report our version.
+func (i *Installer) Client(ctx context.Context, ns k8s.Namespace) (*Client, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if c, ok := i.clients[ns]; ok {
+		return c, nil
+	}
+
+	if i.token == "" {
+		token, err := NewToken()
+		if err != nil {
+			return nil, err
+		}
+		i.token = token
+	}
+
+	entities, err := Entities(ns, i.build, i.token)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Get(ctx).Infof("Installing the in-cluster agent in namespace %s", ns)
+	_, err = i.kCli.Upsert(ctx, entities, upsertTimeout)
+	if err != nil {
+		return nil, errors.Wrapf(err, "installing in-cluster agent in namespace %s", ns)
+	}
+
+	c, err := i.dial(ns, i.token)
+	if err != nil {
+		return nil, err
+	}
+
+	err = i.waitForVersion(ctx, ns, c)
+	if err != nil {
+		return nil, err
+	}
+
+	i.clients[ns] = c
+	return c, nil
+}
+
+// Forgets the agent in a namespace, so that we re-install it next time
+// (e.g., because someone deleted it).
+func (i *Installer) Forget(ns k8s.Namespace) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	delete(i.clients, ns)
+}
+
+func (i *Installer) waitForVersion(ctx context.Context, ns k8s.Namespace, c *Client) error {
+	ctx, cancel := context.WithTimeout(ctx, i.readyTimeout)
+	defer cancel()
+
+	for {
+		version, err := c.Version(ctx)
+		if err == nil {
+			if version == i.build.Version {
+				return nil
+			}
+			// During a rollout, the old agent answers until the new one is ready.
+			err = VersionMismatchError{Namespace: ns, AgentVersion: version, TiltVersion: i.build.Version}
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(err, "waiting for in-cluster agent in namespace %s", ns)
+		case <-time.After(i.pollInterval):
+		}
+	}
+}
+
+// Deletes the agent from any of the given namespaces where Tilt installed it.
+//
+// Namespaces without an agent are left alone, so that users who never needed
+// the agent don't need permission to delete it.
+func Uninstall(ctx context.Context, kCli k8s.Client, namespaces []k8s.Namespace) error {
+	var toDelete []k8s.K8sEntity
+	var errs []error
+	for _, ns := range namespaces {
+		installed, err := isInstalled(ctx, kCli, ns)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "looking for in-cluster agent in namespace %s", ns))
+			continue
+		}
+		if installed {
+			toDelete = append(toDelete, entities(ns, "", "", "")...)
+		}
+	}
+
+	if len(toDelete) > 0 {
+		err := kCli.Delete(ctx, k8s.ReverseSortedEntities(toDelete))
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "deleting in-cluster agent"))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// Whether Tilt's agent deployment is in the namespace.
+func isInstalled(ctx context.Context, kCli k8s.Client, ns k8s.Namespace) (bool, error) {
+	deployments, err := kCli.ListMeta(ctx, appsv1.SchemeGroupVersion.WithKind("Deployment"), ns)
+	if err != nil {
+		return false, err
+	}
+	for _, d := range deployments {
+		if d.GetName() == Name && d.GetLabels()[k8s.ManagedByLabel] == k8s.ManagedByValue {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,167 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/testutils"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestInstallerInstallsOnce(t *testing.T) {
+	f := newInstallerFixture(t, "0.30.0")
+
+	c1, err := f.installer.Client(f.ctx, "default")
+	require.NoError(t, err)
+	assert.Contains(t, f.kCli.Yaml, "image: docker.io/tiltdev/tilt:v0.30.0")
+
+	f.kCli.Yaml = ""
+	c2, err := f.installer.Client(f.ctx, "default")
+	require.NoError(t, err)
+	assert.Same(t, c1, c2)
+	assert.Equal(t, "", f.kCli.Yaml)
+
+	// Each namespace gets its own agent.
+	_, err = f.installer.Client(f.ctx, "other")
+	require.NoError(t, err)
+	assert.Contains(t, f.kCli.Yaml, "namespace: other")
+}
+
+func TestInstallerVersionMismatch(t *testing.T) {
+	f := newInstallerFixture(t, "0.29.0")
+
+	_, err := f.installer.Client(f.ctx, "default")
+	require.Error(t, err)
+
+	var mismatch VersionMismatchError
+	require.True(t, errors.As(err, &mismatch))
+	assert.Equal(t, "0.29.0", mismatch.AgentVersion)
+	assert.Equal(t, "0.30.0", mismatch.TiltVersion)
+	assert.Contains(t, err.Error(),
+		"in-cluster agent in namespace default is running Tilt v0.29.0, but this is Tilt v0.30.0")
+}
+
+func TestInstallerWaitsForUpgrade(t *testing.T) {
+	f := newInstallerFixture(t, "0.29.0")
+
+	// The new agent comes up after a couple of polls.
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		f.transport.SetServer(NewServer(f.kCli, "0.30.0", testToken))
+	}()
+
+	f.installer.readyTimeout = time.Second
+	_, err := f.installer.Client(f.ctx, "default")
+	require.NoError(t, err)
+}
+
+func TestInstallerRejectsAgentWithOldToken(t *testing.T) {
+	f := newInstallerFixture(t, "0.30.0")
+
+	// An agent from an earlier install is still running with its own token.
+	f.transport.SetServer(NewServer(f.kCli, "0.30.0", "old-token"))
+
+	_, err := f.installer.Client(f.ctx, "default")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401 Unauthorized")
+}
+
+func TestInstallerGeneratesToken(t *testing.T) {
+	f := newInstallerFixture(t, "0.30.0")
+	f.installer.token = ""
+
+	c, err := f.installer.Client(f.ctx, "default")
+	require.NoError(t, err)
+	assert.Len(t, c.token, 64)
+	assert.Contains(t, f.kCli.Yaml, "kind: Secret")
+	assert.Contains(t, f.kCli.Yaml, "token: "+c.token)
+}
+
+func TestInstallerDevBuild(t *testing.T) {
+	f := newInstallerFixture(t, "0.30.0")
+	f.installer.build.Dev = true
+
+	_, err := f.installer.Client(f.ctx, "default")
+	require.Error(t, err)
+	assert.Equal(t, "", f.kCli.Yaml)
+}
+
+func TestInstallerForget(t *testing.T) {
+	f := newInstallerFixture(t, "0.30.0")
+
+	_, err := f.installer.Client(f.ctx, "default")
+	require.NoError(t, err)
+
+	f.installer.Forget("default")
+	f.kCli.Yaml = ""
+	_, err = f.installer.Client(f.ctx, "default")
+	require.NoError(t, err)
+	assert.Contains(t, f.kCli.Yaml, "name: tilt-agent")
+}
+
+func TestUninstall(t *testing.T) {
+	kCli := k8s.NewFakeK8sClient(t)
+	InjectFakeAgent(kCli, "a")
+	err := Uninstall(context.Background(), kCli, []k8s.Namespace{"a"})
+	require.NoError(t, err)
+	assert.Contains(t, kCli.DeletedYaml, "kind: Deployment")
+	assert.Contains(t, kCli.DeletedYaml, "kind: Secret")
+	assert.Contains(t, kCli.DeletedYaml, "namespace: a")
+}
+
+func TestUninstallOnlyWhereInstalled(t *testing.T) {
+	kCli := k8s.NewFakeK8sClient(t)
+	InjectFakeAgent(kCli, "a")
+	err := Uninstall(context.Background(), kCli, []k8s.Namespace{"a", "b"})
+	require.NoError(t, err)
+	assert.Contains(t, kCli.DeletedYaml, "namespace: a")
+	assert.NotContains(t, kCli.DeletedYaml, "namespace: b")
+}
+
+func TestUninstallNotInstalled(t *testing.T) {
+	kCli := k8s.NewFakeK8sClient(t)
+	err := Uninstall(context.Background(), kCli, []k8s.Namespace{"a"})
+	require.NoError(t, err)
+	assert.Empty(t, kCli.DeletedYamls)
+}
+
+func TestUninstallListError(t *testing.T) {
+	kCli := k8s.NewFakeK8sClient(t)
+	InjectFakeAgent(kCli, "a")
+	kCli.SetConnectionError(errors.New("forbidden"))
+	err := Uninstall(context.Background(), kCli, []k8s.Namespace{"a"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "looking for in-cluster agent in namespace a: forbidden")
+	assert.Empty(t, kCli.DeletedYamls)
+}
+
+const testToken = "test-token"
+
+type installerFixture struct {
+	ctx       context.Context
+	kCli      *k8s.FakeK8sClient
+	transport *FakeTransport
+	installer *Installer
+}
+
+func newInstallerFixture(t *testing.T, agentVersion string) *installerFixture {
+	kCli := k8s.NewFakeK8sClient(t)
+	transport := NewFakeTransport(kCli, agentVersion)
+	installer := NewInstaller(kCli, FakeDialer(transport), model.TiltBuild{Version: "0.30.0"})
+	installer.token = testToken
+	installer.readyTimeout = 20 * time.Millisecond
+	installer.pollInterval = time.Millisecond
+	ctx, _, _ := testutils.CtxAndAnalyticsForTest()
+	return &installerFixture{
+		ctx:       ctx,
+		kCli:      kCli,
+		transport: transport,
+		installer: installer,
+	}
+}
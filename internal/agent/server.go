@@ -0,0 +1,165 @@
+package agent
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"k8s.io/client-go/util/exec"
+
+	"github.com/tilt-dev/tilt/internal/container"
+	"github.com/tilt-dev/tilt/internal/k8s"
+)
+
+const healthzPath = "/healthz"
+const versionPath = "/version"
+const execPath = "/exec"
+const logsPath = "/logs"
+
+// The header that carries the agent's token.
+//
+// We can't use the Authorization header, because the apiserver removes it
+// after authenticating the request, before proxying it to the agent.
+const TokenHeader = "X-Tilt-Agent-Token"
+
+type versionResponse struct {
+	Version string `json:"version"`
+}
+
+// The agent runs a command to completion, then sends back everything
+// it printed, so that a dropped connection can't leave an exec half-reported.
+type execResponse struct {
+	Stdout   string `json:"stdout,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+	ExitCode int    `json:"exitCode,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Serves the agent's API, from inside the cluster.
+//
+// Every endpoint except the health check requires the token.
+type Server struct {
+	pods    k8s.PodOps
+	version string
+	token   string
+	mux     *http.ServeMux
+}
+
+var _ http.Handler = &Server{}
+
+func NewServer(pods k8s.PodOps, version string, token string) *Server {
+	s := &Server{pods: pods, version: version, token: token, mux: http.NewServeMux()}
+	s.mux.HandleFunc(healthzPath, s.handleHealthz)
+	s.mux.HandleFunc(versionPath, s.requireToken(s.handleVersion))
+	s.mux.HandleFunc(execPath, s.requireToken(s.handleExec))
+	s.mux.HandleFunc(logsPath, s.requireToken(s.handleLogs))
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) requireToken(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		got := r.Header.Get(TokenHeader)
+		if s.token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) != 1 {
+			http.Error(w, "missing or invalid agent token", http.StatusUnauthorized)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, versionResponse{Version: s.version})
+}
+
+func (s *Server) handleExec(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "exec must be a POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	cmd := q["cmd"]
+	if len(cmd) == 0 {
+		http.Error(w, "missing cmd", http.StatusBadRequest)
+		return
+	}
+
+	var stdin io.Reader
+	if q.Get("stdin") == "true" {
+		stdin = r.Body
+	}
+
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	err := s.pods.Exec(r.Context(), k8s.PodID(q.Get("pod")), container.Name(q.Get("container")),
+		k8s.Namespace(q.Get("namespace")), cmd, stdin, stdout, stderr)
+
+	resp := execResponse{Stdout: stdout.String(), Stderr: stderr.String()}
+	if err != nil {
+		resp.Error = err.Error()
+		if exitErr, ok := err.(exec.CodeExitError); ok {
+			resp.ExitCode = exitErr.Code
+		}
+	}
+	writeJSON(w, resp)
+}
+
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var since time.Time
+	if q.Get("since") != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339Nano, q.Get("since"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid since: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	logs, err := s.pods.ContainerLogs(r.Context(), k8s.PodID(q.Get("pod")), container.Name(q.Get("container")),
+		k8s.Namespace(q.Get("namespace")), since)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer func() {
+		_ = logs.Close()
+	}()
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(flushWriter{w: w}, logs)
+}
+
+// Flushes each write, so that logs stream through the proxy as they happen.
+type flushWriter struct {
+	w http.ResponseWriter
+}
+
+func (fw flushWriter) Write(b []byte) (int, error) {
+	n, err := fw.w.Write(b)
+	if f, ok := fw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return n, err
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -1,6 +1,8 @@
 package analytics
 
 import (
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/tilt-dev/wmclient/pkg/analytics"
@@ -11,6 +13,9 @@ const TagOS = "os"
 const TagGitRepoHash = "git.origin"
 const TagSubcommand = "subcommand"
 
+// The app name that events are namespaced under.
+const AppName = "tilt"
+
 // An Analytics that allows opting in/out at runtime.
 type TiltAnalytics struct {
 	opter       AnalyticsOpter
@@ -24,6 +29,10 @@ type TiltAnalytics struct {
 }
 
 type optSet struct {
+	// The user opt is re-read from the report loop while other
+	// goroutines check the effective opt.
+	mu sync.Mutex
+
 	env      analytics.Opt
 	user     analytics.Opt
 	tiltfile analytics.Opt
@@ -103,14 +112,20 @@ func (ta *TiltAnalytics) Flush(timeout time.Duration) {
 }
 
 func (ta *TiltAnalytics) UserOpt() analytics.Opt {
+	ta.opt.mu.Lock()
+	defer ta.opt.mu.Unlock()
 	return ta.opt.user
 }
 
 func (ta *TiltAnalytics) TiltfileOpt() analytics.Opt {
+	ta.opt.mu.Lock()
+	defer ta.opt.mu.Unlock()
 	return ta.opt.tiltfile
 }
 
 func (ta *TiltAnalytics) EffectiveOpt() analytics.Opt {
+	ta.opt.mu.Lock()
+	defer ta.opt.mu.Unlock()
 	if ta.opt.env != analytics.OptDefault {
 		return ta.opt.env
 	}
@@ -121,14 +136,59 @@ func (ta *TiltAnalytics) EffectiveOpt() analytics.Opt {
 }
 
 func (ta *TiltAnalytics) SetUserOpt(opt analytics.Opt) error {
+	ta.opt.mu.Lock()
 	if opt == ta.opt.user {
+		ta.opt.mu.Unlock()
 		return nil
 	}
 	ta.opt.user = opt
+	ta.opt.mu.Unlock()
 	return ta.opter.SetUserOpt(opt)
 }
 
+// Re-read the user's persisted opt choice, so that a choice made
+// from another process (e.g., `tilt analytics opt out`) takes effect
+// without restarting.
+func (ta *TiltAnalytics) RefreshUserOpt() error {
+	opt, err := ta.opter.ReadUserOpt()
+	if err != nil {
+		return err
+	}
+	ta.opt.mu.Lock()
+	defer ta.opt.mu.Unlock()
+	ta.opt.user = opt
+	return nil
+}
+
+// The tags that every event is reported with.
+var globalTagNames = []string{
+	analytics.TagUser,
+	analytics.TagMachine,
+	TagVersion,
+	TagOS,
+	TagSubcommand,
+	TagGitRepoHash,
+}
+
+// Payload builds the JSON body that the remote analytics backend
+// would receive for an event. Used to show users what we send.
+func (ta *TiltAnalytics) Payload(name string, tags map[string]string) map[string]string {
+	result := make(map[string]string, len(tags)+len(globalTagNames)+1)
+	for _, tagName := range globalTagNames {
+		if v, ok := ta.a.GlobalTag(tagName); ok {
+			result[tagName] = v
+		}
+	}
+	for k, v := range tags {
+		result[k] = v
+	}
+	result[analytics.TagName] = fmt.Sprintf("%s.%s", AppName, name)
+	return result
+}
+
 func (ta *TiltAnalytics) SetTiltfileOpt(opt analytics.Opt) {
+	ta.opt.mu.Lock()
+	defer ta.opt.mu.Unlock()
 	ta.opt.tiltfile = opt
 }
 
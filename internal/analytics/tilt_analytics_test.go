@@ -1,6 +1,10 @@
 package analytics
 
 import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -189,3 +193,61 @@ func TestOptTransitionIncr(t *testing.T) {
 		})
 	}
 }
+
+type countingHTTPClient struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (c *countingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+}
+
+func (c *countingHTTPClient) Calls() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+func TestNoNetworkCallsAfterRefreshToOptOut(t *testing.T) {
+	httpClient := &countingHTTPClient{}
+	remote, err := analytics.NewRemoteAnalytics(AppName,
+		analytics.WithHTTPClient(httpClient),
+		analytics.WithEnabled(true),
+		analytics.WithUserID("user"),
+		analytics.WithMachineID("machine"))
+	assert.NoError(t, err)
+
+	opter := &userOptSetting{opt: analytics.OptIn}
+	a, _ := NewTiltAnalytics(opter, remote, versionTest)
+	a.opt.env = analytics.OptDefault
+
+	a.Incr("foo", testTags)
+	a.Flush(time.Second)
+	assert.Equal(t, 1, httpClient.Calls())
+
+	// Simulate the user opting out from another process.
+	opter.opt = analytics.OptOut
+	assert.NoError(t, a.RefreshUserOpt())
+	assert.Equal(t, analytics.OptOut, a.EffectiveOpt())
+
+	a.Incr("foo", testTags)
+	a.Count("foo", testTags, 3)
+	a.Timer("foo", time.Second, testTags)
+	a.Flush(time.Second)
+	assert.Equal(t, 1, httpClient.Calls())
+}
+
+func TestPayload(t *testing.T) {
+	ma := analytics.NewMemoryAnalytics()
+	a, _ := NewTiltAnalytics(&userOptSetting{opt: analytics.OptIn}, ma, versionTest)
+
+	payload := a.Payload("up.running", testTags)
+	assert.Equal(t, map[string]string{
+		analytics.TagName: "tilt.up.running",
+		"bar":             "baz",
+	}, payload)
+}
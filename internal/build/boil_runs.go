@@ -1,6 +1,9 @@
 package build
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/tilt-dev/tilt/pkg/model"
 )
 
@@ -24,3 +27,92 @@ func BoilRuns(runs []model.Run, pathMappings []PathMapping) ([]model.Cmd, error)
 	}
 	return res, nil
 }
+
+// The runs a live update needs, when some of the changed files only
+// need a restart.
+type BoiledRuns struct {
+	Cmds []model.Cmd
+
+	// True if any of the changed files only need the container restarted.
+	Restart bool
+
+	// How we classified the changed files, e.g.,
+	// "3 files: 2 sync+restart, 1 triggered 'pip install'"
+	Summary string
+}
+
+// Like BoilRuns, but changed files that match restartOnly skip all the runs,
+// and restart the container instead.
+//
+// A file that matches both restartOnly and a run's triggers needs the heavier
+// action, so it triggers the run.
+func BoilRunsWithRestarts(runs []model.Run, restartOnly model.PathSet, pathMappings []PathMapping) (BoiledRuns, error) {
+	localPaths := PathMappingsToLocalPaths(pathMappings)
+	matched := make([]bool, len(runs))
+
+	// For the summary, we count each file under the first run it triggered.
+	triggered := make([]int, len(runs))
+	syncCount := 0
+	restartCount := 0
+	for _, localPath := range localPaths {
+		triggeredBy := -1
+		for i, run := range runs {
+			if run.Triggers.Empty() {
+				continue
+			}
+			match, _, err := run.Triggers.AnyMatch([]string{localPath})
+			if err != nil {
+				return BoiledRuns{}, err
+			}
+			if match {
+				matched[i] = true
+				if triggeredBy == -1 {
+					triggeredBy = i
+				}
+			}
+		}
+		if triggeredBy != -1 {
+			triggered[triggeredBy]++
+			continue
+		}
+
+		restart, _, err := restartOnly.AnyMatch([]string{localPath})
+		if err != nil {
+			return BoiledRuns{}, err
+		}
+		if restart {
+			restartCount++
+		} else {
+			syncCount++
+		}
+	}
+
+	// Runs without triggers run on every change, except when all the
+	// changed files only need a restart.
+	onlyRestarts := restartCount > 0 && restartCount == len(localPaths)
+	res := BoiledRuns{Cmds: []model.Cmd{}, Restart: restartCount > 0}
+	for i, run := range runs {
+		if (run.Triggers.Empty() && !onlyRestarts) || matched[i] {
+			res.Cmds = append(res.Cmds, run.Cmd)
+		}
+	}
+
+	var parts []string
+	if syncCount > 0 {
+		parts = append(parts, fmt.Sprintf("%d sync", syncCount))
+	}
+	if restartCount > 0 {
+		parts = append(parts, fmt.Sprintf("%d sync+restart", restartCount))
+	}
+	for i, run := range runs {
+		if triggered[i] > 0 {
+			parts = append(parts, fmt.Sprintf("%d triggered '%s'", triggered[i], run.Cmd.String()))
+		}
+	}
+	files := "files"
+	if len(localPaths) == 1 {
+		files = "file"
+	}
+	res.Summary = fmt.Sprintf("%d %s: %s", len(localPaths), files, strings.Join(parts, ", "))
+	return res, nil
+}
@@ -199,3 +199,83 @@ func AbsPath(parts ...string) string {
 	}
 	return filepath.Join(append([]string{"/home/tilt"}, parts...)...)
 }
+
+func TestBoilRunsWithRestartsOnlyRestartFiles(t *testing.T) {
+	wd := AbsPath("test")
+	runs := []model.Run{
+		model.Run{Cmd: model.ToUnixCmd("echo always")},
+		model.Run{
+			Cmd:      model.ToUnixCmd("pip install"),
+			Triggers: model.NewPathSet([]string{"requirements.txt"}, wd),
+		},
+	}
+	restartOnly := model.NewPathSet([]string{"templates", "static"}, wd)
+
+	pathMappings := []PathMapping{
+		PathMapping{LocalPath: AbsPath("test", "templates", "index.html"), ContainerPath: "/app/templates/index.html"},
+		PathMapping{LocalPath: AbsPath("test", "static", "app.css"), ContainerPath: "/app/static/app.css"},
+	}
+
+	actual, err := BoilRunsWithRestarts(runs, restartOnly, pathMappings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Empty(t, actual.Cmds)
+	assert.True(t, actual.Restart)
+	assert.Equal(t, "2 files: 2 sync+restart", actual.Summary)
+}
+
+func TestBoilRunsWithRestartsMixed(t *testing.T) {
+	wd := AbsPath("test")
+	runs := []model.Run{
+		model.Run{Cmd: model.ToUnixCmd("echo always")},
+		model.Run{
+			Cmd:      model.ToUnixCmd("pip install"),
+			Triggers: model.NewPathSet([]string{"requirements.txt"}, wd),
+		},
+	}
+	restartOnly := model.NewPathSet([]string{"templates"}, wd)
+
+	pathMappings := []PathMapping{
+		PathMapping{LocalPath: AbsPath("test", "templates", "index.html"), ContainerPath: "/app/templates/index.html"},
+		PathMapping{LocalPath: AbsPath("test", "templates", "base.html"), ContainerPath: "/app/templates/base.html"},
+		PathMapping{LocalPath: AbsPath("test", "requirements.txt"), ContainerPath: "/app/requirements.txt"},
+		PathMapping{LocalPath: AbsPath("test", "main.py"), ContainerPath: "/app/main.py"},
+	}
+
+	actual, err := BoilRunsWithRestarts(runs, restartOnly, pathMappings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []model.Cmd{model.ToUnixCmd("echo always"), model.ToUnixCmd("pip install")}, actual.Cmds)
+	assert.True(t, actual.Restart)
+	assert.Equal(t, "4 files: 1 sync, 2 sync+restart, 1 triggered 'pip install'", actual.Summary)
+}
+
+func TestBoilRunsWithRestartsConflictRunsTrigger(t *testing.T) {
+	wd := AbsPath("test")
+	runs := []model.Run{
+		model.Run{Cmd: model.ToUnixCmd("echo always")},
+		model.Run{
+			Cmd:      model.ToUnixCmd("pip install"),
+			Triggers: model.NewPathSet([]string{"templates/requirements.txt"}, wd),
+		},
+	}
+	restartOnly := model.NewPathSet([]string{"templates"}, wd)
+
+	pathMappings := []PathMapping{
+		PathMapping{LocalPath: AbsPath("test", "templates", "requirements.txt"), ContainerPath: "/app/templates/requirements.txt"},
+	}
+
+	actual, err := BoilRunsWithRestarts(runs, restartOnly, pathMappings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The file matches both, so it gets the heavier action.
+	assert.Equal(t, []model.Cmd{model.ToUnixCmd("echo always"), model.ToUnixCmd("pip install")}, actual.Cmds)
+	assert.False(t, actual.Restart)
+	assert.Equal(t, "1 file: 1 triggered 'pip install'", actual.Summary)
+}
@@ -0,0 +1,73 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Tracks what an update is doing right now, so that an update
+// that times out can say where it got stuck.
+//
+// The phase is the pipeline step (e.g., "Building Dockerfile: [foo]"), and
+// the step is the finer-grained thing inside it that's running (e.g., a
+// Dockerfile step, or a custom_build command).
+type BuildPhase struct {
+	mu    sync.Mutex
+	phase string
+	step  string
+}
+
+type buildPhaseKey struct{}
+
+// Returns a context that records the update's progress into a new BuildPhase.
+func WithBuildPhase(ctx context.Context) (context.Context, *BuildPhase) {
+	p := &BuildPhase{}
+	return context.WithValue(ctx, buildPhaseKey{}, p), p
+}
+
+func buildPhaseFromContext(ctx context.Context) *BuildPhase {
+	p, _ := ctx.Value(buildPhaseKey{}).(*BuildPhase)
+	return p
+}
+
+// Marks the start of a new phase of the update, and clears the step.
+//
+// A no-op if the context isn't tracking a BuildPhase.
+func SetBuildPhase(ctx context.Context, format string, a ...interface{}) {
+	p := buildPhaseFromContext(ctx)
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.phase = fmt.Sprintf(format, a...)
+	p.step = ""
+}
+
+// Marks the step that's running inside the current phase.
+//
+// A no-op if the context isn't tracking a BuildPhase.
+func SetBuildPhaseStep(ctx context.Context, format string, a ...interface{}) {
+	p := buildPhaseFromContext(ctx)
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.step = fmt.Sprintf(format, a...)
+}
+
+// A human-readable description of what the update is doing,
+// or "" if it hasn't reported anything yet.
+func (p *BuildPhase) String() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.step == "" {
+		return p.phase
+	}
+	if p.phase == "" {
+		return p.step
+	}
+	return fmt.Sprintf("%s (%s)", p.step, p.phase)
+}
@@ -0,0 +1,164 @@
+package build
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// The classic builder prints each step as it starts, e.g.,
+//
+//	Step 7/12 : COPY go.sum .
+//	 ---> Using cache
+//	 ---> 3f4e2a1b9c0d
+//
+// A step that's not cached prints its own output instead of "Using cache".
+var classicStepRegexp = regexp.MustCompile(`^Step (\d+/\d+) : (.*)$`)
+
+const classicUsingCacheLine = "---> Using cache"
+
+// Printed when a step finishes, with the ID of the layer it produced.
+var classicStepDoneRegexp = regexp.MustCompile(`^---> [0-9a-f]+$`)
+
+// Reconstructs the cache breakdown from the classic builder's stream output.
+//
+// The classic builder doesn't tell us how long a step took, so we time each
+// step from when it starts until it prints the layer it produced.
+type classicStepParser struct {
+	steps   model.DockerBuildSteps
+	current int
+	start   time.Time
+
+	// Stream messages aren't guaranteed to end on a line boundary.
+	partial string
+}
+
+func newClassicStepParser() *classicStepParser {
+	return &classicStepParser{current: -1}
+}
+
+func (p *classicStepParser) write(msg string, now time.Time) {
+	text := p.partial + msg
+	lines := strings.Split(text, "\n")
+	p.partial = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		p.observe(line, now)
+	}
+}
+
+func (p *classicStepParser) observe(line string, now time.Time) {
+	line = strings.TrimSpace(line)
+	match := classicStepRegexp.FindStringSubmatch(line)
+	if len(match) == 3 {
+		p.finishStep(now)
+		p.steps = append(p.steps, model.DockerBuildStep{
+			Position: match[1],
+			Text:     model.DockerBuildStepText(match[2]),
+		})
+		p.current = len(p.steps) - 1
+		p.start = now
+		return
+	}
+
+	if p.current < 0 {
+		return
+	}
+
+	if line == classicUsingCacheLine {
+		p.steps[p.current].Cached = true
+	} else if classicStepDoneRegexp.MatchString(line) {
+		p.finishStep(now)
+	}
+}
+
+func (p *classicStepParser) finishStep(now time.Time) {
+	if p.current < 0 {
+		return
+	}
+	if !p.steps[p.current].Cached {
+		p.steps[p.current].Duration = now.Sub(p.start)
+	}
+	p.current = -1
+}
+
+// Returns the steps seen so far. A step that's still running
+// is timed up to now.
+func (p *classicStepParser) finish(now time.Time) model.DockerBuildSteps {
+	if p.partial != "" {
+		p.observe(p.partial, now)
+		p.partial = ""
+	}
+	p.finishStep(now)
+	return p.steps
+}
+
+// BuildKit names its Dockerfile steps like "[7/12] COPY go.sum ." or
+// "[builder 4/7] RUN go build", and its own housekeeping like
+// "[internal] load metadata" or "exporting to image".
+var buildkitStepRegexp = regexp.MustCompile(`^\[([^\]]*\d+/\d+)\] (.*)$`)
+
+// Reads the cache breakdown off the vertexes that BuildKit sent us,
+// in the order we first saw them.
+//
+// BuildKit runs independent steps in parallel, and reports the wall-clock
+// duration of each.
+func (b *buildkitPrinter) cacheSteps() model.DockerBuildSteps {
+	var result model.DockerBuildSteps
+	for _, d := range b.vOrder {
+		vl, ok := b.vData[d]
+		if !ok {
+			continue
+		}
+		v := vl.vertex
+		if v.isInternal() {
+			continue
+		}
+		match := buildkitStepRegexp.FindStringSubmatch(v.name)
+		if len(match) != 3 {
+			continue
+		}
+
+		step := model.DockerBuildStep{
+			Position: match[1],
+			Text:     model.DockerBuildStepText(match[2]),
+			Cached:   v.cached,
+		}
+		if !v.cached {
+			step.Duration = v.duration
+		}
+		result = append(result, step)
+	}
+	return result
+}
+
+// Returns the Dockerfile step that the classic builder is running,
+// like "[7/12] COPY go.sum .", or "" if it's between steps.
+func (p *classicStepParser) runningStep() string {
+	if p.current < 0 {
+		return ""
+	}
+	step := p.steps[p.current]
+	return fmt.Sprintf("[%s] %s", step.Position, step.Text)
+}
+
+// Returns the most recently started Dockerfile step that BuildKit
+// is still running, or "" if there isn't one.
+func (b *buildkitPrinter) runningStep() string {
+	for i := len(b.vOrder) - 1; i >= 0; i-- {
+		vl, ok := b.vData[b.vOrder[i]]
+		if !ok {
+			continue
+		}
+		v := vl.vertex
+		if !v.started || v.completed || v.isInternal() {
+			continue
+		}
+		if buildkitStepRegexp.MatchString(v.name) {
+			return v.name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,161 @@
+package build
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestClassicCacheSteps(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	at := func(sec int) time.Time { return start.Add(time.Duration(sec) * time.Second) }
+
+	p := newClassicStepParser()
+	p.write("Step 1/4 : FROM golang:1.16\n", at(0))
+	p.write(" ---> 0a1b2c3d4e5f\n", at(1))
+	p.write("Step 2/4 : COPY go.mod .\n", at(1))
+	p.write(" ---> Using cache\n", at(1))
+	p.write(" ---> 1a2b3c4d5e6f\n", at(1))
+	p.write("Step 3/4 : COPY go.sum", at(1))
+	p.write(" .\n", at(1))
+	p.write(" ---> 2a3b4c5d6e7f\n", at(3))
+	p.write("Step 4/4 : RUN go build ./...\n", at(3))
+	p.write(" ---> Running in 9f8e7d6c5b4a\n", at(4))
+	p.write("building...\n", at(10))
+	p.write("Removing intermediate container 9f8e7d6c5b4a\n", at(12))
+	p.write(" ---> 3a4b5c6d7e8f\n", at(12))
+	p.write("Successfully built 3a4b5c6d7e8f\n", at(12))
+
+	steps := p.finish(at(13))
+	assert.Equal(t, model.DockerBuildSteps{
+		{Position: "1/4", Text: "FROM golang:1.16", Duration: time.Second},
+		{Position: "2/4", Text: "COPY go.mod .", Cached: true},
+		{Position: "3/4", Text: "COPY go.sum .", Duration: 2 * time.Second},
+		{Position: "4/4", Text: "RUN go build ./...", Duration: 9 * time.Second},
+	}, steps)
+	assert.Equal(t, "cache busted at step 3/4: COPY go.sum . (1 of 3 steps cached)", steps.Summary())
+}
+
+func TestClassicCacheStepsInterrupted(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+
+	p := newClassicStepParser()
+	p.write("Step 1/2 : FROM alpine\n", start)
+	p.write(" ---> 0a1b2c3d4e5f\n", start)
+	p.write("Step 2/2 : RUN sleep 100\n", start)
+
+	steps := p.finish(start.Add(5 * time.Second))
+	assert.Equal(t, model.DockerBuildSteps{
+		{Position: "1/2", Text: "FROM alpine"},
+		{Position: "2/2", Text: "RUN sleep 100", Duration: 5 * time.Second},
+	}, steps)
+}
+
+func TestBuildkitCacheStepsCached(t *testing.T) {
+	steps := buildkitCacheStepsFromResponse(t, "sleep-cache.response.txt")
+	require.Len(t, steps, 2)
+	assert.Equal(t, "1/2", steps[0].Position)
+	assert.True(t, strings.HasPrefix(steps[0].Text, "FROM docker.io/library/busybox@sha256:"))
+	assert.Equal(t, model.DockerBuildStep{Position: "2/2", Text: "RUN sleep 5", Cached: true}, steps[1])
+	assert.Equal(t, "all steps cached", steps.Summary())
+}
+
+func TestBuildkitCacheStepsMultistage(t *testing.T) {
+	steps := buildkitCacheStepsFromResponse(t, "multistage-success.response.txt")
+
+	var positions []string
+	for _, s := range steps {
+		positions = append(positions, s.Position)
+	}
+	assert.Equal(t, []string{"stage-1 1/3", "builder 2/2", "stage-1 2/3", "stage-1 3/3"}, positions)
+
+	// Each step reports its own wall-clock duration.
+	assert.Equal(t, "1.203s", steps[1].Duration.Truncate(time.Millisecond).String())
+	assert.Equal(t, "1.274s", steps[2].Duration.Truncate(time.Millisecond).String())
+	assert.Equal(t, "1.024s", steps[3].Duration.Truncate(time.Millisecond).String())
+	assert.Equal(t, "cache busted at step builder 2/2: RUN echo hi > hi.txt (0 of 3 steps cached)", steps.Summary())
+}
+
+func TestReadDockerOutputClassicCacheSteps(t *testing.T) {
+	f := newFakeDockerBuildFixture(t)
+	defer f.teardown()
+
+	output := `{"stream":"Step 1/2 : FROM alpine\n"}
+{"stream":" ---> 0a1b2c3d4e5f\n"}
+{"stream":"Step 2/2 : COPY . /src\n"}
+{"stream":" ---> Using cache\n"}
+{"stream":" ---> 1a2b3c4d5e6f\n"}
+{"aux":{"ID":"sha256:11cd0b38bc3ceb958ffb2f9bd70be3fb317ce7d255c8a4c3f4af30e298aa1aab"}}
+{"stream":"Successfully built 1a2b3c4d5e6f\n"}
+`
+	_, steps, err := f.b.getDigestFromBuildOutput(f.ctx, strings.NewReader(output))
+	require.NoError(t, err)
+	require.Len(t, steps, 2)
+	assert.Equal(t, model.DockerBuildStep{Position: "2/2", Text: "COPY . /src", Cached: true}, steps[1])
+	assert.Equal(t, "all steps cached", steps.Summary())
+}
+
+func TestClassicRunningStep(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+
+	p := newClassicStepParser()
+	assert.Equal(t, "", p.runningStep())
+	p.write("Step 1/2 : FROM alpine\n", start)
+	assert.Equal(t, "[1/2] FROM alpine", p.runningStep())
+	p.write(" ---> 0a1b2c3d4e5f\n", start)
+	assert.Equal(t, "", p.runningStep())
+	p.write("Step 2/2 : RUN sleep 100\n", start)
+	assert.Equal(t, "[2/2] RUN sleep 100", p.runningStep())
+}
+
+func TestBuildkitRunningStep(t *testing.T) {
+	p := newBuildkitPrinter(logger.NewLogger(logger.InfoLvl, ioutil.Discard))
+	err := p.parseAndPrint([]*vertex{
+		{digest: "sha256:a", name: "[internal] load build definition from Dockerfile", started: true},
+		{digest: "sha256:b", name: "[1/3] FROM docker.io/library/alpine", started: true, completed: true},
+		{digest: "sha256:c", name: "[2/3] RUN go build ./...", started: true},
+		{digest: "sha256:d", name: "[3/3] COPY . /src"},
+	}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "[2/3] RUN go build ./...", p.runningStep())
+}
+
+func TestReadDockerOutputRecordsRunningStep(t *testing.T) {
+	f := newFakeDockerBuildFixture(t)
+	defer f.teardown()
+
+	output := `{"stream":"Step 1/2 : FROM alpine\n"}
+{"stream":" ---> 0a1b2c3d4e5f\n"}
+{"stream":"Step 2/2 : RUN go build ./...\n"}
+{"stream":" ---> Running in 9f8e7d6c5b4a\n"}
+`
+	ctx, phase := WithBuildPhase(f.ctx)
+	SetBuildPhase(ctx, "Building Dockerfile: [gcr.io/foo/bar]")
+	_, err := readDockerOutput(ctx, strings.NewReader(output))
+	require.NoError(t, err)
+	assert.Equal(t, "Dockerfile step [2/2] RUN go build ./... (Building Dockerfile: [gcr.io/foo/bar])",
+		phase.String())
+}
+
+func buildkitCacheStepsFromResponse(t *testing.T, name string) model.DockerBuildSteps {
+	f, err := os.Open("testdata/TestBuildkitPrinter/" + name)
+	require.NoError(t, err)
+	defer func() { _ = f.Close() }()
+
+	responses, err := buildkitTestCase{}.readResponse(f)
+	require.NoError(t, err)
+
+	p := newBuildkitPrinter(logger.NewLogger(logger.InfoLvl, ioutil.Discard))
+	for _, resp := range responses {
+		require.NoError(t, p.parseAndPrint(toVertexes(resp)))
+	}
+	return p.cacheSteps()
+}
@@ -35,7 +35,7 @@ ADD dir/c.txt .
 	f.WriteFile("dir/c.txt", "c")
 	f.WriteFile("missing.txt", "missing")
 
-	refs, err := f.b.BuildImage(f.ctx, f.ps, f.getNameFromTest(), model.DockerBuild{
+	refs, _, err := f.b.BuildImage(f.ctx, f.ps, f.getNameFromTest(), model.DockerBuild{
 		Dockerfile: df.String(),
 		BuildPath:  f.Path(),
 	}, model.EmptyMatcher)
@@ -69,7 +69,7 @@ ADD $some_variable_name /test.txt`)
 	ba := model.DockerBuildArgs{
 		"some_variable_name": "awesome_variable",
 	}
-	refs, err := f.b.BuildImage(f.ctx, f.ps, f.getNameFromTest(), model.DockerBuild{
+	refs, _, err := f.b.BuildImage(f.ctx, f.ps, f.getNameFromTest(), model.DockerBuild{
 		Dockerfile: df.String(),
 		BuildPath:  f.Path(),
 		BuildArgs:  ba,
@@ -95,7 +95,7 @@ ADD a.txt .`)
 
 	f.WriteFile("a.txt", "a")
 
-	refs, err := f.b.BuildImage(f.ctx, f.ps, f.getNameFromTest(), model.DockerBuild{
+	refs, _, err := f.b.BuildImage(f.ctx, f.ps, f.getNameFromTest(), model.DockerBuild{
 		Dockerfile: df.String(),
 		BuildPath:  f.Path(),
 		ExtraTags:  []string{"fe:jenkins-1234"},
@@ -119,7 +119,7 @@ func TestDetectBuildkitCorruption(t *testing.T) {
 	out := bytes.NewBuffer(nil)
 	ctx := logger.WithLogger(context.Background(), logger.NewTestLogger(out))
 	ps := NewPipelineState(ctx, 1, ProvideClock())
-	_, err := f.b.BuildImage(ctx, ps, f.getNameFromTest(), model.DockerBuild{
+	_, _, err := f.b.BuildImage(ctx, ps, f.getNameFromTest(), model.DockerBuild{
 		// Simulate buildkit corruption
 		Dockerfile: `FROM alpine
 RUN echo 'failed to create LLB definition: failed commit on ref "unknown-sha256:b72fa303a3a5fbf52c723bfcfb93948bb53b3d7e8d22418e9d171a27ad7dcd84": "unknown-sha256:b72fa303a3a5fbf52c723bfcfb93948bb53b3d7e8d22418e9d171a27ad7dcd84" failed size validation: 80941 != 80929: failed precondition' && exit 1
@@ -0,0 +1,161 @@
+package build
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/tilt-dev/tilt/pkg/logger"
+)
+
+// A client for containerd's image store.
+//
+// Custom builds that use nerdctl or buildctl put their images in containerd
+// rather than in Docker, so we can't find them with the Docker client.
+type ContainerdClient interface {
+	// Returns the ID of the image with the given ref.
+	//
+	// Returns an error that satisfies IsContainerdImageNotFound if the
+	// image isn't in the store.
+	ImageID(ctx context.Context, ref string) (digest.Digest, error)
+
+	ImageTag(ctx context.Context, source, target string) error
+	ImagePush(ctx context.Context, ref reference.NamedTagged) error
+
+	// Writes the image to a tarball at the given path.
+	ImageSave(ctx context.Context, ref reference.NamedTagged, path string) error
+}
+
+type containerdImageNotFoundError struct {
+	ref string
+}
+
+func (e containerdImageNotFoundError) Error() string {
+	return fmt.Sprintf("image %q not found in containerd", e.ref)
+}
+
+func IsContainerdImageNotFound(err error) bool {
+	_, ok := err.(containerdImageNotFoundError)
+	return ok
+}
+
+// Talks to containerd by shelling out to nerdctl.
+//
+// nerdctl reads CONTAINERD_ADDRESS and CONTAINERD_NAMESPACE from the
+// environment, so users with a non-default socket or namespace
+// (e.g., k8s.io) can point Tilt at it the same way they point nerdctl at it.
+type NerdctlClient struct{}
+
+var _ ContainerdClient = NerdctlClient{}
+
+func NewNerdctlClient() NerdctlClient {
+	return NerdctlClient{}
+}
+
+func (c NerdctlClient) ImageID(ctx context.Context, ref string) (digest.Digest, error) {
+	out, err := c.output(ctx, "image", "inspect", "--format", "{{.ID}}", ref)
+	if err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "no such") {
+			return "", containerdImageNotFoundError{ref: ref}
+		}
+		return "", err
+	}
+
+	id := strings.TrimSpace(out)
+	if id == "" {
+		return "", containerdImageNotFoundError{ref: ref}
+	}
+	return digest.Parse(id)
+}
+
+func (c NerdctlClient) ImageTag(ctx context.Context, source, target string) error {
+	_, err := c.output(ctx, "tag", source, target)
+	return err
+}
+
+func (c NerdctlClient) ImagePush(ctx context.Context, ref reference.NamedTagged) error {
+	return c.run(ctx, "push", ref.String())
+}
+
+func (c NerdctlClient) ImageSave(ctx context.Context, ref reference.NamedTagged, path string) error {
+	_, err := c.output(ctx, "save", "-o", path, ref.String())
+	return err
+}
+
+// Runs nerdctl, streaming its output to the logs.
+func (c NerdctlClient) run(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "nerdctl", args...)
+	w := logger.NewMutexWriter(logger.Get(ctx).Writer(logger.InfoLvl))
+	cmd.Stdout = w
+	cmd.Stderr = w
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("nerdctl %s: %v", strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// Runs nerdctl and returns its stdout.
+func (c NerdctlClient) output(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "nerdctl", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("nerdctl %s: %s", strings.Join(args, " "), msg)
+	}
+	return stdout.String(), nil
+}
+
+type FakeContainerdClient struct {
+	// Image IDs, keyed by ref.
+	Images map[string]digest.Digest
+
+	// Tags created with ImageTag, mapping the target to the source.
+	Tags map[string]string
+
+	PushedRefs []reference.NamedTagged
+	SavedRefs  []reference.NamedTagged
+}
+
+var _ ContainerdClient = &FakeContainerdClient{}
+
+func NewFakeContainerdClient() *FakeContainerdClient {
+	return &FakeContainerdClient{
+		Images: make(map[string]digest.Digest),
+		Tags:   make(map[string]string),
+	}
+}
+
+func (c *FakeContainerdClient) ImageID(ctx context.Context, ref string) (digest.Digest, error) {
+	id, ok := c.Images[ref]
+	if !ok {
+		return "", containerdImageNotFoundError{ref: ref}
+	}
+	return id, nil
+}
+
+func (c *FakeContainerdClient) ImageTag(ctx context.Context, source, target string) error {
+	c.Tags[target] = source
+	return nil
+}
+
+func (c *FakeContainerdClient) ImagePush(ctx context.Context, ref reference.NamedTagged) error {
+	c.PushedRefs = append(c.PushedRefs, ref)
+	return nil
+}
+
+func (c *FakeContainerdClient) ImageSave(ctx context.Context, ref reference.NamedTagged, path string) error {
+	c.SavedRefs = append(c.SavedRefs, ref)
+	return nil
+}
@@ -0,0 +1,158 @@
+package build
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// Content tags are computed from what goes into a build, rather than from
+// the image that comes out of it. So we know the tag before we build, and
+// if an image with that tag already exists, we can skip the build.
+//
+// The tag covers the Dockerfile, the build args it uses, and the files in
+// the build context (after dockerignores). For custom builds, it covers the
+// command, its environment, and its deps. It doesn't cover anything the build
+// pulls from the network, like a base image that moved to a new version.
+func ContentTag(ctx context.Context, bd model.BuildDetails, filter model.PathMatcher) (string, error) {
+	h := newContentHasher()
+	switch bd := bd.(type) {
+	case model.DockerBuild:
+		h.field("dockerfile", bd.Dockerfile)
+		buildArgs := bd.EffectiveBuildArgs()
+		for _, k := range sortedKeys(buildArgs) {
+			h.field("build-arg", k, buildArgs[k])
+		}
+		h.field("target", string(bd.TargetStage))
+		h.field("platform", bd.Platform)
+		h.field("network", bd.Network)
+		h.field("ssh", bd.SSHSpecs...)
+		h.field("secret", bd.SecretSpecs...)
+
+		err := h.paths(ctx, filter, []PathMapping{{LocalPath: bd.BuildPath, ContainerPath: "/"}})
+		if err != nil {
+			return "", errors.Wrap(err, "ContentTag")
+		}
+	case model.CustomBuild:
+		h.field("command", bd.Command.Argv...)
+		h.field("env", bd.Env.Vars...)
+		h.field("platform", bd.Platform)
+
+		paths := make([]PathMapping, 0, len(bd.Deps))
+		for _, dep := range bd.Deps {
+			rel, err := filepath.Rel(bd.WorkDir, dep)
+			if err != nil {
+				rel = dep
+			}
+			paths = append(paths, PathMapping{LocalPath: dep, ContainerPath: filepath.ToSlash(rel)})
+		}
+		err := h.paths(ctx, filter, paths)
+		if err != nil {
+			return "", errors.Wrap(err, "ContentTag")
+		}
+	default:
+		return "", fmt.Errorf("ContentTag: unsupported build details %T", bd)
+	}
+
+	return fmt.Sprintf("%scontent-%s", ImageTagPrefix, h.sum()[:16]), nil
+}
+
+type contentHasher struct {
+	h hash.Hash
+}
+
+func newContentHasher() contentHasher {
+	return contentHasher{h: sha256.New()}
+}
+
+// Writes a field with a length prefix on each value, so that
+// different fields can never hash the same.
+func (c contentHasher) field(name string, values ...string) {
+	_, _ = fmt.Fprintf(c.h, "%s %d\n", name, len(values))
+	for _, v := range values {
+		_, _ = fmt.Fprintf(c.h, "%d:%s\n", len(v), v)
+	}
+}
+
+// Hashes the files the same way we'd tar them up for the build, but
+// without the timestamps, so that touching a file doesn't change the tag.
+func (c contentHasher) paths(ctx context.Context, filter model.PathMatcher, paths []PathMapping) error {
+	if filter == nil {
+		filter = model.EmptyMatcher
+	}
+	ab := &ArchiveBuilder{filter: filter}
+	entries := []archiveEntry{}
+	for _, p := range paths {
+		newEntries, err := ab.entriesForPath(ctx, p.LocalPath, p.ContainerPath)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, newEntries...)
+	}
+
+	entries = dedupeEntries(entries)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].header.Name < entries[j].header.Name
+	})
+
+	for _, entry := range entries {
+		header := entry.header
+		c.field("entry", header.Name, string(header.Typeflag),
+			fmt.Sprintf("%o", header.Mode), header.Linkname)
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		err := c.file(entry.path)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c contentHasher) file(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		// In case the file has been deleted since we last looked at it.
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "%s: open", path)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	fileHash := sha256.New()
+	_, err = io.Copy(fileHash, f)
+	if err != nil {
+		return errors.Wrapf(err, "%s: read", path)
+	}
+	c.field("contents", hex.EncodeToString(fileHash.Sum(nil)))
+	return nil
+}
+
+func (c contentHasher) sum() string {
+	return hex.EncodeToString(c.h.Sum(nil))
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
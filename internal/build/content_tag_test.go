@@ -0,0 +1,142 @@
+package build
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/internal/dockerignore"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestContentTagStable(t *testing.T) {
+	f := newFixture(t)
+	defer f.tearDown()
+
+	f.WriteFile("a.txt", "a")
+	f.WriteFile("src/b.txt", "b")
+	db := model.DockerBuild{
+		Dockerfile: "FROM alpine\nCOPY . /\n",
+		BuildPath:  f.Path(),
+		BuildArgs:  model.DockerBuildArgs{"x": "1", "y": "2"},
+	}
+
+	tag := f.contentTag(db, nil)
+	assert.True(t, strings.HasPrefix(tag, "tilt-content-"), tag)
+	assert.Len(t, tag, len("tilt-content-")+16)
+
+	// Touching a file doesn't change its contents.
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(f.JoinPath("a.txt"), later, later))
+	assert.Equal(t, tag, f.contentTag(db, nil))
+}
+
+func TestContentTagChangesWithContextFile(t *testing.T) {
+	f := newFixture(t)
+	defer f.tearDown()
+
+	f.WriteFile("a.txt", "a")
+	db := model.DockerBuild{Dockerfile: "FROM alpine\n", BuildPath: f.Path()}
+	tag := f.contentTag(db, nil)
+
+	f.WriteFile("a.txt", "changed")
+	changed := f.contentTag(db, nil)
+	assert.NotEqual(t, tag, changed)
+
+	f.WriteFile("new.txt", "new")
+	assert.NotEqual(t, changed, f.contentTag(db, nil))
+}
+
+func TestContentTagChangesWithBuildInputs(t *testing.T) {
+	f := newFixture(t)
+	defer f.tearDown()
+
+	f.WriteFile("a.txt", "a")
+	db := model.DockerBuild{Dockerfile: "FROM alpine\n", BuildPath: f.Path()}
+	tag := f.contentTag(db, nil)
+
+	withDockerfile := db
+	withDockerfile.Dockerfile = "FROM busybox\n"
+	assert.NotEqual(t, tag, f.contentTag(withDockerfile, nil))
+
+	withArgs := db
+	withArgs.BuildArgs = model.DockerBuildArgs{"x": "1"}
+	assert.NotEqual(t, tag, f.contentTag(withArgs, nil))
+
+	withPlatform := db
+	withPlatform.Platform = "linux/arm64"
+	assert.NotEqual(t, tag, f.contentTag(withPlatform, nil))
+}
+
+func TestContentTagIgnoresUnusedBuildArgs(t *testing.T) {
+	f := newFixture(t)
+	defer f.tearDown()
+
+	f.WriteFile("a.txt", "a")
+	db := model.DockerBuild{
+		Dockerfile:        "ARG VERSION\nFROM alpine:${VERSION}\n",
+		BuildPath:         f.Path(),
+		BuildArgs:         model.DockerBuildArgs{"VERSION": "3.14", "UNUSED": "1"},
+		ResolvedBuildArgs: model.DockerBuildArgs{"VERSION": "3.14"},
+	}
+	tag := f.contentTag(db, nil)
+
+	withUnused := db
+	withUnused.BuildArgs = model.DockerBuildArgs{"VERSION": "3.14", "UNUSED": "2"}
+	assert.Equal(t, tag, f.contentTag(withUnused, nil))
+
+	withVersion := db
+	withVersion.BuildArgs = model.DockerBuildArgs{"VERSION": "3.15", "UNUSED": "1"}
+	withVersion.ResolvedBuildArgs = model.DockerBuildArgs{"VERSION": "3.15"}
+	assert.NotEqual(t, tag, f.contentTag(withVersion, nil))
+}
+
+func TestContentTagIgnoresDockerignoredFiles(t *testing.T) {
+	f := newFixture(t)
+	defer f.tearDown()
+
+	filter, err := dockerignore.NewDockerPatternMatcher(f.Path(), []string{"tmp"})
+	require.NoError(t, err)
+
+	f.WriteFile("a.txt", "a")
+	f.WriteFile("tmp/scratch.txt", "scratch")
+	db := model.DockerBuild{Dockerfile: "FROM alpine\n", BuildPath: f.Path()}
+	tag := f.contentTag(db, filter)
+
+	f.WriteFile("tmp/scratch.txt", "more scratch")
+	assert.Equal(t, tag, f.contentTag(db, filter))
+}
+
+func TestContentTagCustomBuildDeps(t *testing.T) {
+	f := newFixture(t)
+	defer f.tearDown()
+
+	f.WriteFile("src/main.go", "package main")
+	f.WriteFile("other.txt", "other")
+	cb := model.CustomBuild{
+		WorkDir: f.Path(),
+		Command: model.ToHostCmd("./build.sh"),
+		Deps:    []string{f.JoinPath("src")},
+	}
+	tag := f.contentTag(cb, nil)
+
+	// Files outside the deps don't matter.
+	f.WriteFile("other.txt", "changed")
+	assert.Equal(t, tag, f.contentTag(cb, nil))
+
+	f.WriteFile("src/main.go", "package main\n\nfunc main() {}")
+	assert.NotEqual(t, tag, f.contentTag(cb, nil))
+
+	cb.Command = model.ToHostCmd("./build.sh --release")
+	assert.NotEqual(t, tag, f.contentTag(cb, nil))
+}
+
+func (f *fixture) contentTag(bd model.BuildDetails, filter model.PathMatcher) string {
+	tag, err := ContentTag(f.ctx, bd, filter)
+	require.NoError(f.t, err)
+	return tag
+}
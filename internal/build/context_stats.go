@@ -0,0 +1,49 @@
+package build
+
+import (
+	"io"
+	"sort"
+)
+
+// Sizes of a build context, measured while we tarred it up.
+type ContextStats struct {
+	// Bytes of tar sent to the image builder.
+	Size int64
+
+	// Bytes of file contents under each top-level file or directory of the context.
+	EntrySizes map[string]int64
+}
+
+type ContextEntrySize struct {
+	Name string
+	Size int64
+}
+
+// Returns the n biggest top-level files and directories, biggest first.
+func (s ContextStats) Largest(n int) []ContextEntrySize {
+	result := make([]ContextEntrySize, 0, len(s.EntrySizes))
+	for name, size := range s.EntrySizes {
+		result = append(result, ContextEntrySize{Name: name, Size: size})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Size != result[j].Size {
+			return result[i].Size > result[j].Size
+		}
+		return result[i].Name < result[j].Name
+	})
+	if len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
@@ -6,7 +6,9 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"runtime"
 	"strings"
+	"syscall"
 
 	"github.com/opencontainers/go-digest"
 	"github.com/pkg/errors"
@@ -15,6 +17,7 @@ import (
 	"github.com/tilt-dev/tilt/internal/docker"
 	"github.com/tilt-dev/tilt/pkg/logger"
 	"github.com/tilt-dev/tilt/pkg/model"
+	"github.com/tilt-dev/tilt/pkg/procutil"
 )
 
 type CustomBuilder interface {
@@ -23,12 +26,14 @@ type CustomBuilder interface {
 
 type ExecCustomBuilder struct {
 	dCli  docker.Client
+	ctrd  ContainerdClient
 	clock Clock
 }
 
-func NewExecCustomBuilder(dCli docker.Client, clock Clock) *ExecCustomBuilder {
+func NewExecCustomBuilder(dCli docker.Client, ctrd ContainerdClient, clock Clock) *ExecCustomBuilder {
 	return &ExecCustomBuilder{
 		dCli:  dCli,
+		ctrd:  ctrd,
 		clock: clock,
 	}
 }
@@ -45,8 +50,15 @@ func (b *ExecCustomBuilder) Build(ctx context.Context, refs container.RefSet, cb
 	var registryHost string
 	var err error
 
-	// There are 3 modes for determining the output tag.
-	if outputsImageRefTo != "" {
+	// There are 4 modes for determining the output tag.
+	if cb.ContentTag {
+		// In content_tag mode, Tilt computed the tag from the build inputs, so the
+		// user's script gets the full ref, and we keep the tag as-is after the build.
+		expectedBuildRefs, err = refs.AddTagSuffix(expectedTag)
+		if err != nil {
+			return container.TaggedRefs{}, errors.Wrap(err, "CustomBuilder.Build")
+		}
+	} else if outputsImageRefTo != "" {
 		// In outputs_image_ref_to mode, the user script MUST print the tag to a file,
 		// which we recover later. So no need to set expectedBuildRefs.
 
@@ -74,8 +86,10 @@ func (b *ExecCustomBuilder) Build(ctx context.Context, refs container.RefSet, cb
 
 	expectedBuildResult := expectedBuildRefs.LocalRef
 
-	cmd := exec.CommandContext(ctx, command.Argv[0], command.Argv[1:]...)
+	cmd := exec.Command(command.Argv[0], command.Argv[1:]...)
 	cmd.Dir = workDir
+	cmd.SysProcAttr = &syscall.SysProcAttr{}
+	procutil.SetOptNewProcessGroup(cmd.SysProcAttr)
 	cmd.Env = logger.DefaultEnv(ctx)
 
 	l := logger.Get(ctx)
@@ -89,26 +103,47 @@ func (b *ExecCustomBuilder) Build(ctx context.Context, refs container.RefSet, cb
 		extraEnvVars = append(extraEnvVars,
 			fmt.Sprintf("REGISTRY_HOST=%s", registryHost))
 	}
+	if cb.Platform != "" {
+		extraEnvVars = append(extraEnvVars,
+			fmt.Sprintf("EXPECTED_PLATFORM=%s", cb.Platform))
+	}
 
 	extraEnvVars = append(extraEnvVars, b.dCli.Env().AsEnviron()...)
 
-	if len(extraEnvVars) == 0 {
+	policy := cb.Env
+	injectedEnvVars := append(append([]string{}, policy.Vars...), extraEnvVars...)
+	if len(injectedEnvVars) == 0 {
 		l.Infof("Custom Build:")
 	} else {
 		l.Infof("Custom Build: Injecting Environment Variables")
-		for _, v := range extraEnvVars {
+		for _, v := range redactEnv(injectedEnvVars, policy.Secrets) {
+			l.Infof("  %s", v)
+		}
+	}
+	if len(policy.Deny) > 0 && !policy.Isolated {
+		l.Infof("Custom Build: Removing Environment Variables: %s", strings.Join(policy.Deny, ", "))
+	}
+
+	cmd.Env = customBuildEnviron(os.Environ(), policy, extraEnvVars)
+	if policy.Isolated {
+		// The isolated environment is small, so record all of it to help debug the build.
+		l.Infof("Custom Build: Isolated Environment")
+		for _, v := range redactEnv(cmd.Env, policy.Secrets) {
 			l.Infof("  %s", v)
 		}
 	}
-	cmd.Env = append(os.Environ(), extraEnvVars...)
 
 	w := l.Writer(logger.InfoLvl)
 	cmd.Stdout = w
 	cmd.Stderr = w
 
 	l.Infof("Running custom build cmd %q", command)
-	err = cmd.Run()
+	SetBuildPhaseStep(ctx, "custom_build command %q", command)
+	err = runProcessGroup(ctx, cmd)
 	if err != nil {
+		if ctx.Err() != nil {
+			return container.TaggedRefs{}, ctx.Err()
+		}
 		return container.TaggedRefs{}, errors.Wrap(err, "Custom build command failed")
 	}
 
@@ -126,21 +161,22 @@ func (b *ExecCustomBuilder) Build(ctx context.Context, refs container.RefSet, cb
 		return expectedBuildRefs, nil
 	}
 
-	inspect, _, err := b.dCli.ImageInspectWithRaw(ctx, expectedBuildResult.String())
+	var dig digest.Digest
+	if cb.UsesContainerd() {
+		dig, err = b.containerdImageID(ctx, expectedBuildResult.String())
+	} else {
+		dig, err = b.dockerImageID(ctx, expectedBuildResult.String())
+	}
 	if err != nil {
-		return container.TaggedRefs{}, errors.Wrap(err, "Could not find image in Docker\n"+
-			"Did your custom_build script properly tag the image?\n"+
-			"If your custom_build doesn't use Docker, you might need to use skips_local_docker=True, "+
-			"see https://docs.tilt.dev/custom_build.html\n")
+		return container.TaggedRefs{}, err
 	}
 
-	if outputsImageRefTo != "" {
+	if outputsImageRefTo != "" || cb.ContentTag {
 		// If we're using a custom_build-determined build ref, we don't use content-based tags.
+		// If the ref is already content-addressed, we don't need to.
 		return expectedBuildRefs, nil
 	}
 
-	dig := digest.Digest(inspect.ID)
-
 	tag, err := digestAsTag(dig)
 	if err != nil {
 		return container.TaggedRefs{}, errors.Wrap(err, "CustomBuilder.Build")
@@ -151,8 +187,12 @@ func (b *ExecCustomBuilder) Build(ctx context.Context, refs container.RefSet, cb
 		return container.TaggedRefs{}, errors.Wrap(err, "CustomBuilder.Build")
 	}
 
-	// Docker client only needs to care about the localImage
-	err = b.dCli.ImageTag(ctx, dig.String(), taggedWithDigest.LocalRef.String())
+	// The image store only needs to care about the localImage
+	if cb.UsesContainerd() {
+		err = b.ctrd.ImageTag(ctx, expectedBuildResult.String(), taggedWithDigest.LocalRef.String())
+	} else {
+		err = b.dCli.ImageTag(ctx, dig.String(), taggedWithDigest.LocalRef.String())
+	}
 	if err != nil {
 		return container.TaggedRefs{}, errors.Wrap(err, "CustomBuilder.Build")
 	}
@@ -160,6 +200,122 @@ func (b *ExecCustomBuilder) Build(ctx context.Context, refs container.RefSet, cb
 	return taggedWithDigest, nil
 }
 
+// Runs the command, and kills its whole process group if the context is
+// canceled, so that a build script can't leave a wedged child process
+// (like a compiler waiting on a prompt) running after the build is over.
+func runProcessGroup(ctx context.Context, cmd *exec.Cmd) error {
+	err := cmd.Start()
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			procutil.KillProcessGroup(cmd)
+		case <-done:
+		}
+	}()
+
+	return cmd.Wait()
+}
+
+func (b *ExecCustomBuilder) dockerImageID(ctx context.Context, ref string) (digest.Digest, error) {
+	inspect, _, err := b.dCli.ImageInspectWithRaw(ctx, ref)
+	if err != nil {
+		// Check if the user forgot to tell us that the image is in containerd.
+		if _, ctrdErr := b.ctrd.ImageID(ctx, ref); ctrdErr == nil {
+			return "", fmt.Errorf("Could not find image %s in Docker, but found it in containerd\n"+
+				"If your custom_build uses nerdctl or buildctl, you might need to use image_store='containerd', "+
+				"see https://docs.tilt.dev/custom_build.html\n", ref)
+		}
+		return "", errors.Wrap(err, "Could not find image in Docker\n"+
+			"Did your custom_build script properly tag the image?\n"+
+			"If your custom_build doesn't use Docker, you might need to use skips_local_docker=True, "+
+			"see https://docs.tilt.dev/custom_build.html\n")
+	}
+	return digest.Digest(inspect.ID), nil
+}
+
+func (b *ExecCustomBuilder) containerdImageID(ctx context.Context, ref string) (digest.Digest, error) {
+	dig, err := b.ctrd.ImageID(ctx, ref)
+	if err == nil {
+		return dig, nil
+	}
+	if !IsContainerdImageNotFound(err) {
+		return "", errors.Wrap(err, "Could not inspect image in containerd")
+	}
+
+	// Check if the image landed in Docker instead.
+	if _, _, dockerErr := b.dCli.ImageInspectWithRaw(ctx, ref); dockerErr == nil {
+		return "", fmt.Errorf("Could not find image %s in containerd, but found it in Docker\n"+
+			"If your custom_build uses Docker, remove image_store='containerd'\n", ref)
+	}
+	return "", fmt.Errorf("Could not find image %s in containerd\n"+
+		"Did your custom_build script properly tag the image?\n"+
+		"If your script uses a containerd namespace other than the default (e.g., k8s.io), "+
+		"set CONTAINERD_NAMESPACE when you run Tilt\n", ref)
+}
+
+// Constructs the environment for a custom build command from Tilt's
+// environment, the Tiltfile's env policy, and the variables Tilt provides.
+//
+// Later entries take precedence, so Tilt-provided variables win over
+// Tiltfile-declared variables, which win over inherited variables.
+func customBuildEnviron(environ []string, policy model.CustomBuildEnv, tiltVars []string) []string {
+	result := []string{}
+	if policy.Isolated {
+		allowed := append([]string{"PATH"}, policy.Allow...)
+		if runtime.GOOS == "windows" {
+			// Most Windows programs can't start without SYSTEMROOT.
+			allowed = append(allowed, "SYSTEMROOT")
+		}
+		for _, e := range environ {
+			if envKeyIn(e, allowed) {
+				result = append(result, e)
+			}
+		}
+	} else {
+		for _, e := range environ {
+			if !envKeyIn(e, policy.Deny) {
+				result = append(result, e)
+			}
+		}
+	}
+
+	result = append(result, policy.Vars...)
+	result = append(result, tiltVars...)
+	return result
+}
+
+func envKeyIn(entry string, keys []string) bool {
+	key := strings.SplitN(entry, "=", 2)[0]
+	for _, k := range keys {
+		// Environment variable names are case-insensitive on Windows.
+		if key == k || (runtime.GOOS == "windows" && strings.EqualFold(key, k)) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactEnv(env []string, secrets []string) []string {
+	if len(secrets) == 0 {
+		return env
+	}
+	result := make([]string, 0, len(env))
+	for _, e := range env {
+		if envKeyIn(e, secrets) {
+			key := strings.SplitN(e, "=", 2)[0]
+			e = fmt.Sprintf("%s=[redacted]", key)
+		}
+		result = append(result, e)
+	}
+	return result
+}
+
 func (b *ExecCustomBuilder) readImageRef(ctx context.Context, outputsImageRefTo string) (container.TaggedRefs, error) {
 	contents, err := ioutil.ReadFile(outputsImageRefTo)
 	if err != nil {
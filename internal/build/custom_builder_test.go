@@ -1,8 +1,10 @@
 package build
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io/ioutil"
 	"path/filepath"
 	"runtime"
 	"testing"
@@ -98,6 +100,36 @@ func TestCustomBuildCmdFails(t *testing.T) {
 	assert.EqualError(t, err, "Custom build command failed: exit status 1")
 }
 
+func TestCustomBuildTimeoutKillsProcessGroup(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX shell")
+	}
+	f := newFakeCustomBuildFixture(t)
+	defer f.teardown()
+
+	// The script starts a child that keeps ticking, then waits on it forever.
+	script := "(while true; do echo tick >> ticks.txt; sleep 0.05; done) & wait"
+	cb := model.CustomBuild{WorkDir: f.tdf.Path(), Command: model.ToHostCmd(script)}
+
+	ctx, phase := WithBuildPhase(f.ctx)
+	ctx, cancel := context.WithTimeout(ctx, 300*time.Millisecond)
+	defer cancel()
+
+	_, err := f.cb.Build(ctx, refSetFromString("gcr.io/foo/bar"), cb)
+	require.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, fmt.Sprintf("custom_build command %q", script), phase.String())
+
+	// Make sure the child died along with the script.
+	ticks := func() int {
+		contents, err := ioutil.ReadFile(f.tdf.JoinPath("ticks.txt"))
+		require.NoError(t, err)
+		return len(contents)
+	}
+	before := ticks()
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, before, ticks())
+}
+
 func TestCustomBuildImgNotFound(t *testing.T) {
 	f := newFakeCustomBuildFixture(t)
 	defer f.teardown()
@@ -121,6 +153,24 @@ func TestCustomBuildExpectedTag(t *testing.T) {
 	assert.Equal(f.t, container.MustParseNamed("gcr.io/foo/bar:tilt-11cd0eb38bc3ceb9"), refs.ClusterRef)
 }
 
+func TestCustomBuildContentTag(t *testing.T) {
+	f := newFakeCustomBuildFixture(t)
+	defer f.teardown()
+
+	sha := digest.Digest("sha256:11cd0eb38bc3ceb958ffb2f9bd70be3fb317ce7d255c8a4c3f4af30e298aa1aab")
+	f.dCli.Images["localhost:1234/foo_bar:tilt-content-1234"] = types.ImageInspect{ID: string(sha)}
+	cb := model.CustomBuild{WorkDir: f.tdf.Path(), Command: model.ToHostCmd("exit 0"),
+		Tag: "tilt-content-1234", ContentTag: true}
+	refs, err := f.cb.Build(f.ctx, refSetWithRegistryFromString("foo/bar", TwoURLRegistry), cb)
+	require.NoError(t, err)
+
+	// The script gets the registry ref, and the image keeps its content tag.
+	assert.Contains(t, f.out.String(), "EXPECTED_REF=localhost:1234/foo_bar:tilt-content-1234")
+	assert.Equal(f.t, container.MustParseNamed("localhost:1234/foo_bar:tilt-content-1234"), refs.LocalRef)
+	assert.Equal(f.t, container.MustParseNamed("registry:1234/foo_bar:tilt-content-1234"), refs.ClusterRef)
+	assert.Equal(f.t, 0, f.dCli.TagCount)
+}
+
 func TestCustomBuilderExecsRelativeToTiltfile(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("no sh on windows")
@@ -209,31 +259,206 @@ func TestCustomBuildOutputsToImageRefSkipsLocalDocker(t *testing.T) {
 	assert.Equal(f.t, container.MustParseNamed(myTag), refs.ClusterRef)
 }
 
+func TestCustomBuildEnvironLegacy(t *testing.T) {
+	environ := []string{"PATH=/bin", "GOPATH=/home/me/go", "DOCKER_CONFIG=/home/me/.docker"}
+	env := customBuildEnviron(environ, model.CustomBuildEnv{}, []string{"EXPECTED_REF=foo:tilt-1"})
+	assert.Equal(t, []string{
+		"PATH=/bin",
+		"GOPATH=/home/me/go",
+		"DOCKER_CONFIG=/home/me/.docker",
+		"EXPECTED_REF=foo:tilt-1",
+	}, env)
+}
+
+func TestCustomBuildEnvironDenyList(t *testing.T) {
+	environ := []string{"PATH=/bin", "GOPATH=/home/me/go", "DOCKER_CONFIG=/home/me/.docker"}
+	env := customBuildEnviron(environ, model.CustomBuildEnv{
+		Deny: []string{"DOCKER_CONFIG"},
+		Vars: []string{"GOFLAGS=-mod=vendor"},
+	}, []string{"EXPECTED_REF=foo:tilt-1"})
+	assert.Equal(t, []string{
+		"PATH=/bin",
+		"GOPATH=/home/me/go",
+		"GOFLAGS=-mod=vendor",
+		"EXPECTED_REF=foo:tilt-1",
+	}, env)
+}
+
+func TestCustomBuildEnvironAllowList(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("windows keeps SYSTEMROOT")
+	}
+	environ := []string{"PATH=/bin", "HOME=/home/me", "GOPATH=/home/me/go", "DOCKER_CONFIG=/home/me/.docker"}
+	env := customBuildEnviron(environ, model.CustomBuildEnv{
+		Isolated: true,
+		Allow:    []string{"HOME"},
+		Vars:     []string{"GOFLAGS=-mod=vendor", "EXPECTED_REF=overridden"},
+	}, []string{"EXPECTED_REF=foo:tilt-1", "REGISTRY_HOST=localhost:5000"})
+	assert.Equal(t, []string{
+		"PATH=/bin",
+		"HOME=/home/me",
+		"GOFLAGS=-mod=vendor",
+		"EXPECTED_REF=overridden",
+		"EXPECTED_REF=foo:tilt-1",
+		"REGISTRY_HOST=localhost:5000",
+	}, env)
+}
+
+func TestCustomBuildIsolatedEnv(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no sh on windows")
+	}
+	f := newFakeCustomBuildFixture(t)
+	defer f.teardown()
+
+	t.Setenv("CUSTOM_BUILD_LEAK", "leaked")
+	t.Setenv("CUSTOM_BUILD_ALLOWED", "allowed")
+
+	sha := digest.Digest("sha256:11cd0eb38bc3ceb958ffb2f9bd70be3fb317ce7d255c8a4c3f4af30e298aa1aab")
+	f.dCli.Images["gcr.io/foo/bar:tilt-build-1551202573"] = types.ImageInspect{ID: string(sha)}
+	cb := model.CustomBuild{
+		WorkDir: f.tdf.Path(),
+		Command: model.ToHostCmd("env > env.txt"),
+		Env: model.CustomBuildEnv{
+			Isolated: true,
+			Allow:    []string{"CUSTOM_BUILD_ALLOWED"},
+			Vars:     []string{"API_TOKEN=hunter2"},
+			Secrets:  []string{"API_TOKEN"},
+		},
+	}
+	_, err := f.cb.Build(f.ctx, refSetFromString("gcr.io/foo/bar"), cb)
+	require.NoError(t, err)
+
+	out, err := ioutil.ReadFile(f.tdf.JoinPath("env.txt"))
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "CUSTOM_BUILD_ALLOWED=allowed")
+	assert.Contains(t, string(out), "API_TOKEN=hunter2")
+	assert.Contains(t, string(out), "EXPECTED_REF=gcr.io/foo/bar:tilt-build-1551202573")
+	assert.NotContains(t, string(out), "CUSTOM_BUILD_LEAK")
+
+	log := f.out.String()
+	assert.Contains(t, log, "Custom Build: Isolated Environment")
+	assert.Contains(t, log, "API_TOKEN=[redacted]")
+	assert.NotContains(t, log, "hunter2")
+}
+
+func TestCustomBuildExpectedPlatform(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no sh on windows")
+	}
+	f := newFakeCustomBuildFixture(t)
+	defer f.teardown()
+
+	sha := digest.Digest("sha256:11cd0eb38bc3ceb958ffb2f9bd70be3fb317ce7d255c8a4c3f4af30e298aa1aab")
+	f.dCli.Images["gcr.io/foo/bar:tilt-build-1551202573"] = types.ImageInspect{ID: string(sha)}
+	cb := model.CustomBuild{
+		WorkDir:  f.tdf.Path(),
+		Command:  model.ToHostCmd("echo $EXPECTED_PLATFORM > platform.txt"),
+		Platform: "linux/amd64",
+	}
+	_, err := f.cb.Build(f.ctx, refSetFromString("gcr.io/foo/bar"), cb)
+	require.NoError(t, err)
+
+	out, err := ioutil.ReadFile(f.tdf.JoinPath("platform.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "linux/amd64\n", string(out))
+}
+
+func TestCustomBuildContainerdImageStore(t *testing.T) {
+	f := newFakeCustomBuildFixture(t)
+	defer f.teardown()
+
+	sha := digest.Digest("sha256:11cd0eb38bc3ceb958ffb2f9bd70be3fb317ce7d255c8a4c3f4af30e298aa1aab")
+	f.ctrd.Images["gcr.io/foo/bar:tilt-build-1551202573"] = sha
+	cb := model.CustomBuild{
+		WorkDir:    f.tdf.Path(),
+		Command:    model.ToHostCmd("exit 0"),
+		ImageStore: model.ImageStoreContainerd,
+	}
+	refs, err := f.cb.Build(f.ctx, refSetFromString("gcr.io/foo/bar"), cb)
+	require.NoError(t, err)
+
+	assert.Equal(f.t, container.MustParseNamed("gcr.io/foo/bar:tilt-11cd0eb38bc3ceb9"), refs.LocalRef)
+	assert.Equal(f.t, "gcr.io/foo/bar:tilt-build-1551202573", f.ctrd.Tags["gcr.io/foo/bar:tilt-11cd0eb38bc3ceb9"])
+}
+
+func TestCustomBuildContainerdImageStoreImageInDocker(t *testing.T) {
+	f := newFakeCustomBuildFixture(t)
+	defer f.teardown()
+
+	sha := digest.Digest("sha256:11cd0eb38bc3ceb958ffb2f9bd70be3fb317ce7d255c8a4c3f4af30e298aa1aab")
+	f.dCli.Images["gcr.io/foo/bar:tilt-build-1551202573"] = types.ImageInspect{ID: string(sha)}
+	cb := model.CustomBuild{
+		WorkDir:    f.tdf.Path(),
+		Command:    model.ToHostCmd("exit 0"),
+		ImageStore: model.ImageStoreContainerd,
+	}
+	_, err := f.cb.Build(f.ctx, refSetFromString("gcr.io/foo/bar"), cb)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Could not find image gcr.io/foo/bar:tilt-build-1551202573 in containerd, but found it in Docker")
+	}
+}
+
+func TestCustomBuildContainerdImageStoreImageMissing(t *testing.T) {
+	f := newFakeCustomBuildFixture(t)
+	defer f.teardown()
+
+	cb := model.CustomBuild{
+		WorkDir:    f.tdf.Path(),
+		Command:    model.ToHostCmd("exit 0"),
+		ImageStore: model.ImageStoreContainerd,
+	}
+	_, err := f.cb.Build(f.ctx, refSetFromString("gcr.io/foo/bar"), cb)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Could not find image gcr.io/foo/bar:tilt-build-1551202573 in containerd\n")
+	}
+}
+
+func TestCustomBuildDockerImageStoreImageInContainerd(t *testing.T) {
+	f := newFakeCustomBuildFixture(t)
+	defer f.teardown()
+
+	sha := digest.Digest("sha256:11cd0eb38bc3ceb958ffb2f9bd70be3fb317ce7d255c8a4c3f4af30e298aa1aab")
+	f.ctrd.Images["gcr.io/foo/bar:tilt-build-1551202573"] = sha
+	cb := model.CustomBuild{WorkDir: f.tdf.Path(), Command: model.ToHostCmd("exit 0")}
+	_, err := f.cb.Build(f.ctx, refSetFromString("gcr.io/foo/bar"), cb)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Could not find image gcr.io/foo/bar:tilt-build-1551202573 in Docker, but found it in containerd")
+		assert.Contains(t, err.Error(), "image_store='containerd'")
+	}
+}
+
 type fakeCustomBuildFixture struct {
 	t    *testing.T
 	ctx  context.Context
 	dCli *docker.FakeClient
+	ctrd *FakeContainerdClient
 	cb   *ExecCustomBuilder
 	tdf  *tempdir.TempDirFixture
+	out  *bytes.Buffer
 }
 
 func newFakeCustomBuildFixture(t *testing.T) *fakeCustomBuildFixture {
-	ctx, _, _ := testutils.CtxAndAnalyticsForTest()
+	out := bytes.NewBuffer(nil)
+	ctx, _, _ := testutils.ForkedCtxAndAnalyticsForTest(out)
 	dCli := docker.NewFakeClient()
+	ctrd := NewFakeContainerdClient()
 	clock := fakeClock{
 		now: time.Unix(1551202573, 0),
 	}
 
 	tdf := tempdir.NewTempDirFixture(t)
 
-	cb := NewExecCustomBuilder(dCli, clock)
+	cb := NewExecCustomBuilder(dCli, ctrd, clock)
 
 	f := &fakeCustomBuildFixture{
 		t:    t,
 		tdf:  tdf,
 		ctx:  ctx,
 		dCli: dCli,
+		ctrd: ctrd,
 		cb:   cb,
+		out:  out,
 	}
 
 	return f
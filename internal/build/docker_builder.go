@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/jsonmessage"
 	controlapi "github.com/moby/buildkit/api/services/control"
@@ -44,11 +45,33 @@ type DockerKubeConnection interface {
 type DockerBuilder interface {
 	DockerKubeConnection
 
-	BuildImage(ctx context.Context, ps *PipelineState, refs container.RefSet, db model.DockerBuild, filter model.PathMatcher) (container.TaggedRefs, error)
+	BuildImage(ctx context.Context, ps *PipelineState, refs container.RefSet, db model.DockerBuild, filter model.PathMatcher) (container.TaggedRefs, BuildStats, error)
 	DumpImageDeployRef(ctx context.Context, ref string) (reference.NamedTagged, error)
 	PushImage(ctx context.Context, name reference.NamedTagged) error
 	TagRefs(ctx context.Context, refs container.RefSet, dig digest.Digest) (container.TaggedRefs, error)
-	ImageExists(ctx context.Context, ref reference.NamedTagged) (bool, error)
+
+	// If platform is set, the image must also have been built for that platform.
+	ImageExists(ctx context.Context, ref reference.NamedTagged, platform string) (bool, error)
+
+	// The platform that images are built for when the build doesn't ask for
+	// one, like "linux/arm64". Empty if unknown.
+	DefaultPlatform() string
+
+	// Whether the builder can build images for a platform other than its own.
+	CanCrossBuild() bool
+}
+
+// What we learned about a Dockerfile build while running it.
+type BuildStats struct {
+	Context ContextStats
+
+	// How each step used the build cache.
+	// Empty if the build failed.
+	CacheSteps model.DockerBuildSteps
+
+	// Set when we skipped the build, because an image with
+	// the same content tag already existed.
+	ReusedContentTag bool
 }
 
 func DefaultDockerBuilder(b *dockerImageBuilder) DockerBuilder {
@@ -68,7 +91,8 @@ func (d *dockerImageBuilder) WillBuildToKubeContext(kctx k8s.KubeContext) bool {
 	return d.dCli.Env().WillBuildToKubeContext(kctx)
 }
 
-func (d *dockerImageBuilder) BuildImage(ctx context.Context, ps *PipelineState, refs container.RefSet, db model.DockerBuild, filter model.PathMatcher) (container.TaggedRefs, error) {
+// Also returns the size of the build context, even if the build failed.
+func (d *dockerImageBuilder) BuildImage(ctx context.Context, ps *PipelineState, refs container.RefSet, db model.DockerBuild, filter model.PathMatcher) (container.TaggedRefs, BuildStats, error) {
 	paths := []PathMapping{
 		{
 			LocalPath:     db.BuildPath,
@@ -134,37 +158,108 @@ func (d *dockerImageBuilder) PushImage(ctx context.Context, ref reference.NamedT
 
 	imagePushResponse, err := d.dCli.ImagePush(ctx, ref)
 	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		return errors.Wrap(err, "PushImage#ImagePush")
 	}
 
+	stopClosing := closeOnCancel(ctx, imagePushResponse)
 	defer func() {
+		stopClosing()
 		err := imagePushResponse.Close()
-		if err != nil {
+		if err != nil && ctx.Err() == nil {
 			l.Infof("unable to close imagePushResponse: %s", err)
 		}
 	}()
 
 	_, err = readDockerOutput(ctx, imagePushResponse)
 	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		return errors.Wrapf(err, "pushing image %q", ref.Name())
 	}
 
 	return nil
 }
 
-func (d *dockerImageBuilder) ImageExists(ctx context.Context, ref reference.NamedTagged) (bool, error) {
-	_, _, err := d.dCli.ImageInspectWithRaw(ctx, ref.String())
+// Closes a streaming docker response as soon as the context is canceled.
+//
+// Closing the response hangs up on the daemon, which is how the daemon learns
+// to stop the build or push. It also unblocks our read of the output, even if
+// the daemon has stopped sending any.
+//
+// Call the returned func when done reading, to stop watching the context.
+func closeOnCancel(ctx context.Context, body io.Closer) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = body.Close()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
+
+func (d *dockerImageBuilder) ImageExists(ctx context.Context, ref reference.NamedTagged, platform string) (bool, error) {
+	inspect, _, err := d.dCli.ImageInspectWithRaw(ctx, ref.String())
 	if err != nil {
 		if client.IsErrNotFound(err) {
 			return false, nil
 		}
 		return false, errors.Wrapf(err, "error checking if %s exists", ref.String())
 	}
+	if platform != "" && !PlatformMatches(platform, inspect.Os, inspect.Architecture) {
+		return false, nil
+	}
 	return true, nil
 }
 
-func (d *dockerImageBuilder) buildFromDf(ctx context.Context, ps *PipelineState, db model.DockerBuild, paths []PathMapping, filter model.PathMatcher, refs container.RefSet) (container.TaggedRefs, error) {
-	logger.Get(ctx).Infof("Building Dockerfile:\n%s\n", indent(db.Dockerfile, "  "))
+func (d *dockerImageBuilder) DefaultPlatform() string {
+	v := d.dCli.ServerVersion()
+	if v.Os == "" || v.Arch == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s", v.Os, v.Arch)
+}
+
+// The legacy builder ignores the platform, so only BuildKit can cross-build.
+func (d *dockerImageBuilder) CanCrossBuild() bool {
+	return d.dCli.BuilderVersion() == types.BuilderBuildKit
+}
+
+// Whether an image with the given OS and architecture runs on the platform,
+// ignoring the platform's variant (like the "v8" in "linux/arm64/v8").
+func PlatformMatches(platform, os, arch string) bool {
+	parts := strings.Split(platform, "/")
+	if len(parts) < 2 {
+		return false
+	}
+	return parts[0] == os && parts[1] == arch
+}
+
+// Secrets and ssh forwarding only work through a BuildKit session.
+func needsBuildKit(db model.DockerBuild) bool {
+	return len(db.SecretSpecs) > 0 || len(db.SSHSpecs) > 0
+}
+
+func (d *dockerImageBuilder) buildFromDf(ctx context.Context, ps *PipelineState, db model.DockerBuild, paths []PathMapping, filter model.PathMatcher, refs container.RefSet) (container.TaggedRefs, BuildStats, error) {
+	if needsBuildKit(db) && d.dCli.BuilderVersion() != types.BuilderBuildKit {
+		// Fail before we tar anything, with an error that names the image,
+		// rather than letting the daemon reject the build.
+		return container.TaggedRefs{}, BuildStats{}, fmt.Errorf(
+			"Building %s requires BuildKit, because it uses docker_build(secret=...) or docker_build(ssh=...).\n"+
+				"Enable BuildKit with DOCKER_BUILDKIT=1, or upgrade to a Docker daemon that supports it",
+			container.FamiliarString(refs.ConfigurationRef))
+	}
+
+	if db.TargetStage != "" {
+		logger.Get(ctx).Infof("Building Dockerfile (target: %s):\n%s\n", db.TargetStage, indent(db.Dockerfile, "  "))
+	} else {
+		logger.Get(ctx).Infof("Building Dockerfile:\n%s\n", indent(db.Dockerfile, "  "))
+	}
 
 	ps.StartBuildStep(ctx, "Tarring context…")
 
@@ -178,11 +273,14 @@ func (d *dockerImageBuilder) buildFromDf(ctx context.Context, ps *PipelineState,
 	ps.StartBuildStep(ctx, "Building image")
 	allowBuildkit := true
 	ctx = ps.AttachLogger(ctx)
-	digest, err := d.buildFromDfToDigest(ctx, db, paths, filter, allowBuildkit)
+	digest, stats, err := d.buildFromDfToDigest(ctx, db, paths, filter, allowBuildkit)
 	if err != nil {
 		isMysteriousCorruption := strings.Contains(err.Error(), "failed precondition") &&
 			strings.Contains(err.Error(), "failed commit on ref")
-		if isMysteriousCorruption {
+
+		// The legacy builder can't pass secrets or ssh agents, so retrying
+		// without BuildKit would only replace this error with a less useful one.
+		if isMysteriousCorruption && !needsBuildKit(db) {
 			// We've seen weird corruption issues on buildkit
 			// that look like
 			//
@@ -199,38 +297,48 @@ func (d *dockerImageBuilder) buildFromDf(ctx context.Context, ps *PipelineState,
 			// If this happens, just try again without buildkit.
 			allowBuildkit = false
 			logger.Get(ctx).Infof("Detected Buildkit corruption. Rebuilding without Buildkit")
-			digest, err = d.buildFromDfToDigest(ctx, db, paths, filter, allowBuildkit)
+			digest, stats, err = d.buildFromDfToDigest(ctx, db, paths, filter, allowBuildkit)
 		}
 
 		if err != nil {
-			return container.TaggedRefs{}, err
+			return container.TaggedRefs{}, stats, err
 		}
 	}
 
+	if summary := stats.CacheSteps.Summary(); summary != "" {
+		logger.Get(ctx).Infof("Build cache: %s", summary)
+	}
+
 	tagged, err := d.TagRefs(ctx, refs, digest)
 	if err != nil {
-		return container.TaggedRefs{}, errors.Wrap(err, "PushImage")
+		return container.TaggedRefs{}, stats, errors.Wrap(err, "PushImage")
 	}
 
-	return tagged, nil
+	return tagged, stats, nil
 }
 
 // A helper function that builds the paths to the given docker image,
-// then returns the output digest.
-func (d *dockerImageBuilder) buildFromDfToDigest(ctx context.Context, db model.DockerBuild, paths []PathMapping, filter model.PathMatcher, allowBuildkit bool) (digest.Digest, error) {
+// then returns the output digest, the size of the context we sent,
+// and how the build used the cache.
+func (d *dockerImageBuilder) buildFromDfToDigest(ctx context.Context, db model.DockerBuild, paths []PathMapping, filter model.PathMatcher, allowBuildkit bool) (digest.Digest, BuildStats, error) {
 	pr, pw := io.Pipe()
+	statsCh := make(chan ContextStats, 1)
 	go func(ctx context.Context) {
-		err := tarContextAndUpdateDf(ctx, pw, dockerfile.Dockerfile(db.Dockerfile), paths, filter)
+		stats, err := tarContextAndUpdateDf(ctx, pw, dockerfile.Dockerfile(db.Dockerfile), paths, filter)
 		if err != nil {
 			_ = pw.CloseWithError(err)
 		} else {
 			_ = pw.Close()
 		}
+		statsCh <- stats
 	}(ctx)
 
-	defer func() {
+	// If the daemon stopped reading early, closing the reader stops the tar,
+	// and the stats are empty.
+	finishTar := func() ContextStats {
 		_ = pr.Close()
-	}()
+		return <-statsCh
+	}
 
 	options := Options(pr, db)
 	if !allowBuildkit {
@@ -242,31 +350,42 @@ func (d *dockerImageBuilder) buildFromDfToDigest(ctx context.Context, db model.D
 		options,
 	)
 	if err != nil {
-		return "", err
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return "", BuildStats{Context: finishTar()}, err
 	}
 
+	stopClosing := closeOnCancel(ctx, imageBuildResponse.Body)
 	defer func() {
+		stopClosing()
 		err := imageBuildResponse.Body.Close()
-		if err != nil {
+		if err != nil && ctx.Err() == nil {
 			logger.Get(ctx).Infof("unable to close imageBuildResponse: %s", err)
 		}
 	}()
 
-	return d.getDigestFromBuildOutput(ctx, imageBuildResponse.Body)
+	digest, steps, err := d.getDigestFromBuildOutput(ctx, imageBuildResponse.Body)
+	if err != nil && ctx.Err() != nil {
+		// Any error reading the output after a cancel is just the closed
+		// connection, so report the cancellation instead.
+		err = ctx.Err()
+	}
+	return digest, BuildStats{Context: finishTar(), CacheSteps: steps}, err
 }
 
-func (d *dockerImageBuilder) getDigestFromBuildOutput(ctx context.Context, reader io.Reader) (digest.Digest, error) {
+func (d *dockerImageBuilder) getDigestFromBuildOutput(ctx context.Context, reader io.Reader) (digest.Digest, model.DockerBuildSteps, error) {
 	result, err := readDockerOutput(ctx, reader)
 	if err != nil {
-		return "", errors.Wrap(err, "ImageBuild")
+		return "", nil, errors.Wrap(err, "ImageBuild")
 	}
 
 	digest, err := d.getDigestFromDockerOutput(ctx, result)
 	if err != nil {
-		return "", errors.Wrap(err, "getDigestFromBuildOutput")
+		return "", nil, errors.Wrap(err, "getDigestFromBuildOutput")
 	}
 
-	return digest, nil
+	return digest, result.cacheSteps, nil
 }
 
 var dockerBuildCleanupRexes = []*regexp.Regexp{
@@ -312,6 +431,7 @@ func readDockerOutput(ctx context.Context, reader io.Reader) (dockerOutput, erro
 	result := dockerOutput{}
 	decoder := json.NewDecoder(reader)
 	b := newBuildkitPrinter(logger.Get(ctx))
+	classic := newClassicStepParser()
 
 	for decoder.More() {
 		message := jsonmessage.JSONMessage{}
@@ -329,6 +449,11 @@ func readDockerOutput(ctx context.Context, reader io.Reader) (dockerOutput, erro
 				result.shortDigest = builtDigestMatch[1]
 			}
 
+			classic.write(msg, time.Now())
+			if step := classic.runningStep(); step != "" {
+				SetBuildPhaseStep(ctx, "Dockerfile step %s", step)
+			}
+
 			logger.Get(ctx).Write(logger.InfoLvl, []byte(msg))
 		}
 
@@ -365,6 +490,9 @@ func readDockerOutput(ctx context.Context, reader io.Reader) (dockerOutput, erro
 			if err != nil {
 				return dockerOutput{}, err
 			}
+			if step := b.runningStep(); step != "" {
+				SetBuildPhaseStep(ctx, "Dockerfile step %s", step)
+			}
 		}
 
 		if message.Aux != nil && !messageIsFromBuildkit(message) {
@@ -375,6 +503,12 @@ func readDockerOutput(ctx context.Context, reader io.Reader) (dockerOutput, erro
 	if ctx.Err() != nil {
 		return dockerOutput{}, ctx.Err()
 	}
+
+	if len(b.vOrder) > 0 {
+		result.cacheSteps = b.cacheSteps()
+	} else {
+		result.cacheSteps = classic.finish(time.Now())
+	}
 	return result, nil
 }
 
@@ -490,6 +624,7 @@ var oldDigestRegexp = regexp.MustCompile(`^Successfully built ([0-9a-f]+)\s*$`)
 type dockerOutput struct {
 	aux         *json.RawMessage
 	shortDigest string
+	cacheSteps  model.DockerBuildSteps
 }
 
 func indent(text, indent string) string {
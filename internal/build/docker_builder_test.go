@@ -2,10 +2,12 @@ package build
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/opencontainers/go-digest"
@@ -15,6 +17,9 @@ import (
 	"github.com/tilt-dev/tilt/internal/container"
 	"github.com/tilt-dev/tilt/internal/docker"
 	"github.com/tilt-dev/tilt/internal/testutils"
+	"github.com/tilt-dev/tilt/internal/testutils/bufsync"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
 )
 
 func TestDigestAsTag(t *testing.T) {
@@ -66,7 +71,7 @@ func TestDigestFromSingleStepOutput(t *testing.T) {
 
 	input := docker.ExampleBuildOutput1
 	expected := digest.Digest("sha256:11cd0b38bc3ceb958ffb2f9bd70be3fb317ce7d255c8a4c3f4af30e298aa1aab")
-	actual, err := f.b.getDigestFromBuildOutput(f.ctx, bytes.NewBuffer([]byte(input)))
+	actual, _, err := f.b.getDigestFromBuildOutput(f.ctx, bytes.NewBuffer([]byte(input)))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -82,7 +87,7 @@ func TestDigestFromOutputV1_23(t *testing.T) {
 	input := docker.ExampleBuildOutputV1_23
 	expected := digest.Digest("sha256:11cd0eb38bc3ceb958ffb2f9bd70be3fb317ce7d255c8a4c3f4af30e298aa1aab")
 	f.fakeDocker.Images["11cd0b38bc3c"] = types.ImageInspect{ID: string(expected)}
-	actual, err := f.b.getDigestFromBuildOutput(f.ctx, bytes.NewBuffer([]byte(input)))
+	actual, _, err := f.b.getDigestFromBuildOutput(f.ctx, bytes.NewBuffer([]byte(input)))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -102,6 +107,105 @@ func TestDumpImageDeployRef(t *testing.T) {
 	assert.Equal(t, "docker.io/library/example-image:tilt-11cd0eb38bc3ceb9", ref.String())
 }
 
+func TestBuildImageMeasuresContext(t *testing.T) {
+	f := newFakeDockerBuildFixture(t)
+	defer f.teardown()
+
+	f.WriteFile("big/data.bin", strings.Repeat("x", 5000))
+	f.WriteFile("main.go", "package main")
+	f.fakeDocker.BuildOutput = docker.ExampleBuildOutput1
+
+	refs := container.MustSimpleRefSet(container.MustParseSelector("gcr.io/some-project/some-image"))
+	_, stats, err := f.b.BuildImage(f.ctx, f.ps, refs, model.DockerBuild{
+		Dockerfile: "FROM alpine",
+		BuildPath:  f.Path(),
+	}, model.EmptyMatcher)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(f.fakeDocker.BuildContext.Len()), stats.Context.Size)
+	assert.Equal(t, []ContextEntrySize{
+		{Name: "big", Size: 5000},
+		{Name: "main.go", Size: 12},
+	}, stats.Context.Largest(2))
+}
+
+func TestBuildImagePassesSecretsAndSSH(t *testing.T) {
+	f := newFakeDockerBuildFixture(t)
+	defer f.teardown()
+
+	f.fakeDocker.FakeBuilderVersion = types.BuilderBuildKit
+	f.fakeDocker.BuildOutput = docker.ExampleBuildOutput1
+
+	refs := container.MustSimpleRefSet(container.MustParseSelector("gcr.io/some-project/some-image"))
+	_, _, err := f.b.BuildImage(f.ctx, f.ps, refs, model.DockerBuild{
+		Dockerfile:  "FROM alpine",
+		BuildPath:   f.Path(),
+		SecretSpecs: []string{"id=npmrc,src=.npmrc", "id=token,env=NPM_TOKEN"},
+		SSHSpecs:    []string{"default"},
+	}, model.EmptyMatcher)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"id=npmrc,src=.npmrc", "id=token,env=NPM_TOKEN"}, f.fakeDocker.BuildOptions.SecretSpecs)
+	assert.Equal(t, []string{"default"}, f.fakeDocker.BuildOptions.SSHSpecs)
+}
+
+func TestBuildImageSecretsRequireBuildKit(t *testing.T) {
+	f := newFakeDockerBuildFixture(t)
+	defer f.teardown()
+
+	f.fakeDocker.FakeBuilderVersion = types.BuilderV1
+
+	refs := container.MustSimpleRefSet(container.MustParseSelector("gcr.io/some-project/some-image"))
+	_, _, err := f.b.BuildImage(f.ctx, f.ps, refs, model.DockerBuild{
+		Dockerfile:  "FROM alpine",
+		BuildPath:   f.Path(),
+		SecretSpecs: []string{"id=npmrc,src=.npmrc"},
+	}, model.EmptyMatcher)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Building gcr.io/some-project/some-image requires BuildKit")
+	assert.Equal(t, 0, f.fakeDocker.BuildCount)
+}
+
+func TestBuildImageCanceledMidBuild(t *testing.T) {
+	f := newFakeDockerBuildFixture(t)
+	defer f.teardown()
+
+	f.fakeDocker.BuildHangs = true
+	f.fakeDocker.BuildOutput = `{"stream":"Step 1/2 : FROM alpine\n"}` + "\n"
+
+	out := bufsync.NewThreadSafeBuffer()
+	ctx, cancel := context.WithCancel(logger.WithLogger(f.ctx, logger.NewTestLogger(out)))
+	defer cancel()
+
+	errCh := make(chan error)
+	go func() {
+		refs := container.MustSimpleRefSet(container.MustParseSelector("gcr.io/some-project/some-image"))
+		_, _, err := f.b.BuildImage(ctx, f.ps, refs, model.DockerBuild{
+			Dockerfile: "FROM alpine",
+			BuildPath:  f.Path(),
+		}, model.EmptyMatcher)
+		errCh <- err
+	}()
+
+	require.NoError(t, out.WaitUntilContains("Step 1/2", time.Second))
+	cancel()
+
+	select {
+	case err := <-errCh:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(time.Second):
+		t.Fatal("build did not stop after cancel")
+	}
+
+	select {
+	case <-f.fakeDocker.BuildResponseClosed:
+	default:
+		t.Fatal("build response was not closed, so the daemon would keep building")
+	}
+	assert.NotContains(t, out.String(), "unable to close")
+	assert.NotContains(t, out.String(), "decoding docker output")
+}
+
 func makeDockerBuildErrorOutput(s string) string {
 	b := &bytes.Buffer{}
 	err := json.NewEncoder(b).Encode(s)
@@ -166,7 +270,7 @@ func TestCleanUpBuildKitErrors(t *testing.T) {
 
 			ctx, _, _ := testutils.CtxAndAnalyticsForTest()
 			s := makeDockerBuildErrorOutput(tc.buildKitError)
-			_, err := f.b.getDigestFromBuildOutput(ctx, strings.NewReader(s))
+			_, _, err := f.b.getDigestFromBuildOutput(ctx, strings.NewReader(s))
 			require.NotNil(t, err)
 			require.Equal(t, fmt.Sprintf("ImageBuild: %s", tc.expectedTiltError), err.Error())
 		})
@@ -92,10 +92,14 @@ func FilterMappings(mappings []PathMapping, matcher model.PathMatcher) ([]PathMa
 // associates local filepaths with their syncs and destination paths), returning those
 // that it cannot associate with a sync.
 func FilesToPathMappings(files []string, syncs []model.Sync) ([]PathMapping, []string, error) {
+	return filesToPathMappings(ospath.HostSemantics(), files, syncs)
+}
+
+func filesToPathMappings(semantics ospath.PathSemantics, files []string, syncs []model.Sync) ([]PathMapping, []string, error) {
 	pms := make([]PathMapping, 0, len(files))
 	pathsMatchingNoSync := []string{}
 	for _, f := range files {
-		pm, couldMap, err := fileToPathMapping(f, syncs)
+		pm, couldMap, err := fileToPathMapping(semantics, f, syncs)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -110,23 +114,29 @@ func FilesToPathMappings(files []string, syncs []model.Sync) ([]PathMapping, []s
 	return pms, pathsMatchingNoSync, nil
 }
 
-func fileToPathMapping(file string, sync []model.Sync) (pm PathMapping, couldMap bool, err error) {
+// The local paths are compared under the given path semantics, so a file matches a sync
+// regardless of which separator or casing the watcher used. The container path
+// keeps the casing of the local file.
+func fileToPathMapping(semantics ospath.PathSemantics, file string, sync []model.Sync) (pm PathMapping, couldMap bool, err error) {
 	for _, s := range sync {
 		// Open Q: can you sync files inside of syncs?! o_0
 		// TODO(maia): are symlinks etc. gonna kick our asses here? If so, will
 		// need ospath.RealChild -- but then can't deal with deleted local files.
-		relPath, isChild := ospath.Child(s.LocalPath, file)
+		relPath, isChild := semantics.Child(s.LocalPath, file)
 		if isChild {
-			localPathIsFile, err := isFile(s.LocalPath)
-			if err != nil {
-				return PathMapping{}, false, fmt.Errorf("error stat'ing: %v", err)
+			localPathIsFile := false
+			if endsWithUnixSeparator(s.ContainerPath) {
+				localPathIsFile, err = isFile(s.LocalPath)
+				if err != nil {
+					return PathMapping{}, false, fmt.Errorf("error stat'ing: %v", err)
+				}
 			}
 			var containerPath string
-			if endsWithUnixSeparator(s.ContainerPath) && localPathIsFile {
-				fileName := filepath.Base(s.LocalPath)
+			if localPathIsFile {
+				fileName := path.Base(semantics.ToSlash(s.LocalPath))
 				containerPath = path.Join(s.ContainerPath, fileName)
 			} else {
-				containerPath = path.Join(s.ContainerPath, filepath.ToSlash(relPath))
+				containerPath = path.Join(s.ContainerPath, semantics.ToSlash(relPath))
 			}
 			return PathMapping{
 				LocalPath:     file,
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/tilt-dev/tilt/internal/ospath"
 	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
 	"github.com/tilt-dev/tilt/pkg/model"
 )
@@ -124,3 +125,35 @@ func TestFileNotInSyncYieldsNoMapping(t *testing.T) {
 	assert.Empty(t, actual, "expected no path mapping returned for a file not matching any syncs")
 	assert.Equal(t, files, skipped)
 }
+
+func TestFilesToPathMappingsWindows(t *testing.T) {
+	syncs := []model.Sync{
+		model.Sync{
+			LocalPath:     `C:/Users/Dev/Repo/src`,
+			ContainerPath: "/app/src",
+		},
+	}
+	files := []string{
+		`C:\Users\Dev\Repo\src\Main.go`,
+		`c:\users\dev\repo\SRC\pkg\Util.go`,
+		`C:\Users\Dev\Repo\docs\README.md`,
+	}
+
+	actual, skipped, err := filesToPathMappings(ospath.WindowsSemantics, files, syncs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []PathMapping{
+		PathMapping{
+			LocalPath:     `C:\Users\Dev\Repo\src\Main.go`,
+			ContainerPath: "/app/src/Main.go",
+		},
+		PathMapping{
+			LocalPath:     `c:\users\dev\repo\SRC\pkg\Util.go`,
+			ContainerPath: "/app/src/pkg/Util.go",
+		},
+	}
+	assert.ElementsMatch(t, expected, actual)
+	assert.Equal(t, []string{`C:\Users\Dev\Repo\docs\README.md`}, skipped)
+}
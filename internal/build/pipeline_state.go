@@ -92,6 +92,7 @@ func (ps *PipelineState) StartPipelineStep(ctx context.Context, format string, a
 	line := logger.Blue(l).Sprintf("STEP %d/%d", ps.curPipelineIndex(), ps.totalPipelineStepCount)
 	l.Infof("%s — %s", line, stepName)
 	ps.curBuildStep = 1
+	SetBuildPhase(ctx, "%s", stepName)
 }
 
 func (ps *PipelineState) EndPipelineStep(ctx context.Context) {
@@ -102,8 +103,10 @@ func (ps *PipelineState) EndPipelineStep(ctx context.Context) {
 
 func (ps *PipelineState) StartBuildStep(ctx context.Context, format string, a ...interface{}) {
 	l := logger.Get(ctx)
-	l.Infof("%s%s", buildStepOutputPrefix, fmt.Sprintf(format, a...))
+	stepName := fmt.Sprintf(format, a...)
+	l.Infof("%s%s", buildStepOutputPrefix, stepName)
 	ps.curBuildStep++
+	SetBuildPhaseStep(ctx, "%s", stepName)
 }
 
 func (ps *PipelineState) Printf(ctx context.Context, format string, a ...interface{}) {
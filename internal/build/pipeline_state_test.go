@@ -76,6 +76,23 @@ func TestPipelineMultistep(t *testing.T) {
 	assertSnapshot(t, out.String())
 }
 
+func TestPipelineRecordsBuildPhase(t *testing.T) {
+	ctx := logger.WithLogger(context.Background(), logger.NewLogger(logger.InfoLvl, ioutil.Discard))
+	ctx, phase := WithBuildPhase(ctx)
+	ps := NewPipelineState(ctx, 2, fakeClock{})
+
+	ps.StartPipelineStep(ctx, "Building Dockerfile: [%s]", "foo")
+	assert.Equal(t, "Building Dockerfile: [foo]", phase.String())
+	ps.StartBuildStep(ctx, "Building image")
+	assert.Equal(t, "Building image (Building Dockerfile: [foo])", phase.String())
+	ps.EndPipelineStep(ctx)
+
+	// A new pipeline step clears the build step.
+	ps.StartPipelineStep(ctx, "Deploying")
+	assert.Equal(t, "Deploying", phase.String())
+	ps.EndPipelineStep(ctx)
+}
+
 func assertSnapshot(t *testing.T, output string) {
 	d1 := []byte(output)
 	gmPath := fmt.Sprintf("testdata/%s_master", t.Name())
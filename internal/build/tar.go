@@ -22,15 +22,25 @@ type ArchiveBuilder struct {
 	tw     *tar.Writer
 	filter model.PathMatcher
 	paths  []string // local paths archived
+
+	// Measured as we write, so that we don't have to walk the context twice.
+	counter    *countingWriter
+	entrySizes map[string]int64
 }
 
 func NewArchiveBuilder(writer io.Writer, filter model.PathMatcher) *ArchiveBuilder {
-	tw := tar.NewWriter(writer)
+	counter := &countingWriter{w: writer}
+	tw := tar.NewWriter(counter)
 	if filter == nil {
 		filter = model.EmptyMatcher
 	}
 
-	return &ArchiveBuilder{tw: tw, filter: filter}
+	return &ArchiveBuilder{
+		tw:         tw,
+		filter:     filter,
+		counter:    counter,
+		entrySizes: make(map[string]int64),
+	}
 }
 
 func (a *ArchiveBuilder) Close() error {
@@ -66,6 +76,7 @@ func (a *ArchiveBuilder) archiveDf(ctx context.Context, df dockerfile.Dockerfile
 		return err
 	}
 
+	a.recordEntrySize(tarHeader)
 	return nil
 }
 
@@ -107,6 +118,31 @@ func (a *ArchiveBuilder) Paths() []string {
 	return a.paths
 }
 
+// Sizes of what we've archived so far. Call after Close() to include
+// the end-of-archive padding.
+func (a *ArchiveBuilder) Stats() ContextStats {
+	entrySizes := make(map[string]int64, len(a.entrySizes))
+	for k, v := range a.entrySizes {
+		entrySizes[k] = v
+	}
+	return ContextStats{
+		Size:       a.counter.n,
+		EntrySizes: entrySizes,
+	}
+}
+
+// Adds a file's size to the top-level file or directory of the archive that contains it.
+func (a *ArchiveBuilder) recordEntrySize(header *tar.Header) {
+	name := strings.TrimPrefix(header.Name, "/")
+	if i := strings.Index(name, "/"); i != -1 {
+		name = name[:i]
+	}
+	if name == "" || name == "." {
+		return
+	}
+	a.entrySizes[name] += header.Size
+}
+
 type archiveEntry struct {
 	path   string
 	info   os.FileInfo
@@ -254,22 +290,27 @@ func (a *ArchiveBuilder) writeEntry(entry archiveEntry) error {
 	if err := a.tw.Flush(); err != nil {
 		return errors.Wrapf(err, "%s: flush", path)
 	}
+	a.recordEntrySize(header)
 	return nil
 }
 
-func tarContextAndUpdateDf(ctx context.Context, writer io.Writer, df dockerfile.Dockerfile, paths []PathMapping, filter model.PathMatcher) error {
+func tarContextAndUpdateDf(ctx context.Context, writer io.Writer, df dockerfile.Dockerfile, paths []PathMapping, filter model.PathMatcher) (ContextStats, error) {
 	ab := NewArchiveBuilder(writer, filter)
 	err := ab.ArchivePathsIfExist(ctx, paths)
 	if err != nil {
-		return errors.Wrap(err, "archivePaths")
+		return ContextStats{}, errors.Wrap(err, "archivePaths")
 	}
 
 	err = ab.archiveDf(ctx, df)
 	if err != nil {
-		return errors.Wrap(err, "archiveDf")
+		return ContextStats{}, errors.Wrap(err, "archiveDf")
 	}
 
-	return ab.Close()
+	err = ab.Close()
+	if err != nil {
+		return ContextStats{}, err
+	}
+	return ab.Stats(), nil
 }
 
 func TarDfOnly(ctx context.Context, writer io.Writer, df dockerfile.Dockerfile) error {
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -262,6 +263,36 @@ func TestArchiveException(t *testing.T) {
 	f.assertFileInTar(actual, expectedFile{Path: "target/foo.txt", Contents: "bar"})
 }
 
+func TestArchiveContextStats(t *testing.T) {
+	f := newFixture(t)
+	defer f.tearDown()
+
+	filter, err := dockerignore.NewDockerPatternMatcher(f.Path(), []string{"dist"})
+	require.NoError(t, err)
+
+	f.WriteFile("node_modules/left-pad/index.js", strings.Repeat("a", 3000))
+	f.WriteFile("node_modules/react/index.js", strings.Repeat("b", 2000))
+	f.WriteFile(".git/objects/pack", strings.Repeat("c", 4000))
+	f.WriteFile("src/main.go", strings.Repeat("d", 100))
+	f.WriteFile("go.mod", strings.Repeat("e", 10))
+	f.WriteFile("dist/bundle.js", strings.Repeat("f", 9000))
+
+	buf := new(bytes.Buffer)
+	stats, err := tarContextAndUpdateDf(f.ctx, buf, dockerfile.Dockerfile("FROM alpine"),
+		[]PathMapping{{LocalPath: f.Path(), ContainerPath: "/"}}, filter)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(buf.Len()), stats.Size)
+	assert.Equal(t, []ContextEntrySize{
+		{Name: "node_modules", Size: 5000},
+		{Name: ".git", Size: 4000},
+		{Name: "src", Size: 100},
+		{Name: "Dockerfile", Size: 11},
+	}, stats.Largest(4))
+	assert.Equal(t, int64(10), stats.EntrySizes["go.mod"])
+	assert.NotContains(t, stats.EntrySizes, "dist")
+}
+
 type fixture struct {
 	*tempdir.TempDirFixture
 	t   *testing.T
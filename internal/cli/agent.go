@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tilt-dev/tilt/internal/agent"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// Runs Tilt's in-cluster agent. Tilt installs the agent itself
+// (see internal/agent), so users never run this directly.
+type agentCmd struct {
+	port int
+}
+
+func (c *agentCmd) name() model.TiltSubcommand { return "agent" }
+
+func (c *agentCmd) register() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "agent",
+		Short:  "Run the in-cluster agent that Tilt uses when it can't reach pods directly",
+		Hidden: true,
+		Args:   cobra.NoArgs,
+	}
+	cmd.Flags().IntVar(&c.port, "port", agent.Port, "Port to serve the agent API on")
+	return cmd
+}
+
+func (c *agentCmd) run(ctx context.Context, args []string) error {
+	token := os.Getenv(agent.TokenEnv)
+	if token == "" {
+		return fmt.Errorf("the in-cluster agent needs a token in $%s", agent.TokenEnv)
+	}
+
+	pods, err := k8s.NewInClusterPodOps()
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%d", c.port),
+		Handler: agent.NewServer(pods, provideTiltInfo().Version, token),
+	}
+	go func() {
+		<-ctx.Done()
+		_ = server.Shutdown(context.Background())
+	}()
+
+	logger.Get(ctx).Infof("Tilt agent %s listening on %s", buildStamp(), server.Addr)
+	err = server.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
@@ -12,7 +12,6 @@ import (
 	"github.com/tilt-dev/wmclient/pkg/analytics"
 )
 
-const tiltAppName = "tilt"
 const analyticsURLEnvVar = "TILT_ANALYTICS_URL"
 
 // Testing analytics locally:
@@ -56,7 +55,7 @@ func newAnalytics(l logger.Logger, cmdName model.TiltSubcommand, tiltBuild model
 	if analyticsURL != "" {
 		options = append(options, analytics.WithReportURL(analyticsURL))
 	}
-	backingAnalytics, err := analytics.NewRemoteAnalytics(tiltAppName, options...)
+	backingAnalytics, err := analytics.NewRemoteAnalytics(tiltanalytics.AppName, options...)
 	if err != nil {
 		return nil, err
 	}
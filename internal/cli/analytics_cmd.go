@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tilt-dev/wmclient/pkg/analytics"
+	"github.com/tilt-dev/wmclient/pkg/dirs"
+
+	tiltanalytics "github.com/tilt-dev/tilt/internal/analytics"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// Mirrors the choice file used by the analytics library.
+const analyticsChoiceFile = "analytics/user/choice.txt"
+
+func newAnalyticsCmd() *cobra.Command {
+	status := &analyticsStatusCmd{}
+	cmd := status.register()
+	cmd.Use = "analytics"
+	cmd.Short = "Info and status about Tilt analytics"
+	cmd.Long = `Info and status about Tilt analytics.
+
+Tilt reports anonymized usage statistics if you opt in.
+Use 'tilt analytics dump' to see exactly what a running Tilt would report.
+`
+	cmd.Run = func(_ *cobra.Command, args []string) {
+		runTiltCmd(status, args)
+	}
+
+	addCommand(cmd, &analyticsStatusCmd{})
+	addCommand(cmd, &analyticsDumpCmd{})
+	addCommand(cmd, &analyticsOptCmd{})
+	return cmd
+}
+
+type analyticsStatusCmd struct{}
+
+func (c *analyticsStatusCmd) name() model.TiltSubcommand { return "analytics" }
+
+func (c *analyticsStatusCmd) register() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Print the current analytics opt state and how Tilt identifies this machine",
+		Args:  cobra.NoArgs,
+	}
+}
+
+func (c *analyticsStatusCmd) run(ctx context.Context, args []string) error {
+	a := tiltanalytics.Get(ctx)
+
+	fmt.Printf("analytics status : %s\n", a.UserOpt())
+	if disabled, reason := tiltanalytics.IsAnalyticsDisabledFromEnv(); disabled {
+		fmt.Printf("  (overridden to %s by %s)\n", analytics.OptOut, reason)
+	}
+
+	if v, ok := a.GlobalTag(analytics.TagUser); ok {
+		fmt.Printf("user id          : %s (md5 hex digest of `uname -a`)\n", v)
+	}
+	if v, ok := a.GlobalTag(analytics.TagMachine); ok {
+		fmt.Printf("machine id       : %s (md5 hex digest of the OS machine id)\n", v)
+	}
+	if v := a.GitRepoHash(); v != "" {
+		fmt.Printf("git repo id      : %s (base64 md5 digest of the git origin url)\n", v)
+	}
+	return nil
+}
+
+type analyticsDumpCmd struct{}
+
+func (c *analyticsDumpCmd) name() model.TiltSubcommand { return "analytics" }
+
+func (c *analyticsDumpCmd) register() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dump",
+		Short: "Print the analytics payloads that a running Tilt would report, without sending them",
+		Long: `Print the analytics payloads that a running Tilt would report, without sending them.
+
+Runs the same report generation that Tilt runs periodically, against the current
+state of a running Tilt, and prints the payloads as JSON.
+`,
+		Args: cobra.NoArgs,
+	}
+	addConnectServerFlags(cmd)
+	return cmd
+}
+
+func (c *analyticsDumpCmd) run(ctx context.Context, args []string) error {
+	body := apiGet("analytics/dump")
+	err := dumpJSON(body)
+	if err != nil {
+		return fmt.Errorf("dump analytics: %v", err)
+	}
+	return nil
+}
+
+type analyticsOptCmd struct{}
+
+func (c *analyticsOptCmd) name() model.TiltSubcommand { return "analytics" }
+
+func (c *analyticsOptCmd) register() *cobra.Command {
+	return &cobra.Command{
+		Use:   "opt [in|out]",
+		Short: "Opt in or out of Tilt analytics",
+		Long: `Opt in or out of Tilt analytics.
+
+The choice is persisted, and any running Tilt picks it up on its next report cycle.
+`,
+		Args: cobra.ExactArgs(1),
+	}
+}
+
+func (c *analyticsOptCmd) run(ctx context.Context, args []string) error {
+	opt, err := analytics.ParseOpt(args[0])
+	if err != nil || opt == analytics.OptDefault {
+		return fmt.Errorf("choice can be one of {%s, %s}", analytics.OptIn, analytics.OptOut)
+	}
+
+	err = tiltanalytics.Get(ctx).SetUserOpt(opt)
+	if err != nil {
+		return err
+	}
+
+	d, err := dirs.UseTiltDevDir()
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "wrote user collection strategy %q to file %v\n", opt, filepath.Join(d.Root(), analyticsChoiceFile))
+	return nil
+}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	pkgdescribe "k8s.io/kubectl/pkg/describe"
+
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+)
+
+// Adds the most recent applies to the output of 'tilt describe'
+// for resources that Tilt applies to Kubernetes.
+type applyHistoryDescriber struct {
+	pkgdescribe.ResourceDescriber
+	fetch func(name string) ([]k8s.ApplyInvocation, error)
+}
+
+func hasApplyHistory(mapping *meta.RESTMapping) bool {
+	if mapping.Resource.Group != v1alpha1.SchemeGroupVersion.Group {
+		return false
+	}
+	return mapping.Resource.Resource == "kubernetesapplys" ||
+		mapping.Resource.Resource == "uiresources"
+}
+
+func (d *applyHistoryDescriber) Describe(namespace, name string, settings pkgdescribe.DescriberSettings) (string, error) {
+	out, err := d.ResourceDescriber.Describe(namespace, name, settings)
+	if err != nil {
+		return out, err
+	}
+
+	// The history is a debugging aid. If we can't get it
+	// (e.g., we're talking to an older Tilt), describe the object anyway.
+	history, err := d.fetch(name)
+	if err != nil || len(history) == 0 {
+		return out, nil
+	}
+	return out + formatApplyHistory(history), nil
+}
+
+func fetchApplyHistory(name string) ([]k8s.ApplyInvocation, error) {
+	res, err := apiHTTPGet(apiURL(fmt.Sprintf("apply_history/%s", url.PathEscape(name))))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("apply history: %s", res.Status)
+	}
+
+	var history []k8s.ApplyInvocation
+	err = json.NewDecoder(res.Body).Decode(&history)
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func formatApplyHistory(history []k8s.ApplyInvocation) string {
+	var sb strings.Builder
+	sb.WriteString("Apply History:\n")
+	for _, inv := range history {
+		result := "OK"
+		if inv.Error != "" {
+			result = "Failed"
+		}
+		fmt.Fprintf(&sb, "  %s  %s  %s  %s\n",
+			inv.StartTime.Format(time.RFC3339), inv.Duration.Round(time.Millisecond), result, inv.Command)
+		if len(inv.Namespaces) > 0 {
+			fmt.Fprintf(&sb, "    Namespaces:  %s\n", strings.Join(inv.Namespaces, ", "))
+		}
+		if inv.YAMLHash != "" {
+			fmt.Fprintf(&sb, "    YAML:        %d bytes, sha256 %s\n", inv.YAMLBytes, inv.YAMLHash)
+		}
+		if inv.YAMLPath != "" {
+			fmt.Fprintf(&sb, "    YAML Path:   %s\n", inv.YAMLPath)
+		}
+		if inv.Error != "" {
+			fmt.Fprintf(&sb, "    Error:\n")
+			for _, line := range strings.Split(strings.TrimRight(inv.Error, "\n"), "\n") {
+				fmt.Fprintf(&sb, "      %s\n", line)
+			}
+		}
+	}
+	return sb.String()
+}
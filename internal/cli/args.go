@@ -18,10 +18,11 @@ import (
 type argsCmd struct {
 	clear bool
 	post  httpPoster
+	get   httpGetter
 }
 
 func newArgsCmd() *argsCmd {
-	return &argsCmd{post: http.Post}
+	return &argsCmd{post: apiPost, get: apiHTTPGet}
 }
 
 func (c *argsCmd) name() model.TiltSubcommand { return "args" }
@@ -30,8 +31,10 @@ func (c *argsCmd) register() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:                   "args [<flags>] [-- <Tiltfile args>]",
 		DisableFlagsInUseLine: true,
-		Short:                 "Changes the Tiltfile args in use by a running Tilt",
-		Long: `Changes the Tiltfile args in use by a running Tilt.
+		Short:                 "Shows or changes the Tiltfile args in use by a running Tilt",
+		Long: `Shows or changes the Tiltfile args in use by a running Tilt.
+
+With no args, prints the current Tiltfile args.
 
 Note that this does not affect built-in Tilt args (e.g. --hud, --host), but rather the extra args that come after,
 i.e., those specifying which resources to run and/or handled by a Tiltfile calling config.parse.
@@ -39,6 +42,10 @@ i.e., those specifying which resources to run and/or handled by a Tiltfile calli
 To provide args starting with --, insert a standalone --, e.g.:
 
 tilt args -- --foo=bar frontend backend
+
+Tilt reloads the Tiltfile with the new args, then creates any newly enabled
+resources and deletes any resources that are no longer enabled. If the Tiltfile
+fails to load with the new args, Tilt keeps using the previous args.
 `,
 	}
 
@@ -49,19 +56,19 @@ tilt args -- --foo=bar frontend backend
 }
 
 type httpPoster func(url string, contentType string, body io.Reader) (*http.Response, error)
+type httpGetter func(url string) (*http.Response, error)
 
 func (c *argsCmd) run(ctx context.Context, args []string) error {
-	// require --clear instead of an empty args list to ensure an experimental `tilt flags` doesn't unintentionally wipe state
-	if len(args) == 0 {
-		if !c.clear {
-			return errors.New("no args specified. If your intent is to empty the args, run `tilt args --clear`.")
-		}
-	} else {
-		if c.clear {
-			return errors.New("--clear cannot be specified with other values. either use --clear to clear the args or specify args to replace the args with a new (non-empty) value")
-		}
+	// require --clear instead of an empty args list to ensure that printing the args doesn't unintentionally wipe state
+	if len(args) == 0 && !c.clear {
+		return c.printArgs()
 	}
-	url := apiURL("set_tiltfile_args")
+	if len(args) > 0 && c.clear {
+		return errors.New("--clear cannot be specified with other values. either use --clear to clear the args or specify args to replace the args with a new (non-empty) value")
+	}
+
+	// Wait for the Tiltfile to reload, so that we can report whether the new args worked.
+	url := apiURL("set_tiltfile_args") + "?wait=true"
 	body := &bytes.Buffer{}
 	err := json.NewEncoder(body).Encode(args)
 	if err != nil {
@@ -77,6 +84,47 @@ func (c *argsCmd) run(ctx context.Context, args []string) error {
 		_ = res.Body.Close()
 	}()
 
+	err = checkArgsResponse(res)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("changed config args for Tilt running at %s to %v\n", apiHost(), args)
+
+	return nil
+}
+
+func (c *argsCmd) printArgs() error {
+	url := apiURL("tiltfile_args")
+	res, err := c.get(url)
+	if err != nil {
+		fmt.Println("tilt args requires a running Tilt instance")
+		return errors.Wrapf(err, "error making http request to Tilt at %s", url)
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	err = checkArgsResponse(res)
+	if err != nil {
+		return err
+	}
+
+	var args []string
+	err = json.NewDecoder(res.Body).Decode(&args)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode args")
+	}
+
+	if len(args) == 0 {
+		fmt.Printf("Tilt running at %s has no config args. To change them, run `tilt args -- <args>`\n", apiHost())
+		return nil
+	}
+	fmt.Printf("config args for Tilt running at %s: %v\n", apiHost(), args)
+	return nil
+}
+
+func checkArgsResponse(res *http.Response) error {
 	if res.StatusCode != http.StatusOK {
 		// don't print the response body for 404 since it's full of html and more noise than it's worth on the command line
 		if res.StatusCode != http.StatusNotFound {
@@ -87,8 +135,5 @@ func (c *argsCmd) run(ctx context.Context, args []string) error {
 		}
 		return fmt.Errorf("http request to Tilt failed: %s", res.Status)
 	}
-
-	fmt.Printf("changed config args for Tilt running at %s to %v\n", apiHost(), args)
-
 	return nil
 }
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -34,19 +35,40 @@ func TestArgsClearAndNewValue(t *testing.T) {
 	require.Contains(t, err.Error(), "--clear cannot be specified with other values")
 }
 
-func TestArgsEmptyNewValueNoClear(t *testing.T) {
+func TestArgsNewValueWaitsForReload(t *testing.T) {
+	f := newArgsFixture()
+	err := f.cmd.run(context.Background(), []string{"frontend"})
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(f.fakeHttpPoster.lastRequestURL, "/api/set_tiltfile_args?wait=true"),
+		"unexpected url: %s", f.fakeHttpPoster.lastRequestURL)
+}
+
+func TestArgsEmptyNewValueNoClearPrintsArgs(t *testing.T) {
 	f := newArgsFixture()
 	err := f.cmd.run(context.Background(), nil)
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(f.fakeHttpPoster.lastGetURL, "/api/tiltfile_args"),
+		"unexpected url: %s", f.fakeHttpPoster.lastGetURL)
+	require.Equal(t, "", f.fakeHttpPoster.lastRequestBody)
+}
+
+func TestArgsRejected(t *testing.T) {
+	f := newArgsFixture()
+	f.fakeHttpPoster.status = http.StatusBadRequest
+	err := f.cmd.run(context.Background(), []string{"nope"})
 	require.Error(t, err)
-	require.Contains(t, err.Error(), "no args specified.")
-	require.Contains(t, err.Error(), "run `tilt args --clear`")
+	require.Contains(t, err.Error(), "400")
 }
 
 type fakeHttpPoster struct {
+	lastRequestURL  string
 	lastRequestBody string
+	lastGetURL      string
+	status          int
 }
 
 var _ httpPoster = (&fakeHttpPoster{}).Post
+var _ httpGetter = (&fakeHttpPoster{}).Get
 
 func (fp *fakeHttpPoster) Post(url string, contentType string, body io.Reader) (*http.Response, error) {
 	b, err := ioutil.ReadAll(body)
@@ -54,11 +76,26 @@ func (fp *fakeHttpPoster) Post(url string, contentType string, body io.Reader) (
 		return nil, err
 	}
 
+	fp.lastRequestURL = url
 	fp.lastRequestBody = string(b)
+	return fp.response("fake http response"), nil
+}
+
+func (fp *fakeHttpPoster) Get(url string) (*http.Response, error) {
+	fp.lastGetURL = url
+	return fp.response(`["--foo","bar"]`), nil
+}
+
+func (fp *fakeHttpPoster) response(body string) *http.Response {
+	status := fp.status
+	if status == 0 {
+		status = http.StatusOK
+	}
 	return &http.Response{
-		StatusCode: http.StatusOK,
-		Body:       ioutil.NopCloser(strings.NewReader("fake http response")),
-	}, nil
+		StatusCode: status,
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
 }
 
 type argsFixture struct {
@@ -68,5 +105,5 @@ type argsFixture struct {
 
 func newArgsFixture() *argsFixture {
 	fp := &fakeHttpPoster{}
-	return &argsFixture{cmd: argsCmd{post: fp.Post}, fakeHttpPoster: fp}
+	return &argsFixture{cmd: argsCmd{post: fp.Post, get: fp.Get}, fakeHttpPoster: fp}
 }
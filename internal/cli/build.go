@@ -70,7 +70,3 @@ func defaultTiltInfo() model.TiltBuild {
 func provideTiltInfo() model.TiltBuild {
 	return tiltInfo()
 }
-
-func provideWebVersion(b model.TiltBuild) model.WebVersion {
-	return b.WebVersion()
-}
@@ -13,10 +13,14 @@ import (
 	"github.com/tilt-dev/tilt/internal/analytics"
 	"github.com/tilt-dev/tilt/internal/hud/prompt"
 	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/tiltengine"
 	"github.com/tilt-dev/tilt/pkg/logger"
 	"github.com/tilt-dev/tilt/pkg/model"
 )
 
+var allowEmptyFlag bool = false
+var ciTimeoutFlag time.Duration
+
 type ciCmd struct {
 	fileName             string
 	outputSnapshotOnExit string
@@ -37,6 +41,18 @@ Exits with failure if any build fails or any server crashes.
 Exits with success if all tasks have completed successfully
 and all servers are healthy.
 
+The exit code says why Tilt failed, so that CI scripts can
+react differently to each kind of failure. These codes are
+stable across releases:
+
+  0  success
+  1  any other error
+  2  resources didn't become ready before --timeout (if set)
+  3  a resource failed to build or deploy
+  4  a server or job failed after it was deployed
+  5  the Tiltfile failed to load, or didn't enable any resources
+  6  Tilt couldn't connect to the cluster or Docker
+
 While Tilt is running, you can view the UI at %s:%d
 (configurable with --host and --port).
 
@@ -48,9 +64,19 @@ See blog post for additional information: https://blog.tilt.dev/2020/04/16/how-t
 	addDevServerFlags(cmd)
 	addTiltfileFlag(cmd, &c.fileName)
 	addKubeContextFlag(cmd)
+	addTiltfileLimitFlags(cmd)
+	addAllowUnsupportedVersionsFlag(cmd)
 
 	cmd.Flags().BoolVar(&logActionsFlag, "logactions", false, "log all actions and state changes")
 	cmd.Flags().Lookup("logactions").Hidden = true
+	cmd.Flags().BoolVar(&journalActionsFlag, "journal-actions", false, "remember the most recent actions for 'tilt dump actions'")
+	cmd.Flags().Lookup("journal-actions").Hidden = true
+	addForceBootstrapFlag(cmd)
+	addMemoryWarningThresholdFlag(cmd)
+	cmd.Flags().BoolVar(&allowEmptyFlag, "allow-empty", false,
+		"Exit successfully if the Tiltfile doesn't enable any resources (by default, this is an error)")
+	cmd.Flags().DurationVar(&ciTimeoutFlag, "timeout", 0,
+		"Fail if resources aren't ready after this long. By default, wait forever")
 	cmd.Flags().StringVar(&c.outputSnapshotOnExit, "output-snapshot-on-exit", "",
 		"If specified, Tilt will dump a snapshot of its state to the specified path when it exits")
 
@@ -67,8 +93,13 @@ func (c *ciCmd) run(ctx context.Context, args []string) error {
 
 	log.SetFlags(log.Flags() &^ (log.Ldate | log.Ltime))
 
+	err := resolveWebPort()
+	if err != nil {
+		return err
+	}
+
 	webHost := provideWebHost()
-	webURL, _ := provideWebURL(webHost, provideWebPort())
+	webURL, _ := tiltengine.ProvideWebURL(webHost, provideWebPort(), provideWebSecurityOptions(), tiltengine.Exclude{})
 	startLine := prompt.StartStatusLine(webURL, webHost)
 	log.Print(startLine)
 	log.Print(buildStamp())
@@ -83,6 +114,12 @@ func (c *ciCmd) run(ctx context.Context, args []string) error {
 		return err
 	}
 
+	err = checkVersions(ctx, cmdCIDeps.Versions)
+	if err != nil {
+		deferred.SetOutput(deferred.Original())
+		return err
+	}
+
 	upper := cmdCIDeps.Upper
 
 	l := store.NewLogActionLogger(ctx, upper.Dispatch)
@@ -94,7 +131,7 @@ func (c *ciCmd) run(ctx context.Context, args []string) error {
 
 	err = upper.Start(ctx, args, cmdCIDeps.TiltBuild,
 		c.fileName, store.TerminalModeStream, a.UserOpt(), cmdCIDeps.Token,
-		string(cmdCIDeps.CloudAddress))
+		string(cmdCIDeps.CloudAddress), false)
 	if err == nil {
 		_, _ = fmt.Fprintln(colorable.NewColorableStdout(),
 			color.GreenString("SUCCESS. All workloads are healthy."))
@@ -9,12 +9,12 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
-	"github.com/tilt-dev/wmclient/pkg/analytics"
 
 	"github.com/tilt-dev/tilt/pkg/model"
 
 	tiltanalytics "github.com/tilt-dev/tilt/internal/analytics"
 	"github.com/tilt-dev/tilt/internal/output"
+	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/pkg/logger"
 )
 
@@ -38,6 +38,13 @@ func Execute() {
 		os.Exit(1)
 	}
 
+	if err := newRootCmd().Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func newRootCmd() *cobra.Command {
 	rootCmd := &cobra.Command{
 		Use:   "tilt",
 		Short: "Multi-service development with no stress",
@@ -59,6 +66,7 @@ up-to-date in real-time. Think 'docker build && kubectl apply' or 'docker-compos
 	addCommand(rootCmd, newDownCmd())
 	addCommand(rootCmd, &versionCmd{})
 	addCommand(rootCmd, &verifyInstallCmd{})
+	addCommand(rootCmd, newVerifyCmd())
 	addCommand(rootCmd, &dockerPruneCmd{})
 	addCommand(rootCmd, newArgsCmd())
 	addCommand(rootCmd, &logsCmd{})
@@ -70,11 +78,21 @@ up-to-date in real-time. Think 'docker build && kubectl apply' or 'docker-compos
 	addCommand(rootCmd, newApplyCmd())
 	addCommand(rootCmd, newCreateCmd())
 	addCommand(rootCmd, newPatchCmd())
+	addCommand(rootCmd, newWaitCmd())
 	addCommand(rootCmd, &demoCmd{})
+	addCommand(rootCmd, &agentCmd{})
 
-	rootCmd.AddCommand(analytics.NewCommand())
+	rootCmd.AddCommand(newAnalyticsCmd())
 	rootCmd.AddCommand(newDumpCmd(rootCmd))
 	rootCmd.AddCommand(newTriggerCmd())
+	rootCmd.AddCommand(newEnableCmd())
+	rootCmd.AddCommand(newDisableCmd())
+	rootCmd.AddCommand(newDiffCmd())
+	rootCmd.AddCommand(newDebugContainerCmd())
+	rootCmd.AddCommand(newUpdateModeCmd())
+	rootCmd.AddCommand(newPortForwardCmd())
+	rootCmd.AddCommand(newGraphCmd())
+	rootCmd.AddCommand(newSnapshotCmd())
 	rootCmd.AddCommand(newAlphaCmd())
 
 	globalFlags := rootCmd.PersistentFlags()
@@ -82,10 +100,7 @@ up-to-date in real-time. Think 'docker build && kubectl apply' or 'docker-compos
 	globalFlags.BoolVarP(&verbose, "verbose", "v", false, "Enable verbose logging")
 	globalFlags.IntVar(&klogLevel, "klog", 0, "Enable Kubernetes API logging. Uses klog v-levels (0-4 are debug logs, 5-9 are tracing logs)")
 
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
+	return rootCmd
 }
 
 type tiltCmd interface {
@@ -135,18 +150,22 @@ func preCommand(ctx context.Context, cmdName model.TiltSubcommand) context.Conte
 func addCommand(parent *cobra.Command, child tiltCmd) {
 	cobraChild := child.register()
 	cobraChild.Run = func(_ *cobra.Command, args []string) {
-		ctx := preCommand(context.Background(), child.name())
-
-		err := child.run(ctx, args)
-		if err != nil {
-			// TODO(maia): this shouldn't print if we've already pretty-printed it
-			_, printErr := fmt.Fprintf(output.OriginalStderr, "Error: %v\n", err)
-			if printErr != nil {
-				panic(printErr)
-			}
-			os.Exit(1)
-		}
+		runTiltCmd(child, args)
 	}
 
 	parent.AddCommand(cobraChild)
 }
+
+func runTiltCmd(child tiltCmd, args []string) {
+	ctx := preCommand(context.Background(), child.name())
+
+	err := child.run(ctx, args)
+	if err != nil {
+		// TODO(maia): this shouldn't print if we've already pretty-printed it
+		_, printErr := fmt.Fprintf(output.OriginalStderr, "Error: %v\n", err)
+		if printErr != nil {
+			panic(printErr)
+		}
+		os.Exit(store.ExitCodeForError(err))
+	}
+}
@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+// Cobra panics on duplicate flags when it builds or parses a command,
+// which would break every tilt invocation.
+func TestRootCmdFlags(t *testing.T) {
+	var root *cobra.Command
+	assert.NotPanics(t, func() {
+		root = newRootCmd()
+	})
+	if root == nil {
+		return
+	}
+
+	var visit func(cmd *cobra.Command)
+	visit = func(cmd *cobra.Command) {
+		assert.NotPanics(t, func() {
+			// Merges in the persistent flags of the parents, like parsing does.
+			_ = cmd.LocalFlags()
+			_ = cmd.InheritedFlags()
+		}, "command %q", cmd.CommandPath())
+		for _, child := range cmd.Commands() {
+			visit(child)
+		}
+	}
+	visit(root)
+}
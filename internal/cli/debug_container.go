@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tilt-dev/tilt/internal/controllers/apis/debugcontainer"
+)
+
+type debugContainerCmd struct {
+	image   string
+	command string
+	port    int
+}
+
+func newDebugContainerCmd() *cobra.Command {
+	c := &debugContainerCmd{}
+	cmd := &cobra.Command{
+		Use:   "debug-container RESOURCE_NAME",
+		Short: "Attach an ephemeral debug container to a resource's pod",
+		Long: `Attach an ephemeral debug container to a resource's current pod.
+
+Like 'kubectl debug', the debug container runs alongside the resource's
+containers and shares the pod's network, so you can inspect the pod with
+tools that aren't in your image. Its logs show up under the resource.
+
+The debug container goes away when the pod does, e.g., on the next deploy.
+Requires a cluster that supports ephemeral containers.
+`,
+		Example: `tilt debug-container frontend
+tilt debug-container frontend --image=nicolaka/netshoot --command="netstat -tlpn"
+tilt debug-container frontend --forward-port=8080`,
+		Args: cobra.ExactArgs(1),
+		Run:  c.run,
+	}
+	cmd.Flags().StringVar(&c.image, "image", "", "Image for the debug container (default: the debug_container_image from update_settings())")
+	cmd.Flags().StringVar(&c.command, "command", "", "A command to run once in the debug container, with its output in the logs")
+	cmd.Flags().IntVar(&c.port, "forward-port", 0, "A port in the pod to forward to the same port on localhost")
+	addConnectServerFlags(cmd)
+	return cmd
+}
+
+func (c *debugContainerCmd) run(cmd *cobra.Command, args []string) {
+	port := ""
+	if c.port != 0 {
+		port = strconv.Itoa(c.port)
+	}
+
+	payload, err := json.Marshal(map[string]string{
+		"manifest_name": args[0],
+		"image":         c.image,
+		"command":       c.command,
+		"port":          port,
+	})
+	if err != nil {
+		cmdFail(err)
+	}
+
+	body := apiPostJson("debug_container", payload)
+	defer func() {
+		_ = body.Close()
+	}()
+
+	var attachment debugcontainer.Attachment
+	err = json.NewDecoder(body).Decode(&attachment)
+	if err != nil {
+		cmdFail(fmt.Errorf("Error decoding debug container: %v", err))
+	}
+
+	fmt.Printf("Attached debug container %s to pod %s\n", attachment.Container, attachment.Pod)
+	fmt.Printf("Shell: kubectl exec -it -n %s %s -c %s -- sh\n", attachment.Namespace, attachment.Pod, attachment.Container)
+}
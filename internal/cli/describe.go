@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/kubectl/pkg/cmd/describe"
@@ -93,6 +94,16 @@ func (c *describeCmd) run(ctx context.Context, args []string) error {
 	f := cmdutil.NewFactory(getter)
 	cmd := c.cmd
 	cmdutil.CheckErr(o.Complete(f, cmd, args))
+
+	describer := o.Describer
+	o.Describer = func(mapping *meta.RESTMapping) (pkgdescribe.ResourceDescriber, error) {
+		d, err := describer(mapping)
+		if err != nil || !hasApplyHistory(mapping) {
+			return d, err
+		}
+		return &applyHistoryDescriber{ResourceDescriber: d, fetch: fetchApplyHistory}, nil
+	}
+
 	cmdutil.CheckErr(o.Run())
 	return nil
 }
@@ -3,11 +3,13 @@ package cli
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/tilt-dev/tilt/internal/k8s"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
 )
 
@@ -33,3 +35,34 @@ func TestDescribe(t *testing.T) {
 
 	assert.Contains(t, out.String(), `Name:         my-sleep`)
 }
+
+func TestFormatApplyHistory(t *testing.T) {
+	start := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	out := formatApplyHistory([]k8s.ApplyInvocation{
+		{
+			Command:    "kubectl --context kind-kind apply -f -",
+			Namespaces: []string{"default", "monitoring"},
+			YAMLHash:   "abc123",
+			YAMLBytes:  512,
+			StartTime:  start,
+			Duration:   1500 * time.Millisecond,
+		},
+		{
+			Command:   "./deploy.sh",
+			StartTime: start.Add(time.Minute),
+			Duration:  time.Second,
+			Error:     "apply command exited with status 1\nstderr:\nboom\n",
+		},
+	})
+
+	assert.Equal(t, `Apply History:
+  2021-06-01T12:00:00Z  1.5s  OK  kubectl --context kind-kind apply -f -
+    Namespaces:  default, monitoring
+    YAML:        512 bytes, sha256 abc123
+  2021-06-01T12:01:00Z  1s  Failed  ./deploy.sh
+    Error:
+      apply command exited with status 1
+      stderr:
+      boom
+`, out)
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tilt-dev/tilt/internal/k8s"
+)
+
+type diffCmd struct {
+	output string
+}
+
+func newDiffCmd() *cobra.Command {
+	c := &diffCmd{}
+	cmd := &cobra.Command{
+		Use:   "diff RESOURCE_NAME",
+		Short: "Preview what applying a resource would change in the cluster",
+		Long: `Preview what applying a resource would change in the cluster.
+
+Renders the resource's Kubernetes objects with the most recently built images,
+and asks the cluster for a server-side dry-run of applying them.
+
+Nothing in the cluster is modified. Secret values are never shown.
+`,
+		Example: `tilt diff frontend
+tilt diff frontend -o json`,
+		Args: cobra.ExactArgs(1),
+		Run:  c.run,
+	}
+	cmd.Flags().StringVarP(&c.output, "output", "o", "", "Output format. One of: (json)")
+	addConnectServerFlags(cmd)
+	return cmd
+}
+
+func (c *diffCmd) run(cmd *cobra.Command, args []string) {
+	if c.output != "" && c.output != "json" {
+		cmdFail(fmt.Errorf("unknown output format %q", c.output))
+	}
+
+	body := apiGet(fmt.Sprintf("diff/%s", url.PathEscape(args[0])))
+	defer func() {
+		_ = body.Close()
+	}()
+
+	var diffs []k8s.ObjectDiff
+	err := json.NewDecoder(body).Decode(&diffs)
+	if err != nil {
+		cmdFail(fmt.Errorf("Error decoding diff: %v", err))
+	}
+
+	err = printDiffs(os.Stdout, diffs, c.output)
+	if err != nil {
+		cmdFail(err)
+	}
+}
+
+func printDiffs(w io.Writer, diffs []k8s.ObjectDiff, output string) error {
+	if output == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(diffs)
+	}
+
+	counts := make(map[k8s.ObjectDiffStatus]int)
+	for _, diff := range diffs {
+		counts[diff.Status]++
+		if diff.Status == k8s.ObjectDiffUnchanged {
+			continue
+		}
+		_, _ = fmt.Fprint(w, diff.Unified)
+	}
+
+	_, err := fmt.Fprintf(w, "%d added, %d changed, %d unchanged\n",
+		counts[k8s.ObjectDiffAdded], counts[k8s.ObjectDiffChanged], counts[k8s.ObjectDiffUnchanged])
+	return err
+}
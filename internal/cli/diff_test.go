@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/internal/k8s"
+)
+
+var testDiffs = []k8s.ObjectDiff{
+	{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Name:       "frontend",
+		Status:     k8s.ObjectDiffChanged,
+		Fields: []k8s.FieldDiff{{
+			Path: "spec.replicas",
+			Type: k8s.FieldChanged,
+			Old:  float64(1),
+			New:  float64(2),
+		}},
+		Unified: "--- live/Deployment//frontend\n+++ merged/Deployment//frontend\n-  replicas: 1\n+  replicas: 2\n",
+	},
+	{
+		APIVersion: "v1",
+		Kind:       "Service",
+		Name:       "frontend",
+		Status:     k8s.ObjectDiffUnchanged,
+	},
+}
+
+func TestPrintDiffsText(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	err := printDiffs(out, testDiffs, "")
+	require.NoError(t, err)
+	assert.Equal(t, testDiffs[0].Unified+"0 added, 1 changed, 1 unchanged\n", out.String())
+}
+
+func TestPrintDiffsJSON(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	err := printDiffs(out, testDiffs, "json")
+	require.NoError(t, err)
+
+	var actual []k8s.ObjectDiff
+	require.NoError(t, json.Unmarshal(out.Bytes(), &actual))
+	assert.Equal(t, testDiffs, actual)
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+)
+
+type disableCmd struct {
+	disable   bool
+	selectors []string
+	cascade   bool
+	force     bool
+}
+
+// The response from /api/disable.
+type disableResult struct {
+	Changed   []string `json:"changed"`
+	Cascaded  []string `json:"cascaded"`
+	Conflicts []struct {
+		Name    string   `json:"name"`
+		Related []string `json:"related"`
+	} `json:"conflicts"`
+	Applied bool `json:"applied"`
+}
+
+func newDisableCmd() *cobra.Command {
+	return (&disableCmd{disable: true}).register()
+}
+
+func newEnableCmd() *cobra.Command {
+	return (&disableCmd{disable: false}).register()
+}
+
+func (c *disableCmd) register() *cobra.Command {
+	verb, related, cascadeHelp := "enable", "disabled resources they depend on", "Also enable any disabled resources they depend on"
+	if c.disable {
+		verb, related, cascadeHelp = "disable", "enabled resources that depend on them", "Also disable any enabled resources that depend on them"
+	}
+
+	cmd := &cobra.Command{
+		Use:   fmt.Sprintf("%s [RESOURCE_NAME...]", verb),
+		Short: fmt.Sprintf("%s resources in a running Tilt", strings.Title(verb)),
+		Long: fmt.Sprintf(`%s resources in a running Tilt, by name or by label.
+
+All the resources change together. If there are %s,
+Tilt lists them and changes nothing. Pass --cascade to %s them too,
+or --force to %s just the resources you asked for.
+`, strings.Title(verb), related, verb, verb),
+		Example: fmt.Sprintf(`tilt %s frontend backend
+tilt %s -l backend --cascade`, verb, verb),
+		Run: c.run,
+	}
+	cmd.Flags().StringArrayVarP(&c.selectors, "selector", "l", nil,
+		"Selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2). May be repeated.")
+	cmd.Flags().BoolVar(&c.cascade, "cascade", false, cascadeHelp)
+	cmd.Flags().BoolVar(&c.force, "force", false, fmt.Sprintf("%s the resources even if there are %s", strings.Title(verb), related))
+	addConnectServerFlags(cmd)
+	return cmd
+}
+
+func (c *disableCmd) run(cmd *cobra.Command, args []string) {
+	if len(args) == 0 && len(c.selectors) == 0 {
+		cmdFail(fmt.Errorf("Specify at least one resource name or --selector"))
+	}
+	if c.cascade && c.force {
+		cmdFail(fmt.Errorf("--cascade and --force can't be used together"))
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"manifest_names": args,
+		"selectors":      c.selectors,
+		"disable":        c.disable,
+		"cascade":        c.cascade,
+		"force":          c.force,
+		"source":         v1alpha1.DisableChangeSourceCLI,
+	})
+	if err != nil {
+		cmdFail(err)
+	}
+
+	body := apiPostJson("disable", payload)
+	defer func() {
+		_ = body.Close()
+	}()
+
+	var result disableResult
+	err = json.NewDecoder(body).Decode(&result)
+	if err != nil {
+		cmdFail(fmt.Errorf("Error decoding response: %v", err))
+	}
+
+	err = printDisableResult(os.Stdout, c.disable, result)
+	if err != nil {
+		cmdFail(err)
+	}
+	if !result.Applied {
+		os.Exit(1)
+	}
+}
+
+func printDisableResult(w io.Writer, disable bool, result disableResult) error {
+	verb, past, related := "enable", "Enabled", "depends on disabled resources"
+	if disable {
+		verb, past, related = "disable", "Disabled", "has enabled dependents"
+	}
+
+	var sb strings.Builder
+	for _, c := range result.Conflicts {
+		sb.WriteString(fmt.Sprintf("Warning: %s %s: %s\n", c.Name, related, strings.Join(c.Related, ", ")))
+	}
+
+	switch {
+	case !result.Applied:
+		sb.WriteString(fmt.Sprintf("Nothing changed. Re-run with --cascade to %s them too, or --force to %s anyway.\n", verb, verb))
+	case len(result.Changed) == 0:
+		sb.WriteString(fmt.Sprintf("Nothing to %s.\n", verb))
+	default:
+		sb.WriteString(fmt.Sprintf("%s: %s\n", past, strings.Join(result.Changed, ", ")))
+		if len(result.Cascaded) > 0 {
+			sb.WriteString(fmt.Sprintf("  (including %s, to keep dependencies consistent)\n", strings.Join(result.Cascaded, ", ")))
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
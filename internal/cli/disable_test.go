@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintDisableResultBlocked(t *testing.T) {
+	var result disableResult
+	err := json.Unmarshal([]byte(`{"conflicts":[{"name":"db","related":["api","fe"]}],"applied":false}`), &result)
+	require.NoError(t, err)
+
+	out := bytes.NewBuffer(nil)
+	require.NoError(t, printDisableResult(out, true, result))
+	assert.Equal(t, `Warning: db has enabled dependents: api, fe
+Nothing changed. Re-run with --cascade to disable them too, or --force to disable anyway.
+`, out.String())
+}
+
+func TestPrintDisableResultCascaded(t *testing.T) {
+	var result disableResult
+	err := json.Unmarshal([]byte(`{"changed":["api","db","fe"],"cascaded":["api","fe"],"conflicts":[{"name":"fe","related":["api","db"]}],"applied":true}`), &result)
+	require.NoError(t, err)
+
+	out := bytes.NewBuffer(nil)
+	require.NoError(t, printDisableResult(out, false, result))
+	assert.Equal(t, `Warning: fe depends on disabled resources: api, db
+Enabled: api, db, fe
+  (including api, fe, to keep dependencies consistent)
+`, out.String())
+}
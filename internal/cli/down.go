@@ -2,25 +2,28 @@ package cli
 
 import (
 	"context"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
+	"github.com/tilt-dev/tilt/internal/agent"
 	"github.com/tilt-dev/tilt/internal/analytics"
 	ctrltiltfile "github.com/tilt-dev/tilt/internal/controllers/apis/tiltfile"
-	"github.com/tilt-dev/tilt/internal/engine"
 	"github.com/tilt-dev/tilt/internal/k8s"
 	"github.com/tilt-dev/tilt/pkg/logger"
 	"github.com/tilt-dev/tilt/pkg/model"
 )
 
 type downCmd struct {
-	fileName         string
-	deleteNamespaces bool
-	downDepsProvider func(ctx context.Context, tiltAnalytics *analytics.TiltAnalytics, subcommand model.TiltSubcommand) (DownDeps, error)
+	fileName             string
+	deleteNamespaces     bool
+	deleteInfrastructure bool
+	downDepsProvider     func(ctx context.Context, tiltAnalytics *analytics.TiltAnalytics, subcommand model.TiltSubcommand) (DownDeps, error)
 }
 
 func newDownCmd() *downCmd {
@@ -41,8 +44,14 @@ Specify additional flags and arguments to control which resources are deleted.
 
 Namespaces are not deleted by default. Use --delete-namespaces to change that.
 
+Infrastructure (objects with the 'infrastructure' apply discipline) is not
+deleted by default. Use --delete-infrastructure to change that.
+
 Kubernetes resources with the annotation 'tilt.dev/down-policy: keep' are not deleted.
 
+If Tilt installed its in-cluster agent (because it couldn't reach pods directly),
+the agent is deleted too.
+
 For more complex cases, the Tiltfile has APIs to add additional flags and arguments to the Tilt CLI.
 These arguments can be scripted to define custom subsets of resources to delete.
 See https://docs.tilt.dev/tiltfile_config.html for examples.
@@ -51,7 +60,9 @@ See https://docs.tilt.dev/tiltfile_config.html for examples.
 
 	addTiltfileFlag(cmd, &c.fileName)
 	addKubeContextFlag(cmd)
+	addTiltfileLimitFlags(cmd)
 	cmd.Flags().BoolVar(&c.deleteNamespaces, "delete-namespaces", false, "delete namespaces defined in the Tiltfile (by default, don't)")
+	cmd.Flags().BoolVar(&c.deleteInfrastructure, "delete-infrastructure", false, "delete infrastructure defined in the Tiltfile (by default, don't)")
 
 	return cmd
 }
@@ -75,11 +86,26 @@ func (c *downCmd) down(ctx context.Context, downDeps DownDeps, args []string) er
 		return err
 	}
 
-	entities, err := engine.ParseYAMLFromManifests(tlr.Manifests...)
+	entities, infrastructure, err := parseYAMLSplittingInfrastructure(tlr.Manifests)
 	if err != nil {
 		return errors.Wrap(err, "Parsing manifest YAML")
 	}
 
+	// If Tilt couldn't reach the pods directly, it may have installed its
+	// in-cluster agent next to them.
+	agentNamespaces := namespacesOf(append(append([]k8s.K8sEntity{}, entities...), infrastructure...), downDeps.namespace)
+	err = agent.Uninstall(ctx, downDeps.kClient, agentNamespaces)
+	if err != nil {
+		logger.Get(ctx).Warnf("Couldn't delete the in-cluster agent: %v", err)
+	}
+
+	if c.deleteInfrastructure {
+		entities = append(entities, infrastructure...)
+	} else if len(infrastructure) > 0 {
+		logger.Get(ctx).Infof("Not deleting infrastructure: %s", strings.Join(k8s.UniqueNames(infrastructure, 2), ", "))
+		logger.Get(ctx).Infof("Run with --delete-infrastructure to delete infrastructure as well.")
+	}
+
 	entities = k8s.ReverseSortedEntities(entities)
 
 	entities, _, err = k8s.Filter(entities, func(e k8s.K8sEntity) (b bool, err error) {
@@ -133,3 +159,57 @@ func (c *downCmd) down(ctx context.Context, downDeps DownDeps, args []string) er
 
 	return nil
 }
+
+// The namespaces that the entities live in, sorted.
+func namespacesOf(entities []k8s.K8sEntity, defaultNamespace k8s.Namespace) []k8s.Namespace {
+	seen := make(map[k8s.Namespace]bool)
+	var result []k8s.Namespace
+	for _, e := range entities {
+		if e.GVK() == (schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Namespace"}) {
+			continue
+		}
+		ns := k8s.Namespace(e.NamespaceOrDefault(defaultNamespace.String()))
+		if !seen[ns] {
+			seen[ns] = true
+			result = append(result, ns)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
+
+// Parses the YAML of all the manifests, separating out the objects
+// in the infrastructure apply discipline.
+//
+// Objects shared between manifests are only returned once.
+func parseYAMLSplittingInfrastructure(manifests []model.Manifest) (entities []k8s.K8sEntity, infrastructure []k8s.K8sEntity, err error) {
+	seen := make(map[v1.ObjectReference]bool)
+	for _, m := range manifests {
+		if !m.IsK8s() {
+			continue
+		}
+		kTarget := m.K8sTarget()
+		parsed, err := k8s.ParseYAMLFromString(kTarget.YAML)
+		if err != nil {
+			return nil, nil, err
+		}
+		if kTarget.Namespace != "" {
+			parsed, _, _ = k8s.MoveToNamespace(parsed, k8s.Namespace(kTarget.Namespace))
+		}
+
+		for _, e := range parsed {
+			ref := e.ToObjectReference()
+			if seen[ref] {
+				continue
+			}
+			seen[ref] = true
+
+			if k8s.IsInfrastructure(e, kTarget.ApplyDiscipline) {
+				infrastructure = append(infrastructure, e)
+			} else {
+				entities = append(entities, e)
+			}
+		}
+	}
+	return entities, infrastructure, nil
+}
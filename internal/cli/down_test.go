@@ -3,18 +3,21 @@ package cli
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/tilt-dev/tilt/internal/agent"
 	"github.com/tilt-dev/tilt/internal/analytics"
 	"github.com/tilt-dev/tilt/internal/dockercompose"
 	"github.com/tilt-dev/tilt/internal/k8s"
 	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
 	"github.com/tilt-dev/tilt/internal/testutils"
 	"github.com/tilt-dev/tilt/internal/tiltfile"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
 	"github.com/tilt-dev/tilt/pkg/model"
 )
 
@@ -28,6 +31,44 @@ func TestDown(t *testing.T) {
 	assert.Contains(t, f.kCli.DeletedYaml, "sancho")
 }
 
+func TestDownDeletesAgent(t *testing.T) {
+	f := newDownFixture(t)
+	defer f.TearDown()
+
+	agent.InjectFakeAgent(f.kCli, "default")
+	f.tfl.Result = tiltfile.TiltfileLoadResult{Manifests: newK8sManifest()}
+	err := f.cmd.down(f.ctx, f.deps, nil)
+	require.NoError(t, err)
+	require.Len(t, f.kCli.DeletedYamls, 2)
+	assert.Contains(t, f.kCli.DeletedYamls[0], "name: tilt-agent\n  namespace: default")
+	assert.Contains(t, f.kCli.DeletedYamls[1], "sancho")
+}
+
+func TestDownWithoutAgent(t *testing.T) {
+	f := newDownFixture(t)
+	defer f.TearDown()
+
+	f.tfl.Result = tiltfile.TiltfileLoadResult{Manifests: newK8sManifest()}
+	err := f.cmd.down(f.ctx, f.deps, nil)
+	require.NoError(t, err)
+	require.Len(t, f.kCli.DeletedYamls, 1)
+	assert.NotContains(t, f.kCli.DeletedYaml, "tilt-agent")
+	assert.Contains(t, f.kCli.DeletedYaml, "sancho")
+}
+
+func TestDownContinuesWhenAgentDeleteFails(t *testing.T) {
+	f := newDownFixture(t)
+	defer f.TearDown()
+
+	agent.InjectFakeAgent(f.kCli, "default")
+	f.kCli.DeleteError = fmt.Errorf("tilt-agent is forbidden")
+	f.tfl.Result = tiltfile.TiltfileLoadResult{Manifests: newK8sManifest()}
+	err := f.cmd.down(f.ctx, f.deps, nil)
+	require.NoError(t, err)
+	require.Len(t, f.kCli.DeletedYamls, 1)
+	assert.Contains(t, f.kCli.DeletedYaml, "sancho")
+}
+
 func TestDownPreservesEntitiesWithKeepLabel(t *testing.T) {
 	f := newDownFixture(t)
 	defer f.TearDown()
@@ -87,6 +128,67 @@ func TestDownDeletesInReverseOrder(t *testing.T) {
 	require.Regexp(t, "(?s)name: sancho.*name: foo", f.kCli.DeletedYaml) // namespace comes after deployment
 }
 
+func TestDownPreservesInfrastructureByDefault(t *testing.T) {
+	f := newDownFixture(t)
+	defer f.TearDown()
+
+	manifests := append([]model.Manifest{}, newK8sManifest()...)
+	manifests = append(manifests, newK8sInfrastructureManifest("crds"), newK8sPriorityClassManifest("high-priority"))
+
+	f.tfl.Result = tiltfile.TiltfileLoadResult{Manifests: manifests}
+	err := f.cmd.down(f.ctx, f.deps, nil)
+	require.NoError(t, err)
+	require.Contains(t, f.kCli.DeletedYaml, "sancho")
+	require.NotContains(t, f.kCli.DeletedYaml, "crds")
+	require.NotContains(t, f.kCli.DeletedYaml, "high-priority")
+}
+
+func TestDownDeletesInfrastructureIfSpecified(t *testing.T) {
+	f := newDownFixture(t)
+	defer f.TearDown()
+
+	manifests := append([]model.Manifest{}, newK8sManifest()...)
+	manifests = append(manifests, newK8sInfrastructureManifest("crds"), newK8sPriorityClassManifest("high-priority"))
+
+	f.tfl.Result = tiltfile.TiltfileLoadResult{Manifests: manifests}
+	f.cmd.deleteInfrastructure = true
+	err := f.cmd.down(f.ctx, f.deps, nil)
+	require.NoError(t, err)
+	for _, name := range []string{"sancho", "crds", "high-priority"} {
+		require.Contains(t, f.kCli.DeletedYaml, name)
+	}
+}
+
+func TestDownDeletesSharedObjectsOnce(t *testing.T) {
+	f := newDownFixture(t)
+	defer f.TearDown()
+
+	manifests := append([]model.Manifest{}, newK8sManifest()...)
+	manifests = append(manifests, newK8sPVCManifest("shared", "delete"), newK8sPVCManifest("shared", "delete"))
+
+	f.tfl.Result = tiltfile.TiltfileLoadResult{Manifests: manifests}
+	err := f.cmd.down(f.ctx, f.deps, nil)
+	require.NoError(t, err)
+	require.Contains(t, f.kCli.DeletedYaml, "sancho")
+	require.Equal(t, 1, strings.Count(f.kCli.DeletedYaml, "name: shared"))
+}
+
+func TestDownDeletesFromOverrideNamespace(t *testing.T) {
+	f := newDownFixture(t)
+	defer f.TearDown()
+
+	kt := k8s.MustTarget("fe", testyaml.SanchoYAML+"---\n"+testyaml.MyNamespaceYAML)
+	kt.Namespace = "alice-dev"
+	manifests := []model.Manifest{model.Manifest{Name: "fe"}.WithDeployTarget(kt)}
+
+	f.tfl.Result = tiltfile.TiltfileLoadResult{Manifests: manifests}
+	f.cmd.deleteNamespaces = true
+	err := f.cmd.down(f.ctx, f.deps, nil)
+	require.NoError(t, err)
+	require.Contains(t, f.kCli.DeletedYaml, "name: sancho\n  namespace: alice-dev")
+	require.Contains(t, f.kCli.DeletedYaml, "name: mynamespace\n")
+}
+
 func TestDownK8sFails(t *testing.T) {
 	f := newDownFixture(t)
 	defer f.TearDown()
@@ -168,6 +270,32 @@ status: {}`, name, downPolicy)
 	return model.Manifest{Name: model.ManifestName(name)}.WithDeployTarget(model.NewK8sTargetForTesting(yaml))
 }
 
+// A resource applied with the infrastructure discipline.
+func newK8sInfrastructureManifest(name string) model.Manifest {
+	yaml := fmt.Sprintf(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: %s
+data: {}`, name)
+	kt := model.NewK8sTargetForTesting(yaml)
+	kt.ApplyDiscipline = v1alpha1.KubernetesApplyDisciplineInfrastructure
+	return model.Manifest{Name: model.ManifestName(name)}.WithDeployTarget(kt)
+}
+
+// An object that opts in to the infrastructure discipline with an annotation.
+func newK8sPriorityClassManifest(name string) model.Manifest {
+	yaml := fmt.Sprintf(`
+apiVersion: scheduling.k8s.io/v1
+kind: PriorityClass
+metadata:
+  name: %s
+  annotations:
+    tilt.dev/apply-discipline: infrastructure
+value: 1000`, name)
+	return model.Manifest{Name: model.ManifestName(name)}.WithDeployTarget(model.NewK8sTargetForTesting(yaml))
+}
+
 type downFixture struct {
 	t      *testing.T
 	ctx    context.Context
@@ -185,7 +313,7 @@ func newDownFixture(t *testing.T) downFixture {
 	tfl := tiltfile.NewFakeTiltfileLoader()
 	dcc := dockercompose.NewFakeDockerComposeClient(t, ctx)
 	kCli := k8s.NewFakeK8sClient(t)
-	downDeps := DownDeps{tfl, dcc, kCli}
+	downDeps := DownDeps{tfl, dcc, kCli, "default"}
 	cmd := &downCmd{downDepsProvider: func(ctx context.Context, tiltAnalytics *analytics.TiltAnalytics, subcommand model.TiltSubcommand) (deps DownDeps, err error) {
 		return downDeps, nil
 	}}
@@ -33,6 +33,8 @@ and may change frequently.
 	result.AddCommand(newDumpWebviewCmd())
 	result.AddCommand(newDumpEngineCmd())
 	result.AddCommand(newDumpLogStoreCmd())
+	result.AddCommand(newDumpActionsCmd())
+	result.AddCommand(newDumpMemoryCmd())
 	result.AddCommand(newDumpCliDocsCmd(rootCmd))
 	result.AddCommand(newDumpImageDeployRefCmd())
 	addCommand(result, newOpenapiCmd())
@@ -98,6 +100,49 @@ and may change frequently.
 	return cmd
 }
 
+func newDumpActionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "actions",
+		Short: "dump the most recent store actions",
+		Long: `Dumps the most recent actions that the Tilt engine reduced to stdout.
+
+Each action shows its type, a summary of its payload, and how long
+the reducer took. Slow reducers are flagged.
+
+Only works if Tilt was started with --journal-actions or --logactions.
+
+The format of the dump state does not make any API or compatibility promises,
+and may change frequently.
+`,
+		Run:  dumpActions,
+		Args: cobra.NoArgs,
+	}
+	addConnectServerFlags(cmd)
+	return cmd
+}
+
+func newDumpMemoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "memory",
+		Short: "dump the engine's memory usage",
+		Long: `Dumps the most recent sample of the Tilt engine's memory usage to stdout.
+
+Includes the Go runtime's memory stats, and estimates of how much memory
+the engine's logs, file watches, pending file changes, and build history
+are using, largest first.
+
+Tilt samples its memory usage once a minute.
+
+The format of the dump state does not make any API or compatibility promises,
+and may change frequently.
+`,
+		Run:  dumpMemory,
+		Args: cobra.NoArgs,
+	}
+	addConnectServerFlags(cmd)
+	return cmd
+}
+
 type dumpCliDocsCmd struct {
 	rootCmd *cobra.Command
 	dir     string
@@ -242,6 +287,30 @@ func dumpLogStore(cmd *cobra.Command, args []string) {
 	}
 }
 
+func dumpActions(cmd *cobra.Command, args []string) {
+	body := apiGet("dump/actions")
+	defer func() {
+		_ = body.Close()
+	}()
+
+	err := dumpJSON(body)
+	if err != nil {
+		cmdFail(fmt.Errorf("dump actions: %v", err))
+	}
+}
+
+func dumpMemory(cmd *cobra.Command, args []string) {
+	body := apiGet("dump/memory")
+	defer func() {
+		_ = body.Close()
+	}()
+
+	err := dumpJSON(body)
+	if err != nil {
+		cmdFail(fmt.Errorf("dump memory: %v", err))
+	}
+}
+
 func dumpJSON(reader io.Reader) error {
 	result, err := decodeJSON(reader)
 	if err != nil {
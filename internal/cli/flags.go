@@ -1,15 +1,19 @@
 package cli
 
 import (
+	"fmt"
+	"log"
 	"os"
 	"strconv"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
+	"github.com/tilt-dev/tilt/internal/hud/server"
 	"github.com/tilt-dev/tilt/internal/k8s"
 	"github.com/tilt-dev/tilt/internal/tiltfile"
 	"github.com/tilt-dev/tilt/pkg/model"
+	"github.com/tilt-dev/tilt/pkg/model/logstore"
 )
 
 var defaultWebHost = "localhost"
@@ -17,7 +21,14 @@ var defaultWebPort = model.DefaultWebPort
 var defaultNamespace = ""
 var webHostFlag = ""
 var webPortFlag = 0
+
+// Whether the user explicitly asked for a web port (with --port or TILT_PORT).
+// If not, we're allowed to move to a different port when the default is busy.
+var webPortPinned = false
 var namespaceOverride = ""
+var webSecurityFlags server.WebSecurityOptions
+var allowUnsupportedVersionsFlag = false
+var tiltfileExecLimits = tiltfile.DefaultExecLimits()
 
 func readEnvDefaults() error {
 	envPort := os.Getenv("TILT_PORT")
@@ -27,6 +38,7 @@ func readEnvDefaults() error {
 			return errors.Wrap(err, "parsing env TILT_PORT")
 		}
 		defaultWebPort = port
+		webPortPinned = true
 	}
 
 	envHost := os.Getenv("TILT_HOST")
@@ -47,6 +59,19 @@ func addKubeContextFlag(cmd *cobra.Command) {
 	cmd.Flags().StringVar(&kubeContextOverride, "context", "", "Kubernetes context override. Equivalent to kubectl --context")
 }
 
+func addAllowUnsupportedVersionsFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&allowUnsupportedVersionsFlag, "allow-unsupported-versions", false,
+		"Start even if the Kubernetes or Docker server is older than the minimum version Tilt supports")
+}
+
+// For commands that load the Tiltfile.
+func addTiltfileLimitFlags(cmd *cobra.Command) {
+	cmd.Flags().DurationVar(&tiltfileExecLimits.Timeout, "tiltfile-timeout", tiltfileExecLimits.Timeout,
+		"Abort the Tiltfile if it runs longer than this. Set to 0 for no limit")
+	cmd.Flags().DurationVar(&tiltfileExecLimits.LocalTimeout, "tiltfile-local-timeout", tiltfileExecLimits.LocalTimeout,
+		"Kill a local() command in the Tiltfile if it runs longer than this, unless the call sets its own timeout. Set to 0 for no limit")
+}
+
 // For commands that talk to the web server.
 func addConnectServerFlags(cmd *cobra.Command) {
 	cmd.Flags().IntVar(&webPortFlag, "port", defaultWebPort, "Port for the Tilt HTTP server. Only necessary if you started Tilt with --port. Overrides TILT_PORT env variable.")
@@ -55,12 +80,59 @@ func addConnectServerFlags(cmd *cobra.Command) {
 
 // For commands that start a web server.
 func addStartServerFlags(cmd *cobra.Command) {
-	cmd.Flags().IntVar(&webPortFlag, "port", defaultWebPort, "Port for the Tilt HTTP server. Set to 0 to disable. Overrides TILT_PORT env variable.")
+	webPortFlag = defaultWebPort
+	cmd.Flags().Var(pinnedWebPortValue{&webPortFlag}, "port", fmt.Sprintf("Port for the Tilt HTTP server. Set to 0 to disable. Overrides TILT_PORT env variable. If not set and the default port is busy, Tilt tries the next %d ports.", server.WebPortAutoIncrementRange))
 	cmd.Flags().StringVar(&webHostFlag, "host", defaultWebHost, "Host for the Tilt HTTP server and default host for any port-forwards. Set to 0.0.0.0 to listen on all interfaces. Overrides TILT_HOST env variable.")
+	cmd.Flags().BoolVar(&webSecurityFlags.TLS, "web-tls", false, "Serve the Tilt HTTP server over HTTPS. Uses a self-signed cert unless --web-tls-cert and --web-tls-key are specified.")
+	cmd.Flags().StringVar(&webSecurityFlags.CertFile, "web-tls-cert", "", "Path to a TLS cert for the Tilt HTTP server. Implies --web-tls.")
+	cmd.Flags().StringVar(&webSecurityFlags.KeyFile, "web-tls-key", "", "Path to a TLS key for the Tilt HTTP server. Implies --web-tls.")
+	cmd.Flags().BoolVar(&webSecurityFlags.Auth, "web-auth", false, "Require an auth token for requests that modify state. The token is printed at startup, and Tilt CLI commands pick it up automatically.")
+}
+
+// An int flag value that records whether the user set it explicitly.
+type pinnedWebPortValue struct {
+	port *int
+}
+
+func (v pinnedWebPortValue) String() string {
+	if v.port == nil {
+		return "0"
+	}
+	return strconv.Itoa(*v.port)
+}
+
+func (v pinnedWebPortValue) Set(s string) error {
+	port, err := strconv.Atoi(s)
+	if err != nil {
+		return err
+	}
+	*v.port = port
+	webPortPinned = true
+	return nil
+}
+
+func (v pinnedWebPortValue) Type() string {
+	return "int"
+}
+
+// If the requested web port is busy and the user didn't pin it,
+// move to the next free port, so that multiple Tilt instances can run side-by-side.
+func resolveWebPort() error {
+	requested := provideWebPort()
+	port, err := server.ChooseWebPort(provideWebHost(), requested, webPortPinned)
+	if err != nil {
+		return err
+	}
+	if port != requested {
+		webPortFlag = int(port)
+		log.Printf("Port %d is in use by another process. Tilt is using port %d instead.\n"+
+			"To connect CLI commands (like tilt logs) to this Tilt, pass --port=%d", requested, port, port)
+	}
+	return nil
 }
 
 func addDevServerFlags(cmd *cobra.Command) {
-	cmd.Flags().IntVar(&webDevPort, "webdev-port", DefaultWebDevPort, "Port for the Tilt Dev Webpack server. Only applies when using --web-mode=local")
+	cmd.Flags().IntVar(&webDevPort, "webdev-port", model.DefaultWebDevPort, "Port for the Tilt Dev Webpack server. Only applies when using --web-mode=local")
 	cmd.Flags().Var(&webModeFlag, "web-mode", "Values: local, prod. Controls whether to use prod assets or a local dev server. (If flag not specified: if Tilt was built from source, it will use a local asset server; otherwise, prod assets.)")
 }
 
@@ -74,6 +146,22 @@ func ProvideKubeContextOverride() k8s.KubeContextOverride {
 	return k8s.KubeContextOverride(kubeContextOverride)
 }
 
+func provideWebSecurityOptions() server.WebSecurityOptions {
+	opts := webSecurityFlags
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		opts.TLS = true
+	}
+	return opts
+}
+
+func ProvideTiltfileExecLimits() tiltfile.ExecLimits {
+	return tiltfileExecLimits
+}
+
+func ProvideLogLevelFilter() logstore.LevelFilter {
+	return logLevelFilterFlag
+}
+
 func ProvideNamespaceOverride() k8s.NamespaceOverride {
 	return k8s.NamespaceOverride(namespaceOverride)
 }
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/testutils"
+)
+
+const testKubeconfig = `
+apiVersion: v1
+kind: Config
+current-context: prod
+clusters:
+- name: gke_acme_prod
+  cluster:
+    server: https://prod.example.com
+- name: kind-dev-a
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: prod
+  context:
+    cluster: gke_acme_prod
+    user: me
+- name: dev-a
+  context:
+    cluster: kind-dev-a
+    user: me
+users:
+- name: me
+  user:
+    token: fake-token
+`
+
+func TestKubeContextFlag(t *testing.T) {
+	setUpTestKubeconfig(t)
+	ctx, _, _ := testutils.CtxAndAnalyticsForTest()
+
+	for _, test := range []struct {
+		name string
+		cmd  *cobra.Command
+	}{
+		{"up", (&upCmd{}).register()},
+		{"ci", (&ciCmd{}).register()},
+		{"down", (&downCmd{}).register()},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() { kubeContextOverride = "" }()
+
+			err := test.cmd.Flags().Parse([]string{"--context=dev-a"})
+			require.NoError(t, err)
+
+			override := ProvideKubeContextOverride()
+			config, err := k8s.ProvideKubeConfig(k8s.ProvideClientConfig(override, ""), override)
+			require.NoError(t, err)
+
+			kubeContext, err := k8s.ProvideKubeContext(config)
+			require.NoError(t, err)
+			assert.Equal(t, k8s.KubeContext("dev-a"), kubeContext)
+			assert.Equal(t, k8s.EnvKIND6, k8s.ProvideEnv(ctx, config))
+		})
+	}
+}
+
+func TestKubeContextFlagUnknownContext(t *testing.T) {
+	setUpTestKubeconfig(t)
+	defer func() { kubeContextOverride = "" }()
+
+	cmd := (&upCmd{}).register()
+	err := cmd.Flags().Parse([]string{"--context=nope"})
+	require.NoError(t, err)
+
+	override := ProvideKubeContextOverride()
+	_, err = k8s.ProvideKubeConfig(k8s.ProvideClientConfig(override, ""), override)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Overriding Kubernetes context")
+		assert.Contains(t, err.Error(), "nope")
+	}
+}
+
+func setUpTestKubeconfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	err := ioutil.WriteFile(path, []byte(testKubeconfig), 0600)
+	require.NoError(t, err)
+	t.Setenv("KUBECONFIG", path)
+}
@@ -82,7 +82,7 @@ func newServerFixture(t *testing.T) *serverFixture {
 
 	cfgAccess := server.ProvideConfigAccess(dir)
 	hudsc := server.ProvideHeadsUpServerController(cfgAccess, model.ProvideAPIServerName(model.WebPort(webPort)),
-		webListener, cfg, &server.HeadsUpServer{}, assets.NewFakeServer(), model.WebURL{})
+		webListener, cfg, &server.HeadsUpServer{}, assets.NewFakeServer(), model.WebURL{}, server.WebSecurity{})
 	st := store.NewTestingStore()
 	require.NoError(t, hudsc.SetUp(ctx, st))
 
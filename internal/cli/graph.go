@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tilt-dev/tilt/internal/store"
+)
+
+type graphCmd struct {
+	output string
+}
+
+func newGraphCmd() *cobra.Command {
+	c := &graphCmd{}
+	cmd := &cobra.Command{
+		Use:   "graph",
+		Short: "Print the dependency graph of resources in a running Tilt",
+		Long: `Print the dependency graph of resources in a running Tilt.
+
+The graph includes resource_deps, the images each resource deploys, and
+the base images those images are built on. Edges point from a resource
+to the things it depends on.
+
+Dependency cycles are misconfigurations, but Tilt reports them rather
+than failing. Cycles are drawn in red.
+
+By default, prints the graph in DOT format, for piping into graphviz.
+`,
+		Example: `tilt graph | dot -Tsvg > graph.svg
+tilt graph -o mermaid`,
+		Args: cobra.NoArgs,
+		Run:  c.run,
+	}
+	cmd.Flags().StringVarP(&c.output, "output", "o", "dot", "Output format. One of: dot, mermaid, json")
+	addConnectServerFlags(cmd)
+	return cmd
+}
+
+func (c *graphCmd) run(cmd *cobra.Command, args []string) {
+	var printGraph func(w io.Writer, g store.DependencyGraph) error
+	switch c.output {
+	case "dot":
+		printGraph = printGraphDot
+	case "mermaid":
+		printGraph = printGraphMermaid
+	case "json":
+		printGraph = printGraphJSON
+	default:
+		cmdFail(fmt.Errorf("unknown output format %q. Valid values: dot, mermaid, json", c.output))
+	}
+
+	body := apiGet("graph")
+	defer func() {
+		_ = body.Close()
+	}()
+
+	var g store.DependencyGraph
+	err := json.NewDecoder(body).Decode(&g)
+	if err != nil {
+		cmdFail(fmt.Errorf("Error decoding dependency graph: %v", err))
+	}
+
+	err = printGraph(os.Stdout, g)
+	if err != nil {
+		cmdFail(err)
+	}
+}
+
+func printGraphJSON(w io.Writer, g store.DependencyGraph) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(g)
+}
+
+func printGraphDot(w io.Writer, g store.DependencyGraph) error {
+	var sb strings.Builder
+	sb.WriteString("digraph tilt {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	for _, cycle := range g.Cycles {
+		sb.WriteString(fmt.Sprintf("  // cycle: %s\n", strings.Join(cycle, " -> ")))
+	}
+	for _, n := range g.Nodes {
+		attrs := []string{fmt.Sprintf("label=%q", graphNodeLabel(n))}
+		if n.Type == store.DependencyNodeImage {
+			attrs = append(attrs, "shape=box")
+		}
+		if n.InCycle {
+			attrs = append(attrs, "color=red")
+		}
+		sb.WriteString(fmt.Sprintf("  %q [%s];\n", n.ID, strings.Join(attrs, ", ")))
+	}
+	for _, e := range g.Edges {
+		attrs := []string{fmt.Sprintf("label=%q", e.Type)}
+		if e.InCycle {
+			attrs = append(attrs, "color=red")
+		}
+		sb.WriteString(fmt.Sprintf("  %q -> %q [%s];\n", e.From, e.To, strings.Join(attrs, ", ")))
+	}
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func printGraphMermaid(w io.Writer, g store.DependencyGraph) error {
+	// Mermaid IDs can't contain most punctuation, so number the nodes.
+	ids := make(map[string]string, len(g.Nodes))
+	for i, n := range g.Nodes {
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("graph LR\n")
+	for _, cycle := range g.Cycles {
+		sb.WriteString(fmt.Sprintf("  %%%% cycle: %s\n", strings.Join(cycle, " -> ")))
+	}
+	for _, n := range g.Nodes {
+		label := strings.ReplaceAll(graphNodeLabel(n), `"`, "#quot;")
+		if n.Type == store.DependencyNodeImage {
+			sb.WriteString(fmt.Sprintf("  %s[\"%s\"]\n", ids[n.ID], label))
+		} else {
+			sb.WriteString(fmt.Sprintf("  %s(\"%s\")\n", ids[n.ID], label))
+		}
+	}
+
+	var cycleEdges []string
+	for i, e := range g.Edges {
+		sb.WriteString(fmt.Sprintf("  %s -->|%s| %s\n", ids[e.From], e.Type, ids[e.To]))
+		if e.InCycle {
+			cycleEdges = append(cycleEdges, fmt.Sprintf("%d", i))
+		}
+	}
+	for _, n := range g.Nodes {
+		if n.InCycle {
+			sb.WriteString(fmt.Sprintf("  style %s stroke:red\n", ids[n.ID]))
+		}
+	}
+	if len(cycleEdges) > 0 {
+		sb.WriteString(fmt.Sprintf("  linkStyle %s stroke:red\n", strings.Join(cycleEdges, ",")))
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func graphNodeLabel(n store.DependencyNode) string {
+	var status []string
+	if n.UpdateStatus != "" {
+		status = append(status, string(n.UpdateStatus))
+	}
+	if n.RuntimeStatus != "" && n.Type == store.DependencyNodeResource {
+		status = append(status, string(n.RuntimeStatus))
+	}
+	if len(status) == 0 {
+		return n.Name
+	}
+	return fmt.Sprintf("%s (%s)", n.Name, strings.Join(status, ", "))
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+)
+
+func testDependencyGraph() store.DependencyGraph {
+	return store.DependencyGraph{
+		Nodes: []store.DependencyNode{
+			{ID: "manifest:fe", Type: store.DependencyNodeResource, Name: "fe", UpdateStatus: v1alpha1.UpdateStatusOK, RuntimeStatus: v1alpha1.RuntimeStatusOK, InCycle: true},
+			{ID: "manifest:be", Type: store.DependencyNodeResource, Name: "be", UpdateStatus: v1alpha1.UpdateStatusPending, InCycle: true},
+			{ID: "image:gcr.io_fe", Type: store.DependencyNodeImage, Name: "gcr.io/fe"},
+		},
+		Edges: []store.DependencyEdge{
+			{From: "manifest:fe", To: "manifest:be", Type: store.DependencyEdgeResource, InCycle: true},
+			{From: "manifest:be", To: "manifest:fe", Type: store.DependencyEdgeResource, InCycle: true},
+			{From: "manifest:fe", To: "image:gcr.io_fe", Type: store.DependencyEdgeImage},
+		},
+		Cycles: [][]string{{"manifest:be", "manifest:fe"}},
+	}
+}
+
+func TestPrintGraphDot(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	require.NoError(t, printGraphDot(out, testDependencyGraph()))
+	assert.Equal(t, `digraph tilt {
+  rankdir=LR;
+  // cycle: manifest:be -> manifest:fe
+  "manifest:fe" [label="fe (ok, ok)", color=red];
+  "manifest:be" [label="be (pending)", color=red];
+  "image:gcr.io_fe" [label="gcr.io/fe", shape=box];
+  "manifest:fe" -> "manifest:be" [label="resource_dep", color=red];
+  "manifest:be" -> "manifest:fe" [label="resource_dep", color=red];
+  "manifest:fe" -> "image:gcr.io_fe" [label="image"];
+}
+`, out.String())
+}
+
+func TestPrintGraphMermaid(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	require.NoError(t, printGraphMermaid(out, testDependencyGraph()))
+	assert.Equal(t, `graph LR
+  %% cycle: manifest:be -> manifest:fe
+  n0("fe (ok, ok)")
+  n1("be (pending)")
+  n2["gcr.io/fe"]
+  n0 -->|resource_dep| n1
+  n1 -->|resource_dep| n0
+  n0 -->|image| n2
+  style n0 stroke:red
+  style n1 stroke:red
+  linkStyle 0,1 stroke:red
+`, out.String())
+}
@@ -9,12 +9,14 @@ import (
 
 	"github.com/tilt-dev/tilt/internal/hud/server"
 	"github.com/tilt-dev/tilt/pkg/model"
+	"github.com/tilt-dev/tilt/pkg/model/logstore"
 
 	"github.com/tilt-dev/tilt/internal/analytics"
 )
 
 type logsCmd struct {
-	follow bool // if true, follow logs (otherwise print current logs and exit)
+	follow bool                 // if true, follow logs (otherwise print current logs and exit)
+	levels logstore.LevelFilter // only print lines at or above these levels
 }
 
 func (c *logsCmd) name() model.TiltSubcommand { return "logs" }
@@ -33,7 +35,8 @@ By default, looks for a running Tilt instance on localhost:10350
 
 	cmd.Flags().BoolVarP(&c.follow, "follow", "f", false, "If true, stream the requested logs; otherwise, print the requested logs at the current moment in time, then exit.")
 
-	// TODO: log level flags
+	cmd.Flags().Var(&c.levels, "level", "Only print logs at or above this level (debug, verbose, info, warn, error). "+
+		"Accepts per-resource overrides, e.g., --level=warn,frontend=debug")
 	addConnectServerFlags(cmd)
 	return cmd
 }
@@ -53,5 +56,5 @@ func (c *logsCmd) run(ctx context.Context, args []string) error {
 		return err
 	}
 
-	return server.StreamLogs(ctx, c.follow, logDeps.url, args, logDeps.printer)
+	return server.StreamLogs(ctx, c.follow, logDeps.url, apiConnInfo(), args, c.levels, logDeps.printer)
 }
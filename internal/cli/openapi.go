@@ -109,7 +109,7 @@ func newHeadlessServer(ctx context.Context) (*headlessServer, error) {
 	}
 	hudsc := server.ProvideHeadsUpServerController(
 		nil, "tilt-headless", webListener, serverOptions,
-		&server.HeadsUpServer{}, assets.NewFakeServer(), model.WebURL{})
+		&server.HeadsUpServer{}, assets.NewFakeServer(), model.WebURL{}, server.WebSecurity{})
 	st := store.NewTestingStore()
 	err = hudsc.SetUp(ctx, st)
 	if err != nil {
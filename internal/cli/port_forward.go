@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+func newPortForwardCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "port-forward",
+		Short: "Turn a resource's port-forwards on and off in a running Tilt",
+	}
+	cmd.AddCommand(newPortForwardToggleCmd("enable", "Enable", true))
+	cmd.AddCommand(newPortForwardToggleCmd("disable", "Disable", false))
+	return cmd
+}
+
+func newPortForwardToggleCmd(verb, title string, enabled bool) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   fmt.Sprintf("%s RESOURCE_NAME LOCAL_PORT", verb),
+		Short: fmt.Sprintf("%s one of a resource's port-forwards, identified by its local port", title),
+		Long: fmt.Sprintf(`%s one of a resource's port-forwards, identified by its local port.
+
+The resource's other port-forwards keep running. A disabled port-forward
+stays off when the pod is replaced, until you enable it again or Tilt exits.
+
+Enabling a port-forward fails if something else is listening on its local port.
+`, title),
+		Example: fmt.Sprintf("tilt port-forward %s frontend 8080", verb),
+		Args:    cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			runPortForwardToggle(args, enabled)
+		},
+	}
+	addConnectServerFlags(cmd)
+	return cmd
+}
+
+func runPortForwardToggle(args []string, enabled bool) {
+	port, err := strconv.ParseInt(args[1], 10, 32)
+	if err != nil || port <= 0 {
+		cmdFail(fmt.Errorf("Invalid local port %q", args[1]))
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"manifest_name": args[0],
+		"local_port":    port,
+		"enabled":       enabled,
+	})
+	if err != nil {
+		cmdFail(err)
+	}
+
+	body := apiPostJson("port_forward", payload)
+	_ = body.Close()
+
+	state := "Disabled"
+	if enabled {
+		state = "Enabled"
+	}
+	fmt.Printf("%s port-forward on local port %d for %s\n", state, port, args[0])
+}
@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tilt-dev/tilt/internal/cloud"
+	"github.com/tilt-dev/tilt/internal/hud/server"
+	"github.com/tilt-dev/tilt/internal/tiltengine"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func newSnapshotCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Save and view snapshots of Tilt's state, without uploading them anywhere",
+		Long: `Save and view snapshots of Tilt's state, without uploading them anywhere.
+
+A snapshot is a single file with the resources and logs that the web UI
+showed when it was saved. Anyone with the file and a Tilt binary can
+open it with 'tilt snapshot view'.
+`,
+	}
+
+	cmd.AddCommand(newSnapshotSaveCmd())
+	addCommand(cmd, &snapshotViewCmd{})
+	return cmd
+}
+
+func newSnapshotSaveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "save [FILE]",
+		Short: "Save a snapshot of a running Tilt to a file",
+		Long: `Save a snapshot of a running Tilt to a file.
+
+If FILE isn't specified, names the file after the snapshot's ID.
+The ID depends only on what's in the snapshot, so saving the same state
+twice gives the same ID.
+`,
+		Example: `tilt snapshot save
+tilt snapshot save my-bug.json`,
+		Args: cobra.MaximumNArgs(1),
+		Run:  runSnapshotSave,
+	}
+	addConnectServerFlags(cmd)
+	return cmd
+}
+
+func runSnapshotSave(cmd *cobra.Command, args []string) {
+	body := apiGet("snapshot/current")
+	defer func() {
+		_ = body.Close()
+	}()
+
+	snapshot, err := cloud.ReadSnapshot(body)
+	if err != nil {
+		cmdFail(err)
+	}
+	id, err := cloud.LocalSnapshotID(snapshot)
+	if err != nil {
+		cmdFail(err)
+	}
+
+	path := fmt.Sprintf("tilt-snapshot-%s.json", id)
+	if len(args) == 1 {
+		path = args[0]
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		cmdFail(fmt.Errorf("Error saving snapshot: %v", err))
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	err = cloud.WriteSnapshotTo(context.Background(), snapshot, f)
+	if err != nil {
+		cmdFail(fmt.Errorf("Error saving snapshot: %v", err))
+	}
+
+	fmt.Printf("Saved snapshot %s to %s\nTo view it: tilt snapshot view %s\n", id, path, path)
+}
+
+// Serves the web UI for a snapshot file, read-only.
+type snapshotViewCmd struct {
+	host string
+	port int
+}
+
+func (c *snapshotViewCmd) name() model.TiltSubcommand { return "snapshot" }
+
+func (c *snapshotViewCmd) register() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "view FILE",
+		Short: "Open a snapshot file in the web UI",
+		Long: `Open a snapshot file in the web UI.
+
+Starts a local web server that shows the snapshot the same way the web UI
+showed the running Tilt. The snapshot is read-only: buttons that would
+change something (like triggering a resource) don't work.
+`,
+		Example: "tilt snapshot view tilt-snapshot-3f2a9c0d1e4b5a67.json",
+		Args:    cobra.ExactArgs(1),
+	}
+	cmd.Flags().IntVar(&c.port, "port", int(defaultWebPort), "Port to serve the snapshot on. If not set and the default port is busy, tries the next few ports.")
+	cmd.Flags().StringVar(&c.host, "host", defaultWebHost, "Host to serve the snapshot on. Set to 0.0.0.0 to listen on all interfaces.")
+	addDevServerFlags(cmd)
+	return cmd
+}
+
+func (c *snapshotViewCmd) run(ctx context.Context, args []string) error {
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	snapshot, err := cloud.ReadSnapshot(f)
+	_ = f.Close()
+	if err != nil {
+		return err
+	}
+
+	webMode, err := tiltengine.ResolveWebMode(webModeFlag, provideTiltInfo())
+	if err != nil {
+		return err
+	}
+	assetServer, err := tiltengine.ProvideAssetServer(webMode, tiltengine.ProvideWebVersion(provideTiltInfo()), model.WebDevPort(webDevPort))
+	if err != nil {
+		return err
+	}
+	defer assetServer.TearDown(ctx)
+
+	s, err := server.NewSnapshotViewServer(snapshot, assetServer)
+	if err != nil {
+		return err
+	}
+
+	host := model.WebHost(c.host)
+	port, err := server.ChooseWebPort(host, model.WebPort(c.port), c.port != int(defaultWebPort))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		err := assetServer.Serve(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Get(ctx).Errorf("Serving web assets: %v", err)
+		}
+	}()
+
+	httpServer := &http.Server{
+		Addr:    net.JoinHostPort(string(host), strconv.Itoa(int(port))),
+		Handler: s,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = httpServer.Shutdown(context.Background())
+	}()
+
+	logger.Get(ctx).Infof("Viewing snapshot %s (read-only) at http://%s%s", s.ID(), httpServer.Addr, s.Path())
+	err = httpServer.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
@@ -15,6 +15,7 @@ import (
 
 	"github.com/tilt-dev/tilt/internal/analytics"
 	ctrltiltfile "github.com/tilt-dev/tilt/internal/controllers/apis/tiltfile"
+	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/internal/tiltfile"
 	"github.com/tilt-dev/tilt/pkg/model"
 )
@@ -22,8 +23,8 @@ import (
 var tupleRE = regexp.MustCompile(`,\)$`)
 
 // arbitrary non-1 value chosen to allow callers to distinguish between
-// Tilt errors and Tiltfile errors
-const TiltfileErrExitCode = 5
+// Tilt errors and Tiltfile errors. Shared with `tilt ci`.
+const TiltfileErrExitCode = store.ExitCodeTiltfile
 
 type tiltfileResultCmd struct {
 	streams genericclioptions.IOStreams
@@ -74,6 +75,7 @@ Run with -v | --verbose to print Tiltfile execution logs on stderr, regardless o
 
 	addTiltfileFlag(cmd, &c.fileName)
 	addKubeContextFlag(cmd)
+	addTiltfileLimitFlags(cmd)
 	cmd.Flags().BoolVarP(&c.builtinTimings, "builtin-timings", "b", false, "If true, print timing data for Tiltfile builtin calls instead of Tiltfile result JSON")
 	cmd.Flags().DurationVar(&c.durThreshold, "dur-threshold", 0, "Only compatible with Builtin Timings mode. Should be a Go duration string. If passed, only print information about builtin calls lasting this duration and longer.")
 
@@ -4,33 +4,45 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"net/url"
 	"os"
 	"strconv"
 	"time"
 
+	"github.com/docker/go-units"
 	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
 	"k8s.io/klog/v2"
 
 	"github.com/tilt-dev/tilt/internal/analytics"
+	"github.com/tilt-dev/tilt/internal/controllers/core/kubernetesapply"
+	ctrltiltfile "github.com/tilt-dev/tilt/internal/controllers/core/tiltfile"
 	engineanalytics "github.com/tilt-dev/tilt/internal/engine/analytics"
+	"github.com/tilt-dev/tilt/internal/engine/idle"
+	"github.com/tilt-dev/tilt/internal/engine/memory"
+	"github.com/tilt-dev/tilt/internal/engine/resourceprefs"
+	"github.com/tilt-dev/tilt/internal/engine/session"
 	"github.com/tilt-dev/tilt/internal/hud/prompt"
 	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/internal/store/liveupdates"
-	"github.com/tilt-dev/tilt/pkg/assets"
+	"github.com/tilt-dev/tilt/internal/tiltengine"
 	"github.com/tilt-dev/tilt/pkg/logger"
 	"github.com/tilt-dev/tilt/pkg/model"
-	"github.com/tilt-dev/tilt/web"
+	"github.com/tilt-dev/tilt/pkg/model/logstore"
 )
 
 var webModeFlag model.WebMode = model.DefaultWebMode
-
-const DefaultWebDevPort = 46764
+var logLevelFilterFlag logstore.LevelFilter
 
 var updateModeFlag string = string(liveupdates.UpdateModeAuto)
 var webDevPort = 0
 var logActionsFlag bool = false
+var journalActionsFlag bool = false
+var verboseApplyFlag bool = false
+var freshFlag bool = false
+var forceBootstrapFlag bool = false
+var idleTimeoutFlag time.Duration = 0
+
+var memoryWarningThresholdFlag = int64(memory.DefaultWarningThreshold)
 
 type upCmd struct {
 	fileName             string
@@ -75,12 +87,28 @@ local resources--i.e. those using serve_cmd--are terminated when you exit Tilt.
 	cmd.Flags().BoolVar(&c.hud, "hud", true, "If true, tilt will open in HUD mode.")
 	cmd.Flags().BoolVar(&c.legacy, "legacy", false, "If true, tilt will open in legacy terminal mode.")
 	cmd.Flags().BoolVar(&c.stream, "stream", false, "If true, tilt will stream logs in the terminal.")
+	cmd.Flags().Var(&logLevelFilterFlag, "log-level", "Only stream logs at or above this level to the terminal (debug, verbose, info, warn, error). "+
+		"Accepts per-resource overrides, e.g., --log-level=warn,frontend=debug")
 	cmd.Flags().BoolVar(&logActionsFlag, "logactions", false, "log all actions and state changes")
+	cmd.Flags().BoolVar(&journalActionsFlag, "journal-actions", false,
+		"Remember the most recent actions and how long they took to reduce, for 'tilt dump actions'. Implied by --logactions.")
+	cmd.Flags().BoolVar(&webSecurityFlags.ReadOnly, "read-only", false,
+		"Watch Tilt without changing it. Rejects triggers, disables, and other changes from the web UI and CLI, but still reloads on file changes.")
+	cmd.Flags().BoolVar(&verboseApplyFlag, "verbose-apply", false,
+		"Write the YAML of every Kubernetes apply to a temp file, and log its path. Useful for replaying an apply by hand.")
+	cmd.Flags().BoolVar(&freshFlag, "fresh", false,
+		"Ignore the resource choices saved from previous runs of this Tiltfile (like disabled resources and trigger mode overrides), and start from the Tiltfile defaults.")
+	addForceBootstrapFlag(cmd)
+	addMemoryWarningThresholdFlag(cmd)
+	cmd.Flags().DurationVar(&idleTimeoutFlag, "idle-timeout", 0,
+		"If set, Tilt goes to sleep after this long without file changes, builds, or UI activity (e.g., 2h). While asleep, Tilt stops streaming logs and watching events. It wakes up on the next file change, web UI request, or CLI command.")
 	addStartServerFlags(cmd)
 	addDevServerFlags(cmd)
 	addTiltfileFlag(cmd, &c.fileName)
 	addKubeContextFlag(cmd)
+	addTiltfileLimitFlags(cmd)
 	addNamespaceFlag(cmd)
+	addAllowUnsupportedVersionsFlag(cmd)
 	cmd.Flags().Lookup("logactions").Hidden = true
 	cmd.Flags().StringVar(&c.outputSnapshotOnExit, "output-snapshot-on-exit", "", "If specified, Tilt will dump a snapshot of its state to the specified path when it exits")
 
@@ -130,8 +158,13 @@ func (c *upCmd) run(ctx context.Context, args []string) error {
 
 	log.SetFlags(log.Flags() &^ (log.Ldate | log.Ltime))
 
+	err := resolveWebPort()
+	if err != nil {
+		return err
+	}
+
 	webHost := provideWebHost()
-	webURL, _ := provideWebURL(webHost, provideWebPort())
+	webURL, _ := tiltengine.ProvideWebURL(webHost, provideWebPort(), provideWebSecurityOptions(), tiltengine.Exclude{})
 	startLine := prompt.StartStatusLine(webURL, webHost)
 	log.Print(startLine)
 	log.Print(buildStamp())
@@ -146,6 +179,12 @@ func (c *upCmd) run(ctx context.Context, args []string) error {
 		return err
 	}
 
+	err = checkVersions(ctx, cmdUpDeps.Versions)
+	if err != nil {
+		deferred.SetOutput(deferred.Original())
+		return err
+	}
+
 	upper := cmdUpDeps.Upper
 	if termMode == store.TerminalModePrompt {
 		// Any logs that showed up during initialization, make sure they're
@@ -164,7 +203,8 @@ func (c *upCmd) run(ctx context.Context, args []string) error {
 	defer cancel()
 
 	err = upper.Start(ctx, args, cmdUpDeps.TiltBuild,
-		c.fileName, termMode, a.UserOpt(), cmdUpDeps.Token, string(cmdUpDeps.CloudAddress))
+		c.fileName, termMode, a.UserOpt(), cmdUpDeps.Token, string(cmdUpDeps.CloudAddress),
+		webSecurityFlags.ReadOnly)
 	if err != context.Canceled {
 		return err
 	} else {
@@ -179,76 +219,75 @@ func redirectLogs(ctx context.Context, l logger.Logger) context.Context {
 	return ctx
 }
 
-func provideUpdateModeFlag() liveupdates.UpdateModeFlag {
-	return liveupdates.UpdateModeFlag(updateModeFlag)
+// The engine options, from the command-line flags.
+func provideEngineOptions() tiltengine.Options {
+	return tiltengine.Options{
+		TiltBuild: provideTiltInfo(),
+
+		WebHost:     provideWebHost(),
+		WebPort:     provideWebPort(),
+		WebMode:     webModeFlag,
+		WebDevPort:  model.WebDevPort(webDevPort),
+		WebSecurity: provideWebSecurityOptions(),
+
+		KubeContextOverride: ProvideKubeContextOverride(),
+		NamespaceOverride:   ProvideNamespaceOverride(),
+
+		TiltfileExecLimits:     ProvideTiltfileExecLimits(),
+		LogLevelFilter:         ProvideLogLevelFilter(),
+		UpdateMode:             liveupdates.UpdateModeFlag(updateModeFlag),
+		LogActions:             store.LogActionsFlag(logActionsFlag),
+		ActionJournal:          store.ActionJournalFlag(journalActionsFlag || logActionsFlag),
+		AllowEmpty:             session.AllowEmptyFlag(allowEmptyFlag),
+		CITimeout:              session.CITimeoutFlag(ciTimeoutFlag),
+		VerboseApply:           kubernetesapply.VerboseApplyFlag(verboseApplyFlag),
+		Fresh:                  resourceprefs.FreshFlag(freshFlag),
+		ForceBootstrap:         ctrltiltfile.ForceBootstrapFlag(forceBootstrapFlag),
+		IdleTimeout:            idle.Timeout(idleTimeoutFlag),
+		MemoryWarningThreshold: memory.WarningThreshold(memoryWarningThresholdFlag),
+	}
 }
 
-func provideLogActions() store.LogActionsFlag {
-	return store.LogActionsFlag(logActionsFlag)
+func addForceBootstrapFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&forceBootstrapFlag, "force-bootstrap", false,
+		"Run the Tiltfile's bootstrap tasks, even if they already ran on this cluster.")
 }
 
-func provideWebMode(b model.TiltBuild) (model.WebMode, error) {
-	switch webModeFlag {
-	case model.LocalWebMode, model.ProdWebMode, model.PrecompiledWebMode:
-		return webModeFlag, nil
-	case model.DefaultWebMode:
-		// Set prod web mode from an environment variable. Useful for
-		// running integration tests against dev tilt.
-		webMode := os.Getenv("TILT_WEB_MODE")
-		if webMode == "prod" {
-			return model.ProdWebMode, nil
-		}
-
-		if b.Dev {
-			return model.LocalWebMode, nil
-		} else {
-			return model.ProdWebMode, nil
-		}
-	}
-	return "", model.UnrecognizedWebModeError(string(webModeFlag))
+func addMemoryWarningThresholdFlag(cmd *cobra.Command) {
+	memoryWarningThresholdFlag = int64(memory.DefaultWarningThreshold)
+	cmd.Flags().Var(byteSizeValue{&memoryWarningThresholdFlag}, "memory-warning-threshold",
+		"Warn once if Tilt uses more than this much memory (e.g., 4GiB), naming what's using it. Set to 0 to disable.")
 }
 
-func provideWebHost() model.WebHost {
-	return model.WebHost(webHostFlag)
+// A flag value for a number of bytes, like 512MiB or 2GB.
+type byteSizeValue struct {
+	bytes *int64
 }
 
-func provideWebPort() model.WebPort {
-	return model.WebPort(webPortFlag)
+func (v byteSizeValue) String() string {
+	if v.bytes == nil {
+		return "0"
+	}
+	return units.BytesSize(float64(*v.bytes))
 }
 
-func provideWebURL(webHost model.WebHost, webPort model.WebPort) (model.WebURL, error) {
-	if webPort == 0 {
-		return model.WebURL{}, nil
+func (v byteSizeValue) Set(s string) error {
+	bytes, err := units.RAMInBytes(s)
+	if err != nil {
+		return err
 	}
+	*v.bytes = bytes
+	return nil
+}
 
-	if webHost == "0.0.0.0" {
-		// 0.0.0.0 means "listen on all hosts"
-		// For UI displays, we use 127.0.0.1 (loopback)
-		webHost = "127.0.0.1"
-	}
+func (v byteSizeValue) Type() string {
+	return "bytes"
+}
 
-	u, err := url.Parse(fmt.Sprintf("http://%s:%d/", webHost, webPort))
-	if err != nil {
-		return model.WebURL{}, err
-	}
-	return model.WebURL(*u), nil
+func provideWebHost() model.WebHost {
+	return model.WebHost(webHostFlag)
 }
 
-func provideAssetServer(mode model.WebMode, version model.WebVersion) (assets.Server, error) {
-	if mode == model.ProdWebMode {
-		return assets.NewProdServer(assets.ProdAssetBucket, version)
-	}
-	if mode == model.PrecompiledWebMode || mode == model.LocalWebMode {
-		path, err := web.StaticPath()
-		if err != nil {
-			return nil, err
-		}
-		pkgDir := assets.PackageDir(path)
-		if mode == model.PrecompiledWebMode {
-			return assets.NewPrecompiledServer(pkgDir), nil
-		} else {
-			return assets.NewDevServer(pkgDir, model.WebDevPort(webDevPort))
-		}
-	}
-	return nil, model.UnrecognizedWebModeError(string(mode))
+func provideWebPort() model.WebPort {
+	return model.WebPort(webPortFlag)
 }
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/tilt-dev/tilt/internal/store"
+)
+
+func newUpdateModeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "update-mode [MODE]",
+		Short: "Show or switch the update mode of a running Tilt",
+		Long: `Show or switch the update mode of a running Tilt.
+
+With no arguments, prints the update mode Tilt is using, and the inputs
+Tilt used to choose it (the --update-mode flag and the cluster).
+
+With a mode, switches to that mode. The new mode takes effect on the
+next build. Tilt rejects modes that don't work with the current cluster.
+`,
+		Example: `tilt update-mode
+tilt update-mode image`,
+		Args: cobra.MaximumNArgs(1),
+		Run:  runUpdateMode,
+	}
+	addConnectServerFlags(cmd)
+	return cmd
+}
+
+func runUpdateMode(cmd *cobra.Command, args []string) {
+	if len(args) == 1 {
+		payload, err := json.Marshal(map[string]string{"mode": args[0]})
+		if err != nil {
+			cmdFail(err)
+		}
+		body := apiPostJson("update_mode", payload)
+		_ = body.Close()
+
+		fmt.Printf("Switched to update mode %q. Takes effect on the next build.\n", args[0])
+		return
+	}
+
+	body := apiGet("update_mode")
+	defer func() {
+		_ = body.Close()
+	}()
+
+	var state store.UpdateModeState
+	err := json.NewDecoder(body).Decode(&state)
+	if err != nil {
+		cmdFail(fmt.Errorf("Error decoding update mode: %v", err))
+	}
+
+	err = printUpdateMode(os.Stdout, state)
+	if err != nil {
+		cmdFail(err)
+	}
+}
+
+func printUpdateMode(w io.Writer, state store.UpdateModeState) error {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Update mode: %s\n", state.Mode))
+	sb.WriteString(fmt.Sprintf("  --update-mode flag: %s\n", state.Flag))
+	sb.WriteString(fmt.Sprintf("  Kubernetes context: %s\n", state.KubeContext))
+	if state.DockerHost != "" {
+		sb.WriteString(fmt.Sprintf("  Docker host: %s\n", state.DockerHost))
+	}
+	sb.WriteString(fmt.Sprintf("  Builds directly to cluster: %t\n", state.WillBuildToKubeContext))
+	sb.WriteString(fmt.Sprintf("Compatible modes: %s\n", strings.Join(state.CompatibleModes, ", ")))
+
+	var incompatible []string
+	for mode := range state.IncompatibleModes {
+		incompatible = append(incompatible, mode)
+	}
+	sort.Strings(incompatible)
+	for _, mode := range incompatible {
+		sb.WriteString(fmt.Sprintf("Unavailable: %s (%s)\n", mode, state.IncompatibleModes[mode]))
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/internal/store"
+)
+
+func TestPrintUpdateMode(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	err := printUpdateMode(out, store.UpdateModeState{
+		Mode:            "image",
+		Flag:            "auto",
+		KubeContext:     "gke-cluster",
+		CompatibleModes: []string{"auto", "image", "exec"},
+		IncompatibleModes: map[string]string{
+			"container": "only valid with local Docker clusters like Docker For Mac or Minikube",
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `Update mode: image
+  --update-mode flag: auto
+  Kubernetes context: gke-cluster
+  Builds directly to cluster: false
+Compatible modes: auto, image, exec
+Unavailable: container (only valid with local Docker clusters like Docker For Mac or Minikube)
+`, out.String())
+}
@@ -8,20 +8,49 @@ import (
 	"net/http"
 	"os"
 	"strings"
+
+	"github.com/tilt-dev/tilt/internal/hud/server"
+	"github.com/tilt-dev/tilt/internal/xdg"
+	"github.com/tilt-dev/tilt/pkg/model"
 )
 
 func apiHost() string {
 	return fmt.Sprintf("%s:%d", provideWebHost(), provideWebPort())
 }
 
+// Reads the scheme and auth token of the Tilt web server we're connecting to.
+func apiConnInfo() server.WebConnInfo {
+	info, err := server.ReadWebConnInfo(xdg.NewTiltDevBase(), model.ProvideAPIServerName(provideWebPort()))
+	if err != nil {
+		cmdFail(fmt.Errorf("Could not read Tilt web server connection info: %v", err))
+	}
+	return info
+}
+
 func apiURL(path string) string {
 	path = strings.TrimLeft(path, "/")
-	return fmt.Sprintf("http://%s:%d/api/%s", provideWebHost(), provideWebPort(), path)
+	return fmt.Sprintf("%s://%s:%d/api/%s", apiConnInfo().Scheme, provideWebHost(), provideWebPort(), path)
+}
+
+func apiClient() *http.Client {
+	client, err := apiConnInfo().HTTPClient()
+	if err != nil {
+		cmdFail(fmt.Errorf("Could not create Tilt web server client: %v", err))
+	}
+	return client
+}
+
+func apiPost(url string, contentType string, body io.Reader) (*http.Response, error) {
+	return apiClient().Post(url, contentType, body)
+}
+
+func apiHTTPGet(url string) (*http.Response, error) {
+	return apiClient().Get(url)
 }
 
 func apiGet(path string) (body io.ReadCloser) {
 	url := apiURL(path)
-	res, err := http.Get(url)
+	res, err := apiClient().Get(url)
 	if err != nil {
 		cmdFail(fmt.Errorf("Could not connect to Tilt at %s: %v", url, err))
 	}
@@ -34,7 +63,7 @@ func apiGet(path string) (body io.ReadCloser) {
 
 func apiPostJson(path string, payload []byte) (body io.ReadCloser) {
 	url := apiURL(path)
-	res, err := http.Post(url, "application/json", bytes.NewBuffer(payload))
+	res, err := apiPost(url, "application/json", bytes.NewBuffer(payload))
 	if err != nil {
 		cmdFail(fmt.Errorf("Could not connect to Tilt at %s: %v", url, err))
 	}
@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/tilt-dev/tilt/internal/analytics"
+	"github.com/tilt-dev/tilt/internal/container"
+	ctrltiltfile "github.com/tilt-dev/tilt/internal/controllers/apis/tiltfile"
+	"github.com/tilt-dev/tilt/internal/docker"
+	"github.com/tilt-dev/tilt/internal/dockercompose"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/tiltfile"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+type verifyCmd struct {
+	streams genericclioptions.IOStreams
+	exit    func(code int)
+
+	fileName string
+}
+
+var _ tiltCmd = &verifyCmd{}
+
+type cmdVerifyDeps struct {
+	tfl tiltfile.TiltfileLoader
+}
+
+func newVerifyDeps(tfl tiltfile.TiltfileLoader) cmdVerifyDeps {
+	return cmdVerifyDeps{
+		tfl: tfl,
+	}
+}
+
+func newVerifyCmd() *verifyCmd {
+	return &verifyCmd{
+		streams: genericclioptions.IOStreams{Out: os.Stdout, ErrOut: os.Stderr, In: os.Stdin},
+		exit:    os.Exit,
+	}
+}
+
+func (c *verifyCmd) name() model.TiltSubcommand { return "verify" }
+
+func (c *verifyCmd) register() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify [<tiltfile args>]",
+		Short: "Load the Tiltfile and validate it, without deploying anything",
+		Long: `Load the Tiltfile and validate it, without deploying anything.
+
+Never contacts a Kubernetes cluster or a Docker daemon, so it's safe to run as a fast CI check.
+
+Checks that:
+- the Tiltfile executes successfully
+- Kubernetes YAML parses into well-formed objects
+- image refs and Dockerfiles parse
+- live_update sync paths exist on disk
+- port forwards don't conflict with each other
+- resource_deps reference existing resources
+
+Prints any errors and warnings.
+
+Exit code 0: no errors (there may be warnings)
+Exit code 1: some failure in setup
+Exit code 5: the Tiltfile has errors
+`,
+	}
+
+	addTiltfileFlag(cmd, &c.fileName)
+	addKubeContextFlag(cmd)
+	addTiltfileLimitFlags(cmd)
+	return cmd
+}
+
+func (c *verifyCmd) run(ctx context.Context, args []string) error {
+	// Tiltfile logs are noisy here, so only print them on error or in verbose mode.
+	logLvl := logger.Get(ctx).Level()
+	showTiltfileLogs := logLvl.ShouldDisplay(logger.VerboseLvl)
+	if showTiltfileLogs {
+		ctx = logger.WithLogger(ctx, logger.NewLogger(logLvl, c.streams.ErrOut))
+	} else {
+		ctx = logger.WithLogger(ctx, logger.NewDeferredLogger(ctx))
+	}
+
+	deps, err := wireTiltfileVerify(ctx, analytics.Get(ctx), "verify")
+	if err != nil {
+		return errors.Wrap(err, "wiring dependencies")
+	}
+
+	tlr := deps.tfl.Load(ctx, ctrltiltfile.MainTiltfile(c.fileName, args))
+	problems := tiltfile.Verify(tlr)
+	hasErrors := tiltfile.HasVerifyErrors(problems)
+	if hasErrors && !showTiltfileLogs {
+		l, ok := logger.Get(ctx).(*logger.DeferredLogger)
+		if ok {
+			l.SetOutput(logger.NewLogger(l.Level(), c.streams.ErrOut))
+		}
+	}
+
+	for _, p := range problems {
+		fmt.Fprintln(c.streams.Out, p.String())
+	}
+
+	if hasErrors {
+		fmt.Fprintln(c.streams.Out, "Tiltfile verification failed")
+		c.exit(TiltfileErrExitCode)
+		return nil
+	}
+
+	fmt.Fprintf(c.streams.Out, "Tiltfile OK (%d resources, %d warnings)\n", len(tlr.Manifests), len(problems))
+	return nil
+}
+
+// `tilt verify` must never contact a cluster.
+func provideVerifyK8sClient() k8s.Client {
+	return k8s.NewExplodingClient(fmt.Errorf("tilt verify does not connect to a cluster"))
+}
+
+// A Docker Compose client that can parse projects, but
+// refuses to do anything that talks to a Docker daemon.
+type verifyDCClient struct {
+	dockercompose.DockerComposeClient
+}
+
+func provideVerifyDockerComposeClient() dockercompose.DockerComposeClient {
+	return verifyDCClient{DockerComposeClient: dockercompose.NewDockerComposeClient(docker.LocalEnv{})}
+}
+
+var errVerifyDockerCompose = fmt.Errorf("tilt verify does not connect to Docker")
+
+func (c verifyDCClient) Up(ctx context.Context, spec model.DockerComposeUpSpec, shouldBuild bool, stdout, stderr io.Writer) error {
+	return errVerifyDockerCompose
+}
+
+func (c verifyDCClient) Down(ctx context.Context, spec model.DockerComposeProject, stdout, stderr io.Writer) error {
+	return errVerifyDockerCompose
+}
+
+func (c verifyDCClient) StreamLogs(ctx context.Context, spec model.DockerComposeUpSpec) io.ReadCloser {
+	r, w := io.Pipe()
+	_ = w.CloseWithError(errVerifyDockerCompose)
+	return r
+}
+
+func (c verifyDCClient) StreamEvents(ctx context.Context, spec model.DockerComposeProject) (<-chan string, error) {
+	return nil, errVerifyDockerCompose
+}
+
+func (c verifyDCClient) ContainerID(ctx context.Context, spec model.DockerComposeUpSpec) (container.ID, error) {
+	return "", errVerifyDockerCompose
+}
+
+func (c verifyDCClient) Version(ctx context.Context) (string, string, error) {
+	return "", "", errVerifyDockerCompose
+}
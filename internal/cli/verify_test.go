@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
+	"github.com/tilt-dev/tilt/internal/testutils"
+	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
+)
+
+func TestVerifyOK(t *testing.T) {
+	f := newVerifyFixture(t)
+
+	f.WriteFile("Tiltfile", `
+local_resource(name='hi', cmd='echo hi')
+local_resource(name='bye', cmd='echo bye', resource_deps=['hi'])
+`)
+
+	f.run()
+	f.assertExitCode(0)
+	assert.Contains(t, f.out.String(), "Tiltfile OK (2 resources, 0 warnings)")
+}
+
+func TestVerifyTiltfileError(t *testing.T) {
+	f := newVerifyFixture(t)
+
+	f.WriteFile("Tiltfile", `
+local_resource(name='hi', cmd='echo hi')
+fail('oh no')
+`)
+
+	f.run()
+	f.assertExitCode(TiltfileErrExitCode)
+	assert.Contains(t, f.out.String(), "Tiltfile:3:5: error: ")
+	assert.Contains(t, f.out.String(), "oh no")
+}
+
+func TestVerifyUnknownResourceDeps(t *testing.T) {
+	f := newVerifyFixture(t)
+
+	f.WriteFile("Tiltfile", `
+local_resource(name='hi', cmd='echo hi', resource_deps=['nope'])
+`)
+
+	f.run()
+	f.assertExitCode(TiltfileErrExitCode)
+	assert.Contains(t, f.out.String(), "nope")
+}
+
+func TestVerifyMalformedYAML(t *testing.T) {
+	f := newVerifyFixture(t)
+
+	f.WriteFile("foo.yaml", `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: [oops
+`)
+	f.WriteFile("Tiltfile", `
+k8s_yaml('foo.yaml')
+`)
+
+	f.run()
+	f.assertExitCode(TiltfileErrExitCode)
+	assert.Contains(t, f.out.String(), "error: ")
+}
+
+func TestVerifyMissingSyncPath(t *testing.T) {
+	f := newVerifyFixture(t)
+
+	f.WriteFile("Dockerfile", "FROM alpine\nCOPY . /app\n")
+	f.WriteFile("deployment.yaml", testyaml.SanchoYAML)
+	f.WriteFile("Tiltfile", `
+k8s_yaml('deployment.yaml')
+docker_build('gcr.io/some-project-162817/sancho', '.',
+  live_update=[sync('./does-not-exist', '/app/')])
+`)
+
+	f.run()
+	f.assertExitCode(TiltfileErrExitCode)
+	assert.Contains(t, f.out.String(), "live_update sync path does not exist")
+	assert.Contains(t, f.out.String(), f.JoinPath("does-not-exist"))
+}
+
+func TestVerifyMissingRunTriggerIsWarning(t *testing.T) {
+	f := newVerifyFixture(t)
+
+	f.WriteFile("Dockerfile", "FROM alpine\nCOPY . /app\n")
+	f.WriteFile("deployment.yaml", testyaml.SanchoYAML)
+	f.WriteFile("Tiltfile", `
+k8s_yaml('deployment.yaml')
+docker_build('gcr.io/some-project-162817/sancho', '.',
+  live_update=[sync('.', '/app/'), run('make', trigger=['./does-not-exist'])])
+`)
+
+	f.run()
+	f.assertExitCode(0)
+	assert.Contains(t, f.out.String(), "warning: [sancho] image gcr.io/some-project-162817/sancho: live_update run trigger path does not exist")
+	assert.Contains(t, f.out.String(), "Tiltfile OK (1 resources, 1 warnings)")
+}
+
+func TestVerifyInvalidDockerfile(t *testing.T) {
+	f := newVerifyFixture(t)
+
+	f.WriteFile("Dockerfile", "FROM alpine\nFOO bar\n")
+	f.WriteFile("deployment.yaml", testyaml.SanchoYAML)
+	f.WriteFile("Tiltfile", `
+k8s_yaml('deployment.yaml')
+docker_build('gcr.io/some-project-162817/sancho', '.')
+`)
+
+	f.run()
+	f.assertExitCode(TiltfileErrExitCode)
+	assert.Contains(t, f.out.String(),
+		f.JoinPath("Dockerfile")+":2: error: [sancho] image gcr.io/some-project-162817/sancho: invalid Dockerfile")
+}
+
+func TestVerifyInvalidInlineDockerfile(t *testing.T) {
+	f := newVerifyFixture(t)
+
+	f.WriteFile("deployment.yaml", testyaml.SanchoYAML)
+	f.WriteFile("Tiltfile", `
+k8s_yaml('deployment.yaml')
+docker_build('gcr.io/some-project-162817/sancho', '.', dockerfile_contents='FROM alpine\nFOO bar\n')
+`)
+
+	f.run()
+	f.assertExitCode(TiltfileErrExitCode)
+
+	// Inline contents have no file to point to.
+	assert.Contains(t, f.out.String(), "error: [sancho] image gcr.io/some-project-162817/sancho: invalid Dockerfile")
+	assert.NotContains(t, f.out.String(), f.JoinPath("Dockerfile"))
+}
+
+func TestVerifyConflictingPortForwards(t *testing.T) {
+	f := newVerifyFixture(t)
+
+	f.WriteFile("deployment.yaml", testyaml.SanchoYAML+"\n---\n"+testyaml.SnackYaml)
+	f.WriteFile("Tiltfile", `
+k8s_yaml('deployment.yaml')
+k8s_resource('sancho', port_forwards=8000)
+k8s_resource('snack', port_forwards='8000:9000')
+`)
+
+	f.run()
+	f.assertExitCode(TiltfileErrExitCode)
+	assert.Contains(t, f.out.String(), "port forward localhost:8000 is used more than once (by [sancho snack])")
+}
+
+type verifyFixture struct {
+	*tempdir.TempDirFixture
+	t        *testing.T
+	out      *bytes.Buffer
+	exitCode int
+}
+
+func newVerifyFixture(t *testing.T) *verifyFixture {
+	f := tempdir.NewTempDirFixture(t)
+	t.Cleanup(f.TearDown)
+	f.Chdir()
+
+	return &verifyFixture{
+		TempDirFixture: f,
+		t:              t,
+		out:            bytes.NewBuffer(nil),
+	}
+}
+
+func (f *verifyFixture) run() {
+	cmd := newVerifyCmd()
+	cmd.streams.Out = f.out
+	cmd.streams.ErrOut = bytes.NewBuffer(nil)
+	cmd.fileName = "Tiltfile"
+	cmd.exit = func(code int) { f.exitCode = code }
+
+	ctx, _, _ := testutils.CtxAndAnalyticsForTest()
+	err := cmd.run(ctx, nil)
+	require.NoError(f.t, err)
+}
+
+func (f *verifyFixture) assertExitCode(code int) {
+	assert.Equal(f.t, code, f.exitCode, "output:\n%s", f.out.String())
+}
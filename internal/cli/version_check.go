@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tilt-dev/tilt/internal/compat"
+	"github.com/tilt-dev/tilt/pkg/logger"
+)
+
+// Warns about Kubernetes and Docker servers older than the recommended versions.
+//
+// Servers older than the minimum version are an error, unless the user passed
+// --allow-unsupported-versions.
+func checkVersions(ctx context.Context, versions compat.Versions) error {
+	l := logger.Get(ctx)
+	var unsupported []compat.Problem
+	for _, p := range compat.Check(versions) {
+		if p.Severity == compat.SeverityUnsupported && !allowUnsupportedVersionsFlag {
+			unsupported = append(unsupported, p)
+			continue
+		}
+		l.Warnf("%s", p.Message)
+	}
+
+	if len(unsupported) == 0 {
+		return nil
+	}
+
+	for _, p := range unsupported[1:] {
+		l.Errorf("%s", p.Message)
+	}
+	return fmt.Errorf("%s\nTo start anyway, run with --allow-unsupported-versions", unsupported[0].Message)
+}
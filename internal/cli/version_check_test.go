@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/internal/compat"
+	"github.com/tilt-dev/tilt/pkg/logger"
+)
+
+func TestCheckVersionsBelowMinimum(t *testing.T) {
+	ctx, out := versionCheckContext()
+
+	err := checkVersions(ctx, compat.Versions{Kubernetes: "v1.12.10"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Kubernetes v1.12.10 is older than the minimum supported version (1.15)")
+	assert.Contains(t, err.Error(), "--allow-unsupported-versions")
+	assert.Equal(t, "", out.String())
+}
+
+func TestCheckVersionsBelowMinimumAllowed(t *testing.T) {
+	defer func() { allowUnsupportedVersionsFlag = false }()
+	allowUnsupportedVersionsFlag = true
+	ctx, out := versionCheckContext()
+
+	err := checkVersions(ctx, compat.Versions{Kubernetes: "v1.12.10"})
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "Kubernetes v1.12.10 is older than the minimum supported version (1.15)")
+}
+
+func TestCheckVersionsBelowRecommended(t *testing.T) {
+	ctx, out := versionCheckContext()
+
+	err := checkVersions(ctx, compat.Versions{
+		Kubernetes:   "v1.24.9-gke.3200",
+		DockerEngine: "17.06.0-ce",
+		DockerAPI:    "1.30",
+	})
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "Docker 17.06.0-ce (API 1.30) is older than the recommended version (1.40)")
+	assert.NotContains(t, out.String(), "Kubernetes")
+}
+
+func TestCheckVersionsUnparseable(t *testing.T) {
+	ctx, out := versionCheckContext()
+
+	err := checkVersions(ctx, compat.Versions{Kubernetes: "banana"})
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), `Could not parse Kubernetes version "banana"`)
+}
+
+func versionCheckContext() (context.Context, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	ctx := logger.WithLogger(context.Background(), logger.NewTestLogger(out))
+	return ctx, out
+}
@@ -0,0 +1,268 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/tilt-dev/tilt/internal/analytics"
+	engineanalytics "github.com/tilt-dev/tilt/internal/engine/analytics"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// The Session that tracks the main Tiltfile.
+const waitSessionName = "Tiltfile"
+
+type waitCmd struct {
+	all     bool
+	timeout time.Duration
+	out     io.Writer
+}
+
+var _ tiltCmd = &waitCmd{}
+
+func newWaitCmd() *waitCmd {
+	return &waitCmd{out: os.Stdout}
+}
+
+func (c *waitCmd) name() model.TiltSubcommand { return "wait" }
+
+func (c *waitCmd) register() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "wait (RESOURCE_NAME... | --all)",
+		DisableFlagsInUseLine: true,
+		Short:                 "Wait until resources in a running Tilt are ready",
+		Long: `Wait until resources in a running Tilt are ready.
+
+Uses the same rules as 'tilt ci' to decide whether a resource is ready.
+Exits with an error if one of the resources fails, or if the timeout
+passes first.
+`,
+		Example: `tilt wait --all
+tilt wait frontend backend --timeout=2m`,
+	}
+	cmd.Flags().BoolVar(&c.all, "all", false, "Wait for every resource that's been asked to run")
+	cmd.Flags().DurationVar(&c.timeout, "timeout", 30*time.Second, "How long to wait before giving up")
+	addConnectServerFlags(cmd)
+	return cmd
+}
+
+func (c *waitCmd) run(ctx context.Context, args []string) error {
+	if !c.all && len(args) == 0 {
+		return fmt.Errorf("Specify at least one resource name, or --all")
+	}
+	if c.all && len(args) > 0 {
+		return fmt.Errorf("--all can't be used with resource names")
+	}
+
+	a := analytics.Get(ctx)
+	cmdTags := engineanalytics.CmdTags(map[string]string{})
+	a.Incr("cmd.wait", cmdTags.AsMap())
+	defer a.Flush(time.Second)
+
+	getter, err := wireClientGetter(ctx)
+	if err != nil {
+		return err
+	}
+	cfg, err := getter.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+	cli, err := ctrlclient.NewWithWatch(cfg, ctrlclient.Options{Scheme: v1alpha1.NewScheme()})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	s, err := waitForSession(ctx, cli, args)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.out, "%s\n", readyMessage(s, args))
+	return err
+}
+
+// Watches the Session until the resources are ready, one of them fails,
+// or the context is done.
+func waitForSession(ctx context.Context, cli ctrlclient.WithWatch, names []string) (*v1alpha1.Session, error) {
+	var list v1alpha1.SessionList
+	err := cli.List(ctx, &list)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *v1alpha1.Session
+	for i := range list.Items {
+		if list.Items[i].Name == waitSessionName {
+			latest = &list.Items[i]
+			done, err := sessionReady(latest, names)
+			if done || err != nil {
+				return latest, err
+			}
+		}
+	}
+
+	w, err := cli.Watch(ctx, &v1alpha1.SessionList{}, &ctrlclient.ListOptions{
+		Raw: &metav1.ListOptions{ResourceVersion: list.ResourceVersion},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer w.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, waitTimeoutError(ctx, latest, names)
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return nil, fmt.Errorf("lost connection to Tilt")
+			}
+			if event.Type == watch.Error {
+				return nil, fmt.Errorf("watching Session: %v", event.Object)
+			}
+			s, ok := event.Object.(*v1alpha1.Session)
+			if !ok || s.Name != waitSessionName || event.Type == watch.Deleted {
+				continue
+			}
+			latest = s
+			done, err := sessionReady(s, names)
+			if done || err != nil {
+				return s, err
+			}
+		}
+	}
+}
+
+// Returns true if the resources are ready, or an error if one of them failed.
+//
+// If names is empty, waits for every resource that's been asked to run.
+func sessionReady(s *v1alpha1.Session, names []string) (bool, error) {
+	if s.Status.Phase == v1alpha1.SessionPhaseError {
+		err := sessionError(s, names)
+		if err != nil {
+			return false, err
+		}
+	}
+	if len(names) == 0 {
+		return s.Status.Phase == v1alpha1.SessionPhaseReady, nil
+	}
+
+	for _, name := range names {
+		r, ok := sessionResource(s, name)
+		if ok && r.Failed {
+			return false, fmt.Errorf("%s: %s", r.Name, r.Reason)
+		}
+		if ok && r.Ready {
+			continue
+		}
+		if !ok && tiltfileLoaded(s) && !hasTargetsForResource(s, name) {
+			return false, fmt.Errorf("no resource named %q", name)
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// Finds the reason the Session is in an error state.
+//
+// When we're waiting on specific resources, only their failures count.
+func sessionError(s *v1alpha1.Session, names []string) error {
+	for _, r := range s.Status.Resources {
+		if r.Failed && (len(names) == 0 || containsString(names, r.Name)) {
+			return fmt.Errorf("%s: %s", r.Name, r.Reason)
+		}
+	}
+	if s.Status.Error != "" {
+		return fmt.Errorf("%s", s.Status.Error)
+	}
+	for _, t := range s.Status.Targets {
+		if t.Name == "tiltfile:update" && t.State.Terminated != nil && t.State.Terminated.Error != "" {
+			return fmt.Errorf("Tiltfile failed to load: %s", t.State.Terminated.Error)
+		}
+	}
+	if len(names) > 0 {
+		// A resource we're not waiting on failed. Keep waiting.
+		return nil
+	}
+	return fmt.Errorf("session is in an error state")
+}
+
+func sessionResource(s *v1alpha1.Session, name string) (v1alpha1.SessionResourceStatus, bool) {
+	for _, r := range s.Status.Resources {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return v1alpha1.SessionResourceStatus{}, false
+}
+
+func hasTargetsForResource(s *v1alpha1.Session, name string) bool {
+	for _, t := range s.Status.Targets {
+		if containsString(t.Resources, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func tiltfileLoaded(s *v1alpha1.Session) bool {
+	for _, t := range s.Status.Targets {
+		if t.Name == "tiltfile:update" {
+			return t.State.Terminated != nil
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func readyMessage(s *v1alpha1.Session, names []string) string {
+	if len(names) > 0 {
+		return fmt.Sprintf("Ready: %s", strings.Join(names, ", "))
+	}
+	counts := s.Status.ResourceCounts
+	return fmt.Sprintf("All resources ready (%d/%d)", counts.Ready, counts.Total-counts.Inactive)
+}
+
+// Says what we were still waiting on when we gave up.
+func waitTimeoutError(ctx context.Context, s *v1alpha1.Session, names []string) error {
+	if ctx.Err() != context.DeadlineExceeded {
+		return ctx.Err()
+	}
+	if s == nil {
+		return fmt.Errorf("timed out waiting for Tilt to start")
+	}
+
+	var waiting []string
+	for _, r := range s.Status.Resources {
+		if !r.Ready && (len(names) == 0 || containsString(names, r.Name)) {
+			waiting = append(waiting, fmt.Sprintf("%s (%s)", r.Name, r.Reason))
+		}
+	}
+	if len(waiting) == 0 && len(names) > 0 {
+		return fmt.Errorf("timed out waiting on %s", strings.Join(names, ", "))
+	}
+	if len(waiting) == 0 {
+		return fmt.Errorf("timed out waiting for the Tiltfile to load")
+	}
+	return fmt.Errorf("timed out waiting on %s", strings.Join(waiting, ", "))
+}
@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+)
+
+func TestWaitAllReady(t *testing.T) {
+	f := newServerFixture(t)
+	defer f.TearDown()
+
+	f.createSession(v1alpha1.SessionStatus{
+		Phase:          v1alpha1.SessionPhaseReady,
+		ResourceCounts: v1alpha1.SessionResourceCounts{Total: 3, Ready: 2, Inactive: 1},
+	})
+
+	out := bytes.NewBuffer(nil)
+	c := newTestWaitCmd(out, true, 5*time.Second)
+	err := c.run(f.ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "All resources ready (2/2)\n", out.String())
+}
+
+func TestWaitWatchesForChanges(t *testing.T) {
+	f := newServerFixture(t)
+	defer f.TearDown()
+
+	s := f.createSession(v1alpha1.SessionStatus{
+		Phase: v1alpha1.SessionPhaseInProgress,
+		Resources: []v1alpha1.SessionResourceStatus{
+			{Name: "fe", Reason: "building"},
+		},
+	})
+
+	errCh := make(chan error)
+	out := bytes.NewBuffer(nil)
+	go func() {
+		c := newTestWaitCmd(out, false, 5*time.Second)
+		errCh <- c.run(f.ctx, []string{"fe"})
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	s.Status.Phase = v1alpha1.SessionPhaseReady
+	s.Status.Resources = []v1alpha1.SessionResourceStatus{{Name: "fe", BuildComplete: true, Ready: true}}
+	require.NoError(t, f.client.Status().Update(f.ctx, s))
+
+	require.NoError(t, <-errCh)
+	assert.Equal(t, "Ready: fe\n", out.String())
+}
+
+func TestWaitResourceFailed(t *testing.T) {
+	f := newServerFixture(t)
+	defer f.TearDown()
+
+	f.createSession(v1alpha1.SessionStatus{
+		Phase: v1alpha1.SessionPhaseError,
+		Resources: []v1alpha1.SessionResourceStatus{
+			{Name: "fe", Failed: true, Reason: "build failed"},
+			{Name: "be", BuildComplete: true, Ready: true},
+		},
+	})
+
+	c := newTestWaitCmd(bytes.NewBuffer(nil), true, 5*time.Second)
+	err := c.run(f.ctx, nil)
+	require.Error(t, err)
+	assert.Equal(t, "fe: build failed", err.Error())
+
+	// A failure in a resource we're not waiting on doesn't count.
+	out := bytes.NewBuffer(nil)
+	c = newTestWaitCmd(out, false, 5*time.Second)
+	err = c.run(f.ctx, []string{"be"})
+	require.NoError(t, err)
+	assert.Equal(t, "Ready: be\n", out.String())
+}
+
+func TestWaitTimeout(t *testing.T) {
+	f := newServerFixture(t)
+	defer f.TearDown()
+
+	f.createSession(v1alpha1.SessionStatus{
+		Phase: v1alpha1.SessionPhaseInProgress,
+		Resources: []v1alpha1.SessionResourceStatus{
+			{Name: "fe", Reason: "building"},
+			{Name: "be", BuildComplete: true, Reason: "pod Pending"},
+		},
+	})
+
+	c := newTestWaitCmd(bytes.NewBuffer(nil), false, 200*time.Millisecond)
+	err := c.run(f.ctx, []string{"fe"})
+	require.Error(t, err)
+	assert.Equal(t, "timed out waiting on fe (building)", err.Error())
+}
+
+func TestWaitUnknownResource(t *testing.T) {
+	f := newServerFixture(t)
+	defer f.TearDown()
+
+	f.createSession(v1alpha1.SessionStatus{
+		Phase: v1alpha1.SessionPhaseReady,
+		Targets: []v1alpha1.Target{
+			{
+				Name:      "tiltfile:update",
+				Resources: []string{"(Tiltfile)"},
+				Type:      v1alpha1.TargetTypeJob,
+				State: v1alpha1.TargetState{
+					Terminated: &v1alpha1.TargetStateTerminated{},
+				},
+			},
+		},
+	})
+
+	c := newTestWaitCmd(bytes.NewBuffer(nil), false, 5*time.Second)
+	err := c.run(f.ctx, []string{"fe"})
+	require.Error(t, err)
+	assert.Equal(t, `no resource named "fe"`, err.Error())
+}
+
+func TestWaitNeedsResources(t *testing.T) {
+	c := newTestWaitCmd(bytes.NewBuffer(nil), false, 5*time.Second)
+	err := c.run(context.Background(), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Specify at least one resource name, or --all")
+}
+
+func newTestWaitCmd(out io.Writer, all bool, timeout time.Duration) *waitCmd {
+	c := newWaitCmd()
+	c.register()
+	c.out = out
+	c.all = all
+	c.timeout = timeout
+	return c
+}
+
+func (f *serverFixture) createSession(status v1alpha1.SessionStatus) *v1alpha1.Session {
+	s := &v1alpha1.Session{
+		ObjectMeta: metav1.ObjectMeta{Name: "Tiltfile"},
+		Spec: v1alpha1.SessionSpec{
+			TiltfilePath:  f.JoinPath("Tiltfile"),
+			ExitCondition: v1alpha1.ExitConditionManual,
+		},
+	}
+	require.NoError(f.T(), f.client.Create(f.ctx, s))
+
+	s.Status = status
+	s.Status.StartTime = metav1.NowMicro()
+	require.NoError(f.T(), f.client.Status().Update(f.ctx, s))
+	return s
+}
@@ -5,15 +5,10 @@ package cli
 
 import (
 	"context"
-	"time"
 
 	cliclient "github.com/tilt-dev/tilt/internal/cli/client"
-	"github.com/tilt-dev/tilt/internal/controllers/core/filewatch/fsevent"
 
 	"github.com/google/wire"
-	"github.com/jonboulle/clockwork"
-	"github.com/tilt-dev/wmclient/pkg/dirs"
-	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/tools/clientcmd/api"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -23,133 +18,36 @@ import (
 	"github.com/tilt-dev/tilt/internal/build"
 	"github.com/tilt-dev/tilt/internal/cloud"
 	"github.com/tilt-dev/tilt/internal/cloud/cloudurl"
+	"github.com/tilt-dev/tilt/internal/compat"
 	"github.com/tilt-dev/tilt/internal/container"
-	"github.com/tilt-dev/tilt/internal/controllers"
-	"github.com/tilt-dev/tilt/internal/controllers/core/kubernetesdiscovery"
 	"github.com/tilt-dev/tilt/internal/docker"
 	"github.com/tilt-dev/tilt/internal/dockercompose"
 	"github.com/tilt-dev/tilt/internal/engine"
 	engineanalytics "github.com/tilt-dev/tilt/internal/engine/analytics"
-	"github.com/tilt-dev/tilt/internal/engine/buildcontrol"
-	"github.com/tilt-dev/tilt/internal/engine/configs"
-	"github.com/tilt-dev/tilt/internal/engine/dcwatch"
-	"github.com/tilt-dev/tilt/internal/engine/dockerprune"
-	"github.com/tilt-dev/tilt/internal/engine/k8srollout"
-	"github.com/tilt-dev/tilt/internal/engine/k8swatch"
-	"github.com/tilt-dev/tilt/internal/engine/local"
-	"github.com/tilt-dev/tilt/internal/engine/runtimelog"
-	"github.com/tilt-dev/tilt/internal/engine/session"
-	"github.com/tilt-dev/tilt/internal/engine/telemetry"
-	"github.com/tilt-dev/tilt/internal/engine/uiresource"
-	"github.com/tilt-dev/tilt/internal/engine/uisession"
 	"github.com/tilt-dev/tilt/internal/feature"
-	"github.com/tilt-dev/tilt/internal/git"
 	"github.com/tilt-dev/tilt/internal/hud"
 	"github.com/tilt-dev/tilt/internal/hud/prompt"
-	"github.com/tilt-dev/tilt/internal/hud/server"
 	"github.com/tilt-dev/tilt/internal/k8s"
 	"github.com/tilt-dev/tilt/internal/localexec"
-	"github.com/tilt-dev/tilt/internal/openurl"
 	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/tiltengine"
 	"github.com/tilt-dev/tilt/internal/tiltfile"
 	"github.com/tilt-dev/tilt/internal/token"
-	"github.com/tilt-dev/tilt/internal/tracer"
-	"github.com/tilt-dev/tilt/internal/xdg"
 	"github.com/tilt-dev/tilt/pkg/logger"
 	"github.com/tilt-dev/tilt/pkg/model"
 )
 
 var K8sWireSet = wire.NewSet(
-	k8s.ProvideEnv,
-	k8s.ProvideClusterName,
-	k8s.ProvideKubeContext,
-	k8s.ProvideKubeConfig,
-	k8s.ProvideClientConfig,
-	k8s.ProvideClientset,
-	k8s.ProvideRESTConfig,
-	k8s.ProvidePortForwardClient,
-	k8s.ProvideConfigNamespace,
-	k8s.ProvideContainerRuntime,
-	k8s.ProvideServerVersion,
-	k8s.ProvideK8sClient,
-	k8s.ProvideOwnerFetcher,
+	tiltengine.K8sWireSet,
 	ProvideKubeContextOverride,
 	ProvideNamespaceOverride)
 
 var BaseWireSet = wire.NewSet(
-	K8sWireSet,
-	tiltfile.WireSet,
-	git.ProvideGitRemote,
-
-	localexec.DefaultEnv,
-	localexec.NewProcessExecer,
-	wire.Bind(new(localexec.Execer), new(*localexec.ProcessExecer)),
-
-	docker.SwitchWireSet,
-
-	dockercompose.NewDockerComposeClient,
-
-	clockwork.NewRealClock,
-	engine.DeployerWireSet,
-	engine.NewBuildController,
-	local.NewServerController,
-	kubernetesdiscovery.NewContainerRestartDetector,
-	k8swatch.NewServiceWatcher,
-	k8swatch.NewEventWatchManager,
-	uisession.NewSubscriber,
-	uiresource.NewSubscriber,
-	configs.NewConfigsController,
-	configs.NewTriggerQueueSubscriber,
-	telemetry.NewController,
-	dcwatch.NewEventWatcher,
-	runtimelog.NewDockerComposeLogManager,
-	cloud.WireSet,
-	cloudurl.ProvideAddress,
-	k8srollout.NewPodMonitor,
-	telemetry.NewStartTracker,
-	session.NewController,
-
-	build.ProvideClock,
-	provideClock,
-	hud.WireSet,
-	prompt.WireSet,
-	wire.Value(openurl.OpenURL(openurl.BrowserOpen)),
-
-	provideLogActions,
-	store.NewStore,
-	wire.Bind(new(store.RStore), new(*store.Store)),
-
-	dockerprune.NewDockerPruner,
-
-	provideTiltInfo,
-	engine.NewUpper,
-	engineanalytics.NewAnalyticsUpdater,
-	engineanalytics.ProvideAnalyticsReporter,
-	provideUpdateModeFlag,
-	fsevent.ProvideWatcherMaker,
-	fsevent.ProvideTimerMaker,
-
-	controllers.WireSet,
-
-	provideWebVersion,
-	provideWebMode,
-	provideWebURL,
-	provideWebPort,
-	provideWebHost,
-	server.WireSet,
-	provideAssetServer,
-
-	tracer.NewSpanCollector,
-	wire.Bind(new(sdktrace.SpanExporter), new(*tracer.SpanCollector)),
-	wire.Bind(new(tracer.SpanSource), new(*tracer.SpanCollector)),
-
-	dirs.UseTiltDevDir,
-	xdg.NewTiltDevBase,
-	token.GetOrCreateToken,
-
-	buildcontrol.NewKINDLoader,
-
-	wire.Value(feature.MainDefaults),
+	tiltengine.K8sWireSet,
+	tiltengine.ClientsWireSet,
+	tiltengine.EngineWireSet,
+	tiltengine.OptionsWireSet,
+	provideEngineOptions,
 )
 
 var CLIClientWireSet = wire.NewSet(
@@ -159,7 +57,7 @@ var CLIClientWireSet = wire.NewSet(
 
 var UpWireSet = wire.NewSet(
 	BaseWireSet,
-	engine.ProvideSubscribers,
+	tiltengine.ProvideSubscribers,
 )
 
 func wireTiltfileResult(ctx context.Context, analytics *analytics.TiltAnalytics, subcommand model.TiltSubcommand) (cmdTiltfileResultDeps, error) {
@@ -167,6 +65,29 @@ func wireTiltfileResult(ctx context.Context, analytics *analytics.TiltAnalytics,
 	return cmdTiltfileResultDeps{}, nil
 }
 
+func wireTiltfileVerify(ctx context.Context, analytics *analytics.TiltAnalytics, subcommand model.TiltSubcommand) (cmdVerifyDeps, error) {
+	wire.Build(
+		k8s.ProvideEnv,
+		k8s.ProvideKubeContext,
+		k8s.ProvideKubeConfig,
+		k8s.ProvideClientConfig,
+		ProvideKubeContextOverride,
+		ProvideNamespaceOverride,
+		provideVerifyK8sClient,
+		provideVerifyDockerComposeClient,
+		tiltfile.WireSet,
+		ProvideTiltfileExecLimits,
+		localexec.DefaultEnv,
+		localexec.NewProcessExecer,
+		wire.Bind(new(localexec.Execer), new(*localexec.ProcessExecer)),
+		provideTiltInfo,
+		provideWebHost,
+		provideWebPort,
+		wire.Value(feature.MainDefaults),
+		newVerifyDeps)
+	return cmdVerifyDeps{}, nil
+}
+
 func wireDockerPrune(ctx context.Context, analytics *analytics.TiltAnalytics, subcommand model.TiltSubcommand) (dpDeps, error) {
 	wire.Build(UpWireSet, newDPDeps)
 	return dpDeps{}, nil
@@ -187,6 +108,7 @@ type CmdUpDeps struct {
 	CloudAddress cloudurl.Address
 	Prompt       *prompt.TerminalPrompt
 	Snapshotter  *cloud.Snapshotter
+	Versions     compat.Versions
 }
 
 func wireCmdCI(ctx context.Context, analytics *analytics.TiltAnalytics, subcommand model.TiltSubcommand) (CmdCIDeps, error) {
@@ -205,6 +127,7 @@ type CmdCIDeps struct {
 	Token        token.Token
 	CloudAddress cloudurl.Address
 	Snapshotter  *cloud.Snapshotter
+	Versions     compat.Versions
 }
 
 func wireCmdUpdog(ctx context.Context,
@@ -290,19 +213,22 @@ func wireDownDeps(ctx context.Context, tiltAnalytics *analytics.TiltAnalytics, s
 }
 
 type DownDeps struct {
-	tfl      tiltfile.TiltfileLoader
-	dcClient dockercompose.DockerComposeClient
-	kClient  k8s.Client
+	tfl       tiltfile.TiltfileLoader
+	dcClient  dockercompose.DockerComposeClient
+	kClient   k8s.Client
+	namespace k8s.Namespace
 }
 
 func ProvideDownDeps(
 	tfl tiltfile.TiltfileLoader,
 	dcClient dockercompose.DockerComposeClient,
-	kClient k8s.Client) DownDeps {
+	kClient k8s.Client,
+	namespace k8s.Namespace) DownDeps {
 	return DownDeps{
-		tfl:      tfl,
-		dcClient: dcClient,
-		kClient:  kClient,
+		tfl:       tfl,
+		dcClient:  dcClient,
+		kClient:   kClient,
+		namespace: namespace,
 	}
 }
 
@@ -323,10 +249,6 @@ func ProvideLogsDeps(u model.WebURL, p *hud.IncrementalPrinter) LogsDeps {
 	}
 }
 
-func provideClock() func() time.Time {
-	return time.Now
-}
-
 type DumpImageDeployRefDeps struct {
 	DockerBuilder build.DockerBuilder
 	DockerClient  docker.Client
@@ -7,26 +7,27 @@ package cli
 
 import (
 	"context"
-	"time"
 
 	"github.com/google/wire"
 	"github.com/jonboulle/clockwork"
 	"github.com/tilt-dev/wmclient/pkg/dirs"
-	"go.opentelemetry.io/otel/sdk/trace"
 	version2 "k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/tools/clientcmd/api"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/tilt-dev/tilt/internal/agent"
 	"github.com/tilt-dev/tilt/internal/analytics"
 	"github.com/tilt-dev/tilt/internal/build"
 	client2 "github.com/tilt-dev/tilt/internal/cli/client"
 	"github.com/tilt-dev/tilt/internal/cloud"
 	"github.com/tilt-dev/tilt/internal/cloud/cloudurl"
+	"github.com/tilt-dev/tilt/internal/compat"
 	"github.com/tilt-dev/tilt/internal/container"
 	"github.com/tilt-dev/tilt/internal/containerupdate"
 	"github.com/tilt-dev/tilt/internal/controllers"
 	"github.com/tilt-dev/tilt/internal/controllers/core/cmd"
 	"github.com/tilt-dev/tilt/internal/controllers/core/configmap"
+	"github.com/tilt-dev/tilt/internal/controllers/core/debugcontainer"
 	"github.com/tilt-dev/tilt/internal/controllers/core/extension"
 	"github.com/tilt-dev/tilt/internal/controllers/core/extensionrepo"
 	"github.com/tilt-dev/tilt/internal/controllers/core/filewatch"
@@ -50,9 +51,12 @@ import (
 	"github.com/tilt-dev/tilt/internal/engine/configs"
 	"github.com/tilt-dev/tilt/internal/engine/dcwatch"
 	"github.com/tilt-dev/tilt/internal/engine/dockerprune"
+	"github.com/tilt-dev/tilt/internal/engine/idle"
 	"github.com/tilt-dev/tilt/internal/engine/k8srollout"
 	"github.com/tilt-dev/tilt/internal/engine/k8swatch"
 	"github.com/tilt-dev/tilt/internal/engine/local"
+	"github.com/tilt-dev/tilt/internal/engine/memory"
+	"github.com/tilt-dev/tilt/internal/engine/resourceprefs"
 	"github.com/tilt-dev/tilt/internal/engine/runtimelog"
 	"github.com/tilt-dev/tilt/internal/engine/session"
 	"github.com/tilt-dev/tilt/internal/engine/telemetry"
@@ -68,6 +72,7 @@ import (
 	"github.com/tilt-dev/tilt/internal/openurl"
 	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/internal/store/liveupdates"
+	"github.com/tilt-dev/tilt/internal/tiltengine"
 	"github.com/tilt-dev/tilt/internal/tiltfile"
 	"github.com/tilt-dev/tilt/internal/tiltfile/config"
 	"github.com/tilt-dev/tilt/internal/tiltfile/k8scontext"
@@ -83,8 +88,9 @@ import (
 // Injectors from wire.go:
 
 func wireTiltfileResult(ctx context.Context, analytics2 *analytics.TiltAnalytics, subcommand model.TiltSubcommand) (cmdTiltfileResultDeps, error) {
-	k8sKubeContextOverride := ProvideKubeContextOverride()
-	k8sNamespaceOverride := ProvideNamespaceOverride()
+	options := provideEngineOptions()
+	k8sKubeContextOverride := options.KubeContextOverride
+	k8sNamespaceOverride := options.NamespaceOverride
 	clientConfig := k8s.ProvideClientConfig(k8sKubeContextOverride, k8sNamespaceOverride)
 	apiConfig, err := k8s.ProvideKubeConfig(clientConfig, k8sKubeContextOverride)
 	if err != nil {
@@ -101,20 +107,21 @@ func wireTiltfileResult(ctx context.Context, analytics2 *analytics.TiltAnalytics
 	}
 	minikubeClient := k8s.ProvideMinikubeClient(kubeContext)
 	client := k8s.ProvideK8sClient(ctx, env, restConfigOrError, clientsetOrError, portForwardClient, namespace, minikubeClient, clientConfig)
-	plugin := k8scontext.NewPlugin(kubeContext, env)
-	tiltBuild := provideTiltInfo()
+	plugin := k8scontext.ProvidePlugin(kubeContext, env, clientConfig, k8sKubeContextOverride)
+	tiltBuild := options.TiltBuild
 	versionPlugin := version.NewPlugin(tiltBuild)
 	configPlugin := config.NewPlugin(subcommand)
 	runtime := k8s.ProvideContainerRuntime(ctx, client)
 	clusterEnv := docker.ProvideClusterEnv(ctx, kubeContext, env, runtime, minikubeClient)
 	localEnv := docker.ProvideLocalEnv(ctx, kubeContext, env, clusterEnv)
 	dockerComposeClient := dockercompose.NewDockerComposeClient(localEnv)
-	webHost := provideWebHost()
-	webPort := provideWebPort()
+	webHost := options.WebHost
+	webPort := options.WebPort
 	localexecEnv := localexec.DefaultEnv(webPort, webHost)
 	processExecer := localexec.NewProcessExecer(localexecEnv)
 	defaults := _wireDefaultsValue
-	tiltfileLoader := tiltfile.ProvideTiltfileLoader(analytics2, client, plugin, versionPlugin, configPlugin, dockerComposeClient, webHost, processExecer, defaults, env)
+	execLimits := options.TiltfileExecLimits
+	tiltfileLoader := tiltfile.ProvideTiltfileLoader(analytics2, client, plugin, versionPlugin, configPlugin, dockerComposeClient, webHost, processExecer, defaults, env, execLimits)
 	cliCmdTiltfileResultDeps := newTiltfileResultDeps(tiltfileLoader)
 	return cliCmdTiltfileResultDeps, nil
 }
@@ -123,11 +130,46 @@ var (
 	_wireDefaultsValue = feature.MainDefaults
 )
 
-func wireDockerPrune(ctx context.Context, analytics2 *analytics.TiltAnalytics, subcommand model.TiltSubcommand) (dpDeps, error) {
+func wireTiltfileVerify(ctx context.Context, analytics2 *analytics.TiltAnalytics, subcommand model.TiltSubcommand) (cmdVerifyDeps, error) {
+	client := provideVerifyK8sClient()
 	k8sKubeContextOverride := ProvideKubeContextOverride()
 	k8sNamespaceOverride := ProvideNamespaceOverride()
 	clientConfig := k8s.ProvideClientConfig(k8sKubeContextOverride, k8sNamespaceOverride)
 	apiConfig, err := k8s.ProvideKubeConfig(clientConfig, k8sKubeContextOverride)
+	if err != nil {
+		return cmdVerifyDeps{}, err
+	}
+	kubeContext, err := k8s.ProvideKubeContext(apiConfig)
+	if err != nil {
+		return cmdVerifyDeps{}, err
+	}
+	env := k8s.ProvideEnv(ctx, apiConfig)
+	plugin := k8scontext.ProvidePlugin(kubeContext, env, clientConfig, k8sKubeContextOverride)
+	tiltBuild := provideTiltInfo()
+	versionPlugin := version.NewPlugin(tiltBuild)
+	configPlugin := config.NewPlugin(subcommand)
+	dockerComposeClient := provideVerifyDockerComposeClient()
+	webHost := provideWebHost()
+	webPort := provideWebPort()
+	localexecEnv := localexec.DefaultEnv(webPort, webHost)
+	processExecer := localexec.NewProcessExecer(localexecEnv)
+	defaults := _wireFeatureDefaultsValue
+	execLimits := ProvideTiltfileExecLimits()
+	tiltfileLoader := tiltfile.ProvideTiltfileLoader(analytics2, client, plugin, versionPlugin, configPlugin, dockerComposeClient, webHost, processExecer, defaults, env, execLimits)
+	cliCmdVerifyDeps := newVerifyDeps(tiltfileLoader)
+	return cliCmdVerifyDeps, nil
+}
+
+var (
+	_wireFeatureDefaultsValue = feature.MainDefaults
+)
+
+func wireDockerPrune(ctx context.Context, analytics2 *analytics.TiltAnalytics, subcommand model.TiltSubcommand) (dpDeps, error) {
+	options := provideEngineOptions()
+	k8sKubeContextOverride := options.KubeContextOverride
+	k8sNamespaceOverride := options.NamespaceOverride
+	clientConfig := k8s.ProvideClientConfig(k8sKubeContextOverride, k8sNamespaceOverride)
+	apiConfig, err := k8s.ProvideKubeConfig(clientConfig, k8sKubeContextOverride)
 	if err != nil {
 		return dpDeps{}, err
 	}
@@ -151,38 +193,43 @@ func wireDockerPrune(ctx context.Context, analytics2 *analytics.TiltAnalytics, s
 		return dpDeps{}, err
 	}
 	switchCli := docker.ProvideSwitchCli(clusterClient, localClient)
-	plugin := k8scontext.NewPlugin(kubeContext, env)
-	tiltBuild := provideTiltInfo()
+	plugin := k8scontext.ProvidePlugin(kubeContext, env, clientConfig, k8sKubeContextOverride)
+	tiltBuild := options.TiltBuild
 	versionPlugin := version.NewPlugin(tiltBuild)
 	configPlugin := config.NewPlugin(subcommand)
 	dockerComposeClient := dockercompose.NewDockerComposeClient(localEnv)
-	webHost := provideWebHost()
-	webPort := provideWebPort()
+	webHost := options.WebHost
+	webPort := options.WebPort
 	localexecEnv := localexec.DefaultEnv(webPort, webHost)
 	processExecer := localexec.NewProcessExecer(localexecEnv)
 	defaults := _wireDefaultsValue
-	tiltfileLoader := tiltfile.ProvideTiltfileLoader(analytics2, client, plugin, versionPlugin, configPlugin, dockerComposeClient, webHost, processExecer, defaults, env)
+	execLimits := options.TiltfileExecLimits
+	tiltfileLoader := tiltfile.ProvideTiltfileLoader(analytics2, client, plugin, versionPlugin, configPlugin, dockerComposeClient, webHost, processExecer, defaults, env, execLimits)
 	cliDpDeps := newDPDeps(switchCli, tiltfileLoader)
 	return cliDpDeps, nil
 }
 
 func wireCmdUp(ctx context.Context, analytics3 *analytics.TiltAnalytics, cmdTags analytics2.CmdTags, subcommand model.TiltSubcommand) (CmdUpDeps, error) {
 	reducer := _wireReducerValue
-	storeLogActionsFlag := provideLogActions()
-	storeStore := store.NewStore(reducer, storeLogActionsFlag)
+	options := provideEngineOptions()
+	storeLogActionsFlag := options.LogActions
+	actionJournalFlag := options.ActionJournal
+	storeStore := store.NewStore(reducer, storeLogActionsFlag, actionJournalFlag)
+	exclude := options.Exclude
+	extraSubscribers := options.ExtraSubscribers
 	tiltDevDir, err := dirs.UseTiltDevDir()
 	if err != nil {
 		return CmdUpDeps{}, err
 	}
 	configAccess := server.ProvideConfigAccess(tiltDevDir)
-	webPort := provideWebPort()
+	webPort := options.WebPort
 	apiServerName := model.ProvideAPIServerName(webPort)
-	webHost := provideWebHost()
-	webListener, err := server.ProvideWebListener(webHost, webPort)
+	webHost := options.WebHost
+	webListener, err := tiltengine.ProvideWebListener(webHost, webPort, exclude)
 	if err != nil {
 		return CmdUpDeps{}, err
 	}
-	tiltBuild := provideTiltInfo()
+	tiltBuild := options.TiltBuild
 	connProvider := server.ProvideMemConn()
 	bearerToken, err := server.NewBearerToken()
 	if err != nil {
@@ -201,12 +248,13 @@ func wireCmdUp(ctx context.Context, analytics3 *analytics.TiltAnalytics, cmdTags
 	if err != nil {
 		return CmdUpDeps{}, err
 	}
-	webMode, err := provideWebMode(tiltBuild)
+	webMode, err := tiltengine.ProvideWebMode(options)
 	if err != nil {
 		return CmdUpDeps{}, err
 	}
-	webVersion := provideWebVersion(tiltBuild)
-	assetsServer, err := provideAssetServer(webMode, webVersion)
+	webVersion := tiltengine.ProvideWebVersion(tiltBuild)
+	modelWebDevPort := options.WebDevPort
+	assetsServer, err := tiltengine.ProvideAssetServer(webMode, webVersion, modelWebDevPort)
 	if err != nil {
 		return CmdUpDeps{}, err
 	}
@@ -215,37 +263,19 @@ func wireCmdUp(ctx context.Context, analytics3 *analytics.TiltAnalytics, cmdTags
 	snapshotUploader := cloud.NewSnapshotUploader(httpClient, address)
 	websocketList := server.NewWebsocketList()
 	deferredClient := controllers.ProvideDeferredClient()
-	headsUpServer, err := server.ProvideHeadsUpServer(ctx, storeStore, assetsServer, analytics3, snapshotUploader, websocketList, deferredClient)
-	if err != nil {
-		return CmdUpDeps{}, err
-	}
-	webURL, err := provideWebURL(webHost, webPort)
-	if err != nil {
-		return CmdUpDeps{}, err
-	}
-	headsUpServerController := server.ProvideHeadsUpServerController(configAccess, apiServerName, webListener, apiserverConfig, headsUpServer, assetsServer, webURL)
-	scheme := v1alpha1.NewScheme()
-	uncachedObjects := controllers.ProvideUncachedObjects()
-	tiltServerControllerManager, err := controllers.NewTiltServerControllerManager(apiserverConfig, scheme, deferredClient, uncachedObjects)
+	webSecurityOptions := options.WebSecurity
+	webSecurity, err := server.ProvideWebSecurity(webSecurityOptions, apiServerName, webHost, base)
 	if err != nil {
 		return CmdUpDeps{}, err
 	}
-	watcherMaker := fsevent.ProvideWatcherMaker()
-	timerMaker := fsevent.ProvideTimerMaker()
-	controller := filewatch.NewController(deferredClient, storeStore, watcherMaker, timerMaker, scheme)
-	env := localexec.DefaultEnv(webPort, webHost)
-	execer := cmd.ProvideExecer(env)
-	proberManager := cmd.ProvideProberManager()
-	clock := clockwork.NewRealClock()
-	cmdController := cmd.NewController(ctx, execer, proberManager, deferredClient, storeStore, clock, scheme)
-	k8sKubeContextOverride := ProvideKubeContextOverride()
-	k8sNamespaceOverride := ProvideNamespaceOverride()
+	k8sKubeContextOverride := options.KubeContextOverride
+	k8sNamespaceOverride := options.NamespaceOverride
 	clientConfig := k8s.ProvideClientConfig(k8sKubeContextOverride, k8sNamespaceOverride)
 	apiConfig, err := k8s.ProvideKubeConfig(clientConfig, k8sKubeContextOverride)
 	if err != nil {
 		return CmdUpDeps{}, err
 	}
-	k8sEnv := k8s.ProvideEnv(ctx, apiConfig)
+	env := k8s.ProvideEnv(ctx, apiConfig)
 	restConfigOrError := k8s.ProvideRESTConfig(clientConfig)
 	clientsetOrError := k8s.ProvideClientset(restConfigOrError)
 	portForwardClient := k8s.ProvidePortForwardClient(restConfigOrError, clientsetOrError)
@@ -255,15 +285,12 @@ func wireCmdUp(ctx context.Context, analytics3 *analytics.TiltAnalytics, cmdTags
 		return CmdUpDeps{}, err
 	}
 	minikubeClient := k8s.ProvideMinikubeClient(kubeContext)
-	client := k8s.ProvideK8sClient(ctx, k8sEnv, restConfigOrError, clientsetOrError, portForwardClient, namespace, minikubeClient, clientConfig)
-	podSource := podlogstream.NewPodSource(ctx, client, scheme)
-	podlogstreamController := podlogstream.NewController(ctx, deferredClient, storeStore, client, podSource)
-	ownerFetcher := k8s.ProvideOwnerFetcher(ctx, client)
-	containerRestartDetector := kubernetesdiscovery.NewContainerRestartDetector()
-	reconciler := kubernetesdiscovery.NewReconciler(deferredClient, client, ownerFetcher, containerRestartDetector, storeStore)
+	client := k8s.ProvideK8sClient(ctx, env, restConfigOrError, clientsetOrError, portForwardClient, namespace, minikubeClient, clientConfig)
+	analyticsReporter := analytics2.ProvideAnalyticsReporter(analytics3, storeStore, client, env)
+	scheme := v1alpha1.NewScheme()
 	runtime := k8s.ProvideContainerRuntime(ctx, client)
-	clusterEnv := docker.ProvideClusterEnv(ctx, kubeContext, k8sEnv, runtime, minikubeClient)
-	localEnv := docker.ProvideLocalEnv(ctx, kubeContext, k8sEnv, clusterEnv)
+	clusterEnv := docker.ProvideClusterEnv(ctx, kubeContext, env, runtime, minikubeClient)
+	localEnv := docker.ProvideLocalEnv(ctx, kubeContext, env, clusterEnv)
 	localClient := docker.ProvideLocalCli(ctx, localEnv)
 	clusterClient, err := docker.ProvideClusterCli(ctx, localEnv, clusterEnv, localClient)
 	if err != nil {
@@ -273,21 +300,54 @@ func wireCmdUp(ctx context.Context, analytics3 *analytics.TiltAnalytics, cmdTags
 	labels := _wireLabelsValue
 	dockerImageBuilder := build.NewDockerImageBuilder(switchCli, labels)
 	dockerBuilder := build.DefaultDockerBuilder(dockerImageBuilder)
-	processExecer := localexec.NewProcessExecer(env)
-	kubernetesapplyReconciler := kubernetesapply.NewReconciler(deferredClient, client, scheme, dockerBuilder, kubeContext, storeStore, namespace, processExecer)
+	localexecEnv := localexec.DefaultEnv(webPort, webHost)
+	processExecer := localexec.NewProcessExecer(localexecEnv)
+	kubernetesapplyVerboseApplyFlag := options.VerboseApply
+	reconciler := kubernetesapply.NewReconciler(deferredClient, client, scheme, dockerBuilder, kubeContext, storeStore, namespace, processExecer, kubernetesapplyVerboseApplyFlag)
+	debugcontainerReconciler := debugcontainer.NewReconciler(deferredClient, client, storeStore)
+	portforwardReconciler := portforward.NewReconciler(deferredClient, storeStore, client)
+	headsUpServer, err := server.ProvideHeadsUpServer(ctx, storeStore, assetsServer, analytics3, snapshotUploader, websocketList, deferredClient, webSecurity, analyticsReporter, reconciler, reconciler, debugcontainerReconciler, portforwardReconciler)
+	if err != nil {
+		return CmdUpDeps{}, err
+	}
+	webURL, err := tiltengine.ProvideWebURL(webHost, webPort, webSecurityOptions, exclude)
+	if err != nil {
+		return CmdUpDeps{}, err
+	}
+	headsUpServerController := server.ProvideHeadsUpServerController(configAccess, apiServerName, webListener, apiserverConfig, headsUpServer, assetsServer, webURL, webSecurity)
+	uncachedObjects := controllers.ProvideUncachedObjects()
+	tiltServerControllerManager, err := controllers.NewTiltServerControllerManager(apiserverConfig, scheme, deferredClient, uncachedObjects)
+	if err != nil {
+		return CmdUpDeps{}, err
+	}
+	watcherMaker := fsevent.ProvideWatcherMaker()
+	timerMaker := fsevent.ProvideTimerMaker()
+	controller := filewatch.NewController(deferredClient, storeStore, watcherMaker, timerMaker, scheme)
+	execer := cmd.ProvideExecer(localexecEnv)
+	proberManager := cmd.ProvideProberManager()
+	clock := clockwork.NewRealClock()
+	cmdController := cmd.NewController(ctx, execer, proberManager, deferredClient, storeStore, clock, scheme)
+	podSource := podlogstream.NewPodSource(ctx, client, scheme)
+	dialer := agent.ProvideDialer(restConfigOrError)
+	installer := agent.NewInstaller(client, dialer, tiltBuild)
+	podlogstreamController := podlogstream.NewController(ctx, deferredClient, storeStore, client, podSource, installer)
+	ownerFetcher := k8s.ProvideOwnerFetcher(ctx, client)
+	containerRestartDetector := kubernetesdiscovery.NewContainerRestartDetector()
+	kubernetesdiscoveryReconciler := kubernetesdiscovery.NewReconciler(deferredClient, client, ownerFetcher, containerRestartDetector, storeStore)
 	uisessionReconciler := uisession.NewReconciler(deferredClient, websocketList)
 	uiresourceReconciler := uiresource.NewReconciler(deferredClient, websocketList, storeStore)
 	uibuttonReconciler := uibutton.NewReconciler(deferredClient, websocketList)
-	portforwardReconciler := portforward.NewReconciler(deferredClient, storeStore, client)
-	plugin := k8scontext.NewPlugin(kubeContext, k8sEnv)
+	plugin := k8scontext.ProvidePlugin(kubeContext, env, clientConfig, k8sKubeContextOverride)
 	versionPlugin := version.NewPlugin(tiltBuild)
 	configPlugin := config.NewPlugin(subcommand)
 	dockerComposeClient := dockercompose.NewDockerComposeClient(localEnv)
 	defaults := _wireDefaultsValue
-	tiltfileLoader := tiltfile.ProvideTiltfileLoader(analytics3, client, plugin, versionPlugin, configPlugin, dockerComposeClient, webHost, processExecer, defaults, k8sEnv)
+	execLimits := options.TiltfileExecLimits
+	tiltfileLoader := tiltfile.ProvideTiltfileLoader(analytics3, client, plugin, versionPlugin, configPlugin, dockerComposeClient, webHost, processExecer, defaults, env, execLimits)
 	buildSource := tiltfile2.NewBuildSource()
 	engineMode := _wireEngineModeValue
-	tiltfileReconciler := tiltfile2.NewReconciler(storeStore, tiltfileLoader, switchCli, deferredClient, scheme, buildSource, engineMode)
+	tiltfileForceBootstrapFlag := options.ForceBootstrap
+	tiltfileReconciler := tiltfile2.NewReconciler(storeStore, tiltfileLoader, switchCli, deferredClient, scheme, buildSource, engineMode, client, namespace, processExecer, tiltfileForceBootstrapFlag)
 	togglebuttonReconciler := togglebutton.NewReconciler(deferredClient, scheme)
 	extensionReconciler := extension.NewReconciler(deferredClient, scheme, analytics3)
 	extensionrepoReconciler, err := extensionrepo.NewReconciler(deferredClient, base)
@@ -296,55 +356,77 @@ func wireCmdUp(ctx context.Context, analytics3 *analytics.TiltAnalytics, cmdTags
 	}
 	dockerUpdater := containerupdate.NewDockerUpdater(switchCli)
 	execUpdater := containerupdate.NewExecUpdater(client)
-	liveupdatesUpdateModeFlag := provideUpdateModeFlag()
+	agentUpdater := containerupdate.NewAgentUpdater(installer)
+	liveupdatesUpdateModeFlag := options.UpdateMode
 	updateMode, err := liveupdates.ProvideUpdateMode(liveupdatesUpdateModeFlag, kubeContext, clusterEnv)
 	if err != nil {
 		return CmdUpDeps{}, err
 	}
-	liveupdateReconciler := liveupdate.NewReconciler(storeStore, dockerUpdater, execUpdater, updateMode, kubeContext, deferredClient, scheme)
+	liveupdateReconciler := liveupdate.NewReconciler(storeStore, dockerUpdater, execUpdater, agentUpdater, installer, updateMode, kubeContext, client, deferredClient, scheme)
 	configmapReconciler := configmap.NewReconciler(deferredClient, storeStore)
-	v := controllers.ProvideControllers(controller, cmdController, podlogstreamController, reconciler, kubernetesapplyReconciler, uisessionReconciler, uiresourceReconciler, uibuttonReconciler, portforwardReconciler, tiltfileReconciler, togglebuttonReconciler, extensionReconciler, extensionrepoReconciler, liveupdateReconciler, configmapReconciler)
+	v := controllers.ProvideControllers(controller, cmdController, podlogstreamController, kubernetesdiscoveryReconciler, reconciler, uisessionReconciler, uiresourceReconciler, uibuttonReconciler, portforwardReconciler, tiltfileReconciler, togglebuttonReconciler, extensionReconciler, extensionrepoReconciler, liveupdateReconciler, configmapReconciler, debugcontainerReconciler)
 	controllerBuilder := controllers.NewControllerBuilder(tiltServerControllerManager, v)
-	v2 := provideClock()
+	v2 := tiltengine.ProvideClock()
 	renderer := hud.NewRenderer(v2)
 	openURL := _wireOpenURLValue
 	headsUpDisplay := hud.NewHud(renderer, webURL, analytics3, openURL)
 	stdout := hud.ProvideStdout()
 	incrementalPrinter := hud.NewIncrementalPrinter(stdout)
-	terminalStream := hud.NewTerminalStream(incrementalPrinter, storeStore)
+	levelFilter := options.LogLevelFilter
+	terminalStream := hud.NewTerminalStream(incrementalPrinter, storeStore, levelFilter)
 	openInput := _wireOpenInputValue
 	terminalPrompt := prompt.NewTerminalPrompt(analytics3, openInput, openURL, stdout, webHost, webURL)
 	serviceWatcher := k8swatch.NewServiceWatcher(client, ownerFetcher, namespace)
+	eventWatchManager := k8swatch.NewEventWatchManager(client, ownerFetcher, namespace)
+	registryResyncer := k8swatch.NewRegistryResyncer(client)
+	clusterResyncers := engine.ProvideClusterResyncers(kubernetesdiscoveryReconciler, serviceWatcher, eventWatchManager, podlogstreamController, portforwardReconciler, registryResyncer)
+	clusterMonitor := k8swatch.NewClusterMonitor(client, clock, clusterResyncers)
 	buildClock := build.ProvideClock()
 	liveUpdateBuildAndDeployer := buildcontrol.NewLiveUpdateBuildAndDeployer(liveupdateReconciler, buildClock)
-	execCustomBuilder := build.NewExecCustomBuilder(switchCli, buildClock)
+	nerdctlClient := build.NewNerdctlClient()
+	execCustomBuilder := build.NewExecCustomBuilder(switchCli, nerdctlClient, buildClock)
 	clusterName := k8s.ProvideClusterName(ctx, apiConfig)
-	kindLoader := buildcontrol.NewKINDLoader(k8sEnv, clusterName)
-	imageBuildAndDeployer := buildcontrol.NewImageBuildAndDeployer(dockerBuilder, execCustomBuilder, client, k8sEnv, kubeContext, analytics3, buildClock, kindLoader, deferredClient, kubernetesapplyReconciler)
+	kindLoader := buildcontrol.NewKINDLoader(env, clusterName)
+	imageBuildAndDeployer := buildcontrol.NewImageBuildAndDeployer(dockerBuilder, nerdctlClient, execCustomBuilder, client, env, kubeContext, analytics3, buildClock, kindLoader, deferredClient, reconciler)
 	imageBuilder := buildcontrol.NewImageBuilder(dockerBuilder, execCustomBuilder)
 	dockerComposeBuildAndDeployer := buildcontrol.NewDockerComposeBuildAndDeployer(dockerComposeClient, switchCli, imageBuilder, buildClock)
 	localTargetBuildAndDeployer := buildcontrol.NewLocalTargetBuildAndDeployer(buildClock, deferredClient, cmdController)
-	buildOrder := engine.DefaultBuildOrder(liveUpdateBuildAndDeployer, imageBuildAndDeployer, dockerComposeBuildAndDeployer, localTargetBuildAndDeployer, updateMode, k8sEnv, runtime)
+	syncTargetBuildAndDeployer := buildcontrol.NewSyncTargetBuildAndDeployer(client, execUpdater, buildClock)
+	buildOrder := engine.DefaultBuildOrder(liveUpdateBuildAndDeployer, imageBuildAndDeployer, dockerComposeBuildAndDeployer, localTargetBuildAndDeployer, syncTargetBuildAndDeployer, env, runtime)
 	spanCollector := tracer.NewSpanCollector(ctx)
 	traceTracer := tracer.InitOpenTelemetry(spanCollector)
-	compositeBuildAndDeployer := engine.NewCompositeBuildAndDeployer(buildOrder, traceTracer)
+	compositeBuildAndDeployer := engine.NewCompositeBuildAndDeployer(buildOrder, updateMode, traceTracer)
 	buildController := engine.NewBuildController(compositeBuildAndDeployer)
 	configsController := configs.NewConfigsController(deferredClient)
-	triggerQueueSubscriber := configs.NewTriggerQueueSubscriber(deferredClient)
+	triggerQueueSubscriber := configs.NewTriggerQueueSubscriber(deferredClient, clock)
 	eventWatcher := dcwatch.NewEventWatcher(dockerComposeClient, localClient)
 	dockerComposeLogManager := runtimelog.NewDockerComposeLogManager(dockerComposeClient)
-	analyticsReporter := analytics2.ProvideAnalyticsReporter(analytics3, storeStore, client, k8sEnv)
 	analyticsUpdater := analytics2.NewAnalyticsUpdater(analytics3, cmdTags, engineMode)
-	eventWatchManager := k8swatch.NewEventWatchManager(client, ownerFetcher, namespace)
 	cloudStatusManager := cloud.NewStatusManager(httpClient, clock)
 	dockerPruner := dockerprune.NewDockerPruner(switchCli)
 	telemetryController := telemetry.NewController(buildClock, spanCollector)
-	serverController := local.NewServerController(deferredClient)
+	processSignaler := local.ProvideProcessSignaler()
+	serverController := local.NewServerController(deferredClient, processSignaler, clock)
 	podMonitor := k8srollout.NewPodMonitor()
-	sessionController := session.NewController(deferredClient, engineMode)
+	registryChecker := k8srollout.NewDockerRegistryChecker(switchCli)
+	imagePullMonitor := k8srollout.NewImagePullMonitor(registryChecker, clock)
+	pendingPodMonitor := k8srollout.NewPendingPodMonitor(client, clock)
+	pinMonitor := k8srollout.NewPinMonitor(deferredClient)
+	sessionAllowEmptyFlag := options.AllowEmpty
+	sessionCITimeoutFlag := options.CITimeout
+	versions := compat.ProvideVersions(clientsetOrError, switchCli)
+	sessionController := session.NewController(deferredClient, engineMode, sessionAllowEmptyFlag, sessionCITimeoutFlag, versions)
 	subscriber := uisession2.NewSubscriber(deferredClient)
 	uiresourceSubscriber := uiresource2.NewSubscriber(deferredClient)
-	v3 := engine.ProvideSubscribers(headsUpServerController, tiltServerControllerManager, controllerBuilder, headsUpDisplay, terminalStream, terminalPrompt, serviceWatcher, buildController, configsController, triggerQueueSubscriber, eventWatcher, dockerComposeLogManager, analyticsReporter, analyticsUpdater, eventWatchManager, cloudStatusManager, dockerPruner, telemetryController, serverController, podMonitor, sessionController, subscriber, uiresourceSubscriber)
+	updateModeRecorder := engine.NewUpdateModeRecorder(liveupdatesUpdateModeFlag, updateMode, kubeContext, clusterEnv)
+	resourceprefsFreshFlag := options.Fresh
+	resourceprefsSubscriber := resourceprefs.NewSubscriber(deferredClient, tiltDevDir, resourceprefsFreshFlag)
+	timeout := options.IdleTimeout
+	sleepers := engine.ProvideSleepers(controller, podlogstreamController, eventWatchManager)
+	idleController := idle.NewController(timeout, clock, sleepers)
+	warningThreshold := options.MemoryWarningThreshold
+	monitor := memory.NewMonitor(warningThreshold, clock)
+	v3 := tiltengine.ProvideSubscribers(exclude, extraSubscribers, headsUpServerController, tiltServerControllerManager, controllerBuilder, headsUpDisplay, terminalStream, terminalPrompt, serviceWatcher, clusterMonitor, buildController, configsController, triggerQueueSubscriber, eventWatcher, dockerComposeLogManager, analyticsReporter, analyticsUpdater, eventWatchManager, cloudStatusManager, dockerPruner, telemetryController, serverController, podMonitor, imagePullMonitor, pendingPodMonitor, pinMonitor, sessionController, subscriber, uiresourceSubscriber, updateModeRecorder, resourceprefsSubscriber, debugcontainerReconciler, idleController, monitor)
 	upper, err := engine.NewUpper(ctx, storeStore, v3)
 	if err != nil {
 		return CmdUpDeps{}, err
@@ -361,6 +443,7 @@ func wireCmdUp(ctx context.Context, analytics3 *analytics.TiltAnalytics, cmdTags
 		CloudAddress: address,
 		Prompt:       terminalPrompt,
 		Snapshotter:  snapshotter,
+		Versions:     versions,
 	}
 	return cmdUpDeps, nil
 }
@@ -375,21 +458,25 @@ var (
 
 func wireCmdCI(ctx context.Context, analytics3 *analytics.TiltAnalytics, subcommand model.TiltSubcommand) (CmdCIDeps, error) {
 	reducer := _wireReducerValue
-	storeLogActionsFlag := provideLogActions()
-	storeStore := store.NewStore(reducer, storeLogActionsFlag)
+	options := provideEngineOptions()
+	storeLogActionsFlag := options.LogActions
+	actionJournalFlag := options.ActionJournal
+	storeStore := store.NewStore(reducer, storeLogActionsFlag, actionJournalFlag)
+	exclude := options.Exclude
+	extraSubscribers := options.ExtraSubscribers
 	tiltDevDir, err := dirs.UseTiltDevDir()
 	if err != nil {
 		return CmdCIDeps{}, err
 	}
 	configAccess := server.ProvideConfigAccess(tiltDevDir)
-	webPort := provideWebPort()
+	webPort := options.WebPort
 	apiServerName := model.ProvideAPIServerName(webPort)
-	webHost := provideWebHost()
-	webListener, err := server.ProvideWebListener(webHost, webPort)
+	webHost := options.WebHost
+	webListener, err := tiltengine.ProvideWebListener(webHost, webPort, exclude)
 	if err != nil {
 		return CmdCIDeps{}, err
 	}
-	tiltBuild := provideTiltInfo()
+	tiltBuild := options.TiltBuild
 	connProvider := server.ProvideMemConn()
 	bearerToken, err := server.NewBearerToken()
 	if err != nil {
@@ -408,12 +495,13 @@ func wireCmdCI(ctx context.Context, analytics3 *analytics.TiltAnalytics, subcomm
 	if err != nil {
 		return CmdCIDeps{}, err
 	}
-	webMode, err := provideWebMode(tiltBuild)
+	webMode, err := tiltengine.ProvideWebMode(options)
 	if err != nil {
 		return CmdCIDeps{}, err
 	}
-	webVersion := provideWebVersion(tiltBuild)
-	assetsServer, err := provideAssetServer(webMode, webVersion)
+	webVersion := tiltengine.ProvideWebVersion(tiltBuild)
+	modelWebDevPort := options.WebDevPort
+	assetsServer, err := tiltengine.ProvideAssetServer(webMode, webVersion, modelWebDevPort)
 	if err != nil {
 		return CmdCIDeps{}, err
 	}
@@ -422,37 +510,19 @@ func wireCmdCI(ctx context.Context, analytics3 *analytics.TiltAnalytics, subcomm
 	snapshotUploader := cloud.NewSnapshotUploader(httpClient, address)
 	websocketList := server.NewWebsocketList()
 	deferredClient := controllers.ProvideDeferredClient()
-	headsUpServer, err := server.ProvideHeadsUpServer(ctx, storeStore, assetsServer, analytics3, snapshotUploader, websocketList, deferredClient)
-	if err != nil {
-		return CmdCIDeps{}, err
-	}
-	webURL, err := provideWebURL(webHost, webPort)
-	if err != nil {
-		return CmdCIDeps{}, err
-	}
-	headsUpServerController := server.ProvideHeadsUpServerController(configAccess, apiServerName, webListener, apiserverConfig, headsUpServer, assetsServer, webURL)
-	scheme := v1alpha1.NewScheme()
-	uncachedObjects := controllers.ProvideUncachedObjects()
-	tiltServerControllerManager, err := controllers.NewTiltServerControllerManager(apiserverConfig, scheme, deferredClient, uncachedObjects)
+	webSecurityOptions := options.WebSecurity
+	webSecurity, err := server.ProvideWebSecurity(webSecurityOptions, apiServerName, webHost, base)
 	if err != nil {
 		return CmdCIDeps{}, err
 	}
-	watcherMaker := fsevent.ProvideWatcherMaker()
-	timerMaker := fsevent.ProvideTimerMaker()
-	controller := filewatch.NewController(deferredClient, storeStore, watcherMaker, timerMaker, scheme)
-	env := localexec.DefaultEnv(webPort, webHost)
-	execer := cmd.ProvideExecer(env)
-	proberManager := cmd.ProvideProberManager()
-	clock := clockwork.NewRealClock()
-	cmdController := cmd.NewController(ctx, execer, proberManager, deferredClient, storeStore, clock, scheme)
-	k8sKubeContextOverride := ProvideKubeContextOverride()
-	k8sNamespaceOverride := ProvideNamespaceOverride()
+	k8sKubeContextOverride := options.KubeContextOverride
+	k8sNamespaceOverride := options.NamespaceOverride
 	clientConfig := k8s.ProvideClientConfig(k8sKubeContextOverride, k8sNamespaceOverride)
 	apiConfig, err := k8s.ProvideKubeConfig(clientConfig, k8sKubeContextOverride)
 	if err != nil {
 		return CmdCIDeps{}, err
 	}
-	k8sEnv := k8s.ProvideEnv(ctx, apiConfig)
+	env := k8s.ProvideEnv(ctx, apiConfig)
 	restConfigOrError := k8s.ProvideRESTConfig(clientConfig)
 	clientsetOrError := k8s.ProvideClientset(restConfigOrError)
 	portForwardClient := k8s.ProvidePortForwardClient(restConfigOrError, clientsetOrError)
@@ -462,15 +532,12 @@ func wireCmdCI(ctx context.Context, analytics3 *analytics.TiltAnalytics, subcomm
 		return CmdCIDeps{}, err
 	}
 	minikubeClient := k8s.ProvideMinikubeClient(kubeContext)
-	client := k8s.ProvideK8sClient(ctx, k8sEnv, restConfigOrError, clientsetOrError, portForwardClient, namespace, minikubeClient, clientConfig)
-	podSource := podlogstream.NewPodSource(ctx, client, scheme)
-	podlogstreamController := podlogstream.NewController(ctx, deferredClient, storeStore, client, podSource)
-	ownerFetcher := k8s.ProvideOwnerFetcher(ctx, client)
-	containerRestartDetector := kubernetesdiscovery.NewContainerRestartDetector()
-	reconciler := kubernetesdiscovery.NewReconciler(deferredClient, client, ownerFetcher, containerRestartDetector, storeStore)
+	client := k8s.ProvideK8sClient(ctx, env, restConfigOrError, clientsetOrError, portForwardClient, namespace, minikubeClient, clientConfig)
+	analyticsReporter := analytics2.ProvideAnalyticsReporter(analytics3, storeStore, client, env)
+	scheme := v1alpha1.NewScheme()
 	runtime := k8s.ProvideContainerRuntime(ctx, client)
-	clusterEnv := docker.ProvideClusterEnv(ctx, kubeContext, k8sEnv, runtime, minikubeClient)
-	localEnv := docker.ProvideLocalEnv(ctx, kubeContext, k8sEnv, clusterEnv)
+	clusterEnv := docker.ProvideClusterEnv(ctx, kubeContext, env, runtime, minikubeClient)
+	localEnv := docker.ProvideLocalEnv(ctx, kubeContext, env, clusterEnv)
 	localClient := docker.ProvideLocalCli(ctx, localEnv)
 	clusterClient, err := docker.ProvideClusterCli(ctx, localEnv, clusterEnv, localClient)
 	if err != nil {
@@ -480,21 +547,54 @@ func wireCmdCI(ctx context.Context, analytics3 *analytics.TiltAnalytics, subcomm
 	labels := _wireLabelsValue
 	dockerImageBuilder := build.NewDockerImageBuilder(switchCli, labels)
 	dockerBuilder := build.DefaultDockerBuilder(dockerImageBuilder)
-	processExecer := localexec.NewProcessExecer(env)
-	kubernetesapplyReconciler := kubernetesapply.NewReconciler(deferredClient, client, scheme, dockerBuilder, kubeContext, storeStore, namespace, processExecer)
+	localexecEnv := localexec.DefaultEnv(webPort, webHost)
+	processExecer := localexec.NewProcessExecer(localexecEnv)
+	kubernetesapplyVerboseApplyFlag := options.VerboseApply
+	reconciler := kubernetesapply.NewReconciler(deferredClient, client, scheme, dockerBuilder, kubeContext, storeStore, namespace, processExecer, kubernetesapplyVerboseApplyFlag)
+	debugcontainerReconciler := debugcontainer.NewReconciler(deferredClient, client, storeStore)
+	portforwardReconciler := portforward.NewReconciler(deferredClient, storeStore, client)
+	headsUpServer, err := server.ProvideHeadsUpServer(ctx, storeStore, assetsServer, analytics3, snapshotUploader, websocketList, deferredClient, webSecurity, analyticsReporter, reconciler, reconciler, debugcontainerReconciler, portforwardReconciler)
+	if err != nil {
+		return CmdCIDeps{}, err
+	}
+	webURL, err := tiltengine.ProvideWebURL(webHost, webPort, webSecurityOptions, exclude)
+	if err != nil {
+		return CmdCIDeps{}, err
+	}
+	headsUpServerController := server.ProvideHeadsUpServerController(configAccess, apiServerName, webListener, apiserverConfig, headsUpServer, assetsServer, webURL, webSecurity)
+	uncachedObjects := controllers.ProvideUncachedObjects()
+	tiltServerControllerManager, err := controllers.NewTiltServerControllerManager(apiserverConfig, scheme, deferredClient, uncachedObjects)
+	if err != nil {
+		return CmdCIDeps{}, err
+	}
+	watcherMaker := fsevent.ProvideWatcherMaker()
+	timerMaker := fsevent.ProvideTimerMaker()
+	controller := filewatch.NewController(deferredClient, storeStore, watcherMaker, timerMaker, scheme)
+	execer := cmd.ProvideExecer(localexecEnv)
+	proberManager := cmd.ProvideProberManager()
+	clock := clockwork.NewRealClock()
+	cmdController := cmd.NewController(ctx, execer, proberManager, deferredClient, storeStore, clock, scheme)
+	podSource := podlogstream.NewPodSource(ctx, client, scheme)
+	dialer := agent.ProvideDialer(restConfigOrError)
+	installer := agent.NewInstaller(client, dialer, tiltBuild)
+	podlogstreamController := podlogstream.NewController(ctx, deferredClient, storeStore, client, podSource, installer)
+	ownerFetcher := k8s.ProvideOwnerFetcher(ctx, client)
+	containerRestartDetector := kubernetesdiscovery.NewContainerRestartDetector()
+	kubernetesdiscoveryReconciler := kubernetesdiscovery.NewReconciler(deferredClient, client, ownerFetcher, containerRestartDetector, storeStore)
 	uisessionReconciler := uisession.NewReconciler(deferredClient, websocketList)
 	uiresourceReconciler := uiresource.NewReconciler(deferredClient, websocketList, storeStore)
 	uibuttonReconciler := uibutton.NewReconciler(deferredClient, websocketList)
-	portforwardReconciler := portforward.NewReconciler(deferredClient, storeStore, client)
-	plugin := k8scontext.NewPlugin(kubeContext, k8sEnv)
+	plugin := k8scontext.ProvidePlugin(kubeContext, env, clientConfig, k8sKubeContextOverride)
 	versionPlugin := version.NewPlugin(tiltBuild)
 	configPlugin := config.NewPlugin(subcommand)
 	dockerComposeClient := dockercompose.NewDockerComposeClient(localEnv)
 	defaults := _wireDefaultsValue
-	tiltfileLoader := tiltfile.ProvideTiltfileLoader(analytics3, client, plugin, versionPlugin, configPlugin, dockerComposeClient, webHost, processExecer, defaults, k8sEnv)
+	execLimits := options.TiltfileExecLimits
+	tiltfileLoader := tiltfile.ProvideTiltfileLoader(analytics3, client, plugin, versionPlugin, configPlugin, dockerComposeClient, webHost, processExecer, defaults, env, execLimits)
 	buildSource := tiltfile2.NewBuildSource()
 	engineMode := _wireStoreEngineModeValue
-	tiltfileReconciler := tiltfile2.NewReconciler(storeStore, tiltfileLoader, switchCli, deferredClient, scheme, buildSource, engineMode)
+	tiltfileForceBootstrapFlag := options.ForceBootstrap
+	tiltfileReconciler := tiltfile2.NewReconciler(storeStore, tiltfileLoader, switchCli, deferredClient, scheme, buildSource, engineMode, client, namespace, processExecer, tiltfileForceBootstrapFlag)
 	togglebuttonReconciler := togglebutton.NewReconciler(deferredClient, scheme)
 	extensionReconciler := extension.NewReconciler(deferredClient, scheme, analytics3)
 	extensionrepoReconciler, err := extensionrepo.NewReconciler(deferredClient, base)
@@ -503,56 +603,78 @@ func wireCmdCI(ctx context.Context, analytics3 *analytics.TiltAnalytics, subcomm
 	}
 	dockerUpdater := containerupdate.NewDockerUpdater(switchCli)
 	execUpdater := containerupdate.NewExecUpdater(client)
-	liveupdatesUpdateModeFlag := provideUpdateModeFlag()
+	agentUpdater := containerupdate.NewAgentUpdater(installer)
+	liveupdatesUpdateModeFlag := options.UpdateMode
 	updateMode, err := liveupdates.ProvideUpdateMode(liveupdatesUpdateModeFlag, kubeContext, clusterEnv)
 	if err != nil {
 		return CmdCIDeps{}, err
 	}
-	liveupdateReconciler := liveupdate.NewReconciler(storeStore, dockerUpdater, execUpdater, updateMode, kubeContext, deferredClient, scheme)
+	liveupdateReconciler := liveupdate.NewReconciler(storeStore, dockerUpdater, execUpdater, agentUpdater, installer, updateMode, kubeContext, client, deferredClient, scheme)
 	configmapReconciler := configmap.NewReconciler(deferredClient, storeStore)
-	v := controllers.ProvideControllers(controller, cmdController, podlogstreamController, reconciler, kubernetesapplyReconciler, uisessionReconciler, uiresourceReconciler, uibuttonReconciler, portforwardReconciler, tiltfileReconciler, togglebuttonReconciler, extensionReconciler, extensionrepoReconciler, liveupdateReconciler, configmapReconciler)
+	v := controllers.ProvideControllers(controller, cmdController, podlogstreamController, kubernetesdiscoveryReconciler, reconciler, uisessionReconciler, uiresourceReconciler, uibuttonReconciler, portforwardReconciler, tiltfileReconciler, togglebuttonReconciler, extensionReconciler, extensionrepoReconciler, liveupdateReconciler, configmapReconciler, debugcontainerReconciler)
 	controllerBuilder := controllers.NewControllerBuilder(tiltServerControllerManager, v)
-	v2 := provideClock()
+	v2 := tiltengine.ProvideClock()
 	renderer := hud.NewRenderer(v2)
 	openURL := _wireOpenURLValue
 	headsUpDisplay := hud.NewHud(renderer, webURL, analytics3, openURL)
 	stdout := hud.ProvideStdout()
 	incrementalPrinter := hud.NewIncrementalPrinter(stdout)
-	terminalStream := hud.NewTerminalStream(incrementalPrinter, storeStore)
+	levelFilter := options.LogLevelFilter
+	terminalStream := hud.NewTerminalStream(incrementalPrinter, storeStore, levelFilter)
 	openInput := _wireOpenInputValue
 	terminalPrompt := prompt.NewTerminalPrompt(analytics3, openInput, openURL, stdout, webHost, webURL)
 	serviceWatcher := k8swatch.NewServiceWatcher(client, ownerFetcher, namespace)
+	eventWatchManager := k8swatch.NewEventWatchManager(client, ownerFetcher, namespace)
+	registryResyncer := k8swatch.NewRegistryResyncer(client)
+	clusterResyncers := engine.ProvideClusterResyncers(kubernetesdiscoveryReconciler, serviceWatcher, eventWatchManager, podlogstreamController, portforwardReconciler, registryResyncer)
+	clusterMonitor := k8swatch.NewClusterMonitor(client, clock, clusterResyncers)
 	buildClock := build.ProvideClock()
 	liveUpdateBuildAndDeployer := buildcontrol.NewLiveUpdateBuildAndDeployer(liveupdateReconciler, buildClock)
-	execCustomBuilder := build.NewExecCustomBuilder(switchCli, buildClock)
+	nerdctlClient := build.NewNerdctlClient()
+	execCustomBuilder := build.NewExecCustomBuilder(switchCli, nerdctlClient, buildClock)
 	clusterName := k8s.ProvideClusterName(ctx, apiConfig)
-	kindLoader := buildcontrol.NewKINDLoader(k8sEnv, clusterName)
-	imageBuildAndDeployer := buildcontrol.NewImageBuildAndDeployer(dockerBuilder, execCustomBuilder, client, k8sEnv, kubeContext, analytics3, buildClock, kindLoader, deferredClient, kubernetesapplyReconciler)
+	kindLoader := buildcontrol.NewKINDLoader(env, clusterName)
+	imageBuildAndDeployer := buildcontrol.NewImageBuildAndDeployer(dockerBuilder, nerdctlClient, execCustomBuilder, client, env, kubeContext, analytics3, buildClock, kindLoader, deferredClient, reconciler)
 	imageBuilder := buildcontrol.NewImageBuilder(dockerBuilder, execCustomBuilder)
 	dockerComposeBuildAndDeployer := buildcontrol.NewDockerComposeBuildAndDeployer(dockerComposeClient, switchCli, imageBuilder, buildClock)
 	localTargetBuildAndDeployer := buildcontrol.NewLocalTargetBuildAndDeployer(buildClock, deferredClient, cmdController)
-	buildOrder := engine.DefaultBuildOrder(liveUpdateBuildAndDeployer, imageBuildAndDeployer, dockerComposeBuildAndDeployer, localTargetBuildAndDeployer, updateMode, k8sEnv, runtime)
+	syncTargetBuildAndDeployer := buildcontrol.NewSyncTargetBuildAndDeployer(client, execUpdater, buildClock)
+	buildOrder := engine.DefaultBuildOrder(liveUpdateBuildAndDeployer, imageBuildAndDeployer, dockerComposeBuildAndDeployer, localTargetBuildAndDeployer, syncTargetBuildAndDeployer, env, runtime)
 	spanCollector := tracer.NewSpanCollector(ctx)
 	traceTracer := tracer.InitOpenTelemetry(spanCollector)
-	compositeBuildAndDeployer := engine.NewCompositeBuildAndDeployer(buildOrder, traceTracer)
+	compositeBuildAndDeployer := engine.NewCompositeBuildAndDeployer(buildOrder, updateMode, traceTracer)
 	buildController := engine.NewBuildController(compositeBuildAndDeployer)
 	configsController := configs.NewConfigsController(deferredClient)
-	triggerQueueSubscriber := configs.NewTriggerQueueSubscriber(deferredClient)
+	triggerQueueSubscriber := configs.NewTriggerQueueSubscriber(deferredClient, clock)
 	eventWatcher := dcwatch.NewEventWatcher(dockerComposeClient, localClient)
 	dockerComposeLogManager := runtimelog.NewDockerComposeLogManager(dockerComposeClient)
-	analyticsReporter := analytics2.ProvideAnalyticsReporter(analytics3, storeStore, client, k8sEnv)
 	cmdTags := _wireCmdTagsValue
 	analyticsUpdater := analytics2.NewAnalyticsUpdater(analytics3, cmdTags, engineMode)
-	eventWatchManager := k8swatch.NewEventWatchManager(client, ownerFetcher, namespace)
 	cloudStatusManager := cloud.NewStatusManager(httpClient, clock)
 	dockerPruner := dockerprune.NewDockerPruner(switchCli)
 	telemetryController := telemetry.NewController(buildClock, spanCollector)
-	serverController := local.NewServerController(deferredClient)
+	processSignaler := local.ProvideProcessSignaler()
+	serverController := local.NewServerController(deferredClient, processSignaler, clock)
 	podMonitor := k8srollout.NewPodMonitor()
-	sessionController := session.NewController(deferredClient, engineMode)
+	registryChecker := k8srollout.NewDockerRegistryChecker(switchCli)
+	imagePullMonitor := k8srollout.NewImagePullMonitor(registryChecker, clock)
+	pendingPodMonitor := k8srollout.NewPendingPodMonitor(client, clock)
+	pinMonitor := k8srollout.NewPinMonitor(deferredClient)
+	sessionAllowEmptyFlag := options.AllowEmpty
+	sessionCITimeoutFlag := options.CITimeout
+	versions := compat.ProvideVersions(clientsetOrError, switchCli)
+	sessionController := session.NewController(deferredClient, engineMode, sessionAllowEmptyFlag, sessionCITimeoutFlag, versions)
 	subscriber := uisession2.NewSubscriber(deferredClient)
 	uiresourceSubscriber := uiresource2.NewSubscriber(deferredClient)
-	v3 := engine.ProvideSubscribers(headsUpServerController, tiltServerControllerManager, controllerBuilder, headsUpDisplay, terminalStream, terminalPrompt, serviceWatcher, buildController, configsController, triggerQueueSubscriber, eventWatcher, dockerComposeLogManager, analyticsReporter, analyticsUpdater, eventWatchManager, cloudStatusManager, dockerPruner, telemetryController, serverController, podMonitor, sessionController, subscriber, uiresourceSubscriber)
+	updateModeRecorder := engine.NewUpdateModeRecorder(liveupdatesUpdateModeFlag, updateMode, kubeContext, clusterEnv)
+	resourceprefsFreshFlag := options.Fresh
+	resourceprefsSubscriber := resourceprefs.NewSubscriber(deferredClient, tiltDevDir, resourceprefsFreshFlag)
+	timeout := options.IdleTimeout
+	sleepers := engine.ProvideSleepers(controller, podlogstreamController, eventWatchManager)
+	idleController := idle.NewController(timeout, clock, sleepers)
+	warningThreshold := options.MemoryWarningThreshold
+	monitor := memory.NewMonitor(warningThreshold, clock)
+	v3 := tiltengine.ProvideSubscribers(exclude, extraSubscribers, headsUpServerController, tiltServerControllerManager, controllerBuilder, headsUpDisplay, terminalStream, terminalPrompt, serviceWatcher, clusterMonitor, buildController, configsController, triggerQueueSubscriber, eventWatcher, dockerComposeLogManager, analyticsReporter, analyticsUpdater, eventWatchManager, cloudStatusManager, dockerPruner, telemetryController, serverController, podMonitor, imagePullMonitor, pendingPodMonitor, pinMonitor, sessionController, subscriber, uiresourceSubscriber, updateModeRecorder, resourceprefsSubscriber, debugcontainerReconciler, idleController, monitor)
 	upper, err := engine.NewUpper(ctx, storeStore, v3)
 	if err != nil {
 		return CmdCIDeps{}, err
@@ -568,6 +690,7 @@ func wireCmdCI(ctx context.Context, analytics3 *analytics.TiltAnalytics, subcomm
 		Token:        tokenToken,
 		CloudAddress: address,
 		Snapshotter:  snapshotter,
+		Versions:     versions,
 	}
 	return cmdCIDeps, nil
 }
@@ -579,21 +702,24 @@ var (
 
 func wireCmdUpdog(ctx context.Context, analytics3 *analytics.TiltAnalytics, cmdTags analytics2.CmdTags, subcommand model.TiltSubcommand, objects []client.Object) (CmdUpdogDeps, error) {
 	reducer := _wireReducerValue
-	storeLogActionsFlag := provideLogActions()
-	storeStore := store.NewStore(reducer, storeLogActionsFlag)
+	options := provideEngineOptions()
+	storeLogActionsFlag := options.LogActions
+	actionJournalFlag := options.ActionJournal
+	storeStore := store.NewStore(reducer, storeLogActionsFlag, actionJournalFlag)
 	tiltDevDir, err := dirs.UseTiltDevDir()
 	if err != nil {
 		return CmdUpdogDeps{}, err
 	}
 	configAccess := server.ProvideConfigAccess(tiltDevDir)
-	webPort := provideWebPort()
+	webPort := options.WebPort
 	apiServerName := model.ProvideAPIServerName(webPort)
-	webHost := provideWebHost()
-	webListener, err := server.ProvideWebListener(webHost, webPort)
+	webHost := options.WebHost
+	exclude := options.Exclude
+	webListener, err := tiltengine.ProvideWebListener(webHost, webPort, exclude)
 	if err != nil {
 		return CmdUpdogDeps{}, err
 	}
-	tiltBuild := provideTiltInfo()
+	tiltBuild := options.TiltBuild
 	connProvider := server.ProvideMemConn()
 	bearerToken, err := server.NewBearerToken()
 	if err != nil {
@@ -612,12 +738,13 @@ func wireCmdUpdog(ctx context.Context, analytics3 *analytics.TiltAnalytics, cmdT
 	if err != nil {
 		return CmdUpdogDeps{}, err
 	}
-	webMode, err := provideWebMode(tiltBuild)
+	webMode, err := tiltengine.ProvideWebMode(options)
 	if err != nil {
 		return CmdUpdogDeps{}, err
 	}
-	webVersion := provideWebVersion(tiltBuild)
-	assetsServer, err := provideAssetServer(webMode, webVersion)
+	webVersion := tiltengine.ProvideWebVersion(tiltBuild)
+	modelWebDevPort := options.WebDevPort
+	assetsServer, err := tiltengine.ProvideAssetServer(webMode, webVersion, modelWebDevPort)
 	if err != nil {
 		return CmdUpdogDeps{}, err
 	}
@@ -626,37 +753,19 @@ func wireCmdUpdog(ctx context.Context, analytics3 *analytics.TiltAnalytics, cmdT
 	snapshotUploader := cloud.NewSnapshotUploader(httpClient, address)
 	websocketList := server.NewWebsocketList()
 	deferredClient := controllers.ProvideDeferredClient()
-	headsUpServer, err := server.ProvideHeadsUpServer(ctx, storeStore, assetsServer, analytics3, snapshotUploader, websocketList, deferredClient)
-	if err != nil {
-		return CmdUpdogDeps{}, err
-	}
-	webURL, err := provideWebURL(webHost, webPort)
-	if err != nil {
-		return CmdUpdogDeps{}, err
-	}
-	headsUpServerController := server.ProvideHeadsUpServerController(configAccess, apiServerName, webListener, apiserverConfig, headsUpServer, assetsServer, webURL)
-	scheme := v1alpha1.NewScheme()
-	uncachedObjects := controllers.ProvideUncachedObjects()
-	tiltServerControllerManager, err := controllers.NewTiltServerControllerManager(apiserverConfig, scheme, deferredClient, uncachedObjects)
+	webSecurityOptions := options.WebSecurity
+	webSecurity, err := server.ProvideWebSecurity(webSecurityOptions, apiServerName, webHost, base)
 	if err != nil {
 		return CmdUpdogDeps{}, err
 	}
-	watcherMaker := fsevent.ProvideWatcherMaker()
-	timerMaker := fsevent.ProvideTimerMaker()
-	controller := filewatch.NewController(deferredClient, storeStore, watcherMaker, timerMaker, scheme)
-	env := localexec.DefaultEnv(webPort, webHost)
-	execer := cmd.ProvideExecer(env)
-	proberManager := cmd.ProvideProberManager()
-	clock := clockwork.NewRealClock()
-	cmdController := cmd.NewController(ctx, execer, proberManager, deferredClient, storeStore, clock, scheme)
-	k8sKubeContextOverride := ProvideKubeContextOverride()
-	k8sNamespaceOverride := ProvideNamespaceOverride()
+	k8sKubeContextOverride := options.KubeContextOverride
+	k8sNamespaceOverride := options.NamespaceOverride
 	clientConfig := k8s.ProvideClientConfig(k8sKubeContextOverride, k8sNamespaceOverride)
 	apiConfig, err := k8s.ProvideKubeConfig(clientConfig, k8sKubeContextOverride)
 	if err != nil {
 		return CmdUpdogDeps{}, err
 	}
-	k8sEnv := k8s.ProvideEnv(ctx, apiConfig)
+	env := k8s.ProvideEnv(ctx, apiConfig)
 	restConfigOrError := k8s.ProvideRESTConfig(clientConfig)
 	clientsetOrError := k8s.ProvideClientset(restConfigOrError)
 	portForwardClient := k8s.ProvidePortForwardClient(restConfigOrError, clientsetOrError)
@@ -666,15 +775,12 @@ func wireCmdUpdog(ctx context.Context, analytics3 *analytics.TiltAnalytics, cmdT
 		return CmdUpdogDeps{}, err
 	}
 	minikubeClient := k8s.ProvideMinikubeClient(kubeContext)
-	k8sClient := k8s.ProvideK8sClient(ctx, k8sEnv, restConfigOrError, clientsetOrError, portForwardClient, namespace, minikubeClient, clientConfig)
-	podSource := podlogstream.NewPodSource(ctx, k8sClient, scheme)
-	podlogstreamController := podlogstream.NewController(ctx, deferredClient, storeStore, k8sClient, podSource)
-	ownerFetcher := k8s.ProvideOwnerFetcher(ctx, k8sClient)
-	containerRestartDetector := kubernetesdiscovery.NewContainerRestartDetector()
-	reconciler := kubernetesdiscovery.NewReconciler(deferredClient, k8sClient, ownerFetcher, containerRestartDetector, storeStore)
+	k8sClient := k8s.ProvideK8sClient(ctx, env, restConfigOrError, clientsetOrError, portForwardClient, namespace, minikubeClient, clientConfig)
+	analyticsReporter := analytics2.ProvideAnalyticsReporter(analytics3, storeStore, k8sClient, env)
+	scheme := v1alpha1.NewScheme()
 	runtime := k8s.ProvideContainerRuntime(ctx, k8sClient)
-	clusterEnv := docker.ProvideClusterEnv(ctx, kubeContext, k8sEnv, runtime, minikubeClient)
-	localEnv := docker.ProvideLocalEnv(ctx, kubeContext, k8sEnv, clusterEnv)
+	clusterEnv := docker.ProvideClusterEnv(ctx, kubeContext, env, runtime, minikubeClient)
+	localEnv := docker.ProvideLocalEnv(ctx, kubeContext, env, clusterEnv)
 	localClient := docker.ProvideLocalCli(ctx, localEnv)
 	clusterClient, err := docker.ProvideClusterCli(ctx, localEnv, clusterEnv, localClient)
 	if err != nil {
@@ -684,21 +790,54 @@ func wireCmdUpdog(ctx context.Context, analytics3 *analytics.TiltAnalytics, cmdT
 	labels := _wireLabelsValue
 	dockerImageBuilder := build.NewDockerImageBuilder(switchCli, labels)
 	dockerBuilder := build.DefaultDockerBuilder(dockerImageBuilder)
-	processExecer := localexec.NewProcessExecer(env)
-	kubernetesapplyReconciler := kubernetesapply.NewReconciler(deferredClient, k8sClient, scheme, dockerBuilder, kubeContext, storeStore, namespace, processExecer)
+	localexecEnv := localexec.DefaultEnv(webPort, webHost)
+	processExecer := localexec.NewProcessExecer(localexecEnv)
+	kubernetesapplyVerboseApplyFlag := options.VerboseApply
+	reconciler := kubernetesapply.NewReconciler(deferredClient, k8sClient, scheme, dockerBuilder, kubeContext, storeStore, namespace, processExecer, kubernetesapplyVerboseApplyFlag)
+	debugcontainerReconciler := debugcontainer.NewReconciler(deferredClient, k8sClient, storeStore)
+	portforwardReconciler := portforward.NewReconciler(deferredClient, storeStore, k8sClient)
+	headsUpServer, err := server.ProvideHeadsUpServer(ctx, storeStore, assetsServer, analytics3, snapshotUploader, websocketList, deferredClient, webSecurity, analyticsReporter, reconciler, reconciler, debugcontainerReconciler, portforwardReconciler)
+	if err != nil {
+		return CmdUpdogDeps{}, err
+	}
+	webURL, err := tiltengine.ProvideWebURL(webHost, webPort, webSecurityOptions, exclude)
+	if err != nil {
+		return CmdUpdogDeps{}, err
+	}
+	headsUpServerController := server.ProvideHeadsUpServerController(configAccess, apiServerName, webListener, apiserverConfig, headsUpServer, assetsServer, webURL, webSecurity)
+	uncachedObjects := controllers.ProvideUncachedObjects()
+	tiltServerControllerManager, err := controllers.NewTiltServerControllerManager(apiserverConfig, scheme, deferredClient, uncachedObjects)
+	if err != nil {
+		return CmdUpdogDeps{}, err
+	}
+	watcherMaker := fsevent.ProvideWatcherMaker()
+	timerMaker := fsevent.ProvideTimerMaker()
+	controller := filewatch.NewController(deferredClient, storeStore, watcherMaker, timerMaker, scheme)
+	execer := cmd.ProvideExecer(localexecEnv)
+	proberManager := cmd.ProvideProberManager()
+	clock := clockwork.NewRealClock()
+	cmdController := cmd.NewController(ctx, execer, proberManager, deferredClient, storeStore, clock, scheme)
+	podSource := podlogstream.NewPodSource(ctx, k8sClient, scheme)
+	dialer := agent.ProvideDialer(restConfigOrError)
+	installer := agent.NewInstaller(k8sClient, dialer, tiltBuild)
+	podlogstreamController := podlogstream.NewController(ctx, deferredClient, storeStore, k8sClient, podSource, installer)
+	ownerFetcher := k8s.ProvideOwnerFetcher(ctx, k8sClient)
+	containerRestartDetector := kubernetesdiscovery.NewContainerRestartDetector()
+	kubernetesdiscoveryReconciler := kubernetesdiscovery.NewReconciler(deferredClient, k8sClient, ownerFetcher, containerRestartDetector, storeStore)
 	uisessionReconciler := uisession.NewReconciler(deferredClient, websocketList)
 	uiresourceReconciler := uiresource.NewReconciler(deferredClient, websocketList, storeStore)
 	uibuttonReconciler := uibutton.NewReconciler(deferredClient, websocketList)
-	portforwardReconciler := portforward.NewReconciler(deferredClient, storeStore, k8sClient)
-	plugin := k8scontext.NewPlugin(kubeContext, k8sEnv)
+	plugin := k8scontext.ProvidePlugin(kubeContext, env, clientConfig, k8sKubeContextOverride)
 	versionPlugin := version.NewPlugin(tiltBuild)
 	configPlugin := config.NewPlugin(subcommand)
 	dockerComposeClient := dockercompose.NewDockerComposeClient(localEnv)
 	defaults := _wireDefaultsValue
-	tiltfileLoader := tiltfile.ProvideTiltfileLoader(analytics3, k8sClient, plugin, versionPlugin, configPlugin, dockerComposeClient, webHost, processExecer, defaults, k8sEnv)
+	execLimits := options.TiltfileExecLimits
+	tiltfileLoader := tiltfile.ProvideTiltfileLoader(analytics3, k8sClient, plugin, versionPlugin, configPlugin, dockerComposeClient, webHost, processExecer, defaults, env, execLimits)
 	buildSource := tiltfile2.NewBuildSource()
 	engineMode := _wireEngineModeValue2
-	tiltfileReconciler := tiltfile2.NewReconciler(storeStore, tiltfileLoader, switchCli, deferredClient, scheme, buildSource, engineMode)
+	tiltfileForceBootstrapFlag := options.ForceBootstrap
+	tiltfileReconciler := tiltfile2.NewReconciler(storeStore, tiltfileLoader, switchCli, deferredClient, scheme, buildSource, engineMode, k8sClient, namespace, processExecer, tiltfileForceBootstrapFlag)
 	togglebuttonReconciler := togglebutton.NewReconciler(deferredClient, scheme)
 	extensionReconciler := extension.NewReconciler(deferredClient, scheme, analytics3)
 	extensionrepoReconciler, err := extensionrepo.NewReconciler(deferredClient, base)
@@ -707,18 +846,20 @@ func wireCmdUpdog(ctx context.Context, analytics3 *analytics.TiltAnalytics, cmdT
 	}
 	dockerUpdater := containerupdate.NewDockerUpdater(switchCli)
 	execUpdater := containerupdate.NewExecUpdater(k8sClient)
-	liveupdatesUpdateModeFlag := provideUpdateModeFlag()
+	agentUpdater := containerupdate.NewAgentUpdater(installer)
+	liveupdatesUpdateModeFlag := options.UpdateMode
 	updateMode, err := liveupdates.ProvideUpdateMode(liveupdatesUpdateModeFlag, kubeContext, clusterEnv)
 	if err != nil {
 		return CmdUpdogDeps{}, err
 	}
-	liveupdateReconciler := liveupdate.NewReconciler(storeStore, dockerUpdater, execUpdater, updateMode, kubeContext, deferredClient, scheme)
+	liveupdateReconciler := liveupdate.NewReconciler(storeStore, dockerUpdater, execUpdater, agentUpdater, installer, updateMode, kubeContext, k8sClient, deferredClient, scheme)
 	configmapReconciler := configmap.NewReconciler(deferredClient, storeStore)
-	v := controllers.ProvideControllers(controller, cmdController, podlogstreamController, reconciler, kubernetesapplyReconciler, uisessionReconciler, uiresourceReconciler, uibuttonReconciler, portforwardReconciler, tiltfileReconciler, togglebuttonReconciler, extensionReconciler, extensionrepoReconciler, liveupdateReconciler, configmapReconciler)
+	v := controllers.ProvideControllers(controller, cmdController, podlogstreamController, kubernetesdiscoveryReconciler, reconciler, uisessionReconciler, uiresourceReconciler, uibuttonReconciler, portforwardReconciler, tiltfileReconciler, togglebuttonReconciler, extensionReconciler, extensionrepoReconciler, liveupdateReconciler, configmapReconciler, debugcontainerReconciler)
 	controllerBuilder := controllers.NewControllerBuilder(tiltServerControllerManager, v)
 	stdout := hud.ProvideStdout()
 	incrementalPrinter := hud.NewIncrementalPrinter(stdout)
-	terminalStream := hud.NewTerminalStream(incrementalPrinter, storeStore)
+	levelFilter := options.LogLevelFilter
+	terminalStream := hud.NewTerminalStream(incrementalPrinter, storeStore, levelFilter)
 	cliUpdogSubscriber := provideUpdogSubscriber(objects, deferredClient)
 	v2 := provideUpdogCmdSubscribers(headsUpServerController, tiltServerControllerManager, controllerBuilder, terminalStream, cliUpdogSubscriber)
 	upper, err := engine.NewUpper(ctx, storeStore, v2)
@@ -860,8 +1001,9 @@ func wireK8sVersion(ctx context.Context) (*version2.Info, error) {
 }
 
 func wireDockerClusterClient(ctx context.Context) (docker.ClusterClient, error) {
-	k8sKubeContextOverride := ProvideKubeContextOverride()
-	k8sNamespaceOverride := ProvideNamespaceOverride()
+	options := provideEngineOptions()
+	k8sKubeContextOverride := options.KubeContextOverride
+	k8sNamespaceOverride := options.NamespaceOverride
 	clientConfig := k8s.ProvideClientConfig(k8sKubeContextOverride, k8sNamespaceOverride)
 	apiConfig, err := k8s.ProvideKubeConfig(clientConfig, k8sKubeContextOverride)
 	if err != nil {
@@ -890,8 +1032,9 @@ func wireDockerClusterClient(ctx context.Context) (docker.ClusterClient, error)
 }
 
 func wireDockerLocalClient(ctx context.Context) (docker.LocalClient, error) {
-	k8sKubeContextOverride := ProvideKubeContextOverride()
-	k8sNamespaceOverride := ProvideNamespaceOverride()
+	options := provideEngineOptions()
+	k8sKubeContextOverride := options.KubeContextOverride
+	k8sNamespaceOverride := options.NamespaceOverride
 	clientConfig := k8s.ProvideClientConfig(k8sKubeContextOverride, k8sNamespaceOverride)
 	apiConfig, err := k8s.ProvideKubeConfig(clientConfig, k8sKubeContextOverride)
 	if err != nil {
@@ -916,8 +1059,9 @@ func wireDockerLocalClient(ctx context.Context) (docker.LocalClient, error) {
 }
 
 func wireDownDeps(ctx context.Context, tiltAnalytics *analytics.TiltAnalytics, subcommand model.TiltSubcommand) (DownDeps, error) {
-	k8sKubeContextOverride := ProvideKubeContextOverride()
-	k8sNamespaceOverride := ProvideNamespaceOverride()
+	options := provideEngineOptions()
+	k8sKubeContextOverride := options.KubeContextOverride
+	k8sNamespaceOverride := options.NamespaceOverride
 	clientConfig := k8s.ProvideClientConfig(k8sKubeContextOverride, k8sNamespaceOverride)
 	apiConfig, err := k8s.ProvideKubeConfig(clientConfig, k8sKubeContextOverride)
 	if err != nil {
@@ -934,28 +1078,32 @@ func wireDownDeps(ctx context.Context, tiltAnalytics *analytics.TiltAnalytics, s
 	}
 	minikubeClient := k8s.ProvideMinikubeClient(kubeContext)
 	k8sClient := k8s.ProvideK8sClient(ctx, env, restConfigOrError, clientsetOrError, portForwardClient, namespace, minikubeClient, clientConfig)
-	plugin := k8scontext.NewPlugin(kubeContext, env)
-	tiltBuild := provideTiltInfo()
+	plugin := k8scontext.ProvidePlugin(kubeContext, env, clientConfig, k8sKubeContextOverride)
+	tiltBuild := options.TiltBuild
 	versionPlugin := version.NewPlugin(tiltBuild)
 	configPlugin := config.NewPlugin(subcommand)
 	runtime := k8s.ProvideContainerRuntime(ctx, k8sClient)
 	clusterEnv := docker.ProvideClusterEnv(ctx, kubeContext, env, runtime, minikubeClient)
 	localEnv := docker.ProvideLocalEnv(ctx, kubeContext, env, clusterEnv)
 	dockerComposeClient := dockercompose.NewDockerComposeClient(localEnv)
-	webHost := provideWebHost()
-	webPort := provideWebPort()
+	webHost := options.WebHost
+	webPort := options.WebPort
 	localexecEnv := localexec.DefaultEnv(webPort, webHost)
 	processExecer := localexec.NewProcessExecer(localexecEnv)
 	defaults := _wireDefaultsValue
-	tiltfileLoader := tiltfile.ProvideTiltfileLoader(tiltAnalytics, k8sClient, plugin, versionPlugin, configPlugin, dockerComposeClient, webHost, processExecer, defaults, env)
-	downDeps := ProvideDownDeps(tiltfileLoader, dockerComposeClient, k8sClient)
+	execLimits := options.TiltfileExecLimits
+	tiltfileLoader := tiltfile.ProvideTiltfileLoader(tiltAnalytics, k8sClient, plugin, versionPlugin, configPlugin, dockerComposeClient, webHost, processExecer, defaults, env, execLimits)
+	downDeps := ProvideDownDeps(tiltfileLoader, dockerComposeClient, k8sClient, namespace)
 	return downDeps, nil
 }
 
 func wireLogsDeps(ctx context.Context, tiltAnalytics *analytics.TiltAnalytics, subcommand model.TiltSubcommand) (LogsDeps, error) {
-	webHost := provideWebHost()
-	webPort := provideWebPort()
-	webURL, err := provideWebURL(webHost, webPort)
+	options := provideEngineOptions()
+	webHost := options.WebHost
+	webPort := options.WebPort
+	webSecurityOptions := options.WebSecurity
+	exclude := options.Exclude
+	webURL, err := tiltengine.ProvideWebURL(webHost, webPort, webSecurityOptions, exclude)
 	if err != nil {
 		return LogsDeps{}, err
 	}
@@ -966,8 +1114,9 @@ func wireLogsDeps(ctx context.Context, tiltAnalytics *analytics.TiltAnalytics, s
 }
 
 func wireDumpImageDeployRefDeps(ctx context.Context) (DumpImageDeployRefDeps, error) {
-	k8sKubeContextOverride := ProvideKubeContextOverride()
-	k8sNamespaceOverride := ProvideNamespaceOverride()
+	options := provideEngineOptions()
+	k8sKubeContextOverride := options.KubeContextOverride
+	k8sNamespaceOverride := options.NamespaceOverride
 	clientConfig := k8s.ProvideClientConfig(k8sKubeContextOverride, k8sNamespaceOverride)
 	apiConfig, err := k8s.ProvideKubeConfig(clientConfig, k8sKubeContextOverride)
 	if err != nil {
@@ -1004,7 +1153,8 @@ func wireDumpImageDeployRefDeps(ctx context.Context) (DumpImageDeployRefDeps, er
 }
 
 func wireAnalytics(l logger.Logger, cmdName model.TiltSubcommand) (*analytics.TiltAnalytics, error) {
-	tiltBuild := provideTiltInfo()
+	options := provideEngineOptions()
+	tiltBuild := options.TiltBuild
 	gitRemote := git.ProvideGitRemote()
 	tiltAnalytics, err := newAnalytics(l, cmdName, tiltBuild, gitRemote)
 	if err != nil {
@@ -1018,7 +1168,8 @@ func wireClientGetter(ctx context.Context) (*client2.Getter, error) {
 	if err != nil {
 		return nil, err
 	}
-	webPort := provideWebPort()
+	options := provideEngineOptions()
+	webPort := options.WebPort
 	apiServerName := model.ProvideAPIServerName(webPort)
 	configAccess := server.ProvideConfigAccess(tiltDevDir)
 	tiltClientConfig, err := client2.ProvideClientConfig(apiServerName, configAccess)
@@ -1031,23 +1182,17 @@ func wireClientGetter(ctx context.Context) (*client2.Getter, error) {
 
 // wire.go:
 
-var K8sWireSet = wire.NewSet(k8s.ProvideEnv, k8s.ProvideClusterName, k8s.ProvideKubeContext, k8s.ProvideKubeConfig, k8s.ProvideClientConfig, k8s.ProvideClientset, k8s.ProvideRESTConfig, k8s.ProvidePortForwardClient, k8s.ProvideConfigNamespace, k8s.ProvideContainerRuntime, k8s.ProvideServerVersion, k8s.ProvideK8sClient, k8s.ProvideOwnerFetcher, ProvideKubeContextOverride,
+var K8sWireSet = wire.NewSet(tiltengine.K8sWireSet, ProvideKubeContextOverride,
 	ProvideNamespaceOverride)
 
-var BaseWireSet = wire.NewSet(
-	K8sWireSet, tiltfile.WireSet, git.ProvideGitRemote, localexec.DefaultEnv, localexec.NewProcessExecer, wire.Bind(new(localexec.Execer), new(*localexec.ProcessExecer)), docker.SwitchWireSet, dockercompose.NewDockerComposeClient, clockwork.NewRealClock, engine.DeployerWireSet, engine.NewBuildController, local.NewServerController, kubernetesdiscovery.NewContainerRestartDetector, k8swatch.NewServiceWatcher, k8swatch.NewEventWatchManager, uisession2.NewSubscriber, uiresource2.NewSubscriber, configs.NewConfigsController, configs.NewTriggerQueueSubscriber, telemetry.NewController, dcwatch.NewEventWatcher, runtimelog.NewDockerComposeLogManager, cloud.WireSet, cloudurl.ProvideAddress, k8srollout.NewPodMonitor, telemetry.NewStartTracker, session.NewController, build.ProvideClock, provideClock, hud.WireSet, prompt.WireSet, wire.Value(openurl.OpenURL(openurl.BrowserOpen)), provideLogActions, store.NewStore, wire.Bind(new(store.RStore), new(*store.Store)), dockerprune.NewDockerPruner, provideTiltInfo, engine.NewUpper, analytics2.NewAnalyticsUpdater, analytics2.ProvideAnalyticsReporter, provideUpdateModeFlag, fsevent.ProvideWatcherMaker, fsevent.ProvideTimerMaker, controllers.WireSet, provideWebVersion,
-	provideWebMode,
-	provideWebURL,
-	provideWebPort,
-	provideWebHost, server.WireSet, provideAssetServer, tracer.NewSpanCollector, wire.Bind(new(trace.SpanExporter), new(*tracer.SpanCollector)), wire.Bind(new(tracer.SpanSource), new(*tracer.SpanCollector)), dirs.UseTiltDevDir, xdg.NewTiltDevBase, token.GetOrCreateToken, buildcontrol.NewKINDLoader, wire.Value(feature.MainDefaults),
-)
+var BaseWireSet = wire.NewSet(tiltengine.K8sWireSet, tiltengine.ClientsWireSet, tiltengine.EngineWireSet, tiltengine.OptionsWireSet, provideEngineOptions)
 
 var CLIClientWireSet = wire.NewSet(
 	BaseWireSet, client2.WireSet,
 )
 
 var UpWireSet = wire.NewSet(
-	BaseWireSet, engine.ProvideSubscribers,
+	BaseWireSet, tiltengine.ProvideSubscribers,
 )
 
 type CmdUpDeps struct {
@@ -1057,6 +1202,7 @@ type CmdUpDeps struct {
 	CloudAddress cloudurl.Address
 	Prompt       *prompt.TerminalPrompt
 	Snapshotter  *cloud.Snapshotter
+	Versions     compat.Versions
 }
 
 type CmdCIDeps struct {
@@ -1065,6 +1211,7 @@ type CmdCIDeps struct {
 	Token        token.Token
 	CloudAddress cloudurl.Address
 	Snapshotter  *cloud.Snapshotter
+	Versions     compat.Versions
 }
 
 type CmdUpdogDeps struct {
@@ -1076,19 +1223,22 @@ type CmdUpdogDeps struct {
 }
 
 type DownDeps struct {
-	tfl      tiltfile.TiltfileLoader
-	dcClient dockercompose.DockerComposeClient
-	kClient  k8s.Client
+	tfl       tiltfile.TiltfileLoader
+	dcClient  dockercompose.DockerComposeClient
+	kClient   k8s.Client
+	namespace k8s.Namespace
 }
 
 func ProvideDownDeps(
 	tfl tiltfile.TiltfileLoader,
 	dcClient dockercompose.DockerComposeClient,
-	kClient k8s.Client) DownDeps {
+	kClient k8s.Client,
+	namespace k8s.Namespace) DownDeps {
 	return DownDeps{
-		tfl:      tfl,
-		dcClient: dcClient,
-		kClient:  kClient,
+		tfl:       tfl,
+		dcClient:  dcClient,
+		kClient:   kClient,
+		namespace: namespace,
 	}
 }
 
@@ -1104,10 +1254,6 @@ func ProvideLogsDeps(u model.WebURL, p *hud.IncrementalPrinter) LogsDeps {
 	}
 }
 
-func provideClock() func() time.Time {
-	return time.Now
-}
-
 type DumpImageDeployRefDeps struct {
 	DockerBuilder build.DockerBuilder
 	DockerClient  docker.Client
@@ -2,12 +2,15 @@ package cloud
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
 	"os"
 
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/pkg/errors"
 
 	"github.com/tilt-dev/tilt/internal/hud/webview"
 	"github.com/tilt-dev/tilt/internal/store"
@@ -28,7 +31,7 @@ func NewSnapshotter(st store.RStore, client ctrlclient.Client) *Snapshotter {
 }
 
 func (s *Snapshotter) WriteSnapshot(ctx context.Context, path string) {
-	view, err := webview.CompleteView(ctx, s.client, s.st)
+	view, err := webview.SnapshotView(ctx, s.client, s.st)
 	if err != nil {
 		logger.Get(ctx).Errorf("Fetching snapshot: %v", err)
 		return
@@ -54,3 +57,32 @@ func WriteSnapshotTo(ctx context.Context, snapshot *proto_webview.Snapshot, w io
 	}
 	return jsEncoder.NewEncoder(w).Encode(snapshot)
 }
+
+// Reads a snapshot written by WriteSnapshotTo.
+func ReadSnapshot(r io.Reader) (*proto_webview.Snapshot, error) {
+	var snapshot proto_webview.Snapshot
+	err := (&runtime.JSONPb{}).NewDecoder(r).Decode(&snapshot)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading snapshot")
+	}
+	if snapshot.View == nil {
+		return nil, errors.New("reading snapshot: no view")
+	}
+	return &snapshot, nil
+}
+
+// Identifies a snapshot by its contents, so that the same snapshot always
+// gets the same ID, no matter who saved it or how the file is formatted.
+//
+// UI state saved with the snapshot (like the path the user was looking at)
+// doesn't change the ID.
+func LocalSnapshotID(snapshot *proto_webview.Snapshot) (SnapshotID, error) {
+	// Map keys are sorted and fields are in declaration order,
+	// so the same view always encodes the same way.
+	b, err := (&runtime.JSONPb{}).Marshal(snapshot.View)
+	if err != nil {
+		return "", errors.Wrap(err, "encoding snapshot")
+	}
+	sum := sha256.Sum256(b)
+	return SnapshotID(hex.EncodeToString(sum[:8])), nil
+}
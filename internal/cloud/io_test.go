@@ -78,3 +78,46 @@ func TestWriteSnapshotTo(t *testing.T) {
 }
 `, buf.String())
 }
+
+func TestLocalSnapshotIDIgnoresFormattingAndUIState(t *testing.T) {
+	ctx, _, _ := testutils.CtxAndAnalyticsForTest()
+	snapshot := &proto_webview.Snapshot{
+		View: &proto_webview.View{
+			FeatureFlags: map[string]bool{"b": true, "a": false, "c": true},
+			UiResources: []*v1alpha1.UIResource{
+				{ObjectMeta: metav1.ObjectMeta{Name: "fe"}},
+			},
+		},
+	}
+
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, WriteSnapshotTo(ctx, snapshot, buf))
+	read, err := ReadSnapshot(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "fe", read.View.UiResources[0].Name)
+
+	id, err := LocalSnapshotID(snapshot)
+	require.NoError(t, err)
+	readID, err := LocalSnapshotID(read)
+	require.NoError(t, err)
+	assert.Equal(t, id, readID)
+	assert.Len(t, string(id), 16)
+
+	read.Path = "/r/fe/overview"
+	read.IsSidebarClosed = true
+	readID, err = LocalSnapshotID(read)
+	require.NoError(t, err)
+	assert.Equal(t, id, readID)
+
+	read.View.UiResources[0].Name = "be"
+	readID, err = LocalSnapshotID(read)
+	require.NoError(t, err)
+	assert.NotEqual(t, id, readID)
+}
+
+func TestReadSnapshotRequiresView(t *testing.T) {
+	_, err := ReadSnapshot(bytes.NewBufferString(`{"path": "/overview"}`))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "no view")
+	}
+}
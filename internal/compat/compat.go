@@ -0,0 +1,233 @@
+// Package compat checks the versions of Kubernetes and Docker that Tilt is
+// talking to against the versions that Tilt supports.
+//
+// Very old clusters and Docker daemons tend to fail deep in the apply or build
+// path with errors that don't mention the version at all, so we check once at
+// startup and tell the user which feature is going to break.
+package compat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blang/semver"
+	"github.com/docker/docker/api/types"
+	"k8s.io/apimachinery/pkg/version"
+
+	"github.com/tilt-dev/tilt/internal/docker"
+	"github.com/tilt-dev/tilt/internal/k8s"
+)
+
+// The versions of the tools that Tilt talks to, as detected at startup.
+//
+// An empty string means we couldn't detect the version (e.g., because there's
+// no cluster configured), and the check is skipped.
+type Versions struct {
+	// The Kubernetes server version, e.g., v1.24.9-gke.3200.
+	Kubernetes string
+
+	// The Docker engine version, e.g., 20.10.21.
+	//
+	// Informational only. Other engines that speak the Docker API (like Podman)
+	// have their own version numbers, so we check the API version instead.
+	DockerEngine string
+
+	// The Docker API version, e.g., 1.41.
+	DockerAPI string
+}
+
+func NewVersions(k8sVersion *version.Info, dockerVersion types.Version) Versions {
+	v := Versions{
+		DockerEngine: dockerVersion.Version,
+		DockerAPI:    dockerVersion.APIVersion,
+	}
+	if k8sVersion != nil {
+		v.Kubernetes = k8sVersion.GitVersion
+	}
+	return v
+}
+
+// Fetches the Kubernetes server version and reads the Docker version that the
+// client negotiated when it connected.
+//
+// Never fails. If either server is unreachable, its version is left empty.
+func ProvideVersions(clientset k8s.ClientsetOrError, dCli docker.Client) Versions {
+	k8sVersion, err := k8s.ProvideServerVersion(clientset)
+	if err != nil {
+		k8sVersion = nil
+	}
+	return NewVersions(k8sVersion, dCli.ServerVersion())
+}
+
+type Severity int
+
+const (
+	// The version works, but some features are broken.
+	SeverityWarning Severity = iota
+
+	// The version is below the minimum, and Tilt is likely to fail in confusing ways.
+	SeverityUnsupported
+)
+
+// A problem with a detected version.
+type Problem struct {
+	Severity Severity
+
+	// The component with the problem, e.g., "Kubernetes".
+	Component string
+
+	// The detected version, as reported by the server.
+	Detected string
+
+	// The first known-broken feature at the detected version.
+	//
+	// Empty if the version couldn't be parsed.
+	Feature string
+
+	// A human-readable explanation, suitable for logging.
+	Message string
+}
+
+// A feature that needs a minimum server version.
+type feature struct {
+	since semver.Version
+	name  string
+}
+
+type requirement struct {
+	component   string
+	minimum     semver.Version
+	recommended semver.Version
+
+	// Sorted by version, oldest first.
+	features []feature
+}
+
+var kubernetesRequirement = requirement{
+	component:   "Kubernetes",
+	minimum:     semver.MustParse("1.15.0"),
+	recommended: semver.MustParse("1.23.0"),
+	features: []feature{
+		{semver.MustParse("1.15.0"), "metadata-only watches, which Tilt uses to track the owners of pods"},
+		{semver.MustParse("1.16.0"), "server-side dry-run applies"},
+		{semver.MustParse("1.23.0"), "ephemeral debug containers"},
+	},
+}
+
+// Checked against the Docker API version. See Versions.DockerEngine.
+var dockerRequirement = requirement{
+	component:   "Docker",
+	minimum:     semver.MustParse("1.23.0"),
+	recommended: semver.MustParse("1.40.0"),
+	features: []feature{
+		{semver.MustParse("1.23.0"), "image builds"},
+		{semver.MustParse("1.39.0"), "BuildKit, including docker_build(secret=...) and docker_build(ssh=...)"},
+		{semver.MustParse("1.40.0"), "docker_build(platform=...)"},
+	},
+}
+
+// Compares the detected versions against the minimum and recommended versions.
+//
+// Returns nothing if all the detected versions are at or above the recommended
+// versions. Undetected (empty) versions are skipped.
+func Check(v Versions) []Problem {
+	var result []Problem
+	if p, ok := kubernetesRequirement.check(v.Kubernetes, v.Kubernetes); ok {
+		result = append(result, p)
+	}
+
+	dockerDisplay := v.DockerAPI
+	if v.DockerEngine != "" {
+		dockerDisplay = fmt.Sprintf("%s (API %s)", v.DockerEngine, v.DockerAPI)
+	}
+	if p, ok := dockerRequirement.check(v.DockerAPI, dockerDisplay); ok {
+		result = append(result, p)
+	}
+	return result
+}
+
+func (r requirement) check(raw string, display string) (Problem, bool) {
+	if raw == "" {
+		return Problem{}, false
+	}
+
+	detected, err := ParseVersion(raw)
+	if err != nil {
+		return Problem{
+			Severity:  SeverityWarning,
+			Component: r.component,
+			Detected:  display,
+			Message: fmt.Sprintf("Could not parse %s version %q, skipping version compatibility check: %v",
+				r.component, raw, err),
+		}, true
+	}
+
+	if detected.GTE(r.recommended) {
+		return Problem{}, false
+	}
+
+	feature := r.firstBrokenFeature(detected)
+	if detected.LT(r.minimum) {
+		return Problem{
+			Severity:  SeverityUnsupported,
+			Component: r.component,
+			Detected:  display,
+			Feature:   feature,
+			Message: fmt.Sprintf("%s %s is older than the minimum supported version (%s). "+
+				"Known broken: %s. Upgrade to %s or newer.",
+				r.component, display, shortVersion(r.minimum), feature, shortVersion(r.recommended)),
+		}, true
+	}
+
+	return Problem{
+		Severity:  SeverityWarning,
+		Component: r.component,
+		Detected:  display,
+		Feature:   feature,
+		Message: fmt.Sprintf("%s %s is older than the recommended version (%s). "+
+			"Known broken: %s.",
+			r.component, display, shortVersion(r.recommended), feature),
+	}, true
+}
+
+func (r requirement) firstBrokenFeature(v semver.Version) string {
+	for _, f := range r.features {
+		if v.LT(f.since) {
+			return f.name
+		}
+	}
+	return ""
+}
+
+// Parses a version number as reported by a server.
+//
+// Tolerates a leading "v", missing minor and patch versions, leading zeros
+// (Docker 17.06.0-ce), and pre-release or build suffixes (GKE's
+// 1.24.9-gke.3200, EKS's 1.21.14-eks-fb459a0). Suffixes are dropped, because
+// vendor builds are patched releases, not pre-releases.
+func ParseVersion(s string) (semver.Version, error) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(trimmed, "-+"); i != -1 {
+		trimmed = trimmed[:i]
+	}
+
+	parts := strings.Split(trimmed, ".")
+	if len(parts) > 3 {
+		return semver.Version{}, fmt.Errorf("too many version components")
+	}
+
+	nums := make([]uint64, 3)
+	for i, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return semver.Version{}, fmt.Errorf("invalid version component %q", part)
+		}
+		nums[i] = n
+	}
+	return semver.Version{Major: nums[0], Minor: nums[1], Patch: nums[2]}, nil
+}
+
+func shortVersion(v semver.Version) string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
@@ -0,0 +1,123 @@
+package compat
+
+import (
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+func TestParseVersion(t *testing.T) {
+	for _, tc := range []struct {
+		input    string
+		expected string
+	}{
+		{"v1.24.9", "1.24.9"},
+		{"v1.24.9-gke.3200", "1.24.9"},
+		{"v1.21.14-eks-fb459a0", "1.21.14"},
+		{"v1.25.3+k3s1", "1.25.3"},
+		{"17.06.0-ce", "17.6.0"},
+		{"1.41", "1.41.0"},
+		{"20", "20.0.0"},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			v, err := ParseVersion(tc.input)
+			require.NoError(t, err)
+			assert.Equal(t, semver.MustParse(tc.expected), v)
+		})
+	}
+}
+
+func TestParseVersionInvalid(t *testing.T) {
+	for _, input := range []string{"", "banana", "v1.x.3", "1.2.3.4", "1..2"} {
+		t.Run(input, func(t *testing.T) {
+			_, err := ParseVersion(input)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCheckRecommended(t *testing.T) {
+	problems := Check(Versions{
+		Kubernetes:   "v1.26.1-gke.1500",
+		DockerEngine: "24.0.2",
+		DockerAPI:    "1.43",
+	})
+	assert.Empty(t, problems)
+}
+
+func TestCheckUndetected(t *testing.T) {
+	assert.Empty(t, Check(Versions{}))
+}
+
+func TestCheckKubernetesBelowMinimum(t *testing.T) {
+	problems := Check(Versions{Kubernetes: "v1.12.10"})
+	require.Len(t, problems, 1)
+
+	p := problems[0]
+	assert.Equal(t, SeverityUnsupported, p.Severity)
+	assert.Equal(t, "Kubernetes", p.Component)
+	assert.Equal(t, "v1.12.10", p.Detected)
+	assert.Equal(t, "metadata-only watches, which Tilt uses to track the owners of pods", p.Feature)
+	assert.Equal(t,
+		"Kubernetes v1.12.10 is older than the minimum supported version (1.15). "+
+			"Known broken: metadata-only watches, which Tilt uses to track the owners of pods. Upgrade to 1.23 or newer.",
+		p.Message)
+}
+
+func TestCheckKubernetesBelowRecommended(t *testing.T) {
+	problems := Check(Versions{Kubernetes: "v1.21.14-gke.700"})
+	require.Len(t, problems, 1)
+
+	p := problems[0]
+	assert.Equal(t, SeverityWarning, p.Severity)
+	assert.Equal(t, "ephemeral debug containers", p.Feature)
+	assert.Equal(t,
+		"Kubernetes v1.21.14-gke.700 is older than the recommended version (1.23). Known broken: ephemeral debug containers.",
+		p.Message)
+}
+
+func TestCheckDockerBelowRecommended(t *testing.T) {
+	problems := Check(Versions{DockerEngine: "17.06.0-ce", DockerAPI: "1.30"})
+	require.Len(t, problems, 1)
+
+	p := problems[0]
+	assert.Equal(t, SeverityWarning, p.Severity)
+	assert.Equal(t, "Docker", p.Component)
+	assert.Equal(t, "17.06.0-ce (API 1.30)", p.Detected)
+	assert.Equal(t, "BuildKit, including docker_build(secret=...) and docker_build(ssh=...)", p.Feature)
+}
+
+func TestCheckDockerBelowMinimum(t *testing.T) {
+	problems := Check(Versions{DockerEngine: "1.11.2", DockerAPI: "1.22"})
+	require.Len(t, problems, 1)
+	assert.Equal(t, SeverityUnsupported, problems[0].Severity)
+	assert.Equal(t, "image builds", problems[0].Feature)
+}
+
+func TestCheckUnparseable(t *testing.T) {
+	problems := Check(Versions{Kubernetes: "v1.x", DockerEngine: "dev", DockerAPI: "1.41"})
+	require.Len(t, problems, 1)
+
+	p := problems[0]
+	assert.Equal(t, SeverityWarning, p.Severity)
+	assert.Equal(t, "Kubernetes", p.Component)
+	assert.Equal(t, "", p.Feature)
+	assert.Contains(t, p.Message, `Could not parse Kubernetes version "v1.x"`)
+}
+
+func TestNewVersions(t *testing.T) {
+	v := NewVersions(
+		&version.Info{Major: "1", Minor: "24+", GitVersion: "v1.24.9-gke.3200"},
+		types.Version{Version: "20.10.21", APIVersion: "1.41"})
+	assert.Equal(t, Versions{
+		Kubernetes:   "v1.24.9-gke.3200",
+		DockerEngine: "20.10.21",
+		DockerAPI:    "1.41",
+	}, v)
+
+	assert.Equal(t, Versions{}, NewVersions(nil, types.Version{}))
+}
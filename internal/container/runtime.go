@@ -12,6 +12,11 @@ const (
 	RuntimeCrio        Runtime = "cri-o"
 	RuntimeUnknown     Runtime = "unknown"
 	RuntimeReadFailure Runtime = "read-failure"
+
+	// The cluster's nodes don't all use the same runtime (e.g., a cluster
+	// that's migrating from docker to containerd). Check the runtime
+	// of a pod's node before doing anything runtime-specific to it.
+	RuntimeMixed Runtime = "mixed"
 )
 
 func RuntimeFromVersionString(s string) Runtime {
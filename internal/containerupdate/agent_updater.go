@@ -0,0 +1,48 @@
+package containerupdate
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/tilt-dev/tilt/internal/agent"
+	"github.com/tilt-dev/tilt/internal/build"
+	"github.com/tilt-dev/tilt/internal/store/liveupdates"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// Updates containers through Tilt's in-cluster agent, for clusters where
+// we can't exec into pods directly.
+type AgentUpdater struct {
+	agents *agent.Installer
+}
+
+var _ ContainerUpdater = &AgentUpdater{}
+
+func NewAgentUpdater(agents *agent.Installer) *AgentUpdater {
+	return &AgentUpdater{agents: agents}
+}
+
+func (cu *AgentUpdater) UpdateContainer(ctx context.Context, cInfo liveupdates.Container,
+	archiveToCopy io.Reader, filesToDelete []string, cmds []model.Cmd, hotReload bool) error {
+	if !hotReload {
+		return fmt.Errorf("AgentUpdater does not support `restart_container()` step. If you ran Tilt " +
+			"with `--update-mode=agent`, omit this flag. If you are using a non-Docker container runtime, " +
+			"see https://github.com/tilt-dev/tilt-extensions/tree/master/restart_process for a workaround")
+	}
+
+	client, err := cu.agents.Client(ctx, cInfo.Namespace)
+	if err != nil {
+		return err
+	}
+
+	err = updateByExec(ctx, client, cInfo, archiveToCopy, filesToDelete, cmds)
+	if err != nil {
+		if _, ok := build.MaybeRunStepFailure(err); !ok {
+			// The agent may have gone away. Check on it next time.
+			cu.agents.Forget(cInfo.Namespace)
+		}
+		return err
+	}
+	return nil
+}
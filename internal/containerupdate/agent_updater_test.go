@@ -0,0 +1,97 @@
+package containerupdate
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/util/exec"
+
+	"github.com/tilt-dev/tilt/internal/agent"
+	"github.com/tilt-dev/tilt/internal/build"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/testutils"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestAgentUpdaterDoesntSupportRestart(t *testing.T) {
+	f := newAgentFixture(t)
+
+	err := f.acu.UpdateContainer(f.ctx, TestContainerInfo, newReader("boop"), toDelete, cmds, false)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "AgentUpdater does not support `restart_container()` step")
+	}
+	assert.Empty(t, f.transport.Requests())
+}
+
+func TestAgentUpdaterExecsThroughAgent(t *testing.T) {
+	f := newAgentFixture(t)
+
+	err := f.acu.UpdateContainer(f.ctx, TestContainerInfo, newReader("hello world"), toDelete, cmds, true)
+	require.NoError(t, err)
+
+	if assert.Len(t, f.kCli.ExecCalls, 4) {
+		assert.Equal(t, []string{"rm", "-rf", "/foo/delete_me", "/bar/me_too"}, f.kCli.ExecCalls[0].Cmd)
+		assert.Equal(t, []string{"tar", "-C", "/", "-x", "-f", "-"}, f.kCli.ExecCalls[1].Cmd)
+		assert.Equal(t, []byte("hello world"), f.kCli.ExecCalls[1].Stdin)
+		assert.Equal(t, cmdA.Argv, f.kCli.ExecCalls[2].Cmd)
+		assert.Equal(t, cmdB.Argv, f.kCli.ExecCalls[3].Cmd)
+	}
+	assert.Contains(t, f.transport.Requests(), "POST tilt-agent.ns-foo/exec")
+}
+
+func TestAgentUpdaterRunFailure(t *testing.T) {
+	f := newAgentFixture(t)
+
+	// The first exec() call is a copy, so won't trigger a RunStepFailure
+	f.kCli.ExecErrors = []error{nil, exec.CodeExitError{Err: fmt.Errorf("Compile error"), Code: 1}}
+
+	err := f.acu.UpdateContainer(f.ctx, TestContainerInfo, newReader("hello world"), nil, cmds, true)
+	if assert.True(t, build.IsRunStepFailure(err)) {
+		assert.Equal(t, "Run step \"a\" failed with exit code: 1", err.Error())
+	}
+	assert.Equal(t, 2, len(f.kCli.ExecCalls))
+}
+
+func TestAgentUpdaterReinstallsAfterFailure(t *testing.T) {
+	f := newAgentFixture(t)
+
+	err := f.acu.UpdateContainer(f.ctx, TestContainerInfo, newReader("hello world"), nil, nil, true)
+	require.NoError(t, err)
+	assert.NotEmpty(t, f.kCli.Yaml, "expected agent to be installed")
+
+	f.transport.SetError(fmt.Errorf("connection refused"))
+	err = f.acu.UpdateContainer(f.ctx, TestContainerInfo, newReader("hello world"), nil, nil, true)
+	assert.Error(t, err)
+
+	f.transport.SetError(nil)
+	f.kCli.Yaml = ""
+	err = f.acu.UpdateContainer(f.ctx, TestContainerInfo, newReader("hello world"), nil, nil, true)
+	require.NoError(t, err)
+	assert.NotEmpty(t, f.kCli.Yaml, "expected agent to be re-installed")
+}
+
+type agentUpdaterFixture struct {
+	t         testing.TB
+	ctx       context.Context
+	kCli      *k8s.FakeK8sClient
+	transport *agent.FakeTransport
+	acu       *AgentUpdater
+}
+
+func newAgentFixture(t testing.TB) *agentUpdaterFixture {
+	fakeCli := k8s.NewFakeK8sClient(t)
+	transport := agent.NewFakeTransport(fakeCli, "0.30.0")
+	installer := agent.NewInstaller(fakeCli, agent.FakeDialer(transport), model.TiltBuild{Version: "0.30.0"})
+	ctx, _, _ := testutils.CtxAndAnalyticsForTest()
+
+	return &agentUpdaterFixture{
+		t:         t,
+		ctx:       ctx,
+		kCli:      fakeCli,
+		transport: transport,
+		acu:       NewAgentUpdater(installer),
+	}
+}
@@ -32,6 +32,13 @@ func (cu *ExecUpdater) UpdateContainer(ctx context.Context, cInfo liveupdates.Co
 			"see https://github.com/tilt-dev/tilt-extensions/tree/master/restart_process for a workaround")
 	}
 
+	return updateByExec(ctx, cu.kCli, cInfo, archiveToCopy, filesToDelete, cmds)
+}
+
+// Updates a container by running commands in it, through whatever can
+// reach the pod.
+func updateByExec(ctx context.Context, pods k8s.PodOps, cInfo liveupdates.Container,
+	archiveToCopy io.Reader, filesToDelete []string, cmds []model.Cmd) error {
 	l := logger.Get(ctx)
 	w := logger.Get(ctx).Writer(logger.InfoLvl)
 
@@ -39,7 +46,7 @@ func (cu *ExecUpdater) UpdateContainer(ctx context.Context, cInfo liveupdates.Co
 	if len(filesToDelete) > 0 {
 		buf := bytes.NewBuffer(nil)
 		rmWriter := io.MultiWriter(w, buf)
-		err := cu.kCli.Exec(ctx,
+		err := pods.Exec(ctx,
 			cInfo.PodID, cInfo.ContainerName, cInfo.Namespace,
 			append([]string{"rm", "-rf"}, filesToDelete...), nil, rmWriter, rmWriter)
 		if err != nil {
@@ -50,7 +57,7 @@ func (cu *ExecUpdater) UpdateContainer(ctx context.Context, cInfo liveupdates.Co
 	// copy files to container
 	buf := bytes.NewBuffer(nil)
 	tarWriter := io.MultiWriter(w, buf)
-	err := cu.kCli.Exec(ctx, cInfo.PodID, cInfo.ContainerName, cInfo.Namespace,
+	err := pods.Exec(ctx, cInfo.PodID, cInfo.ContainerName, cInfo.Namespace,
 		tarArgv(), archiveToCopy, tarWriter, tarWriter)
 	if err != nil {
 		return fmt.Errorf("copying changed files: %v", handleK8sExecError(buf, err))
@@ -59,7 +66,7 @@ func (cu *ExecUpdater) UpdateContainer(ctx context.Context, cInfo liveupdates.Co
 	// run commands
 	for i, c := range cmds {
 		l.Infof("[CMD %d/%d] %s", i+1, len(cmds), strings.Join(c.Argv, " "))
-		err := cu.kCli.Exec(ctx, cInfo.PodID, cInfo.ContainerName, cInfo.Namespace,
+		err := pods.Exec(ctx, cInfo.PodID, cInfo.ContainerName, cInfo.Namespace,
 			c.Argv, nil, w, w)
 		if err != nil {
 			return build.WrapCodeExitError(err, cInfo.ContainerID, c)
@@ -0,0 +1,100 @@
+// Package apiretry retries calls to Tilt's own API server that can fail
+// while it's still starting up.
+//
+// At startup, the controller caches may not have synced yet, and the API
+// server may not be listening yet. These errors go away on their own, so
+// early callers should wait them out rather than fail.
+package apiretry
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+
+	"github.com/tilt-dev/tilt/pkg/logger"
+)
+
+type Policy struct {
+	// How long to wait before the first retry. Doubles on each retry.
+	InitialBackoff time.Duration
+
+	// The longest to wait between two retries.
+	MaxBackoff time.Duration
+
+	// How long to keep retrying before giving up.
+	Deadline time.Duration
+}
+
+var DefaultPolicy = Policy{
+	InitialBackoff: 50 * time.Millisecond,
+	MaxBackoff:     time.Second,
+	Deadline:       5 * time.Second,
+}
+
+// Whether the error means the API server isn't ready yet, rather than
+// that the request was wrong.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var notStarted *cache.ErrCacheNotStarted
+	if errors.As(err, &notStarted) {
+		return true
+	}
+
+	if utilnet.IsConnectionRefused(err) || utilnet.IsConnectionReset(err) {
+		return true
+	}
+
+	return apierrors.IsServiceUnavailable(err)
+}
+
+// Calls fn until it succeeds, returns an error that isn't retryable, or the
+// deadline passes.
+func (p Policy) Do(ctx context.Context, fn func() error) error {
+	start := time.Now()
+	deadline := start.Add(p.Deadline)
+	backoff := p.InitialBackoff
+	retries := 0
+	for {
+		err := fn()
+		if err == nil {
+			if retries > 1 {
+				logger.Get(ctx).Infof("Tilt API server ready after %d retries (%s)",
+					retries, time.Since(start).Round(time.Millisecond))
+			}
+			return nil
+		}
+		if !IsRetryable(err) {
+			return err
+		}
+
+		if time.Now().Add(backoff).After(deadline) {
+			return errors.Wrapf(err, "Tilt API server not ready after %s", p.Deadline)
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		retries++
+		backoff *= 2
+		if backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+	}
+}
+
+// Calls fn with the default policy.
+func Do(ctx context.Context, fn func() error) error {
+	return DefaultPolicy.Do(ctx, fn)
+}
@@ -0,0 +1,127 @@
+package apiretry
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+
+	"github.com/tilt-dev/tilt/pkg/logger"
+)
+
+var testPolicy = Policy{
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     5 * time.Millisecond,
+	Deadline:       50 * time.Millisecond,
+}
+
+func TestIsRetryable(t *testing.T) {
+	gr := schema.GroupResource{Group: "tilt.dev", Resource: "tiltfiles"}
+	connRefused := &net.OpError{Op: "dial", Net: "tcp", Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}}
+
+	cases := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{"nil", nil, false},
+		{"cache not started", &cache.ErrCacheNotStarted{}, true},
+		{"wrapped cache not started", fmt.Errorf("listing: %w", &cache.ErrCacheNotStarted{}), true},
+		{"connection refused", connRefused, true},
+		{"connection reset", syscall.ECONNRESET, true},
+		{"service unavailable", apierrors.NewServiceUnavailable("starting"), true},
+		{"invalid", apierrors.NewInvalid(schema.GroupKind{Group: "tilt.dev", Kind: "Tiltfile"}, "tf", nil), false},
+		{"not found", apierrors.NewNotFound(gr, "tf"), false},
+		{"forbidden", apierrors.NewForbidden(gr, "tf", fmt.Errorf("nope")), false},
+		{"context canceled", context.Canceled, false},
+		{"other", fmt.Errorf("boom"), false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.retryable, IsRetryable(c.err))
+		})
+	}
+}
+
+func TestDoRetriesUntilSuccess(t *testing.T) {
+	out := &bytes.Buffer{}
+	ctx := logger.WithLogger(context.Background(), logger.NewTestLogger(out))
+
+	calls := 0
+	err := testPolicy.Do(ctx, func() error {
+		calls++
+		if calls < 4 {
+			return &cache.ErrCacheNotStarted{}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 4, calls)
+	assert.Contains(t, out.String(), "Tilt API server ready after 3 retries")
+}
+
+func TestDoQuietAfterOneRetry(t *testing.T) {
+	out := &bytes.Buffer{}
+	ctx := logger.WithLogger(context.Background(), logger.NewTestLogger(out))
+
+	calls := 0
+	err := testPolicy.Do(ctx, func() error {
+		calls++
+		if calls < 2 {
+			return syscall.ECONNREFUSED
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Empty(t, out.String())
+}
+
+func TestDoDoesNotRetryNonRetryable(t *testing.T) {
+	calls := 0
+	invalid := apierrors.NewInvalid(schema.GroupKind{Group: "tilt.dev", Kind: "Tiltfile"}, "tf", nil)
+	err := testPolicy.Do(context.Background(), func() error {
+		calls++
+		return invalid
+	})
+	assert.Equal(t, invalid, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestDoDeadline(t *testing.T) {
+	calls := 0
+	start := time.Now()
+	err := testPolicy.Do(context.Background(), func() error {
+		calls++
+		return &cache.ErrCacheNotStarted{}
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Tilt API server not ready after 50ms")
+	assert.True(t, IsRetryable(err), "the last error should still be inspectable")
+	assert.Greater(t, calls, 1)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestDoContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := Policy{InitialBackoff: time.Minute, MaxBackoff: time.Minute, Deadline: time.Hour}
+
+	calls := 0
+	err := policy.Do(ctx, func() error {
+		calls++
+		cancel()
+		return &cache.ErrCacheNotStarted{}
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,249 @@
+package configmap
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// A request to disable (or enable) several resources at once.
+type DisableRequest struct {
+	Names []model.ManifestName
+
+	// True to disable the resources, false to enable them.
+	Disable bool
+
+	// Also disable any enabled dependents, or enable any disabled
+	// dependencies, so that the set stays consistent.
+	Cascade bool
+
+	// Apply the change even if it leaves dependents enabled (or
+	// dependencies disabled).
+	Force bool
+}
+
+// A resource in the request that other resources need to change with.
+//
+// When disabling, Related are the enabled resources that depend on it.
+// When enabling, Related are the disabled resources that it depends on.
+type DisableConflict struct {
+	Name    model.ManifestName   `json:"name"`
+	Related []model.ManifestName `json:"related"`
+}
+
+type DisablePlan struct {
+	// The resources whose disable state changes, sorted.
+	Changes []model.ManifestName
+
+	// The resources in Changes that weren't in the request, but were
+	// added because Cascade was set.
+	Cascaded []model.ManifestName
+
+	Conflicts []DisableConflict
+
+	// True if there are conflicts and the request asked us neither to
+	// cascade nor to force, so nothing should change.
+	Blocked bool
+}
+
+// Picks out the resources named explicitly, or that match any of the label
+// selectors. Returns an error if a name doesn't exist or a selector is invalid.
+//
+// Selectors match against a resource's annotations as well as its labels,
+// so that resources can be picked by metadata like an owning team.
+func SelectManifests(manifests []model.Manifest, names []string, selectors []string) ([]model.ManifestName, error) {
+	byName := make(map[model.ManifestName]model.Manifest, len(manifests))
+	for _, m := range manifests {
+		byName[m.Name] = m
+	}
+
+	selected := make(map[model.ManifestName]bool)
+	for _, n := range names {
+		mn := model.ManifestName(n)
+		if _, ok := byName[mn]; !ok {
+			return nil, fmt.Errorf("no manifest found with name '%s'", n)
+		}
+		selected[mn] = true
+	}
+
+	for _, s := range selectors {
+		sel, err := labels.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid label selector %q: %v", s, err)
+		}
+		for _, m := range manifests {
+			if sel.Matches(selectableFields(m)) {
+				selected[m.Name] = true
+			}
+		}
+	}
+
+	return sortedNames(selected), nil
+}
+
+// The fields that a label selector matches against. Labels win over
+// annotations with the same key.
+func selectableFields(m model.Manifest) labels.Set {
+	result := make(labels.Set, len(m.Labels)+len(m.Annotations))
+	for k, v := range m.Annotations {
+		result[k] = v
+	}
+	for k, v := range m.Labels {
+		result[k] = v
+	}
+	return result
+}
+
+// Works out which resources change, and which dependents (or dependencies)
+// the request would leave in an inconsistent state.
+func PlanDisable(manifests []model.Manifest, isDisabled map[model.ManifestName]bool, req DisableRequest) DisablePlan {
+	// When disabling, walk from each resource to the resources that
+	// depend on it. When enabling, walk to the resources it depends on.
+	edges := make(map[model.ManifestName][]model.ManifestName)
+	for _, m := range manifests {
+		for _, dep := range m.ResourceDependencies {
+			if req.Disable {
+				edges[dep] = append(edges[dep], m.Name)
+			} else {
+				edges[m.Name] = append(edges[m.Name], dep)
+			}
+		}
+	}
+
+	requested := make(map[model.ManifestName]bool, len(req.Names))
+	for _, n := range req.Names {
+		requested[n] = true
+	}
+
+	// A related resource conflicts if it's in the opposite state of the
+	// one we're asking for.
+	needsChange := func(n model.ManifestName) bool {
+		return isDisabled[n] != req.Disable
+	}
+
+	var plan DisablePlan
+	cascaded := make(map[model.ManifestName]bool)
+	for _, n := range req.Names {
+		related := make(map[model.ManifestName]bool)
+		seen := map[model.ManifestName]bool{n: true}
+		queue := []model.ManifestName{n}
+		for len(queue) > 0 {
+			cur := queue[0]
+			queue = queue[1:]
+			for _, next := range edges[cur] {
+				if seen[next] {
+					continue
+				}
+				seen[next] = true
+				queue = append(queue, next)
+				if !requested[next] && needsChange(next) {
+					related[next] = true
+				}
+			}
+		}
+
+		if len(related) > 0 {
+			plan.Conflicts = append(plan.Conflicts, DisableConflict{Name: n, Related: sortedNames(related)})
+			for r := range related {
+				cascaded[r] = true
+			}
+		}
+	}
+	sort.Slice(plan.Conflicts, func(i, j int) bool { return plan.Conflicts[i].Name < plan.Conflicts[j].Name })
+
+	if len(plan.Conflicts) > 0 && !req.Cascade && !req.Force {
+		plan.Blocked = true
+		return plan
+	}
+
+	changes := make(map[model.ManifestName]bool)
+	for _, n := range req.Names {
+		if needsChange(n) {
+			changes[n] = true
+		}
+	}
+	if req.Cascade {
+		for n := range cascaded {
+			changes[n] = true
+		}
+		plan.Cascaded = sortedNames(cascaded)
+	}
+	plan.Changes = sortedNames(changes)
+	return plan
+}
+
+// Where each change in the plan came from. Resources pulled in by cascade
+// get DisableChangeSourceCascade, and the rest get the request's source.
+func (p DisablePlan) ChangeSources(source v1alpha1.DisableChangeSource) map[model.ManifestName]v1alpha1.DisableChangeSource {
+	result := make(map[model.ManifestName]v1alpha1.DisableChangeSource, len(p.Changes))
+	for _, n := range p.Changes {
+		result[n] = source
+	}
+	for _, n := range p.Cascaded {
+		result[n] = v1alpha1.DisableChangeSourceCascade
+	}
+	return result
+}
+
+// Sets the disable ConfigMaps of all the named resources, recording where
+// each change came from.
+//
+// Reads every ConfigMap before writing any of them, and puts back the ones
+// it already wrote if a later write fails, so that the set changes together
+// or not at all. Returns the updated ConfigMaps.
+func SetDisabled(ctx context.Context, c client.Client, names []model.ManifestName, disable bool, sources map[model.ManifestName]v1alpha1.DisableChangeSource) ([]*v1alpha1.ConfigMap, error) {
+	originals := make([]*v1alpha1.ConfigMap, 0, len(names))
+	for _, n := range names {
+		var cm v1alpha1.ConfigMap
+		err := c.Get(ctx, types.NamespacedName{Name: DisableConfigMapName(n)}, &cm)
+		if err != nil {
+			return nil, fmt.Errorf("reading disable state of %s: %v", n, err)
+		}
+		if _, err := Upgraded(&cm); err != nil {
+			return nil, fmt.Errorf("reading disable state of %s: %v", n, err)
+		}
+		originals = append(originals, &cm)
+	}
+
+	now := time.Now()
+	updated := make([]*v1alpha1.ConfigMap, 0, len(originals))
+	for i, orig := range originals {
+		// We checked that the originals are readable above.
+		upgraded, _ := Upgraded(orig)
+		cm := upgraded.DeepCopy()
+		RecordDisableChange(cm, disable, sources[names[i]], now)
+		err := c.Update(ctx, cm)
+		if err != nil {
+			rollbackDisabled(ctx, c, updated, originals)
+			return nil, fmt.Errorf("updating %s: %v", cm.Name, err)
+		}
+		updated = append(updated, cm)
+	}
+	return updated, nil
+}
+
+// Best-effort: restores the ConfigMaps we already wrote to their original data.
+func rollbackDisabled(ctx context.Context, c client.Client, updated []*v1alpha1.ConfigMap, originals []*v1alpha1.ConfigMap) {
+	for i, cm := range updated {
+		restored := cm.DeepCopy()
+		restored.Data = originals[i].Data
+		_ = c.Update(ctx, restored)
+	}
+}
+
+func sortedNames(set map[model.ManifestName]bool) []model.ManifestName {
+	result := make([]model.ManifestName, 0, len(set))
+	for n := range set {
+		result = append(result, n)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result
+}
@@ -0,0 +1,169 @@
+package configmap
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tilt-dev/tilt/internal/controllers/fake"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// fe -> api -> db, and docs on its own.
+func bulkManifests() []model.Manifest {
+	return []model.Manifest{
+		{Name: "db", Labels: map[string]string{"backend": "backend"}},
+		{Name: "api", Labels: map[string]string{"backend": "backend"}, ResourceDependencies: []model.ManifestName{"db"}},
+		{Name: "fe", Labels: map[string]string{"frontend": "frontend"}, ResourceDependencies: []model.ManifestName{"api"}},
+		{Name: "docs"},
+	}
+}
+
+func TestPlanDisableWarnsAboutEnabledDependents(t *testing.T) {
+	plan := PlanDisable(bulkManifests(), nil, DisableRequest{Names: []model.ManifestName{"db"}, Disable: true})
+	assert.True(t, plan.Blocked)
+	assert.Empty(t, plan.Changes)
+	assert.Equal(t, []DisableConflict{
+		{Name: "db", Related: []model.ManifestName{"api", "fe"}},
+	}, plan.Conflicts)
+}
+
+func TestPlanDisableIgnoresDisabledDependents(t *testing.T) {
+	disabled := map[model.ManifestName]bool{"api": true, "fe": true}
+	plan := PlanDisable(bulkManifests(), disabled, DisableRequest{Names: []model.ManifestName{"db"}, Disable: true})
+	assert.False(t, plan.Blocked)
+	assert.Empty(t, plan.Conflicts)
+	assert.Equal(t, []model.ManifestName{"db"}, plan.Changes)
+}
+
+func TestPlanDisableCascade(t *testing.T) {
+	plan := PlanDisable(bulkManifests(), nil, DisableRequest{Names: []model.ManifestName{"db"}, Disable: true, Cascade: true})
+	assert.False(t, plan.Blocked)
+	assert.Equal(t, []model.ManifestName{"api", "db", "fe"}, plan.Changes)
+	assert.Equal(t, []model.ManifestName{"api", "fe"}, plan.Cascaded)
+}
+
+func TestPlanDisableForce(t *testing.T) {
+	plan := PlanDisable(bulkManifests(), nil, DisableRequest{Names: []model.ManifestName{"db"}, Disable: true, Force: true})
+	assert.False(t, plan.Blocked)
+	assert.Equal(t, []model.ManifestName{"db"}, plan.Changes)
+	assert.Empty(t, plan.Cascaded)
+
+	// The conflicts are still reported, as warnings.
+	assert.Len(t, plan.Conflicts, 1)
+}
+
+func TestPlanDisableWholeChainHasNoConflicts(t *testing.T) {
+	plan := PlanDisable(bulkManifests(), nil, DisableRequest{Names: []model.ManifestName{"api", "db", "fe"}, Disable: true})
+	assert.False(t, plan.Blocked)
+	assert.Empty(t, plan.Conflicts)
+	assert.Equal(t, []model.ManifestName{"api", "db", "fe"}, plan.Changes)
+}
+
+func TestPlanEnableCascadesToDependencies(t *testing.T) {
+	disabled := map[model.ManifestName]bool{"api": true, "db": true, "fe": true}
+
+	plan := PlanDisable(bulkManifests(), disabled, DisableRequest{Names: []model.ManifestName{"fe"}})
+	assert.True(t, plan.Blocked)
+	assert.Equal(t, []DisableConflict{
+		{Name: "fe", Related: []model.ManifestName{"api", "db"}},
+	}, plan.Conflicts)
+
+	plan = PlanDisable(bulkManifests(), disabled, DisableRequest{Names: []model.ManifestName{"fe"}, Cascade: true})
+	assert.False(t, plan.Blocked)
+	assert.Equal(t, []model.ManifestName{"api", "db", "fe"}, plan.Changes)
+}
+
+func TestSelectManifests(t *testing.T) {
+	names, err := SelectManifests(bulkManifests(), []string{"docs"}, []string{"backend"})
+	require.NoError(t, err)
+	assert.Equal(t, []model.ManifestName{"api", "db", "docs"}, names)
+
+	_, err = SelectManifests(bulkManifests(), []string{"nope"}, nil)
+	assert.EqualError(t, err, "no manifest found with name 'nope'")
+
+	_, err = SelectManifests(bulkManifests(), nil, []string{"a=b=c"})
+	assert.Error(t, err)
+}
+
+func TestSelectManifestsMatchesAnnotations(t *testing.T) {
+	manifests := bulkManifests()
+	manifests[0] = manifests[0].WithAnnotations(map[string]string{"example.com/team": "data"})
+	manifests[3] = manifests[3].WithAnnotations(map[string]string{"example.com/team": "web"})
+
+	names, err := SelectManifests(manifests, nil, []string{"example.com/team=data"})
+	require.NoError(t, err)
+	assert.Equal(t, []model.ManifestName{"db"}, names)
+
+	names, err = SelectManifests(manifests, nil, []string{"example.com/team"})
+	require.NoError(t, err)
+	assert.Equal(t, []model.ManifestName{"db", "docs"}, names)
+
+	// Labels win over annotations with the same key.
+	manifests[3] = manifests[3].WithAnnotations(map[string]string{"frontend": "docs"})
+	names, err = SelectManifests(manifests, nil, []string{"frontend=frontend"})
+	require.NoError(t, err)
+	assert.Equal(t, []model.ManifestName{"fe"}, names)
+}
+
+func TestSetDisabled(t *testing.T) {
+	fc := fake.NewFakeTiltClient()
+	ctx := context.Background()
+	for _, n := range []model.ManifestName{"api", "db"} {
+		require.NoError(t, fc.Create(ctx, &v1alpha1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: DisableConfigMapName(n)},
+			Data:       map[string]string{DisableKey: "false"},
+		}))
+	}
+
+	sources := map[model.ManifestName]v1alpha1.DisableChangeSource{
+		"api": v1alpha1.DisableChangeSourceCLI,
+		"db":  v1alpha1.DisableChangeSourceCascade,
+	}
+	updated, err := SetDisabled(ctx, fc, []model.ManifestName{"api", "db"}, true, sources)
+	require.NoError(t, err)
+	require.Len(t, updated, 2)
+	for _, n := range []model.ManifestName{"api", "db"} {
+		var cm v1alpha1.ConfigMap
+		require.NoError(t, fc.Get(ctx, types.NamespacedName{Name: DisableConfigMapName(n)}, &cm))
+		assert.Equal(t, "true", cm.Data[DisableKey], n)
+		history := DisableHistory(&cm)
+		require.Len(t, history, 1, n)
+		assert.True(t, history[0].Disabled, n)
+		assert.Equal(t, sources[n], history[0].Source, n)
+	}
+}
+
+func TestSetDisabledChangesNothingIfAnyConfigMapIsMissing(t *testing.T) {
+	fc := fake.NewFakeTiltClient()
+	ctx := context.Background()
+	require.NoError(t, fc.Create(ctx, &v1alpha1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: DisableConfigMapName("api")},
+		Data:       map[string]string{DisableKey: "false"},
+	}))
+
+	_, err := SetDisabled(ctx, fc, []model.ManifestName{"api", "db"}, true, nil)
+	require.Error(t, err)
+
+	var cm v1alpha1.ConfigMap
+	require.NoError(t, fc.Get(ctx, types.NamespacedName{Name: DisableConfigMapName("api")}, &cm))
+	assert.Equal(t, "false", cm.Data[DisableKey])
+	assert.Empty(t, DisableHistory(&cm))
+}
+
+func TestDisablePlanChangeSources(t *testing.T) {
+	plan := DisablePlan{
+		Changes:  []model.ManifestName{"api", "db", "web"},
+		Cascaded: []model.ManifestName{"web"},
+	}
+	assert.Equal(t, map[model.ManifestName]v1alpha1.DisableChangeSource{
+		"api": v1alpha1.DisableChangeSourceUI,
+		"db":  v1alpha1.DisableChangeSourceUI,
+		"web": v1alpha1.DisableChangeSourceCascade,
+	}, plan.ChangeSources(v1alpha1.DisableChangeSourceUI))
+}
@@ -2,8 +2,11 @@ package configmap
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -11,8 +14,45 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
 )
 
+// The key in a resource's disable ConfigMap that says whether it's disabled.
+const DisableKey = "isDisabled"
+
+// The key in a resource's disable ConfigMap that holds the most recent
+// changes to whether it's disabled, as a JSON list of DisableTransitions.
+const DisableHistoryKey = "history"
+
+// How many changes we keep in a resource's disable history.
+const MaxDisableHistory = 5
+
+// Version 1 always stores DisableKey as "true" or "false", because some
+// readers compare it as a string, and drops history that can't be parsed.
+var DisableFormat = RegisterFormat(Format{
+	Name: "the disable state",
+	Matches: func(name string) bool {
+		return strings.HasSuffix(name, "-disable")
+	},
+	Migrations: []Migration{migrateDisableV0},
+})
+
+func migrateDisableV0(data map[string]string) map[string]string {
+	NormalizeBool(data, DisableKey)
+	if h, ok := data[DisableHistoryKey]; ok {
+		var history []v1alpha1.DisableTransition
+		if json.Unmarshal([]byte(h), &history) != nil {
+			delete(data, DisableHistoryKey)
+		}
+	}
+	return data
+}
+
+// The name of the ConfigMap that controls whether a resource is disabled.
+func DisableConfigMapName(mn model.ManifestName) string {
+	return fmt.Sprintf("%s-disable", mn)
+}
+
 func DisableStatus(getCM func(name string) (v1alpha1.ConfigMap, error), disableSource *v1alpha1.DisableSource) (isDisabled bool, reason string, err error) {
 	if disableSource == nil {
 		return false, "object does not specify a DisableSource", nil
@@ -30,6 +70,12 @@ func DisableStatus(getCM func(name string) (v1alpha1.ConfigMap, error), disableS
 		return false, fmt.Sprintf("error reading ConfigMap %q", disableSource.ConfigMap.Name), err
 	}
 
+	upgraded, err := Upgraded(&cm)
+	if err != nil {
+		return false, err.Error(), nil
+	}
+	cm = *upgraded
+
 	cmVal, ok := cm.Data[disableSource.ConfigMap.Key]
 	if !ok {
 		return false, fmt.Sprintf("ConfigMap %q has no key %q", disableSource.ConfigMap.Name, disableSource.ConfigMap.Key), nil
@@ -64,3 +110,78 @@ func MaybeNewDisableStatus(ctx context.Context, client client.Client, disableSou
 	}
 	return prevStatus, nil
 }
+
+// Sets whether the resource is disabled, and records where the change came
+// from in the ConfigMap's history.
+//
+// The ConfigMap must already be upgraded to the current format.
+//
+// If the resource is already in the requested state, leaves the ConfigMap
+// alone and returns false.
+func RecordDisableChange(cm *v1alpha1.ConfigMap, disabled bool, source v1alpha1.DisableChangeSource, now time.Time) bool {
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+
+	cur, ok := cm.Data[DisableKey]
+	if ok && cur == strconv.FormatBool(disabled) && len(DisableHistory(cm)) > 0 {
+		return false
+	}
+
+	history := append(DisableHistory(cm), v1alpha1.DisableTransition{
+		Disabled: disabled,
+		Source:   source,
+		Time:     metav1.NewMicroTime(now),
+	})
+	if len(history) > MaxDisableHistory {
+		history = history[len(history)-MaxDisableHistory:]
+	}
+
+	// Marshaling a slice of plain structs can't fail.
+	b, _ := json.Marshal(history)
+	cm.Data[DisableKey] = strconv.FormatBool(disabled)
+	cm.Data[DisableHistoryKey] = string(b)
+	DisableFormat.Stamp(cm.Data)
+	return true
+}
+
+// The most recent changes to whether the resource is disabled, oldest first.
+//
+// Returns nil if the ConfigMap has no history, or if it can't be parsed.
+func DisableHistory(cm *v1alpha1.ConfigMap) []v1alpha1.DisableTransition {
+	if cm == nil || cm.Data[DisableHistoryKey] == "" {
+		return nil
+	}
+	var history []v1alpha1.DisableTransition
+	err := json.Unmarshal([]byte(cm.Data[DisableHistoryKey]), &history)
+	if err != nil {
+		return nil
+	}
+	return history
+}
+
+// Describes who made a change, for use in sentences like "disabled by the UI".
+func DescribeDisableChangeSource(source v1alpha1.DisableChangeSource) string {
+	switch source {
+	case v1alpha1.DisableChangeSourceTiltfile:
+		return "the Tiltfile"
+	case v1alpha1.DisableChangeSourceUI:
+		return "the web UI"
+	case v1alpha1.DisableChangeSourceCLI:
+		return "the CLI"
+	case v1alpha1.DisableChangeSourceCascade:
+		return "a change to a related resource"
+	case v1alpha1.DisableChangeSourceRestored:
+		return "choices saved from a previous run"
+	}
+	return "an unknown source"
+}
+
+// Describes a change, e.g., "Resource disabled by the web UI".
+func DescribeDisableTransition(t v1alpha1.DisableTransition) string {
+	verb := "enabled"
+	if t.Disabled {
+		verb = "disabled"
+	}
+	return fmt.Sprintf("Resource %s by %s", verb, DescribeDisableChangeSource(t.Source))
+}
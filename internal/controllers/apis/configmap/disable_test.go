@@ -3,6 +3,7 @@ package configmap
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -163,3 +164,43 @@ func newDisableFixture(t *testing.T) *disableFixture {
 		ctx: ctx,
 	}
 }
+
+func TestRecordDisableChange(t *testing.T) {
+	cm := &v1alpha1.ConfigMap{}
+	start := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	require.True(t, RecordDisableChange(cm, false, v1alpha1.DisableChangeSourceTiltfile, start))
+	require.True(t, RecordDisableChange(cm, true, v1alpha1.DisableChangeSourceUI, start.Add(time.Second)))
+
+	// Asking for the state we're already in isn't a transition.
+	require.False(t, RecordDisableChange(cm, true, v1alpha1.DisableChangeSourceCLI, start.Add(2*time.Second)))
+
+	require.Equal(t, "true", cm.Data[DisableKey])
+	history := DisableHistory(cm)
+	require.Len(t, history, 2)
+	require.Equal(t, v1alpha1.DisableChangeSourceTiltfile, history[0].Source)
+	require.False(t, history[0].Disabled)
+	require.Equal(t, v1alpha1.DisableChangeSourceUI, history[1].Source)
+	require.True(t, history[1].Disabled)
+	require.True(t, history[1].Time.Time.Equal(start.Add(time.Second)))
+}
+
+func TestRecordDisableChangeTrimsHistory(t *testing.T) {
+	cm := &v1alpha1.ConfigMap{}
+	start := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < MaxDisableHistory+2; i++ {
+		RecordDisableChange(cm, i%2 == 1, v1alpha1.DisableChangeSourceCLI, start.Add(time.Duration(i)*time.Second))
+	}
+
+	history := DisableHistory(cm)
+	require.Len(t, history, MaxDisableHistory)
+	require.True(t, history[0].Time.Time.Equal(start.Add(2*time.Second)))
+	require.True(t, history[MaxDisableHistory-1].Time.Time.Equal(start.Add(time.Duration(MaxDisableHistory+1)*time.Second)))
+}
+
+func TestDescribeDisableTransition(t *testing.T) {
+	require.Equal(t, "Resource disabled by the web UI",
+		DescribeDisableTransition(v1alpha1.DisableTransition{Disabled: true, Source: v1alpha1.DisableChangeSourceUI}))
+	require.Equal(t, "Resource enabled by a change to a related resource",
+		DescribeDisableTransition(v1alpha1.DisableTransition{Source: v1alpha1.DisableChangeSourceCascade}))
+}
@@ -5,6 +5,7 @@ package configmap
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -19,6 +20,73 @@ import (
 
 const TriggerQueueName = "tilt-trigger-queue"
 
+// The trigger queue stores each entry as a pair of keys, `{i}-name` and
+// `{i}-reason-code`.
+//
+// Version 1 numbers the entries from 0 in queue order, with no gaps or
+// duplicates, and only stores numeric reason codes.
+var TriggerQueueFormat = RegisterFormat(Format{
+	Name: "the trigger queue",
+	Matches: func(name string) bool {
+		return name == TriggerQueueName
+	},
+	Migrations: []Migration{migrateTriggerQueueV0},
+})
+
+// Before version 1, the queue could have gaps and duplicate names, and
+// entries without a name.
+func migrateTriggerQueueV0(data map[string]string) map[string]string {
+	type entry struct {
+		key    string
+		index  int
+		name   string
+		reason string
+	}
+
+	var entries []entry
+	for k, v := range data {
+		if !strings.HasSuffix(k, "-name") || strings.TrimSpace(v) == "" {
+			continue
+		}
+		cur := strings.TrimSuffix(k, "-name")
+		index, err := strconv.Atoi(cur)
+		if err != nil {
+			index = len(data)
+		}
+		entries = append(entries, entry{
+			key:    cur,
+			index:  index,
+			name:   strings.TrimSpace(v),
+			reason: data[fmt.Sprintf("%s-reason-code", cur)],
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].index != entries[j].index {
+			return entries[i].index < entries[j].index
+		}
+		return entries[i].key < entries[j].key
+	})
+
+	result := make(map[string]string, len(data))
+	seen := make(map[string]bool, len(entries))
+	i := 0
+	for _, e := range entries {
+		if seen[e.name] {
+			continue
+		}
+		seen[e.name] = true
+		result[fmt.Sprintf("%d-name", i)] = e.name
+		if _, err := strconv.Atoi(e.reason); err == nil {
+			result[fmt.Sprintf("%d-reason-code", i)] = e.reason
+		}
+		i++
+	}
+	return result
+}
+
+// Reads the trigger queue, upgraded to the current format.
+//
+// Returns an empty queue if it doesn't exist.
 func TriggerQueue(ctx context.Context, client client.Client) (*v1alpha1.ConfigMap, error) {
 	var cm v1alpha1.ConfigMap
 	err := client.Get(ctx, types.NamespacedName{Name: TriggerQueueName}, &cm)
@@ -26,12 +94,25 @@ func TriggerQueue(ctx context.Context, client client.Client) (*v1alpha1.ConfigMa
 		return nil, err
 	}
 
-	return &cm, nil
+	return Upgraded(&cm)
+}
+
+// The trigger queue's data in the current format.
+//
+// Treats a queue that we can't read (e.g., from a newer Tilt) as empty.
+// TriggerQueue reports the error.
+func triggerQueueData(cm *v1alpha1.ConfigMap) map[string]string {
+	data, err := TriggerQueueFormat.Upgrade(cm.Data)
+	if err != nil {
+		return nil
+	}
+	return data
 }
 
 func NamesInTriggerQueue(cm *v1alpha1.ConfigMap) []string {
-	result := make([]string, 0, len(cm.Data)/2)
-	for k, v := range cm.Data {
+	data := triggerQueueData(cm)
+	result := make([]string, 0, len(data)/2)
+	for k, v := range data {
 		if !strings.HasSuffix(k, "-name") {
 			continue
 		}
@@ -43,7 +124,7 @@ func NamesInTriggerQueue(cm *v1alpha1.ConfigMap) []string {
 
 func InTriggerQueue(cm *v1alpha1.ConfigMap, nn types.NamespacedName) bool {
 	name := nn.Name
-	for k, v := range cm.Data {
+	for k, v := range triggerQueueData(cm) {
 		if !strings.HasSuffix(k, "-name") {
 			continue
 		}
@@ -57,7 +138,8 @@ func InTriggerQueue(cm *v1alpha1.ConfigMap, nn types.NamespacedName) bool {
 
 func TriggerQueueReason(cm *v1alpha1.ConfigMap, nn types.NamespacedName) model.BuildReason {
 	name := nn.Name
-	for k, v := range cm.Data {
+	data := triggerQueueData(cm)
+	for k, v := range data {
 		if !strings.HasSuffix(k, "-name") {
 			continue
 		}
@@ -67,7 +149,7 @@ func TriggerQueueReason(cm *v1alpha1.ConfigMap, nn types.NamespacedName) model.B
 		}
 
 		cur := strings.TrimSuffix(k, "-name")
-		reasonCode := cm.Data[fmt.Sprintf("%s-reason-code", cur)]
+		reasonCode := data[fmt.Sprintf("%s-reason-code", cur)]
 		i, err := strconv.Atoi(reasonCode)
 		if err != nil {
 			return model.BuildReasonFlagTriggerUnknown
@@ -0,0 +1,185 @@
+package configmap
+
+// Versioning for the ConfigMaps that Tilt uses to store its own state.
+//
+// Tilt-internal ConfigMaps can outlive the Tilt that wrote them (e.g., a
+// bootstrap record in the cluster, or a ConfigMap written by a Tilt that was
+// upgraded in place). Each one records the version of its format, so that a
+// newer Tilt can upgrade old data when it reads it, and an older Tilt can
+// refuse data it doesn't understand rather than misreading it.
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+)
+
+// The key that records the version of a Tilt-internal ConfigMap's format.
+//
+// Data without this key predates versioning, and is treated as version 0.
+const VersionKey = "tilt-format-version"
+
+// Upgrades data from one version of a format to the next.
+//
+// Migrations get a copy of the data, so they can modify it in place.
+type Migration func(data map[string]string) map[string]string
+
+// The format of a Tilt-internal ConfigMap.
+type Format struct {
+	// Describes the data in errors, e.g., "the trigger queue".
+	Name string
+
+	// Whether a ConfigMap with the given name has this format.
+	Matches func(name string) bool
+
+	// Migrations[i] upgrades data from version i to version i+1.
+	// The current version is len(Migrations).
+	Migrations []Migration
+}
+
+// The version of the format that this Tilt writes.
+func (f Format) Version() int {
+	return len(f.Migrations)
+}
+
+// Returned when a ConfigMap was written in a format version that this Tilt
+// doesn't know about, probably by a newer Tilt.
+type FutureVersionError struct {
+	// The data we couldn't read, e.g., `ConfigMap "tilt-trigger-queue"`.
+	Object string
+
+	Version    int
+	MaxVersion int
+}
+
+func (e FutureVersionError) Error() string {
+	return fmt.Sprintf("%s was written in format version %d by a newer version of Tilt, "+
+		"but this Tilt only understands up to version %d. "+
+		"Upgrade Tilt, or delete the ConfigMap to start over",
+		e.Object, e.Version, e.MaxVersion)
+}
+
+// Returns the data upgraded to the current version, with the version recorded.
+//
+// Never modifies the data it's given. Returns it as-is if it's already
+// current.
+func (f Format) Upgrade(data map[string]string) (map[string]string, error) {
+	return f.upgrade(data, f.Name)
+}
+
+func (f Format) upgrade(data map[string]string, object string) (map[string]string, error) {
+	version, err := f.dataVersion(data, object)
+	if err != nil {
+		return nil, err
+	}
+	if version == f.Version() {
+		return data, nil
+	}
+
+	result := copyData(data)
+	for _, migrate := range f.Migrations[version:] {
+		result = migrate(result)
+	}
+	return f.Stamp(result), nil
+}
+
+// Records that the data is in the current version of the format.
+//
+// Modifies the data in place, and returns it (or a new map, if it was nil).
+func (f Format) Stamp(data map[string]string) map[string]string {
+	if data == nil {
+		data = make(map[string]string)
+	}
+	data[VersionKey] = strconv.Itoa(f.Version())
+	return data
+}
+
+func (f Format) dataVersion(data map[string]string, object string) (int, error) {
+	v, ok := data[VersionKey]
+	if !ok {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("%s has an invalid format version %q. Delete the ConfigMap to start over", object, v)
+	}
+	if version > f.Version() {
+		return 0, FutureVersionError{Object: object, Version: version, MaxVersion: f.Version()}
+	}
+	return version, nil
+}
+
+var formats []Format
+
+// Registers the format of a Tilt-internal ConfigMap, so that Upgraded
+// recognizes it.
+func RegisterFormat(f Format) Format {
+	formats = append(formats, f)
+	return f
+}
+
+// Returns the format of the Tilt-internal ConfigMap with the given name.
+func FormatFor(name string) (Format, bool) {
+	for _, f := range formats {
+		if f.Matches(name) {
+			return f, true
+		}
+	}
+	return Format{}, false
+}
+
+// Returns the ConfigMap with its data upgraded to the current version of its
+// format, if it's a Tilt-internal ConfigMap.
+//
+// Returns the same ConfigMap if there's nothing to upgrade. Otherwise, returns
+// a copy, so that the original can be shared.
+func Upgraded(cm *v1alpha1.ConfigMap) (*v1alpha1.ConfigMap, error) {
+	f, ok := FormatFor(cm.Name)
+	if !ok {
+		return cm, nil
+	}
+
+	object := fmt.Sprintf("ConfigMap %q", cm.Name)
+	version, err := f.dataVersion(cm.Data, object)
+	if err != nil {
+		return nil, err
+	}
+	if version == f.Version() {
+		return cm, nil
+	}
+
+	data, err := f.upgrade(cm.Data, object)
+	if err != nil {
+		return nil, err
+	}
+	result := *cm
+	result.Data = data
+	return &result, nil
+}
+
+func copyData(data map[string]string) map[string]string {
+	result := make(map[string]string, len(data)+1)
+	for k, v := range data {
+		result[k] = v
+	}
+	return result
+}
+
+// Rewrites a bool to its canonical form, e.g., "True" or "1" to "true",
+// for migrations of formats that Tilt reads with both strconv.ParseBool and
+// string comparisons.
+//
+// Leaves values that aren't bools alone.
+func NormalizeBool(data map[string]string, key string) {
+	v, ok := data[key]
+	if !ok {
+		return
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(v))
+	if err != nil {
+		return
+	}
+	data[key] = strconv.FormatBool(b)
+}
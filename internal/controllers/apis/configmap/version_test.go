@@ -0,0 +1,196 @@
+package configmap
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+var testFormat = Format{
+	Name: "the test data",
+	Migrations: []Migration{
+		func(data map[string]string) map[string]string {
+			data["v1"] = "added"
+			return data
+		},
+		func(data map[string]string) map[string]string {
+			delete(data, "old")
+			return data
+		},
+	},
+}
+
+func TestUpgradeFromV0(t *testing.T) {
+	data := map[string]string{"old": "x", "keep": "y"}
+	result, err := testFormat.Upgrade(data)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"keep": "y", "v1": "added", VersionKey: "2"}, result)
+
+	// The original is untouched.
+	assert.Equal(t, map[string]string{"old": "x", "keep": "y"}, data)
+}
+
+func TestUpgradeFromV1(t *testing.T) {
+	result, err := testFormat.Upgrade(map[string]string{"old": "x", VersionKey: "1"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{VersionKey: "2"}, result)
+}
+
+func TestUpgradeCurrent(t *testing.T) {
+	data := map[string]string{"old": "x", VersionKey: "2"}
+	result, err := testFormat.Upgrade(data)
+	require.NoError(t, err)
+	assert.Equal(t, data, result)
+}
+
+func TestUpgradeNil(t *testing.T) {
+	result, err := testFormat.Upgrade(nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"v1": "added", VersionKey: "2"}, result)
+}
+
+func TestUpgradeFutureVersion(t *testing.T) {
+	_, err := testFormat.Upgrade(map[string]string{VersionKey: "3"})
+	require.Error(t, err)
+
+	var fve FutureVersionError
+	require.True(t, errors.As(err, &fve))
+	assert.Equal(t, 3, fve.Version)
+	assert.Equal(t, 2, fve.MaxVersion)
+	assert.Equal(t,
+		"the test data was written in format version 3 by a newer version of Tilt, "+
+			"but this Tilt only understands up to version 2. "+
+			"Upgrade Tilt, or delete the ConfigMap to start over",
+		err.Error())
+}
+
+func TestUpgradeInvalidVersion(t *testing.T) {
+	_, err := testFormat.Upgrade(map[string]string{VersionKey: "latest"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `the test data has an invalid format version "latest"`)
+}
+
+func TestUpgradedNotInternal(t *testing.T) {
+	cm := &v1alpha1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-config"},
+		Data:       map[string]string{VersionKey: "100"},
+	}
+	result, err := Upgraded(cm)
+	require.NoError(t, err)
+	assert.Same(t, cm, result)
+}
+
+func TestUpgradedCurrent(t *testing.T) {
+	cm := &v1alpha1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "fe-disable"},
+		Data:       map[string]string{DisableKey: "true", VersionKey: "1"},
+	}
+	result, err := Upgraded(cm)
+	require.NoError(t, err)
+	assert.Same(t, cm, result)
+}
+
+func TestUpgradedCopies(t *testing.T) {
+	cm := &v1alpha1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "fe-disable"},
+		Data:       map[string]string{DisableKey: "True"},
+	}
+	result, err := Upgraded(cm)
+	require.NoError(t, err)
+	assert.NotSame(t, cm, result)
+	assert.Equal(t, "fe-disable", result.Name)
+	assert.Equal(t, map[string]string{DisableKey: "true", VersionKey: "1"}, result.Data)
+	assert.Equal(t, map[string]string{DisableKey: "True"}, cm.Data)
+}
+
+func TestUpgradedFutureVersion(t *testing.T) {
+	cm := &v1alpha1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: TriggerQueueName},
+		Data:       map[string]string{VersionKey: "2"},
+	}
+	_, err := Upgraded(cm)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `ConfigMap "tilt-trigger-queue" was written in format version 2`)
+}
+
+func TestTriggerQueueV0(t *testing.T) {
+	cm := &v1alpha1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: TriggerQueueName},
+		Data: map[string]string{
+			"3-name":          "be",
+			"3-reason-code":   "64",
+			"5-name":          "",
+			"5-reason-code":   "8",
+			"7-name":          "fe",
+			"7-reason-code":   "manual",
+			"9-name":          "be",
+			"9-reason-code":   "8",
+			"abc-name":        "db",
+			"abc-reason-code": "8",
+		},
+	}
+
+	names := NamesInTriggerQueue(cm)
+	sort.Strings(names)
+	assert.Equal(t, []string{"be", "db", "fe"}, names)
+	assert.Equal(t, model.BuildReason(64), TriggerQueueReason(cm, types.NamespacedName{Name: "be"}))
+	assert.Equal(t, model.BuildReasonFlagTriggerUnknown, TriggerQueueReason(cm, types.NamespacedName{Name: "fe"}))
+	assert.Equal(t, model.BuildReason(8), TriggerQueueReason(cm, types.NamespacedName{Name: "db"}))
+
+	upgraded, err := Upgraded(cm)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"0-name":        "be",
+		"0-reason-code": "64",
+		"1-name":        "fe",
+		"2-name":        "db",
+		"2-reason-code": "8",
+		VersionKey:      "1",
+	}, upgraded.Data)
+}
+
+func TestTriggerQueueFutureVersionIsEmpty(t *testing.T) {
+	cm := &v1alpha1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: TriggerQueueName},
+		Data:       map[string]string{"0-name": "fe", VersionKey: "2"},
+	}
+	assert.Empty(t, NamesInTriggerQueue(cm))
+	assert.False(t, InTriggerQueue(cm, types.NamespacedName{Name: "fe"}))
+}
+
+func TestDisableV0(t *testing.T) {
+	cm := &v1alpha1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "fe-disable"},
+		Data: map[string]string{
+			DisableKey:        " True",
+			DisableHistoryKey: "{not json",
+		},
+	}
+	upgraded, err := Upgraded(cm)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{DisableKey: "true", VersionKey: "1"}, upgraded.Data)
+}
+
+func TestDisableStatusFutureVersion(t *testing.T) {
+	getCM := func(name string) (v1alpha1.ConfigMap, error) {
+		return v1alpha1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Data:       map[string]string{DisableKey: "true", VersionKey: "2"},
+		}, nil
+	}
+	source := &v1alpha1.DisableSource{
+		ConfigMap: &v1alpha1.ConfigMapDisableSource{Name: "fe-disable", Key: DisableKey},
+	}
+	disabled, reason, err := DisableStatus(getCM, source)
+	require.NoError(t, err)
+	assert.False(t, disabled)
+	assert.Contains(t, reason, "by a newer version of Tilt")
+}
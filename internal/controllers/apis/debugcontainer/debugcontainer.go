@@ -0,0 +1,133 @@
+package debugcontainer
+
+// Functions for attaching ephemeral debug containers to a resource's pod.
+//
+// Each Kubernetes resource gets a UIButton that attaches a debug container
+// (like `kubectl debug`) to the resource's current pod. The button takes the
+// image to run, and optionally a command to run once in the container and a
+// port to forward to it.
+//
+// The same action is available from the CLI with `tilt debug-container`.
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+)
+
+// The value of the button-type annotation on debug container buttons.
+const ButtonType = "DebugContainer"
+
+const (
+	ImageInput   = "image"
+	CommandInput = "command"
+	PortInput    = "port"
+)
+
+// What to attach to the resource's pod.
+type Options struct {
+	// The image to run in the debug container.
+	Image string
+
+	// A command to run once in the debug container after it starts, with its
+	// output going to the debug container's logs. Optional.
+	Command []string
+
+	// A port in the pod to forward to the same port on localhost. Optional.
+	Port int
+}
+
+// A debug container that Tilt attached to a pod.
+type Attachment struct {
+	Pod       string `json:"pod"`
+	Namespace string `json:"namespace"`
+	Container string `json:"container"`
+
+	// The log span that the debug container's logs go to.
+	SpanID string `json:"span_id"`
+}
+
+func UIButtonName(resource string) string {
+	return fmt.Sprintf("%s-debug-container", resource)
+}
+
+// Creates a button that attaches a debug container to the resource's pod.
+func ToUIButton(resource string, defaultImage string) *v1alpha1.UIButton {
+	return &v1alpha1.UIButton{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: UIButtonName(resource),
+			Annotations: map[string]string{
+				v1alpha1.AnnotationButtonType: ButtonType,
+			},
+		},
+		Spec: v1alpha1.UIButtonSpec{
+			Location: v1alpha1.UIComponentLocation{
+				ComponentID:   resource,
+				ComponentType: v1alpha1.ComponentTypeResource,
+			},
+			Text:     "Attach debug container",
+			IconName: "pest_control",
+			Inputs: []v1alpha1.UIInputSpec{
+				{
+					Name:  ImageInput,
+					Label: "Image",
+					Text:  &v1alpha1.UITextInputSpec{DefaultValue: defaultImage},
+				},
+				{
+					Name:  CommandInput,
+					Label: "Command (optional)",
+					Text:  &v1alpha1.UITextInputSpec{Placeholder: "e.g., netstat -tlpn"},
+				},
+				{
+					Name:  PortInput,
+					Label: "Port to forward (optional)",
+					Text:  &v1alpha1.UITextInputSpec{Placeholder: "e.g., 8080"},
+				},
+			},
+		},
+	}
+}
+
+// Reads the options from the inputs of a clicked button.
+func OptionsFromUIButton(b *v1alpha1.UIButton) (Options, error) {
+	var image, command, port string
+	for _, input := range b.Status.Inputs {
+		if input.Text == nil {
+			continue
+		}
+		switch input.Name {
+		case ImageInput:
+			image = input.Text.Value
+		case CommandInput:
+			command = input.Text.Value
+		case PortInput:
+			port = input.Text.Value
+		}
+	}
+	return ParseOptions(image, command, port)
+}
+
+// Parses options from the strings a user typed in.
+func ParseOptions(image string, command string, port string) (Options, error) {
+	result := Options{
+		Image:   strings.TrimSpace(image),
+		Command: strings.Fields(command),
+	}
+	if result.Image == "" {
+		return Options{}, fmt.Errorf("debug container needs an image")
+	}
+
+	port = strings.TrimSpace(port)
+	if port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil || p < 1 || p > 65535 {
+			return Options{}, fmt.Errorf("invalid port %q", port)
+		}
+		result.Port = p
+	}
+	return result, nil
+}
@@ -0,0 +1,41 @@
+package debugcontainer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+)
+
+func TestOptionsFromUIButton(t *testing.T) {
+	b := ToUIButton("fe", "busybox:1.35")
+	assert.Equal(t, "fe-debug-container", b.Name)
+	assert.Equal(t, ButtonType, b.Annotations[v1alpha1.AnnotationButtonType])
+	assert.Equal(t, "busybox:1.35", b.Spec.Inputs[0].Text.DefaultValue)
+
+	b.Status.Inputs = []v1alpha1.UIInputStatus{
+		{Name: ImageInput, Text: &v1alpha1.UITextInputStatus{Value: " nicolaka/netshoot "}},
+		{Name: CommandInput, Text: &v1alpha1.UITextInputStatus{Value: "netstat  -tlpn"}},
+		{Name: PortInput, Text: &v1alpha1.UITextInputStatus{Value: "8080"}},
+	}
+	opts, err := OptionsFromUIButton(b)
+	require.NoError(t, err)
+	assert.Equal(t, Options{
+		Image:   "nicolaka/netshoot",
+		Command: []string{"netstat", "-tlpn"},
+		Port:    8080,
+	}, opts)
+}
+
+func TestParseOptionsErrors(t *testing.T) {
+	_, err := ParseOptions("", "", "")
+	assert.EqualError(t, err, "debug container needs an image")
+
+	_, err = ParseOptions("busybox", "", "http")
+	assert.EqualError(t, err, `invalid port "http"`)
+
+	_, err = ParseOptions("busybox", "", "70000")
+	assert.EqualError(t, err, `invalid port "70000"`)
+}
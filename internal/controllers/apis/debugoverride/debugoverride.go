@@ -0,0 +1,225 @@
+package debugoverride
+
+// Functions for storing debug overrides in ConfigMaps.
+//
+// Each Kubernetes resource can have a debug override, stored in a ConfigMap
+// named after the resource. The ConfigMap can be declared in the Tiltfile
+// (with k8s_debug_override()) or created at runtime with the API.
+//
+// The override is only applied while its `enabled` key is true.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+const (
+	EnabledKey              = "enabled"
+	ContainerKey            = "container"
+	CommandKey              = "command"
+	ArgsKey                 = "args"
+	PortsKey                = "ports"
+	RemoveReadinessProbeKey = "removeReadinessProbe"
+)
+
+// Marks ConfigMaps that the Tiltfile created from k8s_debug_override().
+//
+// The Tiltfile owns every key of these ConfigMaps except `enabled`.
+const AnnotationTiltfileSpec = "tilt.dev/debug-override-spec"
+
+// Version 1 stores EnabledKey and RemoveReadinessProbeKey as "true" or
+// "false", because the ToggleButton compares EnabledKey as a string.
+var Format = configmap.RegisterFormat(configmap.Format{
+	Name: "the debug override",
+	Matches: func(name string) bool {
+		return strings.HasSuffix(name, "-debug-override")
+	},
+	Migrations: []configmap.Migration{migrateV0},
+})
+
+func migrateV0(data map[string]string) map[string]string {
+	configmap.NormalizeBool(data, EnabledKey)
+	configmap.NormalizeBool(data, RemoveReadinessProbeKey)
+	return data
+}
+
+func ConfigMapName(resource string) string {
+	return fmt.Sprintf("%s-debug-override", resource)
+}
+
+func ToggleButtonName(resource string) string {
+	return fmt.Sprintf("%s-debug-override", resource)
+}
+
+// Whether the Tiltfile should overwrite the given key when it reloads,
+// rather than keeping the value that's already in the API server.
+func IsTiltfileOwnedKey(cm *v1alpha1.ConfigMap, key string) bool {
+	return cm.Annotations[AnnotationTiltfileSpec] == "true" && key != EnabledKey
+}
+
+// Creates the ConfigMap for a debug override declared in the Tiltfile.
+// The override starts out disabled.
+func ToConfigMap(resource string, o model.K8sDebugOverride) *v1alpha1.ConfigMap {
+	data := map[string]string{
+		EnabledKey:              "false",
+		ContainerKey:            o.Container,
+		CommandKey:              encodeStrings(o.Command),
+		ArgsKey:                 encodeStrings(o.Args),
+		PortsKey:                encodePorts(o.Ports),
+		RemoveReadinessProbeKey: strconv.FormatBool(o.RemoveReadinessProbe),
+	}
+	return &v1alpha1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: ConfigMapName(resource),
+			Annotations: map[string]string{
+				AnnotationTiltfileSpec: "true",
+			},
+		},
+		Data: Format.Stamp(data),
+	}
+}
+
+// Creates a button that turns the debug override on and off.
+func ToToggleButton(resource string) *v1alpha1.ToggleButton {
+	return &v1alpha1.ToggleButton{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: ToggleButtonName(resource),
+		},
+		Spec: v1alpha1.ToggleButtonSpec{
+			Location: v1alpha1.UIComponentLocation{
+				ComponentID:   resource,
+				ComponentType: v1alpha1.ComponentTypeResource,
+			},
+			On: v1alpha1.ToggleButtonStateSpec{
+				Text:     "Debug override on",
+				IconName: "bug_report",
+			},
+			Off: v1alpha1.ToggleButtonStateSpec{
+				Text:     "Debug override off",
+				IconName: "bug_report",
+			},
+			StateSource: v1alpha1.StateSource{
+				ConfigMap: &v1alpha1.ConfigMapStateSource{
+					Name:     ConfigMapName(resource),
+					Key:      EnabledKey,
+					OnValue:  "true",
+					OffValue: "false",
+				},
+			},
+		},
+	}
+}
+
+// Returns the data of the resource's debug override, if it's enabled.
+//
+// Returns nil if the resource doesn't have a debug override, or it's disabled.
+func EnabledData(ctx context.Context, c client.Client, resource string) (map[string]string, error) {
+	var cm v1alpha1.ConfigMap
+	err := c.Get(ctx, types.NamespacedName{Name: ConfigMapName(resource)}, &cm)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	upgraded, err := configmap.Upgraded(&cm)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled, _ := strconv.ParseBool(upgraded.Data[EnabledKey])
+	if !enabled {
+		return nil, nil
+	}
+	return upgraded.Data, nil
+}
+
+// Parses a debug override from ConfigMap data.
+//
+// The command and args are JSON lists of strings. Ports are a
+// comma-separated list of port numbers.
+func Parse(data map[string]string) (model.K8sDebugOverride, error) {
+	o := model.K8sDebugOverride{
+		Container: data[ContainerKey],
+	}
+
+	var err error
+	o.Command, err = decodeStrings(data[CommandKey])
+	if err != nil {
+		return model.K8sDebugOverride{}, fmt.Errorf("debug override %s: %v", CommandKey, err)
+	}
+
+	o.Args, err = decodeStrings(data[ArgsKey])
+	if err != nil {
+		return model.K8sDebugOverride{}, fmt.Errorf("debug override %s: %v", ArgsKey, err)
+	}
+
+	o.Ports, err = decodePorts(data[PortsKey])
+	if err != nil {
+		return model.K8sDebugOverride{}, fmt.Errorf("debug override %s: %v", PortsKey, err)
+	}
+
+	if v := data[RemoveReadinessProbeKey]; v != "" {
+		o.RemoveReadinessProbe, err = strconv.ParseBool(v)
+		if err != nil {
+			return model.K8sDebugOverride{}, fmt.Errorf("debug override %s: %v", RemoveReadinessProbeKey, err)
+		}
+	}
+	return o, nil
+}
+
+func encodeStrings(s []string) string {
+	if s == nil {
+		return ""
+	}
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func decodeStrings(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	result := []string{}
+	err := json.Unmarshal([]byte(s), &result)
+	if err != nil {
+		return nil, fmt.Errorf("expected a JSON list of strings, got %q", s)
+	}
+	return result, nil
+}
+
+func encodePorts(ports []int32) string {
+	strs := make([]string, 0, len(ports))
+	for _, p := range ports {
+		strs = append(strs, strconv.Itoa(int(p)))
+	}
+	return strings.Join(strs, ",")
+}
+
+func decodePorts(s string) ([]int32, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var result []int32
+	for _, part := range strings.Split(s, ",") {
+		p, err := strconv.ParseInt(strings.TrimSpace(part), 10, 32)
+		if err != nil || p <= 0 || p > 65535 {
+			return nil, fmt.Errorf("invalid port %q", part)
+		}
+		result = append(result, int32(p))
+	}
+	return result, nil
+}
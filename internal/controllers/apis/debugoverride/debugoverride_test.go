@@ -0,0 +1,103 @@
+package debugoverride
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
+	"github.com/tilt-dev/tilt/internal/controllers/fake"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestRoundTrip(t *testing.T) {
+	o := model.K8sDebugOverride{
+		Container:            "app",
+		Command:              []string{"dlv", "exec", "/app/server", "--"},
+		Args:                 []string{"--port", "8080"},
+		Ports:                []int32{2345, 40000},
+		RemoveReadinessProbe: true,
+	}
+
+	cm := ToConfigMap("fe", o)
+	assert.Equal(t, "fe-debug-override", cm.Name)
+	assert.Equal(t, "false", cm.Data[EnabledKey])
+
+	parsed, err := Parse(cm.Data)
+	require.NoError(t, err)
+	assert.Equal(t, o, parsed)
+}
+
+func TestParseLeavesUnsetFieldsAlone(t *testing.T) {
+	parsed, err := Parse(map[string]string{
+		EnabledKey: "true",
+		ArgsKey:    `[]`,
+	})
+	require.NoError(t, err)
+	assert.Nil(t, parsed.Command)
+	assert.Equal(t, []string{}, parsed.Args)
+	assert.False(t, parsed.RemoveReadinessProbe)
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Parse(map[string]string{CommandKey: "dlv exec"})
+	assert.EqualError(t, err, `debug override command: expected a JSON list of strings, got "dlv exec"`)
+
+	_, err = Parse(map[string]string{PortsKey: "2345,http"})
+	assert.EqualError(t, err, `debug override ports: invalid port "http"`)
+}
+
+func TestEnabledData(t *testing.T) {
+	ctx := context.Background()
+	c := fake.NewFakeTiltClient()
+
+	data, err := EnabledData(ctx, c, "fe")
+	require.NoError(t, err)
+	assert.Nil(t, data)
+
+	cm := ToConfigMap("fe", model.K8sDebugOverride{Command: []string{"dlv"}})
+	require.NoError(t, c.Create(ctx, cm))
+
+	data, err = EnabledData(ctx, c, "fe")
+	require.NoError(t, err)
+	assert.Nil(t, data)
+
+	cm.Data[EnabledKey] = "true"
+	require.NoError(t, c.Update(ctx, cm))
+
+	data, err = EnabledData(ctx, c, "fe")
+	require.NoError(t, err)
+	assert.Equal(t, `["dlv"]`, data[CommandKey])
+}
+
+func TestIsTiltfileOwnedKey(t *testing.T) {
+	cm := ToConfigMap("fe", model.K8sDebugOverride{})
+	assert.True(t, IsTiltfileOwnedKey(cm, CommandKey))
+	assert.False(t, IsTiltfileOwnedKey(cm, EnabledKey))
+
+	runtimeCM := &v1alpha1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName("fe")}}
+	assert.False(t, IsTiltfileOwnedKey(runtimeCM, CommandKey))
+}
+
+func TestMigrateV0(t *testing.T) {
+	cm := &v1alpha1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName("fe")},
+		Data: map[string]string{
+			EnabledKey:              "True",
+			RemoveReadinessProbeKey: "1",
+			CommandKey:              `["dlv"]`,
+		},
+	}
+	upgraded, err := configmap.Upgraded(cm)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		EnabledKey:              "true",
+		RemoveReadinessProbeKey: "true",
+		CommandKey:              `["dlv"]`,
+		configmap.VersionKey:    "1",
+	}, upgraded.Data)
+}
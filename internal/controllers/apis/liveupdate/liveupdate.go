@@ -24,6 +24,12 @@ func FallBackOnFiles(spec v1alpha1.LiveUpdateSpec) model.PathSet {
 	return model.NewPathSet(spec.StopPaths, spec.BasePath)
 }
 
+// RestartOnlyFiles returns a PathSet of files which only need the container
+// restarted, rather than running any execs.
+func RestartOnlyFiles(spec v1alpha1.LiveUpdateSpec) model.PathSet {
+	return model.NewPathSet(spec.RestartPaths, spec.BasePath)
+}
+
 // Evaluates live-update syncs relative to the base path,
 // and returns a sync with resolved paths.
 func SyncSteps(spec v1alpha1.LiveUpdateSpec) []model.Sync {
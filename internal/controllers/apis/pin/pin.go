@@ -0,0 +1,116 @@
+package pin
+
+// Functions for pinning a Kubernetes resource to its current pod.
+//
+// While a resource is pinned, Tilt doesn't apply it or replace its pod, so
+// that the state in the pod survives for debugging. Changes that would have
+// been applied are held as pending, and applied when the resource is unpinned.
+// Live updates still sync into the pinned pod.
+//
+// The pin is stored in a ConfigMap named after the resource, and toggled with a
+// ToggleButton (or by editing the ConfigMap with the API).
+
+import (
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+)
+
+const (
+	PinnedKey = "pinned"
+
+	// The pod we pinned to. Tilt fills this in when the resource is pinned,
+	// and clears it when the pin is removed.
+	PodKey = "pod"
+)
+
+// Version 1 stores PinnedKey as "true" or "false", and only keeps PodKey
+// while the resource is pinned.
+var Format = configmap.RegisterFormat(configmap.Format{
+	Name: "the pin",
+	Matches: func(name string) bool {
+		return strings.HasSuffix(name, "-pin")
+	},
+	Migrations: []configmap.Migration{migrateV0},
+})
+
+// Before version 1, unpinning could leave the old pod behind.
+func migrateV0(data map[string]string) map[string]string {
+	configmap.NormalizeBool(data, PinnedKey)
+	if pinned, _ := strconv.ParseBool(data[PinnedKey]); !pinned {
+		delete(data, PodKey)
+	}
+	return data
+}
+
+func ConfigMapName(resource string) string {
+	return resource + "-pin"
+}
+
+func ToggleButtonName(resource string) string {
+	return resource + "-pin"
+}
+
+// Creates the ConfigMap that stores the pin. Resources start out unpinned.
+func ToConfigMap(resource string) *v1alpha1.ConfigMap {
+	return &v1alpha1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: ConfigMapName(resource),
+		},
+		Data: Format.Stamp(map[string]string{PinnedKey: "false"}),
+	}
+}
+
+// Creates a button that pins and unpins the resource.
+func ToToggleButton(resource string) *v1alpha1.ToggleButton {
+	return &v1alpha1.ToggleButton{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: ToggleButtonName(resource),
+		},
+		Spec: v1alpha1.ToggleButtonSpec{
+			Location: v1alpha1.UIComponentLocation{
+				ComponentID:   resource,
+				ComponentType: v1alpha1.ComponentTypeResource,
+			},
+			On: v1alpha1.ToggleButtonStateSpec{
+				Text:     "Unpin and apply pending changes",
+				IconName: "push_pin",
+			},
+			Off: v1alpha1.ToggleButtonStateSpec{
+				Text:     "Pin to current pod",
+				IconName: "push_pin",
+			},
+			StateSource: v1alpha1.StateSource{
+				ConfigMap: &v1alpha1.ConfigMapStateSource{
+					Name:     ConfigMapName(resource),
+					Key:      PinnedKey,
+					OnValue:  "true",
+					OffValue: "false",
+				},
+			},
+		},
+	}
+}
+
+// Returns true if the resource is pinned, given the ConfigMaps in the engine state.
+func IsPinned(configMaps map[string]*v1alpha1.ConfigMap, resource string) bool {
+	cm, ok := configMaps[ConfigMapName(resource)]
+	if !ok || cm == nil {
+		return false
+	}
+	pinned, _ := strconv.ParseBool(cm.Data[PinnedKey])
+	return pinned
+}
+
+// Returns the pod the resource is pinned to, or "" if we haven't recorded one yet.
+func PinnedPod(configMaps map[string]*v1alpha1.ConfigMap, resource string) string {
+	cm, ok := configMaps[ConfigMapName(resource)]
+	if !ok || cm == nil {
+		return ""
+	}
+	return cm.Data[PodKey]
+}
@@ -2,6 +2,7 @@ package restarton
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -18,18 +19,29 @@ import (
 
 var fwGVK = v1alpha1.SchemeGroupVersion.WithKind("FileWatch")
 var btnGVK = v1alpha1.SchemeGroupVersion.WithKind("UIButton")
+var uirGVK = v1alpha1.SchemeGroupVersion.WithKind("UIResource")
 
 var restartOnTypes = []client.Object{
 	&v1alpha1.FileWatch{},
 	&v1alpha1.UIButton{},
+	&v1alpha1.UIResource{},
 }
 
+// How long a resource has to stay ready before we treat it as a restart event.
+//
+// Resources often flap between ready and not ready while they start up
+// (e.g., a pod that fails its first readiness probe). Consumers wait this long
+// after the most recent transition, so that a burst of transitions only
+// triggers one restart.
+const ReadyDebounce = 2 * time.Second
+
 type ExtractFunc func(obj client.Object) (*v1alpha1.RestartOnSpec, *v1alpha1.StartOnSpec)
 
 // Objects is a container for objects referenced by a RestartOnSpec and/or StartOnSpec.
 type Objects struct {
 	UIButtons   map[string]*v1alpha1.UIButton
 	FileWatches map[string]*v1alpha1.FileWatch
+	UIResources map[string]*v1alpha1.UIResource
 }
 
 // SetupController creates watches for types referenced by v1alpha1.RestartOnSpec & v1alpha1.StartOnSpec and registers
@@ -56,9 +68,15 @@ func FetchObjects(ctx context.Context, client client.Reader, restartOn *v1alpha1
 		return Objects{}, err
 	}
 
+	uiResources, err := UIResources(ctx, client, restartOn)
+	if err != nil {
+		return Objects{}, err
+	}
+
 	return Objects{
 		UIButtons:   buttons,
 		FileWatches: fileWatches,
+		UIResources: uiResources,
 	}, nil
 }
 
@@ -134,11 +152,115 @@ func FileWatches(ctx context.Context, client client.Reader, restartOn *v1alpha1.
 	return result, nil
 }
 
+// Fetch all the resources whose readiness this object depends on.
+//
+// If a resource isn't in the API server yet, it will simply be missing from the map.
+//
+// Other errors reaching the API server will be returned to the caller.
+func UIResources(ctx context.Context, client client.Reader, restartOn *v1alpha1.RestartOnSpec) (map[string]*v1alpha1.UIResource, error) {
+	if restartOn == nil {
+		return nil, nil
+	}
+
+	result := make(map[string]*v1alpha1.UIResource, len(restartOn.UIResources))
+	for _, n := range restartOn.UIResources {
+		r := &v1alpha1.UIResource{}
+		err := client.Get(ctx, types.NamespacedName{Name: n}, r)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		result[n] = r
+	}
+	return result, nil
+}
+
+// The value of a button input at the time of a click.
+type Input struct {
+	Name  string
+	Value string
+}
+
+// Resolve the values of the button's inputs for its most recent click.
+//
+// Values come from the click's status, matched to the inputs in the spec.
+// If the click didn't submit a value for an input (or submitted one that
+// isn't valid for it), we use the input's default rather than anything left
+// over from an earlier click.
+func InputsFromButton(b *v1alpha1.UIButton) []Input {
+	if b == nil {
+		return nil
+	}
+
+	statuses := make(map[string]v1alpha1.UIInputStatus, len(b.Status.Inputs))
+	for _, status := range b.Status.Inputs {
+		statuses[status.Name] = status
+	}
+
+	result := make([]Input, 0, len(b.Spec.Inputs))
+	for _, spec := range b.Spec.Inputs {
+		result = append(result, Input{
+			Name:  spec.Name,
+			Value: inputValue(spec, statuses[spec.Name]),
+		})
+	}
+	return result
+}
+
+func inputValue(spec v1alpha1.UIInputSpec, status v1alpha1.UIInputStatus) string {
+	switch {
+	case spec.Text != nil:
+		if status.Text != nil {
+			return status.Text.Value
+		}
+		return spec.Text.DefaultValue
+
+	case spec.Bool != nil:
+		value := spec.Bool.DefaultValue
+		if status.Bool != nil {
+			value = status.Bool.Value
+		}
+		if value {
+			if spec.Bool.TrueString != nil {
+				return *spec.Bool.TrueString
+			}
+			return "true"
+		}
+		if spec.Bool.FalseString != nil {
+			return *spec.Bool.FalseString
+		}
+		return "false"
+
+	case spec.Hidden != nil:
+		// Hidden inputs aren't user-editable, so the spec is the source of truth.
+		return spec.Hidden.Value
+
+	case spec.Choice != nil:
+		if status.Choice != nil {
+			for _, c := range spec.Choice.Choices {
+				if c == status.Choice.Value {
+					return c
+				}
+			}
+		}
+		if spec.Choice.DefaultValue != "" {
+			return spec.Choice.DefaultValue
+		}
+		if len(spec.Choice.Choices) > 0 {
+			return spec.Choice.Choices[0]
+		}
+	}
+	return ""
+}
+
 // Fetch the last time a start was requested from this target's dependencies.
 //
 // Returns the most recent trigger time. If the most recent trigger is a button,
-// return the button. Some consumers use the button for text inputs.
-func LastStartEvent(startOn *v1alpha1.StartOnSpec, restartObjs Objects) (time.Time, *v1alpha1.UIButton) {
+// also returns the values of that button's inputs, so that consumers can pass
+// them on (e.g., as env variables).
+func LastStartEvent(startOn *v1alpha1.StartOnSpec, restartObjs Objects) (time.Time, []Input) {
 	latestTime := time.Time{}
 	var latestButton *v1alpha1.UIButton
 	if startOn == nil {
@@ -158,14 +280,14 @@ func LastStartEvent(startOn *v1alpha1.StartOnSpec, restartObjs Objects) (time.Ti
 		}
 	}
 
-	return latestTime, latestButton
+	return latestTime, InputsFromButton(latestButton)
 }
 
 // Fetch the last time a restart was requested from this target's dependencies.
 //
 // Returns the most recent trigger time. If the most recent trigger is a button,
-// return the button. Some consumers use the button for text inputs.
-func LastRestartEvent(restartOn *v1alpha1.RestartOnSpec, restartObjs Objects) (time.Time, *v1alpha1.UIButton) {
+// also returns the values of that button's inputs.
+func LastRestartEvent(restartOn *v1alpha1.RestartOnSpec, restartObjs Objects) (time.Time, []Input) {
 	cur := time.Time{}
 	var latestButton *v1alpha1.UIButton
 	if restartOn == nil {
@@ -197,7 +319,90 @@ func LastRestartEvent(restartOn *v1alpha1.RestartOnSpec, restartObjs Objects) (t
 		}
 	}
 
-	return cur, latestButton
+	lastReadyTime, _ := LastReadyEvent(restartOn, restartObjs)
+	if lastReadyTime.After(cur) {
+		cur = lastReadyTime
+		latestButton = nil
+	}
+
+	return cur, InputsFromButton(latestButton)
+}
+
+// Fetch the last time one of this target's resource dependencies became ready.
+//
+// Resources that aren't ready right now are skipped, so a resource that went
+// ready then not-ready doesn't trigger anything until it's ready again.
+//
+// Returns the transition time and the name of the resource.
+func LastReadyEvent(restartOn *v1alpha1.RestartOnSpec, restartObjs Objects) (time.Time, string) {
+	cur := time.Time{}
+	name := ""
+	if restartOn == nil {
+		return cur, name
+	}
+
+	for _, rn := range restartOn.UIResources {
+		r, ok := restartObjs.UIResources[rn]
+		if !ok {
+			// ignore missing resources
+			continue
+		}
+		if r.Status.RuntimeStatus != v1alpha1.RuntimeStatusOK {
+			continue
+		}
+		lastEventTime := r.Status.LastReadyTime
+		if lastEventTime.Time.After(cur) {
+			cur = lastEventTime.Time
+			name = rn
+		}
+	}
+	return cur, name
+}
+
+// Describe the most recent restart event, for display on the run it triggers
+// (e.g., "api became ready at 12:03:04").
+//
+// Returns an empty string if nothing has triggered a restart.
+func LastRestartCause(restartOn *v1alpha1.RestartOnSpec, restartObjs Objects) string {
+	cur := time.Time{}
+	cause := ""
+	if restartOn == nil {
+		return cause
+	}
+
+	for _, fwn := range restartOn.FileWatches {
+		fw, ok := restartObjs.FileWatches[fwn]
+		if !ok {
+			continue
+		}
+		lastEventTime := fw.Status.LastEventTime.Time
+		if lastEventTime.After(cur) {
+			cur = lastEventTime
+			cause = fmt.Sprintf("files changed in %s at %s", fwn, formatCauseTime(cur))
+		}
+	}
+
+	for _, bn := range restartOn.UIButtons {
+		b, ok := restartObjs.UIButtons[bn]
+		if !ok {
+			continue
+		}
+		lastEventTime := b.Status.LastClickedAt.Time
+		if lastEventTime.After(cur) {
+			cur = lastEventTime
+			cause = fmt.Sprintf("%s clicked at %s", bn, formatCauseTime(cur))
+		}
+	}
+
+	lastReadyTime, rn := LastReadyEvent(restartOn, restartObjs)
+	if lastReadyTime.After(cur) {
+		cause = fmt.Sprintf("%s became ready at %s", rn, formatCauseTime(lastReadyTime))
+	}
+	return cause
+}
+
+func formatCauseTime(t time.Time) string {
+	return t.Local().Format("15:04:05")
 }
 
 // Fetch the set of files that have changed since the given timestamp.
@@ -226,6 +431,26 @@ func FilesChanged(restartOn *v1alpha1.RestartOnSpec, fileWatches map[string]*v1a
 	return sliceutils.DedupedAndSorted(filesChanged)
 }
 
+// Fetch the time of the most recent file event.
+func LastFileEvent(restartOn *v1alpha1.RestartOnSpec, fileWatches map[string]*v1alpha1.FileWatch) time.Time {
+	cur := time.Time{}
+	if restartOn == nil {
+		return cur
+	}
+	for _, fwn := range restartOn.FileWatches {
+		fw, ok := fileWatches[fwn]
+		if !ok {
+			continue
+		}
+		for _, e := range fw.Status.FileEvents {
+			if e.Time.Time.After(cur) {
+				cur = e.Time.Time
+			}
+		}
+	}
+	return cur
+}
+
 // registerWatches ensures that reconciliation happens on changes to objects referenced by RestartOnSpec/StartOnSpec.
 func registerWatches(builder *builder.Builder, indexer *indexer.Indexer) {
 	for _, t := range restartOnTypes {
@@ -255,6 +480,13 @@ func extractKeysForIndexer(namespace string, restartOn *v1alpha1.RestartOnSpec,
 				GVK:  btnGVK,
 			})
 		}
+
+		for _, name := range restartOn.UIResources {
+			keys = append(keys, indexer.Key{
+				Name: types.NamespacedName{Namespace: namespace, Name: name},
+				GVK:  uirGVK,
+			})
+		}
 	}
 
 	if startOn != nil {
@@ -3,6 +3,7 @@ package restarton
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -45,6 +46,10 @@ func TestExtractKeysForIndexer(t *testing.T) {
 		return key(name, "UIButton")
 	}
 
+	uirKey := func(name string) indexer.Key {
+		return key(name, "UIResource")
+	}
+
 	type tc struct {
 		restartOn *v1alpha1.RestartOnSpec
 		startOn   *v1alpha1.StartOnSpec
@@ -68,6 +73,11 @@ func TestExtractKeysForIndexer(t *testing.T) {
 			&v1alpha1.StartOnSpec{UIButtons: []string{"baz"}},
 			[]indexer.Key{fwKey("foo"), btnKey("bar"), btnKey("baz")},
 		},
+		{
+			&v1alpha1.RestartOnSpec{UIResources: []string{"api"}},
+			nil,
+			[]indexer.Key{uirKey("api")},
+		},
 	}
 
 	for _, tc := range tcs {
@@ -86,11 +96,13 @@ func TestFetchObjects(t *testing.T) {
 	f.Create(&v1alpha1.FileWatch{ObjectMeta: metav1.ObjectMeta{Name: "fw2"}})
 	f.Create(&v1alpha1.UIButton{ObjectMeta: metav1.ObjectMeta{Name: "btn1"}})
 	f.Create(&v1alpha1.UIButton{ObjectMeta: metav1.ObjectMeta{Name: "btn2"}})
+	f.Create(&v1alpha1.UIResource{ObjectMeta: metav1.ObjectMeta{Name: "api"}})
 
 	restartObjs, err := FetchObjects(f.Context(), f.Client,
 		&v1alpha1.RestartOnSpec{
 			FileWatches: []string{"fw1", "fw2", "fw3"},
 			UIButtons:   []string{"btn1"},
+			UIResources: []string{"api", "db"},
 		},
 		&v1alpha1.StartOnSpec{
 			UIButtons: []string{"btn2", "btn3"},
@@ -105,6 +117,10 @@ func TestFetchObjects(t *testing.T) {
 	assert.NotNil(t, restartObjs.UIButtons["btn2"])
 	// btn3 doesn't exist but should have been silently ignored
 	assert.Nil(t, restartObjs.UIButtons["btn3"])
+
+	assert.NotNil(t, restartObjs.UIResources["api"])
+	// db doesn't exist but should have been silently ignored
+	assert.Nil(t, restartObjs.UIResources["db"])
 }
 
 func TestFetchObjects_Error(t *testing.T) {
@@ -138,3 +154,111 @@ func (e explodingReader) Get(_ context.Context, _ ctrlclient.ObjectKey, _ ctrlcl
 func (e explodingReader) List(_ context.Context, _ ctrlclient.ObjectList, _ ...ctrlclient.ListOption) error {
 	return e.err
 }
+
+func TestInputsFromButton(t *testing.T) {
+	b := &v1alpha1.UIButton{
+		Spec: v1alpha1.UIButtonSpec{
+			Inputs: []v1alpha1.UIInputSpec{
+				{Name: "text", Text: &v1alpha1.UITextInputSpec{DefaultValue: "default"}},
+				{Name: "bool", Bool: &v1alpha1.UIBoolInputSpec{DefaultValue: true}},
+				{Name: "hidden", Hidden: &v1alpha1.UIHiddenInputSpec{Value: "from-spec"}},
+				{Name: "choice", Choice: &v1alpha1.UIChoiceInputSpec{Choices: []string{"a", "b"}}},
+			},
+		},
+		Status: v1alpha1.UIButtonStatus{
+			Inputs: []v1alpha1.UIInputStatus{
+				{Name: "choice", Choice: &v1alpha1.UIChoiceInputStatus{Value: "b"}},
+				{Name: "hidden", Hidden: &v1alpha1.UIHiddenInputStatus{Value: "from-status"}},
+				{Name: "removed", Text: &v1alpha1.UITextInputStatus{Value: "ignored"}},
+			},
+		},
+	}
+
+	assert.Equal(t, []Input{
+		{Name: "text", Value: "default"},
+		{Name: "bool", Value: "true"},
+		{Name: "hidden", Value: "from-spec"},
+		{Name: "choice", Value: "b"},
+	}, InputsFromButton(b))
+	assert.Nil(t, InputsFromButton(nil))
+}
+
+func TestLastStartEventInputs(t *testing.T) {
+	startAfter := time.Unix(1000, 0)
+	button := func(name string, clicked time.Time, value string) *v1alpha1.UIButton {
+		return &v1alpha1.UIButton{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: v1alpha1.UIButtonSpec{
+				Inputs: []v1alpha1.UIInputSpec{{Name: "x", Text: &v1alpha1.UITextInputSpec{}}},
+			},
+			Status: v1alpha1.UIButtonStatus{
+				LastClickedAt: metav1.NewMicroTime(clicked),
+				Inputs:        []v1alpha1.UIInputStatus{{Name: "x", Text: &v1alpha1.UITextInputStatus{Value: value}}},
+			},
+		}
+	}
+
+	startOn := &v1alpha1.StartOnSpec{
+		UIButtons:  []string{"early", "late"},
+		StartAfter: metav1.NewTime(startAfter),
+	}
+	objs := Objects{UIButtons: map[string]*v1alpha1.UIButton{
+		"early": button("early", startAfter.Add(time.Second), "from-early"),
+		"late":  button("late", startAfter.Add(2*time.Second), "from-late"),
+	}}
+
+	ts, inputs := LastStartEvent(startOn, objs)
+	assert.Equal(t, startAfter.Add(2*time.Second), ts)
+	assert.Equal(t, []Input{{Name: "x", Value: "from-late"}}, inputs)
+
+	// Clicks from before StartAfter don't count.
+	objs.UIButtons["late"] = button("late", startAfter.Add(-time.Second), "stale")
+	ts, inputs = LastStartEvent(startOn, objs)
+	assert.Equal(t, startAfter.Add(time.Second), ts)
+	assert.Equal(t, []Input{{Name: "x", Value: "from-early"}}, inputs)
+}
+
+func TestLastRestartEventReady(t *testing.T) {
+	start := time.Unix(1000, 0)
+	resource := func(status v1alpha1.RuntimeStatus, lastReady time.Time) *v1alpha1.UIResource {
+		return &v1alpha1.UIResource{
+			Status: v1alpha1.UIResourceStatus{
+				RuntimeStatus: status,
+				LastReadyTime: metav1.NewMicroTime(lastReady),
+			},
+		}
+	}
+
+	restartOn := &v1alpha1.RestartOnSpec{
+		FileWatches: []string{"fw"},
+		UIResources: []string{"api"},
+	}
+	objs := Objects{
+		FileWatches: map[string]*v1alpha1.FileWatch{
+			"fw": {Status: v1alpha1.FileWatchStatus{LastEventTime: metav1.NewMicroTime(start)}},
+		},
+		UIResources: map[string]*v1alpha1.UIResource{
+			"api": resource(v1alpha1.RuntimeStatusOK, start.Add(time.Second)),
+		},
+	}
+
+	ts, _ := LastRestartEvent(restartOn, objs)
+	assert.Equal(t, start.Add(time.Second), ts)
+	assert.Equal(t,
+		fmt.Sprintf("api became ready at %s", start.Add(time.Second).Local().Format("15:04:05")),
+		LastRestartCause(restartOn, objs))
+
+	// A resource that isn't ready right now doesn't count, even if it was
+	// ready more recently than anything else happened.
+	objs.UIResources["api"] = resource(v1alpha1.RuntimeStatusError, start.Add(time.Second))
+	ts, _ = LastRestartEvent(restartOn, objs)
+	assert.Equal(t, start, ts)
+	assert.Equal(t,
+		fmt.Sprintf("files changed in fw at %s", start.Local().Format("15:04:05")),
+		LastRestartCause(restartOn, objs))
+
+	objs.UIResources["api"] = resource(v1alpha1.RuntimeStatusOK, start.Add(2*time.Second))
+	ts, name := LastReadyEvent(restartOn, objs)
+	assert.Equal(t, start.Add(2*time.Second), ts)
+	assert.Equal(t, "api", name)
+}
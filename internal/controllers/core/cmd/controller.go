@@ -86,8 +86,7 @@ func NewController(ctx context.Context, execer Execer, proberManager ProberManag
 func (c *Controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	c.reconcileMu.Lock()
 	defer c.reconcileMu.Unlock()
-	err := c.reconcile(ctx, req.NamespacedName)
-	return ctrl.Result{}, err
+	return c.reconcile(ctx, req.NamespacedName)
 }
 
 // Stop the command, and wait for it to finish before continuing.
@@ -103,6 +102,7 @@ func (c *Controller) stop(name types.NamespacedName) {
 	proc.cancelFunc()
 	<-proc.doneCh
 	proc.probeWorker = nil
+	proc.livenessWorker = nil
 	proc.cancelFunc = nil
 	proc.doneCh = nil
 }
@@ -113,60 +113,23 @@ func (c *Controller) TearDown(ctx context.Context) {
 	}
 }
 
-func inputsFromButton(button v1alpha1.UIButton) []input {
-	statuses := make(map[string]v1alpha1.UIInputStatus)
-	for _, status := range button.Status.Inputs {
-		statuses[status.Name] = status
-	}
-
-	var ret []input
-	for _, spec := range button.Spec.Inputs {
-		ret = append(ret, input{
-			spec:   spec,
-			status: statuses[spec.Name],
-		})
-	}
-
-	return ret
-}
-
-// Fetch the last time a start was requested from this target's dependencies.
-func (c *Controller) lastStartEvent(startOn *StartOnSpec, restartObjs restarton.Objects) (time.Time, []input) {
-	latestTime, latestButton := restarton.LastStartEvent(startOn, restartObjs)
-	var inputs []input
-	if latestButton != nil {
-		inputs = inputsFromButton(*latestButton)
-	}
-	return latestTime, inputs
-}
-
-// Fetch the last time a restart was requested from this target's dependencies.
-func (c *Controller) lastRestartEvent(restartOn *RestartOnSpec, restartObjs restarton.Objects) (time.Time, []input) {
-	cur, latestButton := restarton.LastRestartEvent(restartOn, restartObjs)
-	var inputs []input
-	if latestButton != nil {
-		inputs = inputsFromButton(*latestButton)
-	}
-	return cur, inputs
-}
-
-func (c *Controller) reconcile(ctx context.Context, name types.NamespacedName) error {
+func (c *Controller) reconcile(ctx context.Context, name types.NamespacedName) (ctrl.Result, error) {
 	cmd := &Cmd{}
 	err := c.client.Get(ctx, name, cmd)
 	c.indexer.OnReconcile(name, cmd)
 	if err != nil && !apierrors.IsNotFound(err) {
-		return fmt.Errorf("cmd reconcile: %v", err)
+		return ctrl.Result{}, fmt.Errorf("cmd reconcile: %v", err)
 	}
 
 	if apierrors.IsNotFound(err) || cmd.ObjectMeta.DeletionTimestamp != nil {
 		c.stop(name)
 		delete(c.procs, name)
-		return nil
+		return ctrl.Result{}, nil
 	}
 
 	disableStatus, err := configmap.MaybeNewDisableStatus(ctx, c.client, cmd.Spec.DisableSource, cmd.Status.DisableStatus)
 	if err != nil {
-		return err
+		return ctrl.Result{}, err
 	}
 
 	if disableStatus != cmd.Status.DisableStatus {
@@ -180,23 +143,23 @@ func (c *Controller) reconcile(ctx context.Context, name types.NamespacedName) e
 	if disableStatus.Disabled {
 		c.stop(name)
 		delete(c.procs, name)
-		return nil
+		return ctrl.Result{}, nil
 	}
 
 	if cmd.Annotations[v1alpha1.AnnotationManagedBy] == "local_resource" {
 		// Until resource dependencies are expressed in the API,
 		// we can't use reconciliation to deploy Cmd objects
 		// that are part of local_resource.
-		return nil
+		return ctrl.Result{}, nil
 	}
 
 	restartObjs, err := restarton.FetchObjects(ctx, c.client, cmd.Spec.RestartOn, cmd.Spec.StartOn)
 	if err != nil {
-		return err
+		return ctrl.Result{}, err
 	}
 
-	lastRestartEventTime, _ := c.lastRestartEvent(cmd.Spec.RestartOn, restartObjs)
-	lastStartEventTime, _ := c.lastStartEvent(cmd.Spec.StartOn, restartObjs)
+	lastRestartEventTime, _ := restarton.LastRestartEvent(cmd.Spec.RestartOn, restartObjs)
+	lastStartEventTime, _ := restarton.LastStartEvent(cmd.Spec.StartOn, restartObjs)
 	startOn := cmd.Spec.StartOn
 	waitsOnStartOn := startOn != nil && len(startOn.UIButtons) > 0
 
@@ -215,6 +178,19 @@ func (c *Controller) reconcile(ctx context.Context, name types.NamespacedName) e
 	startOnTriggered := lastStartEventTime.After(lastStartOnEventTime)
 	execSpecChanged := !cmdExecEqual(lastSpec, cmd.Spec)
 
+	// A resource that we restart on just became ready. Wait for it to settle,
+	// so that a resource flapping between ready and not ready only restarts
+	// the command once.
+	if restartOnTriggered && !execSpecChanged && !startOnTriggered {
+		lastReadyTime, _ := restarton.LastReadyEvent(cmd.Spec.RestartOn, restartObjs)
+		if lastReadyTime.Equal(lastRestartEventTime) {
+			wait := lastReadyTime.Add(restarton.ReadyDebounce).Sub(c.clock.Now())
+			if wait > 0 {
+				return ctrl.Result{RequeueAfter: wait}, nil
+			}
+		}
+	}
+
 	// any change to the spec means we should stop the command immediately
 	if execSpecChanged {
 		c.stop(name)
@@ -227,10 +203,14 @@ func (c *Controller) reconcile(ctx context.Context, name types.NamespacedName) e
 	} else if execSpecChanged || restartOnTriggered || startOnTriggered {
 		// Otherwise, any change, new start event, or new restart event
 		// should restart the process to pick up changes.
-		_ = c.runInternal(ctx, cmd, restartObjs)
+		startReason := ""
+		if restartOnTriggered && !execSpecChanged && !startOnTriggered {
+			startReason = restarton.LastRestartCause(cmd.Spec.RestartOn, restartObjs)
+		}
+		_ = c.runInternal(ctx, cmd, restartObjs, startReason)
 	}
 
-	return nil
+	return ctrl.Result{}, nil
 }
 
 // Forces the command to run now.
@@ -241,7 +221,7 @@ func (c *Controller) reconcile(ctx context.Context, name types.NamespacedName) e
 // Blocks until the command is finished, then returns its status.
 func (c *Controller) ForceRun(ctx context.Context, cmd *v1alpha1.Cmd) (*v1alpha1.CmdStatus, error) {
 	c.reconcileMu.Lock()
-	doneCh := c.runInternal(ctx, cmd, restarton.Objects{})
+	doneCh := c.runInternal(ctx, cmd, restarton.Objects{}, "")
 	c.reconcileMu.Unlock()
 
 	select {
@@ -261,43 +241,17 @@ func (c *Controller) ForceRun(ctx context.Context, cmd *v1alpha1.Cmd) (*v1alpha1
 	return result.Status.DeepCopy(), nil
 }
 
-func (i input) stringValue() string {
-	if i.status.Text != nil {
-		return i.status.Text.Value
-	} else if i.status.Bool != nil {
-		if i.status.Bool.Value {
-			if i.spec.Bool.TrueString != nil {
-				return *i.spec.Bool.TrueString
-			} else {
-				return "true"
-			}
-		} else {
-			if i.spec.Bool.FalseString != nil {
-				return *i.spec.Bool.FalseString
-			} else {
-				return "false"
-			}
-		}
-	} else if i.status.Hidden != nil {
-		return i.status.Hidden.Value
-	}
-	return ""
-}
-
-type input struct {
-	spec   v1alpha1.UIInputSpec
-	status v1alpha1.UIInputStatus
-}
-
 // Runs the command unconditionally, stopping any currently running command.
 //
 // The filewatches and buttons are needed for bookkeeping on how the command
-// was triggered.
+// was triggered. The start reason, if any, describes the restart event that
+// triggered it, and is recorded on the command's status.
 //
 // Returns a channel that closes when the Cmd is finished.
 func (c *Controller) runInternal(ctx context.Context,
 	cmd *v1alpha1.Cmd,
-	restartObjs restarton.Objects) (doneCh chan struct{}) {
+	restartObjs restarton.Objects,
+	startReason string) (doneCh chan struct{}) {
 	name := types.NamespacedName{Name: cmd.Name}
 	proc, ok := c.procs[name]
 	if ok {
@@ -314,10 +268,10 @@ func (c *Controller) runInternal(ctx context.Context,
 	proc.spec = cmd.Spec
 	proc.isServer = cmd.ObjectMeta.Annotations[local.AnnotationOwnerKind] == "CmdServer"
 
-	var startInputs, restartInputs []input
+	var startInputs, restartInputs []restarton.Input
 
-	proc.lastRestartOnEventTime, restartInputs = c.lastRestartEvent(cmd.Spec.RestartOn, restartObjs)
-	proc.lastStartOnEventTime, startInputs = c.lastStartEvent(cmd.Spec.StartOn, restartObjs)
+	proc.lastRestartOnEventTime, restartInputs = restarton.LastRestartEvent(cmd.Spec.RestartOn, restartObjs)
+	proc.lastStartOnEventTime, startInputs = restarton.LastStartEvent(cmd.Spec.StartOn, restartObjs)
 
 	mergedInputs := startInputs
 	if proc.lastRestartOnEventTime.After(proc.lastStartOnEventTime) {
@@ -337,6 +291,27 @@ func (c *Controller) runInternal(ctx context.Context,
 	ctx = store.MustObjectLogHandler(ctx, c.st, cmd)
 	spec := cmd.Spec
 
+	if startReason != "" {
+		logger.Get(ctx).Infof("Restarting: %s", startReason)
+	}
+
+	probeMisconfigured := func(kind string, err error) chan struct{} {
+		logger.Get(ctx).Errorf("Invalid %s: %v", kind, err)
+		c.updateStatus(name, func(status *CmdStatus) {
+			status.Terminated = &CmdStateTerminated{
+				ExitCode: 1,
+				Reason:   fmt.Sprintf("%s: %v", ProbeMisconfiguredReason, err),
+			}
+			status.Waiting = nil
+			status.Running = nil
+			status.Ready = false
+		}, stillHasSameProcNum)
+
+		proc.doneCh = make(chan struct{})
+		close(proc.doneCh)
+		return proc.doneCh
+	}
+
 	if spec.ReadinessProbe != nil {
 		probeResultFunc := c.handleProbeResultFunc(ctx, name, stillHasSameProcNum)
 		probeWorker, err := probeWorkerFromSpec(
@@ -344,29 +319,28 @@ func (c *Controller) runInternal(ctx context.Context,
 			spec.ReadinessProbe,
 			probeResultFunc)
 		if err != nil {
-			logger.Get(ctx).Errorf("Invalid readiness probe: %v", err)
-			c.updateStatus(name, func(status *CmdStatus) {
-				status.Terminated = &CmdStateTerminated{
-					ExitCode: 1,
-					Reason:   fmt.Sprintf("Invalid readiness probe: %v", err),
-				}
-				status.Waiting = nil
-				status.Running = nil
-				status.Ready = false
-			}, stillHasSameProcNum)
-
-			proc.doneCh = make(chan struct{})
-			close(proc.doneCh)
-			return proc.doneCh
+			return probeMisconfigured(readinessProbeKind, err)
 		}
 		proc.probeWorker = probeWorker
 	}
 
+	if spec.LivenessProbe != nil {
+		livenessWorker, err := probeWorkerFromSpec(
+			c.proberManager,
+			spec.LivenessProbe,
+			c.handleLivenessResultFunc(ctx, name, stillHasSameProcNum))
+		if err != nil {
+			proc.probeWorker = nil
+			return probeMisconfigured(livenessProbeKind, err)
+		}
+		proc.livenessWorker = livenessWorker
+	}
+
 	startedAt := apis.NewMicroTime(c.clock.Now())
 
 	env := append([]string{}, spec.Env...)
 	for _, input := range mergedInputs {
-		env = append(env, fmt.Sprintf("%s=%s", input.spec.Name, input.stringValue()))
+		env = append(env, fmt.Sprintf("%s=%s", input.Name, input.Value))
 	}
 
 	cmdModel := model.Cmd{
@@ -374,14 +348,18 @@ func (c *Controller) runInternal(ctx context.Context,
 		Dir:  spec.Dir,
 		Env:  env,
 	}
-	statusCh := c.execer.Start(ctx, cmdModel, logger.Get(ctx).Writer(logger.InfoLvl))
+	w := newRunWriter(logger.NewLevelFormatWriter(logger.Get(ctx), logger.LevelFormat(spec.LogLevelFormat), logger.InfoLvl))
+	statusCh := c.execer.Start(ctx, cmdModel, w)
 	proc.doneCh = make(chan struct{})
 
-	go c.processStatuses(ctx, statusCh, proc, name, startedAt, stillHasSameProcNum)
+	go c.processStatuses(ctx, statusCh, proc, w, name, startedAt, startReason, stillHasSameProcNum)
 
 	return proc.doneCh
 }
 
+const readinessProbeKind = "readiness probe"
+const livenessProbeKind = "liveness probe"
+
 func (c *Controller) handleProbeResultFunc(ctx context.Context, name types.NamespacedName, stillHasSameProcNum func() bool) probe.ResultFunc {
 	existingReady := false
 
@@ -390,25 +368,7 @@ func (c *Controller) handleProbeResultFunc(ctx context.Context, name types.Names
 			return
 		}
 
-		// we try to balance logging important probe results without flooding the logs
-		//  * ALL transitions are logged
-		// 		* success->{failure,warning} @ WARN
-		// 		* {failure,warning}->success @ INFO
-		// 	* subsequent non-successful results @ VERBOSE
-		// 		* expected healthy/steady-state is recurring success, and this is apparent
-		// 		  from the "Ready" state, so logging every invocation is superfluous
-		loggerLevel := logger.NoneLvl
-		if statusChanged {
-			if result != prober.Success {
-				loggerLevel = logger.WarnLvl
-			} else {
-				loggerLevel = logger.InfoLvl
-			}
-		} else if result != prober.Success {
-			loggerLevel = logger.VerboseLvl
-		}
-		logProbeOutput(ctx, loggerLevel, result, output, nil)
-
+		logProbeResult(ctx, readinessProbeKind, result, statusChanged, output)
 		if !statusChanged {
 			// the probe did not transition states, so the result is logged but not used to update status
 			return
@@ -422,7 +382,64 @@ func (c *Controller) handleProbeResultFunc(ctx context.Context, name types.Names
 	}
 }
 
-func logProbeOutput(ctx context.Context, level logger.Level, result prober.Result, output string, err error) {
+// Marks the Running state when the liveness probe fails (after its
+// FailureThreshold), and clears the mark if it recovers first.
+func (c *Controller) handleLivenessResultFunc(ctx context.Context, name types.NamespacedName, stillHasSameProcNum func() bool) probe.ResultFunc {
+	return func(result prober.Result, statusChanged bool, output string, err error) {
+		if !stillHasSameProcNum() {
+			return
+		}
+
+		logProbeResult(ctx, livenessProbeKind, result, statusChanged, output)
+		if !statusChanged {
+			return
+		}
+
+		failure := ""
+		if result == prober.Failure {
+			failure = livenessFailureMessage(output, err)
+		}
+		c.updateStatus(name, func(status *CmdStatus) {
+			if status.Running != nil {
+				status.Running.LivenessFailure = failure
+			}
+		}, stillHasSameProcNum)
+	}
+}
+
+func livenessFailureMessage(output string, err error) string {
+	if err != nil {
+		return fmt.Sprintf("%s failed: %v", livenessProbeKind, err)
+	}
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if lines[0] == "" {
+		return fmt.Sprintf("%s failed", livenessProbeKind)
+	}
+	return fmt.Sprintf("%s failed: %s", livenessProbeKind, lines[len(lines)-1])
+}
+
+func logProbeResult(ctx context.Context, kind string, result prober.Result, statusChanged bool, output string) {
+	// we try to balance logging important probe results without flooding the logs
+	//  * ALL transitions are logged
+	// 		* success->{failure,warning} @ WARN
+	// 		* {failure,warning}->success @ INFO
+	// 	* subsequent non-successful results @ VERBOSE
+	// 		* expected healthy/steady-state is recurring success, and this is apparent
+	// 		  from the "Ready" state, so logging every invocation is superfluous
+	loggerLevel := logger.NoneLvl
+	if statusChanged {
+		if result != prober.Success {
+			loggerLevel = logger.WarnLvl
+		} else {
+			loggerLevel = logger.InfoLvl
+		}
+	} else if result != prober.Success {
+		loggerLevel = logger.VerboseLvl
+	}
+	logProbeOutput(ctx, kind, loggerLevel, result, output, nil)
+}
+
+func logProbeOutput(ctx context.Context, kind string, level logger.Level, result prober.Result, output string, err error) {
 	l := logger.Get(ctx)
 	if level == logger.NoneLvl || !l.Level().ShouldDisplay(level) {
 		return
@@ -430,12 +447,14 @@ func logProbeOutput(ctx context.Context, level logger.Level, result prober.Resul
 
 	w := l.Writer(level)
 	if err != nil {
-		_, _ = fmt.Fprintf(w, "[readiness probe error] %v\n", err)
+		_, _ = fmt.Fprintf(w, "[%s error] %v\n", kind, err)
 	} else if output != "" {
 		var logMessage strings.Builder
 		s := bufio.NewScanner(strings.NewReader(output))
 		for s.Scan() {
-			logMessage.WriteString("[readiness probe: ")
+			logMessage.WriteString("[")
+			logMessage.WriteString(kind)
+			logMessage.WriteString(": ")
 			logMessage.WriteString(string(result))
 			logMessage.WriteString("] ")
 			logMessage.Write(s.Bytes())
@@ -504,11 +523,17 @@ func (c *Controller) processStatuses(
 	ctx context.Context,
 	statusCh chan statusAndMetadata,
 	proc *currentProcess,
+	w *runWriter,
 	name types.NamespacedName,
 	startedAt metav1.MicroTime,
+	startReason string,
 	stillHasSameProcNum func() bool) {
 	defer close(proc.doneCh)
 
+	// Close the output stream before we report that we're done,
+	// so that the next run starts with a clean log.
+	defer w.Close()
+
 	var initProbeWorker sync.Once
 
 	for sm := range statusCh {
@@ -526,31 +551,38 @@ func (c *Controller) processStatuses(
 				status.Waiting = nil
 				status.Running = nil
 				status.Terminated = &CmdStateTerminated{
-					PID:        int32(sm.pid),
-					Reason:     sm.reason,
-					ExitCode:   int32(sm.exitCode),
-					StartedAt:  startedAt,
-					FinishedAt: metav1.NowMicro(),
+					PID:         int32(sm.pid),
+					Reason:      sm.reason,
+					ExitCode:    int32(sm.exitCode),
+					StartedAt:   startedAt,
+					FinishedAt:  metav1.NowMicro(),
+					StartReason: startReason,
 				}
 			}, stillHasSameProcNum)
 		} else if sm.status == Running {
-			if proc.probeWorker != nil {
-				initProbeWorker.Do(func() {
-					go proc.probeWorker.Run(ctx)
-				})
-			}
-
 			c.updateStatus(name, func(status *CmdStatus) {
 				status.Waiting = nil
 				status.Running = &CmdStateRunning{
-					PID:       int32(sm.pid),
-					StartedAt: startedAt,
+					PID:         int32(sm.pid),
+					StartedAt:   startedAt,
+					StartReason: startReason,
 				}
 
 				if proc.probeWorker == nil {
 					status.Ready = true
 				}
 			}, stillHasSameProcNum)
+
+			// Start probing after we report the process as Running,
+			// so that a liveness failure has a Running state to mark.
+			initProbeWorker.Do(func() {
+				if proc.probeWorker != nil {
+					go proc.probeWorker.Run(ctx)
+				}
+				if proc.livenessWorker != nil {
+					go proc.livenessWorker.Run(ctx)
+				}
+			})
 		}
 	}
 }
@@ -582,7 +614,10 @@ type currentProcess struct {
 	// closed when the process finishes executing, intentionally or not
 	doneCh      chan struct{}
 	probeWorker *probe.Worker
-	isServer    bool
+
+	// Only set if the Cmd has a liveness probe.
+	livenessWorker *probe.Worker
+	isServer       bool
 
 	lastRestartOnEventTime time.Time
 	lastStartOnEventTime   time.Time
@@ -611,6 +646,37 @@ func (p *currentProcess) currentProcNum() int {
 	return p.procNum
 }
 
+// The output stream of one run of a Cmd.
+//
+// We don't wait on the process's output pipes when it exits, because
+// descendant processes may hold them open. Once the run is over, we close
+// the writer and drop anything else they print, so that it can't land in
+// the logs of the next run.
+type runWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closed bool
+}
+
+func newRunWriter(w io.Writer) *runWriter {
+	return &runWriter{w: w}
+}
+
+func (w *runWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return len(p), nil
+	}
+	return w.w.Write(p)
+}
+
+func (w *runWriter) Close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+}
+
 type statusAndMetadata struct {
 	pid      int
 	status   status
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
@@ -12,6 +14,7 @@ import (
 	"github.com/jonboulle/clockwork"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tilt-dev/probe/pkg/prober"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -19,12 +22,14 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	"github.com/tilt-dev/tilt/internal/controllers/apis/restarton"
 	"github.com/tilt-dev/tilt/internal/controllers/fake"
 	"github.com/tilt-dev/tilt/internal/engine/local"
 	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/pkg/apis"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
 	"github.com/tilt-dev/tilt/pkg/model"
+	"github.com/tilt-dev/tilt/pkg/model/logstore"
 )
 
 var timeout = time.Second
@@ -109,6 +114,22 @@ func TestServe(t *testing.T) {
 	f.assertLogMessage("foo", "Starting cmd sleep 60")
 }
 
+func TestServeSkipsUnrelatedChanges(t *testing.T) {
+	f := newFixture(t)
+
+	t1 := time.Unix(1, 0)
+	f.resource("foo", "sleep 60", "testdir", t1)
+
+	// Nothing the server controller watches has changed.
+	unrelated := store.ChangeSummary{Log: true, UISessions: store.NewChangeSet(types.NamespacedName{Name: "Tiltfile"})}
+	_ = f.sc.OnChange(f.Context(), f.st, unrelated)
+	assert.Equal(t, 0, f.st.CmdCount())
+
+	changed := store.ChangeSummary{ManifestStatuses: store.NewChangeSet(types.NamespacedName{Name: "foo"})}
+	_ = f.sc.OnChange(f.Context(), f.st, changed)
+	assert.Equal(t, 1, f.st.CmdCount())
+}
+
 func TestServeReadinessProbe(t *testing.T) {
 	f := newFixture(t)
 
@@ -153,13 +174,132 @@ func TestServeReadinessProbeInvalidSpec(t *testing.T) {
 	f.step()
 
 	f.assertCmdMatches("foo-serve-1", func(cmd *Cmd) bool {
-		return cmd.Status.Terminated != nil && cmd.Status.Terminated.ExitCode == 1
+		return cmd.Status.Terminated != nil && cmd.Status.Terminated.ExitCode == 1 &&
+			cmd.Status.Terminated.Reason == "probe misconfigured: port number out of range: 70000"
 	})
 
 	f.assertLogMessage("foo", "Invalid readiness probe: port number out of range: 70000")
 	assert.Equal(t, 0, f.fpm.ProbeCount())
 }
 
+func TestServeReadinessProbeMissingExecBinary(t *testing.T) {
+	f := newFixture(t)
+
+	t1 := time.Unix(1, 0)
+
+	c := model.ToHostCmdInDir("sleep 60", "testdir")
+	localTarget := model.NewLocalTarget("foo", model.Cmd{}, c, nil)
+	localTarget.ReadinessProbe = &v1alpha1.Probe{
+		Handler: v1alpha1.Handler{
+			Exec: &v1alpha1.ExecAction{Command: []string{"tilt-probe-binary-that-does-not-exist"}},
+		},
+	}
+
+	f.resourceFromTarget("foo", localTarget, t1)
+	f.step()
+
+	f.assertCmdMatches("foo-serve-1", func(cmd *Cmd) bool {
+		return cmd.Status.Terminated != nil &&
+			strings.HasPrefix(cmd.Status.Terminated.Reason, ProbeMisconfiguredReason)
+	})
+
+	f.assertLogMessage("foo", "Invalid readiness probe")
+	assert.Equal(t, 0, f.fpm.ProbeCount())
+	assert.Empty(t, f.fe.processes)
+}
+
+func TestServeLivenessProbeRestartsHungServer(t *testing.T) {
+	f := newFixture(t)
+
+	f.livenessResource("foo", time.Unix(1, 0))
+	f.fpm.SetExecResult("true", prober.Failure)
+
+	f.step()
+	f.assertCmdMatches("foo-serve-1", func(cmd *Cmd) bool {
+		return cmd.Status.Running != nil &&
+			cmd.Status.Running.LivenessFailure == "liveness probe failed: fake probe failed!"
+	})
+	f.assertLogMessage("foo", "[liveness probe: failure] fake probe failed!")
+
+	f.step()
+	f.assertCmdDeleted("foo-serve-1")
+	f.assertLogMessage("foo", "Server is hung (liveness probe failed: fake probe failed!). Restarting (liveness restart #1)")
+	assert.Equal(t, 1, f.sc.Get("foo").Status.LivenessRestarts)
+
+	f.fpm.SetExecResult("true", prober.Success)
+	f.step()
+	f.assertCmdMatches("foo-serve-2", func(cmd *Cmd) bool {
+		return cmd.Status.Running != nil && cmd.Status.Ready
+	})
+
+	// A live server is left alone.
+	f.step()
+	f.assertCmdCount(1)
+	assert.Equal(t, 1, f.sc.Get("foo").Status.LivenessRestarts)
+}
+
+func TestServeLivenessProbeBackoff(t *testing.T) {
+	f := newFixture(t)
+
+	f.livenessResource("foo", time.Unix(1, 0))
+	f.fpm.SetExecResult("true", prober.Failure)
+
+	f.step()
+	f.assertCmdMatches("foo-serve-1", func(cmd *Cmd) bool {
+		return cmd.Status.Running != nil && cmd.Status.Running.LivenessFailure != ""
+	})
+
+	// The first restart is immediate.
+	f.step()
+	f.assertCmdDeleted("foo-serve-1")
+	f.step()
+	f.assertCmdMatches("foo-serve-2", func(cmd *Cmd) bool {
+		return cmd.Status.Running != nil && cmd.Status.Running.LivenessFailure != ""
+	})
+
+	// The server is still hung, so we wait before restarting it again.
+	f.step()
+	f.assertLogMessage("foo", "Restarting in 10s")
+	assert.Never(t, func() bool {
+		return f.st.Cmd("foo-serve-2") == nil
+	}, 20*time.Millisecond, 5*time.Millisecond)
+	assert.Equal(t, 1, f.sc.Get("foo").Status.LivenessRestarts)
+
+	f.clock.BlockUntil(1)
+	f.clock.Advance(10 * time.Second)
+	assert.Eventually(t, func() bool {
+		for _, a := range f.st.Actions() {
+			if a, ok := a.(local.CmdServerBackoffAction); ok && a.CmdName == "foo-serve-2" {
+				return true
+			}
+		}
+		return false
+	}, timeout, interval)
+
+	f.step()
+	f.assertCmdDeleted("foo-serve-2")
+	f.assertLogMessage("foo", "Restarting (liveness restart #2)")
+	assert.Equal(t, 2, f.sc.Get("foo").Status.LivenessRestarts)
+}
+
+func TestServeLivenessProbeInvalidSpec(t *testing.T) {
+	f := newFixture(t)
+
+	c := model.ToHostCmdInDir("sleep 60", "testdir")
+	localTarget := model.NewLocalTarget("foo", model.Cmd{}, c, nil).
+		WithLivenessProbe(&v1alpha1.Probe{
+			Handler: v1alpha1.Handler{HTTPGet: &v1alpha1.HTTPGetAction{Port: 70000}},
+		})
+
+	f.resourceFromTarget("foo", localTarget, time.Unix(1, 0))
+	f.step()
+
+	f.assertCmdMatches("foo-serve-1", func(cmd *Cmd) bool {
+		return cmd.Status.Terminated != nil && cmd.Status.Terminated.ExitCode == 1
+	})
+	f.assertLogMessage("foo", "Invalid liveness probe: port number out of range: 70000")
+}
+
 func TestFailure(t *testing.T) {
 	f := newFixture(t)
 
@@ -194,6 +334,60 @@ func TestUniqueSpanIDs(t *testing.T) {
 	require.NotEqual(t, fooStart.SpanID(), barStart.SpanID(), "different resources should have unique log span ids")
 }
 
+func TestRestartLogsEachRunToItsOwnSpan(t *testing.T) {
+	f := newFixture(t)
+
+	for run := 1; run <= 4; run++ {
+		f.resource("foo", "sleep 60", ".", time.Unix(int64(run), 0))
+		f.step()
+		if run > 1 {
+			f.assertCmdDeleted(fmt.Sprintf("foo-serve-%d", run-1))
+			f.step()
+		}
+
+		cmd := f.assertCmdMatches(fmt.Sprintf("foo-serve-%d", run), func(cmd *Cmd) bool {
+			return cmd.Status.Running != nil
+		})
+		assert.Equal(t, strconv.Itoa(run), cmd.Annotations[v1alpha1.AnnotationRunNumber])
+		assert.Equal(t, fmt.Sprintf("localserve:foo:%d", run), cmd.Annotations[v1alpha1.AnnotationSpanID])
+	}
+
+	// Each run's logs close out before the next run's begin.
+	l := logstore.NewLogStore()
+	var spans []logstore.SpanID
+	for _, action := range f.st.Actions() {
+		la, ok := action.(store.LogAction)
+		if !ok || la.ManifestName() != "foo" {
+			continue
+		}
+		if len(spans) == 0 || spans[len(spans)-1] != la.SpanID() {
+			spans = append(spans, la.SpanID())
+		}
+		l.Append(la, nil)
+	}
+	assert.Equal(t, []logstore.SpanID{
+		"localserve:foo:1",
+		"localserve:foo:2",
+		"localserve:foo:3",
+		"localserve:foo:4",
+	}, spans)
+
+	assert.Equal(t, `Starting cmd sleep 60
+cmd sleep 60 canceled
+ERROR: Server exited with exit code 0
+── restarting server (run 2) ──
+Starting cmd sleep 60
+cmd sleep 60 canceled
+ERROR: Server exited with exit code 0
+── restarting server (run 3) ──
+Starting cmd sleep 60
+cmd sleep 60 canceled
+ERROR: Server exited with exit code 0
+── restarting server (run 4) ──
+Starting cmd sleep 60
+`, l.ManifestLog("foo"))
+}
+
 func TestTearDown(t *testing.T) {
 	f := newFixture(t)
 
@@ -298,6 +492,89 @@ func TestRestartOnUIButton(t *testing.T) {
 		f.c.indexer.Enqueue(b))
 }
 
+func TestRestartOnUIResourceReady(t *testing.T) {
+	f := newFixture(t)
+
+	f.resource("cmd", "true", ".", f.clock.Now())
+	f.step()
+
+	run := f.assertCmdMatches("cmd-serve-1", func(cmd *Cmd) bool {
+		return cmd.Status.Running != nil
+	})
+	pid := run.Status.Running.PID
+
+	f.updateSpec("cmd-serve-1", func(spec *v1alpha1.CmdSpec) {
+		spec.RestartOn = &RestartOnSpec{
+			UIResources: []string{"api"},
+		}
+	})
+
+	r := &v1alpha1.UIResource{
+		ObjectMeta: ObjectMeta{
+			Name: "api",
+		},
+	}
+	err := f.Client.Create(f.Context(), r)
+	require.NoError(t, err)
+	f.setRuntimeStatus("api", v1alpha1.RuntimeStatusPending)
+	f.assertRunCount("cmd-serve-1", pid)
+
+	// The first transition waits to make sure the resource stays ready.
+	f.clock.Advance(time.Second)
+	readyTime := f.clock.Now()
+	f.setRuntimeStatus("api", v1alpha1.RuntimeStatusOK)
+	result, err := f.c.Reconcile(f.Context(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "cmd-serve-1"}})
+	require.NoError(t, err)
+	assert.Equal(t, restarton.ReadyDebounce, result.RequeueAfter)
+	f.assertRunCount("cmd-serve-1", pid)
+
+	f.clock.Advance(restarton.ReadyDebounce)
+	f.reconcileCmd("cmd-serve-1")
+	run = f.assertCmdMatches("cmd-serve-1", func(cmd *Cmd) bool {
+		return cmd.Status.Running != nil && cmd.Status.Running.PID == pid+1
+	})
+	assert.Equal(t,
+		fmt.Sprintf("api became ready at %s", readyTime.Local().Format("15:04:05")),
+		run.Status.Running.StartReason)
+
+	// Reconciling again doesn't run it again.
+	f.reconcileCmd("cmd-serve-1")
+	f.assertRunCount("cmd-serve-1", pid+1)
+
+	// Flapping only triggers one more run, once it settles.
+	for i := 0; i < 3; i++ {
+		f.clock.Advance(100 * time.Millisecond)
+		f.setRuntimeStatus("api", v1alpha1.RuntimeStatusError)
+		f.reconcileCmd("cmd-serve-1")
+		f.clock.Advance(100 * time.Millisecond)
+		f.setRuntimeStatus("api", v1alpha1.RuntimeStatusOK)
+		f.reconcileCmd("cmd-serve-1")
+	}
+	f.assertRunCount("cmd-serve-1", pid+1)
+
+	f.clock.Advance(restarton.ReadyDebounce)
+	f.reconcileCmd("cmd-serve-1")
+	f.assertCmdMatches("cmd-serve-1", func(cmd *Cmd) bool {
+		return cmd.Status.Running != nil && cmd.Status.Running.PID == pid+2
+	})
+
+	f.clock.Advance(restarton.ReadyDebounce)
+	f.reconcileCmd("cmd-serve-1")
+	f.assertRunCount("cmd-serve-1", pid+2)
+
+	// Going not-ready on its own doesn't trigger anything.
+	f.setRuntimeStatus("api", v1alpha1.RuntimeStatusError)
+	f.clock.Advance(restarton.ReadyDebounce)
+	f.reconcileCmd("cmd-serve-1")
+	f.assertRunCount("cmd-serve-1", pid+2)
+
+	assert.Equal(f.T(),
+		[]reconcile.Request{
+			reconcile.Request{NamespacedName: types.NamespacedName{Name: "cmd-serve-1"}},
+		},
+		f.c.indexer.Enqueue(r))
+}
+
 func setupStartOnTest(t *testing.T, f *fixture) {
 	cmd := &Cmd{
 		ObjectMeta: metav1.ObjectMeta{
@@ -503,6 +780,156 @@ func TestDisposeTerminatedWhenCmdChanges(t *testing.T) {
 	f.assertCmdDeleted("foo-serve-1")
 }
 
+func TestServeReloadOnDesignatedEnvChange(t *testing.T) {
+	f := newFixture(t)
+
+	reload := &model.ServeReload{Env: []string{"LOG_LEVEL"}, Signal: "SIGHUP"}
+	t1 := time.Unix(1, 0)
+	f.reloadableResource("foo", []string{"LOG_LEVEL=info", "PORT=8000"}, reload, t1)
+	f.step()
+	cmd := f.assertCmdMatches("foo-serve-1", func(cmd *Cmd) bool {
+		return cmd.Status.Running != nil
+	})
+
+	t2 := time.Unix(2, 0)
+	f.reloadableResource("foo", []string{"LOG_LEVEL=debug", "PORT=8000"}, reload, t2)
+	f.step()
+
+	assert.Equal(t, []local.FakeSignal{{PID: int(cmd.Status.Running.PID), Signal: "SIGHUP"}}, f.fps.Signals())
+	f.assertCmdCount(1)
+	assert.False(t, f.sc.Get("foo").Status.LastReloadTime.IsZero())
+	f.assertLogMessage("foo", "Reloading server (env changed): sent SIGHUP")
+
+	// Nothing else changed, so another step is a no-op.
+	f.step()
+	assert.Len(t, f.fps.Signals(), 1)
+	f.assertCmdCount(1)
+}
+
+func TestServeReloadFallsBackToRestartOnExit(t *testing.T) {
+	f := newFixture(t)
+
+	reload := &model.ServeReload{Env: []string{"LOG_LEVEL"}, Signal: "SIGUSR1"}
+	t1 := time.Unix(1, 0)
+	f.reloadableResource("foo", []string{"LOG_LEVEL=info"}, reload, t1)
+	f.step()
+	f.assertCmdMatches("foo-serve-1", func(cmd *Cmd) bool {
+		return cmd.Status.Running != nil
+	})
+
+	t2 := time.Unix(2, 0)
+	f.reloadableResource("foo", []string{"LOG_LEVEL=debug"}, reload, t2)
+	f.step()
+	require.Len(t, f.fps.Signals(), 1)
+
+	// The server didn't know how to handle the signal.
+	err := f.fe.stop("sleep 60", 1)
+	require.NoError(t, err)
+	f.assertCmdMatches("foo-serve-1", func(cmd *Cmd) bool {
+		return cmd.Status.Terminated != nil
+	})
+
+	f.step()
+	f.assertCmdMatches("foo-serve-2", func(cmd *Cmd) bool {
+		return cmd.Status.Running != nil
+	})
+	f.assertCmdDeleted("foo-serve-1")
+	f.assertLogMessage("foo", "Server exited after reload, restarting")
+	assert.Equal(t, []string{"LOG_LEVEL=debug"}, f.st.Cmd("foo-serve-2").Spec.Env)
+}
+
+func TestServeReloadRestartsOnOtherChange(t *testing.T) {
+	f := newFixture(t)
+
+	reload := &model.ServeReload{Env: []string{"LOG_LEVEL"}, Signal: "SIGHUP"}
+	t1 := time.Unix(1, 0)
+	f.reloadableResource("foo", []string{"LOG_LEVEL=info", "PORT=8000"}, reload, t1)
+	f.step()
+	f.assertCmdMatches("foo-serve-1", func(cmd *Cmd) bool {
+		return cmd.Status.Running != nil
+	})
+
+	t2 := time.Unix(2, 0)
+	f.reloadableResource("foo", []string{"LOG_LEVEL=debug", "PORT=9000"}, reload, t2)
+	f.step()
+	f.assertCmdDeleted("foo-serve-1")
+	f.step()
+	f.assertCmdMatches("foo-serve-2", func(cmd *Cmd) bool {
+		return cmd.Status.Running != nil
+	})
+	assert.Empty(t, f.fps.Signals())
+	assert.True(t, f.sc.Get("foo").Status.LastReloadTime.IsZero())
+}
+
+func TestServeReloadRestartsIfSignalFails(t *testing.T) {
+	f := newFixture(t)
+	f.fps.Err = fmt.Errorf("no such process")
+
+	reload := &model.ServeReload{Env: []string{"LOG_LEVEL"}, Signal: "SIGHUP"}
+	t1 := time.Unix(1, 0)
+	f.reloadableResource("foo", []string{"LOG_LEVEL=info"}, reload, t1)
+	f.step()
+	f.assertCmdMatches("foo-serve-1", func(cmd *Cmd) bool {
+		return cmd.Status.Running != nil
+	})
+
+	t2 := time.Unix(2, 0)
+	f.reloadableResource("foo", []string{"LOG_LEVEL=debug"}, reload, t2)
+	f.step()
+	f.assertCmdDeleted("foo-serve-1")
+	f.step()
+	f.assertCmdMatches("foo-serve-2", func(cmd *Cmd) bool {
+		return cmd.Status.Running != nil
+	})
+	f.assertLogMessage("foo", "Unable to reload server, restarting instead: sending SIGHUP: no such process")
+}
+
+func TestServeReloadOnConfigFileChange(t *testing.T) {
+	f := newFixture(t)
+
+	dir := t.TempDir()
+	config := filepath.Join(dir, "nginx.conf")
+	envFile := filepath.Join(dir, "reload.env")
+	require.NoError(t, ioutil.WriteFile(config, []byte("worker_processes 1;"), 0644))
+
+	reload := &model.ServeReload{
+		Env:     []string{"LOG_LEVEL"},
+		Files:   []string{config},
+		EnvFile: envFile,
+		Signal:  "SIGHUP",
+	}
+	t1 := time.Unix(1, 0)
+	f.reloadableResource("foo", []string{"LOG_LEVEL=info"}, reload, t1)
+	f.step()
+	f.assertCmdMatches("foo-serve-1", func(cmd *Cmd) bool {
+		return cmd.Status.Running != nil
+	})
+
+	require.NoError(t, ioutil.WriteFile(config, []byte("worker_processes 2;"), 0644))
+	t2 := time.Unix(2, 0)
+	f.reloadableResource("foo", []string{"LOG_LEVEL=warn"}, reload, t2)
+	f.step()
+
+	assert.Len(t, f.fps.Signals(), 1)
+	f.assertCmdCount(1)
+	f.assertLogMessage("foo", "Reloading server (env and config files changed)")
+
+	contents, err := ioutil.ReadFile(envFile)
+	require.NoError(t, err)
+	assert.Equal(t, "LOG_LEVEL=warn\n", string(contents))
+
+	// A trigger that doesn't touch any reloadable input restarts the server.
+	t3 := time.Unix(3, 0)
+	f.reloadableResource("foo", []string{"LOG_LEVEL=warn"}, reload, t3)
+	f.step()
+	f.assertCmdDeleted("foo-serve-1")
+	f.step()
+	f.assertCmdMatches("foo-serve-2", func(cmd *Cmd) bool {
+		return cmd.Status.Running != nil
+	})
+	assert.Len(t, f.fps.Signals(), 1)
+}
+
 func TestDisableCmd(t *testing.T) {
 	f := newFixture(t)
 
@@ -808,6 +1235,79 @@ func TestHiddenInput(t *testing.T) {
 	require.Equal(t, expectedEnv, actualEnv)
 }
 
+func TestChoiceInput(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		input         v1alpha1.UIChoiceInputSpec
+		status        *v1alpha1.UIChoiceInputStatus
+		expectedValue string
+	}{
+		{"selected", v1alpha1.UIChoiceInputSpec{Choices: []string{"small", "large"}}, &v1alpha1.UIChoiceInputStatus{Value: "large"}, "large"},
+		{"omitted, first choice", v1alpha1.UIChoiceInputSpec{Choices: []string{"small", "large"}}, nil, "small"},
+		{"omitted, default", v1alpha1.UIChoiceInputSpec{Choices: []string{"small", "large"}, DefaultValue: "large"}, nil, "large"},
+		{"not a choice", v1alpha1.UIChoiceInputSpec{Choices: []string{"small", "large"}}, &v1alpha1.UIChoiceInputStatus{Value: "huge"}, "small"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFixture(t)
+
+			setupStartOnTest(t, f)
+			f.updateButton("b-1", func(button *v1alpha1.UIButton) {
+				button.Spec.Inputs = []v1alpha1.UIInputSpec{{Name: "size", Choice: &tc.input}}
+				button.Status.Inputs = []v1alpha1.UIInputStatus{{Name: "size", Choice: tc.status}}
+			})
+			f.triggerButton("b-1", f.clock.Now())
+			f.reconcileCmd("testcmd")
+
+			actualEnv := f.fe.processes["myserver"].env
+			expectedEnv := []string{fmt.Sprintf("size=%s", tc.expectedValue)}
+			require.Equal(t, expectedEnv, actualEnv)
+		})
+	}
+}
+
+func TestInputsFromPreviousClickDoNotLeak(t *testing.T) {
+	f := newFixture(t)
+
+	setupStartOnTest(t, f)
+	f.updateButton("b-1", func(button *v1alpha1.UIButton) {
+		button.Spec.Inputs = []v1alpha1.UIInputSpec{
+			{Name: "ROWS", Text: &v1alpha1.UITextInputSpec{DefaultValue: "10"}},
+			{Name: "TRUNCATE", Bool: &v1alpha1.UIBoolInputSpec{}},
+		}
+		button.Status.Inputs = []v1alpha1.UIInputStatus{
+			{Name: "ROWS", Text: &v1alpha1.UITextInputStatus{Value: "5000"}},
+			{Name: "TRUNCATE", Bool: &v1alpha1.UIBoolInputStatus{Value: true}},
+		}
+	})
+	f.clock.Advance(time.Second)
+	f.triggerButton("b-1", f.clock.Now())
+	f.reconcileCmd("testcmd")
+
+	f.fe.mu.Lock()
+	require.Equal(t, []string{"ROWS=5000", "TRUNCATE=true"}, f.fe.processes["myserver"].env)
+	f.fe.mu.Unlock()
+
+	// The second click only submits ROWS.
+	f.clock.Advance(time.Second)
+	secondClickTime := f.clock.Now()
+	f.updateButton("b-1", func(button *v1alpha1.UIButton) {
+		button.Status.Inputs = []v1alpha1.UIInputStatus{
+			{Name: "ROWS", Text: &v1alpha1.UITextInputStatus{Value: "20"}},
+		}
+		button.Status.LastClickedAt = metav1.NewMicroTime(secondClickTime)
+	})
+	f.reconcileCmd("testcmd")
+
+	f.requireCmdMatchesInAPI("testcmd", func(cmd *Cmd) bool {
+		running := cmd.Status.Running
+		return running != nil && !running.StartedAt.Time.Before(secondClickTime)
+	})
+
+	f.fe.mu.Lock()
+	defer f.fe.mu.Unlock()
+	require.Equal(t, []string{"ROWS=20", "TRUNCATE=false"}, f.fe.processes["myserver"].env)
+}
+
 func TestCmdOnlyUsesButtonThatStartedIt(t *testing.T) {
 	f := newFixture(t)
 
@@ -908,6 +1408,7 @@ type fixture struct {
 	st    *testStore
 	fe    *FakeExecer
 	fpm   *FakeProberManager
+	fps   *local.FakeProcessSignaler
 	sc    *local.ServerController
 	c     *Controller
 	clock clockwork.FakeClock
@@ -919,8 +1420,9 @@ func newFixture(t *testing.T) *fixture {
 
 	fe := NewFakeExecer()
 	fpm := NewFakeProberManager()
-	sc := local.NewServerController(f.Client)
+	fps := local.NewFakeProcessSignaler()
 	clock := clockwork.NewFakeClock()
+	sc := local.NewServerController(f.Client, fps, clock)
 	c := NewController(f.Context(), fe, fpm, f.Client, st, clock, v1alpha1.NewScheme())
 
 	return &fixture{
@@ -928,6 +1430,7 @@ func newFixture(t *testing.T) *fixture {
 		st:                st,
 		fe:                fe,
 		fpm:               fpm,
+		fps:               fps,
 		sc:                sc,
 		c:                 c,
 		clock:             clock,
@@ -950,6 +1453,33 @@ func (f *fixture) triggerButton(name string, ts time.Time) {
 	})
 }
 
+// Simulates the UIResource subscriber, which bumps LastReadyTime
+// whenever the runtime status goes to ok.
+func (f *fixture) setRuntimeStatus(name string, status v1alpha1.RuntimeStatus) {
+	r := &v1alpha1.UIResource{}
+	err := f.Client.Get(f.Context(), types.NamespacedName{Name: name}, r)
+	require.NoError(f.T(), err)
+
+	if status == v1alpha1.RuntimeStatusOK && r.Status.RuntimeStatus != v1alpha1.RuntimeStatusOK {
+		r.Status.LastReadyTime = apis.NewMicroTime(f.clock.Now())
+	}
+	r.Status.RuntimeStatus = status
+	err = f.Client.Status().Update(f.Context(), r)
+	require.NoError(f.T(), err)
+}
+
+// Asserts that the most recent run of the command is still running, and
+// has the given PID. The FakeExecer gives each run a new PID, so this
+// is how we count runs.
+func (f *fixture) assertRunCount(name string, pid int32) {
+	f.T().Helper()
+	var cmd Cmd
+	err := f.Client.Get(f.Context(), types.NamespacedName{Name: name}, &cmd)
+	require.NoError(f.T(), err)
+	require.NotNil(f.T(), cmd.Status.Running, "command was restarted")
+	require.Equal(f.T(), pid, cmd.Status.Running.PID)
+}
+
 func (f *fixture) reconcileCmd(name string) {
 	_, err := f.c.Reconcile(f.Context(), ctrl.Request{NamespacedName: types.NamespacedName{Name: name}})
 	require.NoError(f.T(), err)
@@ -1013,6 +1543,28 @@ func (f *fixture) resource(name string, cmd string, workdir string, lastDeploy t
 	f.resourceFromTarget(name, localTarget, lastDeploy)
 }
 
+func (f *fixture) reloadableResource(name string, env []string, reload *model.ServeReload, lastDeploy time.Time) {
+	c := model.ToHostCmd("sleep 60")
+	c.Dir = "."
+	c.Env = env
+	localTarget := model.NewLocalTarget(model.TargetName(name), model.Cmd{}, c, nil).
+		WithServeReload(reload)
+	f.resourceFromTarget(name, localTarget, lastDeploy)
+}
+
+func (f *fixture) livenessResource(name string, lastDeploy time.Time) {
+	c := model.ToHostCmdInDir("sleep 60", "testdir")
+	localTarget := model.NewLocalTarget(model.TargetName(name), model.Cmd{}, c, nil).
+		WithLivenessProbe(&v1alpha1.Probe{
+			PeriodSeconds:    1,
+			FailureThreshold: 1,
+			Handler: v1alpha1.Handler{
+				Exec: &v1alpha1.ExecAction{Command: []string{"true"}},
+			},
+		})
+	f.resourceFromTarget(name, localTarget, lastDeploy)
+}
+
 func (f *fixture) resourceFromTarget(name string, target model.TargetSpec, lastDeploy time.Time) {
 	n := model.ManifestName(name)
 	m := model.Manifest{
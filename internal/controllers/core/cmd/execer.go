@@ -27,6 +27,7 @@ type Execer interface {
 }
 
 type fakeExecProcess struct {
+	pid       int
 	exitCh    chan int
 	workdir   string
 	env       []string
@@ -36,6 +37,7 @@ type fakeExecProcess struct {
 type FakeExecer struct {
 	// really dumb/simple process management - key by the command string, and make duplicates an error
 	processes map[string]*fakeExecProcess
+	lastPID   int
 	mu        sync.Mutex
 }
 
@@ -57,7 +59,10 @@ func (e *FakeExecer) Start(ctx context.Context, cmd model.Cmd, w io.Writer) chan
 	exitCh := make(chan int)
 
 	e.mu.Lock()
+	e.lastPID++
+	pid := e.lastPID
 	e.processes[cmd.String()] = &fakeExecProcess{
+		pid:       pid,
 		exitCh:    exitCh,
 		workdir:   cmd.Dir,
 		startTime: time.Now(),
@@ -67,7 +72,7 @@ func (e *FakeExecer) Start(ctx context.Context, cmd model.Cmd, w io.Writer) chan
 
 	statusCh := make(chan statusAndMetadata)
 	go func() {
-		fakeRun(ctx, cmd, w, statusCh, exitCh)
+		fakeRun(ctx, cmd, w, statusCh, exitCh, pid)
 
 		e.mu.Lock()
 		delete(e.processes, cmd.String())
@@ -93,22 +98,22 @@ func (e *FakeExecer) stop(cmd string, exitCode int) error {
 	return nil
 }
 
-func fakeRun(ctx context.Context, cmd model.Cmd, w io.Writer, statusCh chan statusAndMetadata, exitCh chan int) {
+func fakeRun(ctx context.Context, cmd model.Cmd, w io.Writer, statusCh chan statusAndMetadata, exitCh chan int, pid int) {
 	defer close(statusCh)
 
 	_, _ = fmt.Fprintf(w, "Starting cmd %v\n", cmd)
 
-	statusCh <- statusAndMetadata{status: Running}
+	statusCh <- statusAndMetadata{status: Running, pid: pid}
 
 	select {
 	case <-ctx.Done():
 		_, _ = fmt.Fprintf(w, "cmd %v canceled\n", cmd)
 		// this was cleaned up by the controller, so it's not an error
-		statusCh <- statusAndMetadata{status: Done, exitCode: 0}
+		statusCh <- statusAndMetadata{status: Done, pid: pid, exitCode: 0}
 	case exitCode := <-exitCh:
 		_, _ = fmt.Fprintf(w, "cmd %v exited with code %d\n", cmd, exitCode)
 		// even an exit code of 0 is an error, because services aren't supposed to exit!
-		statusCh <- statusAndMetadata{status: Error, exitCode: exitCode}
+		statusCh <- statusAndMetadata{status: Error, pid: pid, exitCode: exitCode}
 	}
 }
 
@@ -5,6 +5,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"os/exec"
 	"strconv"
 	"strings"
 	"time"
@@ -19,12 +20,20 @@ import (
 
 var ErrUnsupportedProbeType = errors.New("unsupported probe type")
 
+// The reason on a Cmd's terminated state when we can't run its
+// readiness probe as written (e.g., a bad URL or a missing binary).
+const ProbeMisconfiguredReason = "probe misconfigured"
+
 func ProvideProberManager() ProberManager {
-	return prober.NewManager()
+	return proberManager{
+		Manager:            prober.NewManager(),
+		secureHTTPClient:   newHTTPProbeClient(false),
+		insecureHTTPClient: newHTTPProbeClient(true),
+	}
 }
 
 type ProberManager interface {
-	HTTPGet(u *url.URL, headers http.Header) prober.ProberFunc
+	HTTPGet(u *url.URL, headers http.Header, insecureSkipTLSVerify bool) prober.ProberFunc
 	TCPSocket(host string, port int) prober.ProberFunc
 	Exec(name string, args ...string) prober.ProberFunc
 }
@@ -65,13 +74,22 @@ func proberFromSpec(manager ProberManager, probeSpec *v1alpha1.Probe) (prober.Pr
 	if probeSpec == nil {
 		return nil, nil
 	} else if probeSpec.Exec != nil {
-		return manager.Exec(probeSpec.Exec.Command[0], probeSpec.Exec.Command[1:]...), nil
+		command := probeSpec.Exec.Command
+		if len(command) == 0 {
+			return nil, errors.New("exec probe has no command")
+		}
+		// Check up-front, so that a typo shows up as a bad probe
+		// rather than as a probe that fails forever.
+		if _, err := exec.LookPath(command[0]); err != nil {
+			return nil, err
+		}
+		return manager.Exec(command[0], command[1:]...), nil
 	} else if probeSpec.HTTPGet != nil {
 		u, err := extractURL(probeSpec.HTTPGet)
 		if err != nil {
 			return nil, err
 		}
-		return manager.HTTPGet(u, convertHeaders(probeSpec.HTTPGet.HTTPHeaders)), nil
+		return manager.HTTPGet(u, convertHeaders(probeSpec.HTTPGet.HTTPHeaders), probeSpec.HTTPGet.InsecureSkipTLSVerify), nil
 	} else if probeSpec.TCPSocket != nil {
 		port, err := extractPort(probeSpec.TCPSocket.Port)
 		if err != nil {
@@ -122,7 +140,8 @@ func extractURL(httpGet *v1alpha1.HTTPGetAction) (*url.URL, error) {
 // extractPort converts a K8s multi-type value to a valid port number or returns an error.
 // adapted from https://github.com/kubernetes/kubernetes/blob/v1.20.2/pkg/kubelet/prober/prober.go#L203-L223
 // (note: this implementation is substantially simplified from K8s - it does not handle "named" ports as that
-// 		  does not apply)
+//
+//	does not apply)
 func extractPort(port int32) (int, error) {
 	if port <= 0 || port > 65535 {
 		return 0, fmt.Errorf("port number out of range: %d", port)
@@ -132,10 +151,12 @@ func extractPort(port int32) (int, error) {
 
 // convertHeaders creates a stdlib http.Header map from a collection of HTTP header key-value pairs
 // adapted from https://github.com/kubernetes/kubernetes/blob/v1.20.2/pkg/kubelet/prober/prober.go#L146-L154
+//
+// (note: unlike K8s, we canonicalize the header names, so that a "host" header sets the Host)
 func convertHeaders(headerList []v1alpha1.HTTPHeader) http.Header {
 	headers := make(http.Header)
 	for _, header := range headerList {
-		headers[header.Name] = append(headers[header.Name], header.Value)
+		headers.Add(header.Name, header.Value)
 	}
 	return headers
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/url"
+	"sync"
 	"sync/atomic"
 
 	"github.com/tilt-dev/probe/pkg/prober"
@@ -16,19 +17,35 @@ func NewFakeProberManager() *FakeProberManager {
 type FakeProberManager struct {
 	probeCount int32
 
-	httpURL     *url.URL
-	httpHeaders http.Header
+	httpURL                   *url.URL
+	httpHeaders               http.Header
+	httpInsecureSkipTLSVerify bool
 
 	tcpHost string
 	tcpPort int
 
 	execName string
 	execArgs []string
+
+	mu          sync.Mutex
+	execResults map[string]prober.Result
+}
+
+// Makes exec probes of the given binary return the result from now on.
+// Exec probes succeed by default.
+func (m *FakeProberManager) SetExecResult(name string, result prober.Result) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.execResults == nil {
+		m.execResults = make(map[string]prober.Result)
+	}
+	m.execResults[name] = result
 }
 
-func (m *FakeProberManager) HTTPGet(u *url.URL, headers http.Header) prober.ProberFunc {
+func (m *FakeProberManager) HTTPGet(u *url.URL, headers http.Header, insecureSkipTLSVerify bool) prober.ProberFunc {
 	m.httpURL = u
 	m.httpHeaders = headers
+	m.httpInsecureSkipTLSVerify = insecureSkipTLSVerify
 	atomic.AddInt32(&m.probeCount, 1)
 	return successProbe
 }
@@ -44,7 +61,15 @@ func (m *FakeProberManager) Exec(name string, args ...string) prober.ProberFunc
 	m.execName = name
 	m.execArgs = args
 	atomic.AddInt32(&m.probeCount, 1)
-	return successProbe
+	return func(ctx context.Context) (prober.Result, string, error) {
+		m.mu.Lock()
+		result, ok := m.execResults[name]
+		m.mu.Unlock()
+		if !ok || result == prober.Success {
+			return successProbe(ctx)
+		}
+		return result, "fake probe failed!", nil
+	}
 }
 
 func (m *FakeProberManager) ProbeCount() int {
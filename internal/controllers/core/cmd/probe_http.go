@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/tilt-dev/probe/pkg/prober"
+)
+
+const maxHTTPProbeBodyLength = 10 * 1 << 10 // 10KB
+
+// Uses the shared TCP and exec probers, but runs HTTP probes itself,
+// because the shared HTTP prober never verifies TLS certificates.
+type proberManager struct {
+	*prober.Manager
+
+	secureHTTPClient   *http.Client
+	insecureHTTPClient *http.Client
+}
+
+func (m proberManager) HTTPGet(u *url.URL, headers http.Header, insecureSkipTLSVerify bool) prober.ProberFunc {
+	client := m.secureHTTPClient
+	if insecureSkipTLSVerify {
+		client = m.insecureHTTPClient
+	}
+	return func(ctx context.Context) (prober.Result, string, error) {
+		return doHTTPProbe(ctx, client, u, headers)
+	}
+}
+
+// adapted from https://github.com/tilt-dev/probe/blob/v0.3.1/pkg/prober/http.go
+func newHTTPProbeClient(insecureSkipTLSVerify bool) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:    &tls.Config{InsecureSkipVerify: insecureSkipTLSVerify},
+			DisableKeepAlives:  true,
+			DisableCompression: true,
+			Proxy:              http.ProxyURL(nil),
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Hostname() != via[0].URL.Hostname() {
+				return http.ErrUseLastResponse
+			}
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return nil
+		},
+	}
+}
+
+// Any 2xx response is a success, and any 3xx response is a warning.
+//
+// Request and connection errors (including certificate errors) are failures,
+// so that the probe keeps retrying while the server starts up.
+func doHTTPProbe(ctx context.Context, client *http.Client, u *url.URL, headers http.Header) (prober.Result, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return prober.Failure, err.Error(), nil
+	}
+
+	req.Header = headers.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	if _, ok := req.Header["User-Agent"]; !ok {
+		req.Header.Set("User-Agent", "tilt-probe/0.1")
+	}
+	if _, ok := req.Header["Accept"]; !ok {
+		req.Header.Set("Accept", "*/*")
+	}
+	// Go ignores the Host header on outgoing requests.
+	req.Host = req.Header.Get("Host")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return prober.Failure, err.Error(), nil
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	b, err := ioutil.ReadAll(io.LimitReader(res.Body, maxHTTPProbeBodyLength))
+	if err != nil {
+		return prober.Failure, err.Error(), nil
+	}
+	body := string(b)
+
+	if res.StatusCode >= http.StatusOK && res.StatusCode < http.StatusMultipleChoices {
+		return prober.Success, body, nil
+	}
+	if res.StatusCode >= http.StatusMultipleChoices && res.StatusCode < http.StatusBadRequest {
+		return prober.Warning, body, nil
+	}
+	return prober.Failure, fmt.Sprintf("HTTP probe failed with statuscode: %d", res.StatusCode), nil
+}
@@ -1,12 +1,17 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tilt-dev/probe/pkg/prober"
 
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
 )
@@ -160,3 +165,93 @@ func TestProbeFromSpecExec(t *testing.T) {
 		})
 	}
 }
+
+func TestProbeFromSpecExecMisconfigured(t *testing.T) {
+	cases := []struct {
+		command     []string
+		expectedErr string
+	}{
+		{nil, "exec probe has no command"},
+		{[]string{"tilt-probe-binary-that-does-not-exist"}, "executable file not found"},
+	}
+	for i, tc := range cases {
+		t.Run(fmt.Sprintf("[%d] %s", i, tc.command), func(t *testing.T) {
+			probeSpec := &v1alpha1.Probe{
+				Handler: v1alpha1.Handler{
+					Exec: &v1alpha1.ExecAction{Command: tc.command},
+				},
+			}
+			manager := &FakeProberManager{}
+			p, err := proberFromSpec(manager, probeSpec)
+			assert.Nil(t, p)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expectedErr)
+			}
+			assert.Equal(t, 0, manager.ProbeCount())
+		})
+	}
+}
+
+func TestProbeFromSpecHTTPHeaderCase(t *testing.T) {
+	probeSpec := &v1alpha1.Probe{
+		Handler: v1alpha1.Handler{
+			HTTPGet: &v1alpha1.HTTPGetAction{
+				Port:                  8443,
+				Scheme:                v1alpha1.URISchemeHTTPS,
+				HTTPHeaders:           []v1alpha1.HTTPHeader{{Name: "host", Value: "app.localhost"}},
+				InsecureSkipTLSVerify: true,
+			},
+		},
+	}
+	manager := &FakeProberManager{}
+	_, err := proberFromSpec(manager, probeSpec)
+	require.NoError(t, err)
+	assert.Equal(t, "app.localhost", manager.httpHeaders.Get("Host"))
+	assert.True(t, manager.httpInsecureSkipTLSVerify)
+}
+
+func TestHTTPProbeTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != "app.localhost" || r.Header.Get("X-Probe") != "yes" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	goodHeaders := convertHeaders([]v1alpha1.HTTPHeader{
+		{Name: "host", Value: "app.localhost"},
+		{Name: "X-Probe", Value: "yes"},
+	})
+	badHeaders := convertHeaders([]v1alpha1.HTTPHeader{
+		{Name: "X-Probe", Value: "yes"},
+	})
+
+	manager := ProvideProberManager()
+	ctx := context.Background()
+
+	// The server's certificate is self-signed, so we only
+	// get through if we skip verification.
+	result, output, err := manager.HTTPGet(u, goodHeaders, false)(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, prober.Failure, result)
+	assert.Contains(t, output, "certificate")
+
+	result, output, err = manager.HTTPGet(u, goodHeaders, true)(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, prober.Success, result)
+	assert.Equal(t, "ok", output)
+
+	result, output, err = manager.HTTPGet(u, badHeaders, true)(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, prober.Failure, result)
+	assert.Equal(t, "HTTP probe failed with statuscode: 404", output)
+
+	// Probes don't modify the headers they're given.
+	assert.Equal(t, []string{"yes"}, goodHeaders["X-Probe"])
+	assert.Empty(t, goodHeaders.Get("User-Agent"))
+}
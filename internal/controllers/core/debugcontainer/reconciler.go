@@ -0,0 +1,339 @@
+package debugcontainer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/rand"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/tilt-dev/tilt/internal/container"
+	"github.com/tilt-dev/tilt/internal/controllers/apis/debugcontainer"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+	"github.com/tilt-dev/tilt/pkg/model/logstore"
+)
+
+// How long we wait for a debug container to start before we give up on its logs.
+const startTimeout = 30 * time.Second
+
+var logRetryInterval = time.Second
+
+// A debug container that we attached, and the work we're doing for it.
+type session struct {
+	manifest  model.ManifestName
+	pod       k8s.PodID
+	namespace k8s.Namespace
+	container container.Name
+	spanID    logstore.SpanID
+	cancel    context.CancelFunc
+}
+
+// Attaches ephemeral debug containers to the pods of Kubernetes resources.
+//
+// Attaches are requested with the resource's debug container UIButton, or
+// with the HTTP API. Once a debug container is attached, we stream its logs into
+// a span under the resource, and run the one-shot command and port-forward
+// that the user asked for.
+//
+// Ephemeral containers can't be removed, so the debug container lives as long
+// as the pod does. When a deploy replaces the pod, we note that in the
+// resource's logs.
+type Reconciler struct {
+	ctrlClient ctrlclient.Client
+	kCli       k8s.Client
+	st         store.RStore
+
+	mu                    sync.Mutex
+	lastClickProcessTimes map[string]time.Time
+	sessions              []*session
+}
+
+var _ reconcile.Reconciler = &Reconciler{}
+var _ store.Subscriber = &Reconciler{}
+
+func NewReconciler(ctrlClient ctrlclient.Client, kCli k8s.Client, st store.RStore) *Reconciler {
+	return &Reconciler{
+		ctrlClient:            ctrlClient,
+		kCli:                  kCli,
+		st:                    st,
+		lastClickProcessTimes: make(map[string]time.Time),
+	}
+}
+
+func (r *Reconciler) CreateBuilder(mgr ctrl.Manager) (*builder.Builder, error) {
+	b := ctrl.NewControllerManagedBy(mgr).
+		Named("debugcontainer").
+		For(&v1alpha1.UIButton{})
+
+	return b, nil
+}
+
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	button := &v1alpha1.UIButton{}
+	err := r.ctrlClient.Get(ctx, req.NamespacedName, button)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			r.mu.Lock()
+			delete(r.lastClickProcessTimes, req.Name)
+			r.mu.Unlock()
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("debugcontainer reconcile: %v", err)
+	}
+
+	if button.Annotations[v1alpha1.AnnotationButtonType] != debugcontainer.ButtonType ||
+		button.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	clickTime := button.Status.LastClickedAt.Time
+	r.mu.Lock()
+	isNewClick := clickTime.After(r.lastClickProcessTimes[button.Name])
+	if isNewClick {
+		r.lastClickProcessTimes[button.Name] = clickTime
+	}
+	r.mu.Unlock()
+	if !isNewClick {
+		return ctrl.Result{}, nil
+	}
+
+	mn := model.ManifestName(button.Spec.Location.ComponentID)
+	opts, err := debugcontainer.OptionsFromUIButton(button)
+	if err == nil {
+		_, err = r.Attach(ctx, mn, opts)
+	}
+	if err != nil {
+		r.st.Dispatch(store.NewLogAction(mn, spanIDForResource(mn), logger.ErrorLvl, nil,
+			[]byte(fmt.Sprintf("Error attaching debug container: %v\n", err))))
+	}
+	return ctrl.Result{}, nil
+}
+
+// Attaches a debug container to the resource's current pod.
+//
+// The context must outlive the attachment, because we use it to stream the
+// debug container's logs.
+func (r *Reconciler) Attach(ctx context.Context, mn model.ManifestName, opts debugcontainer.Options) (debugcontainer.Attachment, error) {
+	pod, err := r.currentPod(mn)
+	if err != nil {
+		return debugcontainer.Attachment{}, err
+	}
+
+	podID := k8s.PodID(pod.Name)
+	ns := k8s.Namespace(pod.Namespace)
+	name := container.Name(fmt.Sprintf("debugger-%s", rand.String(5)))
+	ctr := v1.EphemeralContainer{
+		EphemeralContainerCommon: v1.EphemeralContainerCommon{
+			Name:  name.String(),
+			Image: opts.Image,
+
+			// Keep stdin open, so that the image's shell waits for input
+			// instead of exiting right away.
+			Stdin: true,
+		},
+	}
+	if len(pod.Containers) > 0 {
+		// Share the process namespace of the main container, so the debugger can see its processes.
+		ctr.TargetContainerName = pod.Containers[0].Name
+	}
+
+	err = r.kCli.AttachEphemeralContainer(ctx, podID, ns, ctr)
+	if err != nil {
+		return debugcontainer.Attachment{}, err
+	}
+
+	spanID := logstore.SpanID(fmt.Sprintf("debug:%s:%s:%s", mn, podID, name))
+	ctx, cancel := context.WithCancel(ctx)
+	ctx = logger.CtxWithLogHandler(ctx, logWriter{st: r.st, manifestName: mn, spanID: spanID})
+	s := &session{
+		manifest:  mn,
+		pod:       podID,
+		namespace: ns,
+		container: name,
+		spanID:    spanID,
+		cancel:    cancel,
+	}
+
+	r.mu.Lock()
+	r.sessions = append(r.sessions, s)
+	r.mu.Unlock()
+
+	logger.Get(ctx).Infof("Attached debug container %s (%s) to pod %s", name, opts.Image, podID)
+	go r.run(ctx, s, opts)
+
+	return debugcontainer.Attachment{
+		Pod:       podID.String(),
+		Namespace: ns.String(),
+		Container: name.String(),
+		SpanID:    string(spanID),
+	}, nil
+}
+
+func (r *Reconciler) currentPod(mn model.ManifestName) (v1alpha1.Pod, error) {
+	state := r.st.RLockState()
+	defer r.st.RUnlockState()
+
+	mt, ok := state.ManifestTargets[mn]
+	if !ok {
+		return v1alpha1.Pod{}, fmt.Errorf("no resource named %q", mn)
+	}
+	if !mt.Manifest.IsK8s() {
+		return v1alpha1.Pod{}, fmt.Errorf("resource %q is not a Kubernetes resource", mn)
+	}
+
+	pod := mt.State.K8sRuntimeState().MostRecentPod()
+	if pod.Name == "" || pod.Deleting {
+		return v1alpha1.Pod{}, fmt.Errorf("resource %q doesn't have a running pod", mn)
+	}
+	return pod, nil
+}
+
+// Streams the debug container's logs, then runs the command and port-forward.
+func (r *Reconciler) run(ctx context.Context, s *session, opts debugcontainer.Options) {
+	l := logger.Get(ctx)
+
+	logs, err := r.waitForLogs(ctx, s)
+	if err != nil {
+		if ctx.Err() == nil {
+			l.Errorf("Error streaming logs from debug container %s: %v", s.container, err)
+		}
+		return
+	}
+
+	go func() {
+		defer func() {
+			_ = logs.Close()
+		}()
+		_, _ = io.Copy(l.Writer(logger.InfoLvl), logs)
+	}()
+
+	if len(opts.Command) > 0 {
+		l.Infof("Running in debug container: %q", opts.Command)
+		w := l.Writer(logger.InfoLvl)
+		err := r.kCli.Exec(ctx, s.pod, s.container, s.namespace, opts.Command, nil, w, w)
+		if err != nil && ctx.Err() == nil {
+			l.Errorf("Error running %q in debug container: %v", opts.Command, err)
+		}
+	}
+
+	if opts.Port != 0 {
+		r.forwardPort(ctx, s, opts.Port)
+	}
+}
+
+// The logs aren't available until the container starts, so keep trying for a while.
+func (r *Reconciler) waitForLogs(ctx context.Context, s *session) (io.ReadCloser, error) {
+	deadline := time.Now().Add(startTimeout)
+	for {
+		logs, err := r.kCli.ContainerLogs(ctx, s.pod, s.container, s.namespace, time.Time{})
+		if err == nil {
+			return logs, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(logRetryInterval):
+		}
+	}
+}
+
+// Debug containers share the pod's network, so forwarding to the pod
+// reaches anything listening in the debug container.
+func (r *Reconciler) forwardPort(ctx context.Context, s *session, port int) {
+	l := logger.Get(ctx)
+	pf, err := r.kCli.CreatePortForwarder(ctx, s.namespace, s.pod, port, port, "localhost")
+	if err != nil {
+		l.Errorf("Error forwarding port %d to debug container: %v", port, err)
+		return
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-pf.ReadyCh():
+			l.Infof("Forwarding localhost:%d to port %d in pod %s", pf.LocalPort(), port, s.pod)
+		}
+	}()
+
+	err = pf.ForwardPorts()
+	if err != nil && ctx.Err() == nil {
+		l.Errorf("Port-forward to debug container stopped: %v", err)
+	}
+}
+
+// When a pod goes away, its debug containers go with it.
+func (r *Reconciler) OnChange(ctx context.Context, st store.RStore, _ store.ChangeSummary) error {
+	r.mu.Lock()
+	sessions := r.sessions
+	r.mu.Unlock()
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	state := st.RLockState()
+	var live, gone []*session
+	for _, s := range sessions {
+		if podExists(state, s) {
+			live = append(live, s)
+		} else {
+			gone = append(gone, s)
+		}
+	}
+	st.RUnlockState()
+
+	if len(gone) == 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	r.sessions = live
+	r.mu.Unlock()
+
+	for _, s := range gone {
+		s.cancel()
+		st.Dispatch(store.NewLogAction(s.manifest, s.spanID, logger.WarnLvl, nil,
+			[]byte(fmt.Sprintf("Pod %s was replaced, so debug container %s is gone\n", s.pod, s.container))))
+	}
+	return nil
+}
+
+func podExists(state store.EngineState, s *session) bool {
+	mt, ok := state.ManifestTargets[s.manifest]
+	if !ok || !mt.Manifest.IsK8s() {
+		return false
+	}
+	pod, ok := mt.State.K8sRuntimeState().Pods[s.pod]
+	return ok && !pod.Deleting
+}
+
+// Where we log errors that happen before a debug container exists.
+func spanIDForResource(mn model.ManifestName) logstore.SpanID {
+	return logstore.SpanID(fmt.Sprintf("debug:%s", mn))
+}
+
+type logWriter struct {
+	st           store.RStore
+	manifestName model.ManifestName
+	spanID       logstore.SpanID
+}
+
+func (w logWriter) Write(level logger.Level, fields logger.Fields, p []byte) error {
+	w.st.Dispatch(store.NewLogAction(w.manifestName, w.spanID, level, fields, p))
+	return nil
+}
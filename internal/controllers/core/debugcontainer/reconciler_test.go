@@ -0,0 +1,180 @@
+package debugcontainer
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tilt-dev/tilt/internal/controllers/apis/debugcontainer"
+	"github.com/tilt-dev/tilt/internal/controllers/fake"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/testutils/manifestbuilder"
+	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model/logstore"
+)
+
+func TestClickAttachesDebugContainer(t *testing.T) {
+	f := newFixture(t)
+	f.setUpPod("pod-a")
+
+	f.click("busybox:1.35", "", "")
+
+	nn := types.NamespacedName{Name: "pod-a", Namespace: "default"}
+	ctrs := f.kCli.EphemeralContainers[nn]
+	require.Len(t, ctrs, 1)
+	assert.True(t, strings.HasPrefix(ctrs[0].Name, "debugger-"))
+	assert.Equal(t, "busybox:1.35", ctrs[0].Image)
+	assert.Equal(t, "main", ctrs[0].TargetContainerName)
+	assert.True(t, ctrs[0].Stdin)
+
+	spanID := logstore.SpanID("debug:fe:pod-a:" + ctrs[0].Name)
+	f.waitForLog(spanID, "Attached debug container "+ctrs[0].Name+" (busybox:1.35) to pod pod-a")
+
+	// Stream the debug container's logs into its span.
+	require.Eventually(t, func() bool {
+		return f.kCli.LastPodLogPipeWriter != nil
+	}, time.Second, 10*time.Millisecond)
+	_, _ = f.kCli.LastPodLogPipeWriter.Write([]byte("hello from the debugger\n"))
+	f.waitForLog(spanID, "hello from the debugger")
+
+	// Another reconcile without a new click shouldn't attach another container.
+	f.MustReconcile(types.NamespacedName{Name: debugcontainer.UIButtonName("fe")})
+	assert.Len(t, f.kCli.EphemeralContainers[nn], 1)
+}
+
+func TestClickRunsCommandAndPortForward(t *testing.T) {
+	f := newFixture(t)
+	f.setUpPod("pod-a")
+	f.kCli.ExecOutputs = []io.Reader{strings.NewReader("tcp 0.0.0.0:8080 LISTEN\n")}
+
+	f.click("nicolaka/netshoot", "netstat -tlpn", "8080")
+
+	nn := types.NamespacedName{Name: "pod-a", Namespace: "default"}
+	require.Len(t, f.kCli.EphemeralContainers[nn], 1)
+	name := f.kCli.EphemeralContainers[nn][0].Name
+	spanID := logstore.SpanID("debug:fe:pod-a:" + name)
+
+	f.waitForLog(spanID, "tcp 0.0.0.0:8080 LISTEN")
+	require.Eventually(t, func() bool {
+		return len(f.kCli.FakePortForwardClient.PortForwardCalls()) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	call := f.kCli.FakePortForwardClient.PortForwardCalls()[0]
+	assert.Equal(t, k8s.PodID("pod-a"), call.PodID)
+	assert.Equal(t, 8080, call.RemotePort)
+}
+
+func TestClickUnsupportedCluster(t *testing.T) {
+	f := newFixture(t)
+	f.setUpPod("pod-a")
+	f.kCli.EphemeralError = k8s.ErrEphemeralContainersUnsupported
+
+	f.click("busybox:1.35", "", "")
+
+	f.waitForLog("debug:fe", "Error attaching debug container: ephemeral containers are not supported by this cluster")
+}
+
+func TestPodReplacedEndsSession(t *testing.T) {
+	f := newFixture(t)
+	f.setUpPod("pod-a")
+
+	f.click("busybox:1.35", "", "")
+	nn := types.NamespacedName{Name: "pod-a", Namespace: "default"}
+	require.Len(t, f.kCli.EphemeralContainers[nn], 1)
+	name := f.kCli.EphemeralContainers[nn][0].Name
+
+	_ = f.r.OnChange(f.Context(), f.st, store.LegacyChangeSummary())
+	assert.Len(t, f.r.sessions, 1)
+
+	f.setUpPod("pod-b")
+	_ = f.r.OnChange(f.Context(), f.st, store.LegacyChangeSummary())
+	assert.Empty(t, f.r.sessions)
+	f.waitForLog(logstore.SpanID("debug:fe:pod-a:"+name),
+		"Pod pod-a was replaced, so debug container "+name+" is gone")
+}
+
+type fixture struct {
+	*fake.ControllerFixture
+	t    *testing.T
+	tmp  *tempdir.TempDirFixture
+	r    *Reconciler
+	kCli *k8s.FakeK8sClient
+	st   *store.TestingStore
+}
+
+func newFixture(t *testing.T) *fixture {
+	cfb := fake.NewControllerFixtureBuilder(t)
+	kCli := k8s.NewFakeK8sClient(t)
+	st := store.NewTestingStore()
+	r := NewReconciler(cfb.Client, kCli, st)
+	f := &fixture{
+		ControllerFixture: cfb.Build(r),
+		t:                 t,
+		tmp:               tempdir.NewTempDirFixture(t),
+		r:                 r,
+		kCli:              kCli,
+		st:                st,
+	}
+	t.Cleanup(func() {
+		kCli.TearDown()
+		f.tmp.TearDown()
+	})
+
+	f.Create(debugcontainer.ToUIButton("fe", "busybox:1.35"))
+	return f
+}
+
+// Sets up the "fe" resource with a single running pod.
+func (f *fixture) setUpPod(name string) {
+	f.kCli.UpsertPod(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+	})
+
+	state := f.st.LockMutableStateForTesting()
+	defer f.st.UnlockMutableState()
+
+	m := manifestbuilder.New(f.tmp, "fe").WithK8sYAML(testyaml.SanchoYAML).Build()
+	state.UpsertManifestTarget(store.NewManifestTarget(m))
+	state.ManifestTargets["fe"].State.RuntimeState = store.NewK8sRuntimeStateWithPods(m, v1alpha1.Pod{
+		Name:       name,
+		Namespace:  "default",
+		Phase:      string(v1.PodRunning),
+		Containers: []v1alpha1.Container{{Name: "main"}},
+	})
+}
+
+func (f *fixture) click(image, command, port string) {
+	var b v1alpha1.UIButton
+	f.MustGet(types.NamespacedName{Name: debugcontainer.UIButtonName("fe")}, &b)
+	b.Status.LastClickedAt = metav1.NowMicro()
+	b.Status.Inputs = []v1alpha1.UIInputStatus{
+		{Name: debugcontainer.ImageInput, Text: &v1alpha1.UITextInputStatus{Value: image}},
+		{Name: debugcontainer.CommandInput, Text: &v1alpha1.UITextInputStatus{Value: command}},
+		{Name: debugcontainer.PortInput, Text: &v1alpha1.UITextInputStatus{Value: port}},
+	}
+	f.UpdateStatus(&b)
+}
+
+func (f *fixture) waitForLog(spanID logstore.SpanID, msg string) {
+	f.t.Helper()
+	require.Eventually(f.t, func() bool {
+		for _, a := range f.st.Actions() {
+			la, ok := a.(store.LogAction)
+			if ok && la.ManifestName() == "fe" && la.SpanID() == spanID &&
+				strings.Contains(string(la.Message()), msg) {
+				return true
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond, "log %q in span %s", msg, spanID)
+}
@@ -0,0 +1,7 @@
+package debugcontainer
+
+import "github.com/google/wire"
+
+var WireSet = wire.NewSet(
+	NewReconciler,
+)
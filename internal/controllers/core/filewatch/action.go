@@ -19,8 +19,10 @@ type FileWatchUpdateStatusAction struct {
 	Status     *filewatches.FileWatchStatus
 }
 
-func (a FileWatchUpdateStatusAction) Summarize(_ *store.ChangeSummary) {
-	// do nothing - we only care about _spec_ changes on the summary
+func (a FileWatchUpdateStatusAction) Summarize(s *store.ChangeSummary) {
+	// File events add pending changes to every manifest that depends on the
+	// target, and we can't tell which manifests those are from here.
+	s.Legacy = true
 }
 
 func (FileWatchUpdateStatusAction) Action() {}
@@ -29,13 +29,16 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	errorutil "k8s.io/apimachinery/pkg/util/errors"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/tilt-dev/tilt/internal/controllers/core/filewatch/fsevent"
+	"github.com/tilt-dev/tilt/internal/engine/idle"
 	"github.com/tilt-dev/tilt/internal/ignore"
+	"github.com/tilt-dev/tilt/internal/ospath"
 	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/internal/store/filewatches"
 	"github.com/tilt-dev/tilt/internal/watch"
@@ -53,8 +56,13 @@ type Controller struct {
 	timerMaker     fsevent.TimerMaker
 	mu             sync.Mutex
 	indexer        *indexer.Indexer
+
+	// Set while Tilt is asleep. See internal/engine/idle.
+	sleeping bool
 }
 
+var _ idle.Sleeper = &Controller{}
+
 func NewController(client ctrlclient.Client, store store.RStore, fsWatcherMaker fsevent.WatcherMaker, timerMaker fsevent.TimerMaker, scheme *runtime.Scheme) *Controller {
 	return &Controller{
 		Client:         client,
@@ -142,20 +150,24 @@ func (c *Controller) removeWatch(tw *watcher) {
 	}
 }
 
+// addOrReplace starts watching with the new spec.
+//
+// If we're already watching with an old spec, the new watcher has to start
+// successfully before the old one is stopped, so that we don't drop any
+// events in between. If the new watcher fails to start, the old one keeps running.
+//
+// mu must be held before calling.
 func (c *Controller) addOrReplace(ctx context.Context, st store.RStore, name types.NamespacedName, fw *v1alpha1.FileWatch) error {
+	existing := c.targetWatches[name]
 	ignoreMatcher, err := ignore.IgnoresToMatcher(fw.Spec.Ignores)
 	if err != nil {
+		c.reportStartError(ctx, fw, existing, err)
 		return err
 	}
-	notify, err := c.fsWatcherMaker(
-		append([]string{}, fw.Spec.WatchedPaths...),
-		ignoreMatcher,
-		logger.Get(ctx))
+	notify, err := c.startNotify(ctx, fw, ignoreMatcher)
 	if err != nil {
-		return fmt.Errorf("failed to initialize filesystem watch: %v", err)
-	}
-	if err := notify.Start(); err != nil {
-		return fmt.Errorf("failed to initialize filesystem watch: %v", err)
+		c.reportStartError(ctx, fw, existing, err)
+		return err
 	}
 
 	// Clear out any old events
@@ -164,34 +176,98 @@ func (c *Controller) addOrReplace(ctx context.Context, st store.RStore, name typ
 	fw.Status.MonitorStartTime = metav1.NowMicro()
 	fw.Status.Error = ""
 
+	ctx, cancel := context.WithCancel(ctx)
+	w := &watcher{
+		name:     name,
+		spec:     *fw.Spec.DeepCopy(),
+		status:   fw.Status.DeepCopy(),
+		notify:   notify,
+		cancel:   cancel,
+		sleeping: c.sleeping,
+	}
+
+	// Hold the new watcher's lock until its status is written,
+	// so that events handed over from the old watcher are recorded after.
+	w.mu.Lock()
+	if existing != nil {
+		existing.handOver(ctx, w, func(path string) bool {
+			return isWatched(fw.Spec.WatchedPaths, ignoreMatcher, path)
+		})
+		w.status.DeepCopyInto(&fw.Status)
+	}
+
 	if err := c.Client.Status().Update(ctx, fw); err != nil {
-		_ = notify.Close()
+		if existing != nil {
+			// Send any events that were handed over back to the old watcher.
+			w.forward = existing
+			existing.mu.Lock()
+			existing.forward = nil
+			existing.mu.Unlock()
+		}
+		w.mu.Unlock()
+		w.cleanupWatch(ctx)
 		return fmt.Errorf("failed to update monitor start time: %v", err)
 	}
+	w.mu.Unlock()
 	c.Store.Dispatch(NewFileWatchUpdateStatusAction(fw))
 
-	ctx, cancel := context.WithCancel(ctx)
-	w := &watcher{
-		name:   name,
-		spec:   *fw.Spec.DeepCopy(),
-		status: fw.Status.DeepCopy(),
-		notify: notify,
-		cancel: cancel,
+	go c.dispatchFileChangesLoop(ctx, st, w)
+
+	c.targetWatches[name] = w
+	return nil
+}
+
+func (c *Controller) startNotify(ctx context.Context, fw *v1alpha1.FileWatch, ignoreMatcher watch.PathMatcher) (watch.Notify, error) {
+	notify, err := c.fsWatcherMaker(
+		append([]string{}, fw.Spec.WatchedPaths...),
+		ignoreMatcher,
+		logger.Get(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize filesystem watch: %v", err)
+	}
+	if err := notify.Start(); err != nil {
+		_ = notify.Close()
+		return nil, fmt.Errorf("failed to initialize filesystem watch: %v", err)
 	}
+	return notify, nil
+}
 
-	go c.dispatchFileChangesLoop(ctx, st, w)
+// Returns true if a watcher with the given paths and ignores would report changes to path.
+func isWatched(watchedPaths []string, ignoreMatcher watch.PathMatcher, path string) bool {
+	ignored, err := ignoreMatcher.Matches(path)
+	if err != nil || ignored {
+		return false
+	}
+	for _, watched := range watchedPaths {
+		if ospath.IsChild(watched, path) {
+			return true
+		}
+	}
+	return false
+}
 
-	if existing, ok := c.targetWatches[name]; ok {
-		// no need to remove from map, will be overwritten
-		existing.cleanupWatch(ctx)
+// reportStartError records that we couldn't watch with the new spec.
+//
+// If there's an existing watcher, it keeps running with the old spec,
+// and keeps its error so that later events don't clear it.
+func (c *Controller) reportStartError(ctx context.Context, fw *v1alpha1.FileWatch, existing *watcher, err error) {
+	if existing != nil {
+		existing.mu.Lock()
+		existing.status.Error = err.Error()
+		existing.mu.Unlock()
 	}
+	fw.Status.Error = err.Error()
 
-	c.targetWatches[name] = w
-	return nil
+	if updateErr := c.Client.Status().Update(ctx, fw); updateErr != nil {
+		logger.Get(ctx).Debugf("Failed to update status for %q: %v", fw.Name, updateErr)
+		return
+	}
+	c.Store.Dispatch(NewFileWatchUpdateStatusAction(fw))
 }
 
 func (c *Controller) dispatchFileChangesLoop(ctx context.Context, st store.RStore, w *watcher) {
 	eventsCh := fsevent.Coalesce(c.timerMaker, w.notify.Events())
+	errorsCh := w.notify.Errors()
 
 	defer func() {
 		c.mu.Lock()
@@ -202,9 +278,12 @@ func (c *Controller) dispatchFileChangesLoop(ctx context.Context, st store.RStor
 
 	for {
 		select {
-		case err, ok := <-w.notify.Errors():
+		case err, ok := <-errorsCh:
 			if !ok {
-				return
+				// Some watchers close the error channel before they've
+				// delivered all their events, so keep reading events.
+				errorsCh = nil
+				continue
 			}
 
 			// TODO(milas): these should probably update the error field and emit FileWatchUpdateAction
@@ -245,6 +324,41 @@ func (c *Controller) dispatchFileChangesLoop(ctx context.Context, st store.RStor
 	}
 }
 
+// Sleep stops recording file events. Watchers keep track of which files
+// changed, and dispatch activity on the first change so that Tilt wakes up.
+func (c *Controller) Sleep(ctx context.Context, st store.RStore) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sleeping = true
+	for _, w := range c.targetWatches {
+		w.sleep()
+	}
+	return nil
+}
+
+// Wake records any files that changed while we were asleep.
+func (c *Controller) Wake(ctx context.Context, st store.RStore) error {
+	c.mu.Lock()
+	c.sleeping = false
+	watchers := make([]*watcher, 0, len(c.targetWatches))
+	for _, w := range c.targetWatches {
+		watchers = append(watchers, w)
+	}
+	c.mu.Unlock()
+
+	var errs []error
+	for _, w := range watchers {
+		fsEvents := w.wake()
+		if len(fsEvents) == 0 {
+			continue
+		}
+		if err := w.recordEvent(ctx, c.Client, st, fsEvents); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errorutil.NewAggregate(errs)
+}
+
 // Find all the objects to watch based on the Filewatch model
 func indexFw(obj ctrlclient.Object) []indexer.Key {
 	fw := obj.(*v1alpha1.FileWatch)
@@ -21,11 +21,13 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/tilt-dev/tilt/internal/controllers/fake"
+	"github.com/tilt-dev/tilt/internal/engine/idle"
 	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
 	"github.com/tilt-dev/tilt/internal/watch"
 	"github.com/tilt-dev/tilt/pkg/apis"
 	filewatches "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/logger"
 )
 
 // Test constants
@@ -321,6 +323,101 @@ func TestController_Reconcile_Watches(t *testing.T) {
 	}
 }
 
+func TestController_Reconcile_SpecUpdateKeepsInFlightEvents(t *testing.T) {
+	f := newFixture(t)
+	key, fw := f.CreateSimpleFileWatch()
+
+	oldNotify := f.controller.targetWatches[key].notify.(*fsevent.FakeWatcher)
+
+	// Hold the coalescing timers, so that events are still in flight
+	// when the spec changes.
+	f.fakeTimerMaker.RestTimerLock.Lock()
+	f.fakeTimerMaker.MaxTimerLock.Lock()
+
+	f.ChangeFile("a", "0")
+	f.ChangeFile("a", "1")
+	require.Eventually(t, func() bool {
+		return oldNotify.TotalEventCount() == 2
+	}, timeout, interval, "Events never seen by the old watcher")
+
+	f.MustGet(key, fw)
+	fw.Spec.Ignores = []filewatches.IgnoreDef{
+		{
+			BasePath: f.tmpdir.Path(),
+			Patterns: []string{"**/ignore_me"},
+		},
+	}
+	f.Update(fw)
+
+	// The new watcher sees the same change again during the handover.
+	f.ChangeFile("a", "1")
+
+	f.fakeTimerMaker.RestTimerLock.Unlock()
+	f.fakeTimerMaker.MaxTimerLock.Unlock()
+
+	f.WaitForSeenFile(key, "a", "0")
+	f.ChangeAndWaitForSeenFile(key, "a", "2")
+
+	var updated filewatches.FileWatch
+	f.MustGet(key, &updated)
+	seen := make(map[string]int)
+	for _, e := range updated.Status.FileEvents {
+		for _, p := range e.SeenFiles {
+			seen[p]++
+		}
+	}
+	assert.Equal(t, map[string]int{
+		f.tmpdir.JoinPath("a", "0"): 1,
+		f.tmpdir.JoinPath("a", "1"): 1,
+		f.tmpdir.JoinPath("a", "2"): 1,
+	}, seen)
+}
+
+func TestController_Reconcile_SpecUpdateKeepsRecentEvents(t *testing.T) {
+	f := newFixture(t)
+	key, fw := f.CreateSimpleFileWatch()
+
+	f.ChangeAndWaitForSeenFile(key, "a", "1")
+	f.ChangeAndWaitForSeenFile(key, "b", "c", "1")
+
+	// Stop watching b/c. The recent change to a/1 is still relevant, but b/c/1 isn't.
+	f.MustGet(key, fw)
+	fw.Spec.WatchedPaths = []string{f.tmpdir.JoinPath("a")}
+	f.Update(fw)
+
+	var updated filewatches.FileWatch
+	f.MustGet(key, &updated)
+	if assert.Equal(t, 1, len(updated.Status.FileEvents)) {
+		assert.Equal(t, []string{f.tmpdir.JoinPath("a", "1")}, updated.Status.FileEvents[0].SeenFiles)
+		assert.Equal(t, updated.Status.FileEvents[0].Time, updated.Status.LastEventTime)
+	}
+}
+
+func TestController_Reconcile_SpecUpdateStartError(t *testing.T) {
+	f := newFixture(t)
+	key, fw := f.CreateSimpleFileWatch()
+	original := f.controller.targetWatches[key]
+
+	f.controller.fsWatcherMaker = func(paths []string, ignore watch.PathMatcher, l logger.Logger) (watch.Notify, error) {
+		return nil, fmt.Errorf("too many open files")
+	}
+
+	f.MustGet(key, fw)
+	fw.Spec.WatchedPaths = []string{f.tmpdir.JoinPath("d")}
+	require.NoError(t, f.Client.Update(f.Context(), fw))
+	f.ReconcileWithErrors(key, "too many open files")
+
+	f.MustGet(key, fw)
+	assert.Contains(t, fw.Status.Error, "too many open files")
+
+	// The old watcher keeps running.
+	assert.Same(t, original, f.controller.targetWatches[key])
+	f.ChangeAndWaitForSeenFile(key, "a", "1")
+
+	f.MustGet(key, fw)
+	assert.Contains(t, fw.Status.Error, "too many open files")
+}
+
 func TestController_Disable_By_Configmap(t *testing.T) {
 	f := newFixture(t)
 	key, _ := f.CreateSimpleFileWatch()
@@ -388,3 +485,65 @@ func TestController_IgnoreErrorsOnCancel(t *testing.T) {
 			`Ignored stale error for "TestController_IgnoreErrorsOnCancel/test-file-watch": apiserver update status error: context canceled`)
 	}, time.Second, 10*time.Millisecond, "Error ignored log message never seen")
 }
+
+func TestController_SleepBuffersEvents(t *testing.T) {
+	f := newFixture(t)
+	key, _ := f.CreateSimpleFileWatch()
+	f.ChangeAndWaitForSeenFile(key, "a", "1")
+
+	require.NoError(t, f.controller.Sleep(f.Context(), f.store))
+	f.store.ClearActions()
+
+	f.ChangeFile("a", "2")
+	f.ChangeFile("b", "c", "3")
+
+	tw := f.controller.targetWatches[key]
+	require.Eventually(t, func() bool {
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+		return len(tw.dirty) == 2
+	}, timeout, interval, "Changes never buffered")
+
+	// The first change wakes Tilt up.
+	var activity []idle.ActivityAction
+	for _, a := range f.store.Actions() {
+		if a, ok := a.(idle.ActivityAction); ok {
+			activity = append(activity, a)
+		}
+	}
+	require.Len(t, activity, 1)
+	assert.Equal(t, "file change", activity[0].Source)
+
+	// Nothing is recorded until we wake up.
+	var fw filewatches.FileWatch
+	f.MustGet(key, &fw)
+	require.Len(t, fw.Status.FileEvents, 1)
+
+	require.NoError(t, f.controller.Wake(f.Context(), f.store))
+	f.MustGet(key, &fw)
+	require.Len(t, fw.Status.FileEvents, 2)
+	assert.Equal(t, []string{f.tmpdir.JoinPath("a", "2"), f.tmpdir.JoinPath("b", "c", "3")},
+		fw.Status.FileEvents[1].SeenFiles)
+
+	// After we wake up, changes are recorded as usual.
+	f.ChangeAndWaitForSeenFile(key, "a", "4")
+}
+
+func TestController_NewWatchWhileSleeping(t *testing.T) {
+	f := newFixture(t)
+	require.NoError(t, f.controller.Sleep(f.Context(), f.store))
+
+	key, _ := f.CreateSimpleFileWatch()
+	f.ChangeFile("a", "1")
+
+	tw := f.controller.targetWatches[key]
+	require.NotNil(t, tw, "Watcher never created")
+	require.Eventually(t, func() bool {
+		tw.mu.Lock()
+		defer tw.mu.Unlock()
+		return len(tw.dirty) == 1
+	}, timeout, interval, "Change never buffered")
+
+	require.NoError(t, f.controller.Wake(f.Context(), f.store))
+	f.WaitForSeenFile(key, "a", "1")
+}
@@ -76,7 +76,10 @@ func (w *FakeMultiWatcher) loop() {
 			w.mu.Lock()
 			for _, watcher := range w.watchers {
 				if watcher.matches(e.Path()) {
-					watcher.inboundCh <- e
+					select {
+					case watcher.inboundCh <- e:
+					case <-watcher.done:
+					}
 				}
 			}
 			w.mu.Unlock()
@@ -95,6 +98,8 @@ type FakeWatcher struct {
 	inboundCh  chan watch.FileEvent
 	outboundCh chan watch.FileEvent
 	errorCh    chan error
+	done       chan struct{}
+	closeOnce  sync.Once
 
 	eventCount uint64
 
@@ -111,6 +116,7 @@ func NewFakeWatcher(inboundCh chan watch.FileEvent, errorCh chan error, paths []
 		inboundCh:  inboundCh,
 		outboundCh: make(chan watch.FileEvent, 20),
 		errorCh:    errorCh,
+		done:       make(chan struct{}),
 		paths:      paths,
 		ignore:     ignore,
 	}
@@ -135,7 +141,12 @@ func (w *FakeWatcher) Start() error {
 	return nil
 }
 
+// Close stops accepting new events. Like a real watcher, events that
+// were already seen are still delivered before the Events channel closes.
 func (w *FakeWatcher) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.done)
+	})
 	return nil
 }
 
@@ -156,15 +167,20 @@ func (w *FakeWatcher) QueuedCount() int {
 }
 
 func (w *FakeWatcher) loop() {
+	defer close(w.outboundCh)
+
 	var q []watch.FileEvent
 	for {
 		if len(q) == 0 {
-			e, ok := <-w.inboundCh
-			if !ok {
-				close(w.outboundCh)
+			select {
+			case e, ok := <-w.inboundCh:
+				if !ok {
+					return
+				}
+				q = append(q, e)
+			case <-w.done:
 				return
 			}
-			q = append(q, e)
 		} else {
 			e := q[0]
 			w.outboundCh <- e
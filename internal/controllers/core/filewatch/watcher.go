@@ -3,13 +3,17 @@ package filewatch
 import (
 	"context"
 	"fmt"
+	"os"
+	"sort"
 	"sync"
+	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/tilt-dev/tilt/internal/engine/idle"
 	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/internal/watch"
 	filewatches "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
@@ -21,14 +25,48 @@ const MaxFileEventHistory = 20
 
 const DetectedOverflowErrMsg = `It looks like the inotify event queue has overflowed. Check these instructions for how to raise the queue limit: https://facebook.github.io/watchman/docs/install#system-specific-preparation`
 
+// How long a replaced watcher has to deliver the events it already saw to its
+// replacement. Needs to be longer than the coalescing delay in fsevent.Coalesce.
+const handoverTimeout = 2 * time.Second
+
 type watcher struct {
-	name   types.NamespacedName
-	spec   filewatches.FileWatchSpec
-	status *filewatches.FileWatchStatus
-	mu     sync.Mutex
-	done   bool
-	notify watch.Notify
-	cancel func()
+	name         types.NamespacedName
+	spec         filewatches.FileWatchSpec
+	status       *filewatches.FileWatchStatus
+	mu           sync.Mutex
+	done         bool
+	notify       watch.Notify
+	notifyClosed bool
+	cancel       func()
+
+	// When the spec changes, the watcher is replaced. Events that the old
+	// watcher was still processing are recorded by the new one.
+	forward *watcher
+
+	// While a replaced watcher drains into this one, the events we've
+	// already recorded, so that we don't record an event seen by both twice.
+	handoverSeen map[fileEventKey]bool
+
+	// While Tilt is asleep, we don't record events. Instead, we remember
+	// which files changed, and record them all when Tilt wakes up.
+	sleeping bool
+	dirty    map[string]bool
+}
+
+// Identifies a change to a file, so that two watchers that
+// both saw the change only record it once.
+type fileEventKey struct {
+	path  string
+	mtime time.Time
+}
+
+func newFileEventKey(path string) fileEventKey {
+	key := fileEventKey{path: path}
+	info, err := os.Stat(path)
+	if err == nil {
+		key.mtime = info.ModTime()
+	}
+	return key
 }
 
 // cleanupWatch stops watching for changes and frees up resources.
@@ -38,17 +76,119 @@ func (w *watcher) cleanupWatch(ctx context.Context) {
 	if w.done {
 		return
 	}
+	w.closeNotify(ctx)
+	w.cancel()
+	w.done = true
+}
+
+// closeNotify stops the filesystem monitor. Events it already saw
+// are still delivered until the watcher is cleaned up.
+//
+// mu must be held before calling.
+func (w *watcher) closeNotify(ctx context.Context) {
+	if w.notifyClosed {
+		return
+	}
 	if err := w.notify.Close(); err != nil {
 		logger.Get(ctx).Debugf("Failed to close notifier for %q: %v", w.name.String(), err)
 	}
-	w.cancel()
-	w.done = true
+	w.notifyClosed = true
+}
+
+// handOver replaces this watcher with next.
+//
+// Stops monitoring the filesystem, and sends any events that were in flight
+// to next. next must already be watching, so that no events are lost in between.
+//
+// Events we recorded recently are copied to next, in case whoever consumes the
+// status hasn't seen them yet. keep filters out files that next doesn't watch.
+//
+// next.mu must be held before calling.
+func (w *watcher) handOver(ctx context.Context, next *watcher, keep func(path string) bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.forward = next
+	w.closeNotify(ctx)
+
+	next.handoverSeen = make(map[fileEventKey]bool)
+	since := time.Now().Add(-handoverTimeout)
+	for _, event := range w.status.FileEvents {
+		if event.Time.Time.Before(since) {
+			continue
+		}
+
+		replayed := filewatches.FileEvent{Time: event.Time}
+		for _, path := range event.SeenFiles {
+			if keep(path) {
+				replayed.SeenFiles = append(replayed.SeenFiles, path)
+				next.handoverSeen[newFileEventKey(path)] = true
+			}
+		}
+		if len(replayed.SeenFiles) != 0 {
+			next.status.FileEvents = append(next.status.FileEvents, replayed)
+			next.status.LastEventTime = event.Time
+		}
+	}
+
+	for path := range w.dirty {
+		if keep(path) {
+			next.markDirty(path)
+		}
+	}
+
+	time.AfterFunc(handoverTimeout, func() {
+		w.cleanupWatch(ctx)
+		next.endHandover()
+	})
+}
+
+func (w *watcher) endHandover() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handoverSeen = nil
+}
+
+// During a handover, drops events that we've already recorded.
+//
+// mu must be held before calling.
+func (w *watcher) dedupeHandoverEvents(fsEvents []watch.FileEvent) []watch.FileEvent {
+	if w.handoverSeen == nil {
+		return fsEvents
+	}
+
+	result := make([]watch.FileEvent, 0, len(fsEvents))
+	for _, fsEvent := range fsEvents {
+		key := newFileEventKey(fsEvent.Path())
+		if w.handoverSeen[key] {
+			continue
+		}
+		w.handoverSeen[key] = true
+		result = append(result, fsEvent)
+	}
+	return result
 }
 
 func (w *watcher) recordEvent(ctx context.Context, client ctrlclient.Client, st store.RStore, fsEvents []watch.FileEvent) error {
 	now := metav1.NowMicro()
 	w.mu.Lock()
+	if w.forward != nil {
+		next := w.forward
+		w.mu.Unlock()
+		return next.recordEvent(ctx, client, st, fsEvents)
+	}
 	defer w.mu.Unlock()
+
+	if w.sleeping {
+		if len(w.dirty) == 0 && len(fsEvents) != 0 {
+			st.Dispatch(idle.NewActivityAction("file change"))
+		}
+		for _, fsEvent := range fsEvents {
+			w.markDirty(fsEvent.Path())
+		}
+		return nil
+	}
+
+	fsEvents = w.dedupeHandoverEvents(fsEvents)
 	event := filewatches.FileEvent{Time: *now.DeepCopy()}
 	for _, fsEvent := range fsEvents {
 		event.SeenFiles = append(event.SeenFiles, fsEvent.Path())
@@ -82,3 +222,37 @@ func (w *watcher) recordEvent(ctx context.Context, client ctrlclient.Client, st
 	}
 	return nil
 }
+
+// mu must be held before calling.
+func (w *watcher) markDirty(path string) {
+	if w.dirty == nil {
+		w.dirty = make(map[string]bool)
+	}
+	w.dirty[path] = true
+}
+
+func (w *watcher) sleep() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.sleeping = true
+}
+
+// wake stops buffering, and returns the files that changed while we were asleep.
+func (w *watcher) wake() []watch.FileEvent {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.sleeping = false
+
+	paths := make([]string, 0, len(w.dirty))
+	for path := range w.dirty {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	w.dirty = nil
+
+	result := make([]watch.FileEvent, 0, len(paths))
+	for _, path := range paths {
+		result = append(result, watch.NewFileEvent(path))
+	}
+	return result
+}
@@ -133,6 +133,10 @@ func (r *Reconciler) toWatchRefs(ka *v1alpha1.KubernetesApply) ([]v1alpha1.Kuber
 
 		for _, ref := range deployedRefs {
 			ns := k8s.Namespace(ref.Namespace)
+			if ns == "" {
+				// cluster-scoped objects don't need their own namespace watched
+				ns = k8s.Namespace(ka.Spec.Namespace)
+			}
 			if ns == "" {
 				// since this entity is actually deployed, don't fallback to cfgNS
 				ns = k8s.DefaultNamespace
@@ -152,7 +156,10 @@ func (r *Reconciler) toWatchRefs(ka *v1alpha1.KubernetesApply) ([]v1alpha1.Kuber
 	}
 
 	for _, e := range entities {
-		ns := k8s.Namespace(e.Meta().GetNamespace())
+		ns := k8s.Namespace(ka.Spec.Namespace)
+		if ns == "" {
+			ns = k8s.Namespace(e.Meta().GetNamespace())
+		}
 		if ns == "" {
 			ns = r.cfgNS
 		}
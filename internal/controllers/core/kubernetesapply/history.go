@@ -0,0 +1,196 @@
+package kubernetesapply
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// How many applies we remember for each KubernetesApply.
+const applyHistoryLimit = 10
+
+// How much of stderr we keep when a custom apply command fails.
+const applyHistoryStderrLimit = 4 * 1024
+
+// When true, write the YAML of every apply to a temp file, and log its path.
+type VerboseApplyFlag bool
+
+// Returns the equivalent kubectl command for applying the given objects.
+//
+// Mirrors what K8sClient.Upsert does: immutable objects are
+// deleted and re-created, everything else is applied.
+func (r *Reconciler) applyCommand(entities []k8s.K8sEntity) string {
+	mutable, immutable := k8s.MutableAndImmutableEntities(entities)
+	cmds := []string{}
+	if len(mutable) > 0 || len(immutable) == 0 {
+		cmds = append(cmds, r.kubectl("apply -f -"))
+	}
+	if len(immutable) > 0 {
+		cmds = append(cmds, r.kubectl("replace --force -f -"))
+	}
+	return strings.Join(cmds, " && ")
+}
+
+// Returns the equivalent kubectl command for deleting the given objects.
+func (r *Reconciler) deleteCommand() string {
+	return r.kubectl("delete --ignore-not-found -f -")
+}
+
+func (r *Reconciler) kubectl(args string) string {
+	if r.kubeContext == "" {
+		return fmt.Sprintf("kubectl %s", args)
+	}
+	return fmt.Sprintf("kubectl --context %s %s", r.kubeContext, args)
+}
+
+// Starts recording a call that sends the given objects to the cluster.
+func (r *Reconciler) startInvocation(ctx context.Context, command string, entities []k8s.K8sEntity) k8s.ApplyInvocation {
+	inv := k8s.ApplyInvocation{
+		Command:   command,
+		StartTime: time.Now(),
+	}
+
+	yaml, err := k8s.SerializeSpecYAML(entities)
+	if err != nil {
+		logger.Get(ctx).Debugf("Recording apply: %v", err)
+		return inv
+	}
+	inv.SetYAML(yaml, entities)
+
+	if r.verboseApply {
+		path, err := writeAppliedYAML(yaml)
+		if err != nil {
+			logger.Get(ctx).Infof("Error writing applied YAML: %v", err)
+		} else {
+			inv.YAMLPath = path
+			logger.Get(ctx).Infof("Applied YAML written to %s", path)
+		}
+	}
+	return inv
+}
+
+// Starts recording a custom apply command.
+func (r *Reconciler) startCmdInvocation(cmd model.Cmd) k8s.ApplyInvocation {
+	return k8s.ApplyInvocation{
+		Command:   cmd.String(),
+		StartTime: time.Now(),
+	}
+}
+
+// Finishes recording a call, and adds it to the history.
+func (r *Reconciler) finishInvocation(nn types.NamespacedName, inv k8s.ApplyInvocation, err error) {
+	inv.Duration = time.Since(inv.StartTime)
+	if err != nil {
+		inv.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	history := append(r.history[nn], inv)
+	if len(history) > applyHistoryLimit {
+		history = append([]k8s.ApplyInvocation{}, history[len(history)-applyHistoryLimit:]...)
+	}
+	r.history[nn] = history
+}
+
+// Returns the most recent applies and deletes for a KubernetesApply, oldest first.
+func (r *Reconciler) ApplyHistory(nn types.NamespacedName) []k8s.ApplyInvocation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]k8s.ApplyInvocation{}, r.history[nn]...)
+}
+
+func (r *Reconciler) deleteHistory(nn types.NamespacedName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.history, nn)
+	delete(r.applied, nn)
+	delete(r.applyDiff, nn)
+}
+
+// Snapshots the objects we're about to apply, and compares them to the
+// last successful apply. Returns false if there's nothing to compare to.
+func (r *Reconciler) diffApplied(ctx context.Context, nn types.NamespacedName, entities []k8s.K8sEntity) (k8s.AppliedSnapshot, model.K8sApplyDiff, bool) {
+	snapshot, err := k8s.SnapshotApplied(entities)
+	if err != nil {
+		logger.Get(ctx).Debugf("Recording applied objects: %v", err)
+		return k8s.AppliedSnapshot{}, model.K8sApplyDiff{}, false
+	}
+
+	r.mu.Lock()
+	prev, ok := r.applied[nn]
+	delete(r.applyDiff, nn)
+	r.mu.Unlock()
+	if !ok {
+		return snapshot, model.K8sApplyDiff{}, false
+	}
+	return snapshot, k8s.DiffApplied(prev, snapshot), true
+}
+
+// Remembers the objects of a successful apply, to diff the next one against.
+func (r *Reconciler) recordApplied(nn types.NamespacedName, snapshot k8s.AppliedSnapshot, diff model.K8sApplyDiff, hasDiff bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if snapshot.Len() == 0 {
+		return
+	}
+	r.applied[nn] = snapshot
+	if hasDiff {
+		r.applyDiff[nn] = diff
+	}
+}
+
+// Returns how the last successful apply of a KubernetesApply differed from
+// the one before it. Returns false if it's the first apply, or the last
+// apply failed.
+func (r *Reconciler) LastApplyDiff(nn types.NamespacedName) (model.K8sApplyDiff, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	diff, ok := r.applyDiff[nn]
+	return diff, ok
+}
+
+func logApplyDiff(ctx context.Context, diff model.K8sApplyDiff) {
+	l := logger.Get(ctx)
+	if diff.Empty() {
+		l.Infof("No changes since the last apply")
+		return
+	}
+	l.Infof("Changes since the last apply:")
+	for _, line := range k8s.FormatApplyDiff(diff) {
+		l.Infof("→ %s", line)
+	}
+}
+
+func writeAppliedYAML(yaml string) (string, error) {
+	f, err := ioutil.TempFile("", "tilt-apply-*.yaml")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	_, err = f.WriteString(yaml)
+	if err != nil {
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// Keeps the end of a stderr stream, which usually has the actual error.
+func stderrTail(stderr string) string {
+	if len(stderr) <= applyHistoryStderrLimit {
+		return stderr
+	}
+	return "..." + stderr[len(stderr)-applyHistoryStderrLimit:]
+}
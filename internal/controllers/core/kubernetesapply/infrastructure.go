@@ -0,0 +1,103 @@
+package kubernetesapply
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/logger"
+)
+
+// Splits the objects we're about to apply into the ones that need applying,
+// and infrastructure objects that are already up to date in the cluster.
+//
+// An infrastructure object is up to date if the cluster has the object, and
+// it was last applied from the same YAML. We stamp the objects we apply with
+// a hash of their YAML, so that we can tell.
+func (r *Reconciler) partitionInfrastructure(ctx context.Context, discipline v1alpha1.KubernetesApplyDiscipline, entities []k8s.K8sEntity) (toApply []k8s.K8sEntity, upToDate []k8s.K8sEntity, err error) {
+	for _, e := range entities {
+		if !k8s.IsInfrastructure(e, discipline) {
+			toApply = append(toApply, e)
+			continue
+		}
+
+		hash, err := hashInfrastructure(e)
+		if err != nil {
+			return nil, nil, err
+		}
+		e = k8s.InjectAppliedHash(e, hash)
+
+		live, err := r.getLiveMeta(ctx, e)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				toApply = append(toApply, e)
+				continue
+			}
+			return nil, nil, fmt.Errorf("fetching %s: %v", e.Name(), err)
+		}
+
+		if live.GetAnnotations()[k8s.AppliedHashAnnotation] != hash {
+			toApply = append(toApply, e)
+			continue
+		}
+
+		// We're not re-applying, so describe the object the way it
+		// was when we last applied it.
+		e.SetUID(string(live.GetUID()))
+		upToDate = append(upToDate, e)
+	}
+	return toApply, upToDate, nil
+}
+
+func (r *Reconciler) getLiveMeta(ctx context.Context, e k8s.K8sEntity) (metav1.Object, error) {
+	ref := e.ToObjectReference()
+	live, err := r.k8sClient.GetMetaByReference(ctx, ref)
+	if apierrors.IsNotFound(err) && ref.Namespace == "" {
+		// Namespaced objects without a namespace are applied to the default namespace.
+		ref.Namespace = r.cfgNS.String()
+		live, err = r.k8sClient.GetMetaByReference(ctx, ref)
+	}
+	return live, err
+}
+
+// Hashes the YAML of an infrastructure object.
+func hashInfrastructure(e k8s.K8sEntity) (string, error) {
+	w := newHashWriter()
+	err := w.append(e.Obj)
+	if err != nil {
+		return "", err
+	}
+	return w.done(), nil
+}
+
+// Reports infrastructure objects that someone else has changed in the cluster.
+//
+// We don't correct the drift, because infrastructure objects are often
+// managed by other tools too.
+func (r *Reconciler) reportInfrastructureDrift(ctx context.Context, upToDate []k8s.K8sEntity) {
+	l := logger.Get(ctx)
+	results, err := r.k8sClient.DryRunApply(ctx, upToDate)
+	if err != nil {
+		l.Debugf("Checking infrastructure for drift: %v", err)
+		return
+	}
+	diffs, err := k8s.DiffDryRunResults(results)
+	if err != nil {
+		l.Debugf("Checking infrastructure for drift: %v", err)
+		return
+	}
+
+	for _, diff := range diffs {
+		if diff.Status != k8s.ObjectDiffChanged {
+			continue
+		}
+		l.Infof("%s:%s has changed in the cluster since Tilt applied it. Leaving it as is.", diff.Kind, diff.Name)
+		for _, field := range diff.Fields {
+			l.Infof("  - %s", field.Path)
+		}
+	}
+}
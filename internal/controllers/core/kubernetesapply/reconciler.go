@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"sync"
 
 	"github.com/docker/distribution/reference"
@@ -23,6 +24,7 @@ import (
 	"github.com/tilt-dev/tilt/internal/build"
 	"github.com/tilt-dev/tilt/internal/container"
 	"github.com/tilt-dev/tilt/internal/controllers/apicmp"
+	"github.com/tilt-dev/tilt/internal/controllers/apis/debugoverride"
 	"github.com/tilt-dev/tilt/internal/controllers/apis/restarton"
 	"github.com/tilt-dev/tilt/internal/controllers/indexer"
 	"github.com/tilt-dev/tilt/internal/k8s"
@@ -46,10 +48,26 @@ type Reconciler struct {
 	indexer     *indexer.Indexer
 	execer      localexec.Execer
 
+	verboseApply VerboseApplyFlag
+
 	mu sync.Mutex
 
 	// Protected by the mutex.
 	results map[types.NamespacedName]*Result
+
+	// The debug overrides that we've asked the build engine to redeploy with.
+	// Protected by the mutex.
+	requestedDebugOverrides map[types.NamespacedName]map[string]string
+
+	// The most recent applies and deletes for each KubernetesApply, oldest first.
+	// Protected by the mutex.
+	history map[types.NamespacedName][]k8s.ApplyInvocation
+
+	// The objects each KubernetesApply sent on its last successful apply,
+	// and how they differed from the apply before that.
+	// Protected by the mutex.
+	applied   map[types.NamespacedName]k8s.AppliedSnapshot
+	applyDiff map[types.NamespacedName]model.K8sApplyDiff
 }
 
 func (r *Reconciler) CreateBuilder(mgr ctrl.Manager) (*builder.Builder, error) {
@@ -57,6 +75,8 @@ func (r *Reconciler) CreateBuilder(mgr ctrl.Manager) (*builder.Builder, error) {
 		For(&v1alpha1.KubernetesApply{}).
 		Owns(&v1alpha1.KubernetesDiscovery{}).
 		Watches(&source.Kind{Type: &v1alpha1.ImageMap{}},
+			handler.EnqueueRequestsFromMapFunc(r.indexer.Enqueue)).
+		Watches(&source.Kind{Type: &v1alpha1.ConfigMap{}},
 			handler.EnqueueRequestsFromMapFunc(r.indexer.Enqueue))
 
 	restarton.SetupController(b, r.indexer, func(obj ctrlclient.Object) (*v1alpha1.RestartOnSpec, *v1alpha1.StartOnSpec) {
@@ -67,7 +87,7 @@ func (r *Reconciler) CreateBuilder(mgr ctrl.Manager) (*builder.Builder, error) {
 	return b, nil
 }
 
-func NewReconciler(ctrlClient ctrlclient.Client, k8sClient k8s.Client, scheme *runtime.Scheme, dkc build.DockerKubeConnection, kubeContext k8s.KubeContext, st store.RStore, cfgNS k8s.Namespace, execer localexec.Execer) *Reconciler {
+func NewReconciler(ctrlClient ctrlclient.Client, k8sClient k8s.Client, scheme *runtime.Scheme, dkc build.DockerKubeConnection, kubeContext k8s.KubeContext, st store.RStore, cfgNS k8s.Namespace, execer localexec.Execer, verboseApply VerboseApplyFlag) *Reconciler {
 	return &Reconciler{
 		ctrlClient:  ctrlClient,
 		k8sClient:   k8sClient,
@@ -78,6 +98,13 @@ func NewReconciler(ctrlClient ctrlclient.Client, k8sClient k8s.Client, scheme *r
 		st:          st,
 		results:     make(map[types.NamespacedName]*Result),
 		cfgNS:       cfgNS,
+
+		verboseApply: verboseApply,
+
+		requestedDebugOverrides: make(map[types.NamespacedName]map[string]string),
+		history:                 make(map[types.NamespacedName][]k8s.ApplyInvocation),
+		applied:                 make(map[types.NamespacedName]k8s.AppliedSnapshot),
+		applyDiff:               make(map[types.NamespacedName]model.K8sApplyDiff),
 	}
 }
 
@@ -94,7 +121,8 @@ func (r *Reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 
 	if apierrors.IsNotFound(err) || !ka.ObjectMeta.DeletionTimestamp.IsZero() {
 		toDelete := r.updateResult(nn, nil)
-		r.bestEffortDelete(ctx, toDelete)
+		r.bestEffortDelete(ctx, nn, toDelete)
+		r.deleteHistory(nn)
 
 		err := r.manageOwnedKubernetesDiscovery(ctx, nn, nil)
 		if err != nil {
@@ -115,21 +143,9 @@ func (r *Reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 	}
 
 	// Fetch all the images needed to apply this YAML.
-	imageMaps := make(map[types.NamespacedName]*v1alpha1.ImageMap)
-	for _, name := range ka.Spec.ImageMaps {
-		var im v1alpha1.ImageMap
-		nn := types.NamespacedName{Name: name}
-		err := r.ctrlClient.Get(ctx, nn, &im)
-		if err != nil {
-			if apierrors.IsNotFound(err) {
-				// If the map isn't found, keep going and let shouldDeployOnReconcile
-				// handle it.
-				continue
-			}
-			return ctrl.Result{}, err
-		}
-
-		imageMaps[nn] = &im
+	imageMaps, err := r.fetchImageMaps(ctx, ka.Spec)
+	if err != nil {
+		return ctrl.Result{}, err
 	}
 
 	restartObjs, err := restarton.FetchObjects(ctx, r.ctrlClient, ka.Spec.RestartOn, nil)
@@ -137,7 +153,13 @@ func (r *Reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 		return ctrl.Result{}, err
 	}
 
-	if !r.shouldDeployOnReconcile(request.NamespacedName, &ka, imageMaps, restartObjs) {
+	debugOverride, err := debugoverride.EnabledData(ctx, r.ctrlClient, nn.Name)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	r.maybeRequestDebugOverrideRedeploy(nn, &ka, debugOverride)
+
+	if !r.shouldDeployOnReconcile(request.NamespacedName, &ka, imageMaps, restartObjs, debugOverride) {
 		// TODO(nick): Like with other reconcilers, there should always
 		// be a reason why we're not deploying, and we should update the
 		// Status field of KubernetesApply with that reason.
@@ -153,6 +175,28 @@ func (r *Reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 	return ctrl.Result{}, nil
 }
 
+// Fetch all the images needed to apply the spec.
+//
+// If an image map isn't found, it's skipped, and the caller
+// is responsible for handling it.
+func (r *Reconciler) fetchImageMaps(ctx context.Context, spec v1alpha1.KubernetesApplySpec) (map[types.NamespacedName]*v1alpha1.ImageMap, error) {
+	imageMaps := make(map[types.NamespacedName]*v1alpha1.ImageMap)
+	for _, name := range spec.ImageMaps {
+		var im v1alpha1.ImageMap
+		nn := types.NamespacedName{Name: name}
+		err := r.ctrlClient.Get(ctx, nn, &im)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		imageMaps[nn] = &im
+	}
+	return imageMaps, nil
+}
+
 // Determine if we should deploy the current YAML.
 //
 // Ensures:
@@ -160,7 +204,8 @@ func (r *Reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 // 2) Either we haven't deployed before,
 //    or one of the inputs has changed since the last deploy.
 func (r *Reconciler) shouldDeployOnReconcile(nn types.NamespacedName, ka *v1alpha1.KubernetesApply,
-	imageMaps map[types.NamespacedName]*v1alpha1.ImageMap, restartObjs restarton.Objects) bool {
+	imageMaps map[types.NamespacedName]*v1alpha1.ImageMap, restartObjs restarton.Objects,
+	debugOverride map[string]string) bool {
 	if ka.Annotations[v1alpha1.AnnotationManagedBy] != "" {
 		// Until resource dependencies are expressed in the API,
 		// we can't use reconciliation to deploy KubernetesApply objects
@@ -190,6 +235,11 @@ func (r *Reconciler) shouldDeployOnReconcile(nn types.NamespacedName, ka *v1alph
 		return true
 	}
 
+	if !apicmp.DeepEqual(debugOverride, result.DebugOverride) {
+		// The debug override was turned on or off, or changed.
+		return true
+	}
+
 	imageMapNames := ka.Spec.ImageMaps
 	if len(imageMapNames) != len(result.ImageMapSpecs) ||
 		len(imageMapNames) != len(result.ImageMapStatuses) {
@@ -215,6 +265,39 @@ func (r *Reconciler) shouldDeployOnReconcile(nn types.NamespacedName, ka *v1alph
 	return false
 }
 
+// The build engine decides when to deploy the objects it manages. So when the
+// user turns a debug override on or off, we ask the engine to redeploy.
+func (r *Reconciler) maybeRequestDebugOverrideRedeploy(nn types.NamespacedName, ka *v1alpha1.KubernetesApply, debugOverride map[string]string) {
+	manifest := ka.Annotations[v1alpha1.AnnotationManifest]
+	if ka.Annotations[v1alpha1.AnnotationManagedBy] == "" || manifest == "" || ka.Spec.YAML == "" {
+		return
+	}
+
+	r.mu.Lock()
+	result, ok := r.results[nn]
+	if !ok || result.Status.LastApplyTime.IsZero() {
+		// The first deploy will pick up the override.
+		r.mu.Unlock()
+		return
+	}
+
+	if apicmp.DeepEqual(debugOverride, result.DebugOverride) {
+		delete(r.requestedDebugOverrides, nn)
+		r.mu.Unlock()
+		return
+	}
+
+	requested, ok := r.requestedDebugOverrides[nn]
+	if ok && apicmp.DeepEqual(debugOverride, requested) {
+		r.mu.Unlock()
+		return
+	}
+	r.requestedDebugOverrides[nn] = debugOverride
+	r.mu.Unlock()
+
+	r.st.Dispatch(kubernetesapplys.NewKubernetesApplyDebugOverrideAction(model.ManifestName(manifest)))
+}
+
 // Inject the images into the YAML and apply it to the cluster, unconditionally.
 //
 // Update the apiserver when finished.
@@ -229,12 +312,18 @@ func (r *Reconciler) ForceApply(
 	spec v1alpha1.KubernetesApplySpec,
 	imageMaps map[types.NamespacedName]*v1alpha1.ImageMap) (v1alpha1.KubernetesApplyStatus, error) {
 
-	status, appliedObjects := r.forceApplyHelper(ctx, spec, imageMaps)
+	debugOverride, err := debugoverride.EnabledData(ctx, r.ctrlClient, nn.Name)
+	if err != nil {
+		return v1alpha1.KubernetesApplyStatus{}, err
+	}
+
+	status, appliedObjects := r.forceApplyHelper(ctx, nn, spec, imageMaps, debugOverride)
 	statusCopy := status.DeepCopy()
 	result := Result{
 		Spec:           spec,
 		Status:         *statusCopy,
 		AppliedObjects: newObjectRefSet(appliedObjects),
+		DebugOverride:  debugOverride,
 	}
 
 	for _, imageMapName := range spec.ImageMaps {
@@ -249,7 +338,7 @@ func (r *Reconciler) ForceApply(
 	}
 
 	var ka v1alpha1.KubernetesApply
-	err := r.ctrlClient.Get(ctx, nn, &ka)
+	err = r.ctrlClient.Get(ctx, nn, &ka)
 	if err != nil {
 		return status, err
 	}
@@ -261,11 +350,114 @@ func (r *Reconciler) ForceApply(
 	}
 
 	toDelete := r.updateResult(nn, &result)
-	r.bestEffortDelete(ctx, toDelete)
+	r.bestEffortDelete(ctx, nn, toDelete)
 
 	return status, nil
 }
 
+// Returns the status of the last apply, if applying the spec with these images
+// wouldn't change anything: the last apply succeeded with the same spec, debug
+// override, and images.
+//
+// Like ForceApply, this is a hack for the build engine, which uses it to skip
+// the apply when an image build reuses the image that's already deployed.
+func (r *Reconciler) UpToDateStatus(
+	ctx context.Context,
+	nn types.NamespacedName,
+	spec v1alpha1.KubernetesApplySpec,
+	imageMaps map[types.NamespacedName]*v1alpha1.ImageMap) (v1alpha1.KubernetesApplyStatus, bool, error) {
+
+	debugOverride, err := debugoverride.EnabledData(ctx, r.ctrlClient, nn.Name)
+	if err != nil {
+		return v1alpha1.KubernetesApplyStatus{}, false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result, ok := r.results[nn]
+	if !ok || result.Status.LastApplyTime.IsZero() || result.Status.Error != "" {
+		return v1alpha1.KubernetesApplyStatus{}, false, nil
+	}
+
+	if !apicmp.DeepEqual(spec, result.Spec) ||
+		!apicmp.DeepEqual(debugOverride, result.DebugOverride) ||
+		len(spec.ImageMaps) != len(result.ImageMapSpecs) ||
+		len(spec.ImageMaps) != len(result.ImageMapStatuses) {
+		return v1alpha1.KubernetesApplyStatus{}, false, nil
+	}
+
+	for i, name := range spec.ImageMaps {
+		im, ok := imageMaps[types.NamespacedName{Name: name}]
+		if !ok {
+			return v1alpha1.KubernetesApplyStatus{}, false, nil
+		}
+
+		// Only the image matters. The rest of the status describes how
+		// the image was built.
+		if !apicmp.DeepEqual(im.Spec, result.ImageMapSpecs[i]) ||
+			im.Status.Image != result.ImageMapStatuses[i].Image {
+			return v1alpha1.KubernetesApplyStatus{}, false, nil
+		}
+	}
+
+	return *result.Status.DeepCopy(), true, nil
+}
+
+// Previews what applying the current spec would change in the cluster,
+// with a server-side dry-run. Never modifies the cluster.
+//
+// Returns a diff for every object in the spec, including ones that wouldn't change.
+func (r *Reconciler) Diff(ctx context.Context, nn types.NamespacedName) ([]k8s.ObjectDiff, error) {
+	var ka v1alpha1.KubernetesApply
+	err := r.ctrlClient.Get(ctx, nn, &ka)
+	if err != nil {
+		return nil, err
+	}
+
+	if ka.Spec.YAML == "" {
+		return nil, fmt.Errorf("%s is deployed with a custom apply command, which can't be previewed", nn.Name)
+	}
+
+	imageMaps, err := r.fetchImageMaps(ctx, ka.Spec)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range ka.Spec.ImageMaps {
+		if _, ok := imageMaps[types.NamespacedName{Name: name}]; !ok {
+			return nil, fmt.Errorf("image %s hasn't been built yet", name)
+		}
+	}
+
+	debugOverride, err := r.fetchDebugOverride(ctx, nn)
+	if err != nil {
+		return nil, err
+	}
+
+	entities, err := r.createEntitiesToDeploy(ctx, nn, imageMaps, ka.Spec, debugOverride)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := r.k8sClient.DryRunApply(ctx, entities)
+	if err != nil {
+		return nil, err
+	}
+	return k8s.DiffDryRunResults(results)
+}
+
+// Fetches the resource's debug override, if it's enabled.
+func (r *Reconciler) fetchDebugOverride(ctx context.Context, nn types.NamespacedName) (*model.K8sDebugOverride, error) {
+	data, err := debugoverride.EnabledData(ctx, r.ctrlClient, nn.Name)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	o, err := debugoverride.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
 // A helper that applies the given specs to the cluster, but doesn't update the APIServer.
 //
 // Returns:
@@ -273,8 +465,10 @@ func (r *Reconciler) ForceApply(
 // - the parsed entities that we tried to apply
 func (r *Reconciler) forceApplyHelper(
 	ctx context.Context,
+	nn types.NamespacedName,
 	spec v1alpha1.KubernetesApplySpec,
-	imageMaps map[types.NamespacedName]*v1alpha1.ImageMap) (v1alpha1.KubernetesApplyStatus, []k8s.K8sEntity) {
+	imageMaps map[types.NamespacedName]*v1alpha1.ImageMap,
+	debugOverrideData map[string]string) (v1alpha1.KubernetesApplyStatus, []k8s.K8sEntity) {
 
 	startTime := apis.NowMicro()
 	status := v1alpha1.KubernetesApplyStatus{
@@ -294,12 +488,28 @@ func (r *Reconciler) forceApplyHelper(
 
 	var deployed []k8s.K8sEntity
 	if spec.YAML != "" {
-		deployed, err = r.runYAMLDeploy(ctx, spec, imageMaps)
+		var debugOverride *model.K8sDebugOverride
+		if debugOverrideData != nil {
+			o, err := debugoverride.Parse(debugOverrideData)
+			if err != nil {
+				return errorStatus(err), nil
+			}
+			debugOverride = &o
+		}
+
+		deployed, err = r.runYAMLDeploy(ctx, nn, spec, imageMaps, debugOverride)
 		if err != nil {
 			return errorStatus(err), nil
 		}
+		if debugOverride != nil {
+			status.DebugOverride = debugOverride.String()
+		}
 	} else {
-		deployed, err = r.runCmdDeploy(ctx, spec)
+		if debugOverrideData != nil {
+			logger.Get(ctx).Warnf("Debug overrides aren't supported for resources deployed with a custom apply command")
+		}
+
+		deployed, err = r.runCmdDeploy(ctx, nn, spec)
 		if err != nil {
 			return errorStatus(err), nil
 		}
@@ -320,39 +530,68 @@ func (r *Reconciler) forceApplyHelper(
 	return status, deployed
 }
 
-func (r *Reconciler) runYAMLDeploy(ctx context.Context, spec v1alpha1.KubernetesApplySpec, imageMaps map[types.NamespacedName]*v1alpha1.ImageMap) ([]k8s.K8sEntity, error) {
+func (r *Reconciler) runYAMLDeploy(ctx context.Context, nn types.NamespacedName, spec v1alpha1.KubernetesApplySpec, imageMaps map[types.NamespacedName]*v1alpha1.ImageMap, debugOverride *model.K8sDebugOverride) ([]k8s.K8sEntity, error) {
 	// Create API objects.
-	newK8sEntities, err := r.createEntitiesToDeploy(ctx, imageMaps, spec)
+	newK8sEntities, err := r.createEntitiesToDeploy(ctx, nn, imageMaps, spec, debugOverride)
 	if err != nil {
 		return newK8sEntities, err
 	}
 
+	toApply, upToDate, err := r.partitionInfrastructure(ctx, spec.ApplyDiscipline, newK8sEntities)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx = r.indentLogger(ctx)
 	l := logger.Get(ctx)
 
+	if len(upToDate) > 0 {
+		l.Infof("Infrastructure already applied:")
+		for _, displayName := range k8s.UniqueNames(upToDate, 2) {
+			l.Infof("→ %s", displayName)
+		}
+		r.reportInfrastructureDrift(ctx, upToDate)
+	}
+
+	if len(toApply) == 0 {
+		return upToDate, nil
+	}
+
 	l.Infof("Applying via kubectl:")
 
 	// Use a min component count of 2 for computing names,
 	// so that the resource type appears
-	displayNames := k8s.UniqueNames(newK8sEntities, 2)
+	displayNames := k8s.UniqueNames(toApply, 2)
 	for _, displayName := range displayNames {
 		l.Infof("→ %s", displayName)
 	}
 
+	snapshot, diff, hasDiff := r.diffApplied(ctx, nn, append(append([]k8s.K8sEntity{}, toApply...), upToDate...))
+	if hasDiff {
+		logApplyDiff(ctx, diff)
+	}
+
+	if debugOverride != nil {
+		l.Warnf("Debug override is on (%s). Turn it off to restore the original spec.", debugOverride)
+	}
+
 	timeout := spec.Timeout.Duration
 	if timeout == 0 {
 		timeout = v1alpha1.KubernetesApplyTimeoutDefault
 	}
 
-	deployed, err := r.k8sClient.Upsert(ctx, newK8sEntities, timeout)
+	inv := r.startInvocation(ctx, r.applyCommand(toApply), toApply)
+	deployed, err := r.k8sClient.Upsert(ctx, toApply, timeout)
+	r.finishInvocation(nn, inv, err)
 	if err != nil {
 		return nil, err
 	}
+	r.recordApplied(nn, snapshot, diff, hasDiff)
 
-	return deployed, nil
+	return append(deployed, upToDate...), nil
 }
 
-func (r *Reconciler) runCmdDeploy(ctx context.Context, spec v1alpha1.KubernetesApplySpec) ([]k8s.K8sEntity, error) {
+func (r *Reconciler) runCmdDeploy(ctx context.Context, nn types.NamespacedName, spec v1alpha1.KubernetesApplySpec) ([]k8s.K8sEntity, error) {
 	cmd := model.Cmd{
 		Argv: spec.Cmd.Args,
 		Dir:  spec.Cmd.Dir,
@@ -366,18 +605,24 @@ func (r *Reconciler) runCmdDeploy(ctx context.Context, spec v1alpha1.KubernetesA
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	var stdoutBuf bytes.Buffer
+	var stdoutBuf, stderrBuf bytes.Buffer
 	runIO := localexec.RunIO{
 		Stdout: &stdoutBuf,
-		Stderr: logger.Get(ctx).Writer(logger.InfoLvl),
+		Stderr: io.MultiWriter(logger.Get(ctx).Writer(logger.InfoLvl), &stderrBuf),
 	}
 
+	inv := r.startCmdInvocation(cmd)
 	exitCode, err := r.execer.Run(ctx, cmd, runIO)
 	if err != nil {
-		return nil, fmt.Errorf("apply command failed: %v", err)
+		err = fmt.Errorf("apply command failed: %v", err)
 	} else if exitCode != 0 {
-		return nil, fmt.Errorf("apply command exited with status %d\nstdout:\n%s\n", exitCode, stdoutBuf.String())
+		err = fmt.Errorf("apply command exited with status %d\nstdout:\n%s\n", exitCode, stdoutBuf.String())
 	}
+	if err != nil {
+		r.finishInvocation(nn, inv, fmt.Errorf("%v\nstderr:\n%s", err, stderrTail(stderrBuf.String())))
+		return nil, err
+	}
+	r.finishInvocation(nn, inv, nil)
 
 	// don't pass the bytes.Buffer directly to the YAML parser or it'll consume it and we can't print it out on failure
 	stdout := stdoutBuf.Bytes()
@@ -395,9 +640,24 @@ func (r *Reconciler) indentLogger(ctx context.Context) context.Context {
 	return logger.WithLogger(ctx, newL)
 }
 
+// Identifies the manifest and project that are applying objects,
+// so that we can find them later if the manifest goes away.
+func (r *Reconciler) managedObjectIdentity(nn types.NamespacedName) k8s.ManagedObjectIdentity {
+	state := r.st.RLockState()
+	defer r.st.RUnlockState()
+
+	id := k8s.ManagedObjectIdentity{Manifest: nn.Name}
+	if path := state.MainTiltfilePath(); path != "" {
+		id.Project = k8s.ProjectLabelValue(path)
+	}
+	return id
+}
+
 func (r *Reconciler) createEntitiesToDeploy(ctx context.Context,
+	nn types.NamespacedName,
 	imageMaps map[types.NamespacedName]*v1alpha1.ImageMap,
-	spec v1alpha1.KubernetesApplySpec) ([]k8s.K8sEntity, error) {
+	spec v1alpha1.KubernetesApplySpec,
+	debugOverride *model.K8sDebugOverride) ([]k8s.K8sEntity, error) {
 	newK8sEntities := []k8s.K8sEntity{}
 
 	entities, err := k8s.ParseYAMLFromString(spec.YAML)
@@ -405,13 +665,19 @@ func (r *Reconciler) createEntitiesToDeploy(ctx context.Context,
 		return nil, err
 	}
 
+	if spec.Namespace != "" {
+		entities = moveToNamespace(ctx, entities, k8s.Namespace(spec.Namespace))
+	}
+
 	locators, err := k8s.ParseImageLocators(spec.ImageLocators)
 	if err != nil {
 		return nil, err
 	}
 
+	id := r.managedObjectIdentity(nn)
 	imageMapNames := spec.ImageMaps
 	injectedImageMaps := map[string]bool{}
+	injectedDebugOverride := false
 	for _, e := range entities {
 		e, err = k8s.InjectLabels(e, []model.LabelPair{
 			k8s.TiltManagedByLabel(),
@@ -419,6 +685,7 @@ func (r *Reconciler) createEntitiesToDeploy(ctx context.Context,
 		if err != nil {
 			return nil, errors.Wrap(err, "deploy")
 		}
+		e = k8s.InjectManagedObjectIdentity(e, id)
 
 		// If we're redeploying these workloads in response to image
 		// changes, we make sure image pull policy isn't set to "Always".
@@ -482,6 +749,15 @@ func (r *Reconciler) createEntitiesToDeploy(ctx context.Context,
 			}
 		}
 
+		if debugOverride != nil {
+			var injected bool
+			e, injected, err = k8s.InjectDebugOverride(e, *debugOverride)
+			if err != nil {
+				return nil, err
+			}
+			injectedDebugOverride = injectedDebugOverride || injected
+		}
+
 		// This needs to be after all the other injections, to ensure the hash includes the Tilt-generated
 		// image tag, etc
 		e, err := k8s.InjectPodTemplateSpecHashes(e)
@@ -498,9 +774,34 @@ func (r *Reconciler) createEntitiesToDeploy(ctx context.Context,
 		}
 	}
 
+	if debugOverride != nil && !injectedDebugOverride {
+		if debugOverride.Container != "" {
+			return nil, fmt.Errorf("debug override: no container named %q", debugOverride.Container)
+		}
+		return nil, fmt.Errorf("debug override: no containers found")
+	}
+
 	return newK8sEntities, nil
 }
 
+// Moves the objects into the resource's namespace, and tells the user about
+// any objects that didn't move where they might have expected.
+func moveToNamespace(ctx context.Context, entities []k8s.K8sEntity, ns k8s.Namespace) []k8s.K8sEntity {
+	result, clusterScoped, conflicts := k8s.MoveToNamespace(entities, ns)
+	l := logger.Get(ctx)
+	for _, e := range conflicts {
+		l.Warnf("%s %s is hardcoded to namespace %q in its YAML; deploying it to %q instead",
+			e.GVK().Kind, e.Name(), e.Meta().GetNamespace(), ns)
+	}
+	if len(clusterScoped) > 0 {
+		l.Infof("Cluster-scoped objects aren't moved to namespace %q:", ns)
+		for _, displayName := range k8s.UniqueNames(clusterScoped, 2) {
+			l.Infof("→ %s", displayName)
+		}
+	}
+	return result
+}
+
 // We keep track of all the objects it's managing in the cluster, and
 // garbage-collect them when it no longer needs to manage them.
 //
@@ -517,6 +818,7 @@ func (r *Reconciler) updateResult(nn types.NamespacedName, result *Result) []k8s
 	existing := r.results[nn]
 	if result == nil {
 		delete(r.results, nn)
+		delete(r.requestedDebugOverrides, nn)
 	} else {
 		r.results[nn] = result
 	}
@@ -546,7 +848,7 @@ func (r *Reconciler) updateResult(nn types.NamespacedName, result *Result) []k8s
 	return toDelete
 }
 
-func (r *Reconciler) bestEffortDelete(ctx context.Context, entities []k8s.K8sEntity) {
+func (r *Reconciler) bestEffortDelete(ctx context.Context, nn types.NamespacedName, entities []k8s.K8sEntity) {
 	if len(entities) == 0 {
 		return
 	}
@@ -561,13 +863,16 @@ func (r *Reconciler) bestEffortDelete(ctx context.Context, entities []k8s.K8sEnt
 		l.Infof("→ %s", displayName)
 	}
 
+	inv := r.startInvocation(ctx, r.deleteCommand(), entities)
 	err := r.k8sClient.Delete(ctx, entities)
+	r.finishInvocation(nn, inv, err)
 	if err != nil {
 		l.Errorf("Error garbage collecting Kubernetes resources: %v", err)
 	}
 }
 
 var imGVK = v1alpha1.SchemeGroupVersion.WithKind("ImageMap")
+var cmGVK = v1alpha1.SchemeGroupVersion.WithKind("ConfigMap")
 
 // indexKubernetesApply returns keys for all the objects we need to watch based on the spec.
 func indexKubernetesApply(obj client.Object) []indexer.Key {
@@ -579,6 +884,10 @@ func indexKubernetesApply(obj client.Object) []indexer.Key {
 			GVK:  imGVK,
 		})
 	}
+	result = append(result, indexer.Key{
+		Name: types.NamespacedName{Name: debugoverride.ConfigMapName(ka.Name)},
+		GVK:  cmGVK,
+	})
 	return result
 }
 
@@ -590,6 +899,9 @@ type Result struct {
 
 	AppliedObjects objectRefSet
 	Status         v1alpha1.KubernetesApplyStatus
+
+	// The data of the debug override we applied, or nil if it was off.
+	DebugOverride map[string]string
 }
 
 type objectRef struct {
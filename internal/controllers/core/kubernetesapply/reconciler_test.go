@@ -1,9 +1,13 @@
 package kubernetesapply
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,6 +20,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/tilt-dev/tilt/internal/build"
+	"github.com/tilt-dev/tilt/internal/controllers/apis/debugoverride"
 	"github.com/tilt-dev/tilt/internal/controllers/fake"
 	"github.com/tilt-dev/tilt/internal/docker"
 	"github.com/tilt-dev/tilt/internal/dockerfile"
@@ -23,9 +28,12 @@ import (
 	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
 	"github.com/tilt-dev/tilt/internal/localexec"
 	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/store/kubernetesapplys"
 	"github.com/tilt-dev/tilt/internal/timecmp"
 	"github.com/tilt-dev/tilt/pkg/apis"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
 )
 
 func TestImageIndexing(t *testing.T) {
@@ -277,6 +285,181 @@ func TestGarbageCollectAfterErrorDuringApply(t *testing.T) {
 	}
 }
 
+func TestApplyHistoryYAML(t *testing.T) {
+	f := newFixture(t)
+	ka := v1alpha1.KubernetesApply{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "a",
+		},
+		Spec: v1alpha1.KubernetesApplySpec{
+			YAML: testyaml.SanchoTwoContainersOneImageYAML,
+		},
+	}
+	f.Create(&ka)
+	f.MustReconcile(types.NamespacedName{Name: "a"})
+
+	history := f.r.ApplyHistory(types.NamespacedName{Name: "a"})
+	require.Len(t, history, 1)
+	inv := history[0]
+	assert.Equal(t, "kubectl --context kind-kind apply -f -", inv.Command)
+	assert.Equal(t, []string{"sancho-ns"}, inv.Namespaces)
+	assert.Len(t, inv.YAMLHash, 64)
+	assert.Equal(t, len(f.kClient.Yaml), inv.YAMLBytes)
+	assert.Equal(t, "", inv.YAMLPath)
+	assert.Equal(t, "", inv.Error)
+	assert.False(t, inv.StartTime.IsZero())
+}
+
+func TestApplyHistoryYAMLError(t *testing.T) {
+	f := newFixture(t)
+	f.kClient.UpsertError = errors.New("oh no")
+
+	ka := v1alpha1.KubernetesApply{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "a",
+		},
+		Spec: v1alpha1.KubernetesApplySpec{
+			YAML: testyaml.SanchoYAML,
+		},
+	}
+	f.Create(&ka)
+	f.MustReconcile(types.NamespacedName{Name: "a"})
+
+	history := f.r.ApplyHistory(types.NamespacedName{Name: "a"})
+	require.Len(t, history, 1)
+	assert.Equal(t, "oh no", history[0].Error)
+	assert.NotZero(t, history[0].YAMLBytes)
+}
+
+func TestApplyHistoryCmdError(t *testing.T) {
+	f := newFixture(t)
+	f.execer.RegisterCommand("custom-apply-cmd", 77, "whoops", "oh no")
+
+	ka := v1alpha1.KubernetesApply{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "a",
+		},
+		Spec: v1alpha1.KubernetesApplySpec{
+			Cmd: &v1alpha1.KubernetesApplyCmd{Args: []string{"custom-apply-cmd"}},
+		},
+	}
+	f.Create(&ka)
+
+	history := f.r.ApplyHistory(types.NamespacedName{Name: "a"})
+	require.Len(t, history, 1)
+	assert.Equal(t, "custom-apply-cmd", history[0].Command)
+	assert.Equal(t, "apply command exited with status 77\nstdout:\nwhoops\n\nstderr:\noh no", history[0].Error)
+}
+
+func TestApplyHistoryGarbageCollect(t *testing.T) {
+	f := newFixture(t)
+	ka := v1alpha1.KubernetesApply{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "a",
+		},
+		Spec: v1alpha1.KubernetesApplySpec{
+			YAML: fmt.Sprintf("%s\n---\n%s\n", testyaml.SanchoYAML, testyaml.PodDisruptionBudgetYAML),
+		},
+	}
+	f.Create(&ka)
+	f.MustReconcile(types.NamespacedName{Name: "a"})
+
+	f.MustGet(types.NamespacedName{Name: "a"}, &ka)
+	ka.Spec.YAML = testyaml.SanchoYAML
+	f.Update(&ka)
+	f.MustReconcile(types.NamespacedName{Name: "a"})
+
+	history := f.r.ApplyHistory(types.NamespacedName{Name: "a"})
+	require.Len(t, history, 3)
+	assert.Equal(t, "kubectl --context kind-kind delete --ignore-not-found -f -", history[2].Command)
+
+	f.Delete(&ka)
+	assert.Empty(t, f.r.ApplyHistory(types.NamespacedName{Name: "a"}))
+}
+
+func TestApplyDiffLogged(t *testing.T) {
+	f := newFixture(t)
+	nn := types.NamespacedName{Name: "a"}
+	ka := v1alpha1.KubernetesApply{
+		ObjectMeta: metav1.ObjectMeta{Name: "a", Annotations: map[string]string{v1alpha1.AnnotationManagedBy: "buildcontrol"}},
+		Spec: v1alpha1.KubernetesApplySpec{
+			YAML: testyaml.SanchoYAML + "\n---\n" + testyaml.SecretYaml,
+		},
+	}
+	f.Create(&ka)
+
+	// Nothing to compare the first apply to.
+	_, err := f.forceApply(nn, ka.Spec)
+	require.NoError(t, err)
+	assert.NotContains(t, f.applyLogs.String(), "since the last apply")
+	_, ok := f.r.LastApplyDiff(nn)
+	assert.False(t, ok)
+
+	f.applyLogs.Reset()
+	spec := ka.Spec
+	spec.YAML = strings.Replace(testyaml.SanchoYAML, "replicas: 1", "replicas: 2", 1) + "\n---\n" + testyaml.SecretYaml
+	_, err = f.forceApply(nn, spec)
+	require.NoError(t, err)
+
+	out := f.applyLogs.String()
+	assert.Contains(t, out, "Changes since the last apply:")
+	assert.Contains(t, out, "→ Deployment/sancho: spec.replicas: 1→2")
+	assert.Contains(t, out, "→ 1 unchanged object")
+
+	diff, ok := f.r.LastApplyDiff(nn)
+	require.True(t, ok)
+	require.Len(t, diff.Objects, 1)
+	assert.Equal(t, "Deployment/sancho", diff.Objects[0].Name)
+
+	f.applyLogs.Reset()
+	_, err = f.forceApply(nn, spec)
+	require.NoError(t, err)
+	assert.Contains(t, f.applyLogs.String(), "No changes since the last apply")
+}
+
+func TestApplyHistoryLimit(t *testing.T) {
+	f := newFixture(t)
+	nn := types.NamespacedName{Name: "a"}
+	for i := 0; i < applyHistoryLimit+5; i++ {
+		f.r.finishInvocation(nn, k8s.ApplyInvocation{Command: fmt.Sprintf("apply-%d", i)}, nil)
+	}
+
+	history := f.r.ApplyHistory(nn)
+	require.Len(t, history, applyHistoryLimit)
+	assert.Equal(t, "apply-5", history[0].Command)
+	assert.Equal(t, fmt.Sprintf("apply-%d", applyHistoryLimit+4), history[applyHistoryLimit-1].Command)
+}
+
+func TestApplyHistoryVerbose(t *testing.T) {
+	f := newFixture(t)
+	f.r.verboseApply = true
+
+	ka := v1alpha1.KubernetesApply{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "a",
+		},
+		Spec: v1alpha1.KubernetesApplySpec{
+			YAML: testyaml.SanchoYAML,
+		},
+	}
+	f.Create(&ka)
+	f.MustReconcile(types.NamespacedName{Name: "a"})
+
+	history := f.r.ApplyHistory(types.NamespacedName{Name: "a"})
+	require.Len(t, history, 1)
+	path := history[0].YAMLPath
+	require.NotEqual(t, "", path)
+	defer func() {
+		_ = os.Remove(path)
+	}()
+
+	contents, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, history[0].YAMLBytes, len(contents))
+	assert.Contains(t, string(contents), "name: sancho")
+	assert.Contains(t, f.logs(), "Applied YAML written to "+path)
+}
+
 func TestRestartOn(t *testing.T) {
 	f := newFixture(t)
 
@@ -377,12 +560,357 @@ func TestIgnoreManagedObjects(t *testing.T) {
 	assert.Equal(f.T(), result, ka.Status)
 }
 
+func TestNamespaceOverride(t *testing.T) {
+	f := newFixture(t)
+	nn := types.NamespacedName{Name: "a"}
+	ka := v1alpha1.KubernetesApply{
+		ObjectMeta: metav1.ObjectMeta{Name: "a"},
+		Spec: v1alpha1.KubernetesApplySpec{
+			YAML:      testyaml.SanchoYAMLWithCommand + "---\n" + testyaml.MyNamespaceYAML,
+			Namespace: "alice-dev",
+		},
+	}
+	f.Create(&ka)
+
+	f.MustReconcile(nn)
+	assert.Contains(t, f.kClient.Yaml, "name: sancho\n  namespace: alice-dev")
+	assert.NotContains(t, f.kClient.Yaml, "sancho-ns")
+	assert.Contains(t, f.logs(), `Deployment sancho is hardcoded to namespace "sancho-ns" in its YAML; deploying it to "alice-dev" instead`)
+	assert.Contains(t, f.logs(), `Cluster-scoped objects aren't moved to namespace "alice-dev"`)
+
+	// Pods are only watched in the override namespace.
+	var kd v1alpha1.KubernetesDiscovery
+	f.MustGet(nn, &kd)
+	for _, w := range kd.Spec.Watches {
+		assert.Equal(t, "alice-dev", w.Namespace)
+	}
+}
+
+func TestDebugOverride(t *testing.T) {
+	f := newFixture(t)
+	nn := types.NamespacedName{Name: "a"}
+	ka := v1alpha1.KubernetesApply{
+		ObjectMeta: metav1.ObjectMeta{Name: "a"},
+		Spec: v1alpha1.KubernetesApplySpec{
+			YAML: testyaml.SanchoYAML,
+		},
+	}
+	f.Create(&ka)
+
+	cm := debugoverride.ToConfigMap("a", model.K8sDebugOverride{
+		Command: []string{"dlv", "exec", "/app/sancho"},
+		Ports:   []int32{2345},
+	})
+	f.Create(cm)
+
+	f.MustReconcile(nn)
+	assert.Contains(t, f.kClient.Yaml, "name: sancho")
+	assert.NotContains(t, f.kClient.Yaml, "dlv")
+	f.MustGet(nn, &ka)
+	assert.Equal(t, "", ka.Status.DebugOverride)
+
+	// Turn the override on.
+	f.MustGet(types.NamespacedName{Name: cm.Name}, cm)
+	cm.Data[debugoverride.EnabledKey] = "true"
+	f.Update(cm)
+
+	f.kClient.Yaml = ""
+	f.MustReconcile(nn)
+	assert.Contains(t, f.kClient.Yaml, "- dlv")
+	assert.Contains(t, f.kClient.Yaml, "containerPort: 2345")
+	assert.Contains(t, f.logs(), "Debug override is on")
+	f.MustGet(nn, &ka)
+	assert.Equal(t, `command=["dlv" "exec" "/app/sancho"], ports=[2345]`, ka.Status.DebugOverride)
+
+	// Re-reconciling doesn't re-apply.
+	f.kClient.Yaml = ""
+	f.MustReconcile(nn)
+	assert.Equal(t, "", f.kClient.Yaml)
+
+	// Turn the override off, and make sure we restore the original spec.
+	f.MustGet(types.NamespacedName{Name: cm.Name}, cm)
+	cm.Data[debugoverride.EnabledKey] = "false"
+	f.Update(cm)
+
+	f.MustReconcile(nn)
+	assert.Contains(t, f.kClient.Yaml, "name: sancho")
+	assert.NotContains(t, f.kClient.Yaml, "dlv")
+	assert.NotContains(t, f.kClient.Yaml, "2345")
+	f.MustGet(nn, &ka)
+	assert.Equal(t, "", ka.Status.DebugOverride)
+}
+
+func TestDebugOverrideNoMatchingContainer(t *testing.T) {
+	f := newFixture(t)
+	nn := types.NamespacedName{Name: "a"}
+	f.Create(&v1alpha1.KubernetesApply{
+		ObjectMeta: metav1.ObjectMeta{Name: "a"},
+		Spec: v1alpha1.KubernetesApplySpec{
+			YAML: testyaml.SanchoYAML,
+		},
+	})
+
+	cm := debugoverride.ToConfigMap("a", model.K8sDebugOverride{
+		Container: "nope",
+		Command:   []string{"dlv"},
+	})
+	cm.Data[debugoverride.EnabledKey] = "true"
+	f.Create(cm)
+
+	f.MustReconcile(nn)
+
+	var ka v1alpha1.KubernetesApply
+	f.MustGet(nn, &ka)
+	assert.Equal(t, `debug override: no container named "nope"`, ka.Status.Error)
+}
+
+func TestDebugOverrideManagedObjects(t *testing.T) {
+	f := newFixture(t)
+	nn := types.NamespacedName{Name: "a"}
+	ka := v1alpha1.KubernetesApply{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "a",
+			Annotations: map[string]string{
+				v1alpha1.AnnotationManagedBy: "buildcontrol",
+				v1alpha1.AnnotationManifest:  "a",
+			},
+		},
+		Spec: v1alpha1.KubernetesApplySpec{
+			YAML: testyaml.SanchoYAML,
+		},
+	}
+	f.Create(&ka)
+
+	cm := debugoverride.ToConfigMap("a", model.K8sDebugOverride{
+		Command: []string{"dlv"},
+	})
+	f.Create(cm)
+
+	_, err := f.r.ForceApply(f.Context(), nn, ka.Spec, nil)
+	require.NoError(t, err)
+	f.MustReconcile(nn)
+	assert.Empty(t, f.debugOverrideActions())
+
+	// Turning on the override asks the engine to redeploy, rather than
+	// deploying directly.
+	f.MustGet(types.NamespacedName{Name: cm.Name}, cm)
+	cm.Data[debugoverride.EnabledKey] = "true"
+	f.Update(cm)
+
+	f.kClient.Yaml = ""
+	f.MustReconcile(nn)
+	assert.Equal(t, "", f.kClient.Yaml)
+	assert.Equal(t, []kubernetesapplys.KubernetesApplyDebugOverrideAction{
+		kubernetesapplys.NewKubernetesApplyDebugOverrideAction("a"),
+	}, f.debugOverrideActions())
+
+	// Only ask once.
+	f.MustReconcile(nn)
+	assert.Len(t, f.debugOverrideActions(), 1)
+
+	_, err = f.r.ForceApply(f.Context(), nn, ka.Spec, nil)
+	require.NoError(t, err)
+	assert.Contains(t, f.kClient.Yaml, "- dlv")
+}
+
+func TestDiffPreview(t *testing.T) {
+	f := newFixture(t)
+	ka := v1alpha1.KubernetesApply{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "a",
+			Annotations: map[string]string{
+				v1alpha1.AnnotationManagedBy: "buildcontrol",
+			},
+		},
+		Spec: v1alpha1.KubernetesApplySpec{
+			YAML: testyaml.SanchoYAML + "\n---\n" + testyaml.SecretYaml,
+		},
+	}
+	f.Create(&ka)
+
+	entities, err := k8s.ParseYAMLFromString(testyaml.SanchoYAML)
+	require.NoError(t, err)
+	live, err := k8s.EntityToUnstructured(entities[0])
+	require.NoError(t, err)
+	live.SetNamespace("default")
+	f.kClient.DryRunLiveObjects = append(f.kClient.DryRunLiveObjects, live)
+
+	diffs, err := f.r.Diff(f.Context(), types.NamespacedName{Name: "a"})
+	require.NoError(t, err)
+	require.Len(t, diffs, 2)
+
+	// The live deployment doesn't have the labels that we inject.
+	assert.Equal(t, "Deployment", diffs[0].Kind)
+	assert.Equal(t, k8s.ObjectDiffChanged, diffs[0].Status)
+	assert.Contains(t, diffs[0].Unified, "+    app.kubernetes.io/managed-by: tilt")
+
+	assert.Equal(t, "Secret", diffs[1].Kind)
+	assert.Equal(t, k8s.ObjectDiffAdded, diffs[1].Status)
+	assert.NotContains(t, diffs[1].Unified, "MWYyZDFlMmU2N2Rm")
+
+	// Make sure nothing was applied.
+	assert.Empty(t, f.kClient.Yaml)
+	f.MustGet(types.NamespacedName{Name: "a"}, &ka)
+	assert.Zero(t, ka.Status.LastApplyTime)
+}
+
+func TestDiffPreviewCmdNotSupported(t *testing.T) {
+	f := newFixture(t)
+	ka := v1alpha1.KubernetesApply{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "a",
+		},
+		Spec: v1alpha1.KubernetesApplySpec{
+			Cmd: &v1alpha1.KubernetesApplyCmd{Args: []string{"custom-apply-cmd"}},
+		},
+	}
+	f.Create(&ka)
+
+	_, err := f.r.Diff(f.Context(), types.NamespacedName{Name: "a"})
+	require.EqualError(t, err, "a is deployed with a custom apply command, which can't be previewed")
+	assert.Empty(t, f.kClient.LastDryRunApply)
+}
+
+func TestDiffPreviewImageNotBuilt(t *testing.T) {
+	f := newFixture(t)
+	ka := v1alpha1.KubernetesApply{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "a",
+		},
+		Spec: v1alpha1.KubernetesApplySpec{
+			YAML:      testyaml.SanchoYAML,
+			ImageMaps: []string{"sancho-image"},
+		},
+	}
+	f.Create(&ka)
+
+	_, err := f.r.Diff(f.Context(), types.NamespacedName{Name: "a"})
+	require.EqualError(t, err, "image sancho-image hasn't been built yet")
+}
+
+const priorityClassYAML = `
+apiVersion: scheduling.k8s.io/v1
+kind: PriorityClass
+metadata:
+  name: high-priority
+value: %d
+`
+
+func TestInfrastructureAppliedOnce(t *testing.T) {
+	f := newFixture(t)
+	nn := types.NamespacedName{Name: "a"}
+	ka := v1alpha1.KubernetesApply{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "a",
+			Annotations: map[string]string{
+				v1alpha1.AnnotationManagedBy: "buildcontrol",
+			},
+		},
+		Spec: v1alpha1.KubernetesApplySpec{
+			YAML:            fmt.Sprintf(priorityClassYAML, 1000),
+			ApplyDiscipline: v1alpha1.KubernetesApplyDisciplineInfrastructure,
+		},
+	}
+	f.Create(&ka)
+
+	// The first apply creates the object, and records its hash.
+	_, err := f.forceApply(nn, ka.Spec)
+	require.NoError(t, err)
+	assert.Contains(t, f.kClient.Yaml, "name: high-priority")
+	assert.Contains(t, f.kClient.Yaml, k8s.AppliedHashAnnotation)
+	f.kClient.Inject(f.kClient.LastUpsertResult...)
+
+	// Applying the same YAML again is a no-op.
+	f.kClient.Yaml = ""
+	status, err := f.forceApply(nn, ka.Spec)
+	require.NoError(t, err)
+	assert.Empty(t, f.kClient.Yaml)
+	assert.Contains(t, f.applyLogs.String(), "Infrastructure already applied")
+	assert.Contains(t, status.ResultYAML, "name: high-priority")
+
+	// Changing the YAML re-applies it.
+	ka.Spec.YAML = fmt.Sprintf(priorityClassYAML, 2000)
+	_, err = f.forceApply(nn, ka.Spec)
+	require.NoError(t, err)
+	assert.Contains(t, f.kClient.Yaml, "value: 2000")
+}
+
+func TestInfrastructureAnnotation(t *testing.T) {
+	f := newFixture(t)
+	nn := types.NamespacedName{Name: "a"}
+	pcYAML := strings.Replace(fmt.Sprintf(priorityClassYAML, 1000), "  name: high-priority\n",
+		"  name: high-priority\n  annotations:\n    tilt.dev/apply-discipline: infrastructure\n", 1)
+	ka := v1alpha1.KubernetesApply{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "a",
+			Annotations: map[string]string{
+				v1alpha1.AnnotationManagedBy: "buildcontrol",
+			},
+		},
+		Spec: v1alpha1.KubernetesApplySpec{
+			YAML: pcYAML + "\n---\n" + testyaml.SanchoYAML,
+		},
+	}
+	f.Create(&ka)
+
+	_, err := f.forceApply(nn, ka.Spec)
+	require.NoError(t, err)
+	assert.Contains(t, f.kClient.Yaml, "name: high-priority")
+	f.kClient.Inject(f.kClient.LastUpsertResult...)
+
+	// Only the infrastructure object is skipped.
+	f.kClient.Yaml = ""
+	_, err = f.forceApply(nn, ka.Spec)
+	require.NoError(t, err)
+	assert.Contains(t, f.kClient.Yaml, "name: sancho")
+	assert.NotContains(t, f.kClient.Yaml, "name: high-priority")
+}
+
+func TestInfrastructureDriftReported(t *testing.T) {
+	f := newFixture(t)
+	nn := types.NamespacedName{Name: "a"}
+	ka := v1alpha1.KubernetesApply{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "a",
+			Annotations: map[string]string{
+				v1alpha1.AnnotationManagedBy: "buildcontrol",
+			},
+		},
+		Spec: v1alpha1.KubernetesApplySpec{
+			YAML:            fmt.Sprintf(priorityClassYAML, 1000),
+			ApplyDiscipline: v1alpha1.KubernetesApplyDisciplineInfrastructure,
+		},
+	}
+	f.Create(&ka)
+
+	_, err := f.forceApply(nn, ka.Spec)
+	require.NoError(t, err)
+	f.kClient.Inject(f.kClient.LastUpsertResult...)
+
+	// Someone else changes the object in the cluster.
+	live, err := k8s.EntityToUnstructured(f.kClient.LastUpsertResult[0])
+	require.NoError(t, err)
+	live.SetNamespace("default")
+	live.Object["value"] = int64(5)
+	f.kClient.DryRunLiveObjects = append(f.kClient.DryRunLiveObjects, live)
+
+	// We report the drift, but don't correct it.
+	f.kClient.Yaml = ""
+	_, err = f.forceApply(nn, ka.Spec)
+	require.NoError(t, err)
+	assert.Empty(t, f.kClient.Yaml)
+	assert.Contains(t, f.applyLogs.String(), "PriorityClass:high-priority has changed in the cluster since Tilt applied it. Leaving it as is.")
+	assert.Contains(t, f.applyLogs.String(), "  - value")
+}
+
 type fixture struct {
 	*fake.ControllerFixture
 	r       *Reconciler
 	kClient *k8s.FakeK8sClient
 	execer  *localexec.FakeExecer
 	st      *store.TestingStore
+
+	applyLogs bytes.Buffer
 }
 
 func newFixture(t *testing.T) *fixture {
@@ -399,7 +927,7 @@ func newFixture(t *testing.T) *fixture {
 	execer := localexec.NewFakeExecer(t)
 
 	db := build.NewDockerImageBuilder(dockerClient, dockerfile.Labels{})
-	r := NewReconciler(cfb.Client, kClient, v1alpha1.NewScheme(), db, kubeContext, st, "default", execer)
+	r := NewReconciler(cfb.Client, kClient, v1alpha1.NewScheme(), db, kubeContext, st, "default", execer, false)
 
 	return &fixture{
 		ControllerFixture: cfb.Build(r),
@@ -409,3 +937,29 @@ func newFixture(t *testing.T) *fixture {
 		st:                st,
 	}
 }
+
+func (f *fixture) debugOverrideActions() []kubernetesapplys.KubernetesApplyDebugOverrideAction {
+	var result []kubernetesapplys.KubernetesApplyDebugOverrideAction
+	for _, a := range f.st.Actions() {
+		if a, ok := a.(kubernetesapplys.KubernetesApplyDebugOverrideAction); ok {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+// Applies the spec the way buildcontrol does, capturing the logs.
+func (f *fixture) forceApply(nn types.NamespacedName, spec v1alpha1.KubernetesApplySpec) (v1alpha1.KubernetesApplyStatus, error) {
+	ctx := logger.WithLogger(f.Context(), logger.NewTestLogger(&f.applyLogs))
+	return f.r.ForceApply(ctx, nn, spec, nil)
+}
+
+func (f *fixture) logs() string {
+	var sb strings.Builder
+	for _, a := range f.st.Actions() {
+		if a, ok := a.(store.LogAction); ok {
+			sb.Write(a.Message())
+		}
+	}
+	return sb.String()
+}
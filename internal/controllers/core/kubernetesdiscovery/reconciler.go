@@ -554,6 +554,58 @@ func (w *Reconciler) handlePodDelete(ctx context.Context, namespace k8s.Namespac
 	}
 }
 
+// ResyncCluster relists the pods in every namespace we're watching, and
+// reconciles them against the pods we know about.
+//
+// Pods that haven't changed are left alone, so that a resync doesn't
+// trigger spurious status updates.
+func (w *Reconciler) ResyncCluster(ctx context.Context, _ store.RStore) error {
+	w.mu.Lock()
+	var namespaces []k8s.Namespace
+	for ns := range w.watchedNamespaces {
+		namespaces = append(namespaces, ns)
+	}
+	w.mu.Unlock()
+
+	var errs []error
+	for _, ns := range namespaces {
+		pods, err := w.kCli.ListPods(ctx, ns)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		seen := k8s.NewUIDSet()
+		var changed []*v1.Pod
+		var deleted []*v1.Pod
+		w.mu.Lock()
+		for _, pod := range pods {
+			seen.Add(pod.UID)
+			known, ok := w.knownPods[pod.UID]
+			if !ok || !equality.Semantic.DeepEqual(known, pod) {
+				changed = append(changed, pod)
+			}
+		}
+		for uid, pod := range w.knownPods {
+			if pod.Namespace == ns.String() && !seen.Contains(uid) {
+				deleted = append(deleted, pod)
+			}
+		}
+		w.mu.Unlock()
+
+		// Handle deletes first, so that a pod that was deleted and re-created
+		// with the same name doesn't get mixed up with its replacement.
+		for _, pod := range deleted {
+			w.handlePodDelete(ctx, k8s.Namespace(pod.Namespace), pod.Name)
+		}
+		for _, pod := range changed {
+			w.upsertPod(pod)
+			w.handlePodChange(ctx, pod)
+		}
+	}
+	return errorutil.NewAggregate(errs)
+}
+
 func (w *Reconciler) manageOwnedObjects(ctx context.Context, nn types.NamespacedName, kd *v1alpha1.KubernetesDiscovery) error {
 	if err := w.manageOwnedPodLogStreams(ctx, nn, kd); err != nil {
 		return err
@@ -643,6 +695,7 @@ func (w *Reconciler) createPodLogStream(ctx context.Context, kd *v1alpha1.Kubern
 			SinceTime:        plsTemplate.SinceTime,
 			IgnoreContainers: plsTemplate.IgnoreContainers,
 			OnlyContainers:   plsTemplate.OnlyContainers,
+			LogLevelFormat:   plsTemplate.LogLevelFormat,
 		},
 	}
 
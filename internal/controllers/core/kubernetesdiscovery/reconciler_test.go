@@ -18,6 +18,7 @@ import (
 
 	"github.com/tilt-dev/tilt/internal/container"
 	"github.com/tilt-dev/tilt/internal/controllers/fake"
+	"github.com/tilt-dev/tilt/internal/engine/k8swatch"
 	"github.com/tilt-dev/tilt/internal/k8s"
 	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/internal/testutils"
@@ -279,6 +280,7 @@ func TestReconcileCreatesPodLogStream(t *testing.T) {
 			string(container.IstioInitContainerName),
 			string(container.IstioSidecarContainerName),
 		},
+		LogLevelFormat: "zap",
 	}
 
 	kd := &v1alpha1.KubernetesDiscovery{
@@ -330,6 +332,7 @@ func TestReconcileCreatesPodLogStream(t *testing.T) {
 			pls.Spec.IgnoreContainers)
 
 		assert.Empty(t, pls.Spec.OnlyContainers)
+		assert.Equal(t, "zap", pls.Spec.LogLevelFormat)
 	}
 
 	// simulate a pod delete and ensure that after it's observed + reconciled, the PLS is also deleted
@@ -352,6 +355,63 @@ func TestReconcileCreatesPodLogStream(t *testing.T) {
 	assert.Equal(t, "pod2", podLogStreams.Items[0].Spec.Pod)
 }
 
+func TestResyncClusterAfterWatchGap(t *testing.T) {
+	f := newFixture(t)
+
+	ns := k8s.Namespace("ns")
+	_, rs := f.simulateDeployment(ns, "dep")
+
+	key := types.NamespacedName{Namespace: "some-ns", Name: "kd"}
+	kd := &v1alpha1.KubernetesDiscovery{
+		ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+		Spec: v1alpha1.KubernetesDiscoverySpec{
+			Watches: []v1alpha1.KubernetesWatchRef{
+				{
+					UID:       string(rs.UID),
+					Namespace: ns.String(),
+					Name:      rs.Name,
+				},
+			},
+		},
+	}
+	f.Create(kd)
+	f.requireMonitorStarted(key)
+
+	pod1 := f.buildPod(ns, "pod1", nil, rs)
+	pod2 := f.buildPod(ns, "pod2", nil, rs)
+	f.kClient.UpsertPod(pod1)
+	f.kClient.UpsertPod(pod2)
+	f.requireObservedPods(key, ancestorMap{pod1.UID: rs.UID, pod2.UID: rs.UID})
+
+	// While the watch isn't delivering events, pod1 crashes, pod2 goes away,
+	// and pod3 replaces it.
+	f.kClient.StartWatchGap()
+	crashed := pod1.DeepCopy()
+	crashed.Status.Phase = v1.PodFailed
+	f.kClient.UpsertPod(crashed)
+	f.kClient.EmitPodDelete(pod2)
+	pod3 := f.buildPod(ns, "pod3", nil, rs)
+	f.kClient.UpsertPod(pod3)
+	f.kClient.EndWatchGap()
+
+	require.NoError(t, f.pw.ResyncCluster(f.ctx, f.store))
+
+	f.requireObservedPods(key, ancestorMap{pod1.UID: rs.UID, pod3.UID: rs.UID})
+	f.requireState(key, func(kd *v1alpha1.KubernetesDiscovery) bool {
+		for _, p := range kd.Status.Pods {
+			if p.Name == "pod1" {
+				return p.Phase == string(v1.PodFailed)
+			}
+		}
+		return false
+	}, "pod1 phase was not corrected after resync")
+
+	// A second resync finds nothing new, so it shouldn't update anything.
+	updateCount := f.updateStatusActionCount()
+	require.NoError(t, f.pw.ResyncCluster(f.ctx, f.store))
+	assert.Equal(t, updateCount, f.updateStatusActionCount())
+}
+
 type fixture struct {
 	*fake.ControllerFixture
 	t       *testing.T
@@ -435,6 +495,16 @@ func (f *fixture) requireObservedPods(key types.NamespacedName, expected ancesto
 	}, "Expected Pods were not observed for key[%s]: %s", key, &desc)
 }
 
+func (f *fixture) updateStatusActionCount() int {
+	count := 0
+	for _, a := range f.store.Actions() {
+		if _, ok := a.(k8swatch.KubernetesDiscoveryUpdateStatusAction); ok {
+			count++
+		}
+	}
+	return count
+}
+
 func (f *fixture) requireState(key types.NamespacedName, cond func(kd *v1alpha1.KubernetesDiscovery) bool, msg string, args ...interface{}) {
 	f.t.Helper()
 	require.Eventuallyf(f.t, func() bool {
@@ -22,6 +22,7 @@ import (
 
 	"github.com/docker/distribution/reference"
 
+	"github.com/tilt-dev/tilt/internal/agent"
 	"github.com/tilt-dev/tilt/internal/build"
 	"github.com/tilt-dev/tilt/internal/container"
 	"github.com/tilt-dev/tilt/internal/containerupdate"
@@ -58,8 +59,11 @@ type Reconciler struct {
 
 	ExecUpdater   containerupdate.ContainerUpdater
 	DockerUpdater containerupdate.ContainerUpdater
+	AgentUpdater  containerupdate.ContainerUpdater
+	agents        *agent.Installer
 	updateMode    liveupdates.UpdateMode
 	kubeContext   k8s.KubeContext
+	nodeRuntimes  k8s.NodeRuntimeSource
 	startedTime   metav1.MicroTime
 
 	monitors map[string]*monitor
@@ -75,15 +79,21 @@ func NewReconciler(
 	st store.RStore,
 	dcu *containerupdate.DockerUpdater,
 	ecu *containerupdate.ExecUpdater,
+	acu *containerupdate.AgentUpdater,
+	agents *agent.Installer,
 	updateMode liveupdates.UpdateMode,
 	kubeContext k8s.KubeContext,
+	kCli k8s.Client,
 	client ctrlclient.Client,
 	scheme *runtime.Scheme) *Reconciler {
 	return &Reconciler{
 		DockerUpdater: dcu,
 		ExecUpdater:   ecu,
+		AgentUpdater:  acu,
+		agents:        agents,
 		updateMode:    updateMode,
 		kubeContext:   kubeContext,
+		nodeRuntimes:  kCli,
 		client:        client,
 		indexer:       indexer.NewIndexer(scheme, indexLiveUpdate),
 		store:         st,
@@ -101,6 +111,7 @@ func NewFakeReconciler(
 	return &Reconciler{
 		DockerUpdater: cu,
 		ExecUpdater:   cu,
+		AgentUpdater:  cu,
 		updateMode:    liveupdates.UpdateModeAuto,
 		kubeContext:   k8s.KubeContext("fake-context"),
 		client:        client,
@@ -567,6 +578,7 @@ func (r *Reconciler) maybeSync(ctx context.Context, lu *v1alpha1.LiveUpdate, mon
 			ContainerName: container.Name(cInfo.Name),
 			PodID:         k8s.PodID(pod.Name),
 			Namespace:     k8s.Namespace(pod.Namespace),
+			NodeName:      pod.NodeName,
 		}
 		cKey := monitorContainerKey{
 			containerID: cInfo.ID,
@@ -816,7 +828,6 @@ func (r *Reconciler) applyInternal(
 	input Input) v1alpha1.LiveUpdateStatus {
 
 	var result v1alpha1.LiveUpdateStatus
-	cu := r.containerUpdater(input)
 	l := logger.Get(ctx)
 	containers := input.Containers
 	names := liveupdates.ContainerDisplayNames(containers)
@@ -828,7 +839,19 @@ func (r *Reconciler) applyInternal(
 	runSteps := liveupdate.RunSteps(spec)
 	changedFiles := input.ChangedFiles
 	hotReload := !liveupdate.ShouldRestart(spec)
-	boiledSteps, err := build.BoilRuns(runSteps, changedFiles)
+	var boiledSteps []model.Cmd
+	var err error
+	if len(spec.RestartPaths) == 0 {
+		boiledSteps, err = build.BoilRuns(runSteps, changedFiles)
+	} else {
+		var boiled build.BoiledRuns
+		boiled, err = build.BoilRunsWithRestarts(runSteps, liveupdate.RestartOnlyFiles(spec), changedFiles)
+		if err == nil {
+			l.Infof("%s", boiled.Summary)
+			boiledSteps = boiled.Cmds
+			hotReload = hotReload && !boiled.Restart
+		}
+	}
 	if err != nil {
 		result.Failed = &v1alpha1.LiveUpdateStateFailed{
 			Reason:  "Invalid",
@@ -863,9 +886,18 @@ func (r *Reconciler) applyInternal(
 
 	var lastExecErrorStatus *v1alpha1.LiveUpdateContainerStatus
 	for _, cInfo := range containers {
+		cu := r.containerUpdater(ctx, input, cInfo)
 		archive := build.TarArchiveForPaths(ctx, toArchive, nil)
 		err = cu.UpdateContainer(ctx, cInfo, archive,
 			build.PathMappingsToContainerPaths(toRemove), boiledSteps, hotReload)
+		if err != nil && r.agents != nil && cu == r.ExecUpdater && agent.IsNetworkError(err) {
+			logger.Get(ctx).Infof("Can't reach pod %s directly (%v). Updating through the in-cluster agent instead.",
+				cInfo.PodID, err)
+			r.agents.SetPreferred()
+			archive = build.TarArchiveForPaths(ctx, toArchive, nil)
+			err = r.AgentUpdater.UpdateContainer(ctx, cInfo, archive,
+				build.PathMappingsToContainerPaths(toRemove), boiledSteps, hotReload)
+		}
 
 		lastFileTimeSynced := input.LastFileTimeSynced
 		if lastFileTimeSynced.IsZero() {
@@ -916,24 +948,68 @@ func (r *Reconciler) applyInternal(
 	return result
 }
 
-func (r *Reconciler) containerUpdater(input Input) containerupdate.ContainerUpdater {
+// Picks how to update one container.
+//
+// We decide for each container, rather than once for the whole cluster,
+// because the nodes of a cluster may not all use the same container runtime,
+// and we can only update a container with Docker if its node runs Docker.
+func (r *Reconciler) containerUpdater(ctx context.Context, input Input, c liveupdates.Container) containerupdate.ContainerUpdater {
+	// The user can switch update modes while Tilt is running.
+	updateMode := liveupdates.CurrentUpdateMode(r.store, r.updateMode)
+
 	isDC := input.IsDC
-	if isDC || r.updateMode == liveupdates.UpdateModeContainer {
+	if isDC {
 		return r.DockerUpdater
 	}
 
-	if r.updateMode == liveupdates.UpdateModeKubectlExec {
-		return r.ExecUpdater
+	if updateMode == liveupdates.UpdateModeAgent {
+		return r.AgentUpdater
+	}
+
+	if updateMode == liveupdates.UpdateModeKubectlExec {
+		return r.execUpdater()
+	}
+
+	if updateMode == liveupdates.UpdateModeContainer {
+		if !r.nodeRunsDocker(ctx, c) {
+			logger.Get(ctx).Infof("Node %s of pod %s doesn't use the Docker runtime. Updating with kubectl exec instead.",
+				c.NodeName, c.PodID)
+			return r.execUpdater()
+		}
+		return r.DockerUpdater
 	}
 
 	dcu, ok := r.DockerUpdater.(*containerupdate.DockerUpdater)
-	if ok && dcu.WillBuildToKubeContext(r.kubeContext) {
+	if ok && dcu.WillBuildToKubeContext(r.kubeContext) && r.nodeRunsDocker(ctx, c) {
 		return r.DockerUpdater
 	}
 
+	return r.execUpdater()
+}
+
+// Once `kubectl exec` has failed to reach a pod, we go straight to the
+// in-cluster agent, rather than waiting for every update to time out.
+func (r *Reconciler) execUpdater() containerupdate.ContainerUpdater {
+	if r.agents != nil && r.agents.Preferred() {
+		return r.AgentUpdater
+	}
 	return r.ExecUpdater
 }
 
+// Returns false only if we know that the container's node uses a runtime
+// other than Docker. If we can't tell, we assume the node matches the cluster.
+func (r *Reconciler) nodeRunsDocker(ctx context.Context, c liveupdates.Container) bool {
+	if r.nodeRuntimes == nil || c.NodeName == "" {
+		return true
+	}
+
+	switch r.nodeRuntimes.NodeContainerRuntime(ctx, c.NodeName) {
+	case container.RuntimeDocker, container.RuntimeUnknown, container.RuntimeReadFailure:
+		return true
+	}
+	return false
+}
+
 func (r *Reconciler) CreateBuilder(mgr ctrl.Manager) (*builder.Builder, error) {
 	b := ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.LiveUpdate{}).
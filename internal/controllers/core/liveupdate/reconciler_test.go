@@ -2,6 +2,7 @@ package liveupdate
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -13,13 +14,17 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	"github.com/tilt-dev/tilt/internal/agent"
 	"github.com/tilt-dev/tilt/internal/container"
 	"github.com/tilt-dev/tilt/internal/containerupdate"
 	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
 	"github.com/tilt-dev/tilt/internal/controllers/apis/liveupdate"
 	"github.com/tilt-dev/tilt/internal/controllers/fake"
+	"github.com/tilt-dev/tilt/internal/docker"
+	"github.com/tilt-dev/tilt/internal/k8s"
 	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/internal/store/buildcontrols"
+	"github.com/tilt-dev/tilt/internal/store/liveupdates"
 	"github.com/tilt-dev/tilt/pkg/apis"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
 	"github.com/tilt-dev/tilt/pkg/logger"
@@ -140,6 +145,47 @@ func TestConsumeFileEvents(t *testing.T) {
 	}
 }
 
+func TestConsumeFileEventsRestartOnly(t *testing.T) {
+	f := newFixture(t)
+
+	p, _ := os.Getwd()
+	nowMicro := apis.NowMicro()
+	htmlPath := filepath.Join(p, "templates", "index.html")
+	reqPath := filepath.Join(p, "requirements.txt")
+
+	f.setupFrontend()
+
+	var lu v1alpha1.LiveUpdate
+	f.MustGet(types.NamespacedName{Name: "frontend-liveupdate"}, &lu)
+	lu.Spec.RestartPaths = []string{"templates"}
+	lu.Spec.Execs = []v1alpha1.LiveUpdateExec{
+		{Args: []string{"echo", "hi"}},
+		{Args: []string{"pip", "install"}, TriggerPaths: []string{"requirements.txt"}},
+	}
+	f.Update(&lu)
+
+	// A template change skips the execs, and restarts the container.
+	f.addFileEvent("frontend-fw", htmlPath, metav1.MicroTime{Time: nowMicro.Add(time.Second)})
+	f.MustReconcile(types.NamespacedName{Name: "frontend-liveupdate"})
+	if assert.Equal(t, 1, len(f.cu.Calls)) {
+		assert.Empty(t, f.cu.Calls[0].Cmds)
+		assert.False(t, f.cu.Calls[0].HotReload)
+	}
+	assert.Contains(t, f.Stdout(), "1 file: 1 sync+restart")
+
+	// A change that triggers an exec runs the execs, as usual.
+	f.addFileEvent("frontend-fw", reqPath, metav1.MicroTime{Time: nowMicro.Add(2 * time.Second)})
+	f.MustReconcile(types.NamespacedName{Name: "frontend-liveupdate"})
+	if assert.Equal(t, 2, len(f.cu.Calls)) {
+		assert.Equal(t, []model.Cmd{
+			{Argv: []string{"echo", "hi"}},
+			{Argv: []string{"pip", "install"}},
+		}, f.cu.Calls[1].Cmds)
+		assert.True(t, f.cu.Calls[1].HotReload)
+	}
+	assert.Contains(t, f.Stdout(), "1 file: 1 triggered 'pip install'")
+}
+
 func TestConsumeFileEventsUpdateModeManual(t *testing.T) {
 	f := newFixture(t)
 
@@ -474,6 +520,84 @@ func TestCrashLoopBackoff(t *testing.T) {
 	}
 }
 
+func TestContainerUpdaterMixedRuntimeCluster(t *testing.T) {
+	f := newFixture(t)
+	f.setupMixedRuntimeCluster()
+
+	dockerPod := liveupdates.Container{PodID: "pod-a", NodeName: "node-docker"}
+	containerdPod := liveupdates.Container{PodID: "pod-b", NodeName: "node-containerd"}
+	newNodePod := liveupdates.Container{PodID: "pod-c", NodeName: "node-new"}
+
+	assert.Same(t, f.r.DockerUpdater, f.r.containerUpdater(f.Context(), Input{}, dockerPod))
+	assert.Same(t, f.r.ExecUpdater, f.r.containerUpdater(f.Context(), Input{}, containerdPod))
+
+	// If we don't know the node's runtime, assume it matches the cluster.
+	assert.Same(t, f.r.DockerUpdater, f.r.containerUpdater(f.Context(), Input{}, newNodePod))
+}
+
+func TestContainerUpdaterMixedRuntimeClusterContainerMode(t *testing.T) {
+	f := newFixture(t)
+	f.setupMixedRuntimeCluster()
+	f.r.updateMode = liveupdates.UpdateModeContainer
+
+	dockerPod := liveupdates.Container{PodID: "pod-a", NodeName: "node-docker"}
+	containerdPod := liveupdates.Container{PodID: "pod-b", NodeName: "node-containerd"}
+
+	assert.Same(t, f.r.DockerUpdater, f.r.containerUpdater(f.Context(), Input{}, dockerPod))
+	assert.Same(t, f.r.ExecUpdater, f.r.containerUpdater(f.Context(), Input{}, containerdPod))
+}
+
+func TestFallBackToAgentOnNetworkError(t *testing.T) {
+	f := newFixture(t)
+	acu := f.setupAgent()
+	f.cu.SetUpdateErr(fmt.Errorf("error dialing backend: dial tcp 10.0.0.5:10250: i/o timeout"))
+
+	p, _ := os.Getwd()
+	f.setupFrontend()
+	f.addFileEvent("frontend-fw", filepath.Join(p, "a.txt"), metav1.MicroTime{Time: apis.NowMicro().Add(time.Second)})
+	f.MustReconcile(types.NamespacedName{Name: "frontend-liveupdate"})
+
+	var lu v1alpha1.LiveUpdate
+	f.MustGet(types.NamespacedName{Name: "frontend-liveupdate"}, &lu)
+	assert.Nil(t, lu.Status.Failed)
+	assert.Equal(t, 1, len(f.cu.Calls))
+	assert.Equal(t, 1, len(acu.Calls))
+
+	// Once exec has failed, go straight to the agent.
+	assert.Same(t, f.r.AgentUpdater, f.r.containerUpdater(f.Context(), Input{}, liveupdates.Container{PodID: "pod-a"}))
+}
+
+func TestNoAgentFallbackOnOtherErrors(t *testing.T) {
+	f := newFixture(t)
+	acu := f.setupAgent()
+	f.cu.SetUpdateErr(fmt.Errorf("tar: permission denied"))
+
+	p, _ := os.Getwd()
+	f.setupFrontend()
+	f.addFileEvent("frontend-fw", filepath.Join(p, "a.txt"), metav1.MicroTime{Time: apis.NowMicro().Add(time.Second)})
+	f.MustReconcile(types.NamespacedName{Name: "frontend-liveupdate"})
+
+	var lu v1alpha1.LiveUpdate
+	f.MustGet(types.NamespacedName{Name: "frontend-liveupdate"}, &lu)
+	if assert.NotNil(t, lu.Status.Failed) {
+		assert.Contains(t, lu.Status.Failed.Message, "permission denied")
+	}
+	assert.Equal(t, 0, len(acu.Calls))
+	assert.False(t, f.r.agents.Preferred())
+	assert.Same(t, f.r.ExecUpdater, f.r.containerUpdater(f.Context(), Input{}, liveupdates.Container{PodID: "pod-a"}))
+}
+
+func TestContainerUpdaterAgentMode(t *testing.T) {
+	f := newFixture(t)
+	f.setupAgent()
+	f.r.updateMode = liveupdates.UpdateModeAgent
+
+	assert.Same(t, f.r.AgentUpdater, f.r.containerUpdater(f.Context(), Input{}, liveupdates.Container{PodID: "pod-a"}))
+
+	// Docker Compose services aren't in the cluster, so the agent can't help.
+	assert.Same(t, f.r.DockerUpdater, f.r.containerUpdater(f.Context(), Input{IsDC: true}, liveupdates.Container{PodID: "pod-a"}))
+}
+
 type TestingStore struct {
 	*store.TestingStore
 	ctx                 context.Context
@@ -519,6 +643,35 @@ func newFixture(t testing.TB) *fixture {
 	}
 }
 
+// Simulates a cluster that's migrating from docker to containerd,
+// and that Tilt builds to, so live update can use Docker on docker nodes.
+func (f *fixture) setupMixedRuntimeCluster() {
+	ecu := &containerupdate.FakeContainerUpdater{}
+	dCli := docker.NewFakeClient()
+	dCli.FakeEnv = docker.Env{BuildToKubeContexts: []string{string(f.r.kubeContext)}}
+	f.r.DockerUpdater = containerupdate.NewDockerUpdater(dCli)
+	f.r.ExecUpdater = ecu
+
+	kCli := k8s.NewFakeK8sClient(f.T())
+	kCli.Runtime = container.RuntimeMixed
+	kCli.NodeRuntimes = map[string]container.Runtime{
+		"node-docker":     container.RuntimeDocker,
+		"node-containerd": container.RuntimeContainerd,
+		"node-new":        container.RuntimeUnknown,
+	}
+	f.r.nodeRuntimes = kCli
+}
+
+// Gives the reconciler a separate updater for the in-cluster agent,
+// and returns it.
+func (f *fixture) setupAgent() *containerupdate.FakeContainerUpdater {
+	acu := &containerupdate.FakeContainerUpdater{}
+	f.r.DockerUpdater = &containerupdate.FakeContainerUpdater{}
+	f.r.AgentUpdater = acu
+	f.r.agents = agent.NewInstaller(k8s.NewFakeK8sClient(f.T()), nil, model.TiltBuild{Version: "0.30.0"})
+	return acu
+}
+
 func (f *fixture) addFileEvent(name string, p string, time metav1.MicroTime) {
 	var fw v1alpha1.FileWatch
 	f.MustGet(types.NamespacedName{Name: name}, &fw)
@@ -19,7 +19,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	"github.com/tilt-dev/tilt/internal/agent"
 	"github.com/tilt-dev/tilt/internal/container"
+	"github.com/tilt-dev/tilt/internal/engine/idle"
 	"github.com/tilt-dev/tilt/internal/engine/runtimelog"
 	"github.com/tilt-dev/tilt/internal/k8s"
 	"github.com/tilt-dev/tilt/internal/store"
@@ -38,6 +40,7 @@ type Controller struct {
 	client    ctrlclient.Client
 	st        store.RStore
 	kClient   k8s.Client
+	agents    *agent.Installer
 	podSource *PodSource
 	mu        sync.Mutex
 
@@ -45,6 +48,10 @@ type Controller struct {
 	hasClosedStream map[podLogKey]bool
 	statuses        map[types.NamespacedName]*PodLogStreamStatus
 	lastUpdate      map[types.NamespacedName]*PodLogStreamStatus
+	logBudget       *globalLogBudget
+
+	// Set while Tilt is asleep. See internal/engine/idle.
+	sleeping bool
 
 	newTicker func(d time.Duration) *time.Ticker
 	since     func(t time.Time) time.Duration
@@ -53,18 +60,21 @@ type Controller struct {
 
 var _ reconcile.Reconciler = &Controller{}
 var _ store.TearDowner = &Controller{}
+var _ idle.Sleeper = &Controller{}
 
-func NewController(ctx context.Context, client ctrlclient.Client, st store.RStore, kClient k8s.Client, podSource *PodSource) *Controller {
+func NewController(ctx context.Context, client ctrlclient.Client, st store.RStore, kClient k8s.Client, podSource *PodSource, agents *agent.Installer) *Controller {
 	return &Controller{
 		ctx:             ctx,
 		client:          client,
 		st:              st,
 		kClient:         kClient,
+		agents:          agents,
 		podSource:       podSource,
 		watches:         make(map[podLogKey]PodLogWatch),
 		hasClosedStream: make(map[podLogKey]bool),
 		statuses:        make(map[types.NamespacedName]*PodLogStreamStatus),
 		lastUpdate:      make(map[types.NamespacedName]*PodLogStreamStatus),
+		logBudget:       newGlobalLogBudget(),
 		newTicker:       time.NewTicker,
 		since:           time.Since,
 		now:             time.Now,
@@ -164,6 +174,21 @@ func (r *Controller) Reconcile(ctx context.Context, req reconcile.Request) (reco
 	containers = append(containers, runContainers...)
 	r.ensureStatus(streamName, containers)
 
+	settings := r.st.RLockState().UpdateSettings
+	r.st.RUnlockState()
+	r.logBudget.setLimit(settings.PodLogTotalRateLimit)
+
+	if r.isSleeping() {
+		// Close the streams, but keep track of them, so that when we wake up,
+		// they pick up where they left off.
+		for key, watch := range r.watches {
+			if key.streamName == streamName {
+				watch.cancel()
+			}
+		}
+		return reconcile.Result{}, nil
+	}
+
 	containerWatches := make(map[podLogKey]bool)
 	for i, c := range containers {
 		// Key the log watcher by the container id, so we auto-restart the
@@ -232,6 +257,8 @@ func (r *Controller) Reconcile(ctx context.Context, req reconcile.Request) (reco
 			startWatchTime:  startWatchTime,
 			terminationTime: make(chan time.Time, 1),
 			shouldPrefix:    shouldPrefix,
+			levelFormat:     logger.LevelFormat(stream.Spec.LogLevelFormat),
+			rateLimit:       settings.PodLogRateLimit,
 		}
 		r.watches[key] = w
 
@@ -251,6 +278,45 @@ func (r *Controller) Reconcile(ctx context.Context, req reconcile.Request) (reco
 	return reconcile.Result{}, nil
 }
 
+// ResyncCluster re-reconciles every stream, so that any log streams that
+// died while we couldn't reach the cluster get restarted where they left off.
+func (r *Controller) ResyncCluster(ctx context.Context, _ store.RStore) error {
+	var list v1alpha1.PodLogStreamList
+	err := r.client.List(ctx, &list)
+	if err != nil {
+		return err
+	}
+
+	names := make([]types.NamespacedName, 0, len(list.Items))
+	for _, pls := range list.Items {
+		names = append(names, types.NamespacedName{Namespace: pls.Namespace, Name: pls.Name})
+	}
+	r.podSource.requeue(names)
+	return nil
+}
+
+// Sleep closes all the log streams.
+func (r *Controller) Sleep(ctx context.Context, st store.RStore) error {
+	r.mu.Lock()
+	r.sleeping = true
+	r.mu.Unlock()
+	return r.ResyncCluster(ctx, st)
+}
+
+// Wake restarts the log streams from where they were closed.
+func (r *Controller) Wake(ctx context.Context, st store.RStore) error {
+	r.mu.Lock()
+	r.sleeping = false
+	r.mu.Unlock()
+	return r.ResyncCluster(ctx, st)
+}
+
+func (r *Controller) isSleeping() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.sleeping
+}
+
 // Delete all the streams generated by the named API object
 func (c *Controller) deleteStreams(streamName types.NamespacedName) {
 	for k, watch := range c.watches {
@@ -266,6 +332,34 @@ func (c *Controller) deleteStreams(streamName types.NamespacedName) {
 	c.mu.Unlock()
 }
 
+// Streams logs from the cluster, through the in-cluster agent if we
+// can't reach the pod directly.
+func (m *Controller) containerLogs(ctx context.Context, pID k8s.PodID, cName container.Name, ns k8s.Namespace, startTime time.Time) (io.ReadCloser, error) {
+	if m.agents != nil && m.agents.Preferred() {
+		return m.agentContainerLogs(ctx, pID, cName, ns, startTime)
+	}
+
+	readCloser, err := m.kClient.ContainerLogs(ctx, pID, cName, ns, startTime)
+	if err != nil && m.agents != nil && agent.IsNetworkError(err) {
+		logger.Get(ctx).Infof("Can't reach pod %s directly (%v). Streaming logs through the in-cluster agent instead.", pID, err)
+		m.agents.SetPreferred()
+		return m.agentContainerLogs(ctx, pID, cName, ns, startTime)
+	}
+	return readCloser, err
+}
+
+func (m *Controller) agentContainerLogs(ctx context.Context, pID k8s.PodID, cName container.Name, ns k8s.Namespace, startTime time.Time) (io.ReadCloser, error) {
+	client, err := m.agents.Client(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+	readCloser, err := client.ContainerLogs(ctx, pID, cName, ns, startTime)
+	if err != nil {
+		m.agents.Forget(ns)
+	}
+	return readCloser, err
+}
+
 func (m *Controller) consumeLogs(watch PodLogWatch, st store.RStore) {
 	pID := watch.podID
 	ctx := watch.ctx
@@ -301,11 +395,25 @@ func (m *Controller) consumeLogs(watch PodLogWatch, st store.RStore) {
 		ctx = logger.WithLogger(ctx, logger.NewPrefixedLogger(prefix, logger.Get(ctx)))
 	}
 
+	// The limiter outlives reconnects, so that a noisy container can't
+	// reset its budget by stalling.
+	out := logger.NewLevelFormatWriter(logger.Get(ctx), watch.levelFormat, logger.InfoLvl)
+	limiter := newLogRateLimiter(out, watch.rateLimit, m.logBudget, m.now, func(total int64) {
+		m.mutateStatus(watch.streamName, containerName, func(cs *ContainerLogStreamStatus) {
+			cs.RateLimited = true
+			cs.DroppedLines = total
+		})
+		m.updateStatus(watch.streamName)
+	})
+	defer func() {
+		_ = limiter.Flush()
+	}()
+
 	retry := true
 	for retry {
 		retry = false
 		ctx, cancel := context.WithCancel(ctx)
-		readCloser, err := m.kClient.ContainerLogs(ctx, pID, containerName, ns, startReadTime)
+		readCloser, err := m.containerLogs(ctx, pID, containerName, ns, startReadTime)
 		if err != nil {
 			if ctx.Err() == nil {
 				exitError = err
@@ -358,7 +466,7 @@ func (m *Controller) consumeLogs(watch PodLogWatch, st store.RStore) {
 		})
 		m.updateStatus(watch.streamName)
 
-		_, err = io.Copy(logger.Get(ctx).Writer(logger.InfoLvl), reader)
+		_, err = io.Copy(limiter, reader)
 		_ = readCloser.Close()
 		close(done)
 
@@ -475,7 +583,9 @@ type PodLogWatch struct {
 	startWatchTime  time.Time
 	terminationTime chan time.Time
 
-	shouldPrefix bool // if true, we'll prefix logs with the container name
+	shouldPrefix bool               // if true, we'll prefix logs with the container name
+	levelFormat  logger.LevelFormat // how to read log levels from each line
+	rateLimit    int64              // bytes/sec of logs to keep; 0 means no limit
 }
 
 type podLogKey struct {
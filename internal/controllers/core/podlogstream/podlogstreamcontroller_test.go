@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -75,6 +76,29 @@ func TestLogActions(t *testing.T) {
 	f.ConsumeLogActionsUntil("hello world!")
 }
 
+func TestLogLevelFormat(t *testing.T) {
+	f := newPLMFixture(t)
+
+	f.kClient.SetLogsForPodContainer(podID, cName,
+		`{"level":"info","msg":"hello"}`+"\n"+
+			`{"level":"error","msg":"boom"}`+"\n"+
+			"not json\n")
+
+	pb := newPodBuilder(podID).addRunningContainer(cName, cID)
+	f.kClient.UpsertPod(pb.toPod())
+
+	pls := plsFromPod("server", pb, time.Time{})
+	pls.Spec.LogLevelFormat = "zap"
+	f.Create(pls)
+
+	f.triggerPodEvent(podID)
+	f.ConsumeLogActionsUntil("not json")
+
+	assert.Equal(t, logger.InfoLvl, f.store.levelOf("hello"))
+	assert.Equal(t, logger.ErrorLvl, f.store.levelOf("boom"))
+	assert.Equal(t, logger.InfoLvl, f.store.levelOf("not json"))
+}
+
 func TestLogsFailed(t *testing.T) {
 	f := newPLMFixture(t)
 
@@ -145,6 +169,50 @@ func TestMultiContainerLogs(t *testing.T) {
 	f.AssertOutputContains("goodbye world!")
 }
 
+func TestRateLimitedLogs(t *testing.T) {
+	f := newPLMFixture(t)
+	f.store.WithState(func(state *store.EngineState) {
+		state.UpdateSettings.PodLogRateLimit = 100
+	})
+	now := time.Now()
+	f.plsc.now = func() time.Time { return now }
+
+	var noisyLogs strings.Builder
+	for i := 0; i < 20000; i++ {
+		fmt.Fprintf(&noisyLogs, "line %05d\n", i)
+	}
+	noisyID := k8s.PodID("noisy")
+	f.kClient.SetLogsForPodContainer(noisyID, cName, noisyLogs.String())
+	noisy := newPodBuilder(noisyID).addRunningContainer(cName, "cid1")
+	f.kClient.UpsertPod(noisy.toPod())
+	noisyPLS := plsFromPod("noisy", noisy, time.Time{})
+	f.Create(noisyPLS)
+
+	quietID := k8s.PodID("quiet")
+	f.kClient.SetLogsForPodContainer(quietID, cName, "hello world!\n")
+	quiet := newPodBuilder(quietID).addRunningContainer(cName, "cid2")
+	f.kClient.UpsertPod(quiet.toPod())
+	quietPLS := plsFromPod("quiet", quiet, time.Time{})
+	f.Create(quietPLS)
+
+	f.triggerPodEvent(noisyID)
+	f.triggerPodEvent(quietID)
+
+	f.AssertOutputContains("line 00009\n⚠ dropped 19,990 lines (rate limit)\n")
+	f.AssertOutputDoesNotContain("line 00010")
+	f.AssertOutputContains("hello world!\n")
+
+	assert.Eventually(t, func() bool {
+		f.MustGet(f.KeyForObject(noisyPLS), noisyPLS)
+		statuses := noisyPLS.Status.ContainerStatuses
+		return len(statuses) == 1 && statuses[0].RateLimited && statuses[0].DroppedLines == 19990
+	}, time.Second, 5*time.Millisecond)
+
+	f.MustGet(f.KeyForObject(quietPLS), quietPLS)
+	require.Len(t, quietPLS.Status.ContainerStatuses, 1)
+	assert.False(t, quietPLS.Status.ContainerStatuses[0].RateLimited)
+}
+
 func TestContainerPrefixes(t *testing.T) {
 	f := newPLMFixture(t)
 
@@ -375,6 +443,9 @@ type plmStore struct {
 	t testing.TB
 	*store.TestingStore
 	out *bufsync.ThreadSafeBuffer
+
+	mu         sync.Mutex
+	logActions []store.LogAction
 }
 
 func newPLMStore(t testing.TB, out *bufsync.ThreadSafeBuffer) *plmStore {
@@ -391,12 +462,72 @@ func (s *plmStore) Dispatch(action store.Action) {
 		s.t.Errorf("Expected action type LogAction. Actual: %T", action)
 	}
 
+	s.mu.Lock()
+	s.logActions = append(s.logActions, event)
+	s.mu.Unlock()
+
 	_, err := s.out.Write(event.Message())
 	if err != nil {
 		fmt.Printf("error writing event: %v\n", err)
 	}
 }
 
+func (s *plmStore) levelOf(msg string) logger.Level {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range s.logActions {
+		if strings.Contains(string(a.Message()), msg) {
+			return a.Level()
+		}
+	}
+	s.t.Errorf("No log action with message %q", msg)
+	return logger.NoneLvl
+}
+
+func TestLogsSleepAndWake(t *testing.T) {
+	f := newPLMFixture(t)
+	pb := newPodBuilder(podID).addRunningContainer(cName, cID)
+	f.kClient.UpsertPod(pb.toPod())
+
+	reader, writer := io.Pipe()
+	defer func() {
+		require.NoError(t, writer.Close())
+	}()
+	f.kClient.SetLogReaderForPodContainer(podID, cName, reader)
+
+	startTime := time.Now().Add(-time.Minute)
+	pls := plsFromPod("server", pb, startTime)
+	f.Create(pls)
+
+	_, err := writer.Write([]byte("hello world!\n"))
+	require.NoError(t, err)
+	f.AssertOutputContains("hello world!\n")
+
+	sleepTime := startTime.Add(30 * time.Second)
+	f.plsc.now = func() time.Time { return sleepTime }
+	require.NoError(t, f.plsc.Sleep(f.ctx, f.store))
+	f.triggerPodEvent(podID)
+	assert.Error(t, f.kClient.LastPodLogContext.Err())
+	require.NoError(t, writer.Close())
+
+	// The stream is closed while we're asleep.
+	assert.Eventually(t, func() bool {
+		f.MustGet(f.KeyForObject(pls), pls)
+		statuses := pls.Status.ContainerStatuses
+		return len(statuses) == 1 && !statuses[0].Active
+	}, time.Second, 5*time.Millisecond)
+
+	f.kClient.SetLogsForPodContainer(podID, cName, "goodbye world!\n")
+	f.triggerPodEvent(podID)
+	f.AssertOutputDoesNotContain("goodbye world!")
+
+	// When we wake up, the stream picks up where it left off.
+	require.NoError(t, f.plsc.Wake(f.ctx, f.store))
+	f.triggerPodEvent(podID)
+	f.AssertOutputContains("goodbye world!\n")
+	f.AssertLogStartTime(sleepTime)
+}
+
 type plmFixture struct {
 	*fake.ControllerFixture
 	t       testing.TB
@@ -420,7 +551,7 @@ func newPLMFixture(t testing.TB) *plmFixture {
 
 	st := newPLMStore(t, out)
 	podSource := NewPodSource(ctx, kClient, cfb.Client.Scheme())
-	plsc := NewController(ctx, cfb.Client, st, kClient, podSource)
+	plsc := NewController(ctx, cfb.Client, st, kClient, podSource, nil)
 
 	return &plmFixture{
 		t:                 t,
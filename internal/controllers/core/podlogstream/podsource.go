@@ -11,6 +11,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/tilt-dev/tilt/internal/controllers/indexer"
@@ -137,6 +138,21 @@ func (s *PodSource) handlePod(obj k8s.ObjectUpdate) {
 	}
 }
 
+// Queue up a Reconcile() call for each of the given streams.
+func (s *PodSource) requeue(names []types.NamespacedName) {
+	s.mu.Lock()
+	q := s.q
+	s.mu.Unlock()
+
+	if q == nil {
+		return
+	}
+
+	for _, name := range names {
+		q.Add(reconcile.Request{NamespacedName: name})
+	}
+}
+
 // Find all the objects we need to watch based on the PodLogStream
 func indexPodLogStream(obj client.Object) []indexer.Key {
 	pls := obj.(*v1alpha1.PodLogStream)
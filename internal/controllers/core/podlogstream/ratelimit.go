@@ -0,0 +1,223 @@
+package podlogstream
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// How often to remind the user that lines are being dropped, while a stream
+// stays over its budget.
+var logDropMarkerInterval = 5 * time.Second
+
+// Tracks the log volume of all streams together, and tightens the
+// per-stream budgets when the total is over the global budget.
+//
+// Budgets are counted in one-second windows.
+type globalLogBudget struct {
+	mu sync.Mutex
+
+	limit       int64 // bytes/sec across all streams. 0 means no limit.
+	windowStart time.Time
+	bytes       int64 // bytes offered in the current window
+	lastBytes   int64 // bytes offered in the previous window
+}
+
+func newGlobalLogBudget() *globalLogBudget {
+	return &globalLogBudget{}
+}
+
+func (g *globalLogBudget) setLimit(limit int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.limit = limit
+}
+
+// Records that a stream has n more bytes of logs, whether or not they're
+// kept, and returns how much to scale the per-stream budgets by.
+//
+// If all the streams together are over the global budget, the scale is the
+// fraction that fits, so every stream gets tightened proportionally.
+func (g *globalLogBudget) offer(now time.Time, n int) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if elapsed := now.Sub(g.windowStart); elapsed >= time.Second {
+		if elapsed < 2*time.Second {
+			g.lastBytes = g.bytes
+		} else {
+			g.lastBytes = 0
+		}
+		g.windowStart = now
+		g.bytes = 0
+	}
+	g.bytes += int64(n)
+
+	if g.limit <= 0 {
+		return 1
+	}
+
+	// Use the busier of this window and the last one, so that the limits
+	// don't loosen at the start of each window.
+	volume := g.bytes
+	if g.lastBytes > volume {
+		volume = g.lastBytes
+	}
+	if volume <= g.limit {
+		return 1
+	}
+	return float64(g.limit) / float64(volume)
+}
+
+// Sits between a container's log stream and the logger, and drops lines once
+// the stream goes over its budget.
+//
+// Never blocks: lines over the budget are dropped rather than delayed, so a
+// noisy container can't back up its connection to the Kubernetes API.
+// Every so often (and when the stream catches up), writes a marker that says
+// how many lines were dropped.
+type logRateLimiter struct {
+	out    io.Writer
+	limit  int64 // bytes/sec. 0 means no limit.
+	global *globalLogBudget
+	now    func() time.Time
+
+	// Called when the stream first drops a line, and with every marker,
+	// with the total number of lines dropped so far.
+	onDrop func(total int64)
+
+	windowStart time.Time
+	used        int64 // bytes kept in the current window
+
+	inLine   bool // whether we're in the middle of a line
+	dropLine bool // whether we're dropping the current line
+
+	dropped      int64 // lines dropped since the last marker
+	totalDropped int64
+	lastMarker   time.Time
+}
+
+func newLogRateLimiter(out io.Writer, limit int64, global *globalLogBudget, now func() time.Time, onDrop func(total int64)) *logRateLimiter {
+	return &logRateLimiter{
+		out:    out,
+		limit:  limit,
+		global: global,
+		now:    now,
+		onDrop: onDrop,
+	}
+}
+
+func (l *logRateLimiter) Write(p []byte) (int, error) {
+	if l.limit <= 0 {
+		return l.out.Write(p)
+	}
+
+	now := l.now()
+	scale := l.global.offer(now, len(p))
+	if now.Sub(l.windowStart) >= time.Second {
+		l.windowStart = now
+		l.used = 0
+	}
+	limit := int64(float64(l.limit) * scale)
+	if limit < 1 {
+		limit = 1
+	}
+
+	rest := p
+	for len(rest) > 0 {
+		if !l.inLine {
+			l.inLine = true
+			l.dropLine = l.used >= limit
+			var err error
+			if l.dropLine {
+				err = l.recordDrop(now)
+			} else {
+				// The stream is under its budget again.
+				err = l.writeMarker(now)
+			}
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		end := len(rest)
+		if i := bytes.IndexByte(rest, '\n'); i != -1 {
+			end = i + 1
+			l.inLine = false
+		}
+		line := rest[:end]
+		rest = rest[end:]
+
+		if l.dropLine {
+			continue
+		}
+		l.used += int64(len(line))
+		_, err := l.out.Write(line)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Counts a dropped line. We're at the start of the line, so this is also
+// a good place for a marker, if it's been a while since the last one.
+func (l *logRateLimiter) recordDrop(now time.Time) error {
+	if l.dropped > 0 && now.Sub(l.lastMarker) >= logDropMarkerInterval {
+		err := l.writeMarker(now)
+		if err != nil {
+			return err
+		}
+	}
+
+	if l.dropped == 0 {
+		l.lastMarker = now
+	}
+	l.dropped++
+	l.totalDropped++
+	if l.totalDropped == 1 && l.onDrop != nil {
+		l.onDrop(l.totalDropped)
+	}
+	return nil
+}
+
+func (l *logRateLimiter) writeMarker(now time.Time) error {
+	if l.dropped == 0 {
+		return nil
+	}
+	msg := fmt.Sprintf("⚠ dropped %s lines (rate limit)\n", formatCount(l.dropped))
+	l.dropped = 0
+	l.lastMarker = now
+	if l.onDrop != nil {
+		l.onDrop(l.totalDropped)
+	}
+	_, err := l.out.Write([]byte(msg))
+	return err
+}
+
+// Reports any lines dropped since the last marker. Call when the stream ends.
+func (l *logRateLimiter) Flush() error {
+	if l.dropped == 0 {
+		return nil
+	}
+	if l.inLine && !l.dropLine {
+		_, err := l.out.Write([]byte("\n"))
+		if err != nil {
+			return err
+		}
+	}
+	l.inLine = false
+	return l.writeMarker(l.now())
+}
+
+// Formats a count with thousands separators, e.g., 14,203.
+func formatCount(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
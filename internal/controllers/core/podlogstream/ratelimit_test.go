@@ -0,0 +1,135 @@
+package podlogstream
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type limiterFixture struct {
+	t       *testing.T
+	out     *bytes.Buffer
+	now     time.Time
+	drops   []int64
+	limiter *logRateLimiter
+}
+
+func newLimiterFixture(t *testing.T, limit int64, global *globalLogBudget) *limiterFixture {
+	f := &limiterFixture{
+		t:   t,
+		out: &bytes.Buffer{},
+		now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	f.limiter = newLogRateLimiter(f.out, limit, global, func() time.Time { return f.now }, func(total int64) {
+		f.drops = append(f.drops, total)
+	})
+	return f
+}
+
+// Writes n ten-byte lines.
+func (f *limiterFixture) writeLines(start, n int) {
+	var b strings.Builder
+	for i := start; i < start+n; i++ {
+		fmt.Fprintf(&b, "line %04d\n", i)
+	}
+	_, err := f.limiter.Write([]byte(b.String()))
+	require.NoError(f.t, err)
+}
+
+func TestLogRateLimiterUnderBudget(t *testing.T) {
+	f := newLimiterFixture(t, 100, newGlobalLogBudget())
+	f.writeLines(0, 10)
+	require.NoError(t, f.limiter.Flush())
+
+	assert.Equal(t, 10, strings.Count(f.out.String(), "\n"))
+	assert.Empty(t, f.drops)
+}
+
+func TestLogRateLimiterDropsWholeLines(t *testing.T) {
+	f := newLimiterFixture(t, 100, newGlobalLogBudget())
+	f.writeLines(0, 1000)
+	require.NoError(t, f.limiter.Flush())
+
+	assert.True(t, strings.HasPrefix(f.out.String(), "line 0000\n"))
+	assert.Contains(t, f.out.String(), "line 0009\n⚠ dropped 990 lines (rate limit)\n")
+	assert.NotContains(t, f.out.String(), "line 0010")
+	assert.Equal(t, []int64{1, 990}, f.drops)
+}
+
+func TestLogRateLimiterPartialLines(t *testing.T) {
+	f := newLimiterFixture(t, 5, newGlobalLogBudget())
+	for _, s := range []string{"hel", "lo wo", "rld\ngood", "bye\nhello again\n"} {
+		_, err := f.limiter.Write([]byte(s))
+		require.NoError(t, err)
+	}
+	require.NoError(t, f.limiter.Flush())
+
+	// A line that starts under budget is kept whole.
+	assert.Equal(t, "hello world\n⚠ dropped 2 lines (rate limit)\n", f.out.String())
+}
+
+func TestLogRateLimiterMarkers(t *testing.T) {
+	f := newLimiterFixture(t, 100, newGlobalLogBudget())
+	f.writeLines(0, 20)
+
+	// Still over budget a few seconds later, so we get a reminder.
+	f.now = f.now.Add(500 * time.Millisecond)
+	f.writeLines(20, 5)
+	f.now = f.now.Add(logDropMarkerInterval)
+	f.writeLines(25, 20)
+	f.writeLines(45, 5)
+	assert.Contains(t, f.out.String(), "⚠ dropped 15 lines (rate limit)\nline 0025\n")
+
+	// When the stream catches up, we say how many lines we dropped since.
+	f.now = f.now.Add(time.Second)
+	f.writeLines(50, 1)
+	assert.True(t, strings.HasSuffix(f.out.String(), "line 0034\n⚠ dropped 15 lines (rate limit)\nline 0050\n"), f.out.String())
+	assert.Equal(t, []int64{1, 15, 30}, f.drops)
+}
+
+func TestLogRateLimiterNoLimit(t *testing.T) {
+	f := newLimiterFixture(t, 0, newGlobalLogBudget())
+	f.writeLines(0, 1000)
+	require.NoError(t, f.limiter.Flush())
+
+	assert.Equal(t, 1000, strings.Count(f.out.String(), "\n"))
+	assert.Empty(t, f.drops)
+}
+
+func TestGlobalLogBudgetTightensStreams(t *testing.T) {
+	global := newGlobalLogBudget()
+	global.setLimit(200)
+
+	quiet := newLimiterFixture(t, 100, global)
+	noisy := newLimiterFixture(t, 100, global)
+
+	// On their own, each stream fits its budget.
+	quiet.writeLines(0, 5)
+	assert.Equal(t, 5, strings.Count(quiet.out.String(), "line 0"))
+
+	// The noisy stream pushes the total to 4x the global budget,
+	// so each stream only gets a quarter of its usual budget.
+	noisy.writeLines(0, 75)
+	assert.Equal(t, 3, strings.Count(noisy.out.String(), "line 0"))
+
+	quiet.now = quiet.now.Add(time.Second)
+	quiet.writeLines(5, 5)
+	assert.Equal(t, 5+3, strings.Count(quiet.out.String(), "line 0"))
+
+	// Once things quiet down, the streams get their full budget back.
+	quiet.now = quiet.now.Add(5 * time.Second)
+	quiet.writeLines(10, 10)
+	assert.Equal(t, 5+3+10, strings.Count(quiet.out.String(), "line 0"))
+}
+
+func TestFormatCount(t *testing.T) {
+	assert.Equal(t, "0", formatCount(0))
+	assert.Equal(t, "999", formatCount(999))
+	assert.Equal(t, "14,203", formatCount(14203))
+	assert.Equal(t, "1,000,000", formatCount(1000000))
+}
@@ -11,9 +11,11 @@ import (
 	"github.com/tilt-dev/tilt/internal/timecmp"
 	"github.com/tilt-dev/tilt/pkg/apis"
 	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
 
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -25,6 +27,7 @@ import (
 
 	"github.com/tilt-dev/tilt/internal/k8s"
 	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/store/portforwards"
 )
 
 type Reconciler struct {
@@ -32,8 +35,23 @@ type Reconciler struct {
 	kClient    k8s.Client
 	ctrlClient ctrlclient.Client
 
+	// mu guards activeForwards, which the cluster monitor may restart
+	// outside of the normal reconcile loop.
+	mu sync.Mutex
+
 	// map of PortForward object name --> running forward(s)
 	activeForwards map[types.NamespacedName]*portForwardEntry
+
+	// Forwards turned off at runtime. These are tracked by resource and local
+	// port rather than by PortForward object, so that they stay off when the
+	// pod is replaced.
+	disabled map[ForwardID]bool
+
+	// Checks that a local port is free before turning a forward back on.
+	checkPortAvailable func(host string, port int32) error
+
+	// How often to re-probe forwards that haven't passed verification.
+	verifyPeriod time.Duration
 }
 
 var _ store.TearDowner = &Reconciler{}
@@ -45,6 +63,10 @@ func NewReconciler(ctrlClient ctrlclient.Client, store store.RStore, kClient k8s
 		kClient:        kClient,
 		ctrlClient:     ctrlClient,
 		activeForwards: make(map[types.NamespacedName]*portForwardEntry),
+		disabled:       make(map[ForwardID]bool),
+
+		checkPortAvailable: checkLocalPortAvailable,
+		verifyPeriod:       defaultVerifyPeriod,
 	}
 }
 
@@ -64,11 +86,22 @@ func (r *Reconciler) reconcile(ctx context.Context, name types.NamespacedName) e
 	pf := &PortForward{}
 	err := r.ctrlClient.Get(ctx, name, pf)
 
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	if apierrors.IsNotFound(err) || pf.ObjectMeta.DeletionTimestamp != nil {
 		// PortForward deleted in API server -- stop and remove it
 		r.stop(name)
+		r.store.Dispatch(portforwards.NewPortForwardDeleteAction(name.Name))
 		return nil
 	}
+	if err != nil {
+		return err
+	}
+
+	// Status updates also come through here, so the engine always sees the
+	// latest state of each forward.
+	r.store.Dispatch(portforwards.NewPortForwardUpsertAction(pf))
 
 	if active, ok := r.activeForwards[name]; ok {
 		if equality.Semantic.DeepEqual(active.Spec, pf.Spec) &&
@@ -83,16 +116,55 @@ func (r *Reconciler) reconcile(ctx context.Context, name types.NamespacedName) e
 	}
 
 	// Create a new PortForward OR recreate a modified PortForward (stopped above)
+	r.start(ctx, name, pf)
+
+	return nil
+}
+
+// Start forwarding ports for the given PortForward.
+//
+// mu must be held by caller.
+func (r *Reconciler) start(ctx context.Context, name types.NamespacedName, pf *PortForward) {
 	entry := newEntry(ctx, pf)
 	r.activeForwards[name] = entry
 
 	// Treat port-forwarding errors as part of the pod log
-	ctx = store.MustObjectLogHandler(entry.ctx, r.store, entry.PortForward)
+	entry.ctx = store.MustObjectLogHandler(entry.ctx, r.store, entry.PortForward)
 
+	anyDisabled := false
 	for _, forward := range entry.Spec.Forwards {
-		go r.portForwardLoop(ctx, entry, forward)
+		if r.disabled[entry.forwardID(forward)] {
+			entry.setDisabled(forward, "")
+			anyDisabled = true
+			continue
+		}
+		r.startForward(entry, forward)
+	}
+
+	if anyDisabled {
+		go r.updateForwardStatus(entry.ctx, entry)
 	}
+}
+
+// Start the loop for a single forward, independent of the others on the same pod.
+func (r *Reconciler) startForward(entry *portForwardEntry, forward Forward) {
+	ctx := entry.startForward(forward)
+	go r.portForwardLoop(ctx, entry, forward)
+}
 
+// ResyncCluster restarts every port-forward.
+//
+// A tunnel can die silently while the cluster is unreachable (e.g., when
+// the laptop sleeps), so it's safer to start over than to wait for the
+// tunnel to notice.
+func (r *Reconciler) ResyncCluster(ctx context.Context, _ store.RStore) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for name, entry := range r.activeForwards {
+		r.stop(name)
+		r.start(ctx, name, entry.PortForward)
+	}
 	return nil
 }
 
@@ -197,18 +269,28 @@ func (r *Reconciler) onePortForward(ctx context.Context, entry *portForwardEntry
 			// forward initialization errored at start before ready
 			return
 		case <-readyCh:
-			entry.setStatus(forward, ForwardStatus{
+			status := ForwardStatus{
 				LocalPort:     int32(pf.LocalPort()),
 				ContainerPort: forward.ContainerPort,
 				Addresses:     pf.Addresses(),
 				StartedAt:     apis.NowMicro(),
-			})
+				Transport:     pf.Transport(),
+			}
+			entry.setStatus(forward, status)
 			r.updateForwardStatus(ctx, entry)
+
+			if forward.Verify != nil {
+				r.verifyForward(ctx, doneCh, entry, forward, status)
+			}
 		}
 	}()
 
 	err = pf.ForwardPorts()
 	close(doneCh)
+	if ctx.Err() != nil {
+		// The forward was stopped (e.g., disabled), so don't clobber its status.
+		return
+	}
 	if err != nil {
 		logError(err)
 		shouldUpdate := entry.setStatus(forward, ForwardStatus{
@@ -225,11 +307,15 @@ func (r *Reconciler) onePortForward(ctx context.Context, entry *portForwardEntry
 }
 
 func (r *Reconciler) TearDown(_ context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	for name := range r.activeForwards {
 		r.stop(name)
 	}
 }
 
+// mu must be held by caller.
 func (r *Reconciler) stop(name types.NamespacedName) {
 	entry, ok := r.activeForwards[name]
 	if !ok {
@@ -246,6 +332,9 @@ type portForwardEntry struct {
 
 	mu     sync.Mutex
 	status map[Forward]statusMeta
+
+	// Cancels each running forward. Disabled forwards aren't in the map.
+	running map[Forward]context.CancelFunc
 }
 
 func newEntry(ctx context.Context, pf *PortForward) *portForwardEntry {
@@ -255,6 +344,51 @@ func newEntry(ctx context.Context, pf *PortForward) *portForwardEntry {
 		ctx:         ctx,
 		cancel:      cancel,
 		status:      make(map[Forward]statusMeta),
+		running:     make(map[Forward]context.CancelFunc),
+	}
+}
+
+func (e *portForwardEntry) forwardID(forward Forward) ForwardID {
+	return ForwardID{
+		ManifestName: model.ManifestName(e.ObjectMeta.Annotations[v1alpha1.AnnotationManifest]),
+		LocalPort:    forward.LocalPort,
+	}
+}
+
+// Returns the context that the forward runs under.
+func (e *portForwardEntry) startForward(forward Forward) context.Context {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(e.ctx)
+	e.running[forward] = cancel
+	return ctx
+}
+
+func (e *portForwardEntry) stopForward(forward Forward) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	cancel, ok := e.running[forward]
+	if ok {
+		cancel()
+		delete(e.running, forward)
+	}
+}
+
+// Mark the forward as disabled in the status, with an optional error
+// explaining why it couldn't be turned back on.
+func (e *portForwardEntry) setDisabled(forward Forward, errMsg string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.status[forward] = statusMeta{
+		status: ForwardStatus{
+			LocalPort:     forward.LocalPort,
+			ContainerPort: forward.ContainerPort,
+			Error:         errMsg,
+			Disabled:      true,
+		},
 	}
 }
 
@@ -269,6 +403,11 @@ func (e *portForwardEntry) setStatus(spec Forward, status ForwardStatus) (should
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	if _, ok := e.running[spec]; !ok {
+		// A late update from a forward that's been stopped.
+		return false
+	}
+
 	var lastError time.Time
 	if status.Error != "" {
 		lastError = time.Now()
@@ -287,6 +426,27 @@ func (e *portForwardEntry) setStatus(spec Forward, status ForwardStatus) (should
 	return shouldUpdate
 }
 
+// Marks the forward verified, unless it has reconnected or failed since
+// the connection that passed verification started. Returns a bool indicating
+// whether an API update should be performed.
+func (e *portForwardEntry) setVerified(spec Forward, startedAt metav1.MicroTime, verifiedAt metav1.MicroTime) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.running[spec]; !ok {
+		return false
+	}
+
+	current, ok := e.status[spec]
+	if !ok || current.status.Error != "" || !current.status.StartedAt.Equal(&startedAt) {
+		return false
+	}
+
+	current.status.VerifiedAt = verifiedAt
+	e.status[spec] = current
+	return true
+}
+
 func (e *portForwardEntry) statuses() []ForwardStatus {
 	e.mu.Lock()
 	defer e.mu.Unlock()
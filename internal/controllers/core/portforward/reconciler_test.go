@@ -4,7 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -47,6 +51,15 @@ func TestCreatePortForward(t *testing.T) {
 	assert.Equal(t, 8080, f.kCli.LastForwardPortRemotePort())
 }
 
+func TestPortForwardStatusReportsTransport(t *testing.T) {
+	f := newPFRFixture(t)
+
+	f.Create(f.makeSimplePF(pfFooName, 8000, 8080))
+	f.requirePortForwardStatus(pfFooName, 8000, 8080, func(status ForwardStatus) (bool, string) {
+		return status.Transport == "spdy", fmt.Sprintf("transport=%q", status.Transport)
+	})
+}
+
 func TestDeletePortForward(t *testing.T) {
 	f := newPFRFixture(t)
 
@@ -277,6 +290,242 @@ func TestPortForwardPartialSuccess(t *testing.T) {
 	f.requirePortForwardError(pfFooName, k8s.MagicTestExplodingPort, 8082, "fake error starting port forwarding")
 }
 
+func TestResyncClusterRestartsPortForward(t *testing.T) {
+	f := newPFRFixture(t)
+
+	pf := f.makeSimplePF(pfFooName, 8000, 8080)
+	f.Create(pf)
+	f.requirePortForwardStarted(pfFooName, 8000, 8080)
+	origForwardCtx := f.kCli.LastForwardContext()
+
+	require.NoError(t, f.r.ResyncCluster(f.Context(), f.st))
+
+	f.assertContextCancelled(t, origForwardCtx)
+	require.Eventually(t, func() bool {
+		ctx := f.kCli.LastForwardContext()
+		return ctx != origForwardCtx && ctx.Err() == nil
+	}, time.Second, 10*time.Millisecond, "port forward was not restarted")
+	require.Equal(t, 1, len(f.r.activeForwards))
+	assert.Equal(t, 8080, f.kCli.LastForwardPortRemotePort())
+}
+
+func TestDisableOneOfTwoForwards(t *testing.T) {
+	f := newPFRFixture(t)
+
+	forwards := []Forward{
+		f.makeForward(8000, 8080, "localhost"),
+		f.makeForward(8001, 8081, "localhost"),
+	}
+	pf := f.makeSimplePFMultipleForwards(pfFooName, forwards)
+	f.Create(pf)
+	f.requirePortForwardStarted(pfFooName, 8000, 8080)
+	f.requirePortForwardStarted(pfFooName, 8001, 8081)
+
+	ctx8080 := f.forwardContext(8080)
+	ctx8081 := f.forwardContext(8081)
+
+	id := ForwardID{ManifestName: "manifest-pf_foo", LocalPort: 8000}
+	require.NoError(t, f.r.SetForwardEnabled(f.Context(), id, false))
+
+	f.requirePortForwardDisabled(pfFooName, 8000, 8080)
+	f.assertContextCancelled(t, ctx8080)
+	f.assertContextNotCancelled(t, ctx8081)
+	f.requirePortForwardStarted(pfFooName, 8001, 8081)
+	require.Equal(t, 2, f.kCli.CreatePortForwardCallCount())
+
+	require.NoError(t, f.r.SetForwardEnabled(f.Context(), id, true))
+	f.requirePortForwardStarted(pfFooName, 8000, 8080)
+	require.Equal(t, 3, f.kCli.CreatePortForwardCallCount())
+	f.assertContextNotCancelled(t, ctx8081)
+}
+
+func TestDisabledForwardStaysOffWhenPodReplaced(t *testing.T) {
+	f := newPFRFixture(t)
+
+	forwards := []Forward{
+		f.makeForward(8000, 8080, "localhost"),
+		f.makeForward(8001, 8081, "localhost"),
+	}
+	pfA := f.makePF("pf-a", "foo", "pod-a", "", forwards)
+	f.Create(pfA)
+	f.requirePortForwardStarted("pf-a", 8000, 8080)
+	f.requirePortForwardStarted("pf-a", 8001, 8081)
+
+	require.NoError(t, f.r.SetForwardEnabled(f.Context(), ForwardID{ManifestName: "foo", LocalPort: 8000}, false))
+	f.requirePortForwardDisabled("pf-a", 8000, 8080)
+
+	f.Delete(pfA)
+	f.requirePortForwardDeleted("pf-a")
+
+	pfB := f.makePF("pf-b", "foo", "pod-b", "", forwards)
+	f.Create(pfB)
+	f.requirePortForwardStarted("pf-b", 8001, 8081)
+	f.requirePortForwardDisabled("pf-b", 8000, 8080)
+
+	for _, call := range f.kCli.PortForwardCalls() {
+		if call.PodID == "pod-b" {
+			assert.NotEqual(t, 8080, call.RemotePort, "disabled forward should not start on the new pod")
+		}
+	}
+}
+
+func TestReenableForwardWithPortInUse(t *testing.T) {
+	f := newPFRFixture(t)
+	f.r.checkPortAvailable = func(host string, port int32) error {
+		return fmt.Errorf("listen tcp 127.0.0.1:%d: bind: address already in use", port)
+	}
+
+	pf := f.makeSimplePF(pfFooName, 8000, 8080)
+	f.Create(pf)
+	f.requirePortForwardStarted(pfFooName, 8000, 8080)
+
+	id := ForwardID{ManifestName: "manifest-pf_foo", LocalPort: 8000}
+	require.NoError(t, f.r.SetForwardEnabled(f.Context(), id, false))
+	f.requirePortForwardDisabled(pfFooName, 8000, 8080)
+
+	err := f.r.SetForwardEnabled(f.Context(), id, true)
+	require.True(t, errors.Is(err, ErrLocalPortInUse), "unexpected error: %v", err)
+	f.requirePortForwardError(pfFooName, 8000, 8080, "address already in use")
+	f.requirePortForwardDisabled(pfFooName, 8000, 8080)
+	require.Equal(t, 1, f.kCli.CreatePortForwardCallCount())
+}
+
+func TestToggleUnknownForward(t *testing.T) {
+	f := newPFRFixture(t)
+
+	pf := f.makeSimplePF(pfFooName, 8000, 8080)
+	f.Create(pf)
+	f.requirePortForwardStarted(pfFooName, 8000, 8080)
+
+	err := f.r.SetForwardEnabled(f.Context(), ForwardID{ManifestName: "manifest-pf_foo", LocalPort: 9999}, false)
+	require.True(t, errors.Is(err, ErrForwardNotFound), "unexpected error: %v", err)
+}
+
+func TestVerifyForwardWaitsForListener(t *testing.T) {
+	f := newPFRFixture(t)
+	port := freePort(t)
+
+	fwd := f.makeForward(port, 8080, "127.0.0.1")
+	fwd.Verify = &v1alpha1.ForwardVerify{}
+	f.Create(f.makeSimplePFMultipleForwards(pfFooName, []Forward{fwd}))
+	f.requirePortForwardStarted(pfFooName, port, 8080)
+
+	// Nothing's listening, so the probes keep failing.
+	time.Sleep(5 * f.r.verifyPeriod)
+	f.requirePortForwardStatus(pfFooName, port, 8080, func(status ForwardStatus) (bool, string) {
+		return status.VerifiedAt.IsZero(), fmt.Sprintf("verifiedAt=%s", status.VerifiedAt.String())
+	})
+
+	l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			// Hold the connection open, like a server waiting for a request.
+			defer func() { _ = conn.Close() }()
+		}
+	}()
+
+	f.requirePortForwardVerified(pfFooName, port, 8080)
+}
+
+func TestVerifyTCPFailsWhenForwardClosesConnection(t *testing.T) {
+	// When nothing in the pod is listening, the forward accepts the
+	// connection, then closes it.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	port := int32(l.Addr().(*net.TCPAddr).Port)
+	err = probeForward(context.Background(), "127.0.0.1", port, &v1alpha1.ForwardVerify{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "closed")
+}
+
+func TestVerifyForwardHTTPPath(t *testing.T) {
+	f := newPFRFixture(t)
+
+	var healthy atomic.Value
+	healthy.Store(false)
+	paths := make(chan string, 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case paths <- r.URL.Path:
+		default:
+		}
+		if !healthy.Load().(bool) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	port := int32(server.Listener.Addr().(*net.TCPAddr).Port)
+
+	fwd := f.makeForward(port, 8080, "127.0.0.1")
+	fwd.Verify = &v1alpha1.ForwardVerify{HTTPPath: "/healthz"}
+	f.Create(f.makeSimplePFMultipleForwards(pfFooName, []Forward{fwd}))
+	f.requirePortForwardStarted(pfFooName, port, 8080)
+
+	// The server is up, but the health check fails.
+	assert.Equal(t, "/healthz", <-paths)
+	f.requirePortForwardStatus(pfFooName, port, 8080, func(status ForwardStatus) (bool, string) {
+		return status.VerifiedAt.IsZero(), fmt.Sprintf("verifiedAt=%s", status.VerifiedAt.String())
+	})
+
+	healthy.Store(true)
+	f.requirePortForwardVerified(pfFooName, port, 8080)
+}
+
+func TestVerifyForwardAgainAfterReconnect(t *testing.T) {
+	f := newPFRFixture(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	port := int32(server.Listener.Addr().(*net.TCPAddr).Port)
+
+	fwd := f.makeForward(port, 8080, "127.0.0.1")
+	fwd.Verify = &v1alpha1.ForwardVerify{HTTPPath: "/"}
+	f.Create(f.makeSimplePFMultipleForwards(pfFooName, []Forward{fwd}))
+	f.requirePortForwardVerified(pfFooName, port, 8080)
+
+	var pf PortForward
+	f.MustGet(types.NamespacedName{Name: pfFooName}, &pf)
+	firstStart := pf.Status.ForwardStatuses[0].StartedAt
+
+	f.kCli.LastForwarder().TriggerFailure(errors.New("connection lost"))
+
+	// The forward reconnects, and has to pass verification again.
+	f.requirePortForwardStatus(pfFooName, port, 8080, func(status ForwardStatus) (bool, string) {
+		ok := status.StartedAt.After(firstStart.Time) && !status.VerifiedAt.IsZero() &&
+			!status.VerifiedAt.Before(&status.StartedAt)
+		return ok, fmt.Sprintf("startedAt=%s / verifiedAt=%s", status.StartedAt.String(), status.VerifiedAt.String())
+	})
+}
+
+func TestForwardWithoutVerifyIsNeverVerified(t *testing.T) {
+	f := newPFRFixture(t)
+
+	f.Create(f.makeSimplePF(pfFooName, 8000, 8080))
+	f.requirePortForwardStarted(pfFooName, 8000, 8080)
+
+	time.Sleep(5 * f.r.verifyPeriod)
+	f.requirePortForwardStatus(pfFooName, 8000, 8080, func(status ForwardStatus) (bool, string) {
+		return status.VerifiedAt.IsZero(), fmt.Sprintf("verifiedAt=%s", status.VerifiedAt.String())
+	})
+}
+
 type pfrFixture struct {
 	*fake.ControllerFixture
 	t    *testing.T
@@ -293,6 +542,7 @@ func newPFRFixture(t *testing.T) *pfrFixture {
 
 	cfb := fake.NewControllerFixtureBuilder(t)
 	r := NewReconciler(cfb.Client, st, kCli)
+	r.verifyPeriod = 10 * time.Millisecond
 
 	return &pfrFixture{
 		ControllerFixture: cfb.Build(r),
@@ -358,6 +608,39 @@ func (f *pfrFixture) requirePortForwardStarted(name string, localPort int32, con
 	})
 }
 
+func (f *pfrFixture) requirePortForwardDisabled(name string, localPort int32, containerPort int32) {
+	f.t.Helper()
+	f.requirePortForwardStatus(name, localPort, containerPort, func(status ForwardStatus) (bool, string) {
+		if !status.Disabled || !status.StartedAt.IsZero() {
+			return false, fmt.Sprintf("status has disabled=%t / startedAt=%s", status.Disabled, status.StartedAt.String())
+		}
+		return true, ""
+	})
+}
+
+func (f *pfrFixture) requirePortForwardVerified(name string, localPort int32, containerPort int32) {
+	f.t.Helper()
+	f.requirePortForwardStatus(name, localPort, containerPort, func(status ForwardStatus) (bool, string) {
+		if status.VerifiedAt.IsZero() || status.Error != "" {
+			return false, fmt.Sprintf("status has verifiedAt=%s / error=%q", status.VerifiedAt.String(), status.Error)
+		}
+		return true, ""
+	})
+}
+
+// The context of the most recent forward to the given remote port.
+func (f *pfrFixture) forwardContext(remotePort int) context.Context {
+	f.t.Helper()
+	var ctx context.Context
+	for _, call := range f.kCli.PortForwardCalls() {
+		if call.RemotePort == remotePort {
+			ctx = call.Context
+		}
+	}
+	require.NotNil(f.t, ctx, "no port forward to remote port %d", remotePort)
+	return ctx
+}
+
 func (f *pfrFixture) requirePortForwardDeleted(name string) {
 	f.t.Helper()
 	f.requireState(name, func(pf *PortForward) bool {
@@ -423,3 +706,12 @@ func (f *pfrFixture) makeForward(localPort, containerPort int32, host string) Fo
 		Host:          host,
 	}
 }
+
+// A local port that nothing is listening on.
+func freePort(t *testing.T) int32 {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	require.NoError(t, l.Close())
+	return int32(port)
+}
@@ -0,0 +1,103 @@
+package portforward
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/tilt-dev/tilt/pkg/logger"
+)
+
+var ErrForwardNotFound = errors.New("port-forward not found")
+var ErrLocalPortInUse = errors.New("local port in use")
+
+// Turn a single port-forward off or on, leaving the other forwards
+// on the same resource alone.
+//
+// A disabled forward stays off for the rest of the session, even if its pod
+// is replaced. Turning a forward back on fails with ErrLocalPortInUse if
+// something else grabbed its local port in the meantime; the forward stays
+// off, and its status reports the conflict.
+func (r *Reconciler) SetForwardEnabled(ctx context.Context, id ForwardID, enabled bool) error {
+	if id.LocalPort == 0 {
+		return fmt.Errorf("port-forwards on a random local port can't be toggled")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := r.entriesForForward(id)
+	if len(entries) == 0 {
+		return fmt.Errorf("%w: %s", ErrForwardNotFound, id)
+	}
+
+	if !enabled {
+		if r.disabled[id] {
+			return nil
+		}
+		r.disabled[id] = true
+		for _, e := range entries {
+			e.entry.stopForward(e.forward)
+			e.entry.setDisabled(e.forward, "")
+			logger.Get(e.entry.ctx).Infof("Disabled port-forward %s", id)
+			go r.updateForwardStatus(e.entry.ctx, e.entry)
+		}
+		return nil
+	}
+
+	if !r.disabled[id] {
+		return nil
+	}
+
+	for _, e := range entries {
+		err := r.checkPortAvailable(e.forward.Host, e.forward.LocalPort)
+		if err != nil {
+			err = fmt.Errorf("%w: can't re-enable port-forward %s: %v", ErrLocalPortInUse, id, err)
+			e.entry.setDisabled(e.forward, err.Error())
+			logger.Get(e.entry.ctx).Infof("%v", err)
+			go r.updateForwardStatus(e.entry.ctx, e.entry)
+			return err
+		}
+	}
+
+	delete(r.disabled, id)
+	for _, e := range entries {
+		logger.Get(e.entry.ctx).Infof("Enabled port-forward %s", id)
+		r.startForward(e.entry, e.forward)
+	}
+	return nil
+}
+
+type entryForward struct {
+	entry   *portForwardEntry
+	forward Forward
+}
+
+// Find the running PortForwards that have a forward matching the ID.
+//
+// mu must be held by caller.
+func (r *Reconciler) entriesForForward(id ForwardID) []entryForward {
+	var result []entryForward
+	for _, entry := range r.activeForwards {
+		for _, forward := range entry.Spec.Forwards {
+			if entry.forwardID(forward) == id {
+				result = append(result, entryForward{entry: entry, forward: forward})
+			}
+		}
+	}
+	return result
+}
+
+// Binds the port to check that nothing else is listening on it.
+func checkLocalPortAvailable(host string, port int32) error {
+	if host == "" {
+		host = "localhost"
+	}
+	l, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+	if err != nil {
+		return err
+	}
+	return l.Close()
+}
@@ -1,9 +1,12 @@
 package portforward
 
 import (
+	"fmt"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
 )
 
 type PortForward = v1alpha1.PortForward
@@ -12,3 +15,14 @@ type PortForwardStatus = v1alpha1.PortForwardStatus
 type ObjectMeta = metav1.ObjectMeta
 type Forward = v1alpha1.Forward
 type ForwardStatus = v1alpha1.ForwardStatus
+
+// ForwardID identifies a single forward, independent of the pod
+// it's currently forwarding to.
+type ForwardID struct {
+	ManifestName model.ManifestName
+	LocalPort    int32
+}
+
+func (id ForwardID) String() string {
+	return fmt.Sprintf("%s:%d", id.ManifestName, id.LocalPort)
+}
@@ -0,0 +1,121 @@
+package portforward
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tilt-dev/tilt/pkg/apis"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/logger"
+)
+
+// How often to re-probe a forward that hasn't passed verification yet.
+const defaultVerifyPeriod = time.Second
+
+// How long a single probe can take.
+const verifyTimeout = time.Second
+
+// A forward accepts connections on the local port even when nothing in the
+// pod is listening, then closes them once it fails to reach the pod. So a
+// TCP probe only passes if the connection is still open after this long.
+const verifyTCPHoldTime = 250 * time.Millisecond
+
+// Probes the forward's local port until it reaches a listening server, then
+// marks the forward verified.
+//
+// Stops early if the forward disconnects (doneCh closes) or is stopped.
+// The next connection starts a new round of probes.
+func (r *Reconciler) verifyForward(ctx context.Context, doneCh <-chan struct{}, entry *portForwardEntry, forward Forward, status ForwardStatus) {
+	ticker := time.NewTicker(r.verifyPeriod)
+	defer ticker.Stop()
+
+	for {
+		err := probeForward(ctx, forward.Host, status.LocalPort, forward.Verify)
+		if err == nil {
+			if entry.setVerified(forward, status.StartedAt, apis.NowMicro()) {
+				r.updateForwardStatus(ctx, entry)
+			}
+			return
+		}
+
+		// Servers usually take a little while to start listening, so this
+		// is expected. Don't clutter the logs with it.
+		logger.Get(ctx).Debugf("Verifying port-forward %s: %v", entry.forwardID(forward), err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-doneCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func probeForward(ctx context.Context, host string, localPort int32, verify *v1alpha1.ForwardVerify) error {
+	if host == "" {
+		host = "localhost"
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(int(localPort)))
+
+	ctx, cancel := context.WithTimeout(ctx, verifyTimeout)
+	defer cancel()
+
+	if verify.HTTPPath != "" {
+		return probeHTTP(ctx, addr, verify.HTTPPath)
+	}
+	return probeTCP(ctx, addr)
+}
+
+func probeHTTP(ctx context.Context, addr string, path string) error {
+	u := fmt.Sprintf("http://%s/%s", addr, strings.TrimPrefix(path, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+
+	// Don't re-use connections, so that each probe goes through the forward
+	// to the pod.
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 10*1<<10))
+	_ = resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return nil
+}
+
+func probeTCP(ctx context.Context, addr string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	err = conn.SetReadDeadline(time.Now().Add(verifyTCPHoldTime))
+	if err != nil {
+		return err
+	}
+
+	// Most servers wait for the client to speak first, so a timeout means
+	// the connection is alive. A server that speaks first is alive too.
+	_, err = conn.Read(make([]byte, 1))
+	var netErr net.Error
+	if err == nil || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return nil
+	}
+	return fmt.Errorf("connection to %s closed: %v", addr, err)
+}
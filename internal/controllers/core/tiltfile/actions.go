@@ -29,7 +29,7 @@ type ConfigsReloadedAction struct {
 
 	FinishTime           time.Time
 	Err                  error
-	Warnings             []string
+	Warnings             []model.TiltfileWarning
 	Features             map[string]bool
 	TeamID               string
 	TelemetrySettings    model.TelemetrySettings
@@ -39,6 +39,13 @@ type ConfigsReloadedAction struct {
 	VersionSettings      model.VersionSettings
 	UpdateSettings       model.UpdateSettings
 	WatchSettings        model.WatchSettings
+	ImageRegistry        model.ImageRegistry
+
+	// Set when the Tiltfile loaded successfully, but didn't enable any resources.
+	NoResourcesReason string
+
+	// What happened to each bootstrap task, if we got far enough to run them.
+	BootstrapTasks []model.BootstrapTaskStatus
 
 	// A checkpoint into the logstore when Tiltfile execution started.
 	// Useful for knowing how far back in time we have to scrub secrets.
@@ -10,12 +10,16 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/errors"
-	"sigs.k8s.io/controller-runtime/pkg/cache"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"github.com/tilt-dev/tilt/internal/controllers/apicmp"
+	"github.com/tilt-dev/tilt/internal/controllers/apiretry"
+	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
+	"github.com/tilt-dev/tilt/internal/controllers/apis/debugcontainer"
+	"github.com/tilt-dev/tilt/internal/controllers/apis/debugoverride"
 	"github.com/tilt-dev/tilt/internal/controllers/apis/liveupdate"
+	"github.com/tilt-dev/tilt/internal/controllers/apis/pin"
 	"github.com/tilt-dev/tilt/internal/controllers/apiset"
 	"github.com/tilt-dev/tilt/internal/controllers/indexer"
 	"github.com/tilt-dev/tilt/internal/feature"
@@ -45,7 +49,7 @@ type disableSourceMap map[model.ManifestName]*v1alpha1.DisableSource
 //
 // In the future, anything that creates objects based on the Tiltfile (e.g., FileWatch specs,
 // LocalServer specs) should go here.
-func updateOwnedObjects(ctx context.Context, client ctrlclient.Client, nn types.NamespacedName,
+func updateOwnedObjects(ctx context.Context, client ctrlclient.Client, updates *updateTracker, nn types.NamespacedName,
 	tf *v1alpha1.Tiltfile, tlr *tiltfile.TiltfileLoadResult, mode store.EngineMode) error {
 
 	disableSources := toDisableSources(tlr)
@@ -76,24 +80,18 @@ func updateOwnedObjects(ctx context.Context, client ctrlclient.Client, nn types.
 		}
 	}
 
-	// Retry until the cache has started.
-	var retryCount = 0
+	// On the first load, the API server may still be starting up.
 	var existingObjects apiset.ObjectSet
-	var err error
-	for {
+	err := apiretry.Do(ctx, func() error {
+		var err error
 		existingObjects, err = getExistingAPIObjects(ctx, client, nn)
-		if err != nil {
-			if _, ok := err.(*cache.ErrCacheNotStarted); ok && retryCount < 5 {
-				retryCount++
-				time.Sleep(200 * time.Millisecond)
-				continue
-			}
-			return err
-		}
-		break
+		return err
+	})
+	if err != nil {
+		return err
 	}
 
-	err = updateNewObjects(ctx, client, apiObjects, existingObjects)
+	err = updateNewObjects(ctx, client, updates, apiObjects, existingObjects)
 	if err != nil {
 		return err
 	}
@@ -162,6 +160,7 @@ var typesToReconcile = append([]apiset.Object{
 	&v1alpha1.ImageMap{},
 	&v1alpha1.UIResource{},
 	&v1alpha1.LiveUpdate{},
+	&v1alpha1.ToggleButton{},
 }, typesWithTiltfileBuiltins...)
 
 // Fetch all the existing API objects that were generated from the Tiltfile.
@@ -212,6 +211,12 @@ func toAPIObjects(
 		for k, obj := range toDisableConfigMaps(disableSources) {
 			cmMap[k] = obj
 		}
+		for k, obj := range toDebugOverrideConfigMaps(tlr) {
+			cmMap[k] = obj
+		}
+		for k, obj := range toPinConfigMaps(tlr) {
+			cmMap[k] = obj
+		}
 
 		updateCmds := toCmdObjects(tlr, disableSources)
 		cmdMap := result.GetOrCreateTypedSet(&v1alpha1.Cmd{})
@@ -219,7 +224,21 @@ func toAPIObjects(
 			cmdMap[key] = cmd
 		}
 
-		result.AddSetForType(&v1alpha1.ToggleButton{}, toToggleButtons(tlr, disableSources))
+		tbMap := result.GetOrCreateTypedSet(&v1alpha1.ToggleButton{})
+		for k, obj := range toToggleButtons(tlr, disableSources) {
+			tbMap[k] = obj
+		}
+		for k, obj := range toDebugOverrideToggleButtons(tlr) {
+			tbMap[k] = obj
+		}
+		for k, obj := range toPinToggleButtons(tlr) {
+			tbMap[k] = obj
+		}
+
+		buttonMap := result.GetOrCreateTypedSet(&v1alpha1.UIButton{})
+		for k, obj := range toDebugContainerUIButtons(tlr) {
+			buttonMap[k] = obj
+		}
 	}
 
 	result.AddSetForType(&v1alpha1.UIResource{}, toUIResourceObjects(tf, tlr, disableSources))
@@ -232,6 +251,7 @@ func toAPIObjects(
 	if tlr != nil {
 		watchInputs.Manifests = tlr.Manifests
 		watchInputs.ConfigFiles = tlr.ConfigFiles
+		watchInputs.ConfigGlobs = tlr.ConfigGlobs
 		watchInputs.Tiltignore = tlr.Tiltignore
 		watchInputs.WatchSettings = tlr.WatchSettings
 	}
@@ -248,19 +268,14 @@ func toAPIObjects(
 	return result
 }
 
-func disableConfigMapName(manifest model.Manifest) string {
-	return fmt.Sprintf("%s-disable", manifest.Name)
-}
-
 func toDisableSources(tlr *tiltfile.TiltfileLoadResult) disableSourceMap {
 	result := make(disableSourceMap)
 	if tlr != nil {
 		for _, m := range tlr.Manifests {
-			name := disableConfigMapName(m)
 			ds := &v1alpha1.DisableSource{
 				ConfigMap: &v1alpha1.ConfigMapDisableSource{
-					Name: name,
-					Key:  "isDisabled",
+					Name: configmap.DisableConfigMapName(m.Name),
+					Key:  configmap.DisableKey,
 				},
 			}
 			result[m.Name] = ds
@@ -269,8 +284,11 @@ func toDisableSources(tlr *tiltfile.TiltfileLoadResult) disableSourceMap {
 	return result
 }
 
+// Resources start enabled. Once a ConfigMap exists, updateNewObjects keeps
+// its data, so this default (and its history entry) only applies on creation.
 func toDisableConfigMaps(disableSources disableSourceMap) apiset.TypedObjectSet {
 	result := apiset.TypedObjectSet{}
+	now := time.Now()
 	for _, ds := range disableSources {
 		cm := &v1alpha1.ConfigMap{
 			ObjectMeta: metav1.ObjectMeta{
@@ -278,6 +296,7 @@ func toDisableConfigMaps(disableSources disableSourceMap) apiset.TypedObjectSet
 			},
 			Data: map[string]string{ds.ConfigMap.Key: "false"},
 		}
+		configmap.RecordDisableChange(cm, false, v1alpha1.DisableChangeSourceTiltfile, now)
 		result[cm.Name] = cm
 	}
 	return result
@@ -324,6 +343,76 @@ func toToggleButtons(tlr *tiltfile.TiltfileLoadResult, disableSources disableSou
 	return result
 }
 
+// Pulls out the ConfigMaps that store debug overrides declared in the Tiltfile.
+func toDebugOverrideConfigMaps(tlr *tiltfile.TiltfileLoadResult) apiset.TypedObjectSet {
+	result := apiset.TypedObjectSet{}
+	for _, m := range tlr.Manifests {
+		if !m.IsK8s() || m.K8sTarget().DebugOverride == nil {
+			continue
+		}
+		cm := debugoverride.ToConfigMap(m.Name.String(), *m.K8sTarget().DebugOverride)
+		result[cm.Name] = cm
+	}
+	return result
+}
+
+// Pulls out the buttons that turn debug overrides on and off.
+func toDebugOverrideToggleButtons(tlr *tiltfile.TiltfileLoadResult) apiset.TypedObjectSet {
+	result := apiset.TypedObjectSet{}
+	for _, m := range tlr.Manifests {
+		if !m.IsK8s() || m.K8sTarget().DebugOverride == nil {
+			continue
+		}
+		tb := debugoverride.ToToggleButton(m.Name.String())
+		result[tb.Name] = tb
+	}
+	return result
+}
+
+// Creates the ConfigMaps that pin Kubernetes resources to their current pods.
+func toPinConfigMaps(tlr *tiltfile.TiltfileLoadResult) apiset.TypedObjectSet {
+	result := apiset.TypedObjectSet{}
+	for _, m := range tlr.Manifests {
+		if !m.IsK8s() {
+			continue
+		}
+		cm := pin.ToConfigMap(m.Name.String())
+		result[cm.Name] = cm
+	}
+	return result
+}
+
+// Creates the buttons that pin and unpin Kubernetes resources.
+func toPinToggleButtons(tlr *tiltfile.TiltfileLoadResult) apiset.TypedObjectSet {
+	result := apiset.TypedObjectSet{}
+	for _, m := range tlr.Manifests {
+		if !m.IsK8s() {
+			continue
+		}
+		tb := pin.ToToggleButton(m.Name.String())
+		result[tb.Name] = tb
+	}
+	return result
+}
+
+// Creates the buttons that attach debug containers to Kubernetes resources.
+func toDebugContainerUIButtons(tlr *tiltfile.TiltfileLoadResult) apiset.TypedObjectSet {
+	image := tlr.UpdateSettings.DebugContainerImage
+	if image == "" {
+		image = model.DefaultDebugContainerImage
+	}
+
+	result := apiset.TypedObjectSet{}
+	for _, m := range tlr.Manifests {
+		if !m.IsK8s() {
+			continue
+		}
+		b := debugcontainer.ToUIButton(m.Name.String(), image)
+		result[b.Name] = b
+	}
+	return result
+}
+
 // Pulls out all the KubernetesApply objects generated by the Tiltfile.
 func toKubernetesApplyObjects(tlr *tiltfile.TiltfileLoadResult, disableSources disableSourceMap) apiset.TypedObjectSet {
 	result := apiset.TypedObjectSet{}
@@ -337,11 +426,11 @@ func toKubernetesApplyObjects(tlr *tiltfile.TiltfileLoadResult, disableSources d
 		ka := &v1alpha1.KubernetesApply{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: name,
-				Annotations: map[string]string{
+				Annotations: manifestAnnotations(m, map[string]string{
 					v1alpha1.AnnotationManifest:  name,
 					v1alpha1.AnnotationSpanID:    fmt.Sprintf("kubernetesapply:%s", name),
 					v1alpha1.AnnotationManagedBy: "buildcontrol",
-				},
+				}),
 			},
 			Spec: kTarget.KubernetesApplySpec,
 		}
@@ -438,11 +527,11 @@ func toCmdObjects(tlr *tiltfile.TiltfileLoadResult, disableSources disableSource
 		cmd := &v1alpha1.Cmd{
 			ObjectMeta: metav1.ObjectMeta{
 				Name: name,
-				Annotations: map[string]string{
+				Annotations: manifestAnnotations(m, map[string]string{
 					v1alpha1.AnnotationManifest:  m.Name.String(),
 					v1alpha1.AnnotationSpanID:    fmt.Sprintf("cmd:%s", name),
 					v1alpha1.AnnotationManagedBy: "local_resource",
-				},
+				}),
 			},
 			Spec: *cmdSpec,
 		}
@@ -464,9 +553,9 @@ func toUIResourceObjects(tf *v1alpha1.Tiltfile, tlr *tiltfile.TiltfileLoadResult
 				ObjectMeta: metav1.ObjectMeta{
 					Name:   name,
 					Labels: m.Labels,
-					Annotations: map[string]string{
+					Annotations: manifestAnnotations(m, map[string]string{
 						v1alpha1.AnnotationManifest: m.Name.String(),
-					},
+					}),
 				},
 			}
 
@@ -494,8 +583,21 @@ func toUIResourceObjects(tf *v1alpha1.Tiltfile, tlr *tiltfile.TiltfileLoadResult
 	return result
 }
 
+// The annotations for an API object generated for the manifest: the
+// annotations from the Tiltfile, plus Tilt's own, which take precedence.
+func manifestAnnotations(m model.Manifest, tiltAnnotations map[string]string) map[string]string {
+	result := make(map[string]string, len(m.Annotations)+len(tiltAnnotations))
+	for k, v := range m.Annotations {
+		result[k] = v
+	}
+	for k, v := range tiltAnnotations {
+		result[k] = v
+	}
+	return result
+}
+
 // Reconcile the new API objects against the existing API objects.
-func updateNewObjects(ctx context.Context, client ctrlclient.Client, newObjects, oldObjects apiset.ObjectSet) error {
+func updateNewObjects(ctx context.Context, client ctrlclient.Client, updates *updateTracker, newObjects, oldObjects apiset.ObjectSet) error {
 	// TODO(nick): Does it make sense to parallelize the API calls?
 	errs := []error{}
 
@@ -516,21 +618,34 @@ func updateNewObjects(ctx context.Context, client ctrlclient.Client, newObjects,
 				continue
 			}
 
+			dataChanged := false
+			if cm, ok := obj.(*v1alpha1.ConfigMap); ok {
+				// Tiltfiles can create ConfigMaps with default values, but
+				// they shouldn't blow away values that were modified elsewhere.
+				//
+				// The exception is debug overrides, where the Tiltfile owns
+				// everything except whether the override is enabled.
+				oldCM, err := configmap.Upgraded(old.(*v1alpha1.ConfigMap))
+				if err != nil {
+					errs = append(errs, fmt.Errorf("update %s/%s: %v", obj.GetGroupVersionResource().Resource, obj.GetName(), err))
+					continue
+				}
+				for k, v := range oldCM.Data {
+					if debugoverride.IsTiltfileOwnedKey(cm, k) {
+						continue
+					}
+					cm.Data[k] = v
+				}
+				dataChanged = !apicmp.DeepEqual(oldCM.Data, cm.Data)
+			}
+
 			// Are there other fields here we should check?
-			specChanged := !apicmp.DeepEqual(old.GetSpec(), obj.GetSpec())
+			specChanged := !apicmp.DeepEqual(canonicalSpec(old.GetSpec()), canonicalSpec(obj.GetSpec()))
 			labelsChanged := !apicmp.DeepEqual(old.GetLabels(), obj.GetLabels())
 			annsChanged := !apicmp.DeepEqual(old.GetAnnotations(), obj.GetAnnotations())
-			if specChanged || labelsChanged || annsChanged {
+			if specChanged || labelsChanged || annsChanged || dataChanged {
+				updates.record(ctx, old, obj)
 				obj.SetResourceVersion(old.GetResourceVersion())
-				if cm, ok := obj.(*v1alpha1.ConfigMap); ok {
-					// Tiltfiles can create ConfigMaps with default values, but
-					// they shouldn't blow away values that were modified elsewhere.
-					// At some point, we might have a use case that contradicts this and
-					// need to make this more complicated.
-					for k, v := range old.(*v1alpha1.ConfigMap).Data {
-						cm.Data[k] = v
-					}
-				}
 				err := client.Update(ctx, obj)
 				if err != nil {
 					errs = append(errs, fmt.Errorf("update %s/%s: %v", obj.GetGroupVersionResource().Resource, obj.GetName(), err))
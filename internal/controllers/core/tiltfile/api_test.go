@@ -4,22 +4,27 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/jonboulle/clockwork"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
+	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
 	"github.com/tilt-dev/tilt/internal/controllers/fake"
 	"github.com/tilt-dev/tilt/internal/feature"
 	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
 	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/testutils/bufsync"
 	"github.com/tilt-dev/tilt/internal/testutils/manifestbuilder"
 	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
 	"github.com/tilt-dev/tilt/internal/tiltfile"
 	"github.com/tilt-dev/tilt/pkg/apis"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/logger"
 	"github.com/tilt-dev/tilt/pkg/model"
 )
 
@@ -32,7 +37,7 @@ func TestAPICreate(t *testing.T) {
 	fe := manifestbuilder.New(f, "fe").WithK8sYAML(testyaml.SanchoYAML).Build()
 	nn := types.NamespacedName{Name: "tiltfile"}
 	tf := &v1alpha1.Tiltfile{ObjectMeta: metav1.ObjectMeta{Name: "tiltfile"}}
-	err := updateOwnedObjects(ctx, c, nn, tf,
+	err := updateOwnedObjects(ctx, c, newUpdateTracker(clockwork.NewRealClock()), nn, tf,
 		&tiltfile.TiltfileLoadResult{Manifests: []model.Manifest{fe}}, store.EngineModeUp)
 	assert.NoError(t, err)
 
@@ -41,6 +46,41 @@ func TestAPICreate(t *testing.T) {
 	assert.Contains(t, ka.Spec.YAML, "name: sancho")
 }
 
+func TestAPICreateWithResourceAnnotations(t *testing.T) {
+	f := tempdir.NewTempDirFixture(t)
+	defer f.TearDown()
+
+	ctx := context.Background()
+	c := fake.NewFakeTiltClient()
+	anns := map[string]string{
+		"example.com/team":          "web",
+		v1alpha1.AnnotationManifest: "not-fe",
+	}
+	fe := manifestbuilder.New(f, "fe").WithK8sYAML(testyaml.SanchoYAML).Build().WithAnnotations(anns)
+	be := manifestbuilder.New(f, "be").WithLocalResource("make", "", nil).Build().WithAnnotations(anns)
+	nn := types.NamespacedName{Name: "tiltfile"}
+	tf := &v1alpha1.Tiltfile{ObjectMeta: metav1.ObjectMeta{Name: "tiltfile"}}
+	err := updateOwnedObjects(ctx, c, newUpdateTracker(clockwork.NewRealClock()), nn, tf,
+		&tiltfile.TiltfileLoadResult{Manifests: []model.Manifest{fe, be}}, store.EngineModeUp)
+	require.NoError(t, err)
+
+	// Tilt's own annotations take precedence.
+	var ka v1alpha1.KubernetesApply
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: "fe"}, &ka))
+	assert.Equal(t, "web", ka.Annotations["example.com/team"])
+	assert.Equal(t, "fe", ka.Annotations[v1alpha1.AnnotationManifest])
+
+	var cmd v1alpha1.Cmd
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: be.LocalTarget().UpdateCmdName()}, &cmd))
+	assert.Equal(t, "web", cmd.Annotations["example.com/team"])
+	assert.Equal(t, "be", cmd.Annotations[v1alpha1.AnnotationManifest])
+
+	var uir v1alpha1.UIResource
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: "fe"}, &uir))
+	assert.Equal(t, "web", uir.Annotations["example.com/team"])
+	assert.Equal(t, "fe", uir.Annotations[v1alpha1.AnnotationManifest])
+}
+
 func TestAPIDelete(t *testing.T) {
 	f := tempdir.NewTempDirFixture(t)
 	defer f.TearDown()
@@ -50,14 +90,14 @@ func TestAPIDelete(t *testing.T) {
 	fe := manifestbuilder.New(f, "fe").WithK8sYAML(testyaml.SanchoYAML).Build()
 	nn := types.NamespacedName{Name: "tiltfile"}
 	tf := &v1alpha1.Tiltfile{ObjectMeta: metav1.ObjectMeta{Name: "tiltfile"}}
-	err := updateOwnedObjects(ctx, c, nn, tf,
+	err := updateOwnedObjects(ctx, c, newUpdateTracker(clockwork.NewRealClock()), nn, tf,
 		&tiltfile.TiltfileLoadResult{Manifests: []model.Manifest{fe}}, store.EngineModeUp)
 	assert.NoError(t, err)
 
 	var ka1 v1alpha1.KubernetesApply
 	assert.NoError(t, c.Get(ctx, types.NamespacedName{Name: "fe"}, &ka1))
 
-	err = updateOwnedObjects(ctx, c, nn, tf,
+	err = updateOwnedObjects(ctx, c, newUpdateTracker(clockwork.NewRealClock()), nn, tf,
 		&tiltfile.TiltfileLoadResult{Manifests: []model.Manifest{}}, store.EngineModeUp)
 	assert.NoError(t, err)
 
@@ -77,14 +117,14 @@ func TestAPINoGarbageCollectOnError(t *testing.T) {
 	fe := manifestbuilder.New(f, "fe").WithK8sYAML(testyaml.SanchoYAML).Build()
 	nn := types.NamespacedName{Name: "tiltfile"}
 	tf := &v1alpha1.Tiltfile{ObjectMeta: metav1.ObjectMeta{Name: "tiltfile"}}
-	err := updateOwnedObjects(ctx, c, nn, tf,
+	err := updateOwnedObjects(ctx, c, newUpdateTracker(clockwork.NewRealClock()), nn, tf,
 		&tiltfile.TiltfileLoadResult{Manifests: []model.Manifest{fe}}, store.EngineModeUp)
 	assert.NoError(t, err)
 
 	var ka1 v1alpha1.KubernetesApply
 	assert.NoError(t, c.Get(ctx, types.NamespacedName{Name: "fe"}, &ka1))
 
-	err = updateOwnedObjects(ctx, c, nn, tf, &tiltfile.TiltfileLoadResult{
+	err = updateOwnedObjects(ctx, c, newUpdateTracker(clockwork.NewRealClock()), nn, tf, &tiltfile.TiltfileLoadResult{
 		Error:     fmt.Errorf("random failure"),
 		Manifests: []model.Manifest{},
 	}, store.EngineModeUp)
@@ -104,7 +144,7 @@ func TestAPIUpdate(t *testing.T) {
 	fe := manifestbuilder.New(f, "fe").WithK8sYAML(testyaml.SanchoYAML).Build()
 	nn := types.NamespacedName{Name: "tiltfile"}
 	tf := &v1alpha1.Tiltfile{ObjectMeta: metav1.ObjectMeta{Name: "tiltfile"}}
-	err := updateOwnedObjects(ctx, c, nn, tf,
+	err := updateOwnedObjects(ctx, c, newUpdateTracker(clockwork.NewRealClock()), nn, tf,
 		&tiltfile.TiltfileLoadResult{Manifests: []model.Manifest{fe}}, store.EngineModeUp)
 	assert.NoError(t, err)
 
@@ -114,7 +154,7 @@ func TestAPIUpdate(t *testing.T) {
 	assert.NotContains(t, ka.Spec.YAML, "sidecar")
 
 	fe = manifestbuilder.New(f, "fe").WithK8sYAML(testyaml.SanchoSidecarYAML).Build()
-	err = updateOwnedObjects(ctx, c, nn, tf,
+	err = updateOwnedObjects(ctx, c, newUpdateTracker(clockwork.NewRealClock()), nn, tf,
 		&tiltfile.TiltfileLoadResult{Manifests: []model.Manifest{fe}}, store.EngineModeUp)
 	assert.NoError(t, err)
 
@@ -123,6 +163,40 @@ func TestAPIUpdate(t *testing.T) {
 	assert.Contains(t, ka.Spec.YAML, "sidecar")
 }
 
+func TestAPIGarbageCollectsFileWatchesInCIMode(t *testing.T) {
+	f := tempdir.NewTempDirFixture(t)
+	defer f.TearDown()
+
+	ctx := context.Background()
+	c := fake.NewFakeTiltClient()
+	fe := manifestbuilder.New(f, "fe").WithLocalResource("echo hi", "", []string{f.Path()}).Build()
+	nn := types.NamespacedName{Name: "tiltfile"}
+	tf := &v1alpha1.Tiltfile{ObjectMeta: metav1.ObjectMeta{Name: "tiltfile"}}
+	err := updateOwnedObjects(ctx, c, newUpdateTracker(clockwork.NewRealClock()), nn, tf,
+		&tiltfile.TiltfileLoadResult{Manifests: []model.Manifest{fe}}, store.EngineModeUp)
+	assert.NoError(t, err)
+
+	var fwList v1alpha1.FileWatchList
+	assert.NoError(t, c.List(ctx, &fwList))
+	assert.NotEmpty(t, fwList.Items)
+
+	err = updateOwnedObjects(ctx, c, newUpdateTracker(clockwork.NewRealClock()), nn, tf,
+		&tiltfile.TiltfileLoadResult{Manifests: []model.Manifest{fe}}, store.EngineModeCI)
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.List(ctx, &fwList))
+	assert.Empty(t, fwList.Items)
+
+	err = updateOwnedObjects(ctx, c, newUpdateTracker(clockwork.NewRealClock()), nn, tf,
+		&tiltfile.TiltfileLoadResult{Manifests: []model.Manifest{fe.WithWatchInCI(true)}}, store.EngineModeCI)
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.List(ctx, &fwList))
+	if assert.Len(t, fwList.Items, 1) {
+		assert.Equal(t, "fe", fwList.Items[0].Annotations[v1alpha1.AnnotationManifest])
+	}
+}
+
 func TestImageMapCreate(t *testing.T) {
 	f := tempdir.NewTempDirFixture(t)
 	defer f.TearDown()
@@ -130,12 +204,12 @@ func TestImageMapCreate(t *testing.T) {
 	ctx := context.Background()
 	c := fake.NewFakeTiltClient()
 	fe := manifestbuilder.New(f, "fe").
-		WithImageTarget(NewSanchoDockerBuildImageTarget(f)).
+		WithImageTargets(NewSanchoDockerBuildImageTarget(f)).
 		WithK8sYAML(testyaml.SanchoYAML).
 		Build()
 	nn := types.NamespacedName{Name: "tiltfile"}
 	tf := &v1alpha1.Tiltfile{ObjectMeta: metav1.ObjectMeta{Name: "tiltfile"}}
-	err := updateOwnedObjects(ctx, c, nn, tf,
+	err := updateOwnedObjects(ctx, c, newUpdateTracker(clockwork.NewRealClock()), nn, tf,
 		&tiltfile.TiltfileLoadResult{Manifests: []model.Manifest{fe}}, store.EngineModeUp)
 	assert.NoError(t, err)
 
@@ -161,11 +235,11 @@ func TestAPITwoTiltfiles(t *testing.T) {
 	nnB := types.NamespacedName{Name: "tiltfile-b"}
 	tfB := &v1alpha1.Tiltfile{ObjectMeta: metav1.ObjectMeta{Name: "tiltfile-b"}}
 
-	err := updateOwnedObjects(ctx, c, nnA, tfA,
+	err := updateOwnedObjects(ctx, c, newUpdateTracker(clockwork.NewRealClock()), nnA, tfA,
 		&tiltfile.TiltfileLoadResult{Manifests: []model.Manifest{feA}}, store.EngineModeUp)
 	assert.NoError(t, err)
 
-	err = updateOwnedObjects(ctx, c, nnB, tfB,
+	err = updateOwnedObjects(ctx, c, newUpdateTracker(clockwork.NewRealClock()), nnB, tfB,
 		&tiltfile.TiltfileLoadResult{Manifests: []model.Manifest{feB}}, store.EngineModeUp)
 	assert.NoError(t, err)
 
@@ -175,7 +249,7 @@ func TestAPITwoTiltfiles(t *testing.T) {
 	assert.NoError(t, c.Get(ctx, types.NamespacedName{Name: "fe-b"}, &ka))
 	assert.Contains(t, ka.Name, "fe-b")
 
-	err = updateOwnedObjects(ctx, c, nnA, nil, nil, store.EngineModeUp)
+	err = updateOwnedObjects(ctx, c, newUpdateTracker(clockwork.NewRealClock()), nnA, nil, nil, store.EngineModeUp)
 	assert.NoError(t, err)
 
 	// Assert that fe-a was deleted but fe-b was not.
@@ -195,13 +269,13 @@ func TestCreateUiResourceForTiltfile(t *testing.T) {
 	ctx := context.Background()
 	c := fake.NewFakeTiltClient()
 	fe := manifestbuilder.New(f, "fe").
-		WithImageTarget(NewSanchoDockerBuildImageTarget(f)).
+		WithImageTargets(NewSanchoDockerBuildImageTarget(f)).
 		WithK8sYAML(testyaml.SanchoYAML).
 		Build()
-	lr := manifestbuilder.New(f, "be").WithLocalResource("ls", []string{"be"}).Build()
+	lr := manifestbuilder.New(f, "be").WithLocalResource("ls", "", []string{"be"}).Build()
 	nn := types.NamespacedName{Name: "tiltfile"}
 	tf := &v1alpha1.Tiltfile{ObjectMeta: metav1.ObjectMeta{Name: "tiltfile", Labels: map[string]string{"some": "sweet-label"}}}
-	err := updateOwnedObjects(ctx, c, nn, tf,
+	err := updateOwnedObjects(ctx, c, newUpdateTracker(clockwork.NewRealClock()), nn, tf,
 		&tiltfile.TiltfileLoadResult{Manifests: []model.Manifest{fe, lr}}, store.EngineModeUp)
 	assert.NoError(t, err)
 
@@ -221,13 +295,13 @@ func TestDisableObjects(t *testing.T) {
 			ctx := context.Background()
 			c := fake.NewFakeTiltClient()
 			fe := manifestbuilder.New(f, "fe").
-				WithImageTarget(NewSanchoDockerBuildImageTarget(f)).
+				WithImageTargets(NewSanchoDockerBuildImageTarget(f)).
 				WithK8sYAML(testyaml.SanchoYAML).
 				Build()
-			lr := manifestbuilder.New(f, "be").WithLocalResource("ls", []string{"be"}).Build()
+			lr := manifestbuilder.New(f, "be").WithLocalResource("ls", "", []string{"be"}).Build()
 			nn := types.NamespacedName{Name: "tiltfile"}
 			tf := &v1alpha1.Tiltfile{ObjectMeta: metav1.ObjectMeta{Name: "tiltfile"}}
-			err := updateOwnedObjects(ctx, c, nn, tf,
+			err := updateOwnedObjects(ctx, c, newUpdateTracker(clockwork.NewRealClock()), nn, tf,
 				&tiltfile.TiltfileLoadResult{
 					Manifests:    []model.Manifest{fe, lr},
 					FeatureFlags: map[string]bool{feature.DisableResources: disableFeatureOn},
@@ -303,19 +377,168 @@ func TestUpdateDisableSource(t *testing.T) {
 	fe := manifestbuilder.New(f, "fe").WithK8sYAML(testyaml.SanchoYAML).Build()
 	nn := types.NamespacedName{Name: "tiltfile"}
 	tf := &v1alpha1.Tiltfile{ObjectMeta: metav1.ObjectMeta{Name: "tiltfile"}}
-	err := updateOwnedObjects(ctx, c, nn, tf,
+	err := updateOwnedObjects(ctx, c, newUpdateTracker(clockwork.NewRealClock()), nn, tf,
 		&tiltfile.TiltfileLoadResult{Manifests: []model.Manifest{fe}}, store.EngineModeUp)
 	assert.NoError(t, err)
 
 	var cm v1alpha1.ConfigMap
 	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: "fe-disable"}, &cm))
-	cm.Data["isDisabled"] = "true"
+	history := configmap.DisableHistory(&cm)
+	require.Len(t, history, 1)
+	require.Equal(t, v1alpha1.DisableChangeSourceTiltfile, history[0].Source)
+
+	configmap.RecordDisableChange(&cm, true, v1alpha1.DisableChangeSourceCLI, time.Now())
 	require.NoError(t, c.Update(ctx, &cm))
 
-	err = updateOwnedObjects(ctx, c, nn, tf,
+	err = updateOwnedObjects(ctx, c, newUpdateTracker(clockwork.NewRealClock()), nn, tf,
 		&tiltfile.TiltfileLoadResult{Manifests: []model.Manifest{fe}}, store.EngineModeUp)
 	assert.NoError(t, err)
 
 	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: cm.Name}, &cm))
 	require.Equal(t, "true", cm.Data["isDisabled"])
+
+	// The reload doesn't add another Tiltfile entry to the history.
+	history = configmap.DisableHistory(&cm)
+	require.Len(t, history, 2)
+	require.Equal(t, v1alpha1.DisableChangeSourceCLI, history[1].Source)
+}
+
+func TestUpdateDebugOverride(t *testing.T) {
+	f := tempdir.NewTempDirFixture(t)
+	defer f.TearDown()
+
+	ctx := context.Background()
+	c := fake.NewFakeTiltClient()
+	fe := manifestbuilder.New(f, "fe").WithK8sYAML(testyaml.SanchoYAML).Build()
+	fe = fe.WithDeployTarget(fe.K8sTarget().WithDebugOverride(&model.K8sDebugOverride{
+		Command: []string{"dlv", "exec", "/app/sancho"},
+	}))
+	nn := types.NamespacedName{Name: "tiltfile"}
+	tf := &v1alpha1.Tiltfile{ObjectMeta: metav1.ObjectMeta{Name: "tiltfile"}}
+	err := updateOwnedObjects(ctx, c, newUpdateTracker(clockwork.NewRealClock()), nn, tf,
+		&tiltfile.TiltfileLoadResult{Manifests: []model.Manifest{fe}}, store.EngineModeUp)
+	assert.NoError(t, err)
+
+	var tb v1alpha1.ToggleButton
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: "fe-debug-override"}, &tb))
+	assert.Equal(t, "fe-debug-override", tb.Spec.StateSource.ConfigMap.Name)
+
+	var cm v1alpha1.ConfigMap
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: "fe-debug-override"}, &cm))
+	assert.Equal(t, "false", cm.Data["enabled"])
+	cm.Data["enabled"] = "true"
+	require.NoError(t, c.Update(ctx, &cm))
+
+	// Changing the override in the Tiltfile updates the ConfigMap,
+	// but keeps it enabled.
+	fe = fe.WithDeployTarget(fe.K8sTarget().WithDebugOverride(&model.K8sDebugOverride{
+		Command: []string{"sleep", "infinity"},
+	}))
+	err = updateOwnedObjects(ctx, c, newUpdateTracker(clockwork.NewRealClock()), nn, tf,
+		&tiltfile.TiltfileLoadResult{Manifests: []model.Manifest{fe}}, store.EngineModeUp)
+	assert.NoError(t, err)
+
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: cm.Name}, &cm))
+	assert.Equal(t, "true", cm.Data["enabled"])
+	assert.Equal(t, `["sleep","infinity"]`, cm.Data["command"])
+}
+
+const reorderedYAMLBefore = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  labels:
+    app: fe
+    tier: web
+data:
+  a: "1"
+  b: "2"
+`
+
+const reorderedYAMLAfter = `
+kind: ConfigMap
+apiVersion: v1
+data:
+  b: "2"
+  a: "1"
+metadata:
+  labels:
+    tier: web
+    app: fe
+  name: settings
+
+`
+
+func TestAPINoUpdateWhenYAMLIsReordered(t *testing.T) {
+	f := tempdir.NewTempDirFixture(t)
+	defer f.TearDown()
+
+	ctx := context.Background()
+	c := fake.NewFakeTiltClient()
+	nn := types.NamespacedName{Name: "tiltfile"}
+	tf := &v1alpha1.Tiltfile{ObjectMeta: metav1.ObjectMeta{Name: "tiltfile"}}
+	updates := newUpdateTracker(clockwork.NewRealClock())
+
+	fe := withRawYAML(manifestbuilder.New(f, "fe").WithK8sYAML(testyaml.SanchoYAML).Build(), reorderedYAMLBefore)
+	err := updateOwnedObjects(ctx, c, updates, nn, tf,
+		&tiltfile.TiltfileLoadResult{Manifests: []model.Manifest{fe}}, store.EngineModeUp)
+	require.NoError(t, err)
+
+	var ka1 v1alpha1.KubernetesApply
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: "fe"}, &ka1))
+
+	// The same objects, with the map keys in a different order.
+	fe = withRawYAML(fe, reorderedYAMLAfter)
+	err = updateOwnedObjects(ctx, c, updates, nn, tf,
+		&tiltfile.TiltfileLoadResult{Manifests: []model.Manifest{fe}}, store.EngineModeUp)
+	require.NoError(t, err)
+
+	var ka2 v1alpha1.KubernetesApply
+	require.NoError(t, c.Get(ctx, types.NamespacedName{Name: "fe"}, &ka2))
+	assert.Equal(t, ka1.ResourceVersion, ka2.ResourceVersion)
+	assert.Equal(t, reorderedYAMLBefore, ka2.Spec.YAML)
+}
+
+func TestUpdateTrackerWarnsOnUpdateStorm(t *testing.T) {
+	out := bufsync.NewThreadSafeBuffer()
+	ctx := logger.WithLogger(context.Background(), logger.NewTestLogger(out))
+	clock := clockwork.NewFakeClock()
+	updates := newUpdateTracker(clock)
+
+	old := &v1alpha1.Cmd{
+		ObjectMeta: metav1.ObjectMeta{Name: "fe-update"},
+		Spec:       v1alpha1.CmdSpec{Args: []string{"echo", "hi"}},
+	}
+	obj := old.DeepCopy()
+	obj.Spec.Env = []string{"FOO=bar"}
+
+	for i := 0; i < updateStormCount; i++ {
+		updates.record(ctx, old, obj)
+		clock.Advance(time.Second)
+	}
+	assert.NotContains(t, out.String(), "has been updated")
+
+	updates.record(ctx, old, obj)
+	assert.Contains(t, out.String(), "cmds fe-update has been updated 5 times")
+	assert.Contains(t, out.String(), `+    "env": [`)
+}
+
+func TestUpdateTrackerIgnoresChangingInput(t *testing.T) {
+	out := bufsync.NewThreadSafeBuffer()
+	ctx := logger.WithLogger(context.Background(), logger.NewTestLogger(out))
+	updates := newUpdateTracker(clockwork.NewFakeClock())
+
+	old := &v1alpha1.Cmd{ObjectMeta: metav1.ObjectMeta{Name: "fe-update"}}
+	for i := 0; i < 2*updateStormCount; i++ {
+		obj := old.DeepCopy()
+		obj.Spec.Args = []string{"echo", fmt.Sprintf("%d", i)}
+		updates.record(ctx, old, obj)
+	}
+	assert.NotContains(t, out.String(), "has been updated")
+}
+
+func withRawYAML(m model.Manifest, yaml string) model.Manifest {
+	kt := m.K8sTarget()
+	kt.KubernetesApplySpec.YAML = yaml
+	return m.WithDeployTarget(kt)
 }
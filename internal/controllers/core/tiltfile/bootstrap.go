@@ -0,0 +1,277 @@
+package tiltfile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/localexec"
+	"github.com/tilt-dev/tilt/internal/tiltfile"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// Bootstrap tasks record that they're done on a ConfigMap in the cluster,
+// one per project, so that they run once per cluster rather than on every
+// `tilt up`.
+//
+// The records live in annotations rather than data, because our Kubernetes
+// client only reads object metadata.
+const bootstrapConfigMapPrefix = "tilt-bootstrap-"
+const bootstrapAnnotationPrefix = "bootstrap.tilt.dev/"
+
+// The format of the bootstrap ConfigMap's annotations.
+//
+// Version 1 drops records that can't be parsed, so that their tasks run again.
+var bootstrapFormat = configmap.Format{
+	Name:       "the bootstrap ConfigMap",
+	Migrations: []configmap.Migration{migrateBootstrapV0},
+}
+
+func migrateBootstrapV0(annotations map[string]string) map[string]string {
+	for k, v := range annotations {
+		if !strings.HasPrefix(k, bootstrapAnnotationPrefix) {
+			continue
+		}
+		var record bootstrapRecord
+		if json.Unmarshal([]byte(v), &record) != nil || record.Hash == "" {
+			delete(annotations, k)
+		}
+	}
+	return annotations
+}
+
+// How long we're willing to wait for the cluster to apply a task's YAML.
+const bootstrapApplyTimeout = 30 * time.Second
+
+const bootstrapLogPrefix = " → "
+
+// When true, re-run every bootstrap task once this session, even if the
+// cluster says it's already done.
+type ForceBootstrapFlag bool
+
+type bootstrapRecord struct {
+	Hash    string    `json:"hash"`
+	LastRun time.Time `json:"lastRun"`
+}
+
+type bootstrapper struct {
+	mu     sync.Mutex
+	kCli   k8s.Client
+	cfgNS  k8s.Namespace
+	execer localexec.Execer
+	force  bool
+
+	// The tasks that have succeeded in this session, by name.
+	session map[string]bootstrapRecord
+}
+
+func newBootstrapper(kCli k8s.Client, cfgNS k8s.Namespace, execer localexec.Execer, force ForceBootstrapFlag) *bootstrapper {
+	return &bootstrapper{
+		kCli:    kCli,
+		cfgNS:   cfgNS,
+		execer:  execer,
+		force:   bool(force),
+		session: make(map[string]bootstrapRecord),
+	}
+}
+
+// Runs the Tiltfile's bootstrap tasks that haven't run yet, in order.
+//
+// Stops at the first task that fails, and returns its error. Always returns
+// the status of every task.
+func (b *bootstrapper) run(ctx context.Context, tf *v1alpha1.Tiltfile, tlr *tiltfile.TiltfileLoadResult) ([]model.BootstrapTaskStatus, error) {
+	if len(tlr.BootstrapTasks) == 0 {
+		return nil, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	l := logger.Get(ctx)
+
+	// Projects that don't use a cluster only remember tasks for the session.
+	useCluster := usesCluster(tlr)
+	ref := bootstrapConfigMapRef(b.cfgNS, tf)
+	statuses := make([]model.BootstrapTaskStatus, 0, len(tlr.BootstrapTasks))
+	var clusterRecords map[string]bootstrapRecord
+	if useCluster && !b.force {
+		annotations, err := b.readAnnotations(ctx, ref)
+		if err != nil {
+			// Don't run tasks that a newer Tilt may have already run.
+			for _, task := range tlr.BootstrapTasks {
+				statuses = append(statuses, model.BootstrapTaskStatus{Name: task.Name, Error: err.Error()})
+			}
+			return statuses, fmt.Errorf("Reading bootstrap tasks from the cluster: %v", err)
+		}
+		clusterRecords = bootstrapRecordsFromAnnotations(annotations)
+	}
+
+	ran := make(map[string]bootstrapRecord)
+	var err error
+	for _, task := range tlr.BootstrapTasks {
+		status := model.BootstrapTaskStatus{Name: task.Name}
+		hash := task.Hash()
+		record, ok := b.session[task.Name]
+		if !ok {
+			record, ok = clusterRecords[task.Name]
+		}
+		if ok {
+			status.LastRunTime = record.LastRun
+		}
+
+		if ok && record.Hash == hash {
+			status.Skipped = true
+			statuses = append(statuses, status)
+			continue
+		}
+
+		// Later tasks may depend on the one that failed.
+		if err != nil {
+			statuses = append(statuses, status)
+			continue
+		}
+
+		l.Infof("Running bootstrap task %q", task.Name)
+		runErr := b.runTask(ctx, task)
+		if runErr != nil {
+			status.Error = runErr.Error()
+			err = fmt.Errorf("Bootstrap task %q failed: %v", task.Name, runErr)
+			statuses = append(statuses, status)
+			continue
+		}
+
+		record = bootstrapRecord{Hash: hash, LastRun: time.Now()}
+		b.session[task.Name] = record
+		ran[task.Name] = record
+		status.LastRunTime = record.LastRun
+		statuses = append(statuses, status)
+	}
+
+	if useCluster && len(ran) > 0 {
+		recordErr := b.recordInCluster(ctx, ref, ran)
+		if recordErr != nil {
+			l.Warnf("Bootstrap tasks succeeded, but Tilt couldn't record them in the cluster, "+
+				"so they'll run again on the next `tilt up`: %v", recordErr)
+		}
+	}
+
+	return statuses, err
+}
+
+func (b *bootstrapper) runTask(ctx context.Context, task model.BootstrapTask) error {
+	if task.YAML != "" {
+		entities, err := k8s.ParseYAMLFromString(task.YAML)
+		if err != nil {
+			return err
+		}
+		_, err = b.kCli.Upsert(ctx, entities, bootstrapApplyTimeout)
+		return err
+	}
+
+	out := logger.NewMutexWriter(logger.NewPrefixedLogger(bootstrapLogPrefix, logger.Get(ctx)).Writer(logger.InfoLvl))
+	exitCode, err := b.execer.Run(ctx, task.Cmd, localexec.RunIO{Stdout: out, Stderr: out})
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("command %q exited with status %d", task.Cmd, exitCode)
+	}
+	return nil
+}
+
+// Returns the annotations on the bootstrap ConfigMap, upgraded to the current
+// format, or nil if we can't read them.
+//
+// Only returns an error if the annotations were written in a format that we
+// don't understand.
+func (b *bootstrapper) readAnnotations(ctx context.Context, ref v1.ObjectReference) (map[string]string, error) {
+	meta, err := b.kCli.GetMetaByReference(ctx, ref)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			logger.Get(ctx).Debugf("Reading bootstrap tasks from the cluster: %v", err)
+		}
+		return nil, nil
+	}
+	return bootstrapFormat.Upgrade(meta.GetAnnotations())
+}
+
+// Adds the records to the bootstrap ConfigMap, keeping the records of other tasks.
+func (b *bootstrapper) recordInCluster(ctx context.Context, ref v1.ObjectReference, records map[string]bootstrapRecord) error {
+	existing, err := b.readAnnotations(ctx, ref)
+	if err != nil {
+		return err
+	}
+	annotations := make(map[string]string)
+	for k, v := range existing {
+		annotations[k] = v
+	}
+	bootstrapFormat.Stamp(annotations)
+	for name, record := range records {
+		value, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		annotations[bootstrapAnnotationPrefix+name] = string(value)
+	}
+
+	cm := &v1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        ref.Name,
+			Namespace:   ref.Namespace,
+			Annotations: annotations,
+			Labels:      map[string]string{k8s.ManagedByLabel: k8s.ManagedByValue},
+		},
+	}
+	_, err = b.kCli.Upsert(ctx, []k8s.K8sEntity{k8s.NewK8sEntity(cm)}, bootstrapApplyTimeout)
+	return err
+}
+
+func bootstrapConfigMapRef(cfgNS k8s.Namespace, tf *v1alpha1.Tiltfile) v1.ObjectReference {
+	return v1.ObjectReference{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Namespace:  cfgNS.String(),
+		Name:       bootstrapConfigMapPrefix + k8s.ProjectLabelValue(tf.Spec.Path),
+	}
+}
+
+func bootstrapRecordsFromAnnotations(annotations map[string]string) map[string]bootstrapRecord {
+	result := make(map[string]bootstrapRecord)
+	for k, v := range annotations {
+		if !strings.HasPrefix(k, bootstrapAnnotationPrefix) {
+			continue
+		}
+		var record bootstrapRecord
+		err := json.Unmarshal([]byte(v), &record)
+		if err != nil {
+			// Someone edited the record by hand, so run the task again.
+			continue
+		}
+		result[strings.TrimPrefix(k, bootstrapAnnotationPrefix)] = record
+	}
+	return result
+}
+
+func usesCluster(tlr *tiltfile.TiltfileLoadResult) bool {
+	if tlr.Orchestrator() == model.OrchestratorK8s {
+		return true
+	}
+	for _, task := range tlr.BootstrapTasks {
+		if task.YAML != "" {
+			return true
+		}
+	}
+	return false
+}
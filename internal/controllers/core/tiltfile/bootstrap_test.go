@@ -0,0 +1,282 @@
+package tiltfile
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
+	"github.com/tilt-dev/tilt/internal/localexec"
+	"github.com/tilt-dev/tilt/internal/testutils/manifestbuilder"
+	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
+	"github.com/tilt-dev/tilt/internal/tiltfile"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestBootstrapFirstRun(t *testing.T) {
+	f := newBootstrapFixture(t)
+
+	f.execer.RegisterCommand("install-certs", 0, "certs installed", "")
+	tlr := f.loadResult(
+		model.BootstrapTask{Name: "certs", Cmd: model.ToHostCmd("install-certs")},
+		model.BootstrapTask{Name: "registry-secret", YAML: testyaml.SecretYaml},
+	)
+
+	statuses, err := f.run(tlr)
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	for _, s := range statuses {
+		assert.False(t, s.Skipped, s.Name)
+		assert.False(t, s.LastRunTime.IsZero(), s.Name)
+		assert.Equal(t, "", s.Error, s.Name)
+	}
+	assert.Contains(t, f.out.String(), `Running bootstrap task "certs"`)
+	assert.Contains(t, f.out.String(), "certs installed")
+	assert.Contains(t, f.out.String(), `Running bootstrap task "registry-secret"`)
+
+	// The last upsert records both tasks in the cluster.
+	records := f.clusterRecords()
+	assert.Equal(t, tlr.BootstrapTasks[0].Hash(), records["certs"].Hash)
+	assert.Equal(t, tlr.BootstrapTasks[1].Hash(), records["registry-secret"].Hash)
+}
+
+func TestBootstrapSkipsTasksThatMatch(t *testing.T) {
+	f := newBootstrapFixture(t)
+
+	tlr := f.loadResult(model.BootstrapTask{Name: "certs", Cmd: model.ToHostCmd("install-certs")})
+	first, err := f.run(tlr)
+	require.NoError(t, err)
+
+	// A new session on the same cluster.
+	f.out.Reset()
+	statuses, err := f.run(tlr)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.True(t, statuses[0].Skipped)
+	assert.True(t, first[0].LastRunTime.Equal(statuses[0].LastRunTime))
+	assert.NotContains(t, f.out.String(), "Running bootstrap task")
+}
+
+func TestBootstrapRerunsChangedTasks(t *testing.T) {
+	f := newBootstrapFixture(t)
+
+	tlr := f.loadResult(
+		model.BootstrapTask{Name: "certs", Cmd: model.ToHostCmd("install-certs v1")},
+		model.BootstrapTask{Name: "secret", Cmd: model.ToHostCmd("create-secret")},
+	)
+	_, err := f.run(tlr)
+	require.NoError(t, err)
+
+	f.out.Reset()
+	tlr.BootstrapTasks[0].Cmd = model.ToHostCmd("install-certs v2")
+	statuses, err := f.run(tlr)
+	require.NoError(t, err)
+	assert.False(t, statuses[0].Skipped)
+	assert.True(t, statuses[1].Skipped)
+	assert.Contains(t, f.out.String(), `Running bootstrap task "certs"`)
+	assert.NotContains(t, f.out.String(), `Running bootstrap task "secret"`)
+
+	// Recording the changed task keeps the record of the other one.
+	records := f.clusterRecords()
+	assert.Equal(t, tlr.BootstrapTasks[0].Hash(), records["certs"].Hash)
+	assert.Equal(t, tlr.BootstrapTasks[1].Hash(), records["secret"].Hash)
+}
+
+func TestBootstrapForce(t *testing.T) {
+	f := newBootstrapFixture(t)
+
+	tlr := f.loadResult(model.BootstrapTask{Name: "certs", Cmd: model.ToHostCmd("install-certs")})
+	_, err := f.run(tlr)
+	require.NoError(t, err)
+
+	f.out.Reset()
+	b := newBootstrapper(f.kClient, "default", f.execer, true)
+	statuses, err := f.runWith(b, tlr)
+	require.NoError(t, err)
+	assert.False(t, statuses[0].Skipped)
+
+	// Forced tasks still only run once per session.
+	f.out.Reset()
+	statuses, err = f.runWith(b, tlr)
+	require.NoError(t, err)
+	assert.True(t, statuses[0].Skipped)
+	assert.NotContains(t, f.out.String(), "Running bootstrap task")
+}
+
+func TestBootstrapFailureStopsLaterTasks(t *testing.T) {
+	f := newBootstrapFixture(t)
+
+	f.execer.RegisterCommand("install-certs", 1, "", "no such chart")
+	tlr := f.loadResult(
+		model.BootstrapTask{Name: "certs", Cmd: model.ToHostCmd("install-certs")},
+		model.BootstrapTask{Name: "secret", Cmd: model.ToHostCmd("create-secret")},
+	)
+
+	statuses, err := f.run(tlr)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `Bootstrap task "certs" failed: command "install-certs" exited with status 1`)
+	assert.Contains(t, f.out.String(), "no such chart")
+	assert.NotContains(t, f.out.String(), `Running bootstrap task "secret"`)
+
+	require.Len(t, statuses, 2)
+	assert.NotEqual(t, "", statuses[0].Error)
+	assert.True(t, statuses[1].LastRunTime.IsZero())
+	assert.False(t, statuses[1].Skipped)
+	assert.Nil(t, f.kClient.LastUpsertResult)
+}
+
+func TestBootstrapFailureBlocksLoad(t *testing.T) {
+	f := newFixture(t)
+	p := f.tempdir.JoinPath("Tiltfile")
+
+	f.execer.RegisterCommand("install-certs", 1, "", "no such chart")
+	f.tfl.Result = tiltfile.TiltfileLoadResult{
+		Manifests: []model.Manifest{
+			manifestbuilder.New(f.tempdir, "fe").WithLocalResource("echo hi", "", nil).Build(),
+		},
+		BootstrapTasks: []model.BootstrapTask{
+			{Name: "certs", Cmd: model.ToHostCmd("install-certs")},
+		},
+	}
+
+	nn := types.NamespacedName{Name: model.MainTiltfileManifestName.String()}
+	tf := v1alpha1.Tiltfile{
+		ObjectMeta: metav1.ObjectMeta{Name: nn.Name},
+		Spec:       v1alpha1.TiltfileSpec{Path: p},
+	}
+	f.Create(&tf)
+	f.waitForLoad(nn)
+
+	f.MustGet(nn, &tf)
+	assert.Contains(t, tf.Status.Terminated.Error, `Bootstrap task "certs" failed`)
+
+	var action ConfigsReloadedAction
+	for _, a := range f.st.Actions() {
+		if a, ok := a.(ConfigsReloadedAction); ok {
+			action = a
+		}
+	}
+	require.Error(t, action.Err)
+	require.Len(t, action.BootstrapTasks, 1)
+	assert.NotEqual(t, "", action.BootstrapTasks[0].Error)
+}
+
+func TestBootstrapRerunsUnreadableRecords(t *testing.T) {
+	f := newBootstrapFixture(t)
+
+	// Records from before the bootstrap ConfigMap was versioned.
+	f.injectAnnotations(map[string]string{
+		bootstrapAnnotationPrefix + "certs": "not-json",
+		"example.com/unrelated":             "keep-me",
+	})
+
+	tlr := f.loadResult(model.BootstrapTask{Name: "certs", Cmd: model.ToHostCmd("install-certs")})
+	statuses, err := f.run(tlr)
+	require.NoError(t, err)
+	assert.False(t, statuses[0].Skipped)
+
+	meta, err := f.kClient.GetMetaByReference(f.ctx, bootstrapConfigMapRef("default", f.tf))
+	require.NoError(t, err)
+	assert.Equal(t, "1", meta.GetAnnotations()[configmap.VersionKey])
+	assert.Equal(t, "keep-me", meta.GetAnnotations()["example.com/unrelated"])
+	assert.Equal(t, tlr.BootstrapTasks[0].Hash(), f.clusterRecords()["certs"].Hash)
+}
+
+func TestBootstrapRefusesNewerFormat(t *testing.T) {
+	f := newBootstrapFixture(t)
+
+	f.injectAnnotations(map[string]string{configmap.VersionKey: "99"})
+
+	tlr := f.loadResult(model.BootstrapTask{Name: "certs", Cmd: model.ToHostCmd("install-certs")})
+	statuses, err := f.run(tlr)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the bootstrap ConfigMap was written in format version 99 by a newer version of Tilt")
+	assert.NotContains(t, f.out.String(), "Running bootstrap task")
+	require.Len(t, statuses, 1)
+	assert.NotEqual(t, "", statuses[0].Error)
+	assert.Nil(t, f.kClient.LastUpsertResult)
+}
+
+type bootstrapFixture struct {
+	t       *testing.T
+	ctx     context.Context
+	out     *bytes.Buffer
+	kClient *k8s.FakeK8sClient
+	execer  *localexec.FakeExecer
+	tf      *v1alpha1.Tiltfile
+}
+
+func newBootstrapFixture(t *testing.T) *bootstrapFixture {
+	tmp := tempdir.NewTempDirFixture(t)
+	t.Cleanup(tmp.TearDown)
+
+	out := bytes.NewBuffer(nil)
+	return &bootstrapFixture{
+		t:       t,
+		ctx:     logger.WithLogger(context.Background(), logger.NewTestLogger(out)),
+		out:     out,
+		kClient: k8s.NewFakeK8sClient(t),
+		execer:  localexec.NewFakeExecer(t),
+		tf: &v1alpha1.Tiltfile{
+			ObjectMeta: metav1.ObjectMeta{Name: model.MainTiltfileManifestName.String()},
+			Spec:       v1alpha1.TiltfileSpec{Path: tmp.JoinPath("Tiltfile")},
+		},
+	}
+}
+
+// A load result for a Kubernetes project, so that tasks are recorded in the cluster.
+func (f *bootstrapFixture) loadResult(tasks ...model.BootstrapTask) *tiltfile.TiltfileLoadResult {
+	m := model.Manifest{Name: "fe"}.WithDeployTarget(model.K8sTarget{})
+	return &tiltfile.TiltfileLoadResult{
+		Manifests:      []model.Manifest{m},
+		BootstrapTasks: tasks,
+	}
+}
+
+// Runs the tasks in a new session.
+func (f *bootstrapFixture) run(tlr *tiltfile.TiltfileLoadResult) ([]model.BootstrapTaskStatus, error) {
+	return f.runWith(newBootstrapper(f.kClient, "default", f.execer, false), tlr)
+}
+
+func (f *bootstrapFixture) runWith(b *bootstrapper, tlr *tiltfile.TiltfileLoadResult) ([]model.BootstrapTaskStatus, error) {
+	f.kClient.LastUpsertResult = nil
+	statuses, err := b.run(f.ctx, f.tf, tlr)
+
+	// The fake client doesn't remember what we upsert, so put the
+	// records where the next session can read them.
+	for _, e := range f.kClient.LastUpsertResult {
+		if e.GVK().Kind == "ConfigMap" && e.Name() == bootstrapConfigMapRef("default", f.tf).Name {
+			f.kClient.Inject(e)
+		}
+	}
+	return statuses, err
+}
+
+func (f *bootstrapFixture) clusterRecords() map[string]bootstrapRecord {
+	meta, err := f.kClient.GetMetaByReference(f.ctx, bootstrapConfigMapRef("default", f.tf))
+	require.NoError(f.t, err)
+	return bootstrapRecordsFromAnnotations(meta.GetAnnotations())
+}
+
+func (f *bootstrapFixture) injectAnnotations(annotations map[string]string) {
+	ref := bootstrapConfigMapRef("default", f.tf)
+	f.kClient.Inject(k8s.NewK8sEntity(&v1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        ref.Name,
+			Namespace:   ref.Namespace,
+			UID:         "bootstrap-uid",
+			Annotations: annotations,
+		},
+	}))
+}
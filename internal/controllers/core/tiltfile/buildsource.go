@@ -21,6 +21,7 @@ import (
 type BuildEntry struct {
 	Name                  model.ManifestName
 	FilesChanged          []string
+	EnvChanged            []string
 	BuildReason           model.BuildReason
 	UserConfigState       model.UserConfigState
 	TiltfilePath          string
@@ -0,0 +1,176 @@
+package tiltfile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/pmezard/go-difflib/difflib"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/tilt-dev/tilt/internal/controllers/apiset"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/logger"
+)
+
+// If we update the same object more than this many times within
+// updateStormWindow, even though the Tiltfile produced exactly the same object
+// every time, warn that our comparison is probably broken.
+const updateStormCount = 5
+const updateStormWindow = 5 * time.Minute
+
+// Normalizes a spec, so that two specs that mean the same thing compare equal.
+//
+// The apiserver stores objects as JSON, so the spec we read back may not be
+// byte-for-byte the spec we wrote. We round-trip through JSON the same way,
+// then normalize fields where the representation isn't semantic.
+func canonicalSpec(spec interface{}) interface{} {
+	if spec == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return spec
+	}
+
+	ptr := reflect.New(reflect.TypeOf(spec))
+	err = json.Unmarshal(data, ptr.Interface())
+	if err != nil {
+		return spec
+	}
+	result := ptr.Elem().Interface()
+
+	switch s := result.(type) {
+	case v1alpha1.KubernetesApplySpec:
+		s.YAML = canonicalYAML(s.YAML)
+		return s
+	case v1alpha1.FileWatchSpec:
+		// We watch the set of paths, so the order doesn't matter.
+		sort.Strings(s.WatchedPaths)
+		return s
+	}
+	return result
+}
+
+// Re-serializes embedded Kubernetes YAML, so that map ordering and
+// whitespace don't register as changes.
+func canonicalYAML(yaml string) string {
+	entities, err := k8s.ParseYAMLFromString(yaml)
+	if err != nil {
+		return strings.TrimSpace(yaml)
+	}
+	result, err := k8s.SerializeSpecYAML(entities)
+	if err != nil {
+		return strings.TrimSpace(yaml)
+	}
+	return result
+}
+
+type updateKey struct {
+	gvr  schema.GroupVersionResource
+	name string
+}
+
+type updateRecord struct {
+	input []byte
+	times []time.Time
+}
+
+// Keeps track of how often we update each object, so that we can
+// detect update storms, where we keep updating an object even though
+// nothing changed.
+type updateTracker struct {
+	clock clockwork.Clock
+
+	mu      sync.Mutex
+	records map[updateKey]*updateRecord
+}
+
+func newUpdateTracker(clock clockwork.Clock) *updateTracker {
+	return &updateTracker{
+		clock:   clock,
+		records: make(map[updateKey]*updateRecord),
+	}
+}
+
+// Records that we're updating an object. If we've updated it too many times
+// with byte-identical input, logs a warning with the diff that triggered the update.
+func (t *updateTracker) record(ctx context.Context, old, obj apiset.Object) {
+	input, err := json.Marshal(updateInput(obj))
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := updateKey{gvr: obj.GetGroupVersionResource(), name: obj.GetName()}
+	r, ok := t.records[key]
+	if !ok || string(r.input) != string(input) {
+		t.records[key] = &updateRecord{input: input}
+		return
+	}
+
+	now := t.clock.Now()
+	times := []time.Time{}
+	for _, ts := range r.times {
+		if now.Sub(ts) < updateStormWindow {
+			times = append(times, ts)
+		}
+	}
+	r.times = append(times, now)
+
+	if len(r.times) < updateStormCount {
+		return
+	}
+
+	logger.Get(ctx).Warnf("%s %s has been updated %d times in %s, even though the Tiltfile didn't change it. "+
+		"This is probably a bug in Tilt. Changes detected:\n%s",
+		key.gvr.Resource, key.name, len(r.times), updateStormWindow, updateDiff(old, obj))
+
+	// Reset, so that we only warn once per storm.
+	r.times = nil
+}
+
+// The parts of the object that the Tiltfile controls.
+func updateInput(obj apiset.Object) interface{} {
+	return map[string]interface{}{
+		"spec":        obj.GetSpec(),
+		"labels":      obj.GetLabels(),
+		"annotations": obj.GetAnnotations(),
+	}
+}
+
+func updateDiff(old, obj apiset.Object) string {
+	toText := func(o apiset.Object) string {
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"spec":        canonicalSpec(o.GetSpec()),
+			"labels":      o.GetLabels(),
+			"annotations": o.GetAnnotations(),
+		}, "", "  ")
+		if err != nil {
+			return fmt.Sprintf("error: %v", err)
+		}
+		return string(data)
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(toText(old)),
+		B:        difflib.SplitLines(toText(obj)),
+		FromFile: "current",
+		ToFile:   "new",
+		Context:  3,
+	})
+	if err != nil {
+		return fmt.Sprintf("error: %v", err)
+	}
+	return diff
+}
@@ -8,7 +8,9 @@ import (
 
 	"github.com/tilt-dev/tilt/internal/controllers/apiset"
 	"github.com/tilt-dev/tilt/internal/ignore"
+	"github.com/tilt-dev/tilt/internal/sliceutils"
 	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/tiltfile/io"
 	"github.com/tilt-dev/tilt/pkg/apis"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
 	"github.com/tilt-dev/tilt/pkg/model"
@@ -19,6 +21,7 @@ type WatchInputs struct {
 	TiltfilePath         string
 	Manifests            []model.Manifest
 	ConfigFiles          []string
+	ConfigGlobs          []io.WatchedGlob
 	WatchSettings        model.WatchSettings
 	Tiltignore           model.Dockerignore
 	EngineMode           store.EngineMode
@@ -33,6 +36,7 @@ type WatchableTarget interface {
 var _ WatchableTarget = model.ImageTarget{}
 var _ WatchableTarget = model.LocalTarget{}
 var _ WatchableTarget = model.DockerComposeTarget{}
+var _ WatchableTarget = model.SyncTarget{}
 
 func specForTarget(t WatchableTarget, globalIgnores []model.Dockerignore) *v1alpha1.FileWatchSpec {
 	watchedPaths := append([]string(nil), t.Dependencies()...)
@@ -63,9 +67,7 @@ func addGlobalIgnoresToSpec(spec *v1alpha1.FileWatchSpec, globalIgnores []model.
 // FileWatchesFromManifests creates FileWatch specs from Tilt manifests in the engine state.
 func ToFileWatchObjects(watchInputs WatchInputs, disableSources map[model.ManifestName]*v1alpha1.DisableSource) apiset.TypedObjectSet {
 	result := apiset.TypedObjectSet{}
-	if !watchInputs.EngineMode.WatchesFiles() {
-		return result
-	}
+	mode := watchInputs.EngineMode
 
 	// TODO(milas): how can global ignores fit into the API model more cleanly?
 	globalIgnores := globalIgnores(watchInputs)
@@ -73,6 +75,10 @@ func ToFileWatchObjects(watchInputs WatchInputs, disableSources map[model.Manife
 	processedTargets := make(map[model.TargetID]bool)
 	for _, m := range watchInputs.Manifests {
 		for _, t := range m.TargetSpecs() {
+			if !watchesTarget(mode, m, t) {
+				continue
+			}
+
 			targetID := t.ID()
 			// ignore targets that have already been processed or aren't watchable
 			_, seen := processedTargets[targetID]
@@ -99,13 +105,22 @@ func ToFileWatchObjects(watchInputs WatchInputs, disableSources map[model.Manife
 		}
 	}
 
+	// Only reload the Tiltfile in modes that watch files.
 	paths := []string{}
-	if len(watchInputs.ConfigFiles) > 0 {
-		paths = append(paths, watchInputs.ConfigFiles...)
-	} else if watchInputs.TiltfilePath != "" {
-		// A complete ConfigFiles set should include the Tiltfile. If it doesn't,
-		// add it to the watch list now.
-		paths = append(paths, watchInputs.TiltfilePath)
+	if mode.WatchesFiles() {
+		if len(watchInputs.ConfigFiles) > 0 {
+			paths = append(paths, watchInputs.ConfigFiles...)
+		} else if watchInputs.TiltfilePath != "" {
+			// A complete ConfigFiles set should include the Tiltfile. If it doesn't,
+			// add it to the watch list now.
+			paths = append(paths, watchInputs.TiltfilePath)
+		}
+
+		// Watch the whole tree under each glob, so that we see new files
+		// that match it. The Tiltfile reconciler ignores the rest.
+		for _, g := range watchInputs.ConfigGlobs {
+			paths = sliceutils.AppendWithoutDupes(paths, g.BaseDir)
+		}
 	}
 
 	if len(paths) > 0 {
@@ -133,6 +148,24 @@ func ToFileWatchObjects(watchInputs WatchInputs, disableSources map[model.Manife
 	return result
 }
 
+// watchesTarget returns whether we should watch the files of the target.
+//
+// In CI mode, we only watch manifests that explicitly opt in, and images
+// that opt in so that their live updates run.
+func watchesTarget(mode store.EngineMode, m model.Manifest, t model.TargetSpec) bool {
+	if mode.WatchesFiles() {
+		return true
+	}
+	if !mode.IsCIMode() {
+		return false
+	}
+	if m.WatchInCI {
+		return true
+	}
+	iTarget, ok := t.(model.ImageTarget)
+	return ok && iTarget.WatchInCI
+}
+
 // globalIgnores returns a list of global ignore patterns.
 func globalIgnores(watchInputs WatchInputs) []model.Dockerignore {
 	ignores := []model.Dockerignore{}
@@ -24,6 +24,7 @@ import (
 	"github.com/tilt-dev/tilt/internal/testutils"
 	"github.com/tilt-dev/tilt/internal/testutils/manifestbuilder"
 	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
+	"github.com/tilt-dev/tilt/internal/tiltfile/io"
 	"github.com/tilt-dev/tilt/pkg/model"
 )
 
@@ -71,6 +72,94 @@ func TestFileWatch_disabledOnCIMode(t *testing.T) {
 	assert.Empty(t, actualSet)
 }
 
+func TestFileWatch_ObjectSetsByMode(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		mode      store.EngineMode
+		watchInCI bool
+		expected  []string
+	}{
+		{"up", store.EngineModeUp, false, []string{"configs:(Tiltfile)", "docker-compose:foo"}},
+		{"up with watch_in_ci", store.EngineModeUp, true, []string{"configs:(Tiltfile)", "docker-compose:foo"}},
+		{"ci", store.EngineModeCI, false, nil},
+		{"ci with watch_in_ci", store.EngineModeCI, true, []string{"docker-compose:foo"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newFWFixture(t)
+			defer f.TearDown()
+
+			f.inputs.EngineMode = tc.mode
+			f.inputs.TiltfilePath = f.JoinPath("Tiltfile")
+
+			target := model.DockerComposeTarget{Name: "foo"}.
+				WithBuildPath(".")
+			m := model.Manifest{Name: "foo"}.WithDeployTarget(target).WithWatchInCI(tc.watchInCI)
+			f.SetManifest(m)
+
+			var actual []string
+			for name := range ToFileWatchObjects(f.inputs, make(disableSourceMap)) {
+				actual = append(actual, name)
+			}
+			assert.ElementsMatch(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestFileWatch_WatchInCIOnlyWatchesOptedInManifests(t *testing.T) {
+	f := newFWFixture(t)
+	defer f.TearDown()
+
+	f.inputs.EngineMode = store.EngineModeCI
+
+	foo := model.DockerComposeTarget{Name: "foo"}.WithBuildPath("foo")
+	bar := model.DockerComposeTarget{Name: "bar"}.WithBuildPath("bar")
+	f.SetManifest(model.Manifest{Name: "foo"}.WithDeployTarget(foo).WithWatchInCI(true))
+	f.SetManifest(model.Manifest{Name: "bar"}.WithDeployTarget(bar))
+
+	f.RequireFileWatchSpecEqual(foo.ID(), v1alpha1.FileWatchSpec{WatchedPaths: []string{"foo"}})
+
+	actualSet := ToFileWatchObjects(f.inputs, make(disableSourceMap))
+	assert.NotContains(t, actualSet, apis.SanitizeName(bar.ID().String()))
+}
+
+func TestFileWatch_WatchInCIOnlyWatchesOptedInImages(t *testing.T) {
+	f := newFWFixture(t)
+	defer f.TearDown()
+
+	f.inputs.EngineMode = store.EngineModeCI
+
+	watched := model.MustNewImageTarget(container.MustParseSelector("watched")).
+		WithBuildDetails(model.CustomBuild{
+			Command: model.ToHostCmd("make watched"),
+			Deps:    []string{f.JoinPath("watched")},
+		}).
+		WithWatchInCI(true)
+	unwatched := model.MustNewImageTarget(container.MustParseSelector("unwatched")).
+		WithBuildDetails(model.CustomBuild{
+			Command: model.ToHostCmd("make unwatched"),
+			Deps:    []string{f.JoinPath("unwatched")},
+		})
+
+	m := manifestbuilder.New(f, "sancho").
+		WithK8sYAML(testyaml.SanchoYAML).
+		WithImageTargets(watched, unwatched).
+		Build()
+	f.SetManifest(m)
+
+	actualSet := ToFileWatchObjects(f.inputs, make(disableSourceMap))
+	var actual []string
+	for name := range actualSet {
+		actual = append(actual, name)
+	}
+	assert.Equal(t, []string{apis.SanitizeName(watched.ID().String())}, actual)
+
+	// Outside of CI, we watch every image.
+	f.inputs.EngineMode = store.EngineModeUp
+	actualSet = ToFileWatchObjects(f.inputs, make(disableSourceMap))
+	assert.Contains(t, actualSet, apis.SanitizeName(watched.ID().String()))
+	assert.Contains(t, actualSet, apis.SanitizeName(unwatched.ID().String()))
+}
+
 func TestFileWatch_IgnoredLocalDirectories(t *testing.T) {
 	f := newFWFixture(t)
 	defer f.TearDown()
@@ -111,13 +200,14 @@ func TestFileWatch_IgnoreOutputsImageRefs(t *testing.T) {
 
 	target := model.MustNewImageTarget(container.MustParseSelector("img")).
 		WithBuildDetails(model.CustomBuild{
+			Command:           model.ToHostCmd("make img"),
 			Deps:              []string{f.Path()},
 			OutputsImageRefTo: f.JoinPath("ref.txt"),
 		})
 
 	m := manifestbuilder.New(f, "sancho").
 		WithK8sYAML(testyaml.SanchoYAML).
-		WithImageTarget(target).
+		WithImageTargets(target).
 		Build()
 	f.SetManifest(m)
 
@@ -181,6 +271,22 @@ func TestFileWatch_ConfigFiles(t *testing.T) {
 	})
 }
 
+func TestFileWatch_ConfigGlobs(t *testing.T) {
+	f := newFWFixture(t)
+	defer f.TearDown()
+
+	f.inputs.ConfigFiles = append(f.inputs.ConfigFiles, "Tiltfile", "config/a.yaml")
+	f.inputs.ConfigGlobs = []io.WatchedGlob{
+		{Pattern: "config/*.yaml", BaseDir: "config"},
+		{Pattern: "config/**/*.json", BaseDir: "config"},
+	}
+
+	id := model.TargetID{Type: model.TargetTypeConfigs, Name: model.TargetName(model.MainTiltfileManifestName)}
+	f.RequireFileWatchSpecEqual(id, v1alpha1.FileWatchSpec{
+		WatchedPaths: []string{"Tiltfile", "config/a.yaml", "config"},
+	})
+}
+
 func TestFileWatch_IgnoreTiltIgnore(t *testing.T) {
 	f := newFWFixture(t)
 	defer f.TearDown()
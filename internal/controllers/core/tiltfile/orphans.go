@@ -0,0 +1,259 @@
+package tiltfile
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/tiltfile"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/logger"
+)
+
+// The ConfigMap where we report Kubernetes objects that Tilt applied,
+// but that no current manifest claims.
+const OrphansConfigMapName = "tilt-k8s-orphans"
+
+// We rewrite the whole report on every load, so old versions don't need
+// any changes.
+var orphansFormat = configmap.RegisterFormat(configmap.Format{
+	Name: "the orphan report",
+	Matches: func(name string) bool {
+		return name == OrphansConfigMapName
+	},
+	Migrations: []configmap.Migration{
+		func(data map[string]string) map[string]string { return data },
+	},
+})
+
+// How long we're willing to spend looking for orphans after a Tiltfile load.
+const orphanCheckTimeout = 30 * time.Second
+
+// Kinds that we never delete automatically, even when they're orphaned,
+// because they may hold data that can't be recovered.
+var protectedOrphanKinds = map[string]bool{
+	"PersistentVolumeClaim": true,
+	"Namespace":             true,
+}
+
+// Kinds that we always check for orphans, in addition to the kinds in the
+// current manifests (which catch most renames).
+var defaultOrphanGVKs = []schema.GroupVersionKind{
+	{Group: "apps", Version: "v1", Kind: "Deployment"},
+	{Group: "apps", Version: "v1", Kind: "StatefulSet"},
+	{Group: "apps", Version: "v1", Kind: "DaemonSet"},
+	{Group: "batch", Version: "v1", Kind: "Job"},
+	{Group: "", Version: "v1", Kind: "Service"},
+	{Group: "", Version: "v1", Kind: "ConfigMap"},
+	{Group: "", Version: "v1", Kind: "Secret"},
+	{Group: "", Version: "v1", Kind: "ServiceAccount"},
+	{Group: "", Version: "v1", Kind: "PersistentVolumeClaim"},
+	{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"},
+	{Group: "", Version: "v1", Kind: "Namespace"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"},
+	{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRoleBinding"},
+}
+
+// A Kubernetes object that Tilt applied for a manifest that no longer exists.
+type orphanedObject struct {
+	gvk      schema.GroupVersionKind
+	meta     metav1.Object
+	manifest string
+}
+
+func (o orphanedObject) String() string {
+	return orphanKeyFor(o.gvk.Kind, o.meta.GetNamespace(), o.meta.GetName())
+}
+
+func (o orphanedObject) protected() bool {
+	return protectedOrphanKinds[o.gvk.Kind]
+}
+
+func (o orphanedObject) entity() k8s.K8sEntity {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(o.gvk)
+	obj.SetNamespace(o.meta.GetNamespace())
+	obj.SetName(o.meta.GetName())
+	obj.SetUID(o.meta.GetUID())
+	return k8s.NewK8sEntity(obj)
+}
+
+// Finds objects in the cluster that this project applied for manifests
+// that aren't in the Tiltfile anymore (e.g., because they were renamed or removed),
+// then reports them, or deletes them if the Tiltfile opted in.
+//
+// Best-effort: if we can't talk to the cluster, we don't report anything.
+func (r *Reconciler) handleOrphans(ctx context.Context, tf *v1alpha1.Tiltfile, tlr *tiltfile.TiltfileLoadResult) {
+	ctx, cancel := context.WithTimeout(ctx, orphanCheckTimeout)
+	defer cancel()
+
+	orphans := findOrphans(ctx, r.k8sClient, r.cfgNS, k8s.ProjectLabelValue(tf.Spec.Path), tlr)
+
+	l := logger.Get(ctx)
+	deleteOrphans := tlr.UpdateSettings.K8sDeleteOrphans
+	status := make(map[string]string, len(orphans))
+	var toDelete []k8s.K8sEntity
+	for _, o := range orphans {
+		switch {
+		case !deleteOrphans:
+			l.Warnf("Kubernetes object %s was applied for resource %q, which no longer exists. "+
+				"To delete orphaned objects automatically, use update_settings(k8s_delete_orphans=True)", o, o.manifest)
+			status[o.String()] = "orphaned"
+		case o.protected():
+			l.Warnf("Kubernetes object %s was applied for resource %q, which no longer exists. "+
+				"Not deleting it, because %s objects are never deleted automatically", o, o.manifest, o.gvk.Kind)
+			status[o.String()] = "orphaned (protected)"
+		default:
+			toDelete = append(toDelete, o.entity())
+			status[o.String()] = "deleted"
+		}
+	}
+
+	if len(toDelete) > 0 {
+		l.Infof("Deleting Kubernetes objects for resources that no longer exist")
+		err := r.k8sClient.Delete(ctx, toDelete)
+		if err != nil {
+			l.Warnf("Error deleting orphaned Kubernetes objects: %v", err)
+			for _, e := range toDelete {
+				status[orphanKey(e)] = "orphaned (delete failed)"
+			}
+		}
+	}
+
+	err := updateOrphansConfigMap(ctx, r.ctrlClient, status)
+	if err != nil {
+		l.Debugf("Error reporting orphaned Kubernetes objects: %v", err)
+	}
+}
+
+func orphanKey(e k8s.K8sEntity) string {
+	return orphanKeyFor(e.GVK().Kind, e.Meta().GetNamespace(), e.Name())
+}
+
+// Cluster-scoped objects are keyed by kind/name.
+func orphanKeyFor(kind, namespace, name string) string {
+	if namespace == "" {
+		return fmt.Sprintf("%s/%s", kind, name)
+	}
+	return fmt.Sprintf("%s/%s/%s", kind, namespace, name)
+}
+
+// Lists the objects carrying this project's labels in every namespace and kind
+// that the current manifests use, and returns the ones that no manifest claims.
+//
+// Cluster-scoped kinds are listed once, without a namespace.
+func findOrphans(ctx context.Context, kCli k8s.Client, cfgNS k8s.Namespace, project string, tlr *tiltfile.TiltfileLoadResult) []orphanedObject {
+	claimed := make(map[string]bool)
+	gvks := append([]schema.GroupVersionKind{}, defaultOrphanGVKs...)
+	seenGVKs := make(map[schema.GroupVersionKind]bool)
+	for _, gvk := range gvks {
+		seenGVKs[gvk] = true
+	}
+	namespaces := []k8s.Namespace{cfgNS}
+	seenNamespaces := map[k8s.Namespace]bool{cfgNS: true}
+
+	for _, m := range tlr.Manifests {
+		if !m.IsK8s() {
+			continue
+		}
+		claimed[k8s.ManifestNameLabelValue(m.Name.String())] = true
+
+		entities, err := k8s.ParseYAMLFromString(m.K8sTarget().YAML)
+		if err != nil {
+			continue
+		}
+		for _, e := range entities {
+			gvk := e.GVK()
+			if !seenGVKs[gvk] {
+				seenGVKs[gvk] = true
+				gvks = append(gvks, gvk)
+			}
+			if e.IsClusterScoped() {
+				continue
+			}
+			ns := k8s.Namespace(e.NamespaceOrDefault(cfgNS.String()))
+			if !seenNamespaces[ns] {
+				seenNamespaces[ns] = true
+				namespaces = append(namespaces, ns)
+			}
+		}
+	}
+
+	var result []orphanedObject
+	seen := make(map[types.UID]bool)
+	list := func(gvk schema.GroupVersionKind, ns k8s.Namespace) {
+		metas, err := kCli.ListMeta(ctx, gvk, ns)
+		if err != nil {
+			// The kind may not exist in this cluster.
+			logger.Get(ctx).Debugf("Checking for orphaned %s objects: %v", gvk.Kind, err)
+			return
+		}
+
+		for _, meta := range metas {
+			// Don't require the managed-by label here, because we never add it to PVCs.
+			labels := meta.GetLabels()
+			manifest := labels[k8s.ManifestNameLabel]
+			if labels[k8s.ProjectLabel] != project ||
+				manifest == "" ||
+				claimed[manifest] ||
+				meta.GetDeletionTimestamp() != nil {
+				continue
+			}
+
+			uid := meta.GetUID()
+			if uid != "" && seen[uid] {
+				continue
+			}
+			seen[uid] = true
+			result = append(result, orphanedObject{gvk: gvk, meta: meta, manifest: manifest})
+		}
+	}
+
+	for _, gvk := range gvks {
+		if k8s.IsClusterScopedKind(gvk.Kind) {
+			list(gvk, "")
+			continue
+		}
+		for _, ns := range namespaces {
+			list(gvk, ns)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].String() < result[j].String()
+	})
+	return result
+}
+
+// Reports the orphans in the API, keyed by kind/namespace/name.
+func updateOrphansConfigMap(ctx context.Context, client ctrlclient.Client, status map[string]string) error {
+	var cm v1alpha1.ConfigMap
+	err := client.Get(ctx, types.NamespacedName{Name: OrphansConfigMapName}, &cm)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	if apierrors.IsNotFound(err) {
+		if len(status) == 0 {
+			return nil
+		}
+		cm = v1alpha1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: OrphansConfigMapName},
+			Data:       orphansFormat.Stamp(status),
+		}
+		return client.Create(ctx, &cm)
+	}
+
+	cm.Data = orphansFormat.Stamp(status)
+	return client.Update(ctx, &cm)
+}
@@ -0,0 +1,190 @@
+package tiltfile
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
+	"github.com/tilt-dev/tilt/internal/testutils/manifestbuilder"
+	"github.com/tilt-dev/tilt/internal/tiltfile"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestOrphanWarningAfterRename(t *testing.T) {
+	f := newFixture(t)
+	p := f.tempdir.JoinPath("Tiltfile")
+
+	m := manifestbuilder.New(f.tempdir, "new-name").WithK8sYAML(testyaml.SanchoYAML).Build()
+	f.tfl.Result = tiltfile.TiltfileLoadResult{
+		Manifests: []model.Manifest{m},
+	}
+
+	f.injectManaged(p, "old-name", "uid-1", testyaml.SanchoYAML)
+	f.injectManaged(p, "new-name", "uid-2", orphanTestServiceYAML("sancho-svc"))
+
+	f.runMainTiltfile(p)
+
+	cm := f.waitForOrphansConfigMap()
+	assert.Equal(t, map[string]string{
+		"Deployment/default/sancho": "orphaned",
+		configmap.VersionKey:        "1",
+	}, cm.Data)
+	assert.Contains(t, f.st.out.String(),
+		`Kubernetes object Deployment/default/sancho was applied for resource "old-name", which no longer exists`)
+	assert.Equal(t, "", f.kClient.DeletedYaml)
+}
+
+func TestOrphanDeleteSkipsPVC(t *testing.T) {
+	f := newFixture(t)
+	p := f.tempdir.JoinPath("Tiltfile")
+
+	m := manifestbuilder.New(f.tempdir, "new-name").WithK8sYAML(testyaml.SanchoYAML).Build()
+	f.tfl.Result = tiltfile.TiltfileLoadResult{
+		Manifests:      []model.Manifest{m},
+		UpdateSettings: model.DefaultUpdateSettings(),
+	}
+	f.tfl.Result.UpdateSettings.K8sDeleteOrphans = true
+
+	f.injectManaged(p, "old-name", "uid-1", testyaml.SanchoYAML)
+	f.injectManaged(p, "old-name", "uid-2", orphanTestPVCYAML)
+
+	f.runMainTiltfile(p)
+
+	cm := f.waitForOrphansConfigMap()
+	assert.Equal(t, map[string]string{
+		"Deployment/default/sancho":             "deleted",
+		"PersistentVolumeClaim/default/db-data": "orphaned (protected)",
+		configmap.VersionKey:                    "1",
+	}, cm.Data)
+	assert.Contains(t, f.kClient.DeletedYaml, "name: sancho")
+	assert.NotContains(t, f.kClient.DeletedYaml, "db-data")
+}
+
+func TestOrphanClusterScoped(t *testing.T) {
+	f := newFixture(t)
+	p := f.tempdir.JoinPath("Tiltfile")
+
+	m := manifestbuilder.New(f.tempdir, "new-name").WithK8sYAML(testyaml.SanchoYAML).Build()
+	f.tfl.Result = tiltfile.TiltfileLoadResult{
+		Manifests:      []model.Manifest{m},
+		UpdateSettings: model.DefaultUpdateSettings(),
+	}
+	f.tfl.Result.UpdateSettings.K8sDeleteOrphans = true
+
+	f.injectManaged(p, "old-name", "uid-1", orphanTestClusterRoleYAML)
+	f.injectManaged(p, "old-name", "uid-2", orphanTestNamespaceYAML)
+
+	f.runMainTiltfile(p)
+
+	cm := f.waitForOrphansConfigMap()
+	assert.Equal(t, map[string]string{
+		"ClusterRole/pod-reader": "deleted",
+		"Namespace/old-ns":       "orphaned (protected)",
+		configmap.VersionKey:     "1",
+	}, cm.Data)
+	assert.Contains(t, f.kClient.DeletedYaml, "name: pod-reader")
+	assert.NotContains(t, f.kClient.DeletedYaml, "old-ns")
+}
+
+const orphanTestClusterRoleYAML = `
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: pod-reader
+rules:
+- apiGroups: [""]
+  resources: ["pods"]
+  verbs: ["get", "list"]
+`
+
+const orphanTestNamespaceYAML = `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: old-ns
+`
+
+const orphanTestPVCYAML = `
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: db-data
+spec:
+  accessModes:
+  - ReadWriteOnce
+  resources:
+    requests:
+      storage: 1Gi
+`
+
+func orphanTestServiceYAML(name string) string {
+	return fmt.Sprintf(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: %s
+spec:
+  ports:
+  - port: 80
+`, name)
+}
+
+// Inject an object into the cluster, labeled as if Tilt applied it
+// for the given manifest in this project.
+func (f *fixture) injectManaged(tiltfilePath string, manifest string, uid types.UID, yaml string) {
+	entities, err := k8s.ParseYAMLFromString(yaml)
+	require.NoError(f.T(), err)
+	require.Len(f.T(), entities, 1)
+
+	e := entities[0]
+	e, err = k8s.InjectLabels(e, []model.LabelPair{k8s.TiltManagedByLabel()})
+	require.NoError(f.T(), err)
+	e = k8s.InjectManagedObjectIdentity(e, k8s.ManagedObjectIdentity{
+		Manifest: manifest,
+		Project:  k8s.ProjectLabelValue(tiltfilePath),
+	})
+	if !e.IsClusterScoped() {
+		e.Meta().SetNamespace("default")
+	}
+	e.Meta().SetUID(uid)
+	f.kClient.Inject(e)
+}
+
+func (f *fixture) runMainTiltfile(p string) {
+	nn := types.NamespacedName{Name: model.MainTiltfileManifestName.String()}
+	tf := v1alpha1.Tiltfile{
+		ObjectMeta: metav1.ObjectMeta{Name: nn.Name},
+		Spec:       v1alpha1.TiltfileSpec{Path: p},
+	}
+	f.Create(&tf)
+
+	assert.Eventually(f.T(), func() bool {
+		f.MustGet(nn, &tf)
+		return tf.Status.Running != nil
+	}, time.Second, time.Millisecond)
+
+	f.popQueue()
+
+	assert.Eventually(f.T(), func() bool {
+		f.MustGet(nn, &tf)
+		return tf.Status.Terminated != nil
+	}, time.Second, time.Millisecond)
+	require.Equal(f.T(), "", tf.Status.Terminated.Error)
+}
+
+func (f *fixture) waitForOrphansConfigMap() *v1alpha1.ConfigMap {
+	var cm v1alpha1.ConfigMap
+	require.Eventually(f.T(), func() bool {
+		return f.Get(types.NamespacedName{Name: OrphansConfigMapName}, &cm)
+	}, time.Second, time.Millisecond)
+	return &cm
+}
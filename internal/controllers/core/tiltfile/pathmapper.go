@@ -0,0 +1,75 @@
+package tiltfile
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/tilt-dev/tilt/internal/dockerfile"
+	"github.com/tilt-dev/tilt/pkg/model"
+	"github.com/tilt-dev/tilt/pkg/model/logstore"
+)
+
+// Maps the container paths in a resource's logs back to local files,
+// using its images' live-update syncs and Dockerfile COPYs.
+//
+// Returns nil if the resource doesn't have any paths we can map.
+func manifestPathMapper(workspace string, m model.Manifest) *logstore.PathMapper {
+	var mappings []logstore.PathMapping
+	for _, iTarget := range m.ImageTargets {
+		lu := iTarget.LiveUpdateSpec
+		for _, sync := range lu.Syncs {
+			localPath := sync.LocalPath
+			if !filepath.IsAbs(localPath) {
+				localPath = filepath.Join(lu.BasePath, localPath)
+			}
+			mappings = append(mappings, logstore.PathMapping{
+				ContainerPath: sync.ContainerPath,
+				LocalPath:     localPath,
+			})
+		}
+
+		if iTarget.IsDockerBuild() {
+			mappings = append(mappings, dockerCopyMappings(iTarget.DockerBuildInfo())...)
+		}
+	}
+	if len(mappings) == 0 {
+		return nil
+	}
+	return logstore.NewPathMapper(workspace, mappings)
+}
+
+// Maps the destinations of COPYs from the build context.
+//
+// We check the local files to tell whether each source is a file or a
+// directory, because a COPY treats them differently.
+func dockerCopyMappings(db model.DockerBuild) []logstore.PathMapping {
+	if db.BuildPath == "" {
+		return nil
+	}
+	ast, err := dockerfile.ParseAST(dockerfile.Dockerfile(db.Dockerfile))
+	if err != nil {
+		return nil
+	}
+
+	var mappings []logstore.PathMapping
+	for _, c := range ast.Copies() {
+		for _, src := range c.Srcs {
+			localPath := filepath.Join(db.BuildPath, filepath.FromSlash(src))
+			info, err := os.Stat(localPath)
+			if err != nil {
+				continue
+			}
+
+			containerPath := c.Dest
+			if !info.IsDir() && c.DestIsDir() {
+				containerPath = path.Join(c.Dest, filepath.Base(localPath))
+			}
+			mappings = append(mappings, logstore.PathMapping{
+				ContainerPath: containerPath,
+				LocalPath:     localPath,
+			})
+		}
+	}
+	return mappings
+}
@@ -3,9 +3,12 @@ package tiltfile
 import (
 	"context"
 	"fmt"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/jonboulle/clockwork"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -24,10 +27,14 @@ import (
 	"github.com/tilt-dev/tilt/internal/controllers/apis/restarton"
 	"github.com/tilt-dev/tilt/internal/controllers/indexer"
 	"github.com/tilt-dev/tilt/internal/docker"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/localexec"
+	"github.com/tilt-dev/tilt/internal/ospath"
 	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/internal/store/buildcontrols"
 	"github.com/tilt-dev/tilt/internal/store/tiltfiles"
 	"github.com/tilt-dev/tilt/internal/tiltfile"
+	tiltfileos "github.com/tilt-dev/tilt/internal/tiltfile/os"
 	"github.com/tilt-dev/tilt/pkg/apis"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
 	"github.com/tilt-dev/tilt/pkg/logger"
@@ -43,9 +50,26 @@ type Reconciler struct {
 	indexer      *indexer.Indexer
 	buildSource  *BuildSource
 	engineMode   store.EngineMode
+	k8sClient    k8s.Client
+	cfgNS        k8s.Namespace
 	loadCount    int // used to differentiate spans
+	updates      *updateTracker
+	bootstrapper *bootstrapper
 
 	runs map[types.NamespacedName]*runStatus
+
+	// The args of the last successful load of each Tiltfile,
+	// so that we can roll back args that fail to load.
+	lastGoodArgs map[types.NamespacedName][]string
+
+	// How long to wait for file changes to stop before reloading a Tiltfile.
+	// Each Tiltfile can override this with watch_settings().
+	settleDelay  time.Duration
+	settleDelays map[types.NamespacedName]time.Duration
+
+	// Reads the environment that the Tiltfile will see, to check if any
+	// variable it read has changed.
+	lookupEnv func(key string) (string, bool)
 }
 
 func (r *Reconciler) CreateBuilder(mgr ctrl.Manager) (*builder.Builder, error) {
@@ -65,7 +89,8 @@ func (r *Reconciler) CreateBuilder(mgr ctrl.Manager) (*builder.Builder, error) {
 
 func NewReconciler(st store.RStore, tfl tiltfile.TiltfileLoader, dockerClient docker.Client,
 	ctrlClient ctrlclient.Client, scheme *runtime.Scheme,
-	buildSource *BuildSource, engineMode store.EngineMode) *Reconciler {
+	buildSource *BuildSource, engineMode store.EngineMode, k8sClient k8s.Client, cfgNS k8s.Namespace,
+	execer localexec.Execer, forceBootstrap ForceBootstrapFlag) *Reconciler {
 	return &Reconciler{
 		st:           st,
 		tfl:          tfl,
@@ -73,8 +98,16 @@ func NewReconciler(st store.RStore, tfl tiltfile.TiltfileLoader, dockerClient do
 		ctrlClient:   ctrlClient,
 		indexer:      indexer.NewIndexer(scheme, indexTiltfile),
 		runs:         make(map[types.NamespacedName]*runStatus),
+		updates:      newUpdateTracker(clockwork.NewRealClock()),
+		lastGoodArgs: make(map[types.NamespacedName][]string),
+		settleDelay:  model.DefaultTiltfileSettleDelay,
+		settleDelays: make(map[types.NamespacedName]time.Duration),
 		buildSource:  buildSource,
 		engineMode:   engineMode,
+		k8sClient:    k8sClient,
+		cfgNS:        cfgNS,
+		bootstrapper: newBootstrapper(k8sClient, cfgNS, execer, forceBootstrap),
+		lookupEnv:    os.LookupEnv,
 	}
 }
 
@@ -95,7 +128,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 		r.deleteExistingRun(nn)
 
 		// Delete owned objects
-		err := updateOwnedObjects(ctx, r.ctrlClient, nn, nil, nil, r.engineMode)
+		err := updateOwnedObjects(ctx, r.ctrlClient, r.updates, nn, nil, nil, r.engineMode)
 		if err != nil {
 			return ctrl.Result{}, err
 		}
@@ -110,7 +143,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 	run := r.runs[nn]
 	if run == nil {
 		// Initialize the UISession and filewatch if this has never been initialized before.
-		err := updateOwnedObjects(ctx, r.ctrlClient, nn, &tf, nil, r.engineMode)
+		err := updateOwnedObjects(ctx, r.ctrlClient, r.updates, nn, &tf, nil, r.engineMode)
 		if err != nil {
 			return ctrl.Result{}, err
 		}
@@ -123,6 +156,10 @@ func (r *Reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 	}
 
 	// If the tiltfile isn't being run, check to see if anything has triggered a run.
+	//
+	// If the tiltfile is being run, any changes that come in are coalesced
+	// into one more run after the current run finishes.
+	result := ctrl.Result{}
 	if step == runStepNone || step == runStepDone {
 		restartObjs, err := restarton.FetchObjects(ctx, r.ctrlClient, tf.Spec.RestartOn, nil)
 		if err != nil {
@@ -135,9 +172,11 @@ func (r *Reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 			return ctrl.Result{}, err
 		}
 
-		be := r.needsBuild(ctx, nn, &tf, run, restartObjs.FileWatches, queue, lastRestartEventTime)
+		be, settleWait := r.needsBuild(ctx, nn, &tf, run, restartObjs.FileWatches, queue, lastRestartEventTime)
 		if be != nil {
 			r.startRunAsync(ctx, nn, &tf, be)
+		} else if settleWait > 0 {
+			result.RequeueAfter = settleWait
 		}
 	}
 
@@ -163,7 +202,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 		}
 	}
 
-	return ctrl.Result{}, nil
+	return result, nil
 }
 
 // Modeled after BuildController.needsBuild and NextBuildReason(). Check to see that:
@@ -172,27 +211,44 @@ func (r *Reconciler) Reconcile(ctx context.Context, request reconcile.Request) (
 // 3) Those files have changed since the last Tiltfile build
 //    (so that we don't keep re-running a failed build)
 // 4) OR the command-line args have changed since the last Tiltfile build
-// 5) OR user has manually triggered a Tiltfile build
-func (r *Reconciler) needsBuild(ctx context.Context, nn types.NamespacedName, tf *v1alpha1.Tiltfile, run *runStatus, fileWatches map[string]*v1alpha1.FileWatch, triggerQueue *v1alpha1.ConfigMap, lastRestartEvent time.Time) *BuildEntry {
+// 5) OR an environment variable that the last Tiltfile build read has changed
+// 6) OR user has manually triggered a Tiltfile build
+//    (unless Tilt is read-only, in which case we only reload on file changes)
+//
+// If the only reason to build is file changes, and the files are still
+// changing, returns how long to wait for the changes to settle instead.
+func (r *Reconciler) needsBuild(ctx context.Context, nn types.NamespacedName, tf *v1alpha1.Tiltfile, run *runStatus, fileWatches map[string]*v1alpha1.FileWatch, triggerQueue *v1alpha1.ConfigMap, lastRestartEvent time.Time) (*BuildEntry, time.Duration) {
 	var reason model.BuildReason
 	filesChanged := []string{}
 
 	step := runStepNone
 	lastStartTime := time.Time{}
 	lastStartArgs := []string{}
+	var lastEnvReads []tiltfileos.EnvRead
 	if run != nil {
 		step = run.step
 		lastStartTime = run.startTime
 		lastStartArgs = run.startArgs
+		if run.tlr != nil {
+			lastEnvReads = run.tlr.EnvReads
+		}
 	}
 
+	readOnly := r.isReadOnly()
+
 	if step == runStepNone {
 		reason = reason.With(model.BuildReasonFlagInit)
 	} else {
-		filesChanged = restarton.FilesChanged(tf.Spec.RestartOn, fileWatches, lastStartTime)
+		seenFiles := restarton.FilesChanged(tf.Spec.RestartOn, fileWatches, lastStartTime)
+		filesChanged = run.configFilesChanged(ctx, seenFiles)
+
+		// If the latest event was a file under a watch_glob() that didn't
+		// match, it's not a reason to reload.
+		onlyIgnoredFiles := len(seenFiles) > 0 &&
+			!lastRestartEvent.After(restarton.LastFileEvent(tf.Spec.RestartOn, fileWatches))
 		if len(filesChanged) > 0 {
 			reason = reason.With(model.BuildReasonFlagChangedFiles)
-		} else if lastRestartEvent.After(lastStartTime) {
+		} else if lastRestartEvent.After(lastStartTime) && !readOnly && !onlyIgnoredFiles {
 			reason = reason.With(model.BuildReasonFlagTriggerUnknown)
 		}
 	}
@@ -202,12 +258,29 @@ func (r *Reconciler) needsBuild(ctx context.Context, nn types.NamespacedName, tf
 		reason = reason.With(model.BuildReasonFlagTiltfileArgs)
 	}
 
-	if configmap.InTriggerQueue(triggerQueue, nn) {
+	envChanged := tiltfileos.ChangedEnv(lastEnvReads, r.lookupEnv)
+	if len(envChanged) > 0 {
+		reason = reason.With(model.BuildReasonFlagTiltfileEnv)
+	}
+
+	if configmap.InTriggerQueue(triggerQueue, nn) && !readOnly {
 		reason = reason.With(configmap.TriggerQueueReason(triggerQueue, nn))
 	}
 
 	if reason == model.BuildReasonNone {
-		return nil
+		return nil, 0
+	}
+
+	if reason == model.BuildReasonFlagChangedFiles {
+		settleDelay := r.settleDelay
+		if d, ok := r.settleDelays[nn]; ok {
+			settleDelay = d
+		}
+		lastEvent := restarton.LastFileEvent(tf.Spec.RestartOn, fileWatches)
+		wait := time.Until(lastEvent.Add(settleDelay))
+		if wait > 0 {
+			return nil, wait
+		}
 	}
 
 	state := r.st.RLockState()
@@ -218,12 +291,19 @@ func (r *Reconciler) needsBuild(ctx context.Context, nn types.NamespacedName, tf
 	return &BuildEntry{
 		Name:                  model.ManifestName(nn.Name),
 		FilesChanged:          filesChanged,
+		EnvChanged:            envChanged,
 		BuildReason:           reason,
 		UserConfigState:       userConfigState,
 		TiltfilePath:          tf.Spec.Path,
 		CheckpointAtExecStart: state.LogStore.Checkpoint(),
 		LoadCount:             r.loadCount,
-	}
+	}, 0
+}
+
+func (r *Reconciler) isReadOnly() bool {
+	state := r.st.RLockState()
+	defer r.st.RUnlockState()
+	return state.ReadOnly
 }
 
 // Start a tiltfile run asynchronously, returning immediately.
@@ -264,6 +344,9 @@ func (r *Reconciler) run(ctx context.Context, nn types.NamespacedName, tf *v1alp
 	if entry.BuildReason.Has(model.BuildReasonFlagTiltfileArgs) {
 		logger.Get(ctx).Infof("Tiltfile args changed to: %v", userConfigState.Args)
 	}
+	if entry.BuildReason.Has(model.BuildReasonFlagTiltfileEnv) {
+		logger.Get(ctx).Infof("Environment variables changed: %s", strings.Join(entry.EnvChanged, ", "))
+	}
 
 	tlr := r.tfl.Load(ctx, tf)
 
@@ -281,7 +364,17 @@ func (r *Reconciler) run(ctx context.Context, nn types.NamespacedName, tf *v1alp
 	if requiresDocker(tlr) {
 		dockerErr := r.dockerClient.CheckConnected()
 		if tlr.Error == nil && dockerErr != nil {
-			tlr.Error = errors.Wrap(dockerErr, "Failed to connect to Docker")
+			tlr.Error = store.NewConnectivityError(errors.Wrap(dockerErr, "Failed to connect to Docker"))
+		}
+	}
+
+	// Bootstrap tasks run before we hand the manifests to the engine,
+	// so that they're done before the first build.
+	if tlr.Error == nil && tf.Name == model.MainTiltfileManifestName.String() && !r.isReadOnly() {
+		statuses, err := r.bootstrapper.run(ctx, tf, &tlr)
+		tlr.BootstrapStatuses = statuses
+		if err != nil {
+			tlr.Error = err
 		}
 	}
 
@@ -301,7 +394,7 @@ func (r *Reconciler) run(ctx context.Context, nn types.NamespacedName, tf *v1alp
 // apiserver.
 func (r *Reconciler) handleLoaded(ctx context.Context, nn types.NamespacedName, tf *v1alpha1.Tiltfile, entry *BuildEntry, tlr *tiltfile.TiltfileLoadResult) error {
 	// TODO(nick): Rewrite to handle multiple tiltfiles.
-	err := updateOwnedObjects(ctx, r.ctrlClient, nn, tf, tlr, r.engineMode)
+	err := updateOwnedObjects(ctx, r.ctrlClient, r.updates, nn, tf, tlr, r.engineMode)
 	if err != nil {
 		// If updating the API server fails, just return the error, so that the
 		// reconciler will retry.
@@ -310,6 +403,22 @@ func (r *Reconciler) handleLoaded(ctx context.Context, nn types.NamespacedName,
 
 	if tlr.Error != nil {
 		logger.Get(ctx).Errorf("%s", tlr.Error.Error())
+
+		err := r.maybeRestoreArgs(ctx, nn, tf, entry)
+		if err != nil {
+			return errors.Wrap(err, "Failed to restore Tiltfile args")
+		}
+	} else {
+		if reason := tlr.NoResourcesReason(); reason != "" {
+			logger.Get(ctx).Warnf("No resources enabled: %s", reason)
+		}
+
+		r.lastGoodArgs[nn] = entry.UserConfigState.Args
+		if tlr.WatchSettings.TiltfileSettleDelay != nil {
+			r.settleDelays[nn] = *tlr.WatchSettings.TiltfileSettleDelay
+		} else {
+			delete(r.settleDelays, nn)
+		}
 	}
 
 	r.st.Dispatch(ConfigsReloadedAction{
@@ -319,6 +428,7 @@ func (r *Reconciler) handleLoaded(ctx context.Context, nn types.NamespacedName,
 		ConfigFiles:           tlr.ConfigFiles,
 		FinishTime:            time.Now(),
 		Err:                   tlr.Error,
+		Warnings:              tlr.Warnings,
 		Features:              tlr.FeatureFlags,
 		TeamID:                tlr.TeamID,
 		TelemetrySettings:     tlr.TelemetrySettings,
@@ -329,6 +439,9 @@ func (r *Reconciler) handleLoaded(ctx context.Context, nn types.NamespacedName,
 		VersionSettings:       tlr.VersionSettings,
 		UpdateSettings:        tlr.UpdateSettings,
 		WatchSettings:         tlr.WatchSettings,
+		ImageRegistry:         tlr.ImageRegistry,
+		NoResourcesReason:     tlr.NoResourcesReason(),
+		BootstrapTasks:        tlr.BootstrapStatuses,
 	})
 
 	run, ok := r.runs[nn]
@@ -337,6 +450,12 @@ func (r *Reconciler) handleLoaded(ctx context.Context, nn types.NamespacedName,
 		run.finishTime = time.Now()
 	}
 
+	// Only the main Tiltfile owns the project's cluster objects. Projects without
+	// any Kubernetes resources don't talk to the cluster at all.
+	if tlr.Error == nil && nn.Name == model.MainTiltfileManifestName.String() && tlr.Orchestrator() == model.OrchestratorK8s {
+		go r.handleOrphans(ctx, tf.DeepCopy(), tlr)
+	}
+
 	// Schedule a reconcile in case any triggers happened while we were updating
 	// API objects.
 	r.buildSource.Add(nn)
@@ -344,8 +463,49 @@ func (r *Reconciler) handleLoaded(ctx context.Context, nn types.NamespacedName,
 	return nil
 }
 
+// If the Tiltfile failed to load because the user changed the args
+// (e.g., the new args fail config.parse()), switch back to the last args
+// that loaded successfully.
+//
+// Because a failed load never replaces the existing manifests, the running
+// resources keep running as if the args had never changed.
+func (r *Reconciler) maybeRestoreArgs(ctx context.Context, nn types.NamespacedName, tf *v1alpha1.Tiltfile, entry *BuildEntry) error {
+	if !entry.BuildReason.Has(model.BuildReasonFlagTiltfileArgs) ||
+		entry.BuildReason.Has(model.BuildReasonFlagChangedFiles) {
+		// If the files changed too, the error might be in the Tiltfile itself.
+		return nil
+	}
+
+	goodArgs, ok := r.lastGoodArgs[nn]
+	if !ok || !apicmp.DeepEqual(tf.Spec.Args, entry.UserConfigState.Args) {
+		return nil
+	}
+
+	update := tf.DeepCopy()
+	update.Spec.Args = goodArgs
+	err := r.ctrlClient.Update(ctx, update)
+	if err != nil {
+		return err
+	}
+	*tf = *update
+
+	// Don't reload the Tiltfile with the restored args. We already
+	// know how that turns out.
+	run, ok := r.runs[nn]
+	if ok {
+		run.startArgs = goodArgs
+	}
+	r.st.Dispatch(tiltfiles.SetTiltfileArgsAction{Args: goodArgs})
+
+	logger.Get(ctx).Errorf("Tiltfile failed to load with args %v. Restored the previous args: %v",
+		entry.UserConfigState.Args, goodArgs)
+	return nil
+}
+
 // Cancel execution of a running tiltfile and delete all record of it.
 func (r *Reconciler) deleteExistingRun(nn types.NamespacedName) {
+	delete(r.lastGoodArgs, nn)
+	delete(r.settleDelays, nn)
 	run, ok := r.runs[nn]
 	if !ok {
 		return
@@ -431,6 +591,62 @@ type runStatus struct {
 	startTime  time.Time
 	startArgs  []string
 	finishTime time.Time
+
+	// Built from tlr.ConfigGlobs the first time we need them.
+	globMatchers []model.PathMatcher
+}
+
+// Filters the files that the config FileWatch saw down to the ones that
+// should reload the Tiltfile.
+//
+// We watch the whole directory under each watch_glob(), so a file there
+// only counts if it matches one of the globs, or if the Tiltfile read it
+// some other way.
+func (rs *runStatus) configFilesChanged(ctx context.Context, seen []string) []string {
+	if rs.tlr == nil || len(rs.tlr.ConfigGlobs) == 0 {
+		return seen
+	}
+
+	if rs.globMatchers == nil {
+		rs.globMatchers = make([]model.PathMatcher, 0, len(rs.tlr.ConfigGlobs))
+		for _, g := range rs.tlr.ConfigGlobs {
+			m, err := g.Matcher()
+			if err != nil {
+				// The Tiltfile loader already checked the patterns, so this
+				// shouldn't happen. Err on the side of reloading.
+				logger.Get(ctx).Debugf("Invalid watch_glob(%q): %v", g.Pattern, err)
+				m = model.NewRelativeFileOrChildMatcher(g.BaseDir, g.BaseDir)
+			}
+			rs.globMatchers = append(rs.globMatchers, m)
+		}
+	}
+
+	result := []string{}
+	for _, f := range seen {
+		if rs.isConfigFile(f) {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+func (rs *runStatus) isConfigFile(f string) bool {
+	for _, m := range rs.globMatchers {
+		if ok, _ := m.Matches(f); ok {
+			return true
+		}
+	}
+	for _, cf := range rs.tlr.ConfigFiles {
+		if f == cf || ospath.IsChild(cf, f) {
+			return true
+		}
+	}
+	for _, g := range rs.tlr.ConfigGlobs {
+		if f == g.BaseDir || ospath.IsChild(g.BaseDir, f) {
+			return false
+		}
+	}
+	return true
 }
 
 func (rs *runStatus) TiltfileStatus() v1alpha1.TiltfileStatus {
@@ -446,11 +662,20 @@ func (rs *runStatus) TiltfileStatus() v1alpha1.TiltfileStatus {
 		if rs.tlr.Error != nil {
 			error = rs.tlr.Error.Error()
 		}
+		var globs []v1alpha1.TiltfileWatchedGlob
+		for _, g := range rs.tlr.ConfigGlobs {
+			globs = append(globs, v1alpha1.TiltfileWatchedGlob{
+				Pattern:    g.Pattern,
+				Ignores:    g.Ignores,
+				MatchCount: int32(len(g.Matches)),
+			})
+		}
 		return v1alpha1.TiltfileStatus{
 			Terminated: &v1alpha1.TiltfileStateTerminated{
-				StartedAt:  apis.NewMicroTime(rs.startTime),
-				FinishedAt: apis.NewMicroTime(rs.finishTime),
-				Error:      error,
+				StartedAt:    apis.NewMicroTime(rs.startTime),
+				FinishedAt:   apis.NewMicroTime(rs.finishTime),
+				Error:        error,
+				WatchedGlobs: globs,
 			},
 		}
 	}
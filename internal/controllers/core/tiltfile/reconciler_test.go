@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"path/filepath"
 	"reflect"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 
@@ -18,13 +20,20 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/tilt-dev/tilt/internal/container"
+	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
 	"github.com/tilt-dev/tilt/internal/controllers/fake"
 	"github.com/tilt-dev/tilt/internal/docker"
+	"github.com/tilt-dev/tilt/internal/k8s"
 	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
+	"github.com/tilt-dev/tilt/internal/localexec"
 	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/store/tiltfiles"
 	"github.com/tilt-dev/tilt/internal/testutils/manifestbuilder"
 	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
 	"github.com/tilt-dev/tilt/internal/tiltfile"
+	"github.com/tilt-dev/tilt/internal/tiltfile/io"
+	tiltfileos "github.com/tilt-dev/tilt/internal/tiltfile/os"
+	"github.com/tilt-dev/tilt/pkg/apis"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
 	"github.com/tilt-dev/tilt/pkg/model"
 )
@@ -209,6 +218,379 @@ func TestLocalServe(t *testing.T) {
 	require.Equal(t, "foo-disable", lt.ServeCmdDisableSource.ConfigMap.Name)
 }
 
+func TestArgsAddAndRemoveResources(t *testing.T) {
+	f := newFixture(t)
+	f.tfl.Delegate = newArgsLoader(f.tempdir)
+	nn := types.NamespacedName{Name: "my-tf"}
+
+	tf := v1alpha1.Tiltfile{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-tf"},
+		Spec: v1alpha1.TiltfileSpec{
+			Path: f.tempdir.JoinPath("Tiltfile"),
+			Args: []string{"fe"},
+		},
+	}
+	f.Create(&tf)
+	f.waitForLoad(nn)
+	assert.Equal(t, []string{"fe"}, f.uiResourceNames())
+
+	f.setArgs(nn, "fe", "be")
+	f.waitForLoad(nn)
+	assert.Equal(t, []string{"be", "fe"}, f.uiResourceNames())
+
+	f.setArgs(nn, "be")
+	f.waitForLoad(nn)
+	assert.Equal(t, []string{"be"}, f.uiResourceNames())
+}
+
+func TestReloadOnEnvChange(t *testing.T) {
+	f := newFixture(t)
+	nn := types.NamespacedName{Name: "my-tf"}
+	env := map[string]string{"MY_PORT": "8000"}
+	f.r.lookupEnv = func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+	f.tfl.Result = tiltfile.TiltfileLoadResult{
+		EnvReads: []tiltfileos.EnvRead{
+			{Name: "MY_PORT", Value: "8000", Set: true},
+			{Name: "MY_DEBUG"},
+		},
+	}
+
+	tf := v1alpha1.Tiltfile{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-tf"},
+		Spec:       v1alpha1.TiltfileSpec{Path: f.tempdir.JoinPath("Tiltfile")},
+	}
+	f.Create(&tf)
+	f.waitForLoad(nn)
+
+	// Variables the Tiltfile didn't read don't matter.
+	env["UNRELATED"] = "1"
+	f.MustReconcile(nn)
+	assert.Equal(t, 1, f.reloadCount())
+
+	env["MY_PORT"] = "9000"
+	f.tfl.Result.EnvReads = []tiltfileos.EnvRead{
+		{Name: "MY_PORT", Value: "9000", Set: true},
+		{Name: "MY_DEBUG"},
+	}
+	f.MustReconcile(nn)
+	f.waitForLoad(nn)
+	assert.Equal(t, 2, f.reloadCount())
+	assert.True(t, f.lastReloadStarted().Reason.Has(model.BuildReasonFlagTiltfileEnv))
+	assert.Contains(t, f.st.out.String(), "Environment variables changed: MY_PORT")
+
+	// Once the Tiltfile has seen the new value, it doesn't reload again.
+	f.MustReconcile(nn)
+	assert.Equal(t, 2, f.reloadCount())
+}
+
+func TestArgsRestoredOnLoadError(t *testing.T) {
+	f := newFixture(t)
+	f.tfl.Delegate = newArgsLoader(f.tempdir)
+	nn := types.NamespacedName{Name: "my-tf"}
+
+	tf := v1alpha1.Tiltfile{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-tf"},
+		Spec: v1alpha1.TiltfileSpec{
+			Path: f.tempdir.JoinPath("Tiltfile"),
+			Args: []string{"fe"},
+		},
+	}
+	f.Create(&tf)
+	f.waitForLoad(nn)
+
+	f.setArgs(nn, "fe", "nope")
+	f.waitForLoad(nn)
+
+	f.MustGet(nn, &tf)
+	assert.Equal(t, []string{"fe"}, tf.Spec.Args)
+	assert.Contains(t, tf.Status.Terminated.Error, `unknown resource "nope"`)
+	assert.Equal(t, []string{"fe"}, f.uiResourceNames())
+	assert.Contains(t, f.st.out.String(), "Tiltfile failed to load with args [fe nope]. Restored the previous args: [fe]")
+
+	a := f.st.WaitForAction(t, reflect.TypeOf(tiltfiles.SetTiltfileArgsAction{})).(tiltfiles.SetTiltfileArgsAction)
+	assert.Equal(t, []string{"fe"}, a.Args)
+
+	// The restored args don't trigger another reload.
+	f.MustReconcile(nn)
+	f.MustGet(nn, &tf)
+	assert.Nil(t, tf.Status.Running)
+}
+
+func TestCoalesceChangesDuringLoad(t *testing.T) {
+	f := newFixture(t)
+	f.r.settleDelay = 0
+	loader := newBlockingLoader()
+	f.tfl.Delegate = loader
+	nn := types.NamespacedName{Name: "my-tf"}
+
+	f.createTiltfileWithFileWatch()
+	f.waitForLoad(nn)
+	assert.Equal(t, 1, loader.count())
+
+	// The first change starts a load.
+	loader.block()
+	f.changeFiles("Tiltfile")
+	f.MustReconcile(nn)
+	assert.Equal(t, 2, loader.waitForCount(2))
+
+	// The rest of the burst comes in during the load.
+	f.changeFiles("a.star")
+	f.MustReconcile(nn)
+	f.changeFiles("b.star")
+	f.MustReconcile(nn)
+	f.changeFiles("c.star")
+	f.MustReconcile(nn)
+
+	// When the load finishes, it schedules another reconcile,
+	// which starts exactly one more load with all the changes from the burst.
+	loader.unblock()
+	f.popQueueUntil(func() bool { return loader.count() >= 3 })
+	a := f.lastReloadStarted()
+	assert.Equal(t, []string{"a.star", "b.star", "c.star"}, a.FilesChanged)
+
+	f.MustReconcile(nn)
+	assert.Equal(t, 3, loader.count())
+}
+
+func TestSettleDelay(t *testing.T) {
+	f := newFixture(t)
+	f.r.settleDelay = 50 * time.Millisecond
+	loader := newBlockingLoader()
+	f.tfl.Delegate = loader
+	nn := types.NamespacedName{Name: "my-tf"}
+
+	f.createTiltfileWithFileWatch()
+	f.waitForLoad(nn)
+	assert.Equal(t, 1, loader.count())
+
+	// While changes are streaming in, wait for them to settle.
+	f.changeFiles("Tiltfile")
+	result := f.MustReconcile(nn)
+	assert.Greater(t, int64(result.RequeueAfter), int64(0))
+	f.changeFiles("a.star")
+	result = f.MustReconcile(nn)
+	assert.Greater(t, int64(result.RequeueAfter), int64(0))
+	assert.Equal(t, 1, loader.count())
+
+	time.Sleep(result.RequeueAfter)
+	result = f.MustReconcile(nn)
+	assert.Equal(t, time.Duration(0), result.RequeueAfter)
+	f.waitForLoad(nn)
+	assert.Equal(t, 2, loader.count())
+	assert.Equal(t, []string{"Tiltfile", "a.star"}, f.lastReloadStarted().FilesChanged)
+}
+
+func TestSettleDelayFromWatchSettings(t *testing.T) {
+	f := newFixture(t)
+	f.r.settleDelay = 0
+	settle := time.Hour
+	f.tfl.Result = tiltfile.TiltfileLoadResult{
+		WatchSettings: model.WatchSettings{TiltfileSettleDelay: &settle},
+	}
+	nn := types.NamespacedName{Name: "my-tf"}
+
+	f.createTiltfileWithFileWatch()
+	f.waitForLoad(nn)
+
+	f.changeFiles("Tiltfile")
+	result := f.MustReconcile(nn)
+	assert.Greater(t, int64(result.RequeueAfter), int64(time.Minute))
+}
+
+func TestReadOnlyIgnoresTriggersButReloadsOnFileChange(t *testing.T) {
+	f := newFixture(t)
+	f.r.settleDelay = 0
+	f.st.WithState(func(state *store.EngineState) {
+		state.ReadOnly = true
+	})
+	loader := newBlockingLoader()
+	f.tfl.Delegate = loader
+	nn := types.NamespacedName{Name: "my-tf"}
+
+	f.createTiltfileWithFileWatch()
+	f.waitForLoad(nn)
+	assert.Equal(t, 1, loader.count())
+
+	f.Upsert(&v1alpha1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: configmap.TriggerQueueName,
+		},
+		Data: map[string]string{
+			"0-name":        "my-tf",
+			"0-reason-code": fmt.Sprintf("%d", model.BuildReasonFlagTriggerWeb),
+		},
+	})
+	f.MustReconcile(nn)
+	assert.Equal(t, 1, loader.count())
+
+	f.changeFiles("Tiltfile")
+	f.MustReconcile(nn)
+	f.waitForLoad(nn)
+	assert.Equal(t, 2, loader.count())
+	a := f.lastReloadStarted()
+	assert.Equal(t, []string{"Tiltfile"}, a.FilesChanged)
+	assert.False(t, a.Reason.Has(model.BuildReasonFlagTriggerWeb))
+}
+
+func TestWatchGlobFiltersFileEvents(t *testing.T) {
+	f := newFixture(t)
+	f.r.settleDelay = 0
+	config := f.tempdir.JoinPath("config")
+	f.tfl.Result = tiltfile.TiltfileLoadResult{
+		ConfigFiles: []string{f.tempdir.JoinPath("Tiltfile")},
+		ConfigGlobs: []io.WatchedGlob{{
+			Pattern: filepath.Join(config, "**", "*.yaml"),
+			Ignores: []string{filepath.Join(config, "generated")},
+			BaseDir: config,
+			Matches: []string{filepath.Join(config, "a.yaml")},
+		}},
+	}
+	nn := types.NamespacedName{Name: "my-tf"}
+
+	f.createTiltfileWithFileWatch()
+	f.waitForLoad(nn)
+	assert.Equal(t, 1, f.reloadCount())
+
+	var tf v1alpha1.Tiltfile
+	f.MustGet(nn, &tf)
+	assert.Equal(t, []v1alpha1.TiltfileWatchedGlob{{
+		Pattern:    filepath.Join(config, "**", "*.yaml"),
+		Ignores:    []string{filepath.Join(config, "generated")},
+		MatchCount: 1,
+	}}, tf.Status.Terminated.WatchedGlobs)
+
+	// Files under the glob's directory that don't match don't reload.
+	f.changeFiles(filepath.Join(config, "notes.txt"))
+	f.MustReconcile(nn)
+	f.changeFiles(filepath.Join(config, "generated", "b.yaml"))
+	f.MustReconcile(nn)
+	assert.Equal(t, 1, f.reloadCount())
+
+	// A new file that matches does.
+	f.changeFiles(filepath.Join(config, "nested", "c.yaml"))
+	f.MustReconcile(nn)
+	f.waitForLoad(nn)
+	assert.Equal(t, 2, f.reloadCount())
+	assert.Equal(t, []string{filepath.Join(config, "nested", "c.yaml")}, f.lastReloadStarted().FilesChanged)
+
+	// So does the Tiltfile.
+	f.changeFiles(f.tempdir.JoinPath("Tiltfile"))
+	f.MustReconcile(nn)
+	f.waitForLoad(nn)
+	assert.Equal(t, 3, f.reloadCount())
+}
+
+func TestWarnWhenNoResourcesEnabled(t *testing.T) {
+	f := newFixture(t)
+	f.tfl.Result = tiltfile.TiltfileLoadResult{
+		DefinedManifestCount:   3,
+		EnabledResourcesFilter: "command-line args",
+	}
+	nn := types.NamespacedName{Name: "my-tf"}
+
+	f.createTiltfileWithFileWatch()
+	f.waitForLoad(nn)
+
+	reason := "3 resources were defined, but all were filtered out by command-line args"
+	assert.Contains(t, f.st.out.String(), "No resources enabled: "+reason)
+
+	var reloaded []ConfigsReloadedAction
+	for _, a := range f.st.Actions() {
+		if action, ok := a.(ConfigsReloadedAction); ok {
+			reloaded = append(reloaded, action)
+		}
+	}
+	require.Len(t, reloaded, 1)
+	assert.Equal(t, reason, reloaded[0].NoResourcesReason)
+}
+
+func TestNoWarningWhenTiltfileFails(t *testing.T) {
+	f := newFixture(t)
+	f.tfl.Result = tiltfile.TiltfileLoadResult{Error: fmt.Errorf("syntax error")}
+	nn := types.NamespacedName{Name: "my-tf"}
+
+	f.createTiltfileWithFileWatch()
+	f.waitForLoad(nn)
+
+	assert.NotContains(t, f.st.out.String(), "No resources enabled")
+}
+
+// Counts loads, and optionally blocks them until the test unblocks them.
+type blockingLoader struct {
+	mu      sync.Mutex
+	n       int
+	blockCh chan struct{}
+}
+
+func newBlockingLoader() *blockingLoader {
+	return &blockingLoader{}
+}
+
+func (l *blockingLoader) Load(ctx context.Context, tf *v1alpha1.Tiltfile) tiltfile.TiltfileLoadResult {
+	l.mu.Lock()
+	l.n++
+	ch := l.blockCh
+	l.mu.Unlock()
+
+	if ch != nil {
+		<-ch
+	}
+	return tiltfile.TiltfileLoadResult{}
+}
+
+func (l *blockingLoader) block() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.blockCh = make(chan struct{})
+}
+
+func (l *blockingLoader) unblock() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	close(l.blockCh)
+	l.blockCh = nil
+}
+
+func (l *blockingLoader) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.n
+}
+
+func (l *blockingLoader) waitForCount(n int) int {
+	deadline := time.Now().Add(time.Second)
+	for l.count() < n && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	return l.count()
+}
+
+// Loads a local resource for each arg, like a Tiltfile that uses config.parse()
+// to select resources.
+type argsLoader struct {
+	tempdir *tempdir.TempDirFixture
+}
+
+func newArgsLoader(tempdir *tempdir.TempDirFixture) argsLoader {
+	return argsLoader{tempdir: tempdir}
+}
+
+func (l argsLoader) Load(ctx context.Context, tf *v1alpha1.Tiltfile) tiltfile.TiltfileLoadResult {
+	var manifests []model.Manifest
+	for _, arg := range tf.Spec.Args {
+		if arg != "fe" && arg != "be" {
+			return tiltfile.TiltfileLoadResult{Error: fmt.Errorf("unknown resource %q", arg)}
+		}
+		manifests = append(manifests, manifestbuilder.New(l.tempdir, model.ManifestName(arg)).
+			WithLocalResource(fmt.Sprintf("echo %s", arg), "", nil).
+			Build())
+	}
+	return tiltfile.TiltfileLoadResult{Manifests: manifests}
+}
+
 type testStore struct {
 	*store.TestingStore
 	out *bytes.Buffer
@@ -238,6 +620,8 @@ type fixture struct {
 	bs      *BuildSource
 	q       workqueue.RateLimitingInterface
 	tfl     *tiltfile.FakeTiltfileLoader
+	kClient *k8s.FakeK8sClient
+	execer  *localexec.FakeExecer
 }
 
 func newFixture(t *testing.T) *fixture {
@@ -249,7 +633,9 @@ func newFixture(t *testing.T) *fixture {
 	tfl := tiltfile.NewFakeTiltfileLoader()
 	d := docker.NewFakeClient()
 	bs := NewBuildSource()
-	r := NewReconciler(st, tfl, d, cfb.Client, v1alpha1.NewScheme(), bs, store.EngineModeUp)
+	kClient := k8s.NewFakeK8sClient(t)
+	execer := localexec.NewFakeExecer(t)
+	r := NewReconciler(st, tfl, d, cfb.Client, v1alpha1.NewScheme(), bs, store.EngineModeUp, kClient, "default", execer, false)
 	q := workqueue.NewRateLimitingQueue(
 		workqueue.NewItemExponentialFailureRateLimiter(time.Millisecond, time.Millisecond))
 	_ = bs.Start(context.Background(), handler.Funcs{}, q)
@@ -262,7 +648,99 @@ func newFixture(t *testing.T) *fixture {
 		bs:                bs,
 		q:                 q,
 		tfl:               tfl,
+		kClient:           kClient,
+		execer:            execer,
+	}
+}
+
+func (f *fixture) createTiltfileWithFileWatch() {
+	tf := v1alpha1.Tiltfile{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-tf"},
+		Spec: v1alpha1.TiltfileSpec{
+			Path: f.tempdir.JoinPath("Tiltfile"),
+			RestartOn: &v1alpha1.RestartOnSpec{
+				FileWatches: []string{"configs:my-tf"},
+			},
+		},
+	}
+	f.Create(&tf)
+}
+
+func (f *fixture) changeFiles(files ...string) {
+	var fw v1alpha1.FileWatch
+	f.MustGet(types.NamespacedName{Name: "configs:my-tf"}, &fw)
+	now := apis.NowMicro()
+	fw.Status.LastEventTime = now
+	fw.Status.FileEvents = append(fw.Status.FileEvents, v1alpha1.FileEvent{Time: now, SeenFiles: files})
+	require.NoError(f.T(), f.Client.Status().Update(f.Context(), &fw))
+}
+
+func (f *fixture) lastReloadStarted() ConfigsReloadStartedAction {
+	var result ConfigsReloadStartedAction
+	for _, a := range f.st.Actions() {
+		if a, ok := a.(ConfigsReloadStartedAction); ok {
+			result = a
+		}
+	}
+	return result
+}
+
+func (f *fixture) reloadCount() int {
+	count := 0
+	for _, a := range f.st.Actions() {
+		if _, ok := a.(ConfigsReloadStartedAction); ok {
+			count++
+		}
+	}
+	return count
+}
+
+func (f *fixture) setArgs(nn types.NamespacedName, args ...string) {
+	var tf v1alpha1.Tiltfile
+	f.MustGet(nn, &tf)
+	tf.Spec.Args = args
+	f.Update(&tf)
+}
+
+// Run reconciles off the workqueue until the condition is true.
+func (f *fixture) popQueueUntil(cond func() bool) {
+	f.T().Helper()
+	for i := 0; i < 10 && !cond(); i++ {
+		f.popQueue()
+	}
+	require.True(f.T(), cond(), "condition never satisfied")
+}
+
+// Wait for the Tiltfile to start loading, then wait for it to finish.
+func (f *fixture) waitForLoad(nn types.NamespacedName) {
+	f.T().Helper()
+
+	var tf v1alpha1.Tiltfile
+	require.Eventually(f.T(), func() bool {
+		f.MustGet(nn, &tf)
+		return tf.Status.Running != nil
+	}, time.Second, time.Millisecond)
+
+	f.popQueue()
+
+	require.Eventually(f.T(), func() bool {
+		f.MustGet(nn, &tf)
+		return tf.Status.Terminated != nil
+	}, time.Second, time.Millisecond)
+}
+
+func (f *fixture) uiResourceNames() []string {
+	var list v1alpha1.UIResourceList
+	f.List(&list)
+
+	var names []string
+	for _, r := range list.Items {
+		if r.Name != "my-tf" {
+			names = append(names, r.Name)
+		}
 	}
+	sort.Strings(names)
+	return names
 }
 
 // Wait for the next item on the workqueue, then run reconcile on it.
@@ -2,6 +2,7 @@ package tiltfile
 
 import (
 	"context"
+	"path/filepath"
 
 	"github.com/tilt-dev/tilt/internal/sliceutils"
 	"github.com/tilt-dev/tilt/internal/store"
@@ -108,6 +109,12 @@ func HandleConfigsReloaded(
 		ms.AddCompletedBuild(b)
 	}
 	ms.CurrentBuild = model.BuildRecord{}
+
+	// A failed bootstrap task fails the load, but we still want to show it.
+	if isMainTiltfile && (event.Err == nil || event.BootstrapTasks != nil) {
+		state.BootstrapTasks = event.BootstrapTasks
+	}
+
 	if event.Err != nil {
 		// When the Tiltfile had an error, we want to differentiate between two cases:
 		//
@@ -137,6 +144,15 @@ func HandleConfigsReloaded(
 		return
 	}
 
+	if isMainTiltfile {
+		state.NoResourcesReason = event.NoResourcesReason
+	}
+
+	workspace := ""
+	if state.DesiredTiltfilePath != "" {
+		workspace = filepath.Dir(state.DesiredTiltfilePath)
+	}
+
 	// Make sure all the new manifests are in the EngineState.
 	for _, m := range manifests {
 		mt, ok := state.ManifestTargets[m.ManifestName()]
@@ -152,6 +168,7 @@ func HandleConfigsReloaded(
 		configFilesThatChanged := ms.LastBuild().Edits
 		old := mt.Manifest
 		mt.Manifest = m
+		delete(state.TriggerModeOverrides, m.Name)
 
 		if model.ChangesInvalidateBuild(old, m) {
 			// Manifest has changed such that the current build is invalid;
@@ -162,6 +179,7 @@ func HandleConfigsReloaded(
 			ms.ConfigFilesThatCausedChange = configFilesThatChanged
 		}
 		state.UpsertManifestTarget(mt)
+		state.LogStore.SetPathMapper(m.Name, manifestPathMapper(workspace, m))
 	}
 
 	// Go through all the existing manifest targets. If they were from this
@@ -172,6 +190,7 @@ func HandleConfigsReloaded(
 		if m.SourceTiltfile == event.Name {
 			if !loadedManifestNames[m.Name] {
 				state.RemoveManifestTarget(m.Name)
+				state.LogStore.SetPathMapper(m.Name, nil)
 			}
 			continue
 		}
@@ -179,6 +198,8 @@ func HandleConfigsReloaded(
 
 	state.TiltfileConfigPaths[event.Name] = event.ConfigFiles
 
+	updateTiltfileWarnings(state, ms, event, loadedManifestNames)
+
 	// Global state that's only configurable from the main manifest.
 	if isMainTiltfile {
 		state.Features = event.Features
@@ -187,5 +208,35 @@ func HandleConfigsReloaded(
 		state.AnalyticsTiltfileOpt = event.AnalyticsTiltfileOpt
 		state.UpdateSettings = event.UpdateSettings
 		state.DockerPruneSettings = event.DockerPruneSettings
+
+		if event.Err == nil && event.ImageRegistry != state.ImageRegistry {
+			logger.Get(ctx).Infof("Image registry: %s", event.ImageRegistry)
+			state.ImageRegistry = event.ImageRegistry
+		}
+	}
+}
+
+// Shows each warning on the resource it's about, and global warnings
+// (or warnings about resources this Tiltfile didn't define) on the Tiltfile.
+//
+// Warnings that the latest load didn't produce are cleared.
+func updateTiltfileWarnings(state *store.EngineState, ms *store.ManifestState, event ConfigsReloadedAction, loadedManifestNames map[model.ManifestName]bool) {
+	byManifest := make(map[model.ManifestName][]model.TiltfileWarning)
+	var global []model.TiltfileWarning
+	for _, w := range event.Warnings {
+		if w.ManifestName != "" && loadedManifestNames[w.ManifestName] {
+			byManifest[w.ManifestName] = append(byManifest[w.ManifestName], w)
+			continue
+		}
+		global = append(global, w)
+	}
+
+	ms.TiltfileWarnings = global
+	for name := range loadedManifestNames {
+		mt, ok := state.ManifestTargets[name]
+		if !ok || mt.Manifest.SourceTiltfile != event.Name {
+			continue
+		}
+		mt.State.TiltfileWarnings = byManifest[name]
 	}
 }
@@ -1,13 +1,20 @@
 package tiltfile
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/tilt-dev/tilt/internal/container"
 	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
 	"github.com/tilt-dev/tilt/pkg/logger"
 	"github.com/tilt-dev/tilt/pkg/model"
 )
@@ -84,3 +91,180 @@ func TestManifestOrder(t *testing.T) {
 		[]model.ManifestName{"b", "extra-x", "d", "extra-omega", "a", "c"},
 		state.ManifestDefinitionOrder)
 }
+
+func TestReloadAttributesAndClearsWarnings(t *testing.T) {
+	ctx := logger.WithLogger(context.Background(), logger.NewTestLogger(os.Stdout))
+	state := store.NewState()
+	tfMain := model.MainTiltfileManifestName
+
+	fooWarning := model.TiltfileWarning{Key: "foo:ports", Message: "check your ports", ManifestName: "foo"}
+	globalWarning := model.TiltfileWarning{Key: "metrics", Message: "deprecated"}
+	unknownWarning := model.TiltfileWarning{Key: "baz:typo", Message: "typo", ManifestName: "baz"}
+
+	HandleConfigsReloaded(ctx, state, ConfigsReloadedAction{
+		Name:      tfMain,
+		Manifests: []model.Manifest{{Name: "foo"}, {Name: "bar"}},
+		Warnings:  []model.TiltfileWarning{fooWarning, globalWarning, unknownWarning},
+	})
+	assert.Equal(t, []model.TiltfileWarning{fooWarning}, state.ManifestTargets["foo"].State.TiltfileWarnings)
+	assert.Empty(t, state.ManifestTargets["bar"].State.TiltfileWarnings)
+	assert.Equal(t, []model.TiltfileWarning{globalWarning, unknownWarning}, state.MainTiltfileState().TiltfileWarnings)
+
+	// A failed load doesn't clear anything.
+	HandleConfigsReloaded(ctx, state, ConfigsReloadedAction{
+		Name: tfMain,
+		Err:  fmt.Errorf("syntax error"),
+	})
+	assert.Equal(t, []model.TiltfileWarning{fooWarning}, state.ManifestTargets["foo"].State.TiltfileWarnings)
+	assert.Equal(t, []model.TiltfileWarning{globalWarning, unknownWarning}, state.MainTiltfileState().TiltfileWarnings)
+
+	HandleConfigsReloaded(ctx, state, ConfigsReloadedAction{
+		Name:      tfMain,
+		Manifests: []model.Manifest{{Name: "foo"}, {Name: "bar"}},
+		Warnings:  []model.TiltfileWarning{globalWarning},
+	})
+	assert.Empty(t, state.ManifestTargets["foo"].State.TiltfileWarnings)
+	assert.Equal(t, []model.TiltfileWarning{globalWarning}, state.MainTiltfileState().TiltfileWarnings)
+}
+
+func TestReloadClearsTriggerModeOverrides(t *testing.T) {
+	ctx := logger.WithLogger(context.Background(), logger.NewTestLogger(os.Stdout))
+	state := store.NewState()
+
+	tfMain := model.MainTiltfileManifestName
+	HandleConfigsReloaded(ctx, state, ConfigsReloadedAction{
+		Name: tfMain,
+		Manifests: []model.Manifest{
+			model.Manifest{Name: "a"},
+			model.Manifest{Name: "b"},
+			model.Manifest{Name: "c"},
+		},
+	})
+
+	override := store.TriggerModeOverride{
+		TriggerMode:         model.TriggerModeManual,
+		TiltfileTriggerMode: model.TriggerModeAuto,
+	}
+	state.TriggerModeOverrides["a"] = override
+	state.TriggerModeOverrides["c"] = override
+
+	HandleConfigsReloaded(ctx, state, ConfigsReloadedAction{
+		Name: tfMain,
+		Manifests: []model.Manifest{
+			model.Manifest{Name: "a"},
+			model.Manifest{Name: "b"},
+		},
+	})
+	assert.Empty(t, state.TriggerModeOverrides)
+}
+
+func TestImageRegistryLoggedOnce(t *testing.T) {
+	out := &bytes.Buffer{}
+	ctx := logger.WithLogger(context.Background(), logger.NewTestLogger(out))
+	state := store.NewState()
+
+	reg, err := container.NewRegistryWithHostFromCluster("localhost:5000", "registry:5000")
+	require.NoError(t, err)
+	discovered := model.ImageRegistry{Registry: reg, Source: model.ImageRegistrySourceDiscovered}
+
+	tfMain := model.MainTiltfileManifestName
+	HandleConfigsReloaded(ctx, state, ConfigsReloadedAction{Name: tfMain, ImageRegistry: discovered})
+	HandleConfigsReloaded(ctx, state, ConfigsReloadedAction{Name: tfMain, ImageRegistry: discovered})
+	assert.Equal(t, discovered, state.ImageRegistry)
+	assert.Equal(t, 1, strings.Count(out.String(),
+		"Image registry: localhost:5000 (cluster pulls from registry:5000) [discovered]"))
+
+	// A failed load keeps the registry from the last successful load.
+	HandleConfigsReloaded(ctx, state, ConfigsReloadedAction{Name: tfMain, Err: fmt.Errorf("oops")})
+	assert.Equal(t, discovered, state.ImageRegistry)
+
+	configured := model.ImageRegistry{
+		Registry: container.MustNewRegistry("bar.com"),
+		Source:   model.ImageRegistrySourceConfigured,
+	}
+	HandleConfigsReloaded(ctx, state, ConfigsReloadedAction{Name: tfMain, ImageRegistry: configured})
+	assert.Equal(t, configured, state.ImageRegistry)
+	assert.Contains(t, out.String(), "Image registry: bar.com [configured]")
+}
+
+func TestBootstrapTaskStatuses(t *testing.T) {
+	ctx := logger.WithLogger(context.Background(), logger.NewTestLogger(os.Stdout))
+	state := store.NewState()
+	tfMain := model.MainTiltfileManifestName
+
+	ok := []model.BootstrapTaskStatus{{Name: "certs", Skipped: true}}
+	HandleConfigsReloaded(ctx, state, ConfigsReloadedAction{Name: tfMain, BootstrapTasks: ok})
+	assert.Equal(t, ok, state.BootstrapTasks)
+
+	// A Tiltfile error before we got to the bootstrap tasks keeps the old statuses.
+	HandleConfigsReloaded(ctx, state, ConfigsReloadedAction{Name: tfMain, Err: fmt.Errorf("syntax error")})
+	assert.Equal(t, ok, state.BootstrapTasks)
+
+	// A failed bootstrap task replaces them.
+	failed := []model.BootstrapTaskStatus{{Name: "certs", Error: "exit status 1"}}
+	HandleConfigsReloaded(ctx, state, ConfigsReloadedAction{
+		Name:           tfMain,
+		Err:            fmt.Errorf("Bootstrap task \"certs\" failed"),
+		BootstrapTasks: failed,
+	})
+	assert.Equal(t, failed, state.BootstrapTasks)
+
+	// Removing all the tasks clears them.
+	HandleConfigsReloaded(ctx, state, ConfigsReloadedAction{Name: tfMain})
+	assert.Empty(t, state.BootstrapTasks)
+}
+
+func TestReloadMapsContainerPathsInLogs(t *testing.T) {
+	f := tempdir.NewTempDirFixture(t)
+	defer f.TearDown()
+	f.WriteFile("Tiltfile", "")
+	f.WriteFile("api/main.go", "")
+	f.WriteFile("web/src/index.js", "")
+
+	ctx := logger.WithLogger(context.Background(), logger.NewTestLogger(os.Stdout))
+	state := store.NewState()
+	state.DesiredTiltfilePath = f.JoinPath("Tiltfile")
+
+	api := model.MustNewImageTarget(container.MustParseSelector("api")).
+		WithBuildDetails(model.DockerBuild{
+			BuildPath: f.JoinPath("api"),
+			Dockerfile: `FROM golang:1.17
+WORKDIR /go/src/api
+COPY main.go .
+`,
+		})
+	web := model.MustNewImageTarget(container.MustParseSelector("web")).
+		WithLiveUpdateSpec("web", v1alpha1.LiveUpdateSpec{
+			BasePath: f.Path(),
+			Syncs:    []v1alpha1.LiveUpdateSync{{LocalPath: "web/src", ContainerPath: "/app/src"}},
+		}).
+		WithBuildDetails(model.DockerBuild{
+			BuildPath:  f.JoinPath("web"),
+			Dockerfile: "FROM node:14\nCOPY src /app/src\n",
+		})
+
+	tfMain := model.MainTiltfileManifestName
+	HandleConfigsReloaded(ctx, state, ConfigsReloadedAction{
+		Name: tfMain,
+		Manifests: []model.Manifest{
+			model.Manifest{Name: "api"}.WithImageTarget(api),
+			model.Manifest{Name: "web"}.WithImageTarget(web),
+		},
+	})
+
+	state.LogStore.Append(store.NewLogAction("api", "build:api", logger.InfoLvl, nil,
+		[]byte("/go/src/api/main.go:5:2: undefined: foo\n")), nil)
+	state.LogStore.Append(store.NewLogAction("web", "pod:web", logger.InfoLvl, nil,
+		[]byte("    at Object.<anonymous> (/app/src/index.js:1:7)\n")), nil)
+	assert.Equal(t, "/go/src/api/main.go:5:2 [api/main.go:5:2]: undefined: foo\n", state.LogStore.ManifestLog("api"))
+	assert.Equal(t, "    at Object.<anonymous> (/app/src/index.js:1:7 [web/src/index.js:1:7])\n", state.LogStore.ManifestLog("web"))
+
+	// Once the resource is gone, we stop mapping its paths.
+	HandleConfigsReloaded(ctx, state, ConfigsReloadedAction{
+		Name:      tfMain,
+		Manifests: []model.Manifest{model.Manifest{Name: "web"}.WithImageTarget(web)},
+	})
+	state.LogStore.Append(store.NewLogAction("api", "build:api", logger.InfoLvl, nil,
+		[]byte("/go/src/api/main.go:6:2: undefined: bar\n")), nil)
+	assert.Contains(t, state.LogStore.ManifestLog("api"), "\n/go/src/api/main.go:6:2: undefined: bar\n")
+}
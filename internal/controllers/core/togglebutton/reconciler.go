@@ -3,6 +3,7 @@ package togglebutton
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
@@ -20,6 +21,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
+	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
 	"github.com/tilt-dev/tilt/internal/controllers/indexer"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
 )
@@ -168,6 +170,12 @@ func (r *Reconciler) processClick(ctx context.Context, tb *v1alpha1.ToggleButton
 		if err != nil {
 			return errors.Wrap(err, "fetching ToggleButton ConfigMap")
 		}
+		upgraded, err := configmap.Upgraded(&cm)
+		if err != nil {
+			tb.Status.Error = err.Error()
+			return nil
+		}
+		cm = *upgraded
 
 		var newValue string
 		if isOn {
@@ -184,6 +192,11 @@ func (r *Reconciler) processClick(ctx context.Context, tb *v1alpha1.ToggleButton
 
 		if !ok || currentValue != newValue {
 			cm.Data[ss.Key] = newValue
+			if ss.Key == configmap.DisableKey {
+				// Record that the change came from the UI.
+				disabled, _ := strconv.ParseBool(newValue)
+				configmap.RecordDisableChange(&cm, disabled, v1alpha1.DisableChangeSourceUI, time.Now())
+			}
 			err := r.ctrlClient.Update(ctx, &cm)
 			if err != nil {
 				return errors.Wrap(err, "updating ConfigMap with ToggleButton value")
@@ -214,6 +227,13 @@ func (r *Reconciler) isOn(ctx context.Context, tb *v1alpha1.ToggleButton) (bool,
 		return isOn, nil
 	}
 
+	upgraded, err := configmap.Upgraded(&cm)
+	if err != nil {
+		tb.Status.Error = err.Error()
+		return isOn, nil
+	}
+	cm = *upgraded
+
 	if cm.Data != nil {
 		cmVal, ok := cm.Data[ss.Key]
 		if ok {
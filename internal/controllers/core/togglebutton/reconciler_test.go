@@ -8,6 +8,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
+	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
 	"github.com/tilt-dev/tilt/internal/controllers/fake"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
 )
@@ -88,6 +89,34 @@ func TestReconciler_HandlesClick(t *testing.T) {
 	f.requireSteadyState()
 }
 
+func TestReconciler_DisableClickRecordsUISource(t *testing.T) {
+	f := newFixture(t)
+
+	f.setupTest()
+
+	tb := f.toggleButton()
+	tb.Spec.StateSource.ConfigMap.Key = configmap.DisableKey
+	require.NoError(t, f.Client.Update(f.ctx, &tb))
+	f.MustReconcile(tbName)
+
+	uib := f.uiButton()
+	uib.Status.LastClickedAt = metav1.NowMicro()
+	uib.Status.Inputs = append(uib.Status.Inputs, v1alpha1.UIInputStatus{
+		Name:   uib.Spec.Inputs[0].Name,
+		Hidden: &v1alpha1.UIHiddenInputStatus{Value: uib.Spec.Inputs[0].Hidden.Value},
+	})
+	require.NoError(t, f.Client.Status().Update(f.ctx, &uib))
+
+	f.MustReconcile(tbName)
+
+	cm := f.configMap()
+	require.Equal(t, "true", cm.Data[configmap.DisableKey])
+	history := configmap.DisableHistory(&cm)
+	require.Len(t, history, 1)
+	require.True(t, history[0].Disabled)
+	require.Equal(t, v1alpha1.DisableChangeSourceUI, history[0].Source)
+}
+
 func TestReconciler_HandlesConfigMapUpdate(t *testing.T) {
 	f := newFixture(t)
 
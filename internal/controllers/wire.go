@@ -6,6 +6,7 @@ import (
 
 	"github.com/tilt-dev/tilt/internal/controllers/core/cmd"
 	"github.com/tilt-dev/tilt/internal/controllers/core/configmap"
+	"github.com/tilt-dev/tilt/internal/controllers/core/debugcontainer"
 	"github.com/tilt-dev/tilt/internal/controllers/core/extension"
 	"github.com/tilt-dev/tilt/internal/controllers/core/extensionrepo"
 	"github.com/tilt-dev/tilt/internal/controllers/core/filewatch"
@@ -48,6 +49,7 @@ func ProvideControllers(
 	extrr *extensionrepo.Reconciler,
 	lur *liveupdate.Reconciler,
 	cmr *configmap.Reconciler,
+	dcr *debugcontainer.Reconciler,
 ) []Controller {
 	return []Controller{
 		fileWatch,
@@ -65,6 +67,7 @@ func ProvideControllers(
 		extrr,
 		lur,
 		cmr,
+		dcr,
 	}
 }
 
@@ -82,6 +85,7 @@ var WireSet = wire.NewSet(
 	uiresource.WireSet,
 	uisession.WireSet,
 	uibutton.WireSet,
+	debugcontainer.WireSet,
 	togglebutton.WireSet,
 	tiltfile.WireSet,
 	extensionrepo.WireSet,
@@ -16,12 +16,12 @@ import (
 	"github.com/blang/semver"
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/config"
-	"github.com/docker/cli/cli/connhelper"
 	cliflags "github.com/docker/cli/cli/flags"
 	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/api/types"
 	mobycontainer "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
+	registrytypes "github.com/docker/docker/api/types/registry"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/docker/registry"
@@ -95,6 +95,10 @@ type Client interface {
 	ImageBuild(ctx context.Context, buildContext io.Reader, options BuildOptions) (types.ImageBuildResponse, error)
 	ImageTag(ctx context.Context, source, target string) error
 	ImageInspectWithRaw(ctx context.Context, imageID string) (types.ImageInspect, []byte, error)
+
+	// Ask the daemon to fetch the image manifest from its registry,
+	// without pulling the image.
+	DistributionInspect(ctx context.Context, ref reference.Named) (registrytypes.DistributionInspect, error)
 	ImageList(ctx context.Context, options types.ImageListOptions) ([]types.ImageSummary, error)
 	ImageRemove(ctx context.Context, imageID string, options types.ImageRemoveOptions) ([]types.ImageDeleteResponseItem, error)
 
@@ -131,11 +135,15 @@ type Cli struct {
 }
 
 func NewDockerClient(ctx context.Context, env Env) Client {
+	return newDockerClient(ctx, env, sshConnHelper)
+}
+
+func newDockerClient(ctx context.Context, env Env, newConnHelper connHelperFunc) Client {
 	if env.Error != nil {
 		return newExplodingClient(env.Error)
 	}
 
-	opts, err := CreateClientOpts(ctx, env)
+	opts, err := createClientOpts(ctx, env, newConnHelper)
 	if err != nil {
 		return newExplodingClient(err)
 	}
@@ -146,6 +154,9 @@ func NewDockerClient(ctx context.Context, env Env) Client {
 
 	serverVersion, err := d.ServerVersion(ctx)
 	if err != nil {
+		if isSSHHost(env.Host) {
+			err = wrapSSHError(env.Host, err)
+		}
 		return newExplodingClient(err)
 	}
 
@@ -242,7 +253,11 @@ func SupportsBuildkit(v types.Version, env Env) bool {
 // DOCKER_API_VERSION to set the version of the API to reach, leave empty for latest.
 // DOCKER_CERT_PATH to load the TLS certificates from.
 // DOCKER_TLS_VERIFY to enable or disable TLS verification, off by default.
-func CreateClientOpts(_ context.Context, env Env) ([]client.Opt, error) {
+func CreateClientOpts(ctx context.Context, env Env) ([]client.Opt, error) {
+	return createClientOpts(ctx, env, sshConnHelper)
+}
+
+func createClientOpts(_ context.Context, env Env, newConnHelper connHelperFunc) ([]client.Opt, error) {
 	result := make([]client.Opt, 0)
 
 	if env.CertPath != "" {
@@ -270,15 +285,10 @@ func CreateClientOpts(_ context.Context, env Env) ([]client.Opt, error) {
 		//
 		// WARNING: due to the complexity of this setup, there is currently NO integration test that covers
 		// 	using an SSH remote executor (CI DOES use a remote executor, but not via SSH)
-		connHelper, err := connhelper.GetConnectionHelper(env.Host)
-		if err != nil {
-			return nil, err
-		}
-		if connHelper != nil {
-			httpClient := &http.Client{
-				Transport: &http.Transport{
-					DialContext: connHelper.Dialer,
-				},
+		if isSSHHost(env.Host) {
+			httpClient, connHelper, err := sshClientOpts(env.Host, newConnHelper)
+			if err != nil {
+				return nil, err
 			}
 			result = append(result,
 				client.WithHTTPClient(httpClient),
@@ -286,9 +296,8 @@ func CreateClientOpts(_ context.Context, env Env) ([]client.Opt, error) {
 				client.WithDialContext(connHelper.Dialer),
 			)
 		} else {
-			// N.B. GetConnectionHelper() returns nil if there's no special helper needed (i.e.
-			// 	for everything non-SSH), at which point we can just pass the host value through
-			// 	as-is to Moby code to let it handle it for http/https/tcp
+			// Everything non-SSH can be passed through as-is
+			// to Moby code to let it handle it for http/https/tcp
 			result = append(result, client.WithHost(env.Host))
 		}
 	}
@@ -305,29 +314,19 @@ func CreateClientOpts(_ context.Context, env Env) ([]client.Opt, error) {
 }
 
 func (c *Cli) startBuildkitSession(ctx context.Context, key string, sshSpecs []string, secretSpecs []string) (*session.Session, error) {
-	session, err := session.NewSession(ctx, "tilt", key)
+	attachables, err := buildkitAttachables(ctx, sshSpecs, secretSpecs)
 	if err != nil {
 		return nil, err
-
 	}
 
-	provider := authprovider.NewDockerAuthProvider(logger.Get(ctx).Writer(logger.InfoLvl))
-	session.Allow(provider)
+	session, err := session.NewSession(ctx, "tilt", key)
+	if err != nil {
+		return nil, err
 
-	if len(secretSpecs) > 0 {
-		ss, err := buildkit.ParseSecretSpecs(secretSpecs)
-		if err != nil {
-			return nil, errors.Wrapf(err, "could not parse secret: %v", secretSpecs)
-		}
-		session.Allow(ss)
 	}
 
-	if len(sshSpecs) > 0 {
-		sshp, err := buildkit.ParseSSHSpecs(sshSpecs)
-		if err != nil {
-			return nil, errors.Wrapf(err, "could not parse ssh: %v", sshSpecs)
-		}
-		session.Allow(sshp)
+	for _, a := range attachables {
+		session.Allow(a)
 	}
 
 	go func() {
@@ -344,6 +343,34 @@ func (c *Cli) startBuildkitSession(ctx context.Context, key string, sshSpecs []s
 	return session, nil
 }
 
+// The services that a BuildKit session offers the daemon during a build:
+// registry credentials, plus any secrets and ssh agents that the build asked for.
+//
+// Secret specs only name where the secret comes from (a file or an env var).
+// The contents are read when the daemon asks for them, and never logged.
+func buildkitAttachables(ctx context.Context, sshSpecs []string, secretSpecs []string) ([]session.Attachable, error) {
+	result := []session.Attachable{
+		authprovider.NewDockerAuthProvider(logger.Get(ctx).Writer(logger.InfoLvl)),
+	}
+
+	if len(secretSpecs) > 0 {
+		ss, err := buildkit.ParseSecretSpecs(secretSpecs)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not parse secret: %v", secretSpecs)
+		}
+		result = append(result, ss)
+	}
+
+	if len(sshSpecs) > 0 {
+		sshp, err := buildkit.ParseSSHSpecs(sshSpecs)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not parse ssh: %v", sshSpecs)
+		}
+		result = append(result, sshp)
+	}
+	return result, nil
+}
+
 // When we pull from a private docker registry, we have to get credentials
 // from somewhere. These credentials are not stored on the server. The client
 // is responsible for managing them.
@@ -499,6 +526,20 @@ func (c *Cli) ImagePush(ctx context.Context, ref reference.NamedTagged) (io.Read
 	return c.Client.ImagePush(ctx, ref.String(), options)
 }
 
+func (c *Cli) DistributionInspect(ctx context.Context, ref reference.Named) (registrytypes.DistributionInspect, error) {
+	repoInfo, err := registry.ParseRepositoryInfo(ref)
+	if err != nil {
+		return registrytypes.DistributionInspect{}, errors.Wrap(err, "DistributionInspect#ParseRepositoryInfo")
+	}
+
+	encodedAuth, _, err := c.authInfo(ctx, repoInfo, "pull")
+	if err != nil {
+		return registrytypes.DistributionInspect{}, errors.Wrap(err, "DistributionInspect: authenticate")
+	}
+
+	return c.Client.DistributionInspect(ctx, ref.String(), string(encodedAuth))
+}
+
 func (c *Cli) ImageBuild(ctx context.Context, buildContext io.Reader, options BuildOptions) (types.ImageBuildResponse, error) {
 	// Always use a one-time session when using buildkit, since credential
 	// passing is fast and we want to get the latest creds.
@@ -521,8 +562,7 @@ func (c *Cli) ImageBuild(ctx context.Context, buildContext io.Reader, options Bu
 		}
 		sessionID = oneTimeSession.ID()
 	} else if mustUseBuildkit {
-		return types.ImageBuildResponse{},
-			fmt.Errorf("Docker SSH secrets only work on Buildkit, but Buildkit has been disabled")
+		return types.ImageBuildResponse{}, ErrBuildKitRequired
 	}
 
 	opts := types.ImageBuildOptions{}
@@ -530,6 +570,7 @@ func (c *Cli) ImageBuild(ctx context.Context, buildContext io.Reader, options Bu
 
 	if isUsingBuildkit {
 		opts.SessionID = sessionID
+		opts.BuildID = identity.NewID()
 	} else {
 		c.initAuthConfigs(ctx)
 		opts.AuthConfigs = c.authConfigs
@@ -557,11 +598,33 @@ func (c *Cli) ImageBuild(ctx context.Context, buildContext io.Reader, options Bu
 	}
 
 	if oneTimeSession != nil {
-		response.Body = WrapReadCloserWithTearDown(response.Body, oneTimeSession.Close)
+		stopCanceling := c.cancelBuildOnDone(ctx, opts.BuildID)
+		response.Body = WrapReadCloserWithTearDown(response.Body, func() error {
+			stopCanceling()
+			return oneTimeSession.Close()
+		})
 	}
 	return response, err
 }
 
+// BuildKit keeps building after the client hangs up, so when the context is
+// canceled, explicitly cancel the build on the daemon, like the Docker CLI does.
+//
+// Call the returned func once the build is finished, to stop watching the context.
+func (c *Cli) cancelBuildOnDone(ctx context.Context, buildID string) (stop func()) {
+	done := make(chan struct{})
+	var once sync.Once
+	go func() {
+		select {
+		case <-ctx.Done():
+			// The build's context is already canceled, so cancel it with a fresh one.
+			_ = c.Client.BuildCancel(context.Background(), buildID)
+		case <-done:
+		}
+	}()
+	return func() { once.Do(func() { close(done) }) }
+}
+
 func (c *Cli) ContainerRestartNoWait(ctx context.Context, containerID string) error {
 
 	// Don't wait on the container to fully start.
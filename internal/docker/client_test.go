@@ -3,14 +3,21 @@ package docker
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/docker/docker/api/types"
+	"github.com/moby/buildkit/session/secrets"
+	"github.com/moby/buildkit/session/sshforward"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/tilt-dev/tilt/internal/container"
 	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/testutils"
 )
 
 type buildkitTestCase struct {
@@ -291,3 +298,71 @@ func TestProvideEnv(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildkitAttachablesSecrets(t *testing.T) {
+	dir := t.TempDir()
+	npmrc := filepath.Join(dir, ".npmrc")
+	require.NoError(t, ioutil.WriteFile(npmrc, []byte("//registry.npmjs.org/:_authToken=file-token"), 0600))
+	t.Setenv("TILT_TEST_NPM_TOKEN", "env-token")
+
+	ctx, _, _ := testutils.CtxAndAnalyticsForTest()
+	attachables, err := buildkitAttachables(ctx, nil, []string{
+		fmt.Sprintf("id=npmrc,src=%s", npmrc),
+		"id=token,type=env,env=TILT_TEST_NPM_TOKEN",
+	})
+	require.NoError(t, err)
+
+	var server secrets.SecretsServer
+	for _, a := range attachables {
+		if s, ok := a.(secrets.SecretsServer); ok {
+			server = s
+		}
+	}
+	require.NotNil(t, server, "no secrets provider attached")
+
+	resp, err := server.GetSecret(context.Background(), &secrets.GetSecretRequest{ID: "npmrc"})
+	require.NoError(t, err)
+	assert.Equal(t, "//registry.npmjs.org/:_authToken=file-token", string(resp.Data))
+
+	resp, err = server.GetSecret(context.Background(), &secrets.GetSecretRequest{ID: "token"})
+	require.NoError(t, err)
+	assert.Equal(t, "env-token", string(resp.Data))
+}
+
+func TestBuildkitAttachablesSSH(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := net.Listen("unix", sock)
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	ctx, _, _ := testutils.CtxAndAnalyticsForTest()
+	attachables, err := buildkitAttachables(ctx, []string{fmt.Sprintf("default=%s", sock)}, nil)
+	require.NoError(t, err)
+
+	var server sshforward.SSHServer
+	for _, a := range attachables {
+		if s, ok := a.(sshforward.SSHServer); ok {
+			server = s
+		}
+	}
+	require.NotNil(t, server, "no ssh agent provider attached")
+
+	_, err = server.CheckAgent(context.Background(), &sshforward.CheckAgentRequest{ID: "default"})
+	assert.NoError(t, err)
+
+	_, err = server.CheckAgent(context.Background(), &sshforward.CheckAgentRequest{ID: "other"})
+	assert.Error(t, err)
+}
+
+func TestBuildkitAttachablesMissingSecretFile(t *testing.T) {
+	ctx, _, _ := testutils.CtxAndAnalyticsForTest()
+	_, err := buildkitAttachables(ctx, nil, []string{"id=npmrc,src=/does/not/exist"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "could not parse secret")
+}
+
+func TestImageBuildWithoutBuildKit(t *testing.T) {
+	cli := &Cli{builderVersion: types.BuilderV1}
+	_, err := cli.ImageBuild(context.Background(), nil, BuildOptions{SecretSpecs: []string{"id=npmrc"}})
+	assert.Equal(t, ErrBuildKitRequired, err)
+}
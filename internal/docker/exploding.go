@@ -7,6 +7,7 @@ import (
 	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
+	registrytypes "github.com/docker/docker/api/types/registry"
 
 	"github.com/tilt-dev/tilt/internal/container"
 	"github.com/tilt-dev/tilt/pkg/model"
@@ -68,6 +69,9 @@ func (c explodingClient) ImageTag(ctx context.Context, source, target string) er
 func (c explodingClient) ImageInspectWithRaw(ctx context.Context, imageID string) (types.ImageInspect, []byte, error) {
 	return types.ImageInspect{}, nil, c.err
 }
+func (c explodingClient) DistributionInspect(ctx context.Context, ref reference.Named) (registrytypes.DistributionInspect, error) {
+	return registrytypes.DistributionInspect{}, c.err
+}
 func (c explodingClient) ImageList(ctx context.Context, options types.ImageListOptions) ([]types.ImageSummary, error) {
 	return nil, c.err
 }
@@ -7,6 +7,7 @@ import (
 	"io"
 	"io/ioutil"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/docker/go-units"
@@ -16,6 +17,7 @@ import (
 	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
+	registrytypes "github.com/docker/docker/api/types/registry"
 
 	"github.com/tilt-dev/tilt/internal/container"
 	"github.com/tilt-dev/tilt/pkg/model"
@@ -96,6 +98,12 @@ type FakeClient struct {
 	BuildOutput       string
 	BuildErrorToThrow error // next call to Build will throw this err (after which we clear the error)
 
+	// If true, builds send BuildOutput and then hang, like a slow build on the
+	// daemon, until the response is closed. BuildResponseClosed is closed
+	// when that happens.
+	BuildHangs          bool
+	BuildResponseClosed chan struct{}
+
 	ImageListCount int
 	ImageListOpts  []types.ImageListOptions
 
@@ -122,9 +130,19 @@ type FakeClient struct {
 	// even if one hasn't been explicitly pre-loaded.
 	ImageAlwaysExists bool
 
+	// Errors returned by DistributionInspect, indexed by ref.
+	// Refs without an error are treated as present in the registry.
+	DistributionInspectErrors map[string]error
+
 	Orchestrator      model.Orchestrator
 	CheckConnectedErr error
 
+	// Defaults to the legacy builder.
+	FakeBuilderVersion types.BuilderVersion
+
+	// Includes the daemon's OS and architecture.
+	FakeServerVersion types.Version
+
 	ThrowNewVersionError   bool
 	BuildCachePruneErr     error
 	BuildCachePruneOpts    types.BuildCachePruneOptions
@@ -156,10 +174,13 @@ func (c *FakeClient) Env() Env {
 	return c.FakeEnv
 }
 func (c *FakeClient) BuilderVersion() types.BuilderVersion {
+	if c.FakeBuilderVersion != "" {
+		return c.FakeBuilderVersion
+	}
 	return types.BuilderV1
 }
 func (c *FakeClient) ServerVersion() types.Version {
-	return types.Version{}
+	return c.FakeServerVersion
 }
 
 func (c *FakeClient) SetExecError(err error) {
@@ -265,6 +286,11 @@ func (c *FakeClient) ImageBuild(ctx context.Context, buildContext io.Reader, opt
 		return types.ImageBuildResponse{}, err
 	}
 
+	if c.BuildHangs {
+		c.BuildResponseClosed = make(chan struct{})
+		return types.ImageBuildResponse{Body: newHangingDockerResponse(c.BuildOutput, c.BuildResponseClosed)}, nil
+	}
+
 	return types.ImageBuildResponse{Body: NewFakeDockerResponse(c.BuildOutput)}, nil
 }
 
@@ -288,6 +314,14 @@ func (c *FakeClient) ImageInspectWithRaw(ctx context.Context, imageID string) (t
 	return types.ImageInspect{}, nil, newNotFoundErrorf("fakeClient.Images key: %s", imageID)
 }
 
+func (c *FakeClient) DistributionInspect(ctx context.Context, ref reference.Named) (registrytypes.DistributionInspect, error) {
+	err := c.DistributionInspectErrors[ref.String()]
+	if err != nil {
+		return registrytypes.DistributionInspect{}, err
+	}
+	return registrytypes.DistributionInspect{}, nil
+}
+
 func (c *FakeClient) ImageList(ctx context.Context, options types.ImageListOptions) ([]types.ImageSummary, error) {
 	c.ImageListOpts = append(c.ImageListOpts, options)
 	summaries := make([]types.ImageSummary, c.ImageListCount)
@@ -368,6 +402,29 @@ func (r fakeDockerResponse) Close() error { return nil }
 
 var _ io.ReadCloser = fakeDockerResponse{}
 
+// A response that sends its contents and then never finishes,
+// until it's closed.
+type hangingDockerResponse struct {
+	*io.PipeReader
+	closed chan struct{}
+	once   *sync.Once
+}
+
+func newHangingDockerResponse(contents string, closed chan struct{}) hangingDockerResponse {
+	pr, pw := io.Pipe()
+	go func() {
+		_, _ = pw.Write([]byte(contents))
+	}()
+	return hangingDockerResponse{PipeReader: pr, closed: closed, once: &sync.Once{}}
+}
+
+func (r hangingDockerResponse) Close() error {
+	r.once.Do(func() { close(r.closed) })
+	return r.PipeReader.Close()
+}
+
+var _ io.ReadCloser = hangingDockerResponse{}
+
 type notFoundError struct {
 	details string
 }
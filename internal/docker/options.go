@@ -1,6 +1,13 @@
 package docker
 
-import "io"
+import (
+	"errors"
+	"io"
+)
+
+// Returned when a build asks for secrets or ssh forwarding, which only
+// BuildKit supports, but the build would use the legacy builder.
+var ErrBuildKitRequired = errors.New("secret and ssh build options require BuildKit, but BuildKit is disabled")
 
 type BuildOptions struct {
 	Context            io.Reader
@@ -0,0 +1,93 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/docker/cli/cli/connhelper"
+)
+
+// How long ssh waits to reach the remote host before giving up.
+//
+// We don't put a deadline on the docker requests themselves: logging in and
+// starting `docker system dial-stdio` on a cold connection can take a while,
+// and a slow first connection shouldn't abort startup.
+const sshConnectTimeout = 30 * time.Second
+
+// Flags we pass to ssh when tunneling to a DOCKER_HOST=ssh:// daemon.
+//
+// We shell out to the user's own ssh binary, so host aliases in ~/.ssh/config,
+// keys, and agent auth all work the same as they do for the docker CLI.
+// BatchMode stops ssh from prompting for a password on the terminal, where it
+// would fight with the HUD and hang forever. We'd rather fail with ssh's error.
+var sshFlags = []string{
+	"-o", "BatchMode=yes",
+	"-o", fmt.Sprintf("ConnectTimeout=%d", int(sshConnectTimeout.Seconds())),
+}
+
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// Creates the connection helper that tunnels to a docker host URL.
+// Swapped out in tests, so that we don't need a real sshd.
+type connHelperFunc func(daemonURL string) (*connhelper.ConnectionHelper, error)
+
+func sshConnHelper(daemonURL string) (*connhelper.ConnectionHelper, error) {
+	return connhelper.GetConnectionHelperWithSSHOpts(daemonURL, sshFlags)
+}
+
+func isSSHHost(host string) bool {
+	u, err := url.Parse(host)
+	return err == nil && u.Scheme == "ssh"
+}
+
+// The docker client options for a daemon on the other end of an ssh tunnel.
+//
+// API requests, build streams, and hijacked exec connections all go
+// through the same dialer.
+func sshClientOpts(host string, newConnHelper connHelperFunc) (*http.Client, *connhelper.ConnectionHelper, error) {
+	helper, err := newConnHelper(host)
+	if err != nil {
+		return nil, nil, wrapSSHError(host, err)
+	}
+	if helper == nil {
+		return nil, nil, fmt.Errorf("no connection helper for DOCKER_HOST=%s", host)
+	}
+
+	dial := detachedDial(helper.Dialer)
+	helper = &connhelper.ConnectionHelper{Dialer: dial, Host: helper.Host}
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			DialContext: dial,
+
+			// Every new connection costs an ssh handshake, so hang onto idle ones.
+			MaxIdleConnsPerHost: 4,
+			IdleConnTimeout:     5 * time.Minute,
+		},
+	}
+	return httpClient, helper, nil
+}
+
+// Each ssh connection is a separate process, killed when its dial context
+// is canceled.
+//
+// The http transport dials with the context of whichever request needed the
+// connection, then keeps the connection around to serve other requests. So we
+// detach the process from the dial context, and let the transport close the
+// connection when it's done with it. ConnectTimeout and BatchMode keep a bad
+// host from hanging the dial.
+func detachedDial(dial dialFunc) dialFunc {
+	return func(_ context.Context, network, addr string) (net.Conn, error) {
+		return dial(context.Background(), network, addr)
+	}
+}
+
+// The error from a failed ssh connection already includes ssh's own stderr
+// (e.g., "Could not resolve hostname"), so we only need to say what we were
+// trying to do.
+func wrapSSHError(host string, err error) error {
+	return fmt.Errorf("connecting to docker over ssh (DOCKER_HOST=%s): %v", host, err)
+}
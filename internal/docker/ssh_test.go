@@ -0,0 +1,135 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/docker/cli/cli/connhelper"
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSHHostDialsThroughConnHelper(t *testing.T) {
+	f := newFakeSSHDaemon(t)
+
+	cli := newDockerClient(context.Background(), Env{Host: "ssh://dev-box"}, f.connHelper)
+	require.IsType(t, &Cli{}, cli)
+	assert.Equal(t, "1.40", cli.ServerVersion().APIVersion)
+	assert.Equal(t, []string{"ssh://dev-box"}, f.helperURLs())
+	assert.True(t, f.dialCount() > 0)
+
+	// Exec and attach hijack a raw connection through the same dialer.
+	before := f.dialCount()
+	conn, err := cli.(*Cli).Client.Dialer()(context.Background())
+	require.NoError(t, err)
+	_ = conn.Close()
+	assert.Equal(t, before+1, f.dialCount())
+}
+
+func TestTCPHostDoesNotUseConnHelper(t *testing.T) {
+	f := newFakeSSHDaemon(t)
+
+	host := fmt.Sprintf("tcp://%s", f.server.Listener.Addr())
+	cli := newDockerClient(context.Background(), Env{Host: host}, f.connHelper)
+	require.IsType(t, &Cli{}, cli)
+	assert.Empty(t, f.helperURLs())
+	assert.Equal(t, 0, f.dialCount())
+}
+
+func TestSSHConnectionErrorIncludesSSHOutput(t *testing.T) {
+	f := newFakeSSHDaemon(t)
+	f.dialErr = fmt.Errorf("command [ssh dev-box docker system dial-stdio] has exited with exit status 255: " +
+		"stderr=ssh: Could not resolve hostname dev-box")
+
+	cli := newDockerClient(context.Background(), Env{Host: "ssh://dev-box"}, f.connHelper)
+	err := cli.CheckConnected()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "connecting to docker over ssh (DOCKER_HOST=ssh://dev-box)")
+	assert.Contains(t, err.Error(), "Could not resolve hostname dev-box")
+}
+
+func TestSSHConnectionOutlivesRequestContext(t *testing.T) {
+	f := newFakeSSHDaemon(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cli := newDockerClient(ctx, Env{Host: "ssh://dev-box"}, f.connHelper)
+	require.IsType(t, &Cli{}, cli)
+	cancel()
+
+	// The ssh process for a pooled connection must not die with the request
+	// that happened to open it.
+	for _, dialCtx := range f.dialContexts() {
+		assert.NoError(t, dialCtx.Err())
+	}
+}
+
+type fakeSSHDaemon struct {
+	t       *testing.T
+	server  *httptest.Server
+	dialErr error
+
+	mu       sync.Mutex
+	urls     []string
+	dialCtxs []context.Context
+}
+
+// A docker daemon that's reachable only through the conn helper, standing in
+// for `ssh dev-box docker system dial-stdio`.
+func newFakeSSHDaemon(t *testing.T) *fakeSSHDaemon {
+	f := &fakeSSHDaemon{t: t}
+	f.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("API-Version", "1.40")
+		if strings.HasSuffix(r.URL.Path, "/version") {
+			_ = json.NewEncoder(w).Encode(types.Version{Version: "20.10.7", APIVersion: "1.40"})
+			return
+		}
+		_, _ = w.Write([]byte("OK"))
+	}))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeSSHDaemon) connHelper(daemonURL string) (*connhelper.ConnectionHelper, error) {
+	f.mu.Lock()
+	f.urls = append(f.urls, daemonURL)
+	f.mu.Unlock()
+
+	return &connhelper.ConnectionHelper{
+		Host: "http://docker",
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			f.mu.Lock()
+			f.dialCtxs = append(f.dialCtxs, ctx)
+			f.mu.Unlock()
+			if f.dialErr != nil {
+				return nil, f.dialErr
+			}
+			return net.Dial("tcp", f.server.Listener.Addr().String())
+		},
+	}, nil
+}
+
+func (f *fakeSSHDaemon) helperURLs() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.urls...)
+}
+
+func (f *fakeSSHDaemon) dialCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.dialCtxs)
+}
+
+func (f *fakeSSHDaemon) dialContexts() []context.Context {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]context.Context(nil), f.dialCtxs...)
+}
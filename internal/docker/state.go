@@ -9,6 +9,10 @@ func HasStarted(cState types.ContainerState) bool {
 	return cState.StartedAt != "" && cState.StartedAt != ZeroTime
 }
 
+func IsHealthy(cState types.ContainerState) bool {
+	return cState.Health != nil && cState.Health.Status == types.Healthy
+}
+
 func HasFinished(cState types.ContainerState) bool {
 	return cState.FinishedAt != "" && cState.FinishedAt != ZeroTime
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/docker/distribution/reference"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
+	registrytypes "github.com/docker/docker/api/types/registry"
 
 	"github.com/tilt-dev/tilt/internal/container"
 	"github.com/tilt-dev/tilt/pkg/model"
@@ -89,6 +90,9 @@ func (c *switchCli) ImageTag(ctx context.Context, source, target string) error {
 func (c *switchCli) ImageInspectWithRaw(ctx context.Context, imageID string) (types.ImageInspect, []byte, error) {
 	return c.client().ImageInspectWithRaw(ctx, imageID)
 }
+func (c *switchCli) DistributionInspect(ctx context.Context, ref reference.Named) (registrytypes.DistributionInspect, error) {
+	return c.client().DistributionInspect(ctx, ref)
+}
 func (c *switchCli) ImageList(ctx context.Context, options types.ImageListOptions) ([]types.ImageSummary, error) {
 	return c.client().ImageList(ctx, options)
 }
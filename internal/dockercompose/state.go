@@ -32,6 +32,9 @@ type State struct {
 
 	LastReadyTime time.Time
 
+	// When the container first passed its healthcheck.
+	LastHealthyTime time.Time
+
 	SpanID model.LogSpanID
 
 	Ports nat.PortMap
@@ -100,6 +103,21 @@ func (s State) WithLastReadyTime(time time.Time) State {
 	return s
 }
 
+func (s State) WithLastHealthyTime(time time.Time) State {
+	s.LastHealthyTime = time
+	return s
+}
+
 func (s State) HasEverBeenReadyOrSucceeded() bool {
 	return !s.LastReadyTime.IsZero()
 }
+
+// Whether the container has ever passed its healthcheck. A container
+// without a healthcheck counts as healthy once it's ready, so that
+// services that depend on it don't wait forever.
+func (s State) HasEverBeenHealthy() bool {
+	if !s.LastHealthyTime.IsZero() {
+		return true
+	}
+	return s.ContainerState.Health == nil && s.HasEverBeenReadyOrSucceeded()
+}
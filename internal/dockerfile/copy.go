@@ -0,0 +1,88 @@
+package dockerfile
+
+import (
+	"path"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/command"
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+)
+
+// A COPY from the build context into the image.
+type Copy struct {
+	// Paths relative to the build context.
+	Srcs []string
+
+	// An absolute path in the image, resolved against the WORKDIR.
+	Dest string
+}
+
+// Whether the destination is a directory that the sources are copied into,
+// rather than the path of the copied file.
+func (c Copy) DestIsDir() bool {
+	return len(c.Srcs) > 1 || strings.HasSuffix(c.Dest, "/")
+}
+
+// Finds the COPY and ADD instructions that copy from the build context.
+//
+// Skips instructions that copy from other stages or images, and paths we
+// can't resolve without evaluating the Dockerfile (like wildcards and
+// build args).
+func (a AST) Copies() []Copy {
+	result := []Copy{}
+	workdir := "/"
+	for _, node := range a.result.AST.Children {
+		switch node.Value {
+		case command.From:
+			workdir = "/"
+		case command.Workdir:
+			args := getCmdArgs(node)
+			if len(args) != 1 || strings.Contains(args[0], "$") {
+				workdir = ""
+				continue
+			}
+			if path.IsAbs(args[0]) {
+				workdir = path.Clean(args[0])
+			} else if workdir != "" {
+				workdir = path.Join(workdir, args[0])
+			}
+		case command.Copy, command.Add:
+			c, ok := copyFromNode(node, workdir)
+			if ok {
+				result = append(result, c)
+			}
+		}
+	}
+	return result
+}
+
+func copyFromNode(node *parser.Node, workdir string) (Copy, bool) {
+	for _, flag := range node.Flags {
+		if strings.HasPrefix(flag, "--from") {
+			return Copy{}, false
+		}
+	}
+
+	args := getCmdArgs(node)
+	if len(args) < 2 {
+		return Copy{}, false
+	}
+	for _, arg := range args {
+		if strings.ContainsAny(arg, "$*?[") || strings.Contains(arg, "://") {
+			return Copy{}, false
+		}
+	}
+
+	srcs, dest := args[:len(args)-1], args[len(args)-1]
+	if !path.IsAbs(dest) {
+		if workdir == "" {
+			return Copy{}, false
+		}
+		trailingSlash := strings.HasSuffix(dest, "/") || dest == "."
+		dest = path.Join(workdir, dest)
+		if trailingSlash && dest != "/" {
+			dest += "/"
+		}
+	}
+	return Copy{Srcs: srcs, Dest: dest}, true
+}
@@ -0,0 +1,47 @@
+package dockerfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopies(t *testing.T) {
+	ast, err := ParseAST(Dockerfile(`
+FROM golang:1.17 AS builder
+WORKDIR /src
+COPY go.mod go.sum ./
+COPY --chown=1000 cmd cmd
+COPY . .
+RUN go build ./cmd/app
+
+FROM alpine
+COPY --from=builder /src/app /usr/bin/app
+COPY ["static", "/srv/static"]
+ADD https://example.com/file.tar.gz /tmp/
+COPY $CONFIG /etc/config
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []Copy{
+		{Srcs: []string{"go.mod", "go.sum"}, Dest: "/src/"},
+		{Srcs: []string{"cmd"}, Dest: "/src/cmd"},
+		{Srcs: []string{"."}, Dest: "/src/"},
+		{Srcs: []string{"static"}, Dest: "/srv/static"},
+	}, ast.Copies())
+}
+
+func TestCopiesRelativeToUnknownWorkdir(t *testing.T) {
+	ast, err := ParseAST(Dockerfile(`
+FROM golang:1.17
+WORKDIR $GOPATH/src
+COPY . .
+COPY main.go /app/main.go
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []Copy{
+		{Srcs: []string{"main.go"}, Dest: "/app/main.go"},
+	}, ast.Copies())
+}
@@ -6,7 +6,9 @@ import (
 
 	"github.com/docker/distribution/reference"
 	"github.com/moby/buildkit/frontend/dockerfile/command"
+	"github.com/moby/buildkit/frontend/dockerfile/instructions"
 	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/moby/buildkit/frontend/dockerfile/shell"
 
 	"github.com/tilt-dev/tilt/pkg/model"
 )
@@ -130,6 +132,17 @@ func (d Dockerfile) ValidateBaseDockerfile() error {
 	})
 }
 
+// Validate checks that the Dockerfile parses into valid build instructions
+// (e.g., FROM has the right number of args, no unknown instructions).
+func (d Dockerfile) Validate() error {
+	result, err := parser.Parse(newReader(d))
+	if err != nil {
+		return err
+	}
+	_, _, err = instructions.Parse(result.AST)
+	return err
+}
+
 // Find all images referenced in this dockerfile.
 func (d Dockerfile) FindImages(buildArgs map[string]string) ([]reference.Named, error) {
 	result := []reference.Named{}
@@ -148,6 +161,80 @@ func (d Dockerfile) FindImages(buildArgs map[string]string) ([]reference.Named,
 	return result, nil
 }
 
+// Find the build args that can change the image built from this Dockerfile,
+// with the values they resolve to.
+//
+// An arg only affects the build if the Dockerfile declares it with ARG.
+// (Docker also passes its predefined proxy args to every build, but leaves
+// them out of the build cache unless they're declared.)
+// A declared arg that isn't passed resolves to its default, so passing the
+// default explicitly resolves to the same value. If the Dockerfile declares
+// an arg more than once with different defaults, and it isn't passed, it's
+// left out, so that passing it always counts as a change.
+//
+// BuildKit's own args (BUILDKIT_*) change how the image is built, so they're
+// kept whenever they're passed.
+func (d Dockerfile) ResolveBuildArgs(buildArgs map[string]string) (map[string]string, error) {
+	ast, err := ParseAST(d)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string)
+	for k, v := range buildArgs {
+		if strings.HasPrefix(k, "BUILDKIT_") {
+			result[k] = v
+		}
+	}
+
+	shlex := shell.NewLex(ast.result.EscapeToken)
+	defaults := make(map[string]string)
+	conflicts := make(map[string]bool)
+	err = ast.Traverse(func(node *parser.Node) error {
+		if node.Value != command.Arg {
+			return nil
+		}
+		inst, err := instructions.ParseInstruction(node)
+		if err != nil {
+			return nil // ignore parsing error
+		}
+		argCmd, ok := inst.(*instructions.ArgCommand)
+		if !ok {
+			return nil
+		}
+
+		for _, arg := range argCmd.Args {
+			if v, ok := buildArgs[arg.Key]; ok {
+				result[arg.Key] = v
+				continue
+			}
+
+			value := ""
+			if arg.Value != nil {
+				// Defaults can refer to earlier args.
+				value, err = shlex.ProcessWordWithMap(*arg.Value, result)
+				if err != nil {
+					value = *arg.Value
+				}
+			}
+			if prev, ok := defaults[arg.Key]; ok && prev != value {
+				conflicts[arg.Key] = true
+			}
+			defaults[arg.Key] = value
+			result[arg.Key] = value
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for k := range conflicts {
+		delete(result, k)
+	}
+	return result, nil
+}
+
 func (d Dockerfile) String() string {
 	return string(d)
 }
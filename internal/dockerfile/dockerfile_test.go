@@ -67,6 +67,12 @@ ADD`)
 	}
 }
 
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Dockerfile("FROM alpine\nRUN echo hi\n").Validate())
+	assert.Error(t, Dockerfile("FROM alpine\nFOO bar\n").Validate())
+	assert.Error(t, Dockerfile("FROM a b c d\n").Validate())
+}
+
 func TestSplitIntoBaseDf(t *testing.T) {
 	df := Dockerfile(`
 
@@ -204,3 +210,60 @@ RUN --mount=type=cache,id=pip,target=/root/.cache/pip pip install python-dateuti
 		assert.Equal(t, "docker.io/library/python2-base", images[0].String())
 	}
 }
+
+func TestResolveBuildArgs(t *testing.T) {
+	df := Dockerfile(`
+ARG BASE=alpine
+FROM ${BASE}
+ARG VERSION
+ARG TAG="${VERSION}-dev"
+RUN echo $TAG
+`)
+	args, err := df.ResolveBuildArgs(map[string]string{
+		"VERSION":               "1.2",
+		"UNUSED":                "x",
+		"HTTP_PROXY":            "http://proxy",
+		"BUILDKIT_SYNTAX":       "docker/dockerfile:1",
+		"BUILDKIT_INLINE_CACHE": "1",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"BASE":                  "alpine",
+		"VERSION":               "1.2",
+		"TAG":                   "1.2-dev",
+		"BUILDKIT_SYNTAX":       "docker/dockerfile:1",
+		"BUILDKIT_INLINE_CACHE": "1",
+	}, args)
+}
+
+func TestResolveBuildArgsExplicitDefault(t *testing.T) {
+	df := Dockerfile(`
+ARG TAG=latest
+FROM gcr.io/image-a:${TAG}
+`)
+	withDefault, err := df.ResolveBuildArgs(nil)
+	assert.NoError(t, err)
+	explicit, err := df.ResolveBuildArgs(map[string]string{"TAG": "latest"})
+	assert.NoError(t, err)
+	assert.Equal(t, withDefault, explicit)
+
+	changed, err := df.ResolveBuildArgs(map[string]string{"TAG": "v2"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"TAG": "v2"}, changed)
+}
+
+func TestResolveBuildArgsConflictingDefaults(t *testing.T) {
+	df := Dockerfile(`
+FROM alpine AS a
+ARG MODE=debug
+FROM alpine AS b
+ARG MODE=release
+`)
+	args, err := df.ResolveBuildArgs(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{}, args)
+
+	args, err = df.ResolveBuildArgs(map[string]string{"MODE": "debug"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"MODE": "debug"}, args)
+}
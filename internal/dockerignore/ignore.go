@@ -13,15 +13,16 @@ import (
 )
 
 type dockerPathMatcher struct {
-	repoRoot string
-	matcher  *tiltDockerignore.PatternMatcher
+	repoRoot  string
+	matcher   *tiltDockerignore.PatternMatcher
+	semantics ospath.PathSemantics
 }
 
 func (i dockerPathMatcher) Matches(f string) (bool, error) {
-	if !filepath.IsAbs(f) {
-		f = filepath.Join(i.repoRoot, f)
+	if !i.semantics.IsAbs(f) {
+		f = i.semantics.Join(i.repoRoot, f)
 	}
-	return i.matcher.Matches(f)
+	return i.matcher.Matches(matchForm(i.semantics, f))
 }
 
 func (i dockerPathMatcher) MatchesEntireDir(f string) (bool, error) {
@@ -36,7 +37,7 @@ func (i dockerPathMatcher) MatchesEntireDir(f string) (bool, error) {
 			if !pattern.Exclusion() {
 				continue
 			}
-			if ospath.IsChild(f, pattern.String()) {
+			if i.semantics.IsChild(f, pattern.String()) {
 				// Found an exclusion match -- we don't match this whole dir
 				return false, nil
 			}
@@ -60,8 +61,16 @@ func NewDockerIgnoreTester(repoRoot string) (*dockerPathMatcher, error) {
 	return NewDockerPatternMatcher(absRoot, patterns)
 }
 
+// The form of a path that we hand to the pattern matcher.
+//
+// The matcher splits paths on the host separator and compares them
+// case-sensitively, so we normalize patterns and paths the same way.
+func matchForm(s ospath.PathSemantics, p string) string {
+	return filepath.FromSlash(s.Key(p))
+}
+
 // Make all the patterns use absolute paths.
-func absPatterns(absRoot string, patterns []string) []string {
+func absPatterns(s ospath.PathSemantics, absRoot string, patterns []string) []string {
 	absPatterns := make([]string, 0, len(patterns))
 	for _, p := range patterns {
 		// The pattern parsing here is loosely adapted from fileutils' NewPatternMatcher
@@ -69,8 +78,6 @@ func absPatterns(absRoot string, patterns []string) []string {
 		if p == "" {
 			continue
 		}
-		p = filepath.Clean(p)
-
 		pPath := p
 		isExclusion := false
 		if p[0] == '!' {
@@ -78,9 +85,10 @@ func absPatterns(absRoot string, patterns []string) []string {
 			isExclusion = true
 		}
 
-		if !filepath.IsAbs(pPath) {
-			pPath = filepath.Join(absRoot, pPath)
+		if !s.IsAbs(pPath) {
+			pPath = s.Join(absRoot, pPath)
 		}
+		pPath = matchForm(s, pPath)
 		absPattern := pPath
 		if isExclusion {
 			absPattern = fmt.Sprintf("!%s", pPath)
@@ -91,19 +99,30 @@ func absPatterns(absRoot string, patterns []string) []string {
 }
 
 func NewDockerPatternMatcher(repoRoot string, patterns []string) (*dockerPathMatcher, error) {
-	absRoot, err := filepath.Abs(repoRoot)
-	if err != nil {
-		return nil, err
+	return NewDockerPatternMatcherWithSemantics(ospath.HostSemantics(), repoRoot, patterns)
+}
+
+// Like NewDockerPatternMatcher, but matches paths under the given path semantics
+// rather than the host's.
+func NewDockerPatternMatcherWithSemantics(s ospath.PathSemantics, repoRoot string, patterns []string) (*dockerPathMatcher, error) {
+	absRoot := s.Native(repoRoot)
+	if !s.IsAbs(absRoot) {
+		var err error
+		absRoot, err = filepath.Abs(repoRoot)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	pm, err := tiltDockerignore.NewPatternMatcher(absPatterns(absRoot, patterns))
+	pm, err := tiltDockerignore.NewPatternMatcher(absPatterns(s, absRoot, patterns))
 	if err != nil {
 		return nil, err
 	}
 
 	return &dockerPathMatcher{
-		repoRoot: absRoot,
-		matcher:  pm,
+		repoRoot:  absRoot,
+		matcher:   pm,
+		semantics: s,
 	}, nil
 }
 
@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/tilt-dev/tilt/internal/dockerignore"
+	"github.com/tilt-dev/tilt/internal/ospath"
 	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
 	"github.com/tilt-dev/tilt/pkg/model"
 )
@@ -119,6 +120,42 @@ func TestNoDockerignoreFile(t *testing.T) {
 	tf.AssertResultEntireDir(tf.JoinPath("hi"), false)
 }
 
+func TestWindowsPaths(t *testing.T) {
+	m, err := dockerignore.NewDockerPatternMatcherWithSemantics(ospath.WindowsSemantics,
+		`C:\Users\Dev\Repo`, []string{"node_modules", "build/*.tmp", "Docs", "!docs/README.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		path     string
+		expected bool
+	}{
+		{`C:\Users\Dev\Repo\node_modules\foo`, true},
+		{`C:/Users/Dev/Repo/node_modules/foo`, true},
+		{`c:\users\dev\repo\Node_Modules\foo`, true},
+		{`node_modules\foo`, true},
+		{`C:\Users\Dev\Repo\build\out.tmp`, true},
+		{`C:\Users\Dev\Repo\build\out.go`, false},
+		{`C:\Users\Dev\Repo\docs\stuff.md`, true},
+		{`C:\Users\Dev\Repo\Docs\README.md`, false},
+		{`C:\Users\Dev\Repo\src\main.go`, false},
+	} {
+		actual, err := m.Matches(tc.path)
+		if assert.NoError(t, err) {
+			assert.Equalf(t, tc.expected, actual, "Matches(%q)", tc.path)
+		}
+	}
+
+	entireDir, err := m.MatchesEntireDir(`C:\Users\Dev\Repo\Node_Modules`)
+	assert.NoError(t, err)
+	assert.True(t, entireDir)
+
+	entireDir, err = m.MatchesEntireDir(`C:\Users\Dev\Repo\docs`)
+	assert.NoError(t, err)
+	assert.False(t, entireDir)
+}
+
 type testFixture struct {
 	repoRoot *tempdir.TempDirFixture
 	t        *testing.T
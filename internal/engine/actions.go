@@ -30,6 +30,7 @@ type InitAction struct {
 	CloudAddress string
 	Token        token.Token
 	TerminalMode store.TerminalMode
+	ReadOnly     bool
 }
 
 func (InitAction) Action() {}
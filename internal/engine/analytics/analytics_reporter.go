@@ -3,27 +3,58 @@ package analytics
 import (
 	"context"
 	"strconv"
+	"sync"
 	"time"
 
+	wmanalytics "github.com/tilt-dev/wmclient/pkg/analytics"
+
 	"github.com/tilt-dev/tilt/internal/analytics"
 	"github.com/tilt-dev/tilt/internal/controllers/apis/liveupdate"
 	"github.com/tilt-dev/tilt/internal/k8s"
 	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
 )
 
 // How often to periodically report data for analytics while Tilt is running
 const analyticsReportingInterval = time.Minute * 15
 
+// How long to wait after startup before the first report
+const analyticsInitialReportDelay = 10 * time.Second
+
+// The longest we'll wait to flush the last report when Tilt exits,
+// so that a slow network can't hang exit.
+const analyticsShutdownFlushTimeout = 2 * time.Second
+
+// AnalyticsReporter periodically reports an aggregate of the session:
+// the resources in the Tiltfile, plus statistics over all the builds that
+// completed since the last report.
 type AnalyticsReporter struct {
 	a       *analytics.TiltAnalytics
 	store   store.RStore
 	kClient k8s.Client
 	env     k8s.Env
-	started bool
+
+	initialDelay time.Duration
+	interval     time.Duration
+
+	mu       sync.Mutex
+	started  bool
+	tornDown bool
+
+	// Builds that have completed since the last report.
+	window []model.BuildRecord
+
+	// The finish time of the most recent build we've collected, per manifest.
+	lastSeen map[model.ManifestName]time.Time
 }
 
 func (ar *AnalyticsReporter) OnChange(ctx context.Context, st store.RStore, _ store.ChangeSummary) error {
-	if ar.started {
+	ar.collectBuilds(st)
+
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	if ar.started || ar.tornDown {
 		return nil
 	}
 
@@ -33,30 +64,107 @@ func (ar *AnalyticsReporter) OnChange(ctx context.Context, st store.RStore, _ st
 	// wait until state has been kinda initialized
 	if !state.TiltStartTime.IsZero() && state.LastMainTiltfileError() == nil {
 		ar.started = true
-		go func() {
-			select {
-			case <-time.After(10 * time.Second):
-				ar.report(ctx) // report once pretty soon after startup...
-			case <-ctx.Done():
-				return
-			}
+		go ar.loop(ctx)
+	}
+
+	return nil
+}
+
+func (ar *AnalyticsReporter) loop(ctx context.Context) {
+	select {
+	case <-time.After(ar.initialDelay):
+		ar.reportUnlessTornDown(ctx) // report once pretty soon after startup...
+	case <-ctx.Done():
+		return
+	}
+
+	for {
+		select {
+		case <-time.After(ar.interval):
+			// and once every <interval> thereafter
+			ar.reportUnlessTornDown(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (ar *AnalyticsReporter) reportUnlessTornDown(ctx context.Context) {
+	ar.mu.Lock()
+	tornDown := ar.tornDown
+	ar.mu.Unlock()
+	if tornDown {
+		return
+	}
+	ar.report(ctx)
+}
+
+// Flush the last aggregate on exit, with a short deadline.
+func (ar *AnalyticsReporter) TearDown(ctx context.Context) {
+	ar.mu.Lock()
+	started := ar.started
+	alreadyTornDown := ar.tornDown
+	ar.tornDown = true
+	ar.mu.Unlock()
+
+	if !started || alreadyTornDown {
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ar.report(ctx)
+		ar.a.Flush(analyticsShutdownFlushTimeout)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(analyticsShutdownFlushTimeout):
+	}
+}
+
+// Copy any newly-completed builds into the aggregation window.
+//
+// While opted out, we still mark builds as seen, but never buffer them,
+// so opting back in doesn't report them either.
+func (ar *AnalyticsReporter) collectBuilds(st store.RStore) {
+	optedOut := ar.a.EffectiveOpt() == wmanalytics.OptOut
 
-			for {
-				select {
-				case <-time.After(analyticsReportingInterval):
-					// and once every <interval> thereafter
-					ar.report(ctx)
-				case <-ctx.Done():
-					return
+	state := st.RLockState()
+	var newBuilds []model.BuildRecord
+	newLastSeen := make(map[model.ManifestName]time.Time)
+
+	ar.mu.Lock()
+	for _, mt := range state.Targets() {
+		name := mt.Manifest.Name
+		lastSeen := ar.lastSeen[name]
+		latest := lastSeen
+		for _, record := range mt.State.BuildHistory {
+			if record.FinishTime.After(lastSeen) {
+				newBuilds = append(newBuilds, record)
+				if record.FinishTime.After(latest) {
+					latest = record.FinishTime
 				}
 			}
-		}()
+		}
+		if latest != lastSeen {
+			newLastSeen[name] = latest
+		}
 	}
+	st.RUnlockState()
 
-	return nil
+	for name, t := range newLastSeen {
+		ar.lastSeen[name] = t
+	}
+	if !optedOut {
+		ar.window = append(ar.window, newBuilds...)
+	}
+	ar.mu.Unlock()
 }
 
 var _ store.Subscriber = &AnalyticsReporter{}
+var _ store.TearDowner = &AnalyticsReporter{}
 
 func ProvideAnalyticsReporter(
 	a *analytics.TiltAnalytics,
@@ -64,15 +172,51 @@ func ProvideAnalyticsReporter(
 	kClient k8s.Client,
 	env k8s.Env) *AnalyticsReporter {
 	return &AnalyticsReporter{
-		a:       a,
-		store:   st,
-		kClient: kClient,
-		env:     env,
-		started: false,
+		a:            a,
+		store:        st,
+		kClient:      kClient,
+		env:          env,
+		initialDelay: analyticsInitialReportDelay,
+		interval:     analyticsReportingInterval,
+		lastSeen:     make(map[model.ManifestName]time.Time),
 	}
 }
 
+// Sends the periodic analytics report.
+//
+// Re-reads the user's opt choice on every cycle, so that
+// opting out mid-session takes effect without a restart.
 func (ar *AnalyticsReporter) report(ctx context.Context) {
+	err := ar.a.RefreshUserOpt()
+	if err != nil {
+		logger.Get(ctx).Debugf("reading analytics opt: %v", err)
+	}
+	ar.mu.Lock()
+	window := ar.window
+	ar.window = nil
+	ar.mu.Unlock()
+
+	// Drop any builds collected before we noticed the user opted out.
+	if ar.a.EffectiveOpt() == wmanalytics.OptOut {
+		return
+	}
+
+	name, tags := ar.upRunningStats(ctx, window)
+	ar.a.Incr(name, tags)
+}
+
+// Payloads returns the payloads that the next report would send,
+// without sending them.
+func (ar *AnalyticsReporter) Payloads(ctx context.Context) []map[string]string {
+	ar.mu.Lock()
+	window := append([]model.BuildRecord{}, ar.window...)
+	ar.mu.Unlock()
+
+	name, tags := ar.upRunningStats(ctx, window)
+	return []map[string]string{ar.a.Payload(name, tags)}
+}
+
+func (ar *AnalyticsReporter) upRunningStats(ctx context.Context, window []model.BuildRecord) (string, map[string]string) {
 	st := ar.store.RLockState()
 	defer ar.store.RUnlockState()
 	var dcCount, k8sCount, liveUpdateCount, unbuiltCount,
@@ -149,5 +293,9 @@ func (ar *AnalyticsReporter) report(ctx context.Context) {
 
 	stats["tiltfile.error"] = tiltfileIsInError
 
-	ar.a.Incr("up.running", stats)
+	for k, v := range AggregateBuildStats(window).Tags() {
+		stats[k] = v
+	}
+
+	return "up.running", stats
 }
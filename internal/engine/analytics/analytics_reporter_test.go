@@ -68,6 +68,7 @@ func TestAnalyticsReporter_Everything(t *testing.T) {
 
 	expectedTags := map[string]string{
 		"builds.completed_count":                              "3",
+		"builds.window.count":                                 "0",
 		"resource.count":                                      "9",
 		"resource.dockercompose.count":                        "3",
 		"resource.unbuiltresources.count":                     "3",
@@ -158,6 +159,7 @@ func TestAnalyticsReporter_TiltfileError(t *testing.T) {
 
 	expectedTags := map[string]string{
 		"builds.completed_count": "3",
+		"builds.window.count":    "0",
 		"tiltfile.error":         "true",
 		"up.starttime":           state.TiltStartTime.Format(time.RFC3339),
 		"env":                    string(k8s.EnvDockerDesktop),
@@ -168,12 +170,139 @@ func TestAnalyticsReporter_TiltfileError(t *testing.T) {
 	tf.assertStats(t, expectedTags)
 }
 
+func TestAnalyticsReporter_AggregatesBuildsSinceLastReport(t *testing.T) {
+	tf := newAnalyticsReporterTestFixture(t)
+	start := time.Now()
+	tf.addManifestWithBuilds(tf.nextManifest().WithDeployTarget(kTarg),
+		buildRecord(start, time.Second, model.BuildReasonFlagInit, nil, model.BuildTypeImage, model.BuildTypeK8s))
+	tf.addManifestWithBuilds(tf.nextManifest().WithDeployTarget(kTarg),
+		buildRecord(start, 3*time.Second, model.BuildReasonFlagInit, errors.New("oh no"), model.BuildTypeImage))
+
+	tf.onChange()
+	tf.run()
+
+	tags := tf.ma.Counts[0].Tags
+	assert.Equal(t, "2", tags["builds.window.count"])
+	assert.Equal(t, "2", tags["builds.reason.init.count"])
+	assert.Equal(t, "1", tags["builds.outcome.success.count"])
+	assert.Equal(t, "1", tags["builds.outcome.error.count"])
+	assert.Equal(t, "2", tags["builds.type.image.count"])
+	assert.Equal(t, "1000", tags["builds.type.image.p50_ms"])
+	assert.Equal(t, "3000", tags["builds.type.image.p95_ms"])
+
+	// Builds we've already reported shouldn't be reported again.
+	tf.onChange()
+	tf.run()
+	assert.Equal(t, "0", tf.ma.Counts[1].Tags["builds.window.count"])
+}
+
+func TestAnalyticsReporter_DropsBuildsWhileOptedOut(t *testing.T) {
+	tf := newAnalyticsReporterTestFixture(t)
+	_ = tf.opter.SetUserOpt(analytics.OptOut)
+	tf.addManifestWithBuilds(tf.nextManifest().WithDeployTarget(kTarg),
+		buildRecord(time.Now(), time.Second, model.BuildReasonFlagInit, nil, model.BuildTypeImage))
+
+	tf.onChange()
+	tf.run()
+	assert.Empty(t, tf.ma.Counts)
+
+	// Opting back in shouldn't send the builds from while we were opted out.
+	_ = tf.opter.SetUserOpt(analytics.OptIn)
+	tf.run()
+	assert.Equal(t, "0", tf.ma.Counts[0].Tags["builds.window.count"])
+}
+
+func TestAnalyticsReporter_NeverBuffersBuildsWhileOptedOut(t *testing.T) {
+	tf := newAnalyticsReporterTestFixture(t)
+	_ = tf.ar.a.SetUserOpt(analytics.OptOut)
+	tf.addManifestWithBuilds(tf.nextManifest().WithDeployTarget(kTarg),
+		buildRecord(time.Now(), time.Second, model.BuildReasonFlagInit, nil, model.BuildTypeImage))
+
+	tf.onChange()
+	assert.Empty(t, tf.ar.window)
+
+	// Builds that completed while opted out stay out after opting back in.
+	_ = tf.ar.a.SetUserOpt(analytics.OptIn)
+	tf.onChange()
+	assert.Empty(t, tf.ar.window)
+	tf.run()
+	assert.Equal(t, "0", tf.ma.Counts[0].Tags["builds.window.count"])
+}
+
+func TestAnalyticsReporter_FlushCadence(t *testing.T) {
+	tf := newAnalyticsReporterTestFixture(t)
+	tf.ar.initialDelay = 0
+	tf.ar.interval = 10 * time.Millisecond
+
+	state := tf.st.LockMutableStateForTesting()
+	state.TiltStartTime = time.Now()
+	tf.st.UnlockMutableState()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_ = tf.ar.OnChange(ctx, tf.st, store.ChangeSummary{})
+
+	assert.Eventually(t, func() bool {
+		return len(tf.ma.Counts) >= 3
+	}, time.Second, 5*time.Millisecond)
+
+	tf.ar.TearDown(ctx)
+
+	// No reports after teardown, except the final flush.
+	time.Sleep(50 * time.Millisecond)
+	countAfterTearDown := len(tf.ma.Counts)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, countAfterTearDown, len(tf.ma.Counts))
+}
+
+func TestAnalyticsReporter_TearDownFlushes(t *testing.T) {
+	tf := newAnalyticsReporterTestFixture(t)
+	tf.ar.initialDelay = time.Hour
+
+	state := tf.st.LockMutableStateForTesting()
+	state.TiltStartTime = time.Now()
+	tf.st.UnlockMutableState()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_ = tf.ar.OnChange(ctx, tf.st, store.ChangeSummary{})
+	tf.addManifestWithBuilds(tf.nextManifest().WithDeployTarget(kTarg),
+		buildRecord(time.Now(), time.Second, model.BuildReasonFlagInit, nil, model.BuildTypeImage))
+	tf.onChange()
+
+	tf.ar.TearDown(ctx)
+	if assert.Len(t, tf.ma.Counts, 1) {
+		assert.Equal(t, "1", tf.ma.Counts[0].Tags["builds.window.count"])
+	}
+
+	// A second teardown is a no-op.
+	tf.ar.TearDown(ctx)
+	assert.Len(t, tf.ma.Counts, 1)
+}
+
+func TestAnalyticsReporter_TearDownBeforeStartIsNoop(t *testing.T) {
+	tf := newAnalyticsReporterTestFixture(t)
+	tf.ar.TearDown(context.Background())
+	assert.Empty(t, tf.ma.Counts)
+}
+
+func buildRecord(start time.Time, dur time.Duration, reason model.BuildReason, err error, types ...model.BuildType) model.BuildRecord {
+	return model.BuildRecord{
+		StartTime:  start,
+		FinishTime: start.Add(dur),
+		Reason:     reason,
+		Error:      err,
+		BuildTypes: types,
+	}
+}
+
 type analyticsReporterTestFixture struct {
 	manifestCount int
 	ar            *AnalyticsReporter
 	ma            *analytics.MemoryAnalytics
 	kClient       *k8s.FakeK8sClient
 	st            *store.TestingStore
+	opter         *tiltanalytics.FakeOpter
 }
 
 func newAnalyticsReporterTestFixture(t testing.TB) *analyticsReporterTestFixture {
@@ -188,6 +317,7 @@ func newAnalyticsReporterTestFixture(t testing.TB) *analyticsReporterTestFixture
 		ma:            ma,
 		kClient:       kClient,
 		st:            st,
+		opter:         opter,
 	}
 }
 
@@ -197,6 +327,20 @@ func (artf *analyticsReporterTestFixture) addManifest(m model.Manifest) {
 	artf.st.UnlockMutableState()
 }
 
+func (artf *analyticsReporterTestFixture) addManifestWithBuilds(m model.Manifest, records ...model.BuildRecord) {
+	state := artf.st.LockMutableStateForTesting()
+	mt := store.NewManifestTarget(m)
+	for _, r := range records {
+		mt.State.AddCompletedBuild(r)
+	}
+	state.UpsertManifestTarget(mt)
+	artf.st.UnlockMutableState()
+}
+
+func (artf *analyticsReporterTestFixture) onChange() {
+	artf.ar.collectBuilds(artf.st)
+}
+
 func (artf *analyticsReporterTestFixture) nextManifest() model.Manifest {
 	artf.manifestCount++
 	return model.Manifest{Name: model.ManifestName(fmt.Sprintf("manifest%d", artf.manifestCount))}
@@ -218,3 +362,43 @@ func iTargetForRef(ref string) model.ImageTarget {
 	selector := container.NameSelector(named)
 	return model.MustNewImageTarget(selector)
 }
+
+func TestAnalyticsReporter_OptOutMidSession(t *testing.T) {
+	tf := newAnalyticsReporterTestFixture(t)
+	tf.addManifest(tf.nextManifest().WithDeployTarget(kTarg))
+
+	state := tf.st.LockMutableStateForTesting()
+	state.TiltStartTime = time.Now()
+	tf.st.UnlockMutableState()
+
+	tf.run()
+	assert.Len(t, tf.ma.Counts, 1)
+
+	// The user opts out from another process, e.g., `tilt analytics opt out`
+	_ = tf.opter.SetUserOpt(analytics.OptOut)
+
+	tf.run()
+	assert.Len(t, tf.ma.Counts, 1)
+}
+
+func TestAnalyticsReporter_PayloadsMatchReport(t *testing.T) {
+	tf := newAnalyticsReporterTestFixture(t)
+	tf.addManifest(tf.nextManifest().WithImageTarget(imgTargDBWithLU).WithDeployTarget(kTarg))
+	tf.addManifest(tf.nextManifest().WithDeployTarget(dTarg))
+
+	state := tf.st.LockMutableStateForTesting()
+	state.TiltStartTime = time.Now()
+	tf.st.UnlockMutableState()
+
+	payloads := tf.ar.Payloads(context.Background())
+	tf.run()
+
+	if assert.Len(t, payloads, 1) && assert.Len(t, tf.ma.Counts, 1) {
+		sent := tf.ma.Counts[0]
+		expected := map[string]string{analytics.TagName: "tilt." + sent.Name}
+		for k, v := range sent.Tags {
+			expected[k] = v
+		}
+		assert.Equal(t, expected, payloads[0])
+	}
+}
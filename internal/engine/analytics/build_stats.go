@@ -0,0 +1,131 @@
+package analytics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// Analytics-friendly names for each build reason flag.
+//
+// A build can have multiple reasons, so each build may be counted
+// under more than one reason.
+var buildReasonKeys = []struct {
+	flag model.BuildReason
+	key  string
+}{
+	{model.BuildReasonFlagInit, "init"},
+	{model.BuildReasonFlagChangedFiles, "changed_files"},
+	{model.BuildReasonFlagConfig, "config"},
+	{model.BuildReasonFlagCrash, "crash"},
+	{model.BuildReasonFlagTriggerWeb, "trigger_web"},
+	{model.BuildReasonFlagTriggerCLI, "trigger_cli"},
+	{model.BuildReasonFlagTriggerUnknown, "trigger_unknown"},
+	{model.BuildReasonFlagTiltfileArgs, "tiltfile_args"},
+	{model.BuildReasonFlagChangedDeps, "changed_deps"},
+	{model.BuildReasonFlagDebugOverride, "debug_override"},
+	{model.BuildReasonFlagTiltfileEnv, "tiltfile_env"},
+}
+
+const (
+	buildOutcomeSuccess = "success"
+	buildOutcomeError   = "error"
+)
+
+// Duration percentiles for one build type.
+type DurationStats struct {
+	Count int
+	P50   time.Duration
+	P95   time.Duration
+}
+
+// Aggregated statistics over a window of completed builds.
+type BuildStats struct {
+	Count           int
+	CountsByReason  map[string]int
+	CountsByOutcome map[string]int
+	DurationsByType map[model.BuildType]DurationStats
+}
+
+// Aggregates a window of build records.
+//
+// In-progress builds (with no FinishTime) are skipped.
+func AggregateBuildStats(records []model.BuildRecord) BuildStats {
+	stats := BuildStats{
+		CountsByReason:  make(map[string]int),
+		CountsByOutcome: make(map[string]int),
+		DurationsByType: make(map[model.BuildType]DurationStats),
+	}
+
+	durations := make(map[model.BuildType][]time.Duration)
+	for _, r := range records {
+		if r.Empty() || r.FinishTime.IsZero() {
+			continue
+		}
+
+		stats.Count++
+		for _, rk := range buildReasonKeys {
+			if r.Reason.Has(rk.flag) {
+				stats.CountsByReason[rk.key]++
+			}
+		}
+
+		if r.Error != nil {
+			stats.CountsByOutcome[buildOutcomeError]++
+		} else {
+			stats.CountsByOutcome[buildOutcomeSuccess]++
+		}
+
+		d := r.Duration()
+		for _, bt := range r.BuildTypes {
+			durations[bt] = append(durations[bt], d)
+		}
+	}
+
+	for bt, ds := range durations {
+		sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+		stats.DurationsByType[bt] = DurationStats{
+			Count: len(ds),
+			P50:   percentile(ds, 50),
+			P95:   percentile(ds, 95),
+		}
+	}
+	return stats
+}
+
+// Nearest-rank percentile over a sorted slice.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Flattens the stats into analytics tags.
+//
+// Every count covers only the builds in this window, not the whole session,
+// so the tags are named builds.window.* rather than builds.session.*.
+func (s BuildStats) Tags() map[string]string {
+	tags := map[string]string{
+		"builds.window.count": strconv.Itoa(s.Count),
+	}
+	for reason, n := range s.CountsByReason {
+		tags[fmt.Sprintf("builds.reason.%s.count", reason)] = strconv.Itoa(n)
+	}
+	for outcome, n := range s.CountsByOutcome {
+		tags[fmt.Sprintf("builds.outcome.%s.count", outcome)] = strconv.Itoa(n)
+	}
+	for bt, ds := range s.DurationsByType {
+		tags[fmt.Sprintf("builds.type.%s.count", bt)] = strconv.Itoa(ds.Count)
+		tags[fmt.Sprintf("builds.type.%s.p50_ms", bt)] = strconv.FormatInt(ds.P50.Milliseconds(), 10)
+		tags[fmt.Sprintf("builds.type.%s.p95_ms", bt)] = strconv.FormatInt(ds.P95.Milliseconds(), 10)
+	}
+	return tags
+}
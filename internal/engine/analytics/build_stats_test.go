@@ -0,0 +1,47 @@
+package analytics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestAggregateBuildStatsEmpty(t *testing.T) {
+	stats := AggregateBuildStats(nil)
+	assert.Equal(t, 0, stats.Count)
+	assert.Equal(t, map[string]string{"builds.window.count": "0"}, stats.Tags())
+}
+
+func TestAggregateBuildStats(t *testing.T) {
+	start := time.Now()
+	var records []model.BuildRecord
+	for i := 1; i <= 20; i++ {
+		records = append(records, buildRecord(start, time.Duration(i)*time.Second,
+			model.BuildReasonFlagChangedFiles, nil, model.BuildTypeLiveUpdate))
+	}
+	records = append(records,
+		buildRecord(start, time.Second, model.BuildReasonFlagInit.With(model.BuildReasonFlagConfig),
+			errors.New("oops"), model.BuildTypeDockerCompose),
+		// in-progress builds are skipped
+		model.BuildRecord{StartTime: start, BuildTypes: []model.BuildType{model.BuildTypeImage}})
+
+	stats := AggregateBuildStats(records)
+	assert.Equal(t, 21, stats.Count)
+	assert.Equal(t, map[string]int{"changed_files": 20, "init": 1, "config": 1}, stats.CountsByReason)
+	assert.Equal(t, map[string]int{"success": 20, "error": 1}, stats.CountsByOutcome)
+	assert.Equal(t, DurationStats{Count: 20, P50: 10 * time.Second, P95: 19 * time.Second},
+		stats.DurationsByType[model.BuildTypeLiveUpdate])
+	assert.Equal(t, DurationStats{Count: 1, P50: time.Second, P95: time.Second},
+		stats.DurationsByType[model.BuildTypeDockerCompose])
+	_, ok := stats.DurationsByType[model.BuildTypeImage]
+	assert.False(t, ok)
+
+	tags := stats.Tags()
+	assert.Equal(t, "10000", tags["builds.type.live-update.p50_ms"])
+	assert.Equal(t, "19000", tags["builds.type.live-update.p95_ms"])
+	assert.Equal(t, "1", tags["builds.outcome.error.count"])
+}
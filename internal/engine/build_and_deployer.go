@@ -32,6 +32,22 @@ func (bo BuildOrder) String() string {
 	return output.String()
 }
 
+// Returns the builders to try in the given update mode.
+func (bo BuildOrder) ForUpdateMode(mode liveupdates.UpdateMode) BuildOrder {
+	if mode != liveupdates.UpdateModeImage {
+		return bo
+	}
+
+	result := BuildOrder{}
+	for _, b := range bo {
+		if _, isLiveUpdate := b.(*buildcontrol.LiveUpdateBuildAndDeployer); isLiveUpdate {
+			continue
+		}
+		result = append(result, b)
+	}
+	return result
+}
+
 type FallbackTester func(error) bool
 
 // CompositeBuildAndDeployer tries to run each builder in order.  If a builder
@@ -39,14 +55,15 @@ type FallbackTester func(error) bool
 // critical enough to stop the whole pipeline, or to fallback to the next
 // builder.
 type CompositeBuildAndDeployer struct {
-	builders BuildOrder
-	tracer   trace.Tracer
+	builders   BuildOrder
+	updateMode liveupdates.UpdateMode
+	tracer     trace.Tracer
 }
 
 var _ buildcontrol.BuildAndDeployer = &CompositeBuildAndDeployer{}
 
-func NewCompositeBuildAndDeployer(builders BuildOrder, tracer trace.Tracer) *CompositeBuildAndDeployer {
-	return &CompositeBuildAndDeployer{builders: builders, tracer: tracer}
+func NewCompositeBuildAndDeployer(builders BuildOrder, updateMode liveupdates.UpdateMode, tracer trace.Tracer) *CompositeBuildAndDeployer {
+	return &CompositeBuildAndDeployer{builders: builders, updateMode: updateMode, tracer: tracer}
 }
 
 func (composite *CompositeBuildAndDeployer) BuildAndDeploy(ctx context.Context, st store.RStore, specs []model.TargetSpec, currentState store.BuildStateSet) (store.BuildResultSet, error) {
@@ -61,8 +78,12 @@ func (composite *CompositeBuildAndDeployer) BuildAndDeploy(ctx context.Context,
 	}
 	span.SetAttributes(attribute.KeyValue{Key: attribute.Key("targetNames"), Value: attribute.StringValue(strings.Join(specNames, ","))})
 
-	logger.Get(ctx).Debugf("Building with BuildOrder: %s", composite.builders.String())
-	for i, builder := range composite.builders {
+	// The user can switch update modes while Tilt is running,
+	// so we re-evaluate the build order on every build.
+	builders := composite.builders.ForUpdateMode(liveupdates.CurrentUpdateMode(st, composite.updateMode))
+
+	logger.Get(ctx).Debugf("Building with BuildOrder: %s", builders.String())
+	for i, builder := range builders {
 		buildType := fmt.Sprintf("%T", builder)
 		logger.Get(ctx).Debugf("Trying to build and deploy with %s", buildType)
 
@@ -96,7 +117,7 @@ func (composite *CompositeBuildAndDeployer) BuildAndDeploy(ctx context.Context,
 				errMsg := strings.Replace(strings.TrimSpace(fmt.Sprintf("%v", err)), "\n", "\n\t", -1)
 				l.Warnf("Live Update failed with unexpected error:\n\t%s\n"+
 					"Falling back to a full image build + deploy", errMsg)
-			} else if i+1 < len(composite.builders) {
+			} else if i+1 < len(builders) {
 				logger.Get(ctx).Infof("got unexpected error during build/deploy: %v", err)
 			}
 		}
@@ -110,11 +131,10 @@ func (composite *CompositeBuildAndDeployer) BuildAndDeploy(ctx context.Context,
 	return store.BuildResultSet{}, lastErr
 }
 
+// The full build order. CompositeBuildAndDeployer drops the builders
+// that don't apply to the current update mode.
 func DefaultBuildOrder(lubad *buildcontrol.LiveUpdateBuildAndDeployer, ibad *buildcontrol.ImageBuildAndDeployer, dcbad *buildcontrol.DockerComposeBuildAndDeployer,
-	ltbad *buildcontrol.LocalTargetBuildAndDeployer, updMode liveupdates.UpdateMode, env k8s.Env, runtime container.Runtime) BuildOrder {
-	if updMode == liveupdates.UpdateModeImage {
-		return BuildOrder{dcbad, ibad, ltbad}
-	}
-
-	return BuildOrder{lubad, dcbad, ibad, ltbad}
+	ltbad *buildcontrol.LocalTargetBuildAndDeployer, stbad *buildcontrol.SyncTargetBuildAndDeployer,
+	env k8s.Env, runtime container.Runtime) BuildOrder {
+	return BuildOrder{lubad, dcbad, ibad, ltbad, stbad}
 }
@@ -184,7 +184,7 @@ func TestLiveUpdateDockerBuildLocalContainer(t *testing.T) {
 	m := manifestbuilder.New(f, "sancho").
 		WithK8sYAML(SanchoYAML).
 		WithLiveUpdateBAD().
-		WithImageTarget(NewSanchoLiveUpdateImageTarget(f)).
+		WithImageTargets(NewSanchoLiveUpdateImageTarget(f)).
 		Build()
 	tCase := testCase{
 		manifest:                 m,
@@ -205,7 +205,7 @@ func TestLiveUpdateDockerBuildLocalContainerSameImgMultipleContainers(t *testing
 	m := manifestbuilder.New(f, "sancho").
 		WithK8sYAML(SanchoYAML).
 		WithLiveUpdateBAD().
-		WithImageTarget(NewSanchoLiveUpdateImageTarget(f)).
+		WithImageTargets(NewSanchoLiveUpdateImageTarget(f)).
 		Build()
 	cIDs := []container.ID{"c1", "c2", "c3"}
 	tCase := testCase{
@@ -228,14 +228,13 @@ func TestLiveUpdateDockerBuildExecSameImgMultipleContainers(t *testing.T) {
 	defer f.TearDown()
 
 	iTarg := NewSanchoDockerBuildImageTarget(f)
-	lu := assembleLiveUpdate(SanchoSyncSteps(f), nil, false, []string{"i/match/nothing"}, f)
 	cIDs := []container.ID{"c1", "c2", "c3"}
 	tCase := testCase{
 		manifest: manifestbuilder.New(f, "sancho").
 			WithK8sYAML(SanchoYAML).
 			WithLiveUpdateBAD().
-			WithImageTarget(iTarg).
-			WithLiveUpdate(lu).
+			WithImageTargets(iTarg).
+			WithLiveUpdate(SanchoSyncSteps(f), nil, []string{"i/match/nothing"}).
 			Build(),
 		runningContainersByTarget: map[model.TargetID][]container.ID{iTarg.ID(): cIDs},
 		changedFiles:              []string{"a.txt"},
@@ -443,7 +442,7 @@ func TestLiveUpdateDockerContainerUserRunFailureDoesntFallBack(t *testing.T) {
 	m := manifestbuilder.New(f, "sancho").
 		WithK8sYAML(SanchoYAML).
 		WithLiveUpdateBAD().
-		WithImageTarget(NewSanchoLiveUpdateImageTarget(f)).
+		WithImageTargets(NewSanchoLiveUpdateImageTarget(f)).
 		Build()
 	f.docker.SetExecError(userFailureErrDocker)
 	tCase := testCase{
@@ -473,13 +472,12 @@ func TestLiveUpdateExecUserRunFailureDoesntFallBack(t *testing.T) {
 
 	f.k8s.ExecErrors = []error{nil, userFailureErrExec}
 
-	lu := assembleLiveUpdate(SanchoSyncSteps(f), SanchoRunSteps, false, []string{"i/match/nothing"}, f)
 	tCase := testCase{
 		manifest: manifestbuilder.New(f, "sancho").
 			WithK8sYAML(SanchoYAML).
 			WithLiveUpdateBAD().
-			WithImageTarget(NewSanchoDockerBuildImageTarget(f)).
-			WithLiveUpdate(lu).
+			WithImageTargets(NewSanchoDockerBuildImageTarget(f)).
+			WithLiveUpdate(SanchoSyncSteps(f), SanchoRunSteps, []string{"i/match/nothing"}).
 			Build(),
 		changedFiles: []string{"a.txt"},
 
@@ -507,7 +505,7 @@ func TestLiveUpdateMultipleContainersFallsBackForFailure(t *testing.T) {
 	m := manifestbuilder.New(f, "sancho").
 		WithK8sYAML(SanchoYAML).
 		WithLiveUpdateBAD().
-		WithImageTarget(NewSanchoLiveUpdateImageTarget(f)).
+		WithImageTargets(NewSanchoLiveUpdateImageTarget(f)).
 		Build()
 	cIDs := []container.ID{"c1", "c2", "c3"}
 	tCase := testCase{
@@ -538,7 +536,7 @@ func TestLiveUpdateMultipleContainersFallsBackForFailureAfterSuccess(t *testing.
 	m := manifestbuilder.New(f, "sancho").
 		WithK8sYAML(SanchoYAML).
 		WithLiveUpdateBAD().
-		WithImageTarget(NewSanchoLiveUpdateImageTarget(f)).
+		WithImageTargets(NewSanchoLiveUpdateImageTarget(f)).
 		Build()
 	cIDs := []container.ID{"c1", "c2", "c3"}
 	tCase := testCase{
@@ -571,7 +569,7 @@ func TestLiveUpdateMultipleContainersUpdatesAllForUserRunFailuresAndDoesntFallBa
 	m := manifestbuilder.New(f, "sancho").
 		WithK8sYAML(SanchoYAML).
 		WithLiveUpdateBAD().
-		WithImageTarget(NewSanchoLiveUpdateImageTarget(f)).
+		WithImageTargets(NewSanchoLiveUpdateImageTarget(f)).
 		Build()
 	cIDs := []container.ID{"c1", "c2", "c3"}
 	tCase := testCase{
@@ -608,7 +606,7 @@ func TestLiveUpdateMultipleContainersFallsBackForSomeUserRunFailuresSomeSuccess(
 	m := manifestbuilder.New(f, "sancho").
 		WithK8sYAML(SanchoYAML).
 		WithLiveUpdateBAD().
-		WithImageTarget(NewSanchoLiveUpdateImageTarget(f)).
+		WithImageTargets(NewSanchoLiveUpdateImageTarget(f)).
 		Build()
 	cIDs := []container.ID{"c1", "c2", "c3"}
 	tCase := testCase{
@@ -643,7 +641,7 @@ func TestLiveUpdateMultipleContainersFallsBackForSomeUserRunFailuresSomeNonUserF
 	m := manifestbuilder.New(f, "sancho").
 		WithK8sYAML(SanchoYAML).
 		WithLiveUpdateBAD().
-		WithImageTarget(NewSanchoLiveUpdateImageTarget(f)).
+		WithImageTargets(NewSanchoLiveUpdateImageTarget(f)).
 		Build()
 	cIDs := []container.ID{"c1", "c2", "c3"}
 	tCase := testCase{
@@ -673,8 +671,8 @@ func TestLiveUpdateCustomBuildLocalContainer(t *testing.T) {
 		manifest: manifestbuilder.New(f, "sancho").
 			WithK8sYAML(SanchoYAML).
 			WithLiveUpdateBAD().
-			WithImageTarget(NewSanchoCustomBuildImageTarget(f)).
-			WithLiveUpdate(lu).
+			WithImageTargets(NewSanchoCustomBuildImageTarget(f)).
+			WithLiveUpdateSpec(lu).
 			Build(),
 		changedFiles:             []string{"app/a.txt"},
 		expectDockerBuildCount:   0,
@@ -690,13 +688,12 @@ func TestLiveUpdateHotReloadLocalContainer(t *testing.T) {
 	f := newBDFixture(t, k8s.EnvDockerDesktop, container.RuntimeDocker)
 	defer f.TearDown()
 
-	lu := assembleLiveUpdate(SanchoSyncSteps(f), SanchoRunSteps, false, nil, f)
 	tCase := testCase{
 		manifest: manifestbuilder.New(f, "sancho").
 			WithK8sYAML(SanchoYAML).
 			WithLiveUpdateBAD().
-			WithImageTarget(NewSanchoDockerBuildImageTarget(f)).
-			WithLiveUpdate(lu).
+			WithImageTargets(NewSanchoDockerBuildImageTarget(f)).
+			WithLiveUpdate(SanchoSyncSteps(f), SanchoRunSteps, nil).
 			Build(),
 		changedFiles:             []string{"a.txt"},
 		expectDockerBuildCount:   0,
@@ -722,8 +719,8 @@ func TestLiveUpdateRunTriggerLocalContainer(t *testing.T) {
 		manifest: manifestbuilder.New(f, "sancho").
 			WithK8sYAML(SanchoYAML).
 			WithLiveUpdateBAD().
-			WithImageTarget(NewSanchoDockerBuildImageTarget(f)).
-			WithLiveUpdate(lu).
+			WithImageTargets(NewSanchoDockerBuildImageTarget(f)).
+			WithLiveUpdateSpec(lu).
 			Build(),
 		changedFiles:             []string{"a.txt"},
 		expectDockerBuildCount:   0,
@@ -744,13 +741,12 @@ func TestLiveUpdateRunTriggerExec(t *testing.T) {
 		{Args: model.ToUnixCmd("echo a").Argv, TriggerPaths: []string{"a.txt"}}, // matches changed file
 		{Args: model.ToUnixCmd("echo b").Argv, TriggerPaths: []string{"b.txt"}}, // does NOT match changed file
 	}
-	lu := assembleLiveUpdate(SanchoSyncSteps(f), runs, false, nil, f)
 	tCase := testCase{
 		manifest: manifestbuilder.New(f, "sancho").
 			WithK8sYAML(SanchoYAML).
 			WithLiveUpdateBAD().
-			WithImageTarget(NewSanchoDockerBuildImageTarget(f)).
-			WithLiveUpdate(lu).
+			WithImageTargets(NewSanchoDockerBuildImageTarget(f)).
+			WithLiveUpdate(SanchoSyncSteps(f), runs, nil).
 			Build(),
 		changedFiles:             []string{"a.txt"},
 		expectDockerBuildCount:   0,
@@ -767,13 +763,12 @@ func TestLiveUpdateCustomBuildExec(t *testing.T) {
 	f := newBDFixture(t, k8s.EnvGKE, container.RuntimeDocker)
 	defer f.TearDown()
 
-	lu := assembleLiveUpdate(SanchoSyncSteps(f), SanchoRunSteps, false, nil, f)
 	tCase := testCase{
 		manifest: manifestbuilder.New(f, "sancho").
 			WithK8sYAML(SanchoYAML).
 			WithLiveUpdateBAD().
-			WithImageTarget(NewSanchoCustomBuildImageTarget(f)).
-			WithLiveUpdate(lu).
+			WithImageTargets(NewSanchoCustomBuildImageTarget(f)).
+			WithLiveUpdate(SanchoSyncSteps(f), SanchoRunSteps, nil).
 			Build(),
 		changedFiles:             []string{"app/a.txt"},
 		expectDockerBuildCount:   0,
@@ -795,8 +790,8 @@ func TestLiveUpdateExecDoesNotSupportRestart(t *testing.T) {
 		manifest: manifestbuilder.New(f, "sancho").
 			WithK8sYAML(SanchoYAML).
 			WithLiveUpdateBAD().
-			WithImageTarget(NewSanchoDockerBuildImageTarget(f)).
-			WithLiveUpdate(lu).
+			WithImageTargets(NewSanchoDockerBuildImageTarget(f)).
+			WithLiveUpdateSpec(lu).
 			Build(),
 		changedFiles:             []string{"a.txt"},
 		expectDockerBuildCount:   1, // we did a Docker build instead of an in-place update!
@@ -814,13 +809,12 @@ func TestLiveUpdateDockerBuildExec(t *testing.T) {
 	f := newBDFixture(t, k8s.EnvGKE, container.RuntimeContainerd)
 	defer f.TearDown()
 
-	lu := assembleLiveUpdate(SanchoSyncSteps(f), SanchoRunSteps, false, nil, f)
 	tCase := testCase{
 		manifest: manifestbuilder.New(f, "sancho").
 			WithK8sYAML(SanchoYAML).
 			WithLiveUpdateBAD().
-			WithImageTarget(NewSanchoDockerBuildImageTarget(f)).
-			WithLiveUpdate(lu).
+			WithImageTargets(NewSanchoDockerBuildImageTarget(f)).
+			WithLiveUpdate(SanchoSyncSteps(f), SanchoRunSteps, nil).
 			Build(),
 		changedFiles:           []string{"a.txt"},
 		expectDockerBuildCount: 0,
@@ -839,8 +833,8 @@ func TestLiveUpdateLocalContainerFallBackOn(t *testing.T) {
 		manifest: manifestbuilder.New(f, "sancho").
 			WithK8sYAML(SanchoYAML).
 			WithLiveUpdateBAD().
-			WithImageTarget(NewSanchoDockerBuildImageTarget(f)).
-			WithLiveUpdate(lu).
+			WithImageTargets(NewSanchoDockerBuildImageTarget(f)).
+			WithLiveUpdateSpec(lu).
 			Build(),
 		changedFiles:             []string{"a.txt"},
 		expectDockerBuildCount:   1, // we did a Docker build instead of an in-place update!
@@ -858,13 +852,12 @@ func TestLiveUpdateExecFallBackOn(t *testing.T) {
 	f := newBDFixture(t, k8s.EnvGKE, container.RuntimeDocker)
 	defer f.TearDown()
 
-	lu := assembleLiveUpdate(SanchoSyncSteps(f), SanchoRunSteps, false, []string{"a.txt"}, f)
 	tCase := testCase{
 		manifest: manifestbuilder.New(f, "sancho").
 			WithK8sYAML(SanchoYAML).
 			WithLiveUpdateBAD().
-			WithImageTarget(NewSanchoDockerBuildImageTarget(f)).
-			WithLiveUpdate(lu).
+			WithImageTargets(NewSanchoDockerBuildImageTarget(f)).
+			WithLiveUpdate(SanchoSyncSteps(f), SanchoRunSteps, []string{"a.txt"}).
 			Build(),
 		changedFiles:             []string{"a.txt"},
 		expectDockerBuildCount:   1, // we did a Docker build instead of an in-place update!
@@ -892,8 +885,8 @@ func TestLiveUpdateLocalContainerChangedFileNotMatchingSyncFallsBack(t *testing.
 		manifest: manifestbuilder.New(f, "sancho").
 			WithK8sYAML(SanchoYAML).
 			WithLiveUpdateBAD().
-			WithImageTarget(NewSanchoDockerBuildImageTarget(f)).
-			WithLiveUpdate(lu).
+			WithImageTargets(NewSanchoDockerBuildImageTarget(f)).
+			WithLiveUpdateSpec(lu).
 			Build(),
 		changedFiles: []string{f.JoinPath("a.txt")}, // matches context but not sync'd directory
 
@@ -919,13 +912,12 @@ func TestLiveUpdateExecChangedFileNotMatchingSyncFallsBack(t *testing.T) {
 		ContainerPath: "/go/src/github.com/tilt-dev/sancho",
 	}}
 
-	lu := assembleLiveUpdate(steps, SanchoRunSteps, false, []string{"a.txt"}, f)
 	tCase := testCase{
 		manifest: manifestbuilder.New(f, "sancho").
 			WithK8sYAML(SanchoYAML).
 			WithLiveUpdateBAD().
-			WithImageTarget(NewSanchoDockerBuildImageTarget(f)).
-			WithLiveUpdate(lu).
+			WithImageTargets(NewSanchoDockerBuildImageTarget(f)).
+			WithLiveUpdate(steps, SanchoRunSteps, []string{"a.txt"}).
 			Build(),
 		changedFiles: []string{f.JoinPath("a.txt")}, // matches context but not sync'd directory
 
@@ -963,13 +955,12 @@ func TestLiveUpdateManyFilesNotMatching(t *testing.T) {
 		f.JoinPath("a11.txt"),
 		f.JoinPath("a12.txt"))
 
-	lu := assembleLiveUpdate(steps, SanchoRunSteps, false, []string{"a.txt"}, f)
 	tCase := testCase{
 		manifest: manifestbuilder.New(f, "sancho").
 			WithK8sYAML(SanchoYAML).
 			WithLiveUpdateBAD().
-			WithImageTarget(NewSanchoDockerBuildImageTarget(f)).
-			WithLiveUpdate(lu).
+			WithImageTargets(NewSanchoDockerBuildImageTarget(f)).
+			WithLiveUpdate(steps, SanchoRunSteps, []string{"a.txt"}).
 			Build(),
 		changedFiles: changedFiles,
 
@@ -1000,8 +991,8 @@ func TestLiveUpdateSomeFilesMatchSyncSomeDontFallsBack(t *testing.T) {
 		manifest: manifestbuilder.New(f, "sancho").
 			WithK8sYAML(SanchoYAML).
 			WithLiveUpdateBAD().
-			WithImageTarget(NewSanchoDockerBuildImageTarget(f)).
-			WithLiveUpdate(lu).
+			WithImageTargets(NewSanchoDockerBuildImageTarget(f)).
+			WithLiveUpdateSpec(lu).
 			Build(),
 		// One file matches a sync, one does not -- we should still fall back.
 		changedFiles: f.JoinPaths([]string{"specific/directory/i_match", "a.txt"}),
@@ -141,7 +141,7 @@ func TestLiveUpdateTaskKilled(t *testing.T) {
 	manifest := manifestbuilder.New(f, "sancho").
 		WithK8sYAML(SanchoYAML).
 		WithLiveUpdateBAD().
-		WithImageTarget(NewSanchoLiveUpdateImageTarget(f)).
+		WithImageTargets(NewSanchoLiveUpdateImageTarget(f)).
 		Build()
 	bs := resultToStateSet(manifest, alreadyBuiltSet, []string{changed}, testContainerInfo)
 	f.docker.SetExecError(docker.ExitError{ExitCode: build.TaskKillExitCode})
@@ -159,6 +159,27 @@ func TestLiveUpdateTaskKilled(t *testing.T) {
 	assert.Equal(t, 1, f.docker.BuildCount)
 }
 
+func TestSwitchToImageUpdateMode(t *testing.T) {
+	f := newBDFixture(t, k8s.EnvDockerDesktop, container.RuntimeDocker)
+	defer f.TearDown()
+
+	f.st.WithState(func(state *store.EngineState) {
+		state.UpdateMode.Mode = string(liveupdates.UpdateModeImage)
+	})
+
+	manifest := NewSanchoLiveUpdateManifest(f)
+	changed := f.WriteFile("a.txt", "a")
+	bs := resultToStateSet(manifest, alreadyBuiltSet, []string{changed}, testContainerInfo)
+
+	targets := buildcontrol.BuildTargets(manifest)
+	_, err := f.BuildAndDeploy(targets, bs)
+	require.NoError(t, err)
+
+	// Skips the live update, and goes straight to an image build.
+	assert.Equal(t, 0, f.docker.CopyCount)
+	assert.Equal(t, 1, f.docker.BuildCount)
+}
+
 func TestFallBackToImageDeploy(t *testing.T) {
 	f := newBDFixture(t, k8s.EnvDockerDesktop, container.RuntimeDocker)
 	defer f.TearDown()
@@ -191,8 +212,8 @@ func TestLiveUpdateFallbackMessagingRedirect(t *testing.T) {
 	lu := assembleLiveUpdate(syncs,
 		nil, false, []string{f.JoinPath("fall_back.txt")}, f)
 	manifest := manifestbuilder.New(f, "foobar").
-		WithImageTarget(NewSanchoDockerBuildImageTarget(f)).
-		WithLiveUpdate(lu).
+		WithImageTargets(NewSanchoDockerBuildImageTarget(f)).
+		WithLiveUpdateSpec(lu).
 		WithLiveUpdateBAD().
 		WithK8sYAML(SanchoYAML).
 		Build()
@@ -224,7 +245,7 @@ func TestLiveUpdateFallbackMessagingUnexpectedError(t *testing.T) {
 	manifest := manifestbuilder.New(f, "sancho").
 		WithK8sYAML(SanchoYAML).
 		WithLiveUpdateBAD().
-		WithImageTarget(NewSanchoLiveUpdateImageTarget(f)).
+		WithImageTargets(NewSanchoLiveUpdateImageTarget(f)).
 		Build()
 	changed := f.WriteFile("a.txt", "a")
 	bs := resultToStateSet(manifest, alreadyBuiltSet, []string{changed}, testContainerInfo)
@@ -251,7 +272,7 @@ func TestLiveUpdateTwice(t *testing.T) {
 	manifest := manifestbuilder.New(f, "sancho").
 		WithK8sYAML(SanchoYAML).
 		WithLiveUpdateBAD().
-		WithImageTarget(NewSanchoLiveUpdateImageTarget(f)).
+		WithImageTargets(NewSanchoLiveUpdateImageTarget(f)).
 		Build()
 	targets := buildcontrol.BuildTargets(manifest)
 	aPath := f.WriteFile("a.txt", "a")
@@ -293,7 +314,7 @@ func TestLiveUpdateTwiceDeadPod(t *testing.T) {
 	manifest := manifestbuilder.New(f, "sancho").
 		WithK8sYAML(SanchoYAML).
 		WithLiveUpdateBAD().
-		WithImageTarget(NewSanchoLiveUpdateImageTarget(f)).
+		WithImageTargets(NewSanchoLiveUpdateImageTarget(f)).
 		Build()
 	targets := buildcontrol.BuildTargets(manifest)
 	aPath := f.WriteFile("a.txt", "a")
@@ -540,7 +561,7 @@ func TestLiveUpdateWithRunFailureReturnsContainerIDs(t *testing.T) {
 	manifest := manifestbuilder.New(f, "sancho").
 		WithK8sYAML(SanchoYAML).
 		WithLiveUpdateBAD().
-		WithImageTarget(NewSanchoLiveUpdateImageTarget(f)).
+		WithImageTargets(NewSanchoLiveUpdateImageTarget(f)).
 		Build()
 	targets := buildcontrol.BuildTargets(manifest)
 	changed := f.WriteFile("a.txt", "a")
@@ -827,7 +848,7 @@ func newBDFixtureWithUpdateMode(t *testing.T, env k8s.Env, runtime container.Run
 	ctrlClient := fake.NewFakeTiltClient()
 	st := NewTestingStore(logs)
 	execer := localexec.NewFakeExecer(t)
-	bd, err := provideFakeBuildAndDeployer(ctx, dockerClient, k8s, dir, env, mode, dcc,
+	bd, err := provideFakeBuildAndDeployer(ctx, dockerClient, build.NewFakeContainerdClient(), k8s, dir, env, mode, dcc,
 		fakeClock{now: time.Unix(1551202573, 0)}, kl, ta, ctrlClient, st, execer)
 	require.NoError(t, err)
 
@@ -1010,10 +1031,16 @@ type fakeClock struct {
 func (c fakeClock) Now() time.Time { return c.now }
 
 type fakeKINDLoader struct {
-	loadCount int
+	loadCount        int
+	archiveLoadCount int
 }
 
 func (kl *fakeKINDLoader) LoadToKIND(ctx context.Context, ref reference.NamedTagged) error {
 	kl.loadCount++
 	return nil
 }
+
+func (kl *fakeKINDLoader) LoadArchiveToKIND(ctx context.Context, path string) error {
+	kl.archiveLoadCount++
+	return nil
+}
@@ -0,0 +1,85 @@
+package buildcontrol
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/go-units"
+
+	"github.com/tilt-dev/tilt/internal/build"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// Warn if the build context is this many times bigger than last build's,
+// even if it's under the size limit.
+const buildContextGrowthWarnFactor = 2
+
+// Small contexts are cheap to send, no matter how fast they grow.
+const buildContextGrowthWarnMinSize = 50 * 1000 * 1000
+
+// How many of the biggest files and directories to name in the warning.
+const buildContextLargestEntryCount = 5
+
+// The size of the build context of the last successful build of this image.
+func lastContextSize(stateSet store.BuildStateSet, id model.TargetID) int64 {
+	result, ok := stateSet[id].LastResult.(store.ImageBuildResult)
+	if !ok {
+		return 0
+	}
+	return result.ContextSize
+}
+
+func buildContextWarnSize(st store.RStore) int64 {
+	state := st.RLockState()
+	defer st.RUnlockState()
+	return state.UpdateSettings.BuildContextWarnSize
+}
+
+// Warns if the build context is big enough to slow down builds,
+// which usually means something like .git or node_modules isn't ignored.
+func warnOnLargeBuildContext(ctx context.Context, iTarget model.ImageTarget, stats build.ContextStats, lastSize int64, warnSize int64) {
+	msg := largeBuildContextMessage(iTarget, stats, lastSize, warnSize)
+	if msg != "" {
+		logger.Get(ctx).Warnf("%s", msg)
+	}
+}
+
+func largeBuildContextMessage(iTarget model.ImageTarget, stats build.ContextStats, lastSize int64, warnSize int64) string {
+	if stats.Size == 0 {
+		return ""
+	}
+
+	tooBig := warnSize > 0 && stats.Size > warnSize
+	grewSharply := lastSize > 0 &&
+		stats.Size >= buildContextGrowthWarnMinSize &&
+		stats.Size > lastSize*buildContextGrowthWarnFactor
+	if !tooBig && !grewSharply {
+		return ""
+	}
+
+	var sb strings.Builder
+	if grewSharply {
+		sb.WriteString(fmt.Sprintf("Build context for %s grew from %s to %s since the last build.\n",
+			iTarget.Refs.ConfigurationRef.String(), humanSize(lastSize), humanSize(stats.Size)))
+	} else {
+		sb.WriteString(fmt.Sprintf("Build context for %s is %s, which slows down every build.\n",
+			iTarget.Refs.ConfigurationRef.String(), humanSize(stats.Size)))
+	}
+
+	largest := stats.Largest(buildContextLargestEntryCount)
+	if len(largest) > 0 {
+		sb.WriteString("Largest files and directories in the context:\n")
+		for _, entry := range largest {
+			sb.WriteString(fmt.Sprintf("  %s: %s\n", entry.Name, humanSize(entry.Size)))
+		}
+	}
+	sb.WriteString("If the image doesn't need them, add them to .dockerignore or to the `ignore` argument of docker_build().")
+	return sb.String()
+}
+
+func humanSize(size int64) string {
+	return units.HumanSize(float64(size))
+}
@@ -0,0 +1,61 @@
+package buildcontrol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tilt-dev/tilt/internal/build"
+	"github.com/tilt-dev/tilt/internal/container"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+const mb = 1000 * 1000
+
+func TestLargeBuildContextMessage(t *testing.T) {
+	iTarget := model.MustNewImageTarget(container.MustParseSelector("gcr.io/foo"))
+	stats := build.ContextStats{
+		Size: 1500 * mb,
+		EntrySizes: map[string]int64{
+			"node_modules": 1200 * mb,
+			".git":         290 * mb,
+			"src":          10 * mb,
+		},
+	}
+
+	assert.Equal(t, `Build context for gcr.io/foo is 1.5GB, which slows down every build.
+Largest files and directories in the context:
+  node_modules: 1.2GB
+  .git: 290MB
+  src: 10MB
+If the image doesn't need them, add them to .dockerignore or to the `+"`ignore`"+` argument of docker_build().`,
+		largeBuildContextMessage(iTarget, stats, 1500*mb, model.DefaultBuildContextWarnSize))
+}
+
+func TestLargeBuildContextMessageGrowth(t *testing.T) {
+	iTarget := model.MustNewImageTarget(container.MustParseSelector("gcr.io/foo"))
+	stats := build.ContextStats{
+		Size:       200 * mb,
+		EntrySizes: map[string]int64{"node_modules": 190 * mb, "src": 10 * mb},
+	}
+
+	msg := largeBuildContextMessage(iTarget, stats, 20*mb, model.DefaultBuildContextWarnSize)
+	assert.Contains(t, msg, "Build context for gcr.io/foo grew from 20MB to 200MB since the last build.\n")
+	assert.Contains(t, msg, "  node_modules: 190MB\n")
+}
+
+func TestLargeBuildContextMessageNoWarning(t *testing.T) {
+	iTarget := model.MustNewImageTarget(container.MustParseSelector("gcr.io/foo"))
+
+	// Under the limit, and not much bigger than last time.
+	stats := build.ContextStats{Size: 100 * mb}
+	assert.Equal(t, "", largeBuildContextMessage(iTarget, stats, 90*mb, model.DefaultBuildContextWarnSize))
+
+	// Grew a lot, but still small.
+	stats = build.ContextStats{Size: 10 * mb}
+	assert.Equal(t, "", largeBuildContextMessage(iTarget, stats, 1*mb, model.DefaultBuildContextWarnSize))
+
+	// Over the limit, but the warning is turned off.
+	stats = build.ContextStats{Size: 1500 * mb}
+	assert.Equal(t, "", largeBuildContextMessage(iTarget, stats, 1500*mb, 0))
+}
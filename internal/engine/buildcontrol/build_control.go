@@ -1,12 +1,16 @@
 package buildcontrol
 
 import (
+	"strconv"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
 
 	"github.com/tilt-dev/tilt/internal/build"
+	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
 	"github.com/tilt-dev/tilt/internal/controllers/apis/liveupdate"
+	"github.com/tilt-dev/tilt/internal/controllers/apis/pin"
+	"github.com/tilt-dev/tilt/internal/dockercompose"
 	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/internal/store/k8sconv"
 	"github.com/tilt-dev/tilt/internal/store/liveupdates"
@@ -61,10 +65,17 @@ func NextTargetToBuild(state store.EngineState) (*store.ManifestTarget, HoldSet)
 	}
 
 	HoldTargetsWithBuildingComponents(targets, holds)
+	HoldTargetsSharingDockerfileWithBuilding(targets, holds)
 	HoldTargetsWaitingOnDependencies(state, targets, holds)
+	HoldTargetsWaitingOnOutputs(state, targets, holds)
 	HoldDisabledTargets(state, targets, holds)
+	HoldPinnedTargets(state, targets, holds)
 
 	// If any of the manifest targets haven't been built yet, build them now.
+	//
+	// Resources waiting on their resource_deps are held above, so the first
+	// builds start from the roots of the dependency graph.
+	allTargets := targets
 	targets = holds.RemoveIneligibleTargets(targets)
 	unbuilt := FindTargetsNeedingInitialBuild(targets)
 
@@ -72,6 +83,16 @@ func NextTargetToBuild(state store.EngineState) (*store.ManifestTarget, HoldSet)
 		return NextUnbuiltTargetToBuild(unbuilt), holds
 	}
 
+	// Nothing else is ready for its first build, so fill the free build slot
+	// with a resource that's still waiting on its dependencies, closest to
+	// the roots first.
+	if state.UpdateSettings.RelaxedStartupOrder {
+		if mt := nextTargetWaitingOnDependencies(state, allTargets, holds); mt != nil {
+			delete(holds, mt.Manifest.Name)
+			return mt, holds
+		}
+	}
+
 	// Next prioritize builds that crashed and need a rebuilt to have up-to-date code.
 	for _, mt := range targets {
 		if mt.State.NeedsRebuildFromCrash {
@@ -120,17 +141,121 @@ func waitingOnDependencies(state store.EngineState, mt *store.ManifestTarget) []
 		return nil
 	}
 
+	var healthyDeps []model.ManifestName
+	if mt.Manifest.IsDC() {
+		healthyDeps = mt.Manifest.DockerComposeTarget().HealthyDependencies()
+	}
+
 	var waitingOn []model.TargetID
 	for _, mn := range mt.Manifest.ResourceDependencies {
 		ms, ok := state.ManifestState(mn)
 		if !ok || ms == nil || ms.RuntimeState == nil || !ms.RuntimeState.HasEverBeenReadyOrSucceeded() {
 			waitingOn = append(waitingOn, mn.TargetID())
+			continue
+		}
+		if containsManifestName(healthyDeps, mn) && !hasEverBeenHealthy(ms) {
+			waitingOn = append(waitingOn, mn.TargetID())
 		}
 	}
 
 	return waitingOn
 }
 
+// Whether a Docker Compose dependency has passed its healthcheck.
+func hasEverBeenHealthy(ms *store.ManifestState) bool {
+	dcState, ok := ms.RuntimeState.(dockercompose.State)
+	return ok && dcState.HasEverBeenHealthy()
+}
+
+func containsManifestName(mns []model.ManifestName, mn model.ManifestName) bool {
+	for _, m := range mns {
+		if m == mn {
+			return true
+		}
+	}
+	return false
+}
+
+// The depth of each resource in the resource_deps graph.
+//
+// Resources without dependencies have depth 0. A resource is one deeper
+// than its deepest dependency. Cycles are cut where we find them.
+func resourceDepDepths(state store.EngineState) map[model.ManifestName]int {
+	depths := make(map[model.ManifestName]int)
+	visiting := make(map[model.ManifestName]bool)
+
+	var visit func(mn model.ManifestName) int
+	visit = func(mn model.ManifestName) int {
+		if d, ok := depths[mn]; ok {
+			return d
+		}
+		mt, ok := state.ManifestTargets[mn]
+		if !ok || visiting[mn] {
+			return -1
+		}
+
+		visiting[mn] = true
+		depth := 0
+		for _, dep := range mt.Manifest.ResourceDependencies {
+			if d := visit(dep) + 1; d > depth {
+				depth = d
+			}
+		}
+		visiting[mn] = false
+
+		depths[mn] = depth
+		return depth
+	}
+
+	for _, mn := range state.ManifestDefinitionOrder {
+		visit(mn)
+	}
+	return depths
+}
+
+// Filters the targets down to the ones closest to the roots of the
+// resource_deps graph, preserving their order.
+func shallowestTargets(state store.EngineState, mts []*store.ManifestTarget) []*store.ManifestTarget {
+	depths := resourceDepDepths(state)
+	minDepth := -1
+	for _, mt := range mts {
+		if d := depths[mt.Manifest.Name]; minDepth == -1 || d < minDepth {
+			minDepth = d
+		}
+	}
+
+	result := make([]*store.ManifestTarget, 0, len(mts))
+	for _, mt := range mts {
+		if depths[mt.Manifest.Name] == minDepth {
+			result = append(result, mt)
+		}
+	}
+	return result
+}
+
+// Finds a resource that's waiting on its dependencies for its first build,
+// but isn't held for any other reason.
+func nextTargetWaitingOnDependencies(state store.EngineState, mts []*store.ManifestTarget, holds HoldSet) *store.ManifestTarget {
+	var waiting []*store.ManifestTarget
+	for _, mt := range FindTargetsNeedingInitialBuild(mts) {
+		if holds[mt.Manifest.Name].Reason == store.HoldReasonWaitingForDep {
+			waiting = append(waiting, mt)
+		}
+	}
+
+	// A target only gets one hold, so the dependency hold may be
+	// hiding holds that were added after it.
+	otherHolds := HoldSet{}
+	HoldTargetsWaitingOnOutputs(state, waiting, otherHolds)
+	HoldDisabledTargets(state, waiting, otherHolds)
+	HoldPinnedTargets(state, waiting, otherHolds)
+	waiting = otherHolds.RemoveIneligibleTargets(waiting)
+	if len(waiting) == 0 {
+		return nil
+	}
+	return NextUnbuiltTargetToBuild(shallowestTargets(state, waiting))
+}
+
 // Check to see if this is an ImageTarget where the built image
 // can be potentially reused.
 //
@@ -218,6 +343,84 @@ func HoldTargetsWithBuildingComponents(mts []*store.ManifestTarget, holds HoldSe
 	}
 }
 
+// Identifies the Dockerfile and build context of an image, regardless of
+// the target stage or build args.
+type dockerfileKey struct {
+	dockerfile string
+	buildPath  string
+}
+
+func dockerfileKeyForSpec(spec model.TargetSpec) (dockerfileKey, bool) {
+	iTarget, ok := spec.(model.ImageTarget)
+	if !ok || !iTarget.IsDockerBuild() {
+		return dockerfileKey{}, false
+	}
+	db := iTarget.DockerBuildInfo()
+	return dockerfileKey{dockerfile: db.Dockerfile, buildPath: db.BuildPath}, true
+}
+
+// Holds targets with an image that builds from the same Dockerfile and context
+// as an image that's building right now (e.g., a different target stage of a
+// multi-stage Dockerfile).
+//
+// Building them one at a time means the later builds find the shared stages
+// in the daemon's layer cache, rather than building them again concurrently.
+func HoldTargetsSharingDockerfileWithBuilding(mts []*store.ManifestTarget, holds HoldSet) {
+	building := make(map[dockerfileKey][]model.TargetID)
+	for _, mt := range mts {
+		if !mt.State.IsBuilding() {
+			continue
+		}
+
+		for _, spec := range mt.Manifest.TargetSpecs() {
+			if canReuseImageTargetHeuristic(spec, mt.State.BuildStatus(spec.ID())) {
+				continue
+			}
+
+			key, ok := dockerfileKeyForSpec(spec)
+			if ok {
+				building[key] = append(building[key], spec.ID())
+			}
+		}
+	}
+
+	if len(building) == 0 {
+		return
+	}
+
+	for _, mt := range mts {
+		var waitingOn []model.TargetID
+		seen := make(map[model.TargetID]bool)
+		for _, spec := range mt.Manifest.TargetSpecs() {
+			if canReuseImageTargetHeuristic(spec, mt.State.BuildStatus(spec.ID())) {
+				continue
+			}
+
+			key, ok := dockerfileKeyForSpec(spec)
+			if !ok {
+				continue
+			}
+
+			for _, id := range building[key] {
+				// An image that's itself building is a building component, not a
+				// shared Dockerfile.
+				if id == spec.ID() || seen[id] {
+					continue
+				}
+				seen[id] = true
+				waitingOn = append(waitingOn, id)
+			}
+		}
+
+		if len(waitingOn) > 0 {
+			holds.AddHold(mt, store.Hold{
+				Reason: store.HoldReasonWaitingForSharedDockerfile,
+				HoldOn: waitingOn,
+			})
+		}
+	}
+}
+
 func HoldTargetsWaitingOnDependencies(state store.EngineState, mts []*store.ManifestTarget, holds HoldSet) {
 	for _, mt := range mts {
 		if waitingOn := waitingOnDependencies(state, mt); len(waitingOn) != 0 {
@@ -229,12 +432,87 @@ func HoldTargetsWaitingOnDependencies(state store.EngineState, mts []*store.Mani
 	}
 }
 
+// Hold back targets that build from another resource's outputs while
+// that resource is about to update them, so that we don't build from
+// half-written outputs.
+func HoldTargetsWaitingOnOutputs(state store.EngineState, mts []*store.ManifestTarget, holds HoldSet) {
+	for _, mt := range mts {
+		if waitingOn := waitingOnOutputs(state, mt); len(waitingOn) != 0 {
+			holds.AddHold(mt, store.Hold{
+				Reason: store.HoldReasonWaitingForOutputs,
+				HoldOn: waitingOn,
+			})
+		}
+	}
+}
+
+func waitingOnOutputs(state store.EngineState, mt *store.ManifestTarget) []model.TargetID {
+	var waitingOn []model.TargetID
+	for _, mn := range mt.Manifest.OutputDependencies {
+		producer, ok := state.ManifestTargets[mn]
+		if !ok || isDisabled(state, producer) {
+			continue
+		}
+
+		if producerWillUpdateOutputs(state, producer) {
+			waitingOn = append(waitingOn, mn.TargetID())
+		}
+	}
+	return waitingOn
+}
+
+// Returns true if the producer is building, or will build as soon as it can.
+func producerWillUpdateOutputs(state store.EngineState, producer *store.ManifestTarget) bool {
+	ms := producer.State
+	if ms.IsBuilding() {
+		return true
+	}
+
+	if !ms.StartedFirstBuild() {
+		// Don't wait on a first build that's itself waiting on dependencies,
+		// in case those dependencies are waiting on us.
+		return producer.Manifest.TriggerMode.AutoInitial() && len(waitingOnDependencies(state, producer)) == 0
+	}
+
+	for _, mn := range state.TriggerQueue {
+		if mn == producer.Manifest.Name {
+			return true
+		}
+	}
+
+	hasPendingChanges, _ := ms.HasPendingChanges()
+	return hasPendingChanges && producer.Manifest.TriggerMode.AutoOnChange()
+}
+
+// A resource is disabled if its UIResource says so, or if its disable
+// ConfigMap already does. The ConfigMap changes first, and changes together
+// with the other resources in a bulk disable, so we check it to avoid
+// building part of a set that's on its way down.
+func isDisabled(state store.EngineState, mt *store.ManifestTarget) bool {
+	cm, ok := state.ConfigMaps[configmap.DisableConfigMapName(mt.Manifest.Name)]
+	if ok {
+		if disabled, _ := strconv.ParseBool(cm.Data[configmap.DisableKey]); disabled {
+			return true
+		}
+	}
+	uir, ok := state.UIResources[string(mt.Manifest.Name)]
+	return ok && uir.Status.DisableStatus.DisabledCount > 0
+}
+
 func HoldDisabledTargets(state store.EngineState, mts []*store.ManifestTarget, holds HoldSet) {
 	for _, mt := range mts {
-		if uir, ok := state.UIResources[string(mt.Manifest.Name)]; ok {
-			if uir.Status.DisableStatus.DisabledCount > 0 {
-				holds.AddHold(mt, store.Hold{Reason: store.HoldReasonDisabled})
-			}
+		if isDisabled(state, mt) {
+			holds.AddHold(mt, store.Hold{Reason: store.HoldReasonDisabled})
+		}
+	}
+}
+
+// Hold back resources pinned to their current pod. Live updates still
+// sync into the pod, because the LiveUpdate reconciler handles them.
+func HoldPinnedTargets(state store.EngineState, mts []*store.ManifestTarget, holds HoldSet) {
+	for _, mt := range mts {
+		if mt.Manifest.IsK8s() && pin.IsPinned(state.ConfigMaps, mt.Manifest.Name.String()) {
+			holds.AddHold(mt, store.Hold{Reason: store.HoldReasonPinned})
 		}
 	}
 }
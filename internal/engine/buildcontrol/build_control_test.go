@@ -11,11 +11,15 @@ import (
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
 
 	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
 
 	"github.com/tilt-dev/tilt/internal/container"
+	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
+	"github.com/tilt-dev/tilt/internal/controllers/apis/pin"
+	"github.com/tilt-dev/tilt/internal/dockercompose"
 	"github.com/tilt-dev/tilt/internal/k8s"
 	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
 	"github.com/tilt-dev/tilt/internal/store"
@@ -102,6 +106,41 @@ func TestK8sDependsOnLocal(t *testing.T) {
 	_ = k8s2
 }
 
+func TestDCDependsOnHealthyService(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	db := f.upsertManifest(manifestbuilder.New(f, "db").WithDockerCompose("db", nil).Build())
+	app := manifestbuilder.New(f, "app").
+		WithDockerCompose("app", nil).
+		WithResourceDeps("db").
+		Build()
+	app = app.WithDeployTarget(app.DockerComposeTarget().WithHealthyDependencies([]model.ManifestName{"db"}))
+	f.upsertManifest(app)
+
+	f.assertNextTargetToBuild("db")
+	f.assertHold("app", store.HoldReasonWaitingForDep, model.ManifestName("db").TargetID())
+
+	// Started, but the healthcheck hasn't passed yet.
+	db.State.AddCompletedBuild(model.BuildRecord{
+		StartTime:  time.Now(),
+		FinishTime: time.Now(),
+	})
+	db.State.RuntimeState = dockercompose.State{
+		ContainerState: types.ContainerState{Health: &types.Health{Status: types.Starting}},
+		LastReadyTime:  time.Now(),
+	}
+	f.assertNoTargetNextToBuild()
+	f.assertHold("app", store.HoldReasonWaitingForDep, model.ManifestName("db").TargetID())
+
+	db.State.RuntimeState = dockercompose.State{
+		ContainerState:  types.ContainerState{Health: &types.Health{Status: types.Healthy}},
+		LastReadyTime:   time.Now(),
+		LastHealthyTime: time.Now(),
+	}
+	f.assertNextTargetToBuild("app")
+}
+
 func TestLocalDependsOnNonWorkloadK8s(t *testing.T) {
 	f := newTestFixture(t)
 	defer f.TearDown()
@@ -134,6 +173,96 @@ func TestLocalDependsOnNonWorkloadK8s(t *testing.T) {
 	_ = k8s2
 }
 
+// frontend -> (api, worker) -> db
+func (f *testFixture) upsertDiamond() map[model.ManifestName]*store.ManifestTarget {
+	ignore := withK8sPodReadiness(model.PodReadinessIgnore)
+	return map[model.ManifestName]*store.ManifestTarget{
+		"frontend": f.upsertK8sManifest("frontend", ignore, withResourceDeps("api", "worker")),
+		"api":      f.upsertK8sManifest("api", ignore, withResourceDeps("db")),
+		"worker":   f.upsertK8sManifest("worker", ignore, withResourceDeps("db")),
+		"db":       f.upsertK8sManifest("db", ignore),
+	}
+}
+
+func TestDiamondDependenciesStartInOrder(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	mts := f.upsertDiamond()
+
+	f.assertNextTargetToBuild("db")
+	f.assertHold("api", store.HoldReasonWaitingForDep, model.ManifestName("db").TargetID())
+	f.assertHold("frontend", store.HoldReasonWaitingForDep,
+		model.ManifestName("api").TargetID(), model.ManifestName("worker").TargetID())
+
+	f.startBuild(mts["db"])
+	f.assertNoTargetNextToBuild()
+
+	f.finishBuildAndReady(mts["db"])
+	f.assertNextTargetToBuild("api")
+	f.startBuild(mts["api"])
+	f.assertNextTargetToBuild("worker")
+	f.startBuild(mts["worker"])
+	f.assertNoTargetNextToBuild()
+
+	f.finishBuildAndReady(mts["api"])
+	f.assertNoTargetNextToBuild()
+	f.assertHold("frontend", store.HoldReasonWaitingForDep, model.ManifestName("worker").TargetID())
+
+	f.finishBuildAndReady(mts["worker"])
+	f.assertNextTargetToBuild("frontend")
+}
+
+func TestDiamondDependenciesRelaxedStartupOrder(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	f.st.UpdateSettings.RelaxedStartupOrder = true
+	mts := f.upsertDiamond()
+
+	// Roots still go first.
+	f.assertNextTargetToBuild("db")
+	f.startBuild(mts["db"])
+
+	// With a free slot, dependents start in dependency order,
+	// while still showing what they're waiting on.
+	f.assertNextTargetToBuild("api")
+	f.assertHold("frontend", store.HoldReasonWaitingForDep,
+		model.ManifestName("api").TargetID(), model.ManifestName("worker").TargetID())
+	f.startBuild(mts["api"])
+	f.assertNextTargetToBuild("worker")
+	f.startBuild(mts["worker"])
+	f.assertNextTargetToBuild("frontend")
+	f.startBuild(mts["frontend"])
+	f.assertNoTargetNextToBuild()
+}
+
+func TestRelaxedStartupOrderPrefersReadyTargets(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	f.st.UpdateSettings.RelaxedStartupOrder = true
+	mts := f.upsertDiamond()
+	f.upsertK8sManifest("standalone", withK8sPodReadiness(model.PodReadinessIgnore))
+
+	f.startBuild(mts["db"])
+
+	// A resource without dependencies goes before the ones that are waiting.
+	f.assertNextTargetToBuild("standalone")
+}
+
+func TestRelaxedStartupOrderKeepsOtherHolds(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	f.st.UpdateSettings.RelaxedStartupOrder = true
+	mts := f.upsertDiamond()
+	f.setPinned("api", true)
+
+	f.startBuild(mts["db"])
+	f.assertNextTargetToBuild("worker")
+}
+
 func TestCurrentlyBuildingLocalResourceDisablesK8sScheduling(t *testing.T) {
 	f := newTestFixture(t)
 	defer f.TearDown()
@@ -194,10 +323,13 @@ func TestTwoK8sTargetsWithBaseImage(t *testing.T) {
 	f := newTestFixture(t)
 	defer f.TearDown()
 
-	baseImage := model.MustNewImageTarget(container.MustParseSelector("sancho-base"))
+	baseImage := model.MustNewImageTarget(container.MustParseSelector("sancho-base")).
+		WithBuildDetails(model.DockerBuild{BuildPath: f.JoinPath("sancho-base")})
 	sanchoOneImage := model.MustNewImageTarget(container.MustParseSelector("sancho-one")).
+		WithBuildDetails(model.DockerBuild{BuildPath: f.JoinPath("sancho-one")}).
 		WithDependencyIDs([]model.TargetID{baseImage.ID()})
 	sanchoTwoImage := model.MustNewImageTarget(container.MustParseSelector("sancho-two")).
+		WithBuildDetails(model.DockerBuild{BuildPath: f.JoinPath("sancho-two")}).
 		WithDependencyIDs([]model.TargetID{baseImage.ID()})
 
 	sanchoOne := f.upsertManifest(manifestbuilder.New(f, "sancho-one").
@@ -229,10 +361,13 @@ func TestTwoK8sTargetsWithBaseImagePrebuilt(t *testing.T) {
 	f := newTestFixture(t)
 	defer f.TearDown()
 
-	baseImage := model.MustNewImageTarget(container.MustParseSelector("sancho-base"))
+	baseImage := model.MustNewImageTarget(container.MustParseSelector("sancho-base")).
+		WithBuildDetails(model.DockerBuild{BuildPath: f.JoinPath("sancho-base")})
 	sanchoOneImage := model.MustNewImageTarget(container.MustParseSelector("sancho-one")).
+		WithBuildDetails(model.DockerBuild{BuildPath: f.JoinPath("sancho-one")}).
 		WithDependencyIDs([]model.TargetID{baseImage.ID()})
 	sanchoTwoImage := model.MustNewImageTarget(container.MustParseSelector("sancho-two")).
+		WithBuildDetails(model.DockerBuild{BuildPath: f.JoinPath("sancho-two")}).
 		WithDependencyIDs([]model.TargetID{baseImage.ID()})
 
 	sanchoOne := f.upsertManifest(manifestbuilder.New(f, "sancho-one").
@@ -255,6 +390,76 @@ func TestTwoK8sTargetsWithBaseImagePrebuilt(t *testing.T) {
 	f.assertNextTargetToBuild("sancho-two")
 }
 
+func TestTwoK8sTargetsWithSharedDockerfile(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	df := "FROM golang AS builder\nRUN go build ./...\nFROM alpine AS runtime\n"
+	builderImage := model.MustNewImageTarget(container.MustParseSelector("sancho-builder")).
+		WithBuildDetails(model.DockerBuild{Dockerfile: df, BuildPath: f.Path(), TargetStage: "builder"})
+	runtimeImage := model.MustNewImageTarget(container.MustParseSelector("sancho-runtime")).
+		WithBuildDetails(model.DockerBuild{Dockerfile: df, BuildPath: f.Path(), TargetStage: "runtime"})
+	otherImage := model.MustNewImageTarget(container.MustParseSelector("sancho-other")).
+		WithBuildDetails(model.DockerBuild{Dockerfile: df, BuildPath: f.JoinPath("other")})
+
+	builder := f.upsertManifest(manifestbuilder.New(f, "builder").
+		WithImageTargets(builderImage).
+		WithK8sYAML(testyaml.SanchoYAML).
+		Build())
+	f.upsertManifest(manifestbuilder.New(f, "runtime").
+		WithImageTargets(runtimeImage).
+		WithK8sYAML(testyaml.SanchoYAML).
+		Build())
+	f.upsertManifest(manifestbuilder.New(f, "other").
+		WithImageTargets(otherImage).
+		WithK8sYAML(testyaml.SanchoYAML).
+		Build())
+
+	f.assertNextTargetToBuild("builder")
+
+	builder.State.CurrentBuild = model.BuildRecord{StartTime: time.Now()}
+
+	// A different context doesn't share the layer cache, so it doesn't wait.
+	f.assertNextTargetToBuild("other")
+	f.assertHold("runtime", store.HoldReasonWaitingForSharedDockerfile, builderImage.ID())
+
+	builder.State.CurrentBuild = model.BuildRecord{}
+	builder.State.AddCompletedBuild(model.BuildRecord{
+		StartTime:  time.Now(),
+		FinishTime: time.Now(),
+	})
+
+	f.assertNextTargetToBuild("runtime")
+}
+
+func TestSharedDockerfileDoesNotHoldReusableImage(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	df := "FROM golang AS builder\nRUN go build ./...\nFROM alpine AS runtime\n"
+	builderImage := model.MustNewImageTarget(container.MustParseSelector("sancho-builder")).
+		WithBuildDetails(model.DockerBuild{Dockerfile: df, BuildPath: f.Path(), TargetStage: "builder"})
+	runtimeImage := model.MustNewImageTarget(container.MustParseSelector("sancho-runtime")).
+		WithBuildDetails(model.DockerBuild{Dockerfile: df, BuildPath: f.Path(), TargetStage: "runtime"})
+
+	builder := f.upsertManifest(manifestbuilder.New(f, "builder").
+		WithImageTargets(builderImage).
+		WithK8sYAML(testyaml.SanchoYAML).
+		Build())
+	runtime := f.upsertManifest(manifestbuilder.New(f, "runtime").
+		WithImageTargets(runtimeImage).
+		WithK8sYAML(testyaml.SanchoYAML).
+		Build())
+
+	// The runtime image is already built, so its next deploy doesn't
+	// need the layer cache.
+	runtime.State.MutableBuildStatus(runtimeImage.ID()).LastResult = store.ImageBuildResult{}
+
+	f.assertNextTargetToBuild("builder")
+	builder.State.CurrentBuild = model.BuildRecord{StartTime: time.Now()}
+	f.assertNextTargetToBuild("runtime")
+}
+
 func TestHoldForDeploy(t *testing.T) {
 	f := newTestFixture(t)
 	defer f.TearDown()
@@ -341,6 +546,126 @@ func TestHoldDisabled(t *testing.T) {
 	f.assertNoTargetNextToBuild()
 }
 
+func TestHoldDisabledByConfigMap(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	// The ConfigMap says disabled before the UIResource catches up.
+	f.upsertLocalManifest("local")
+	f.st.ConfigMaps[configmap.DisableConfigMapName("local")] = &v1alpha1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: configmap.DisableConfigMapName("local")},
+		Data:       map[string]string{configmap.DisableKey: "true"},
+	}
+	f.assertHold("local", store.HoldReasonDisabled)
+	f.assertNoTargetNextToBuild()
+}
+
+func TestHoldPinned(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	sancho := f.upsertK8sManifest("sancho")
+	sancho.State.AddCompletedBuild(model.BuildRecord{StartTime: time.Now(), FinishTime: time.Now()})
+	f.setPinned("sancho", true)
+
+	// Changes accumulate while the resource is pinned.
+	targetID := sancho.Manifest.K8sTarget().ID()
+	sancho.State.AddPendingFileChange(targetID, f.JoinPath("a.yaml"), time.Now())
+	sancho.State.AddPendingFileChange(targetID, f.JoinPath("b.yaml"), time.Now())
+	f.assertHold("sancho", store.HoldReasonPinned)
+	f.assertNoTargetNextToBuild()
+
+	// Unpinning applies everything that was pending.
+	f.setPinned("sancho", false)
+	f.assertHold("sancho", store.HoldReasonNone)
+	f.assertNextTargetToBuild("sancho")
+	assert.Len(t, sancho.State.BuildStatus(targetID).PendingFileChanges, 2)
+}
+
+func TestHoldPinnedLeavesLiveUpdateToReconciler(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	luSpec := v1alpha1.LiveUpdateSpec{
+		BasePath: f.Path(),
+		Syncs:    []v1alpha1.LiveUpdateSync{{LocalPath: "src", ContainerPath: "/src"}},
+	}
+	sanchoImage := model.MustNewImageTarget(container.MustParseSelector("sancho")).
+		WithLiveUpdateSpec("sancho", luSpec).
+		WithBuildDetails(model.DockerBuild{BuildPath: f.Path()})
+	sancho := f.upsertManifest(manifestbuilder.New(f, "sancho").
+		WithImageTargets(sanchoImage).
+		WithK8sYAML(testyaml.SanchoYAML).
+		Build())
+	sancho.State.AddCompletedBuild(model.BuildRecord{StartTime: time.Now(), FinishTime: time.Now()})
+	f.st.KubernetesResources["sancho"] = &k8sconv.KubernetesResource{
+		FilteredPods: []v1alpha1.Pod{*readyPod("pod-1", sanchoImage.Refs.ClusterRef())},
+	}
+	f.setPinned("sancho", true)
+
+	// The build controller doesn't redeploy. The LiveUpdate reconciler
+	// doesn't look at pins, so it keeps syncing into the pinned pod.
+	sancho.State.AddPendingFileChange(sanchoImage.ID(), f.JoinPath("src", "a.txt"), time.Now())
+	f.assertHold("sancho", store.HoldReasonPinned)
+	f.assertNoTargetNextToBuild()
+
+	f.setPinned("sancho", false)
+	f.assertHold("sancho", store.HoldReconciling)
+	f.assertNoTargetNextToBuild()
+}
+
+func TestHoldForOutputsWithinOneChange(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	// Parallelizable, so that only the outputs hold the server back.
+	codegen := f.upsertLocalManifest("codegen", func(m manifestbuilder.ManifestBuilder) manifestbuilder.ManifestBuilder {
+		return m.WithLocalAllowParallel(true)
+	})
+	m := manifestbuilder.New(f, "server").WithK8sYAML(testyaml.SanchoYAML).Build()
+	m.OutputDependencies = []model.ManifestName{"codegen"}
+	server := f.upsertManifest(m)
+
+	for _, mt := range []*store.ManifestTarget{codegen, server} {
+		mt.State.AddCompletedBuild(model.BuildRecord{StartTime: time.Now(), FinishTime: time.Now()})
+	}
+
+	// A change to the proto source is an input to both. The server
+	// changed first, but has to wait for the codegen to update its outputs.
+	proto := f.JoinPath("api.proto")
+	server.State.AddPendingFileChange(server.Manifest.K8sTarget().ID(), proto, time.Now().Add(-time.Second))
+	codegen.State.AddPendingFileChange(codegen.Manifest.LocalTarget().ID(), proto, time.Now())
+
+	f.assertNextTargetToBuild("codegen")
+	f.assertHold("server", store.HoldReasonWaitingForOutputs, model.ManifestName("codegen").TargetID())
+
+	codegen.State.CurrentBuild = model.BuildRecord{StartTime: time.Now()}
+	codegen.State.MutableBuildStatus(codegen.Manifest.LocalTarget().ID()).ClearPendingChangesBefore(time.Now())
+	f.assertHold("server", store.HoldReasonWaitingForOutputs, model.ManifestName("codegen").TargetID())
+	f.assertNoTargetNextToBuild()
+
+	codegen.State.AddCompletedBuild(codegen.State.CurrentBuild)
+	codegen.State.CurrentBuild = model.BuildRecord{}
+	f.assertHold("server", store.HoldReasonNone)
+	f.assertNextTargetToBuild("server")
+}
+
+func TestHoldForOutputsOnInitialBuild(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+
+	m := manifestbuilder.New(f, "server").WithK8sYAML(testyaml.SanchoYAML).Build()
+	m.OutputDependencies = []model.ManifestName{"codegen"}
+	f.upsertManifest(m)
+	codegen := f.upsertLocalManifest("codegen")
+
+	f.assertNextTargetToBuild("codegen")
+	f.assertHold("server", store.HoldReasonWaitingForOutputs, model.ManifestName("codegen").TargetID())
+
+	codegen.State.AddCompletedBuild(model.BuildRecord{StartTime: time.Now(), FinishTime: time.Now()})
+	f.assertNextTargetToBuild("server")
+}
+
 func readyPod(podID k8s.PodID, ref reference.Named) *v1alpha1.Pod {
 	return &v1alpha1.Pod{
 		Name:   podID.String(),
@@ -477,6 +802,12 @@ func newTestFixture(t *testing.T) testFixture {
 	}
 }
 
+func (f *testFixture) setPinned(mn model.ManifestName, pinned bool) {
+	cm := pin.ToConfigMap(mn.String())
+	cm.Data[pin.PinnedKey] = fmt.Sprintf("%v", pinned)
+	f.st.ConfigMaps[cm.Name] = cm
+}
+
 func (f *testFixture) assertHold(m model.ManifestName, reason store.HoldReason, holdOn ...model.TargetID) {
 	f.T().Helper()
 	_, hs := NextTargetToBuild(*f.st)
@@ -503,6 +834,22 @@ func (f *testFixture) assertNoTargetNextToBuild() {
 	}
 }
 
+func (f *testFixture) startBuild(mt *store.ManifestTarget) {
+	mt.State.CurrentBuild = model.BuildRecord{StartTime: time.Now()}
+}
+
+func (f *testFixture) finishBuildAndReady(mt *store.ManifestTarget) {
+	mt.State.CurrentBuild = model.BuildRecord{}
+	mt.State.AddCompletedBuild(model.BuildRecord{
+		StartTime:  time.Now(),
+		FinishTime: time.Now(),
+	})
+	mt.State.RuntimeState = store.K8sRuntimeState{
+		PodReadinessMode:            model.PodReadinessIgnore,
+		HasEverDeployedSuccessfully: true,
+	}
+}
+
 func (f *testFixture) upsertManifest(m model.Manifest) *store.ManifestTarget {
 	mt := store.NewManifestTarget(m)
 	f.st.UpsertManifestTarget(mt)
@@ -522,7 +869,7 @@ func (f *testFixture) upsertLocalManifest(name model.ManifestName, opts ...manif
 	for _, o := range opts {
 		b = o(b)
 	}
-	return f.upsertManifest(b.WithLocalResource(fmt.Sprintf("exec-%s", name), nil).Build())
+	return f.upsertManifest(b.WithLocalResource(fmt.Sprintf("exec-%s", name), "", nil).Build())
 }
 
 func (f *testFixture) manifestNeedingCrashRebuild() *store.ManifestTarget {
@@ -2,6 +2,7 @@ package buildcontrol
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -74,3 +75,23 @@ func ShouldFallBackForErr(err error) bool {
 	}
 	return true
 }
+
+// The update ran longer than its build timeout, so Tilt canceled it.
+//
+// Unlike other cancellations, this is the resource's fault,
+// so it counts as a failed build.
+type BuildTimeoutError struct {
+	Timeout time.Duration
+
+	// What the update was doing when it timed out.
+	Phase string
+}
+
+func (e BuildTimeoutError) Error() string {
+	if e.Phase == "" {
+		return fmt.Sprintf("Timed out after %s", e.Timeout)
+	}
+	return fmt.Sprintf("Timed out after %s during %s", e.Timeout, e.Phase)
+}
+
+var _ error = BuildTimeoutError{}
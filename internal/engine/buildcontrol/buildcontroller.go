@@ -16,6 +16,8 @@ func BuildTargets(manifest model.Manifest) []model.TargetSpec {
 		result = append(result, manifest.K8sTarget())
 	} else if manifest.IsLocal() {
 		result = append(result, manifest.LocalTarget())
+	} else if manifest.IsSync() {
+		result = append(result, manifest.SyncTarget())
 	}
 
 	return result
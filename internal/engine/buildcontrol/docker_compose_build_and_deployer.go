@@ -117,7 +117,8 @@ func (bd *DockerComposeBuildAndDeployer) BuildAndDeploy(ctx context.Context, st
 		// NOTE(maia): we assume that this func takes one DC target and up to one image target
 		// corresponding to that service. If this func ever supports specs for more than one
 		// service at once, we'll have to match up image build results to DC target by ref.
-		refs, err := bd.ib.Build(ctx, iTarget, ps)
+		refs, buildStats, err := bd.ib.Build(ctx, iTarget, ps)
+		warnOnLargeBuildContext(ctx, iTarget, buildStats.Context, lastContextSize(currentState, iTarget.ID()), buildContextWarnSize(st))
 		if err != nil {
 			return store.ImageBuildResult{}, err
 		}
@@ -127,7 +128,11 @@ func (bd *DockerComposeBuildAndDeployer) BuildAndDeploy(ctx context.Context, st
 			return store.ImageBuildResult{}, err
 		}
 
-		return store.NewImageBuildResultSingleRef(iTarget.ID(), ref), nil
+		result := store.NewImageBuildResultSingleRef(iTarget.ID(), ref)
+		result.ContextSize = buildStats.Context.Size
+		result.CacheSteps = buildStats.CacheSteps
+		result.TargetStage = iTarget.DockerBuildInfo().TargetStage
+		return result, nil
 	})
 
 	newResults := q.NewResults().ToBuildResultSet()
@@ -137,6 +142,7 @@ func (bd *DockerComposeBuildAndDeployer) BuildAndDeploy(ctx context.Context, st
 
 	stdout := logger.Get(ctx).Writer(logger.InfoLvl)
 	stderr := logger.Get(ctx).Writer(logger.InfoLvl)
+	build.SetBuildPhase(ctx, "docker-compose up")
 	err = bd.dcc.Up(ctx, dcTarget.Spec, !haveImage, stdout, stderr)
 	if err != nil {
 		return newResults, err
@@ -10,6 +10,7 @@ import (
 
 	"github.com/tilt-dev/wmclient/pkg/dirs"
 
+	"github.com/tilt-dev/tilt/internal/build"
 	"github.com/tilt-dev/tilt/internal/container"
 	"github.com/tilt-dev/tilt/internal/docker"
 	"github.com/tilt-dev/tilt/internal/dockercompose"
@@ -27,7 +28,7 @@ func TestDockerComposeTargetBuilt(t *testing.T) {
 	expectedContainerID := "fake-container-id"
 	f.dcCli.ContainerIdOutput = container.ID(expectedContainerID)
 
-	manifest := manifestbuilder.New(f, "fe").WithDockerCompose().Build()
+	manifest := manifestbuilder.New(f, "fe").WithDockerCompose("fe", nil).Build()
 	dcTarg := manifest.DockerComposeTarget()
 
 	res, err := f.dcbad.BuildAndDeploy(f.ctx, f.st, BuildTargets(manifest), store.BuildStateSet{})
@@ -51,8 +52,8 @@ func TestTiltBuildsImage(t *testing.T) {
 
 	iTarget := NewSanchoDockerBuildImageTarget(f)
 	manifest := manifestbuilder.New(f, "fe").
-		WithDockerCompose().
-		WithImageTarget(iTarget).
+		WithDockerCompose("fe", nil).
+		WithImageTargets(iTarget).
 		Build()
 	dcTarg := manifest.DockerComposeTarget()
 
@@ -81,11 +82,9 @@ func TestTiltBuildsImageWithTag(t *testing.T) {
 	defer f.TearDown()
 
 	refWithTag := "gcr.io/foo:bar"
-	iTarget := model.MustNewImageTarget(container.MustParseSelector(refWithTag)).
-		WithBuildDetails(model.DockerBuild{})
 	manifest := manifestbuilder.New(f, "fe").
-		WithDockerCompose().
-		WithImageTarget(iTarget).
+		WithDockerCompose("fe", nil).
+		WithImageTarget(refWithTag, ".").
 		Build()
 
 	_, err := f.dcbad.BuildAndDeploy(f.ctx, f.st, BuildTargets(manifest), store.BuildStateSet{})
@@ -188,7 +187,7 @@ func newDCBDFixture(t *testing.T) *dcbdFixture {
 	// when testing the BuildAndDeployers.
 	dCli.ImageAlwaysExists = true
 
-	dcbad, err := ProvideDockerComposeBuildAndDeployer(ctx, dcCli, dCli, dir)
+	dcbad, err := ProvideDockerComposeBuildAndDeployer(ctx, dcCli, dCli, build.NewFakeContainerdClient(), dir)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -3,7 +3,10 @@ package buildcontrol
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -29,8 +32,15 @@ import (
 
 var _ BuildAndDeployer = &ImageBuildAndDeployer{}
 
+// The ImageMap status message when we skip a build because
+// an image with the same content tag already exists.
+const reusedTagMessage = "unchanged, reused tag"
+
 type KINDLoader interface {
 	LoadToKIND(ctx context.Context, ref reference.NamedTagged) error
+
+	// Loads an image tarball, for images that aren't in Docker.
+	LoadArchiveToKIND(ctx context.Context, path string) error
 }
 
 type cmdKINDLoader struct {
@@ -39,6 +49,14 @@ type cmdKINDLoader struct {
 }
 
 func (kl *cmdKINDLoader) LoadToKIND(ctx context.Context, ref reference.NamedTagged) error {
+	return kl.load(ctx, "docker-image", ref.String())
+}
+
+func (kl *cmdKINDLoader) LoadArchiveToKIND(ctx context.Context, path string) error {
+	return kl.load(ctx, "image-archive", path)
+}
+
+func (kl *cmdKINDLoader) load(ctx context.Context, source string, arg string) error {
 	// In Kind5, --name specifies the name of the cluster in the kubeconfig.
 	// In Kind6, the -name parameter is prefixed with 'kind-' before being written to/read from the kubeconfig
 	kindName := string(kl.clusterName)
@@ -46,7 +64,7 @@ func (kl *cmdKINDLoader) LoadToKIND(ctx context.Context, ref reference.NamedTagg
 		kindName = strings.TrimPrefix(kindName, "kind-")
 	}
 
-	cmd := exec.CommandContext(ctx, "kind", "load", "docker-image", ref.String(), "--name", kindName)
+	cmd := exec.CommandContext(ctx, "kind", "load", source, arg, "--name", kindName)
 	w := logger.NewMutexWriter(logger.Get(ctx).Writer(logger.InfoLvl))
 	cmd.Stdout = w
 	cmd.Stderr = w
@@ -63,6 +81,7 @@ func NewKINDLoader(env k8s.Env, clusterName k8s.ClusterName) KINDLoader {
 
 type ImageBuildAndDeployer struct {
 	db          build.DockerBuilder
+	ctrd        build.ContainerdClient
 	ib          *ImageBuilder
 	k8sClient   k8s.Client
 	env         k8s.Env
@@ -76,6 +95,7 @@ type ImageBuildAndDeployer struct {
 
 func NewImageBuildAndDeployer(
 	db build.DockerBuilder,
+	ctrd build.ContainerdClient,
 	customBuilder build.CustomBuilder,
 	k8sClient k8s.Client,
 	env k8s.Env,
@@ -88,6 +108,7 @@ func NewImageBuildAndDeployer(
 ) *ImageBuildAndDeployer {
 	return &ImageBuildAndDeployer{
 		db:          db,
+		ctrd:        ctrd,
 		ib:          NewImageBuilder(db, customBuilder),
 		k8sClient:   k8sClient,
 		env:         env,
@@ -107,6 +128,7 @@ func (ibd *ImageBuildAndDeployer) BuildAndDeploy(ctx context.Context, st store.R
 	}
 
 	kTarget := kTargets[0]
+	iTargets = ibd.withClusterPlatform(ctx, iTargets)
 
 	startTime := time.Now()
 	defer func() {
@@ -171,6 +193,10 @@ func (ibd *ImageBuildAndDeployer) BuildAndDeploy(ctx context.Context, st store.R
 		imageMapSet[nn] = im.DeepCopy()
 	}
 
+	// Whether every image we build turns out to be a content-tagged image that's
+	// already deployed. If so, there's nothing new to apply.
+	allBuildsAlreadyDeployed := q.CountBuilds() > 0
+
 	err = q.RunBuilds(func(target model.TargetSpec, depResults []store.ImageBuildResult) (store.ImageBuildResult, error) {
 		iTarget, ok := target.(model.ImageTarget)
 		if !ok {
@@ -189,22 +215,43 @@ func (ibd *ImageBuildAndDeployer) BuildAndDeploy(ctx context.Context, st store.R
 		// while an image build is going on in parallel.
 		startTime := apis.NowMicro()
 
-		refs, err := ibd.ib.Build(ctx, iTarget, ps)
+		refs, buildStats, err := ibd.ib.Build(ctx, iTarget, ps)
+		warnOnLargeBuildContext(ctx, iTarget, buildStats.Context, lastContextSize(stateSet, iTarget.ID()), buildContextWarnSize(st))
 		if err != nil {
 			return store.ImageBuildResult{}, err
 		}
 
-		err = ibd.push(ctx, refs.LocalRef, ps, iTarget, kTarget)
+		nn := types.NamespacedName{Name: iTarget.ImageMapName()}
+		im, ok := imageMapSet[nn]
+		if !ok {
+			return store.ImageBuildResult{}, fmt.Errorf("apiserver missing ImageMap: %s", iTarget.ID().Name)
+		}
+
+		alreadyDeployed := buildStats.ReusedContentTag && im.Status.Image == refs.ClusterRef.String()
+		if !alreadyDeployed {
+			allBuildsAlreadyDeployed = false
+		}
+
+		pushMode, pushReason, err := ibd.push(ctx, refs.LocalRef, ps, iTarget, kTarget, alreadyDeployed)
 		if err != nil {
 			return store.ImageBuildResult{}, err
 		}
 
 		result := store.NewImageBuildResult(iTarget.ID(), refs.LocalRef, refs.ClusterRef)
 		result.ImageMapStatus.BuildStartTime = &startTime
-		nn := types.NamespacedName{Name: iTarget.ImageMapName()}
-		im, ok := imageMapSet[nn]
-		if !ok {
-			return store.ImageBuildResult{}, fmt.Errorf("apiserver missing ImageMap: %s", iTarget.ID().Name)
+		result.ContextSize = buildStats.Context.Size
+		result.CacheSteps = buildStats.CacheSteps
+		result.TargetStage = iTarget.DockerBuildInfo().TargetStage
+		result.PushMode = pushMode
+		result.PushReason = pushReason
+		if buildStats.ReusedContentTag {
+			result.ImageMapStatus.Message = reusedTagMessage
+			if alreadyDeployed {
+				// The running containers still come from the original build, so keep
+				// its start time. Otherwise, live update would forget about file
+				// changes that the containers haven't seen.
+				result.ImageMapStatus.BuildStartTime = im.Status.BuildStartTime
+			}
 		}
 		im.Status = result.ImageMapStatus
 		err = ibd.ctrlClient.Status().Update(ctx, im)
@@ -220,6 +267,17 @@ func (ibd *ImageBuildAndDeployer) BuildAndDeploy(ctx context.Context, st store.R
 		return newResults, WrapDontFallBackError(err)
 	}
 
+	if allBuildsAlreadyDeployed && !hasDeleteStep {
+		k8sResult, upToDate, err := ibd.skipDeployIfUpToDate(ctx, ps, kTarget.ID(), kTarget.KubernetesApplySpec, imageMapSet)
+		if err != nil {
+			return newResults, WrapDontFallBackError(err)
+		}
+		if upToDate {
+			newResults[kTarget.ID()] = k8sResult
+			return newResults, nil
+		}
+	}
+
 	startDeployTime := time.Now()
 
 	// (If we pass an empty list of refs here (as we will do if only deploying
@@ -233,50 +291,133 @@ func (ibd *ImageBuildAndDeployer) BuildAndDeploy(ctx context.Context, st store.R
 	return newResults, nil
 }
 
-func (ibd *ImageBuildAndDeployer) push(ctx context.Context, ref reference.NamedTagged, ps *build.PipelineState, iTarget model.ImageTarget, kTarget model.K8sTarget) error {
+// Gets a built image to the cluster, and returns how we did it.
+func (ibd *ImageBuildAndDeployer) push(ctx context.Context, ref reference.NamedTagged, ps *build.PipelineState, iTarget model.ImageTarget, kTarget model.K8sTarget, alreadyDeployed bool) (model.ImagePushMode, string, error) {
 	ps.StartPipelineStep(ctx, "Pushing %s", container.FamiliarString(ref))
 	defer ps.EndPipelineStep(ctx)
 
+	if !iTarget.PushMode.IsAuto() {
+		ps.Printf(ctx, "Using push_mode=%q from the Tiltfile", iTarget.PushMode)
+	}
+
+	mode, reason, err := ibd.pushMode(ctx, iTarget, kTarget, alreadyDeployed)
+	if err != nil {
+		return "", "", err
+	}
+
+	usesContainerd := iTarget.IsCustomBuild() && iTarget.CustomBuildInfo().UsesContainerd()
+	switch mode {
+	case model.ImagePushModeSkip:
+		ps.Printf(ctx, "Skipping push: %s", reason)
+	case model.ImagePushModeLoad:
+		if usesContainerd {
+			err = ibd.loadFromContainerd(ctx, ref, ps)
+		} else {
+			ps.Printf(ctx, "Loading image to KIND")
+			err = ibd.kl.LoadToKIND(ps.AttachLogger(ctx), ref)
+			if err != nil {
+				err = fmt.Errorf("Error loading image to KIND: %v", err)
+			}
+		}
+	default:
+		if usesContainerd {
+			ps.Printf(ctx, "Pushing with containerd client")
+			err = ibd.ctrd.ImagePush(ps.AttachLogger(ctx), ref)
+		} else {
+			ps.Printf(ctx, "Pushing with Docker client")
+			err = ibd.db.PushImage(ps.AttachLogger(ctx), ref)
+		}
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return mode, reason, nil
+}
+
+// Decides how to get an image to the cluster. Honors the image's push_mode,
+// but returns an error if the cluster can't work that way.
+func (ibd *ImageBuildAndDeployer) pushMode(ctx context.Context, iTarget model.ImageTarget, kTarget model.K8sTarget, alreadyDeployed bool) (model.ImagePushMode, string, error) {
 	cbSkip := false
+	usesContainerd := false
 	if iTarget.IsCustomBuild() {
 		cbSkip = iTarget.CustomBuildInfo().SkipsPush()
+		usesContainerd = iTarget.CustomBuildInfo().UsesContainerd()
+	}
+	name := container.FamiliarString(iTarget.Refs.ConfigurationRef)
+
+	// Checks that apply no matter what the Tiltfile asked for.
+	if alreadyDeployed {
+		return model.ImagePushModeSkip, "image is already deployed", nil
+	} else if cbSkip {
+		return model.ImagePushModeSkip, "custom_build() configured to handle push itself", nil
+	}
+
+	switch iTarget.PushMode {
+	case model.ImagePushModePush:
+		return model.ImagePushModePush, "push_mode='push'", nil
+
+	case model.ImagePushModeLoad:
+		if !ibd.isKIND() {
+			return "", "", fmt.Errorf("Image %s has push_mode='load', but only KIND clusters can load images, "+
+				"and the current cluster is %s. Use push_mode='auto' or 'push'", name, ibd.env)
+		}
+		if iTarget.HasDistinctClusterRef() {
+			return "", "", fmt.Errorf("Image %s has push_mode='load', but the cluster pulls it from a registry as %s. "+
+				"Use push_mode='auto' or 'push'", name, container.FamiliarString(iTarget.Refs.ClusterRef()))
+		}
+		return model.ImagePushModeLoad, "push_mode='load'", nil
+
+	case model.ImagePushModeSkip:
+		// If we can't tell where a containerd image lives, trust the Tiltfile.
+		if IsImageDeployedToK8s(iTarget, kTarget) && !usesContainerd && !ibd.db.WillBuildToKubeContext(ibd.kubeContext) {
+			return "", "", fmt.Errorf("Image %s has push_mode='skip', but the cluster (%s) can't see images "+
+				"in the local Docker daemon. Use push_mode='auto', 'push', or 'load'", name, ibd.kubeContext)
+		}
+		return model.ImagePushModeSkip, "push_mode='skip'", nil
 	}
 
 	// We can also skip the push of the image if it isn't used
 	// in any k8s resources! (e.g., it's consumed by another image).
-
-	if cbSkip {
-		ps.Printf(ctx, "Skipping push: custom_build() configured to handle push itself")
-		return nil
-	} else if !IsImageDeployedToK8s(iTarget, kTarget) {
-		ps.Printf(ctx, "Skipping push: base image does not need deploy")
-		return nil
-	} else if ibd.db.WillBuildToKubeContext(ibd.kubeContext) {
-		ps.Printf(ctx, "Skipping push: building on cluster's container runtime")
-		return nil
+	if !IsImageDeployedToK8s(iTarget, kTarget) {
+		return model.ImagePushModeSkip, "base image does not need deploy", nil
+	} else if !usesContainerd && ibd.db.WillBuildToKubeContext(ibd.kubeContext) {
+		return model.ImagePushModeSkip, "building on cluster's container runtime", nil
 	}
 
-	var err error
 	if ibd.shouldUseKINDLoad(ctx, iTarget) {
-		ps.Printf(ctx, "Loading image to KIND")
-		err := ibd.kl.LoadToKIND(ps.AttachLogger(ctx), ref)
-		if err != nil {
-			return fmt.Errorf("Error loading image to KIND: %v", err)
-		}
-	} else {
-		ps.Printf(ctx, "Pushing with Docker client")
-		err = ibd.db.PushImage(ps.AttachLogger(ctx), ref)
-		if err != nil {
-			return err
-		}
+		return model.ImagePushModeLoad, "KIND cluster without a local registry", nil
 	}
+	return model.ImagePushModePush, "cluster pulls images from a registry", nil
+}
 
+// Images in containerd's image store aren't visible to the Docker client,
+// so we load them into KIND with the containerd client.
+func (ibd *ImageBuildAndDeployer) loadFromContainerd(ctx context.Context, ref reference.NamedTagged, ps *build.PipelineState) error {
+	ps.Printf(ctx, "Loading image to KIND from containerd")
+	dir, err := ioutil.TempDir("", "tilt-kind-load")
+	if err != nil {
+		return fmt.Errorf("Error loading image to KIND: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	archive := filepath.Join(dir, "image.tar")
+	err = ibd.ctrd.ImageSave(ps.AttachLogger(ctx), ref, archive)
+	if err != nil {
+		return fmt.Errorf("Error loading image to KIND: %v", err)
+	}
+	err = ibd.kl.LoadArchiveToKIND(ps.AttachLogger(ctx), archive)
+	if err != nil {
+		return fmt.Errorf("Error loading image to KIND: %v", err)
+	}
 	return nil
 }
 
+func (ibd *ImageBuildAndDeployer) isKIND() bool {
+	return ibd.env == k8s.EnvKIND5 || ibd.env == k8s.EnvKIND6
+}
+
 func (ibd *ImageBuildAndDeployer) shouldUseKINDLoad(ctx context.Context, iTarg model.ImageTarget) bool {
-	isKIND := ibd.env == k8s.EnvKIND5 || ibd.env == k8s.EnvKIND6
-	if !isKIND {
+	if !ibd.isKIND() {
 		return false
 	}
 
@@ -309,6 +450,7 @@ func (ibd *ImageBuildAndDeployer) deploy(
 	ps.StartBuildStep(ctx, "Injecting images into Kubernetes YAML")
 
 	kTargetNN := types.NamespacedName{Name: kTargetID.Name.String()}
+	build.SetBuildPhaseStep(ctx, "apply")
 	status, err := ibd.r.ForceApply(ctx, kTargetNN, spec, imageMaps)
 	if err != nil {
 		return store.K8sBuildResult{}, fmt.Errorf("applying %s: %v", kTargetID, err)
@@ -321,7 +463,37 @@ func (ibd *ImageBuildAndDeployer) deploy(
 	if err != nil {
 		return store.K8sBuildResult{}, err
 	}
-	return store.NewK8sDeployResult(kTargetID, filter), nil
+	result := store.NewK8sDeployResult(kTargetID, filter)
+	if diff, ok := ibd.r.LastApplyDiff(kTargetNN); ok {
+		result.ApplyDiff = &diff
+	}
+	return result, nil
+}
+
+// When all the images are already deployed, and the YAML hasn't changed since
+// the last apply, applying again wouldn't change anything. So reuse the last
+// apply instead.
+func (ibd *ImageBuildAndDeployer) skipDeployIfUpToDate(
+	ctx context.Context,
+	ps *build.PipelineState,
+	kTargetID model.TargetID,
+	spec v1alpha1.KubernetesApplySpec,
+	imageMaps map[types.NamespacedName]*v1alpha1.ImageMap) (store.K8sBuildResult, bool, error) {
+	kTargetNN := types.NamespacedName{Name: kTargetID.Name.String()}
+	status, upToDate, err := ibd.r.UpToDateStatus(ctx, kTargetNN, spec, imageMaps)
+	if err != nil || !upToDate {
+		return store.K8sBuildResult{}, false, err
+	}
+
+	ps.StartPipelineStep(ctx, "Deploying")
+	defer ps.EndPipelineStep(ctx)
+	ps.Printf(ctx, "Skipping deploy: images unchanged and already deployed")
+
+	filter, err := k8sconv.NewKubernetesApplyFilter(&status)
+	if err != nil {
+		return store.K8sBuildResult{}, false, err
+	}
+	return store.NewK8sDeployResult(kTargetID, filter), true, nil
 }
 
 // Delete all the resources in the Kubernetes target, to ensure that they restart when
@@ -21,6 +21,7 @@ import (
 	ktypes "k8s.io/apimachinery/pkg/types"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/tilt-dev/tilt/internal/build"
 	"github.com/tilt-dev/tilt/internal/container"
 	"github.com/tilt-dev/tilt/internal/controllers/fake"
 	"github.com/tilt-dev/tilt/internal/docker"
@@ -95,7 +96,7 @@ metadata:
   name: my-config
   namespace: my-namespace
 `).
-		WithImageTarget(NewSanchoDockerBuildImageTarget(f)).
+		WithImageTargets(NewSanchoDockerBuildImageTarget(f)).
 		Build()
 
 	iTargetID1 := m.ImageTargets[0].ID()
@@ -524,7 +525,7 @@ func TestCustomBuildSkipsLocalDocker(t *testing.T) {
 
 	manifest := manifestbuilder.New(f, "sancho").
 		WithK8sYAML(SanchoYAML).
-		WithImageTarget(model.MustNewImageTarget(SanchoRef).WithBuildDetails(cb)).
+		WithImageTargets(model.MustNewImageTarget(SanchoRef).WithBuildDetails(cb)).
 		Build()
 
 	_, err := f.BuildAndDeploy(BuildTargets(manifest), store.BuildStateSet{})
@@ -537,6 +538,155 @@ func TestCustomBuildSkipsLocalDocker(t *testing.T) {
 	assert.Equal(t, 0, f.docker.PushCount)
 }
 
+func TestCustomBuildContainerdKINDLoad(t *testing.T) {
+	f := newIBDFixture(t, k8s.EnvKIND6)
+	defer f.TearDown()
+	sha := digest.Digest("sha256:11cd0eb38bc3ceb958ffb2f9bd70be3fb317ce7d255c8a4c3f4af30e298aa1aab")
+	f.ctrd.Images["gcr.io/some-project-162817/sancho:tilt-build"] = sha
+
+	cb := model.CustomBuild{
+		Command:    model.ToHostCmd("exit 0"),
+		Deps:       []string{f.JoinPath("app")},
+		Tag:        "tilt-build",
+		ImageStore: model.ImageStoreContainerd,
+	}
+
+	manifest := manifestbuilder.New(f, "sancho").
+		WithK8sYAML(SanchoYAML).
+		WithImageTargets(model.MustNewImageTarget(SanchoRef).WithBuildDetails(cb)).
+		Build()
+
+	_, err := f.BuildAndDeploy(BuildTargets(manifest), store.BuildStateSet{})
+	require.NoError(t, err)
+
+	// We loaded the image from containerd, and never touched Docker.
+	assert.Equal(t, 1, f.kl.archiveLoadCount)
+	assert.Equal(t, 0, f.kl.loadCount)
+	assert.Equal(t, 0, f.docker.TagCount)
+	assert.Equal(t, 0, f.docker.PushCount)
+	if assert.Len(t, f.ctrd.SavedRefs, 1) {
+		assert.Equal(t, "gcr.io/some-project-162817/sancho:tilt-11cd0eb38bc3ceb9", f.ctrd.SavedRefs[0].String())
+	}
+}
+
+func TestCustomBuildContainerdPush(t *testing.T) {
+	f := newIBDFixture(t, k8s.EnvGKE)
+	defer f.TearDown()
+	sha := digest.Digest("sha256:11cd0eb38bc3ceb958ffb2f9bd70be3fb317ce7d255c8a4c3f4af30e298aa1aab")
+	f.ctrd.Images["gcr.io/some-project-162817/sancho:tilt-build"] = sha
+
+	cb := model.CustomBuild{
+		Command:    model.ToHostCmd("exit 0"),
+		Deps:       []string{f.JoinPath("app")},
+		Tag:        "tilt-build",
+		ImageStore: model.ImageStoreContainerd,
+	}
+
+	manifest := manifestbuilder.New(f, "sancho").
+		WithK8sYAML(SanchoYAML).
+		WithImageTargets(model.MustNewImageTarget(SanchoRef).WithBuildDetails(cb)).
+		Build()
+
+	_, err := f.BuildAndDeploy(BuildTargets(manifest), store.BuildStateSet{})
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, f.docker.PushCount)
+	if assert.Len(t, f.ctrd.PushedRefs, 1) {
+		assert.Equal(t, "gcr.io/some-project-162817/sancho:tilt-11cd0eb38bc3ceb9", f.ctrd.PushedRefs[0].String())
+	}
+}
+
+func TestPushMode(t *testing.T) {
+	type env struct {
+		name         string
+		k8sEnv       k8s.Env
+		sharedDaemon bool
+	}
+	kind := env{name: "kind", k8sEnv: k8s.EnvKIND6}
+	minikube := env{name: "minikube-shared-daemon", k8sEnv: k8s.EnvMinikube, sharedDaemon: true}
+	remote := env{name: "remote", k8sEnv: k8s.EnvGKE}
+
+	for _, tc := range []struct {
+		env      env
+		pushMode model.ImagePushMode
+		expected model.ImagePushMode
+		err      string
+	}{
+		{kind, model.ImagePushModeAuto, model.ImagePushModeLoad, ""},
+		{kind, model.ImagePushModePush, model.ImagePushModePush, ""},
+		{kind, model.ImagePushModeLoad, model.ImagePushModeLoad, ""},
+		{kind, model.ImagePushModeSkip, "", "can't see images in the local Docker daemon"},
+		{minikube, model.ImagePushModeAuto, model.ImagePushModeSkip, ""},
+		{minikube, model.ImagePushModePush, model.ImagePushModePush, ""},
+		{minikube, model.ImagePushModeLoad, "", "only KIND clusters can load images"},
+		{minikube, model.ImagePushModeSkip, model.ImagePushModeSkip, ""},
+		{remote, model.ImagePushModeAuto, model.ImagePushModePush, ""},
+		{remote, model.ImagePushModePush, model.ImagePushModePush, ""},
+		{remote, model.ImagePushModeLoad, "", "only KIND clusters can load images"},
+		{remote, model.ImagePushModeSkip, "", "can't see images in the local Docker daemon"},
+	} {
+		t.Run(fmt.Sprintf("%s-%s", tc.env.name, tc.pushMode), func(t *testing.T) {
+			f := newIBDFixture(t, tc.env.k8sEnv)
+			defer f.TearDown()
+			if tc.env.sharedDaemon {
+				f.docker.FakeEnv = docker.Env{BuildToKubeContexts: []string{fmt.Sprintf("%s-me", tc.env.k8sEnv)}}
+			}
+
+			manifest := NewSanchoDockerBuildManifest(f)
+			iTarget := manifest.ImageTargetAt(0)
+			iTarget.PushMode = tc.pushMode
+			manifest = manifest.WithImageTarget(iTarget)
+
+			result, err := f.BuildAndDeploy(BuildTargets(manifest), store.BuildStateSet{})
+			if tc.err != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.err)
+				}
+				assert.Equal(t, 0, f.docker.PushCount, "Docker push count")
+				assert.Equal(t, 0, f.kl.loadCount, "KIND load count")
+				return
+			}
+			require.NoError(t, err)
+
+			pushes := result.ImagePushes()
+			if assert.Len(t, pushes, 1) {
+				assert.Equal(t, tc.expected, pushes[0].Mode)
+			}
+
+			expectedPushes, expectedLoads := 0, 0
+			switch tc.expected {
+			case model.ImagePushModePush:
+				expectedPushes = 1
+			case model.ImagePushModeLoad:
+				expectedLoads = 1
+			}
+			assert.Equal(t, expectedPushes, f.docker.PushCount, "Docker push count")
+			assert.Equal(t, expectedLoads, f.kl.loadCount, "KIND load count")
+
+			if tc.pushMode != model.ImagePushModeAuto {
+				assert.Contains(t, f.out.String(), fmt.Sprintf("Using push_mode=%q from the Tiltfile", tc.pushMode))
+			}
+		})
+	}
+}
+
+func TestPushModeLoadWithLocalRegistry(t *testing.T) {
+	f := newIBDFixture(t, k8s.EnvKIND6)
+	defer f.TearDown()
+
+	manifest := NewSanchoDockerBuildManifest(f)
+	iTarg := manifest.ImageTargetAt(0)
+	iTarg.Refs = iTarg.Refs.MustWithRegistry(container.MustNewRegistryWithHostFromCluster("localhost:1234", "registry:1234"))
+	iTarg.PushMode = model.ImagePushModeLoad
+	manifest = manifest.WithImageTarget(iTarg)
+
+	_, err := f.BuildAndDeploy(BuildTargets(manifest), store.BuildStateSet{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "the cluster pulls it from a registry")
+	}
+	assert.Equal(t, 0, f.kl.loadCount)
+}
+
 func TestBuildAndDeployUsesCorrectRef(t *testing.T) {
 	expectedImages := []string{"foo.com/gcr.io_some-project-162817_sancho"}
 	expectedImagesClusterRef := []string{"registry:1234/gcr.io_some-project-162817_sancho"}
@@ -726,6 +876,51 @@ func TestDeployPodTemplateSpecHashChangesWhenImageChanges(t *testing.T) {
 	require.NotEqual(t, hash1, hash2)
 }
 
+func TestDeployRecordsApplyDiff(t *testing.T) {
+	f := newIBDFixture(t, k8s.EnvGKE)
+	defer f.TearDown()
+
+	manifest := NewSanchoDockerBuildManifest(f)
+	kTargetID := manifest.K8sTarget().ID()
+	result, err := f.BuildAndDeploy(BuildTargets(manifest), store.BuildStateSet{})
+	require.NoError(t, err)
+	assert.Nil(t, result.ApplyDiff())
+
+	f.docker.BuildOutput = docker.ExampleBuildOutput2
+	result, err = f.BuildAndDeploy(BuildTargets(manifest), store.BuildStateSet{})
+	require.NoError(t, err)
+
+	diff := result[kTargetID].(store.K8sBuildResult).ApplyDiff
+	require.NotNil(t, diff)
+	assert.Same(t, diff, result.ApplyDiff())
+	require.Len(t, diff.Objects, 1)
+	require.Len(t, diff.Objects[0].Fields, 1)
+	assert.Equal(t, "spec.template.spec.containers[0].image", diff.Objects[0].Fields[0].Path)
+	assert.Contains(t, f.out.String(), "Deployment/sancho: spec.template.spec.containers[0].image: gcr.io/some-project-162817/sancho:tilt-")
+}
+
+func TestBuildContextSizeWarning(t *testing.T) {
+	f := newIBDFixture(t, k8s.EnvGKE)
+	defer f.TearDown()
+
+	f.WriteFile("node_modules/dep/index.js", strings.Repeat("x", 5000))
+	f.WriteFile("main.go", "package main")
+	f.st.WithState(func(state *store.EngineState) {
+		state.UpdateSettings.BuildContextWarnSize = 4000
+	})
+
+	manifest := NewSanchoDockerBuildManifest(f)
+	iTargetID := manifest.ImageTargetAt(0).ID()
+	result, err := f.BuildAndDeploy(BuildTargets(manifest), store.BuildStateSet{})
+	require.NoError(t, err)
+
+	contextSize := result[iTargetID].(store.ImageBuildResult).ContextSize
+	assert.Equal(t, int64(f.docker.BuildContext.Len()), contextSize)
+	assert.Equal(t, contextSize, result.ContextSize())
+	assert.Contains(t, f.out.String(), "Build context for gcr.io/some-project-162817/sancho is")
+	assert.Contains(t, f.out.String(), "node_modules: 5kB")
+}
+
 func TestDeployInjectOverrideCommandClearsOldCommandButNotArgs(t *testing.T) {
 	f := newIBDFixture(t, k8s.EnvGKE)
 	defer f.TearDown()
@@ -807,7 +1002,7 @@ func TestCantInjectOverrideCommandWithoutContainer(t *testing.T) {
 		WithK8sYAML(crdYamlWithSanchoImage).
 		WithNamedJSONPathImageLocator("projects.example.martin-helmich.de",
 			"{.spec.validation.openAPIV3Schema.properties.spec.properties.image}").
-		WithImageTarget(NewSanchoDockerBuildImageTarget(f).WithOverrideCommand(cmd)).
+		WithImageTargets(NewSanchoDockerBuildImageTarget(f).WithOverrideCommand(cmd)).
 		Build()
 
 	_, err := f.BuildAndDeploy(BuildTargets(manifest), store.BuildStateSet{})
@@ -888,11 +1083,217 @@ func TestDockerBuildTargetStage(t *testing.T) {
 		WithK8sYAML(testyaml.SanchoYAML).
 		WithImageTargets(iTarget).
 		Build()
-	_, err := f.BuildAndDeploy(BuildTargets(manifest), store.BuildStateSet{})
+	result, err := f.BuildAndDeploy(BuildTargets(manifest), store.BuildStateSet{})
 	if err != nil {
 		t.Fatal(err)
 	}
 	assert.Equal(t, "stage", f.docker.BuildOptions.Target)
+	assert.Equal(t, model.DockerBuildTarget("stage"), result[iTarget.ID()].(store.ImageBuildResult).TargetStage)
+	assert.Contains(t, f.out.String(), "Building Dockerfile (target: stage):")
+}
+
+func TestDockerBuildForClusterPlatform(t *testing.T) {
+	f := newIBDFixture(t, k8s.EnvGKE)
+	defer f.TearDown()
+
+	f.docker.FakeServerVersion = types.Version{Os: "linux", Arch: "arm64"}
+	f.docker.FakeBuilderVersion = types.BuilderBuildKit
+	f.k8s.Platforms = []string{"linux/amd64"}
+
+	_, err := f.BuildAndDeploy(BuildTargets(NewSanchoDockerBuildManifest(f)), store.BuildStateSet{})
+	require.NoError(t, err)
+	assert.Equal(t, "linux/amd64", f.docker.BuildOptions.Platform)
+	assert.Contains(t, f.out.String(),
+		"Building gcr.io/some-project-162817/sancho for linux/amd64 to match the cluster's nodes (Docker builds for linux/arm64 by default)")
+}
+
+func TestDockerBuildForClusterPlatformWithoutBuildKit(t *testing.T) {
+	f := newIBDFixture(t, k8s.EnvGKE)
+	defer f.TearDown()
+
+	f.docker.FakeServerVersion = types.Version{Os: "linux", Arch: "arm64"}
+	f.k8s.Platforms = []string{"linux/amd64"}
+
+	_, err := f.BuildAndDeploy(BuildTargets(NewSanchoDockerBuildManifest(f)), store.BuildStateSet{})
+	require.NoError(t, err)
+	assert.Equal(t, "", f.docker.BuildOptions.Platform)
+	assert.Contains(t, f.out.String(),
+		"Image gcr.io/some-project-162817/sancho will be built for linux/arm64, but the cluster's nodes run linux/amd64")
+	assert.Contains(t, f.out.String(), "exec format error")
+}
+
+func TestDockerBuildMatchesClusterPlatform(t *testing.T) {
+	f := newIBDFixture(t, k8s.EnvGKE)
+	defer f.TearDown()
+
+	f.docker.FakeServerVersion = types.Version{Os: "linux", Arch: "arm64"}
+	f.docker.FakeBuilderVersion = types.BuilderBuildKit
+	f.k8s.Platforms = []string{"linux/amd64", "linux/arm64"}
+
+	_, err := f.BuildAndDeploy(BuildTargets(NewSanchoDockerBuildManifest(f)), store.BuildStateSet{})
+	require.NoError(t, err)
+	assert.Equal(t, "", f.docker.BuildOptions.Platform)
+	assert.NotContains(t, f.out.String(), "exec format error")
+}
+
+func TestDockerBuildKeepsTiltfilePlatform(t *testing.T) {
+	f := newIBDFixture(t, k8s.EnvGKE)
+	defer f.TearDown()
+
+	f.docker.FakeServerVersion = types.Version{Os: "linux", Arch: "arm64"}
+	f.docker.FakeBuilderVersion = types.BuilderBuildKit
+	f.k8s.Platforms = []string{"linux/amd64"}
+
+	iTarget := NewSanchoDockerBuildImageTarget(f)
+	db := iTarget.BuildDetails.(model.DockerBuild)
+	db.Platform = "linux/arm64"
+	iTarget.BuildDetails = db
+
+	manifest := manifestbuilder.New(f, "sancho").
+		WithK8sYAML(testyaml.SanchoYAML).
+		WithImageTargets(iTarget).
+		Build()
+	_, err := f.BuildAndDeploy(BuildTargets(manifest), store.BuildStateSet{})
+	require.NoError(t, err)
+	assert.Equal(t, "linux/arm64", f.docker.BuildOptions.Platform)
+}
+
+func TestReusedImageMustMatchPlatform(t *testing.T) {
+	f := newIBDFixture(t, k8s.EnvGKE)
+	defer f.TearDown()
+
+	f.docker.FakeServerVersion = types.Version{Os: "linux", Arch: "arm64"}
+	f.docker.FakeBuilderVersion = types.BuilderBuildKit
+	f.k8s.Platforms = []string{"linux/amd64"}
+
+	manifest := NewSanchoDockerBuildManifest(f)
+	iTargetID := manifest.ImageTargetAt(0).ID()
+	result, err := f.BuildAndDeploy(BuildTargets(manifest), store.BuildStateSet{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, f.docker.BuildCount)
+
+	// The image from the last build was built for the wrong platform,
+	// so we shouldn't reuse it.
+	ref := store.LocalImageRefFromBuildResult(result[iTargetID])
+	f.docker.ImageAlwaysExists = false
+	f.docker.Images[ref.String()] = types.ImageInspect{Os: "linux", Architecture: "arm64"}
+
+	stateSet := store.BuildStateSet{iTargetID: store.NewBuildState(result[iTargetID], nil, nil)}
+	_, err = f.BuildAndDeploy(BuildTargets(manifest), stateSet)
+	require.NoError(t, err)
+	assert.Equal(t, 2, f.docker.BuildCount)
+
+	f.docker.Images[ref.String()] = types.ImageInspect{Os: "linux", Architecture: "amd64"}
+	_, err = f.BuildAndDeploy(BuildTargets(manifest), stateSet)
+	require.NoError(t, err)
+	assert.Equal(t, 2, f.docker.BuildCount)
+}
+
+func TestContentTagSkipsBuildAndDeploy(t *testing.T) {
+	f := newIBDFixture(t, k8s.EnvGKE)
+	defer f.TearDown()
+
+	f.docker.ImageAlwaysExists = false
+	f.WriteFile("main.go", "package main")
+	manifest := newSanchoContentTagManifest(f)
+	iTargetID := manifest.ImageTargetAt(0).ID()
+
+	result, err := f.BuildAndDeploy(BuildTargets(manifest), store.BuildStateSet{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, f.docker.BuildCount)
+
+	ref := store.ClusterImageRefFromBuildResult(result[iTargetID])
+	assert.True(t, strings.HasPrefix(ref.Tag(), "tilt-content-"), ref.String())
+	assert.Contains(t, f.docker.BuildOptions.ExtraTags, ref.String())
+	assert.Contains(t, f.k8s.Yaml, ref.String())
+
+	// The file changed, but its contents are the same, and the image is still around.
+	f.docker.Images[ref.String()] = types.ImageInspect{}
+	f.k8s.Yaml = ""
+	stateSet := f.resultsToNextState(result)
+	stateSet[iTargetID] = store.NewBuildState(result[iTargetID], []string{f.JoinPath("main.go")}, nil)
+	result, err = f.BuildAndDeploy(BuildTargets(manifest), stateSet)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, f.docker.BuildCount)
+	assert.Equal(t, "", f.k8s.Yaml, "should not re-apply the YAML")
+	assert.Equal(t, ref.String(), store.ClusterImageRefFromBuildResult(result[iTargetID]).String())
+	assert.Contains(t, f.out.String(), "Skipping push: image is already deployed")
+	assert.Contains(t, f.out.String(), "Skipping deploy: images unchanged and already deployed")
+
+	var im v1alpha1.ImageMap
+	require.NoError(t, f.ctrlClient.Get(f.ctx, ktypes.NamespacedName{Name: iTargetID.Name.String()}, &im))
+	assert.Equal(t, ref.String(), im.Status.Image)
+	assert.Equal(t, "unchanged, reused tag", im.Status.Message)
+}
+
+func TestContentTagRebuildsWhenContextChanges(t *testing.T) {
+	f := newIBDFixture(t, k8s.EnvGKE)
+	defer f.TearDown()
+
+	f.docker.ImageAlwaysExists = false
+	f.WriteFile("main.go", "package main")
+	manifest := newSanchoContentTagManifest(f)
+	iTargetID := manifest.ImageTargetAt(0).ID()
+
+	result, err := f.BuildAndDeploy(BuildTargets(manifest), store.BuildStateSet{})
+	require.NoError(t, err)
+	ref := store.ClusterImageRefFromBuildResult(result[iTargetID])
+	f.docker.Images[ref.String()] = types.ImageInspect{}
+
+	f.WriteFile("main.go", "package main\n\nfunc main() {}")
+	f.k8s.Yaml = ""
+	stateSet := f.resultsToNextState(result)
+	stateSet[iTargetID] = store.NewBuildState(result[iTargetID], []string{f.JoinPath("main.go")}, nil)
+	result, err = f.BuildAndDeploy(BuildTargets(manifest), stateSet)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, f.docker.BuildCount)
+	newRef := store.ClusterImageRefFromBuildResult(result[iTargetID])
+	assert.NotEqual(t, ref.String(), newRef.String())
+	assert.Contains(t, f.k8s.Yaml, newRef.String())
+}
+
+func TestContentTagReusedImageIsDeployed(t *testing.T) {
+	f := newIBDFixture(t, k8s.EnvGKE)
+	defer f.TearDown()
+
+	f.docker.ImageAlwaysExists = false
+	f.WriteFile("main.go", "package main")
+	manifest := newSanchoContentTagManifest(f)
+	iTargetID := manifest.ImageTargetAt(0).ID()
+
+	result, err := f.BuildAndDeploy(BuildTargets(manifest), store.BuildStateSet{})
+	require.NoError(t, err)
+	ref := store.ClusterImageRefFromBuildResult(result[iTargetID])
+
+	// A new session finds the image from the last one, but hasn't deployed it yet.
+	f = newIBDFixture(t, k8s.EnvGKE)
+	defer f.TearDown()
+	f.docker.ImageAlwaysExists = false
+	f.docker.Images[ref.String()] = types.ImageInspect{}
+	f.WriteFile("main.go", "package main")
+	manifest = newSanchoContentTagManifest(f)
+
+	result, err = f.BuildAndDeploy(BuildTargets(manifest), store.BuildStateSet{})
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, f.docker.BuildCount)
+	assert.Equal(t, ref.String(), store.ClusterImageRefFromBuildResult(result[iTargetID]).String())
+	assert.Contains(t, f.k8s.Yaml, ref.String())
+	assert.Equal(t, "unchanged, reused tag", result[iTargetID].(store.ImageBuildResult).ImageMapStatus.Message)
+}
+
+func newSanchoContentTagManifest(f *ibdFixture) model.Manifest {
+	iTarget := model.MustNewImageTarget(SanchoRef).WithBuildDetails(model.DockerBuild{
+		Dockerfile: SanchoDockerfile,
+		BuildPath:  f.Path(),
+		ContentTag: true,
+	})
+	return manifestbuilder.New(f, "sancho").
+		WithK8sYAML(SanchoYAML).
+		WithImageTargets(iTarget).
+		Build()
 }
 
 func TestTwoManifestsWithCommonImage(t *testing.T) {
@@ -996,6 +1397,7 @@ type ibdFixture struct {
 	ibd        *ImageBuildAndDeployer
 	st         *store.TestingStore
 	kl         *fakeKINDLoader
+	ctrd       *build.FakeContainerdClient
 	ctrlClient ctrlclient.Client
 }
 
@@ -1020,7 +1422,8 @@ func newIBDFixture(t *testing.T, env k8s.Env) *ibdFixture {
 	ctrlClient := fake.NewFakeTiltClient()
 	st := store.NewTestingStore()
 	execer := localexec.NewFakeExecer(t)
-	ibd, err := ProvideImageBuildAndDeployer(ctx, dockerClient, kClient, env, kubeContext,
+	ctrd := build.NewFakeContainerdClient()
+	ibd, err := ProvideImageBuildAndDeployer(ctx, dockerClient, ctrd, kClient, env, kubeContext,
 		clusterEnv, dir, clock, kl, ta, ctrlClient, st, execer)
 	if err != nil {
 		t.Fatal(err)
@@ -1034,6 +1437,7 @@ func newIBDFixture(t *testing.T, env k8s.Env) *ibdFixture {
 		ibd:            ibd,
 		st:             st,
 		kl:             kl,
+		ctrd:           ctrd,
 		ctrlClient:     ctrlClient,
 	}
 }
@@ -1116,7 +1520,8 @@ status: {}`, name, name)
 }
 
 type fakeKINDLoader struct {
-	loadCount int
+	loadCount        int
+	archiveLoadCount int
 }
 
 func (kl *fakeKINDLoader) LoadToKIND(ctx context.Context, ref reference.NamedTagged) error {
@@ -1124,6 +1529,11 @@ func (kl *fakeKINDLoader) LoadToKIND(ctx context.Context, ref reference.NamedTag
 	return nil
 }
 
+func (kl *fakeKINDLoader) LoadArchiveToKIND(ctx context.Context, path string) error {
+	kl.archiveLoadCount++
+	return nil
+}
+
 type fakeClock struct {
 	now time.Time
 }
@@ -65,10 +65,13 @@ func NewImageBuilder(db build.DockerBuilder, custb build.CustomBuilder) *ImageBu
 }
 
 func (icb *ImageBuilder) CanReuseRef(ctx context.Context, iTarget model.ImageTarget, ref reference.NamedTagged) (bool, error) {
-	switch iTarget.BuildDetails.(type) {
+	switch bd := iTarget.BuildDetails.(type) {
 	case model.DockerBuild:
-		return icb.db.ImageExists(ctx, ref)
+		return icb.db.ImageExists(ctx, ref, bd.Platform)
 	case model.CustomBuild:
+		if bd.ContentTag {
+			return icb.db.ImageExists(ctx, ref, bd.Platform)
+		}
 		// Custom build doesn't have a good way to check if the ref still exists in the image
 		// store, so just assume we can.
 		return true, nil
@@ -77,13 +80,15 @@ func (icb *ImageBuilder) CanReuseRef(ctx context.Context, iTarget model.ImageTar
 		"DockerBuild nor CustomBuild)", iTarget.Refs.ConfigurationRef)
 }
 
+// Builds the image. For Dockerfile builds, also returns the size of the
+// build context, even if the build failed, and how the build used the cache.
 func (icb *ImageBuilder) Build(ctx context.Context, iTarget model.ImageTarget,
-	ps *build.PipelineState) (refs container.TaggedRefs, err error) {
+	ps *build.PipelineState) (refs container.TaggedRefs, buildStats build.BuildStats, err error) {
 	userFacingRefName := container.FamiliarString(iTarget.Refs.ConfigurationRef)
 	startTime := time.Now()
 	ctx, err = tag.New(ctx, tag.Upsert(KeyImageRef, userFacingRefName))
 	if err != nil {
-		return container.TaggedRefs{}, err
+		return container.TaggedRefs{}, build.BuildStats{}, err
 	}
 
 	defer func() {
@@ -105,25 +110,79 @@ func (icb *ImageBuilder) Build(ctx context.Context, iTarget model.ImageTarget,
 		ps.StartPipelineStep(ctx, "Building Dockerfile: [%s]", userFacingRefName)
 		defer ps.EndPipelineStep(ctx)
 
-		refs, err = icb.db.BuildImage(ctx, ps, iTarget.Refs, bd,
-			ignore.CreateBuildContextFilter(iTarget))
+		filter := ignore.CreateBuildContextFilter(iTarget)
+		var contentRefs container.TaggedRefs
+		if bd.ContentTag {
+			var reused bool
+			contentRefs, reused, err = icb.contentTaggedRefs(ctx, ps, iTarget.Refs, bd, bd.Platform, filter)
+			if err != nil {
+				return container.TaggedRefs{}, build.BuildStats{}, err
+			}
+			if reused {
+				return contentRefs, build.BuildStats{ReusedContentTag: true}, nil
+			}
+			bd.ExtraTags = append(append([]string{}, bd.ExtraTags...), contentRefs.LocalRef.String())
+		}
 
+		refs, buildStats, err = icb.db.BuildImage(ctx, ps, iTarget.Refs, bd, filter)
 		if err != nil {
-			return container.TaggedRefs{}, err
+			return container.TaggedRefs{}, buildStats, err
+		}
+
+		if bd.ContentTag {
+			refs = contentRefs
 		}
 	case model.CustomBuild:
 		ps.StartPipelineStep(ctx, "Building Custom Build: [%s]", userFacingRefName)
 		defer ps.EndPipelineStep(ctx)
+
+		if bd.ContentTag {
+			contentRefs, reused, err := icb.contentTaggedRefs(ctx, ps, iTarget.Refs, bd, bd.Platform,
+				ignore.CreateBuildContextFilter(iTarget))
+			if err != nil {
+				return container.TaggedRefs{}, build.BuildStats{}, err
+			}
+			if reused {
+				return contentRefs, build.BuildStats{ReusedContentTag: true}, nil
+			}
+			bd = bd.WithTag(contentRefs.LocalRef.Tag())
+		}
+
 		refs, err = icb.custb.Build(ctx, iTarget.Refs, bd)
 		if err != nil {
-			return container.TaggedRefs{}, err
+			return container.TaggedRefs{}, build.BuildStats{}, err
 		}
 	default:
 		// Theoretically this should never trip b/c we `validate` the manifest beforehand...?
 		// If we get here, something is very wrong.
-		return container.TaggedRefs{}, fmt.Errorf("image %q has no valid buildDetails (neither "+
+		return container.TaggedRefs{}, build.BuildStats{}, fmt.Errorf("image %q has no valid buildDetails (neither "+
 			"DockerBuild nor CustomBuild)", iTarget.Refs.ConfigurationRef)
 	}
 
-	return refs, nil
+	return refs, buildStats, nil
+}
+
+// Computes the content tag for the build, and whether an image with that
+// tag already exists. If it does, building again would produce the same image.
+func (icb *ImageBuilder) contentTaggedRefs(ctx context.Context, ps *build.PipelineState, refs container.RefSet,
+	bd model.BuildDetails, platform string, filter model.PathMatcher) (container.TaggedRefs, bool, error) {
+	tag, err := build.ContentTag(ctx, bd, filter)
+	if err != nil {
+		return container.TaggedRefs{}, false, err
+	}
+
+	tagged, err := refs.AddTagSuffix(tag)
+	if err != nil {
+		return container.TaggedRefs{}, false, err
+	}
+
+	exists, err := icb.db.ImageExists(ctx, tagged.LocalRef, platform)
+	if err != nil {
+		return container.TaggedRefs{}, false, err
+	}
+	if exists {
+		ps.Printf(ctx, "Skipping build: found %s, built from the same inputs",
+			container.FamiliarString(tagged.LocalRef))
+	}
+	return tagged, exists, nil
 }
@@ -353,7 +353,7 @@ func TestSkipLiveUpdateIfForceUpdate(t *testing.T) {
 	m := manifestbuilder.New(f, "sancho").
 		WithK8sYAML(SanchoYAML).
 		WithLiveUpdateBAD().
-		WithImageTarget(NewSanchoLiveUpdateImageTarget(f)).
+		WithImageTargets(NewSanchoLiveUpdateImageTarget(f)).
 		Build()
 
 	container := liveupdates.Container{
@@ -63,6 +63,7 @@ func (bd *LocalTargetBuildAndDeployer) BuildAndDeploy(ctx context.Context, st st
 		return store.BuildResultSet{}, DontFallBackErrorf("Loading command: %v", err)
 	}
 
+	build.SetBuildPhase(ctx, "local_resource command %q", model.ArgListToString(cmd.Spec.Args))
 	status, err := bd.cmds.ForceRun(ctx, &cmd)
 	if err != nil {
 		// (Never fall back from the LocalTargetBaD, none of our other BaDs can handle this target)
@@ -0,0 +1,90 @@
+package buildcontrol
+
+import (
+	"context"
+	"strings"
+
+	"github.com/tilt-dev/tilt/internal/build"
+	"github.com/tilt-dev/tilt/internal/container"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// Picks the platform to build each image for, so that images built on one
+// architecture (like an Apple Silicon laptop) can run on a cluster of another.
+func (ibd *ImageBuildAndDeployer) withClusterPlatform(ctx context.Context, iTargets []model.ImageTarget) []model.ImageTarget {
+	return withClusterPlatform(ctx, iTargets,
+		ibd.db.DefaultPlatform(), ibd.db.CanCrossBuild(), ibd.k8sClient.NodePlatforms(ctx))
+}
+
+// If the cluster's nodes can't run images built for the builder's default
+// platform, Dockerfile builds ask for the cluster's platform instead. If the
+// builder can't do that, we warn, because the pods will crash with
+// "exec format error" and nothing else will explain why.
+//
+// Custom builds always learn the cluster's platform from $EXPECTED_PLATFORM,
+// since Tilt can't tell what their build command does with it.
+//
+// Builds that ask for a platform in the Tiltfile keep it.
+func withClusterPlatform(ctx context.Context, iTargets []model.ImageTarget,
+	defaultPlatform string, canCrossBuild bool, clusterPlatforms []string) []model.ImageTarget {
+	platform, mismatch := choosePlatform(defaultPlatform, clusterPlatforms)
+	if platform == "" {
+		return iTargets
+	}
+
+	l := logger.Get(ctx)
+	result := make([]model.ImageTarget, 0, len(iTargets))
+	for _, iTarget := range iTargets {
+		switch bd := iTarget.BuildDetails.(type) {
+		case model.DockerBuild:
+			if !mismatch || bd.Platform != "" {
+				break
+			}
+
+			name := container.FamiliarString(iTarget.Refs.ConfigurationRef)
+			if !canCrossBuild {
+				l.Warnf("Image %s will be built for %s, but the cluster's nodes run %s.\n"+
+					"Pods that use it will likely crash with \"exec format error\".\n"+
+					"Building for another platform needs BuildKit. Enable it with DOCKER_BUILDKIT=1, "+
+					"or build on a machine that matches the cluster.",
+					name, defaultPlatform, strings.Join(clusterPlatforms, ", "))
+				break
+			}
+
+			l.Infof("Building %s for %s to match the cluster's nodes (Docker builds for %s by default)",
+				name, platform, defaultPlatform)
+			bd.Platform = platform
+			iTarget = iTarget.WithBuildDetails(bd)
+		case model.CustomBuild:
+			bd.Platform = platform
+			iTarget = iTarget.WithBuildDetails(bd)
+		}
+		result = append(result, iTarget)
+	}
+	return result
+}
+
+// Returns the cluster platform to build for, and whether it differs from the
+// builder's default platform.
+//
+// Returns an empty platform if we don't know what the nodes run.
+func choosePlatform(defaultPlatform string, clusterPlatforms []string) (string, bool) {
+	if len(clusterPlatforms) == 0 {
+		return "", false
+	}
+
+	if defaultPlatform == "" {
+		return clusterPlatforms[0], false
+	}
+
+	parts := strings.Split(defaultPlatform, "/")
+	if len(parts) >= 2 {
+		for _, p := range clusterPlatforms {
+			if build.PlatformMatches(p, parts[0], parts[1]) {
+				return p, false
+			}
+		}
+	}
+	return clusterPlatforms[0], true
+}
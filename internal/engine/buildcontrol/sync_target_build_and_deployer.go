@@ -0,0 +1,254 @@
+package buildcontrol
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/tilt-dev/tilt/internal/build"
+	"github.com/tilt-dev/tilt/internal/container"
+	"github.com/tilt-dev/tilt/internal/containerupdate"
+	"github.com/tilt-dev/tilt/internal/ignore"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/store/liveupdates"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+var _ BuildAndDeployer = &SyncTargetBuildAndDeployer{}
+
+// Runs only the sync step of a live update: copies changed files into
+// a pod that Tilt doesn't manage, or into a local directory.
+type SyncTargetBuildAndDeployer struct {
+	kCli  k8s.Client
+	cu    *containerupdate.ExecUpdater
+	clock build.Clock
+}
+
+func NewSyncTargetBuildAndDeployer(kCli k8s.Client, cu *containerupdate.ExecUpdater, c build.Clock) *SyncTargetBuildAndDeployer {
+	return &SyncTargetBuildAndDeployer{
+		kCli:  kCli,
+		cu:    cu,
+		clock: c,
+	}
+}
+
+func (bd *SyncTargetBuildAndDeployer) BuildAndDeploy(ctx context.Context, st store.RStore, specs []model.TargetSpec, stateSet store.BuildStateSet) (resultSet store.BuildResultSet, err error) {
+	targets := bd.extract(specs)
+	if len(targets) != 1 {
+		return store.BuildResultSet{}, SilentRedirectToNextBuilderf(
+			"SyncTargetBuildAndDeployer requires exactly one SyncTarget (got %d)", len(targets))
+	}
+
+	targ := targets[0]
+	state := stateSet[targ.ID()]
+
+	ps := build.NewPipelineState(ctx, 1, bd.clock)
+	defer func() { ps.End(ctx, err) }()
+
+	ps.StartPipelineStep(ctx, "Syncing files")
+	defer ps.EndPipelineStep(ctx)
+
+	// Sync everything on the first build, or when the user asks for a full update.
+	// Otherwise, only sync the files that changed.
+	var toSync []build.PathMapping
+	filesChanged := state.FilesChanged()
+	if state.LastResult == nil || state.FullBuildTriggered || len(filesChanged) == 0 {
+		toSync = build.SyncsToPathMappings(targ.Syncs)
+	} else {
+		toSync, _, err = build.FilesToPathMappings(filesChanged, targ.Syncs)
+		if err != nil {
+			return store.BuildResultSet{}, DontFallBackErrorf("Mapping paths: %v", err)
+		}
+	}
+
+	filter, err := ignore.CreateFileChangeFilter(targ)
+	if err != nil {
+		return store.BuildResultSet{}, DontFallBackErrorf("Reading ignores: %v", err)
+	}
+
+	toRemove, toCopy, err := build.MissingLocalPaths(ctx, toSync)
+	if err != nil {
+		return store.BuildResultSet{}, DontFallBackErrorf("Mapping paths: %v", err)
+	}
+
+	result := store.NewSyncBuildResult(targ.ID())
+	if targ.IsLocalDest() {
+		err = syncToLocalDir(ctx, toCopy, toRemove, filter)
+	} else {
+		result.PodID, err = bd.syncToPod(ctx, targ, toCopy, toRemove, filter)
+	}
+	if err != nil {
+		return store.BuildResultSet{}, DontFallBackErrorf("Syncing files: %v", err)
+	}
+	result.PathsCopied = len(toCopy)
+	result.PathsDeleted = len(toRemove)
+
+	return store.BuildResultSet{targ.ID(): result}, nil
+}
+
+func (bd *SyncTargetBuildAndDeployer) syncToPod(ctx context.Context, targ model.SyncTarget,
+	toCopy, toRemove []build.PathMapping, filter model.PathMatcher) (k8s.PodID, error) {
+	ns := k8s.Namespace(targ.Namespace)
+	if ns.Empty() {
+		ns = k8s.DefaultNamespace
+	}
+
+	podID, err := bd.findPod(ctx, ns, targ.PodSelector)
+	if err != nil {
+		return "", err
+	}
+
+	l := logger.Get(ctx)
+	l.Infof("Syncing %d path(s) and deleting %d path(s) in pod %s", len(toCopy), len(toRemove), podID)
+	for _, pm := range toRemove {
+		l.Infof("- Deleting '%s' (matched local path: '%s')", pm.ContainerPath, pm.LocalPath)
+	}
+	for _, pm := range toCopy {
+		l.Infof("- %s", pm.PrettyStr())
+	}
+
+	cInfo := liveupdates.Container{
+		PodID:         podID,
+		ContainerName: container.Name(targ.Container),
+		Namespace:     ns,
+	}
+	archive := build.TarArchiveForPaths(ctx, toCopy, filter)
+	err = bd.cu.UpdateContainer(ctx, cInfo, archive,
+		build.PathMappingsToContainerPaths(toRemove), nil, true)
+	if err != nil {
+		return "", fmt.Errorf("updating pod %s: %v", podID, err)
+	}
+	return podID, nil
+}
+
+// Finds the newest pod that matches the selector.
+func (bd *SyncTargetBuildAndDeployer) findPod(ctx context.Context, ns k8s.Namespace, selector map[string]string) (k8s.PodID, error) {
+	metas, err := bd.kCli.ListMeta(ctx, v1.SchemeGroupVersion.WithKind("Pod"), ns)
+	if err != nil {
+		return "", fmt.Errorf("listing pods: %v", err)
+	}
+
+	sel := labels.SelectorFromSet(selector)
+	var matches []metav1.Object
+	for _, m := range metas {
+		if m.GetDeletionTimestamp() != nil || !sel.Matches(labels.Set(m.GetLabels())) {
+			continue
+		}
+		matches = append(matches, m)
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no pods in namespace %q match selector %q", ns, sel.String())
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		ti := matches[i].GetCreationTimestamp()
+		tj := matches[j].GetCreationTimestamp()
+		if !ti.Equal(&tj) {
+			return tj.Before(&ti)
+		}
+		return matches[i].GetName() < matches[j].GetName()
+	})
+	return k8s.PodID(matches[0].GetName()), nil
+}
+
+// Copies files into local directories, and deletes the destinations of
+// files that no longer exist.
+func syncToLocalDir(ctx context.Context, toCopy, toRemove []build.PathMapping, filter model.PathMatcher) error {
+	l := logger.Get(ctx)
+	for _, pm := range toRemove {
+		l.Infof("- Deleting '%s' (matched local path: '%s')", pm.ContainerPath, pm.LocalPath)
+		err := os.RemoveAll(pm.ContainerPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, pm := range toCopy {
+		err := filepath.WalkDir(pm.LocalPath, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if filter != nil {
+				matches, err := filter.Matches(path)
+				if err != nil {
+					return err
+				}
+				if matches {
+					if d.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+			}
+
+			rel, err := filepath.Rel(pm.LocalPath, path)
+			if err != nil {
+				return err
+			}
+			dest := filepath.Join(pm.ContainerPath, rel)
+			if d.IsDir() {
+				return os.MkdirAll(dest, 0755)
+			}
+
+			l.Infof("- %s", build.PathMapping{LocalPath: path, ContainerPath: dest}.PrettyStr())
+			return copyFile(path, dest)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(src, dest string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(dest), 0755)
+	if err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Extract the targets we can sync -- i.e. SyncTargets
+func (bd *SyncTargetBuildAndDeployer) extract(specs []model.TargetSpec) []model.SyncTarget {
+	var targs []model.SyncTarget
+	for _, s := range specs {
+		switch s := s.(type) {
+		case model.SyncTarget:
+			targs = append(targs, s)
+		}
+	}
+	return targs
+}
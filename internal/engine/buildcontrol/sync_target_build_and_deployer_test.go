@@ -0,0 +1,179 @@
+package buildcontrol
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tilt-dev/tilt/internal/containerupdate"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/testutils"
+	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestSyncToLocalDir(t *testing.T) {
+	f := newSTFixture(t)
+	defer f.TearDown()
+
+	f.WriteFile("src/a.txt", "a")
+	f.WriteFile("src/nested/b.txt", "b")
+	f.WriteFile("src/ignored.log", "log")
+	targ := model.NewSyncTarget("config", []model.Sync{
+		{LocalPath: f.JoinPath("src"), ContainerPath: f.JoinPath("dest")},
+	}).WithIgnores([]model.Dockerignore{{LocalPath: f.JoinPath("src"), Patterns: []string{"*.log"}}})
+
+	res, err := f.stbad.BuildAndDeploy(f.ctx, f.st, []model.TargetSpec{targ}, store.BuildStateSet{})
+	require.NoError(t, err)
+
+	f.assertFileContents("dest/a.txt", "a")
+	f.assertFileContents("dest/nested/b.txt", "b")
+	assert.NoFileExists(t, f.JoinPath("dest/ignored.log"))
+	assert.Equal(t, 1, res[targ.ID()].(store.SyncBuildResult).PathsCopied)
+}
+
+func TestSyncToLocalDirDeletes(t *testing.T) {
+	f := newSTFixture(t)
+	defer f.TearDown()
+
+	f.WriteFile("src/a.txt", "a")
+	f.WriteFile("src/b.txt", "b")
+	targ := model.NewSyncTarget("config", []model.Sync{
+		{LocalPath: f.JoinPath("src"), ContainerPath: f.JoinPath("dest")},
+	})
+
+	res, err := f.stbad.BuildAndDeploy(f.ctx, f.st, []model.TargetSpec{targ}, store.BuildStateSet{})
+	require.NoError(t, err)
+	f.assertFileContents("dest/b.txt", "b")
+
+	f.Rm("src/b.txt")
+	f.WriteFile("src/a.txt", "a2")
+	state := store.NewBuildState(res[targ.ID()], []string{f.JoinPath("src/a.txt"), f.JoinPath("src/b.txt")}, nil)
+	res, err = f.stbad.BuildAndDeploy(f.ctx, f.st, []model.TargetSpec{targ}, store.BuildStateSet{targ.ID(): state})
+	require.NoError(t, err)
+
+	f.assertFileContents("dest/a.txt", "a2")
+	assert.NoFileExists(t, f.JoinPath("dest/b.txt"))
+	result := res[targ.ID()].(store.SyncBuildResult)
+	assert.Equal(t, 1, result.PathsCopied)
+	assert.Equal(t, 1, result.PathsDeleted)
+}
+
+func TestSyncToPod(t *testing.T) {
+	f := newSTFixture(t)
+	defer f.TearDown()
+
+	f.injectPod("shared-old", "shared", time.Unix(1, 0))
+	f.injectPod("shared-new", "shared", time.Unix(2, 0))
+	f.injectPod("other", "other", time.Unix(3, 0))
+
+	f.WriteFile("src/a.txt", "a")
+	targ := model.NewSyncTarget("config", []model.Sync{
+		{LocalPath: f.JoinPath("src"), ContainerPath: "/etc/config"},
+	}).WithPodSelector(map[string]string{"app": "shared"}, "main", "")
+
+	res, err := f.stbad.BuildAndDeploy(f.ctx, f.st, []model.TargetSpec{targ}, store.BuildStateSet{})
+	require.NoError(t, err)
+	assert.Equal(t, k8s.PodID("shared-new"), res[targ.ID()].(store.SyncBuildResult).PodID)
+
+	require.Len(t, f.kCli.ExecCalls, 1)
+	call := f.kCli.ExecCalls[0]
+	assert.Equal(t, k8s.PodID("shared-new"), call.PID)
+	assert.Equal(t, "main", call.CName.String())
+	assert.Equal(t, k8s.DefaultNamespace, call.Ns)
+	testutils.AssertFileInTar(t, tar.NewReader(bytes.NewReader(call.Stdin)), testutils.ExpectedFile{
+		Path:     "etc/config/a.txt",
+		Contents: "a",
+	})
+}
+
+func TestSyncToPodDeletes(t *testing.T) {
+	f := newSTFixture(t)
+	defer f.TearDown()
+
+	f.injectPod("shared", "shared", time.Unix(1, 0))
+	f.MkdirAll("src")
+	targ := model.NewSyncTarget("config", []model.Sync{
+		{LocalPath: f.JoinPath("src"), ContainerPath: "/etc/config"},
+	}).WithPodSelector(map[string]string{"app": "shared"}, "", "")
+
+	lastResult := store.NewSyncBuildResult(targ.ID())
+	state := store.NewBuildState(lastResult, []string{f.JoinPath("src/gone.txt")}, nil)
+	_, err := f.stbad.BuildAndDeploy(f.ctx, f.st, []model.TargetSpec{targ}, store.BuildStateSet{targ.ID(): state})
+	require.NoError(t, err)
+
+	require.Len(t, f.kCli.ExecCalls, 2)
+	assert.Equal(t, []string{"rm", "-rf", "/etc/config/gone.txt"}, f.kCli.ExecCalls[0].Cmd)
+}
+
+func TestSyncToPodNoMatchingPod(t *testing.T) {
+	f := newSTFixture(t)
+	defer f.TearDown()
+
+	f.injectPod("other", "other", time.Unix(1, 0))
+	f.MkdirAll("src")
+	targ := model.NewSyncTarget("config", []model.Sync{
+		{LocalPath: f.JoinPath("src"), ContainerPath: "/etc/config"},
+	}).WithPodSelector(map[string]string{"app": "shared"}, "", "config-ns")
+
+	_, err := f.stbad.BuildAndDeploy(f.ctx, f.st, []model.TargetSpec{targ}, store.BuildStateSet{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `no pods in namespace "config-ns" match selector "app=shared"`)
+	}
+}
+
+type stFixture struct {
+	*tempdir.TempDirFixture
+
+	ctx   context.Context
+	kCli  *k8s.FakeK8sClient
+	stbad *SyncTargetBuildAndDeployer
+	st    *testStore
+}
+
+func newSTFixture(t *testing.T) *stFixture {
+	f := tempdir.NewTempDirFixture(t)
+
+	out := new(bytes.Buffer)
+	ctx, _, _ := testutils.ForkedCtxAndAnalyticsForTest(out)
+	clock := fakeClock{time.Date(2019, 1, 1, 1, 1, 1, 1, time.UTC)}
+	kCli := k8s.NewFakeK8sClient(t)
+
+	return &stFixture{
+		TempDirFixture: f,
+		ctx:            ctx,
+		kCli:           kCli,
+		stbad:          NewSyncTargetBuildAndDeployer(kCli, containerupdate.NewExecUpdater(kCli), clock),
+		st:             NewTestingStore(out),
+	}
+}
+
+func (f *stFixture) injectPod(name string, app string, createdAt time.Time) {
+	pod := &v1.Pod{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         k8s.DefaultNamespace.String(),
+			UID:               types.UID(name + "-uid"),
+			Labels:            map[string]string{"app": app},
+			CreationTimestamp: metav1.NewTime(createdAt),
+		},
+	}
+	f.kCli.Inject(k8s.NewK8sEntity(pod))
+}
+
+func (f *stFixture) assertFileContents(path string, expected string) {
+	contents, err := ioutil.ReadFile(f.JoinPath(path))
+	require.NoError(f.T(), err)
+	assert.Equal(f.T(), expected, string(contents))
+}
@@ -64,7 +64,7 @@ func NewSanchoCustomBuildImageTargetWithTag(fixture Fixture, tag string) model.I
 func NewSanchoCustomBuildManifestWithTag(fixture Fixture, tag string) model.Manifest {
 	return manifestbuilder.New(fixture, "sancho").
 		WithK8sYAML(SanchoYAML).
-		WithImageTarget(NewSanchoCustomBuildImageTargetWithTag(fixture, tag)).
+		WithImageTargets(NewSanchoCustomBuildImageTargetWithTag(fixture, tag)).
 		Build()
 }
 
@@ -78,7 +78,7 @@ func NewSanchoCustomBuildManifestWithPushDisabled(fixture Fixture) model.Manifes
 
 	return manifestbuilder.New(fixture, "sancho").
 		WithK8sYAML(SanchoYAML).
-		WithImageTarget(model.MustNewImageTarget(SanchoRef).WithBuildDetails(cb)).
+		WithImageTargets(model.MustNewImageTarget(SanchoRef).WithBuildDetails(cb)).
 		Build()
 }
 
@@ -123,7 +123,7 @@ func NewSanchoDockerBuildManifest(f Fixture) model.Manifest {
 func NewSanchoDockerBuildManifestWithYaml(f Fixture, yaml string) model.Manifest {
 	return manifestbuilder.New(f, "sancho").
 		WithK8sYAML(yaml).
-		WithImageTarget(NewSanchoDockerBuildImageTarget(f)).
+		WithImageTargets(NewSanchoDockerBuildImageTarget(f)).
 		Build()
 }
 
@@ -42,8 +42,10 @@ var BaseWireSet = wire.NewSet(
 	NewImageBuildAndDeployer,
 	NewLiveUpdateBuildAndDeployer,
 	NewLocalTargetBuildAndDeployer,
+	NewSyncTargetBuildAndDeployer,
 	containerupdate.NewDockerUpdater,
 	containerupdate.NewExecUpdater,
+	containerupdate.NewAgentUpdater,
 	NewImageBuilder,
 
 	tracer.InitOpenTelemetry,
@@ -54,6 +56,7 @@ var BaseWireSet = wire.NewSet(
 func ProvideImageBuildAndDeployer(
 	ctx context.Context,
 	docker docker.Client,
+	ctrd build.ContainerdClient,
 	kClient k8s.Client,
 	env k8s.Env,
 	kubeContext k8s.KubeContext,
@@ -68,6 +71,7 @@ func ProvideImageBuildAndDeployer(
 	wire.Build(
 		BaseWireSet,
 		kubernetesapply.NewReconciler,
+		wire.Value(kubernetesapply.VerboseApplyFlag(false)),
 		provideFakeK8sNamespace,
 	)
 
@@ -82,6 +86,7 @@ func ProvideDockerComposeBuildAndDeployer(
 	ctx context.Context,
 	dcCli dockercompose.DockerComposeClient,
 	dCli docker.Client,
+	ctrd build.ContainerdClient,
 	dir *dirs.TiltDevDir) (*DockerComposeBuildAndDeployer, error) {
 	wire.Build(
 		BaseWireSet,
@@ -29,28 +29,30 @@ import (
 
 // Injectors from wire.go:
 
-func ProvideImageBuildAndDeployer(ctx context.Context, docker2 docker.Client, kClient k8s.Client, env k8s.Env, kubeContext k8s.KubeContext, clusterEnv docker.ClusterEnv, dir *dirs.TiltDevDir, clock build.Clock, kp KINDLoader, analytics2 *analytics.TiltAnalytics, ctrlclient client.Client, st store.RStore, execer localexec.Execer) (*ImageBuildAndDeployer, error) {
+func ProvideImageBuildAndDeployer(ctx context.Context, docker2 docker.Client, ctrd build.ContainerdClient, kClient k8s.Client, env k8s.Env, kubeContext k8s.KubeContext, clusterEnv docker.ClusterEnv, dir *dirs.TiltDevDir, clock build.Clock, kp KINDLoader, analytics2 *analytics.TiltAnalytics, ctrlclient client.Client, st store.RStore, execer localexec.Execer) (*ImageBuildAndDeployer, error) {
 	labels := _wireLabelsValue
 	dockerImageBuilder := build.NewDockerImageBuilder(docker2, labels)
 	dockerBuilder := build.DefaultDockerBuilder(dockerImageBuilder)
-	execCustomBuilder := build.NewExecCustomBuilder(docker2, clock)
+	execCustomBuilder := build.NewExecCustomBuilder(docker2, ctrd, clock)
 	scheme := v1alpha1.NewScheme()
 	namespace := provideFakeK8sNamespace()
-	reconciler := kubernetesapply.NewReconciler(ctrlclient, kClient, scheme, dockerBuilder, kubeContext, st, namespace, execer)
-	imageBuildAndDeployer := NewImageBuildAndDeployer(dockerBuilder, execCustomBuilder, kClient, env, kubeContext, analytics2, clock, kp, ctrlclient, reconciler)
+	verboseApplyFlag := _wireVerboseApplyFlagValue
+	reconciler := kubernetesapply.NewReconciler(ctrlclient, kClient, scheme, dockerBuilder, kubeContext, st, namespace, execer, verboseApplyFlag)
+	imageBuildAndDeployer := NewImageBuildAndDeployer(dockerBuilder, ctrd, execCustomBuilder, kClient, env, kubeContext, analytics2, clock, kp, ctrlclient, reconciler)
 	return imageBuildAndDeployer, nil
 }
 
 var (
-	_wireLabelsValue = dockerfile.Labels{}
+	_wireLabelsValue           = dockerfile.Labels{}
+	_wireVerboseApplyFlagValue = kubernetesapply.VerboseApplyFlag(false)
 )
 
-func ProvideDockerComposeBuildAndDeployer(ctx context.Context, dcCli dockercompose.DockerComposeClient, dCli docker.Client, dir *dirs.TiltDevDir) (*DockerComposeBuildAndDeployer, error) {
+func ProvideDockerComposeBuildAndDeployer(ctx context.Context, dcCli dockercompose.DockerComposeClient, dCli docker.Client, ctrd build.ContainerdClient, dir *dirs.TiltDevDir) (*DockerComposeBuildAndDeployer, error) {
 	labels := _wireLabelsValue
 	dockerImageBuilder := build.NewDockerImageBuilder(dCli, labels)
 	dockerBuilder := build.DefaultDockerBuilder(dockerImageBuilder)
 	clock := build.ProvideClock()
-	execCustomBuilder := build.NewExecCustomBuilder(dCli, clock)
+	execCustomBuilder := build.NewExecCustomBuilder(dCli, ctrd, clock)
 	imageBuilder := NewImageBuilder(dockerBuilder, execCustomBuilder)
 	dockerComposeBuildAndDeployer := NewDockerComposeBuildAndDeployer(dcCli, dCli, imageBuilder, clock)
 	return dockerComposeBuildAndDeployer, nil
@@ -61,7 +63,8 @@ func ProvideDockerComposeBuildAndDeployer(ctx context.Context, dcCli dockercompo
 var BaseWireSet = wire.NewSet(wire.Value(dockerfile.Labels{}), v1alpha1.NewScheme, k8s.ProvideMinikubeClient, build.DefaultDockerBuilder, build.NewDockerImageBuilder, build.NewExecCustomBuilder, wire.Bind(new(build.CustomBuilder), new(*build.ExecCustomBuilder)), wire.Bind(new(build.DockerKubeConnection), new(build.DockerBuilder)), NewDockerComposeBuildAndDeployer,
 	NewImageBuildAndDeployer,
 	NewLiveUpdateBuildAndDeployer,
-	NewLocalTargetBuildAndDeployer, containerupdate.NewDockerUpdater, containerupdate.NewExecUpdater, NewImageBuilder, tracer.InitOpenTelemetry, liveupdates.ProvideUpdateMode,
+	NewLocalTargetBuildAndDeployer,
+	NewSyncTargetBuildAndDeployer, containerupdate.NewDockerUpdater, containerupdate.NewExecUpdater, containerupdate.NewAgentUpdater, NewImageBuilder, tracer.InitOpenTelemetry, liveupdates.ProvideUpdateMode,
 )
 
 func provideFakeK8sNamespace() k8s.Namespace {
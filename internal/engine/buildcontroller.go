@@ -2,11 +2,13 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"sync"
 	"time"
 
+	"github.com/tilt-dev/tilt/internal/build"
 	"github.com/tilt-dev/tilt/internal/engine/buildcontrol"
 	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/internal/store/buildcontrols"
@@ -34,6 +36,9 @@ type buildEntry struct {
 	filesChanged  []string
 	buildReason   model.BuildReason
 	spanID        logstore.SpanID
+
+	// 0 means no timeout.
+	buildTimeout time.Duration
 }
 
 func (e buildEntry) Name() model.ManifestName       { return e.name }
@@ -83,6 +88,7 @@ func (c *BuildController) needsBuild(ctx context.Context, st store.RStore) (buil
 		buildStateSet: buildStateSet,
 		filesChanged:  append(ms.ConfigFilesThatCausedChange, buildStateSet.FilesChanged()...),
 		spanID:        SpanIDForBuildLog(c.buildsStartedCount),
+		buildTimeout:  state.UpdateSettings.BuildTimeoutFor(manifest),
 	}, true
 }
 
@@ -119,6 +125,13 @@ func (c *BuildController) OnChange(ctx context.Context, st store.RStore, summary
 		ctx = c.buildContext(ctx, entry, st)
 		defer c.cleanupBuildContext(entry.name)
 
+		ctx, phase := build.WithBuildPhase(ctx)
+		if entry.buildTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, entry.buildTimeout)
+			defer cancel()
+		}
+
 		buildcontrols.LogBuildEntry(ctx, buildcontrols.BuildEntry{
 			Name:         entry.Name(),
 			BuildReason:  entry.BuildReason(),
@@ -126,6 +139,15 @@ func (c *BuildController) OnChange(ctx context.Context, st store.RStore, summary
 		})
 
 		result, err := c.buildAndDeploy(ctx, st, entry)
+		if err != nil && ctx.Err() != nil {
+			// Once the build is canceled, whatever error it returned is fallout
+			// from the cancellation (e.g., a closed connection to the daemon),
+			// so report the cancellation itself.
+			err = ctx.Err()
+		}
+		if errors.Is(err, context.DeadlineExceeded) && entry.buildTimeout > 0 {
+			err = buildcontrol.BuildTimeoutError{Timeout: entry.buildTimeout, Phase: phase.String()}
+		}
 		st.Dispatch(buildcontrols.NewBuildCompleteAction(entry.name, entry.spanID, result, err))
 	}()
 
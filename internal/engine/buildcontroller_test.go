@@ -61,7 +61,7 @@ func TestBuildControllerTooManyPodsForLiveUpdateErrorMessage(t *testing.T) {
 	manifest := manifestbuilder.New(f, "sancho").
 		WithK8sYAML(SanchoYAML).
 		WithLiveUpdateBAD().
-		WithImageTarget(NewSanchoLiveUpdateImageTarget(f)).
+		WithImageTargets(NewSanchoLiveUpdateImageTarget(f)).
 		Build()
 	// basePB is used for all pods so that they share the same deployment
 	basePB := f.registerForDeployer(manifest)
@@ -151,16 +151,11 @@ func TestBuildControllerIgnoresImageTags(t *testing.T) {
 	f := newTestFixture(t)
 	defer f.TearDown()
 
-	ref := container.MustParseNamed("image-foo:tagged")
-	refSel := container.NewRefSelector(ref)
-
-	iTarget := NewSanchoLiveUpdateImageTarget(f)
-	iTarget = iTarget.MustWithRef(refSel)
-
 	manifest := manifestbuilder.New(f, "fe").
 		WithK8sYAML(SanchoYAML).
 		WithLiveUpdateBAD().
-		WithImageTarget(iTarget).
+		WithImageTarget("image-foo:tagged", ".").
+		WithLiveUpdate(SanchoSyncSteps(f), SanchoRunSteps, nil).
 		Build()
 	basePB := f.registerForDeployer(manifest)
 	f.Start([]model.Manifest{manifest})
@@ -219,7 +214,7 @@ func TestBuildControllerLocalResource(t *testing.T) {
 
 	dep := f.JoinPath("stuff.json")
 	manifest := manifestbuilder.New(f, "local").
-		WithLocalResource("echo beep boop", []string{dep}).
+		WithLocalResource("echo beep boop", "", []string{dep}).
 		Build()
 	f.Start([]model.Manifest{manifest})
 
@@ -392,7 +387,7 @@ func TestBuildControllerCrashRebuild(t *testing.T) {
 	manifest := manifestbuilder.New(f, "fe").
 		WithK8sYAML(SanchoYAML).
 		WithLiveUpdateBAD().
-		WithImageTarget(NewSanchoLiveUpdateImageTarget(f)).
+		WithImageTargets(NewSanchoLiveUpdateImageTarget(f)).
 		Build()
 	basePB := f.registerForDeployer(manifest)
 	f.Start([]model.Manifest{manifest})
@@ -446,7 +441,7 @@ func TestCrashRebuildTwoContainersOneImage(t *testing.T) {
 	manifest := manifestbuilder.New(f, "sancho").
 		WithK8sYAML(testyaml.SanchoTwoContainersOneImageYAML).
 		WithLiveUpdateBAD().
-		WithImageTarget(NewSanchoLiveUpdateImageTarget(f)).
+		WithImageTargets(NewSanchoLiveUpdateImageTarget(f)).
 		Build()
 	// basePB is used for all pods so that they share the same deployment
 	basePB := f.registerForDeployer(manifest)
@@ -498,8 +493,8 @@ func TestCrashRebuildTwoContainersTwoImages(t *testing.T) {
 	manifest := manifestbuilder.New(f, "sancho").
 		WithK8sYAML(testyaml.SanchoTwoContainersOneImageYAML).
 		WithLiveUpdateBAD().
-		WithImageTarget(NewSanchoLiveUpdateImageTarget(f)).
-		WithImageTarget(NewSanchoSidecarLiveUpdateImageTarget(f)).
+		WithImageTargets(NewSanchoLiveUpdateImageTarget(f)).
+		WithImageTargets(NewSanchoSidecarLiveUpdateImageTarget(f)).
 		Build()
 	// basePB is used for all pods so that they share the same deployment
 	basePB := f.registerForDeployer(manifest)
@@ -550,7 +545,7 @@ func TestRecordLiveUpdatedContainerIDsForFailedLiveUpdate(t *testing.T) {
 
 	manifest := manifestbuilder.New(f, "sancho").
 		WithK8sYAML(testyaml.SanchoTwoContainersOneImageYAML).
-		WithImageTarget(NewSanchoLiveUpdateImageTarget(f)).
+		WithImageTargets(NewSanchoLiveUpdateImageTarget(f)).
 		WithLiveUpdateBAD().
 		Build()
 	basePB := f.registerForDeployer(manifest)
@@ -994,8 +989,8 @@ func TestBuildControllerRespectDockerComposeOrder(t *testing.T) {
 	defer f.TearDown()
 
 	sancho := NewSanchoLiveUpdateDCManifest(f)
-	redis := manifestbuilder.New(f, "redis").WithDockerCompose().Build()
-	donQuixote := manifestbuilder.New(f, "don-quixote").WithDockerCompose().Build()
+	redis := manifestbuilder.New(f, "redis").WithDockerCompose("redis", nil).Build()
+	donQuixote := manifestbuilder.New(f, "don-quixote").WithDockerCompose("don-quixote", nil).Build()
 	manifests := []model.Manifest{redis, sancho, donQuixote}
 	f.Start(manifests)
 
@@ -1028,10 +1023,10 @@ func TestBuildControllerLocalResourcesBeforeClusterResources(t *testing.T) {
 		manifestbuilder.New(f, "clusterUnbuilt").
 			WithK8sYAML(SanchoYAML).Build(),
 		manifestbuilder.New(f, "local1").
-			WithLocalResource("echo local1", nil).Build(),
+			WithLocalResource("echo local1", "", nil).Build(),
 		f.newManifest("clusterBuilt3"),
 		manifestbuilder.New(f, "local2").
-			WithLocalResource("echo local2", nil).Build(),
+			WithLocalResource("echo local2", "", nil).Build(),
 	}
 
 	manifests = append(manifests, manifestbuilder.New(f, model.UnresourcedYAMLManifestName).
@@ -1125,7 +1120,7 @@ func TestBuildControllerResourceDepTrumpsLocalResourcePriority(t *testing.T) {
 	k8sManifest := f.newManifest("foo")
 	pb := f.registerForDeployer(k8sManifest)
 	localManifest := manifestbuilder.New(f, "bar").
-		WithLocalResource("echo bar", nil).
+		WithLocalResource("echo bar", "", nil).
 		WithResourceDeps("foo").Build()
 	manifests := []model.Manifest{localManifest, k8sManifest}
 	f.Start(manifests)
@@ -1152,10 +1147,10 @@ func TestBuildControllerResourceDepTrumpsInitialBuild(t *testing.T) {
 	defer f.TearDown()
 
 	foo := manifestbuilder.New(f, "foo").
-		WithLocalResource("foo cmd", []string{f.JoinPath("foo")}).
+		WithLocalResource("foo cmd", "", []string{f.JoinPath("foo")}).
 		Build()
 	bar := manifestbuilder.New(f, "bar").
-		WithLocalResource("bar cmd", []string{f.JoinPath("bar")}).
+		WithLocalResource("bar cmd", "", []string{f.JoinPath("bar")}).
 		WithResourceDeps("foo").
 		Build()
 	manifests := []model.Manifest{foo, bar}
@@ -1185,10 +1180,10 @@ func TestBuildControllerResourceDepTrumpsPendingBuild(t *testing.T) {
 	defer f.TearDown()
 
 	foo := manifestbuilder.New(f, "foo").
-		WithLocalResource("foo cmd", []string{f.JoinPath("foo")}).
+		WithLocalResource("foo cmd", "", []string{f.JoinPath("foo")}).
 		Build()
 	bar := manifestbuilder.New(f, "bar").
-		WithLocalResource("bar cmd", []string{f.JoinPath("bar")}).
+		WithLocalResource("bar cmd", "", []string{f.JoinPath("bar")}).
 		WithResourceDeps("foo").
 		Build()
 
@@ -1579,7 +1574,7 @@ func TestLocalDependsOnNonWorkloadK8s(t *testing.T) {
 	defer f.TearDown()
 
 	local1 := manifestbuilder.New(f, "local").
-		WithLocalResource("exec-local", nil).
+		WithLocalResource("exec-local", "", nil).
 		WithResourceDeps("k8s1").
 		Build()
 	k8s1 := manifestbuilder.New(f, "k8s1").
@@ -1632,7 +1627,7 @@ func TestManifestsWithCommonAncestorAndTrigger(t *testing.T) {
 func TestDisablingCancelsBuild(t *testing.T) {
 	f := newTestFixture(t)
 	manifest := manifestbuilder.New(f, "local").
-		WithLocalResource("sleep 10000", nil).
+		WithLocalResource("sleep 10000", "", nil).
 		Build()
 	f.b.completeBuildsManually = true
 
@@ -1655,12 +1650,89 @@ func TestDisablingCancelsBuild(t *testing.T) {
 
 	f.withManifestState("local", func(ms store.ManifestState) {
 		require.Equal(t, "context canceled", ms.LastBuild().Error.Error())
+		require.True(t, ms.LastBuild().Canceled)
+		require.True(t, ms.CurrentBuild.Empty())
+	})
+
+	// Canceling one resource's build is neither a build failure
+	// nor a reason for Tilt to stop.
+	f.withState(func(state store.EngineState) {
+		assert.NoError(t, state.FatalError)
+		assert.Contains(t, state.LogStore.ManifestLog("local"), "Build canceled")
+		assert.NotContains(t, state.LogStore.ManifestLog("local"), "Build Failed")
 	})
 
 	err = f.Stop()
 	require.NoError(t, err)
 }
 
+func TestBuildTimeoutFailsBuild(t *testing.T) {
+	f := newTestFixture(t)
+	manifest := manifestbuilder.New(f, "local").
+		WithLocalResource("sleep 10000", "", nil).
+		Build().
+		WithBuildTimeout(50 * time.Millisecond)
+	f.b.completeBuildsManually = true
+	f.b.nextBuildPhase = "custom_build command \"./wedged.sh\""
+
+	f.Start([]model.Manifest{manifest})
+	f.waitForCompletedBuildCount(1)
+
+	f.withManifestState("local", func(ms store.ManifestState) {
+		err := ms.LastBuild().Error
+		require.Error(t, err)
+		assert.Equal(t, buildcontrol.BuildTimeoutError{
+			Timeout: 50 * time.Millisecond,
+			Phase:   "custom_build command \"./wedged.sh\"",
+		}, err)
+		assert.False(t, ms.LastBuild().Canceled)
+		assert.True(t, ms.CurrentBuild.Empty())
+	})
+
+	f.withState(func(state store.EngineState) {
+		log := state.LogStore.ManifestLog("local")
+
+		// The builder saw its context canceled, so it stopped.
+		assert.Contains(t, log, "error: context deadline exceeded")
+		assert.Contains(t, log,
+			"Build Failed: Timed out after 50ms during custom_build command \"./wedged.sh\"")
+		assert.NotContains(t, log, "Build canceled")
+	})
+
+	err := f.Stop()
+	require.NoError(t, err)
+}
+
+func TestBuildTimeoutOverrideDisablesGlobalTimeout(t *testing.T) {
+	f := newTestFixture(t)
+	manifest := manifestbuilder.New(f, "local").
+		WithLocalResource("sleep 10000", "", nil).
+		Build().
+		WithBuildTimeout(0)
+	f.b.completeBuildsManually = true
+
+	f.tfl.Result.UpdateSettings = model.DefaultUpdateSettings()
+	f.tfl.Result.UpdateSettings.BuildTimeout = 10 * time.Millisecond
+
+	f.Start([]model.Manifest{manifest})
+	f.waitUntilManifestBuilding("local")
+
+	// Wait out the global timeout before letting the build finish.
+	time.Sleep(100 * time.Millisecond)
+	f.withState(func(state store.EngineState) {
+		assert.Equal(t, 10*time.Millisecond, state.UpdateSettings.BuildTimeout)
+	})
+	f.b.completeBuild(targetIDStringForManifest(manifest))
+
+	f.waitForCompletedBuildCount(1)
+	f.withManifestState("local", func(ms store.ManifestState) {
+		assert.NoError(t, ms.LastBuild().Error)
+	})
+
+	err := f.Stop()
+	require.NoError(t, err)
+}
+
 func TestBuildControllerK8sFileDependencies(t *testing.T) {
 	f := newTestFixture(t)
 	defer f.TearDown()
@@ -1759,6 +1831,6 @@ func (f *testFixture) completeAndCheckBuildsForManifests(manifests ...model.Mani
 
 func (f *testFixture) simpleManifestWithTriggerMode(name model.ManifestName, tm model.TriggerMode) model.Manifest {
 	return manifestbuilder.New(f, name).WithTriggerMode(tm).
-		WithImageTarget(NewSanchoDockerBuildImageTarget(f)).
+		WithImageTargets(NewSanchoDockerBuildImageTarget(f)).
 		WithK8sYAML(SanchoYAML).Build()
 }
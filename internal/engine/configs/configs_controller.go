@@ -22,17 +22,10 @@ func NewConfigsController(ctrlClient ctrlclient.Client) *ConfigsController {
 }
 
 func (cc *ConfigsController) OnChange(ctx context.Context, st store.RStore, summary store.ChangeSummary) error {
-	if summary.IsLogOnly() {
+	if cc.isInitialTiltfileCreated || summary.IsLogOnly() {
 		return nil
 	}
-
-	if !cc.isInitialTiltfileCreated {
-		err := cc.maybeCreateInitialTiltfile(ctx, st)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return cc.maybeCreateInitialTiltfile(ctx, st)
 }
 
 // Register the tiltfile with the APIServer, then dispatch an action to also copy it into the EngineState.
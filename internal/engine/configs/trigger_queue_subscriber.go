@@ -3,70 +3,247 @@ package configs
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
+	"github.com/jonboulle/clockwork"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"github.com/tilt-dev/tilt/internal/controllers/apicmp"
 	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
 	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
 )
 
+// During a burst of triggers, we write the queue at most this often.
+// The last change in the burst is written at the end of the interval.
+const triggerQueueMinWriteInterval = 100 * time.Millisecond
+
+// How many times we re-read and re-write the queue when someone else
+// updates it at the same time.
+const triggerQueueConflictRetries = 5
+
+type triggerQueueEntry struct {
+	name   model.ManifestName
+	reason model.BuildReason
+	hasMS  bool
+}
+
 // Replicates the TriggerQueue back to the API server.
 type TriggerQueueSubscriber struct {
-	client     ctrlclient.Client
-	lastUpdate *v1alpha1.ConfigMap
-}
+	client ctrlclient.Client
+	clock  clockwork.Clock
 
-func NewTriggerQueueSubscriber(client ctrlclient.Client) *TriggerQueueSubscriber {
-	return &TriggerQueueSubscriber{client: client}
-}
+	mu sync.Mutex
+
+	// The data we last wrote to the API server.
+	lastWritten map[string]string
+	lastWrite   time.Time
 
-func (s *TriggerQueueSubscriber) fromState(st store.RStore) *v1alpha1.ConfigMap {
-	state := st.RLockState()
-	defer st.RUnlockState()
+	// True if a write is scheduled for the end of the current interval.
+	flushPending bool
 
-	cm := &v1alpha1.ConfigMap{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: configmap.TriggerQueueName,
-		},
-		Data: make(map[string]string, len(state.TriggerQueue)),
+	// Names that someone else removed from the ConfigMap while they were
+	// still in the engine's queue. We don't re-add them until the engine
+	// removes them too, so that a stale snapshot doesn't undo the removal.
+	removed map[model.ManifestName]bool
+}
+
+func NewTriggerQueueSubscriber(client ctrlclient.Client, clock clockwork.Clock) *TriggerQueueSubscriber {
+	return &TriggerQueueSubscriber{
+		client:  client,
+		clock:   clock,
+		removed: make(map[model.ManifestName]bool),
 	}
+}
 
-	for i, v := range state.TriggerQueue {
-		cm.Data[fmt.Sprintf("%d-name", i)] = v.String()
+func (s *TriggerQueueSubscriber) fromState(st store.RStore) []triggerQueueEntry {
+	state := st.Snapshot()
 
+	result := make([]triggerQueueEntry, 0, len(state.TriggerQueue))
+	for _, v := range state.TriggerQueue {
+		entry := triggerQueueEntry{name: v}
 		ms, ok := state.ManifestState(v)
-		if !ok {
+		if ok {
+			entry.reason = ms.TriggerReason
+			entry.hasMS = true
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+// Converts the queue to ConfigMap data, skipping entries that were removed by someone else.
+//
+// mu must be held before calling.
+func (s *TriggerQueueSubscriber) toData(queue []triggerQueueEntry) map[string]string {
+	data := make(map[string]string, len(queue))
+	i := 0
+	for _, entry := range queue {
+		if s.removed[entry.name] {
 			continue
 		}
-		cm.Data[fmt.Sprintf("%d-reason-code", i)] = fmt.Sprintf("%d", ms.TriggerReason)
+		data[fmt.Sprintf("%d-name", i)] = entry.name.String()
+		if entry.hasMS {
+			data[fmt.Sprintf("%d-reason-code", i)] = fmt.Sprintf("%d", entry.reason)
+		}
+		i++
+	}
+	return configmap.TriggerQueueFormat.Stamp(data)
+}
+
+// Once the engine has removed an entry from its queue,
+// a new trigger for the same manifest should be written again.
+//
+// mu must be held before calling.
+func (s *TriggerQueueSubscriber) forgetRemoved(queue []triggerQueueEntry) {
+	inQueue := make(map[model.ManifestName]bool, len(queue))
+	for _, entry := range queue {
+		inQueue[entry.name] = true
+	}
+	for name := range s.removed {
+		if !inQueue[name] {
+			delete(s.removed, name)
+		}
 	}
-	return cm
 }
 
 func (s *TriggerQueueSubscriber) OnChange(ctx context.Context, st store.RStore, summary store.ChangeSummary) error {
-	if summary.IsLogOnly() {
+	if !summary.TriggerQueueChanged() {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.flushPending {
+		// The scheduled write will pick up this change.
+		return nil
+	}
+
+	queue := s.fromState(st)
+	s.forgetRemoved(queue)
+	if s.lastWritten != nil && apicmp.DeepEqual(s.toData(queue), s.lastWritten) {
 		return nil
 	}
 
-	cm := s.fromState(st)
-	if s.lastUpdate != nil && apicmp.DeepEqual(cm.Data, s.lastUpdate.Data) {
+	wait := s.lastWrite.Add(triggerQueueMinWriteInterval).Sub(s.clock.Now())
+	if !s.lastWrite.IsZero() && wait > 0 {
+		s.scheduleFlush(ctx, st, wait)
 		return nil
 	}
 
-	obj := v1alpha1.ConfigMap{
-		ObjectMeta: cm.ObjectMeta,
+	return s.write(ctx, queue)
+}
+
+// Writes the latest queue after the given delay.
+//
+// mu must be held before calling.
+func (s *TriggerQueueSubscriber) scheduleFlush(ctx context.Context, st store.RStore, wait time.Duration) {
+	s.flushPending = true
+	after := s.clock.After(wait)
+	go func() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-after:
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.flushPending = false
+
+		queue := s.fromState(st)
+		s.forgetRemoved(queue)
+		err := s.write(ctx, queue)
+		if err != nil && ctx.Err() == nil {
+			logger.Get(ctx).Debugf("Error updating trigger queue: %v", err)
+			s.scheduleFlush(ctx, st, triggerQueueMinWriteInterval)
+		}
+	}()
+}
+
+// Writes the queue to the API server.
+//
+// Always starts from a fresh read of the ConfigMap, so that if someone else
+// removed an entry since our last write, we don't add it back.
+//
+// mu must be held before calling.
+func (s *TriggerQueueSubscriber) write(ctx context.Context, queue []triggerQueueEntry) error {
+	var err error
+	for i := 0; i < triggerQueueConflictRetries; i++ {
+		err = s.tryWrite(ctx, queue)
+		if err == nil {
+			return nil
+		}
+		if !apierrors.IsConflict(err) && !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// mu must be held before calling.
+func (s *TriggerQueueSubscriber) tryWrite(ctx context.Context, queue []triggerQueueEntry) error {
+	var live v1alpha1.ConfigMap
+	err := s.client.Get(ctx, types.NamespacedName{Name: configmap.TriggerQueueName}, &live)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
 	}
-	_, err := controllerutil.CreateOrUpdate(ctx, s.client, &obj, func() error {
-		obj.Data = cm.Data
+	exists := err == nil
+
+	// If the queue was written in a format we can't read (e.g., by a newer
+	// Tilt), we can't tell what was removed from it. The engine's queue is
+	// the source of truth, so overwrite it.
+	readable := true
+	if exists {
+		_, err := configmap.Upgraded(&live)
+		readable = err == nil
+	}
+
+	if exists && readable && s.lastWritten != nil {
+		liveNames := make(map[string]bool)
+		for _, name := range configmap.NamesInTriggerQueue(&live) {
+			liveNames[name] = true
+		}
+		for _, name := range configmap.NamesInTriggerQueue(&v1alpha1.ConfigMap{Data: s.lastWritten}) {
+			if !liveNames[name] {
+				s.removed[model.ManifestName(name)] = true
+			}
+		}
+		s.forgetRemoved(queue)
+	}
+
+	data := s.toData(queue)
+	if exists && apicmp.DeepEqual(data, live.Data) {
+		s.recordWrite(data)
 		return nil
-	})
+	}
+
+	if !exists {
+		cm := &v1alpha1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: configmap.TriggerQueueName},
+			Data:       data,
+		}
+		err = s.client.Create(ctx, cm)
+	} else {
+		live.Data = data
+		err = s.client.Update(ctx, &live)
+	}
 	if err != nil {
 		return err
 	}
-	s.lastUpdate = &obj
+	s.recordWrite(data)
 	return nil
 }
+
+// mu must be held before calling.
+func (s *TriggerQueueSubscriber) recordWrite(data map[string]string) {
+	s.lastWritten = data
+	s.lastWrite = s.clock.Now()
+}
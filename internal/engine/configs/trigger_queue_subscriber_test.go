@@ -2,11 +2,15 @@ package configs
 
 import (
 	"context"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/jonboulle/clockwork"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
 	"github.com/tilt-dev/tilt/internal/controllers/fake"
@@ -34,8 +38,8 @@ func TestTriggerQueue(t *testing.T) {
 	nnC := types.NamespacedName{Name: "c"}
 	assert.False(t, configmap.InTriggerQueue(cm, nnA))
 
-	tqs := NewTriggerQueueSubscriber(client)
-	require.NoError(t, tqs.OnChange(ctx, st, store.ChangeSummary{}))
+	tqs := NewTriggerQueueSubscriber(client, clockwork.NewFakeClock())
+	require.NoError(t, tqs.OnChange(ctx, st, store.ChangeSummary{TriggerQueue: true}))
 
 	cm, err = configmap.TriggerQueue(ctx, client)
 	require.NoError(t, err)
@@ -48,3 +52,164 @@ func TestTriggerQueue(t *testing.T) {
 	assert.Equal(t, model.BuildReasonFlagTriggerWeb, configmap.TriggerQueueReason(cm, nnB))
 	assert.Equal(t, model.BuildReasonNone, configmap.TriggerQueueReason(cm, nnC))
 }
+
+func TestTriggerQueueConcurrentRemoval(t *testing.T) {
+	f := newTQFixture(t)
+	f.trigger("a", "b")
+	f.onChange()
+	assert.ElementsMatch(t, []string{"a", "b"}, f.names())
+
+	// The consumer removes b between the subscriber's read and its write.
+	f.clock.Advance(triggerQueueMinWriteInterval)
+	f.trigger("c")
+	f.client.beforeUpdate = func() {
+		f.removeFromConfigMap("b")
+	}
+	f.onChange()
+	assert.ElementsMatch(t, []string{"a", "c"}, f.names())
+
+	// Later writes don't bring it back, while the engine still has the same trigger queued.
+	f.clock.Advance(triggerQueueMinWriteInterval)
+	f.trigger("d")
+	f.onChange()
+	assert.ElementsMatch(t, []string{"a", "c", "d"}, f.names())
+
+	// Once the engine is done with b, a new trigger for b is written.
+	f.clock.Advance(triggerQueueMinWriteInterval)
+	f.st.WithState(func(s *store.EngineState) {
+		s.RemoveFromTriggerQueue("b")
+	})
+	f.onChange()
+	f.clock.Advance(triggerQueueMinWriteInterval)
+	f.trigger("b")
+	f.onChange()
+	assert.ElementsMatch(t, []string{"a", "b", "c", "d"}, f.names())
+}
+
+func TestTriggerQueueCollapsesBursts(t *testing.T) {
+	f := newTQFixture(t)
+	f.trigger("a")
+	f.onChange()
+	assert.ElementsMatch(t, []string{"a"}, f.names())
+
+	updates := f.client.updateCount()
+	f.trigger("b")
+	f.onChange()
+	f.trigger("c")
+	f.onChange()
+	assert.ElementsMatch(t, []string{"a"}, f.names())
+	assert.Equal(t, updates, f.client.updateCount())
+
+	f.clock.Advance(triggerQueueMinWriteInterval)
+	require.Eventually(t, func() bool {
+		return len(f.names()) == 3
+	}, time.Second, 10*time.Millisecond)
+	assert.ElementsMatch(t, []string{"a", "b", "c"}, f.names())
+	assert.Equal(t, updates+1, f.client.updateCount())
+}
+
+func TestTriggerQueueSkipsUnrelatedChanges(t *testing.T) {
+	f := newTQFixture(t)
+	f.trigger("a")
+
+	err := f.tqs.OnChange(f.ctx, f.st, store.ChangeSummary{Log: true, CmdSpecs: store.NewChangeSet(types.NamespacedName{Name: "cmd"})})
+	require.NoError(t, err)
+	assert.Empty(t, f.names())
+	assert.Equal(t, 0, f.client.updateCount())
+
+	f.onChange()
+	assert.ElementsMatch(t, []string{"a"}, f.names())
+}
+
+type tqFixture struct {
+	t      *testing.T
+	ctx    context.Context
+	st     *store.TestingStore
+	client *racyClient
+	clock  clockwork.FakeClock
+	tqs    *TriggerQueueSubscriber
+}
+
+func newTQFixture(t *testing.T) *tqFixture {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	st := store.NewTestingStore()
+	st.WithState(func(s *store.EngineState) {
+		for _, name := range []model.ManifestName{"a", "b", "c", "d"} {
+			s.UpsertManifestTarget(store.NewManifestTarget(model.Manifest{Name: name}))
+		}
+	})
+
+	client := &racyClient{Client: fake.NewFakeTiltClient()}
+	clock := clockwork.NewFakeClock()
+	return &tqFixture{
+		t:      t,
+		ctx:    ctx,
+		st:     st,
+		client: client,
+		clock:  clock,
+		tqs:    NewTriggerQueueSubscriber(client, clock),
+	}
+}
+
+func (f *tqFixture) trigger(names ...model.ManifestName) {
+	f.st.WithState(func(s *store.EngineState) {
+		for _, name := range names {
+			s.AppendToTriggerQueue(name, model.BuildReasonFlagTriggerWeb)
+		}
+	})
+}
+
+func (f *tqFixture) onChange() {
+	require.NoError(f.t, f.tqs.OnChange(f.ctx, f.st, store.ChangeSummary{TriggerQueue: true}))
+}
+
+func (f *tqFixture) names() []string {
+	cm, err := configmap.TriggerQueue(f.ctx, f.client.Client)
+	require.NoError(f.t, err)
+	return configmap.NamesInTriggerQueue(cm)
+}
+
+// Removes an entry the way a consumer of the queue would.
+func (f *tqFixture) removeFromConfigMap(name string) {
+	cm, err := configmap.TriggerQueue(f.ctx, f.client.Client)
+	require.NoError(f.t, err)
+	for k, v := range cm.Data {
+		if v == name {
+			delete(cm.Data, k)
+		}
+	}
+	require.NoError(f.t, f.client.Client.Update(f.ctx, cm))
+}
+
+// A client that can run a function right before the next update,
+// to simulate a concurrent write.
+type racyClient struct {
+	ctrlclient.Client
+
+	mu           sync.Mutex
+	beforeUpdate func()
+	updates      int
+}
+
+func (c *racyClient) Update(ctx context.Context, obj ctrlclient.Object, opts ...ctrlclient.UpdateOption) error {
+	c.mu.Lock()
+	before := c.beforeUpdate
+	c.beforeUpdate = nil
+	c.updates++
+	c.mu.Unlock()
+
+	if before != nil {
+		before()
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *racyClient) updateCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.updates
+}
+
+var _ ctrlclient.Client = &racyClient{}
@@ -0,0 +1,43 @@
+package idle
+
+import (
+	"time"
+
+	"github.com/tilt-dev/tilt/internal/store"
+)
+
+// Dispatched when Tilt goes to sleep.
+type SleepAction struct {
+	Time time.Time
+}
+
+func (SleepAction) Action() {}
+
+func NewSleepAction(t time.Time) SleepAction {
+	return SleepAction{Time: t}
+}
+
+func HandleSleepAction(state *store.EngineState, action SleepAction) {
+	state.Sleeping = true
+}
+
+// Dispatched when the user does something that should keep Tilt awake,
+// or wake it up if it's sleeping.
+type ActivityAction struct {
+	// What the user did, for logging (e.g., "file change", "web UI request").
+	Source string
+	Time   time.Time
+}
+
+func (ActivityAction) Action() {}
+
+func NewActivityAction(source string) ActivityAction {
+	return ActivityAction{Source: source, Time: time.Now()}
+}
+
+func HandleActivityAction(state *store.EngineState, action ActivityAction) {
+	if action.Time.After(state.LastActivityTime) {
+		state.LastActivityTime = action.Time
+	}
+	state.Sleeping = false
+}
@@ -0,0 +1,216 @@
+// Package idle puts Tilt to sleep after a period of inactivity.
+//
+// Developers often leave `tilt up` running overnight. Nothing gets built, but
+// the file watchers, log streams, and Kubernetes watches keep running. After
+// the idle timeout, we ask each Sleeper to suspend its work, and wake them all
+// up again on the next file change, web UI request, or CLI command.
+package idle
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/pkg/logger"
+)
+
+// How long Tilt waits without activity before it goes to sleep.
+//
+// 0 means Tilt never sleeps.
+type Timeout time.Duration
+
+// How often we check whether Tilt has been idle for long enough.
+const idleCheckInterval = 10 * time.Second
+
+// Something that can suspend its work while Tilt is asleep.
+//
+// Wake must re-sync anything that might have changed during Sleep, so that
+// nothing is lost while Tilt was asleep.
+type Sleeper interface {
+	Sleep(ctx context.Context, st store.RStore) error
+	Wake(ctx context.Context, st store.RStore) error
+}
+
+type Sleepers []Sleeper
+
+// Watches for activity, and puts the Sleepers to sleep when there hasn't
+// been any for the idle timeout.
+//
+// Activity is anything the user does: a file change, a build, a web UI
+// request, or a CLI command.
+type Controller struct {
+	timeout  time.Duration
+	clock    clockwork.Clock
+	sleepers Sleepers
+
+	mu sync.Mutex
+
+	// The most recent activity we've seen in the engine state.
+	lastActivity time.Time
+
+	// When we noticed the most recent activity, according to our clock.
+	lastActiveAt time.Time
+
+	asleep bool
+	cancel context.CancelFunc
+}
+
+var _ store.SubscriberLifecycle = &Controller{}
+
+func NewController(timeout Timeout, clock clockwork.Clock, sleepers Sleepers) *Controller {
+	return &Controller{
+		timeout:  time.Duration(timeout),
+		clock:    clock,
+		sleepers: sleepers,
+	}
+}
+
+func (c *Controller) SetUp(ctx context.Context, st store.RStore) error {
+	c.mu.Lock()
+	c.lastActiveAt = c.clock.Now()
+	c.mu.Unlock()
+
+	if c.timeout <= 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	c.cancel = cancel
+
+	go c.loop(ctx, st)
+	return nil
+}
+
+func (c *Controller) TearDown(ctx context.Context) {
+	if c.cancel != nil {
+		c.cancel()
+	}
+}
+
+func (c *Controller) OnChange(ctx context.Context, st store.RStore, _ store.ChangeSummary) error {
+	if c.timeout <= 0 {
+		return nil
+	}
+
+	state := st.RLockState()
+	activity, _ := lastActivity(state)
+	sleeping := state.Sleeping
+	st.RUnlockState()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if activity.After(c.lastActivity) {
+		c.lastActivity = activity
+		c.lastActiveAt = c.clock.Now()
+		if c.asleep {
+			c.wake(ctx, st)
+		}
+	}
+
+	if sleeping && !c.asleep {
+		// The activity came from somewhere that doesn't know about sleep
+		// (e.g., a build), or we woke up before the engine saw us go to
+		// sleep. Either way, mark Tilt awake ourselves.
+		st.Dispatch(ActivityAction{Source: "wake", Time: c.lastActivity})
+	}
+	return nil
+}
+
+func (c *Controller) loop(ctx context.Context, st store.RStore) {
+	ticker := c.clock.NewTicker(idleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.Chan():
+			c.checkIdle(ctx, st)
+		}
+	}
+}
+
+// Go to sleep if we've been idle for long enough.
+func (c *Controller) checkIdle(ctx context.Context, st store.RStore) {
+	if c.timeout <= 0 {
+		return
+	}
+
+	state := st.RLockState()
+	_, busy := lastActivity(state)
+	st.RUnlockState()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now()
+	if busy {
+		// Don't count a long build as idle time.
+		c.lastActiveAt = now
+		return
+	}
+
+	idleTime := now.Sub(c.lastActiveAt)
+	if c.asleep || idleTime < c.timeout {
+		return
+	}
+
+	c.asleep = true
+	for _, s := range c.sleepers {
+		err := s.Sleep(ctx, st)
+		if err != nil {
+			logger.Get(ctx).Debugf("Error going to sleep: %v", err)
+		}
+	}
+	st.Dispatch(NewSleepAction(now))
+	logger.Get(ctx).Infof("Tilt is sleeping after %s without activity. "+
+		"It'll wake up on the next file change, web UI request, or CLI command.",
+		idleTime.Round(time.Second))
+}
+
+// mu must be held before calling.
+func (c *Controller) wake(ctx context.Context, st store.RStore) {
+	c.asleep = false
+	logger.Get(ctx).Infof("Tilt is waking up. Resyncing...")
+	for _, s := range c.sleepers {
+		err := s.Wake(ctx, st)
+		if err != nil {
+			logger.Get(ctx).Infof("Error waking up: %v", err)
+		}
+	}
+}
+
+// Finds the most recent activity in the engine state, and whether
+// anything is building right now.
+func lastActivity(state store.EngineState) (time.Time, bool) {
+	result := state.LastActivityTime
+	busy := len(state.CurrentlyBuilding) > 0
+
+	visit := func(t time.Time) {
+		if t.After(result) {
+			result = t
+		}
+	}
+
+	for _, fw := range state.FileWatches {
+		visit(fw.Status.LastEventTime.Time)
+	}
+
+	manifestStates := state.ManifestStates()
+	for _, ms := range state.TiltfileStates {
+		manifestStates = append(manifestStates, ms)
+	}
+	for _, ms := range manifestStates {
+		if ms.IsBuilding() {
+			busy = true
+			visit(ms.CurrentBuild.StartTime)
+		}
+		visit(ms.LastBuild().StartTime)
+		visit(ms.LastBuild().FinishTime)
+	}
+	return result, busy
+}
@@ -0,0 +1,272 @@
+package idle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/testutils"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+const testTimeout = 30 * time.Minute
+
+func TestSleepAfterTimeout(t *testing.T) {
+	f := newFixture(t)
+
+	f.clock.Advance(testTimeout - time.Second)
+	f.checkIdle()
+	f.assertSleeps(0)
+
+	f.clock.Advance(time.Second)
+	f.checkIdle()
+	f.assertSleeps(1)
+	assert.Equal(t, 0, f.sleeper.wakes)
+
+	actions := f.store.Actions()
+	require.Len(t, actions, 1)
+	assert.Equal(t, NewSleepAction(f.clock.Now()), actions[0])
+
+	// We only go to sleep once.
+	f.clock.Advance(testTimeout)
+	f.checkIdle()
+	f.assertSleeps(1)
+}
+
+func TestNoTimeoutNeverSleeps(t *testing.T) {
+	f := newFixtureWithTimeout(t, 0)
+
+	f.clock.Advance(24 * time.Hour)
+	f.checkIdle()
+	f.onChange()
+	f.assertSleeps(0)
+}
+
+func TestNoSleepWhileBuilding(t *testing.T) {
+	f := newFixture(t)
+	f.store.WithState(func(state *store.EngineState) {
+		state.CurrentlyBuilding["fe"] = true
+	})
+
+	f.clock.Advance(2 * testTimeout)
+	f.checkIdle()
+	f.assertSleeps(0)
+
+	// The idle timer starts when the build is done, not when it started.
+	f.store.WithState(func(state *store.EngineState) {
+		delete(state.CurrentlyBuilding, "fe")
+	})
+	f.clock.Advance(testTimeout - time.Second)
+	f.checkIdle()
+	f.assertSleeps(0)
+
+	f.clock.Advance(time.Second)
+	f.checkIdle()
+	f.assertSleeps(1)
+}
+
+func TestActivityResetsTimer(t *testing.T) {
+	f := newFixture(t)
+
+	f.clock.Advance(testTimeout - time.Second)
+	f.setFileEventTime(time.Now())
+	f.onChange()
+
+	f.clock.Advance(time.Second)
+	f.checkIdle()
+	f.assertSleeps(0)
+
+	f.clock.Advance(testTimeout)
+	f.checkIdle()
+	f.assertSleeps(1)
+}
+
+func TestWakeOnUserActivity(t *testing.T) {
+	f := newFixture(t)
+	f.sleep()
+
+	// State changes without new activity don't wake us up.
+	f.onChange()
+	assert.Equal(t, 0, f.sleeper.wakes)
+
+	f.store.ClearActions()
+	f.reduce(NewActivityAction("web UI request"))
+	f.onChange()
+	assert.Equal(t, 1, f.sleeper.wakes)
+	assert.False(t, f.store.RLockState().Sleeping)
+	f.store.RUnlockState()
+
+	// The user's action already marked Tilt awake, so we don't do it again.
+	assert.Empty(t, f.store.Actions())
+
+	// The idle timer starts over.
+	f.clock.Advance(testTimeout - time.Second)
+	f.checkIdle()
+	f.assertSleeps(1)
+	f.clock.Advance(time.Second)
+	f.checkIdle()
+	f.assertSleeps(2)
+}
+
+func TestWakeOnBuild(t *testing.T) {
+	f := newFixture(t)
+	f.sleep()
+
+	f.store.ClearActions()
+	f.store.WithState(func(state *store.EngineState) {
+		state.TiltfileStates[model.MainTiltfileManifestName].CurrentBuild = model.BuildRecord{StartTime: time.Now()}
+	})
+	f.onChange()
+	assert.Equal(t, 1, f.sleeper.wakes)
+
+	// Nothing told the engine we're awake, so we do it ourselves.
+	actions := f.store.Actions()
+	require.Len(t, actions, 1)
+	assert.IsType(t, ActivityAction{}, actions[0])
+}
+
+func TestActivityAction(t *testing.T) {
+	state := store.NewState()
+	HandleSleepAction(state, NewSleepAction(time.Now()))
+	assert.True(t, state.Sleeping)
+
+	a := NewActivityAction("file change")
+	HandleActivityAction(state, a)
+	assert.False(t, state.Sleeping)
+	assert.Equal(t, a.Time, state.LastActivityTime)
+
+	// An older action doesn't move the activity time back.
+	HandleActivityAction(state, ActivityAction{Source: "file change", Time: a.Time.Add(-time.Minute)})
+	assert.Equal(t, a.Time, state.LastActivityTime)
+}
+
+type fakeSleeper struct {
+	sleeps int
+	wakes  int
+}
+
+func (s *fakeSleeper) Sleep(ctx context.Context, st store.RStore) error {
+	s.sleeps++
+	return nil
+}
+
+func (s *fakeSleeper) Wake(ctx context.Context, st store.RStore) error {
+	s.wakes++
+	return nil
+}
+
+type fixture struct {
+	t       *testing.T
+	ctx     context.Context
+	clock   clockwork.FakeClock
+	sleeper *fakeSleeper
+	c       *Controller
+	store   *store.TestingStore
+}
+
+func newFixture(t *testing.T) *fixture {
+	return newFixtureWithTimeout(t, Timeout(testTimeout))
+}
+
+func newFixtureWithTimeout(t *testing.T, timeout Timeout) *fixture {
+	ctx, _, _ := testutils.CtxAndAnalyticsForTest()
+	ctx, cancel := context.WithCancel(ctx)
+	t.Cleanup(cancel)
+
+	clock := clockwork.NewFakeClock()
+	sleeper := &fakeSleeper{}
+	st := store.NewTestingStore()
+	st.WithState(func(state *store.EngineState) {
+		state.TiltfileStates[model.MainTiltfileManifestName] = &store.ManifestState{}
+		state.FileWatches["fe"] = &v1alpha1.FileWatch{}
+	})
+
+	f := &fixture{
+		t:       t,
+		ctx:     ctx,
+		clock:   clock,
+		sleeper: sleeper,
+		c:       NewController(timeout, clock, Sleepers{sleeper}),
+		store:   st,
+	}
+
+	// Don't start the ticker loop. The tests call checkIdle directly.
+	f.c.lastActiveAt = clock.Now()
+	return f
+}
+
+func (f *fixture) checkIdle() {
+	f.c.checkIdle(f.ctx, f.store)
+}
+
+func (f *fixture) onChange() {
+	err := f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	require.NoError(f.t, err)
+}
+
+// Run an action through the reducer.
+func (f *fixture) reduce(action store.Action) {
+	f.store.WithState(func(state *store.EngineState) {
+		switch action := action.(type) {
+		case SleepAction:
+			HandleSleepAction(state, action)
+		case ActivityAction:
+			HandleActivityAction(state, action)
+		}
+	})
+}
+
+func (f *fixture) sleep() {
+	f.clock.Advance(testTimeout)
+	f.checkIdle()
+	f.assertSleeps(1)
+
+	actions := f.store.Actions()
+	require.NotEmpty(f.t, actions)
+	f.reduce(actions[len(actions)-1])
+	assert.True(f.t, f.store.RLockState().Sleeping)
+	f.store.RUnlockState()
+}
+
+func (f *fixture) setFileEventTime(t time.Time) {
+	f.store.WithState(func(state *store.EngineState) {
+		state.FileWatches["fe"].Status.LastEventTime = metav1.NewMicroTime(t)
+	})
+}
+
+func (f *fixture) assertSleeps(expected int) {
+	f.t.Helper()
+	assert.Equal(f.t, expected, f.sleeper.sleeps)
+}
+
+func TestWakeBeforeEngineSawSleep(t *testing.T) {
+	f := newFixture(t)
+	f.clock.Advance(testTimeout)
+	f.checkIdle()
+	f.assertSleeps(1)
+	sleepAction := f.store.Actions()[0]
+
+	// A file changes before the engine handles the SleepAction.
+	f.setFileEventTime(time.Now())
+	f.onChange()
+	assert.Equal(t, 1, f.sleeper.wakes)
+
+	f.store.ClearActions()
+	f.reduce(sleepAction)
+	f.onChange()
+
+	// The engine thinks we're asleep, but we're not.
+	actions := f.store.Actions()
+	require.Len(t, actions, 1)
+	f.reduce(actions[0])
+	assert.False(t, f.store.RLockState().Sleeping)
+	f.store.RUnlockState()
+	assert.Equal(t, 1, f.sleeper.wakes)
+}
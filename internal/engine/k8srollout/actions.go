@@ -0,0 +1,39 @@
+package k8srollout
+
+import (
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+type ImagePullCheckAction struct {
+	ManifestName model.ManifestName
+	PodID        k8s.PodID
+	Check        store.ImagePullCheck
+}
+
+func (ImagePullCheckAction) Action() {}
+
+func NewImagePullCheckAction(mn model.ManifestName, podID k8s.PodID, check store.ImagePullCheck) ImagePullCheckAction {
+	return ImagePullCheckAction{
+		ManifestName: mn,
+		PodID:        podID,
+		Check:        check,
+	}
+}
+
+type PendingPodDiagnosticAction struct {
+	ManifestName model.ManifestName
+	PodID        k8s.PodID
+	Diagnostic   store.PendingPodDiagnostic
+}
+
+func (PendingPodDiagnosticAction) Action() {}
+
+func NewPendingPodDiagnosticAction(mn model.ManifestName, podID k8s.PodID, diag store.PendingPodDiagnostic) PendingPodDiagnosticAction {
+	return PendingPodDiagnosticAction{
+		ManifestName: mn,
+		PodID:        podID,
+		Diagnostic:   diag,
+	}
+}
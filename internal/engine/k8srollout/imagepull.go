@@ -0,0 +1,244 @@
+package k8srollout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/errdefs"
+	"github.com/jonboulle/clockwork"
+
+	"github.com/tilt-dev/tilt/internal/container"
+	"github.com/tilt-dev/tilt/internal/docker"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// How long we give the kubelet to retry pulling an image that we've
+// verified is in the registry.
+const imagePullRetryWindow = 30 * time.Second
+
+// How long we give the registry to respond to a check.
+const registryCheckTimeout = 10 * time.Second
+
+var ErrManifestNotFound = errors.New("manifest not found")
+var ErrRegistryUnauthorized = errors.New("registry unauthorized")
+
+// Checks whether an image manifest can be fetched from its registry.
+//
+// Returns ErrManifestNotFound if the registry doesn't have the image,
+// and ErrRegistryUnauthorized if the registry rejected our credentials.
+type RegistryChecker interface {
+	CheckManifest(ctx context.Context, ref reference.Named) error
+}
+
+type dockerRegistryChecker struct {
+	dCli docker.Client
+}
+
+// Checks the registry through the Docker daemon, so that we see the registry the
+// same way that `docker push` did.
+func NewDockerRegistryChecker(dCli docker.Client) RegistryChecker {
+	return dockerRegistryChecker{dCli: dCli}
+}
+
+func (c dockerRegistryChecker) CheckManifest(ctx context.Context, ref reference.Named) error {
+	_, err := c.dCli.DistributionInspect(ctx, ref)
+	if err == nil {
+		return nil
+	}
+
+	// The daemon doesn't always preserve the registry's status code,
+	// so fall back to the registry's error messages.
+	msg := strings.ToLower(err.Error())
+	if errdefs.IsUnauthorized(err) || errdefs.IsForbidden(err) ||
+		strings.Contains(msg, "unauthorized") ||
+		strings.Contains(msg, "denied") ||
+		strings.Contains(msg, "authentication required") {
+		return fmt.Errorf("%w: %v", ErrRegistryUnauthorized, err)
+	}
+	if errdefs.IsNotFound(err) ||
+		strings.Contains(msg, "manifest unknown") ||
+		strings.Contains(msg, "not found") {
+		return fmt.Errorf("%w: %v", ErrManifestNotFound, err)
+	}
+	return err
+}
+
+type imagePullKey struct {
+	manifest model.ManifestName
+	pod      k8s.PodID
+	image    string
+}
+
+type imagePullFailure struct {
+	key     imagePullKey
+	built   store.ImageBuildResult
+	message string
+}
+
+// When a pod fails to pull an image that Tilt just pushed, checks the
+// registry to decide whether the kubelet is likely to succeed on retry.
+//
+// Registries sometimes take a moment to serve an image after a push.
+// If we treated every ErrImagePull as fatal, CI runs would fail on that race.
+// Failures to pull images that Tilt didn't build are left alone.
+type ImagePullMonitor struct {
+	checker RegistryChecker
+	clock   clockwork.Clock
+
+	mu      sync.Mutex
+	checked map[imagePullKey]bool
+}
+
+func NewImagePullMonitor(checker RegistryChecker, clock clockwork.Clock) *ImagePullMonitor {
+	return &ImagePullMonitor{
+		checker: checker,
+		clock:   clock,
+		checked: make(map[imagePullKey]bool),
+	}
+}
+
+func (m *ImagePullMonitor) diff(st store.RStore) []imagePullFailure {
+	state := st.RLockState()
+	defer st.RUnlockState()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var failures []imagePullFailure
+	activePods := make(map[podManifest]bool)
+	for _, mt := range state.Targets() {
+		ms := mt.State
+		if !ms.IsK8s() {
+			continue
+		}
+
+		for _, pod := range ms.K8sRuntimeState().Pods {
+			activePods[podManifest{pod: k8s.PodID(pod.Name), manifest: mt.Manifest.Name}] = true
+			for _, ctr := range store.AllPodContainers(*pod) {
+				if !store.IsImagePullError(ctr) {
+					continue
+				}
+
+				key := imagePullKey{manifest: mt.Manifest.Name, pod: k8s.PodID(pod.Name), image: ctr.Image}
+				if m.checked[key] {
+					continue
+				}
+
+				built, ok := ms.TiltBuiltImage(ctr.Image)
+				if !ok {
+					continue
+				}
+
+				m.checked[key] = true
+				failures = append(failures, imagePullFailure{
+					key:     key,
+					built:   built,
+					message: strings.Join(pod.Errors, "; "),
+				})
+			}
+		}
+	}
+
+	for key := range m.checked {
+		if !activePods[podManifest{pod: key.pod, manifest: key.manifest}] {
+			delete(m.checked, key)
+		}
+	}
+	return failures
+}
+
+func (m *ImagePullMonitor) OnChange(ctx context.Context, st store.RStore, _ store.ChangeSummary) error {
+	for _, failure := range m.diff(st) {
+		go m.check(ctx, st, failure)
+	}
+	return nil
+}
+
+// Diagnoses the failure, and if it looks transient, gives the kubelet a chance
+// to retry before marking it as failed.
+func (m *ImagePullMonitor) check(ctx context.Context, st store.RStore, failure imagePullFailure) {
+	result := m.diagnose(ctx, failure)
+	st.Dispatch(NewImagePullCheckAction(failure.key.manifest, failure.key.pod, result))
+	if result.Verdict != store.ImagePullVerdictRetrying {
+		return
+	}
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-m.clock.After(imagePullRetryWindow):
+	}
+
+	// If the kubelet has since pulled the image, nothing will look at this check.
+	st.Dispatch(NewImagePullCheckAction(failure.key.manifest, failure.key.pod, store.ImagePullCheck{
+		Image:   failure.key.image,
+		Verdict: store.ImagePullVerdictFailed,
+		Error: fmt.Sprintf("Image %s is in the registry, but the cluster still couldn't pull %s after %s. "+
+			"Check that the cluster can reach the registry.",
+			failure.built.ImageLocalRef, failure.key.image, imagePullRetryWindow),
+	}))
+}
+
+func (m *ImagePullMonitor) diagnose(ctx context.Context, failure imagePullFailure) store.ImagePullCheck {
+	image := failure.key.image
+	pushedRef := failure.built.ImageLocalRef
+	clusterRef := failure.built.ImageClusterRef
+	failed := func(format string, a ...interface{}) store.ImagePullCheck {
+		return store.ImagePullCheck{
+			Image:   image,
+			Verdict: store.ImagePullVerdictFailed,
+			Error:   fmt.Sprintf(format, a...),
+		}
+	}
+
+	podRef, err := container.ParseNamed(image)
+	if err == nil {
+		domain := reference.Domain(podRef)
+		if domain != reference.Domain(pushedRef) && domain != reference.Domain(clusterRef) {
+			return failed("Tilt pushed image %s, but the cluster is pulling %s from a different registry",
+				pushedRef, image)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, registryCheckTimeout)
+	defer cancel()
+
+	err = m.checker.CheckManifest(ctx, pushedRef)
+	switch {
+	case errors.Is(err, ErrManifestNotFound):
+		return failed("Image %s was never pushed to the registry, so the cluster can't pull %s",
+			pushedRef, image)
+	case errors.Is(err, ErrRegistryUnauthorized):
+		return failed("Registry rejected credentials for image %s, so Tilt couldn't verify that the cluster can pull %s: %v",
+			pushedRef, image, err)
+	case err != nil:
+		return failed("Couldn't check the registry for image %s (the cluster is pulling %s): %v",
+			pushedRef, image, err)
+	}
+
+	if isAuthMessage(failure.message) {
+		return failed("Image %s is in the registry, but the cluster isn't authorized to pull %s. "+
+			"Check the imagePullSecrets for the pod.", pushedRef, image)
+	}
+
+	return store.ImagePullCheck{
+		Image:   image,
+		Verdict: store.ImagePullVerdictRetrying,
+	}
+}
+
+func isAuthMessage(msg string) bool {
+	msg = strings.ToLower(msg)
+	return strings.Contains(msg, "unauthorized") ||
+		strings.Contains(msg, "authentication required") ||
+		strings.Contains(msg, "access denied")
+}
+
+var _ store.Subscriber = &ImagePullMonitor{}
@@ -0,0 +1,196 @@
+package k8srollout
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/docker/distribution/reference"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/tilt-dev/tilt/internal/container"
+	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/testutils/manifestbuilder"
+	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+const pushedImage = "localhost:5000/sancho:tilt-123"
+
+func TestImagePullRetryThenFail(t *testing.T) {
+	f := newIPMFixture(t)
+	f.setUpPod(pushedImage, "")
+
+	_ = f.ipm.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	f.waitForCheck(store.ImagePullVerdictRetrying)
+
+	// Give the kubelet a chance to retry.
+	f.clock.BlockUntil(1)
+	f.clock.Advance(imagePullRetryWindow)
+	check := f.waitForCheck(store.ImagePullVerdictFailed)
+	assert.Contains(t, check.Error, "is in the registry, but the cluster still couldn't pull")
+
+	// We only check each image once per pod.
+	_ = f.ipm.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	assert.Empty(t, f.ipm.diff(f.store))
+}
+
+func TestImagePullNeverPushed(t *testing.T) {
+	f := newIPMFixture(t)
+	f.checker.errors[pushedImage] = fmt.Errorf("%w: manifest unknown", ErrManifestNotFound)
+	f.setUpPod(pushedImage, "")
+
+	check := f.diagnose()
+	assert.Equal(t, store.ImagePullVerdictFailed, check.Verdict)
+	assert.Equal(t, "Image localhost:5000/sancho:tilt-123 was never pushed to the registry, "+
+		"so the cluster can't pull localhost:5000/sancho:tilt-123", check.Error)
+}
+
+func TestImagePullRegistryUnauthorized(t *testing.T) {
+	f := newIPMFixture(t)
+	f.checker.errors[pushedImage] = fmt.Errorf("%w: denied", ErrRegistryUnauthorized)
+	f.setUpPod(pushedImage, "")
+
+	check := f.diagnose()
+	assert.Equal(t, store.ImagePullVerdictFailed, check.Verdict)
+	assert.Contains(t, check.Error, "Registry rejected credentials for image localhost:5000/sancho:tilt-123")
+}
+
+func TestImagePullClusterUnauthorized(t *testing.T) {
+	f := newIPMFixture(t)
+	f.setUpPod(pushedImage, "pull access denied: unauthorized: authentication required")
+
+	check := f.diagnose()
+	assert.Equal(t, store.ImagePullVerdictFailed, check.Verdict)
+	assert.Contains(t, check.Error, "the cluster isn't authorized to pull localhost:5000/sancho:tilt-123")
+}
+
+func TestImagePullDifferentRegistry(t *testing.T) {
+	f := newIPMFixture(t)
+	f.setUpPod("gcr.io/other-project/sancho:tilt-123", "")
+
+	check := f.diagnose()
+	assert.Equal(t, store.ImagePullVerdictFailed, check.Verdict)
+	assert.Equal(t, "Tilt pushed image localhost:5000/sancho:tilt-123, "+
+		"but the cluster is pulling gcr.io/other-project/sancho:tilt-123 from a different registry", check.Error)
+	assert.Empty(t, f.checker.checked, "shouldn't need the registry to diagnose")
+}
+
+func TestImagePullNonTiltImage(t *testing.T) {
+	f := newIPMFixture(t)
+	f.setUpPod("redis:6", "")
+
+	assert.Empty(t, f.ipm.diff(f.store))
+}
+
+type fakeRegistryChecker struct {
+	errors  map[string]error
+	checked []string
+}
+
+func (c *fakeRegistryChecker) CheckManifest(ctx context.Context, ref reference.Named) error {
+	c.checked = append(c.checked, ref.String())
+	return c.errors[ref.String()]
+}
+
+type ipmFixture struct {
+	*tempdir.TempDirFixture
+	t       *testing.T
+	ctx     context.Context
+	cancel  context.CancelFunc
+	clock   clockwork.FakeClock
+	checker *fakeRegistryChecker
+	ipm     *ImagePullMonitor
+	store   *store.TestingStore
+}
+
+func newIPMFixture(t *testing.T) *ipmFixture {
+	ctx, cancel := context.WithCancel(context.Background())
+	clock := clockwork.NewFakeClock()
+	checker := &fakeRegistryChecker{errors: make(map[string]error)}
+	f := &ipmFixture{
+		TempDirFixture: tempdir.NewTempDirFixture(t),
+		t:              t,
+		ctx:            ctx,
+		cancel:         cancel,
+		clock:          clock,
+		checker:        checker,
+		ipm:            NewImagePullMonitor(checker, clock),
+		store:          store.NewTestingStore(),
+	}
+	t.Cleanup(func() {
+		cancel()
+		f.TearDown()
+	})
+	return f
+}
+
+// Sets up a manifest with a Tilt-built image whose pod can't pull the given image.
+func (f *ipmFixture) setUpPod(image string, podErr string) {
+	state := f.store.LockMutableStateForTesting()
+	defer f.store.UnlockMutableState()
+
+	m := manifestbuilder.New(f, "sancho").WithK8sYAML(testyaml.SanchoYAML).Build()
+	state.UpsertManifestTarget(store.NewManifestTarget(m))
+	ms := state.ManifestTargets["sancho"].State
+
+	id := model.ImageID(container.MustParseSelector("sancho"))
+	ms.MutableBuildStatus(id).LastResult = store.NewImageBuildResultSingleRef(id,
+		container.MustParseNamedTagged(pushedImage))
+
+	pod := v1alpha1.Pod{
+		Name:  "pod-a",
+		Phase: string(v1.PodPending),
+		Containers: []v1alpha1.Container{
+			{
+				Name:  "sancho",
+				Image: image,
+				State: v1alpha1.ContainerState{
+					Waiting: &v1alpha1.ContainerStateWaiting{Reason: "ErrImagePull"},
+				},
+			},
+		},
+	}
+	if podErr != "" {
+		pod.Errors = []string{podErr}
+	}
+	ms.RuntimeState = store.NewK8sRuntimeStateWithPods(m, pod)
+}
+
+func (f *ipmFixture) diagnose() store.ImagePullCheck {
+	failures := f.ipm.diff(f.store)
+	require.Len(f.t, failures, 1)
+	return f.ipm.diagnose(f.ctx, failures[0])
+}
+
+// Waits for the monitor to dispatch a check with the given verdict,
+// and applies it to the state.
+func (f *ipmFixture) waitForCheck(verdict store.ImagePullVerdict) store.ImagePullCheck {
+	var result store.ImagePullCheck
+	require.Eventually(f.t, func() bool {
+		for _, a := range f.store.Actions() {
+			action, ok := a.(ImagePullCheckAction)
+			if ok && action.Check.Verdict == verdict {
+				state := f.store.LockMutableStateForTesting()
+				HandleImagePullCheckAction(state, action)
+				f.store.UnlockMutableState()
+				result = action.Check
+				return true
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+
+	state := f.store.RLockState()
+	defer f.store.RUnlockState()
+	check, ok := state.ManifestTargets["sancho"].State.K8sRuntimeState().ImagePullCheck("pod-a", pushedImage)
+	require.True(f.t, ok)
+	assert.Equal(f.t, verdict, check.Verdict)
+	return result
+}
@@ -0,0 +1,369 @@
+package k8srollout
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/logger"
+)
+
+// How long a pod can be Pending before we go looking for why.
+const pendingPodDiagnosticThreshold = 30 * time.Second
+
+// Events that explain why a pod can't be scheduled or started.
+var pendingPodEventReasons = map[string]bool{
+	"FailedScheduling": true,
+	"FailedMount":      true,
+}
+
+type pendingPod struct {
+	key    podManifest
+	pod    v1alpha1.Pod
+	reason string
+
+	// When the pod was created, in local time.
+	createdAt time.Time
+}
+
+// When a pod stays Pending, diagnoses why.
+//
+// The answer is usually in the pod's conditions and events (e.g., not enough CPU
+// on any node), or in an object that the pod references but doesn't exist
+// (e.g., a missing PersistentVolumeClaim). We log a single consolidated
+// diagnostic to the resource, and only diagnose again if the reason changes.
+type PendingPodMonitor struct {
+	kCli  k8s.Client
+	clock clockwork.Clock
+
+	mu sync.Mutex
+
+	// The pending reason we last diagnosed for each pod.
+	diagnosed map[podManifest]string
+
+	// Pods that we've set a timer for, to check them when they cross the threshold.
+	waiting map[podManifest]bool
+}
+
+func NewPendingPodMonitor(kCli k8s.Client, clock clockwork.Clock) *PendingPodMonitor {
+	return &PendingPodMonitor{
+		kCli:      kCli,
+		clock:     clock,
+		diagnosed: make(map[podManifest]string),
+		waiting:   make(map[podManifest]bool),
+	}
+}
+
+// Returns the pods that are ready to diagnose, and how long to wait
+// before checking the pods that haven't been Pending long enough.
+func (m *PendingPodMonitor) diff(st store.RStore) ([]pendingPod, []time.Duration) {
+	state := st.RLockState()
+	defer st.RUnlockState()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var ready []pendingPod
+	var waits []time.Duration
+	active := make(map[podManifest]bool)
+	now := m.clock.Now()
+	for _, mt := range state.Targets() {
+		ms := mt.State
+		if !ms.IsK8s() {
+			continue
+		}
+
+		for _, pod := range ms.K8sRuntimeState().Pods {
+			if !isDiagnosablePendingPod(*pod) {
+				continue
+			}
+
+			key := podManifest{pod: k8s.PodID(pod.Name), manifest: mt.Manifest.Name}
+			active[key] = true
+
+			createdAt := state.ClusterClockSkew.ToLocal(pod.CreatedAt.Time)
+			pendingFor := now.Sub(createdAt)
+			if pendingFor < pendingPodDiagnosticThreshold {
+				if !m.waiting[key] {
+					m.waiting[key] = true
+					waits = append(waits, pendingPodDiagnosticThreshold-pendingFor)
+				}
+				continue
+			}
+
+			reason := pendingReason(*pod)
+			if m.diagnosed[key] == reason {
+				continue
+			}
+			m.diagnosed[key] = reason
+			ready = append(ready, pendingPod{key: key, pod: *pod, reason: reason, createdAt: createdAt})
+		}
+	}
+
+	for key := range m.diagnosed {
+		if !active[key] {
+			delete(m.diagnosed, key)
+		}
+	}
+	for key := range m.waiting {
+		if !active[key] {
+			delete(m.waiting, key)
+		}
+	}
+	return ready, waits
+}
+
+func (m *PendingPodMonitor) OnChange(ctx context.Context, st store.RStore, _ store.ChangeSummary) error {
+	ready, waits := m.diff(st)
+	for _, p := range ready {
+		go m.check(ctx, st, p)
+	}
+	for _, wait := range waits {
+		go m.recheckAfter(ctx, st, wait)
+	}
+	return nil
+}
+
+// Nothing in the store changes when a pod crosses the threshold,
+// so we need our own timer to look at it again.
+func (m *PendingPodMonitor) recheckAfter(ctx context.Context, st store.RStore, wait time.Duration) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-m.clock.After(wait):
+	}
+	_ = m.OnChange(ctx, st, store.LegacyChangeSummary())
+}
+
+func (m *PendingPodMonitor) check(ctx context.Context, st store.RStore, p pendingPod) {
+	diag := m.diagnose(ctx, p)
+	st.Dispatch(NewPendingPodDiagnosticAction(p.key.manifest, p.key.pod, diag))
+	st.Dispatch(store.NewLogAction(p.key.manifest, spanIDForPod(p.key.manifest, p.key.pod),
+		logger.WarnLvl, nil, []byte(diag.Message+"\n")))
+}
+
+func (m *PendingPodMonitor) diagnose(ctx context.Context, p pendingPod) store.PendingPodDiagnostic {
+	pod := p.pod
+	var details []string
+	summary := ""
+
+	for _, c := range pod.Conditions {
+		if c.Type == string(v1.PodScheduled) && c.Status == string(v1.ConditionFalse) {
+			details = append(details, fmt.Sprintf("Not scheduled (%s): %s", c.Reason, c.Message))
+			summary = c.Message
+		}
+	}
+
+	for _, e := range pod.Errors {
+		details = append(details, e)
+		if summary == "" {
+			summary = e
+		}
+	}
+
+	events, err := m.podEvents(ctx, pod)
+	if err != nil {
+		details = append(details, fmt.Sprintf("Couldn't list events: %v", err))
+	}
+	for _, e := range events {
+		details = append(details, fmt.Sprintf("Event %s: %s", e.Reason, e.Message))
+		if summary == "" {
+			summary = e.Message
+		}
+	}
+
+	// A missing object is the most actionable thing we can tell the user,
+	// so it takes over the summary.
+	missing := m.missingObjects(ctx, pod)
+	for i, ref := range missing {
+		details = append(details, fmt.Sprintf("%s %q doesn't exist", ref.Kind, ref.Name))
+		if i == 0 {
+			summary = fmt.Sprintf("missing %s %q", ref.Kind, ref.Name)
+		}
+	}
+
+	if summary == "" {
+		summary = p.reason
+		details = append(details, "Kubernetes hasn't reported why")
+	}
+
+	pendingFor := m.clock.Since(p.createdAt).Truncate(time.Second)
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Pod %s has been Pending for %s:", pod.Name, pendingFor))
+	for _, d := range details {
+		sb.WriteString(fmt.Sprintf("\n  %s", d))
+	}
+
+	return store.PendingPodDiagnostic{
+		Reason:  p.reason,
+		Summary: summary,
+		Message: sb.String(),
+	}
+}
+
+// The events that explain why the pod is Pending, oldest first, without repeats.
+func (m *PendingPodMonitor) podEvents(ctx context.Context, pod v1alpha1.Pod) ([]*v1.Event, error) {
+	events, err := m.kCli.ListEvents(ctx, k8s.Namespace(pod.Namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*v1.Event
+	for _, e := range events {
+		obj := e.InvolvedObject
+		if obj.Kind != "Pod" || obj.Name != pod.Name || !pendingPodEventReasons[e.Reason] {
+			continue
+		}
+		if obj.UID != "" && pod.UID != "" && string(obj.UID) != pod.UID {
+			continue
+		}
+		result = append(result, e)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		ti, tj := result[i].LastTimestamp.Time, result[j].LastTimestamp.Time
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return result[i].Message < result[j].Message
+	})
+
+	seen := make(map[string]bool)
+	deduped := result[:0]
+	for _, e := range result {
+		key := e.Reason + ":" + e.Message
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		deduped = append(deduped, e)
+	}
+	return deduped, nil
+}
+
+// Finds the PVCs, ConfigMaps, and Secrets that the pod needs but that don't exist.
+func (m *PendingPodMonitor) missingObjects(ctx context.Context, pod v1alpha1.Pod) []v1.ObjectReference {
+	kPod, err := m.kCli.PodFromInformerCache(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: pod.Name})
+	if err != nil {
+		return nil
+	}
+
+	var result []v1.ObjectReference
+	for _, ref := range referencedObjects(kPod.Spec) {
+		ref.Namespace = pod.Namespace
+		_, err := m.kCli.GetMetaByReference(ctx, ref)
+		// If we can't tell (e.g., we're not allowed to read Secrets), assume it's there.
+		if apierrors.IsNotFound(err) {
+			result = append(result, ref)
+		}
+	}
+	return result
+}
+
+// The objects that the pod can't start without, in the order they appear in the spec.
+func referencedObjects(spec v1.PodSpec) []v1.ObjectReference {
+	var result []v1.ObjectReference
+	seen := make(map[v1.ObjectReference]bool)
+	add := func(kind string, name string, optional *bool) {
+		if name == "" || (optional != nil && *optional) {
+			return
+		}
+		ref := v1.ObjectReference{APIVersion: "v1", Kind: kind, Name: name}
+		if seen[ref] {
+			return
+		}
+		seen[ref] = true
+		result = append(result, ref)
+	}
+
+	for _, vol := range spec.Volumes {
+		if vol.PersistentVolumeClaim != nil {
+			add("PersistentVolumeClaim", vol.PersistentVolumeClaim.ClaimName, nil)
+		}
+		if vol.ConfigMap != nil {
+			add("ConfigMap", vol.ConfigMap.Name, vol.ConfigMap.Optional)
+		}
+		if vol.Secret != nil {
+			add("Secret", vol.Secret.SecretName, vol.Secret.Optional)
+		}
+		if vol.Projected != nil {
+			for _, src := range vol.Projected.Sources {
+				if src.ConfigMap != nil {
+					add("ConfigMap", src.ConfigMap.Name, src.ConfigMap.Optional)
+				}
+				if src.Secret != nil {
+					add("Secret", src.Secret.Name, src.Secret.Optional)
+				}
+			}
+		}
+	}
+
+	containers := append(append([]v1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, c := range containers {
+		for _, envFrom := range c.EnvFrom {
+			if envFrom.ConfigMapRef != nil {
+				add("ConfigMap", envFrom.ConfigMapRef.Name, envFrom.ConfigMapRef.Optional)
+			}
+			if envFrom.SecretRef != nil {
+				add("Secret", envFrom.SecretRef.Name, envFrom.SecretRef.Optional)
+			}
+		}
+		for _, env := range c.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+				add("ConfigMap", ref.Name, ref.Optional)
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+				add("Secret", ref.Name, ref.Optional)
+			}
+		}
+	}
+	return result
+}
+
+// Image pull failures are Pending too, but the ImagePullMonitor handles those.
+func isDiagnosablePendingPod(pod v1alpha1.Pod) bool {
+	if pod.Phase != string(v1.PodPending) || pod.Deleting || pod.CreatedAt.IsZero() {
+		return false
+	}
+	for _, ctr := range store.AllPodContainers(pod) {
+		if store.IsImagePullError(ctr) {
+			return false
+		}
+	}
+	return true
+}
+
+// Why the pod is Pending, in a form we can compare across pod updates.
+func pendingReason(pod v1alpha1.Pod) string {
+	for _, c := range pod.Conditions {
+		if c.Type == string(v1.PodScheduled) && c.Status == string(v1.ConditionFalse) {
+			return fmt.Sprintf("%s: %s", c.Reason, c.Message)
+		}
+	}
+
+	var reasons []string
+	for _, ctr := range store.AllPodContainers(pod) {
+		if ctr.State.Waiting != nil && ctr.State.Waiting.Reason != "" {
+			reasons = append(reasons, fmt.Sprintf("%s: %s", ctr.Name, ctr.State.Waiting.Reason))
+		}
+	}
+	if len(reasons) == 0 {
+		return string(v1.PodPending)
+	}
+	return strings.Join(reasons, ", ")
+}
+
+var _ store.Subscriber = &PendingPodMonitor{}
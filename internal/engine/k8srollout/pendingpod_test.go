@@ -0,0 +1,208 @@
+package k8srollout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/testutils"
+	"github.com/tilt-dev/tilt/internal/testutils/manifestbuilder"
+	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+)
+
+func TestPendingPodInsufficientResources(t *testing.T) {
+	f := newPPMFixture(t)
+	msg := "0/3 nodes are available: 3 Insufficient cpu."
+	f.setUpPod(time.Minute, unschedulable(msg), v1.PodSpec{})
+	f.addEvent("event-1", "FailedScheduling", msg, 2*time.Second)
+	f.addEvent("event-2", "FailedScheduling", msg, time.Second)
+	f.addEvent("event-3", "Scheduled", "unrelated", time.Second)
+
+	diag := f.diagnose()
+	assert.Equal(t, msg, diag.Summary)
+	assert.Equal(t, `Pod pod-a has been Pending for 1m0s:
+  Not scheduled (Unschedulable): 0/3 nodes are available: 3 Insufficient cpu.
+  Event FailedScheduling: 0/3 nodes are available: 3 Insufficient cpu.`, diag.Message)
+}
+
+func TestPendingPodMissingPVC(t *testing.T) {
+	f := newPPMFixture(t)
+	f.kCli.Inject(k8s.NewK8sEntity(&v1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "default", UID: "settings-uid"},
+	}))
+
+	msg := `0/1 nodes are available: 1 persistentvolumeclaim "data" not found.`
+	f.setUpPod(time.Minute, unschedulable(msg), v1.PodSpec{
+		Volumes: []v1.Volume{
+			{
+				Name: "data",
+				VolumeSource: v1.VolumeSource{
+					PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "data"},
+				},
+			},
+			{
+				Name: "settings",
+				VolumeSource: v1.VolumeSource{
+					ConfigMap: &v1.ConfigMapVolumeSource{
+						LocalObjectReference: v1.LocalObjectReference{Name: "settings"},
+					},
+				},
+			},
+		},
+	})
+
+	diag := f.diagnose()
+	assert.Equal(t, `missing PersistentVolumeClaim "data"`, diag.Summary)
+	assert.Equal(t, `Pod pod-a has been Pending for 1m0s:
+  Not scheduled (Unschedulable): 0/1 nodes are available: 1 persistentvolumeclaim "data" not found.
+  PersistentVolumeClaim "data" doesn't exist`, diag.Message)
+}
+
+func TestPendingPodWaitsForThreshold(t *testing.T) {
+	f := newPPMFixture(t)
+	f.setUpPod(0, unschedulable("0/3 nodes are available: 3 Insufficient cpu."), v1.PodSpec{})
+
+	_ = f.ppm.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	f.clock.BlockUntil(1)
+	assert.Empty(t, f.store.Actions())
+
+	f.clock.Advance(pendingPodDiagnosticThreshold)
+	diag := f.waitForDiagnostic()
+	assert.Equal(t, "0/3 nodes are available: 3 Insufficient cpu.", diag.Summary)
+}
+
+func TestPendingPodRediagnosesWhenReasonChanges(t *testing.T) {
+	f := newPPMFixture(t)
+	f.setUpPod(time.Minute, unschedulable("0/3 nodes are available: 3 Insufficient cpu."), v1.PodSpec{})
+
+	ready, _ := f.ppm.diff(f.store)
+	assert.Len(t, ready, 1)
+	ready, _ = f.ppm.diff(f.store)
+	assert.Empty(t, ready, "same reason shouldn't be diagnosed twice")
+
+	f.setUpPod(time.Minute, unschedulable("0/3 nodes are available: 3 Insufficient memory."), v1.PodSpec{})
+	ready, _ = f.ppm.diff(f.store)
+	assert.Len(t, ready, 1)
+}
+
+func unschedulable(msg string) []v1alpha1.PodCondition {
+	return []v1alpha1.PodCondition{
+		{
+			Type:    string(v1.PodScheduled),
+			Status:  string(v1.ConditionFalse),
+			Reason:  "Unschedulable",
+			Message: msg,
+		},
+	}
+}
+
+type ppmFixture struct {
+	*tempdir.TempDirFixture
+	t     *testing.T
+	ctx   context.Context
+	clock clockwork.FakeClock
+	kCli  *k8s.FakeK8sClient
+	ppm   *PendingPodMonitor
+	store *store.TestingStore
+}
+
+func newPPMFixture(t *testing.T) *ppmFixture {
+	ctx, _, _ := testutils.CtxAndAnalyticsForTest()
+	ctx, cancel := context.WithCancel(ctx)
+	clock := clockwork.NewFakeClock()
+	kCli := k8s.NewFakeK8sClient(t)
+	f := &ppmFixture{
+		TempDirFixture: tempdir.NewTempDirFixture(t),
+		t:              t,
+		ctx:            ctx,
+		clock:          clock,
+		kCli:           kCli,
+		ppm:            NewPendingPodMonitor(kCli, clock),
+		store:          store.NewTestingStore(),
+	}
+	t.Cleanup(func() {
+		cancel()
+		kCli.TearDown()
+		f.TearDown()
+	})
+	return f
+}
+
+// Sets up a manifest with a pod that has been Pending for the given duration.
+func (f *ppmFixture) setUpPod(pendingFor time.Duration, conditions []v1alpha1.PodCondition, spec v1.PodSpec) {
+	createdAt := f.clock.Now().Add(-pendingFor)
+	f.kCli.UpsertPod(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "pod-a",
+			Namespace:         "default",
+			CreationTimestamp: metav1.NewTime(createdAt),
+		},
+		Spec: spec,
+	})
+
+	state := f.store.LockMutableStateForTesting()
+	defer f.store.UnlockMutableState()
+
+	m := manifestbuilder.New(f, "sancho").WithK8sYAML(testyaml.SanchoYAML).Build()
+	state.UpsertManifestTarget(store.NewManifestTarget(m))
+	ms := state.ManifestTargets["sancho"].State
+	ms.RuntimeState = store.NewK8sRuntimeStateWithPods(m, v1alpha1.Pod{
+		Name:       "pod-a",
+		Namespace:  "default",
+		CreatedAt:  metav1.NewTime(createdAt),
+		Phase:      string(v1.PodPending),
+		Conditions: conditions,
+	})
+}
+
+func (f *ppmFixture) addEvent(name, reason, msg string, ago time.Duration) {
+	f.kCli.UpsertEvent(&v1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "default"},
+		InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "pod-a", Namespace: "default"},
+		Reason:         reason,
+		Message:        msg,
+		LastTimestamp:  metav1.NewTime(f.clock.Now().Add(-ago)),
+	})
+}
+
+func (f *ppmFixture) diagnose() store.PendingPodDiagnostic {
+	ready, _ := f.ppm.diff(f.store)
+	require.Len(f.t, ready, 1)
+	return f.ppm.diagnose(f.ctx, ready[0])
+}
+
+// Waits for the monitor to dispatch a diagnostic, and applies it to the state.
+func (f *ppmFixture) waitForDiagnostic() store.PendingPodDiagnostic {
+	var result store.PendingPodDiagnostic
+	require.Eventually(f.t, func() bool {
+		for _, a := range f.store.Actions() {
+			action, ok := a.(PendingPodDiagnosticAction)
+			if ok {
+				state := f.store.LockMutableStateForTesting()
+				HandlePendingPodDiagnosticAction(state, action)
+				f.store.UnlockMutableState()
+				result = action.Diagnostic
+				return true
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+
+	state := f.store.RLockState()
+	defer f.store.RUnlockState()
+	diag, ok := state.ManifestTargets["sancho"].State.K8sRuntimeState().PendingPodDiagnostic("pod-a")
+	require.True(f.t, ok)
+	assert.Equal(f.t, result, diag)
+	return result
+}
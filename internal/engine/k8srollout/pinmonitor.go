@@ -0,0 +1,159 @@
+package k8srollout
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
+	"github.com/tilt-dev/tilt/internal/controllers/apis/pin"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/store/k8sconv"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// Keeps track of which pod a pinned resource is pinned to.
+//
+// When a resource is pinned, records its current pod in the pin ConfigMap.
+// If that pod goes away on its own, breaks the pin, so that Tilt can
+// deploy the resource again.
+type PinMonitor struct {
+	client ctrlclient.Client
+}
+
+func NewPinMonitor(client ctrlclient.Client) *PinMonitor {
+	return &PinMonitor{client: client}
+}
+
+type pinUpdate struct {
+	manifestName model.ManifestName
+
+	// The new pod to pin to. Empty if the pin should be cleared.
+	pod k8s.PodID
+
+	// If the pin broke because the pod went away, the pod that went away.
+	brokenPod k8s.PodID
+}
+
+func (m *PinMonitor) diff(st store.RStore) []pinUpdate {
+	state := st.RLockState()
+	defer st.RUnlockState()
+
+	var updates []pinUpdate
+	for _, mt := range state.Targets() {
+		if !mt.Manifest.IsK8s() {
+			continue
+		}
+
+		mn := mt.Manifest.Name
+		pinned := pin.IsPinned(state.ConfigMaps, mn.String())
+		pinnedPod := k8s.PodID(pin.PinnedPod(state.ConfigMaps, mn.String()))
+		runtime := mt.State.K8sRuntimeState()
+
+		if !pinned {
+			if !pinnedPod.Empty() {
+				updates = append(updates, pinUpdate{manifestName: mn})
+			}
+			continue
+		}
+
+		if pinnedPod.Empty() {
+			pod := runtime.MostRecentPod()
+			if pod.Name != "" && !isPodGone(pod) {
+				updates = append(updates, pinUpdate{manifestName: mn, pod: k8s.PodID(pod.Name)})
+			}
+			continue
+		}
+
+		if isPinnedPodGone(runtime, pinnedPod) {
+			updates = append(updates, pinUpdate{manifestName: mn, brokenPod: pinnedPod})
+		}
+	}
+	return updates
+}
+
+// Returns true if the pinned pod has been deleted or has exited.
+//
+// If we haven't heard about any pods yet (e.g., the pod watcher
+// is still starting up), we assume the pod is still there.
+func isPinnedPodGone(runtime store.K8sRuntimeState, podID k8s.PodID) bool {
+	if runtime.PodLen() == 0 {
+		return false
+	}
+	pod, ok := runtime.Pods[podID]
+	if !ok || pod == nil {
+		return true
+	}
+	return isPodGone(*pod)
+}
+
+func isPodGone(pod v1alpha1.Pod) bool {
+	return pod.Deleting ||
+		pod.Phase == string(v1.PodFailed) ||
+		pod.Phase == string(v1.PodSucceeded)
+}
+
+func (m *PinMonitor) OnChange(ctx context.Context, st store.RStore, summary store.ChangeSummary) error {
+	if summary.IsLogOnly() {
+		return nil
+	}
+
+	for _, update := range m.diff(st) {
+		err := m.apply(ctx, update)
+		if err != nil {
+			return err
+		}
+
+		if !update.brokenPod.Empty() {
+			msg := fmt.Sprintf("Pinned pod %s went away. Unpinning and applying pending changes.", update.brokenPod)
+			spanID := k8sconv.SpanIDForPod(update.manifestName, update.brokenPod)
+			st.Dispatch(store.NewLogAction(update.manifestName, spanID, logger.WarnLvl, nil, []byte(msg)))
+		}
+	}
+	return nil
+}
+
+func (m *PinMonitor) apply(ctx context.Context, update pinUpdate) error {
+	var cm v1alpha1.ConfigMap
+	err := m.client.Get(ctx, types.NamespacedName{Name: pin.ConfigMapName(update.manifestName.String())}, &cm)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	upgraded, err := configmap.Upgraded(&cm)
+	if err != nil {
+		return err
+	}
+	cm = *upgraded
+
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	if update.pod.Empty() {
+		delete(cm.Data, pin.PodKey)
+	} else {
+		cm.Data[pin.PodKey] = update.pod.String()
+	}
+	if !update.brokenPod.Empty() {
+		cm.Data[pin.PinnedKey] = "false"
+	}
+
+	err = m.client.Update(ctx, &cm)
+	if err != nil && (apierrors.IsNotFound(err) || apierrors.IsConflict(err)) {
+		// We'll try again on the next change.
+		return nil
+	}
+	return err
+}
+
+var _ store.Subscriber = &PinMonitor{}
@@ -0,0 +1,132 @@
+package k8srollout
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/tilt-dev/tilt/internal/controllers/apis/pin"
+	"github.com/tilt-dev/tilt/internal/controllers/fake"
+	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/testutils/bufsync"
+	"github.com/tilt-dev/tilt/internal/testutils/manifestbuilder"
+	"github.com/tilt-dev/tilt/internal/testutils/manifestutils"
+	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+)
+
+func TestPinMonitorRecordsPod(t *testing.T) {
+	f := newPinFixture(t)
+
+	f.setPod(v1alpha1.Pod{Name: "pod-1", Phase: string(v1.PodRunning)})
+	f.onChange()
+	assert.Equal(t, "", f.configMap().Data[pin.PodKey])
+
+	f.setPinned(true)
+	f.onChange()
+	assert.Equal(t, "pod-1", f.configMap().Data[pin.PodKey])
+
+	f.setPinned(false)
+	f.onChange()
+	assert.Equal(t, "", f.configMap().Data[pin.PodKey])
+}
+
+func TestPinMonitorBreaksPinWhenPodDies(t *testing.T) {
+	f := newPinFixture(t)
+
+	f.setPod(v1alpha1.Pod{Name: "pod-1", Phase: string(v1.PodRunning)})
+	f.setPinned(true)
+	f.onChange()
+	require.Equal(t, "pod-1", f.configMap().Data[pin.PodKey])
+
+	f.setPod(v1alpha1.Pod{Name: "pod-1", Phase: string(v1.PodFailed)})
+	f.onChange()
+
+	cm := f.configMap()
+	assert.Equal(t, "false", cm.Data[pin.PinnedKey])
+	assert.Equal(t, "", cm.Data[pin.PodKey])
+	assert.Contains(t, f.out.String(), "Pinned pod pod-1 went away")
+}
+
+func TestPinMonitorBreaksPinWhenPodReplaced(t *testing.T) {
+	f := newPinFixture(t)
+
+	f.setPod(v1alpha1.Pod{Name: "pod-1", Phase: string(v1.PodRunning)})
+	f.setPinned(true)
+	f.onChange()
+
+	f.setPod(v1alpha1.Pod{Name: "pod-2", Phase: string(v1.PodRunning)})
+	f.onChange()
+
+	assert.Equal(t, "false", f.configMap().Data[pin.PinnedKey])
+	assert.Contains(t, f.out.String(), "Pinned pod pod-1 went away")
+}
+
+type pinFixture struct {
+	*tempdir.TempDirFixture
+	t      *testing.T
+	ctx    context.Context
+	client ctrlclient.Client
+	pm     *PinMonitor
+	out    *bufsync.ThreadSafeBuffer
+	store  *testStore
+}
+
+func newPinFixture(t *testing.T) *pinFixture {
+	f := tempdir.NewTempDirFixture(t)
+	t.Cleanup(f.TearDown)
+
+	out := bufsync.NewThreadSafeBuffer()
+	client := fake.NewFakeTiltClient()
+	ctx := context.Background()
+	require.NoError(t, client.Create(ctx, pin.ToConfigMap("sancho")))
+
+	return &pinFixture{
+		TempDirFixture: f,
+		t:              t,
+		ctx:            ctx,
+		client:         client,
+		pm:             NewPinMonitor(client),
+		out:            out,
+		store:          NewTestingStore(out),
+	}
+}
+
+func (f *pinFixture) setPod(pod v1alpha1.Pod) {
+	m := manifestbuilder.New(f, "sancho").WithK8sYAML(testyaml.SanchoYAML).Build()
+	f.store.WithState(func(state *store.EngineState) {
+		state.UpsertManifestTarget(manifestutils.NewManifestTargetWithPod(m, pod))
+	})
+}
+
+func (f *pinFixture) setPinned(pinned bool) {
+	cm := f.configMap()
+	if pinned {
+		cm.Data[pin.PinnedKey] = "true"
+	} else {
+		cm.Data[pin.PinnedKey] = "false"
+	}
+	require.NoError(f.t, f.client.Update(f.ctx, cm))
+}
+
+func (f *pinFixture) configMap() *v1alpha1.ConfigMap {
+	var cm v1alpha1.ConfigMap
+	require.NoError(f.t, f.client.Get(f.ctx, types.NamespacedName{Name: pin.ConfigMapName("sancho")}, &cm))
+	return &cm
+}
+
+// Copies the ConfigMap into the engine state, the way the ConfigMap
+// reconciler would, then notifies the monitor.
+func (f *pinFixture) onChange() {
+	cm := f.configMap()
+	f.store.WithState(func(state *store.EngineState) {
+		state.ConfigMaps[cm.Name] = cm
+	})
+	require.NoError(f.t, f.pm.OnChange(f.ctx, f.store, store.ChangeSummary{}))
+}
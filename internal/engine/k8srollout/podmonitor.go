@@ -35,8 +35,7 @@ func NewPodMonitor() *PodMonitor {
 }
 
 func (m *PodMonitor) diff(st store.RStore) []podStatus {
-	state := st.RLockState()
-	defer st.RUnlockState()
+	state := st.Snapshot()
 
 	updates := make([]podStatus, 0)
 	active := make(map[podManifest]bool)
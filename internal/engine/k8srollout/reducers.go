@@ -0,0 +1,54 @@
+package k8srollout
+
+import (
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/store"
+)
+
+func HandleImagePullCheckAction(state *store.EngineState, action ImagePullCheckAction) {
+	ms, ok := state.ManifestState(action.ManifestName)
+	if !ok || !ms.IsK8s() {
+		return
+	}
+
+	runtime := ms.K8sRuntimeState()
+	if _, ok := runtime.Pods[action.PodID]; !ok {
+		// The pod has gone away since we started the check.
+		return
+	}
+
+	if runtime.ImagePullChecks == nil {
+		runtime.ImagePullChecks = make(map[k8s.PodID]map[string]store.ImagePullCheck)
+	}
+	checks := runtime.ImagePullChecks[action.PodID]
+	if checks == nil {
+		checks = make(map[string]store.ImagePullCheck)
+		runtime.ImagePullChecks[action.PodID] = checks
+	}
+	checks[action.Check.Image] = action.Check
+	ms.RuntimeState = runtime
+}
+
+func HandlePendingPodDiagnosticAction(state *store.EngineState, action PendingPodDiagnosticAction) {
+	ms, ok := state.ManifestState(action.ManifestName)
+	if !ok || !ms.IsK8s() {
+		return
+	}
+
+	runtime := ms.K8sRuntimeState()
+	if _, ok := runtime.Pods[action.PodID]; !ok {
+		// The pod has gone away since we started the diagnosis.
+		return
+	}
+
+	diagnostics := make(map[k8s.PodID]store.PendingPodDiagnostic)
+	for podID, diag := range runtime.PendingPodDiagnostics {
+		// Drop diagnostics for pods that are gone.
+		if _, ok := runtime.Pods[podID]; ok {
+			diagnostics[podID] = diag
+		}
+	}
+	diagnostics[action.PodID] = action.Diagnostic
+	runtime.PendingPodDiagnostics = diagnostics
+	ms.RuntimeState = runtime
+}
@@ -2,9 +2,12 @@ package k8swatch
 
 import (
 	"net/url"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
 
 	v1 "k8s.io/api/core/v1"
@@ -41,3 +44,25 @@ func NewServiceChangeAction(service *v1.Service, mn model.ManifestName, url *url
 		URL:          url,
 	}
 }
+
+type ClusterConnectionAction struct {
+	Status store.ClusterConnectionStatus
+	Error  string
+	Time   time.Time
+}
+
+func (ClusterConnectionAction) Action() {}
+
+type ClusterClockSkewAction struct {
+	Skew k8s.ClockSkew
+}
+
+func (ClusterClockSkewAction) Action() {}
+
+func NewClusterConnectionAction(status store.ClusterConnectionStatus, err error, t time.Time) ClusterConnectionAction {
+	a := ClusterConnectionAction{Status: status, Time: t}
+	if err != nil {
+		a.Error = err.Error()
+	}
+	return a
+}
@@ -0,0 +1,213 @@
+package k8swatch
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/pkg/logger"
+)
+
+// How often we check that the cluster is reachable.
+const clusterCheckInterval = 5 * time.Second
+
+// How long we give the cluster to respond to a check.
+const clusterCheckTimeout = 5 * time.Second
+
+// If we haven't heard from the cluster in this long, assume that our watches
+// have missed events.
+//
+// This covers both the case where the cluster is unreachable and the
+// case where Tilt wasn't running at all (e.g., the laptop was asleep).
+const clusterStaleThreshold = 30 * time.Second
+
+// How often we re-estimate the skew between our clock and the cluster's.
+// Clocks drift, and laptops re-sync their clocks after sleeping.
+const clockSkewCheckInterval = 5 * time.Minute
+
+// Something that caches cluster state from watches.
+//
+// After we lose touch with the cluster, the watch may have silently dropped
+// events, so the cache needs to be reconciled against a fresh list.
+type ClusterResyncer interface {
+	ResyncCluster(ctx context.Context, st store.RStore) error
+}
+
+type ClusterResyncers []ClusterResyncer
+
+// Watches the health of the connection to the Kubernetes cluster.
+//
+// When the cluster goes away, marks the connection as degraded.
+// When it comes back, asks everything that caches cluster state to resync.
+//
+// Also keeps track of the skew between our clock and the cluster's.
+type ClusterMonitor struct {
+	kCli      k8s.Client
+	clock     clockwork.Clock
+	resyncers ClusterResyncers
+
+	mu          sync.Mutex
+	lastContact time.Time
+	status      store.ClusterConnectionStatus
+	cancel      context.CancelFunc
+
+	lastSkewCheck time.Time
+	skew          k8s.ClockSkew
+}
+
+var _ store.SubscriberLifecycle = &ClusterMonitor{}
+
+func NewClusterMonitor(kCli k8s.Client, clock clockwork.Clock, resyncers ClusterResyncers) *ClusterMonitor {
+	return &ClusterMonitor{
+		kCli:      kCli,
+		clock:     clock,
+		resyncers: resyncers,
+	}
+}
+
+func (m *ClusterMonitor) SetUp(ctx context.Context, st store.RStore) error {
+	ctx, cancel := context.WithCancel(ctx)
+	m.cancel = cancel
+
+	go m.loop(ctx, st)
+	return nil
+}
+
+func (m *ClusterMonitor) TearDown(ctx context.Context) {
+	if m.cancel != nil {
+		m.cancel()
+	}
+}
+
+func (m *ClusterMonitor) OnChange(ctx context.Context, st store.RStore, _ store.ChangeSummary) error {
+	return nil
+}
+
+func (m *ClusterMonitor) loop(ctx context.Context, st store.RStore) {
+	ticker := m.clock.NewTicker(clusterCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.Chan():
+			m.check(ctx, st)
+		}
+	}
+}
+
+func hasK8sManifests(st store.RStore) bool {
+	state := st.RLockState()
+	defer st.RUnlockState()
+	for _, mt := range state.Targets() {
+		if mt.Manifest.IsK8s() {
+			return true
+		}
+	}
+	return false
+}
+
+// Check the connection, and resync if we've been out of touch.
+func (m *ClusterMonitor) check(ctx context.Context, st store.RStore) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !hasK8sManifests(st) {
+		// Nothing is watching the cluster, so there's nothing to go stale.
+		m.lastContact = time.Time{}
+		return
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, clusterCheckTimeout)
+	err := m.kCli.CheckConnected(checkCtx)
+	cancel()
+
+	now := m.clock.Now()
+	if m.lastContact.IsZero() {
+		// Start the clock on the first check, so that a cluster that's
+		// unreachable from the start goes stale like any other.
+		m.lastContact = now
+	}
+	stale := now.Sub(m.lastContact) >= clusterStaleThreshold
+	degraded := m.status == store.ClusterConnectionDegraded
+	if err != nil {
+		// A credential plugin that hangs or fails won't fix itself,
+		// so don't wait for the connection to go stale to say so.
+		if (stale || isCredentialPluginError(err)) && !degraded {
+			m.status = store.ClusterConnectionDegraded
+			logger.Get(ctx).Warnf("Lost connection to Kubernetes cluster: %v\n"+
+				"Pod and service status may be out of date until it comes back.", err)
+			st.Dispatch(NewClusterConnectionAction(store.ClusterConnectionDegraded, err, now))
+		}
+		return
+	}
+
+	if degraded || stale {
+		if degraded {
+			logger.Get(ctx).Infof("Reconnected to Kubernetes cluster. Resyncing cluster state...")
+		} else {
+			logger.Get(ctx).Infof("Haven't heard from the Kubernetes cluster in %s. Resyncing cluster state...",
+				now.Sub(m.lastContact).Round(time.Second))
+		}
+		m.resync(ctx, st)
+	}
+
+	m.lastContact = now
+	if m.status != store.ClusterConnectionConnected {
+		m.status = store.ClusterConnectionConnected
+		st.Dispatch(NewClusterConnectionAction(store.ClusterConnectionConnected, nil, now))
+	}
+
+	if degraded || stale || m.lastSkewCheck.IsZero() || now.Sub(m.lastSkewCheck) >= clockSkewCheckInterval {
+		m.checkClockSkew(ctx, st, now)
+	}
+}
+
+// Re-estimate the clock skew, and warn if it's gotten big enough
+// to throw off how we compare cluster timestamps to ours.
+func (m *ClusterMonitor) checkClockSkew(ctx context.Context, st store.RStore, now time.Time) {
+	checkCtx, cancel := context.WithTimeout(ctx, clusterCheckTimeout)
+	skew, err := m.kCli.ClockSkew(checkCtx)
+	cancel()
+	if err != nil {
+		logger.Get(ctx).Debugf("Error estimating cluster clock skew: %v", err)
+		return
+	}
+	m.lastSkewCheck = now
+
+	// Don't bother the user (or the store) over measurement noise.
+	if !skew.Significant() && !m.skew.Significant() {
+		return
+	}
+	if k8s.ClockSkew(time.Duration(skew)-time.Duration(m.skew)).Significant() || skew.Significant() != m.skew.Significant() {
+		if skew.Significant() {
+			logger.Get(ctx).Warnf("The Kubernetes cluster's clock is %s compared to this machine's clock.\n"+
+				"Tilt will correct for it when comparing timestamps, but you may want to sync your clocks.", skew)
+		} else {
+			logger.Get(ctx).Infof("The Kubernetes cluster's clock is back in sync with this machine's clock.")
+			skew = 0
+		}
+		m.skew = skew
+		st.Dispatch(ClusterClockSkewAction{Skew: skew})
+	}
+}
+
+func isCredentialPluginError(err error) bool {
+	var pluginErr k8s.CredentialPluginError
+	return errors.As(err, &pluginErr)
+}
+
+func (m *ClusterMonitor) resync(ctx context.Context, st store.RStore) {
+	for _, r := range m.resyncers {
+		err := r.ResyncCluster(ctx, st)
+		if err != nil {
+			logger.Get(ctx).Infof("Error resyncing cluster state: %v", err)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package k8swatch
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/testutils"
+	"github.com/tilt-dev/tilt/internal/testutils/bufsync"
+	"github.com/tilt-dev/tilt/internal/testutils/manifestbuilder"
+	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
+)
+
+func TestClusterMonitorDegradedAndReconnect(t *testing.T) {
+	f := newCMFixture(t)
+	f.addK8sManifest()
+
+	f.check()
+	f.assertStatuses(store.ClusterConnectionConnected)
+	assert.Equal(t, 0, f.resyncer.count)
+
+	f.kClient.SetConnectionError(fmt.Errorf("connection refused"))
+
+	// A brief blip isn't enough to mark the connection degraded.
+	f.clock.Advance(clusterCheckInterval)
+	f.check()
+	f.assertStatuses(store.ClusterConnectionConnected)
+
+	f.clock.Advance(clusterStaleThreshold)
+	f.check()
+	f.assertStatuses(store.ClusterConnectionConnected, store.ClusterConnectionDegraded)
+	assert.Equal(t, 0, f.resyncer.count)
+
+	f.clock.Advance(clusterCheckInterval)
+	f.check()
+	f.assertStatuses(store.ClusterConnectionConnected, store.ClusterConnectionDegraded)
+
+	f.kClient.SetConnectionError(nil)
+	f.clock.Advance(clusterCheckInterval)
+	f.check()
+	f.assertStatuses(store.ClusterConnectionConnected, store.ClusterConnectionDegraded, store.ClusterConnectionConnected)
+	assert.Equal(t, 1, f.resyncer.count)
+
+	// Once we've caught up, we don't resync again.
+	f.clock.Advance(clusterCheckInterval)
+	f.check()
+	assert.Equal(t, 1, f.resyncer.count)
+}
+
+func TestClusterMonitorUnreachableFromStart(t *testing.T) {
+	f := newCMFixture(t)
+	f.addK8sManifest()
+	f.kClient.SetConnectionError(fmt.Errorf("connection refused"))
+
+	f.check()
+	f.assertStatuses()
+
+	f.clock.Advance(clusterStaleThreshold)
+	f.check()
+	f.assertStatuses(store.ClusterConnectionDegraded)
+
+	f.kClient.SetConnectionError(nil)
+	f.clock.Advance(clusterCheckInterval)
+	f.check()
+	f.assertStatuses(store.ClusterConnectionDegraded, store.ClusterConnectionConnected)
+	assert.Equal(t, 1, f.resyncer.count)
+}
+
+func TestClusterMonitorResyncsAfterSleep(t *testing.T) {
+	f := newCMFixture(t)
+	f.addK8sManifest()
+
+	f.check()
+	assert.Equal(t, 0, f.resyncer.count)
+
+	// The laptop sleeps, so no checks run. When it wakes up, the cluster
+	// is reachable, but the watches may have missed events.
+	f.clock.Advance(time.Hour)
+	f.check()
+	assert.Equal(t, 1, f.resyncer.count)
+	f.assertStatuses(store.ClusterConnectionConnected)
+}
+
+func TestClusterMonitorIgnoresClusterWithoutK8sManifests(t *testing.T) {
+	f := newCMFixture(t)
+	f.kClient.SetConnectionError(fmt.Errorf("connection refused"))
+
+	f.check()
+	f.clock.Advance(time.Hour)
+	f.check()
+
+	f.assertStatuses()
+	assert.Equal(t, 0, f.resyncer.count)
+}
+
+func TestClusterMonitorCredentialPluginError(t *testing.T) {
+	f := newCMFixture(t)
+	f.addK8sManifest()
+
+	f.kClient.SetConnectionError(errors.Wrap(k8s.CredentialPluginError{
+		Command: "/usr/local/bin/aws",
+		Timeout: 10 * time.Second,
+		Err:     context.DeadlineExceeded,
+	}, "checking cluster connection"))
+
+	// A hung credential plugin won't fix itself, so we report it right away.
+	f.check()
+	f.assertStatuses(store.ClusterConnectionDegraded)
+	assert.Contains(t, f.logs.String(),
+		"credential plugin aws timed out after 10s — your SSO session may be expired; run `aws sso login`")
+
+	a := f.store.Actions()[0].(ClusterConnectionAction)
+	assert.Contains(t, a.Error, "credential plugin aws timed out")
+}
+
+func TestClusterMonitorClockSkew(t *testing.T) {
+	f := newCMFixture(t)
+	f.addK8sManifest()
+
+	// Small skews are measurement noise.
+	f.kClient.SetClockSkew(k8s.ClockSkew(2 * time.Second))
+	f.check()
+	f.assertSkews()
+
+	f.kClient.SetClockSkew(k8s.ClockSkew(-time.Minute))
+
+	// We only re-estimate every so often.
+	f.clock.Advance(clusterCheckInterval)
+	f.check()
+	f.assertSkews()
+
+	f.checkFor(clockSkewCheckInterval)
+	f.assertSkews(k8s.ClockSkew(-time.Minute))
+	assert.Contains(t, f.logs.String(), "The Kubernetes cluster's clock is 1m0s behind compared to this machine's clock")
+
+	// A drift smaller than the threshold doesn't change anything.
+	f.kClient.SetClockSkew(k8s.ClockSkew(-time.Minute - time.Second))
+	f.checkFor(clockSkewCheckInterval)
+	f.assertSkews(k8s.ClockSkew(-time.Minute))
+
+	f.kClient.SetClockSkew(k8s.ClockSkew(time.Second))
+	f.checkFor(clockSkewCheckInterval)
+	f.assertSkews(k8s.ClockSkew(-time.Minute), 0)
+	assert.Contains(t, f.logs.String(), "back in sync")
+}
+
+type fakeResyncer struct {
+	count int
+}
+
+func (r *fakeResyncer) ResyncCluster(ctx context.Context, st store.RStore) error {
+	r.count++
+	return nil
+}
+
+type cmFixture struct {
+	*tempdir.TempDirFixture
+	t        *testing.T
+	ctx      context.Context
+	logs     *bufsync.ThreadSafeBuffer
+	clock    clockwork.FakeClock
+	kClient  *k8s.FakeK8sClient
+	resyncer *fakeResyncer
+	cm       *ClusterMonitor
+	store    *store.TestingStore
+}
+
+func newCMFixture(t *testing.T) *cmFixture {
+	out := bufsync.NewThreadSafeBuffer()
+	ctx, _, _ := testutils.ForkedCtxAndAnalyticsForTest(out)
+	clock := clockwork.NewFakeClock()
+	kClient := k8s.NewFakeK8sClient(t)
+	resyncer := &fakeResyncer{}
+	f := &cmFixture{
+		TempDirFixture: tempdir.NewTempDirFixture(t),
+		t:              t,
+		ctx:            ctx,
+		logs:           out,
+		clock:          clock,
+		kClient:        kClient,
+		resyncer:       resyncer,
+		cm:             NewClusterMonitor(kClient, clock, ClusterResyncers{resyncer}),
+		store:          store.NewTestingStore(),
+	}
+	t.Cleanup(f.TearDown)
+	return f
+}
+
+func (f *cmFixture) addK8sManifest() {
+	state := f.store.LockMutableStateForTesting()
+	defer f.store.UnlockMutableState()
+
+	m := manifestbuilder.New(f, "sancho").
+		WithK8sYAML(testyaml.SanchoYAML).
+		Build()
+	state.UpsertManifestTarget(store.NewManifestTarget(m))
+}
+
+func (f *cmFixture) check() {
+	f.cm.check(f.ctx, f.store)
+}
+
+// Run checks at the usual interval for the given duration.
+func (f *cmFixture) checkFor(d time.Duration) {
+	for elapsed := time.Duration(0); elapsed < d; elapsed += clusterCheckInterval {
+		f.clock.Advance(clusterCheckInterval)
+		f.check()
+	}
+}
+
+func (f *cmFixture) assertStatuses(expected ...store.ClusterConnectionStatus) {
+	var actual []store.ClusterConnectionStatus
+	for _, a := range f.store.Actions() {
+		ca, ok := a.(ClusterConnectionAction)
+		if ok {
+			actual = append(actual, ca.Status)
+		}
+	}
+	assert.Equal(f.t, expected, actual)
+}
+
+func (f *cmFixture) assertSkews(expected ...k8s.ClockSkew) {
+	var actual []k8s.ClockSkew
+	for _, a := range f.store.Actions() {
+		sa, ok := a.(ClusterClockSkewAction)
+		if ok {
+			actual = append(actual, sa.Skew)
+		}
+	}
+	assert.Equal(f.t, expected, actual)
+}
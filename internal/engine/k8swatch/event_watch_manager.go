@@ -10,7 +10,9 @@ import (
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	errorutil "k8s.io/apimachinery/pkg/util/errors"
 
+	"github.com/tilt-dev/tilt/internal/engine/idle"
 	"github.com/tilt-dev/tilt/internal/k8s"
 	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/pkg/logger"
@@ -40,8 +42,16 @@ type EventWatchManager struct {
 
 	// An index of all the known events, by UID
 	knownEvents map[types.UID]*v1.Event
+
+	// Set while Tilt is asleep. See internal/engine/idle.
+	//
+	// The namespace watches are stopped, but we keep track of
+	// which namespaces we were watching, so we can restart them.
+	sleeping bool
 }
 
+var _ idle.Sleeper = &EventWatchManager{}
+
 func NewEventWatchManager(kClient k8s.Client, ownerFetcher k8s.OwnerFetcher, cfgNS k8s.Namespace) *EventWatchManager {
 	return &EventWatchManager{
 		kClient:                  kClient,
@@ -77,6 +87,10 @@ func (m *EventWatchManager) OnChange(ctx context.Context, st store.RStore, _ sto
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if m.sleeping {
+		return nil
+	}
+
 	for _, teardown := range taskList.teardownNamespaces {
 		watcher, ok := m.watcherKnownState.namespaceWatches[teardown]
 		if ok {
@@ -86,7 +100,7 @@ func (m *EventWatchManager) OnChange(ctx context.Context, st store.RStore, _ sto
 	}
 
 	for _, setup := range taskList.setupNamespaces {
-		m.setupWatch(ctx, st, setup, taskList.tiltStartTime)
+		m.setupWatch(ctx, st, setup, taskList.tiltStartTime, false)
 	}
 
 	if len(taskList.newUIDs) > 0 {
@@ -95,7 +109,10 @@ func (m *EventWatchManager) OnChange(ctx context.Context, st store.RStore, _ sto
 	return nil
 }
 
-func (m *EventWatchManager) setupWatch(ctx context.Context, st store.RStore, ns k8s.Namespace, tiltStartTime time.Time) {
+// If skipSeen is set, events we've already dispatched aren't dispatched again.
+// The watch starts by listing every existing event, so this keeps a restarted
+// watch from logging them twice.
+func (m *EventWatchManager) setupWatch(ctx context.Context, st store.RStore, ns k8s.Namespace, tiltStartTime time.Time, skipSeen bool) {
 	ch, err := m.kClient.WatchEvents(ctx, ns)
 	if err != nil {
 		err = errors.Wrapf(err, "Error watching events. Are you connected to kubernetes?\nTry running `kubectl get events -n %q`", ns)
@@ -106,7 +123,36 @@ func (m *EventWatchManager) setupWatch(ctx context.Context, st store.RStore, ns
 	ctx, cancel := context.WithCancel(ctx)
 	m.watcherKnownState.namespaceWatches[ns] = namespaceWatch{cancel: cancel}
 
-	go m.dispatchEventsLoop(ctx, ch, st, tiltStartTime)
+	go m.dispatchEventsLoop(ctx, ch, st, tiltStartTime, skipSeen)
+}
+
+// Sleep stops watching events.
+func (m *EventWatchManager) Sleep(ctx context.Context, st store.RStore) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sleeping = true
+	for _, watch := range m.watcherKnownState.namespaceWatches {
+		watch.cancel()
+	}
+	return nil
+}
+
+// Wake restarts the event watches, and dispatches any events
+// that happened while we were asleep.
+func (m *EventWatchManager) Wake(ctx context.Context, st store.RStore) error {
+	state := st.RLockState()
+	tiltStartTime := state.TiltStartTime
+	st.RUnlockState()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sleeping = false
+	for ns := range m.watcherKnownState.namespaceWatches {
+		m.setupWatch(ctx, st, ns, tiltStartTime, true)
+	}
+	return nil
 }
 
 // When new UIDs are deployed, go through all our known events and dispatch
@@ -178,7 +224,53 @@ func (m *EventWatchManager) dispatchEventChange(ctx context.Context, event *v1.E
 	st.Dispatch(store.NewK8sEventAction(event, mn))
 }
 
-func (m *EventWatchManager) dispatchEventsLoop(ctx context.Context, ch <-chan *v1.Event, st store.RStore, tiltStartTime time.Time) {
+// Relist the events in every namespace we're watching, and dispatch any
+// events that the watch missed.
+//
+// Events we've already seen are skipped, so that we don't log them twice.
+func (m *EventWatchManager) ResyncCluster(ctx context.Context, st store.RStore) error {
+	state := st.RLockState()
+	tiltStartTime := state.TiltStartTime
+	skew := state.ClusterClockSkew
+	st.RUnlockState()
+
+	m.mu.RLock()
+	var namespaces []k8s.Namespace
+	for ns := range m.watcherKnownState.namespaceWatches {
+		namespaces = append(namespaces, ns)
+	}
+	m.mu.RUnlock()
+
+	var errs []error
+	for _, ns := range namespaces {
+		events, err := m.kClient.ListEvents(ctx, ns)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, event := range events {
+			if !timecmp.AfterOrEqual(skew.ToLocalTime(event.ObjectMeta.CreationTimestamp), tiltStartTime) ||
+				!ShouldLogEvent(event) ||
+				!m.isNewEvent(event) {
+				continue
+			}
+			m.dispatchEventChange(ctx, event, st)
+		}
+	}
+	return errorutil.NewAggregate(errs)
+}
+
+// Returns true if we haven't seen this event, or if it's
+// happened again since we last saw it.
+func (m *EventWatchManager) isNewEvent(event *v1.Event) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	known, ok := m.knownEvents[event.UID]
+	return !ok || known.Count < event.Count
+}
+
+func (m *EventWatchManager) dispatchEventsLoop(ctx context.Context, ch <-chan *v1.Event, st store.RStore, tiltStartTime time.Time, skipSeen bool) {
 	for {
 		select {
 		case event, ok := <-ch:
@@ -194,7 +286,7 @@ func (m *EventWatchManager) dispatchEventsLoop(ctx context.Context, ch <-chan *v
 			// TODO(nick): We might need to remove this check and optimize
 			// it in a different way. We want Tilt to be to attach to existing
 			// resources, and these resources might have pre-existing events.
-			if !timecmp.AfterOrEqual(event.ObjectMeta.CreationTimestamp, tiltStartTime) {
+			if !timecmp.AfterOrEqual(clusterClockSkew(st).ToLocalTime(event.ObjectMeta.CreationTimestamp), tiltStartTime) {
 				continue
 			}
 
@@ -202,6 +294,10 @@ func (m *EventWatchManager) dispatchEventsLoop(ctx context.Context, ch <-chan *v
 				continue
 			}
 
+			if skipSeen && !m.isNewEvent(event) {
+				continue
+			}
+
 			go m.dispatchEventChange(ctx, event, st)
 
 		case <-ctx.Done():
@@ -220,3 +316,9 @@ func ShouldLogEvent(e *v1.Event) bool {
 
 	return e.Reason == ImagePullingReason || e.Reason == ImagePulledReason
 }
+
+func clusterClockSkew(st store.RStore) k8s.ClockSkew {
+	state := st.RLockState()
+	defer st.RUnlockState()
+	return state.ClusterClockSkew
+}
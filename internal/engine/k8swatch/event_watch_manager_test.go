@@ -11,6 +11,7 @@ import (
 	"github.com/jonboulle/clockwork"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -236,6 +237,72 @@ func TestEventWatchManager_ignoresPreStartEvents(t *testing.T) {
 	f.assertActions(expected)
 }
 
+func TestEventWatchManager_correctsForClockSkew(t *testing.T) {
+	f := newEWMFixture(t)
+	defer f.TearDown()
+
+	// The cluster's clock is a minute behind ours.
+	state := f.store.LockMutableStateForTesting()
+	state.ClusterClockSkew = k8s.ClockSkew(-time.Minute)
+	f.store.UnlockMutableState()
+
+	mn := model.ManifestName("someK8sManifest")
+	manifest := f.addManifest(mn)
+	pb := podbuilder.New(t, manifest)
+	entities := pb.ObjectTreeEntities()
+	f.addDeployedEntity(manifest, entities.Deployment())
+	f.kClient.Inject(entities...)
+
+	entity := k8s.NewK8sEntity(pb.Build())
+
+	// Happened two minutes before Tilt started.
+	evt1 := f.makeEvent(entity)
+	evt1.CreationTimestamp = apis.NewTime(f.clock.Now().Add(-3 * time.Minute))
+	f.kClient.UpsertEvent(evt1)
+
+	// Happened just now, but the cluster's clock says it was before Tilt started.
+	evt2 := f.makeEvent(entity)
+	evt2.CreationTimestamp = apis.NewTime(f.clock.Now().Add(-time.Minute))
+	f.kClient.UpsertEvent(evt2)
+
+	f.assertActions(store.K8sEventAction{Event: evt2, ManifestName: mn})
+}
+
+func TestEventWatchManager_sleepAndWake(t *testing.T) {
+	f := newEWMFixture(t)
+	defer f.TearDown()
+
+	mn := model.ManifestName("someK8sManifest")
+	manifest := f.addManifest(mn)
+	pb := podbuilder.New(t, manifest)
+	entities := pb.ObjectTreeEntities()
+	f.addDeployedEntity(manifest, entities.Deployment())
+	f.kClient.Inject(entities...)
+
+	entity := k8s.NewK8sEntity(pb.Build())
+	evt1 := f.makeEvent(entity)
+	evt1.Name = "evt1"
+	evt1.UID = "evt1-uid"
+	f.kClient.UpsertEvent(evt1)
+	f.assertActions(store.K8sEventAction{Event: evt1, ManifestName: mn})
+
+	require.NoError(t, f.ewm.Sleep(f.ctx, f.store))
+	f.store.ClearActions()
+
+	// While we're asleep, we don't see new events, or start new watches.
+	evt2 := f.makeEvent(entity)
+	evt2.Name = "evt2"
+	evt2.UID = "evt2-uid"
+	f.kClient.UpsertEvent(evt2)
+	_ = f.ewm.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	f.assertNoActions()
+
+	// When we wake up, the new watch lists every event,
+	// but we only dispatch the one we missed.
+	require.NoError(t, f.ewm.Wake(f.ctx, f.store))
+	f.assertActions(store.K8sEventAction{Event: evt2, ManifestName: mn})
+}
+
 func (f *ewmFixture) makeEvent(obj k8s.K8sEntity) *v1.Event {
 	return &v1.Event{
 		ObjectMeta: metav1.ObjectMeta{
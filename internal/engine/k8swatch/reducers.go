@@ -2,6 +2,7 @@ package k8swatch
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
@@ -11,12 +12,26 @@ import (
 
 	"github.com/tilt-dev/tilt/internal/k8s"
 	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/store/buildcontrols"
 	"github.com/tilt-dev/tilt/internal/store/k8sconv"
 	"github.com/tilt-dev/tilt/internal/store/liveupdates"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/logger"
 	"github.com/tilt-dev/tilt/pkg/model"
 )
 
+func HandleClusterConnectionAction(state *store.EngineState, a ClusterConnectionAction) {
+	state.ClusterConnection = store.ClusterConnection{
+		Status: a.Status,
+		Error:  a.Error,
+		Since:  a.Time,
+	}
+}
+
+func HandleClusterClockSkewAction(state *store.EngineState, a ClusterClockSkewAction) {
+	state.ClusterClockSkew = a.Skew
+}
+
 func HandleKubernetesDiscoveryUpdateStatusAction(ctx context.Context, state *store.EngineState, a KubernetesDiscoveryUpdateStatusAction) {
 	UpdateK8sRuntimeState(ctx, state, a.ObjectMeta, a.Status)
 }
@@ -60,6 +75,21 @@ func UpdateK8sRuntimeState(ctx context.Context, state *store.EngineState, objMet
 	if anyPodsUpdated {
 		liveupdates.CheckForContainerCrash(state, mn.String())
 	}
+
+	// While a build is in progress, the pods are expected to be running
+	// the images from the previous build.
+	if !state.IsCurrentlyBuilding(mn) {
+		for _, drift := range ms.UpdateImageDrifts(time.Now()) {
+			msg := fmt.Sprintf("Pod %s, container %s: %s. Trigger an update to re-deploy (e.g., tilt trigger %s).\n",
+				drift.Pod, drift.Container, drift.Message(), mn)
+			le := store.NewLogAction(mn, ms.LastBuild().SpanID, logger.WarnLvl, nil, []byte(msg))
+			state.LogStore.Append(le, state.Secrets)
+		}
+	}
+
+	if !ms.PendingLatency.Ready.IsZero() {
+		buildcontrols.FinishLatency(state, mt)
+	}
 }
 
 func maybeUpdateStateForPod(ms *store.ManifestState, pod *v1alpha1.Pod) bool {
@@ -94,12 +124,18 @@ func maybeUpdateStateForPod(ms *store.ManifestState, pod *v1alpha1.Pod) bool {
 		// restart count so that everything that happened before Tilt was aware of the Pod can be ignored
 		// (this is also updated after a live update by the build controller)
 		runtime.BaselineRestarts[podID] = store.AllPodContainerRestarts(*pod)
+
+		latency := &ms.PendingLatency
+		if !latency.Empty() && !latency.LiveUpdate && latency.PodObserved.IsZero() {
+			latency.PodObserved = time.Now()
+		}
 	} else if equality.Semantic.DeepEqual(existing, pod) {
 		return false
 	}
 
 	// Track the new version
 	runtime.Pods[podID] = pod
+	runtime.UpdateTaskRunForPod(pod)
 
 	isReadyOrSucceeded := false
 	if ms.K8sRuntimeState().PodReadinessMode == model.PodReadinessSucceeded {
@@ -110,6 +146,12 @@ func maybeUpdateStateForPod(ms *store.ManifestState, pod *v1alpha1.Pod) bool {
 	}
 	if isReadyOrSucceeded {
 		runtime.LastReadyOrSucceededTime = time.Now()
+
+		// A full deploy is only ready once one of its new pods is.
+		latency := &ms.PendingLatency
+		if !latency.Empty() && (latency.LiveUpdate || !latency.PodObserved.IsZero()) {
+			latency.Ready = runtime.LastReadyOrSucceededTime
+		}
 	}
 
 	ms.RuntimeState = runtime
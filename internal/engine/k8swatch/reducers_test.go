@@ -0,0 +1,325 @@
+package k8swatch
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/tilt-dev/tilt/internal/container"
+	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/store/buildcontrols"
+	"github.com/tilt-dev/tilt/internal/testutils/manifestbuilder"
+	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestLatencyFullDeploy(t *testing.T) {
+	f := newLatencyFixture(t)
+
+	f.changeFile("main.go")
+	f.build(store.BuildResultSet{})
+	assert.True(t, f.lastBuild().Latency.Empty(), "latency should wait for the new pod")
+
+	f.pods(pendingPod("pod-1"))
+	assert.True(t, f.lastBuild().Latency.Empty(), "latency should wait for the new pod to be ready")
+
+	f.pods(readyPod("pod-1"))
+	f.assertPhases(model.LatencyPhaseBuild, model.LatencyPhaseRollout, model.LatencyPhaseReady)
+	assert.Contains(t, f.logs(), "updated in ")
+	assert.Contains(t, f.logs(), "build ")
+	assert.False(t, f.lastBuild().TriggerTime.IsZero())
+}
+
+func TestLatencyIgnoresPodsFromBeforeTheDeploy(t *testing.T) {
+	f := newLatencyFixture(t)
+
+	f.pods(readyPod("pod-1"))
+	f.changeFile("main.go")
+	f.build(store.BuildResultSet{})
+
+	// The old pod is still around and ready, but it's not the one we deployed.
+	f.pods(readyPod("pod-1"))
+	assert.True(t, f.lastBuild().Latency.Empty())
+
+	f.pods(readyPod("pod-1"), readyPod("pod-2"))
+	f.assertPhases(model.LatencyPhaseBuild, model.LatencyPhaseRollout, model.LatencyPhaseReady)
+}
+
+func TestLatencyLiveUpdate(t *testing.T) {
+	f := newLatencyFixture(t)
+
+	f.pods(readyPod("pod-1"))
+	f.changeFile("main.go")
+	f.build(f.liveUpdateResult())
+	f.assertPhases(model.LatencyPhaseSync)
+	assert.Contains(t, f.logs(), "updated in ")
+	assert.Contains(t, f.logs(), "sync ")
+}
+
+func TestLatencyLiveUpdateWithRestart(t *testing.T) {
+	f := newLatencyFixture(t)
+
+	f.pods(pendingPod("pod-1"))
+	f.changeFile("main.go")
+	f.build(f.liveUpdateResult())
+	assert.True(t, f.lastBuild().Latency.Empty(), "latency should wait for the restarted container")
+
+	f.pods(readyPod("pod-1"))
+	f.assertPhases(model.LatencyPhaseSync, model.LatencyPhaseRestart)
+}
+
+func TestLatencyNotTrackedOnError(t *testing.T) {
+	f := newLatencyFixture(t)
+
+	f.changeFile("main.go")
+	f.buildWithError(assert.AnError)
+	f.pods(readyPod("pod-1"))
+	assert.True(t, f.lastBuild().Latency.Empty())
+	assert.NotContains(t, f.logs(), "updated in ")
+}
+
+func TestLatencyManualTriggerStartsAtBuild(t *testing.T) {
+	f := newLatencyFixture(t)
+
+	f.changeFile("main.go")
+	f.startBuild(model.BuildReasonFlagChangedFiles | model.BuildReasonFlagTriggerWeb)
+	f.completeBuild(store.BuildResultSet{}, nil)
+	assert.True(t, f.lastBuild().TriggerTime.IsZero())
+}
+
+const (
+	builtTag    = "tilt-0123456789abcdef"
+	builtDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	otherDigest = "sha256:fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210"
+)
+
+func TestImageDriftWarnsWhenPodRunsDifferentImage(t *testing.T) {
+	f := newImageDriftFixture(t)
+
+	f.builtImage(builtTag)
+	f.pods(runningPod("pod-1", testyaml.SanchoImage+":v2", "docker-pullable://"+testyaml.SanchoImage+"@"+otherDigest))
+
+	drifts := f.ms().K8sRuntimeState().ImageDrifts
+	require.Len(t, drifts, 1)
+	assert.Equal(t, "pod-1", drifts[0].Pod.String())
+	assert.Equal(t, "sancho", drifts[0].Container.String())
+	assert.Equal(t, "running image differs from last Tilt build: sha256:fedcba987654 vs expected :tilt-0123456789abcdef",
+		drifts[0].Message())
+	assert.Contains(t, f.logs(), "Trigger an update to re-deploy (e.g., tilt trigger sancho)")
+
+	// Only warn once.
+	since := drifts[0].Since
+	f.pods(runningPod("pod-1", testyaml.SanchoImage+":v2", "docker-pullable://"+testyaml.SanchoImage+"@"+otherDigest))
+	assert.Equal(t, since, f.ms().K8sRuntimeState().ImageDrifts[0].Since)
+	assert.Equal(t, 1, strings.Count(f.logs(), "running image differs"))
+}
+
+func TestImageDriftMatchesEquivalentRefs(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		image   string
+		imageID string
+	}{
+		{"same tag", testyaml.SanchoImage + ":" + builtTag, "docker-pullable://" + testyaml.SanchoImage + "@" + otherDigest},
+		{"image reported by digest", testyaml.SanchoImage + "@" + builtDigest, "docker://" + builtDigest},
+		{"registry rewrote the tag", testyaml.SanchoImage + ":latest", "docker://" + builtDigest},
+		{"registry rewrote the ref to a digest", "registry.local/sancho@" + otherDigest, "registry.local/sancho@" + otherDigest},
+		{"other registry", "localhost:5000/sancho:" + builtTag, ""},
+		{"sidecar", "envoyproxy/envoy:v1.20", "docker-pullable://envoyproxy/envoy@" + otherDigest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newImageDriftFixture(t)
+
+			f.builtImage(builtTag)
+			f.pods(runningPod("pod-1", tc.image, tc.imageID))
+			assert.Empty(t, f.ms().K8sRuntimeState().ImageDrifts)
+			assert.NotContains(t, f.logs(), "running image differs")
+		})
+	}
+}
+
+func TestImageDriftIgnoredWhileBuilding(t *testing.T) {
+	f := newImageDriftFixture(t)
+
+	f.builtImage(builtTag)
+	f.startBuild(model.BuildReasonFlagChangedFiles)
+	f.state.CurrentlyBuilding[f.m.Name] = true
+	f.pods(runningPod("pod-1", testyaml.SanchoImage+":v2", ""))
+	assert.Empty(t, f.ms().K8sRuntimeState().ImageDrifts)
+}
+
+func TestImageDriftClearedByRedeploy(t *testing.T) {
+	f := newImageDriftFixture(t)
+
+	f.builtImage(builtTag)
+	f.pods(runningPod("pod-1", testyaml.SanchoImage+":v2", ""))
+	require.Len(t, f.ms().K8sRuntimeState().ImageDrifts, 1)
+
+	// Triggering a re-deploy clears the warning right away...
+	f.startBuild(model.BuildReasonFlagTriggerWeb)
+	assert.Empty(t, f.ms().K8sRuntimeState().ImageDrifts)
+	f.completeBuild(f.imageResult(builtTag), nil)
+
+	// ...and it stays clear once the re-deployed pod is running the built image.
+	f.pods(runningPod("pod-2", testyaml.SanchoImage+":"+builtTag, ""))
+	assert.Empty(t, f.ms().K8sRuntimeState().ImageDrifts)
+}
+
+type reducerFixture struct {
+	t     *testing.T
+	ctx   context.Context
+	state *store.EngineState
+	m     model.Manifest
+
+	// When file changes happen. Builds start a second later, so that
+	// they're always after the change that triggered them.
+	changeTime time.Time
+}
+
+func newLatencyFixture(t *testing.T) *reducerFixture {
+	tf := tempdir.NewTempDirFixture(t)
+	t.Cleanup(tf.TearDown)
+
+	m := manifestbuilder.New(tf, "sancho").WithK8sYAML(testyaml.SanchoYAML).Build()
+	return newK8sFixture(t, m)
+}
+
+func newImageDriftFixture(t *testing.T) *reducerFixture {
+	tf := tempdir.NewTempDirFixture(t)
+	t.Cleanup(tf.TearDown)
+
+	m := manifestbuilder.New(tf, "sancho").
+		WithK8sYAML(testyaml.SanchoYAML).
+		WithImageTarget(testyaml.SanchoImage, ".").
+		Build()
+	return newK8sFixture(t, m)
+}
+
+func newK8sFixture(t *testing.T, m model.Manifest) *reducerFixture {
+	state := store.NewState()
+	state.UpsertManifestTarget(store.NewManifestTarget(m))
+	ms, _ := state.ManifestState(m.Name)
+	ms.RuntimeState = store.NewK8sRuntimeState(m)
+
+	return &reducerFixture{
+		t:          t,
+		ctx:        context.Background(),
+		state:      state,
+		m:          m,
+		changeTime: time.Now().Add(-time.Minute),
+	}
+}
+
+func (f *reducerFixture) ms() *store.ManifestState {
+	ms, _ := f.state.ManifestState(f.m.Name)
+	return ms
+}
+
+func (f *reducerFixture) changeFile(path string) {
+	f.ms().AddPendingFileChange(f.m.K8sTarget().ID(), path, f.changeTime)
+}
+
+func (f *reducerFixture) startBuild(reason model.BuildReason) {
+	buildcontrols.HandleBuildStarted(f.ctx, f.state, buildcontrols.BuildStartedAction{
+		ManifestName: f.m.Name,
+		StartTime:    f.changeTime.Add(time.Second),
+		Reason:       reason,
+		SpanID:       "build:1",
+	})
+}
+
+func (f *reducerFixture) completeBuild(result store.BuildResultSet, err error) {
+	buildcontrols.HandleBuildCompleted(f.ctx, f.state,
+		buildcontrols.NewBuildCompleteAction(f.m.Name, "build:1", result, err))
+}
+
+func (f *reducerFixture) build(result store.BuildResultSet) {
+	f.startBuild(model.BuildReasonFlagChangedFiles)
+	f.completeBuild(result, nil)
+}
+
+func (f *reducerFixture) buildWithError(err error) {
+	f.startBuild(model.BuildReasonFlagChangedFiles)
+	f.completeBuild(store.BuildResultSet{}, err)
+}
+
+func (f *reducerFixture) liveUpdateResult() store.BuildResultSet {
+	id := f.m.K8sTarget().ID()
+	return store.BuildResultSet{
+		id: store.NewLiveUpdateBuildResult(id, []container.ID{"c1"}),
+	}
+}
+
+func (f *reducerFixture) imageResult(tag string) store.BuildResultSet {
+	iTarget := f.m.ImageTargetAt(0)
+	ref := container.MustWithTag(iTarget.Refs.ClusterRef(), tag)
+	return store.BuildResultSet{
+		iTarget.ID(): store.NewImageBuildResultSingleRef(iTarget.ID(), ref),
+	}
+}
+
+// Records a successful image build, without going through a build.
+func (f *reducerFixture) builtImage(tag string) {
+	for id, result := range f.imageResult(tag) {
+		f.ms().MutableBuildStatus(id).LastResult = result
+	}
+}
+
+func (f *reducerFixture) pods(pods ...v1alpha1.Pod) {
+	objMeta := &metav1.ObjectMeta{
+		Name:        f.m.Name.String(),
+		Annotations: map[string]string{v1alpha1.AnnotationManifest: f.m.Name.String()},
+	}
+	UpdateK8sRuntimeState(f.ctx, f.state, objMeta, &v1alpha1.KubernetesDiscoveryStatus{Pods: pods})
+}
+
+func (f *reducerFixture) lastBuild() model.BuildRecord {
+	return f.ms().LastBuild()
+}
+
+func (f *reducerFixture) logs() string {
+	return f.state.LogStore.ManifestLog(f.m.Name)
+}
+
+func (f *reducerFixture) assertPhases(names ...string) {
+	f.t.Helper()
+	latency := f.lastBuild().Latency
+	require.False(f.t, latency.Empty(), "expected latency on the last build")
+
+	actual := []string{}
+	var sum time.Duration
+	for _, p := range latency.Phases {
+		actual = append(actual, p.Name)
+		sum += p.Duration
+	}
+	assert.Equal(f.t, names, actual)
+	assert.Equal(f.t, latency.Total, sum)
+}
+
+func pendingPod(name string) v1alpha1.Pod {
+	return v1alpha1.Pod{
+		Name:        name,
+		AncestorUID: "deployment-uid",
+		Containers:  []v1alpha1.Container{{Name: "sancho"}},
+	}
+}
+
+func readyPod(name string) v1alpha1.Pod {
+	pod := pendingPod(name)
+	pod.Containers[0].Ready = true
+	return pod
+}
+
+func runningPod(name string, image string, imageID string) v1alpha1.Pod {
+	pod := readyPod(name)
+	pod.Containers[0].Image = image
+	pod.Containers[0].ImageID = imageID
+	return pod
+}
@@ -0,0 +1,46 @@
+package k8swatch
+
+import (
+	"context"
+
+	"github.com/tilt-dev/tilt/internal/hud/server"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// Re-reads the local registry that the cluster advertises after we lose
+// touch with the cluster.
+//
+// The cluster may have been re-created in the meantime (e.g., with
+// `k3d cluster delete && k3d cluster create --registry-create`) with a
+// different registry. The Tiltfile decides how to rewrite image references,
+// so it needs to reload to pick up the new registry.
+type RegistryResyncer struct {
+	kCli k8s.Client
+}
+
+var _ ClusterResyncer = &RegistryResyncer{}
+
+func NewRegistryResyncer(kCli k8s.Client) *RegistryResyncer {
+	return &RegistryResyncer{kCli: kCli}
+}
+
+func (r *RegistryResyncer) ResyncCluster(ctx context.Context, st store.RStore) error {
+	registry, changed := r.kCli.RefreshLocalRegistry(ctx)
+	if !changed {
+		return nil
+	}
+
+	host := registry.Host
+	if host == "" {
+		host = "none"
+	}
+	logger.Get(ctx).Infof("Cluster's local registry changed to %s. Reloading Tiltfile...", host)
+	st.Dispatch(server.AppendToTriggerQueueAction{
+		Name:   model.MainTiltfileManifestName,
+		Reason: model.BuildReasonFlagTriggerUnknown,
+	})
+	return nil
+}
@@ -0,0 +1,39 @@
+package k8swatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/internal/container"
+	"github.com/tilt-dev/tilt/internal/hud/server"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/testutils"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestRegistryResyncerReloadsTiltfileWhenRegistryChanges(t *testing.T) {
+	ctx, _, _ := testutils.CtxAndAnalyticsForTest()
+	kClient := k8s.NewFakeK8sClient(t)
+	st := store.NewTestingStore()
+	r := NewRegistryResyncer(kClient)
+
+	kClient.LocalRegistry(ctx)
+	require.NoError(t, r.ResyncCluster(ctx, st))
+	assert.Empty(t, st.Actions())
+
+	kClient.Registry = container.MustNewRegistry("localhost:5000")
+	require.NoError(t, r.ResyncCluster(ctx, st))
+	assert.Equal(t, []store.Action{
+		server.AppendToTriggerQueueAction{
+			Name:   model.MainTiltfileManifestName,
+			Reason: model.BuildReasonFlagTriggerUnknown,
+		},
+	}, st.Actions())
+
+	// Once we've seen the new registry, we don't reload again.
+	require.NoError(t, r.ResyncCluster(ctx, st))
+	assert.Len(t, st.Actions(), 1)
+}
@@ -6,7 +6,9 @@ import (
 
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/types"
+	errorutil "k8s.io/apimachinery/pkg/util/errors"
 
 	"github.com/tilt-dev/tilt/internal/k8s"
 	"github.com/tilt-dev/tilt/internal/store"
@@ -120,6 +122,50 @@ func (w *ServiceWatcher) triageServiceUpdate(service *v1.Service) model.Manifest
 	return manifestName
 }
 
+// Relist the services in every namespace we're watching, and dispatch
+// any changes that the watch missed.
+func (w *ServiceWatcher) ResyncCluster(ctx context.Context, st store.RStore) error {
+	w.mu.RLock()
+	var namespaces []k8s.Namespace
+	for ns := range w.watcherKnownState.namespaceWatches {
+		namespaces = append(namespaces, ns)
+	}
+	w.mu.RUnlock()
+
+	var errs []error
+	for _, ns := range namespaces {
+		services, err := w.kCli.ListServices(ctx, ns)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, service := range services {
+			if !w.hasServiceChanged(service) {
+				continue
+			}
+
+			manifestName := w.triageServiceUpdate(service)
+			if manifestName == "" {
+				continue
+			}
+
+			err := DispatchServiceChange(st, service, manifestName, w.kCli.NodeIP(ctx))
+			if err != nil {
+				logger.Get(ctx).Infof("error resolving service url %s: %v", service.Name, err)
+			}
+		}
+	}
+	return errorutil.NewAggregate(errs)
+}
+
+func (w *ServiceWatcher) hasServiceChanged(service *v1.Service) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	known, ok := w.knownServices[service.UID]
+	return !ok || !equality.Semantic.DeepEqual(known, service)
+}
+
 func (w *ServiceWatcher) dispatchServiceChangesLoop(ctx context.Context, ch <-chan *v1.Service, st store.RStore) {
 	for {
 		select {
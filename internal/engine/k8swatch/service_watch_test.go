@@ -95,6 +95,55 @@ func TestServiceWatchUIDDelayed(t *testing.T) {
 	f.assertObservedServiceChangeActions(expected...)
 }
 
+func TestServiceWatchResyncCluster(t *testing.T) {
+	f := newSWFixture(t)
+	defer f.TearDown()
+
+	manifest := f.addManifest("server")
+	s := servicebuilder.New(f.t, manifest).
+		WithPort(9998).
+		WithNodePort(9998).
+		WithIP(string(f.nip)).
+		WithUID("fake-uid").
+		Build()
+	f.addDeployedService(manifest, s)
+	f.kClient.UpsertService(s)
+	f.waitUntilServiceKnown(s.UID)
+	f.assertObservedServiceChangeActions(ServiceChangeAction{
+		Service:      s,
+		ManifestName: manifest.Name,
+		URL:          &url.URL{Scheme: "http", Host: fmt.Sprintf("%s:9998", f.nip), Path: "/"},
+	})
+
+	// The port changes while the watch isn't delivering events.
+	f.kClient.StartWatchGap()
+	changed := s.DeepCopy()
+	changed.Spec.Ports[0].Port = 9999
+	changed.Spec.Ports[0].NodePort = 9999
+	f.kClient.UpsertService(changed)
+	f.kClient.EndWatchGap()
+
+	err := f.sw.ResyncCluster(f.ctx, f.store)
+	assert.NoError(t, err)
+
+	// Resyncing again is a no-op, because nothing else changed.
+	err = f.sw.ResyncCluster(f.ctx, f.store)
+	assert.NoError(t, err)
+
+	f.assertObservedServiceChangeActions(
+		ServiceChangeAction{
+			Service:      s,
+			ManifestName: manifest.Name,
+			URL:          &url.URL{Scheme: "http", Host: fmt.Sprintf("%s:9998", f.nip), Path: "/"},
+		},
+		ServiceChangeAction{
+			Service:      changed,
+			ManifestName: manifest.Name,
+			URL:          &url.URL{Scheme: "http", Host: fmt.Sprintf("%s:9999", f.nip), Path: "/"},
+		},
+	)
+}
+
 func (f *swFixture) addManifest(manifestName model.ManifestName) model.Manifest {
 	state := f.store.LockMutableStateForTesting()
 	defer f.store.UnlockMutableState()
@@ -4,6 +4,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
 )
 
 type CmdCreateAction struct {
@@ -20,6 +21,7 @@ func (CmdCreateAction) Action() {}
 
 func (a CmdCreateAction) Summarize(s *store.ChangeSummary) {
 	s.CmdSpecs.Add(types.NamespacedName{Name: a.Cmd.Name})
+	summarizeCmdManifest(a.Cmd, s)
 }
 
 type CmdUpdateStatusAction struct {
@@ -30,8 +32,35 @@ func NewCmdUpdateStatusAction(cmd *Cmd) CmdUpdateStatusAction {
 	return CmdUpdateStatusAction{Cmd: cmd.DeepCopy()}
 }
 
+var _ store.Summarizer = CmdUpdateStatusAction{}
+
 func (CmdUpdateStatusAction) Action() {}
 
+func (a CmdUpdateStatusAction) Summarize(s *store.ChangeSummary) {
+	s.CmdStatuses.Add(types.NamespacedName{Name: a.Cmd.Name})
+	summarizeCmdManifest(a.Cmd, s)
+}
+
+// A Cmd that runs a manifest's server also updates the manifest's runtime status.
+func summarizeCmdManifest(cmd *Cmd, s *store.ChangeSummary) {
+	mn := cmd.Annotations[v1alpha1.AnnotationManifest]
+	if mn != "" {
+		s.ManifestStatuses.Add(types.NamespacedName{Name: mn})
+	}
+}
+
+// Dispatched when a server's liveness restart backoff runs out, so that
+// the ServerController takes another look at it.
+type CmdServerBackoffAction struct {
+	CmdName string
+}
+
+func (CmdServerBackoffAction) Action() {}
+
+func (a CmdServerBackoffAction) Summarize(s *store.ChangeSummary) {
+	s.CmdStatuses.Add(types.NamespacedName{Name: a.CmdName})
+}
+
 type CmdDeleteAction struct {
 	Name string
 }
@@ -3,10 +3,11 @@ package local
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
-	"k8s.io/apimachinery/pkg/api/equality"
+	"github.com/jonboulle/clockwork"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -15,6 +16,7 @@ import (
 	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
 	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
 	"github.com/tilt-dev/tilt/pkg/model/logstore"
 )
 
@@ -30,15 +32,27 @@ const AnnotationDepStatus = "tilt.dev/dep-status"
 //
 // A CmdServer offers two constraints on top of a Cmd:
 //
-// - We ensure that the old Cmd is terminated before we replace it
-//   with a new one, because they likely use the same port.
+//   - We ensure that the old Cmd is terminated before we replace it
+//     with a new one, because they likely use the same port.
 //
-// - We report the Cmd status Terminated as an Error state,
-//   and report it in a standard way.
+//   - We report the Cmd status Terminated as an Error state,
+//     and report it in a standard way.
+//
+// A server that can reload its config in place gets a signal instead
+// of a restart when only its reloadable inputs change.
+//
+// A server that fails its liveness probe is restarted, with a backoff
+// so that a server that hangs every time doesn't restart in a tight loop.
 type ServerController struct {
 	recentlyCreatedCmd map[string]string
 	createdTriggerTime map[string]time.Time
 	client             ctrlclient.Client
+	signaler           ProcessSignaler
+	clock              clockwork.Clock
+
+	// The spec that each server's running Cmd was last started or reloaded with.
+	applied        map[string]appliedServerSpec
+	lastReloadTime map[string]time.Time
 
 	// store latest copies of CmdServer to allow introspection by tests
 	// via a substitute for a `GET` API endpoint
@@ -46,16 +60,26 @@ type ServerController struct {
 	mu         sync.Mutex
 	cmdServers map[string]CmdServer
 
-	cmdCount int
+	// How many times we've started each server. Never reset, so that
+	// every run gets a unique Cmd name and log span.
+	runCounts map[string]int
+
+	livenessBackoffs map[string]*livenessBackoff
 }
 
 var _ store.Subscriber = &ServerController{}
 
-func NewServerController(client ctrlclient.Client) *ServerController {
+func NewServerController(client ctrlclient.Client, signaler ProcessSignaler, clock clockwork.Clock) *ServerController {
 	return &ServerController{
 		recentlyCreatedCmd: make(map[string]string),
 		createdTriggerTime: make(map[string]time.Time),
 		client:             client,
+		signaler:           signaler,
+		clock:              clock,
+		applied:            make(map[string]appliedServerSpec),
+		lastReloadTime:     make(map[string]time.Time),
+		runCounts:          make(map[string]int),
+		livenessBackoffs:   make(map[string]*livenessBackoff),
 	}
 }
 
@@ -66,7 +90,9 @@ func (c *ServerController) upsert(server CmdServer) {
 }
 
 func (c *ServerController) OnChange(ctx context.Context, st store.RStore, summary store.ChangeSummary) error {
-	if summary.IsLogOnly() {
+	// Servers are derived from manifests, the Cmds that run them,
+	// and the ConfigMaps that disable them.
+	if !summary.ManifestsChanged() && !summary.CmdsChanged() && !summary.ConfigMapsChanged() {
 		return nil
 	}
 
@@ -91,8 +117,7 @@ func (c *ServerController) OnChange(ctx context.Context, st store.RStore, summar
 
 // Returns a list of server objects and the Cmd they own (if any).
 func (c *ServerController) determineServers(ctx context.Context, st store.RStore) (servers []CmdServer, owned [][]*Cmd, orphaned []*Cmd) {
-	state := st.RLockState()
-	defer st.RUnlockState()
+	state := st.Snapshot()
 
 	// Find all the Cmds owned by CmdServer.
 	//
@@ -137,7 +162,14 @@ func (c *ServerController) determineServers(ctx context.Context, st store.RStore
 				Env:            lt.ServeCmd.Env,
 				TriggerTime:    mt.State.LastSuccessfulDeployTime,
 				ReadinessProbe: lt.ReadinessProbe,
+				LivenessProbe:  lt.LivenessProbe,
 				DisableSource:  lt.ServeCmdDisableSource,
+				Reload:         lt.ServeReload,
+				LogLevelFormat: lt.LogLevelFormat,
+			},
+			Status: CmdServerStatus{
+				LastReloadTime:   c.lastReloadTime[name],
+				LivenessRestarts: c.livenessBackoffs[name].totalRestarts(),
 			},
 		}
 
@@ -265,13 +297,26 @@ func (c *ServerController) reconcile(ctx context.Context, server CmdServer, owne
 		Dir:            server.Spec.Dir,
 		Env:            server.Spec.Env,
 		ReadinessProbe: server.Spec.ReadinessProbe,
+		LivenessProbe:  server.Spec.LivenessProbe,
+		LogLevelFormat: server.Spec.LogLevelFormat,
 	}
 
 	triggerTime := c.createdTriggerTime[name]
 	mostRecent := c.mostRecentCmd(ownedCmds)
-	if mostRecent != nil && equality.Semantic.DeepEqual(mostRecent.Spec, cmdSpec) && triggerTime.Equal(server.Spec.TriggerTime) {
-		// We're in the correct state! Nothing to do.
-		return
+	if mostRecent != nil {
+		change := c.classify(ctx, server, mostRecent, cmdSpec, triggerTime)
+		if change == serverUnchanged && !c.livenessRestartDue(ctx, server, mostRecent, st) {
+			// We're in the correct state! Nothing to do.
+			return
+		}
+
+		if change == serverNeedsReload {
+			err := c.reload(ctx, server, mostRecent, cmdSpec)
+			if err == nil {
+				return
+			}
+			logger.Get(ctx).Infof("Unable to reload server, restarting instead: %v", err)
+		}
 	}
 
 	// Otherwise, we need to create a new command.
@@ -292,10 +337,11 @@ func (c *ServerController) reconcile(ctx context.Context, server CmdServer, owne
 
 	// Start the command!
 	c.createdTriggerTime[name] = server.Spec.TriggerTime
-	c.cmdCount++
+	c.runCounts[name]++
+	runNumber := c.runCounts[name]
 
-	cmdName := fmt.Sprintf("%s-serve-%d", name, c.cmdCount)
-	spanID := SpanIDForServeLog(c.cmdCount)
+	cmdName := fmt.Sprintf("%s-serve-%d", name, runNumber)
+	spanID := SpanIDForServeLog(name, runNumber)
 
 	cmd := &Cmd{
 		ObjectMeta: ObjectMeta{
@@ -306,13 +352,19 @@ func (c *ServerController) reconcile(ctx context.Context, server CmdServer, owne
 				AnnotationOwnerName: name,
 				AnnotationOwnerKind: "CmdServer",
 
-				v1alpha1.AnnotationManifest: name,
-				v1alpha1.AnnotationSpanID:   string(spanID),
+				v1alpha1.AnnotationManifest:  name,
+				v1alpha1.AnnotationSpanID:    string(spanID),
+				v1alpha1.AnnotationRunNumber: strconv.Itoa(runNumber),
 			},
 		},
 		Spec: cmdSpec,
 	}
 	c.recentlyCreatedCmd[name] = cmdName
+	c.applied[name] = appliedServerSpec{
+		cmdName:     cmdName,
+		spec:        cmdSpec,
+		filesDigest: reloadFilesDigest(server.Spec.Reload),
+	}
 
 	err = c.client.Create(ctx, cmd)
 	if err != nil && !apierrors.IsNotFound(err) {
@@ -323,6 +375,170 @@ func (c *ServerController) reconcile(ctx context.Context, server CmdServer, owne
 	st.Dispatch(CmdCreateAction{Cmd: cmd})
 }
 
+// Compares the running Cmd against the server's current spec.
+func (c *ServerController) classify(ctx context.Context, server CmdServer, cmd *Cmd, cmdSpec CmdSpec, triggerTime time.Time) serverChange {
+	triggered := !triggerTime.Equal(server.Spec.TriggerTime)
+	applied, ok := c.applied[server.Name]
+	if !ok || applied.cmdName != cmd.Name {
+		// We didn't start this Cmd, so we can't know what it was reloaded with.
+		return classifyServerChange(nil, cmd.Spec, cmdSpec, "", "", triggered)
+	}
+
+	if !applied.reloadTime.IsZero() && cmd.Status.Terminated != nil &&
+		cmd.Status.Terminated.FinishedAt.Time.Sub(applied.reloadTime) < reloadExitWindow {
+		logger.Get(ctx).Infof("Server exited after reload, restarting")
+		return serverNeedsRestart
+	}
+
+	newDigest := applied.filesDigest
+	if server.Spec.Reload != nil {
+		newDigest = reloadFilesDigest(server.Spec.Reload)
+	}
+	return classifyServerChange(server.Spec.Reload, applied.spec, cmdSpec, applied.filesDigest, newDigest, triggered)
+}
+
+// Asks the running Cmd to reload its config in place.
+func (c *ServerController) reload(ctx context.Context, server CmdServer, cmd *Cmd, cmdSpec CmdSpec) error {
+	name := server.Name
+	reload := server.Spec.Reload
+	if cmd.Status.Running == nil || cmd.Status.Running.PID == 0 {
+		return fmt.Errorf("server is not running")
+	}
+
+	applied := c.applied[name]
+	digest := reloadFilesDigest(reload)
+	err := writeReloadEnvFile(reload, cmdSpec)
+	if err != nil {
+		return fmt.Errorf("writing env file: %v", err)
+	}
+
+	signal := reload.Signal
+	if signal == "" {
+		signal = "SIGHUP"
+	}
+	pid := int(cmd.Status.Running.PID)
+	err = c.signaler.Signal(pid, signal)
+	if err != nil {
+		return fmt.Errorf("sending %s: %v", signal, err)
+	}
+
+	logger.Get(ctx).Infof("Reloading server (%s changed): sent %s to pid %d",
+		describeReloadChange(reload, applied.spec, cmdSpec, applied.filesDigest, digest), signal, pid)
+
+	now := time.Now()
+	c.applied[name] = appliedServerSpec{
+		cmdName:     cmd.Name,
+		spec:        cmdSpec,
+		filesDigest: digest,
+		reloadTime:  now,
+	}
+	c.createdTriggerTime[name] = server.Spec.TriggerTime
+	c.lastReloadTime[name] = now
+
+	server.Status.LastReloadTime = now
+	c.upsert(server)
+	return nil
+}
+
+// Decides whether to restart a server that failed its liveness probe.
+//
+// Waits out the backoff first, and asks the store to wake us up when it's over.
+func (c *ServerController) livenessRestartDue(ctx context.Context, server CmdServer, cmd *Cmd, st store.RStore) bool {
+	running := cmd.Status.Running
+	if running == nil || running.LivenessFailure == "" {
+		return false
+	}
+
+	name := server.Name
+	b, ok := c.livenessBackoffs[name]
+	if !ok {
+		b = &livenessBackoff{}
+		c.livenessBackoffs[name] = b
+	}
+	if b.restartedCmd == cmd.Name {
+		// We've already decided to restart it, and are waiting for it to exit.
+		return true
+	}
+
+	now := c.clock.Now()
+	if now.Sub(running.StartedAt.Time) >= livenessBackoffReset {
+		// It ran fine for a while before it hung, so it's not stuck in a loop.
+		b.restarts = 0
+	}
+
+	restartAt := b.lastRestart.Add(b.delay())
+	if now.Before(restartAt) {
+		if b.waitingCmd != cmd.Name {
+			b.waitingCmd = cmd.Name
+			wait := restartAt.Sub(now)
+			logger.Get(ctx).Infof("Server is hung (%s). Restarting in %s", running.LivenessFailure, wait.Round(time.Second))
+			go c.wakeAfter(ctx, st, cmd.Name, wait)
+		}
+		return false
+	}
+
+	b.restarts++
+	b.total++
+	b.lastRestart = now
+	b.restartedCmd = cmd.Name
+	logger.Get(ctx).Infof("Server is hung (%s). Restarting (liveness restart #%d)", running.LivenessFailure, b.total)
+
+	server.Status.LivenessRestarts = b.total
+	c.upsert(server)
+	return true
+}
+
+func (c *ServerController) wakeAfter(ctx context.Context, st store.RStore, cmdName string, wait time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-c.clock.After(wait):
+		st.Dispatch(CmdServerBackoffAction{CmdName: cmdName})
+	}
+}
+
+// The first liveness restart happens right away. After that, we wait this
+// long, doubling each time up to livenessBackoffMax.
+const livenessBackoffBase = 10 * time.Second
+const livenessBackoffMax = 5 * time.Minute
+
+// If a server ran this long before it hung, start the backoff over.
+const livenessBackoffReset = 10 * time.Minute
+
+type livenessBackoff struct {
+	// How many times in a row we've restarted the server because it hung.
+	restarts    int
+	lastRestart time.Time
+
+	// How many times we've ever restarted the server because it hung.
+	total int
+
+	// The Cmd we're waiting out the backoff for, and the Cmd we last
+	// restarted, so that we only log and count each one once.
+	waitingCmd   string
+	restartedCmd string
+}
+
+func (b *livenessBackoff) delay() time.Duration {
+	if b.restarts == 0 {
+		return 0
+	}
+	delay := livenessBackoffBase
+	for i := 1; i < b.restarts && delay < livenessBackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > livenessBackoffMax {
+		delay = livenessBackoffMax
+	}
+	return delay
+}
+
+func (b *livenessBackoff) totalRestarts() int {
+	if b == nil {
+		return 0
+	}
+	return b.total
+}
+
 type CmdServer struct {
 	metav1.TypeMeta
 	metav1.ObjectMeta
@@ -337,17 +553,35 @@ type CmdServerSpec struct {
 	Env            []string
 	ReadinessProbe *v1alpha1.Probe
 
+	// If set, the server is restarted when this fails.
+	LivenessProbe *v1alpha1.Probe
+
 	// Kubernetes tends to represent this as a "generation" field
 	// to force an update.
 	TriggerTime time.Time
 
 	DisableSource *v1alpha1.DisableSource
+
+	// If set, changes to only these inputs reload the server in place.
+	Reload *model.ServeReload
+
+	// How to read log levels from the server's output.
+	LogLevelFormat string
 }
 
 type CmdServerStatus struct {
 	DisableStatus *v1alpha1.DisableStatus
+
+	// The last time we asked the server to reload in place.
+	LastReloadTime time.Time
+
+	// How many times we've restarted the server because it failed
+	// its liveness probe.
+	LivenessRestarts int
 }
 
-func SpanIDForServeLog(procNum int) logstore.SpanID {
-	return logstore.SpanID(fmt.Sprintf("localserve:%d", procNum))
+// Each run of a server logs to its own span, so that the logs of
+// one run never interleave with the next.
+func SpanIDForServeLog(name string, runNumber int) logstore.SpanID {
+	return logstore.SpanID(fmt.Sprintf("localserve:%s:%d", name, runNumber))
 }
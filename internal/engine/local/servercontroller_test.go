@@ -1,3 +1,87 @@
 package local
 
-// servercontroller is currently tested via internal/controllers/core/cmd/controller_test.go
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// servercontroller is mostly tested via internal/controllers/core/cmd/controller_test.go
+
+func TestClassifyServerChange(t *testing.T) {
+	reload := &model.ServeReload{Env: []string{"LOG_LEVEL", "FEATURES"}, Signal: "SIGHUP"}
+	base := CmdSpec{
+		Args: []string{"nginx"},
+		Dir:  "/app",
+		Env:  []string{"LOG_LEVEL=info", "PORT=8000"},
+	}
+	withEnv := func(env ...string) CmdSpec {
+		spec := *base.DeepCopy()
+		spec.Env = env
+		return spec
+	}
+	withArgs := func(args ...string) CmdSpec {
+		spec := *base.DeepCopy()
+		spec.Args = args
+		return spec
+	}
+	withProbe := func() CmdSpec {
+		spec := *base.DeepCopy()
+		spec.ReadinessProbe = &v1alpha1.Probe{PeriodSeconds: 5}
+		return spec
+	}
+
+	for _, tc := range []struct {
+		name      string
+		reload    *model.ServeReload
+		new       CmdSpec
+		newDigest string
+		triggered bool
+		expected  serverChange
+	}{
+		{"no change", reload, base, "a", false, serverUnchanged},
+		{"designated env changed", reload, withEnv("LOG_LEVEL=debug", "PORT=8000"), "a", true, serverNeedsReload},
+		{"designated env added", reload, withEnv("FEATURES=x", "LOG_LEVEL=info", "PORT=8000"), "a", true, serverNeedsReload},
+		{"designated env removed", reload, withEnv("PORT=8000"), "a", true, serverNeedsReload},
+		{"config file changed", reload, base, "b", true, serverNeedsReload},
+		{"other env changed", reload, withEnv("LOG_LEVEL=debug", "PORT=9000"), "a", true, serverNeedsRestart},
+		{"args changed", reload, withArgs("nginx", "-g", "daemon off;"), "b", true, serverNeedsRestart},
+		{"probe changed", reload, withProbe(), "a", false, serverNeedsRestart},
+		{"triggered without reloadable change", reload, base, "a", true, serverNeedsRestart},
+		{"no reload spec, env changed", nil, withEnv("LOG_LEVEL=debug", "PORT=8000"), "", false, serverNeedsRestart},
+		{"no reload spec, triggered", nil, base, "", true, serverNeedsRestart},
+		{"no reload spec, no change", nil, base, "", false, serverUnchanged},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			oldDigest := "a"
+			if tc.reload == nil {
+				oldDigest = ""
+			}
+			actual := classifyServerChange(tc.reload, base, tc.new, oldDigest, tc.newDigest, tc.triggered)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestLivenessBackoffDelay(t *testing.T) {
+	b := &livenessBackoff{}
+	var delays []time.Duration
+	for i := 0; i < 8; i++ {
+		delays = append(delays, b.delay())
+		b.restarts++
+	}
+	assert.Equal(t, []time.Duration{
+		0,
+		10 * time.Second,
+		20 * time.Second,
+		40 * time.Second,
+		80 * time.Second,
+		160 * time.Second,
+		livenessBackoffMax,
+		livenessBackoffMax,
+	}, delays)
+}
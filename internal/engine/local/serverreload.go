@@ -0,0 +1,140 @@
+package local
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// If a server exits this soon after we ask it to reload, we assume
+// it couldn't handle the signal, and restart it.
+const reloadExitWindow = 10 * time.Second
+
+type serverChange int
+
+const (
+	serverUnchanged serverChange = iota
+	serverNeedsReload
+	serverNeedsRestart
+)
+
+// What the running Cmd of a server was last started or reloaded with.
+type appliedServerSpec struct {
+	cmdName     string
+	spec        CmdSpec
+	filesDigest string
+
+	// Zero if this Cmd has never been reloaded.
+	reloadTime time.Time
+}
+
+// Decides whether a server can pick up a new spec by reloading in place.
+//
+// Only a change to the designated env vars or config files can be reloaded.
+// A change to anything else, or a trigger that didn't touch any reloadable
+// input (like a manual trigger), needs a restart.
+func classifyServerChange(reload *model.ServeReload, old, new CmdSpec, oldDigest, newDigest string, triggered bool) serverChange {
+	if reload == nil {
+		if triggered || !equality.Semantic.DeepEqual(old, new) {
+			return serverNeedsRestart
+		}
+		return serverUnchanged
+	}
+
+	oldEnv, oldRest := splitReloadEnv(old, reload.Env)
+	newEnv, newRest := splitReloadEnv(new, reload.Env)
+	if !equality.Semantic.DeepEqual(oldRest, newRest) {
+		return serverNeedsRestart
+	}
+	if !equality.Semantic.DeepEqual(oldEnv, newEnv) || oldDigest != newDigest {
+		return serverNeedsReload
+	}
+	if triggered {
+		return serverNeedsRestart
+	}
+	return serverUnchanged
+}
+
+// Splits the reloadable env vars out of a spec.
+//
+// Returns the reloadable env entries, and a copy of the spec without them.
+func splitReloadEnv(spec CmdSpec, names []string) ([]string, CmdSpec) {
+	var reloadable, rest []string
+	for _, e := range spec.Env {
+		if isReloadEnv(e, names) {
+			reloadable = append(reloadable, e)
+		} else {
+			rest = append(rest, e)
+		}
+	}
+	spec = *spec.DeepCopy()
+	spec.Env = rest
+	return reloadable, spec
+}
+
+func isReloadEnv(entry string, names []string) bool {
+	key := strings.SplitN(entry, "=", 2)[0]
+	for _, name := range names {
+		if key == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Hashes the contents of the reloadable config files, so that we can
+// tell when they've changed.
+func reloadFilesDigest(reload *model.ServeReload) string {
+	if reload == nil || len(reload.Files) == 0 {
+		return ""
+	}
+
+	h := sha256.New()
+	for _, f := range reload.Files {
+		_, _ = fmt.Fprintf(h, "%s\x00", f)
+		contents, err := ioutil.ReadFile(f)
+		if err != nil {
+			// A missing file is a change too.
+			_, _ = fmt.Fprintf(h, "error: %v\x00", err)
+			continue
+		}
+		_, _ = h.Write(contents)
+		_, _ = h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// Writes the reloadable env vars to the env file as KEY=value lines.
+func writeReloadEnvFile(reload *model.ServeReload, spec CmdSpec) error {
+	if reload.EnvFile == "" {
+		return nil
+	}
+	env, _ := splitReloadEnv(spec, reload.Env)
+	contents := ""
+	for _, e := range env {
+		contents += e + "\n"
+	}
+	return ioutil.WriteFile(reload.EnvFile, []byte(contents), os.FileMode(0644))
+}
+
+// Describes what changed, for the reload log message.
+func describeReloadChange(reload *model.ServeReload, old, new CmdSpec, oldDigest, newDigest string) string {
+	oldEnv, _ := splitReloadEnv(old, reload.Env)
+	newEnv, _ := splitReloadEnv(new, reload.Env)
+
+	var changes []string
+	if !equality.Semantic.DeepEqual(oldEnv, newEnv) {
+		changes = append(changes, "env")
+	}
+	if oldDigest != newDigest {
+		changes = append(changes, "config files")
+	}
+	return strings.Join(changes, " and ")
+}
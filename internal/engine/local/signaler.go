@@ -0,0 +1,60 @@
+package local
+
+import (
+	"sync"
+
+	"github.com/tilt-dev/tilt/pkg/procutil"
+)
+
+// Sends signals to running servers, so that they can reload
+// their config without a restart.
+type ProcessSignaler interface {
+	Signal(pid int, signal string) error
+}
+
+func ProvideProcessSignaler() ProcessSignaler {
+	return processGroupSignaler{}
+}
+
+// Each server runs in its own process group, so we signal the whole group.
+// That way the signal reaches the server even if it's wrapped in a shell.
+type processGroupSignaler struct{}
+
+func (processGroupSignaler) Signal(pid int, signal string) error {
+	return procutil.SignalProcessGroup(pid, signal)
+}
+
+type FakeSignal struct {
+	PID    int
+	Signal string
+}
+
+type FakeProcessSignaler struct {
+	mu      sync.Mutex
+	signals []FakeSignal
+
+	// If set, every Signal call fails with this error.
+	Err error
+}
+
+var _ ProcessSignaler = &FakeProcessSignaler{}
+
+func NewFakeProcessSignaler() *FakeProcessSignaler {
+	return &FakeProcessSignaler{}
+}
+
+func (s *FakeProcessSignaler) Signal(pid int, signal string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Err != nil {
+		return s.Err
+	}
+	s.signals = append(s.signals, FakeSignal{PID: pid, Signal: signal})
+	return nil
+}
+
+func (s *FakeProcessSignaler) Signals() []FakeSignal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]FakeSignal(nil), s.signals...)
+}
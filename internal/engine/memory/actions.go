@@ -0,0 +1,20 @@
+package memory
+
+import (
+	"github.com/tilt-dev/tilt/internal/store"
+)
+
+// Dispatched each time we sample the engine's memory usage.
+type MemoryReportAction struct {
+	Report store.MemoryReport
+}
+
+func (MemoryReportAction) Action() {}
+
+func NewMemoryReportAction(report store.MemoryReport) MemoryReportAction {
+	return MemoryReportAction{Report: report}
+}
+
+func HandleMemoryReportAction(state *store.EngineState, action MemoryReportAction) {
+	state.MemoryReport = action.Report
+}
@@ -0,0 +1,158 @@
+// Package memory keeps an eye on how much memory the engine is using.
+//
+// Tilt holds onto logs, build history, and file events for as long as it
+// runs, and some of that bookkeeping has grown without bound in the past.
+// We periodically sample the Go runtime's memory stats along with the
+// engine's own accounting, and warn once if Tilt is using more memory than
+// it should, naming the biggest consumers so that users can tell us where
+// it went.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/jonboulle/clockwork"
+
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/pkg/logger"
+)
+
+// How much memory Tilt can use before we warn about it.
+//
+// 0 means we never warn.
+type WarningThreshold int64
+
+// 2GiB. Tilt usually uses a few hundred MB, even with lots of resources.
+const DefaultWarningThreshold = WarningThreshold(2 * units.GiB)
+
+// How often we sample the engine's memory usage.
+const sampleInterval = time.Minute
+
+// How many consumers we name in the warning.
+const warningConsumerCount = 3
+
+// The memory stats we read from the Go runtime.
+type RuntimeStats struct {
+	TotalBytes int64
+	HeapBytes  int64
+	Goroutines int
+}
+
+func readRuntimeStats() RuntimeStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return RuntimeStats{
+		TotalBytes: int64(m.Sys - m.HeapReleased),
+		HeapBytes:  int64(m.HeapAlloc),
+		Goroutines: runtime.NumGoroutine(),
+	}
+}
+
+// Periodically samples the engine's memory usage, and warns once if it
+// crosses the threshold.
+type Monitor struct {
+	threshold   int64
+	clock       clockwork.Clock
+	readRuntime func() RuntimeStats
+
+	mu sync.Mutex
+
+	// We only warn once, so that we don't spam the logs.
+	warned bool
+
+	cancel context.CancelFunc
+}
+
+var _ store.SubscriberLifecycle = &Monitor{}
+
+func NewMonitor(threshold WarningThreshold, clock clockwork.Clock) *Monitor {
+	return &Monitor{
+		threshold:   int64(threshold),
+		clock:       clock,
+		readRuntime: readRuntimeStats,
+	}
+}
+
+func (m *Monitor) SetUp(ctx context.Context, st store.RStore) error {
+	ctx, cancel := context.WithCancel(ctx)
+	m.cancel = cancel
+
+	go m.loop(ctx, st)
+	return nil
+}
+
+func (m *Monitor) TearDown(ctx context.Context) {
+	if m.cancel != nil {
+		m.cancel()
+	}
+}
+
+func (m *Monitor) OnChange(ctx context.Context, st store.RStore, _ store.ChangeSummary) error {
+	return nil
+}
+
+func (m *Monitor) loop(ctx context.Context, st store.RStore) {
+	ticker := m.clock.NewTicker(sampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.Chan():
+			m.sample(ctx, st)
+		}
+	}
+}
+
+// Samples the engine's memory usage, and warns if it's over the threshold.
+func (m *Monitor) sample(ctx context.Context, st store.RStore) {
+	// The LogStore and manifest states are shared with the engine,
+	// so count them while we hold the lock.
+	state := st.RLockState()
+	accounting := state.MemoryAccounting()
+	st.RUnlockState()
+
+	stats := m.readRuntime()
+	report := store.MemoryReport{
+		Time:       m.clock.Now(),
+		TotalBytes: stats.TotalBytes,
+		HeapBytes:  stats.HeapBytes,
+		Goroutines: stats.Goroutines,
+		Accounting: accounting,
+	}
+	st.Dispatch(NewMemoryReportAction(report))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.warned || m.threshold <= 0 || report.TotalBytes < m.threshold {
+		return
+	}
+
+	m.warned = true
+	logger.Get(ctx).Warnf("%s", warningMessage(report, m.threshold))
+}
+
+func warningMessage(report store.MemoryReport, threshold int64) string {
+	var consumers []string
+	for _, c := range report.Accounting.Largest(warningConsumerCount) {
+		if c.Bytes <= 0 {
+			continue
+		}
+		consumers = append(consumers, fmt.Sprintf("%s (%s)", c.Name, units.BytesSize(float64(c.Bytes))))
+	}
+
+	msg := fmt.Sprintf("Tilt is using %s of memory, over the warning threshold of %s.",
+		units.BytesSize(float64(report.TotalBytes)), units.BytesSize(float64(threshold)))
+	if len(consumers) > 0 {
+		msg += fmt.Sprintf(" Largest consumers: %s.", strings.Join(consumers, ", "))
+	}
+	msg += " Run `tilt dump memory` for details, and please file an issue at https://github.com/tilt-dev/tilt/issues"
+	return msg
+}
@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/docker/go-units"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model/logstore"
+)
+
+const testThreshold = units.GiB
+
+func TestReportsMemoryUsage(t *testing.T) {
+	f := newFixture(t, testThreshold)
+	f.stats = RuntimeStats{TotalBytes: 100 * units.MiB, HeapBytes: 50 * units.MiB, Goroutines: 12}
+
+	f.sample()
+
+	actions := f.store.Actions()
+	require.Len(t, actions, 1)
+	report := actions[0].(MemoryReportAction).Report
+	assert.Equal(t, f.clock.Now(), report.Time)
+	assert.Equal(t, int64(100*units.MiB), report.TotalBytes)
+	assert.Equal(t, int64(50*units.MiB), report.HeapBytes)
+	assert.Equal(t, 12, report.Goroutines)
+	assert.Equal(t, len("hello\n"), report.Accounting.LogBytes)
+	assert.Equal(t, "", f.out.String())
+}
+
+func TestWarnsOnceOverThreshold(t *testing.T) {
+	f := newFixture(t, testThreshold)
+
+	f.stats = RuntimeStats{TotalBytes: testThreshold - 1}
+	f.sample()
+	assert.Equal(t, 0, f.warnings())
+
+	f.stats = RuntimeStats{TotalBytes: 2 * testThreshold}
+	f.sample()
+	assert.Equal(t, 1, f.warnings())
+	assert.Contains(t, f.out.String(), "Tilt is using 2GiB of memory, over the warning threshold of 1GiB. Largest consumers: logs (")
+
+	// Stay quiet, even if usage keeps growing.
+	f.stats = RuntimeStats{TotalBytes: 4 * testThreshold}
+	f.sample()
+	f.sample()
+	assert.Equal(t, 1, f.warnings())
+
+	// But keep reporting.
+	assert.Len(t, f.store.Actions(), 4)
+}
+
+func TestNoThresholdNeverWarns(t *testing.T) {
+	f := newFixture(t, 0)
+	f.stats = RuntimeStats{TotalBytes: 100 * testThreshold}
+	f.sample()
+	assert.Equal(t, 0, f.warnings())
+	assert.Len(t, f.store.Actions(), 1)
+}
+
+func TestWarningNamesLargestConsumers(t *testing.T) {
+	report := store.MemoryReport{
+		TotalBytes: 3 * units.GiB,
+		Accounting: store.MemoryAccounting{
+			Consumers: []store.MemoryConsumer{
+				{Name: "logs", Bytes: 2 * units.GiB},
+				{Name: "build history", Bytes: 512 * units.MiB},
+				{Name: "file watches", Bytes: 1 * units.MiB},
+				{Name: "pending file changes", Bytes: 1 * units.KiB},
+			},
+		},
+	}
+	assert.Equal(t,
+		"Tilt is using 3GiB of memory, over the warning threshold of 2GiB. "+
+			"Largest consumers: logs (2GiB), build history (512MiB), file watches (1MiB). "+
+			"Run `tilt dump memory` for details, and please file an issue at https://github.com/tilt-dev/tilt/issues",
+		warningMessage(report, 2*units.GiB))
+}
+
+type fixture struct {
+	t     *testing.T
+	ctx   context.Context
+	out   *bytes.Buffer
+	clock clockwork.FakeClock
+	store *store.TestingStore
+	m     *Monitor
+	stats RuntimeStats
+}
+
+func newFixture(t *testing.T, threshold WarningThreshold) *fixture {
+	out := &bytes.Buffer{}
+	ctx := logger.WithLogger(context.Background(), logger.NewTestLogger(out))
+	ctx, cancel := context.WithCancel(ctx)
+	t.Cleanup(cancel)
+
+	clock := clockwork.NewFakeClock()
+	st := store.NewTestingStore()
+	st.WithState(func(state *store.EngineState) {
+		state.LogStore = logstore.NewLogStoreForTesting("hello\n")
+	})
+
+	f := &fixture{
+		t:     t,
+		ctx:   ctx,
+		out:   out,
+		clock: clock,
+		store: st,
+		m:     NewMonitor(threshold, clock),
+	}
+	f.m.readRuntime = func() RuntimeStats { return f.stats }
+	return f
+}
+
+// Don't start the ticker loop. The tests sample directly.
+func (f *fixture) sample() {
+	f.m.sample(f.ctx, f.store)
+}
+
+func (f *fixture) warnings() int {
+	return strings.Count(f.out.String(), "Tilt is using")
+}
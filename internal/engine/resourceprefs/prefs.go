@@ -0,0 +1,67 @@
+package resourceprefs
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/tilt-dev/wmclient/pkg/dirs"
+
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+const prefsFileName = "resource_prefs.json"
+
+// The choices a user made about resources in the UI, saved across Tilt restarts.
+type prefsFile struct {
+	// Keyed by the absolute path of the main Tiltfile.
+	Tiltfiles map[string]tiltfilePrefs `json:"tiltfiles"`
+}
+
+type tiltfilePrefs struct {
+	UpdatedAt time.Time                            `json:"updatedAt"`
+	Resources map[model.ManifestName]resourcePrefs `json:"resources"`
+}
+
+type resourcePrefs struct {
+	Disabled bool `json:"disabled,omitempty"`
+
+	// Set if the user overrode the trigger mode.
+	TriggerMode *model.TriggerMode `json:"triggerMode,omitempty"`
+
+	// The trigger mode the Tiltfile declared when the user overrode it.
+	// If the Tiltfile declares something else now, the Tiltfile wins.
+	TiltfileTriggerMode model.TriggerMode `json:"tiltfileTriggerMode,omitempty"`
+}
+
+func (p resourcePrefs) isEmpty() bool {
+	return !p.Disabled && p.TriggerMode == nil
+}
+
+func readPrefsFile(dir *dirs.TiltDevDir) (prefsFile, error) {
+	result := prefsFile{Tiltfiles: make(map[string]tiltfilePrefs)}
+	contents, err := dir.ReadFile(prefsFileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return result, nil
+		}
+		return result, err
+	}
+
+	err = json.Unmarshal([]byte(contents), &result)
+	if err != nil {
+		return result, err
+	}
+	if result.Tiltfiles == nil {
+		result.Tiltfiles = make(map[string]tiltfilePrefs)
+	}
+	return result, nil
+}
+
+func writePrefsFile(dir *dirs.TiltDevDir, f prefsFile) error {
+	contents, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return dir.WriteFile(prefsFileName, string(contents))
+}
@@ -0,0 +1,230 @@
+package resourceprefs
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/tilt-dev/wmclient/pkg/dirs"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/tilt-dev/tilt/internal/controllers/apicmp"
+	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
+	"github.com/tilt-dev/tilt/internal/hud/server"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// If true, ignore the choices saved from previous runs of this Tiltfile.
+type FreshFlag bool
+
+// Saves the choices the user made about resources in the UI (which resources
+// are disabled, trigger mode overrides), and restores them when Tilt restarts.
+//
+// Choices are restored once, after the first successful Tiltfile load, on top
+// of the Tiltfile's defaults.
+type Subscriber struct {
+	client ctrlclient.Client
+	dir    *dirs.TiltDevDir
+	fresh  FreshFlag
+
+	tiltfilePath string
+	restored     bool
+	saved        bool
+	lastSaved    map[model.ManifestName]resourcePrefs
+}
+
+var _ store.Subscriber = &Subscriber{}
+
+func NewSubscriber(client ctrlclient.Client, dir *dirs.TiltDevDir, fresh FreshFlag) *Subscriber {
+	return &Subscriber{
+		client: client,
+		dir:    dir,
+		fresh:  fresh,
+	}
+}
+
+func (s *Subscriber) OnChange(ctx context.Context, st store.RStore, summary store.ChangeSummary) error {
+	if summary.IsLogOnly() {
+		return nil
+	}
+
+	state := st.RLockState()
+	tiltfilePath := state.MainTiltfilePath()
+	lastBuild := state.MainTiltfileState().LastBuild()
+	loaded := !lastBuild.Empty() && lastBuild.Error == nil
+	manifests := make(map[model.ManifestName]model.Manifest, len(state.ManifestTargets))
+	for name, mt := range state.ManifestTargets {
+		manifests[name] = mt.Manifest
+	}
+	current := currentPrefs(state)
+	st.RUnlockState()
+
+	if tiltfilePath == "" || !loaded {
+		return nil
+	}
+
+	if !s.restored || tiltfilePath != s.tiltfilePath {
+		s.restored = true
+		s.tiltfilePath = tiltfilePath
+		s.saved = false
+		s.lastSaved = nil
+		if !s.fresh {
+			s.restore(ctx, st, manifests)
+			// Save on the next change, once the restored choices are in the EngineState.
+			return nil
+		}
+	}
+
+	if s.saved && apicmp.DeepEqual(current, s.lastSaved) {
+		return nil
+	}
+
+	err := s.save(current)
+	if err != nil {
+		logger.Get(ctx).Debugf("Error saving resource choices: %v", err)
+		return nil
+	}
+	s.saved = true
+	s.lastSaved = current
+	return nil
+}
+
+// The choices the user has made in this session, by resource.
+func currentPrefs(state store.EngineState) map[model.ManifestName]resourcePrefs {
+	result := make(map[model.ManifestName]resourcePrefs)
+	for name := range state.ManifestTargets {
+		var p resourcePrefs
+
+		cm, ok := state.ConfigMaps[configmap.DisableConfigMapName(name)]
+		if ok {
+			p.Disabled, _ = strconv.ParseBool(cm.Data[configmap.DisableKey])
+		}
+
+		override, ok := state.TriggerModeOverrides[name]
+		if ok {
+			tm := override.TriggerMode
+			p.TriggerMode = &tm
+			p.TiltfileTriggerMode = override.TiltfileTriggerMode
+		}
+
+		if !p.isEmpty() {
+			result[name] = p
+		}
+	}
+	return result
+}
+
+// Saves the choices for the current Tiltfile, leaving other Tiltfiles' choices alone.
+//
+// Choices for resources that no longer exist are dropped.
+func (s *Subscriber) save(current map[model.ManifestName]resourcePrefs) error {
+	f, err := readPrefsFile(s.dir)
+	if err != nil {
+		// If the file is corrupt, start over.
+		f = prefsFile{Tiltfiles: make(map[string]tiltfilePrefs)}
+	}
+
+	if len(current) == 0 {
+		delete(f.Tiltfiles, s.tiltfilePath)
+	} else {
+		f.Tiltfiles[s.tiltfilePath] = tiltfilePrefs{
+			UpdatedAt: time.Now(),
+			Resources: current,
+		}
+	}
+	return writePrefsFile(s.dir, f)
+}
+
+func (s *Subscriber) restore(ctx context.Context, st store.RStore, manifests map[model.ManifestName]model.Manifest) {
+	f, err := readPrefsFile(s.dir)
+	if err != nil {
+		logger.Get(ctx).Debugf("Error reading saved resource choices: %v", err)
+		return
+	}
+
+	saved, ok := f.Tiltfiles[s.tiltfilePath]
+	if !ok {
+		return
+	}
+
+	toDisable, triggerModes := choicesToRestore(saved.Resources, manifests)
+	for _, name := range toDisable {
+		err := s.disable(ctx, name)
+		if err != nil {
+			logger.Get(ctx).Debugf("Error restoring disable state of %s: %v", name, err)
+		}
+	}
+
+	modes := make([]model.TriggerMode, 0, len(triggerModes))
+	for tm := range triggerModes {
+		modes = append(modes, tm)
+	}
+	sort.Slice(modes, func(i, j int) bool { return modes[i] < modes[j] })
+	for _, tm := range modes {
+		st.Dispatch(server.OverrideTriggerModeAction{
+			ManifestNames: triggerModes[tm],
+			TriggerMode:   tm,
+		})
+	}
+}
+
+// Works out which saved choices still apply.
+//
+// Choices for resources that no longer exist are skipped. A trigger mode
+// override is skipped if the Tiltfile has declared a different trigger mode
+// since it was saved, because the Tiltfile change is more recent.
+//
+// Tiltfiles don't declare whether a resource starts disabled, so a saved
+// disable applies to any resource that still exists.
+func choicesToRestore(saved map[model.ManifestName]resourcePrefs, manifests map[model.ManifestName]model.Manifest) ([]model.ManifestName, map[model.TriggerMode][]model.ManifestName) {
+	toDisable := []model.ManifestName{}
+	triggerModes := make(map[model.TriggerMode][]model.ManifestName)
+	for name, p := range saved {
+		m, ok := manifests[name]
+		if !ok {
+			continue
+		}
+
+		if p.Disabled {
+			toDisable = append(toDisable, name)
+		}
+
+		if p.TriggerMode != nil && m.TriggerMode == p.TiltfileTriggerMode && model.ValidTriggerMode(*p.TriggerMode) {
+			triggerModes[*p.TriggerMode] = append(triggerModes[*p.TriggerMode], name)
+		}
+	}
+
+	sort.Slice(toDisable, func(i, j int) bool { return toDisable[i] < toDisable[j] })
+	for _, names := range triggerModes {
+		sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	}
+	return toDisable, triggerModes
+}
+
+func (s *Subscriber) disable(ctx context.Context, name model.ManifestName) error {
+	var cm v1alpha1.ConfigMap
+	err := s.client.Get(ctx, types.NamespacedName{Name: configmap.DisableConfigMapName(name)}, &cm)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	upgraded, err := configmap.Upgraded(&cm)
+	if err != nil {
+		return err
+	}
+	cm = *upgraded
+
+	if !configmap.RecordDisableChange(&cm, true, v1alpha1.DisableChangeSourceRestored, time.Now()) {
+		return nil
+	}
+	return s.client.Update(ctx, &cm)
+}
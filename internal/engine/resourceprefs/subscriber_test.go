@@ -0,0 +1,284 @@
+package resourceprefs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tilt-dev/wmclient/pkg/dirs"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
+	"github.com/tilt-dev/tilt/internal/controllers/fake"
+	"github.com/tilt-dev/tilt/internal/hud/server"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestSaveAndRestore(t *testing.T) {
+	f := newFixture(t)
+	f.load("fe", "be")
+	f.onChange()
+
+	f.setDisabled("fe", true)
+	f.overrideTriggerMode("be", model.TriggerModeManual)
+	f.onChange()
+
+	saved := f.savedPrefs()
+	assert.Equal(t, map[model.ManifestName]resourcePrefs{
+		"fe": {Disabled: true},
+		"be": {TriggerMode: triggerModePtr(model.TriggerModeManual), TiltfileTriggerMode: model.TriggerModeAuto},
+	}, saved.Resources)
+
+	// Restart Tilt.
+	f.restart(false)
+	f.load("fe", "be")
+	f.onChange()
+
+	assert.Equal(t, "true", f.disableValue("fe"))
+	assert.Equal(t, "false", f.disableValue("be"))
+	assert.Equal(t, v1alpha1.DisableChangeSourceRestored, f.lastDisableSource("fe"))
+	assert.Equal(t, []store.Action{
+		server.OverrideTriggerModeAction{
+			ManifestNames: []model.ManifestName{"be"},
+			TriggerMode:   model.TriggerModeManual,
+		},
+	}, f.st.Actions())
+}
+
+func TestRestoreWaitsForTiltfileLoad(t *testing.T) {
+	f := newFixture(t)
+	f.writePrefs(map[model.ManifestName]resourcePrefs{
+		"fe": {Disabled: true},
+	})
+
+	f.st.WithState(func(state *store.EngineState) {
+		state.Tiltfiles[model.MainTiltfileManifestName.String()] = &v1alpha1.Tiltfile{
+			ObjectMeta: metav1.ObjectMeta{Name: model.MainTiltfileManifestName.String()},
+			Spec:       v1alpha1.TiltfileSpec{Path: f.tiltfilePath},
+		}
+	})
+	f.onChange()
+	assert.Equal(t, "", f.disableValue("fe"))
+
+	f.load("fe")
+	f.onChange()
+	assert.Equal(t, "true", f.disableValue("fe"))
+}
+
+func TestRestoreSkipsRemovedResource(t *testing.T) {
+	f := newFixture(t)
+	f.writePrefs(map[model.ManifestName]resourcePrefs{
+		"fe":   {Disabled: true},
+		"gone": {Disabled: true, TriggerMode: triggerModePtr(model.TriggerModeManual)},
+	})
+	f.createDisableConfigMap("gone")
+
+	f.load("fe")
+	f.onChange()
+
+	assert.Equal(t, "true", f.disableValue("fe"))
+	assert.Equal(t, "false", f.disableValue("gone"))
+	assert.Empty(t, f.st.Actions())
+
+	// Once saved, the removed resource's choices are dropped.
+	f.syncConfigMaps()
+	f.onChange()
+	assert.Equal(t, map[model.ManifestName]resourcePrefs{
+		"fe": {Disabled: true},
+	}, f.savedPrefs().Resources)
+}
+
+func TestTiltfileDefaultChangedWins(t *testing.T) {
+	f := newFixture(t)
+	f.writePrefs(map[model.ManifestName]resourcePrefs{
+		"fe": {TriggerMode: triggerModePtr(model.TriggerModeManual), TiltfileTriggerMode: model.TriggerModeAuto},
+		"be": {TriggerMode: triggerModePtr(model.TriggerModeManual), TiltfileTriggerMode: model.TriggerModeAuto},
+	})
+
+	// Since the choices were saved, the Tiltfile changed fe's trigger mode.
+	f.load("fe", "be")
+	f.st.WithState(func(state *store.EngineState) {
+		state.ManifestTargets["fe"].Manifest.TriggerMode = model.TriggerModeAutoWithManualInit
+	})
+	f.onChange()
+
+	assert.Equal(t, []store.Action{
+		server.OverrideTriggerModeAction{
+			ManifestNames: []model.ManifestName{"be"},
+			TriggerMode:   model.TriggerModeManual,
+		},
+	}, f.st.Actions())
+}
+
+func TestFresh(t *testing.T) {
+	f := newFixture(t)
+	f.writePrefs(map[model.ManifestName]resourcePrefs{
+		"fe": {Disabled: true},
+		"be": {TriggerMode: triggerModePtr(model.TriggerModeManual), TiltfileTriggerMode: model.TriggerModeAuto},
+	})
+
+	f.restart(true)
+	f.load("fe", "be")
+	f.onChange()
+
+	assert.Equal(t, "false", f.disableValue("fe"))
+	assert.Empty(t, f.st.Actions())
+
+	// The old choices are replaced by the choices made in this session.
+	f.setDisabled("be", true)
+	f.onChange()
+	assert.Equal(t, map[model.ManifestName]resourcePrefs{
+		"be": {Disabled: true},
+	}, f.savedPrefs().Resources)
+}
+
+func triggerModePtr(tm model.TriggerMode) *model.TriggerMode {
+	return &tm
+}
+
+type fixture struct {
+	*tempdir.TempDirFixture
+	ctx          context.Context
+	st           *store.TestingStore
+	client       ctrlclient.Client
+	dir          *dirs.TiltDevDir
+	tiltfilePath string
+	sub          *Subscriber
+}
+
+func newFixture(t *testing.T) *fixture {
+	f := tempdir.NewTempDirFixture(t)
+	t.Cleanup(f.TearDown)
+
+	dir := dirs.NewTiltDevDirAt(f.JoinPath(".tilt-dev"))
+	client := fake.NewFakeTiltClient()
+	return &fixture{
+		TempDirFixture: f,
+		ctx:            context.Background(),
+		st:             store.NewTestingStore(),
+		client:         client,
+		dir:            dir,
+		tiltfilePath:   f.JoinPath("Tiltfile"),
+		sub:            NewSubscriber(client, dir, false),
+	}
+}
+
+// Simulates a new Tilt process: the state and API server start empty,
+// but the dev dir is the same.
+func (f *fixture) restart(fresh FreshFlag) {
+	f.st = store.NewTestingStore()
+	f.client = fake.NewFakeTiltClient()
+	f.sub = NewSubscriber(f.client, f.dir, fresh)
+}
+
+// Simulates a successful Tiltfile load that defines the given resources.
+func (f *fixture) load(names ...model.ManifestName) {
+	for _, name := range names {
+		f.createDisableConfigMap(name)
+	}
+
+	f.st.WithState(func(state *store.EngineState) {
+		state.Tiltfiles[model.MainTiltfileManifestName.String()] = &v1alpha1.Tiltfile{
+			ObjectMeta: metav1.ObjectMeta{Name: model.MainTiltfileManifestName.String()},
+			Spec:       v1alpha1.TiltfileSpec{Path: f.tiltfilePath},
+		}
+		now := time.Now()
+		state.MainTiltfileState().AddCompletedBuild(model.BuildRecord{StartTime: now, FinishTime: now})
+		for _, name := range names {
+			state.UpsertManifestTarget(store.NewManifestTarget(model.Manifest{Name: name}))
+		}
+	})
+	f.syncConfigMaps()
+}
+
+func (f *fixture) createDisableConfigMap(name model.ManifestName) {
+	err := f.client.Create(f.ctx, &v1alpha1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: configmap.DisableConfigMapName(name)},
+		Data:       map[string]string{configmap.DisableKey: "false"},
+	})
+	require.NoError(f.T(), err)
+}
+
+// Copies the ConfigMaps from the API server to the EngineState,
+// like the ConfigMap reconciler does.
+func (f *fixture) syncConfigMaps() {
+	var list v1alpha1.ConfigMapList
+	require.NoError(f.T(), f.client.List(f.ctx, &list))
+	f.st.WithState(func(state *store.EngineState) {
+		for i := range list.Items {
+			cm := list.Items[i]
+			state.ConfigMaps[cm.Name] = &cm
+		}
+	})
+}
+
+func (f *fixture) setDisabled(name model.ManifestName, disabled bool) {
+	var cm v1alpha1.ConfigMap
+	nn := types.NamespacedName{Name: configmap.DisableConfigMapName(name)}
+	require.NoError(f.T(), f.client.Get(f.ctx, nn, &cm))
+	cm.Data[configmap.DisableKey] = "false"
+	if disabled {
+		cm.Data[configmap.DisableKey] = "true"
+	}
+	require.NoError(f.T(), f.client.Update(f.ctx, &cm))
+	f.syncConfigMaps()
+}
+
+func (f *fixture) overrideTriggerMode(name model.ManifestName, tm model.TriggerMode) {
+	f.st.WithState(func(state *store.EngineState) {
+		mt := state.ManifestTargets[name]
+		state.TriggerModeOverrides[name] = store.TriggerModeOverride{
+			TriggerMode:         tm,
+			TiltfileTriggerMode: mt.Manifest.TriggerMode,
+		}
+		mt.Manifest.TriggerMode = tm
+	})
+}
+
+func (f *fixture) disableValue(name model.ManifestName) string {
+	var cm v1alpha1.ConfigMap
+	err := f.client.Get(f.ctx, types.NamespacedName{Name: configmap.DisableConfigMapName(name)}, &cm)
+	if err != nil {
+		return ""
+	}
+	return cm.Data[configmap.DisableKey]
+}
+
+func (f *fixture) lastDisableSource(name model.ManifestName) v1alpha1.DisableChangeSource {
+	var cm v1alpha1.ConfigMap
+	err := f.client.Get(f.ctx, types.NamespacedName{Name: configmap.DisableConfigMapName(name)}, &cm)
+	if err != nil {
+		return ""
+	}
+	history := configmap.DisableHistory(&cm)
+	if len(history) == 0 {
+		return ""
+	}
+	return history[len(history)-1].Source
+}
+
+func (f *fixture) writePrefs(resources map[model.ManifestName]resourcePrefs) {
+	err := writePrefsFile(f.dir, prefsFile{
+		Tiltfiles: map[string]tiltfilePrefs{
+			f.tiltfilePath: {UpdatedAt: time.Now(), Resources: resources},
+		},
+	})
+	require.NoError(f.T(), err)
+}
+
+func (f *fixture) savedPrefs() tiltfilePrefs {
+	prefs, err := readPrefsFile(f.dir)
+	require.NoError(f.T(), err)
+	return prefs.Tiltfiles[f.tiltfilePath]
+}
+
+func (f *fixture) onChange() {
+	require.NoError(f.T(), f.sub.OnChange(f.ctx, f.st, store.LegacyChangeSummary()))
+}
@@ -13,6 +13,9 @@ import (
 type SessionUpdateStatusAction struct {
 	ObjectMeta *metav1.ObjectMeta
 	Status     *session.SessionStatus
+
+	// Why the session failed, if it's done with an error.
+	ErrorCategory store.ExitCategory
 }
 
 var _ store.Summarizer = SessionUpdateStatusAction{}
@@ -21,10 +24,11 @@ func (a SessionUpdateStatusAction) Summarize(summary *store.ChangeSummary) {
 	summary.Sessions.Add(types.NamespacedName{Namespace: a.ObjectMeta.Namespace, Name: a.ObjectMeta.Name})
 }
 
-func NewSessionUpdateStatusAction(session *session.Session) SessionUpdateStatusAction {
+func NewSessionUpdateStatusAction(session *session.Session, category store.ExitCategory) SessionUpdateStatusAction {
 	return SessionUpdateStatusAction{
-		ObjectMeta: session.ObjectMeta.DeepCopy(),
-		Status:     session.Status.DeepCopy(),
+		ObjectMeta:    session.ObjectMeta.DeepCopy(),
+		Status:        session.Status.DeepCopy(),
+		ErrorCategory: category,
 	}
 }
 
@@ -34,7 +38,33 @@ func HandleSessionUpdateStatusAction(state *store.EngineState, action SessionUpd
 	if action.Status.Done {
 		state.ExitSignal = true
 		if action.Status.Error != "" {
-			state.ExitError = errors.New(action.Status.Error)
+			state.ExitError = store.ExitError{
+				Category: action.ErrorCategory,
+				Err:      errors.New(action.Status.Error),
+			}
 		}
 	}
 }
+
+type ReadinessSummaryAction struct {
+	Summary store.ReadinessSummary
+}
+
+func (ReadinessSummaryAction) Action() {}
+
+func NewReadinessSummaryAction(summary store.ReadinessSummary) ReadinessSummaryAction {
+	return ReadinessSummaryAction{Summary: summary}
+}
+
+func HandleReadinessSummaryAction(state *store.EngineState, action ReadinessSummaryAction) {
+	state.Readiness = action.Summary
+}
+
+// Dispatched in CI mode when resources haven't become ready before the timeout.
+type CITimeoutAction struct{}
+
+func (CITimeoutAction) Action() {}
+
+func HandleCITimeoutAction(state *store.EngineState, action CITimeoutAction) {
+	state.CITimedOut = true
+}
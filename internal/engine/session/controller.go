@@ -2,7 +2,9 @@ package session
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"os"
 	"sort"
 	"strings"
@@ -11,10 +13,13 @@ import (
 	"github.com/tilt-dev/tilt/pkg/apis"
 	"github.com/tilt-dev/tilt/pkg/model"
 
+	"github.com/tilt-dev/tilt/internal/compat"
 	"github.com/tilt-dev/tilt/internal/engine/buildcontrol"
 
 	"github.com/tilt-dev/tilt/pkg/logger"
 
+	dockerclient "github.com/docker/docker/client"
+	"github.com/jonboulle/clockwork"
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -37,6 +42,12 @@ type Controller struct {
 	startTime  time.Time
 	client     ctrlclient.Client
 	engineMode store.EngineMode
+	allowEmpty AllowEmptyFlag
+	ciTimeout  CITimeoutFlag
+	versions   compat.Versions
+	clock      clockwork.Clock
+
+	cancelTimeout context.CancelFunc
 
 	// The last status object sent to the server.
 	lastStatus *session.SessionStatus
@@ -45,16 +56,59 @@ type Controller struct {
 	// Note that the server may annotate and transform this
 	// on top of what we sent.
 	session *session.Session
+
+	// The last readiness summary sent to the store, and the last progress line we printed.
+	lastReadiness    *store.ReadinessSummary
+	lastProgress     string
+	lastProgressTime time.Time
 }
 
 var _ store.Subscriber = &Controller{}
+var _ store.SubscriberLifecycle = &Controller{}
+
+// If true, a CI session where the Tiltfile doesn't enable any resources
+// succeeds rather than failing.
+type AllowEmptyFlag bool
 
-func NewController(cli ctrlclient.Client, engineMode store.EngineMode) *Controller {
+// How long a CI session waits for resources to become ready before failing.
+//
+// 0 means we wait forever.
+type CITimeoutFlag time.Duration
+
+func NewController(cli ctrlclient.Client, engineMode store.EngineMode, allowEmpty AllowEmptyFlag, ciTimeout CITimeoutFlag, versions compat.Versions) *Controller {
 	return &Controller{
 		pid:        int64(os.Getpid()),
 		startTime:  time.Now(),
 		client:     cli,
 		engineMode: engineMode,
+		allowEmpty: allowEmpty,
+		ciTimeout:  ciTimeout,
+		versions:   versions,
+		clock:      clockwork.NewRealClock(),
+	}
+}
+
+func (c *Controller) SetUp(ctx context.Context, st store.RStore) error {
+	if !c.engineMode.IsCIMode() || c.ciTimeout <= 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	c.cancelTimeout = cancel
+	timer := c.clock.After(time.Duration(c.ciTimeout))
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-timer:
+			st.Dispatch(CITimeoutAction{})
+		}
+	}()
+	return nil
+}
+
+func (c *Controller) TearDown(ctx context.Context) {
+	if c.cancelTimeout != nil {
+		c.cancelTimeout()
 	}
 }
 
@@ -73,8 +127,12 @@ func (c *Controller) OnChange(ctx context.Context, st store.RStore, summary stor
 		}
 	}
 
-	newStatus := c.makeLatestStatus(st)
-	if err := c.handleLatestStatus(ctx, st, newStatus); err != nil {
+	newStatus, category, readiness := c.makeLatestStatus(st)
+	if c.engineMode.IsCIMode() {
+		c.handleReadiness(ctx, st, readiness, newStatus.Done)
+	}
+
+	if err := c.handleLatestStatus(ctx, st, newStatus, category); err != nil {
 		if strings.Contains(err.Error(), context.Canceled.Error()) {
 			return nil
 		}
@@ -118,10 +176,7 @@ func (c *Controller) makeSession(st store.RStore) *session.Session {
 		Spec: session.SessionSpec{
 			TiltfilePath: tf.Spec.Path,
 		},
-		Status: session.SessionStatus{
-			PID:       c.pid,
-			StartTime: apis.NewMicroTime(c.startTime),
-		},
+		Status: *c.initialStatus(),
 	}
 
 	// currently, manual + CI are the only supported modes; the apiserver will validate this field and reject
@@ -136,14 +191,22 @@ func (c *Controller) makeSession(st store.RStore) *session.Session {
 	return s
 }
 
-func (c *Controller) makeLatestStatus(st store.RStore) *session.SessionStatus {
+func (c *Controller) initialStatus() *session.SessionStatus {
+	return &session.SessionStatus{
+		PID:               c.pid,
+		StartTime:         apis.NewMicroTime(c.startTime),
+		KubernetesVersion: c.versions.Kubernetes,
+		DockerVersion:     c.versions.DockerEngine,
+		DockerAPIVersion:  c.versions.DockerAPI,
+	}
+}
+
+// Returns the latest status, and why the session failed, if it did.
+func (c *Controller) makeLatestStatus(st store.RStore) (*session.SessionStatus, store.ExitCategory, store.ReadinessSummary) {
 	state := st.RLockState()
 	defer st.RUnlockState()
 
-	status := &session.SessionStatus{
-		PID:       c.pid,
-		StartTime: apis.NewMicroTime(c.startTime),
-	}
+	status := c.initialStatus()
 
 	// A session only captures services that are created by the main Tiltfile
 	// entrypoint. We don't consider any extension Tiltfiles or Manifests created
@@ -165,11 +228,52 @@ func (c *Controller) makeLatestStatus(st store.RStore) *session.SessionStatus {
 		return status.Targets[i].Name < status.Targets[j].Name
 	})
 
-	processExitCondition(c.session.Spec.ExitCondition, status)
-	return status
+	readiness := evaluateReadiness(state, status.Targets)
+
+	category := store.ExitCategoryUnknown
+	if failed := processExitCondition(c.session.Spec.ExitCondition, status); failed != nil {
+		category = failureCategory(state, *failed)
+	}
+	if c.session.Spec.ExitCondition == session.ExitConditionCI && !status.Done &&
+		len(state.ManifestTargets) == 0 && state.NoResourcesReason != "" {
+		processNoResources(state.NoResourcesReason, c.allowEmpty, status)
+		category = store.ExitCategoryTiltfile
+	}
+	if c.session.Spec.ExitCondition == session.ExitConditionCI && !status.Done && state.CITimedOut {
+		processTimeout(time.Duration(c.ciTimeout), readiness, status)
+		category = store.ExitCategoryTimeout
+	}
+
+	status.EngineMode = engineModeName(c.engineMode)
+	status.Resources = sessionResources(readiness)
+	status.ResourceCounts = resourceCounts(len(state.ManifestTargets), readiness)
+	status.Phase = sessionPhase(status)
+	return status, category, readiness
 }
 
-func (c *Controller) handleLatestStatus(ctx context.Context, st store.RStore, newStatus *session.SessionStatus) error {
+// Records the latest readiness summary, and prints a progress line when it
+// changes (or periodically, so that it's clear Tilt is still waiting).
+func (c *Controller) handleReadiness(ctx context.Context, st store.RStore, readiness store.ReadinessSummary, done bool) {
+	if c.lastReadiness == nil || !equality.Semantic.DeepEqual(*c.lastReadiness, readiness) {
+		c.lastReadiness = &readiness
+		st.Dispatch(NewReadinessSummaryAction(readiness))
+	}
+
+	if done || len(readiness.Resources) == 0 {
+		return
+	}
+
+	progress := readiness.String()
+	now := c.clock.Now()
+	if progress == c.lastProgress && now.Sub(c.lastProgressTime) < progressInterval {
+		return
+	}
+	c.lastProgress = progress
+	c.lastProgressTime = now
+	logger.Get(ctx).Infof("%s", progress)
+}
+
+func (c *Controller) handleLatestStatus(ctx context.Context, st store.RStore, newStatus *session.SessionStatus, category store.ExitCategory) error {
 	// Use the lastStatus to check for changes, so we don't have to worry
 	// about server-side changes affecting the equality check.
 	if equality.Semantic.DeepEqual(c.lastStatus, newStatus) {
@@ -186,43 +290,189 @@ func (c *Controller) handleLatestStatus(ctx context.Context, st store.RStore, ne
 	}
 
 	c.session = updated
-	st.Dispatch(NewSessionUpdateStatusAction(updated))
+	st.Dispatch(NewSessionUpdateStatusAction(updated, category))
 
 	return nil
 }
 
-func processExitCondition(exitCondition session.ExitCondition, status *session.SessionStatus) {
+// Evaluates the exit condition. Returns the target whose failure ended the
+// session, if any.
+//
+// If several targets failed, the first one to fail wins, since later failures
+// are often fallout from the first.
+func processExitCondition(exitCondition session.ExitCondition, status *session.SessionStatus) *session.Target {
 	if exitCondition == session.ExitConditionManual {
-		return
+		return nil
 	} else if exitCondition != session.ExitConditionCI {
 		status.Done = true
 		status.Error = fmt.Sprintf("unsupported exit condition: %s", exitCondition)
 	}
 
+	var failed *session.Target
 	allResourcesOK := true
-	for _, res := range status.Targets {
-		if res.State.Waiting == nil && res.State.Active == nil && res.State.Terminated == nil {
-			// if all states are nil, the target has not been requested to run, e.g. auto_init=False
+	for i, res := range status.Targets {
+		if targetInactive(res) {
 			continue
 		}
 		if res.State.Terminated != nil && res.State.Terminated.Error != "" {
-			status.Done = true
-			status.Error = res.State.Terminated.Error
-			return
+			if failed == nil || failedBefore(res, *failed) {
+				failed = &status.Targets[i]
+			}
+			continue
 		}
-		if res.State.Waiting != nil {
-			allResourcesOK = false
-		} else if res.State.Active != nil && (!res.State.Active.Ready || res.Type == session.TargetTypeJob) {
-			// jobs must run to completion
+		if !targetReady(res) {
 			allResourcesOK = false
 		}
 	}
 
+	if failed != nil {
+		status.Done = true
+		status.Error = failed.State.Terminated.Error
+		return failed
+	}
+
 	// Tiltfile is _always_ a target, so ensure that there's at least one other real target, or it's possible to
 	// exit before the targets have actually been initialized
 	if allResourcesOK && len(status.Targets) > 1 {
 		status.Done = true
 	}
+	return nil
+}
+
+// Whether target a failed before target b. Failures that we don't know the
+// time of count as the most recent.
+func failedBefore(a, b session.Target) bool {
+	aTime := failureTime(a)
+	bTime := failureTime(b)
+	if aTime.IsZero() {
+		return false
+	}
+	return bTime.IsZero() || aTime.Before(bTime)
+}
+
+func failureTime(t session.Target) time.Time {
+	if !t.State.Terminated.FinishTime.IsZero() {
+		return t.State.Terminated.FinishTime.Time
+	}
+	return t.State.Terminated.StartTime.Time
+}
+
+// Classifies the failure of a target, so that `tilt ci` can exit with the
+// right code.
+func failureCategory(state store.EngineState, failed session.Target) store.ExitCategory {
+	if failed.Name == tiltfileTargetName {
+		if ms, ok := state.TiltfileStates[model.MainTiltfileManifestName]; ok && isConnectivityError(ms.LastBuild().Error) {
+			return store.ExitCategoryConnectivity
+		}
+		return store.ExitCategoryTiltfile
+	}
+
+	var mt *store.ManifestTarget
+	if len(failed.Resources) > 0 {
+		mt = state.ManifestTargets[model.ManifestName(failed.Resources[0])]
+	}
+
+	// If we've lost the cluster, a failed deploy or pod is probably
+	// fallout, rather than a problem with the resource.
+	if mt != nil && mt.Manifest.IsK8s() && state.ClusterConnection.Status == store.ClusterConnectionDegraded {
+		return store.ExitCategoryConnectivity
+	}
+
+	if strings.HasSuffix(failed.Name, buildTargetSuffix) {
+		if mt != nil && isConnectivityError(mt.State.LastBuild().Error) {
+			return store.ExitCategoryConnectivity
+		}
+		return store.ExitCategoryBuild
+	}
+	return store.ExitCategoryRuntime
+}
+
+// Whether the error came from failing to reach the cluster or Docker.
+func isConnectivityError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if store.IsConnectivityError(err) || dockerclient.IsErrConnectionFailed(err) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// Resources didn't become ready in time. Fail, and say what we were waiting on.
+func processTimeout(timeout time.Duration, readiness store.ReadinessSummary, status *session.SessionStatus) {
+	status.Done = true
+	status.Error = fmt.Sprintf("Timed out after %s waiting for resources to become ready (%s)", timeout, readiness)
+}
+
+func engineModeName(mode store.EngineMode) string {
+	if mode == store.EngineModeUp {
+		return "up"
+	}
+	return mode.Name
+}
+
+func sessionResources(readiness store.ReadinessSummary) []session.SessionResourceStatus {
+	var result []session.SessionResourceStatus
+	for _, r := range readiness.Resources {
+		result = append(result, session.SessionResourceStatus{
+			Name:          r.Name.String(),
+			BuildComplete: r.BuildComplete,
+			Ready:         r.Ready,
+			Failed:        r.Failed,
+			Reason:        r.Reason,
+		})
+	}
+	return result
+}
+
+// Resources that weren't evaluated haven't been asked to run, so count as inactive.
+func resourceCounts(total int, readiness store.ReadinessSummary) session.SessionResourceCounts {
+	ready := readiness.ReadyCount()
+	failed := readiness.FailedCount()
+	return session.SessionResourceCounts{
+		Total:      int32(total),
+		Ready:      int32(ready),
+		InProgress: int32(len(readiness.Resources) - ready - failed),
+		Error:      int32(failed),
+		Inactive:   int32(total - len(readiness.Resources)),
+	}
+}
+
+// Summarizes the Session with the same rules as the CI exit condition, so
+// that tools watching an "up" session see the same thing "ci" would act on.
+func sessionPhase(status *session.SessionStatus) session.SessionPhase {
+	if status.Error != "" {
+		return session.SessionPhaseError
+	}
+
+	ready := true
+	for _, t := range status.Targets {
+		if targetInactive(t) {
+			continue
+		}
+		if t.State.Terminated != nil && t.State.Terminated.Error != "" {
+			return session.SessionPhaseError
+		}
+		if !targetReady(t) {
+			ready = false
+		}
+	}
+
+	// Until the Tiltfile has loaded, we don't know what we're waiting on.
+	if !ready || len(status.Targets) == 0 {
+		return session.SessionPhaseInProgress
+	}
+	return session.SessionPhaseReady
+}
+
+// The Tiltfile loaded successfully, but there's nothing for CI to wait on.
+// Fail, unless the user told us that's expected.
+func processNoResources(reason string, allowEmpty AllowEmptyFlag, status *session.SessionStatus) {
+	status.Done = true
+	if !allowEmpty {
+		status.Error = fmt.Sprintf("no resources enabled: %s (use --allow-empty to exit successfully)", reason)
+	}
 }
 
 // errToString returns a stringified version of an error or an empty string if the error is nil.
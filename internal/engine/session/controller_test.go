@@ -4,16 +4,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/jonboulle/clockwork"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/tilt-dev/tilt/internal/compat"
+	"github.com/tilt-dev/tilt/internal/container"
 	"github.com/tilt-dev/tilt/internal/controllers/fake"
 	"github.com/tilt-dev/tilt/internal/k8s"
 	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
@@ -42,6 +46,24 @@ func TestExitControlCI_TiltfileFailure(t *testing.T) {
 
 	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
 	f.store.requireExitSignalWithError("fake Tiltfile error")
+	f.store.requireExitCode(store.ExitCodeTiltfile)
+}
+
+func TestExitControlCI_DockerUnreachable(t *testing.T) {
+	f := newFixture(t, store.EngineModeCI)
+	defer f.TearDown()
+
+	f.store.WithState(func(state *store.EngineState) {
+		ms := &store.ManifestState{}
+		ms.AddCompletedBuild(model.BuildRecord{
+			Error: store.NewConnectivityError(errors.New("Failed to connect to Docker")),
+		})
+		state.TiltfileStates[model.MainTiltfileManifestName] = ms
+	})
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	f.store.requireExitSignalWithError("Failed to connect to Docker")
+	f.store.requireExitCode(store.ExitCodeConnectivity)
 }
 
 func TestExitControlIdempotent(t *testing.T) {
@@ -81,6 +103,56 @@ func TestExitControlCI_FirstBuildFailure(t *testing.T) {
 
 	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
 	f.store.requireExitSignalWithError("does not compile")
+	f.store.requireExitCode(store.ExitCodeBuild)
+}
+
+func TestExitControlCI_EarliestFailureWins(t *testing.T) {
+	f := newFixture(t, store.EngineModeCI)
+	defer f.TearDown()
+
+	start := time.Now()
+	f.store.WithState(func(state *store.EngineState) {
+		m := manifestbuilder.New(f, "fe").WithK8sYAML(testyaml.SanchoYAML).Build()
+		state.UpsertManifestTarget(store.NewManifestTarget(m))
+
+		m2 := manifestbuilder.New(f, "fe2").WithK8sYAML(testyaml.SanchoYAML).Build()
+		state.UpsertManifestTarget(store.NewManifestTarget(m2))
+
+		// fe sorts first, but fe2 failed first.
+		state.ManifestTargets["fe"].State.AddCompletedBuild(model.BuildRecord{
+			StartTime:  start,
+			FinishTime: start.Add(2 * time.Second),
+			Error:      fmt.Errorf("fallout"),
+		})
+		state.ManifestTargets["fe2"].State.AddCompletedBuild(model.BuildRecord{
+			StartTime:  start,
+			FinishTime: start.Add(time.Second),
+			Error:      fmt.Errorf("root cause"),
+		})
+	})
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	f.store.requireExitSignalWithError("root cause")
+	f.store.requireExitCode(store.ExitCodeBuild)
+}
+
+func TestExitControlCI_BuildConnectivityFailure(t *testing.T) {
+	f := newFixture(t, store.EngineModeCI)
+	defer f.TearDown()
+
+	f.store.WithState(func(state *store.EngineState) {
+		m := manifestbuilder.New(f, "fe").WithK8sYAML(testyaml.SanchoYAML).Build()
+		state.UpsertManifestTarget(store.NewManifestTarget(m))
+		state.ManifestTargets["fe"].State.AddCompletedBuild(model.BuildRecord{
+			StartTime:  time.Now(),
+			FinishTime: time.Now(),
+			Error:      fmt.Errorf("pushing image: %w", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}),
+		})
+	})
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	f.store.requireExitSignalWithError("pushing image: dial tcp: connection refused")
+	f.store.requireExitCode(store.ExitCodeConnectivity)
 }
 
 func TestExitControlCI_FirstRuntimeFailure(t *testing.T) {
@@ -130,6 +202,87 @@ func TestExitControlCI_FirstRuntimeFailure(t *testing.T) {
 
 	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
 	f.store.requireExitSignalWithError("Pod pod-a in error state due to container c1: ErrImagePull")
+	f.store.requireExitCode(store.ExitCodeRuntime)
+}
+
+func TestExitControlCI_RuntimeFailureWhileClusterDegraded(t *testing.T) {
+	f := newFixture(t, store.EngineModeCI)
+	defer f.TearDown()
+
+	f.store.WithState(func(state *store.EngineState) {
+		m := manifestbuilder.New(f, "fe").WithK8sYAML(testyaml.SanchoYAML).Build()
+		state.UpsertManifestTarget(store.NewManifestTarget(m))
+		mt := state.ManifestTargets["fe"]
+		mt.State.AddCompletedBuild(model.BuildRecord{
+			StartTime:  time.Now(),
+			FinishTime: time.Now(),
+		})
+		mt.State.RuntimeState = store.NewK8sRuntimeStateWithPods(mt.Manifest, v1alpha1.Pod{
+			Name:   "pod-a",
+			Status: "Error",
+			Phase:  string(v1.PodFailed),
+		})
+		state.ClusterConnection = store.ClusterConnection{Status: store.ClusterConnectionDegraded}
+	})
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	f.store.requireExitCode(store.ExitCodeConnectivity)
+}
+
+func TestExitControlCI_Timeout(t *testing.T) {
+	f := newFixture(t, store.EngineModeCI)
+	defer f.TearDown()
+
+	clock := clockwork.NewFakeClock()
+	f.c.clock = clock
+	f.c.ciTimeout = CITimeoutFlag(time.Minute)
+	require.NoError(t, f.c.SetUp(f.ctx, f.store))
+	defer f.c.TearDown(f.ctx)
+
+	f.store.WithState(func(state *store.EngineState) {
+		m := manifestbuilder.New(f, "fe").WithK8sYAML(testyaml.SanchoYAML).Build()
+		state.UpsertManifestTarget(store.NewManifestTarget(m))
+	})
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	f.store.requireNoExitSignal()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	require.Eventually(t, func() bool {
+		state := f.store.RLockState()
+		defer f.store.RUnlockState()
+		return state.CITimedOut
+	}, time.Second, time.Millisecond)
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	f.store.requireExitSignalWithError("Timed out after 1m0s waiting for resources to become ready " +
+		"(ready 0/1: waiting on fe (waiting for first build))")
+	f.store.requireExitCode(store.ExitCodeTimeout)
+}
+
+func TestExitControlCI_NoTimeoutByDefault(t *testing.T) {
+	f := newFixture(t, store.EngineModeCI)
+	defer f.TearDown()
+
+	clock := clockwork.NewFakeClock()
+	f.c.clock = clock
+	require.NoError(t, f.c.SetUp(f.ctx, f.store))
+	defer f.c.TearDown(f.ctx)
+
+	f.store.WithState(func(state *store.EngineState) {
+		m := manifestbuilder.New(f, "fe").WithK8sYAML(testyaml.SanchoYAML).Build()
+		state.UpsertManifestTarget(store.NewManifestTarget(m))
+	})
+
+	// Without a --timeout, nothing waits on the clock.
+	clock.Advance(24 * time.Hour)
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	f.store.requireNoExitSignal()
+
+	state := f.store.RLockState()
+	defer f.store.RUnlockState()
+	assert.False(t, state.CITimedOut)
 }
 
 func TestExitControlCI_PodRunningContainerError(t *testing.T) {
@@ -383,6 +536,54 @@ func TestExitControlCI_JobSuccess(t *testing.T) {
 	f.store.requireExitSignalWithNoError()
 }
 
+func TestExitControlCI_TaskWaitsForLatestRun(t *testing.T) {
+	f := newFixture(t, store.EngineModeCI)
+	defer f.TearDown()
+
+	f.store.WithState(func(state *store.EngineState) {
+		m := manifestbuilder.New(f, "fe").
+			WithK8sYAML(testyaml.JobYAML).
+			WithK8sPodReadiness(model.PodReadinessSucceeded).
+			Build()
+		state.UpsertManifestTarget(store.NewManifestTarget(m))
+
+		state.ManifestTargets["fe"].State.AddCompletedBuild(model.BuildRecord{
+			StartTime:  time.Now(),
+			FinishTime: time.Now(),
+		})
+
+		// The pod from the last run succeeded, but we've since re-created the Job.
+		oldPod := successPod("pod-a")
+		oldPod.AncestorUID = "job-1"
+		krs := store.NewK8sRuntimeStateWithPods(m, oldPod)
+		krs.StartTaskRun(v1.ObjectReference{Kind: "Job", Name: "pi", UID: "job-1"}, time.Now())
+		krs.UpdateTaskRunForPod(krs.Pods["pod-a"])
+		krs.StartTaskRun(v1.ObjectReference{Kind: "Job", Name: "pi", UID: "job-2"}, time.Now())
+		state.ManifestTargets["fe"].State.RuntimeState = krs
+	})
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	f.store.requireNoExitSignal()
+
+	f.store.WithState(func(state *store.EngineState) {
+		krs := state.ManifestTargets["fe"].State.K8sRuntimeState()
+		newPod := pod("pod-b", false)
+		newPod.AncestorUID = "job-2"
+		newPod.CreatedAt = metav1.NewTime(time.Now().Add(time.Second))
+		newPod.Phase = string(v1.PodFailed)
+		newPod.Containers[0].Name = "pi"
+		newPod.Containers[0].State = v1alpha1.ContainerState{
+			Terminated: &v1alpha1.ContainerStateTerminated{ExitCode: 1},
+		}
+		krs.Pods["pod-b"] = &newPod
+		krs.UpdateTaskRunForPod(&newPod)
+		state.ManifestTargets["fe"].State.RuntimeState = krs
+	})
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	f.store.requireExitSignalWithError("Job pi failed: container pi exited with code 1")
+}
+
 func TestExitControlCI_TriggerMode_Local(t *testing.T) {
 	type tc struct {
 		triggerMode model.TriggerMode
@@ -409,7 +610,7 @@ func TestExitControlCI_TriggerMode_Local(t *testing.T) {
 
 			f.store.WithState(func(state *store.EngineState) {
 				mb := manifestbuilder.New(f, "fe").
-					WithLocalResource("echo hi", nil).
+					WithLocalResource("echo hi", "", nil).
 					WithTriggerMode(tc.triggerMode)
 
 				if tc.serveCmd {
@@ -510,6 +711,193 @@ type fixture struct {
 	c     *Controller
 }
 
+func TestExitControlCI_TiltImagePullError(t *testing.T) {
+	f := newFixture(t, store.EngineModeCI)
+	defer f.TearDown()
+
+	f.setUpImagePullError("gcr.io/some-project/fe:tilt-123", true)
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	// Tilt just pushed the image, so wait for the registry check
+	f.store.requireNoExitSignal()
+
+	f.setImagePullCheck(store.ImagePullCheck{
+		Image:   "gcr.io/some-project/fe:tilt-123",
+		Verdict: store.ImagePullVerdictRetrying,
+	})
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	f.store.requireNoExitSignal()
+
+	f.setImagePullCheck(store.ImagePullCheck{
+		Image:   "gcr.io/some-project/fe:tilt-123",
+		Verdict: store.ImagePullVerdictFailed,
+		Error:   "Image gcr.io/some-project/fe:tilt-123 was never pushed to the registry",
+	})
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	f.store.requireExitSignalWithError("Pod pod-a in error state due to container c1: " +
+		"Image gcr.io/some-project/fe:tilt-123 was never pushed to the registry")
+}
+
+func TestExitControlCI_NonTiltImagePullError(t *testing.T) {
+	f := newFixture(t, store.EngineModeCI)
+	defer f.TearDown()
+
+	f.setUpImagePullError("gcr.io/some-project/fe:v1", false)
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	f.store.requireExitSignalWithError("Pod pod-a in error state due to container c1: ErrImagePull")
+}
+
+func TestExitControlCI_TiltfileDefinesNothing(t *testing.T) {
+	f := newFixture(t, store.EngineModeCI)
+	defer f.TearDown()
+
+	f.store.WithState(func(state *store.EngineState) {
+		state.NoResourcesReason = "Tiltfile defines no resources"
+	})
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	f.store.requireExitSignalWithError(
+		"no resources enabled: Tiltfile defines no resources (use --allow-empty to exit successfully)")
+	f.store.requireExitCode(store.ExitCodeTiltfile)
+}
+
+func TestExitControlCI_AllResourcesFilteredOut(t *testing.T) {
+	f := newFixture(t, store.EngineModeCI)
+	defer f.TearDown()
+
+	f.store.WithState(func(state *store.EngineState) {
+		state.NoResourcesReason = "2 resources were defined, but all were filtered out by command-line args"
+	})
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	f.store.requireExitSignalWithError("no resources enabled: 2 resources were defined, " +
+		"but all were filtered out by command-line args (use --allow-empty to exit successfully)")
+}
+
+func TestExitControlCI_AllowEmpty(t *testing.T) {
+	f := newFixture(t, store.EngineModeCI)
+	defer f.TearDown()
+
+	f.c.allowEmpty = true
+	f.store.WithState(func(state *store.EngineState) {
+		state.NoResourcesReason = "Tiltfile defines no resources"
+	})
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	f.store.requireExitSignalWithNoError()
+}
+
+func TestExitControlUp_NoResources(t *testing.T) {
+	f := newFixture(t, store.EngineModeUp)
+	defer f.TearDown()
+
+	f.store.WithState(func(state *store.EngineState) {
+		state.NoResourcesReason = "Tiltfile defines no resources"
+	})
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	f.store.requireNoExitSignal()
+}
+
+func TestStatusRecordsVersions(t *testing.T) {
+	f := newFixture(t, store.EngineModeUp)
+	defer f.TearDown()
+
+	f.c.versions = compat.Versions{
+		Kubernetes:   "v1.24.9-gke.3200",
+		DockerEngine: "20.10.21",
+		DockerAPI:    "1.41",
+	}
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	require.NotNil(t, f.c.session)
+	status := f.c.session.Status
+	assert.Equal(t, "v1.24.9-gke.3200", status.KubernetesVersion)
+	assert.Equal(t, "20.10.21", status.DockerVersion)
+	assert.Equal(t, "1.41", status.DockerAPIVersion)
+}
+
+func TestStatusSummarizesUpSession(t *testing.T) {
+	f := newFixture(t, store.EngineModeUp)
+	defer f.TearDown()
+
+	f.store.WithState(func(state *store.EngineState) {
+		m := manifestbuilder.New(f, "fe").
+			WithK8sYAML(testyaml.SanchoYAML).
+			WithK8sPodReadiness(model.PodReadinessWait).
+			Build()
+		state.UpsertManifestTarget(store.NewManifestTarget(m))
+
+		m2 := manifestbuilder.New(f, "fe2").
+			WithK8sYAML(testyaml.SanchoYAML).
+			WithK8sPodReadiness(model.PodReadinessWait).
+			Build()
+		state.UpsertManifestTarget(store.NewManifestTarget(m2))
+
+		state.ManifestTargets["fe"].State.AddCompletedBuild(model.BuildRecord{
+			StartTime:  time.Now(),
+			FinishTime: time.Now(),
+		})
+		state.ManifestTargets["fe"].State.RuntimeState = store.NewK8sRuntimeStateWithPods(m, pod("pod-a", true))
+	})
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	status := f.c.session.Status
+	assert.Equal(t, "up", status.EngineMode)
+	assert.Equal(t, v1alpha1.SessionPhaseInProgress, status.Phase)
+	assert.Equal(t, v1alpha1.SessionResourceCounts{Total: 2, Ready: 1, InProgress: 1}, status.ResourceCounts)
+	require.Len(t, status.Resources, 2)
+	assert.Equal(t, v1alpha1.SessionResourceStatus{Name: "fe", BuildComplete: true, Ready: true}, status.Resources[0])
+	assert.Equal(t, "fe2", status.Resources[1].Name)
+	assert.False(t, status.Resources[1].Ready)
+	assert.NotEmpty(t, status.Resources[1].Reason)
+
+	f.store.WithState(func(state *store.EngineState) {
+		mt := state.ManifestTargets["fe2"]
+		mt.State.AddCompletedBuild(model.BuildRecord{
+			StartTime:  time.Now(),
+			FinishTime: time.Now(),
+		})
+		mt.State.RuntimeState = store.NewK8sRuntimeStateWithPods(mt.Manifest, pod("pod-b", true))
+	})
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	status = f.c.session.Status
+	assert.Equal(t, v1alpha1.SessionPhaseReady, status.Phase)
+	assert.Equal(t, v1alpha1.SessionResourceCounts{Total: 2, Ready: 2}, status.ResourceCounts)
+	f.store.requireNoExitSignal()
+}
+
+func TestStatusSummarizesFailedCISession(t *testing.T) {
+	f := newFixture(t, store.EngineModeCI)
+	defer f.TearDown()
+
+	f.store.WithState(func(state *store.EngineState) {
+		m := manifestbuilder.New(f, "fe").WithK8sYAML(testyaml.SanchoYAML).Build()
+		state.UpsertManifestTarget(store.NewManifestTarget(m))
+
+		m2 := manifestbuilder.New(f, "fe2").WithK8sYAML(testyaml.SanchoYAML).Build()
+		state.UpsertManifestTarget(store.NewManifestTarget(m2))
+
+		state.ManifestTargets["fe"].State.AddCompletedBuild(model.BuildRecord{
+			StartTime:  time.Now(),
+			FinishTime: time.Now(),
+			Error:      fmt.Errorf("does not compile"),
+		})
+	})
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	f.store.requireExitSignalWithError("does not compile")
+
+	status := f.c.session.Status
+	assert.Equal(t, "ci", status.EngineMode)
+	assert.Equal(t, v1alpha1.SessionPhaseError, status.Phase)
+	assert.Equal(t, v1alpha1.SessionResourceCounts{Total: 2, InProgress: 1, Error: 1}, status.ResourceCounts)
+	require.Len(t, status.Resources, 2)
+	assert.Equal(t, v1alpha1.SessionResourceStatus{Name: "fe", Failed: true, Reason: "build failed"}, status.Resources[0])
+}
+
 func newFixture(t *testing.T, engineMode store.EngineMode) *fixture {
 	f := tempdir.NewTempDirFixture(t)
 
@@ -530,7 +918,7 @@ func newFixture(t *testing.T, engineMode store.EngineMode) *fixture {
 	})
 
 	cli := fake.NewFakeTiltClient()
-	c := NewController(cli, engineMode)
+	c := NewController(cli, engineMode, false, 0, compat.Versions{})
 	ctx := context.Background()
 	l := logger.NewLogger(logger.VerboseLvl, os.Stdout)
 	ctx = logger.WithLogger(ctx, l)
@@ -577,11 +965,19 @@ func (s *testStore) Dispatch(action store.Action) {
 
 	s.TestingStore.Dispatch(action)
 
-	a, ok := action.(SessionUpdateStatusAction)
-	if ok {
+	switch a := action.(type) {
+	case SessionUpdateStatusAction:
 		state := s.LockMutableStateForTesting()
 		HandleSessionUpdateStatusAction(state, a)
 		s.UnlockMutableState()
+	case ReadinessSummaryAction:
+		state := s.LockMutableStateForTesting()
+		HandleReadinessSummaryAction(state, a)
+		s.UnlockMutableState()
+	case CITimeoutAction:
+		state := s.LockMutableStateForTesting()
+		HandleCITimeoutAction(state, a)
+		s.UnlockMutableState()
 	}
 }
 
@@ -600,6 +996,13 @@ func (s *testStore) requireExitSignalWithError(errString string) {
 	assert.True(s.t, state.ExitSignal, "ExitSignal was not true")
 }
 
+func (s *testStore) requireExitCode(code int) {
+	s.t.Helper()
+	state := s.RLockState()
+	defer s.RUnlockState()
+	require.Equal(s.t, code, store.ExitCodeForError(state.ExitError))
+}
+
 func (s *testStore) requireExitSignalWithNoError() {
 	s.t.Helper()
 	state := s.RLockState()
@@ -658,3 +1061,47 @@ func triggerModeString(v model.TriggerMode) string {
 		panic(fmt.Errorf("unknown trigger mode value: %v", v))
 	}
 }
+
+// Sets up a manifest whose pod can't pull the given image.
+func (f *fixture) setUpImagePullError(image string, tiltBuilt bool) {
+	f.store.WithState(func(state *store.EngineState) {
+		m := manifestbuilder.New(f, "fe").WithK8sYAML(testyaml.SanchoYAML).Build()
+		state.UpsertManifestTarget(store.NewManifestTarget(m))
+
+		mt := state.ManifestTargets["fe"]
+		mt.State.AddCompletedBuild(model.BuildRecord{
+			StartTime:  time.Now(),
+			FinishTime: time.Now(),
+		})
+
+		if tiltBuilt {
+			id := model.ImageID(container.MustParseSelector("gcr.io/some-project/fe"))
+			mt.State.MutableBuildStatus(id).LastResult = store.NewImageBuildResultSingleRef(id,
+				container.MustParseNamedTagged(image))
+		}
+
+		mt.State.RuntimeState = store.NewK8sRuntimeStateWithPods(mt.Manifest, v1alpha1.Pod{
+			Name:   "pod-a",
+			Phase:  string(v1.PodPending),
+			Status: "ErrImagePull",
+			Containers: []v1alpha1.Container{
+				{
+					Name:  "c1",
+					Image: image,
+					State: v1alpha1.ContainerState{
+						Waiting: &v1alpha1.ContainerStateWaiting{Reason: "ErrImagePull"},
+					},
+				},
+			},
+		})
+	})
+}
+
+func (f *fixture) setImagePullCheck(check store.ImagePullCheck) {
+	f.store.WithState(func(state *store.EngineState) {
+		mt := state.ManifestTargets["fe"]
+		krs := mt.State.K8sRuntimeState()
+		krs.ImagePullChecks["pod-a"] = map[string]store.ImagePullCheck{check.Image: check}
+		mt.State.RuntimeState = krs
+	})
+}
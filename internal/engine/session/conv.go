@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/tilt-dev/tilt/internal/engine/buildcontrol"
 	"github.com/tilt-dev/tilt/internal/store/k8sconv"
@@ -16,6 +17,11 @@ import (
 	"github.com/tilt-dev/tilt/pkg/model"
 )
 
+const tiltfileTargetName = "tiltfile:update"
+
+// The suffix of the targets that build and deploy a resource.
+const buildTargetSuffix = ":update"
+
 func targetsForResource(mt *store.ManifestTarget, holds buildcontrol.HoldSet) []session.Target {
 	var targets []session.Target
 
@@ -48,6 +54,35 @@ func k8sRuntimeTarget(mt *store.ManifestTarget) *session.Target {
 		Resources: []string{mt.Manifest.Name.String()},
 	}
 
+	// a task is only as done as its latest run, so that CI waits for
+	// the Job we just created rather than reporting on the last one
+	if run, ok := krs.LatestTaskRun(); ok && krs.HasEverDeployedSuccessfully {
+		switch run.Status {
+		case store.TaskRunSucceeded:
+			target.State.Terminated = &session.TargetStateTerminated{
+				StartTime:  apis.NewMicroTime(run.StartTime),
+				FinishTime: apis.NewMicroTime(run.FinishTime),
+			}
+			return target
+		case store.TaskRunFailed:
+			target.State.Terminated = &session.TargetStateTerminated{
+				StartTime:  apis.NewMicroTime(run.StartTime),
+				FinishTime: apis.NewMicroTime(run.FinishTime),
+				Error:      run.Error,
+			}
+			return target
+		}
+
+		// until the new Job's pod shows up, the most recent pod is from an
+		// older run
+		if pod := krs.MostRecentPod(); types.UID(pod.AncestorUID) != run.JobUID {
+			target.State.Waiting = &session.TargetStateWaiting{
+				WaitReason: "waiting-for-pod",
+			}
+			return target
+		}
+	}
+
 	// a lot of this logic is duplicated from K8sRuntimeState::RuntimeStatus()
 	// but ensures Job containers are handled correctly and adds additional
 	// metadata
@@ -77,7 +112,27 @@ func k8sRuntimeTarget(mt *store.ManifestTarget) *session.Target {
 			return target
 		}
 
+		imagePullWaitReason := ""
 		for _, ctr := range store.AllPodContainers(pod) {
+			if store.IsImagePullError(ctr) {
+				if _, ok := mt.State.TiltBuiltImage(ctr.Image); ok {
+					// Tilt just pushed this image, so the pull may be racing the registry.
+					// Wait until we've checked the registry before deciding it's fatal.
+					check, ok := krs.ImagePullCheck(pod.Name, ctr.Image)
+					if !ok || check.Verdict == store.ImagePullVerdictRetrying {
+						imagePullWaitReason = "waiting-for-image-pull-retry"
+						continue
+					}
+
+					target.State.Terminated = &session.TargetStateTerminated{
+						StartTime: apis.NewMicroTime(pod.CreatedAt.Time),
+						Error: fmt.Sprintf("Pod %s in error state due to container %s: %s",
+							pod.Name, ctr.Name, check.Error),
+					}
+					return target
+				}
+			}
+
 			if k8sconv.ContainerStatusToRuntimeState(ctr) == v1alpha1.RuntimeStatusError {
 				target.State.Terminated = &session.TargetStateTerminated{
 					StartTime: apis.NewMicroTime(pod.CreatedAt.Time),
@@ -87,6 +142,13 @@ func k8sRuntimeTarget(mt *store.ManifestTarget) *session.Target {
 				return target
 			}
 		}
+
+		if imagePullWaitReason != "" {
+			target.State.Waiting = &session.TargetStateWaiting{
+				WaitReason: imagePullWaitReason,
+			}
+			return target
+		}
 	}
 
 	// for resources with auto_init=True, fake a fallback waiting state
@@ -215,7 +277,7 @@ func buildTarget(mt *store.ManifestTarget, holds buildcontrol.HoldSet) *session.
 	}
 
 	res := &session.Target{
-		Name:      fmt.Sprintf("%s:update", mt.Manifest.Name.String()),
+		Name:      mt.Manifest.Name.String() + buildTargetSuffix,
 		Resources: []string{mt.Manifest.Name.String()},
 		Type:      session.TargetTypeJob,
 	}
@@ -276,7 +338,7 @@ func waitingFromHolds(mn model.ManifestName, holds buildcontrol.HoldSet) *sessio
 // things.
 func tiltfileTarget(name model.ManifestName, ms *store.ManifestState) session.Target {
 	target := session.Target{
-		Name:      "tiltfile:update",
+		Name:      tiltfileTargetName,
 		Resources: []string{name.String()},
 		Type:      session.TargetTypeJob,
 	}
@@ -0,0 +1,180 @@
+package session
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/tilt-dev/tilt/internal/store"
+	session "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// How often we print a progress line if nothing has changed.
+const progressInterval = 10 * time.Second
+
+// targetInactive returns true if the target has not been requested to run, e.g. auto_init=False
+func targetInactive(t session.Target) bool {
+	return t.State.Waiting == nil && t.State.Active == nil && t.State.Terminated == nil
+}
+
+// targetReady returns true if the target has done everything the CI exit condition waits for.
+func targetReady(t session.Target) bool {
+	if t.State.Waiting != nil {
+		return false
+	}
+	if t.State.Active != nil && (!t.State.Active.Ready || t.Type == session.TargetTypeJob) {
+		// jobs must run to completion
+		return false
+	}
+	return t.State.Terminated == nil || t.State.Terminated.Error == ""
+}
+
+// evaluateReadiness summarizes which resources are ready, and what the rest are blocked on.
+//
+// It uses the same rules as the CI exit condition, so that the progress we report
+// matches what Tilt is actually waiting for.
+func evaluateReadiness(state store.EngineState, targets []session.Target) store.ReadinessSummary {
+	targetsByResource := make(map[model.ManifestName][]session.Target)
+	for _, t := range targets {
+		for _, r := range t.Resources {
+			mn := model.ManifestName(r)
+			targetsByResource[mn] = append(targetsByResource[mn], t)
+		}
+	}
+
+	var summary store.ReadinessSummary
+	for _, mt := range state.ManifestTargets {
+		rr, ok := resourceReadiness(mt, targetsByResource[mt.Manifest.Name])
+		if ok {
+			summary.Resources = append(summary.Resources, rr)
+		}
+	}
+	sort.Slice(summary.Resources, func(i, j int) bool {
+		return summary.Resources[i].Name < summary.Resources[j].Name
+	})
+	return summary
+}
+
+// resourceReadiness evaluates a single resource. Returns false if none of the
+// resource's targets have been requested to run.
+func resourceReadiness(mt *store.ManifestTarget, targets []session.Target) (store.ResourceReadiness, bool) {
+	result := store.ResourceReadiness{
+		Name:          mt.Manifest.Name,
+		BuildComplete: true,
+		Ready:         true,
+	}
+
+	var buildTarget, runtimeTarget *session.Target
+	activeCount := 0
+	for i, t := range targets {
+		if targetInactive(t) {
+			continue
+		}
+		activeCount++
+		if strings.HasSuffix(t.Name, ":update") {
+			buildTarget = &targets[i]
+		} else {
+			runtimeTarget = &targets[i]
+		}
+	}
+	if activeCount == 0 {
+		return store.ResourceReadiness{}, false
+	}
+
+	if buildTarget != nil && !targetReady(*buildTarget) {
+		result.BuildComplete = false
+		result.Ready = false
+		result.Failed = buildTarget.State.Terminated != nil
+		result.Reason = buildBlockingReason(mt, *buildTarget)
+		return result, true
+	}
+
+	if runtimeTarget != nil && !targetReady(*runtimeTarget) {
+		result.Ready = false
+		result.Failed = runtimeTarget.State.Terminated != nil
+		result.Reason = runtimeBlockingReason(mt, *runtimeTarget)
+	}
+	return result, true
+}
+
+func buildBlockingReason(mt *store.ManifestTarget, t session.Target) string {
+	switch {
+	case t.State.Terminated != nil:
+		return "build failed"
+	case t.State.Active != nil:
+		return "building"
+	case len(mt.State.BuildHistory) == 0:
+		return waitingReason("waiting for first build", t)
+	default:
+		return waitingReason("build pending", t)
+	}
+}
+
+func runtimeBlockingReason(mt *store.ManifestTarget, t session.Target) string {
+	if t.State.Terminated != nil {
+		return "runtime error"
+	}
+
+	if mt.Manifest.IsK8s() {
+		return k8sBlockingReason(mt, t)
+	}
+
+	if t.State.Active != nil {
+		return "not ready"
+	}
+	return waitingReason("waiting to start", t)
+}
+
+func k8sBlockingReason(mt *store.ManifestTarget, t session.Target) string {
+	pod := mt.State.K8sRuntimeState().MostRecentPod()
+	if pod.Name == "" {
+		return "waiting for pod"
+	}
+
+	if t.Type == session.TargetTypeJob && t.State.Active != nil {
+		return "job running"
+	}
+
+	switch v1.PodPhase(pod.Phase) {
+	case v1.PodPending:
+		diag, ok := mt.State.K8sRuntimeState().PendingPodDiagnostic(pod.Name)
+		if ok {
+			return fmt.Sprintf("pod Pending: %s", diag.Summary)
+		}
+		for _, c := range pod.Conditions {
+			if c.Type == string(v1.PodScheduled) && c.Status == string(v1.ConditionFalse) && c.Reason != "" {
+				return fmt.Sprintf("pod Pending: %s", strings.ToLower(c.Reason))
+			}
+		}
+		if pod.Status != "" && pod.Status != string(v1.PodPending) {
+			return fmt.Sprintf("pod Pending: %s", pod.Status)
+		}
+		return "pod Pending"
+	case v1.PodRunning:
+		containers := store.AllPodContainers(pod)
+		ready := 0
+		for _, c := range containers {
+			if c.Ready {
+				ready++
+			}
+		}
+		return fmt.Sprintf("%d/%d containers ready", ready, len(containers))
+	}
+
+	if pod.Phase == "" {
+		return "pod starting"
+	}
+	return fmt.Sprintf("pod %s", pod.Phase)
+}
+
+// Adds the hold reason from the target, if there is one.
+func waitingReason(prefix string, t session.Target) string {
+	if t.State.Waiting == nil || t.State.Waiting.WaitReason == "" || t.State.Waiting.WaitReason == "unknown" {
+		return prefix
+	}
+	return fmt.Sprintf("%s: %s", prefix, t.State.Waiting.WaitReason)
+}
@@ -0,0 +1,205 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/testutils/bufsync"
+	"github.com/tilt-dev/tilt/internal/testutils/manifestbuilder"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestReadinessWaitingForFirstBuild(t *testing.T) {
+	f := newFixture(t, store.EngineModeCI)
+	defer f.TearDown()
+
+	f.store.WithState(func(state *store.EngineState) {
+		m := manifestbuilder.New(f, "fe").WithK8sYAML(testyaml.SanchoYAML).Build()
+		state.UpsertManifestTarget(store.NewManifestTarget(m))
+	})
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	assert.Equal(t, []store.ResourceReadiness{
+		{Name: "fe", Reason: "waiting for first build"},
+	}, f.readiness().Resources)
+	assert.Equal(t, "ready 0/1: waiting on fe (waiting for first build)", f.readiness().String())
+}
+
+func TestReadinessBuilding(t *testing.T) {
+	f := newFixture(t, store.EngineModeCI)
+	defer f.TearDown()
+
+	f.store.WithState(func(state *store.EngineState) {
+		m := manifestbuilder.New(f, "fe").WithK8sYAML(testyaml.SanchoYAML).Build()
+		state.UpsertManifestTarget(store.NewManifestTarget(m))
+		state.ManifestTargets["fe"].State.CurrentBuild = model.BuildRecord{StartTime: time.Now()}
+	})
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	assert.Equal(t, "ready 0/1: waiting on fe (building)", f.readiness().String())
+	assert.False(t, f.readiness().Resources[0].BuildComplete)
+}
+
+func TestReadinessPodUnschedulable(t *testing.T) {
+	f := newFixture(t, store.EngineModeCI)
+	defer f.TearDown()
+
+	f.store.WithState(func(state *store.EngineState) {
+		m := manifestbuilder.New(f, "fe").WithK8sYAML(testyaml.SanchoYAML).Build()
+		state.UpsertManifestTarget(store.NewManifestTarget(m))
+
+		mt := state.ManifestTargets["fe"]
+		mt.State.AddCompletedBuild(model.BuildRecord{
+			StartTime:  time.Now(),
+			FinishTime: time.Now(),
+		})
+		mt.State.RuntimeState = store.NewK8sRuntimeStateWithPods(m, v1alpha1.Pod{
+			Name:   "pod-a",
+			Phase:  string(v1.PodPending),
+			Status: string(v1.PodPending),
+			Conditions: []v1alpha1.PodCondition{
+				{
+					Type:   string(v1.PodScheduled),
+					Status: string(v1.ConditionFalse),
+					Reason: "Unschedulable",
+				},
+			},
+		})
+	})
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	assert.Equal(t, []store.ResourceReadiness{
+		{Name: "fe", BuildComplete: true, Reason: "pod Pending: unschedulable"},
+	}, f.readiness().Resources)
+}
+
+func TestReadinessPodPendingDiagnostic(t *testing.T) {
+	f := newFixture(t, store.EngineModeCI)
+	defer f.TearDown()
+
+	f.store.WithState(func(state *store.EngineState) {
+		m := manifestbuilder.New(f, "fe").WithK8sYAML(testyaml.SanchoYAML).Build()
+		state.UpsertManifestTarget(store.NewManifestTarget(m))
+
+		mt := state.ManifestTargets["fe"]
+		mt.State.AddCompletedBuild(model.BuildRecord{
+			StartTime:  time.Now(),
+			FinishTime: time.Now(),
+		})
+		krs := store.NewK8sRuntimeStateWithPods(m, v1alpha1.Pod{
+			Name:   "pod-a",
+			Phase:  string(v1.PodPending),
+			Status: string(v1.PodPending),
+		})
+		krs.PendingPodDiagnostics["pod-a"] = store.PendingPodDiagnostic{
+			Summary: `missing PersistentVolumeClaim "data"`,
+		}
+		mt.State.RuntimeState = krs
+	})
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	assert.Equal(t, `ready 0/1: waiting on fe (pod Pending: missing PersistentVolumeClaim "data")`,
+		f.readiness().String())
+}
+
+func TestReadinessContainersNotReady(t *testing.T) {
+	f := newFixture(t, store.EngineModeCI)
+	defer f.TearDown()
+
+	f.store.WithState(func(state *store.EngineState) {
+		m := manifestbuilder.New(f, "fe").
+			WithK8sYAML(testyaml.SanchoYAML).
+			WithK8sPodReadiness(model.PodReadinessWait).
+			Build()
+		state.UpsertManifestTarget(store.NewManifestTarget(m))
+
+		m2 := manifestbuilder.New(f, "fe2").
+			WithK8sYAML(testyaml.SanchoYAML).
+			WithK8sPodReadiness(model.PodReadinessWait).
+			Build()
+		state.UpsertManifestTarget(store.NewManifestTarget(m2))
+
+		for _, mt := range state.ManifestTargets {
+			mt.State.AddCompletedBuild(model.BuildRecord{
+				StartTime:  time.Now(),
+				FinishTime: time.Now(),
+			})
+		}
+
+		notReady := pod("pod-a", false)
+		notReady.Containers = append(notReady.Containers, pod("pod-a", true).Containers[0])
+		state.ManifestTargets["fe"].State.RuntimeState = store.NewK8sRuntimeStateWithPods(m, notReady)
+		state.ManifestTargets["fe2"].State.RuntimeState = store.NewK8sRuntimeStateWithPods(m2, pod("pod-b", true))
+	})
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	assert.Equal(t, []store.ResourceReadiness{
+		{Name: "fe", BuildComplete: true, Reason: "1/2 containers ready"},
+		{Name: "fe2", BuildComplete: true, Ready: true},
+	}, f.readiness().Resources)
+	assert.Equal(t, "ready 1/2: waiting on fe (1/2 containers ready)", f.readiness().String())
+}
+
+func TestReadinessSkipsInactiveResources(t *testing.T) {
+	f := newFixture(t, store.EngineModeCI)
+	defer f.TearDown()
+
+	f.store.WithState(func(state *store.EngineState) {
+		m := manifestbuilder.New(f, "fe").
+			WithK8sYAML(testyaml.SanchoYAML).
+			WithTriggerMode(model.TriggerModeManual).
+			Build()
+		state.UpsertManifestTarget(store.NewManifestTarget(m))
+	})
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	assert.Empty(t, f.readiness().Resources)
+}
+
+func TestReadinessProgressLineThrottled(t *testing.T) {
+	f := newFixture(t, store.EngineModeCI)
+	defer f.TearDown()
+
+	out := bufsync.NewThreadSafeBuffer()
+	f.ctx = logger.WithLogger(f.ctx, logger.NewTestLogger(out))
+	clock := clockwork.NewFakeClock()
+	f.c.clock = clock
+
+	f.store.WithState(func(state *store.EngineState) {
+		m := manifestbuilder.New(f, "fe").WithK8sYAML(testyaml.SanchoYAML).Build()
+		state.UpsertManifestTarget(store.NewManifestTarget(m))
+	})
+
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	assert.Equal(t, "ready 0/1: waiting on fe (waiting for first build)\n", out.String())
+
+	// Print again when something changes.
+	f.store.WithState(func(state *store.EngineState) {
+		state.ManifestTargets["fe"].State.CurrentBuild = model.BuildRecord{StartTime: time.Now()}
+	})
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	assert.Equal(t, "ready 0/1: waiting on fe (waiting for first build)\n"+
+		"ready 0/1: waiting on fe (building)\n", out.String())
+
+	// Or periodically, if nothing has changed.
+	clock.Advance(progressInterval)
+	_ = f.c.OnChange(f.ctx, f.store, store.LegacyChangeSummary())
+	assert.Equal(t, "ready 0/1: waiting on fe (waiting for first build)\n"+
+		"ready 0/1: waiting on fe (building)\n"+
+		"ready 0/1: waiting on fe (building)\n", out.String())
+}
+
+func (f *fixture) readiness() store.ReadinessSummary {
+	state := f.store.RLockState()
+	defer f.store.RUnlockState()
+	return state.Readiness
+}
@@ -3,13 +3,21 @@ package engine
 import (
 	"github.com/tilt-dev/tilt/internal/cloud"
 	"github.com/tilt-dev/tilt/internal/controllers"
+	"github.com/tilt-dev/tilt/internal/controllers/core/debugcontainer"
+	"github.com/tilt-dev/tilt/internal/controllers/core/filewatch"
+	"github.com/tilt-dev/tilt/internal/controllers/core/kubernetesdiscovery"
+	"github.com/tilt-dev/tilt/internal/controllers/core/podlogstream"
+	"github.com/tilt-dev/tilt/internal/controllers/core/portforward"
 	"github.com/tilt-dev/tilt/internal/engine/analytics"
 	"github.com/tilt-dev/tilt/internal/engine/configs"
 	"github.com/tilt-dev/tilt/internal/engine/dcwatch"
 	"github.com/tilt-dev/tilt/internal/engine/dockerprune"
+	"github.com/tilt-dev/tilt/internal/engine/idle"
 	"github.com/tilt-dev/tilt/internal/engine/k8srollout"
 	"github.com/tilt-dev/tilt/internal/engine/k8swatch"
 	"github.com/tilt-dev/tilt/internal/engine/local"
+	"github.com/tilt-dev/tilt/internal/engine/memory"
+	"github.com/tilt-dev/tilt/internal/engine/resourceprefs"
 	"github.com/tilt-dev/tilt/internal/engine/runtimelog"
 	"github.com/tilt-dev/tilt/internal/engine/session"
 	"github.com/tilt-dev/tilt/internal/engine/telemetry"
@@ -50,6 +58,7 @@ func ProvideSubscribers(
 	ts *hud.TerminalStream,
 	tp *prompt.TerminalPrompt,
 	sw *k8swatch.ServiceWatcher,
+	cm *k8swatch.ClusterMonitor,
 	bc *BuildController,
 	cc *configs.ConfigsController,
 	tqs *configs.TriggerQueueSubscriber,
@@ -63,9 +72,17 @@ func ProvideSubscribers(
 	tc *telemetry.Controller,
 	lsc *local.ServerController,
 	podm *k8srollout.PodMonitor,
+	ipm *k8srollout.ImagePullMonitor,
+	ppm *k8srollout.PendingPodMonitor,
+	pinm *k8srollout.PinMonitor,
 	sc *session.Controller,
 	uss *uisession.Subscriber,
 	urs *uiresource.Subscriber,
+	umr *UpdateModeRecorder,
+	rps *resourceprefs.Subscriber,
+	dcr *debugcontainer.Reconciler,
+	ic *idle.Controller,
+	mm *memory.Monitor,
 ) []store.Subscriber {
 	apiSubscribers := ProvideSubscribersAPIOnly(hudsc, tscm, cb, ts)
 
@@ -73,6 +90,7 @@ func ProvideSubscribers(
 		hud,
 		tp,
 		sw,
+		cm,
 		bc,
 		cc,
 		tqs,
@@ -86,9 +104,52 @@ func ProvideSubscribers(
 		tc,
 		lsc,
 		podm,
+		ipm,
+		ppm,
+		pinm,
 		sc,
 		uss,
 		urs,
+		umr,
+		rps,
+		dcr,
+		ic,
+		mm,
 	}
 	return append(apiSubscribers, legacySubscribers...)
 }
+
+// Everything that caches cluster state and needs to catch up
+// after we lose touch with the cluster.
+func ProvideClusterResyncers(
+	kdr *kubernetesdiscovery.Reconciler,
+	sw *k8swatch.ServiceWatcher,
+	ewm *k8swatch.EventWatchManager,
+	plsc *podlogstream.Controller,
+	pfr *portforward.Reconciler,
+	rr *k8swatch.RegistryResyncer,
+) k8swatch.ClusterResyncers {
+	return k8swatch.ClusterResyncers{
+		// Pods go first, so that the log streams and port-forwards
+		// restart against up-to-date pods.
+		kdr,
+		sw,
+		ewm,
+		plsc,
+		pfr,
+		rr,
+	}
+}
+
+// Everything that can suspend its work while Tilt is asleep.
+func ProvideSleepers(
+	fwc *filewatch.Controller,
+	plsc *podlogstream.Controller,
+	ewm *k8swatch.EventWatchManager,
+) idle.Sleepers {
+	return idle.Sleepers{
+		fwc,
+		plsc,
+		ewm,
+	}
+}
@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tilt-dev/tilt/internal/engine/local"
+	"github.com/tilt-dev/tilt/internal/hud/server"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/store/buildcontrols"
+	"github.com/tilt-dev/tilt/internal/store/configmaps"
+	"github.com/tilt-dev/tilt/internal/store/kubernetesapplys"
+	"github.com/tilt-dev/tilt/internal/store/uiresources"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestActionSummaries(t *testing.T) {
+	fe := types.NamespacedName{Name: "fe"}
+	serverCmd := &v1alpha1.Cmd{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "fe-serve-1",
+			Annotations: map[string]string{v1alpha1.AnnotationManifest: "fe"},
+		},
+	}
+	cmdNN := types.NamespacedName{Name: "fe-serve-1"}
+
+	for _, tc := range []struct {
+		name     string
+		action   store.Summarizer
+		expected store.ChangeSummary
+	}{
+		{
+			"trigger",
+			server.AppendToTriggerQueueAction{Name: "fe", Reason: model.BuildReasonFlagTriggerWeb},
+			store.ChangeSummary{TriggerQueue: true, ManifestStatuses: store.NewChangeSet(fe)},
+		},
+		{
+			"trigger mode override",
+			server.OverrideTriggerModeAction{ManifestNames: []model.ManifestName{"fe"}, TriggerMode: model.TriggerModeManual},
+			store.ChangeSummary{ManifestSpecs: store.NewChangeSet(fe)},
+		},
+		{
+			"build started",
+			buildcontrols.BuildStartedAction{ManifestName: "fe"},
+			store.ChangeSummary{TriggerQueue: true, ManifestStatuses: store.NewChangeSet(fe)},
+		},
+		{
+			"debug override",
+			kubernetesapplys.NewKubernetesApplyDebugOverrideAction("fe"),
+			store.ChangeSummary{TriggerQueue: true, ManifestStatuses: store.NewChangeSet(fe)},
+		},
+		{
+			"cmd create",
+			local.NewCmdCreateAction(serverCmd),
+			store.ChangeSummary{CmdSpecs: store.NewChangeSet(cmdNN), ManifestStatuses: store.NewChangeSet(fe)},
+		},
+		{
+			"cmd status",
+			local.NewCmdUpdateStatusAction(serverCmd),
+			store.ChangeSummary{CmdStatuses: store.NewChangeSet(cmdNN), ManifestStatuses: store.NewChangeSet(fe)},
+		},
+		{
+			"configmap",
+			configmaps.NewConfigMapUpsertAction(&v1alpha1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "fe-disable"}}),
+			store.ChangeSummary{ConfigMaps: store.NewChangeSet(types.NamespacedName{Name: "fe-disable"})},
+		},
+		{
+			"uiresource",
+			uiresources.NewUIResourceDeleteAction("fe"),
+			store.ChangeSummary{UIResources: store.NewChangeSet(fe)},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var summary store.ChangeSummary
+			tc.action.Summarize(&summary)
+			assert.Equal(t, tc.expected, summary)
+		})
+	}
+}
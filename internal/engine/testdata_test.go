@@ -49,14 +49,14 @@ var SanchoRunSteps = RunStepsForApp("sancho")
 func NewSanchoLiveUpdateManifest(f Fixture) model.Manifest {
 	return manifestbuilder.New(f, "sancho").
 		WithK8sYAML(SanchoYAML).
-		WithImageTarget(NewSanchoLiveUpdateImageTarget(f)).
+		WithImageTargets(NewSanchoLiveUpdateImageTarget(f)).
 		Build()
 }
 
 func NewSanchoLiveUpdateDCManifest(f Fixture) model.Manifest {
 	return manifestbuilder.New(f, "sancho").
-		WithDockerCompose().
-		WithImageTarget(NewSanchoLiveUpdateImageTarget(f)).
+		WithDockerCompose("sancho", nil).
+		WithImageTargets(NewSanchoLiveUpdateImageTarget(f)).
 		Build()
 }
 
@@ -80,7 +80,7 @@ func NewSanchoCustomBuildImageTargetWithTag(fixture Fixture, tag string) model.I
 func NewSanchoCustomBuildManifestWithTag(fixture Fixture, tag string) model.Manifest {
 	return manifestbuilder.New(fixture, "sancho").
 		WithK8sYAML(SanchoYAML).
-		WithImageTarget(NewSanchoCustomBuildImageTargetWithTag(fixture, tag)).
+		WithImageTargets(NewSanchoCustomBuildImageTargetWithTag(fixture, tag)).
 		Build()
 }
 
@@ -127,7 +127,7 @@ func NewSanchoDockerBuildManifest(f Fixture) model.Manifest {
 func NewSanchoDockerBuildManifestWithYaml(f Fixture, yaml string) model.Manifest {
 	return manifestbuilder.New(f, "sancho").
 		WithK8sYAML(yaml).
-		WithImageTarget(NewSanchoDockerBuildImageTarget(f)).
+		WithImageTargets(NewSanchoDockerBuildImageTarget(f)).
 		Build()
 }
 
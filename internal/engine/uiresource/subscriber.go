@@ -4,12 +4,14 @@ import (
 	"context"
 	"fmt"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/tilt-dev/tilt/internal/controllers/apicmp"
 	"github.com/tilt-dev/tilt/internal/hud/webview"
 	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/pkg/apis"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
 )
 
@@ -70,6 +72,8 @@ func (s *Subscriber) OnChange(ctx context.Context, st store.RStore, summary stor
 			continue
 		}
 
+		r.Status.LastReadyTime = lastReadyTime(r.Status, stored.Status)
+
 		if !apicmp.DeepEqual(r.Status, stored.Status) {
 			// If the current version is different than what's stored, update it.
 			update := stored.DeepCopy()
@@ -85,4 +89,13 @@ func (s *Subscriber) OnChange(ctx context.Context, st store.RStore, summary stor
 	return nil
 }
 
+// The runtime status is recomputed from scratch on every change, so we
+// find readiness transitions by comparing against the stored status.
+func lastReadyTime(current, stored v1alpha1.UIResourceStatus) metav1.MicroTime {
+	if current.RuntimeStatus == v1alpha1.RuntimeStatusOK && stored.RuntimeStatus != v1alpha1.RuntimeStatusOK {
+		return apis.NowMicro()
+	}
+	return stored.LastReadyTime
+}
+
 var _ store.Subscriber = &Subscriber{}
@@ -80,3 +80,23 @@ func (f *fixture) resource(name string) *v1alpha1.UIResource {
 	require.NoError(f.T(), err)
 	return r
 }
+
+func TestLastReadyTime(t *testing.T) {
+	earlier := metav1.NewMicroTime(time.Unix(1000, 0))
+	stored := v1alpha1.UIResourceStatus{
+		RuntimeStatus: v1alpha1.RuntimeStatusPending,
+		LastReadyTime: earlier,
+	}
+
+	// Not ready to ready is a new transition.
+	current := v1alpha1.UIResourceStatus{RuntimeStatus: v1alpha1.RuntimeStatusOK}
+	assert.True(t, lastReadyTime(current, stored).Time.After(earlier.Time))
+
+	// Staying ready keeps the old transition time.
+	stored.RuntimeStatus = v1alpha1.RuntimeStatusOK
+	assert.Equal(t, earlier, lastReadyTime(current, stored))
+
+	// So does going not ready.
+	current.RuntimeStatus = v1alpha1.RuntimeStatusError
+	assert.Equal(t, earlier, lastReadyTime(current, stored))
+}
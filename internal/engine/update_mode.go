@@ -0,0 +1,34 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/tilt-dev/tilt/internal/docker"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/store/liveupdates"
+)
+
+// Records which update mode Tilt chose at startup, and why,
+// so that users can see it in the API.
+type UpdateModeRecorder struct {
+	state store.UpdateModeState
+}
+
+var _ store.Subscriber = &UpdateModeRecorder{}
+var _ store.SetUpper = &UpdateModeRecorder{}
+
+func NewUpdateModeRecorder(flag liveupdates.UpdateModeFlag, mode liveupdates.UpdateMode, kubeContext k8s.KubeContext, env docker.ClusterEnv) *UpdateModeRecorder {
+	return &UpdateModeRecorder{
+		state: liveupdates.NewUpdateModeState(flag, mode, kubeContext, env),
+	}
+}
+
+func (r *UpdateModeRecorder) SetUp(ctx context.Context, st store.RStore) error {
+	st.Dispatch(liveupdates.NewUpdateModeDecidedAction(r.state))
+	return nil
+}
+
+func (r *UpdateModeRecorder) OnChange(ctx context.Context, st store.RStore, summary store.ChangeSummary) error {
+	return nil
+}
@@ -12,12 +12,17 @@ import (
 
 	tiltanalytics "github.com/tilt-dev/tilt/internal/analytics"
 	"github.com/tilt-dev/tilt/internal/container"
+	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
 	"github.com/tilt-dev/tilt/internal/controllers/core/filewatch"
 	ctrltiltfile "github.com/tilt-dev/tilt/internal/controllers/core/tiltfile"
+	"github.com/tilt-dev/tilt/internal/docker"
 	"github.com/tilt-dev/tilt/internal/dockercompose"
 	"github.com/tilt-dev/tilt/internal/engine/dcwatch"
+	"github.com/tilt-dev/tilt/internal/engine/idle"
+	"github.com/tilt-dev/tilt/internal/engine/k8srollout"
 	"github.com/tilt-dev/tilt/internal/engine/k8swatch"
 	"github.com/tilt-dev/tilt/internal/engine/local"
+	"github.com/tilt-dev/tilt/internal/engine/memory"
 	"github.com/tilt-dev/tilt/internal/engine/runtimelog"
 	"github.com/tilt-dev/tilt/internal/engine/session"
 	"github.com/tilt-dev/tilt/internal/hud"
@@ -31,6 +36,7 @@ import (
 	"github.com/tilt-dev/tilt/internal/store/kubernetesapplys"
 	"github.com/tilt-dev/tilt/internal/store/kubernetesdiscoverys"
 	"github.com/tilt-dev/tilt/internal/store/liveupdates"
+	"github.com/tilt-dev/tilt/internal/store/portforwards"
 	"github.com/tilt-dev/tilt/internal/store/tiltfiles"
 	"github.com/tilt-dev/tilt/internal/store/uiresources"
 	"github.com/tilt-dev/tilt/internal/token"
@@ -75,6 +81,7 @@ func (u Upper) Start(
 	analyticsUserOpt analytics.Opt,
 	token token.Token,
 	cloudAddress string,
+	readOnly bool,
 ) error {
 
 	startTime := time.Now()
@@ -96,6 +103,7 @@ func (u Upper) Start(
 		Token:            token,
 		CloudAddress:     cloudAddress,
 		TerminalMode:     initTerminalMode,
+		ReadOnly:         readOnly,
 	})
 }
 
@@ -136,6 +144,14 @@ func upperReducerFn(ctx context.Context, state *store.EngineState, action store.
 
 	case k8swatch.ServiceChangeAction:
 		handleServiceEvent(ctx, state, action)
+	case k8swatch.ClusterConnectionAction:
+		k8swatch.HandleClusterConnectionAction(state, action)
+	case k8swatch.ClusterClockSkewAction:
+		k8swatch.HandleClusterClockSkewAction(state, action)
+	case k8srollout.ImagePullCheckAction:
+		k8srollout.HandleImagePullCheckAction(state, action)
+	case k8srollout.PendingPodDiagnosticAction:
+		k8srollout.HandlePendingPodDiagnosticAction(state, action)
 	case store.K8sEventAction:
 		handleK8sEvent(ctx, state, action)
 	case buildcontrols.BuildCompleteAction:
@@ -149,7 +165,7 @@ func upperReducerFn(ctx context.Context, state *store.EngineState, action store.
 	case dcwatch.EventAction:
 		handleDockerComposeEvent(ctx, state, action)
 	case server.AppendToTriggerQueueAction:
-		state.AppendToTriggerQueue(action.Name, action.Reason)
+		handleAppendToTriggerQueueAction(ctx, state, action)
 	case hud.DumpEngineStateAction:
 		handleDumpEngineStateAction(ctx, state)
 	case store.AnalyticsUserOptAction:
@@ -166,18 +182,36 @@ func upperReducerFn(ctx context.Context, state *store.EngineState, action store.
 		handleSetTiltfileArgsAction(state, action)
 	case store.LogAction:
 		handleLogAction(state, action)
+	case store.LogBatchAction:
+		for _, la := range action.Actions {
+			handleLogAction(state, la)
+		}
 	case session.SessionUpdateStatusAction:
 		session.HandleSessionUpdateStatusAction(state, action)
+	case idle.SleepAction:
+		idle.HandleSleepAction(state, action)
+	case idle.ActivityAction:
+		idle.HandleActivityAction(state, action)
+	case memory.MemoryReportAction:
+		memory.HandleMemoryReportAction(state, action)
+	case session.ReadinessSummaryAction:
+		session.HandleReadinessSummaryAction(state, action)
+	case session.CITimeoutAction:
+		session.HandleCITimeoutAction(state, action)
 	case prompt.SwitchTerminalModeAction:
 		handleSwitchTerminalModeAction(state, action)
 	case server.OverrideTriggerModeAction:
 		handleOverrideTriggerModeAction(ctx, state, action)
+	case server.ClearLogsAction:
+		handleClearLogsAction(state, action)
 	case local.CmdCreateAction:
 		local.HandleCmdCreateAction(state, action)
 	case local.CmdUpdateStatusAction:
 		local.HandleCmdUpdateStatusAction(state, action)
 	case local.CmdDeleteAction:
 		local.HandleCmdDeleteAction(state, action)
+	case local.CmdServerBackoffAction:
+		// Nothing to change. The ServerController only needs to look again.
 	case tiltfiles.TiltfileUpsertAction:
 		tiltfiles.HandleTiltfileUpsertAction(state, action)
 	case tiltfiles.TiltfileDeleteAction:
@@ -190,6 +224,8 @@ func upperReducerFn(ctx context.Context, state *store.EngineState, action store.
 		kubernetesapplys.HandleKubernetesApplyUpsertAction(state, action)
 	case kubernetesapplys.KubernetesApplyDeleteAction:
 		kubernetesapplys.HandleKubernetesApplyDeleteAction(state, action)
+	case kubernetesapplys.KubernetesApplyDebugOverrideAction:
+		kubernetesapplys.HandleKubernetesApplyDebugOverrideAction(state, action)
 	case kubernetesdiscoverys.KubernetesDiscoveryUpsertAction:
 		kubernetesdiscoverys.HandleKubernetesDiscoveryUpsertAction(state, action)
 	case kubernetesdiscoverys.KubernetesDiscoveryDeleteAction:
@@ -200,12 +236,22 @@ func upperReducerFn(ctx context.Context, state *store.EngineState, action store.
 		uiresources.HandleUIResourceDeleteAction(state, action)
 	case configmaps.ConfigMapUpsertAction:
 		configmaps.HandleConfigMapUpsertAction(state, action)
+	case configmaps.ConfigMapsUpsertAction:
+		configmaps.HandleConfigMapsUpsertAction(state, action)
 	case configmaps.ConfigMapDeleteAction:
 		configmaps.HandleConfigMapDeleteAction(state, action)
 	case liveupdates.LiveUpdateUpsertAction:
 		liveupdates.HandleLiveUpdateUpsertAction(state, action)
 	case liveupdates.LiveUpdateDeleteAction:
 		liveupdates.HandleLiveUpdateDeleteAction(state, action)
+	case portforwards.PortForwardUpsertAction:
+		portforwards.HandlePortForwardUpsertAction(state, action)
+	case portforwards.PortForwardDeleteAction:
+		portforwards.HandlePortForwardDeleteAction(state, action)
+	case liveupdates.UpdateModeDecidedAction:
+		liveupdates.HandleUpdateModeDecidedAction(state, action)
+	case liveupdates.UpdateModeSwitchAction:
+		liveupdates.HandleUpdateModeSwitchAction(ctx, state, action)
 	default:
 		state.FatalError = fmt.Errorf("unrecognized action: %T", action)
 	}
@@ -217,6 +263,14 @@ func handleLogAction(state *store.EngineState, action store.LogAction) {
 	state.LogStore.Append(action, state.Secrets)
 }
 
+func handleClearLogsAction(state *store.EngineState, action server.ClearLogsAction) {
+	mns := make(model.ManifestNameSet, len(action.ManifestNames))
+	for _, mn := range action.ManifestNames {
+		mns[mn] = true
+	}
+	state.LogStore.Clear(mns, action.Retain)
+}
+
 func handleSwitchTerminalModeAction(state *store.EngineState, action prompt.SwitchTerminalModeAction) {
 	state.TerminalMode = action.Mode
 }
@@ -270,6 +324,22 @@ func handleInitAction(ctx context.Context, engineState *store.EngineState, actio
 	engineState.CloudAddress = action.CloudAddress
 	engineState.Token = action.Token
 	engineState.TerminalMode = action.TerminalMode
+	engineState.ReadOnly = action.ReadOnly
+}
+
+func handleAppendToTriggerQueueAction(ctx context.Context, state *store.EngineState, action server.AppendToTriggerQueueAction) {
+	if state.ReadOnly {
+		logger.Get(ctx).Infof("Ignoring trigger for %s: Tilt is in read-only mode", action.Name)
+		return
+	}
+	if cm, ok := state.ConfigMaps[configmap.DisableConfigMapName(action.Name)]; ok && cm.Data[configmap.DisableKey] == "true" {
+		by := ""
+		if history := configmap.DisableHistory(cm); len(history) > 0 {
+			by = fmt.Sprintf(" by %s", configmap.DescribeDisableChangeSource(history[len(history)-1].Source))
+		}
+		logger.Get(ctx).Infof("Resource %s will not build until it's enabled: it was disabled%s", action.Name, by)
+	}
+	state.AppendToTriggerQueue(action.Name, action.Reason)
 }
 
 func handleHudExitAction(state *store.EngineState, action hud.ExitAction) {
@@ -309,6 +379,10 @@ func handleDockerComposeEvent(ctx context.Context, engineState *store.EngineStat
 		state = state.WithLastReadyTime(action.Time)
 	}
 
+	if docker.IsHealthy(action.ContainerState) && state.LastHealthyTime.IsZero() {
+		state = state.WithLastHealthyTime(action.Time)
+	}
+
 	ms.RuntimeState = state
 }
 
@@ -352,9 +426,7 @@ func handleOverrideTriggerModeAction(ctx context.Context, state *store.EngineSta
 	action server.OverrideTriggerModeAction) {
 	// TODO(maia): in this implementation, overrides do NOT persist across Tiltfile loads
 	//   (i.e. the next Tiltfile load will wipe out the override we just put in place).
-	//   If we want to keep this functionality, the next step is to store the set of overrides
-	//   on the engine state, and whenever we load the manifest from the Tiltfile, apply
-	//   any necessary overrides.
+	//   We record them on the engine state so that they can be saved across Tilt restarts.
 
 	// We validate trigger mode when we receive a request, so this should never happen
 	if !model.ValidTriggerMode(action.TriggerMode) {
@@ -369,6 +441,12 @@ func handleOverrideTriggerModeAction(ctx context.Context, state *store.EngineSta
 			logger.Get(ctx).Errorf("INTERNAL ERROR overriding trigger mode: no such manifest %q", mName)
 			return
 		}
+		override, ok := state.TriggerModeOverrides[mName]
+		if !ok {
+			override.TiltfileTriggerMode = mt.Manifest.TriggerMode
+		}
+		override.TriggerMode = action.TriggerMode
+		state.TriggerModeOverrides[mName] = override
 		mt.Manifest.TriggerMode = action.TriggerMode
 	}
 }
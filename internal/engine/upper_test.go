@@ -26,6 +26,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tilt-dev/wmclient/pkg/analytics"
+	"github.com/tilt-dev/wmclient/pkg/dirs"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -33,13 +34,17 @@ import (
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	tiltanalytics "github.com/tilt-dev/tilt/internal/analytics"
+	"github.com/tilt-dev/tilt/internal/build"
 	"github.com/tilt-dev/tilt/internal/cloud"
+	"github.com/tilt-dev/tilt/internal/compat"
 	"github.com/tilt-dev/tilt/internal/container"
 	"github.com/tilt-dev/tilt/internal/containerupdate"
 	"github.com/tilt-dev/tilt/internal/controllers"
+	apiconfigmap "github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
 	apitiltfile "github.com/tilt-dev/tilt/internal/controllers/apis/tiltfile"
 	"github.com/tilt-dev/tilt/internal/controllers/core/cmd"
 	"github.com/tilt-dev/tilt/internal/controllers/core/configmap"
+	"github.com/tilt-dev/tilt/internal/controllers/core/debugcontainer"
 	"github.com/tilt-dev/tilt/internal/controllers/core/extension"
 	"github.com/tilt-dev/tilt/internal/controllers/core/extensionrepo"
 	"github.com/tilt-dev/tilt/internal/controllers/core/filewatch"
@@ -61,9 +66,12 @@ import (
 	"github.com/tilt-dev/tilt/internal/engine/configs"
 	"github.com/tilt-dev/tilt/internal/engine/dcwatch"
 	"github.com/tilt-dev/tilt/internal/engine/dockerprune"
+	"github.com/tilt-dev/tilt/internal/engine/idle"
 	"github.com/tilt-dev/tilt/internal/engine/k8srollout"
 	"github.com/tilt-dev/tilt/internal/engine/k8swatch"
 	"github.com/tilt-dev/tilt/internal/engine/local"
+	"github.com/tilt-dev/tilt/internal/engine/memory"
+	"github.com/tilt-dev/tilt/internal/engine/resourceprefs"
 	"github.com/tilt-dev/tilt/internal/engine/runtimelog"
 	"github.com/tilt-dev/tilt/internal/engine/session"
 	"github.com/tilt-dev/tilt/internal/engine/telemetry"
@@ -81,6 +89,7 @@ import (
 	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/internal/store/buildcontrols"
 	"github.com/tilt-dev/tilt/internal/store/k8sconv"
+	"github.com/tilt-dev/tilt/internal/store/liveupdates"
 	"github.com/tilt-dev/tilt/internal/store/tiltfiles"
 	"github.com/tilt-dev/tilt/internal/testutils"
 	"github.com/tilt-dev/tilt/internal/testutils/bufsync"
@@ -244,6 +253,10 @@ type fakeBuildAndDeployer struct {
 
 	buildLogOutput map[model.TargetID]string
 
+	// Set this to simulate a build reporting what it's doing, e.g.,
+	// so that a timeout can say which phase the build was stuck in.
+	nextBuildPhase string
+
 	resultsByID store.BuildResultSet
 
 	// kClient registers deployed entities for subsequent retrieval.
@@ -288,6 +301,11 @@ func (b *fakeBuildAndDeployer) BuildAndDeploy(ctx context.Context, st store.RSto
 		}
 	}
 
+	if b.nextBuildPhase != "" {
+		build.SetBuildPhase(ctx, "%s", b.nextBuildPhase)
+		b.nextBuildPhase = ""
+	}
+
 	defer func() {
 		b.mu.Unlock()
 
@@ -2870,7 +2888,7 @@ func TestEmptyTiltfile(t *testing.T) {
 		err := f.upper.Start(f.ctx, []string{}, model.TiltBuild{},
 			f.JoinPath("Tiltfile"), store.TerminalModeHUD,
 			analytics.OptIn, token.Token("unit test token"),
-			"nonexistent.example.com")
+			"nonexistent.example.com", false)
 		closeCh <- err
 	}()
 	f.WaitUntil("build is set", func(st store.EngineState) bool {
@@ -2905,7 +2923,7 @@ func TestUpperStart(t *testing.T) {
 	go func() {
 		err := f.upper.Start(f.ctx, []string{"foo", "bar"}, model.TiltBuild{},
 			f.JoinPath("Tiltfile"), store.TerminalModeHUD,
-			analytics.OptIn, tok, cloudAddress)
+			analytics.OptIn, tok, cloudAddress, false)
 		closeCh <- err
 	}()
 	f.WaitUntil("init action processed", func(state store.EngineState) bool {
@@ -3140,6 +3158,29 @@ alert-injest… │ ghij`)
 	assert.Nil(t, err)
 }
 
+func TestLogBatchAction(t *testing.T) {
+	f := newTestFixture(t)
+	defer f.TearDown()
+	f.bc.DisableForTesting()
+
+	manifest := f.newManifest("fe")
+	f.Start([]model.Manifest{manifest})
+
+	spanID := SpanIDForBuildLog(1)
+	f.store.Dispatch(store.LogBatchAction{Actions: []store.LogAction{
+		store.NewLogAction(manifest.Name, spanID, logger.InfoLvl, nil, []byte("a\n")),
+		store.NewLogAction(manifest.Name, spanID, logger.InfoLvl, nil, []byte("b")),
+		store.NewLogAction(manifest.Name, spanID, logger.InfoLvl, nil, []byte("c\n")),
+	}})
+
+	f.WaitUntil("logs appear", func(es store.EngineState) bool {
+		return es.LogStore.SpanLog(spanID) == "a\nbc\n"
+	})
+
+	err := f.Stop()
+	assert.Nil(t, err)
+}
+
 func TestBuildErrorLoggedOnceByUpper(t *testing.T) {
 	f := newTestFixture(t)
 	defer f.TearDown()
@@ -3381,7 +3422,7 @@ func TestHasEverBeenReadyLocal(t *testing.T) {
 	f := newTestFixture(t)
 	defer f.TearDown()
 
-	m := manifestbuilder.New(f, "foobar").WithLocalResource("foo", []string{f.Path()}).Build()
+	m := manifestbuilder.New(f, "foobar").WithLocalResource("foo", "", []string{f.Path()}).Build()
 	f.b.nextBuildError = errors.New("failure!")
 	f.Start([]model.Manifest{m})
 
@@ -3538,8 +3579,7 @@ func TestLocalResourceServeWithNoUpdate(t *testing.T) {
 			defer f.TearDown()
 
 			m := manifestbuilder.New(f, "foo").
-				WithLocalServeCmd("true").
-				WithLocalResource("", []string{f.JoinPath("deps")}).
+				WithLocalResource("", "true", []string{f.JoinPath("deps")}).
 				WithTriggerMode(triggerMode).
 				Build()
 
@@ -3830,7 +3870,7 @@ func TestDisablingResourcePreventsBuild(t *testing.T) {
 	f := newTestFixture(t)
 	defer f.TearDown()
 
-	m := manifestbuilder.New(f, "foo").WithLocalResource("foo", []string{f.Path()}).Build()
+	m := manifestbuilder.New(f, "foo").WithLocalResource("foo", "", []string{f.Path()}).Build()
 
 	f.Start([]model.Manifest{m})
 
@@ -3838,7 +3878,7 @@ func TestDisablingResourcePreventsBuild(t *testing.T) {
 	err := f.ctrlClient.Get(f.ctx, types.NamespacedName{Name: "foo-disable"}, &cm)
 	require.NoError(t, err)
 
-	cm.Data["isDisabled"] = "true"
+	apiconfigmap.RecordDisableChange(&cm, true, v1alpha1.DisableChangeSourceCLI, time.Now())
 	err = f.ctrlClient.Update(f.ctx, &cm)
 	require.NoError(t, err)
 
@@ -3856,6 +3896,13 @@ func TestDisablingResourcePreventsBuild(t *testing.T) {
 		_, holds := buildcontrol.NextTargetToBuild(state)
 		return holds["foo"].Reason == store.HoldReasonDisabled
 	})
+
+	err = f.log.WaitUntilContains("Resource foo will not build until it's enabled: it was disabled by the CLI", stdTimeout)
+	require.NoError(t, err)
+
+	f.withState(func(state store.EngineState) {
+		assert.Contains(t, state.LogStore.ManifestLog("foo"), "Resource disabled by the CLI")
+	})
 }
 
 func TestDisableButtonIsCreated(t *testing.T) {
@@ -3992,7 +4039,7 @@ func newTestFixture(t *testing.T, options ...fixtureOptions) *testFixture {
 	dockerClient := docker.NewFakeClient()
 
 	fSub := fixtureSub{ch: make(chan bool, 1000)}
-	st := store.NewStore(UpperReducer, store.LogActionsFlag(false))
+	st := store.NewStore(UpperReducer, store.LogActionsFlag(false), store.ActionJournalFlag(false))
 	require.NoError(t, st.AddSubscriber(ctx, fSub))
 
 	bc := NewBuildController(b)
@@ -4005,7 +4052,7 @@ func newTestFixture(t *testing.T, options ...fixtureOptions) *testFixture {
 	clock := clockwork.NewRealClock()
 	env := k8s.EnvDockerDesktop
 	podSource := podlogstream.NewPodSource(ctx, b.kClient, v1alpha1.NewScheme())
-	plsc := podlogstream.NewController(ctx, cdc, st, b.kClient, podSource)
+	plsc := podlogstream.NewController(ctx, cdc, st, b.kClient, podSource, nil)
 	au := engineanalytics.NewAnalyticsUpdater(ta, engineanalytics.CmdTags{}, engineMode)
 	ar := engineanalytics.ProvideAnalyticsReporter(ta, st, b.kClient, env)
 	fakeDcc := dockercompose.NewFakeDockerComposeClient(t, ctx)
@@ -4013,11 +4060,11 @@ func newTestFixture(t *testing.T, options ...fixtureOptions) *testFixture {
 	versionExt := version.NewPlugin(model.TiltBuild{Version: "0.5.0"})
 	configExt := config.NewPlugin("up")
 	execer := localexec.NewFakeExecer(t)
-	realTFL := tiltfile.ProvideTiltfileLoader(ta, b.kClient, k8sContextExt, versionExt, configExt, fakeDcc, "localhost", execer, feature.MainDefaults, env)
+	realTFL := tiltfile.ProvideTiltfileLoader(ta, b.kClient, k8sContextExt, versionExt, configExt, fakeDcc, "localhost", execer, feature.MainDefaults, env, tiltfile.DefaultExecLimits())
 	tfl := tiltfile.NewFakeTiltfileLoader()
 	buildSource := ctrltiltfile.NewBuildSource()
 	cc := configs.NewConfigsController(cdc)
-	tqs := configs.NewTriggerQueueSubscriber(cdc)
+	tqs := configs.NewTriggerQueueSubscriber(cdc, clock)
 	dcw := dcwatch.NewEventWatcher(fakeDcc, dockerClient)
 	dclm := runtimelog.NewDockerComposeLogManager(fakeDcc)
 	serverOptions, err := server.ProvideTiltServerOptionsForTesting(ctx)
@@ -4026,7 +4073,7 @@ func newTestFixture(t *testing.T, options ...fixtureOptions) *testFixture {
 	require.NoError(t, err)
 	hudsc := server.ProvideHeadsUpServerController(
 		nil, "tilt-default", webListener, serverOptions,
-		&server.HeadsUpServer{}, assets.NewFakeServer(), model.WebURL{})
+		&server.HeadsUpServer{}, assets.NewFakeServer(), model.WebURL{}, server.WebSecurity{})
 	ns := k8s.Namespace("default")
 	of := k8s.ProvideOwnerFetcher(ctx, b.kClient)
 	rd := kubernetesdiscovery.NewContainerRestartDetector()
@@ -4038,9 +4085,9 @@ func newTestFixture(t *testing.T, options ...fixtureOptions) *testFixture {
 	fpm := cmd.NewFakeProberManager()
 	fwc := filewatch.NewController(cdc, st, watcher.NewSub, timerMaker.Maker(), v1alpha1.NewScheme())
 	cmds := cmd.NewController(ctx, fe, fpm, cdc, st, clock, v1alpha1.NewScheme())
-	lsc := local.NewServerController(cdc)
-	sessionController := session.NewController(cdc, engineMode)
-	ts := hud.NewTerminalStream(hud.NewIncrementalPrinter(log), st)
+	lsc := local.NewServerController(cdc, local.NewFakeProcessSignaler(), clock)
+	sessionController := session.NewController(cdc, engineMode, false, 0, compat.Versions{})
+	ts := hud.NewTerminalStream(hud.NewIncrementalPrinter(log), st, logstore.LevelFilter{})
 	tp := prompt.NewTerminalPrompt(ta, prompt.TTYOpen, openurl.BrowserOpen,
 		log, "localhost", model.WebURL{})
 	h := hud.NewFakeHud()
@@ -4061,14 +4108,15 @@ func newTestFixture(t *testing.T, options ...fixtureOptions) *testFixture {
 
 	wsl := server.NewWebsocketList()
 
-	kar := kubernetesapply.NewReconciler(cdc, b.kClient, sch, docker.Env{}, k8s.KubeContext("kind-kind"), st, "default", execer)
+	kar := kubernetesapply.NewReconciler(cdc, b.kClient, sch, docker.Env{}, k8s.KubeContext("kind-kind"), st, "default", execer, false)
 
-	tfr := ctrltiltfile.NewReconciler(st, tfl, dockerClient, cdc, sch, buildSource, engineMode)
+	tfr := ctrltiltfile.NewReconciler(st, tfl, dockerClient, cdc, sch, buildSource, engineMode, b.kClient, "default", execer, false)
 	tbr := togglebutton.NewReconciler(cdc, sch)
 	extr := extension.NewReconciler(cdc, sch, ta)
 	extrr, err := extensionrepo.NewReconciler(cdc, base)
 	require.NoError(t, err)
 	cmr := configmap.NewReconciler(cdc, st)
+	dcr := debugcontainer.NewReconciler(cdc, b.kClient, st)
 
 	cu := &containerupdate.FakeContainerUpdater{}
 	lur := liveupdate.NewFakeReconciler(st, cu, cdc)
@@ -4088,6 +4136,7 @@ func newTestFixture(t *testing.T, options ...fixtureOptions) *testFixture {
 		extrr,
 		lur,
 		cmr,
+		dcr,
 	))
 
 	dp := dockerprune.NewDockerPruner(dockerClient)
@@ -4128,11 +4177,20 @@ func newTestFixture(t *testing.T, options ...fixtureOptions) *testFixture {
 
 	tc := telemetry.NewController(clock, tracer.NewSpanCollector(ctx))
 	podm := k8srollout.NewPodMonitor()
+	ipm := k8srollout.NewImagePullMonitor(k8srollout.NewDockerRegistryChecker(dockerClient), clock)
+	ppm := k8srollout.NewPendingPodMonitor(b.kClient, clock)
+	pinm := k8srollout.NewPinMonitor(cdc)
 
 	uss := uisession.NewSubscriber(cdc)
 	urs := uiresource.NewSubscriber(cdc)
+	umr := NewUpdateModeRecorder(liveupdates.UpdateModeFlag(liveupdates.UpdateModeAuto), liveupdates.UpdateModeAuto, k8s.KubeContext("kind-kind"), docker.ClusterEnv{})
+
+	cm := k8swatch.NewClusterMonitor(b.kClient, clock, ProvideClusterResyncers(kdc, sw, ewm, plsc, pfr, k8swatch.NewRegistryResyncer(b.kClient)))
 
-	subs := ProvideSubscribers(hudsc, tscm, cb, h, ts, tp, sw, bc, cc, tqs, dcw, dclm, ar, au, ewm, tcum, dp, tc, lsc, podm, sessionController, uss, urs)
+	rps := resourceprefs.NewSubscriber(cdc, dirs.NewTiltDevDirAt(f.JoinPath(".tilt-dev")), resourceprefs.FreshFlag(false))
+	ic := idle.NewController(idle.Timeout(0), clock, ProvideSleepers(fwc, plsc, ewm))
+	mm := memory.NewMonitor(memory.WarningThreshold(0), clock)
+	subs := ProvideSubscribers(hudsc, tscm, cb, h, ts, tp, sw, cm, bc, cc, tqs, dcw, dclm, ar, au, ewm, tcum, dp, tc, lsc, podm, ipm, ppm, pinm, sessionController, uss, urs, umr, rps, dcr, ic, mm)
 	ret.upper, err = NewUpper(ctx, st, subs)
 	require.NoError(t, err)
 
@@ -4595,7 +4653,7 @@ func (f *testFixture) newManifest(name string) model.Manifest {
 		// Right now, most of our tests assume that we're going through
 		// using BuildAndDeployer to do live updates. :\
 		WithLiveUpdateBAD().
-		WithImageTarget(iTarget).
+		WithImageTargets(iTarget).
 		Build()
 }
 
@@ -4607,7 +4665,7 @@ func (f *testFixture) newManifestWithRef(name string, ref reference.Named) model
 
 	return manifestbuilder.New(f, model.ManifestName(name)).
 		WithK8sYAML(SanchoYAML).
-		WithImageTarget(iTarget).
+		WithImageTargets(iTarget).
 		Build()
 }
 
@@ -4617,7 +4675,7 @@ func (f *testFixture) newDockerBuildManifestWithBuildPath(name string, path stri
 	iTarget = iTarget.MustWithRef(container.MustParseSelector(strings.ToLower(name))) // each target should have a unique ID
 	return manifestbuilder.New(f, model.ManifestName(name)).
 		WithK8sYAML(SanchoYAML).
-		WithImageTarget(iTarget).
+		WithImageTargets(iTarget).
 		Build()
 }
 
@@ -12,6 +12,7 @@ import (
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/tilt-dev/tilt/internal/agent"
 	"github.com/tilt-dev/tilt/internal/analytics"
 	"github.com/tilt-dev/tilt/internal/build"
 	"github.com/tilt-dev/tilt/internal/container"
@@ -25,6 +26,7 @@ import (
 	"github.com/tilt-dev/tilt/internal/localexec"
 	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/internal/store/liveupdates"
+	"github.com/tilt-dev/tilt/pkg/model"
 )
 
 var DeployerBaseWireSet = wire.NewSet(
@@ -50,6 +52,7 @@ var DeployerWireSet = wire.NewSet(
 func provideFakeBuildAndDeployer(
 	ctx context.Context,
 	docker docker.Client,
+	ctrd build.ContainerdClient,
 	kClient k8s.Client,
 	dir *dirs.TiltDevDir,
 	env k8s.Env,
@@ -67,8 +70,10 @@ func provideFakeBuildAndDeployer(
 		provideFakeKubeContext,
 		provideFakeDockerClusterEnv,
 		provideFakeK8sNamespace,
+		provideFakeAgentInstaller,
 		liveupdate.NewReconciler,
 		kubernetesapply.NewReconciler,
+		wire.Value(kubernetesapply.VerboseApplyFlag(false)),
 		cmd.WireSet,
 		clockwork.NewRealClock,
 		provideFakeEnv,
@@ -81,6 +86,11 @@ func provideFakeEnv() *localexec.Env {
 	return localexec.EmptyEnv()
 }
 
+// Tests don't run a released Tilt, so the agent can never install.
+func provideFakeAgentInstaller(kClient k8s.Client) *agent.Installer {
+	return agent.NewInstaller(kClient, nil, model.TiltBuild{})
+}
+
 func provideFakeK8sNamespace() k8s.Namespace {
 	return "default"
 }
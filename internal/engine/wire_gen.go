@@ -14,6 +14,7 @@ import (
 	"go.opentelemetry.io/otel/sdk/trace"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/tilt-dev/tilt/internal/agent"
 	"github.com/tilt-dev/tilt/internal/analytics"
 	"github.com/tilt-dev/tilt/internal/build"
 	"github.com/tilt-dev/tilt/internal/container"
@@ -31,11 +32,12 @@ import (
 	"github.com/tilt-dev/tilt/internal/store/liveupdates"
 	"github.com/tilt-dev/tilt/internal/tracer"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
 )
 
 // Injectors from wire.go:
 
-func provideFakeBuildAndDeployer(ctx context.Context, docker2 docker.Client, kClient k8s.Client, dir *dirs.TiltDevDir, env k8s.Env, updateMode liveupdates.UpdateModeFlag, dcc dockercompose.DockerComposeClient, clock build.Clock, kp buildcontrol.KINDLoader, analytics2 *analytics.TiltAnalytics, ctrlClient client.Client, st store.RStore, execer localexec.Execer) (buildcontrol.BuildAndDeployer, error) {
+func provideFakeBuildAndDeployer(ctx context.Context, docker2 docker.Client, ctrd build.ContainerdClient, kClient k8s.Client, dir *dirs.TiltDevDir, env k8s.Env, updateMode liveupdates.UpdateModeFlag, dcc dockercompose.DockerComposeClient, clock build.Clock, kp buildcontrol.KINDLoader, analytics2 *analytics.TiltAnalytics, ctrlClient client.Client, st store.RStore, execer localexec.Execer) (buildcontrol.BuildAndDeployer, error) {
 	dockerUpdater := containerupdate.NewDockerUpdater(docker2)
 	execUpdater := containerupdate.NewExecUpdater(kClient)
 	kubeContext := provideFakeKubeContext(env)
@@ -46,15 +48,18 @@ func provideFakeBuildAndDeployer(ctx context.Context, docker2 docker.Client, kCl
 		return nil, err
 	}
 	scheme := v1alpha1.NewScheme()
-	reconciler := liveupdate.NewReconciler(st, dockerUpdater, execUpdater, liveupdatesUpdateMode, kubeContext, ctrlClient, scheme)
+	installer := provideFakeAgentInstaller(kClient)
+	agentUpdater := containerupdate.NewAgentUpdater(installer)
+	reconciler := liveupdate.NewReconciler(st, dockerUpdater, execUpdater, agentUpdater, installer, liveupdatesUpdateMode, kubeContext, kClient, ctrlClient, scheme)
 	liveUpdateBuildAndDeployer := buildcontrol.NewLiveUpdateBuildAndDeployer(reconciler, clock)
 	labels := _wireLabelsValue
 	dockerImageBuilder := build.NewDockerImageBuilder(docker2, labels)
 	dockerBuilder := build.DefaultDockerBuilder(dockerImageBuilder)
-	execCustomBuilder := build.NewExecCustomBuilder(docker2, clock)
+	execCustomBuilder := build.NewExecCustomBuilder(docker2, ctrd, clock)
 	namespace := provideFakeK8sNamespace()
-	kubernetesapplyReconciler := kubernetesapply.NewReconciler(ctrlClient, kClient, scheme, dockerBuilder, kubeContext, st, namespace, execer)
-	imageBuildAndDeployer := buildcontrol.NewImageBuildAndDeployer(dockerBuilder, execCustomBuilder, kClient, env, kubeContext, analytics2, clock, kp, ctrlClient, kubernetesapplyReconciler)
+	verboseApplyFlag := _wireVerboseApplyFlagValue
+	kubernetesapplyReconciler := kubernetesapply.NewReconciler(ctrlClient, kClient, scheme, dockerBuilder, kubeContext, st, namespace, execer, verboseApplyFlag)
+	imageBuildAndDeployer := buildcontrol.NewImageBuildAndDeployer(dockerBuilder, ctrd, execCustomBuilder, kClient, env, kubeContext, analytics2, clock, kp, ctrlClient, kubernetesapplyReconciler)
 	imageBuilder := buildcontrol.NewImageBuilder(dockerBuilder, execCustomBuilder)
 	dockerComposeBuildAndDeployer := buildcontrol.NewDockerComposeBuildAndDeployer(dcc, docker2, imageBuilder, clock)
 	localexecEnv := provideFakeEnv()
@@ -63,16 +68,18 @@ func provideFakeBuildAndDeployer(ctx context.Context, docker2 docker.Client, kCl
 	clockworkClock := clockwork.NewRealClock()
 	controller := cmd.NewController(ctx, cmdExecer, proberManager, ctrlClient, st, clockworkClock, scheme)
 	localTargetBuildAndDeployer := buildcontrol.NewLocalTargetBuildAndDeployer(clock, ctrlClient, controller)
-	buildOrder := DefaultBuildOrder(liveUpdateBuildAndDeployer, imageBuildAndDeployer, dockerComposeBuildAndDeployer, localTargetBuildAndDeployer, liveupdatesUpdateMode, env, runtime)
+	syncTargetBuildAndDeployer := buildcontrol.NewSyncTargetBuildAndDeployer(kClient, execUpdater, clock)
+	buildOrder := DefaultBuildOrder(liveUpdateBuildAndDeployer, imageBuildAndDeployer, dockerComposeBuildAndDeployer, localTargetBuildAndDeployer, syncTargetBuildAndDeployer, env, runtime)
 	spanExporter := _wireSpanExporterValue
 	traceTracer := tracer.InitOpenTelemetry(spanExporter)
-	compositeBuildAndDeployer := NewCompositeBuildAndDeployer(buildOrder, traceTracer)
+	compositeBuildAndDeployer := NewCompositeBuildAndDeployer(buildOrder, liveupdatesUpdateMode, traceTracer)
 	return compositeBuildAndDeployer, nil
 }
 
 var (
-	_wireLabelsValue       = dockerfile.Labels{}
-	_wireSpanExporterValue = (trace.SpanExporter)(nil)
+	_wireLabelsValue           = dockerfile.Labels{}
+	_wireVerboseApplyFlagValue = kubernetesapply.VerboseApplyFlag(false)
+	_wireSpanExporterValue     = (trace.SpanExporter)(nil)
 )
 
 // wire.go:
@@ -91,6 +98,11 @@ func provideFakeEnv() *localexec.Env {
 	return localexec.EmptyEnv()
 }
 
+// Tests don't run a released Tilt, so the agent can never install.
+func provideFakeAgentInstaller(kClient k8s.Client) *agent.Installer {
+	return agent.NewInstaller(kClient, nil, model.TiltBuild{})
+}
+
 func provideFakeK8sNamespace() k8s.Namespace {
 	return "default"
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/tilt-dev/tilt/internal/analytics"
+	"github.com/tilt-dev/tilt/internal/engine/idle"
 	"github.com/tilt-dev/tilt/internal/hud/view"
 	"github.com/tilt-dev/tilt/internal/openurl"
 	"github.com/tilt-dev/tilt/internal/output"
@@ -39,6 +40,9 @@ type Hud struct {
 	webURL  model.WebURL
 	openurl openurl.OpenURL
 
+	// The view as derived from the engine state, before any sorting.
+	stateView view.View
+
 	currentView      view.View
 	currentViewState view.ViewState
 	mu               sync.RWMutex
@@ -137,6 +141,9 @@ func (h *Hud) handleScreenEvent(ctx context.Context, dispatch func(action store.
 
 	switch ev := ev.(type) {
 	case *tcell.EventKey:
+		if h.currentView.Sleeping {
+			dispatch(idle.NewActivityAction("terminal HUD"))
+		}
 		switch ev.Key() {
 		case tcell.KeyEscape:
 			escape()
@@ -177,6 +184,10 @@ func (h *Hud) handleScreenEvent(ctx context.Context, dispatch func(action store.
 			case r == 'x':
 				h.recordInteraction("cycle_view_log_state")
 				h.currentViewState.CycleViewLogState()
+			case r == 'a':
+				h.recordInteraction("toggle_sort_by_attention")
+				h.currentViewState.SortByAttention = !h.currentViewState.SortByAttention
+				h.currentView = h.sortedView(h.stateView)
 			case r == '1':
 				h.recordInteraction("tab_all_log")
 				h.currentViewState.TabState = view.TabAllLog
@@ -293,11 +304,20 @@ func (h *Hud) OnChange(ctx context.Context, st store.RStore, _ store.ChangeSumma
 	if len(h.currentView.Resources) == 1 && len(view.Resources) > 1 {
 		h.resetResourceSelection()
 	}
-	h.currentView = view
+	h.stateView = view
+	h.currentView = h.sortedView(view)
 	h.refreshSelectedIndex()
 	return nil
 }
 
+// Must hold the lock
+func (h *Hud) sortedView(v view.View) view.View {
+	if h.currentViewState.SortByAttention {
+		return v.SortedByAttention()
+	}
+	return v
+}
+
 func (h *Hud) Refresh(ctx context.Context) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
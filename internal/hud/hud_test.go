@@ -7,9 +7,13 @@ import (
 	"time"
 
 	"github.com/gdamore/tcell"
+	"github.com/stretchr/testify/assert"
 
+	"github.com/tilt-dev/tilt/internal/engine/idle"
+	"github.com/tilt-dev/tilt/internal/hud/view"
 	"github.com/tilt-dev/tilt/internal/openurl"
 	"github.com/tilt-dev/tilt/internal/rty"
+	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/internal/testutils"
 	"github.com/tilt-dev/tilt/pkg/model"
 )
@@ -25,3 +29,61 @@ func TestRenderInit(t *testing.T) {
 	hud := NewHud(r, model.WebURL(*webURL), ta, openurl.BrowserOpen)
 	hud.(*Hud).refresh(ctx) // Ensure we render without error
 }
+
+func TestToggleSortByAttention(t *testing.T) {
+	logs := new(bytes.Buffer)
+	ctx, _, ta := testutils.ForkedCtxAndAnalyticsForTest(logs)
+
+	clockForTest := func() time.Time { return time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC) }
+	r := NewRenderer(clockForTest)
+	r.rty = rty.NewRTY(tcell.NewSimulationScreen(""), t)
+	h := NewHud(r, model.WebURL{}, ta, openurl.BrowserOpen).(*Hud)
+
+	h.stateView = view.View{Resources: []view.Resource{
+		{Name: "a"},
+		{Name: "b", AttentionScore: 5},
+		{Name: "c"},
+		{Name: "d", AttentionScore: 100},
+	}}
+	h.currentView = h.stateView
+
+	names := func() []model.ManifestName {
+		var result []model.ManifestName
+		for _, r := range h.currentView.Resources {
+			result = append(result, r.Name)
+		}
+		return result
+	}
+
+	h.handleScreenEvent(ctx, func(action store.Action) {}, tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone))
+	assert.Equal(t, []model.ManifestName{"d", "b", "a", "c"}, names())
+	assert.Contains(t, keyLegend(h.currentView, h.currentViewState), "sorted by attention")
+
+	h.handleScreenEvent(ctx, func(action store.Action) {}, tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone))
+	assert.Equal(t, []model.ManifestName{"a", "b", "c", "d"}, names())
+}
+
+func TestKeyPressWakesTilt(t *testing.T) {
+	logs := new(bytes.Buffer)
+	ctx, _, ta := testutils.ForkedCtxAndAnalyticsForTest(logs)
+
+	clockForTest := func() time.Time { return time.Date(2017, 1, 1, 12, 0, 0, 0, time.UTC) }
+	r := NewRenderer(clockForTest)
+	r.rty = rty.NewRTY(tcell.NewSimulationScreen(""), t)
+	h := NewHud(r, model.WebURL{}, ta, openurl.BrowserOpen).(*Hud)
+
+	var actions []store.Action
+	dispatch := func(action store.Action) { actions = append(actions, action) }
+
+	h.currentView = view.View{Resources: []view.Resource{{Name: "a"}}}
+	h.handleScreenEvent(ctx, dispatch, tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone))
+	assert.Empty(t, actions)
+	assert.NotContains(t, keyLegend(h.currentView, h.currentViewState), "sleeping")
+
+	h.currentView.Sleeping = true
+	assert.Contains(t, keyLegend(h.currentView, h.currentViewState), "sleeping")
+	h.handleScreenEvent(ctx, dispatch, tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone))
+	if assert.Len(t, actions, 1) {
+		assert.Equal(t, "terminal HUD", actions[0].(idle.ActivityAction).Source)
+	}
+}
@@ -84,6 +84,12 @@ func (p *TerminalPrompt) tiltBuild(st store.RStore) model.TiltBuild {
 	return state.TiltBuildInfo
 }
 
+func (p *TerminalPrompt) isReadOnly(st store.RStore) bool {
+	state := st.RLockState()
+	defer st.RUnlockState()
+	return state.ReadOnly
+}
+
 func (p *TerminalPrompt) isEnabled(st store.RStore) bool {
 	state := st.RLockState()
 	defer st.RUnlockState()
@@ -113,6 +119,10 @@ func (p *TerminalPrompt) OnChange(ctx context.Context, st store.RStore, _ store.
 	_, _ = fmt.Fprintf(p.stdout, "%s\n", firstLine)
 	_, _ = fmt.Fprintf(p.stdout, "%s\n\n", buildStamp)
 
+	if p.isReadOnly(st) {
+		_, _ = fmt.Fprintf(p.stdout, "Read-only mode: triggers and other changes from the UI are turned off\n\n")
+	}
+
 	// Print all the init output. See comments on SetInitOutput()
 	infoLines := strings.Split(strings.TrimRight(p.initOutput.String(), "\n"), "\n")
 	needsNewline := false
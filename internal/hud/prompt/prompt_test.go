@@ -71,6 +71,18 @@ func TestInitOutput(t *testing.T) {
 (space) to open the browser`)
 }
 
+func TestReadOnly(t *testing.T) {
+	f := newFixture()
+	defer f.TearDown()
+
+	f.st.WithState(func(state *store.EngineState) {
+		state.ReadOnly = true
+	})
+	_ = f.prompt.OnChange(f.ctx, f.st, store.LegacyChangeSummary())
+
+	assert.Contains(t, f.out.String(), "Read-only mode")
+}
+
 type fixture struct {
 	ctx    context.Context
 	cancel func()
@@ -171,11 +171,18 @@ func (r *Renderer) renderStatusBar(v view.View) rty.Component {
 	l.Add(rty.TextString(" "))
 	l.AddDynamic(rty.NewFillerString(' '))
 
-	msg := " To explore, open web view (enter) • terminal is limited "
-	l.Add(rty.ColoredString(msg, cText))
+	msg, color := statusBarMessage(v)
+	l.Add(rty.ColoredString(msg, color))
 	return rty.Bg(rty.OneLine(l), tcell.ColorWhiteSmoke)
 }
 
+func statusBarMessage(v view.View) (string, tcell.Color) {
+	if v.ClusterConnectionError != "" {
+		return " Lost connection to Kubernetes cluster • pod status may be stale ", cBad
+	}
+	return " To explore, open web view (enter) • terminal is limited ", cText
+}
+
 func (r *Renderer) renderFooter(v view.View, keys string) rty.Component {
 	footer := rty.NewConcatLayout(rty.DirVert)
 	footer.Add(r.renderStatusBar(v))
@@ -193,6 +200,15 @@ func keyLegend(v view.View, vs view.ViewState) string {
 	if vs.AlertMessage != "" {
 		return "Tilt (l)og ┊ (esc) close alert "
 	}
+	if vs.SortByAttention {
+		defaultKeys += "┊ (a) sorted by attention  "
+	}
+	if v.Sleeping {
+		defaultKeys += "┊ sleeping (press any key to wake)  "
+	}
+	if v.ReadOnly {
+		return defaultKeys + "┊ read-only  "
+	}
 	return defaultKeys
 }
 
@@ -610,6 +610,18 @@ func TestStatusBarDCRebuild(t *testing.T) {
 	rtf.run("status bar after intentional DC restart", 60, 20, v, vs)
 }
 
+func TestStatusBarClusterConnection(t *testing.T) {
+	v := newView(view.Resource{Name: "snack"})
+	msg, color := statusBarMessage(v)
+	assert.Contains(t, msg, "open web view")
+	assert.Equal(t, cText, color)
+
+	v.ClusterConnectionError = "connection refused"
+	msg, color = statusBarMessage(v)
+	assert.Contains(t, msg, "Lost connection to Kubernetes cluster")
+	assert.Equal(t, cBad, color)
+}
+
 func TestDetectDCCrashExpanded(t *testing.T) {
 	rtf := newRendererTestFixture(t)
 
@@ -159,12 +159,27 @@ func (v *ResourceView) titleTextName() rty.Component {
 	return sb.Build()
 }
 
+// The warnings from the last build, followed by any Tiltfile warnings
+// that aren't in the build log (e.g., because they were announced by an
+// earlier Tiltfile load).
 func (v *ResourceView) warnings() []string {
+	var result []string
 	spanID := v.res.LastBuild().SpanID
-	if spanID == "" {
-		return nil
+	if spanID != "" {
+		result = v.logReader.Warnings(spanID)
+	}
+
+	logged := make(map[string]bool, len(result))
+	for _, w := range result {
+		logged[w] = true
+	}
+	for _, w := range v.res.TiltfileWarnings {
+		msg := w.Message + "\n"
+		if !logged[msg] {
+			result = append(result, msg)
+		}
 	}
-	return v.logReader.Warnings(spanID)
+	return result
 }
 
 func (v *ResourceView) titleText() rty.Component {
@@ -1,6 +1,9 @@
 package server
 
 import (
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/pkg/model"
 )
 
@@ -11,6 +14,13 @@ type AppendToTriggerQueueAction struct {
 
 func (AppendToTriggerQueueAction) Action() {}
 
+var _ store.Summarizer = AppendToTriggerQueueAction{}
+
+func (a AppendToTriggerQueueAction) Summarize(s *store.ChangeSummary) {
+	s.TriggerQueue = true
+	s.ManifestStatuses.Add(types.NamespacedName{Name: a.Name.String()})
+}
+
 // TODO: a way to clear an override
 type OverrideTriggerModeAction struct {
 	ManifestNames []model.ManifestName
@@ -18,3 +28,27 @@ type OverrideTriggerModeAction struct {
 }
 
 func (OverrideTriggerModeAction) Action() {}
+
+var _ store.Summarizer = OverrideTriggerModeAction{}
+
+func (a OverrideTriggerModeAction) Summarize(s *store.ChangeSummary) {
+	for _, mn := range a.ManifestNames {
+		s.ManifestSpecs.Add(types.NamespacedName{Name: mn.String()})
+	}
+}
+
+// Clears the logs of the given manifests (or all logs, if empty) from the UI.
+type ClearLogsAction struct {
+	ManifestNames []model.ManifestName
+
+	// Keep the cleared logs around for snapshots.
+	Retain bool
+}
+
+func (ClearLogsAction) Action() {}
+
+var _ store.Summarizer = ClearLogsAction{}
+
+func (a ClearLogsAction) Summarize(s *store.ChangeSummary) {
+	s.Log = true
+}
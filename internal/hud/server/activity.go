@@ -0,0 +1,41 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/tilt-dev/tilt/internal/engine/idle"
+	"github.com/tilt-dev/tilt/internal/store"
+)
+
+// The user agent of the clients that Tilt uses to talk to its own API server.
+//
+// Requests from these clients are Tilt talking to itself, so they don't count
+// as activity.
+const LoopbackUserAgent = "tilt-loopback"
+
+// While Tilt is awake, how often a stream of requests counts as new activity.
+//
+// The idle timeout is measured in minutes, so there's no need to dispatch an
+// action on every request.
+const activityInterval = time.Minute
+
+// Wraps a handler so that every request counts as user activity, and wakes
+// Tilt up if it's sleeping.
+//
+// The request is always served, whether or not Tilt is asleep.
+func recordActivity(st store.RStore, source string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.UserAgent() != LoopbackUserAgent {
+			state := st.RLockState()
+			sleeping := state.Sleeping
+			lastActivity := state.LastActivityTime
+			st.RUnlockState()
+
+			if sleeping || time.Since(lastActivity) >= activityInterval {
+				st.Dispatch(idle.NewActivityAction(source))
+			}
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tilt-dev/tilt/internal/engine/idle"
+	"github.com/tilt-dev/tilt/internal/store"
+)
+
+func TestRecordActivity(t *testing.T) {
+	st := store.NewTestingStore()
+	served := 0
+	handler := recordActivity(st, "web UI request", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served++
+	}))
+
+	serve := func(userAgent string) {
+		req := httptest.NewRequest("GET", "/api/view", nil)
+		req.Header.Set("User-Agent", userAgent)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	serve("Mozilla/5.0")
+	if assert.Len(t, st.Actions(), 1) {
+		assert.Equal(t, "web UI request", st.Actions()[0].(idle.ActivityAction).Source)
+	}
+
+	// While we're awake, recent activity doesn't need to be recorded again.
+	st.ClearActions()
+	st.WithState(func(state *store.EngineState) {
+		state.LastActivityTime = time.Now()
+	})
+	serve("Mozilla/5.0")
+	assert.Empty(t, st.Actions())
+
+	// While we're asleep, every request wakes us up...
+	st.WithState(func(state *store.EngineState) {
+		state.Sleeping = true
+	})
+	serve("Mozilla/5.0")
+	assert.Len(t, st.Actions(), 1)
+
+	// ...except requests from Tilt itself.
+	st.ClearActions()
+	serve(LoopbackUserAgent)
+	assert.Empty(t, st.Actions())
+
+	assert.Equal(t, 4, served)
+}
@@ -88,18 +88,52 @@ func ProvideConfigAccess(dir *dirs.TiltDevDir) clientcmd.ConfigAccess {
 	return ret
 }
 
+// How many ports past the requested port we try when the requested
+// port is busy and the user didn't pin it explicitly.
+const WebPortAutoIncrementRange = 10
+
 // Creates a listener for the plain http web server.
 func ProvideWebListener(host model.WebHost, port model.WebPort) (WebListener, error) {
 	webListener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", string(host), int(port)))
 	if err != nil {
-		return nil, fmt.Errorf("Tilt cannot start because you already have another process on port %d\n"+
+		owner := ""
+		if desc := describePortOwner(int(port)); desc != "" {
+			owner = fmt.Sprintf(" (%s)", desc)
+		}
+		return nil, fmt.Errorf("Tilt cannot start because you already have another process on port %d%s\n"+
 			"If you want to run multiple Tilt instances simultaneously,\n"+
 			"use the --port flag or TILT_PORT env variable to set a custom port\nOriginal error: %v",
-			port, err)
+			port, owner, err)
 	}
 	return WebListener(webListener), nil
 }
 
+// Picks the port for the web server.
+//
+// If the requested port is busy and the user didn't pin it explicitly
+// (with --port or TILT_PORT), tries the next few ports until it finds a free one.
+//
+// If the port is pinned, returns it unchanged, and lets ProvideWebListener
+// report the conflict.
+func ChooseWebPort(host model.WebHost, port model.WebPort, pinned bool) (model.WebPort, error) {
+	if pinned || port == 0 {
+		return port, nil
+	}
+
+	for i := 0; i <= WebPortAutoIncrementRange; i++ {
+		candidate := port + model.WebPort(i)
+		l, err := net.Listen("tcp", fmt.Sprintf("%s:%d", string(host), int(candidate)))
+		if err != nil {
+			continue
+		}
+		_ = l.Close()
+		return candidate, nil
+	}
+	return 0, fmt.Errorf("Tilt cannot start because ports %d-%d are all in use\n"+
+		"Use the --port flag or TILT_PORT env variable to set a custom port",
+		port, port+WebPortAutoIncrementRange)
+}
+
 // Picks a random port for the APIServer.
 //
 // TODO(nick): In the future, we should be able to have the apiserver listen
@@ -175,6 +209,9 @@ func ProvideTiltServerOptions(
 	config.GenericConfig.LoopbackClientConfig.QPS = 1000
 	config.GenericConfig.LoopbackClientConfig.Burst = 1000
 
+	// So that Tilt talking to itself doesn't keep it awake.
+	config.GenericConfig.LoopbackClientConfig.UserAgent = LoopbackUserAgent
+
 	config.GenericConfig.MaxRequestBodyBytes = maxRequestBodyBytes
 	return config, nil
 }
@@ -233,10 +233,48 @@ func newAPIServerFixture(t testing.TB) *apiserverFixture {
 func (f *apiserverFixture) start() *HeadsUpServerController {
 	f.t.Helper()
 	hudsc := ProvideHeadsUpServerController(f.configAccess, "tilt-default",
-		f.webListener, f.serverConfig, &HeadsUpServer{}, assets.NewFakeServer(), f.webURL)
+		f.webListener, f.serverConfig, &HeadsUpServer{}, assets.NewFakeServer(), f.webURL, WebSecurity{})
 	require.NoError(f.t, hudsc.SetUp(f.ctx, f.st))
 	f.t.Cleanup(func() {
 		hudsc.TearDown(f.ctx)
 	})
 	return hudsc
 }
+
+func TestChooseWebPortAutoIncrements(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer busy.Close()
+	busyPort := model.WebPort(busy.Addr().(*net.TCPAddr).Port)
+
+	port, err := ChooseWebPort("127.0.0.1", busyPort, false)
+	require.NoError(t, err)
+	assert.Greater(t, int(port), int(busyPort))
+	assert.LessOrEqual(t, int(port), int(busyPort)+WebPortAutoIncrementRange)
+
+	l, err := ProvideWebListener("127.0.0.1", port)
+	require.NoError(t, err)
+	_ = l.Close()
+}
+
+func TestChooseWebPortPinnedBusyFails(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer busy.Close()
+	busyPort := model.WebPort(busy.Addr().(*net.TCPAddr).Port)
+
+	port, err := ChooseWebPort("127.0.0.1", busyPort, true)
+	require.NoError(t, err)
+	assert.Equal(t, busyPort, port)
+
+	_, err = ProvideWebListener("127.0.0.1", port)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), fmt.Sprintf("another process on port %d", busyPort))
+	}
+}
+
+func TestParseLsofOwner(t *testing.T) {
+	assert.Equal(t, "node, pid 1234", parseLsofOwner("p1234\ncnode\nf20\n"))
+	assert.Equal(t, "pid 1234", parseLsofOwner("p1234\n"))
+	assert.Equal(t, "", parseLsofOwner(""))
+}
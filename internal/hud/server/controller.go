@@ -19,6 +19,7 @@ import (
 	"github.com/tilt-dev/tilt-apiserver/pkg/server/start"
 	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/pkg/assets"
+	"github.com/tilt-dev/tilt/pkg/logger"
 	"github.com/tilt-dev/tilt/pkg/model"
 )
 
@@ -38,7 +39,10 @@ type HeadsUpServerController struct {
 	// want to persist the config to disk.
 	configAccess clientcmd.ConfigAccess
 
-	apiServerName   model.APIServerName
+	apiServerName model.APIServerName
+
+	// webListener may be nil when the web UI is turned off,
+	// e.g., when Tilt is embedded in another program.
 	webListener     WebListener
 	hudServer       *HeadsUpServer
 	assetServer     assets.Server
@@ -46,6 +50,7 @@ type HeadsUpServerController struct {
 	webServer       *http.Server
 	webURL          model.WebURL
 	apiServerConfig *APIServerConfig
+	security        WebSecurity
 
 	shutdown func()
 }
@@ -57,7 +62,8 @@ func ProvideHeadsUpServerController(
 	apiServerConfig *APIServerConfig,
 	hudServer *HeadsUpServer,
 	assetServer assets.Server,
-	webURL model.WebURL) *HeadsUpServerController {
+	webURL model.WebURL,
+	security WebSecurity) *HeadsUpServerController {
 
 	emptyCh := make(chan struct{})
 	close(emptyCh)
@@ -70,6 +76,7 @@ func ProvideHeadsUpServerController(
 		assetServer:     assetServer,
 		webURL:          webURL,
 		apiServerConfig: apiServerConfig,
+		security:        security,
 		shutdown:        func() {},
 	}
 }
@@ -81,10 +88,15 @@ func (s *HeadsUpServerController) TearDown(ctx context.Context) {
 	// Close all active connections immediately.
 	// Tilt is deleting all its state, so there's no good
 	// reason to handle graceful shutdown.
-	_ = s.webServer.Close()
-	_ = s.apiServer.Close()
+	if s.webServer != nil {
+		_ = s.webServer.Close()
+	}
+	if s.apiServer != nil {
+		_ = s.apiServer.Close()
+	}
 
 	_ = s.removeFromAPIServerConfig()
+	_ = s.security.removeConnInfo()
 }
 
 func (s *HeadsUpServerController) OnChange(ctx context.Context, st store.RStore, _ store.ChangeSummary) error {
@@ -105,6 +117,16 @@ func (s *HeadsUpServerController) SetUp(ctx context.Context, st store.RStore) er
 	if err != nil {
 		return fmt.Errorf("writing tilt api configs: %v", err)
 	}
+	err = s.security.writeConnInfo()
+	if err != nil {
+		return fmt.Errorf("writing tilt web connection info: %v", err)
+	}
+	if s.security.Token != "" && !s.webURL.Empty() {
+		authURL := s.webURL
+		authURL.RawQuery = fmt.Sprintf("%s=%s", webAuthTokenParam, s.security.Token)
+		logger.Get(ctx).Infof("Tilt web server requires auth. Open the web UI with:\n  %s\n"+
+			"(CLI commands read the token from %s)", authURL.String(), s.security.ConnInfoPath)
+	}
 	return nil
 }
 
@@ -155,25 +177,28 @@ func (s *HeadsUpServerController) setUpHelper(ctx context.Context, st store.RSto
 		return fmt.Errorf("failed to create apiserver proxy: %v", err)
 	}
 
-	webRouter := mux.NewRouter()
-	webRouter.PathPrefix("/debug").Handler(http.DefaultServeMux) // for /debug/pprof
-	// the path prefix here must be kept in sync with the prefix configured in the proxy handler
-	// (it needs to know what to strip before forwarding the request)
-	webRouter.PathPrefix(apiServerProxyPrefix).Handler(proxyHandler)
-	webRouter.PathPrefix("/").Handler(s.hudServer.Router())
-
-	s.webServer = &http.Server{
-		Addr:    s.webListener.Addr().String(),
-		Handler: webRouter,
-
-		// blackhole any server errors
-		ErrorLog: log.New(ioutil.Discard, "", 0),
+	if s.webListener != nil {
+		webRouter := mux.NewRouter()
+		webRouter.PathPrefix("/debug").Handler(http.DefaultServeMux) // for /debug/pprof
+		// the path prefix here must be kept in sync with the prefix configured in the proxy handler
+		// (it needs to know what to strip before forwarding the request)
+		webRouter.PathPrefix(apiServerProxyPrefix).Handler(s.security.RequireAuth(s.security.RequireWritable(proxyHandler)))
+		webRouter.PathPrefix("/").Handler(s.hudServer.Router())
+
+		s.webServer = &http.Server{
+			Addr:      s.webListener.Addr().String(),
+			Handler:   recordActivity(st, "web UI request", webRouter),
+			TLSConfig: s.security.TLSConfig,
+
+			// blackhole any server errors
+			ErrorLog: log.New(ioutil.Discard, "", 0),
+		}
+		runServer(ctx, s.webServer, s.webListener)
 	}
-	runServer(ctx, s.webServer, s.webListener)
 
 	s.apiServer = &http.Server{
 		Addr:           serving.Listener.Addr().String(),
-		Handler:        apiRouter,
+		Handler:        recordActivity(st, "CLI command", apiRouter),
 		MaxHeaderBytes: 1 << 20,
 		TLSConfig:      apiTLSConfig,
 
@@ -183,6 +208,10 @@ func (s *HeadsUpServerController) setUpHelper(ctx context.Context, st store.RSto
 	runServer(ctx, s.apiServer, serving.Listener)
 	server.GenericAPIServer.RunPostStartHooks(stopCh)
 
+	if s.webListener == nil {
+		return nil
+	}
+
 	go func() {
 		err := s.assetServer.Serve(ctx)
 		if err != nil && ctx.Err() == nil {
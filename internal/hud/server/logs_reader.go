@@ -2,7 +2,9 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"net/http"
 
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/gorilla/websocket"
@@ -14,6 +16,7 @@ import (
 	"github.com/tilt-dev/tilt/pkg/model"
 	"github.com/tilt-dev/tilt/pkg/model/logstore"
 	proto_webview "github.com/tilt-dev/tilt/pkg/webview"
+	"github.com/tilt-dev/tilt/pkg/webview/protocol"
 )
 
 // This file defines machinery to connect to the HUD server websocket and
@@ -32,8 +35,8 @@ type WebsocketReader struct {
 	handler      ViewHandler
 }
 
-func newWebsocketReaderForLogs(conn WebsocketConn, persistent bool, resources []string, p *hud.IncrementalPrinter) *WebsocketReader {
-	ls := NewLogStreamer(resources, p)
+func newWebsocketReaderForLogs(conn WebsocketConn, persistent bool, resources []string, levels logstore.LevelFilter, p *hud.IncrementalPrinter) *WebsocketReader {
+	ls := NewLogStreamer(resources, levels, p)
 	return newWebsocketReader(conn, persistent, ls)
 }
 
@@ -62,10 +65,11 @@ type LogStreamer struct {
 	// This value should only be used to compare to other server values, NOT client checkpoints.
 	serverWatermark int32
 	resources       model.ManifestNameSet // if present, resource(s) to stream logs for
+	levels          logstore.LevelFilter  // only print lines that this filter allows
 	printer         *hud.IncrementalPrinter
 }
 
-func NewLogStreamer(resources []string, p *hud.IncrementalPrinter) *LogStreamer {
+func NewLogStreamer(resources []string, levels logstore.LevelFilter, p *hud.IncrementalPrinter) *LogStreamer {
 	mnSet := make(map[model.ManifestName]bool, len(resources))
 	for _, r := range resources {
 		mnSet[model.ManifestName(r)] = true
@@ -73,6 +77,7 @@ func NewLogStreamer(resources []string, p *hud.IncrementalPrinter) *LogStreamer
 
 	return &LogStreamer{
 		resources: mnSet,
+		levels:    levels,
 		logstore:  logstore.NewLogStore(),
 		printer:   p,
 	}
@@ -102,6 +107,7 @@ func (ls *LogStreamer) Handle(v *proto_webview.View) error {
 	ls.printer.Print(ls.logstore.ContinuingLinesWithOptions(ls.checkpoint, logstore.LineOptions{
 		ManifestNames:  ls.resources,
 		SuppressPrefix: suppressPrefix,
+		Levels:         ls.levels,
 	}))
 
 	ls.checkpoint = ls.logstore.Checkpoint()
@@ -109,18 +115,34 @@ func (ls *LogStreamer) Handle(v *proto_webview.View) error {
 
 	return nil
 }
-func StreamLogs(ctx context.Context, follow bool, url model.WebURL, resources []string, printer *hud.IncrementalPrinter) error {
+func StreamLogs(ctx context.Context, follow bool, url model.WebURL, connInfo WebConnInfo, resources []string, levels logstore.LevelFilter, printer *hud.IncrementalPrinter) error {
 	url.Scheme = "ws"
+	if connInfo.Scheme == "https" {
+		url.Scheme = "wss"
+	}
 	url.Path = "/ws/view"
+
+	// We decode the view with the webview protobuf, which is the
+	// version 1 shape.
+	url.RawQuery = fmt.Sprintf("%s=%d", protocol.VersionQueryParam, protocol.Version1)
 	logger.Get(ctx).Debugf("connecting to %s", url.String())
 
-	conn, _, err := websocket.DefaultDialer.Dial(url.String(), nil)
+	tlsConfig, err := connInfo.TLSClientConfig()
+	if err != nil {
+		return errors.Wrap(err, "loading Tilt web server cert")
+	}
+	dialer := *websocket.DefaultDialer
+	dialer.TLSClientConfig = tlsConfig
+	header := http.Header{}
+	connInfo.AddAuth(header)
+
+	conn, _, err := dialer.Dial(url.String(), header)
 	if err != nil {
 		return errors.Wrapf(err, "dialing websocket %s", url.String())
 	}
 	defer conn.Close()
 
-	wsr := newWebsocketReaderForLogs(conn, follow, resources, printer)
+	wsr := newWebsocketReaderForLogs(conn, follow, resources, levels, printer)
 	return wsr.Listen(ctx)
 }
 
@@ -9,7 +9,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/tilt-dev/tilt/pkg/logger"
 	"github.com/tilt-dev/tilt/pkg/model"
+	"github.com/tilt-dev/tilt/pkg/model/logstore"
 
 	"github.com/tilt-dev/tilt/internal/hud"
 	proto_webview "github.com/tilt-dev/tilt/pkg/webview"
@@ -131,6 +133,22 @@ func TestLogStreamerCheckpointHandlingWithFiltering(t *testing.T) {
 	f.assertExpectedLogLines(expected)
 }
 
+func TestLogStreamerLevelFilter(t *testing.T) {
+	f := newLogStreamerFixture(t).withLevels("warn,bar=info")
+	manifestNames := []string{"foo", "foo", "bar", "bar"}
+
+	view := f.newViewWithLogsForManifests(alphabet[:4], manifestNames, 0)
+	levels := []logger.Level{logger.InfoLvl, logger.ErrorLvl, logger.DebugLvl, logger.InfoLvl}
+	for i, seg := range view.LogList.Segments {
+		seg.Level = proto_webview.LogLevel(levels[i].ToProtoID())
+	}
+	f.handle(view)
+
+	expected := f.expectedLinesWithPrefixes(
+		[]string{"ERROR: bravo", "delta"}, []string{"foo", "bar"})
+	f.assertExpectedLogLines(expected)
+}
+
 type logStreamerFixture struct {
 	t          *testing.T
 	fakeStdout *bytes.Buffer
@@ -145,7 +163,7 @@ func newLogStreamerFixture(t *testing.T) *logStreamerFixture {
 		t:          t,
 		fakeStdout: fakeStdout,
 		printer:    printer,
-		ls:         NewLogStreamer(nil, printer),
+		ls:         NewLogStreamer(nil, logstore.LevelFilter{}, printer),
 	}
 }
 
@@ -158,6 +176,13 @@ func (f *logStreamerFixture) withResourceNames(resourceNames ...string) *logStre
 	return f
 }
 
+func (f *logStreamerFixture) withLevels(levels string) *logStreamerFixture {
+	filter, err := logstore.ParseLevelFilter(levels)
+	require.NoError(f.t, err)
+	f.ls.levels = filter
+	return f
+}
+
 func (f *logStreamerFixture) handle(view *proto_webview.View) {
 	err := f.ls.Handle(view)
 	require.NoError(f.t, err)
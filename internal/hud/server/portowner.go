@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Makes a best-effort attempt to describe the process listening on a port,
+// e.g., "node, pid 1234". Returns the empty string if we can't tell.
+func describePortOwner(port int) string {
+	if runtime.GOOS == "windows" {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "lsof", "-nP", fmt.Sprintf("-iTCP:%d", port), "-sTCP:LISTEN", "-Fpc").Output()
+	if err != nil {
+		return ""
+	}
+	return parseLsofOwner(string(out))
+}
+
+// Parses the -Fpc output format of lsof, which prints
+// one field per line, prefixed by the field type.
+func parseLsofOwner(out string) string {
+	pid := ""
+	command := ""
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) < 2 {
+			continue
+		}
+		switch line[0] {
+		case 'p':
+			if pid == "" {
+				pid = line[1:]
+			}
+		case 'c':
+			if command == "" {
+				command = line[1:]
+			}
+		}
+	}
+
+	if pid == "" {
+		return ""
+	}
+	if command == "" {
+		return fmt.Sprintf("pid %s", pid)
+	}
+	return fmt.Sprintf("%s, pid %s", command, pid)
+}
@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
 	_ "net/http/pprof"
+	"net/url"
+	"strconv"
 
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/gorilla/mux"
@@ -16,15 +19,26 @@ import (
 	"github.com/grpc-ecosystem/grpc-gateway/runtime"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/tilt-dev/wmclient/pkg/analytics"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	tiltanalytics "github.com/tilt-dev/tilt/internal/analytics"
 	"github.com/tilt-dev/tilt/internal/cloud"
+	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
+	"github.com/tilt-dev/tilt/internal/controllers/apis/debugcontainer"
+	"github.com/tilt-dev/tilt/internal/controllers/core/portforward"
+	engineanalytics "github.com/tilt-dev/tilt/internal/engine/analytics"
 	"github.com/tilt-dev/tilt/internal/hud/webview"
+	"github.com/tilt-dev/tilt/internal/k8s"
 	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/store/configmaps"
+	"github.com/tilt-dev/tilt/internal/store/liveupdates"
 	"github.com/tilt-dev/tilt/internal/store/tiltfiles"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
 	"github.com/tilt-dev/tilt/pkg/assets"
 	"github.com/tilt-dev/tilt/pkg/model"
+	"github.com/tilt-dev/tilt/pkg/model/logstore"
 	proto_webview "github.com/tilt-dev/tilt/pkg/webview"
 )
 
@@ -50,6 +64,70 @@ type overrideTriggerModePayload struct {
 	TriggerMode   int      `json:"trigger_mode"`
 }
 
+type updateModePayload struct {
+	Mode string `json:"mode"`
+}
+
+type portForwardTogglePayload struct {
+	ManifestName string `json:"manifest_name"`
+	LocalPort    int32  `json:"local_port"`
+	Enabled      bool   `json:"enabled"`
+}
+
+type clearLogsPayload struct {
+	// If empty, clears the logs of every resource.
+	ManifestName string `json:"manifest_name"`
+	Retain       bool   `json:"retain"`
+}
+
+type disablePayload struct {
+	ManifestNames []string `json:"manifest_names"`
+	// Label selectors, like `tilt get -l`. Matches are added to ManifestNames.
+	Selectors []string `json:"selectors"`
+	Disable   bool     `json:"disable"`
+	Cascade   bool     `json:"cascade"`
+	Force     bool     `json:"force"`
+	// Who's asking: "UI" or "CLI". Defaults to "UI".
+	Source v1alpha1.DisableChangeSource `json:"source"`
+}
+
+type disableResponse struct {
+	// The resources whose disable state changed.
+	Changed []model.ManifestName `json:"changed"`
+	// The resources in Changed that were pulled in by cascade.
+	Cascaded  []model.ManifestName        `json:"cascaded"`
+	Conflicts []configmap.DisableConflict `json:"conflicts"`
+	// False if conflicts kept us from changing anything.
+	Applied bool `json:"applied"`
+}
+
+type debugContainerPayload struct {
+	ManifestName string `json:"manifest_name"`
+	Image        string `json:"image"`
+	Command      string `json:"command"`
+	Port         string `json:"port"`
+}
+
+// Previews what applying a resource would change in the cluster.
+type ManifestDiffer interface {
+	Diff(ctx context.Context, nn types.NamespacedName) ([]k8s.ObjectDiff, error)
+}
+
+// Remembers the most recent applies Tilt ran for a resource.
+type ApplyHistory interface {
+	ApplyHistory(nn types.NamespacedName) []k8s.ApplyInvocation
+}
+
+// Attaches ephemeral debug containers to the pods of Kubernetes resources.
+type DebugContainerAttacher interface {
+	Attach(ctx context.Context, mn model.ManifestName, opts debugcontainer.Options) (debugcontainer.Attachment, error)
+}
+
+// Turns individual port-forwards on and off at runtime.
+type PortForwardToggler interface {
+	SetForwardEnabled(ctx context.Context, id portforward.ForwardID, enabled bool) error
+}
+
 type HeadsUpServer struct {
 	ctx        context.Context
 	store      *store.Store
@@ -58,6 +136,12 @@ type HeadsUpServer struct {
 	uploader   cloud.SnapshotUploader
 	wsList     *WebsocketList
 	ctrlClient ctrlclient.Client
+	security   WebSecurity
+	reporter   *engineanalytics.AnalyticsReporter
+	differ     ManifestDiffer
+	history    ApplyHistory
+	debugger   DebugContainerAttacher
+	forwards   PortForwardToggler
 }
 
 func ProvideHeadsUpServer(
@@ -67,7 +151,13 @@ func ProvideHeadsUpServer(
 	analytics *tiltanalytics.TiltAnalytics,
 	uploader cloud.SnapshotUploader,
 	wsList *WebsocketList,
-	ctrlClient ctrlclient.Client) (*HeadsUpServer, error) {
+	ctrlClient ctrlclient.Client,
+	security WebSecurity,
+	reporter *engineanalytics.AnalyticsReporter,
+	differ ManifestDiffer,
+	history ApplyHistory,
+	debugger DebugContainerAttacher,
+	forwards PortForwardToggler) (*HeadsUpServer, error) {
 	r := mux.NewRouter().UseEncodedPath()
 	s := &HeadsUpServer{
 		ctx:        ctx,
@@ -77,20 +167,50 @@ func ProvideHeadsUpServer(
 		uploader:   uploader,
 		wsList:     wsList,
 		ctrlClient: ctrlClient,
+		security:   security,
+		reporter:   reporter,
+		differ:     differ,
+		history:    history,
+		debugger:   debugger,
+		forwards:   forwards,
+	}
+
+	// Endpoints that mutate state require auth (if enabled),
+	// and are turned off entirely on a read-only server.
+	auth := security.RequireAuth
+	mutate := func(handler http.Handler) http.Handler {
+		return auth(security.RequireWritable(handler))
 	}
 
 	r.HandleFunc("/api/view", s.ViewJSON)
 	r.HandleFunc("/api/dump/engine", s.DumpEngineJSON)
+	r.HandleFunc("/api/dump/actions", s.DumpActionsJSON)
+	r.HandleFunc("/api/dump/memory", s.DumpMemoryJSON)
 	r.HandleFunc("/api/analytics", s.HandleAnalytics)
-	r.HandleFunc("/api/analytics_opt", s.HandleAnalyticsOpt)
-	r.HandleFunc("/api/trigger", s.HandleTrigger)
-	r.HandleFunc("/api/override/trigger_mode", s.HandleOverrideTriggerMode)
-	r.HandleFunc("/api/snapshot/new", s.HandleNewSnapshot).Methods("POST")
-	// this endpoint is only used for testing snapshots in development
+	r.HandleFunc("/api/analytics/dump", s.DumpAnalyticsJSON)
+	r.Handle("/api/analytics_opt", mutate(http.HandlerFunc(s.HandleAnalyticsOpt)))
+	r.Handle("/api/trigger", mutate(http.HandlerFunc(s.HandleTrigger)))
+	r.Handle("/api/override/trigger_mode", mutate(http.HandlerFunc(s.HandleOverrideTriggerMode)))
+	r.Handle("/api/snapshot/new", auth(http.HandlerFunc(s.HandleNewSnapshot))).Methods("POST")
+	// Serves the current state as a snapshot, for 'tilt snapshot save'
+	// (and for testing snapshots in development).
 	r.HandleFunc("/api/snapshot/{snapshot_id}", s.SnapshotJSON)
-	r.HandleFunc("/ws/view", s.ViewWebsocket)
-	r.HandleFunc("/api/user_started_tilt_cloud_registration", s.userStartedTiltCloudRegistration)
-	r.HandleFunc("/api/set_tiltfile_args", s.HandleSetTiltfileArgs).Methods("POST")
+	r.Handle("/ws/view", auth(http.HandlerFunc(s.ViewWebsocket)))
+	r.Handle("/api/user_started_tilt_cloud_registration", auth(http.HandlerFunc(s.userStartedTiltCloudRegistration)))
+	r.Handle("/api/set_tiltfile_args", mutate(http.HandlerFunc(s.HandleSetTiltfileArgs))).Methods("POST")
+	r.HandleFunc("/api/tiltfile_args", s.TiltfileArgsJSON).Methods("GET")
+	// Doesn't mutate anything, but reads live objects from the cluster.
+	r.Handle("/api/diff/{name}", auth(http.HandlerFunc(s.HandleDiff))).Methods("GET")
+	r.Handle("/api/apply_history/{name}", auth(http.HandlerFunc(s.HandleApplyHistory))).Methods("GET")
+	r.Handle("/api/debug_container", mutate(http.HandlerFunc(s.HandleDebugContainer))).Methods("POST")
+	r.Handle("/api/port_forward", mutate(http.HandlerFunc(s.HandleTogglePortForward))).Methods("POST")
+	r.Handle("/api/disable", mutate(http.HandlerFunc(s.HandleDisable))).Methods("POST")
+	r.HandleFunc("/api/update_mode", s.UpdateModeJSON).Methods("GET")
+	r.Handle("/api/update_mode", mutate(http.HandlerFunc(s.HandleSwitchUpdateMode))).Methods("POST")
+	r.HandleFunc("/api/graph", s.DependencyGraphJSON).Methods("GET")
+	r.HandleFunc("/api/links", s.LinksJSON).Methods("GET")
+	r.Handle("/api/logs/clear", mutate(http.HandlerFunc(s.HandleClearLogs))).Methods("POST")
+	r.HandleFunc("/api/logs/search", s.SearchLogsJSON).Methods("GET")
 
 	r.PathPrefix("/").Handler(s.cookieWrapper(assetServer))
 
@@ -110,6 +230,19 @@ func (s *HeadsUpServer) cookieWrapper(handler http.Handler) http.Handler {
 		state := s.store.RLockState()
 		http.SetCookie(w, &http.Cookie{Name: TiltTokenCookieName, Value: string(state.Token), Path: "/"})
 		s.store.RUnlockState()
+
+		// If the user opened the web UI with a valid auth token,
+		// remember it so that the UI can make mutating requests.
+		if s.security.Token != "" && s.security.tokenMatches(r.URL.Query().Get(webAuthTokenParam)) {
+			http.SetCookie(w, &http.Cookie{
+				Name:     TiltWebAuthCookieName,
+				Value:    string(s.security.Token),
+				Path:     "/",
+				HttpOnly: true,
+				Secure:   s.security.TLSConfig != nil,
+				SameSite: http.SameSiteStrictMode,
+			})
+		}
 		handler.ServeHTTP(w, r)
 	}}
 }
@@ -146,6 +279,53 @@ func (s *HeadsUpServer) DumpEngineJSON(w http.ResponseWriter, req *http.Request)
 	}
 }
 
+// Dump the most recent actions the store reduced.
+// Only intended for 'tilt dump actions'.
+func (s *HeadsUpServer) DumpActionsJSON(w http.ResponseWriter, req *http.Request) {
+	entries, ok := s.store.ActionJournal()
+	if !ok {
+		http.Error(w, "The action journal is off. Restart Tilt with --journal-actions to turn it on.", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(entries)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error encoding actions: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// Dump the most recent sample of the engine's memory usage.
+// Only intended for 'tilt dump memory'.
+func (s *HeadsUpServer) DumpMemoryJSON(w http.ResponseWriter, req *http.Request) {
+	state := s.store.RLockState()
+	report := state.MemoryReport
+	s.store.RUnlockState()
+
+	if report.Empty() {
+		http.Error(w, "Tilt hasn't sampled its memory usage yet. Try again in a minute.", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(report)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error encoding memory report: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// Dump the analytics payloads that Tilt would report, without sending them.
+// Only intended for 'tilt analytics dump'.
+func (s *HeadsUpServer) DumpAnalyticsJSON(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(s.reporter.Payloads(req.Context()))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering analytics payloads: %v", err), http.StatusInternalServerError)
+	}
+}
+
 func (s *HeadsUpServer) SnapshotJSON(w http.ResponseWriter, req *http.Request) {
 	view, err := webview.CompleteView(req.Context(), s.ctrlClient, s.store)
 	if err != nil {
@@ -225,13 +405,424 @@ func (s *HeadsUpServer) HandleSetTiltfileArgs(w http.ResponseWriter, req *http.R
 	}
 
 	ctx := req.Context()
-	err = tiltfiles.SetTiltfileArgs(ctx, s.store, s.ctrlClient, args)
+
+	// With ?wait=true, don't respond until the Tiltfile has reloaded with the new args.
+	if req.URL.Query().Get("wait") == "true" {
+		err = tiltfiles.SetTiltfileArgsAndWait(ctx, s.store, s.ctrlClient, args)
+	} else {
+		err = tiltfiles.SetTiltfileArgs(ctx, s.store, s.ctrlClient, args)
+	}
+
+	if loadErr, ok := err.(tiltfiles.ArgsLoadError); ok {
+		http.Error(w, loadErr.Error(), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, fmt.Sprintf("error updating apiserver: %v", err), http.StatusInternalServerError)
 		return
 	}
 }
 
+func (s *HeadsUpServer) TiltfileArgsJSON(w http.ResponseWriter, req *http.Request) {
+	args, err := tiltfiles.GetTiltfileArgs(req.Context(), s.ctrlClient)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error reading Tiltfile args: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if args == nil {
+		args = []string{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(args)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering Tiltfile args: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// Preview what applying a resource would change in the cluster.
+// Only intended for 'tilt diff'.
+func (s *HeadsUpServer) HandleDiff(w http.ResponseWriter, req *http.Request) {
+	name, err := url.PathUnescape(mux.Vars(req)["name"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid resource name: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	err = checkManifestsExist(s.store, []string{name})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	diffs, err := s.differ.Diff(req.Context(), types.NamespacedName{Name: name})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			http.Error(w, fmt.Sprintf("resource %q is not deployed to Kubernetes", name), http.StatusNotFound)
+			return
+		}
+		http.Error(w, fmt.Sprintf("error computing diff: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(diffs)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering diff: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// The most recent applies Tilt ran for a resource, oldest first.
+// Only intended for 'tilt describe'.
+func (s *HeadsUpServer) HandleApplyHistory(w http.ResponseWriter, req *http.Request) {
+	name, err := url.PathUnescape(mux.Vars(req)["name"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid resource name: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	err = checkManifestsExist(s.store, []string{name})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	history := s.history.ApplyHistory(types.NamespacedName{Name: name})
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(history)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering apply history: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// Attaches a debug container to a resource's current pod.
+// Only intended for 'tilt debug-container'.
+func (s *HeadsUpServer) HandleDebugContainer(w http.ResponseWriter, req *http.Request) {
+	var payload debugContainerPayload
+
+	decoder := json.NewDecoder(req.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&payload)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error parsing JSON payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	err = checkManifestsExist(s.store, []string{payload.ManifestName})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	image := payload.Image
+	if image == "" {
+		state := s.store.RLockState()
+		image = state.UpdateSettings.DebugContainerImage
+		s.store.RUnlockState()
+	}
+
+	opts, err := debugcontainer.ParseOptions(image, payload.Command, payload.Port)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The debug container outlives this request, so attach it with the server's context.
+	attachment, err := s.debugger.Attach(s.ctx, model.ManifestName(payload.ManifestName), opts)
+	if err != nil {
+		if errors.Is(err, k8s.ErrEphemeralContainersUnsupported) {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		http.Error(w, fmt.Sprintf("error attaching debug container: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(attachment)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering debug container: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// Turns one of a resource's port-forwards on or off, identified by its local port.
+func (s *HeadsUpServer) HandleTogglePortForward(w http.ResponseWriter, req *http.Request) {
+	var payload portForwardTogglePayload
+
+	decoder := json.NewDecoder(req.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&payload)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error parsing JSON payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	err = checkManifestsExist(s.store, []string{payload.ManifestName})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	id := portforward.ForwardID{
+		ManifestName: model.ManifestName(payload.ManifestName),
+		LocalPort:    payload.LocalPort,
+	}
+	err = s.forwards.SetForwardEnabled(req.Context(), id, payload.Enabled)
+	if err != nil {
+		switch {
+		case errors.Is(err, portforward.ErrForwardNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, portforward.ErrLocalPortInUse):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+}
+
+// Disables or enables a set of resources together.
+//
+// If the change would leave an enabled resource depending on a disabled one,
+// responds with the conflicts and changes nothing, unless the payload asks
+// us to cascade the change to those resources or to force it through.
+func (s *HeadsUpServer) HandleDisable(w http.ResponseWriter, req *http.Request) {
+	var payload disablePayload
+
+	decoder := json.NewDecoder(req.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&payload)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error parsing JSON payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if len(payload.ManifestNames) == 0 && len(payload.Selectors) == 0 {
+		http.Error(w, "must specify at least one resource name or label selector", http.StatusBadRequest)
+		return
+	}
+
+	switch payload.Source {
+	case "":
+		payload.Source = v1alpha1.DisableChangeSourceUI
+	case v1alpha1.DisableChangeSourceUI, v1alpha1.DisableChangeSourceCLI:
+	default:
+		http.Error(w, fmt.Sprintf("invalid source %q: must be %q or %q", payload.Source,
+			v1alpha1.DisableChangeSourceUI, v1alpha1.DisableChangeSourceCLI), http.StatusBadRequest)
+		return
+	}
+
+	state := s.store.RLockState()
+	manifests := state.Manifests()
+	isDisabled := make(map[model.ManifestName]bool, len(manifests))
+	for _, m := range manifests {
+		cm, ok := state.ConfigMaps[configmap.DisableConfigMapName(m.Name)]
+		if ok {
+			isDisabled[m.Name], _ = strconv.ParseBool(cm.Data[configmap.DisableKey])
+		}
+	}
+	s.store.RUnlockState()
+
+	names, err := configmap.SelectManifests(manifests, payload.ManifestNames, payload.Selectors)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(names) == 0 {
+		http.Error(w, "no resources matched the label selectors", http.StatusBadRequest)
+		return
+	}
+
+	plan := configmap.PlanDisable(manifests, isDisabled, configmap.DisableRequest{
+		Names:   names,
+		Disable: payload.Disable,
+		Cascade: payload.Cascade,
+		Force:   payload.Force,
+	})
+
+	if !plan.Blocked && len(plan.Changes) > 0 {
+		updated, err := configmap.SetDisabled(req.Context(), s.ctrlClient, plan.Changes, payload.Disable,
+			plan.ChangeSources(payload.Source))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.store.Dispatch(configmaps.NewConfigMapsUpsertAction(updated))
+	}
+
+	resp := disableResponse{
+		Changed:   plan.Changes,
+		Cascaded:  plan.Cascaded,
+		Conflicts: plan.Conflicts,
+		Applied:   !plan.Blocked,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering response: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// The update mode Tilt is using, and why it chose it.
+func (s *HeadsUpServer) UpdateModeJSON(w http.ResponseWriter, req *http.Request) {
+	state := s.store.RLockState()
+	updateMode := state.UpdateMode
+	s.store.RUnlockState()
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(updateMode)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering update mode: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// Switches to a different update mode for subsequent builds.
+func (s *HeadsUpServer) HandleSwitchUpdateMode(w http.ResponseWriter, req *http.Request) {
+	var payload updateModePayload
+
+	decoder := json.NewDecoder(req.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&payload)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error parsing JSON payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	state := s.store.RLockState()
+	err = state.UpdateMode.ValidateSwitch(payload.Mode)
+	s.store.RUnlockState()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.store.Dispatch(liveupdates.NewUpdateModeSwitchAction(liveupdates.UpdateMode(payload.Mode)))
+}
+
+// Serves the graph of resource_deps and shared images between resources.
+func (s *HeadsUpServer) DependencyGraphJSON(w http.ResponseWriter, req *http.Request) {
+	state := s.store.RLockState()
+	graph := store.NewDependencyGraph(state)
+	s.store.RUnlockState()
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(graph)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering dependency graph: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// Serves the port-forwards, service URLs, and Tiltfile links of every resource.
+//
+// Clients that want updates can watch the links on UIResources over the
+// websocket instead of polling.
+func (s *HeadsUpServer) LinksJSON(w http.ResponseWriter, req *http.Request) {
+	state := s.store.RLockState()
+	links := store.NewResourceLinkList(state)
+	s.store.RUnlockState()
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(links)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering links: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// Clears a resource's logs, so that the UI starts fresh.
+//
+// Clients that are already streaming logs over the websocket keep their
+// checkpoint and only receive new logs. Clients that load the view
+// afterwards don't see the cleared logs.
+func (s *HeadsUpServer) HandleClearLogs(w http.ResponseWriter, req *http.Request) {
+	var payload clearLogsPayload
+
+	decoder := json.NewDecoder(req.Body)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&payload)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error parsing JSON payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var mns []model.ManifestName
+	if payload.ManifestName != "" {
+		err = checkManifestsExist(s.store, []string{payload.ManifestName})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		mns = append(mns, model.ManifestName(payload.ManifestName))
+	}
+
+	s.store.Dispatch(ClearLogsAction{ManifestNames: mns, Retain: payload.Retain})
+}
+
+// The most results a single search can ask for.
+const maxSearchResults = 1000
+
+// Searches the logs of one resource (or all of them), newest first.
+//
+// Looks for the text in the "q" param, or treats it as a regular expression
+// if "regexp" is "true". The "resource" param limits the search to one
+// resource, and "max_results" caps the number of matches.
+func (s *HeadsUpServer) SearchLogsJSON(w http.ResponseWriter, req *http.Request) {
+	params := req.URL.Query()
+	opts := logstore.SearchOptions{
+		ManifestName: model.ManifestName(params.Get("resource")),
+		Query:        params.Get("q"),
+		Regexp:       params.Get("regexp") == "true",
+	}
+
+	if maxResults := params.Get("max_results"); maxResults != "" {
+		n, err := strconv.Atoi(maxResults)
+		if err != nil || n <= 0 {
+			http.Error(w, fmt.Sprintf("invalid max_results: %q", maxResults), http.StatusBadRequest)
+			return
+		}
+		if n > maxSearchResults {
+			n = maxSearchResults
+		}
+		opts.MaxResults = n
+	}
+
+	if opts.ManifestName != "" {
+		err := checkManifestsExist(s.store, []string{opts.ManifestName.String()})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+	}
+
+	state := s.store.RLockState()
+	result, err := state.LogStore.Search(opts)
+	s.store.RUnlockState()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(result)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering search results: %v", err), http.StatusInternalServerError)
+	}
+}
+
 func (s *HeadsUpServer) HandleTrigger(w http.ResponseWriter, req *http.Request) {
 	if req.Method != http.MethodPost {
 		http.Error(w, "must be POST request", http.StatusBadRequest)
@@ -3,6 +3,7 @@ package server_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -11,16 +12,27 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
+	"github.com/tilt-dev/tilt/internal/controllers/apis/debugcontainer"
+	"github.com/tilt-dev/tilt/internal/controllers/core/portforward"
 	"github.com/tilt-dev/tilt/internal/controllers/fake"
+	"github.com/tilt-dev/tilt/internal/docker"
+	"github.com/tilt-dev/tilt/internal/store/configmaps"
+	"github.com/tilt-dev/tilt/internal/store/liveupdates"
 	"github.com/tilt-dev/tilt/internal/store/tiltfiles"
 	"github.com/tilt-dev/tilt/internal/testutils"
 
+	"github.com/gorilla/websocket"
 	grpcRuntime "github.com/grpc-ecosystem/grpc-gateway/runtime"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -29,12 +41,18 @@ import (
 	tiltanalytics "github.com/tilt-dev/tilt/internal/analytics"
 	"github.com/tilt-dev/tilt/internal/cloud"
 	"github.com/tilt-dev/tilt/internal/cloud/cloudurl"
+	engineanalytics "github.com/tilt-dev/tilt/internal/engine/analytics"
 	"github.com/tilt-dev/tilt/internal/hud/server"
+	"github.com/tilt-dev/tilt/internal/k8s"
 	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/pkg/apis"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
 	"github.com/tilt-dev/tilt/pkg/assets"
+	"github.com/tilt-dev/tilt/pkg/logger"
 	"github.com/tilt-dev/tilt/pkg/model"
+	"github.com/tilt-dev/tilt/pkg/model/logstore"
 	proto_webview "github.com/tilt-dev/tilt/pkg/webview"
+	"github.com/tilt-dev/tilt/pkg/webview/protocol"
 )
 
 func TestHandleAnalyticsEmptyRequest(t *testing.T) {
@@ -327,6 +345,242 @@ func TestHandleOverrideTriggerModeDispatchesEvent(t *testing.T) {
 	assert.Equal(t, expected, action)
 }
 
+func TestUpdateModeJSON(t *testing.T) {
+	f := newTestFixture(t).withUpdateMode()
+
+	status, respBody := f.makeReq("/api/update_mode", f.serv.UpdateModeJSON, http.MethodGet, "")
+	require.Equal(t, http.StatusOK, status, "handler returned wrong status code")
+
+	var result store.UpdateModeState
+	require.NoError(t, json.Unmarshal([]byte(respBody), &result))
+	assert.Equal(t, store.UpdateModeState{
+		Mode:            "auto",
+		Flag:            "auto",
+		KubeContext:     "gke-cluster",
+		DockerHost:      "tcp://docker:2376",
+		CompatibleModes: []string{"auto", "image", "exec", "agent"},
+		IncompatibleModes: map[string]string{
+			"container": "only valid with local Docker clusters like Docker For Mac or Minikube",
+		},
+	}, result)
+}
+
+func TestDependencyGraphJSON(t *testing.T) {
+	f := newTestFixture(t)
+
+	state := f.st.LockMutableStateForTesting()
+	state.UpsertManifestTarget(store.NewManifestTarget(model.Manifest{Name: "fe", ResourceDependencies: []model.ManifestName{"be"}}))
+	state.UpsertManifestTarget(store.NewManifestTarget(model.Manifest{Name: "be"}))
+	f.st.UnlockMutableState()
+
+	status, respBody := f.makeReq("/api/graph", f.serv.DependencyGraphJSON, http.MethodGet, "")
+	require.Equal(t, http.StatusOK, status, "handler returned wrong status code")
+
+	var result store.DependencyGraph
+	require.NoError(t, json.Unmarshal([]byte(respBody), &result))
+	require.Len(t, result.Nodes, 2)
+	assert.Equal(t, []store.DependencyEdge{
+		{From: "manifest:fe", To: "manifest:be", Type: store.DependencyEdgeResource},
+	}, result.Edges)
+	assert.Empty(t, result.Cycles)
+}
+
+func TestLinksJSON(t *testing.T) {
+	f := newTestFixture(t)
+
+	state := f.st.LockMutableStateForTesting()
+	state.UpsertManifestTarget(store.NewManifestTarget(model.Manifest{Name: "fe"}.WithDeployTarget(model.K8sTarget{
+		KubernetesApplySpec: v1alpha1.KubernetesApplySpec{
+			PortForwardTemplateSpec: &v1alpha1.PortForwardTemplateSpec{
+				Forwards: []v1alpha1.Forward{{LocalPort: 8000, ContainerPort: 5000}},
+			},
+		},
+		Links: []model.Link{model.MustNewLink("http://localhost:8000/docs", "docs")},
+	})))
+	f.st.UnlockMutableState()
+
+	status, respBody := f.makeReq("/api/links", f.serv.LinksJSON, http.MethodGet, "")
+	require.Equal(t, http.StatusOK, status, "handler returned wrong status code")
+
+	var result store.ResourceLinkList
+	require.NoError(t, json.Unmarshal([]byte(respBody), &result))
+	assert.Equal(t, []store.ResourceLinks{
+		{
+			Name: "fe",
+			Links: []v1alpha1.UIResourceLink{
+				{URL: "http://localhost:8000/docs", Name: "docs", Kind: v1alpha1.UIResourceLinkKindCustom, Ready: true},
+				{URL: "http://localhost:8000/", Kind: v1alpha1.UIResourceLinkKindPortForward},
+			},
+		},
+	}, result.Resources)
+}
+
+func TestHandleClearLogs(t *testing.T) {
+	f := newTestFixture(t).withDummyManifests("fe")
+
+	status, _ := f.makeReq("/api/logs/clear", f.serv.HandleClearLogs, http.MethodPost,
+		`{"manifest_name":"fe","retain":true}`)
+	require.Equal(t, http.StatusOK, status, "handler returned wrong status code")
+
+	a := store.WaitForAction(t, reflect.TypeOf(server.ClearLogsAction{}), f.getActions)
+	assert.Equal(t, server.ClearLogsAction{ManifestNames: []model.ManifestName{"fe"}, Retain: true}, a)
+}
+
+func TestHandleClearLogsNoSuchResource(t *testing.T) {
+	f := newTestFixture(t)
+
+	status, respBody := f.makeReq("/api/logs/clear", f.serv.HandleClearLogs, http.MethodPost,
+		`{"manifest_name":"nope"}`)
+	require.Equal(t, http.StatusNotFound, status, "handler returned wrong status code")
+	assert.Contains(t, respBody, "no manifest found with name 'nope'")
+}
+
+func TestSearchLogsJSON(t *testing.T) {
+	f := newTestFixture(t).withDummyManifests("fe", "be")
+
+	state := f.st.LockMutableStateForTesting()
+	for _, line := range []string{"error: 1\n", "ok\n", "error: 2\n", "error: 3\n"} {
+		state.LogStore.Append(store.NewLogAction("fe", "fe", logger.InfoLvl, nil, []byte(line)), nil)
+	}
+	state.LogStore.Append(store.NewLogAction("be", "be", logger.InfoLvl, nil, []byte("error: be\n")), nil)
+	f.st.UnlockMutableState()
+
+	status, respBody := f.makeReq("/api/logs/search?resource=fe&q=error&max_results=2",
+		f.serv.SearchLogsJSON, http.MethodGet, "")
+	require.Equal(t, http.StatusOK, status, "handler returned wrong status code")
+
+	var result logstore.SearchResult
+	require.NoError(t, json.Unmarshal([]byte(respBody), &result))
+	assert.True(t, result.Truncated)
+	require.Len(t, result.Matches, 2)
+	assert.Equal(t, "error: 3\n", result.Matches[0].Text)
+	assert.Equal(t, "error: 2\n", result.Matches[1].Text)
+}
+
+func TestSearchLogsJSONBadRequest(t *testing.T) {
+	f := newTestFixture(t).withDummyManifests("fe")
+
+	status, _ := f.makeReq("/api/logs/search", f.serv.SearchLogsJSON, http.MethodGet, "")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, _ = f.makeReq("/api/logs/search?q=(&regexp=true", f.serv.SearchLogsJSON, http.MethodGet, "")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, _ = f.makeReq("/api/logs/search?q=x&max_results=-1", f.serv.SearchLogsJSON, http.MethodGet, "")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, _ = f.makeReq("/api/logs/search?q=x&resource=nope", f.serv.SearchLogsJSON, http.MethodGet, "")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestHandleSwitchUpdateMode(t *testing.T) {
+	f := newTestFixture(t).withUpdateMode()
+
+	status, _ := f.makeReq("/api/update_mode", f.serv.HandleSwitchUpdateMode, http.MethodPost, `{"mode":"image"}`)
+	require.Equal(t, http.StatusOK, status, "handler returned wrong status code")
+
+	a := store.WaitForAction(t, reflect.TypeOf(liveupdates.UpdateModeSwitchAction{}), f.getActions)
+	assert.Equal(t, liveupdates.NewUpdateModeSwitchAction(liveupdates.UpdateModeImage), a)
+}
+
+func TestHandleSwitchUpdateModeIncompatible(t *testing.T) {
+	f := newTestFixture(t).withUpdateMode()
+
+	status, respBody := f.makeReq("/api/update_mode", f.serv.HandleSwitchUpdateMode, http.MethodPost, `{"mode":"container"}`)
+	require.Equal(t, http.StatusBadRequest, status, "handler returned wrong status code")
+	assert.Contains(t, respBody, `can't switch to update mode "container": only valid with local Docker clusters`)
+	store.AssertNoActionOfType(t, reflect.TypeOf(liveupdates.UpdateModeSwitchAction{}), f.getActions)
+
+	status, respBody = f.makeReq("/api/update_mode", f.serv.HandleSwitchUpdateMode, http.MethodPost, `{"mode":"fast"}`)
+	require.Equal(t, http.StatusBadRequest, status, "handler returned wrong status code")
+	assert.Contains(t, respBody, `unknown update mode "fast"`)
+	store.AssertNoActionOfType(t, reflect.TypeOf(liveupdates.UpdateModeSwitchAction{}), f.getActions)
+}
+
+func TestHandleDisableWarnsAboutDependents(t *testing.T) {
+	f := newTestFixture(t).withDisableManifests()
+
+	payload := `{"manifest_names":["db"], "disable": true}`
+	status, respBody := f.makeReq("/api/disable", f.serv.HandleDisable, http.MethodPost, payload)
+
+	require.Equal(t, http.StatusOK, status, respBody)
+	assert.Contains(t, respBody, `"conflicts":[{"name":"db","related":["api","fe"]}]`)
+	assert.Contains(t, respBody, `"applied":false`)
+	f.assertDisabled("db", false)
+	store.AssertNoActionOfType(t, reflect.TypeOf(configmaps.ConfigMapsUpsertAction{}), f.getActions)
+}
+
+func TestHandleDisableCascade(t *testing.T) {
+	f := newTestFixture(t).withDisableManifests()
+
+	payload := `{"manifest_names":["db"], "disable": true, "cascade": true}`
+	status, respBody := f.makeReq("/api/disable", f.serv.HandleDisable, http.MethodPost, payload)
+
+	require.Equal(t, http.StatusOK, status, respBody)
+	assert.Contains(t, respBody, `"changed":["api","db","fe"]`)
+	assert.Contains(t, respBody, `"cascaded":["api","fe"]`)
+	for _, n := range []model.ManifestName{"api", "db", "fe"} {
+		f.assertDisabled(n, true)
+	}
+	f.assertDisabled("docs", false)
+
+	f.assertDisableSource("db", v1alpha1.DisableChangeSourceUI)
+	f.assertDisableSource("api", v1alpha1.DisableChangeSourceCascade)
+	f.assertDisableSource("fe", v1alpha1.DisableChangeSourceCascade)
+}
+
+func TestHandleDisableFromCLI(t *testing.T) {
+	f := newTestFixture(t).withDisableManifests()
+
+	payload := `{"manifest_names":["docs"], "disable": true, "source": "CLI"}`
+	status, respBody := f.makeReq("/api/disable", f.serv.HandleDisable, http.MethodPost, payload)
+
+	require.Equal(t, http.StatusOK, status, respBody)
+	f.assertDisabled("docs", true)
+	f.assertDisableSource("docs", v1alpha1.DisableChangeSourceCLI)
+}
+
+func TestHandleDisableBadSource(t *testing.T) {
+	f := newTestFixture(t).withDisableManifests()
+
+	payload := `{"manifest_names":["docs"], "disable": true, "source": "Tiltfile"}`
+	status, respBody := f.makeReq("/api/disable", f.serv.HandleDisable, http.MethodPost, payload)
+
+	require.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, respBody, `invalid source "Tiltfile"`)
+	f.assertDisabled("docs", false)
+}
+
+func TestHandleDisableMixedSelectorAppliesInOneAction(t *testing.T) {
+	f := newTestFixture(t).withDisableManifests()
+
+	// fe by name, and api and db by label.
+	payload := `{"manifest_names":["fe"], "selectors":["backend"], "disable": true}`
+	status, respBody := f.makeReq("/api/disable", f.serv.HandleDisable, http.MethodPost, payload)
+
+	require.Equal(t, http.StatusOK, status, respBody)
+	assert.Contains(t, respBody, `"changed":["api","db","fe"]`)
+	assert.Contains(t, respBody, `"applied":true`)
+
+	a := store.WaitForAction(t, reflect.TypeOf(configmaps.ConfigMapsUpsertAction{}), f.getActions)
+	var names []string
+	for _, cm := range a.(configmaps.ConfigMapsUpsertAction).ConfigMaps {
+		names = append(names, cm.Name)
+		assert.Equal(t, "true", cm.Data[configmap.DisableKey])
+	}
+	assert.ElementsMatch(t, []string{"api-disable", "db-disable", "fe-disable"}, names)
+}
+
+func TestHandleDisableBadSelector(t *testing.T) {
+	f := newTestFixture(t).withDisableManifests()
+
+	payload := `{"selectors":["nobody"], "disable": true}`
+	status, respBody := f.makeReq("/api/disable", f.serv.HandleDisable, http.MethodPost, payload)
+
+	require.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, respBody, "no resources matched")
+}
+
 func TestHandleNewSnapshot(t *testing.T) {
 	f := newTestFixture(t)
 
@@ -384,6 +638,433 @@ func TestSetTiltfileArgs(t *testing.T) {
 	assert.Equal(t, []string{"--foo", "bar", "as df"}, action.Args)
 }
 
+func TestTiltfileArgsJSON(t *testing.T) {
+	f := newTestFixture(t)
+
+	status, respBody := f.makeReq("/api/tiltfile_args", f.serv.TiltfileArgsJSON, http.MethodGet, "")
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "[]\n", respBody)
+
+	status, _ = f.makeReq("/api/set_tiltfile_args", f.serv.HandleSetTiltfileArgs, http.MethodPost, `["fe", "be"]`)
+	require.Equal(t, http.StatusOK, status)
+
+	status, respBody = f.makeReq("/api/tiltfile_args", f.serv.TiltfileArgsJSON, http.MethodGet, "")
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `["fe","be"]`+"\n", respBody)
+}
+
+func TestSetTiltfileArgsWaitRejected(t *testing.T) {
+	f := newTestFixture(t)
+
+	// Simulate the Tiltfile reconciler rejecting the new args.
+	go func() {
+		ctx := context.Background()
+		nn := types.NamespacedName{Name: model.MainTiltfileManifestName.String()}
+		var tf v1alpha1.Tiltfile
+		for {
+			if err := f.ctrlClient.Get(ctx, nn, &tf); err == nil && len(tf.Spec.Args) > 0 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		tf.Spec.Args = nil
+		assert.NoError(t, f.ctrlClient.Update(ctx, &tf))
+
+		now := apis.NowMicro()
+		tf.Status.Terminated = &v1alpha1.TiltfileStateTerminated{
+			StartedAt:  now,
+			FinishedAt: now,
+			Error:      `unknown resource "nope"`,
+		}
+		assert.NoError(t, f.ctrlClient.Status().Update(ctx, &tf))
+	}()
+
+	status, respBody := f.makeReq("/api/set_tiltfile_args?wait=true", f.serv.HandleSetTiltfileArgs, http.MethodPost, `["nope"]`)
+	require.Equal(t, http.StatusBadRequest, status)
+	assert.Contains(t, respBody, `Tiltfile failed to load with args [nope]. Still using args [].`)
+	assert.Contains(t, respBody, `unknown resource "nope"`)
+}
+
+func TestAuthRejectsUnauthenticatedMutation(t *testing.T) {
+	f := newTestFixtureWithSecurity(t, server.WebSecurity{Token: "secret"})
+
+	payload := `{"manifest_names":["(Tiltfile)"]}`
+	status, respBody := f.makeReq("/api/trigger", f.serv.Router().ServeHTTP, http.MethodPost, payload)
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Contains(t, respBody, "unauthorized")
+
+	req := httptest.NewRequest(http.MethodPost, "/api/trigger", strings.NewReader(payload))
+	req.Header.Set("Authorization", "Bearer wrong")
+	rr := httptest.NewRecorder()
+	f.serv.Router().ServeHTTP(rr, req)
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+	require.Empty(t, f.getActions())
+}
+
+func TestAuthAcceptsAuthenticatedMutation(t *testing.T) {
+	f := newTestFixtureWithSecurity(t, server.WebSecurity{Token: "secret"})
+
+	payload := `{"manifest_names":["(Tiltfile)"]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/trigger", strings.NewReader(payload))
+	req.Header.Set("Authorization", "Bearer secret")
+	rr := httptest.NewRecorder()
+	f.serv.Router().ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/api/trigger", strings.NewReader(payload))
+	req.AddCookie(&http.Cookie{Name: server.TiltWebAuthCookieName, Value: "secret"})
+	rr = httptest.NewRecorder()
+	f.serv.Router().ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+}
+
+func TestAuthWebsocketRequiresToken(t *testing.T) {
+	f := newTestFixtureWithSecurity(t, server.WebSecurity{Token: "secret"})
+
+	req := httptest.NewRequest(http.MethodGet, "/ws/view", nil)
+	rr := httptest.NewRecorder()
+	f.serv.Router().ServeHTTP(rr, req)
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestViewWebsocketVersion(t *testing.T) {
+	f := newTestFixture(t)
+	hs := httptest.NewServer(f.serv.Router())
+	defer hs.Close()
+	wsURL := "ws" + strings.TrimPrefix(hs.URL, "http") + "/ws/view"
+
+	conn, resp, err := websocket.DefaultDialer.Dial(wsURL+"?version=2", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, "2", resp.Header.Get(protocol.VersionHeader))
+
+	var view protocol.View
+	require.NoError(t, conn.ReadJSON(&view))
+	assert.True(t, view.IsComplete)
+
+	// Clients that don't ask for a version get version 1.
+	conn1, resp, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+	defer conn1.Close()
+	assert.Equal(t, "1", resp.Header.Get(protocol.VersionHeader))
+}
+
+func TestViewWebsocketUnsupportedVersion(t *testing.T) {
+	f := newTestFixture(t)
+	hs := httptest.NewServer(f.serv.Router())
+	defer hs.Close()
+	wsURL := "ws" + strings.TrimPrefix(hs.URL, "http") + "/ws/view?version=7"
+
+	conn, resp, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, "", resp.Header.Get(protocol.VersionHeader))
+
+	var frame protocol.ErrorFrame
+	require.NoError(t, conn.ReadJSON(&frame))
+	assert.Equal(t, protocol.ErrorCodeUnsupportedVersion, frame.Error.Code)
+	assert.Contains(t, frame.Error.Message, `"7"`)
+	assert.Equal(t, protocol.SupportedVersions, frame.Error.SupportedVersions)
+
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseUnsupportedData), "expected close, got %v", err)
+}
+
+func TestViewWebsocketLevelFilter(t *testing.T) {
+	f := newTestFixture(t)
+	state := f.st.LockMutableStateForTesting()
+	state.LogStore.Append(store.NewLogAction("fe", "fe", logger.InfoLvl, nil, []byte("fe info\n")), nil)
+	state.LogStore.Append(store.NewLogAction("fe", "fe", logger.WarnLvl, nil, []byte("fe warn\n")), nil)
+	state.LogStore.Append(store.NewLogAction("be", "be", logger.InfoLvl, nil, []byte("be info\n")), nil)
+	f.st.UnlockMutableState()
+
+	hs := httptest.NewServer(f.serv.Router())
+	defer hs.Close()
+	wsURL := "ws" + strings.TrimPrefix(hs.URL, "http") + "/ws/view?version=2&level=warn,be=info"
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var view protocol.View
+	require.NoError(t, conn.ReadJSON(&view))
+	require.NotNil(t, view.LogList)
+
+	texts := []string{}
+	for _, seg := range view.LogList.Segments {
+		texts = append(texts, seg.Text)
+	}
+	assert.Equal(t, []string{"fe warn\n", "be info\n"}, texts)
+}
+
+func TestViewWebsocketInvalidLevel(t *testing.T) {
+	f := newTestFixture(t)
+	hs := httptest.NewServer(f.serv.Router())
+	defer hs.Close()
+	wsURL := "ws" + strings.TrimPrefix(hs.URL, "http") + "/ws/view?level=loud"
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var frame protocol.ErrorFrame
+	require.NoError(t, conn.ReadJSON(&frame))
+	assert.Equal(t, protocol.ErrorCodeInvalidLevel, frame.Error.Code)
+	assert.Contains(t, frame.Error.Message, `"loud"`)
+
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseUnsupportedData), "expected close, got %v", err)
+}
+
+func TestAuthSetsCookieFromQueryToken(t *testing.T) {
+	f := newTestFixtureWithSecurity(t, server.WebSecurity{Token: "secret"})
+
+	req := httptest.NewRequest(http.MethodGet, "/?token=secret", nil)
+	rr := httptest.NewRecorder()
+	f.serv.Router().ServeHTTP(rr, req)
+
+	var found bool
+	for _, c := range rr.Result().Cookies() {
+		if c.Name == server.TiltWebAuthCookieName {
+			found = true
+			assert.Equal(t, "secret", c.Value)
+			assert.True(t, c.HttpOnly)
+		}
+	}
+	assert.True(t, found, "expected auth cookie")
+}
+
+func TestReadOnlyRejectsMutations(t *testing.T) {
+	f := newTestFixtureWithSecurity(t, server.WebSecurity{ReadOnly: true})
+
+	for _, tc := range []struct {
+		path    string
+		payload string
+	}{
+		{"/api/trigger", `{"manifest_names":["(Tiltfile)"]}`},
+		{"/api/override/trigger_mode", `{"manifest_names":["foo"],"trigger_mode":1}`},
+		{"/api/set_tiltfile_args", `["foo"]`},
+		{"/api/update_mode", `{"mode":"container"}`},
+		{"/api/analytics_opt", `{"opt":"opt-in"}`},
+	} {
+		t.Run(tc.path, func(t *testing.T) {
+			status, respBody := f.makeReq(tc.path, f.serv.Router().ServeHTTP, http.MethodPost, tc.payload)
+			assert.Equal(t, http.StatusForbidden, status)
+			assert.Contains(t, respBody, "read-only")
+		})
+	}
+	require.Empty(t, f.getActions())
+}
+
+func TestReadOnlyAllowsReads(t *testing.T) {
+	f := newTestFixtureWithSecurity(t, server.WebSecurity{ReadOnly: true})
+
+	status, _ := f.makeReq("/api/update_mode", f.serv.Router().ServeHTTP, http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = f.makeReq("/api/tiltfile_args", f.serv.Router().ServeHTTP, http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestDumpAnalyticsJSON(t *testing.T) {
+	f := newTestFixture(t)
+
+	status, respBody := f.makeReq("/api/analytics/dump", f.serv.DumpAnalyticsJSON, http.MethodGet, "")
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, respBody, `"name": "tilt.up.running"`)
+	require.Empty(t, f.a.Counts, "dump should not send any analytics")
+}
+
+func TestDumpActionsJSONOff(t *testing.T) {
+	f := newTestFixture(t)
+
+	status, respBody := f.makeReq("/api/dump/actions", f.serv.DumpActionsJSON, http.MethodGet, "")
+	require.Equal(t, http.StatusNotFound, status)
+	require.Contains(t, respBody, "--journal-actions")
+}
+
+func TestHandleDiff(t *testing.T) {
+	f := newTestFixture(t)
+	f.upsertManifest("foobar")
+	f.differ.diffs = []k8s.ObjectDiff{{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Name:       "foobar",
+		Status:     k8s.ObjectDiffAdded,
+		Unified:    "+kind: Deployment\n",
+	}}
+
+	status, respBody := f.makeRouterReq("/api/diff/foobar", http.MethodGet)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "foobar", f.differ.lastName)
+
+	var diffs []k8s.ObjectDiff
+	require.NoError(t, json.Unmarshal([]byte(respBody), &diffs))
+	assert.Equal(t, f.differ.diffs, diffs)
+}
+
+func TestHandleDiffNoManifestWithName(t *testing.T) {
+	f := newTestFixture(t)
+
+	status, respBody := f.makeRouterReq("/api/diff/foobar", http.MethodGet)
+	require.Equal(t, http.StatusNotFound, status)
+	assert.Contains(t, respBody, "no manifest found with name 'foobar'")
+	assert.Equal(t, "", f.differ.lastName)
+}
+
+func TestHandleDiffNotKubernetes(t *testing.T) {
+	f := newTestFixture(t)
+	f.upsertManifest("foobar")
+	f.differ.err = apierrors.NewNotFound(v1alpha1.Resource("kubernetesapply"), "foobar")
+
+	status, respBody := f.makeRouterReq("/api/diff/foobar", http.MethodGet)
+	require.Equal(t, http.StatusNotFound, status)
+	assert.Contains(t, respBody, `resource "foobar" is not deployed to Kubernetes`)
+}
+
+func TestApplyHistory(t *testing.T) {
+	f := newTestFixture(t)
+	f.upsertManifest("foobar")
+	f.history.invocations = []k8s.ApplyInvocation{
+		{Command: "kubectl --context kind-kind apply -f -", YAMLHash: "abc", YAMLBytes: 42},
+	}
+
+	status, respBody := f.makeRouterReq("/api/apply_history/foobar", http.MethodGet)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "foobar", f.history.lastName)
+
+	var history []k8s.ApplyInvocation
+	require.NoError(t, json.Unmarshal([]byte(respBody), &history))
+	require.Len(t, history, 1)
+	assert.Equal(t, "kubectl --context kind-kind apply -f -", history[0].Command)
+	assert.Equal(t, 42, history[0].YAMLBytes)
+}
+
+func TestApplyHistoryUnknownResource(t *testing.T) {
+	f := newTestFixture(t)
+
+	status, _ := f.makeRouterReq("/api/apply_history/foobar", http.MethodGet)
+	require.Equal(t, http.StatusNotFound, status)
+}
+
+func TestHandleDebugContainer(t *testing.T) {
+	f := newTestFixture(t)
+	f.upsertManifest("foobar")
+	f.debugger.attachment = debugcontainer.Attachment{Pod: "pod-a", Container: "debugger-abcde"}
+
+	payload := `{"manifest_name": "foobar", "command": "netstat -tlpn", "port": "8080"}`
+	status, respBody := f.makeReq("/api/debug_container", f.serv.HandleDebugContainer, http.MethodPost, payload)
+	require.Equal(t, http.StatusOK, status, respBody)
+	assert.Equal(t, model.ManifestName("foobar"), f.debugger.lastName)
+	assert.Equal(t, debugcontainer.Options{
+		Image:   model.DefaultDebugContainerImage,
+		Command: []string{"netstat", "-tlpn"},
+		Port:    8080,
+	}, f.debugger.lastOpts)
+
+	var attachment debugcontainer.Attachment
+	require.NoError(t, json.Unmarshal([]byte(respBody), &attachment))
+	assert.Equal(t, f.debugger.attachment, attachment)
+}
+
+func TestHandleDebugContainerUnsupported(t *testing.T) {
+	f := newTestFixture(t)
+	f.upsertManifest("foobar")
+	f.debugger.err = k8s.ErrEphemeralContainersUnsupported
+
+	payload := `{"manifest_name": "foobar", "image": "busybox"}`
+	status, respBody := f.makeReq("/api/debug_container", f.serv.HandleDebugContainer, http.MethodPost, payload)
+	require.Equal(t, http.StatusNotImplemented, status)
+	assert.Contains(t, respBody, "ephemeral containers are not supported by this cluster")
+}
+
+func TestHandleDebugContainerUnknownResource(t *testing.T) {
+	f := newTestFixture(t)
+
+	payload := `{"manifest_name": "foobar", "image": "busybox"}`
+	status, _ := f.makeReq("/api/debug_container", f.serv.HandleDebugContainer, http.MethodPost, payload)
+	require.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, model.ManifestName(""), f.debugger.lastName)
+}
+
+func TestHandleTogglePortForward(t *testing.T) {
+	f := newTestFixture(t)
+	f.upsertManifest("foobar")
+
+	payload := `{"manifest_name": "foobar", "local_port": 8080, "enabled": false}`
+	status, respBody := f.makeReq("/api/port_forward", f.serv.HandleTogglePortForward, http.MethodPost, payload)
+	require.Equal(t, http.StatusOK, status, respBody)
+	assert.Equal(t, portforward.ForwardID{ManifestName: "foobar", LocalPort: 8080}, f.forwards.lastID)
+	assert.False(t, f.forwards.lastEnabled)
+}
+
+func TestHandleTogglePortForwardConflict(t *testing.T) {
+	f := newTestFixture(t)
+	f.upsertManifest("foobar")
+	f.forwards.err = fmt.Errorf("%w: address already in use", portforward.ErrLocalPortInUse)
+
+	payload := `{"manifest_name": "foobar", "local_port": 8080, "enabled": true}`
+	status, respBody := f.makeReq("/api/port_forward", f.serv.HandleTogglePortForward, http.MethodPost, payload)
+	require.Equal(t, http.StatusConflict, status)
+	assert.Contains(t, respBody, "address already in use")
+}
+
+func TestHandleTogglePortForwardUnknownForward(t *testing.T) {
+	f := newTestFixture(t)
+	f.upsertManifest("foobar")
+	f.forwards.err = fmt.Errorf("%w: foobar:9999", portforward.ErrForwardNotFound)
+
+	payload := `{"manifest_name": "foobar", "local_port": 9999, "enabled": false}`
+	status, _ := f.makeReq("/api/port_forward", f.serv.HandleTogglePortForward, http.MethodPost, payload)
+	require.Equal(t, http.StatusNotFound, status)
+}
+
+type fakeForwardToggler struct {
+	err         error
+	lastID      portforward.ForwardID
+	lastEnabled bool
+}
+
+func (t *fakeForwardToggler) SetForwardEnabled(ctx context.Context, id portforward.ForwardID, enabled bool) error {
+	t.lastID = id
+	t.lastEnabled = enabled
+	return t.err
+}
+
+type fakeDebugger struct {
+	attachment debugcontainer.Attachment
+	err        error
+	lastName   model.ManifestName
+	lastOpts   debugcontainer.Options
+}
+
+func (d *fakeDebugger) Attach(ctx context.Context, mn model.ManifestName, opts debugcontainer.Options) (debugcontainer.Attachment, error) {
+	d.lastName = mn
+	d.lastOpts = opts
+	return d.attachment, d.err
+}
+
+type fakeApplyHistory struct {
+	invocations []k8s.ApplyInvocation
+	lastName    string
+}
+
+func (h *fakeApplyHistory) ApplyHistory(nn types.NamespacedName) []k8s.ApplyInvocation {
+	h.lastName = nn.Name
+	return h.invocations
+}
+
+type fakeDiffer struct {
+	diffs    []k8s.ObjectDiff
+	err      error
+	lastName string
+}
+
+func (d *fakeDiffer) Diff(ctx context.Context, nn types.NamespacedName) ([]k8s.ObjectDiff, error) {
+	d.lastName = nn.Name
+	return d.diffs, d.err
+}
+
 type serverFixture struct {
 	t            *testing.T
 	serv         *server.HeadsUpServer
@@ -392,9 +1073,18 @@ type serverFixture struct {
 	st           *store.Store
 	getActions   func() []store.Action
 	snapshotHTTP *fakeHTTPClient
+	differ       *fakeDiffer
+	history      *fakeApplyHistory
+	debugger     *fakeDebugger
+	forwards     *fakeForwardToggler
+	ctrlClient   ctrlclient.Client
 }
 
 func newTestFixture(t *testing.T) *serverFixture {
+	return newTestFixtureWithSecurity(t, server.WebSecurity{})
+}
+
+func newTestFixtureWithSecurity(t *testing.T, security server.WebSecurity) *serverFixture {
 	st, getActions := store.NewStoreWithFakeReducer()
 	go func() {
 		err := st.Loop(context.Background())
@@ -411,7 +1101,12 @@ func newTestFixture(t *testing.T) *serverFixture {
 		ObjectMeta: metav1.ObjectMeta{Name: model.MainTiltfileManifestName.String()},
 	})
 
-	serv, err := server.ProvideHeadsUpServer(context.Background(), st, assets.NewFakeServer(), ta, uploader, wsl, ctrlClient)
+	reporter := engineanalytics.ProvideAnalyticsReporter(ta, st, k8s.NewFakeK8sClient(t), k8s.EnvDockerDesktop)
+	differ := &fakeDiffer{}
+	history := &fakeApplyHistory{}
+	debugger := &fakeDebugger{}
+	forwards := &fakeForwardToggler{}
+	serv, err := server.ProvideHeadsUpServer(context.Background(), st, assets.NewFakeServer(), ta, uploader, wsl, ctrlClient, security, reporter, differ, history, debugger, forwards)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -424,9 +1119,32 @@ func newTestFixture(t *testing.T) *serverFixture {
 		st:           st,
 		getActions:   getActions,
 		snapshotHTTP: snapshotHTTP,
+		differ:       differ,
+		history:      history,
+		debugger:     debugger,
+		forwards:     forwards,
+		ctrlClient:   ctrlClient,
 	}
 }
 
+func (f *serverFixture) upsertManifest(name model.ManifestName) {
+	state := f.st.LockMutableStateForTesting()
+	state.UpsertManifestTarget(&store.ManifestTarget{Manifest: model.Manifest{Name: name}})
+	f.st.UnlockMutableState()
+}
+
+// Sends the request through the router, so that path variables get parsed.
+func (f *serverFixture) makeRouterReq(endpoint string, method string) (statusCode int, respBody string) {
+	req, err := http.NewRequest(method, endpoint, nil)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	f.serv.Router().ServeHTTP(rr, req)
+	return rr.Code, rr.Body.String()
+}
+
 func (f *serverFixture) makeReq(endpoint string, handler http.HandlerFunc,
 	method, body string) (statusCode int, respBody string) {
 	var reader io.Reader
@@ -445,6 +1163,15 @@ func (f *serverFixture) makeReq(endpoint string, handler http.HandlerFunc,
 	return rr.Code, rr.Body.String()
 }
 
+func (f *serverFixture) withUpdateMode() *serverFixture {
+	state := f.st.LockMutableStateForTesting()
+	state.UpdateMode = liveupdates.NewUpdateModeState(
+		liveupdates.UpdateModeFlag(liveupdates.UpdateModeAuto), liveupdates.UpdateModeAuto,
+		k8s.KubeContext("gke-cluster"), docker.ClusterEnv{Host: "tcp://docker:2376"})
+	f.st.UnlockMutableState()
+	return f
+}
+
 func (f *serverFixture) withDummyManifests(mNames ...string) *serverFixture {
 	state := f.st.LockMutableStateForTesting()
 	for _, mName := range mNames {
@@ -456,6 +1183,45 @@ func (f *serverFixture) withDummyManifests(mNames ...string) *serverFixture {
 	return f
 }
 
+// fe -> api -> db, and docs on its own, all enabled.
+func (f *serverFixture) withDisableManifests() *serverFixture {
+	manifests := []model.Manifest{
+		{Name: "db", Labels: map[string]string{"backend": "backend"}},
+		{Name: "api", Labels: map[string]string{"backend": "backend"}, ResourceDependencies: []model.ManifestName{"db"}},
+		{Name: "fe", ResourceDependencies: []model.ManifestName{"api"}},
+		{Name: "docs"},
+	}
+
+	state := f.st.LockMutableStateForTesting()
+	defer f.st.UnlockMutableState()
+	for _, m := range manifests {
+		state.UpsertManifestTarget(store.NewManifestTarget(m))
+		cm := &v1alpha1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: configmap.DisableConfigMapName(m.Name)},
+			Data:       map[string]string{configmap.DisableKey: "false"},
+		}
+		state.ConfigMaps[cm.Name] = cm
+		require.NoError(f.t, f.ctrlClient.Create(context.Background(), cm.DeepCopy()))
+	}
+	return f
+}
+
+func (f *serverFixture) assertDisabled(name model.ManifestName, expected bool) {
+	var cm v1alpha1.ConfigMap
+	err := f.ctrlClient.Get(context.Background(), types.NamespacedName{Name: configmap.DisableConfigMapName(name)}, &cm)
+	require.NoError(f.t, err)
+	assert.Equal(f.t, strconv.FormatBool(expected), cm.Data[configmap.DisableKey], name)
+}
+
+func (f *serverFixture) assertDisableSource(name model.ManifestName, expected v1alpha1.DisableChangeSource) {
+	var cm v1alpha1.ConfigMap
+	err := f.ctrlClient.Get(context.Background(), types.NamespacedName{Name: configmap.DisableConfigMapName(name)}, &cm)
+	require.NoError(f.t, err)
+	history := configmap.DisableHistory(&cm)
+	require.NotEmpty(f.t, history, name)
+	assert.Equal(f.t, expected, history[len(history)-1].Source, name)
+}
+
 type fakeHTTPClient struct {
 	lastReq *http.Request
 }
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+
+	"github.com/tilt-dev/tilt/internal/cloud"
+	"github.com/tilt-dev/tilt/pkg/assets"
+	proto_webview "github.com/tilt-dev/tilt/pkg/webview"
+)
+
+// Serves the web UI for a saved snapshot, with no engine behind it.
+//
+// The web UI already knows how to render a snapshot from /snapshot/{id}.
+// We serve the snapshot at the URL it fetches, and the assets everywhere else.
+// Nothing can change a snapshot, so every request that would mutate
+// state gets turned away.
+type SnapshotViewServer struct {
+	id       cloud.SnapshotID
+	snapshot *proto_webview.Snapshot
+	router   *mux.Router
+}
+
+var _ http.Handler = &SnapshotViewServer{}
+
+func NewSnapshotViewServer(snapshot *proto_webview.Snapshot, assetServer assets.Server) (*SnapshotViewServer, error) {
+	id, err := cloud.LocalSnapshotID(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	r := mux.NewRouter().UseEncodedPath()
+	s := &SnapshotViewServer{
+		id:       id,
+		snapshot: snapshot,
+		router:   r,
+	}
+
+	r.MatcherFunc(isMutatingRequest).HandlerFunc(s.HandleReadOnly)
+	r.HandleFunc("/api/snapshot/{snapshot_id}", s.SnapshotJSON)
+	r.HandleFunc("/api/view", s.ViewJSON)
+	r.PathPrefix("/api/").HandlerFunc(s.HandleNoEngine)
+	r.PathPrefix("/ws/").HandlerFunc(s.HandleNoEngine)
+	r.Path("/").Handler(http.RedirectHandler(s.Path(), http.StatusFound))
+	r.PathPrefix("/").Handler(assetServer)
+
+	return s, nil
+}
+
+func (s *SnapshotViewServer) ID() cloud.SnapshotID {
+	return s.id
+}
+
+// The path of the snapshot in the web UI.
+func (s *SnapshotViewServer) Path() string {
+	return fmt.Sprintf("/snapshot/%s/overview", s.id)
+}
+
+func (s *SnapshotViewServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.router.ServeHTTP(w, req)
+}
+
+func (s *SnapshotViewServer) SnapshotJSON(w http.ResponseWriter, req *http.Request) {
+	if mux.Vars(req)["snapshot_id"] != string(s.id) {
+		http.Error(w, fmt.Sprintf("Snapshot not found. This server only has snapshot %s", s.id), http.StatusNotFound)
+		return
+	}
+	s.writeJSON(w, s.snapshot)
+}
+
+func (s *SnapshotViewServer) ViewJSON(w http.ResponseWriter, req *http.Request) {
+	s.writeJSON(w, s.snapshot.View)
+}
+
+func (s *SnapshotViewServer) HandleReadOnly(w http.ResponseWriter, req *http.Request) {
+	http.Error(w, "Snapshots are read-only", http.StatusForbidden)
+}
+
+func (s *SnapshotViewServer) HandleNoEngine(w http.ResponseWriter, req *http.Request) {
+	http.Error(w, "Not available when viewing a snapshot", http.StatusNotFound)
+}
+
+func (s *SnapshotViewServer) writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	err := (&runtime.JSONPb{}).NewEncoder(w).Encode(v)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering snapshot: %v", err), http.StatusInternalServerError)
+	}
+}
+
+func isMutatingRequest(req *http.Request, _ *mux.RouteMatch) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	grpcRuntime "github.com/grpc-ecosystem/grpc-gateway/runtime"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/internal/cloud"
+	"github.com/tilt-dev/tilt/internal/hud/server"
+	"github.com/tilt-dev/tilt/pkg/assets"
+	proto_webview "github.com/tilt-dev/tilt/pkg/webview"
+)
+
+func TestSnapshotViewServesArchivedSnapshot(t *testing.T) {
+	f := newSnapshotViewFixture(t)
+
+	rr := f.get("/api/snapshot/" + string(f.serv.ID()))
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var snapshot proto_webview.Snapshot
+	require.NoError(t, (&grpcRuntime.JSONPb{}).NewDecoder(rr.Body).Decode(&snapshot))
+	if assert.Len(t, snapshot.View.UiResources, 2) {
+		assert.Equal(t, "frontend", snapshot.View.UiResources[1].Name)
+	}
+	assert.Equal(t, "0.22.7", snapshot.View.UiSession.Status.RunningTiltBuild.Version)
+	assert.Equal(t, "/r/frontend/overview", snapshot.Path)
+
+	segments := snapshot.View.LogList.Segments
+	if assert.Len(t, segments, 2) {
+		assert.Equal(t, "frontend listening on :8000\n", segments[1].Text)
+		assert.Equal(t, "frontend", snapshot.View.LogList.Spans[segments[1].SpanId].ManifestName)
+	}
+}
+
+func TestSnapshotViewServesArchivedView(t *testing.T) {
+	f := newSnapshotViewFixture(t)
+
+	rr := f.get("/api/view")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var view proto_webview.View
+	require.NoError(t, (&grpcRuntime.JSONPb{}).NewDecoder(rr.Body).Decode(&view))
+	assert.Len(t, view.UiResources, 2)
+	assert.Len(t, view.LogList.Segments, 2)
+}
+
+func TestSnapshotViewIDIsStable(t *testing.T) {
+	f1 := newSnapshotViewFixture(t)
+	f2 := newSnapshotViewFixture(t)
+	assert.Equal(t, f1.serv.ID(), f2.serv.ID())
+	assert.Equal(t, "/snapshot/"+string(f1.serv.ID())+"/overview", f1.serv.Path())
+}
+
+func TestSnapshotViewUnknownID(t *testing.T) {
+	f := newSnapshotViewFixture(t)
+
+	rr := f.get("/api/snapshot/aaaaa")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestSnapshotViewRedirectsToSnapshot(t *testing.T) {
+	f := newSnapshotViewFixture(t)
+
+	rr := f.get("/")
+	assert.Equal(t, http.StatusFound, rr.Code)
+	assert.Equal(t, f.serv.Path(), rr.Header().Get("Location"))
+}
+
+func TestSnapshotViewIsReadOnly(t *testing.T) {
+	f := newSnapshotViewFixture(t)
+
+	for _, path := range []string{
+		"/api/trigger",
+		"/api/override/trigger_mode",
+		"/api/snapshot/new",
+		"/api/set_tiltfile_args",
+		"/api/disable",
+		"/api/logs/clear",
+		"/proxy/apis/tilt.dev/v1alpha1/uibuttons/foo/status",
+	} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader("{}"))
+		rr := httptest.NewRecorder()
+		f.serv.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusForbidden, rr.Code, "POST %s", path)
+	}
+}
+
+func TestSnapshotViewHasNoEngine(t *testing.T) {
+	f := newSnapshotViewFixture(t)
+
+	for _, path := range []string{"/ws/view", "/api/dump/engine", "/api/graph"} {
+		rr := f.get(path)
+		assert.Equal(t, http.StatusNotFound, rr.Code, "GET %s", path)
+	}
+}
+
+type snapshotViewFixture struct {
+	t    *testing.T
+	serv *server.SnapshotViewServer
+}
+
+func newSnapshotViewFixture(t *testing.T) *snapshotViewFixture {
+	file, err := os.Open("testdata/snapshot.json")
+	require.NoError(t, err)
+	defer func() {
+		_ = file.Close()
+	}()
+
+	snapshot, err := cloud.ReadSnapshot(file)
+	require.NoError(t, err)
+
+	serv, err := server.NewSnapshotViewServer(snapshot, assets.NewFakeServer())
+	require.NoError(t, err)
+	return &snapshotViewFixture{t: t, serv: serv}
+}
+
+func (f *snapshotViewFixture) get(path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	rr := httptest.NewRecorder()
+	f.serv.ServeHTTP(rr, req)
+	return rr
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/grpc-ecosystem/grpc-gateway/runtime"
+
+	proto_webview "github.com/tilt-dev/tilt/pkg/webview"
+	"github.com/tilt-dev/tilt/pkg/webview/protocol"
+)
+
+// Writes the view to the websocket in the shape of one version of the protocol.
+type viewSerializer func(w io.Writer, view *proto_webview.View) error
+
+// We keep a serializer for the current major version and the one before it.
+var viewSerializers = map[protocol.Version]viewSerializer{
+	protocol.Version1: serializeViewV1,
+	protocol.Version2: serializeViewV2,
+}
+
+// Reads the protocol version that the client asked for.
+func parseViewVersion(query string) (protocol.Version, error) {
+	if query == "" {
+		return protocol.DefaultVersion, nil
+	}
+
+	n, err := strconv.Atoi(query)
+	if err == nil {
+		v := protocol.Version(n)
+		if _, ok := viewSerializers[v]; ok {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported view protocol version %q (supported: %v)", query, protocol.SupportedVersions)
+}
+
+// Version 1 is the JSON encoding of the webview protobuf.
+func serializeViewV1(w io.Writer, view *proto_webview.View) error {
+	jsEncoder := &runtime.JSONPb{}
+	return jsEncoder.NewEncoder(w).Encode(view)
+}
+
+func serializeViewV2(w io.Writer, view *proto_webview.View) error {
+	return json.NewEncoder(w).Encode(toProtocolView(view))
+}
+
+func toProtocolView(view *proto_webview.View) protocol.View {
+	result := protocol.View{
+		Session:    view.UiSession,
+		Resources:  view.UiResources,
+		Buttons:    view.UiButtons,
+		LogList:    toProtocolLogList(view.LogList),
+		IsComplete: view.IsComplete,
+	}
+	if view.TiltStartTime != nil {
+		t := view.TiltStartTime.AsTime()
+		result.TiltStartTime = &t
+	}
+	return result
+}
+
+func toProtocolLogList(list *proto_webview.LogList) *protocol.LogList {
+	if list == nil {
+		return nil
+	}
+
+	result := &protocol.LogList{
+		FromCheckpoint: list.FromCheckpoint,
+		ToCheckpoint:   list.ToCheckpoint,
+	}
+	if len(list.Spans) > 0 {
+		result.Spans = make(map[string]*protocol.LogSpan, len(list.Spans))
+		for id, span := range list.Spans {
+			result.Spans[id] = &protocol.LogSpan{ManifestName: span.ManifestName}
+		}
+	}
+	for _, seg := range list.Segments {
+		s := &protocol.LogSegment{
+			SpanID: seg.SpanId,
+			Text:   seg.Text,
+			Anchor: seg.Anchor,
+			Fields: seg.Fields,
+		}
+		if seg.Level != proto_webview.LogLevel_NONE {
+			s.Level = seg.Level.String()
+		}
+		if seg.Time != nil {
+			t := seg.Time.AsTime()
+			s.Time = &t
+		}
+		result.Segments = append(result.Segments, s)
+	}
+	return result
+}
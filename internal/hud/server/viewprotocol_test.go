@@ -0,0 +1,101 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	proto_webview "github.com/tilt-dev/tilt/pkg/webview"
+	"github.com/tilt-dev/tilt/pkg/webview/protocol"
+)
+
+func TestViewProtocolRoundTrip(t *testing.T) {
+	startTime := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	view := &proto_webview.View{
+		UiSession: &v1alpha1.UISession{
+			ObjectMeta: metav1.ObjectMeta{Name: "Tiltfile"},
+		},
+		UiResources: []*v1alpha1.UIResource{
+			{ObjectMeta: metav1.ObjectMeta{Name: "fe"}},
+		},
+		UiButtons: []*v1alpha1.UIButton{
+			{ObjectMeta: metav1.ObjectMeta{Name: "fe-restart"}},
+		},
+		LogList: &proto_webview.LogList{
+			Spans: map[string]*proto_webview.LogSpan{"build:fe": {ManifestName: "fe"}},
+			Segments: []*proto_webview.LogSegment{
+				{SpanId: "build:fe", Text: "oh no\n", Level: proto_webview.LogLevel_WARN, Time: timestamppb.New(startTime)},
+			},
+			FromCheckpoint: 0,
+			ToCheckpoint:   1,
+		},
+		TiltStartTime: timestamppb.New(startTime),
+		IsComplete:    true,
+	}
+
+	var v1Buf, v2Buf bytes.Buffer
+	require.NoError(t, viewSerializers[protocol.Version1](&v1Buf, view))
+	require.NoError(t, viewSerializers[protocol.Version2](&v2Buf, view))
+
+	// Version 1 keeps the names the web UI has always used.
+	v1Keys := topLevelKeys(t, v1Buf.Bytes())
+	assert.ElementsMatch(t, []string{"uiSession", "uiResources", "uiButtons", "logList", "tiltStartTime", "isComplete"}, v1Keys)
+
+	var v1 proto_webview.View
+	require.NoError(t, (&jsonpb.Unmarshaler{}).Unmarshal(&v1Buf, &v1))
+	assert.Equal(t, "fe", v1.UiResources[0].Name)
+	assert.Equal(t, "oh no\n", v1.LogList.Segments[0].Text)
+
+	// Version 2 renames the UI objects.
+	v2Keys := topLevelKeys(t, v2Buf.Bytes())
+	assert.ElementsMatch(t, []string{"session", "resources", "buttons", "logList", "tiltStartTime", "isComplete"}, v2Keys)
+
+	var v2 protocol.View
+	require.NoError(t, json.Unmarshal(v2Buf.Bytes(), &v2))
+	assert.Equal(t, "Tiltfile", v2.Session.Name)
+	assert.Equal(t, v1.UiResources[0].Name, v2.Resources[0].Name)
+	assert.Equal(t, v1.UiButtons[0].Name, v2.Buttons[0].Name)
+	assert.True(t, v2.IsComplete)
+	assert.True(t, startTime.Equal(*v2.TiltStartTime))
+	assert.Equal(t, &protocol.LogList{
+		Spans: map[string]*protocol.LogSpan{"build:fe": {ManifestName: "fe"}},
+		Segments: []*protocol.LogSegment{
+			{SpanID: "build:fe", Text: "oh no\n", Level: "WARN", Time: v2.LogList.Segments[0].Time},
+		},
+		ToCheckpoint: 1,
+	}, v2.LogList)
+	assert.True(t, startTime.Equal(*v2.LogList.Segments[0].Time))
+}
+
+func TestParseViewVersion(t *testing.T) {
+	v, err := parseViewVersion("")
+	require.NoError(t, err)
+	assert.Equal(t, protocol.Version1, v)
+
+	v, err = parseViewVersion("2")
+	require.NoError(t, err)
+	assert.Equal(t, protocol.Version2, v)
+
+	for _, bad := range []string{"3", "0", "v2", "latest"} {
+		_, err = parseViewVersion(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func topLevelKeys(t *testing.T, data []byte) []string {
+	var m map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &m))
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
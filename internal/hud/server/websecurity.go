@@ -0,0 +1,354 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tilt-dev/tilt/internal/xdg"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// The cookie that the web UI uses to authenticate mutating requests
+// once the user has visited the HUD with a valid ?token= parameter.
+const TiltWebAuthCookieName = "Tilt-Web-Auth"
+
+// The query parameter that the user can pass to the web UI (or the websocket)
+// to authenticate.
+const webAuthTokenParam = "token"
+
+// WebSecurityOptions are the user-facing knobs for exposing the
+// web server beyond an SSH tunnel.
+type WebSecurityOptions struct {
+	// Serve the web UI over HTTPS.
+	TLS bool
+
+	// A cert/key pair. If empty and TLS is enabled, we generate a self-signed
+	// cert and persist it under the tilt dir.
+	CertFile string
+	KeyFile  string
+
+	// Require a bearer token on mutating endpoints.
+	Auth bool
+
+	// Reject all requests that modify state, so that users can watch
+	// a shared Tilt without changing it by accident.
+	ReadOnly bool
+}
+
+// WebSecurity is the resolved security config of the web server.
+//
+// The zero value serves plain HTTP with no auth.
+type WebSecurity struct {
+	TLSConfig *tls.Config
+	CertFile  string
+	Token     BearerToken
+
+	// Where we write the connection info so that CLI commands can find it.
+	ConnInfoPath string
+
+	ReadOnly bool
+}
+
+// The connection info that the CLI needs to talk to a running Tilt web server.
+type WebConnInfo struct {
+	Scheme   string `json:"scheme"`
+	Token    string `json:"token,omitempty"`
+	CertFile string `json:"certFile,omitempty"`
+}
+
+func webConnInfoPath(base xdg.Base, name model.APIServerName) (string, error) {
+	return base.StateFile(filepath.Join("web", fmt.Sprintf("%s.json", name)))
+}
+
+func ProvideWebSecurity(opts WebSecurityOptions, name model.APIServerName, host model.WebHost, base xdg.Base) (WebSecurity, error) {
+	connInfoPath, err := webConnInfoPath(base, name)
+	if err != nil {
+		return WebSecurity{}, err
+	}
+
+	result := WebSecurity{ConnInfoPath: connInfoPath, ReadOnly: opts.ReadOnly}
+	if opts.TLS {
+		certFile, keyFile := opts.CertFile, opts.KeyFile
+		if (certFile == "") != (keyFile == "") {
+			return WebSecurity{}, fmt.Errorf("--web-tls-cert and --web-tls-key must be specified together")
+		}
+
+		if certFile == "" {
+			certFile, keyFile, err = ensureSelfSignedCert(base, name, host)
+			if err != nil {
+				return WebSecurity{}, fmt.Errorf("generating self-signed cert: %v", err)
+			}
+		}
+
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return WebSecurity{}, fmt.Errorf("loading web TLS cert: %v", err)
+		}
+		result.CertFile = certFile
+		result.TLSConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+	}
+
+	if opts.Auth {
+		token, err := NewBearerToken()
+		if err != nil {
+			return WebSecurity{}, err
+		}
+		result.Token = token
+	}
+	return result, nil
+}
+
+func (s WebSecurity) Scheme() string {
+	if s.TLSConfig != nil {
+		return "https"
+	}
+	return "http"
+}
+
+func (s WebSecurity) ConnInfo() WebConnInfo {
+	return WebConnInfo{
+		Scheme:   s.Scheme(),
+		Token:    string(s.Token),
+		CertFile: s.CertFile,
+	}
+}
+
+// Checks whether the request carries the auth token,
+// either as a bearer token, the web UI cookie, or (for websockets, where
+// browsers can't set headers) a query param.
+func (s WebSecurity) IsAuthorized(req *http.Request) bool {
+	if s.Token == "" {
+		return true
+	}
+
+	auth := req.Header.Get("Authorization")
+	if strings.HasPrefix(auth, "Bearer ") && s.tokenMatches(strings.TrimPrefix(auth, "Bearer ")) {
+		return true
+	}
+
+	cookie, err := req.Cookie(TiltWebAuthCookieName)
+	if err == nil && s.tokenMatches(cookie.Value) {
+		return true
+	}
+
+	return s.tokenMatches(req.URL.Query().Get(webAuthTokenParam))
+}
+
+func (s WebSecurity) tokenMatches(candidate string) bool {
+	if candidate == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(candidate), []byte(s.Token)) == 1
+}
+
+// Wraps a handler so that it rejects unauthenticated requests.
+func (s WebSecurity) RequireAuth(handler http.Handler) http.Handler {
+	if s.Token == "" {
+		return handler
+	}
+	return funcHandler{f: func(w http.ResponseWriter, req *http.Request) {
+		if !s.IsAuthorized(req) {
+			http.Error(w, "unauthorized: this Tilt server requires an auth token", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, req)
+	}}
+}
+
+// Wraps a handler so that it rejects requests that modify state
+// when the server is read-only.
+//
+// Read requests (including watches on the API server) still go through.
+func (s WebSecurity) RequireWritable(handler http.Handler) http.Handler {
+	if !s.ReadOnly {
+		return handler
+	}
+	return funcHandler{f: func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			handler.ServeHTTP(w, req)
+		default:
+			http.Error(w, "forbidden: this Tilt server is read-only", http.StatusForbidden)
+		}
+	}}
+}
+
+func (s WebSecurity) writeConnInfo() error {
+	if s.ConnInfoPath == "" {
+		return nil
+	}
+	contents, err := json.Marshal(s.ConnInfo())
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.ConnInfoPath, contents, 0600)
+}
+
+func (s WebSecurity) removeConnInfo() error {
+	if s.ConnInfoPath == "" {
+		return nil
+	}
+	err := os.Remove(s.ConnInfoPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Reads the connection info that a running Tilt server wrote.
+//
+// If no Tilt server has written connection info, returns a plain
+// HTTP connection with no auth.
+func ReadWebConnInfo(base xdg.Base, name model.APIServerName) (WebConnInfo, error) {
+	p, err := webConnInfoPath(base, name)
+	if err != nil {
+		return WebConnInfo{}, err
+	}
+
+	contents, err := ioutil.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return WebConnInfo{Scheme: "http"}, nil
+		}
+		return WebConnInfo{}, err
+	}
+
+	var info WebConnInfo
+	err = json.Unmarshal(contents, &info)
+	if err != nil {
+		return WebConnInfo{}, fmt.Errorf("reading %s: %v", p, err)
+	}
+	if info.Scheme == "" {
+		info.Scheme = "http"
+	}
+	return info, nil
+}
+
+// The TLS config that clients should use to trust the server cert.
+func (i WebConnInfo) TLSClientConfig() (*tls.Config, error) {
+	if i.CertFile == "" {
+		return nil, nil
+	}
+	contents, err := ioutil.ReadFile(i.CertFile)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(contents) {
+		return nil, fmt.Errorf("no certificates found in %s", i.CertFile)
+	}
+	return &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}, nil
+}
+
+// Adds auth headers to an outgoing request.
+func (i WebConnInfo) AddAuth(header http.Header) {
+	if i.Token != "" {
+		header.Set("Authorization", fmt.Sprintf("Bearer %s", i.Token))
+	}
+}
+
+// An http client that trusts the server cert and adds auth headers.
+func (i WebConnInfo) HTTPClient() (*http.Client, error) {
+	tlsConfig, err := i.TLSClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Transport: authTransport{info: i, delegate: transport}}, nil
+}
+
+type authTransport struct {
+	info     WebConnInfo
+	delegate http.RoundTripper
+}
+
+func (t authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	t.info.AddAuth(req.Header)
+	return t.delegate.RoundTrip(req)
+}
+
+// Generates a self-signed cert for the web server, or re-uses the
+// one from a previous session.
+func ensureSelfSignedCert(base xdg.Base, name model.APIServerName, host model.WebHost) (string, string, error) {
+	certFile, err := base.DataFile(filepath.Join("web-certs", string(name), "tls.crt"))
+	if err != nil {
+		return "", "", err
+	}
+	keyFile := filepath.Join(filepath.Dir(certFile), "tls.key")
+
+	if _, err := tls.LoadX509KeyPair(certFile, keyFile); err == nil {
+		return certFile, keyFile, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"Tilt"}, CommonName: string(host)},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+	}
+	if ip := net.ParseIP(string(host)); ip != nil {
+		template.IPAddresses = append(template.IPAddresses, ip)
+	} else if host != "" && host != "localhost" {
+		template.DNSNames = append(template.DNSNames, string(host))
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return "", "", err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	if err != nil {
+		return "", "", err
+	}
+	err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	if err != nil {
+		return "", "", err
+	}
+	return certFile, keyFile, nil
+}
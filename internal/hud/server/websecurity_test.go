@@ -0,0 +1,115 @@
+package server
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
+	"github.com/tilt-dev/tilt/internal/xdg"
+)
+
+func TestWebSecurityDefaultIsPlain(t *testing.T) {
+	f := tempdir.NewTempDirFixture(t)
+	base := xdg.FakeBase{Dir: f.Path()}
+
+	sec, err := ProvideWebSecurity(WebSecurityOptions{}, "tilt-default", "localhost", base)
+	require.NoError(t, err)
+	assert.Equal(t, "http", sec.Scheme())
+	assert.Equal(t, BearerToken(""), sec.Token)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/trigger", nil)
+	assert.True(t, sec.IsAuthorized(req))
+}
+
+func TestWebSecurityReadOnlyRejectsWrites(t *testing.T) {
+	sec := WebSecurity{ReadOnly: true}
+	handler := sec.RequireWritable(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodOptions} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(method, "/proxy/apis/tilt.dev/v1alpha1/uibuttons", nil))
+		assert.Equal(t, http.StatusOK, rr.Code, method)
+	}
+
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(method, "/proxy/apis/tilt.dev/v1alpha1/uibuttons/foo/status", nil))
+		assert.Equal(t, http.StatusForbidden, rr.Code, method)
+	}
+}
+
+func TestWebSecurityConnInfoRoundTrip(t *testing.T) {
+	f := tempdir.NewTempDirFixture(t)
+	base := xdg.FakeBase{Dir: f.Path()}
+
+	info, err := ReadWebConnInfo(base, "tilt-default")
+	require.NoError(t, err)
+	assert.Equal(t, WebConnInfo{Scheme: "http"}, info)
+
+	sec, err := ProvideWebSecurity(WebSecurityOptions{TLS: true, Auth: true}, "tilt-default", "localhost", base)
+	require.NoError(t, err)
+	require.NoError(t, sec.writeConnInfo())
+
+	info, err = ReadWebConnInfo(base, "tilt-default")
+	require.NoError(t, err)
+	assert.Equal(t, "https", info.Scheme)
+	assert.Equal(t, string(sec.Token), info.Token)
+	assert.Equal(t, sec.CertFile, info.CertFile)
+
+	require.NoError(t, sec.removeConnInfo())
+	info, err = ReadWebConnInfo(base, "tilt-default")
+	require.NoError(t, err)
+	assert.Equal(t, "http", info.Scheme)
+}
+
+func TestWebSecuritySelfSignedCertReused(t *testing.T) {
+	f := tempdir.NewTempDirFixture(t)
+	base := xdg.FakeBase{Dir: f.Path()}
+
+	sec1, err := ProvideWebSecurity(WebSecurityOptions{TLS: true}, "tilt-default", "localhost", base)
+	require.NoError(t, err)
+	cert1, err := ioutil.ReadFile(sec1.CertFile)
+	require.NoError(t, err)
+
+	sec2, err := ProvideWebSecurity(WebSecurityOptions{TLS: true}, "tilt-default", "localhost", base)
+	require.NoError(t, err)
+	cert2, err := ioutil.ReadFile(sec2.CertFile)
+	require.NoError(t, err)
+	assert.Equal(t, cert1, cert2)
+}
+
+func TestWebSecurityClientOverTLS(t *testing.T) {
+	f := tempdir.NewTempDirFixture(t)
+	base := xdg.FakeBase{Dir: f.Path()}
+
+	sec, err := ProvideWebSecurity(WebSecurityOptions{TLS: true, Auth: true}, "tilt-default", "localhost", base)
+	require.NoError(t, err)
+
+	ts := httptest.NewUnstartedServer(sec.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})))
+	ts.TLS = sec.TLSConfig
+	ts.StartTLS()
+	defer ts.Close()
+
+	client, err := sec.ConnInfo().HTTPClient()
+	require.NoError(t, err)
+	res, err := client.Get(ts.URL)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	unauthed, err := WebConnInfo{Scheme: "https", CertFile: sec.CertFile}.HTTPClient()
+	require.NoError(t, err)
+	res2, err := unauthed.Get(ts.URL)
+	require.NoError(t, err)
+	defer res2.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res2.StatusCode)
+}
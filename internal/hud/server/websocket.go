@@ -5,10 +5,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
-	"github.com/grpc-ecosystem/grpc-gateway/runtime"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -21,6 +21,7 @@ import (
 	"github.com/tilt-dev/tilt/pkg/logger"
 	"github.com/tilt-dev/tilt/pkg/model/logstore"
 	proto_webview "github.com/tilt-dev/tilt/pkg/webview"
+	"github.com/tilt-dev/tilt/pkg/webview/protocol"
 
 	"github.com/gorilla/websocket"
 )
@@ -44,11 +45,15 @@ type WebsocketSubscriber struct {
 	ctrlClient ctrlclient.Client
 	mu         sync.Mutex
 	conn       WebsocketConn
+	serialize  viewSerializer
 	initDone   chan bool
 	streamDone chan bool
 
 	tiltStartTime    *timestamp.Timestamp
 	clientCheckpoint logstore.Checkpoint
+
+	// Only send log lines that this filter allows.
+	levels logstore.LevelFilter
 }
 
 type WebsocketConn interface {
@@ -59,12 +64,18 @@ type WebsocketConn interface {
 
 var _ WebsocketConn = &websocket.Conn{}
 
-func NewWebsocketSubscriber(ctx context.Context, ctrlClient ctrlclient.Client, st store.RStore, conn WebsocketConn) *WebsocketSubscriber {
+func NewWebsocketSubscriber(ctx context.Context, ctrlClient ctrlclient.Client, st store.RStore, conn WebsocketConn, version protocol.Version, levels logstore.LevelFilter) *WebsocketSubscriber {
+	serialize, ok := viewSerializers[version]
+	if !ok {
+		serialize = viewSerializers[protocol.DefaultVersion]
+	}
 	return &WebsocketSubscriber{
 		ctx:        ctx,
 		ctrlClient: ctrlClient,
 		st:         st,
 		conn:       conn,
+		serialize:  serialize,
+		levels:     levels,
 		initDone:   make(chan bool),
 		streamDone: make(chan bool),
 	}
@@ -96,7 +107,7 @@ func (ws *WebsocketSubscriber) Stream(ctx context.Context) {
 		defer close(ws.initDone)
 
 		// initialize the stream with a full view
-		view, err := webview.CompleteView(ctx, ws.ctrlClient, ws.st)
+		view, err := webview.CompleteViewWithLevels(ctx, ws.ctrlClient, ws.st, ws.levels)
 		if err != nil {
 			// not much to do
 			return
@@ -134,7 +145,7 @@ func (ws *WebsocketSubscriber) OnChange(ctx context.Context, s store.RStore, sum
 		return nil
 	}
 
-	view, err := webview.LogUpdate(s, ws.clientCheckpoint)
+	view, err := webview.LogUpdate(s, ws.clientCheckpoint, ws.levels)
 	if err != nil {
 		return nil // Not much we can do on error right now.
 	}
@@ -249,7 +260,6 @@ func (ws *WebsocketSubscriber) sendView(ctx context.Context, view *proto_webview
 		ws.tiltStartTime = view.TiltStartTime
 	}
 
-	jsEncoder := &runtime.JSONPb{}
 	w, err := ws.conn.NextWriter(websocket.TextMessage)
 	if err != nil {
 		logger.Get(ctx).Verbosef("getting writer: %v", err)
@@ -262,20 +272,56 @@ func (ws *WebsocketSubscriber) sendView(ctx context.Context, view *proto_webview
 		}
 	}()
 
-	err = jsEncoder.NewEncoder(w).Encode(view)
+	err = ws.serialize(w, view)
 	if err != nil {
 		logger.Get(ctx).Verbosef("sending webview data: %v", err)
 	}
 }
 
 func (s *HeadsUpServer) ViewWebsocket(w http.ResponseWriter, req *http.Request) {
-	conn, err := upgrader.Upgrade(w, req, nil)
+	version, versionErr := parseViewVersion(req.URL.Query().Get(protocol.VersionQueryParam))
+	header := http.Header{}
+	if versionErr == nil {
+		header.Set(protocol.VersionHeader, strconv.Itoa(int(version)))
+	}
+	levels, levelErr := logstore.ParseLevelFilter(req.URL.Query().Get(protocol.LevelQueryParam))
+
+	conn, err := upgrader.Upgrade(w, req, header)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error upgrading websocket: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	ws := NewWebsocketSubscriber(s.ctx, s.ctrlClient, s.store, conn)
+	if versionErr != nil {
+		// Tell the client what went wrong in a frame it can parse,
+		// rather than failing the handshake.
+		_ = conn.WriteJSON(protocol.ErrorFrame{
+			Error: protocol.Error{
+				Code:              protocol.ErrorCodeUnsupportedVersion,
+				Message:           versionErr.Error(),
+				SupportedVersions: protocol.SupportedVersions,
+			},
+		})
+		_ = conn.WriteMessage(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseUnsupportedData, "unsupported version"))
+		_ = conn.Close()
+		return
+	}
+
+	if levelErr != nil {
+		_ = conn.WriteJSON(protocol.ErrorFrame{
+			Error: protocol.Error{
+				Code:    protocol.ErrorCodeInvalidLevel,
+				Message: levelErr.Error(),
+			},
+		})
+		_ = conn.WriteMessage(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseUnsupportedData, "invalid level"))
+		_ = conn.Close()
+		return
+	}
+
+	ws := NewWebsocketSubscriber(s.ctx, s.ctrlClient, s.store, conn, version, levels)
 	s.wsList.Add(ws)
 	_ = s.store.AddSubscriber(s.ctx, ws)
 
@@ -15,6 +15,8 @@ import (
 	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/internal/testutils"
 	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model/logstore"
+	"github.com/tilt-dev/tilt/pkg/webview/protocol"
 )
 
 func TestWebsocketCloseOnReadErr(t *testing.T) {
@@ -27,7 +29,7 @@ func TestWebsocketCloseOnReadErr(t *testing.T) {
 
 	conn := newFakeConn()
 	ctrlClient := fake.NewFakeTiltClient()
-	ws := NewWebsocketSubscriber(ctx, ctrlClient, st, conn)
+	ws := NewWebsocketSubscriber(ctx, ctrlClient, st, conn, protocol.DefaultVersion, logstore.LevelFilter{})
 	require.NoError(t, st.AddSubscriber(ctx, ws))
 
 	done := make(chan bool)
@@ -57,7 +59,7 @@ func TestWebsocketReadErrDuringMsg(t *testing.T) {
 
 	conn := newFakeConn()
 	ctrlClient := fake.NewFakeTiltClient()
-	ws := NewWebsocketSubscriber(ctx, ctrlClient, st, conn)
+	ws := NewWebsocketSubscriber(ctx, ctrlClient, st, conn, protocol.DefaultVersion, logstore.LevelFilter{})
 	require.NoError(t, st.AddSubscriber(ctx, ws))
 
 	done := make(chan bool)
@@ -93,7 +95,7 @@ func TestWebsocketNextWriterError(t *testing.T) {
 	conn := newFakeConn()
 	conn.nextWriterError = fmt.Errorf("fake NextWriter error")
 	ctrlClient := fake.NewFakeTiltClient()
-	ws := NewWebsocketSubscriber(ctx, ctrlClient, st, conn)
+	ws := NewWebsocketSubscriber(ctx, ctrlClient, st, conn, protocol.DefaultVersion, logstore.LevelFilter{})
 	require.NoError(t, st.AddSubscriber(ctx, ws))
 
 	done := make(chan bool)
@@ -127,7 +129,7 @@ func TestWebsocketIgnoreEmptyLogList(t *testing.T) {
 
 	conn := newFakeConn()
 	ctrlClient := fake.NewFakeTiltClient()
-	ws := NewWebsocketSubscriber(ctx, ctrlClient, st, conn)
+	ws := NewWebsocketSubscriber(ctx, ctrlClient, st, conn, protocol.DefaultVersion, logstore.LevelFilter{})
 	require.NoError(t, st.AddSubscriber(ctx, ws))
 
 	done := make(chan bool)
@@ -8,11 +8,11 @@ import (
 
 var WireSet = wire.NewSet(
 	NewBearerToken,
-	ProvideWebListener,
 	ProvideAPIServerPort,
 	ProvideConfigAccess,
 	model.ProvideAPIServerName,
 	ProvideKeyCert,
+	ProvideWebSecurity,
 	ProvideMemConn,
 	ProvideTiltServerOptions,
 	ProvideTiltDynamic,
@@ -11,10 +11,11 @@ type TerminalStream struct {
 	ProcessedLogs logstore.Checkpoint
 	printer       *IncrementalPrinter
 	store         store.RStore
+	levels        logstore.LevelFilter
 }
 
-func NewTerminalStream(printer *IncrementalPrinter, store store.RStore) *TerminalStream {
-	return &TerminalStream{printer: printer, store: store}
+func NewTerminalStream(printer *IncrementalPrinter, store store.RStore, levels logstore.LevelFilter) *TerminalStream {
+	return &TerminalStream{printer: printer, store: store, levels: levels}
 }
 
 // TODO(nick): We should change this API so that TearDown gets
@@ -47,7 +48,7 @@ func (h *TerminalStream) OnChange(ctx context.Context, st store.RStore, _ store.
 	}
 
 	state := st.RLockState()
-	lines := state.LogStore.ContinuingLines(h.ProcessedLogs)
+	lines := state.LogStore.ContinuingLinesWithOptions(h.ProcessedLogs, logstore.LineOptions{Levels: h.levels})
 	checkpoint := state.LogStore.Checkpoint()
 	st.RUnlockState()
 
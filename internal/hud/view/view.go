@@ -1,6 +1,7 @@
 package view
 
 import (
+	"sort"
 	"strings"
 	"time"
 
@@ -126,6 +127,12 @@ type Resource struct {
 	ResourceInfo ResourceInfoView
 
 	IsTiltfile bool
+
+	// How much this resource needs the user's attention. See store.AttentionScore.
+	AttentionScore int32
+
+	// Warnings about this resource from the last successful Tiltfile load.
+	TiltfileWarnings []model.TiltfileWarning
 }
 
 func (r Resource) DockerComposeTarget() DCResourceInfo {
@@ -187,6 +194,7 @@ func (r Resource) DefaultCollapse() bool {
 	autoExpand = autoExpand ||
 		r.LastBuild().Error != nil ||
 		r.LastBuild().WarningCount > 0 ||
+		len(r.TiltfileWarnings) > 0 ||
 		r.LastBuild().Reason.Has(model.BuildReasonFlagCrash) ||
 		r.CurrentBuild.Reason.Has(model.BuildReasonFlagCrash) ||
 		r.PendingBuildReason.Has(model.BuildReasonFlagCrash)
@@ -208,6 +216,14 @@ type View struct {
 	LogReader  logstore.Reader
 	Resources  []Resource
 	FatalError error
+	ReadOnly   bool
+
+	// Set while Tilt is asleep after a period of inactivity.
+	Sleeping bool
+
+	// Set while Tilt has lost touch with the Kubernetes cluster,
+	// to the error from the most recent check.
+	ClusterConnectionError string
 }
 
 func (v View) TiltfileErrorMessage() string {
@@ -223,6 +239,17 @@ func (v View) TiltfileErrorMessage() string {
 	return ""
 }
 
+// Returns a copy of the view with the resources that need the most attention
+// first. Resources with the same score keep their current order.
+func (v View) SortedByAttention() View {
+	resources := append([]Resource{}, v.Resources...)
+	sort.SliceStable(resources, func(i, j int) bool {
+		return resources[i].AttentionScore > resources[j].AttentionScore
+	})
+	v.Resources = resources
+	return v
+}
+
 func (v View) Resource(n model.ManifestName) (Resource, bool) {
 	for _, res := range v.Resources {
 		if res.Name == n {
@@ -241,6 +268,7 @@ type ViewState struct {
 	TabState         TabState
 	SelectedIndex    int
 	TiltLogState     TiltLogState
+	SortByAttention  bool
 }
 
 type TabState int
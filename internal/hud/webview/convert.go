@@ -2,8 +2,10 @@ package webview
 
 import (
 	"context"
+	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -11,6 +13,7 @@ import (
 	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
 
 	"github.com/golang/protobuf/ptypes"
+	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -35,6 +38,21 @@ const UISessionName = "Tiltfile"
 
 // Create the complete snapshot of the webview.
 func CompleteView(ctx context.Context, client ctrlclient.Client, st store.RStore) (*proto_webview.View, error) {
+	return completeView(ctx, client, st, false, logstore.LevelFilter{})
+}
+
+// Like CompleteView, but only includes log lines that the filter allows.
+func CompleteViewWithLevels(ctx context.Context, client ctrlclient.Client, st store.RStore, levels logstore.LevelFilter) (*proto_webview.View, error) {
+	return completeView(ctx, client, st, false, levels)
+}
+
+// Like CompleteView, but includes logs that the user cleared in the UI,
+// so that snapshots written to disk show everything that happened.
+func SnapshotView(ctx context.Context, client ctrlclient.Client, st store.RStore) (*proto_webview.View, error) {
+	return completeView(ctx, client, st, true, logstore.LevelFilter{})
+}
+
+func completeView(ctx context.Context, client ctrlclient.Client, st store.RStore, includeCleared bool, levels logstore.LevelFilter) (*proto_webview.View, error) {
 	ret := &proto_webview.View{}
 	session := &v1alpha1.UISession{}
 	err := client.Get(ctx, types.NamespacedName{Name: UISessionName}, session)
@@ -70,7 +88,13 @@ func CompleteView(ctx context.Context, client ctrlclient.Client, st store.RStore
 
 	s := st.RLockState()
 	defer st.RUnlockState()
-	logList, err := s.LogStore.ToLogList(0)
+	toLogList := func(c logstore.Checkpoint) (*proto_webview.LogList, error) {
+		return s.LogStore.ToFilteredLogList(c, levels)
+	}
+	if includeCleared {
+		toLogList = s.LogStore.ToLogListWithCleared
+	}
+	logList, err := toLogList(0)
 	if err != nil {
 		return nil, err
 	}
@@ -92,12 +116,12 @@ func CompleteView(ctx context.Context, client ctrlclient.Client, st store.RStore
 }
 
 // Create a view that only contains logs since the given checkpoint.
-func LogUpdate(st store.RStore, checkpoint logstore.Checkpoint) (*proto_webview.View, error) {
+func LogUpdate(st store.RStore, checkpoint logstore.Checkpoint, levels logstore.LevelFilter) (*proto_webview.View, error) {
 	ret := &proto_webview.View{}
 
 	s := st.RLockState()
 	defer st.RUnlockState()
-	logList, err := s.LogStore.ToLogList(checkpoint)
+	logList, err := s.LogStore.ToFilteredLogList(checkpoint, levels)
 	if err != nil {
 		return nil, err
 	}
@@ -139,6 +163,9 @@ func sortUIResources(resources []*v1alpha1.UIResource, order []model.ManifestNam
 	})
 }
 
+// Not a real feature flag. Tells the web UI that the server is read-only.
+const ReadOnlyFeatureFlag = "read_only"
+
 // Converts EngineState into the public data model representation, a UISession.
 func ToUISession(s store.EngineState) *v1alpha1.UISession {
 	ret := &v1alpha1.UISession{
@@ -164,6 +191,12 @@ func ToUISession(s store.EngineState) *v1alpha1.UISession {
 			Value: v,
 		})
 	}
+	if s.ReadOnly {
+		status.FeatureFlags = append(status.FeatureFlags, v1alpha1.UIFeatureFlag{
+			Name:  ReadOnlyFeatureFlag,
+			Value: true,
+		})
+	}
 	sort.Slice(status.FeatureFlags, func(i, j int) bool {
 		return status.FeatureFlags[i].Name < status.FeatureFlags[j].Name
 	})
@@ -182,10 +215,46 @@ func ToUISession(s store.EngineState) *v1alpha1.UISession {
 	status.TiltStartTime = metav1.NewTime(s.TiltStartTime)
 
 	status.TiltfileKey = s.MainTiltfilePath()
+	status.Sleeping = s.Sleeping
+
+	if !s.ImageRegistry.Empty() {
+		status.ImageRegistry = &v1alpha1.UIImageRegistry{
+			Host:            s.ImageRegistry.Registry.Host,
+			HostFromCluster: s.ImageRegistry.Registry.HostFromCluster(),
+			Source:          string(s.ImageRegistry.Source),
+		}
+	}
+
+	if !s.MemoryReport.Empty() {
+		status.MemoryUsage = ToUIMemoryUsage(s.MemoryReport)
+	}
+
+	if s.ClusterConnection.Status != store.ClusterConnectionUnknown {
+		status.ClusterConnection = &v1alpha1.UIClusterConnection{
+			Status: string(s.ClusterConnection.Status),
+			Error:  s.ClusterConnection.Error,
+			Since:  metav1.NewTime(s.ClusterConnection.Since),
+		}
+	}
 
 	return ret
 }
 
+func ToUIMemoryUsage(r store.MemoryReport) *v1alpha1.UIMemoryUsage {
+	result := &v1alpha1.UIMemoryUsage{
+		SampleTime: metav1.NewMicroTime(r.Time),
+		TotalBytes: r.TotalBytes,
+		HeapBytes:  r.HeapBytes,
+	}
+	for _, c := range r.Accounting.Consumers {
+		result.Consumers = append(result.Consumers, v1alpha1.UIMemoryConsumer{
+			Name:  c.Name,
+			Bytes: c.Bytes,
+		})
+	}
+	return result
+}
+
 // Converts an EngineState into a list of UIResources.
 // The order of the list is non-deterministic.
 func ToUIResourceList(state store.EngineState, disableSources map[string][]v1alpha1.DisableSource) ([]*v1alpha1.UIResource, error) {
@@ -203,6 +272,12 @@ func ToUIResourceList(state store.EngineState, disableSources map[string][]v1alp
 		ret = append(ret, r)
 	}
 
+	for _, task := range state.BootstrapTasks {
+		r := BootstrapTaskProtoView(task)
+		r.Status.Order = int32(len(ret) + 1)
+		ret = append(ret, r)
+	}
+
 	_, holds := buildcontrol.NextTargetToBuild(state)
 
 	for _, mt := range state.Targets() {
@@ -216,9 +291,30 @@ func ToUIResourceList(state store.EngineState, disableSources map[string][]v1alp
 		ret = append(ret, r)
 	}
 
+	populateAttention(ret, store.AttentionScores(state, time.Now()))
+
 	return ret, nil
 }
 
+// Fills in the attention score and order of each resource.
+//
+// Expects resources in Order.
+func populateAttention(resources []*v1alpha1.UIResource, scores map[model.ManifestName]float64) {
+	names := make([]model.ManifestName, 0, len(resources))
+	byName := make(map[model.ManifestName]*v1alpha1.UIResource, len(resources))
+	for _, r := range resources {
+		name := model.ManifestName(r.Name)
+		r.Status.AttentionScore = store.RoundAttentionScore(scores[name])
+		names = append(names, name)
+		byName[name] = r
+	}
+
+	store.SortByAttention(names, scores)
+	for i, name := range names {
+		byName[name].Status.AttentionOrder = int32(i + 1)
+	}
+}
+
 func disableResourceStatus(disableSources []v1alpha1.DisableSource, s store.EngineState) (v1alpha1.DisableResourceStatus, error) {
 	var result v1alpha1.DisableResourceStatus
 	for _, source := range disableSources {
@@ -239,6 +335,14 @@ func disableResourceStatus(disableSources []v1alpha1.DisableSource, s store.Engi
 		} else {
 			result.EnabledCount += 1
 		}
+
+		if source.ConfigMap != nil {
+			history := configmap.DisableHistory(s.ConfigMaps[source.ConfigMap.Name])
+			if len(history) > 0 {
+				result.Source = history[len(history)-1].Source
+				result.Transitions = history
+			}
+		}
 	}
 	result.Sources = disableSources
 	return result, nil
@@ -271,33 +375,56 @@ func toUIResource(mt *store.ManifestTarget, s store.EngineState, disableSources
 		return nil, errors.Wrap(err, "error determining disable resource status")
 	}
 
+	links := store.ManifestTargetLinks(s, mt)
+
 	r := &v1alpha1.UIResource{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   mn.String(),
-			Labels: mt.Manifest.Labels,
+			Name:        mn.String(),
+			Labels:      mt.Manifest.Labels,
+			Annotations: mt.Manifest.Annotations,
 		},
 		Status: v1alpha1.UIResourceStatus{
 			LastDeployTime:    lastDeploy,
 			BuildHistory:      bh,
 			PendingBuildSince: metav1.NewMicroTime(pendingBuildSince),
 			CurrentBuild:      cb,
-			EndpointLinks:     ToAPILinks(endpoints),
+			EndpointLinks:     toEndpointLinks(endpoints, links),
 			Specs:             specs,
 			TriggerMode:       int32(mt.Manifest.TriggerMode),
 			HasPendingChanges: hasPendingChanges,
 			Queued:            s.ManifestInTriggerQueue(mn),
 			DisableStatus:     drs,
 			Waiting:           holdToWaiting(hold),
+			Links:             links,
+			TiltfileWarnings:  ToUITiltfileWarnings(ms.TiltfileWarnings),
 		},
 	}
 
-	err = populateResourceInfoView(mt, r)
+	err = populateResourceInfoView(mt, s.ClusterClockSkew, r)
 	if err != nil {
 		return nil, err
 	}
+
+	if c, ok := debugOverrideCondition(mt, s); ok {
+		r.Status.Conditions = append(r.Status.Conditions, c)
+	}
 	return r, nil
 }
 
+// The links to show in the UI, marking the port-forwards that Tilt is
+// still verifying as pending.
+func toEndpointLinks(endpoints []model.Link, links []v1alpha1.UIResourceLink) []v1alpha1.UIResourceLink {
+	result := ToAPILinks(endpoints)
+	for i, ep := range result {
+		for _, ln := range links {
+			if ln.Kind == v1alpha1.UIResourceLinkKindPortForward && ln.URL == ep.URL && ln.Pending {
+				result[i].Pending = true
+			}
+		}
+	}
+	return result
+}
+
 // TODO(nick): We should build this from the Tiltfile in the apiserver,
 // not the Tiltfile state in EngineState.
 func TiltfileResourceProtoView(name model.ManifestName, ms *store.ManifestState, logStore *logstore.LogStore) *v1alpha1.UIResource {
@@ -314,23 +441,94 @@ func TiltfileResourceProtoView(name model.ManifestName, ms *store.ManifestState,
 			Name: string(name),
 		},
 		Status: v1alpha1.UIResourceStatus{
-			CurrentBuild:  pctfb,
-			BuildHistory:  history,
-			RuntimeStatus: v1alpha1.RuntimeStatusNotApplicable,
-			UpdateStatus:  ms.UpdateStatus(model.TriggerModeAuto),
+			CurrentBuild:     pctfb,
+			BuildHistory:     history,
+			RuntimeStatus:    v1alpha1.RuntimeStatusNotApplicable,
+			UpdateStatus:     ms.UpdateStatus(model.TriggerModeAuto),
+			TiltfileWarnings: ToUITiltfileWarnings(ms.TiltfileWarnings),
 		},
 	}
 	start := metav1.NewMicroTime(ctfb.StartTime)
 	finish := metav1.NewMicroTime(ltfb.FinishTime)
 	if !ctfb.Empty() {
 		tr.Status.PendingBuildSince = start
+
+		// Changes that arrive while the Tiltfile is loading are
+		// queued up for one more load when this one finishes.
+		tr.Status.HasPendingChanges = hasPendingChangesAfter(ms, ctfb.StartTime)
+		tr.Status.Queued = tr.Status.HasPendingChanges
 	} else {
 		tr.Status.LastDeployTime = finish
+
+		// Changes that haven't settled yet.
+		hasPendingChanges, pendingSince := ms.HasPendingChanges()
+		tr.Status.HasPendingChanges = hasPendingChanges
+		if hasPendingChanges {
+			tr.Status.PendingBuildSince = metav1.NewMicroTime(pendingSince)
+		}
 	}
 	return tr
 }
 
-func populateResourceInfoView(mt *store.ManifestTarget, r *v1alpha1.UIResource) error {
+func ToUITiltfileWarnings(warnings []model.TiltfileWarning) []v1alpha1.UITiltfileWarning {
+	var result []v1alpha1.UITiltfileWarning
+	for _, w := range warnings {
+		result = append(result, v1alpha1.UITiltfileWarning{
+			Message:  w.Message,
+			Key:      w.Key,
+			Resource: w.ManifestName.String(),
+			Location: w.Location,
+		})
+	}
+	return result
+}
+
+// Bootstrap tasks aren't resources, but we show them next to resources,
+// so that it's clear what ran before the first build.
+func BootstrapTaskProtoView(task model.BootstrapTaskStatus) *v1alpha1.UIResource {
+	r := &v1alpha1.UIResource{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   task.Name,
+			Labels: map[string]string{model.BootstrapLabel: model.BootstrapLabel},
+		},
+		Status: v1alpha1.UIResourceStatus{
+			LastDeployTime: metav1.NewMicroTime(task.LastRunTime),
+			RuntimeStatus:  v1alpha1.RuntimeStatusNotApplicable,
+			UpdateStatus:   v1alpha1.UpdateStatusOK,
+			BuildHistory:   []v1alpha1.UIBuildTerminated{},
+		},
+	}
+
+	switch {
+	case task.Error != "":
+		r.Status.UpdateStatus = v1alpha1.UpdateStatusError
+		r.Status.BuildHistory = append(r.Status.BuildHistory, v1alpha1.UIBuildTerminated{
+			Error: task.Error,
+		})
+	case task.LastRunTime.IsZero():
+		// An earlier task failed, so this one never ran.
+		r.Status.UpdateStatus = v1alpha1.UpdateStatusPending
+	default:
+		r.Status.BuildHistory = append(r.Status.BuildHistory, v1alpha1.UIBuildTerminated{
+			StartTime:  metav1.NewMicroTime(task.LastRunTime),
+			FinishTime: metav1.NewMicroTime(task.LastRunTime),
+		})
+	}
+	return r
+}
+
+func hasPendingChangesAfter(ms *store.ManifestState, t time.Time) bool {
+	for _, status := range ms.BuildStatuses {
+		for _, changeTime := range status.PendingFileChanges {
+			if changeTime.After(t) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func populateResourceInfoView(mt *store.ManifestTarget, skew k8s.ClockSkew, r *v1alpha1.UIResource) error {
 	r.Status.UpdateStatus = mt.UpdateStatus()
 	r.Status.RuntimeStatus = v1alpha1.RuntimeStatusNotApplicable
 
@@ -353,27 +551,79 @@ func populateResourceInfoView(mt *store.ManifestTarget, r *v1alpha1.UIResource)
 		kState := mt.State.K8sRuntimeState()
 		pod := kState.MostRecentPod()
 		podID := k8s.PodID(pod.Name)
+		statusMessages := pod.Errors
+		diag, ok := kState.PendingPodDiagnostic(pod.Name)
+		if ok && pod.Phase == string(v1.PodPending) {
+			statusMessages = append(append([]string{}, pod.Errors...), diag.Summary)
+		}
 		rK8s := &v1alpha1.UIResourceKubernetes{
 			PodName:            pod.Name,
-			PodCreationTime:    pod.CreatedAt,
+			PodCreationTime:    skew.ToLocalTime(pod.CreatedAt),
 			PodUpdateStartTime: apis.NewTime(kState.UpdateStartTime[k8s.PodID(pod.Name)]),
 			PodStatus:          pod.Status,
-			PodStatusMessage:   strings.Join(pod.Errors, "\n"),
+			PodStatusMessage:   strings.Join(statusMessages, "\n"),
 			AllContainersReady: store.AllPodContainersReady(pod),
 			PodRestarts:        kState.VisiblePodContainerRestarts(podID),
-			DisplayNames:       kState.EntityDisplayNames(),
+			DisplayNames:       kState.EntityDisplayNames(mt.Manifest.K8sTarget().SharedObjectRefs()...),
 		}
 		if podID != "" {
 			rK8s.SpanID = string(k8sconv.SpanIDForPod(mt.Manifest.Name, podID))
 		}
 		r.Status.K8sResourceInfo = rK8s
 		r.Status.RuntimeStatus = v1alpha1.RuntimeStatus(kState.RuntimeStatus())
+		if c, ok := imageDriftCondition(kState); ok {
+			r.Status.Conditions = append(r.Status.Conditions, c)
+		}
 		return nil
 	}
 
 	panic("Unrecognized manifest type (not one of: k8s, DC, local)")
 }
 
+// Warns while the resource is deployed with a debug override,
+// so that nobody mistakes it for the original spec.
+func debugOverrideCondition(mt *store.ManifestTarget, s store.EngineState) (v1alpha1.UIResourceCondition, bool) {
+	if !mt.Manifest.IsK8s() {
+		return v1alpha1.UIResourceCondition{}, false
+	}
+	ka, ok := s.KubernetesApplys[mt.Manifest.K8sTarget().ID().Name.String()]
+	if !ok || ka.Status.DebugOverride == "" {
+		return v1alpha1.UIResourceCondition{}, false
+	}
+
+	return v1alpha1.UIResourceCondition{
+		Type:    v1alpha1.UIResourceDebugOverride,
+		Status:  metav1.ConditionTrue,
+		Reason:  "DebugOverrideOn",
+		Message: fmt.Sprintf("Deployed with a debug override (%s).\nTurn it off to restore the original spec.", ka.Status.DebugOverride),
+	}, true
+}
+
+// Warns when the pods are running different images than Tilt last built.
+func imageDriftCondition(kState store.K8sRuntimeState) (v1alpha1.UIResourceCondition, bool) {
+	if len(kState.ImageDrifts) == 0 {
+		return v1alpha1.UIResourceCondition{}, false
+	}
+
+	since := kState.ImageDrifts[0].Since
+	lines := make([]string, 0, len(kState.ImageDrifts)+1)
+	for _, d := range kState.ImageDrifts {
+		if d.Since.Before(since) {
+			since = d.Since
+		}
+		lines = append(lines, fmt.Sprintf("%s/%s: %s", d.Pod, d.Container, d.Message()))
+	}
+	lines = append(lines, "Trigger an update to re-deploy.")
+
+	return v1alpha1.UIResourceCondition{
+		Type:               v1alpha1.UIResourceImageDrift,
+		Status:             metav1.ConditionTrue,
+		LastTransitionTime: apis.NewMicroTime(since),
+		Reason:             "RunningImageDiffers",
+		Message:            strings.Join(lines, "\n"),
+	}, true
+}
+
 func LogSegmentToEvent(seg *proto_webview.LogSegment, spans map[string]*proto_webview.LogSpan) store.LogAction {
 	span, ok := spans[seg.SpanId]
 	if !ok {
@@ -381,9 +631,11 @@ func LogSegmentToEvent(seg *proto_webview.LogSegment, spans map[string]*proto_we
 		return store.LogAction{}
 	}
 
-	// TODO(maia): actually get level (just spoofing for now)
-	spoofedLevel := logger.InfoLvl
-	return store.NewLogAction(model.ManifestName(span.ManifestName), logstore.SpanID(seg.SpanId), spoofedLevel, seg.Fields, []byte(seg.Text))
+	level := logger.LevelFromProtoID(int32(seg.Level))
+	if level == logger.NoneLvl {
+		level = logger.InfoLvl
+	}
+	return store.NewLogAction(model.ManifestName(span.ManifestName), logstore.SpanID(seg.SpanId), level, seg.Fields, []byte(seg.Text))
 }
 
 func holdToWaiting(hold store.Hold) *v1alpha1.UIResourceStateWaiting {
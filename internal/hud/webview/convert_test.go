@@ -1,10 +1,12 @@
 package webview
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/tilt-dev/tilt/internal/store/k8sconv"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
@@ -12,14 +14,19 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/tilt-dev/tilt/internal/container"
+	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
 	ctrltiltfile "github.com/tilt-dev/tilt/internal/controllers/core/tiltfile"
 	"github.com/tilt-dev/tilt/internal/k8s"
 	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
 	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/store/portforwards"
 	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
 	"github.com/tilt-dev/tilt/internal/timecmp"
+	"github.com/tilt-dev/tilt/pkg/apis"
 	"github.com/tilt-dev/tilt/pkg/logger"
 	"github.com/tilt-dev/tilt/pkg/model"
+	"github.com/tilt-dev/tilt/pkg/model/logstore"
 	proto_webview "github.com/tilt-dev/tilt/pkg/webview"
 )
 
@@ -29,7 +36,7 @@ func completeProtoView(t *testing.T, s store.EngineState) *proto_webview.View {
 	st := store.NewTestingStore()
 	st.SetState(s)
 
-	view, err := LogUpdate(st, 0)
+	view, err := LogUpdate(st, 0, logstore.LevelFilter{})
 	require.NoError(t, err)
 
 	view.UiSession = ToUISession(s)
@@ -125,6 +132,65 @@ func TestStateToWebViewLinksAndPortForwards(t *testing.T) {
 	assert.Equal(t, expected, res.EndpointLinks)
 }
 
+func TestStateToWebViewPortForwardsPendingVerification(t *testing.T) {
+	m := model.Manifest{
+		Name: "foo",
+	}.WithDeployTarget(model.K8sTarget{
+		KubernetesApplySpec: v1alpha1.KubernetesApplySpec{
+			PortForwardTemplateSpec: &v1alpha1.PortForwardTemplateSpec{
+				Forwards: []v1alpha1.Forward{
+					{LocalPort: 8000, ContainerPort: 5000, Verify: &v1alpha1.ForwardVerify{HTTPPath: "/healthz"}},
+					{LocalPort: 8001, ContainerPort: 5001},
+				},
+			},
+		},
+	})
+	state := newState([]model.Manifest{m})
+	v := completeProtoView(t, *state)
+
+	expected := []v1alpha1.UIResourceLink{
+		v1alpha1.UIResourceLink{URL: "http://localhost:8000/", Pending: true},
+		v1alpha1.UIResourceLink{URL: "http://localhost:8001/"},
+	}
+	res, _ := findResource(m.Name, v)
+	assert.Equal(t, expected, res.EndpointLinks)
+}
+
+func TestStateToWebViewLinksFollowForwardStatus(t *testing.T) {
+	m := model.Manifest{
+		Name: "foo",
+	}.WithDeployTarget(model.K8sTarget{
+		KubernetesApplySpec: v1alpha1.KubernetesApplySpec{
+			PortForwardTemplateSpec: &v1alpha1.PortForwardTemplateSpec{
+				Forwards: []v1alpha1.Forward{{LocalPort: 8000, ContainerPort: 5000}},
+			},
+		},
+	})
+	state := newState([]model.Manifest{m})
+
+	res, _ := findResource(m.Name, completeProtoView(t, *state))
+	assert.Equal(t, []v1alpha1.UIResourceLink{
+		{URL: "http://localhost:8000/", Kind: v1alpha1.UIResourceLinkKindPortForward},
+	}, res.Links)
+
+	portforwards.HandlePortForwardUpsertAction(state, portforwards.NewPortForwardUpsertAction(&v1alpha1.PortForward{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "foo-pod",
+			Annotations: map[string]string{v1alpha1.AnnotationManifest: "foo"},
+		},
+		Status: v1alpha1.PortForwardStatus{
+			ForwardStatuses: []v1alpha1.ForwardStatus{
+				{LocalPort: 8000, ContainerPort: 5000, StartedAt: apis.NowMicro()},
+			},
+		},
+	}))
+
+	res, _ = findResource(m.Name, completeProtoView(t, *state))
+	assert.Equal(t, []v1alpha1.UIResourceLink{
+		{URL: "http://localhost:8000/", Kind: v1alpha1.UIResourceLinkKindPortForward, Ready: true},
+	}, res.Links)
+}
+
 func TestStateToWebViewLocalResourceLink(t *testing.T) {
 	m := model.Manifest{
 		Name: "foo",
@@ -191,6 +257,70 @@ func TestStateToViewTiltfileLog(t *testing.T) {
 	assert.Equal(t, "(Tiltfile)", string(v.LogList.Spans[string(spanID)].ManifestName))
 }
 
+func TestTiltfilePendingChanges(t *testing.T) {
+	es := newState([]model.Manifest{})
+	ms := es.TiltfileStates[model.MainTiltfileManifestName]
+	targetID := model.TargetID{Type: model.TargetTypeConfigs, Name: model.TargetName(model.MainTiltfileManifestName)}
+
+	// Changes that are still settling.
+	changeTime := time.Now()
+	ms.AddPendingFileChange(targetID, "Tiltfile", changeTime)
+	r := TiltfileResourceProtoView(model.MainTiltfileManifestName, ms, es.LogStore)
+	assert.True(t, r.Status.HasPendingChanges)
+	assert.False(t, r.Status.Queued)
+	assert.True(t, changeTime.Equal(r.Status.PendingBuildSince.Time))
+
+	// The Tiltfile is loading, and has consumed the pending changes.
+	ms.CurrentBuild = model.BuildRecord{StartTime: changeTime.Add(time.Second)}
+	r = TiltfileResourceProtoView(model.MainTiltfileManifestName, ms, es.LogStore)
+	assert.NotNil(t, r.Status.CurrentBuild)
+	assert.False(t, r.Status.HasPendingChanges)
+	assert.False(t, r.Status.Queued)
+
+	// More changes came in during the load.
+	ms.AddPendingFileChange(targetID, "lib.star", changeTime.Add(2*time.Second))
+	r = TiltfileResourceProtoView(model.MainTiltfileManifestName, ms, es.LogStore)
+	assert.True(t, r.Status.HasPendingChanges)
+	assert.True(t, r.Status.Queued)
+}
+
+func TestBootstrapTasks(t *testing.T) {
+	es := newState([]model.Manifest{fooManifest})
+	lastRun := time.Now()
+	es.BootstrapTasks = []model.BootstrapTaskStatus{
+		{Name: "certs", LastRunTime: lastRun, Skipped: true},
+		{Name: "secret", Error: "exit status 1"},
+		{Name: "later"},
+	}
+
+	v := completeProtoView(t, *es)
+
+	for _, r := range v.UiResources {
+		if r.Name == "certs" {
+			assert.Equal(t, map[string]string{"bootstrap": "bootstrap"}, r.Labels)
+		}
+	}
+
+	certs, ok := findResource("certs", v)
+	require.True(t, ok)
+	assert.Equal(t, v1alpha1.UpdateStatusOK, certs.UpdateStatus)
+	assert.True(t, lastRun.Equal(certs.LastDeployTime.Time))
+
+	secret, ok := findResource("secret", v)
+	require.True(t, ok)
+	assert.Equal(t, v1alpha1.UpdateStatusError, secret.UpdateStatus)
+	assert.Equal(t, "exit status 1", secret.BuildHistory[0].Error)
+
+	later, ok := findResource("later", v)
+	require.True(t, ok)
+	assert.Equal(t, v1alpha1.UpdateStatusPending, later.UpdateStatus)
+
+	// Bootstrap tasks come after the Tiltfile, and before resources.
+	foo, ok := findResource("foo", v)
+	require.True(t, ok)
+	assert.Less(t, certs.Order, foo.Order)
+}
+
 func TestNeedsNudgeSet(t *testing.T) {
 	state := newState(nil)
 
@@ -236,6 +366,86 @@ func TestFeatureFlags(t *testing.T) {
 	})
 }
 
+func TestReadOnlyFeatureFlag(t *testing.T) {
+	state := newState(nil)
+	state.Features = map[string]bool{"foo_feature": true}
+	state.ReadOnly = true
+
+	v := completeProtoView(t, *state)
+	assert.Equal(t, v.UiSession.Status.FeatureFlags, []v1alpha1.UIFeatureFlag{
+		v1alpha1.UIFeatureFlag{Name: "foo_feature", Value: true},
+		v1alpha1.UIFeatureFlag{Name: ReadOnlyFeatureFlag, Value: true},
+	})
+}
+
+func TestImageRegistry(t *testing.T) {
+	state := newState(nil)
+	v := completeProtoView(t, *state)
+	assert.Nil(t, v.UiSession.Status.ImageRegistry)
+
+	reg, err := container.NewRegistryWithHostFromCluster("localhost:5000", "registry:5000")
+	require.NoError(t, err)
+	state.ImageRegistry = model.ImageRegistry{Registry: reg, Source: model.ImageRegistrySourceDiscovered}
+
+	v = completeProtoView(t, *state)
+	assert.Equal(t, &v1alpha1.UIImageRegistry{
+		Host:            "localhost:5000",
+		HostFromCluster: "registry:5000",
+		Source:          "discovered",
+	}, v.UiSession.Status.ImageRegistry)
+}
+
+func TestMemoryUsage(t *testing.T) {
+	state := newState(nil)
+	v := completeProtoView(t, *state)
+	assert.Nil(t, v.UiSession.Status.MemoryUsage)
+
+	now := time.Now()
+	state.MemoryReport = store.MemoryReport{
+		Time:       now,
+		TotalBytes: 300,
+		HeapBytes:  200,
+		Accounting: store.MemoryAccounting{
+			Consumers: []store.MemoryConsumer{{Name: "logs", Bytes: 100}, {Name: "build history", Bytes: 10}},
+		},
+	}
+
+	v = completeProtoView(t, *state)
+	usage := v.UiSession.Status.MemoryUsage
+	require.NotNil(t, usage)
+	timecmp.RequireTimeEqual(t, now, usage.SampleTime)
+	assert.Equal(t, int64(300), usage.TotalBytes)
+	assert.Equal(t, int64(200), usage.HeapBytes)
+	assert.Equal(t, []v1alpha1.UIMemoryConsumer{{Name: "logs", Bytes: 100}, {Name: "build history", Bytes: 10}},
+		usage.Consumers)
+}
+
+func TestClusterConnection(t *testing.T) {
+	state := newState(nil)
+	v := completeProtoView(t, *state)
+	assert.Nil(t, v.UiSession.Status.ClusterConnection)
+
+	// e.g., a credential plugin that's waiting for the user to log in again.
+	now := time.Now()
+	state.ClusterConnection = store.ClusterConnection{
+		Status: store.ClusterConnectionDegraded,
+		Error:  "credential plugin aws timed out after 30s",
+		Since:  now,
+	}
+
+	v = completeProtoView(t, *state)
+	conn := v.UiSession.Status.ClusterConnection
+	require.NotNil(t, conn)
+	assert.Equal(t, "degraded", conn.Status)
+	assert.Equal(t, "credential plugin aws timed out after 30s", conn.Error)
+	timecmp.RequireTimeEqual(t, now, conn.Since)
+
+	state.ClusterConnection = store.ClusterConnection{Status: store.ClusterConnectionConnected, Since: now}
+	v = completeProtoView(t, *state)
+	assert.Equal(t, "connected", v.UiSession.Status.ClusterConnection.Status)
+	assert.Equal(t, "", v.UiSession.Status.ClusterConnection.Error)
+}
+
 func TestReadinessCheckFailing(t *testing.T) {
 	m := model.Manifest{
 		Name: "foo",
@@ -261,6 +471,93 @@ func TestReadinessCheckFailing(t *testing.T) {
 	require.Equal(t, v1alpha1.RuntimeStatusPending, v1alpha1.RuntimeStatus(rv.RuntimeStatus))
 }
 
+func TestImageDriftCondition(t *testing.T) {
+	m := model.Manifest{
+		Name: "foo",
+	}.WithDeployTarget(model.K8sTarget{})
+	state := newState([]model.Manifest{m})
+	since := time.Now().Add(-time.Minute)
+	state.ManifestTargets[m.Name].State.RuntimeState = store.K8sRuntimeState{
+		Pods: map[k8s.PodID]*v1alpha1.Pod{
+			"pod-1": {Name: "pod-1", Status: "Running", Phase: "Running"},
+		},
+		ImageDrifts: []store.ImageDrift{
+			{
+				Pod:       "pod-1",
+				Container: "foo",
+				Running:   "sha256:0123456789ab",
+				Expected:  container.MustParseNamedTagged("gcr.io/foo:tilt-4f2a4f2a4f2a4f2a"),
+				Since:     since,
+			},
+		},
+	}
+
+	v := completeProtoView(t, *state)
+	rv, ok := findResource(m.Name, v)
+	require.True(t, ok)
+	require.Len(t, rv.Conditions, 1)
+
+	c := rv.Conditions[0]
+	assert.Equal(t, v1alpha1.UIResourceImageDrift, c.Type)
+	assert.Equal(t, metav1.ConditionTrue, c.Status)
+	assert.Equal(t, apis.NewMicroTime(since), c.LastTransitionTime)
+	assert.Equal(t, "pod-1/foo: running image differs from last Tilt build: sha256:0123456789ab vs expected :tilt-4f2a4f2a4f2a4f2a\n"+
+		"Trigger an update to re-deploy.", c.Message)
+}
+
+func TestDebugOverrideCondition(t *testing.T) {
+	m := model.Manifest{
+		Name: "foo",
+	}.WithDeployTarget(model.K8sTarget{Name: "foo"})
+	state := newState([]model.Manifest{m})
+	ka := &v1alpha1.KubernetesApply{ObjectMeta: metav1.ObjectMeta{Name: "foo"}}
+	state.KubernetesApplys["foo"] = ka
+
+	v := completeProtoView(t, *state)
+	rv, ok := findResource(m.Name, v)
+	require.True(t, ok)
+	assert.Empty(t, rv.Conditions)
+
+	ka.Status.DebugOverride = "readiness probe removed"
+	v = completeProtoView(t, *state)
+	rv, ok = findResource(m.Name, v)
+	require.True(t, ok)
+	require.Len(t, rv.Conditions, 1)
+	c := rv.Conditions[0]
+	assert.Equal(t, v1alpha1.UIResourceDebugOverride, c.Type)
+	assert.Equal(t, metav1.ConditionTrue, c.Status)
+	assert.Equal(t, "Deployed with a debug override (readiness probe removed).\n"+
+		"Turn it off to restore the original spec.", c.Message)
+
+	// Restoring the original spec clears the condition.
+	ka.Status.DebugOverride = ""
+	v = completeProtoView(t, *state)
+	rv, ok = findResource(m.Name, v)
+	require.True(t, ok)
+	assert.Empty(t, rv.Conditions)
+}
+
+func TestTiltfileWarnings(t *testing.T) {
+	state := newState([]model.Manifest{fooManifest})
+	state.ManifestTargets["foo"].State.TiltfileWarnings = []model.TiltfileWarning{
+		{Key: "foo:ports", Message: "check your ports", ManifestName: "foo", Location: "Tiltfile:3:5"},
+	}
+	state.MainTiltfileState().TiltfileWarnings = []model.TiltfileWarning{
+		{Key: "metrics", Message: "deprecated"},
+	}
+
+	v := completeProtoView(t, *state)
+	rv, ok := findResource("foo", v)
+	require.True(t, ok)
+	assert.Equal(t, []v1alpha1.UITiltfileWarning{
+		{Message: "check your ports", Key: "foo:ports", Resource: "foo", Location: "Tiltfile:3:5"},
+	}, rv.TiltfileWarnings)
+
+	rv, ok = findResource(model.MainTiltfileManifestName, v)
+	require.True(t, ok)
+	assert.Equal(t, []v1alpha1.UITiltfileWarning{{Message: "deprecated", Key: "metrics"}}, rv.TiltfileWarnings)
+}
+
 func TestLocalResource(t *testing.T) {
 	cmd := model.Cmd{
 		Argv: []string{"make", "test"},
@@ -328,6 +625,30 @@ func TestBuildHistory(t *testing.T) {
 	}
 }
 
+func TestAttention(t *testing.T) {
+	foo := model.Manifest{Name: "foo"}.WithDeployTarget(model.K8sTarget{})
+	bar := model.Manifest{Name: "bar"}.WithDeployTarget(model.K8sTarget{})
+	state := newState([]model.Manifest{foo, bar})
+	state.ManifestTargets[bar.Name].State.BuildHistory = []model.BuildRecord{{
+		StartTime:  time.Now().Add(-time.Minute),
+		FinishTime: time.Now().Add(-time.Minute),
+		Error:      fmt.Errorf("compile error"),
+	}}
+
+	v := completeProtoView(t, *state)
+	require.Len(t, v.UiResources, 3)
+
+	attention := make(map[string][2]int32)
+	for _, r := range v.UiResources {
+		attention[r.Name] = [2]int32{r.Status.AttentionScore, r.Status.AttentionOrder}
+	}
+	assert.Equal(t, map[string][2]int32{
+		"(Tiltfile)": {0, 2},
+		"foo":        {0, 3},
+		"bar":        {int32(store.AttentionWeightCurrentError), 1},
+	}, attention)
+}
+
 func TestSpecs(t *testing.T) {
 	luSpec := v1alpha1.LiveUpdateSpec{
 		BasePath: ".",
@@ -421,6 +742,33 @@ func TestDisableResourceStatus(t *testing.T) {
 	}
 }
 
+func TestDisableResourceStatusHistory(t *testing.T) {
+	m1 := model.Manifest{Name: "m1"}.WithDeployTarget(model.LocalTarget{})
+	state := newState([]model.Manifest{m1})
+
+	cm := &v1alpha1.ConfigMap{}
+	start := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+	configmap.RecordDisableChange(cm, false, v1alpha1.DisableChangeSourceTiltfile, start)
+	configmap.RecordDisableChange(cm, true, v1alpha1.DisableChangeSourceCLI, start.Add(time.Minute))
+	state.ConfigMaps = map[string]*v1alpha1.ConfigMap{"m1-disable": cm}
+
+	disableSources := map[string][]v1alpha1.DisableSource{
+		"m1": {{ConfigMap: &v1alpha1.ConfigMapDisableSource{Name: "m1-disable", Key: "isDisabled"}}},
+	}
+
+	uiResources, err := ToUIResourceList(*state, disableSources)
+	require.NoError(t, err)
+
+	ds := uiResources[1].Status.DisableStatus
+	assert.Equal(t, int32(1), ds.DisabledCount)
+	assert.Equal(t, v1alpha1.DisableChangeSourceCLI, ds.Source)
+	require.Len(t, ds.Transitions, 2)
+	assert.Equal(t, v1alpha1.DisableChangeSourceTiltfile, ds.Transitions[0].Source)
+	assert.False(t, ds.Transitions[0].Disabled)
+	assert.True(t, ds.Transitions[1].Disabled)
+	assert.True(t, ds.Transitions[1].Time.Time.Equal(start.Add(time.Minute)))
+}
+
 func findResource(n model.ManifestName, view *proto_webview.View) (v1alpha1.UIResourceStatus, bool) {
 	for _, r := range view.UiResources {
 		if r.Name == n.String() {
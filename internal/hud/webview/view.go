@@ -82,9 +82,50 @@ func ToBuildTerminated(br model.BuildRecord, logStore *logstore.LogStore) v1alph
 		FinishTime:     metav1.NewMicroTime(br.FinishTime),
 		IsCrashRebuild: br.Reason.IsCrashOnly(),
 		SpanID:         string(br.SpanID),
+		Latency:        ToBuildLatency(br.Latency),
+		ImageCache:     ToImageBuildCache(br.ImageCache),
 	}
 }
 
+func ToBuildLatency(l model.BuildLatency) *v1alpha1.UIBuildLatency {
+	if l.Empty() {
+		return nil
+	}
+
+	phases := make([]v1alpha1.UIBuildLatencyPhase, len(l.Phases))
+	for i, p := range l.Phases {
+		phases[i] = v1alpha1.UIBuildLatencyPhase{
+			Name:     p.Name,
+			Duration: metav1.Duration{Duration: p.Duration},
+		}
+	}
+	return &v1alpha1.UIBuildLatency{
+		Total:  metav1.Duration{Duration: l.Total},
+		Phases: phases,
+	}
+}
+
+func ToImageBuildCache(caches []model.ImageBuildCache) []v1alpha1.UIImageBuildCache {
+	if len(caches) == 0 {
+		return nil
+	}
+
+	result := make([]v1alpha1.UIImageBuildCache, len(caches))
+	for i, c := range caches {
+		steps := make([]v1alpha1.UIBuildCacheStep, len(c.Steps))
+		for j, step := range c.Steps {
+			steps[j] = v1alpha1.UIBuildCacheStep{
+				Position: step.Position,
+				Text:     step.Text,
+				Cached:   step.Cached,
+				Duration: metav1.Duration{Duration: step.Duration},
+			}
+		}
+		result[i] = v1alpha1.UIImageBuildCache{Image: c.Image, Target: c.Target, Steps: steps}
+	}
+	return result
+}
+
 func ToBuildsTerminated(brs []model.BuildRecord, logStore *logstore.LogStore) []v1alpha1.UIBuildTerminated {
 	ret := make([]v1alpha1.UIBuildTerminated, len(brs))
 	for i, br := range brs {
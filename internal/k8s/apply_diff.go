@@ -0,0 +1,251 @@
+package k8s
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// The biggest object (as JSON) whose content we keep for the next diff.
+// For bigger objects we only keep a hash, so we can tell that they
+// changed, but not how.
+const appliedObjectContentLimit = 64 * 1024
+
+// The most field changes we report for one apply.
+const applyDiffFieldLimit = 50
+
+// The longest rendering of a field value we report.
+const applyDiffValueLimit = 80
+
+// The objects we sent in one apply, so that we can tell what the next
+// apply changes.
+//
+// Secret values are only kept as hashes.
+type AppliedSnapshot struct {
+	// In the order we applied them.
+	keys    []string
+	objects map[string]appliedObject
+}
+
+type appliedObject struct {
+	// The kind and name, e.g., Deployment/fe
+	name     string
+	isSecret bool
+	hash     string
+
+	// nil if the object was too big to keep.
+	content map[string]interface{}
+}
+
+func (s AppliedSnapshot) Len() int {
+	return len(s.keys)
+}
+
+func SnapshotApplied(entities []K8sEntity) (AppliedSnapshot, error) {
+	s := AppliedSnapshot{objects: make(map[string]appliedObject, len(entities))}
+	for _, e := range entities {
+		obj, err := EntityToUnstructured(e)
+		if err != nil {
+			return AppliedSnapshot{}, err
+		}
+
+		content := normalizeForDiff(obj)
+		isSecret := obj.GetKind() == "Secret" && obj.GetAPIVersion() == "v1"
+		if isSecret {
+			hashSecretValues(content)
+		}
+
+		b, err := json.Marshal(content)
+		if err != nil {
+			return AppliedSnapshot{}, err
+		}
+		sum := sha256.Sum256(b)
+		if len(b) > appliedObjectContentLimit {
+			content = nil
+		}
+
+		name := obj.GetName()
+		if name == "" {
+			name = obj.GetGenerateName()
+		}
+		gk := obj.GroupVersionKind().GroupKind()
+		key := strings.Join([]string{gk.String(), obj.GetNamespace(), name}, "/")
+		if _, ok := s.objects[key]; !ok {
+			s.keys = append(s.keys, key)
+		}
+		s.objects[key] = appliedObject{
+			name:     fmt.Sprintf("%s/%s", gk.Kind, name),
+			isSecret: isSecret,
+			hash:     hex.EncodeToString(sum[:]),
+			content:  content,
+		}
+	}
+	return s, nil
+}
+
+// Compares the objects in two applies, field by field.
+//
+// Never includes Secret values. Stops listing fields after applyDiffFieldLimit.
+func DiffApplied(prev, next AppliedSnapshot) model.K8sApplyDiff {
+	var diff model.K8sApplyDiff
+	budget := applyDiffFieldLimit
+	for _, key := range next.keys {
+		n := next.objects[key]
+		p, ok := prev.objects[key]
+		if !ok {
+			diff.Objects = append(diff.Objects, model.K8sObjectDiff{Name: n.name, Status: model.K8sObjectAdded})
+			continue
+		}
+		if p.hash == n.hash {
+			diff.Unchanged++
+			continue
+		}
+
+		od := model.K8sObjectDiff{Name: n.name, Status: model.K8sObjectChanged}
+		if p.content == nil || n.content == nil {
+			od.TooLarge = true
+			diff.Objects = append(diff.Objects, od)
+			continue
+		}
+
+		var fields []FieldDiff
+		for _, f := range diffMaps("", p.content, n.content) {
+			// Tilt changes this whenever the pod template changes,
+			// so it would only repeat the real change.
+			if !strings.HasSuffix(f.Path, "labels."+TiltPodTemplateHashLabel) {
+				fields = append(fields, f)
+			}
+		}
+		if len(fields) == 0 {
+			diff.Unchanged++
+			continue
+		}
+		for _, f := range fields {
+			if budget == 0 {
+				diff.TruncatedFields++
+				continue
+			}
+			budget--
+			od.Fields = append(od.Fields, toModelFieldDiff(f, n.isSecret))
+		}
+		diff.Objects = append(diff.Objects, od)
+	}
+
+	for _, key := range prev.keys {
+		if _, ok := next.objects[key]; !ok {
+			diff.Objects = append(diff.Objects, model.K8sObjectDiff{Name: prev.objects[key].name, Status: model.K8sObjectRemoved})
+		}
+	}
+	return diff
+}
+
+// Renders a diff as log lines, one per object, e.g.,
+//
+//	Deployment/fe: spec.replicas: 1→2; spec.template.spec.containers[0].image: abc→def
+func FormatApplyDiff(diff model.K8sApplyDiff) []string {
+	var lines []string
+	for _, od := range diff.Objects {
+		switch {
+		case od.Status != model.K8sObjectChanged:
+			lines = append(lines, fmt.Sprintf("%s: %s", od.Name, od.Status))
+		case od.TooLarge:
+			lines = append(lines, fmt.Sprintf("%s: changed (too large to diff)", od.Name))
+		case len(od.Fields) == 0:
+			lines = append(lines, fmt.Sprintf("%s: changed (diff truncated)", od.Name))
+		default:
+			fields := make([]string, 0, len(od.Fields))
+			for _, f := range od.Fields {
+				fields = append(fields, formatFieldDiff(f))
+			}
+			lines = append(lines, fmt.Sprintf("%s: %s", od.Name, strings.Join(fields, "; ")))
+		}
+	}
+
+	if diff.TruncatedFields > 0 {
+		lines = append(lines, fmt.Sprintf("…and %d more field changes (diff truncated)", diff.TruncatedFields))
+	}
+	if diff.Unchanged == 1 {
+		lines = append(lines, "1 unchanged object")
+	} else if diff.Unchanged > 1 {
+		lines = append(lines, fmt.Sprintf("%d unchanged objects", diff.Unchanged))
+	}
+	return lines
+}
+
+func formatFieldDiff(f model.K8sFieldDiff) string {
+	switch {
+	case f.Old == "":
+		return fmt.Sprintf("%s: +%s", f.Path, f.New)
+	case f.New == "":
+		return fmt.Sprintf("%s: -%s", f.Path, f.Old)
+	default:
+		return fmt.Sprintf("%s: %s→%s", f.Path, f.Old, f.New)
+	}
+}
+
+func toModelFieldDiff(f FieldDiff, isSecret bool) model.K8sFieldDiff {
+	result := model.K8sFieldDiff{Path: f.Path}
+	if isSecret && isSecretValuePath(f.Path) {
+		switch f.Type {
+		case FieldAdded:
+			result.New = maskedSecretValue
+		case FieldRemoved:
+			result.Old = maskedSecretValue
+		default:
+			result.Old, result.New = maskedSecretValue, maskedChangedSecretValue
+		}
+		return result
+	}
+
+	if f.Type != FieldAdded {
+		result.Old = renderDiffValue(f.Old)
+	}
+	if f.Type != FieldRemoved {
+		result.New = renderDiffValue(f.New)
+	}
+	return result
+}
+
+func isSecretValuePath(path string) bool {
+	for _, field := range []string{"data", "stringData"} {
+		if path == field || strings.HasPrefix(path, field+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func renderDiffValue(v interface{}) string {
+	s, ok := v.(string)
+	if !ok {
+		b, err := json.Marshal(v)
+		if err != nil {
+			s = fmt.Sprintf("%v", v)
+		} else {
+			s = string(b)
+		}
+	}
+	if s == "" {
+		s = `""`
+	}
+	if len(s) > applyDiffValueLimit {
+		s = s[:applyDiffValueLimit] + "…"
+	}
+	return s
+}
+
+// Replaces Secret values with their hashes, so that we can tell when they
+// change without keeping them around.
+func hashSecretValues(content map[string]interface{}) {
+	for _, field := range []string{"data", "stringData"} {
+		values, _ := content[field].(map[string]interface{})
+		for key, value := range values {
+			sum := sha256.Sum256([]byte(fmt.Sprintf("%v", value)))
+			values[key] = hex.EncodeToString(sum[:])
+		}
+	}
+}
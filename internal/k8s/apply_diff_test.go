@@ -0,0 +1,128 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestDiffAppliedImageOnly(t *testing.T) {
+	prev := mustSnapshot(t, testyaml.SanchoYAML, testyaml.DoggosServiceYaml)
+	next := mustSnapshot(t,
+		strings.Replace(testyaml.SanchoYAML, "sancho\n        env", "sancho:tilt-123\n        env", 1),
+		testyaml.DoggosServiceYaml)
+
+	diff := DiffApplied(prev, next)
+	assert.Equal(t, model.K8sApplyDiff{
+		Objects: []model.K8sObjectDiff{
+			{
+				Name:   "Deployment/sancho",
+				Status: model.K8sObjectChanged,
+				Fields: []model.K8sFieldDiff{
+					{
+						Path: "spec.template.spec.containers[0].image",
+						Old:  "gcr.io/some-project-162817/sancho",
+						New:  "gcr.io/some-project-162817/sancho:tilt-123",
+					},
+				},
+			},
+		},
+		Unchanged: 1,
+	}, diff)
+
+	assert.Equal(t, []string{
+		"Deployment/sancho: spec.template.spec.containers[0].image: gcr.io/some-project-162817/sancho→gcr.io/some-project-162817/sancho:tilt-123",
+		"1 unchanged object",
+	}, FormatApplyDiff(diff))
+}
+
+func TestDiffAppliedSeveralFields(t *testing.T) {
+	prev := mustSnapshot(t, testyaml.SanchoYAML)
+	next := mustSnapshot(t, strings.Replace(testyaml.SanchoYAML, "replicas: 1", "replicas: 2", 1))
+
+	diff := DiffApplied(prev, next)
+	assert.Equal(t, []string{"Deployment/sancho: spec.replicas: 1→2"}, FormatApplyDiff(diff))
+}
+
+func TestDiffAppliedAddedAndRemovedObjects(t *testing.T) {
+	prev := mustSnapshot(t, testyaml.SanchoYAML, testyaml.DoggosServiceYaml)
+	next := mustSnapshot(t, testyaml.CatsServiceYaml, testyaml.SanchoYAML)
+
+	diff := DiffApplied(prev, next)
+	assert.Equal(t, []string{
+		"Service/cats: added",
+		"Service/doggos: removed",
+		"1 unchanged object",
+	}, FormatApplyDiff(diff))
+}
+
+func TestDiffAppliedMasksSecrets(t *testing.T) {
+	prev := mustSnapshot(t, testyaml.SecretYaml)
+	next := mustSnapshot(t, strings.Replace(
+		testyaml.SecretYaml, "password: MWYyZDFlMmU2N2Rm", "password: bmV3LXBhc3N3b3Jk\n  token: dG9rZW4=", 1))
+
+	diff := DiffApplied(prev, next)
+	lines := FormatApplyDiff(diff)
+	assert.Equal(t, []string{
+		"Secret/mysecret: data.password: ***→*** (changed); data.token: +***",
+	}, lines)
+
+	for _, secret := range []string{"MWYyZDFlMmU2N2Rm", "bmV3LXBhc3N3b3Jk", "dG9rZW4="} {
+		assert.NotContains(t, fmt.Sprintf("%v", diff), secret)
+		for _, obj := range next.objects {
+			assert.NotContains(t, fmt.Sprintf("%v", obj.content), secret)
+		}
+	}
+}
+
+func TestDiffAppliedTruncatesLargeDiffs(t *testing.T) {
+	var prevLabels, nextLabels strings.Builder
+	for i := 0; i < applyDiffFieldLimit+5; i++ {
+		prevLabels.WriteString(fmt.Sprintf("    label%d: a\n", i))
+		nextLabels.WriteString(fmt.Sprintf("    label%d: b\n", i))
+	}
+	cm := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: big
+  labels:
+%s`
+
+	diff := DiffApplied(mustSnapshot(t, fmt.Sprintf(cm, prevLabels.String())),
+		mustSnapshot(t, fmt.Sprintf(cm, nextLabels.String())))
+	require.Len(t, diff.Objects, 1)
+	assert.Len(t, diff.Objects[0].Fields, applyDiffFieldLimit)
+	assert.Equal(t, 5, diff.TruncatedFields)
+
+	lines := FormatApplyDiff(diff)
+	assert.Equal(t, "…and 5 more field changes (diff truncated)", lines[len(lines)-1])
+}
+
+func TestDiffAppliedTooLargeToKeep(t *testing.T) {
+	cm := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: huge
+data:
+  blob: %s
+`
+	prev := mustSnapshot(t, fmt.Sprintf(cm, strings.Repeat("a", appliedObjectContentLimit)))
+	next := mustSnapshot(t, fmt.Sprintf(cm, strings.Repeat("b", appliedObjectContentLimit)))
+
+	diff := DiffApplied(prev, next)
+	assert.Equal(t, []string{"ConfigMap/huge: changed (too large to diff)"}, FormatApplyDiff(diff))
+}
+
+func mustSnapshot(t *testing.T, yamls ...string) AppliedSnapshot {
+	entities, err := ParseYAMLFromString(strings.Join(yamls, "\n---\n"))
+	require.NoError(t, err)
+	s, err := SnapshotApplied(entities)
+	require.NoError(t, err)
+	return s
+}
@@ -0,0 +1,55 @@
+package k8s
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"time"
+)
+
+// A record of one apply (or delete) that Tilt ran against the cluster,
+// so that users can see exactly what Tilt did.
+//
+// We only keep a hash and the size of the YAML we sent, not the YAML itself,
+// so that recording stays cheap for big resources.
+type ApplyInvocation struct {
+	// The equivalent kubectl command, e.g., `kubectl --context kind-kind apply -f -`.
+	// For resources with a custom apply command, the command itself.
+	Command string `json:"command"`
+
+	// The namespaces of the objects we sent. Objects without a namespace
+	// (which go to the default namespace) aren't listed.
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// The sha256 of the YAML we sent, and its size in bytes.
+	YAMLHash  string `json:"yamlHash,omitempty"`
+	YAMLBytes int    `json:"yamlBytes,omitempty"`
+
+	// With --verbose-apply, the temp file where we wrote the YAML we sent.
+	YAMLPath string `json:"yamlPath,omitempty"`
+
+	StartTime time.Time     `json:"startTime"`
+	Duration  time.Duration `json:"duration"`
+
+	// If the apply failed, the error. For custom apply commands,
+	// includes the end of stderr.
+	Error string `json:"error,omitempty"`
+}
+
+// Fills in the hash, size, and namespaces of the YAML we're about to send.
+func (i *ApplyInvocation) SetYAML(yaml string, entities []K8sEntity) {
+	sum := sha256.Sum256([]byte(yaml))
+	i.YAMLHash = hex.EncodeToString(sum[:])
+	i.YAMLBytes = len(yaml)
+
+	seen := make(map[string]bool)
+	for _, e := range entities {
+		ns := e.Meta().GetNamespace()
+		if ns == "" || seen[ns] {
+			continue
+		}
+		seen[ns] = true
+		i.Namespaces = append(i.Namespaces, ns)
+	}
+	sort.Strings(i.Namespaces)
+}
@@ -87,6 +87,10 @@ type Client interface {
 	Delete(ctx context.Context, entities []K8sEntity) error
 
 	GetMetaByReference(ctx context.Context, ref v1.ObjectReference) (metav1.Object, error)
+
+	// Lists the metadata of every object of the given kind in the namespace.
+	//
+	// Cluster-scoped kinds ignore the namespace.
 	ListMeta(ctx context.Context, gvk schema.GroupVersionKind, ns Namespace) ([]metav1.Object, error)
 
 	// Streams the container logs
@@ -99,13 +103,42 @@ type Client interface {
 
 	ContainerRuntime(ctx context.Context) container.Runtime
 
+	// The runtime of a particular node, which may differ from the
+	// cluster's runtime if the nodes don't all match.
+	NodeContainerRuntime(ctx context.Context, nodeName string) container.Runtime
+
+	// The distinct platforms of the cluster's nodes, like "linux/amd64", sorted.
+	// Empty if we can't read the nodes.
+	NodePlatforms(ctx context.Context) []string
+
 	// Some clusters support a local image registry that we can push to.
 	LocalRegistry(ctx context.Context) container.Registry
 
+	// Re-reads the local registry from the cluster.
+	// Returns true if it's changed since we last read it.
+	RefreshLocalRegistry(ctx context.Context) (container.Registry, bool)
+
 	// Some clusters support a node IP where all servers are reachable.
 	NodeIP(ctx context.Context) NodeIP
 
 	Exec(ctx context.Context, podID PodID, cName container.Name, n Namespace, cmd []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error
+
+	// Runs a server-side dry-run apply of the entities, without changing anything
+	// in the cluster.
+	//
+	// Returns the live and merged versions of each object, in the order they were passed in.
+	DryRunApply(ctx context.Context, entities []K8sEntity) ([]DryRunResult, error)
+
+	// Makes a cheap request to the cluster to check that it's reachable.
+	CheckConnected(ctx context.Context) error
+
+	// Estimates how far the cluster's clock is ahead of ours.
+	ClockSkew(ctx context.Context) (ClockSkew, error)
+
+	// Adds an ephemeral container to a running pod.
+	//
+	// Returns ErrEphemeralContainersUnsupported if the cluster can't run them.
+	AttachEphemeralContainer(ctx context.Context, podID PodID, n Namespace, ctr v1.EphemeralContainer) error
 }
 
 type RESTMapper interface {
@@ -119,6 +152,7 @@ type K8sClient struct {
 	env               Env
 	core              apiv1.CoreV1Interface
 	restConfig        *rest.Config
+	credentialPlugin  *credentialPlugin
 	portForwardClient PortForwardClient
 	configNamespace   Namespace
 	clientset         kubernetes.Interface
@@ -189,6 +223,7 @@ func ProvideK8sClient(
 		env:               env,
 		core:              core,
 		restConfig:        restConfig,
+		credentialPlugin:  maybeRESTConfig.credentialPlugin,
 		portForwardClient: pfClient,
 		discovery:         discovery,
 		configNamespace:   configNamespace,
@@ -363,7 +398,7 @@ func (k *K8sClient) deleteAndCreateEntity(ctx context.Context, entity K8sEntity)
 		return nil, errors.Wrap(err, "kubernetes delete and re-create")
 	}
 
-	result, err := k.deleteAndCreate(resources)
+	result, err := k.deleteAndCreate(ctx, resources)
 	if err != nil {
 		return nil, err
 	}
@@ -424,7 +459,10 @@ func (k *K8sClient) helmResultToEntities(result *kube.Result) ([]K8sEntity, erro
 	return parsed, nil
 }
 
-func (k *K8sClient) deleteAndCreate(list kube.ResourceList) (*kube.Result, error) {
+// How often we retry creating an object whose old version is still being deleted.
+const recreateRetryInterval = 250 * time.Millisecond
+
+func (k *K8sClient) deleteAndCreate(ctx context.Context, list kube.ResourceList) (*kube.Result, error) {
 	// Delete is destructive, so clone first.
 	toDelete := kube.ResourceList{}
 	for _, r := range list {
@@ -461,11 +499,24 @@ func (k *K8sClient) deleteAndCreate(list kube.ResourceList) (*kube.Result, error
 
 	wg.Wait()
 
-	result, err := k.resourceClient.Create(list)
-	if err != nil {
-		return nil, errors.Wrap(err, "kubernetes create")
+	// The old object may outlive our wait (e.g., a Job whose pods are slow to
+	// shut down), so the new one can collide with it. Keep retrying until the
+	// name frees up or we run out of time.
+	for {
+		result, err := k.resourceClient.Create(list)
+		if err == nil {
+			return result, nil
+		}
+		if !isAlreadyExistsError(err) {
+			return nil, errors.Wrap(err, "kubernetes create")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrap(err, "kubernetes create: timed out waiting for the old object to be deleted")
+		case <-time.After(recreateRetryInterval):
+		}
 	}
-	return result, nil
 }
 
 // Update a resource in-place, starting with the least intrusive
@@ -561,6 +612,14 @@ func (k *K8sClient) Delete(ctx context.Context, entities []K8sEntity) error {
 }
 
 func (k *K8sClient) forceDiscovery(ctx context.Context, gvk schema.GroupVersionKind) (schema.GroupVersionResource, error) {
+	rm, err := k.forceRESTMapping(gvk)
+	if err != nil {
+		return schema.GroupVersionResource{}, err
+	}
+	return rm.Resource, nil
+}
+
+func (k *K8sClient) forceRESTMapping(gvk schema.GroupVersionKind) (*meta.RESTMapping, error) {
 	rm, err := k.drm.RESTMapping(gvk.GroupKind(), gvk.Version)
 	if err != nil {
 		// The REST mapper doesn't have any sort of internal invalidation
@@ -576,19 +635,24 @@ func (k *K8sClient) forceDiscovery(ctx context.Context, gvk schema.GroupVersionK
 
 		rm, err = k.drm.RESTMapping(gvk.GroupKind(), gvk.Version)
 		if err != nil {
-			return schema.GroupVersionResource{}, errors.Wrapf(err, "error mapping %s/%s", gvk.Group, gvk.Kind)
+			return nil, errors.Wrapf(err, "error mapping %s/%s", gvk.Group, gvk.Kind)
 		}
 	}
-	return rm.Resource, nil
+	return rm, nil
 }
 
 func (k *K8sClient) ListMeta(ctx context.Context, gvk schema.GroupVersionKind, ns Namespace) ([]metav1.Object, error) {
-	gvr, err := k.forceDiscovery(ctx, gvk)
+	mapping, err := k.forceRESTMapping(gvk)
 	if err != nil {
 		return nil, err
 	}
 
-	metaList, err := k.metadata.Resource(gvr).Namespace(ns.String()).List(ctx, metav1.ListOptions{})
+	var ri metadata.ResourceInterface = k.metadata.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		ri = k.metadata.Resource(mapping.Resource).Namespace(ns.String())
+	}
+
+	metaList, err := ri.List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -602,6 +666,14 @@ func (k *K8sClient) ListMeta(ctx context.Context, gvk schema.GroupVersionKind, n
 	return result, nil
 }
 
+func (k *K8sClient) CheckConnected(ctx context.Context) error {
+	err := k.clientset.Discovery().RESTClient().Get().AbsPath("/version").Do(ctx).Error()
+	if err != nil {
+		return errors.Wrap(maybeUnpackStatusError(err), "checking cluster connection")
+	}
+	return nil
+}
+
 func (k *K8sClient) GetMetaByReference(ctx context.Context, ref v1.ObjectReference) (metav1.Object, error) {
 	gvk := ReferenceGVK(ref)
 	gvr, err := k.forceDiscovery(ctx, gvk)
@@ -708,9 +780,15 @@ func ProvideConfigNamespace(clientLoader clientcmd.ClientConfig) Namespace {
 type RESTConfigOrError struct {
 	Config *rest.Config
 	Error  error
+
+	credentialPlugin *credentialPlugin
 }
 
 func ProvideRESTConfig(clientLoader clientcmd.ClientConfig) RESTConfigOrError {
 	config, err := clientLoader.ClientConfig()
-	return RESTConfigOrError{Config: config, Error: err}
+	if err != nil {
+		return RESTConfigOrError{Error: err}
+	}
+	config, plugin := withCredentialPlugin(config, credentialPluginTimeout)
+	return RESTConfigOrError{Config: config, credentialPlugin: plugin}
 }
@@ -14,7 +14,9 @@ import (
 	"github.com/stretchr/testify/require"
 	"helm.sh/helm/v3/pkg/kube"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/cli-runtime/pkg/resource"
 	dynfake "k8s.io/client-go/dynamic/fake"
@@ -50,6 +52,24 @@ func TestUpsert(t *testing.T) {
 	assert.Equal(t, 5, len(f.resourceClient.updates))
 }
 
+func TestDryRunApply(t *testing.T) {
+	f := newClientTestFixture(t)
+	sancho, err := ParseYAMLFromString(testyaml.SanchoYAML)
+	require.NoError(t, err)
+
+	results, err := f.client.DryRunApply(f.ctx, sancho)
+	require.NoError(t, err)
+
+	// The dry run goes through the same client-side apply as Upsert.
+	assert.Equal(t, 1, len(f.resourceClient.dryRunUpdates))
+	assert.Equal(t, 0, len(f.resourceClient.updates))
+
+	require.Len(t, results, 1)
+	assert.Nil(t, results[0].Live)
+	assert.Equal(t, "Deployment", results[0].Merged.GetKind())
+	assert.Equal(t, "sancho", results[0].Merged.GetName())
+}
+
 func TestDelete(t *testing.T) {
 	f := newClientTestFixture(t)
 	postgres, err := ParseYAMLFromString(testyaml.PostgresYAML)
@@ -109,6 +129,47 @@ func TestUpsertMutableAndImmutable(t *testing.T) {
 	require.Equal(t, eJob, call2Entity, "expect create job")
 }
 
+func TestUpsertJobRetriesWhileOldJobIsDeleting(t *testing.T) {
+	f := newClientTestFixture(t)
+	eJob := MustParseYAMLFromString(t, testyaml.JobYAML)[0]
+
+	alreadyExists := apierrors.NewAlreadyExists(schema.GroupResource{Group: "batch", Resource: "jobs"}, eJob.Name())
+	f.resourceClient.createErrs = []error{alreadyExists, alreadyExists}
+
+	_, err := f.k8sUpsert(f.ctx, []K8sEntity{eJob})
+	require.NoError(t, err)
+	require.Len(t, f.resourceClient.deletes, 1)
+	require.Len(t, f.resourceClient.creates, 1)
+	assert.Empty(t, f.resourceClient.createErrs)
+}
+
+func TestUpsertJobGivesUpWhenOldJobIsNeverDeleted(t *testing.T) {
+	f := newClientTestFixture(t)
+	eJob := MustParseYAMLFromString(t, testyaml.JobYAML)[0]
+
+	alreadyExists := apierrors.NewAlreadyExists(schema.GroupResource{Group: "batch", Resource: "jobs"}, eJob.Name())
+	for i := 0; i < 100; i++ {
+		f.resourceClient.createErrs = append(f.resourceClient.createErrs, alreadyExists)
+	}
+
+	_, err := f.client.Upsert(f.ctx, []K8sEntity{eJob}, time.Second)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out waiting for the old object to be deleted")
+	assert.Len(t, f.resourceClient.creates, 0)
+}
+
+func TestUpsertJobDoesNotRetryOtherErrors(t *testing.T) {
+	f := newClientTestFixture(t)
+	eJob := MustParseYAMLFromString(t, testyaml.JobYAML)[0]
+
+	f.resourceClient.createErrs = []error{fmt.Errorf("admission webhook denied the request")}
+
+	_, err := f.k8sUpsert(f.ctx, []K8sEntity{eJob})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "admission webhook denied the request")
+	assert.Len(t, f.resourceClient.creates, 0)
+}
+
 func TestUpsertAnnotationTooLong(t *testing.T) {
 	f := newClientTestFixture(t)
 	postgres := MustParseYAMLFromString(t, testyaml.PostgresYAML)
@@ -177,11 +238,15 @@ func TestGetGroup(t *testing.T) {
 
 type fakeResourceClient struct {
 	updates          kube.ResourceList
+	dryRunUpdates    kube.ResourceList
 	creates          kube.ResourceList
 	deletes          kube.ResourceList
 	createOrReplaces kube.ResourceList
 	updateErr        error
 	buildErrFn       func(e K8sEntity) error
+
+	// Errors returned by successive calls to Create.
+	createErrs []error
 }
 
 func (c *fakeResourceClient) Apply(target kube.ResourceList) (*kube.Result, error) {
@@ -195,11 +260,20 @@ func (c *fakeResourceClient) Apply(target kube.ResourceList) (*kube.Result, erro
 	c.updates = append(c.updates, target...)
 	return &kube.Result{Updated: target}, nil
 }
+func (c *fakeResourceClient) DryRunApply(target kube.ResourceList) error {
+	c.dryRunUpdates = append(c.dryRunUpdates, target...)
+	return nil
+}
 func (c *fakeResourceClient) Delete(l kube.ResourceList) (*kube.Result, []error) {
 	c.deletes = append(c.deletes, l...)
 	return &kube.Result{Deleted: l}, nil
 }
 func (c *fakeResourceClient) Create(l kube.ResourceList) (*kube.Result, error) {
+	if len(c.createErrs) > 0 {
+		err := c.createErrs[0]
+		c.createErrs = c.createErrs[1:]
+		return nil, err
+	}
 	c.creates = append(c.creates, l...)
 	return &kube.Result{Created: l}, nil
 }
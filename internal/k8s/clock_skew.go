@@ -0,0 +1,95 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+// Skews smaller than this are within the granularity of our measurement
+// (and of most apiserver timestamps), so we don't warn about them.
+const ClockSkewWarnThreshold = 5 * time.Second
+
+// How far the cluster's clock is ahead of ours.
+//
+// Negative if the cluster's clock is behind. Use it to translate
+// cluster timestamps (like a pod's creation time) into local time before
+// comparing them to timestamps Tilt took itself.
+type ClockSkew time.Duration
+
+// Translates a timestamp taken by the cluster into local time.
+func (s ClockSkew) ToLocal(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return t.Add(-time.Duration(s))
+}
+
+// Translates a cluster timestamp into local time, preserving its type
+// so that timecmp still compares it at second granularity.
+func (s ClockSkew) ToLocalTime(t metav1.Time) metav1.Time {
+	return metav1.Time{Time: s.ToLocal(t.Time)}
+}
+
+// True if the skew is big enough that we should tell the user about it.
+func (s ClockSkew) Significant() bool {
+	d := time.Duration(s)
+	return d >= ClockSkewWarnThreshold || d <= -ClockSkewWarnThreshold
+}
+
+func (s ClockSkew) String() string {
+	d := time.Duration(s).Round(time.Second)
+	if d < 0 {
+		return fmt.Sprintf("%s behind", -d)
+	}
+	return fmt.Sprintf("%s ahead", d)
+}
+
+// Estimates the skew from the Date header of an apiserver response.
+func (k *K8sClient) ClockSkew(ctx context.Context) (ClockSkew, error) {
+	transport, err := rest.TransportFor(k.restConfig)
+	if err != nil {
+		return 0, errors.Wrap(err, "estimating cluster clock skew")
+	}
+
+	u, _, err := rest.DefaultServerURL(k.restConfig.Host, k.restConfig.APIPath,
+		schema.GroupVersion{}, rest.IsConfigTransportTLS(*k.restConfig))
+	if err != nil {
+		return 0, errors.Wrap(err, "estimating cluster clock skew")
+	}
+	u.Path = path.Join(u.Path, "/version")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return 0, errors.Wrap(err, "estimating cluster clock skew")
+	}
+
+	start := time.Now()
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "estimating cluster clock skew")
+	}
+	end := time.Now()
+	_ = resp.Body.Close()
+
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return 0, errors.Wrap(err, "estimating cluster clock skew: reading Date header")
+	}
+	return estimateClockSkew(date, start, end), nil
+}
+
+// The server sets the Date header somewhere between when we sent the request
+// and when we got the response, truncated to the second. So compare the middle
+// of that second to the middle of the request.
+func estimateClockSkew(date, start, end time.Time) ClockSkew {
+	local := start.Add(end.Sub(start) / 2)
+	return ClockSkew(date.Add(500 * time.Millisecond).Sub(local))
+}
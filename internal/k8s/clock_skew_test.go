@@ -0,0 +1,62 @@
+package k8s
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+
+	"github.com/tilt-dev/tilt/internal/timecmp"
+	"github.com/tilt-dev/tilt/pkg/apis"
+)
+
+func TestClockSkewFromDateHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/version", r.URL.Path)
+		w.Header().Set("Date", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+		_, _ = w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	c := &K8sClient{restConfig: &rest.Config{Host: server.URL}}
+	skew, err := c.ClockSkew(context.Background())
+	require.NoError(t, err)
+	assert.InDelta(t, float64(-time.Hour), float64(skew), float64(2*time.Second))
+	assert.True(t, skew.Significant())
+	assert.Equal(t, "1h0m0s behind", skew.String())
+}
+
+func TestEstimateClockSkew(t *testing.T) {
+	start := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Second)
+
+	// The server's clock is 10.5s ahead, but the Date header is truncated.
+	date := start.Add(time.Second + 10*time.Second)
+	assert.Equal(t, ClockSkew(10500*time.Millisecond), estimateClockSkew(date, start, end))
+}
+
+func TestClockSkewSignificant(t *testing.T) {
+	assert.False(t, ClockSkew(ClockSkewWarnThreshold-time.Millisecond).Significant())
+	assert.False(t, ClockSkew(-ClockSkewWarnThreshold+time.Millisecond).Significant())
+	assert.True(t, ClockSkew(ClockSkewWarnThreshold).Significant())
+	assert.True(t, ClockSkew(-ClockSkewWarnThreshold).Significant())
+}
+
+func TestClockSkewToLocal(t *testing.T) {
+	now := time.Now()
+
+	// The cluster is a minute ahead, so a pod it says it created a minute
+	// from now was created just now.
+	skew := ClockSkew(time.Minute)
+	podCreated := apis.NewTime(now.Add(time.Minute))
+	assert.True(t, timecmp.AfterOrEqual(podCreated, now))
+	assert.True(t, timecmp.Equal(skew.ToLocalTime(podCreated), apis.NewTime(now)))
+
+	// The zero time stays zero.
+	assert.True(t, skew.ToLocal(time.Time{}).IsZero())
+}
@@ -0,0 +1,314 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/transport"
+)
+
+// How long a kubeconfig exec credential plugin (like `aws eks get-token`)
+// gets to hand us a token.
+//
+// Plugins that hang are usually waiting for input we'll never give them,
+// e.g., an expired SSO session prompting the user to log in again.
+const credentialPluginTimeout = 10 * time.Second
+
+// Returned when a kubeconfig exec credential plugin hangs or fails.
+//
+// The cluster monitor treats this as a cluster connection problem, so that
+// it reaches the user rather than getting buried in watch retries.
+type CredentialPluginError struct {
+	// The plugin command, e.g., "aws".
+	Command string
+
+	// Non-zero if the plugin timed out.
+	Timeout time.Duration
+
+	Err error
+}
+
+func (e CredentialPluginError) Error() string {
+	name := filepath.Base(e.Command)
+	if e.Timeout != 0 {
+		return fmt.Sprintf("credential plugin %s timed out after %s — %s",
+			name, e.Timeout, credentialPluginHint(name))
+	}
+	return fmt.Sprintf("credential plugin %s failed: %v", name, e.Err)
+}
+
+func (e CredentialPluginError) Unwrap() error {
+	return e.Err
+}
+
+// Suggests how to fix a plugin that's hanging, for the plugins we know.
+func credentialPluginHint(name string) string {
+	switch name {
+	case "aws":
+		return "your SSO session may be expired; run `aws sso login`"
+	case "gcloud", "gke-gcloud-auth-plugin":
+		return "your login may be expired; run `gcloud auth login`"
+	case "kubelogin":
+		return "your login may be expired; run `az login`"
+	}
+	return "it may be waiting for input, e.g., to log in again"
+}
+
+// The parts of an ExecCredential that we read. They're the same in every
+// client.authentication.k8s.io version.
+type execCredential struct {
+	Status *struct {
+		Token                 string       `json:"token"`
+		ClientCertificateData string       `json:"clientCertificateData"`
+		ExpirationTimestamp   *metav1.Time `json:"expirationTimestamp"`
+	} `json:"status"`
+}
+
+// Runs a kubeconfig exec credential plugin with a timeout, and caches the
+// token it returns until it expires.
+//
+// Every client made from the same rest.Config shares one credentialPlugin,
+// so watch reconnects and parallel clients don't each re-run the plugin.
+//
+// Plugins that return a client certificate (like `tsh kube credentials`)
+// can only authenticate the TLS connection, so we leave them to client-go's
+// exec authenticator, using the original rest.Config.
+type credentialPlugin struct {
+	config   clientcmdapi.ExecConfig
+	original *rest.Config
+	timeout  time.Duration
+	now      func() time.Time
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time // zero if the token doesn't expire
+
+	// Set once the plugin returns a client certificate.
+	clientCert   bool
+	certRT       http.RoundTripper
+	certRTErr    error
+	certRTLoaded bool
+}
+
+func newCredentialPlugin(config clientcmdapi.ExecConfig, timeout time.Duration) *credentialPlugin {
+	return &credentialPlugin{
+		config:  config,
+		timeout: timeout,
+		now:     time.Now,
+	}
+}
+
+// Returns a transport where client-go presents the plugin's client certificate.
+func (p *credentialPlugin) certTransport() (http.RoundTripper, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.certRTLoaded {
+		// The transport we delegate from already adds the user agent
+		// and impersonation headers.
+		config := rest.CopyConfig(p.original)
+		config.UserAgent = ""
+		config.Impersonate = rest.ImpersonationConfig{}
+		p.certRT, p.certRTErr = rest.TransportFor(config)
+		p.certRTLoaded = true
+	}
+	return p.certRT, p.certRTErr
+}
+
+// Returns the config for a connection that we upgrade (like exec or port-forward).
+//
+// Upgraded connections set up TLS themselves, so if the plugin returns client
+// certificates, they need client-go's exec authenticator.
+func (p *credentialPlugin) upgradeConfig(ctx context.Context, config *rest.Config) (*rest.Config, error) {
+	if p == nil {
+		return config, nil
+	}
+	token, err := p.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		return p.original, nil
+	}
+	return config, nil
+}
+
+// Returns the cached token, or runs the plugin for a new one.
+//
+// Returns an empty token if the plugin returns a client certificate instead.
+func (p *credentialPlugin) Token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.clientCert {
+		return "", nil
+	}
+	if p.token != "" && (p.expiry.IsZero() || p.now().Before(p.expiry)) {
+		return p.token, nil
+	}
+
+	cred, err := p.run(ctx)
+	if err != nil {
+		return "", err
+	}
+	if cred.Status.Token == "" {
+		p.clientCert = true
+		return "", nil
+	}
+
+	p.token = cred.Status.Token
+	p.expiry = time.Time{}
+	if cred.Status.ExpirationTimestamp != nil {
+		p.expiry = cred.Status.ExpirationTimestamp.Time
+	}
+	return p.token, nil
+}
+
+// Forgets the token, e.g., because the cluster rejected it.
+func (p *credentialPlugin) invalidate(token string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token == token {
+		p.token = ""
+		p.expiry = time.Time{}
+	}
+}
+
+func (p *credentialPlugin) run(ctx context.Context) (execCredential, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	execInfo, err := json.Marshal(map[string]interface{}{
+		"apiVersion": p.config.APIVersion,
+		"kind":       "ExecCredential",
+		"spec":       map[string]interface{}{"interactive": false},
+	})
+	if err != nil {
+		return execCredential{}, p.error(err)
+	}
+
+	cmd := exec.Command(p.config.Command, p.config.Args...)
+	cmd.Env = os.Environ()
+	for _, env := range p.config.Env {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", env.Name, env.Value))
+	}
+	cmd.Env = append(cmd.Env, fmt.Sprintf("KUBERNETES_EXEC_INFO=%s", execInfo))
+
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err = cmd.Start()
+	if err != nil {
+		return execCredential{}, p.error(err)
+	}
+
+	// Don't wait on a plugin that we've killed: it may have left children
+	// behind that hold its output open.
+	doneCh := make(chan error, 1)
+	go func() {
+		doneCh <- cmd.Wait()
+	}()
+
+	select {
+	case <-ctx.Done():
+		_ = cmd.Process.Kill()
+		if ctx.Err() == context.DeadlineExceeded {
+			return execCredential{}, CredentialPluginError{Command: p.config.Command, Timeout: p.timeout, Err: ctx.Err()}
+		}
+		return execCredential{}, ctx.Err()
+	case err := <-doneCh:
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				err = fmt.Errorf("%v: %s", err, msg)
+			}
+			return execCredential{}, p.error(err)
+		}
+	}
+
+	var cred execCredential
+	err = json.Unmarshal(stdout.Bytes(), &cred)
+	if err != nil {
+		return execCredential{}, p.error(fmt.Errorf("decoding ExecCredential: %v", err))
+	}
+	if cred.Status == nil || (cred.Status.Token == "" && cred.Status.ClientCertificateData == "") {
+		return execCredential{}, p.error(fmt.Errorf("ExecCredential has no token or client certificate"))
+	}
+	return cred, nil
+}
+
+func (p *credentialPlugin) error(err error) error {
+	return CredentialPluginError{Command: p.config.Command, Err: err}
+}
+
+// Adds the plugin's token to each request.
+type credentialPluginRoundTripper struct {
+	plugin *credentialPlugin
+	rt     http.RoundTripper
+}
+
+func (rt *credentialPluginRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A request with its own credentials doesn't need ours.
+	if req.Header.Get("Authorization") != "" {
+		return rt.rt.RoundTrip(req)
+	}
+
+	token, err := rt.plugin.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		certRT, err := rt.plugin.certTransport()
+		if err != nil {
+			return nil, err
+		}
+		return certRT.RoundTrip(req)
+	}
+
+	req = utilnet.CloneRequest(req)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	res, err := rt.rt.RoundTrip(req)
+	if err == nil && res.StatusCode == http.StatusUnauthorized {
+		// The token was revoked early. Get a new one next time.
+		rt.plugin.invalidate(token)
+	}
+	return res, err
+}
+
+func (rt *credentialPluginRoundTripper) WrappedRoundTripper() http.RoundTripper { return rt.rt }
+
+// Takes over running the config's exec credential plugin, so that it gets a
+// timeout, and so that everything made from the config shares one token cache.
+//
+// If the plugin returns a client certificate instead of a token, requests go
+// through client-go's exec authenticator instead.
+//
+// Leaves plugins that need the cluster info to client-go.
+func withCredentialPlugin(config *rest.Config, timeout time.Duration) (*rest.Config, *credentialPlugin) {
+	if config == nil || config.ExecProvider == nil || config.ExecProvider.ProvideClusterInfo {
+		return config, nil
+	}
+
+	plugin := newCredentialPlugin(*config.ExecProvider, timeout)
+	plugin.original = config
+	config = rest.CopyConfig(config)
+	config.ExecProvider = nil
+	config.WrapTransport = transport.Wrappers(config.WrapTransport, func(rt http.RoundTripper) http.RoundTripper {
+		return &credentialPluginRoundTripper{plugin: plugin, rt: rt}
+	})
+	return config, plugin
+}
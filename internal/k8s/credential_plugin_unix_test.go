@@ -0,0 +1,271 @@
+// +build !windows
+
+package k8s
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
+)
+
+func TestCredentialPluginTimeout(t *testing.T) {
+	f := newCredentialPluginFixture(t)
+
+	// Like an expired SSO session, waiting for the user to log in.
+	p := f.plugin("aws", "sleep 30", 200*time.Millisecond)
+
+	start := time.Now()
+	_, err := p.Token(context.Background())
+	require.Error(t, err)
+	assert.Less(t, int64(time.Since(start)), int64(5*time.Second))
+
+	var pluginErr CredentialPluginError
+	require.True(t, errors.As(err, &pluginErr))
+	assert.Equal(t, 200*time.Millisecond, pluginErr.Timeout)
+	assert.Equal(t, "credential plugin aws timed out after 200ms — your SSO session may be expired; run `aws sso login`",
+		err.Error())
+}
+
+func TestCredentialPluginFailure(t *testing.T) {
+	f := newCredentialPluginFixture(t)
+	p := f.plugin("gcloud", "echo 'not logged in' >&2; exit 1", time.Second)
+
+	_, err := p.Token(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, "credential plugin gcloud failed: exit status 1: not logged in", err.Error())
+}
+
+func TestCredentialPluginCachesShortLivedToken(t *testing.T) {
+	f := newCredentialPluginFixture(t)
+	now := time.Now()
+	p := f.tokenPlugin("token-1", now.Add(time.Minute))
+	p.now = func() time.Time { return now }
+
+	token, err := p.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	token, err = p.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+	assert.Equal(t, 1, f.runs())
+
+	// Once the token expires, we ask for a new one.
+	now = now.Add(2 * time.Minute)
+	_, err = p.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, f.runs())
+}
+
+func TestCredentialPluginSharedAcrossClients(t *testing.T) {
+	f := newCredentialPluginFixture(t)
+
+	var mu sync.Mutex
+	var auths []string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		auths = append(auths, r.Header.Get("Authorization"))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	config, plugin := withCredentialPlugin(&rest.Config{
+		Host:         server.URL,
+		ExecProvider: f.tokenExecConfig("token-1", time.Now().Add(time.Hour)),
+	}, time.Second)
+	require.Nil(t, config.ExecProvider)
+
+	// Every client made from the config shares the token.
+	for i := 0; i < 3; i++ {
+		f.get(config, server.URL)
+	}
+	assert.Equal(t, 1, f.runs())
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-1", "Bearer token-1"}, auths)
+
+	// Upgraded connections get the token from our round tripper too.
+	upgradeConfig, err := plugin.upgradeConfig(context.Background(), config)
+	require.NoError(t, err)
+	assert.Equal(t, config, upgradeConfig)
+
+	// If the cluster rejects the token, we ask for a new one.
+	mu.Lock()
+	status = http.StatusUnauthorized
+	mu.Unlock()
+	f.get(config, server.URL)
+	f.get(config, server.URL)
+	assert.Equal(t, 2, f.runs())
+}
+
+func TestCredentialPluginClientCertificate(t *testing.T) {
+	f := newCredentialPluginFixture(t)
+
+	var mu sync.Mutex
+	var certs []string
+	var auths []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, cert := range r.TLS.PeerCertificates {
+			certs = append(certs, cert.Subject.CommonName)
+		}
+		auths = append(auths, r.Header.Get("Authorization"))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	// Like `tsh kube credentials`, which returns a client certificate instead of a token.
+	certPEM, keyPEM := f.clientCert("teleport-user")
+	cred, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "client.authentication.k8s.io/v1beta1",
+		"kind":       "ExecCredential",
+		"status": map[string]string{
+			"clientCertificateData": certPEM,
+			"clientKeyData":         keyPEM,
+		},
+	})
+	require.NoError(t, err)
+	f.WriteFile("cred.json", string(cred))
+	p := f.plugin("tsh", fmt.Sprintf("cat %s", f.JoinPath("cred.json")), time.Second)
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	config, plugin := withCredentialPlugin(&rest.Config{
+		Host:            server.URL,
+		TLSClientConfig: rest.TLSClientConfig{CAData: caPEM},
+		ExecProvider:    &p.config,
+	}, time.Second)
+
+	// Requests go through client-go's exec authenticator, which presents the certificate.
+	f.get(config, server.URL)
+	f.get(config, server.URL)
+	assert.Equal(t, []string{"teleport-user", "teleport-user"}, certs)
+	assert.Equal(t, []string{"", ""}, auths)
+
+	// So do upgraded connections.
+	upgradeConfig, err := plugin.upgradeConfig(context.Background(), config)
+	require.NoError(t, err)
+	assert.Equal(t, &p.config, upgradeConfig.ExecProvider)
+}
+
+func TestCredentialPluginNoCredentials(t *testing.T) {
+	f := newCredentialPluginFixture(t)
+	p := f.plugin("fake-plugin", `echo '{"apiVersion":"client.authentication.k8s.io/v1beta1","kind":"ExecCredential","status":{}}'`, time.Second)
+
+	_, err := p.Token(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ExecCredential has no token or client certificate")
+}
+
+func TestCredentialPluginLeavesClusterInfoPluginsAlone(t *testing.T) {
+	exec := &clientcmdapi.ExecConfig{Command: "aws", ProvideClusterInfo: true}
+	config, plugin := withCredentialPlugin(&rest.Config{ExecProvider: exec}, time.Second)
+	assert.Equal(t, exec, config.ExecProvider)
+	assert.Nil(t, config.WrapTransport)
+
+	upgradeConfig, err := plugin.upgradeConfig(context.Background(), config)
+	require.NoError(t, err)
+	assert.Equal(t, config, upgradeConfig)
+}
+
+type credentialPluginFixture struct {
+	*tempdir.TempDirFixture
+	t *testing.T
+}
+
+func newCredentialPluginFixture(t *testing.T) *credentialPluginFixture {
+	f := &credentialPluginFixture{TempDirFixture: tempdir.NewTempDirFixture(t), t: t}
+	t.Cleanup(f.TearDown)
+	return f
+}
+
+// Writes a fake plugin that runs the given shell script.
+func (f *credentialPluginFixture) plugin(name string, script string, timeout time.Duration) *credentialPlugin {
+	path := f.JoinPath(name)
+	f.WriteFile(name, fmt.Sprintf("#!/bin/sh\n%s\n", script))
+	require.NoError(f.t, os.Chmod(path, 0755))
+	return newCredentialPlugin(clientcmdapi.ExecConfig{
+		Command:         path,
+		APIVersion:      "client.authentication.k8s.io/v1beta1",
+		InteractiveMode: clientcmdapi.IfAvailableExecInteractiveMode,
+	}, timeout)
+}
+
+// Writes a fake plugin that counts its runs, and returns the token.
+func (f *credentialPluginFixture) tokenExecConfig(token string, expiry time.Time) *clientcmdapi.ExecConfig {
+	script := fmt.Sprintf(`echo run >> %s
+echo '{"apiVersion":"client.authentication.k8s.io/v1beta1","kind":"ExecCredential","status":{"token":"%s","expirationTimestamp":"%s"}}'`,
+		f.JoinPath("runs"), token, expiry.UTC().Format(time.RFC3339))
+	return &f.plugin("fake-plugin", script, time.Second).config
+}
+
+func (f *credentialPluginFixture) tokenPlugin(token string, expiry time.Time) *credentialPlugin {
+	return newCredentialPlugin(*f.tokenExecConfig(token, expiry), time.Second)
+}
+
+func (f *credentialPluginFixture) runs() int {
+	contents, err := ioutil.ReadFile(f.JoinPath("runs"))
+	if os.IsNotExist(err) {
+		return 0
+	}
+	require.NoError(f.t, err)
+	return strings.Count(string(contents), "run")
+}
+
+// Generates a self-signed client certificate, as PEM.
+func (f *credentialPluginFixture) clientCert(name string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(f.t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(f.t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(f.t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return string(certPEM), string(keyPEM)
+}
+
+// Makes a request with a new client from the config.
+func (f *credentialPluginFixture) get(config *rest.Config, url string) {
+	rt, err := rest.TransportFor(config)
+	require.NoError(f.t, err)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(f.t, err)
+	res, err := rt.RoundTrip(req)
+	require.NoError(f.t, err)
+	_ = res.Body.Close()
+}
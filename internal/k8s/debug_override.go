@@ -0,0 +1,74 @@
+package k8s
+
+import (
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// Applies a debug override to the main container of every pod spec in the entity.
+//
+// The main container is the one named by the override, or the first container
+// if the override doesn't name one.
+//
+// Returns: the new entity, whether any container was changed, and an error.
+func InjectDebugOverride(entity K8sEntity, o model.K8sDebugOverride) (K8sEntity, bool, error) {
+	entity = entity.DeepCopy()
+	pods, err := ExtractPods(&entity)
+	if err != nil {
+		return K8sEntity{}, false, err
+	}
+
+	injected := false
+	for _, pod := range pods {
+		c := debugOverrideContainer(pod, o.Container)
+		if c == nil {
+			continue
+		}
+
+		if o.Command != nil {
+			c.Command = append([]string{}, o.Command...)
+		}
+		if o.Args != nil {
+			c.Args = append([]string{}, o.Args...)
+		}
+		if o.RemoveReadinessProbe {
+			c.ReadinessProbe = nil
+		}
+		for _, port := range o.Ports {
+			if !hasContainerPort(c, port) {
+				c.Ports = append(c.Ports, v1.ContainerPort{
+					ContainerPort: port,
+					Protocol:      v1.ProtocolTCP,
+				})
+			}
+		}
+		injected = true
+	}
+	return entity, injected, nil
+}
+
+func debugOverrideContainer(pod *v1.PodSpec, name string) *v1.Container {
+	if name == "" {
+		if len(pod.Containers) == 0 {
+			return nil
+		}
+		return &pod.Containers[0]
+	}
+
+	for i := range pod.Containers {
+		if pod.Containers[i].Name == name {
+			return &pod.Containers[i]
+		}
+	}
+	return nil
+}
+
+func hasContainerPort(c *v1.Container, port int32) bool {
+	for _, p := range c.Ports {
+		if p.ContainerPort == port {
+			return true
+		}
+	}
+	return false
+}
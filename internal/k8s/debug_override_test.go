@@ -0,0 +1,132 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestInjectDebugOverrideCommandAndArgs(t *testing.T) {
+	entity := parseOneEntity(t, testyaml.SanchoYAMLWithCommand)
+	orig := entity.DeepCopy()
+
+	newEntity, injected, err := InjectDebugOverride(entity, model.K8sDebugOverride{
+		Command: []string{"dlv", "exec", "/app/sancho"},
+		Args:    []string{"--headless", "--listen=:2345"},
+	})
+	require.NoError(t, err)
+	assert.True(t, injected)
+
+	c := mainContainer(t, newEntity)
+	assert.Equal(t, []string{"dlv", "exec", "/app/sancho"}, c.Command)
+	assert.Equal(t, []string{"--headless", "--listen=:2345"}, c.Args)
+
+	// The original entity is untouched, so that turning off the override
+	// restores the original spec.
+	assert.Equal(t, orig, entity)
+	assert.Equal(t, []string{"foo.sh"}, mainContainer(t, entity).Command)
+}
+
+func TestInjectDebugOverrideKeepsUnsetFields(t *testing.T) {
+	entity := parseOneEntity(t, testyaml.SanchoYAMLWithCommand)
+
+	newEntity, injected, err := InjectDebugOverride(entity, model.K8sDebugOverride{
+		Args: []string{"--debug"},
+	})
+	require.NoError(t, err)
+	assert.True(t, injected)
+
+	c := mainContainer(t, newEntity)
+	assert.Equal(t, []string{"foo.sh"}, c.Command)
+	assert.Equal(t, []string{"--debug"}, c.Args)
+}
+
+func TestInjectDebugOverrideRemovesReadinessProbe(t *testing.T) {
+	entities, err := ParseYAMLFromString(testyaml.TracerYAML)
+	require.NoError(t, err)
+	entity := entities[0]
+	require.NotNil(t, mainContainer(t, entity).ReadinessProbe)
+
+	newEntity, injected, err := InjectDebugOverride(entity, model.K8sDebugOverride{
+		RemoveReadinessProbe: true,
+	})
+	require.NoError(t, err)
+	assert.True(t, injected)
+
+	c := mainContainer(t, newEntity)
+	assert.Nil(t, c.ReadinessProbe)
+	assert.NotNil(t, c.LivenessProbe)
+	assert.NotNil(t, mainContainer(t, entity).ReadinessProbe)
+}
+
+func TestInjectDebugOverrideAddsPorts(t *testing.T) {
+	entities, err := ParseYAMLFromString(testyaml.TracerYAML)
+	require.NoError(t, err)
+	entity := entities[0]
+
+	newEntity, injected, err := InjectDebugOverride(entity, model.K8sDebugOverride{
+		Ports: []int32{2345, 9411},
+	})
+	require.NoError(t, err)
+	assert.True(t, injected)
+
+	// The existing port isn't duplicated.
+	assert.Equal(t, []v1.ContainerPort{
+		{Name: "http", ContainerPort: 9411},
+		{ContainerPort: 2345, Protocol: v1.ProtocolTCP},
+	}, mainContainer(t, newEntity).Ports)
+	assert.Len(t, mainContainer(t, entity).Ports, 1)
+}
+
+func TestInjectDebugOverrideNamedContainer(t *testing.T) {
+	entity := parseOneEntity(t, testyaml.SanchoSidecarYAML)
+	pods, err := ExtractPods(&entity)
+	require.NoError(t, err)
+	require.Len(t, pods[0].Containers, 2)
+	sidecar := pods[0].Containers[1].Name
+
+	newEntity, injected, err := InjectDebugOverride(entity, model.K8sDebugOverride{
+		Container: sidecar,
+		Command:   []string{"sleep", "infinity"},
+	})
+	require.NoError(t, err)
+	assert.True(t, injected)
+
+	pods, err = ExtractPods(&newEntity)
+	require.NoError(t, err)
+	assert.NotEqual(t, []string{"sleep", "infinity"}, pods[0].Containers[0].Command)
+	assert.Equal(t, []string{"sleep", "infinity"}, pods[0].Containers[1].Command)
+}
+
+func TestInjectDebugOverrideNoMatchingContainer(t *testing.T) {
+	entities, err := ParseYAMLFromString(testyaml.DoggosServiceYaml)
+	require.NoError(t, err)
+
+	_, injected, err := InjectDebugOverride(entities[0], model.K8sDebugOverride{
+		Command: []string{"dlv"},
+	})
+	require.NoError(t, err)
+	assert.False(t, injected)
+
+	entity := parseOneEntity(t, testyaml.SanchoYAML)
+	_, injected, err = InjectDebugOverride(entity, model.K8sDebugOverride{
+		Container: "nope",
+		Command:   []string{"dlv"},
+	})
+	require.NoError(t, err)
+	assert.False(t, injected)
+}
+
+func mainContainer(t *testing.T, e K8sEntity) v1.Container {
+	t.Helper()
+	pods, err := ExtractPods(&e)
+	require.NoError(t, err)
+	require.NotEmpty(t, pods)
+	require.NotEmpty(t, pods[0].Containers)
+	return pods[0].Containers[0]
+}
@@ -0,0 +1,130 @@
+package k8s
+
+import (
+	"reflect"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// Marks objects that Tilt deployed with a dev override, so that it's obvious
+// the running spec differs from the YAML in version control.
+const DevOverrideAnnotation = "tilt.dev/dev-override"
+
+// Applies a dev override to the entity, like a strategic merge patch:
+// maps are merged key-by-key, and tolerations are added if they're missing.
+//
+// If an overridden limit is below a request that wasn't overridden, we lower the
+// request to the limit, so that the patched container is still valid.
+//
+// Returns: the new entity and whether anything was changed.
+func InjectDevOverride(entity K8sEntity, o model.K8sDevOverride) (K8sEntity, bool, error) {
+	entity = entity.DeepCopy()
+	pods, err := ExtractPods(&entity)
+	if err != nil {
+		return K8sEntity{}, false, err
+	}
+
+	injected := false
+	if o.Replicas != nil && setReplicas(entity, *o.Replicas) {
+		injected = true
+	}
+
+	for _, pod := range pods {
+		injected = true
+		for k, v := range o.NodeSelector {
+			if pod.NodeSelector == nil {
+				pod.NodeSelector = make(map[string]string)
+			}
+			pod.NodeSelector[k] = v
+		}
+
+		for _, t := range o.Tolerations {
+			if !hasToleration(pod, t) {
+				pod.Tolerations = append(pod.Tolerations, t)
+			}
+		}
+
+		for i := range pod.InitContainers {
+			overrideContainerResources(&pod.InitContainers[i], o)
+		}
+		for i := range pod.Containers {
+			overrideContainerResources(&pod.Containers[i], o)
+		}
+	}
+
+	if injected {
+		annotations := entity.Meta().GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[DevOverrideAnnotation] = o.String()
+		entity.Meta().SetAnnotations(annotations)
+	}
+	return entity, injected, nil
+}
+
+// Sets .spec.replicas on workloads that have it (Deployments, StatefulSets, etc).
+func setReplicas(entity K8sEntity, replicas int32) bool {
+	v := reflect.ValueOf(entity.Obj)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	spec := v.Elem().FieldByName("Spec")
+	if !spec.IsValid() || spec.Kind() != reflect.Struct {
+		return false
+	}
+	field := spec.FieldByName("Replicas")
+	if !field.IsValid() || field.Type() != reflect.TypeOf((*int32)(nil)) {
+		return false
+	}
+	field.Set(reflect.ValueOf(&replicas))
+	return true
+}
+
+func hasToleration(pod *v1.PodSpec, t v1.Toleration) bool {
+	for _, existing := range pod.Tolerations {
+		if equality.Semantic.DeepEqual(existing, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func overrideContainerResources(c *v1.Container, o model.K8sDevOverride) {
+	r, ok := o.ContainerResources[c.Name]
+	if !ok {
+		if o.Resources == nil {
+			return
+		}
+		r = *o.Resources
+	}
+
+	c.Resources.Requests = mergeResourceList(c.Resources.Requests, r.Requests)
+	c.Resources.Limits = mergeResourceList(c.Resources.Limits, r.Limits)
+
+	for name, limit := range r.Limits {
+		if _, overridden := r.Requests[name]; overridden {
+			continue
+		}
+		request, ok := c.Resources.Requests[name]
+		if ok && request.Cmp(limit) > 0 {
+			c.Resources.Requests[name] = limit.DeepCopy()
+		}
+	}
+}
+
+func mergeResourceList(dst, src v1.ResourceList) v1.ResourceList {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(v1.ResourceList, len(src))
+	}
+	for name, q := range src {
+		dst[name] = q.DeepCopy()
+	}
+	return dst
+}
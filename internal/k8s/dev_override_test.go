@@ -0,0 +1,161 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+const prodResourcesYAML = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  replicas: 3
+  selector:
+    matchLabels:
+      app: api
+  template:
+    metadata:
+      labels:
+        app: api
+    spec:
+      nodeSelector:
+        pool: prod
+      tolerations:
+      - key: dedicated
+        operator: Equal
+        value: api
+        effect: NoSchedule
+      containers:
+      - name: api
+        image: api
+        resources:
+          requests:
+            cpu: "2"
+            memory: 4Gi
+          limits:
+            cpu: "2"
+            memory: 4Gi
+      - name: proxy
+        image: proxy
+        resources:
+          requests:
+            cpu: 500m
+            memory: 1Gi
+`
+
+func TestInjectDevOverrideAllContainers(t *testing.T) {
+	entity := parseOneEntity(t, prodResourcesYAML)
+	orig := entity.DeepCopy()
+
+	newEntity, injected, err := InjectDevOverride(entity, model.K8sDevOverride{
+		Resources: &v1.ResourceRequirements{
+			Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m")},
+		},
+	})
+	require.NoError(t, err)
+	assert.True(t, injected)
+
+	containers := podSpec(t, newEntity).Containers
+	assert.Equal(t, resourceList("100m", "4Gi"), containers[0].Resources.Requests)
+	assert.Equal(t, resourceList("2", "4Gi"), containers[0].Resources.Limits)
+	assert.Equal(t, resourceList("100m", "1Gi"), containers[1].Resources.Requests)
+	assert.Nil(t, containers[1].Resources.Limits)
+	assert.Equal(t, "resources={requests.cpu=100m}", newEntity.Annotations()[DevOverrideAnnotation])
+
+	// The original entity is untouched, so that removing the override
+	// restores the original spec.
+	assert.Equal(t, orig, entity)
+	assert.NotContains(t, entity.Annotations(), DevOverrideAnnotation)
+}
+
+func TestInjectDevOverrideNamedContainer(t *testing.T) {
+	entity := parseOneEntity(t, prodResourcesYAML)
+
+	newEntity, injected, err := InjectDevOverride(entity, model.K8sDevOverride{
+		Resources: &v1.ResourceRequirements{
+			Requests: resourceList("10m", "64Mi"),
+		},
+		ContainerResources: map[string]v1.ResourceRequirements{
+			"api": {
+				Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("512Mi")},
+			},
+		},
+	})
+	require.NoError(t, err)
+	assert.True(t, injected)
+
+	containers := podSpec(t, newEntity).Containers
+
+	// The named override wins, and the request is lowered to fit under the new limit.
+	assert.Equal(t, resourceList("2", "512Mi"), containers[0].Resources.Requests)
+	assert.Equal(t, resourceList("2", "512Mi"), containers[0].Resources.Limits)
+
+	// Other containers get the override for all containers.
+	assert.Equal(t, resourceList("10m", "64Mi"), containers[1].Resources.Requests)
+}
+
+func TestInjectDevOverrideScheduling(t *testing.T) {
+	entity := parseOneEntity(t, prodResourcesYAML)
+	replicas := int32(1)
+	kindToleration := v1.Toleration{Key: "node-role.kubernetes.io/master", Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoSchedule}
+
+	newEntity, injected, err := InjectDevOverride(entity, model.K8sDevOverride{
+		Replicas:     &replicas,
+		NodeSelector: map[string]string{"pool": "dev", "kubernetes.io/os": "linux"},
+		Tolerations: []v1.Toleration{
+			{Key: "dedicated", Operator: v1.TolerationOpEqual, Value: "api", Effect: v1.TaintEffectNoSchedule},
+			kindToleration,
+		},
+	})
+	require.NoError(t, err)
+	assert.True(t, injected)
+
+	assert.Equal(t, int32(1), *newEntity.Obj.(*appsv1.Deployment).Spec.Replicas)
+	pod := podSpec(t, newEntity)
+	assert.Equal(t, map[string]string{"pool": "dev", "kubernetes.io/os": "linux"}, pod.NodeSelector)
+	assert.Equal(t, []v1.Toleration{
+		{Key: "dedicated", Operator: v1.TolerationOpEqual, Value: "api", Effect: v1.TaintEffectNoSchedule},
+		kindToleration,
+	}, pod.Tolerations)
+	assert.Equal(t, "replicas=1, nodeSelector=[kubernetes.io/os=linux,pool=dev], tolerations=2",
+		newEntity.Annotations()[DevOverrideAnnotation])
+
+	assert.Equal(t, int32(3), *entity.Obj.(*appsv1.Deployment).Spec.Replicas)
+}
+
+func TestInjectDevOverrideSkipsObjectsWithoutPods(t *testing.T) {
+	entity := parseOneEntity(t, testyaml.DoggosServiceYaml)
+	replicas := int32(1)
+
+	newEntity, injected, err := InjectDevOverride(entity, model.K8sDevOverride{
+		Replicas:     &replicas,
+		NodeSelector: map[string]string{"pool": "dev"},
+	})
+	require.NoError(t, err)
+	assert.False(t, injected)
+	assert.NotContains(t, newEntity.Annotations(), DevOverrideAnnotation)
+}
+
+func podSpec(t *testing.T, entity K8sEntity) *v1.PodSpec {
+	pods, err := ExtractPods(&entity)
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	return pods[0]
+}
+
+func resourceList(cpu, memory string) v1.ResourceList {
+	return v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse(cpu),
+		v1.ResourceMemory: resource.MustParse(memory),
+	}
+}
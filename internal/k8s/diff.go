@@ -0,0 +1,262 @@
+package k8s
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+type ObjectDiffStatus string
+
+const (
+	// The object doesn't exist in the cluster yet.
+	ObjectDiffAdded ObjectDiffStatus = "added"
+
+	// The object exists, and applying it would change it.
+	ObjectDiffChanged ObjectDiffStatus = "changed"
+
+	// The object exists, and applying it would be a no-op.
+	ObjectDiffUnchanged ObjectDiffStatus = "unchanged"
+)
+
+type FieldDiffType string
+
+const (
+	FieldAdded   FieldDiffType = "added"
+	FieldRemoved FieldDiffType = "removed"
+	FieldChanged FieldDiffType = "changed"
+)
+
+// A single field that differs between the live and merged versions of an object.
+type FieldDiff struct {
+	// A dotted path to the field, like spec.template.spec.containers[0].image
+	Path string `json:"path"`
+
+	Type FieldDiffType `json:"type"`
+	Old  interface{}   `json:"old,omitempty"`
+	New  interface{}   `json:"new,omitempty"`
+}
+
+// What applying an object would change in the cluster.
+type ObjectDiff struct {
+	APIVersion string           `json:"apiVersion"`
+	Kind       string           `json:"kind"`
+	Namespace  string           `json:"namespace,omitempty"`
+	Name       string           `json:"name"`
+	Status     ObjectDiffStatus `json:"status"`
+	Fields     []FieldDiff      `json:"fields,omitempty"`
+
+	// A unified diff of the live and merged objects as YAML.
+	Unified string `json:"unified"`
+}
+
+// The placeholder we show instead of Secret values.
+const maskedSecretValue = "***"
+const maskedChangedSecretValue = "*** (changed)"
+
+// Fields that the server manages, which would only add noise to a diff.
+var ignoredDiffMetadataFields = []string{
+	"managedFields",
+	"resourceVersion",
+	"uid",
+	"generation",
+	"creationTimestamp",
+	"selfLink",
+}
+
+// Computes what applying an object would change, based on the result
+// of a dry-run apply.
+//
+// Never includes the values of Secret data.
+func DiffDryRunResult(r DryRunResult) (ObjectDiff, error) {
+	merged := normalizeForDiff(r.Merged)
+	var live map[string]interface{}
+	if r.Live != nil {
+		live = normalizeForDiff(r.Live)
+	}
+
+	if r.Merged.GetKind() == "Secret" && r.Merged.GetAPIVersion() == "v1" {
+		maskSecretValues(live, merged)
+	}
+
+	diff := ObjectDiff{
+		APIVersion: r.Merged.GetAPIVersion(),
+		Kind:       r.Merged.GetKind(),
+		Namespace:  r.Merged.GetNamespace(),
+		Name:       r.Merged.GetName(),
+	}
+	if diff.Name == "" {
+		diff.Name = r.Merged.GetGenerateName()
+	}
+
+	if live == nil {
+		diff.Status = ObjectDiffAdded
+		diff.Fields = diffMaps("", map[string]interface{}{}, merged)
+	} else {
+		diff.Fields = diffMaps("", live, merged)
+		if len(diff.Fields) == 0 {
+			diff.Status = ObjectDiffUnchanged
+		} else {
+			diff.Status = ObjectDiffChanged
+		}
+	}
+
+	unified, err := unifiedDiff(diff, live, merged)
+	if err != nil {
+		return ObjectDiff{}, err
+	}
+	diff.Unified = unified
+	return diff, nil
+}
+
+// Computes diffs for each of the dry-run results, in order.
+func DiffDryRunResults(results []DryRunResult) ([]ObjectDiff, error) {
+	diffs := make([]ObjectDiff, 0, len(results))
+	for _, r := range results {
+		diff, err := DiffDryRunResult(r)
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs, nil
+}
+
+func normalizeForDiff(obj *unstructured.Unstructured) map[string]interface{} {
+	content := runtime.DeepCopyJSON(obj.Object)
+	delete(content, "status")
+	if md, ok := content["metadata"].(map[string]interface{}); ok {
+		for _, field := range ignoredDiffMetadataFields {
+			delete(md, field)
+		}
+		if annotations, ok := md["annotations"].(map[string]interface{}); ok {
+			delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+			if len(annotations) == 0 {
+				delete(md, "annotations")
+			}
+		}
+	}
+	return content
+}
+
+// Replaces Secret values with placeholders.
+//
+// A value that would change gets a different placeholder in the merged
+// object, so that the diff still shows the change.
+func maskSecretValues(live, merged map[string]interface{}) {
+	for _, field := range []string{"data", "stringData"} {
+		liveValues, _ := live[field].(map[string]interface{})
+		mergedValues, _ := merged[field].(map[string]interface{})
+		for key, value := range mergedValues {
+			liveValue, ok := liveValues[key]
+			if ok && !reflect.DeepEqual(liveValue, value) {
+				mergedValues[key] = maskedChangedSecretValue
+			} else {
+				mergedValues[key] = maskedSecretValue
+			}
+		}
+		for key := range liveValues {
+			liveValues[key] = maskedSecretValue
+		}
+	}
+}
+
+func diffFields(path string, old, new interface{}) []FieldDiff {
+	oldMap, oldIsMap := old.(map[string]interface{})
+	newMap, newIsMap := new.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		return diffMaps(path, oldMap, newMap)
+	}
+
+	oldList, oldIsList := old.([]interface{})
+	newList, newIsList := new.([]interface{})
+	if oldIsList && newIsList {
+		return diffLists(path, oldList, newList)
+	}
+
+	if reflect.DeepEqual(old, new) {
+		return nil
+	}
+	return []FieldDiff{{Path: path, Type: FieldChanged, Old: old, New: new}}
+}
+
+func diffMaps(path string, old, new map[string]interface{}) []FieldDiff {
+	keys := make([]string, 0, len(old)+len(new))
+	for key := range old {
+		keys = append(keys, key)
+	}
+	for key := range new {
+		if _, ok := old[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var result []FieldDiff
+	for _, key := range keys {
+		childPath := key
+		if path != "" {
+			childPath = path + "." + key
+		}
+
+		oldValue, hasOld := old[key]
+		newValue, hasNew := new[key]
+		if !hasNew {
+			result = append(result, FieldDiff{Path: childPath, Type: FieldRemoved, Old: oldValue})
+			continue
+		}
+		if !hasOld {
+			result = append(result, FieldDiff{Path: childPath, Type: FieldAdded, New: newValue})
+			continue
+		}
+		result = append(result, diffFields(childPath, oldValue, newValue)...)
+	}
+	return result
+}
+
+func diffLists(path string, old, new []interface{}) []FieldDiff {
+	var result []FieldDiff
+	for i := 0; i < len(old) || i < len(new); i++ {
+		childPath := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case i >= len(new):
+			result = append(result, FieldDiff{Path: childPath, Type: FieldRemoved, Old: old[i]})
+		case i >= len(old):
+			result = append(result, FieldDiff{Path: childPath, Type: FieldAdded, New: new[i]})
+		default:
+			result = append(result, diffFields(childPath, old[i], new[i])...)
+		}
+	}
+	return result
+}
+
+func unifiedDiff(diff ObjectDiff, live, merged map[string]interface{}) (string, error) {
+	liveYAML := ""
+	if live != nil {
+		b, err := yaml.Marshal(live)
+		if err != nil {
+			return "", err
+		}
+		liveYAML = string(b)
+	}
+
+	mergedYAML, err := yaml.Marshal(merged)
+	if err != nil {
+		return "", err
+	}
+
+	name := strings.Join([]string{diff.Kind, diff.Namespace, diff.Name}, "/")
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(liveYAML),
+		B:        difflib.SplitLines(string(mergedYAML)),
+		FromFile: "live/" + name,
+		ToFile:   "merged/" + name,
+		Context:  3,
+	})
+}
@@ -0,0 +1,136 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
+)
+
+func TestDiffNewObjectIsAddition(t *testing.T) {
+	merged := mustParseUnstructured(t, testyaml.SanchoYAML)
+
+	diff, err := DiffDryRunResult(DryRunResult{Merged: merged})
+	require.NoError(t, err)
+
+	assert.Equal(t, ObjectDiffAdded, diff.Status)
+	assert.Equal(t, "Deployment", diff.Kind)
+	assert.Equal(t, "sancho", diff.Name)
+	for _, field := range diff.Fields {
+		assert.Equal(t, FieldAdded, field.Type, field.Path)
+	}
+	assert.Contains(t, diff.Unified, "--- live/Deployment//sancho")
+	assert.Contains(t, diff.Unified, "+++ merged/Deployment//sancho")
+	assert.Contains(t, diff.Unified, "+kind: Deployment")
+	assert.NotContains(t, diff.Unified, "\n-")
+}
+
+func TestDiffChangedImage(t *testing.T) {
+	live := mustParseUnstructured(t, testyaml.SanchoYAML)
+	live.SetResourceVersion("1234")
+	live.SetUID("some-uid")
+	live.Object["status"] = map[string]interface{}{"replicas": int64(1)}
+
+	merged := mustParseUnstructured(t, testyaml.SanchoYAML)
+	containers, _, _ := unstructured.NestedSlice(merged.Object, "spec", "template", "spec", "containers")
+	containers[0].(map[string]interface{})["image"] = "gcr.io/some-project-162817/sancho:tilt-123"
+	require.NoError(t, unstructured.SetNestedSlice(merged.Object, containers, "spec", "template", "spec", "containers"))
+
+	diff, err := DiffDryRunResult(DryRunResult{Live: live, Merged: merged})
+	require.NoError(t, err)
+
+	assert.Equal(t, ObjectDiffChanged, diff.Status)
+	assert.Equal(t, []FieldDiff{{
+		Path: "spec.template.spec.containers[0].image",
+		Type: FieldChanged,
+		Old:  "gcr.io/some-project-162817/sancho",
+		New:  "gcr.io/some-project-162817/sancho:tilt-123",
+	}}, diff.Fields)
+	assert.Contains(t, diff.Unified, "-        image: gcr.io/some-project-162817/sancho\n")
+	assert.Contains(t, diff.Unified, "+        image: gcr.io/some-project-162817/sancho:tilt-123\n")
+	assert.NotContains(t, diff.Unified, "resourceVersion")
+	assert.NotContains(t, diff.Unified, "status")
+}
+
+func TestDiffUnchanged(t *testing.T) {
+	live := mustParseUnstructured(t, testyaml.SanchoYAML)
+	merged := mustParseUnstructured(t, testyaml.SanchoYAML)
+	merged.SetResourceVersion("5678")
+
+	diff, err := DiffDryRunResult(DryRunResult{Live: live, Merged: merged})
+	require.NoError(t, err)
+	assert.Equal(t, ObjectDiffUnchanged, diff.Status)
+	assert.Empty(t, diff.Fields)
+	assert.Equal(t, "", diff.Unified)
+}
+
+func TestDiffMasksSecretData(t *testing.T) {
+	live := mustParseUnstructured(t, testyaml.SecretYaml)
+	merged := mustParseUnstructured(t, testyaml.SecretYaml)
+	require.NoError(t, unstructured.SetNestedField(merged.Object, "bmV3LXBhc3N3b3Jk", "data", "password"))
+
+	diff, err := DiffDryRunResult(DryRunResult{Live: live, Merged: merged})
+	require.NoError(t, err)
+
+	assert.Equal(t, ObjectDiffChanged, diff.Status)
+	assert.Equal(t, []FieldDiff{{
+		Path: "data.password",
+		Type: FieldChanged,
+		Old:  maskedSecretValue,
+		New:  maskedChangedSecretValue,
+	}}, diff.Fields)
+	assert.NotContains(t, diff.Unified, "bmV3LXBhc3N3b3Jk")
+	assert.NotContains(t, diff.Unified, secretValue(t, live, "password"))
+}
+
+func TestDiffMasksNewSecretData(t *testing.T) {
+	merged := mustParseUnstructured(t, testyaml.SecretYaml)
+	password := secretValue(t, merged, "password")
+
+	diff, err := DiffDryRunResult(DryRunResult{Merged: merged})
+	require.NoError(t, err)
+
+	assert.Equal(t, ObjectDiffAdded, diff.Status)
+	assert.NotContains(t, diff.Unified, password)
+	assert.Contains(t, diff.Unified, "password: '***'")
+}
+
+func TestFakeDryRunApply(t *testing.T) {
+	entities, err := ParseYAMLFromString(testyaml.SanchoYAML + "\n---\n" + testyaml.SecretYaml)
+	require.NoError(t, err)
+
+	live := mustParseUnstructured(t, testyaml.SanchoYAML)
+	live.SetNamespace("default")
+
+	client := NewFakeK8sClient(t)
+	client.DryRunLiveObjects = []*unstructured.Unstructured{live}
+
+	results, err := client.DryRunApply(context.Background(), entities)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "sancho", results[0].Live.GetName())
+	assert.Nil(t, results[1].Live)
+	assert.Equal(t, "Secret", results[1].Merged.GetKind())
+}
+
+func mustParseUnstructured(t *testing.T, yaml string) *unstructured.Unstructured {
+	t.Helper()
+	entities, err := ParseYAMLFromString(yaml)
+	require.NoError(t, err)
+	require.Len(t, entities, 1)
+	obj, err := EntityToUnstructured(entities[0])
+	require.NoError(t, err)
+	return obj
+}
+
+func secretValue(t *testing.T, obj *unstructured.Unstructured, key string) string {
+	t.Helper()
+	value, found, err := unstructured.NestedString(obj.Object, "data", key)
+	require.NoError(t, err)
+	require.True(t, found)
+	return value
+}
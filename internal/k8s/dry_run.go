@@ -0,0 +1,125 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+)
+
+// The result of a server-side dry run of a single object's apply.
+type DryRunResult struct {
+	// The object as it exists in the cluster right now.
+	//
+	// Nil if the object doesn't exist yet.
+	Live *unstructured.Unstructured
+
+	// The object as it would exist in the cluster after the apply.
+	Merged *unstructured.Unstructured
+}
+
+// Applies the entities the same way Upsert does (a client-side apply, merged
+// against the last-applied-configuration annotation), as a server-side dry run,
+// so that the results match what the next real apply would do.
+func (k *K8sClient) DryRunApply(ctx context.Context, entities []K8sEntity) ([]DryRunResult, error) {
+	results := make([]DryRunResult, 0, len(entities))
+	for _, e := range entities {
+		result, err := k.dryRunApplyOne(ctx, e)
+		if err != nil {
+			return nil, errors.Wrapf(err, "dry-run apply %s/%s", e.GVK().Kind, e.Name())
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (k *K8sClient) dryRunApplyOne(ctx context.Context, e K8sEntity) (DryRunResult, error) {
+	obj, err := EntityToUnstructured(e)
+	if err != nil {
+		return DryRunResult{}, err
+	}
+
+	mapping, err := k.forceRESTMapping(e.GVK())
+	if err != nil {
+		return DryRunResult{}, err
+	}
+
+	var ri dynamic.ResourceInterface = k.dynamic.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		ns := obj.GetNamespace()
+		if ns == "" {
+			ns = k.configNamespace.String()
+			obj.SetNamespace(ns)
+		}
+		ri = k.dynamic.Resource(mapping.Resource).Namespace(ns)
+	}
+
+	// Objects with only a generateName get a fresh name every time they're
+	// created, so they never have a live counterpart.
+	if obj.GetName() == "" {
+		merged, err := ri.Create(ctx, obj, metav1.CreateOptions{
+			DryRun: []string{metav1.DryRunAll},
+		})
+		if err != nil {
+			return DryRunResult{}, err
+		}
+		return DryRunResult{Merged: merged}, nil
+	}
+
+	live, err := ri.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return DryRunResult{}, err
+		}
+		live = nil
+	}
+
+	resources, err := k.buildResourceList(ctx, e)
+	if err != nil {
+		return DryRunResult{}, err
+	}
+	err = k.resourceClient.DryRunApply(resources)
+	if err != nil {
+		return DryRunResult{}, err
+	}
+	if len(resources) != 1 {
+		return DryRunResult{}, fmt.Errorf("expected 1 object, got %d", len(resources))
+	}
+
+	merged, err := toUnstructured(resources[0].Object)
+	if err != nil {
+		return DryRunResult{}, err
+	}
+	return DryRunResult{Live: live, Merged: merged}, nil
+}
+
+func toUnstructured(obj runtime.Object) (*unstructured.Unstructured, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u, nil
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
+	return u, nil
+}
+
+// Converts a parsed entity to its unstructured form, making sure
+// that the apiVersion and kind are filled in.
+func EntityToUnstructured(e K8sEntity) (*unstructured.Unstructured, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(e.Obj)
+	if err != nil {
+		return nil, errors.Wrapf(err, "converting %s/%s", e.GVK().Kind, e.Name())
+	}
+	obj := &unstructured.Unstructured{Object: content}
+	obj.SetGroupVersionKind(e.GVK())
+	return obj, nil
+}
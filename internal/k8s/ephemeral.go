@@ -0,0 +1,56 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Returned when the cluster can't run ephemeral containers, either because it's
+// too old or because the EphemeralContainers feature gate is off.
+var ErrEphemeralContainersUnsupported = errors.New(
+	"ephemeral containers are not supported by this cluster (requires Kubernetes 1.23+, or the EphemeralContainers feature gate)")
+
+// Adds an ephemeral container to a running pod, the same way `kubectl debug` does.
+//
+// Ephemeral containers can't be changed or removed once they're added,
+// so they go away when the pod does.
+func (k *K8sClient) AttachEphemeralContainer(ctx context.Context, podID PodID, n Namespace, ctr v1.EphemeralContainer) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"ephemeralContainers": []v1.EphemeralContainer{ctr},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "attaching ephemeral container")
+	}
+
+	pod, err := k.core.Pods(n.String()).Patch(ctx, podID.String(), types.StrategicMergePatchType,
+		patch, metav1.PatchOptions{}, "ephemeralcontainers")
+	if err != nil {
+		// If the ephemeralcontainers subresource doesn't exist at all, the server
+		// returns a NotFound without the name of the pod.
+		statusErr, ok := err.(*apierrors.StatusError)
+		if ok && apierrors.IsNotFound(err) &&
+			(statusErr.ErrStatus.Details == nil || statusErr.ErrStatus.Details.Name == "") {
+			return ErrEphemeralContainersUnsupported
+		}
+		if apierrors.IsMethodNotSupported(err) {
+			return ErrEphemeralContainersUnsupported
+		}
+		return errors.Wrapf(maybeUnpackStatusError(err), "attaching ephemeral container to pod %s", podID)
+	}
+
+	// When the feature gate is off, the server silently drops the container.
+	for _, ec := range pod.Spec.EphemeralContainers {
+		if ec.Name == ctr.Name {
+			return nil
+		}
+	}
+	return ErrEphemeralContainersUnsupported
+}
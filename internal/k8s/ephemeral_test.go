@@ -0,0 +1,107 @@
+package k8s
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kfake "k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+
+	"github.com/tilt-dev/tilt/internal/testutils"
+)
+
+func TestAttachEphemeralContainer(t *testing.T) {
+	cs := kfake.NewSimpleClientset(&v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-a", Namespace: "default"},
+	})
+	kCli := K8sClient{core: cs.CoreV1()}
+	ctx, _, _ := testutils.CtxAndAnalyticsForTest()
+
+	err := kCli.AttachEphemeralContainer(ctx, "pod-a", "default", v1.EphemeralContainer{
+		EphemeralContainerCommon: v1.EphemeralContainerCommon{
+			Name:  "debugger-abcde",
+			Image: "busybox",
+			Stdin: true,
+			TTY:   true,
+		},
+		TargetContainerName: "app",
+	})
+	require.NoError(t, err)
+
+	var patch ktesting.PatchAction
+	for _, a := range cs.Actions() {
+		if pa, ok := a.(ktesting.PatchAction); ok {
+			patch = pa
+		}
+	}
+	require.NotNil(t, patch)
+	assert.Equal(t, "ephemeralcontainers", patch.GetSubresource())
+	assert.Equal(t, types.StrategicMergePatchType, patch.GetPatchType())
+	assert.JSONEq(t, `{"spec":{"ephemeralContainers":[{
+		"name":"debugger-abcde",
+		"image":"busybox",
+		"resources":{},
+		"stdin":true,
+		"tty":true,
+		"targetContainerName":"app"
+	}]}}`, string(patch.GetPatch()))
+
+	pod, err := cs.CoreV1().Pods("default").Get(ctx, "pod-a", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, pod.Spec.EphemeralContainers, 1)
+	assert.Equal(t, "debugger-abcde", pod.Spec.EphemeralContainers[0].Name)
+}
+
+func TestAttachEphemeralContainerUnsupported(t *testing.T) {
+	cs := kfake.NewSimpleClientset()
+	cs.PrependReactor("patch", "pods", func(action ktesting.Action) (bool, runtime.Object, error) {
+		// Older API servers don't have the subresource at all.
+		return true, nil, &apierrors.StatusError{ErrStatus: metav1.Status{
+			Status: metav1.StatusFailure,
+			Reason: metav1.StatusReasonNotFound,
+			Code:   http.StatusNotFound,
+		}}
+	})
+	kCli := K8sClient{core: cs.CoreV1()}
+	ctx, _, _ := testutils.CtxAndAnalyticsForTest()
+
+	err := kCli.AttachEphemeralContainer(ctx, "pod-a", "default", v1.EphemeralContainer{
+		EphemeralContainerCommon: v1.EphemeralContainerCommon{Name: "debugger-abcde", Image: "busybox"},
+	})
+	assert.Equal(t, ErrEphemeralContainersUnsupported, err)
+}
+
+func TestAttachEphemeralContainerDroppedByServer(t *testing.T) {
+	cs := kfake.NewSimpleClientset()
+	cs.PrependReactor("patch", "pods", func(action ktesting.Action) (bool, runtime.Object, error) {
+		// With the feature gate off, the server accepts the patch but ignores the field.
+		return true, &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-a", Namespace: "default"}}, nil
+	})
+	kCli := K8sClient{core: cs.CoreV1()}
+	ctx, _, _ := testutils.CtxAndAnalyticsForTest()
+
+	err := kCli.AttachEphemeralContainer(ctx, "pod-a", "default", v1.EphemeralContainer{
+		EphemeralContainerCommon: v1.EphemeralContainerCommon{Name: "debugger-abcde", Image: "busybox"},
+	})
+	assert.Equal(t, ErrEphemeralContainersUnsupported, err)
+}
+
+func TestAttachEphemeralContainerMissingPod(t *testing.T) {
+	cs := kfake.NewSimpleClientset()
+	kCli := K8sClient{core: cs.CoreV1()}
+	ctx, _, _ := testutils.CtxAndAnalyticsForTest()
+
+	err := kCli.AttachEphemeralContainer(ctx, "pod-a", "default", v1.EphemeralContainer{
+		EphemeralContainerCommon: v1.EphemeralContainerCommon{Name: "debugger-abcde", Image: "busybox"},
+	})
+	require.Error(t, err)
+	assert.NotEqual(t, ErrEphemeralContainersUnsupported, err)
+	assert.Contains(t, err.Error(), "attaching ephemeral container to pod pod-a")
+}
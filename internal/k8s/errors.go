@@ -28,6 +28,15 @@ func isNotFoundError(err error) bool {
 		strings.Contains(err.Error(), "object not found")
 }
 
+func isAlreadyExistsError(err error) bool {
+	if err == nil {
+		return false
+	}
+	return apierrors.IsAlreadyExists(err) ||
+		// Helm wraps the apiserver's error in its own.
+		strings.Contains(err.Error(), "already exists")
+}
+
 func isMissingKindError(err error) bool {
 	if err == nil {
 		return false
@@ -26,7 +26,11 @@ func (k *K8sClient) Exec(ctx context.Context, podID PodID, cName container.Name,
 		Stderr:    stderr != nil,
 	}, scheme.ParameterCodec)
 
-	exec, err := remotecommand.NewSPDYExecutor(k.restConfig, "POST", req.URL())
+	config, err := k.credentialPlugin.upgradeConfig(ctx, k.restConfig)
+	if err != nil {
+		return err
+	}
+	exec, err := remotecommand.NewSPDYExecutor(config, "POST", req.URL())
 	if err != nil {
 		return err
 	}
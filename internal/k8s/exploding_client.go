@@ -22,6 +22,13 @@ type explodingClient struct {
 	err error
 }
 
+// A client that returns errors on every call that would talk to the cluster.
+//
+// Useful for commands that must never contact a cluster.
+func NewExplodingClient(err error) Client {
+	return &explodingClient{err: err}
+}
+
 func (ec *explodingClient) Upsert(ctx context.Context, entities []K8sEntity, timeout time.Duration) ([]K8sEntity, error) {
 	return nil, errors.Wrap(ec.err, "could not set up k8s client")
 }
@@ -70,6 +77,26 @@ func (ec *explodingClient) WatchEvents(ctx context.Context, ns Namespace) (<-cha
 	return nil, errors.Wrap(ec.err, "could not set up k8s client")
 }
 
+func (ec *explodingClient) ListPods(ctx context.Context, ns Namespace) ([]*v1.Pod, error) {
+	return nil, errors.Wrap(ec.err, "could not set up k8s client")
+}
+
+func (ec *explodingClient) ListServices(ctx context.Context, ns Namespace) ([]*v1.Service, error) {
+	return nil, errors.Wrap(ec.err, "could not set up k8s client")
+}
+
+func (ec *explodingClient) ListEvents(ctx context.Context, ns Namespace) ([]*v1.Event, error) {
+	return nil, errors.Wrap(ec.err, "could not set up k8s client")
+}
+
+func (ec *explodingClient) CheckConnected(ctx context.Context) error {
+	return errors.Wrap(ec.err, "could not set up k8s client")
+}
+
+func (ec *explodingClient) ClockSkew(ctx context.Context) (ClockSkew, error) {
+	return 0, errors.Wrap(ec.err, "could not set up k8s client")
+}
+
 func (ec *explodingClient) WatchMeta(ctx context.Context, gvk schema.GroupVersionKind, ns Namespace) (<-chan metav1.Object, error) {
 	return nil, errors.Wrap(ec.err, "could not set up k8s client")
 }
@@ -78,10 +105,22 @@ func (ec *explodingClient) ContainerRuntime(ctx context.Context) container.Runti
 	return container.RuntimeUnknown
 }
 
+func (ec *explodingClient) NodeContainerRuntime(ctx context.Context, nodeName string) container.Runtime {
+	return container.RuntimeUnknown
+}
+
+func (ec *explodingClient) NodePlatforms(ctx context.Context) []string {
+	return nil
+}
+
 func (ec *explodingClient) LocalRegistry(ctx context.Context) container.Registry {
 	return container.Registry{}
 }
 
+func (ec *explodingClient) RefreshLocalRegistry(ctx context.Context) (container.Registry, bool) {
+	return container.Registry{}, false
+}
+
 func (ec *explodingClient) NodeIP(ctx context.Context) NodeIP {
 	return ""
 }
@@ -89,3 +128,11 @@ func (ec *explodingClient) NodeIP(ctx context.Context) NodeIP {
 func (ec *explodingClient) Exec(ctx context.Context, podID PodID, cName container.Name, n Namespace, cmd []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
 	return errors.Wrap(ec.err, "could not set up k8s client")
 }
+
+func (ec *explodingClient) DryRunApply(ctx context.Context, entities []K8sEntity) ([]DryRunResult, error) {
+	return nil, errors.Wrap(ec.err, "could not set up k8s client")
+}
+
+func (ec *explodingClient) AttachEphemeralContainer(ctx context.Context, podID PodID, n Namespace, ctr v1.EphemeralContainer) error {
+	return errors.Wrap(ec.err, "could not set up k8s client")
+}
@@ -15,10 +15,12 @@ import (
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/tilt-dev/tilt/internal/container"
+	"github.com/tilt-dev/tilt/internal/k8s/portforward"
 	"github.com/tilt-dev/tilt/pkg/logger"
 )
 
@@ -48,7 +50,10 @@ type FakeK8sClient struct {
 	Lb   LoadBalancerSpec
 
 	DeletedYaml string
-	DeleteError error
+
+	// The YAML passed to every call to Delete, in order.
+	DeletedYamls []string
+	DeleteError  error
 
 	LastPodQueryNamespace Namespace
 	LastPodQueryImage     reference.NamedTagged
@@ -68,6 +73,15 @@ type FakeK8sClient struct {
 
 	EventsWatchErr error
 
+	// Returned from CheckConnected and the List methods, to simulate losing the cluster.
+	connectionError error
+	clockSkew       ClockSkew
+
+	// When true, changes to pods, services, and events are stored but not sent
+	// to watchers, to simulate a watch that missed events (e.g., while the
+	// machine was asleep).
+	inWatchGap bool
+
 	UpsertError      error
 	LastUpsertResult []K8sEntity
 	UpsertTimeout    time.Duration
@@ -76,6 +90,18 @@ type FakeK8sClient struct {
 	Registry   container.Registry
 	FakeNodeIP NodeIP
 
+	// The runtime of each node, for simulating clusters whose nodes
+	// don't all use the same runtime. Nodes not in the map use Runtime.
+	NodeRuntimes map[string]container.Runtime
+
+	// The platforms of the cluster's nodes, like "linux/amd64".
+	Platforms []string
+
+	// The Registry as of the last time we read it, so that
+	// RefreshLocalRegistry can tell when a test changes it.
+	lastRegistry container.Registry
+	registryRead bool
+
 	// entities are injected objects keyed by UID.
 	entities map[types.UID]K8sEntity
 	// currentVersions maintains a mapping of object name to UID which represents the most recently injected value.
@@ -92,6 +118,15 @@ type FakeK8sClient struct {
 	ExecCalls   []ExecCall
 	ExecOutputs []io.Reader
 	ExecErrors  []error
+
+	// Canned cluster state for DryRunApply, matched against entities by kind, namespace, and name.
+	DryRunLiveObjects []*unstructured.Unstructured
+	DryRunError       error
+	LastDryRunApply   []K8sEntity
+
+	// Ephemeral containers attached with AttachEphemeralContainer, keyed by pod.
+	EphemeralContainers map[types.NamespacedName][]v1.EphemeralContainer
+	EphemeralError      error
 }
 
 type ExecCall struct {
@@ -125,6 +160,9 @@ func (c *FakeK8sClient) UpsertService(s *v1.Service) {
 	defer c.mu.Unlock()
 
 	c.services[types.NamespacedName{Name: s.Name, Namespace: s.Namespace}] = s
+	if c.inWatchGap {
+		return
+	}
 	for _, w := range c.serviceWatches {
 		if w.ns != Namespace(s.Namespace) {
 			continue
@@ -138,6 +176,9 @@ func (c *FakeK8sClient) UpsertPod(pod *v1.Pod) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.pods[types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}] = pod
+	if c.inWatchGap {
+		return
+	}
 	for _, w := range c.podWatches {
 		if w.ns != Namespace(pod.Namespace) {
 			continue
@@ -152,6 +193,9 @@ func (c *FakeK8sClient) UpsertEvent(event *v1.Event) {
 	defer c.mu.Unlock()
 
 	c.events[types.NamespacedName{Name: event.Name, Namespace: event.Namespace}] = event
+	if c.inWatchGap {
+		return
+	}
 	for _, w := range c.eventWatches {
 		if w.ns != Namespace(event.Namespace) {
 			continue
@@ -161,6 +205,93 @@ func (c *FakeK8sClient) UpsertEvent(event *v1.Event) {
 	}
 }
 
+func (c *FakeK8sClient) SetConnectionError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.connectionError = err
+}
+
+// Stop sending changes to watchers until EndWatchGap is called.
+//
+// Changes made in the meantime are only visible by listing.
+func (c *FakeK8sClient) StartWatchGap() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inWatchGap = true
+}
+
+func (c *FakeK8sClient) EndWatchGap() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inWatchGap = false
+}
+
+func (c *FakeK8sClient) ListPods(ctx context.Context, ns Namespace) ([]*v1.Pod, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.connectionError != nil {
+		return nil, c.connectionError
+	}
+
+	var result []*v1.Pod
+	for _, pod := range c.pods {
+		if Namespace(pod.Namespace) == ns {
+			result = append(result, pod)
+		}
+	}
+	return result, nil
+}
+
+func (c *FakeK8sClient) ListServices(ctx context.Context, ns Namespace) ([]*v1.Service, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.connectionError != nil {
+		return nil, c.connectionError
+	}
+
+	var result []*v1.Service
+	for _, service := range c.services {
+		if Namespace(service.Namespace) == ns {
+			result = append(result, service)
+		}
+	}
+	return result, nil
+}
+
+func (c *FakeK8sClient) ListEvents(ctx context.Context, ns Namespace) ([]*v1.Event, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.connectionError != nil {
+		return nil, c.connectionError
+	}
+
+	var result []*v1.Event
+	for _, event := range c.events {
+		if Namespace(event.Namespace) == ns {
+			result = append(result, event)
+		}
+	}
+	return result, nil
+}
+
+func (c *FakeK8sClient) CheckConnected(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.connectionError
+}
+
+func (c *FakeK8sClient) SetClockSkew(skew ClockSkew) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clockSkew = skew
+}
+
+func (c *FakeK8sClient) ClockSkew(ctx context.Context) (ClockSkew, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.clockSkew, c.connectionError
+}
+
 func (c *FakeK8sClient) PodFromInformerCache(ctx context.Context, nn types.NamespacedName) (*v1.Pod, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -261,6 +392,9 @@ func (c *FakeK8sClient) EmitPodDelete(p *v1.Pod) {
 	defer c.mu.Unlock()
 
 	delete(c.pods, types.NamespacedName{Name: p.Name, Namespace: p.Namespace})
+	if c.inWatchGap {
+		return
+	}
 	for _, w := range c.podWatches {
 		if w.ns != Namespace(p.Namespace) {
 			continue
@@ -385,6 +519,7 @@ func (c *FakeK8sClient) Delete(ctx context.Context, entities []K8sEntity) error
 		return errors.Wrap(err, "kubectl delete")
 	}
 	c.DeletedYaml = yaml
+	c.DeletedYamls = append(c.DeletedYamls, yaml)
 	return nil
 }
 
@@ -411,6 +546,11 @@ func (c *FakeK8sClient) GetMetaByReference(ctx context.Context, ref v1.ObjectRef
 
 	c.getByReferenceCallCount++
 	resp, ok := c.entities[ref.UID]
+	if !ok && ref.UID == "" {
+		// Without a UID, look up the current version by name.
+		resp, ok = c.entities[c.currentVersions[ref.Name]]
+		ok = ok && resp.GVK().Kind == ref.Kind && resp.Namespace().String() == Namespace(ref.Namespace).String()
+	}
 	if !ok {
 		logger.Get(ctx).Infof("FakeK8sClient.GetMetaByReference: resource not found: %s", ref.Name)
 		return nil, apierrors.NewNotFound(v1.Resource(ref.Kind), ref.Name)
@@ -423,6 +563,9 @@ func (c *FakeK8sClient) ListMeta(_ context.Context, gvk schema.GroupVersionKind,
 	defer c.mu.Unlock()
 
 	c.listCallCount++
+	if c.connectionError != nil {
+		return nil, c.connectionError
+	}
 	if c.listReturnsEmpty {
 		return nil, nil
 	}
@@ -430,7 +573,7 @@ func (c *FakeK8sClient) ListMeta(_ context.Context, gvk schema.GroupVersionKind,
 	result := make([]metav1.Object, 0)
 	for _, uid := range c.currentVersions {
 		entity := c.entities[uid]
-		if entity.Namespace() != ns {
+		if !entity.IsClusterScoped() && entity.Namespace().String() != ns.String() {
 			continue
 		}
 		if entity.GVK() != gvk {
@@ -533,13 +676,44 @@ func (c *FakeK8sClient) ContainerRuntime(ctx context.Context) container.Runtime
 	return container.RuntimeDocker
 }
 
+func (c *FakeK8sClient) NodeContainerRuntime(ctx context.Context, nodeName string) container.Runtime {
+	c.mu.Lock()
+	runtime, ok := c.NodeRuntimes[nodeName]
+	c.mu.Unlock()
+
+	if ok {
+		return runtime
+	}
+	return c.ContainerRuntime(ctx)
+}
+
+func (c *FakeK8sClient) NodePlatforms(ctx context.Context) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.Platforms...)
+}
+
 func (c *FakeK8sClient) LocalRegistry(ctx context.Context) container.Registry {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if !c.registryRead {
+		c.lastRegistry = c.Registry
+		c.registryRead = true
+	}
 	return c.Registry
 }
 
+func (c *FakeK8sClient) RefreshLocalRegistry(ctx context.Context) (container.Registry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	changed := c.registryRead && c.Registry != c.lastRegistry
+	c.lastRegistry = c.Registry
+	c.registryRead = true
+	return c.Registry, changed
+}
+
 func (c *FakeK8sClient) NodeIP(ctx context.Context) NodeIP {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -645,6 +819,10 @@ func (pf FakePortForwarder) ReadyCh() <-chan struct{} {
 	return pf.ready
 }
 
+func (pf FakePortForwarder) Transport() string {
+	return portforward.TransportSPDY
+}
+
 // TriggerFailure allows tests to inject errors during forwarding that will be returned by ForwardPorts.
 func (pf FakePortForwarder) TriggerFailure(err error) {
 	pf.done <- err
@@ -746,3 +924,63 @@ func (c *FakePortForwardClient) PortForwardCalls() []PortForwardCall {
 	}
 	return calls
 }
+
+func (c *FakeK8sClient) DryRunApply(ctx context.Context, entities []K8sEntity) ([]DryRunResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.LastDryRunApply = entities
+	if c.DryRunError != nil {
+		return nil, c.DryRunError
+	}
+
+	results := make([]DryRunResult, 0, len(entities))
+	for _, e := range entities {
+		merged, err := EntityToUnstructured(e)
+		if err != nil {
+			return nil, err
+		}
+		if merged.GetNamespace() == "" {
+			merged.SetNamespace(DefaultNamespace.String())
+		}
+
+		result := DryRunResult{Merged: merged}
+		if merged.GetName() != "" {
+			for _, live := range c.DryRunLiveObjects {
+				if live.GetKind() == merged.GetKind() &&
+					live.GetNamespace() == merged.GetNamespace() &&
+					live.GetName() == merged.GetName() {
+					result.Live = live.DeepCopy()
+					break
+				}
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (c *FakeK8sClient) AttachEphemeralContainer(ctx context.Context, podID PodID, n Namespace, ctr v1.EphemeralContainer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.EphemeralError != nil {
+		return c.EphemeralError
+	}
+
+	nn := types.NamespacedName{Name: podID.String(), Namespace: n.String()}
+	pod, ok := c.pods[nn]
+	if !ok {
+		return apierrors.NewNotFound(PodGVR.GroupResource(), nn.Name)
+	}
+
+	pod = pod.DeepCopy()
+	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, ctr)
+	c.pods[nn] = pod
+
+	if c.EphemeralContainers == nil {
+		c.EphemeralContainers = make(map[types.NamespacedName][]v1.EphemeralContainer)
+	}
+	c.EphemeralContainers[nn] = append(c.EphemeralContainers[nn], ctr)
+	return nil
+}
@@ -0,0 +1,44 @@
+package k8s
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/tilt-dev/tilt/internal/container"
+)
+
+// The pod operations that Tilt's in-cluster agent runs on Tilt's behalf.
+//
+// Client implements this, so the agent can stand in for it.
+type PodOps interface {
+	Exec(ctx context.Context, podID PodID, cName container.Name, n Namespace, cmd []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error
+	ContainerLogs(ctx context.Context, podID PodID, cName container.Name, n Namespace, startTime time.Time) (io.ReadCloser, error)
+}
+
+var _ PodOps = Client(nil)
+
+// Creates a client from the service account of the pod we're running in.
+//
+// There's no kubeconfig in a pod, so only the pod operations work.
+func NewInClusterPodOps() (PodOps, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "loading in-cluster config")
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating in-cluster client")
+	}
+
+	return &K8sClient{
+		core:       clientset.CoreV1(),
+		restConfig: config,
+		clientset:  clientset,
+	}, nil
+}
@@ -0,0 +1,33 @@
+package k8s
+
+import (
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+)
+
+// Records the hash of an infrastructure object's YAML when Tilt applied it,
+// so that we can tell whether the Tiltfile has changed the object since.
+const AppliedHashAnnotation = "tilt.dev/applied-hash"
+
+// Whether an object is applied with the infrastructure discipline, either
+// because its resource is, or because the object opts in with an annotation.
+func IsInfrastructure(e K8sEntity, discipline v1alpha1.KubernetesApplyDiscipline) bool {
+	if discipline == v1alpha1.KubernetesApplyDisciplineInfrastructure {
+		return true
+	}
+	return e.Annotations()[v1alpha1.AnnotationApplyDiscipline] ==
+		string(v1alpha1.KubernetesApplyDisciplineInfrastructure)
+}
+
+// Stamps the object's own metadata with the hash of its YAML.
+func InjectAppliedHash(entity K8sEntity, hash string) K8sEntity {
+	entity = entity.DeepCopy()
+	meta := entity.Meta()
+
+	annotations := meta.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string, 1)
+	}
+	annotations[AppliedHashAnnotation] = hash
+	meta.SetAnnotations(annotations)
+	return entity
+}
@@ -219,3 +219,21 @@ func (e K8sEntity) MatchesMetadataLabels(labels map[string]string) (bool, error)
 	return true, nil
 
 }
+
+// Stamps the object's own metadata (but not its pod templates or selectors)
+// with the manifest that applied it.
+func InjectManagedObjectIdentity(entity K8sEntity, id ManagedObjectIdentity) K8sEntity {
+	entity = entity.DeepCopy()
+	meta := entity.Meta()
+
+	labels := meta.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string, 2)
+	}
+	labels[ManifestNameLabel] = ManifestNameLabelValue(id.Manifest)
+	if id.Project != "" {
+		labels[ProjectLabel] = id.Project
+	}
+	meta.SetLabels(labels)
+	return entity
+}
@@ -1,6 +1,7 @@
 package k8s
 
 import (
+	"strings"
 	"testing"
 
 	extbeta1 "k8s.io/api/extensions/v1beta1"
@@ -12,6 +13,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/api/apps/v1beta2"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
 	"github.com/tilt-dev/tilt/pkg/model"
@@ -351,6 +353,37 @@ func TestMatchesMetadataLabels(t *testing.T) {
 	assertMatchesMetadataLabels(t, e, wrongValForKey, false, "label with wrong val for key")
 }
 
+func TestInjectManagedObjectIdentity(t *testing.T) {
+	entity := parseOneEntity(t, testyaml.SanchoYAML)
+	newEntity := InjectManagedObjectIdentity(entity, ManagedObjectIdentity{
+		Manifest: "sancho",
+		Project:  "abc123",
+	})
+
+	meta := newEntity.Meta()
+	assert.Equal(t, "sancho", meta.GetLabels()[ManifestNameLabel])
+	assert.Equal(t, "abc123", meta.GetLabels()[ProjectLabel])
+	assert.Empty(t, meta.GetAnnotations())
+
+	// Pod templates and selectors are untouched, so that stamping the
+	// identity doesn't restart any pods.
+	result, err := SerializeSpecYAML([]K8sEntity{newEntity})
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(result, ManifestNameLabel))
+	assert.Empty(t, entity.Meta().GetLabels()[ManifestNameLabel])
+}
+
+func TestManifestNameLabelValue(t *testing.T) {
+	assert.Equal(t, "frontend", ManifestNameLabelValue("frontend"))
+
+	value := ManifestNameLabelValue("my app:frontend")
+	assert.Equal(t, "my_app_frontend-"+shortHash("my app:frontend"), value)
+	assert.NotEqual(t, value, ManifestNameLabelValue("my_app:frontend"))
+
+	long := ManifestNameLabelValue(strings.Repeat("a", 100))
+	assert.Empty(t, validation.IsValidLabelValue(long))
+}
+
 func assertMatchesMetadataLabels(t *testing.T, e K8sEntity, labels map[string]string, expected bool, msg string) {
 	match, err := e.MatchesMetadataLabels(labels)
 	if err != nil {
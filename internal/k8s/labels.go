@@ -1,7 +1,13 @@
 package k8s
 
 import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	"github.com/tilt-dev/tilt/pkg/model"
 )
@@ -12,6 +18,52 @@ const ManagedByValue = "tilt"
 
 const ManifestNameLabel = "tilt-manifest"
 
+// Identifies the project (by the path of its main Tiltfile) that applied an object,
+// so that Tilt sessions sharing a cluster don't claim each other's objects.
+const ProjectLabel = "tilt-project"
+
+// Identifies which manifest applied an object.
+//
+// Both parts are stable across Tiltfile loads, so stamping them on an
+// object doesn't change it unless the manifest does.
+type ManagedObjectIdentity struct {
+	Manifest string
+	Project  string
+}
+
+var invalidLabelValueChars = regexp.MustCompile(`[^-A-Za-z0-9_.]`)
+
+// Converts a manifest name to a stable, valid label value.
+//
+// Names that aren't valid label values are sanitized, with a hash suffix
+// so that different names can't collide.
+func ManifestNameLabelValue(name string) string {
+	if len(validation.IsValidLabelValue(name)) == 0 {
+		return name
+	}
+
+	sanitized := invalidLabelValueChars.ReplaceAllString(name, "_")
+	if len(sanitized) > 50 {
+		sanitized = sanitized[:50]
+	}
+
+	// Label values must start with an alphanumeric character.
+	sanitized = strings.TrimLeft(sanitized, "-_.")
+	if sanitized == "" {
+		return shortHash(name)
+	}
+	return fmt.Sprintf("%s-%s", sanitized, shortHash(name))
+}
+
+// Converts the path of a main Tiltfile to a label value.
+func ProjectLabelValue(tiltfilePath string) string {
+	return shortHash(tiltfilePath)
+}
+
+func shortHash(s string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(s)))[:12]
+}
+
 func TiltManagedByLabel() model.LabelPair {
 	return model.LabelPair{
 		Key:   ManagedByLabel,
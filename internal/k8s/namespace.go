@@ -0,0 +1,130 @@
+package k8s
+
+import (
+	rbacv1 "k8s.io/api/rbac/v1"
+	rbacv1alpha1 "k8s.io/api/rbac/v1alpha1"
+	rbacv1beta1 "k8s.io/api/rbac/v1beta1"
+)
+
+// Kinds that don't live in a namespace.
+//
+// We can't ask the cluster which kinds are namespaced without a round-trip,
+// so we keep a list of the built-in ones. Custom resources are assumed to be
+// namespaced.
+var clusterScopedKinds = map[string]bool{
+	"APIService":                     true,
+	"CSIDriver":                      true,
+	"CSINode":                        true,
+	"CertificateSigningRequest":      true,
+	"ClusterRole":                    true,
+	"ClusterRoleBinding":             true,
+	"ComponentStatus":                true,
+	"CustomResourceDefinition":       true,
+	"IngressClass":                   true,
+	"MutatingWebhookConfiguration":   true,
+	"Namespace":                      true,
+	"Node":                           true,
+	"PersistentVolume":               true,
+	"PodSecurityPolicy":              true,
+	"PriorityClass":                  true,
+	"RuntimeClass":                   true,
+	"StorageClass":                   true,
+	"ValidatingWebhookConfiguration": true,
+	"VolumeAttachment":               true,
+}
+
+func (e K8sEntity) IsClusterScoped() bool {
+	return IsClusterScopedKind(e.GVK().Kind)
+}
+
+func IsClusterScopedKind(kind string) bool {
+	return clusterScopedKinds[kind]
+}
+
+// Moves all the namespaced objects into the given namespace.
+//
+// ServiceAccount subjects of RoleBindings and ClusterRoleBindings are moved too,
+// if the ServiceAccount is one of the objects being moved.
+//
+// Returns the moved objects, the cluster-scoped objects that were left alone,
+// and the original objects that had a different namespace hardcoded in their YAML.
+// The result includes the cluster-scoped objects, in their original order.
+func MoveToNamespace(entities []K8sEntity, ns Namespace) (result, clusterScoped, conflicts []K8sEntity) {
+	// The ServiceAccounts we're moving, keyed by their original namespace/name.
+	serviceAccounts := make(map[string]bool)
+	for _, e := range entities {
+		if e.GVK().Kind == "ServiceAccount" {
+			serviceAccounts[e.Namespace().String()+"/"+e.Name()] = true
+		}
+	}
+
+	result = make([]K8sEntity, 0, len(entities))
+	for _, orig := range entities {
+		e := orig.DeepCopy()
+		moveSubjects(e, ns, serviceAccounts)
+
+		if e.IsClusterScoped() {
+			clusterScoped = append(clusterScoped, e)
+			result = append(result, e)
+			continue
+		}
+
+		current := e.Meta().GetNamespace()
+		if current != "" && current != ns.String() {
+			conflicts = append(conflicts, orig)
+		}
+		e.Meta().SetNamespace(ns.String())
+		result = append(result, e)
+	}
+	return result, clusterScoped, conflicts
+}
+
+// Moves the ServiceAccount subjects of a binding that point at one of the given
+// ServiceAccounts. Modifies the entity in-place.
+func moveSubjects(e K8sEntity, ns Namespace, serviceAccounts map[string]bool) {
+	shouldMove := func(kind, namespace, name string) bool {
+		if namespace == "" {
+			namespace = DefaultNamespace.String()
+		}
+		return kind == "ServiceAccount" && serviceAccounts[namespace+"/"+name]
+	}
+
+	switch obj := e.Obj.(type) {
+	case *rbacv1.RoleBinding:
+		for i, s := range obj.Subjects {
+			if shouldMove(s.Kind, s.Namespace, s.Name) {
+				obj.Subjects[i].Namespace = ns.String()
+			}
+		}
+	case *rbacv1.ClusterRoleBinding:
+		for i, s := range obj.Subjects {
+			if shouldMove(s.Kind, s.Namespace, s.Name) {
+				obj.Subjects[i].Namespace = ns.String()
+			}
+		}
+	case *rbacv1beta1.RoleBinding:
+		for i, s := range obj.Subjects {
+			if shouldMove(s.Kind, s.Namespace, s.Name) {
+				obj.Subjects[i].Namespace = ns.String()
+			}
+		}
+	case *rbacv1beta1.ClusterRoleBinding:
+		for i, s := range obj.Subjects {
+			if shouldMove(s.Kind, s.Namespace, s.Name) {
+				obj.Subjects[i].Namespace = ns.String()
+			}
+		}
+	case *rbacv1alpha1.RoleBinding:
+		for i, s := range obj.Subjects {
+			if shouldMove(s.Kind, s.Namespace, s.Name) {
+				obj.Subjects[i].Namespace = ns.String()
+			}
+		}
+	case *rbacv1alpha1.ClusterRoleBinding:
+		for i, s := range obj.Subjects {
+			if shouldMove(s.Kind, s.Namespace, s.Name) {
+				obj.Subjects[i].Namespace = ns.String()
+			}
+		}
+	}
+}
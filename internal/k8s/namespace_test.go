@@ -0,0 +1,121 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
+)
+
+const namespaceBundleYAML = `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: team
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: builder
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: deployer
+  namespace: team
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: reader
+rules: []
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: builder-binding
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: reader
+subjects:
+- kind: ServiceAccount
+  name: builder
+- kind: ServiceAccount
+  name: deployer
+  namespace: team
+- kind: ServiceAccount
+  name: monitor
+  namespace: monitoring
+- kind: User
+  name: builder
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: reader-binding
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: reader
+subjects:
+- kind: ServiceAccount
+  name: deployer
+  namespace: team
+`
+
+func TestMoveToNamespace(t *testing.T) {
+	entities, err := ParseYAMLFromString(namespaceBundleYAML + "---\n" + testyaml.SanchoYAML + "---\n" + testyaml.CRDImageObjectYAML)
+	require.NoError(t, err)
+
+	result, clusterScoped, conflicts := MoveToNamespace(entities, "alice-dev")
+	require.Len(t, result, len(entities))
+
+	namespaces := make(map[string]string)
+	for _, e := range result {
+		namespaces[e.GVK().Kind+"/"+e.Name()] = e.Meta().GetNamespace()
+	}
+	assert.Equal(t, map[string]string{
+		"Namespace/team":                    "",
+		"ServiceAccount/builder":            "alice-dev",
+		"ServiceAccount/deployer":           "alice-dev",
+		"ClusterRole/reader":                "",
+		"RoleBinding/builder-binding":       "alice-dev",
+		"ClusterRoleBinding/reader-binding": "",
+		"Deployment/sancho":                 "alice-dev",
+		"UselessMachine/um":                 "alice-dev",
+	}, namespaces)
+
+	assert.Equal(t, []string{"Namespace/team", "ClusterRole/reader", "ClusterRoleBinding/reader-binding"},
+		kindsAndNames(clusterScoped))
+	assert.Equal(t, []string{"ServiceAccount/deployer"}, kindsAndNames(conflicts))
+
+	// Subjects in the bundle move along with it. Other subjects stay put.
+	rb := result[4].Obj.(*rbacv1.RoleBinding)
+	assert.Equal(t, []rbacv1.Subject{
+		{Kind: "ServiceAccount", Name: "builder", Namespace: "alice-dev"},
+		{Kind: "ServiceAccount", Name: "deployer", Namespace: "alice-dev"},
+		{Kind: "ServiceAccount", Name: "monitor", Namespace: "monitoring"},
+		{Kind: "User", Name: "builder"},
+	}, rb.Subjects)
+
+	crb := result[5].Obj.(*rbacv1.ClusterRoleBinding)
+	assert.Equal(t, []rbacv1.Subject{
+		{Kind: "ServiceAccount", Name: "deployer", Namespace: "alice-dev"},
+	}, crb.Subjects)
+
+	// The originals are untouched.
+	assert.Equal(t, "team", entities[2].Meta().GetNamespace())
+	assert.Equal(t, "team", entities[4].Obj.(*rbacv1.RoleBinding).Subjects[1].Namespace)
+}
+
+func kindsAndNames(entities []K8sEntity) []string {
+	var result []string
+	for _, e := range entities {
+		result = append(result, e.GVK().Kind+"/"+e.Name())
+	}
+	return result
+}
@@ -3,10 +3,16 @@ package k8s
 import (
 	"context"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/gorilla/websocket"
+	"k8s.io/apimachinery/pkg/util/httpstream"
 	v1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp" // registers gcp auth provider
 	"k8s.io/client-go/rest"
@@ -36,6 +42,10 @@ type PortForwarder interface {
 	// case this channel will NOT be closed.
 	ReadyCh() <-chan struct{}
 
+	// The transport that reached the cluster (e.g., "websocket" or "spdy"),
+	// or "" if ForwardPorts hasn't connected yet.
+	Transport() string
+
 	// Listens on the configured port and forward all traffic to the container.
 	// Returns when the port-forwarder sees an unrecoverable error or
 	// when the context passed at creation is canceled.
@@ -53,6 +63,7 @@ type PortForwarder interface {
 type portForwarder struct {
 	*portforward.PortForwarder
 	localPort int
+	dialer    *fallbackDialer
 }
 
 var _ PortForwarder = portForwarder{}
@@ -65,6 +76,10 @@ func (pf portForwarder) ReadyCh() <-chan struct{} {
 	return pf.Ready
 }
 
+func (pf portForwarder) Transport() string {
+	return pf.dialer.transport()
+}
+
 func (k *K8sClient) CreatePortForwarder(ctx context.Context, namespace Namespace, podID PodID, optionalLocalPort, remotePort int, host string) (PortForwarder, error) {
 	localPort := optionalLocalPort
 	if localPort == 0 {
@@ -85,8 +100,10 @@ func (k *K8sClient) CreatePortForwarder(ctx context.Context, namespace Namespace
 }
 
 type portForwardClient struct {
-	config *rest.Config
-	core   v1.CoreV1Interface
+	config           *rest.Config
+	credentialPlugin *credentialPlugin
+	core             v1.CoreV1Interface
+	transports       *portForwardTransportMemory
 }
 
 func ProvidePortForwardClient(
@@ -99,24 +116,43 @@ func ProvidePortForwardClient(
 		return explodingPortForwardClient{error: maybeClientset.Error}
 	}
 	return portForwardClient{
-		maybeRESTConfig.Config,
-		maybeClientset.Clientset.CoreV1(),
+		config:           maybeRESTConfig.Config,
+		credentialPlugin: maybeRESTConfig.credentialPlugin,
+		core:             maybeClientset.Clientset.CoreV1(),
+		transports:       newPortForwardTransportMemory(),
 	}
 }
 
 func (c portForwardClient) CreatePortForwarder(ctx context.Context, namespace Namespace, podID PodID, localPort int, remotePort int, host string) (PortForwarder, error) {
-	transport, upgrader, err := spdy.RoundTripperFor(c.config)
-	if err != nil {
-		return nil, errors.Wrap(err, "error getting roundtripper")
-	}
-
 	req := c.core.RESTClient().Post().
 		Resource("pods").
 		Namespace(namespace.String()).
 		Name(podID.String()).
 		SubResource("portforward")
 
-	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", req.URL())
+	config, err := c.credentialPlugin.upgradeConfig(ctx, c.config)
+	if err != nil {
+		return nil, err
+	}
+	spdyDialer, err := spdyDialer(config, req.URL())
+	if err != nil {
+		return nil, err
+	}
+	wsDialer, err := websocketDialer(config, req.URL(), remotePort)
+	if err != nil {
+		return nil, err
+	}
+
+	cluster := c.config.Host
+	dialer := &fallbackDialer{
+		cluster: cluster,
+		memory:  c.transports,
+		order:   c.transports.order(cluster),
+		dialers: map[string]httpstream.Dialer{
+			portforward.TransportSPDY:      spdyDialer,
+			portforward.TransportWebsocket: wsDialer,
+		},
+	}
 
 	readyChan := make(chan struct{}, 1)
 
@@ -145,6 +181,70 @@ func (c portForwardClient) CreatePortForwarder(ctx context.Context, namespace Na
 	return portForwarder{
 		PortForwarder: pf,
 		localPort:     localPort,
+		dialer:        dialer,
+	}, nil
+}
+
+func spdyDialer(config *rest.Config, u *url.URL) (httpstream.Dialer, error) {
+	transport, upgrader, err := spdy.RoundTripperFor(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting roundtripper")
+	}
+	return spdy.NewDialer(spdyErrorUpgrader{upgrader}, &http.Client{Transport: transport}, "POST", u), nil
+}
+
+func websocketDialer(config *rest.Config, u *url.URL, remotePort int) (httpstream.Dialer, error) {
+	tlsConfig, err := rest.TLSConfigFor(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting TLS config")
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if config.Proxy != nil {
+		proxy = config.Proxy
+	}
+
+	header := func() (http.Header, error) {
+		return authHeaders(config, u)
+	}
+	return portforward.NewWebsocketDialer(u, &websocket.Dialer{
+		TLSClientConfig:  tlsConfig,
+		Proxy:            proxy,
+		HandshakeTimeout: 30 * time.Second,
+	}, header, remotePort), nil
+}
+
+// The headers that client-go would add to a request to the apiserver
+// (e.g., bearer tokens from an exec plugin).
+func authHeaders(config *rest.Config, u *url.URL) (http.Header, error) {
+	capture := &headerCapture{}
+	rt, err := rest.HTTPWrappersForConfig(config, capture)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting auth headers")
+	}
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "error getting auth headers")
+	}
+	_ = resp.Body.Close()
+	return capture.header, nil
+}
+
+// A RoundTripper that records the request headers instead of sending the request.
+type headerCapture struct {
+	header http.Header
+}
+
+func (c *headerCapture) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.header = req.Header.Clone()
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader("")),
+		Request:    req,
 	}, nil
 }
 
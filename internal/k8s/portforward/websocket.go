@@ -0,0 +1,357 @@
+package portforward
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+)
+
+// The transports that a port-forward can use to reach the cluster.
+const (
+	TransportSPDY      = "spdy"
+	TransportWebsocket = "websocket"
+)
+
+// The websocket subprotocol for port-forwarding.
+//
+// Each port gets two channels: a data channel (2*i) and an error channel (2*i+1).
+// Every message starts with a channel byte. The server starts each channel
+// with the port number as two little-endian bytes.
+const WebsocketProtocolV4Name = "v4.channel.k8s.io"
+
+const (
+	websocketDataChannel  = 0
+	websocketErrorChannel = 1
+)
+
+// The handshake response body is truncated to this many bytes.
+const maxUpgradeErrorBody = 1024
+
+// UpgradeError is returned when the server (or something between us and the
+// server) refuses to upgrade the connection.
+type UpgradeError struct {
+	Transport  string
+	StatusCode int
+
+	// The start of the response body, e.g., a JSON Status from the apiserver,
+	// or an HTML page from a proxy.
+	Body string
+
+	Err error
+}
+
+func (e *UpgradeError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("%s: %v", e.Transport, e.Err)
+	}
+	return fmt.Sprintf("%s: unable to upgrade connection (HTTP %d): %v", e.Transport, e.StatusCode, e.Err)
+}
+
+func (e *UpgradeError) Unwrap() error {
+	return e.Err
+}
+
+// Reads the start of a failed handshake response into an UpgradeError.
+func NewUpgradeError(transport string, resp *http.Response, err error) *UpgradeError {
+	result := &UpgradeError{Transport: transport, Err: err}
+	if resp == nil {
+		return result
+	}
+	result.StatusCode = resp.StatusCode
+	if resp.Body != nil {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxUpgradeErrorBody))
+		result.Body = string(body)
+	}
+	return result
+}
+
+// WebsocketDialer dials port-forward connections over websockets, for clusters
+// (or proxies) that don't support SPDY.
+//
+// The websocket protocol can't multiplex connections, so each local
+// connection gets its own websocket.
+type WebsocketDialer struct {
+	url       *url.URL
+	dialer    *websocket.Dialer
+	header    func() (http.Header, error)
+	probePort int
+}
+
+var _ httpstream.Dialer = &WebsocketDialer{}
+
+// Creates a dialer for the pod port-forward URL (an http or https URL).
+//
+// The header func returns the auth headers for each websocket. The probe port
+// is used to check that the server accepts websockets when dialing.
+func NewWebsocketDialer(u *url.URL, dialer *websocket.Dialer, header func() (http.Header, error), probePort int) *WebsocketDialer {
+	return &WebsocketDialer{
+		url:       u,
+		dialer:    dialer,
+		header:    header,
+		probePort: probePort,
+	}
+}
+
+// Dial checks that the server accepts a port-forward websocket, and returns a
+// connection that opens a websocket for each stream pair.
+func (d *WebsocketDialer) Dial(protocols ...string) (httpstream.Connection, string, error) {
+	conn, err := d.dial(fmt.Sprintf("%d", d.probePort))
+	if err != nil {
+		return nil, "", err
+	}
+	protocol := conn.Subprotocol()
+	_ = conn.Close()
+
+	return &websocketConnection{
+		dial:     d.dial,
+		sessions: make(map[string]*websocketSession),
+		closeCh:  make(chan bool),
+	}, protocol, nil
+}
+
+func (d *WebsocketDialer) dial(port string) (*websocket.Conn, error) {
+	u := *d.url
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	case "http":
+		u.Scheme = "ws"
+	}
+	q := u.Query()
+	q.Set("ports", port)
+	u.RawQuery = q.Encode()
+
+	header, err := d.header()
+	if err != nil {
+		return nil, &UpgradeError{Transport: TransportWebsocket, Err: err}
+	}
+
+	dialer := *d.dialer
+	dialer.Subprotocols = []string{WebsocketProtocolV4Name}
+	conn, resp, err := dialer.Dial(u.String(), header)
+	if err != nil {
+		return nil, NewUpgradeError(TransportWebsocket, resp, err)
+	}
+	return conn, nil
+}
+
+// Adapts per-connection websockets to the stream API that PortForwarder uses.
+//
+// PortForwarder creates an error stream, then a data stream, with the same
+// request ID for each local connection. The first stream dials the websocket.
+type websocketConnection struct {
+	dial func(port string) (*websocket.Conn, error)
+
+	mu       sync.Mutex
+	sessions map[string]*websocketSession
+
+	closeOnce sync.Once
+	closeCh   chan bool
+}
+
+var _ httpstream.Connection = &websocketConnection{}
+
+func (c *websocketConnection) CreateStream(headers http.Header) (httpstream.Stream, error) {
+	requestID := headers.Get(v1.PortForwardRequestIDHeader)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.sessions[requestID]
+	if !ok {
+		conn, err := c.dial(headers.Get(v1.PortHeader))
+		if err != nil {
+			return nil, err
+		}
+		s = newWebsocketSession(conn)
+		c.sessions[requestID] = s
+		go s.run()
+	}
+
+	switch headers.Get(v1.StreamType) {
+	case v1.StreamTypeError:
+		s.errorStream.headers = headers.Clone()
+		return s.errorStream, nil
+	case v1.StreamTypeData:
+		// Both streams exist now, so the session manages itself.
+		delete(c.sessions, requestID)
+		s.dataStream.headers = headers.Clone()
+		return s.dataStream, nil
+	}
+	return nil, fmt.Errorf("unknown stream type: %q", headers.Get(v1.StreamType))
+}
+
+func (c *websocketConnection) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closeCh)
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, s := range c.sessions {
+		s.close()
+		delete(c.sessions, id)
+	}
+	return nil
+}
+
+// Each websocket closes on its own, so the connection only closes when
+// the port-forwarder closes it.
+func (c *websocketConnection) CloseChan() <-chan bool {
+	return c.closeCh
+}
+
+func (c *websocketConnection) SetIdleTimeout(timeout time.Duration) {}
+
+func (c *websocketConnection) RemoveStreams(streams ...httpstream.Stream) {}
+
+// One websocket, carrying the data and error channels for one local connection.
+type websocketSession struct {
+	conn *websocket.Conn
+
+	writeMu sync.Mutex
+
+	dataStream  *websocketStream
+	errorStream *websocketStream
+
+	closeOnce sync.Once
+}
+
+func newWebsocketSession(conn *websocket.Conn) *websocketSession {
+	s := &websocketSession{conn: conn}
+	s.dataStream = newWebsocketStream(s, websocketDataChannel)
+	s.errorStream = newWebsocketStream(s, websocketErrorChannel)
+	return s
+}
+
+// Routes messages from the server to the streams until the websocket closes.
+func (s *websocketSession) run() {
+	var err error
+	defer func() {
+		if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+			err = nil
+		}
+		s.dataStream.pw.CloseWithError(err)
+		s.errorStream.pw.CloseWithError(err)
+	}()
+
+	gotPort := map[byte]bool{}
+	for {
+		var msg []byte
+		_, msg, err = s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if len(msg) == 0 {
+			continue
+		}
+
+		channel, payload := msg[0], msg[1:]
+		var stream *websocketStream
+		switch channel {
+		case websocketDataChannel:
+			stream = s.dataStream
+		case websocketErrorChannel:
+			stream = s.errorStream
+		default:
+			continue
+		}
+
+		if !gotPort[channel] {
+			gotPort[channel] = true
+			if len(payload) < 2 {
+				err = fmt.Errorf("websocket channel %d: missing port prefix", channel)
+				return
+			}
+			payload = payload[2:]
+		}
+		if len(payload) == 0 {
+			continue
+		}
+		if _, err = stream.pw.Write(payload); err != nil {
+			return
+		}
+	}
+}
+
+func (s *websocketSession) write(channel byte, p []byte) (int, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	msg := make([]byte, len(p)+1)
+	msg[0] = channel
+	copy(msg[1:], p)
+	err := s.conn.WriteMessage(websocket.BinaryMessage, msg)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// The websocket protocol has no half-close, so closing the data stream
+// closes the whole websocket.
+func (s *websocketSession) close() {
+	s.closeOnce.Do(func() {
+		s.writeMu.Lock()
+		_ = s.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+			time.Now().Add(time.Second))
+		s.writeMu.Unlock()
+		_ = s.conn.Close()
+	})
+}
+
+type websocketStream struct {
+	session *websocketSession
+	channel byte
+	headers http.Header
+
+	pr *io.PipeReader
+	pw *io.PipeWriter
+}
+
+var _ httpstream.Stream = &websocketStream{}
+
+func newWebsocketStream(s *websocketSession, channel byte) *websocketStream {
+	pr, pw := io.Pipe()
+	return &websocketStream{session: s, channel: channel, pr: pr, pw: pw}
+}
+
+func (s *websocketStream) Read(p []byte) (int, error) {
+	return s.pr.Read(p)
+}
+
+func (s *websocketStream) Write(p []byte) (int, error) {
+	if s.channel != websocketDataChannel {
+		return 0, fmt.Errorf("websocket channel %d is read-only", s.channel)
+	}
+	return s.session.write(s.channel, p)
+}
+
+// Closing the error stream is a no-op, because we never write to it.
+func (s *websocketStream) Close() error {
+	if s.channel == websocketDataChannel {
+		s.session.close()
+	}
+	return nil
+}
+
+func (s *websocketStream) Reset() error {
+	s.session.close()
+	return nil
+}
+
+func (s *websocketStream) Headers() http.Header {
+	return s.headers
+}
+
+func (s *websocketStream) Identifier() uint32 {
+	return uint32(s.channel)
+}
@@ -0,0 +1,123 @@
+package portforward
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Speaks the kubelet's v4 port-forward protocol, and echoes data back in upper case.
+func fakeKubeletHandler(t *testing.T) http.HandlerFunc {
+	upgrader := websocket.Upgrader{Subprotocols: []string{WebsocketProtocolV4Name}}
+	return func(w http.ResponseWriter, r *http.Request) {
+		port, err := strconv.Atoi(r.URL.Query().Get("ports"))
+		if err != nil {
+			http.Error(w, "missing ports", http.StatusBadRequest)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+
+		prefix := []byte{byte(port), byte(port >> 8)}
+		for _, channel := range []byte{websocketDataChannel, websocketErrorChannel} {
+			if err := conn.WriteMessage(websocket.BinaryMessage, append([]byte{channel}, prefix...)); err != nil {
+				return
+			}
+		}
+
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if len(msg) == 0 || msg[0] != websocketDataChannel {
+				continue
+			}
+			reply := append([]byte{websocketDataChannel}, bytes.ToUpper(msg[1:])...)
+			if err := conn.WriteMessage(websocket.BinaryMessage, reply); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func TestWebsocketForward(t *testing.T) {
+	server := httptest.NewServer(fakeKubeletHandler(t))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	noHeader := func() (http.Header, error) { return http.Header{}, nil }
+	dialer := NewWebsocketDialer(u, &websocket.Dialer{}, noHeader, 8080)
+
+	ctx, cancel := context.WithCancel(newCtx())
+	defer cancel()
+
+	pf, err := New(ctx, dialer, []string{":8080"}, make(chan struct{}))
+	require.NoError(t, err)
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- pf.ForwardPorts()
+	}()
+	defer func() {
+		cancel()
+		require.NoError(t, <-errChan)
+	}()
+	<-pf.Ready
+
+	ports, err := pf.GetPorts()
+	require.NoError(t, err)
+
+	// Each local connection gets its own websocket.
+	for i := 0; i < 2; i++ {
+		conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", ports[0].Local))
+		require.NoError(t, err)
+
+		_, err = conn.Write([]byte("hello"))
+		require.NoError(t, err)
+
+		buf := make([]byte, 5)
+		_, err = io.ReadFull(conn, buf)
+		require.NoError(t, err)
+		assert.Equal(t, "HELLO", string(buf))
+		_ = conn.Close()
+	}
+}
+
+func TestWebsocketDialUpgradeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte("<html><body>502 Bad Gateway</body></html>"))
+	}))
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	noHeader := func() (http.Header, error) { return http.Header{}, nil }
+	dialer := NewWebsocketDialer(u, &websocket.Dialer{}, noHeader, 8080)
+
+	_, _, err = dialer.Dial()
+
+	var upgradeErr *UpgradeError
+	require.True(t, errors.As(err, &upgradeErr))
+	assert.Equal(t, TransportWebsocket, upgradeErr.Transport)
+	assert.Equal(t, http.StatusBadGateway, upgradeErr.StatusCode)
+	assert.Equal(t, "<html><body>502 Bad Gateway</body></html>", upgradeErr.Body)
+}
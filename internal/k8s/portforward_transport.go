@@ -0,0 +1,223 @@
+package k8s
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/transport/spdy"
+
+	"github.com/tilt-dev/tilt/internal/k8s/portforward"
+)
+
+// Remembers which port-forward transport worked for each cluster,
+// so that we don't retry a broken transport on every forward.
+type portForwardTransportMemory struct {
+	mu        sync.Mutex
+	byCluster map[string]string
+}
+
+func newPortForwardTransportMemory() *portForwardTransportMemory {
+	return &portForwardTransportMemory{byCluster: make(map[string]string)}
+}
+
+func (m *portForwardTransportMemory) get(cluster string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.byCluster[cluster]
+}
+
+func (m *portForwardTransportMemory) set(cluster string, transport string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.byCluster[cluster] = transport
+}
+
+// Orders the transports to try: the one that worked last time first,
+// otherwise websockets before SPDY.
+func (m *portForwardTransportMemory) order(cluster string) []string {
+	if m.get(cluster) == portforward.TransportSPDY {
+		return []string{portforward.TransportSPDY, portforward.TransportWebsocket}
+	}
+	return []string{portforward.TransportWebsocket, portforward.TransportSPDY}
+}
+
+// Tries each transport in order until one connects.
+type fallbackDialer struct {
+	cluster string
+	memory  *portForwardTransportMemory
+	order   []string
+	dialers map[string]httpstream.Dialer
+
+	mu     sync.Mutex
+	chosen string
+}
+
+var _ httpstream.Dialer = &fallbackDialer{}
+
+func (d *fallbackDialer) Dial(protocols ...string) (httpstream.Connection, string, error) {
+	var errs []error
+	for _, name := range d.order {
+		conn, protocol, err := d.dialers[name].Dial(protocols...)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		d.mu.Lock()
+		d.chosen = name
+		d.mu.Unlock()
+		d.memory.set(d.cluster, name)
+		return conn, protocol, nil
+	}
+	return nil, "", DiagnosePortForwardErrors(errs)
+}
+
+// The transport of the last successful dial, or "" if none succeeded yet.
+func (d *fallbackDialer) transport() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.chosen
+}
+
+// Records the response when the SPDY upgrade fails, so that we can tell
+// what refused it.
+type spdyErrorUpgrader struct {
+	spdy.Upgrader
+}
+
+func (u spdyErrorUpgrader) NewConnection(resp *http.Response) (httpstream.Connection, error) {
+	conn, err := u.Upgrader.NewConnection(resp)
+	if err != nil {
+		upgradeErr := &portforward.UpgradeError{Transport: portforward.TransportSPDY, Err: err}
+		if resp != nil && resp.StatusCode != http.StatusSwitchingProtocols {
+			upgradeErr.StatusCode = resp.StatusCode
+			upgradeErr.Body = spdyErrorBody(err)
+		}
+		return nil, upgradeErr
+	}
+	return conn, nil
+}
+
+// The SPDY upgrader reads the response body into its error, either as
+// a Status from the apiserver, or as raw text.
+func spdyErrorBody(err error) string {
+	var statusErr interface{ Status() metav1.Status }
+	if errors.As(err, &statusErr) {
+		status := statusErr.Status()
+		status.Kind = "Status"
+		status.APIVersion = "v1"
+		body, _ := json.Marshal(status)
+		return string(body)
+	}
+	return strings.TrimPrefix(err.Error(), "unable to upgrade connection: ")
+}
+
+// Why port-forwarding failed on every transport.
+type PortForwardDiagnosis string
+
+const (
+	PortForwardDiagnosisUnknown     PortForwardDiagnosis = "Unknown"
+	PortForwardDiagnosisAuth        PortForwardDiagnosis = "Auth"
+	PortForwardDiagnosisPodNotReady PortForwardDiagnosis = "PodNotReady"
+	PortForwardDiagnosisProxy       PortForwardDiagnosis = "Proxy"
+)
+
+// The error when no transport could connect.
+type PortForwardError struct {
+	Diagnosis PortForwardDiagnosis
+
+	// The response body that a proxy sent instead of upgrading.
+	ProxyBody string
+
+	// The error from each transport, in the order we tried them.
+	Attempts []error
+}
+
+func (e *PortForwardError) Error() string {
+	var attempts []string
+	for _, err := range e.Attempts {
+		attempts = append(attempts, err.Error())
+	}
+	detail := strings.Join(attempts, "; ")
+
+	switch e.Diagnosis {
+	case PortForwardDiagnosisAuth:
+		return fmt.Sprintf("port-forward was not authorized; check your credentials and RBAC permissions for pods/portforward (%s)", detail)
+	case PortForwardDiagnosisPodNotReady:
+		return fmt.Sprintf("pod is not ready for port-forwarding (%s)", detail)
+	case PortForwardDiagnosisProxy:
+		return fmt.Sprintf("something between Tilt and the cluster (e.g., a proxy or load balancer) refused to upgrade the connection (%s). Response: %s",
+			detail, e.ProxyBody)
+	}
+	return fmt.Sprintf("port-forward failed on all transports (%s)", detail)
+}
+
+// Classifies the transport errors, most specific cause first: auth failures
+// fail every transport, and an unready pod explains proxy-looking errors.
+func DiagnosePortForwardErrors(errs []error) error {
+	result := &PortForwardError{Diagnosis: PortForwardDiagnosisUnknown, Attempts: errs}
+	rank := map[PortForwardDiagnosis]int{
+		PortForwardDiagnosisUnknown:     0,
+		PortForwardDiagnosisProxy:       1,
+		PortForwardDiagnosisPodNotReady: 2,
+		PortForwardDiagnosisAuth:        3,
+	}
+	for _, err := range errs {
+		d, body := diagnosePortForwardError(err)
+		if rank[d] > rank[result.Diagnosis] {
+			result.Diagnosis = d
+		}
+		if d == PortForwardDiagnosisProxy && result.ProxyBody == "" {
+			result.ProxyBody = body
+		}
+	}
+	return result
+}
+
+var podNotReadyMessages = []string{
+	"does not have a host assigned",
+	"container not running",
+	"pod not found",
+	"is not running",
+	"unable to do port forwarding",
+}
+
+func diagnosePortForwardError(err error) (PortForwardDiagnosis, string) {
+	var upgradeErr *portforward.UpgradeError
+	if !errors.As(err, &upgradeErr) || upgradeErr.StatusCode == 0 {
+		return PortForwardDiagnosisUnknown, ""
+	}
+
+	if upgradeErr.StatusCode == http.StatusUnauthorized || upgradeErr.StatusCode == http.StatusForbidden {
+		return PortForwardDiagnosisAuth, ""
+	}
+
+	// The apiserver and kubelet explain themselves with a Status.
+	var status metav1.Status
+	if json.Unmarshal([]byte(upgradeErr.Body), &status) == nil && status.Kind == "Status" {
+		if status.Reason == metav1.StatusReasonNotFound {
+			return PortForwardDiagnosisPodNotReady, ""
+		}
+		for _, msg := range podNotReadyMessages {
+			if strings.Contains(status.Message, msg) {
+				return PortForwardDiagnosisPodNotReady, ""
+			}
+		}
+		return PortForwardDiagnosisUnknown, ""
+	}
+
+	for _, msg := range podNotReadyMessages {
+		if strings.Contains(upgradeErr.Body, msg) {
+			return PortForwardDiagnosisPodNotReady, ""
+		}
+	}
+
+	// Anything else answered instead of the apiserver.
+	return PortForwardDiagnosisProxy, upgradeErr.Body
+}
@@ -0,0 +1,221 @@
+package k8s
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+
+	"github.com/tilt-dev/tilt/internal/k8s/portforward"
+)
+
+func TestFallbackDialerPrefersWebsocket(t *testing.T) {
+	memory := newPortForwardTransportMemory()
+	ws := &fakeTransportDialer{}
+	spdy := &fakeTransportDialer{}
+
+	d := newTestFallbackDialer(memory, ws, spdy)
+	_, _, err := d.Dial()
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, ws.dials)
+	assert.Equal(t, 0, spdy.dials)
+	assert.Equal(t, portforward.TransportWebsocket, d.transport())
+	assert.Equal(t, portforward.TransportWebsocket, memory.get("cluster"))
+}
+
+func TestFallbackDialerFallsBackToSPDYAndRemembers(t *testing.T) {
+	memory := newPortForwardTransportMemory()
+	ws := &fakeTransportDialer{err: proxyError(portforward.TransportWebsocket)}
+	spdy := &fakeTransportDialer{}
+
+	d := newTestFallbackDialer(memory, ws, spdy)
+	_, _, err := d.Dial()
+	require.NoError(t, err)
+	assert.Equal(t, 1, ws.dials)
+	assert.Equal(t, 1, spdy.dials)
+	assert.Equal(t, portforward.TransportSPDY, d.transport())
+
+	// The next forward to the same cluster skips the broken transport.
+	d = newTestFallbackDialer(memory, ws, spdy)
+	_, _, err = d.Dial()
+	require.NoError(t, err)
+	assert.Equal(t, 1, ws.dials)
+	assert.Equal(t, 2, spdy.dials)
+
+	// Other clusters still start with websockets.
+	assert.Equal(t, []string{portforward.TransportWebsocket, portforward.TransportSPDY}, memory.order("other-cluster"))
+}
+
+func TestFallbackDialerForgetsSPDYWhenItBreaks(t *testing.T) {
+	memory := newPortForwardTransportMemory()
+	memory.set("cluster", portforward.TransportSPDY)
+	ws := &fakeTransportDialer{}
+	spdy := &fakeTransportDialer{err: proxyError(portforward.TransportSPDY)}
+
+	d := newTestFallbackDialer(memory, ws, spdy)
+	_, _, err := d.Dial()
+	require.NoError(t, err)
+	assert.Equal(t, 1, spdy.dials)
+	assert.Equal(t, 1, ws.dials)
+	assert.Equal(t, portforward.TransportWebsocket, memory.get("cluster"))
+}
+
+func TestFallbackDialerDiagnosesFailure(t *testing.T) {
+	memory := newPortForwardTransportMemory()
+	ws := &fakeTransportDialer{err: proxyError(portforward.TransportWebsocket)}
+	spdy := &fakeTransportDialer{err: proxyError(portforward.TransportSPDY)}
+
+	d := newTestFallbackDialer(memory, ws, spdy)
+	_, _, err := d.Dial()
+
+	var pfErr *PortForwardError
+	require.True(t, errors.As(err, &pfErr))
+	assert.Equal(t, PortForwardDiagnosisProxy, pfErr.Diagnosis)
+	assert.Len(t, pfErr.Attempts, 2)
+	assert.Equal(t, "", d.transport())
+	assert.Equal(t, "", memory.get("cluster"))
+}
+
+func TestDiagnosePortForwardErrors(t *testing.T) {
+	notFound := `{"kind":"Status","apiVersion":"v1","status":"Failure","message":"pods \"foo\" not found","reason":"NotFound","code":404}`
+	noHost := `{"kind":"Status","apiVersion":"v1","status":"Failure","message":"pod foo does not have a host assigned","reason":"BadRequest","code":400}`
+	conflict := `{"kind":"Status","apiVersion":"v1","status":"Failure","message":"something else","reason":"Conflict","code":409}`
+
+	for _, tc := range []struct {
+		name      string
+		errs      []error
+		diagnosis PortForwardDiagnosis
+		proxyBody string
+	}{
+		{
+			name:      "unauthorized",
+			errs:      []error{upgradeError(portforward.TransportWebsocket, http.StatusUnauthorized, "Unauthorized")},
+			diagnosis: PortForwardDiagnosisAuth,
+		},
+		{
+			name:      "forbidden",
+			errs:      []error{upgradeError(portforward.TransportSPDY, http.StatusForbidden, "")},
+			diagnosis: PortForwardDiagnosisAuth,
+		},
+		{
+			name:      "pod not found",
+			errs:      []error{upgradeError(portforward.TransportSPDY, http.StatusNotFound, notFound)},
+			diagnosis: PortForwardDiagnosisPodNotReady,
+		},
+		{
+			name:      "pod not scheduled",
+			errs:      []error{upgradeError(portforward.TransportSPDY, http.StatusBadRequest, noHost)},
+			diagnosis: PortForwardDiagnosisPodNotReady,
+		},
+		{
+			name:      "kubelet text error",
+			errs:      []error{upgradeError(portforward.TransportWebsocket, http.StatusInternalServerError, "container not running (abc123)")},
+			diagnosis: PortForwardDiagnosisPodNotReady,
+		},
+		{
+			name:      "other apiserver error",
+			errs:      []error{upgradeError(portforward.TransportSPDY, http.StatusConflict, conflict)},
+			diagnosis: PortForwardDiagnosisUnknown,
+		},
+		{
+			name:      "proxy",
+			errs:      []error{proxyError(portforward.TransportWebsocket)},
+			diagnosis: PortForwardDiagnosisProxy,
+			proxyBody: "<html><body>502 Bad Gateway</body></html>",
+		},
+		{
+			name:      "network error",
+			errs:      []error{&portforward.UpgradeError{Transport: portforward.TransportSPDY, Err: errors.New("connection refused")}},
+			diagnosis: PortForwardDiagnosisUnknown,
+		},
+		{
+			name: "unready pod explains proxy error",
+			errs: []error{
+				proxyError(portforward.TransportWebsocket),
+				upgradeError(portforward.TransportSPDY, http.StatusNotFound, notFound),
+			},
+			diagnosis: PortForwardDiagnosisPodNotReady,
+			proxyBody: "<html><body>502 Bad Gateway</body></html>",
+		},
+		{
+			name: "auth beats everything",
+			errs: []error{
+				upgradeError(portforward.TransportWebsocket, http.StatusBadRequest, noHost),
+				upgradeError(portforward.TransportSPDY, http.StatusForbidden, ""),
+			},
+			diagnosis: PortForwardDiagnosisAuth,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := DiagnosePortForwardErrors(tc.errs)
+
+			var pfErr *PortForwardError
+			require.True(t, errors.As(err, &pfErr))
+			assert.Equal(t, tc.diagnosis, pfErr.Diagnosis)
+			assert.Equal(t, tc.proxyBody, pfErr.ProxyBody)
+			assert.Equal(t, tc.errs, pfErr.Attempts)
+		})
+	}
+}
+
+func TestPortForwardErrorMessage(t *testing.T) {
+	err := DiagnosePortForwardErrors([]error{proxyError(portforward.TransportWebsocket)})
+	assert.Contains(t, err.Error(), "proxy or load balancer")
+	assert.Contains(t, err.Error(), "502 Bad Gateway")
+
+	err = DiagnosePortForwardErrors([]error{upgradeError(portforward.TransportSPDY, http.StatusForbidden, "")})
+	assert.Contains(t, err.Error(), "pods/portforward")
+}
+
+func newTestFallbackDialer(memory *portForwardTransportMemory, ws, spdy httpstream.Dialer) *fallbackDialer {
+	return &fallbackDialer{
+		cluster: "cluster",
+		memory:  memory,
+		order:   memory.order("cluster"),
+		dialers: map[string]httpstream.Dialer{
+			portforward.TransportWebsocket: ws,
+			portforward.TransportSPDY:      spdy,
+		},
+	}
+}
+
+func upgradeError(transport string, statusCode int, body string) error {
+	return &portforward.UpgradeError{
+		Transport:  transport,
+		StatusCode: statusCode,
+		Body:       body,
+		Err:        errors.New("bad handshake"),
+	}
+}
+
+func proxyError(transport string) error {
+	return upgradeError(transport, http.StatusBadGateway, "<html><body>502 Bad Gateway</body></html>")
+}
+
+type fakeTransportDialer struct {
+	dials int
+	err   error
+}
+
+func (d *fakeTransportDialer) Dial(protocols ...string) (httpstream.Connection, string, error) {
+	d.dials++
+	if d.err != nil {
+		return nil, "", d.err
+	}
+	return fakeTransportConnection{}, "", nil
+}
+
+type fakeTransportConnection struct{}
+
+func (fakeTransportConnection) CreateStream(headers http.Header) (httpstream.Stream, error) {
+	return nil, errors.New("not implemented")
+}
+func (fakeTransportConnection) Close() error                               { return nil }
+func (fakeTransportConnection) CloseChan() <-chan bool                     { return nil }
+func (fakeTransportConnection) SetIdleTimeout(timeout time.Duration)       {}
+func (fakeTransportConnection) RemoveStreams(streams ...httpstream.Stream) {}
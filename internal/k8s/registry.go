@@ -47,8 +47,10 @@ type registryAsync struct {
 	env           Env
 	core          apiv1.CoreV1Interface
 	runtimeSource RuntimeSource
-	registry      container.Registry
-	once          sync.Once
+
+	mu       sync.Mutex
+	loaded   bool
+	registry container.Registry
 }
 
 func newRegistryAsync(env Env, core apiv1.CoreV1Interface, runtimeSource RuntimeSource) *registryAsync {
@@ -165,43 +167,67 @@ func (r *registryAsync) inferRegistryFromConfigMap(ctx context.Context) (registr
 	return registry, hosting.Help
 }
 
-func (r *registryAsync) Registry(ctx context.Context) container.Registry {
-	r.once.Do(func() {
-		reg, help := r.inferRegistryFromConfigMap(ctx)
+func (r *registryAsync) discover(ctx context.Context) container.Registry {
+	reg, help := r.inferRegistryFromConfigMap(ctx)
+	if !reg.Empty() {
+		return reg
+	}
+
+	// Auto-infer the microk8s local registry.
+	if r.env == EnvMicroK8s {
+		reg := r.inferRegistryFromMicrok8s(ctx)
 		if !reg.Empty() {
-			r.registry = reg
-			return
+			return reg
 		}
+	}
 
-		// Auto-infer the microk8s local registry.
-		if r.env == EnvMicroK8s {
-			reg := r.inferRegistryFromMicrok8s(ctx)
-			if !reg.Empty() {
-				r.registry = reg
-				return
-			}
-		}
+	reg = r.inferRegistryFromNodeAnnotations(ctx)
+	if !reg.Empty() {
+		return reg
+	}
 
-		reg = r.inferRegistryFromNodeAnnotations(ctx)
-		if !reg.Empty() {
-			r.registry = reg
-		}
+	if help != "" {
+		logger.Get(ctx).Warnf("You are running without a local image registry.\n"+
+			"Tilt can use the local registry to speed up builds.\n"+
+			"Instructions: %s", help)
+	} else if r.env == EnvKIND6 {
+		logger.Get(ctx).Warnf("You are running Kind without a local image registry.\n" +
+			"Tilt can use the local registry to speed up builds.\n" +
+			"Instructions: https://github.com/tilt-dev/kind-local")
+	}
+	return container.Registry{}
+}
 
-		if r.registry.Empty() {
-			if help != "" {
-				logger.Get(ctx).Warnf("You are running without a local image registry.\n"+
-					"Tilt can use the local registry to speed up builds.\n"+
-					"Instructions: %s", help)
-			} else if r.env == EnvKIND6 {
-				logger.Get(ctx).Warnf("You are running Kind without a local image registry.\n" +
-					"Tilt can use the local registry to speed up builds.\n" +
-					"Instructions: https://github.com/tilt-dev/kind-local")
-			}
-		}
-	})
+func (r *registryAsync) Registry(ctx context.Context) container.Registry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.loaded {
+		r.registry = r.discover(ctx)
+		r.loaded = true
+	}
 	return r.registry
 }
 
+// Re-reads the local registry from the cluster, e.g., after we reconnect to
+// a cluster that may have been re-created with a different registry.
+//
+// We already warned about a missing registry the first time, so we
+// don't warn again. Returns true if the registry changed.
+func (r *registryAsync) Refresh(ctx context.Context) (container.Registry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	quietCtx := logger.WithLogger(ctx, logger.NewDeferredLogger(ctx))
+	old, wasLoaded := r.registry, r.loaded
+	r.registry = r.discover(quietCtx)
+	r.loaded = true
+	return r.registry, wasLoaded && r.registry != old
+}
+
 func (c K8sClient) LocalRegistry(ctx context.Context) container.Registry {
 	return c.registryAsync.Registry(ctx)
 }
+
+func (c K8sClient) RefreshLocalRegistry(ctx context.Context) (container.Registry, bool) {
+	return c.registryAsync.Refresh(ctx)
+}
@@ -217,3 +217,56 @@ func addConfigMap(tracker ktesting.ObjectTracker, configMap string) error {
 	}
 	return tracker.Add(obj)
 }
+
+func TestLocalRegistryRefresh(t *testing.T) {
+	cs := &fake.Clientset{}
+	tracker := ktesting.NewObjectTracker(scheme.Scheme, scheme.Codecs.UniversalDecoder())
+	cs.AddReactor("*", "*", ktesting.ObjectReaction(tracker))
+
+	core := cs.CoreV1()
+	registryAsync := newRegistryAsync(EnvK3D, core, NewNaiveRuntimeSource(container.RuntimeContainerd))
+
+	registry := registryAsync.Registry(newLoggerCtx(os.Stdout))
+	assert.True(t, registry.Empty())
+
+	// The cluster was re-created with a local registry.
+	err := addConfigMap(tracker, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: local-registry-hosting
+  namespace: kube-public
+data:
+  localRegistryHosting.v1: |
+    host: "localhost:5000"
+    hostFromContainerRuntime: "registry:5000"
+`)
+	require.NoError(t, err)
+
+	// Registry() doesn't re-read until we refresh.
+	assert.True(t, registryAsync.Registry(newLoggerCtx(os.Stdout)).Empty())
+
+	registry, changed := registryAsync.Refresh(newLoggerCtx(os.Stdout))
+	assert.True(t, changed)
+	assert.Equal(t, "localhost:5000", registry.Host)
+	assert.Equal(t, "registry:5000", registry.HostFromCluster())
+	assert.Equal(t, registry, registryAsync.Registry(newLoggerCtx(os.Stdout)))
+
+	_, changed = registryAsync.Refresh(newLoggerCtx(os.Stdout))
+	assert.False(t, changed)
+}
+
+func TestLocalRegistryRefreshDoesNotRepeatWarning(t *testing.T) {
+	cs := &fake.Clientset{}
+	core := cs.CoreV1()
+	registryAsync := newRegistryAsync(EnvKIND6, core, NewNaiveRuntimeSource(container.RuntimeContainerd))
+
+	out := bytes.NewBuffer(nil)
+	registryAsync.Registry(newLoggerCtx(out))
+	assert.Contains(t, out.String(), "https://github.com/tilt-dev/kind-local")
+
+	out.Reset()
+	_, changed := registryAsync.Refresh(newLoggerCtx(out))
+	assert.False(t, changed)
+	assert.Equal(t, "", out.String())
+}
@@ -17,6 +17,7 @@ import (
 // We've adapted Helm's kubernetes client for our needs
 type ResourceClient interface {
 	Apply(target kube.ResourceList) (*kube.Result, error)
+	DryRunApply(target kube.ResourceList) error
 	CreateOrReplace(target kube.ResourceList) (*kube.Result, error)
 	Delete(existing kube.ResourceList) (*kube.Result, []error)
 	Create(l kube.ResourceList) (*kube.Result, error)
@@ -31,6 +32,35 @@ type resourceClient struct {
 // Helm's update function doesn't really work for us,
 // so we use the kubectl apply code directly.
 func (c *resourceClient) Apply(target kube.ResourceList) (*kube.Result, error) {
+	o, err := c.newApplyOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	o.SetObjects(target)
+	err = o.Run()
+	if err != nil {
+		return nil, err
+	}
+	return &kube.Result{Updated: target}, nil
+}
+
+// Runs the same apply as Apply, as a server-side dry run.
+//
+// Replaces each object in the target with what the server would store.
+func (c *resourceClient) DryRunApply(target kube.ResourceList) error {
+	o, err := c.newApplyOptions()
+	if err != nil {
+		return err
+	}
+	o.DryRunStrategy = cmdutil.DryRunServer
+	o.DryRunVerifier = resource.NewDryRunVerifier(o.DynamicClient, c.factory.OpenAPIGetter())
+
+	o.SetObjects(target)
+	return o.Run()
+}
+
+func (c *resourceClient) newApplyOptions() (*apply.ApplyOptions, error) {
 	f := c.factory
 	o := apply.NewApplyOptions(genericclioptions.IOStreams{
 		In:     strings.NewReader(""),
@@ -59,13 +89,7 @@ func (c *resourceClient) Apply(target kube.ResourceList) (*kube.Result, error) {
 	if err != nil {
 		return nil, err
 	}
-
-	o.SetObjects(target)
-	err = o.Run()
-	if err != nil {
-		return nil, err
-	}
-	return &kube.Result{Updated: target}, nil
+	return o, nil
 }
 
 // A simplified implementation that creates or replaces the whole object.
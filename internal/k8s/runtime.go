@@ -2,9 +2,12 @@ package k8s
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"sort"
 	"sync"
 
+	v1 "k8s.io/api/core/v1"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	apiv1 "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -13,54 +16,172 @@ import (
 	"github.com/tilt-dev/tilt/pkg/logger"
 )
 
+// Reports the container runtime of the node a pod is scheduled on.
+type NodeRuntimeSource interface {
+	NodeContainerRuntime(ctx context.Context, nodeName string) container.Runtime
+}
+
+// Detects the container runtime of each node in the cluster.
+//
+// Clusters that are migrating between runtimes can have nodes with
+// different runtimes, so we remember the runtime of every node, and
+// re-read the nodes when we see a pod on a node we don't know about.
+//
+// We also remember the platform (OS and architecture) of each node,
+// so that we can build images that run on them.
 type runtimeAsync struct {
-	core    apiv1.CoreV1Interface
-	runtime container.Runtime
-	once    sync.Once
+	core apiv1.CoreV1Interface
+
+	mu        sync.Mutex
+	detected  bool
+	runtime   container.Runtime
+	nodes     map[string]container.Runtime
+	platforms []string
 }
 
 func newRuntimeAsync(core apiv1.CoreV1Interface) *runtimeAsync {
 	return &runtimeAsync{
 		core:    core,
 		runtime: container.RuntimeUnknown,
+		nodes:   make(map[string]container.Runtime),
 	}
 }
 
+// The runtime of the whole cluster, or RuntimeMixed if the nodes disagree.
 func (r *runtimeAsync) Runtime(ctx context.Context) container.Runtime {
-	r.once.Do(func() {
-		nodeList, err := r.core.Nodes().List(ctx, metav1.ListOptions{
-			Limit: 1,
-		})
-		if err != nil {
-			logger.Get(ctx).Debugf("Error fetching nodes: %v", err)
-
-			statusErr, isStatusErr := err.(*apiErrors.StatusError)
-			if isStatusErr {
-				status := statusErr.ErrStatus
-				if status.Code == http.StatusForbidden {
-					logger.Get(ctx).Debugf(
-						"Tilt could not read your node configuration\n"+
-							"  Ask your Kubernetes admin for access to run `kubectl get nodes`.\n"+
-							"  Detail: %v", err)
-				}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.detected {
+		r.detect(ctx)
+	}
+	return r.runtime
+}
+
+// The runtime of a single node.
+//
+// Returns RuntimeUnknown if the node doesn't exist or we can't read it.
+func (r *runtimeAsync) NodeRuntime(ctx context.Context, nodeName string) container.Runtime {
+	if nodeName == "" {
+		return container.RuntimeUnknown
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if runtime, ok := r.nodes[nodeName]; ok {
+		return runtime
+	}
+
+	// The node may have joined the cluster since we last looked.
+	r.detect(ctx)
+	if runtime, ok := r.nodes[nodeName]; ok {
+		return runtime
+	}
+	return container.RuntimeUnknown
+}
+
+// The distinct platforms of the cluster's nodes, like "linux/amd64", sorted.
+//
+// Empty if we can't read the nodes.
+func (r *runtimeAsync) Platforms(ctx context.Context) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.detected {
+		r.detect(ctx)
+	}
+	return append([]string{}, r.platforms...)
+}
+
+func (r *runtimeAsync) detect(ctx context.Context) {
+	r.detected = true
+
+	nodeList, err := r.core.Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		logger.Get(ctx).Debugf("Error fetching nodes: %v", err)
+
+		statusErr, isStatusErr := err.(*apiErrors.StatusError)
+		if isStatusErr {
+			status := statusErr.ErrStatus
+			if status.Code == http.StatusForbidden {
+				logger.Get(ctx).Debugf(
+					"Tilt could not read your node configuration\n"+
+						"  Ask your Kubernetes admin for access to run `kubectl get nodes`.\n"+
+						"  Detail: %v", err)
 			}
 		}
-		if nodeList == nil || len(nodeList.Items) == 0 {
+	}
+	if nodeList == nil || len(nodeList.Items) == 0 {
+		if len(r.nodes) == 0 {
 			r.runtime = container.RuntimeReadFailure
-			return
 		}
+		return
+	}
 
-		node := nodeList.Items[0]
-		info := node.Status.NodeInfo
-		r.runtime = container.RuntimeFromVersionString(info.ContainerRuntimeVersion)
-	})
-	return r.runtime
+	nodes := make(map[string]container.Runtime, len(nodeList.Items))
+	for _, node := range nodeList.Items {
+		nodes[node.Name] = nodeRuntime(node)
+	}
+	r.nodes = nodes
+	r.runtime = clusterRuntime(nodes)
+	r.platforms = clusterPlatforms(nodeList.Items)
+}
+
+func nodePlatform(node v1.Node) string {
+	info := node.Status.NodeInfo
+	if info.OperatingSystem == "" || info.Architecture == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s", info.OperatingSystem, info.Architecture)
+}
+
+func clusterPlatforms(nodes []v1.Node) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, node := range nodes {
+		platform := nodePlatform(node)
+		if platform == "" || seen[platform] {
+			continue
+		}
+		seen[platform] = true
+		result = append(result, platform)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func nodeRuntime(node v1.Node) container.Runtime {
+	return container.RuntimeFromVersionString(node.Status.NodeInfo.ContainerRuntimeVersion)
+}
+
+func clusterRuntime(nodes map[string]container.Runtime) container.Runtime {
+	seen := make(map[container.Runtime]bool)
+	result := container.RuntimeUnknown
+	for _, runtime := range nodes {
+		seen[runtime] = true
+		result = runtime
+	}
+	if len(seen) > 1 {
+		return container.RuntimeMixed
+	}
+	return result
 }
 
 func (c K8sClient) ContainerRuntime(ctx context.Context) container.Runtime {
 	return c.runtimeAsync.Runtime(ctx)
 }
 
+func (c K8sClient) NodeContainerRuntime(ctx context.Context, nodeName string) container.Runtime {
+	return c.runtimeAsync.NodeRuntime(ctx, nodeName)
+}
+
+func (c K8sClient) NodePlatforms(ctx context.Context) []string {
+	return c.runtimeAsync.Platforms(ctx)
+}
+
 func ProvideContainerRuntime(ctx context.Context, kCli Client) container.Runtime {
-	return kCli.ContainerRuntime(ctx)
+	runtime := kCli.ContainerRuntime(ctx)
+	if runtime == container.RuntimeMixed {
+		logger.Get(ctx).Debugf("Cluster nodes use different container runtimes. " +
+			"Tilt will check each pod's node before updating it in place.")
+	}
+	return runtime
 }
@@ -9,6 +9,9 @@ import (
 	"github.com/tilt-dev/tilt/pkg/logger"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
 	ktesting "k8s.io/client-go/testing"
@@ -29,3 +32,69 @@ func TestRuntimeReadNodeConfig(t *testing.T) {
 	assert.Equal(t, container.RuntimeReadFailure, runtime)
 	assert.Contains(t, out.String(), "Tilt could not read your node configuration")
 }
+
+func TestRuntimeMixedNodes(t *testing.T) {
+	cs := fake.NewSimpleClientset(
+		runtimeNode("node-docker", "docker://20.10.7"),
+		runtimeNode("node-containerd", "containerd://1.5.5"))
+	runtimeAsync := newRuntimeAsync(cs.CoreV1())
+
+	ctx := logger.WithLogger(context.Background(), logger.NewTestLogger(&bytes.Buffer{}))
+	assert.Equal(t, container.RuntimeMixed, runtimeAsync.Runtime(ctx))
+	assert.Equal(t, container.RuntimeDocker, runtimeAsync.NodeRuntime(ctx, "node-docker"))
+	assert.Equal(t, container.RuntimeContainerd, runtimeAsync.NodeRuntime(ctx, "node-containerd"))
+	assert.Equal(t, container.RuntimeUnknown, runtimeAsync.NodeRuntime(ctx, "node-missing"))
+}
+
+func TestRuntimeSameNodes(t *testing.T) {
+	cs := fake.NewSimpleClientset(
+		runtimeNode("node-1", "containerd://1.5.5"),
+		runtimeNode("node-2", "containerd://1.4.9"))
+	runtimeAsync := newRuntimeAsync(cs.CoreV1())
+
+	ctx := logger.WithLogger(context.Background(), logger.NewTestLogger(&bytes.Buffer{}))
+	assert.Equal(t, container.RuntimeContainerd, runtimeAsync.Runtime(ctx))
+}
+
+func TestRuntimeDetectsNewNodes(t *testing.T) {
+	cs := fake.NewSimpleClientset(runtimeNode("node-docker", "docker://20.10.7"))
+	runtimeAsync := newRuntimeAsync(cs.CoreV1())
+
+	ctx := logger.WithLogger(context.Background(), logger.NewTestLogger(&bytes.Buffer{}))
+	assert.Equal(t, container.RuntimeDocker, runtimeAsync.Runtime(ctx))
+
+	_, err := cs.CoreV1().Nodes().Create(ctx,
+		runtimeNode("node-containerd", "containerd://1.5.5"), metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, container.RuntimeContainerd, runtimeAsync.NodeRuntime(ctx, "node-containerd"))
+	assert.Equal(t, container.RuntimeMixed, runtimeAsync.Runtime(ctx))
+}
+
+func TestNodePlatforms(t *testing.T) {
+	cs := fake.NewSimpleClientset(
+		platformNode("node-1", "amd64"),
+		platformNode("node-2", "arm64"),
+		platformNode("node-3", "amd64"),
+		runtimeNode("node-unknown", "containerd://1.5.5"))
+	runtimeAsync := newRuntimeAsync(cs.CoreV1())
+
+	ctx := logger.WithLogger(context.Background(), logger.NewTestLogger(&bytes.Buffer{}))
+	assert.Equal(t, []string{"linux/amd64", "linux/arm64"}, runtimeAsync.Platforms(ctx))
+}
+
+func platformNode(name string, arch string) *v1.Node {
+	node := runtimeNode(name, "containerd://1.5.5")
+	node.Status.NodeInfo.OperatingSystem = "linux"
+	node.Status.NodeInfo.Architecture = arch
+	return node
+}
+
+func runtimeNode(name string, runtimeVersion string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: v1.NodeStatus{
+			NodeInfo: v1.NodeSystemInfo{ContainerRuntimeVersion: runtimeVersion},
+		},
+	}
+}
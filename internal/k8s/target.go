@@ -113,6 +113,7 @@ func PortForwardTemplateSpec(forwards []model.PortForward) *v1alpha1.PortForward
 			Host:          fwd.Host,
 			Name:          fwd.Name,
 			Path:          fwd.PathForAppend(),
+			Verify:        fwd.Verify,
 		}
 	}
 	return &v1alpha1.PortForwardTemplateSpec{
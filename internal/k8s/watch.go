@@ -41,6 +41,13 @@ type InformerSet interface {
 	//
 	// The pod should be treated as immutable (since it's a pointer to a shared cache reference).
 	PodFromInformerCache(ctx context.Context, nn types.NamespacedName) (*v1.Pod, error)
+
+	// Fetch the current objects straight from the cluster, bypassing the informer caches.
+	//
+	// Useful for catching up on changes that a watch may have missed.
+	ListPods(ctx context.Context, ns Namespace) ([]*v1.Pod, error)
+	ListServices(ctx context.Context, ns Namespace) ([]*v1.Service, error)
+	ListEvents(ctx context.Context, ns Namespace) ([]*v1.Event, error)
 }
 
 type informerSet struct {
@@ -325,6 +332,45 @@ func (s *informerSet) WatchServices(ctx context.Context, ns Namespace) (<-chan *
 	return ch, nil
 }
 
+func (s *informerSet) ListPods(ctx context.Context, ns Namespace) ([]*v1.Pod, error) {
+	list, err := s.clientset.CoreV1().Pods(ns.String()).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(maybeUnpackStatusError(err), "ListPods")
+	}
+
+	result := make([]*v1.Pod, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, FixContainerStatusImagesNoMutation(&list.Items[i]))
+	}
+	return result, nil
+}
+
+func (s *informerSet) ListServices(ctx context.Context, ns Namespace) ([]*v1.Service, error) {
+	list, err := s.clientset.CoreV1().Services(ns.String()).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(maybeUnpackStatusError(err), "ListServices")
+	}
+
+	result := make([]*v1.Service, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, &list.Items[i])
+	}
+	return result, nil
+}
+
+func (s *informerSet) ListEvents(ctx context.Context, ns Namespace) ([]*v1.Event, error) {
+	list, err := s.clientset.CoreV1().Events(ns.String()).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(maybeUnpackStatusError(err), "ListEvents")
+	}
+
+	result := make([]*v1.Event, 0, len(list.Items))
+	for i := range list.Items {
+		result = append(result, &list.Items[i])
+	}
+	return result, nil
+}
+
 func supportsPartialMetadata(v *version.Info) bool {
 	k1dot15, err := semver.ParseTolerant("v1.15.0")
 	if err != nil {
@@ -62,72 +62,138 @@ func loadKustFile(dir string) ([]byte, string, error) {
 	}
 }
 
+// The files that a kustomization reads, as found by walking the
+// kustomization chain.
+type Dependencies struct {
+	// Local files, including the kustomization files themselves.
+	Local []string
+
+	// Remote bases and resources (e.g., github.com/org/repo//base?ref=v1).
+	// We can't watch these, so we only report them.
+	Remote []string
+}
+
 // Code for parsing Kustomize adapted from Kustomize
 // https://github.com/kubernetes-sigs/kustomize/blob/ee68a9c450bc884b0d657fb7e3d62eb1ac59d14f/pkg/target/kusttarget.go#L97
 //
 // Code for parsing out dependencies copied from Skaffold
 // https://github.com/GoogleContainerTools/skaffold/blob/511c77f1736b657415500eb9b820ae7e4f753347/pkg/skaffold/deploy/kustomize.go
-func dependenciesForKustomization(dir string) ([]string, error) {
-	var deps []string
+func dependenciesForKustomization(dir string, visited map[string]bool, deps *Dependencies) error {
+	// Overlays often share a base, and kustomize itself rejects cycles,
+	// so each directory only needs to be read once.
+	if visited[dir] {
+		return nil
+	}
+	visited[dir] = true
 
 	buf, path, err := loadKustFile(dir)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	content := types.Kustomization{}
 	if err := yaml.Unmarshal(buf, &content); err != nil {
-		return nil, err
+		return err
 	}
 
 	errs := content.EnforceFields()
 	if len(errs) > 0 {
-		return nil, fmt.Errorf("Failed to read kustomization file under %s:\n"+strings.Join(errs, "\n"), dir)
+		return fmt.Errorf("Failed to read kustomization file under %s:\n"+strings.Join(errs, "\n"), dir)
 	}
+	content.FixKustomizationPostUnmarshalling()
 
 	paths := append([]string{}, content.Bases...)
 	paths = append(paths, content.Resources...)
+	paths = append(paths, content.Components...)
 
 	for _, p := range paths {
 		abs := filepath.Join(dir, p)
 		if ospath.IsDir(abs) {
-			curDeps, err := dependenciesForKustomization(filepath.Join(dir, p))
+			err := dependenciesForKustomization(abs, visited, deps)
 			if err != nil {
-				return nil, err
+				return err
 			}
-			deps = append(deps, curDeps...)
+		} else if !ospath.IsRegularFile(abs) && isRemote(p) {
+			deps.Remote = append(deps.Remote, p)
 		} else {
-			deps = append(deps, abs)
+			deps.Local = append(deps.Local, abs)
 		}
 	}
 
-	deps = append(deps, path)
+	deps.Local = append(deps.Local, path)
 	for _, patch := range content.Patches {
 		if patch.Path != "" {
-			deps = append(deps, filepath.Join(dir, patch.Path))
+			deps.Local = append(deps.Local, filepath.Join(dir, patch.Path))
 		}
 	}
 	for _, patch := range content.PatchesStrategicMerge {
-		deps = append(deps, filepath.Join(dir, string(patch)))
+		// Strategic merge patches may be written inline.
+		if !strings.Contains(string(patch), "\n") {
+			deps.Local = append(deps.Local, filepath.Join(dir, string(patch)))
+		}
 	}
-	deps = append(deps, joinPaths(dir, content.Crds)...)
+	deps.Local = append(deps.Local, joinPaths(dir, content.Crds)...)
 	for _, patch := range content.PatchesJson6902 {
-		deps = append(deps, filepath.Join(dir, patch.Path))
+		if patch.Path != "" {
+			deps.Local = append(deps.Local, filepath.Join(dir, patch.Path))
+		}
 	}
 	for _, generator := range content.ConfigMapGenerator {
-		deps = append(deps, joinPaths(dir, generator.FileSources)...)
+		deps.Local = append(deps.Local, generatorPaths(dir, generator.KvPairSources)...)
+	}
+	for _, generator := range content.SecretGenerator {
+		deps.Local = append(deps.Local, generatorPaths(dir, generator.KvPairSources)...)
 	}
 
+	return nil
+}
+
+// Returns every file that the kustomization in baseDir reads, following
+// bases, resources, and components transitively.
+func Resolve(baseDir string) (Dependencies, error) {
+	deps := Dependencies{}
+	err := dependenciesForKustomization(baseDir, map[string]bool{}, &deps)
+	if err != nil {
+		return Dependencies{}, err
+	}
+
+	deps.Local = uniqDependencies(deps.Local)
+	deps.Remote = uniqDependencies(deps.Remote)
 	return deps, nil
 }
 
 func Deps(baseDir string) ([]string, error) {
-	deps, err := dependenciesForKustomization(baseDir)
+	deps, err := Resolve(baseDir)
 	if err != nil {
 		return nil, err
 	}
+	return deps.Local, nil
+}
 
-	return uniqDependencies(deps), nil
+// Kustomize accepts git repos and URLs anywhere it accepts a directory.
+// We only call something remote if there's no local file by that name.
+func isRemote(p string) bool {
+	if strings.Contains(p, "://") || strings.HasPrefix(p, "git@") {
+		return true
+	}
+	for _, host := range []string{"github.com/", "gitlab.com/", "bitbucket.org/"} {
+		if strings.HasPrefix(p, host) {
+			return true
+		}
+	}
+	return strings.Contains(p, "?ref=")
+}
+
+// Generator file sources are of the form [{key}=]{path}.
+func generatorPaths(root string, sources types.KvPairSources) []string {
+	var list []string
+	for _, source := range sources.FileSources {
+		if i := strings.Index(source, "="); i >= 0 {
+			source = source[i+1:]
+		}
+		list = append(list, filepath.Join(root, source))
+	}
+	return append(list, joinPaths(root, sources.EnvSources)...)
 }
 
 func joinPaths(root string, paths []string) []string {
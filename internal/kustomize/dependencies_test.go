@@ -161,6 +161,124 @@ patches:
 	f.assertDeps(expected)
 }
 
+func TestTwoLevelsOfBases(t *testing.T) {
+	f := newKustomizeFixture(t)
+	f.writeBaseKustomize("overlays/dev", `resources:
+- ../../base/app
+patchesStrategicMerge:
+- replicas.yaml`)
+	f.writeBaseFile("overlays/dev", "replicas.yaml", "")
+	f.writeBaseKustomize("base/app", `resources:
+- ../common
+- deployment.yaml`)
+	f.writeBaseFile("base/app", "deployment.yaml", "")
+	f.writeBaseKustomize("base/common", `resources:
+- namespace.yaml`)
+	f.writeBaseFile("base/common", "namespace.yaml", "")
+
+	deps, err := Resolve(f.tempdir.JoinPath("overlays/dev"))
+	require.NoError(t, err)
+	require.ElementsMatch(t, f.tempdir.JoinPaths([]string{
+		"overlays/dev/kustomization.yaml",
+		"overlays/dev/replicas.yaml",
+		"base/app/kustomization.yaml",
+		"base/app/deployment.yaml",
+		"base/common/kustomization.yaml",
+		"base/common/namespace.yaml",
+	}), deps.Local)
+	require.Empty(t, deps.Remote)
+}
+
+func TestComponents(t *testing.T) {
+	f := newKustomizeFixture(t)
+	f.writeRootKustomize(`resources:
+- deployment.yaml
+components:
+- ./monitoring`)
+	f.writeBaseFile("monitoring", "kustomization.yaml", `apiVersion: kustomize.config.k8s.io/v1alpha1
+kind: Component
+resources:
+- servicemonitor.yaml`)
+
+	expected := []string{
+		"kustomization.yaml",
+		"deployment.yaml",
+		"monitoring/kustomization.yaml",
+		"monitoring/servicemonitor.yaml",
+	}
+	f.assertDeps(expected)
+}
+
+func TestRemoteBases(t *testing.T) {
+	f := newKustomizeFixture(t)
+	f.writeRootKustomize(`resources:
+- github.com/kubernetes-sigs/kustomize/examples/multibases?ref=v1.0.6
+- https://raw.githubusercontent.com/org/repo/main/deployment.yaml
+- service.yaml`)
+
+	deps, err := Resolve(f.tempdir.Path())
+	require.NoError(t, err)
+	require.ElementsMatch(t, f.tempdir.JoinPaths([]string{"kustomization.yaml", "service.yaml"}), deps.Local)
+	require.Equal(t, []string{
+		"github.com/kubernetes-sigs/kustomize/examples/multibases?ref=v1.0.6",
+		"https://raw.githubusercontent.com/org/repo/main/deployment.yaml",
+	}, deps.Remote)
+}
+
+func TestSharedBase(t *testing.T) {
+	f := newKustomizeFixture(t)
+	f.writeRootKustomize(`resources:
+- ./a
+- ./b`)
+	f.writeBaseKustomize("a", `resources:
+- ../base`)
+	f.writeBaseKustomize("b", `resources:
+- ../base`)
+	f.writeBaseKustomize("base", `resources:
+- pod.yaml`)
+
+	expected := []string{
+		"kustomization.yaml",
+		"a/kustomization.yaml",
+		"b/kustomization.yaml",
+		"base/kustomization.yaml",
+		"base/pod.yaml",
+	}
+	f.assertDeps(expected)
+}
+
+func TestGenerators(t *testing.T) {
+	f := newKustomizeFixture(t)
+	f.writeRootKustomize(`configMapGenerator:
+- name: app-config
+  files:
+  - app.properties
+  - renamed.properties=configs/original.properties
+  env: app.env
+secretGenerator:
+- name: app-secret
+  files:
+  - secret.txt
+  envs:
+  - secret.env
+patchesStrategicMerge:
+- |-
+  apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: app`)
+
+	expected := []string{
+		"kustomization.yaml",
+		"app.properties",
+		"configs/original.properties",
+		"app.env",
+		"secret.txt",
+		"secret.env",
+	}
+	f.assertDeps(expected)
+}
+
 type kustomizeFixture struct {
 	t       *testing.T
 	tempdir *tempdir.TempDirFixture
@@ -0,0 +1,169 @@
+package ospath
+
+import (
+	"path"
+	"runtime"
+	"strings"
+)
+
+// PathSemantics describes how an OS spells and compares file paths.
+//
+// Most code should use the host semantics. They're split out so that
+// path-matching code can be exercised with Windows paths on any OS.
+type PathSemantics struct {
+	// The separator that the OS uses in the paths it hands us.
+	// Forward slashes are accepted as a separator under all semantics.
+	Separator byte
+
+	// Whether two paths that differ only in case refer to the same file.
+	CaseInsensitive bool
+}
+
+var UnixSemantics = PathSemantics{Separator: '/'}
+var WindowsSemantics = PathSemantics{Separator: '\\', CaseInsensitive: true}
+
+// The semantics of the OS we're running on.
+//
+// Darwin file systems are usually case-insensitive too, but not always,
+// so we leave it to Child to check the file system when it matters.
+func HostSemantics() PathSemantics {
+	if runtime.GOOS == "windows" {
+		return WindowsSemantics
+	}
+	return UnixSemantics
+}
+
+func (s PathSemantics) isWindows() bool {
+	return s.Separator == '\\'
+}
+
+// Length of the drive letter prefix (e.g., "C:"), if any.
+func (s PathSemantics) volumeLen(p string) int {
+	if !s.isWindows() || len(p) < 2 || p[1] != ':' {
+		return 0
+	}
+	c := p[0]
+	if ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') {
+		return 2
+	}
+	return 0
+}
+
+// ToSlash returns a cleaned version of the path that uses forward slashes.
+// The casing is left alone.
+func (s PathSemantics) ToSlash(p string) string {
+	if p == "" {
+		return ""
+	}
+	if s.isWindows() {
+		p = strings.ReplaceAll(p, "\\", "/")
+	}
+
+	// path.Clean would collapse the leading slashes of a UNC path.
+	if s.isWindows() && strings.HasPrefix(p, "//") {
+		return "/" + path.Clean(p[1:])
+	}
+
+	cleaned := path.Clean(p)
+
+	// path.Clean would turn "C:/" into "C:", which is relative to the current
+	// directory on that drive.
+	if vol := s.volumeLen(cleaned); vol > 0 && len(cleaned) == vol && len(p) > vol && p[vol] == '/' {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// Native returns a cleaned version of the path that uses the OS's separator.
+func (s PathSemantics) Native(p string) string {
+	p = s.ToSlash(p)
+	if s.isWindows() {
+		p = strings.ReplaceAll(p, "/", "\\")
+	}
+	return p
+}
+
+// Key returns a canonical form of the path, suitable for comparing paths and
+// using them as map keys. Two paths that refer to the same file under these
+// semantics have the same key.
+//
+// The key may not preserve the casing of the original path, so should never
+// be shown to the user or used to build other paths.
+func (s PathSemantics) Key(p string) string {
+	p = s.ToSlash(p)
+	if s.CaseInsensitive {
+		p = strings.ToLower(p)
+	}
+	return p
+}
+
+func (s PathSemantics) IsAbs(p string) bool {
+	if !s.isWindows() {
+		return strings.HasPrefix(p, "/")
+	}
+	p = strings.ReplaceAll(p, "\\", "/")
+	if strings.HasPrefix(p, "//") {
+		return true
+	}
+	vol := s.volumeLen(p)
+	return vol > 0 && len(p) > vol && p[vol] == '/'
+}
+
+// Join joins the path elements and returns a cleaned, native path.
+func (s PathSemantics) Join(elem ...string) string {
+	parts := make([]string, 0, len(elem))
+	for _, e := range elem {
+		if e != "" {
+			parts = append(parts, s.ToSlash(e))
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return s.Native(strings.Join(parts, "/"))
+}
+
+func (s PathSemantics) equal(a, b string) bool {
+	if s.CaseInsensitive {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// Child is like the package-level Child, but compares paths under these
+// semantics, so it accepts either separator on Windows and ignores case where
+// the OS does.
+//
+// The relative path is returned with the OS's separator, and keeps the casing
+// of `file`.
+func (s PathSemantics) Child(dir string, file string) (string, bool) {
+	if dir == "" {
+		return "", false
+	}
+
+	// On the host, the package-level Child knows how to check the file system
+	// for case-insensitivity.
+	if s == HostSemantics() && !s.CaseInsensitive {
+		return Child(dir, file)
+	}
+
+	dir = s.ToSlash(dir)
+	file = s.ToSlash(file)
+	if s.equal(dir, file) {
+		return ".", true
+	}
+
+	prefix := dir
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	if len(file) <= len(prefix) || !s.equal(file[:len(prefix)], prefix) {
+		return "", false
+	}
+	return s.Native(file[len(prefix):]), true
+}
+
+func (s PathSemantics) IsChild(dir string, file string) bool {
+	_, ok := s.Child(dir, file)
+	return ok
+}
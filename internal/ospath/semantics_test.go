@@ -0,0 +1,60 @@
+package ospath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindowsSemanticsNative(t *testing.T) {
+	s := WindowsSemantics
+	assert.Equal(t, `C:\Repo\src\main.go`, s.Native(`C:/Repo/src/main.go`))
+	assert.Equal(t, `C:\Repo\src\main.go`, s.Native(`C:\Repo\.\src/main.go`))
+	assert.Equal(t, `C:\`, s.Native(`C:/`))
+	assert.Equal(t, `\\server\share\dir`, s.Native(`//server/share/dir/`))
+}
+
+func TestWindowsSemanticsKey(t *testing.T) {
+	s := WindowsSemantics
+	assert.Equal(t, s.Key(`C:\Repo\src`), s.Key(`c:/repo/SRC/`))
+	assert.NotEqual(t, UnixSemantics.Key(`/Repo/src`), UnixSemantics.Key(`/repo/src`))
+}
+
+func TestWindowsSemanticsIsAbs(t *testing.T) {
+	s := WindowsSemantics
+	assert.True(t, s.IsAbs(`C:\Repo`))
+	assert.True(t, s.IsAbs(`c:/repo`))
+	assert.True(t, s.IsAbs(`\\server\share`))
+	assert.False(t, s.IsAbs(`C:repo`))
+	assert.False(t, s.IsAbs(`repo\src`))
+	assert.False(t, s.IsAbs(`/repo`))
+	assert.False(t, UnixSemantics.IsAbs(`C:\Repo`))
+}
+
+func TestWindowsSemanticsJoin(t *testing.T) {
+	s := WindowsSemantics
+	assert.Equal(t, `C:\Repo\node_modules\foo`, s.Join(`C:\Repo`, "node_modules/foo"))
+	assert.Equal(t, `C:\Repo`, s.Join(`C:/Repo/`, ""))
+}
+
+func TestWindowsSemanticsChild(t *testing.T) {
+	s := WindowsSemantics
+	for _, tc := range []struct {
+		dir, file string
+		expected  string
+		ok        bool
+	}{
+		{`C:\Repo`, `C:\Repo\src\Main.go`, `src\Main.go`, true},
+		{`C:/Repo`, `C:\Repo\src\Main.go`, `src\Main.go`, true},
+		{`c:\repo`, `C:\Repo\Src\Main.go`, `Src\Main.go`, true},
+		{`C:\Repo\`, `C:\Repo`, `.`, true},
+		{`C:\`, `C:\Repo\src`, `Repo\src`, true},
+		{`C:\Repo`, `C:\Repository\src`, ``, false},
+		{`C:\Repo`, `D:\Repo\src`, ``, false},
+		{``, `C:\Repo`, ``, false},
+	} {
+		actual, ok := s.Child(tc.dir, tc.file)
+		assert.Equal(t, tc.ok, ok, "Child(%q, %q)", tc.dir, tc.file)
+		assert.Equal(t, tc.expected, actual, "Child(%q, %q)", tc.dir, tc.file)
+	}
+}
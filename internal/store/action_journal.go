@@ -0,0 +1,93 @@
+package store
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// How many of the most recent actions the journal remembers.
+const actionJournalSize = 500
+
+// Payload summaries longer than this are truncated.
+const actionJournalMaxPayload = 2048
+
+// Reducers that take longer than this are flagged as slow.
+const SlowReduceThreshold = 50 * time.Millisecond
+
+// Whether the store keeps a journal of the actions it reduces.
+type ActionJournalFlag bool
+
+// A summary of one action that the store reduced.
+type JournalEntry struct {
+	Type    string    `json:"type"`
+	Time    time.Time `json:"time"`
+	Payload string    `json:"payload"`
+
+	// How long the reducer took on this action.
+	ReduceDuration time.Duration `json:"reduceDuration"`
+
+	// True if the reducer took longer than SlowReduceThreshold.
+	Slow bool `json:"slow,omitempty"`
+}
+
+// Actions can point to big structs (like whole manifests),
+// so don't follow pointers too far when summarizing them.
+var journalSpew = spew.ConfigState{
+	Indent:                  " ",
+	MaxDepth:                3,
+	DisablePointerAddresses: true,
+	DisableCapacities:       true,
+	SortKeys:                true,
+}
+
+// A ring buffer of the most recent actions, to help debug reducers.
+type actionJournal struct {
+	mu      sync.Mutex
+	entries []JournalEntry
+	next    int
+	full    bool
+}
+
+func newActionJournal(size int) *actionJournal {
+	return &actionJournal{entries: make([]JournalEntry, size)}
+}
+
+func (j *actionJournal) record(action Action, start time.Time, duration time.Duration, secrets model.SecretSet) {
+	payload := secrets.Scrub([]byte(journalSpew.Sdump(action)))
+	if len(payload) > actionJournalMaxPayload {
+		payload = append(payload[:actionJournalMaxPayload:actionJournalMaxPayload], []byte("...(truncated)")...)
+	}
+
+	entry := JournalEntry{
+		Type:           fmt.Sprintf("%T", action),
+		Time:           start,
+		Payload:        string(payload),
+		ReduceDuration: duration,
+		Slow:           duration > SlowReduceThreshold,
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries[j.next] = entry
+	j.next++
+	if j.next == len(j.entries) {
+		j.next = 0
+		j.full = true
+	}
+}
+
+// The journal's entries, oldest first.
+func (j *actionJournal) Entries() []JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if !j.full {
+		return append([]JournalEntry{}, j.entries[:j.next]...)
+	}
+	result := append([]JournalEntry{}, j.entries[j.next:]...)
+	return append(result, j.entries[:j.next]...)
+}
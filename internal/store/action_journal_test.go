@@ -0,0 +1,82 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestActionJournal(t *testing.T) {
+	f := newFixtureWithStore(t, NewStore(TestReducer, LogActionsFlag(false), ActionJournalFlag(true)))
+	f.Start()
+
+	f.store.Dispatch(CompletedBuildAction{})
+	f.store.Dispatch(SlowAction{})
+	f.store.Dispatch(DoneAction{})
+	f.WaitUntilDone()
+
+	entries, ok := f.store.ActionJournal()
+	require.True(t, ok)
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, "store.CompletedBuildAction", entries[0].Type)
+	assert.False(t, entries[0].Slow)
+	assert.Equal(t, "store.SlowAction", entries[1].Type)
+	assert.True(t, entries[1].Slow)
+	assert.True(t, entries[1].ReduceDuration > SlowReduceThreshold)
+	assert.Equal(t, "store.DoneAction", entries[2].Type)
+	assert.False(t, entries[2].Slow)
+
+	assert.False(t, entries[0].Time.IsZero())
+	assert.False(t, entries[1].Time.Before(entries[0].Time))
+}
+
+func TestActionJournalOff(t *testing.T) {
+	f := newFixture(t)
+	f.Start()
+
+	f.store.Dispatch(CompletedBuildAction{})
+	f.store.Dispatch(DoneAction{})
+	f.WaitUntilDone()
+
+	entries, ok := f.store.ActionJournal()
+	assert.False(t, ok)
+	assert.Empty(t, entries)
+}
+
+func TestActionJournalDropsOldest(t *testing.T) {
+	j := newActionJournal(3)
+	for i := 0; i < 5; i++ {
+		j.record(NewLogAction(model.ManifestName(fmt.Sprintf("m%d", i)), "", logger.InfoLvl, nil, nil), time.Now(), 0, nil)
+	}
+
+	entries := j.Entries()
+	require.Len(t, entries, 3)
+	for i, e := range entries {
+		assert.Contains(t, e.Payload, fmt.Sprintf("m%d", i+2))
+	}
+}
+
+func TestActionJournalPayload(t *testing.T) {
+	secrets := model.SecretSet{}
+	secrets.AddSecret("my-secret", "password", []byte("hunter2hunter2"))
+
+	j := newActionJournal(2)
+	j.record(NewLogAction("fe", "", logger.InfoLvl, nil, []byte("password is hunter2hunter2")), time.Now(), 0, secrets)
+	j.record(NewLogAction("fe", "", logger.InfoLvl, nil, []byte(strings.Repeat("x", 10*actionJournalMaxPayload))), time.Now(), 0, secrets)
+
+	entries := j.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "store.LogAction", entries[0].Type)
+	assert.NotContains(t, entries[0].Payload, "hunter2hunter2")
+	assert.Contains(t, entries[0].Payload, "[redacted secret my-secret:password]")
+	assert.True(t, strings.HasSuffix(entries[1].Payload, "...(truncated)"))
+	assert.Len(t, entries[1].Payload, actionJournalMaxPayload+len("...(truncated)"))
+}
@@ -0,0 +1,128 @@
+package store
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// Weights for the signals that make up a resource's attention score.
+//
+// Current error states always count in full. Everything else is a past event,
+// and its weight halves every AttentionHalfLife, so that a resource that broke
+// an hour ago and has been fine since sinks back down the list.
+const (
+	// The resource's server is currently in an error state, or its most recent
+	// build failed.
+	AttentionWeightCurrentError = 100.0
+
+	// An older build failed.
+	AttentionWeightBuildFailure = 30.0
+
+	// The resource was rebuilt because its container crashed, or is waiting to be.
+	AttentionWeightCrash = 20.0
+
+	// The resource has file changes that haven't been built yet.
+	//
+	// Grows linearly with the age of the oldest pending change, up to
+	// AttentionPendingMaxAge.
+	AttentionWeightPendingChanges = 10.0
+
+	// A build finished with warnings.
+	AttentionWeightWarnings = 5.0
+)
+
+// How long it takes for a past event to count half as much.
+const AttentionHalfLife = 5 * time.Minute
+
+// How long pending changes have to wait before they get the full
+// AttentionWeightPendingChanges.
+const AttentionPendingMaxAge = 5 * time.Minute
+
+// Scores how much a resource needs the user's attention. Higher is more urgent;
+// 0 means there's nothing to look at.
+//
+// A pure function of the manifest state and the current time, so that the
+// same state always produces the same score.
+func AttentionScore(ms *ManifestState, now time.Time) float64 {
+	score := 0.0
+
+	if ms.RuntimeState != nil && ms.RuntimeState.RuntimeStatus() == v1alpha1.RuntimeStatusError {
+		score += AttentionWeightCurrentError
+	}
+
+	for i, b := range ms.BuildHistory {
+		decay := attentionDecay(b.FinishTime, now)
+		if b.Error != nil && !b.Canceled {
+			if i == 0 {
+				score += AttentionWeightCurrentError
+			} else {
+				score += AttentionWeightBuildFailure * decay
+			}
+		}
+		if b.Reason.Has(model.BuildReasonFlagCrash) {
+			score += AttentionWeightCrash * decay
+		}
+		if b.WarningCount > 0 {
+			score += AttentionWeightWarnings * decay
+		}
+	}
+
+	if ms.NeedsRebuildFromCrash {
+		score += AttentionWeightCrash
+	}
+
+	if ok, since := ms.HasPendingChangesBeforeOrEqual(now); ok {
+		age := now.Sub(since)
+		if age > AttentionPendingMaxAge {
+			age = AttentionPendingMaxAge
+		}
+		if age > 0 {
+			score += AttentionWeightPendingChanges * float64(age) / float64(AttentionPendingMaxAge)
+		}
+	}
+
+	return score
+}
+
+// Scores every Tiltfile and resource in the engine state.
+func AttentionScores(state EngineState, now time.Time) map[model.ManifestName]float64 {
+	result := make(map[model.ManifestName]float64, len(state.TiltfileStates)+len(state.ManifestTargets))
+	for name, ms := range state.TiltfileStates {
+		result[name] = AttentionScore(ms, now)
+	}
+	for name, mt := range state.ManifestTargets {
+		result[name] = AttentionScore(mt.State, now)
+	}
+	return result
+}
+
+// Rounds a score for display and for the API, so that small changes from decay
+// don't register as updates.
+func RoundAttentionScore(score float64) int32 {
+	return int32(math.Round(score))
+}
+
+// Sorts names from most to least urgent.
+//
+// Compares rounded scores, and keeps the input order for ties, so that
+// resources with similar scores don't trade places as their scores decay.
+func SortByAttention(names []model.ManifestName, scores map[model.ManifestName]float64) {
+	sort.SliceStable(names, func(i, j int) bool {
+		return RoundAttentionScore(scores[names[i]]) > RoundAttentionScore(scores[names[j]])
+	})
+}
+
+func attentionDecay(t time.Time, now time.Time) float64 {
+	if t.IsZero() {
+		return 0
+	}
+	age := now.Sub(t)
+	if age <= 0 {
+		return 1
+	}
+	return math.Pow(0.5, float64(age)/float64(AttentionHalfLife))
+}
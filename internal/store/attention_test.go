@@ -0,0 +1,151 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+var attentionStart = time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func TestAttentionScoreHealthy(t *testing.T) {
+	ms := &ManifestState{
+		RuntimeState: LocalRuntimeState{Status: v1alpha1.RuntimeStatusOK},
+		BuildHistory: []model.BuildRecord{{FinishTime: attentionStart}},
+	}
+	assert.Equal(t, 0.0, AttentionScore(ms, attentionStart))
+}
+
+func TestAttentionScoreCanceledBuild(t *testing.T) {
+	ms := &ManifestState{
+		BuildHistory: []model.BuildRecord{{
+			FinishTime: attentionStart,
+			Error:      fmt.Errorf("context canceled"),
+			Canceled:   true,
+		}},
+	}
+	assert.Equal(t, 0.0, AttentionScore(ms, attentionStart))
+}
+
+func TestAttentionScoreOrdering(t *testing.T) {
+	now := attentionStart
+	failed := fmt.Errorf("build failed")
+	states := map[model.ManifestName]*ManifestState{
+		"healthy": {
+			BuildHistory: []model.BuildRecord{{FinishTime: now.Add(-time.Minute)}},
+		},
+		"runtime-error": {
+			RuntimeState: LocalRuntimeState{Status: v1alpha1.RuntimeStatusError},
+		},
+		"last-build-failed": {
+			BuildHistory: []model.BuildRecord{{FinishTime: now.Add(-time.Hour), Error: failed}},
+		},
+		"fixed-after-failure": {
+			BuildHistory: []model.BuildRecord{
+				{FinishTime: now.Add(-time.Minute)},
+				{FinishTime: now.Add(-2 * time.Minute), Error: failed},
+			},
+		},
+		"crash-rebuild": {
+			BuildHistory: []model.BuildRecord{
+				{FinishTime: now.Add(-time.Minute), Reason: model.BuildReasonFlagCrash},
+			},
+		},
+		"warnings": {
+			BuildHistory: []model.BuildRecord{{FinishTime: now.Add(-time.Minute), WarningCount: 2}},
+		},
+		"pending": {
+			PendingManifestChange: now.Add(-time.Minute),
+		},
+	}
+
+	state := NewState()
+	state.TiltfileStates = map[model.ManifestName]*ManifestState{}
+	var names []model.ManifestName
+	for _, name := range []model.ManifestName{
+		"healthy", "warnings", "pending", "crash-rebuild", "fixed-after-failure", "last-build-failed", "runtime-error",
+	} {
+		state.TiltfileStates[name] = states[name]
+		names = append(names, name)
+	}
+
+	SortByAttention(names, AttentionScores(*state, now))
+	assert.Equal(t, []model.ManifestName{
+		"last-build-failed",
+		"runtime-error",
+		"fixed-after-failure",
+		"crash-rebuild",
+		"warnings",
+		"pending",
+		"healthy",
+	}, names)
+}
+
+func TestAttentionScoreDecay(t *testing.T) {
+	clock := clockwork.NewFakeClockAt(attentionStart)
+	ms := &ManifestState{
+		BuildHistory: []model.BuildRecord{
+			{FinishTime: attentionStart},
+			{FinishTime: attentionStart, Error: fmt.Errorf("build failed")},
+		},
+	}
+
+	assert.Equal(t, AttentionWeightBuildFailure, AttentionScore(ms, clock.Now()))
+
+	clock.Advance(AttentionHalfLife)
+	assert.InDelta(t, AttentionWeightBuildFailure/2, AttentionScore(ms, clock.Now()), 0.001)
+
+	clock.Advance(AttentionHalfLife)
+	assert.InDelta(t, AttentionWeightBuildFailure/4, AttentionScore(ms, clock.Now()), 0.001)
+
+	clock.Advance(time.Hour)
+	assert.Equal(t, int32(0), RoundAttentionScore(AttentionScore(ms, clock.Now())))
+}
+
+func TestAttentionScoreCurrentErrorDoesNotDecay(t *testing.T) {
+	clock := clockwork.NewFakeClockAt(attentionStart)
+	ms := &ManifestState{
+		RuntimeState: LocalRuntimeState{Status: v1alpha1.RuntimeStatusError},
+	}
+
+	assert.Equal(t, AttentionWeightCurrentError, AttentionScore(ms, clock.Now()))
+	clock.Advance(24 * time.Hour)
+	assert.Equal(t, AttentionWeightCurrentError, AttentionScore(ms, clock.Now()))
+}
+
+func TestAttentionScorePendingChangesGrow(t *testing.T) {
+	clock := clockwork.NewFakeClockAt(attentionStart)
+	ms := &ManifestState{PendingManifestChange: attentionStart}
+
+	assert.Equal(t, 0.0, AttentionScore(ms, clock.Now()))
+
+	clock.Advance(AttentionPendingMaxAge / 2)
+	assert.InDelta(t, AttentionWeightPendingChanges/2, AttentionScore(ms, clock.Now()), 0.001)
+
+	clock.Advance(time.Hour)
+	assert.Equal(t, AttentionWeightPendingChanges, AttentionScore(ms, clock.Now()))
+}
+
+func TestSortByAttentionStable(t *testing.T) {
+	names := []model.ManifestName{"c", "a", "b", "d"}
+	scores := map[model.ManifestName]float64{
+		"a": 10.2,
+		"b": 9.8,
+		"c": 0,
+		"d": 50,
+	}
+
+	// a and b round to the same score, so they keep their order.
+	SortByAttention(names, scores)
+	assert.Equal(t, []model.ManifestName{"d", "a", "b", "c"}, names)
+
+	names = []model.ManifestName{"c", "b", "a", "d"}
+	SortByAttention(names, scores)
+	assert.Equal(t, []model.ManifestName{"d", "b", "a", "c"}, names)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/docker/go-connections/nat"
 
 	"github.com/tilt-dev/tilt/internal/container"
+	"github.com/tilt-dev/tilt/internal/k8s"
 	"github.com/tilt-dev/tilt/internal/store/dcconv"
 	"github.com/tilt-dev/tilt/internal/store/k8sconv"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
@@ -45,6 +46,26 @@ func NewLocalBuildResult(id model.TargetID) LocalBuildResult {
 	}
 }
 
+type SyncBuildResult struct {
+	id model.TargetID
+
+	// The pod we synced files to. Empty if we synced to a local directory.
+	PodID k8s.PodID
+
+	// The number of synced paths that we copied and deleted.
+	PathsCopied  int
+	PathsDeleted int
+}
+
+func (r SyncBuildResult) TargetID() model.TargetID   { return r.id }
+func (r SyncBuildResult) BuildType() model.BuildType { return model.BuildTypeSync }
+
+func NewSyncBuildResult(id model.TargetID) SyncBuildResult {
+	return SyncBuildResult{
+		id: id,
+	}
+}
+
 type ImageBuildResult struct {
 	id model.TargetID
 
@@ -59,6 +80,22 @@ type ImageBuildResult struct {
 	// ClusterRef is http://registry/my-img:tilt-abc
 
 	ImageMapStatus v1alpha1.ImageMapStatus
+
+	// Bytes of build context sent to the image builder.
+	// 0 for custom builds, where we don't see the context.
+	ContextSize int64
+
+	// How each Dockerfile step used the build cache.
+	// Empty for custom builds.
+	CacheSteps model.DockerBuildSteps
+
+	// The Dockerfile stage we built, if the image didn't use the last one.
+	TargetStage model.DockerBuildTarget
+
+	// How we got the image to the cluster, and why.
+	// Empty if this build doesn't get images to a cluster.
+	PushMode   model.ImagePushMode
+	PushReason string
 }
 
 func (r ImageBuildResult) TargetID() model.TargetID   { return r.id }
@@ -137,6 +174,10 @@ type K8sBuildResult struct {
 	*k8sconv.KubernetesApplyFilter
 
 	id model.TargetID
+
+	// How the objects we applied differ from the previous apply.
+	// nil if we didn't apply, or have nothing to compare to.
+	ApplyDiff *model.K8sApplyDiff
 }
 
 func (r K8sBuildResult) TargetID() model.TargetID   { return r.id }
@@ -214,6 +255,70 @@ func (set BuildResultSet) BuildTypes() []model.BuildType {
 	return result
 }
 
+// Total bytes of build context sent to the image builder for the images in this set.
+func (set BuildResultSet) ContextSize() int64 {
+	total := int64(0)
+	for _, br := range set {
+		result, ok := br.(ImageBuildResult)
+		if ok {
+			total += result.ContextSize
+		}
+	}
+	return total
+}
+
+// The cache breakdown of each Dockerfile build in this set, ordered by image.
+func (set BuildResultSet) ImageCache() []model.ImageBuildCache {
+	var result []model.ImageBuildCache
+	for _, br := range set {
+		r, ok := br.(ImageBuildResult)
+		if !ok || len(r.CacheSteps) == 0 {
+			continue
+		}
+		result = append(result, model.ImageBuildCache{
+			Image:  r.id.Name.String(),
+			Target: string(r.TargetStage),
+			Steps:  r.CacheSteps,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Image < result[j].Image
+	})
+	return result
+}
+
+// How each image in this set got to the cluster, ordered by image.
+func (set BuildResultSet) ImagePushes() []model.ImagePush {
+	var result []model.ImagePush
+	for _, br := range set {
+		r, ok := br.(ImageBuildResult)
+		if !ok || r.PushMode == "" {
+			continue
+		}
+		result = append(result, model.ImagePush{
+			Image:  r.id.Name.String(),
+			Mode:   r.PushMode,
+			Reason: r.PushReason,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Image < result[j].Image
+	})
+	return result
+}
+
+// How the Kubernetes objects applied in this set differ from the previous apply.
+// nil if nothing was applied, or there's nothing to compare to.
+func (set BuildResultSet) ApplyDiff() *model.K8sApplyDiff {
+	for _, br := range set {
+		r, ok := br.(K8sBuildResult)
+		if ok && r.ApplyDiff != nil {
+			return r.ApplyDiff
+		}
+	}
+	return nil
+}
+
 // Returns a container ID iff it's the only container ID in the result set.
 // If there are multiple container IDs, we have to give up.
 func (set BuildResultSet) OneAndOnlyLiveUpdatedContainerID() container.ID {
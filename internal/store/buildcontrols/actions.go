@@ -3,6 +3,8 @@ package buildcontrols
 import (
 	"time"
 
+	"k8s.io/apimachinery/pkg/types"
+
 	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/pkg/model"
 	"github.com/tilt-dev/tilt/pkg/model/logstore"
@@ -20,6 +22,14 @@ type BuildStartedAction struct {
 
 func (BuildStartedAction) Action() {}
 
+var _ store.Summarizer = BuildStartedAction{}
+
+// Starting a build takes the manifest off the trigger queue.
+func (a BuildStartedAction) Summarize(s *store.ChangeSummary) {
+	s.TriggerQueue = true
+	s.ManifestStatuses.Add(types.NamespacedName{Name: a.ManifestName.String()})
+}
+
 type BuildCompleteAction struct {
 	ManifestName model.ManifestName
 	SpanID       logstore.SpanID
@@ -2,6 +2,7 @@ package buildcontrols
 
 import (
 	"context"
+	stderrors "errors"
 
 	"github.com/pkg/errors"
 )
@@ -12,3 +13,9 @@ func IsFatalError(err error) bool {
 	cause := errors.Cause(err)
 	return cause == context.Canceled
 }
+
+// A canceled error indicates that the build stopped because its context was
+// canceled or timed out, not because anything was wrong with it.
+func IsCanceledError(err error) bool {
+	return stderrors.Is(err, context.Canceled) || stderrors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,105 @@
+package buildcontrols
+
+import (
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// The Tilt-observed time of the earliest file change that a build
+// starting now will consume.
+//
+// Builds kicked off by a trigger don't count, even if files changed,
+// because the time the user spent before hitting the button isn't latency.
+func triggerTime(ms *store.ManifestState, reason model.BuildReason, startTime time.Time) time.Time {
+	if !reason.Has(model.BuildReasonFlagChangedFiles) || reason.HasTrigger() {
+		return time.Time{}
+	}
+
+	earliest := time.Time{}
+	for _, status := range ms.BuildStatuses {
+		for _, t := range status.PendingFileChanges {
+			if t.After(startTime) {
+				continue
+			}
+			if earliest.IsZero() || t.Before(earliest) {
+				earliest = t
+			}
+		}
+	}
+	return earliest
+}
+
+// Record the events of a successful build, then either report its latency
+// right away or wait for the resource to become ready.
+func startLatencyTracking(state *store.EngineState, mt *store.ManifestTarget, bs model.BuildRecord, liveUpdate bool) {
+	ms := mt.State
+	events := model.LatencyEvents{
+		LiveUpdate:  liveUpdate,
+		Trigger:     bs.TriggerTime,
+		BuildStart:  bs.StartTime,
+		BuildFinish: bs.FinishTime,
+	}
+
+	manifest := mt.Manifest
+	if !manifest.IsK8s() {
+		ms.PendingLatency = events
+		FinishLatency(state, mt)
+		return
+	}
+
+	if ka, ok := state.KubernetesApplys[manifest.K8sTarget().ID().Name.String()]; ok {
+		applyStart := ka.Status.LastApplyStartTime.Time
+		applyFinish := ka.Status.LastApplyTime.Time
+		if !applyStart.Before(bs.StartTime) && !applyFinish.After(bs.FinishTime) {
+			events.ApplyStart = applyStart
+			events.ApplyFinish = applyFinish
+		}
+	}
+	ms.PendingLatency = events
+
+	krs := ms.K8sRuntimeState()
+	if krs.PodReadinessMode == model.PodReadinessIgnore ||
+		(liveUpdate && isPodReadyOrSucceeded(krs, krs.MostRecentPod())) {
+		FinishLatency(state, mt)
+	}
+}
+
+func isPodReadyOrSucceeded(krs store.K8sRuntimeState, pod v1alpha1.Pod) bool {
+	if krs.PodReadinessMode == model.PodReadinessSucceeded {
+		return pod.Phase == string(v1.PodSucceeded)
+	}
+	return store.AllPodContainersReady(pod)
+}
+
+// FinishLatency stitches together the pending latency events of a manifest,
+// attaches the result to the build that caused them, and logs a summary.
+func FinishLatency(state *store.EngineState, mt *store.ManifestTarget) {
+	ms := mt.State
+	events := ms.PendingLatency
+	ms.PendingLatency = model.LatencyEvents{}
+	if events.Empty() || len(ms.BuildHistory) == 0 {
+		return
+	}
+
+	bs := &ms.BuildHistory[0]
+	if !bs.StartTime.Equal(events.BuildStart) {
+		return
+	}
+
+	bs.Latency = events.Stitch()
+	if bs.Latency.Empty() {
+		return
+	}
+
+	msg := fmt.Sprintf("%s\n", bs.Latency)
+	state.LogStore.Append(
+		store.NewLogAction(mt.Manifest.Name, bs.SpanID, logger.InfoLvl, nil, []byte(msg)),
+		state.Secrets)
+}
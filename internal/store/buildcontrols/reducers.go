@@ -39,10 +39,15 @@ func HandleBuildStarted(ctx context.Context, state *store.EngineState, action Bu
 		StartTime: action.StartTime,
 		Reason:    action.Reason,
 		SpanID:    action.SpanID,
+
+		TriggerTime: triggerTime(ms, action.Reason, action.StartTime),
 	}
 	ms.ConfigFilesThatCausedChange = []string{}
 	ms.CurrentBuild = bs
 
+	// A new build supersedes whatever we were still waiting on.
+	ms.PendingLatency = model.LatencyEvents{}
+
 	if ms.IsK8s() {
 		krs := ms.K8sRuntimeState()
 		for podID := range krs.Pods {
@@ -54,6 +59,11 @@ func HandleBuildStarted(ctx context.Context, state *store.EngineState, action Bu
 				delete(krs.UpdateStartTime, podID)
 			}
 		}
+
+		// Re-deploying is how users fix drifted images, so stop warning about
+		// them. We check again when the build finishes.
+		krs.ImageDrifts = nil
+		ms.RuntimeState = krs
 	} else if manifest.IsDC() {
 		// Attach the SpanID and initialize the runtime state if we haven't yet.
 		state, _ := ms.RuntimeState.(dockercompose.State)
@@ -186,7 +196,14 @@ func HandleBuildCompleted(ctx context.Context, engineState *store.EngineState, c
 	}
 
 	err := cb.Error
-	if err != nil {
+	canceled := err != nil && IsCanceledError(err)
+	if canceled {
+		// The cancellation isn't the resource's fault, so don't report it
+		// as a failure.
+		engineState.LogStore.Append(
+			store.NewLogAction(mt.Manifest.Name, cb.SpanID, logger.InfoLvl, nil, []byte("Build canceled")),
+			engineState.Secrets)
+	} else if err != nil {
 		s := fmt.Sprintf("Build Failed: %v", err)
 
 		engineState.LogStore.Append(
@@ -197,8 +214,13 @@ func HandleBuildCompleted(ctx context.Context, engineState *store.EngineState, c
 	ms := mt.State
 	bs := ms.CurrentBuild
 	bs.Error = err
+	bs.Canceled = canceled
 	bs.FinishTime = cb.FinishTime
 	bs.BuildTypes = cb.Result.BuildTypes()
+	bs.ContextSize = cb.Result.ContextSize()
+	bs.ImageCache = cb.Result.ImageCache()
+	bs.ImagePushes = cb.Result.ImagePushes()
+	bs.ApplyDiff = cb.Result.ApplyDiff()
 	if bs.SpanID != "" {
 		bs.WarningCount = len(engineState.LogStore.Warnings(bs.SpanID))
 	}
@@ -216,8 +238,10 @@ func HandleBuildCompleted(ctx context.Context, engineState *store.EngineState, c
 	}
 
 	if err != nil {
-		if IsFatalError(err) {
-			engineState.FatalError = err
+		if canceled {
+			// Whoever canceled the build is responsible for what happens
+			// next. When Tilt is shutting down, the store stops on its own,
+			// and a disabled resource shouldn't take Tilt down with it.
 			return
 		}
 	} else {
@@ -242,7 +266,7 @@ func HandleBuildCompleted(ctx context.Context, engineState *store.EngineState, c
 
 		krs := ms.K8sRuntimeState()
 		bestPod := krs.MostRecentPod()
-		if timecmp.AfterOrEqual(bestPod.CreatedAt, bs.StartTime) ||
+		if timecmp.AfterOrEqual(engineState.ClusterClockSkew.ToLocalTime(bestPod.CreatedAt), bs.StartTime) ||
 			timecmp.Equal(krs.UpdateStartTime[k8s.PodID(bestPod.Name)], bs.StartTime) {
 			liveupdates.CheckForContainerCrash(engineState, mn.String())
 		}
@@ -259,6 +283,10 @@ func HandleBuildCompleted(ctx context.Context, engineState *store.EngineState, c
 
 		if err == nil {
 			state.HasEverDeployedSuccessfully = true
+
+			if job, ok := store.TaskJobRef(manifest, applyFilter); ok {
+				state.StartTaskRun(job, cb.FinishTime)
+			}
 		}
 
 		ms.RuntimeState = state
@@ -288,6 +316,9 @@ func HandleBuildCompleted(ctx context.Context, engineState *store.EngineState, c
 					state = state.WithLastReadyTime(cb.FinishTime)
 				}
 			}
+			if docker.IsHealthy(*cState) && state.LastHealthyTime.IsZero() {
+				state = state.WithLastHealthyTime(cb.FinishTime)
+			}
 		}
 
 		ms.RuntimeState = state
@@ -309,4 +340,12 @@ func HandleBuildCompleted(ctx context.Context, engineState *store.EngineState, c
 		}
 		ms.RuntimeState = lrs
 	}
+
+	if mt.Manifest.IsSync() && err == nil {
+		ms.RuntimeState = store.SyncRuntimeState{LastSyncTime: cb.FinishTime}
+	}
+
+	if err == nil {
+		startLatencyTracking(engineState, mt, bs, len(liveUpdateContainerIDs) > 0)
+	}
 }
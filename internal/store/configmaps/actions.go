@@ -1,6 +1,11 @@
 package configmaps
 
-import "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+import (
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+)
 
 type ConfigMapUpsertAction struct {
 	ConfigMap *v1alpha1.ConfigMap
@@ -12,6 +17,32 @@ func NewConfigMapUpsertAction(obj *v1alpha1.ConfigMap) ConfigMapUpsertAction {
 
 func (ConfigMapUpsertAction) Action() {}
 
+var _ store.Summarizer = ConfigMapUpsertAction{}
+
+func (a ConfigMapUpsertAction) Summarize(s *store.ChangeSummary) {
+	s.ConfigMaps.Add(types.NamespacedName{Name: a.ConfigMap.Name})
+}
+
+// Upserts several ConfigMaps in one action, so that subscribers never see
+// some of them changed and others not.
+type ConfigMapsUpsertAction struct {
+	ConfigMaps []*v1alpha1.ConfigMap
+}
+
+func NewConfigMapsUpsertAction(objs []*v1alpha1.ConfigMap) ConfigMapsUpsertAction {
+	return ConfigMapsUpsertAction{ConfigMaps: objs}
+}
+
+func (ConfigMapsUpsertAction) Action() {}
+
+var _ store.Summarizer = ConfigMapsUpsertAction{}
+
+func (a ConfigMapsUpsertAction) Summarize(s *store.ChangeSummary) {
+	for _, cm := range a.ConfigMaps {
+		s.ConfigMaps.Add(types.NamespacedName{Name: cm.Name})
+	}
+}
+
 type ConfigMapDeleteAction struct {
 	Name string
 }
@@ -21,3 +52,9 @@ func NewConfigMapDeleteAction(n string) ConfigMapDeleteAction {
 }
 
 func (ConfigMapDeleteAction) Action() {}
+
+var _ store.Summarizer = ConfigMapDeleteAction{}
+
+func (a ConfigMapDeleteAction) Summarize(s *store.ChangeSummary) {
+	s.ConfigMaps.Add(types.NamespacedName{Name: a.Name})
+}
@@ -1,14 +1,69 @@
 package configmaps
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
 	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+	"github.com/tilt-dev/tilt/pkg/model/logstore"
 )
 
 func HandleConfigMapUpsertAction(state *store.EngineState, action ConfigMapUpsertAction) {
-	n := action.ConfigMap.Name
-	state.ConfigMaps[n] = action.ConfigMap
+	upsert(state, action.ConfigMap)
+}
+
+func HandleConfigMapsUpsertAction(state *store.EngineState, action ConfigMapsUpsertAction) {
+	for _, cm := range action.ConfigMaps {
+		upsert(state, cm)
+	}
 }
 
 func HandleConfigMapDeleteAction(state *store.EngineState, action ConfigMapDeleteAction) {
 	delete(state.ConfigMaps, action.Name)
 }
+
+func upsert(state *store.EngineState, cm *v1alpha1.ConfigMap) {
+	old := state.ConfigMaps[cm.Name]
+	upgraded, err := configmap.Upgraded(cm)
+	if err != nil {
+		// Keep the data as-is, and tell the user why we can't use it.
+		// Only complain once per version.
+		if old == nil || old.Data[configmap.VersionKey] != cm.Data[configmap.VersionKey] {
+			msg := fmt.Sprintf("Error reading ConfigMap %q: %v\n", cm.Name, err)
+			state.LogStore.Append(store.NewGlobalLogAction(logger.ErrorLvl, []byte(msg)), state.Secrets)
+		}
+		state.ConfigMaps[cm.Name] = cm
+		return
+	}
+
+	logDisableTransitions(state, old, upgraded)
+	state.ConfigMaps[cm.Name] = upgraded
+}
+
+func SpanIDForDisableLog(mn model.ManifestName) logstore.SpanID {
+	return logstore.SpanID(fmt.Sprintf("disable:%s", mn))
+}
+
+// If the ConfigMap controls whether a resource is disabled, logs each new
+// change to the resource's log.
+func logDisableTransitions(state *store.EngineState, old, cm *v1alpha1.ConfigMap) {
+	if _, ok := cm.Data[configmap.DisableKey]; !ok || !strings.HasSuffix(cm.Name, "-disable") {
+		return
+	}
+	mn := model.ManifestName(strings.TrimSuffix(cm.Name, "-disable"))
+
+	oldHistory := configmap.DisableHistory(old)
+	for _, t := range configmap.DisableHistory(cm) {
+		if len(oldHistory) > 0 && !t.Time.After(oldHistory[len(oldHistory)-1].Time.Time) {
+			continue
+		}
+		msg := fmt.Sprintf("%s\n", configmap.DescribeDisableTransition(t))
+		state.LogStore.Append(
+			store.NewLogAction(mn, SpanIDForDisableLog(mn), logger.InfoLvl, nil, []byte(msg)),
+			state.Secrets)
+	}
+}
@@ -0,0 +1,73 @@
+package configmaps
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/tilt-dev/tilt/internal/controllers/apis/configmap"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+)
+
+func TestLogsOneLinePerDisableTransition(t *testing.T) {
+	state := store.NewState()
+	start := time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	cm := &v1alpha1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "fe-disable"}}
+	configmap.RecordDisableChange(cm, false, v1alpha1.DisableChangeSourceTiltfile, start)
+	HandleConfigMapUpsertAction(state, NewConfigMapUpsertAction(cm))
+	assert.Equal(t, "Resource enabled by the Tiltfile\n", state.LogStore.ManifestLog("fe"))
+
+	cm = cm.DeepCopy()
+	configmap.RecordDisableChange(cm, true, v1alpha1.DisableChangeSourceUI, start.Add(time.Second))
+	HandleConfigMapsUpsertAction(state, NewConfigMapsUpsertAction([]*v1alpha1.ConfigMap{cm}))
+
+	// Upserting the same ConfigMap again doesn't repeat the line.
+	HandleConfigMapUpsertAction(state, NewConfigMapUpsertAction(cm))
+
+	assert.Equal(t, "Resource enabled by the Tiltfile\nResource disabled by the web UI\n",
+		state.LogStore.SpanLog(SpanIDForDisableLog("fe")))
+}
+
+func TestIgnoresOtherConfigMaps(t *testing.T) {
+	state := store.NewState()
+	cm := &v1alpha1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "fe-debug-override"},
+		Data:       map[string]string{"enabled": "true"},
+	}
+	HandleConfigMapUpsertAction(state, NewConfigMapUpsertAction(cm))
+	assert.Equal(t, "", state.LogStore.ManifestLog("fe"))
+	assert.Equal(t, cm, state.ConfigMaps["fe-debug-override"])
+}
+
+func TestUpgradesOldFormats(t *testing.T) {
+	state := store.NewState()
+	cm := &v1alpha1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "fe-disable"},
+		Data:       map[string]string{configmap.DisableKey: "True"},
+	}
+	HandleConfigMapUpsertAction(state, NewConfigMapUpsertAction(cm))
+	assert.Equal(t, map[string]string{
+		configmap.DisableKey: "true",
+		configmap.VersionKey: "1",
+	}, state.ConfigMaps["fe-disable"].Data)
+}
+
+func TestLogsOncePerFutureVersion(t *testing.T) {
+	state := store.NewState()
+	cm := &v1alpha1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "fe-disable"},
+		Data:       map[string]string{configmap.DisableKey: "true", configmap.VersionKey: "2"},
+	}
+	HandleConfigMapUpsertAction(state, NewConfigMapUpsertAction(cm))
+	HandleConfigMapUpsertAction(state, NewConfigMapUpsertAction(cm.DeepCopy()))
+
+	log := state.LogStore.String()
+	assert.Equal(t, 1, strings.Count(log, `Error reading ConfigMap "fe-disable"`))
+	assert.Contains(t, log, "by a newer version of Tilt")
+	assert.Equal(t, cm.Data, state.ConfigMaps["fe-disable"].Data)
+}
@@ -0,0 +1,278 @@
+package store
+
+import (
+	"sort"
+
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+type DependencyNodeType string
+
+const (
+	DependencyNodeResource DependencyNodeType = "resource"
+	DependencyNodeImage    DependencyNodeType = "image"
+)
+
+type DependencyEdgeType string
+
+const (
+	// A resource that waits for another resource (resource_deps).
+	DependencyEdgeResource DependencyEdgeType = "resource_dep"
+
+	// A resource that deploys an image.
+	DependencyEdgeImage DependencyEdgeType = "image"
+
+	// An image built on top of another image (e.g., a live-update base image).
+	DependencyEdgeBaseImage DependencyEdgeType = "base_image"
+)
+
+// The implicit graph formed by resource_deps and images shared between resources.
+//
+// Edges point from the dependent to its dependency.
+type DependencyGraph struct {
+	Nodes []DependencyNode `json:"nodes"`
+	Edges []DependencyEdge `json:"edges"`
+
+	// Each cycle is a list of node IDs that depend on each other.
+	// Cycles are a misconfiguration, but we report them rather than fail.
+	Cycles [][]string `json:"cycles,omitempty"`
+}
+
+type DependencyNode struct {
+	// The target ID, e.g., manifest:frontend or image:gcr.io/frontend
+	ID   string             `json:"id"`
+	Type DependencyNodeType `json:"type"`
+	Name string             `json:"name"`
+
+	UpdateStatus  v1alpha1.UpdateStatus  `json:"updateStatus,omitempty"`
+	RuntimeStatus v1alpha1.RuntimeStatus `json:"runtimeStatus,omitempty"`
+
+	InCycle bool `json:"inCycle,omitempty"`
+}
+
+type DependencyEdge struct {
+	From    string             `json:"from"`
+	To      string             `json:"to"`
+	Type    DependencyEdgeType `json:"type"`
+	InCycle bool               `json:"inCycle,omitempty"`
+}
+
+// Computes the dependency graph of the resources in the engine state.
+func NewDependencyGraph(state EngineState) DependencyGraph {
+	g := DependencyGraph{}
+	nodeIndex := make(map[string]int)
+	edgeSeen := make(map[DependencyEdge]bool)
+
+	addEdge := func(e DependencyEdge) {
+		if edgeSeen[e] {
+			return
+		}
+		edgeSeen[e] = true
+		g.Edges = append(g.Edges, e)
+	}
+
+	for _, mt := range state.Targets() {
+		m := mt.Manifest
+		id := m.ID().String()
+		nodeIndex[id] = len(g.Nodes)
+		g.Nodes = append(g.Nodes, DependencyNode{
+			ID:            id,
+			Type:          DependencyNodeResource,
+			Name:          m.Name.String(),
+			UpdateStatus:  mt.UpdateStatus(),
+			RuntimeStatus: runtimeStatus(mt.State),
+		})
+
+		for _, dep := range m.ResourceDependencies {
+			addEdge(DependencyEdge{From: id, To: dep.TargetID().String(), Type: DependencyEdgeResource})
+		}
+	}
+
+	for _, mt := range state.Targets() {
+		m := mt.Manifest
+		for _, iTarget := range m.ImageTargets {
+			iID := iTarget.ID().String()
+			status := imageUpdateStatus(mt.State, iTarget.ID())
+			if i, ok := nodeIndex[iID]; ok {
+				// The image is shared between resources. Report the least-healthy status.
+				g.Nodes[i].UpdateStatus = worseUpdateStatus(g.Nodes[i].UpdateStatus, status)
+			} else {
+				nodeIndex[iID] = len(g.Nodes)
+				g.Nodes = append(g.Nodes, DependencyNode{
+					ID:           iID,
+					Type:         DependencyNodeImage,
+					Name:         iTarget.ID().Name.String(),
+					UpdateStatus: status,
+				})
+			}
+
+			// Only the images deployed directly hang off the resource.
+			// Base images hang off the images built on top of them.
+			if isDeployedImage(m, iTarget.ID()) {
+				addEdge(DependencyEdge{From: m.ID().String(), To: iID, Type: DependencyEdgeImage})
+			}
+			for _, depID := range iTarget.DependencyIDs() {
+				addEdge(DependencyEdge{From: iID, To: depID.String(), Type: DependencyEdgeBaseImage})
+			}
+		}
+	}
+
+	// Drop edges to resources that don't exist (e.g., from a typo'd resource_dep).
+	edges := g.Edges[:0]
+	for _, e := range g.Edges {
+		if _, ok := nodeIndex[e.To]; ok {
+			edges = append(edges, e)
+		}
+	}
+	g.Edges = edges
+
+	g.markCycles(nodeIndex)
+	return g
+}
+
+// Finds the strongly-connected components with Tarjan's algorithm.
+// Every component with more than one node (or a self-loop) is a cycle.
+func (g *DependencyGraph) markCycles(nodeIndex map[string]int) {
+	adj := make([][]int, len(g.Nodes))
+	selfLoop := make([]bool, len(g.Nodes))
+	for _, e := range g.Edges {
+		from, to := nodeIndex[e.From], nodeIndex[e.To]
+		if from == to {
+			selfLoop[from] = true
+		}
+		adj[from] = append(adj[from], to)
+	}
+
+	index := 0
+	indices := make([]int, len(g.Nodes))
+	lowlink := make([]int, len(g.Nodes))
+	onStack := make([]bool, len(g.Nodes))
+	for i := range indices {
+		indices[i] = -1
+	}
+	stack := []int{}
+	component := make([]int, len(g.Nodes))
+	componentCount := 0
+
+	var visit func(v int)
+	visit = func(v int) {
+		indices[v] = index
+		lowlink[v] = index
+		index++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range adj[v] {
+			if indices[w] == -1 {
+				visit(w)
+				if lowlink[w] < lowlink[v] {
+					lowlink[v] = lowlink[w]
+				}
+			} else if onStack[w] && indices[w] < lowlink[v] {
+				lowlink[v] = indices[w]
+			}
+		}
+
+		if lowlink[v] != indices[v] {
+			return
+		}
+
+		var members []int
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			component[w] = componentCount
+			members = append(members, w)
+			if w == v {
+				break
+			}
+		}
+		componentCount++
+
+		if len(members) == 1 && !selfLoop[members[0]] {
+			return
+		}
+
+		var cycle []string
+		for _, m := range members {
+			g.Nodes[m].InCycle = true
+			cycle = append(cycle, g.Nodes[m].ID)
+		}
+		sort.Strings(cycle)
+		g.Cycles = append(g.Cycles, cycle)
+	}
+
+	for v := range g.Nodes {
+		if indices[v] == -1 {
+			visit(v)
+		}
+	}
+
+	for i, e := range g.Edges {
+		from, to := nodeIndex[e.From], nodeIndex[e.To]
+		if g.Nodes[from].InCycle && component[from] == component[to] {
+			g.Edges[i].InCycle = true
+		}
+	}
+
+	sort.Slice(g.Cycles, func(i, j int) bool {
+		return g.Cycles[i][0] < g.Cycles[j][0]
+	})
+}
+
+// Images that the resource deploys, rather than images
+// that are only used as a base for other images.
+func isDeployedImage(m model.Manifest, id model.TargetID) bool {
+	for _, iTarget := range m.ImageTargets {
+		for _, depID := range iTarget.DependencyIDs() {
+			if depID == id {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func runtimeStatus(ms *ManifestState) v1alpha1.RuntimeStatus {
+	if ms == nil || ms.RuntimeState == nil {
+		return v1alpha1.RuntimeStatusUnknown
+	}
+	return ms.RuntimeState.RuntimeStatus()
+}
+
+func imageUpdateStatus(ms *ManifestState, id model.TargetID) v1alpha1.UpdateStatus {
+	if ms == nil {
+		return v1alpha1.UpdateStatusNone
+	}
+	if ms.IsBuilding() {
+		return v1alpha1.UpdateStatusInProgress
+	}
+	bs := ms.BuildStatus(id)
+	if len(bs.PendingFileChanges) > 0 || len(bs.PendingDependencyChanges) > 0 {
+		return v1alpha1.UpdateStatusPending
+	}
+	if bs.LastResult != nil {
+		return v1alpha1.UpdateStatusOK
+	}
+	if ms.LastBuild().Error != nil {
+		return v1alpha1.UpdateStatusError
+	}
+	return v1alpha1.UpdateStatusNone
+}
+
+var updateStatusSeverity = map[v1alpha1.UpdateStatus]int{
+	v1alpha1.UpdateStatusError:      5,
+	v1alpha1.UpdateStatusInProgress: 4,
+	v1alpha1.UpdateStatusPending:    3,
+	v1alpha1.UpdateStatusNone:       2,
+	v1alpha1.UpdateStatusOK:         1,
+}
+
+func worseUpdateStatus(a, b v1alpha1.UpdateStatus) v1alpha1.UpdateStatus {
+	if updateStatusSeverity[b] > updateStatusSeverity[a] {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,105 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/internal/container"
+	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
+	"github.com/tilt-dev/tilt/internal/testutils/manifestbuilder"
+	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestDependencyGraphMultiLevelDeps(t *testing.T) {
+	f := tempdir.NewTempDirFixture(t)
+	defer f.TearDown()
+
+	a := manifestbuilder.New(f, "a").WithLocalResource("echo a", "", nil).WithResourceDeps("b").Build()
+	b := manifestbuilder.New(f, "b").WithLocalResource("echo b", "", nil).WithResourceDeps("c").Build()
+	c := manifestbuilder.New(f, "c").WithLocalResource("echo c", "", nil).Build()
+	g := NewDependencyGraph(*newState([]model.Manifest{a, b, c}))
+
+	assert.Equal(t, []string{"manifest:a", "manifest:b", "manifest:c"}, nodeIDs(g))
+	assert.Equal(t, []DependencyEdge{
+		{From: "manifest:a", To: "manifest:b", Type: DependencyEdgeResource},
+		{From: "manifest:b", To: "manifest:c", Type: DependencyEdgeResource},
+	}, g.Edges)
+	assert.Empty(t, g.Cycles)
+	assert.Equal(t, v1alpha1.UpdateStatusPending, g.Nodes[0].UpdateStatus)
+}
+
+func TestDependencyGraphSharedImages(t *testing.T) {
+	f := tempdir.NewTempDirFixture(t)
+	defer f.TearDown()
+
+	base := model.MustNewImageTarget(container.MustParseSelector("gcr.io/base")).
+		WithBuildDetails(model.DockerBuild{BuildPath: f.JoinPath("base")})
+	sancho := model.MustNewImageTarget(container.MustParseSelector(testyaml.SanchoImage)).
+		WithBuildDetails(model.DockerBuild{BuildPath: f.JoinPath("sancho")}).
+		WithDependencyIDs([]model.TargetID{base.ID()})
+
+	fe := manifestbuilder.New(f, "fe").WithK8sYAML(testyaml.SanchoYAML).WithImageTargets(base, sancho).Build()
+	be := manifestbuilder.New(f, "be").WithK8sYAML(testyaml.SanchoYAML).WithImageTargets(base, sancho).Build()
+	state := newState([]model.Manifest{fe, be})
+	state.ManifestTargets["be"].State.MutableBuildStatus(sancho.ID()).PendingFileChanges["main.go"] = time.Now()
+	g := NewDependencyGraph(*state)
+
+	baseID := base.ID().String()
+	sanchoID := sancho.ID().String()
+	assert.Equal(t, []string{"manifest:fe", "manifest:be", baseID, sanchoID}, nodeIDs(g))
+	assert.Equal(t, []DependencyEdge{
+		{From: "manifest:fe", To: sanchoID, Type: DependencyEdgeImage},
+		{From: sanchoID, To: baseID, Type: DependencyEdgeBaseImage},
+		{From: "manifest:be", To: sanchoID, Type: DependencyEdgeImage},
+	}, g.Edges)
+
+	// The shared image reports the least-healthy status of its consumers.
+	assert.Equal(t, v1alpha1.UpdateStatusPending, g.Nodes[3].UpdateStatus)
+	assert.Equal(t, DependencyNodeImage, g.Nodes[3].Type)
+}
+
+func TestDependencyGraphCycle(t *testing.T) {
+	f := tempdir.NewTempDirFixture(t)
+	defer f.TearDown()
+
+	a := manifestbuilder.New(f, "a").WithLocalResource("echo a", "", nil).WithResourceDeps("b").Build()
+	b := manifestbuilder.New(f, "b").WithLocalResource("echo b", "", nil).WithResourceDeps("c").Build()
+	c := manifestbuilder.New(f, "c").WithLocalResource("echo c", "", nil).WithResourceDeps("a", "missing").Build()
+	d := manifestbuilder.New(f, "d").WithLocalResource("echo d", "", nil).WithResourceDeps("a").Build()
+	g := NewDependencyGraph(*newState([]model.Manifest{a, b, c, d}))
+
+	require.Equal(t, [][]string{{"manifest:a", "manifest:b", "manifest:c"}}, g.Cycles)
+
+	inCycle := map[string]bool{}
+	for _, n := range g.Nodes {
+		inCycle[n.ID] = n.InCycle
+	}
+	assert.Equal(t, map[string]bool{
+		"manifest:a": true,
+		"manifest:b": true,
+		"manifest:c": true,
+		"manifest:d": false,
+	}, inCycle)
+
+	// Edges to missing resources are dropped, and only edges
+	// within the cycle are marked.
+	assert.Equal(t, []DependencyEdge{
+		{From: "manifest:a", To: "manifest:b", Type: DependencyEdgeResource, InCycle: true},
+		{From: "manifest:b", To: "manifest:c", Type: DependencyEdgeResource, InCycle: true},
+		{From: "manifest:c", To: "manifest:a", Type: DependencyEdgeResource, InCycle: true},
+		{From: "manifest:d", To: "manifest:a", Type: DependencyEdgeResource},
+	}, g.Edges)
+}
+
+func nodeIDs(g DependencyGraph) []string {
+	var result []string
+	for _, n := range g.Nodes {
+		result = append(result, n.ID)
+	}
+	return result
+}
@@ -38,6 +38,10 @@ type EngineState struct {
 	CurrentlyBuilding map[model.ManifestName]bool
 	TerminalMode      TerminalMode
 
+	// Set by `tilt up --read-only`. Tilt ignores changes requested from the
+	// UIs, like triggers, but still reacts to file changes.
+	ReadOnly bool
+
 	// For synchronizing BuildController -- wait until engine records all builds started
 	// so far before starting another build
 	BuildControllerStartCount int
@@ -65,9 +69,8 @@ type EngineState struct {
 	// Note that ExitSignal/ExitError is never triggered in normal
 	// 'tilt up`/dev mode. It's more for CI modes and tilt up --watch=false modes.
 	//
-	// We don't provide the ability to customize exit codes. Either the
-	// process exited successfully, or with an error. In the future, we might
-	// add the ability to put an exit code in the error.
+	// When the session fails, ExitError is an ExitError, whose category
+	// decides the exit code.
 	ExitSignal bool
 	ExitError  error
 
@@ -76,6 +79,10 @@ type EngineState struct {
 
 	TriggerQueue []model.ManifestName
 
+	// Trigger modes that the user picked in the UI, by manifest.
+	// A Tiltfile reload that redefines the manifest clears its override.
+	TriggerModeOverrides map[model.ManifestName]TriggerModeOverride
+
 	TiltfileDefinitionOrder []model.ManifestName
 	TiltfileStates          map[model.ManifestName]*ManifestState
 
@@ -86,6 +93,10 @@ type EngineState struct {
 	SuggestedTiltVersion string
 	VersionSettings      model.VersionSettings
 
+	// The registry that images are pushed to, as decided by the
+	// last load of the main Tiltfile.
+	ImageRegistry model.ImageRegistry
+
 	// Analytics Info
 	AnalyticsEnvOpt        analytics.Opt
 	AnalyticsUserOpt       analytics.Opt // changes to this field will propagate into the TiltAnalytics subscriber + we'll record them as user choice
@@ -102,12 +113,47 @@ type EngineState struct {
 
 	CloudStatus CloudStatus
 
+	// The health of our connection to the Kubernetes cluster.
+	ClusterConnection ClusterConnection
+
+	// How far the cluster's clock is ahead of ours, last we checked.
+	// Translate cluster timestamps with it before comparing them to local ones.
+	ClusterClockSkew k8s.ClockSkew
+
+	// Which resources are ready, for reporting progress in CI mode.
+	Readiness ReadinessSummary
+
+	// In CI mode, whether we gave up waiting for resources to become ready.
+	CITimedOut bool
+
+	// Set when Tilt has gone to sleep after a period of inactivity.
+	// See internal/engine/idle.
+	Sleeping bool
+
+	// The last time the user did something that should keep Tilt awake
+	// (e.g., loaded the web UI or pressed a key in the HUD).
+	LastActivityTime time.Time
+
+	// The most recent sample of how much memory the engine is using.
+	// See internal/engine/memory.
+	MemoryReport MemoryReport
+
+	// Set when the main Tiltfile loaded successfully, but didn't enable any
+	// resources. Explains why (e.g., all resources were filtered out by args).
+	NoResourcesReason string
+
+	// What happened to each of the main Tiltfile's bootstrap tasks
+	// on the last load, in the order that the Tiltfile declared them.
+	BootstrapTasks []model.BootstrapTaskStatus
+
 	DockerPruneSettings model.DockerPruneSettings
 
 	TelemetrySettings model.TelemetrySettings
 
 	UserConfigState model.UserConfigState
 
+	UpdateMode UpdateModeState
+
 	// The initialization sequence is unfortunate. Currently we have:
 	// 1) Dispatch an InitAction
 	// 1) InitAction sets DesiredTiltfilePath
@@ -129,6 +175,14 @@ type EngineState struct {
 	UIResources          map[string]*v1alpha1.UIResource          `json:"-"`
 	ConfigMaps           map[string]*v1alpha1.ConfigMap           `json:"-"`
 	LiveUpdates          map[string]*v1alpha1.LiveUpdate          `json:"-"`
+	PortForwards         map[string]*v1alpha1.PortForward         `json:"-"`
+}
+
+type TriggerModeOverride struct {
+	TriggerMode model.TriggerMode
+
+	// The trigger mode the Tiltfile declared when the user overrode it.
+	TiltfileTriggerMode model.TriggerMode
 }
 
 type CloudStatus struct {
@@ -138,6 +192,27 @@ type CloudStatus struct {
 	WaitingForStatusPostRegistration bool
 }
 
+type ClusterConnectionStatus string
+
+const (
+	// We haven't checked the connection, e.g., because there's nothing deployed to Kubernetes.
+	ClusterConnectionUnknown   ClusterConnectionStatus = ""
+	ClusterConnectionConnected ClusterConnectionStatus = "connected"
+
+	// We've lost touch with the cluster, so pod and service state may be stale.
+	ClusterConnectionDegraded ClusterConnectionStatus = "degraded"
+)
+
+type ClusterConnection struct {
+	Status ClusterConnectionStatus
+
+	// The error from the most recent failed check, when degraded.
+	Error string
+
+	// When the connection entered its current status.
+	Since time.Time
+}
+
 func (e *EngineState) MainTiltfilePath() string {
 	tf, ok := e.Tiltfiles[model.MainTiltfileManifestName.String()]
 	if !ok {
@@ -222,6 +297,7 @@ func (e *EngineState) UpsertManifestTarget(mt *ManifestTarget) {
 
 func (e *EngineState) RemoveManifestTarget(mn model.ManifestName) {
 	delete(e.ManifestTargets, mn)
+	delete(e.TriggerModeOverrides, mn)
 	newOrder := []model.ManifestName{}
 	for _, n := range e.ManifestDefinitionOrder {
 		if n == mn {
@@ -480,6 +556,10 @@ type ManifestState struct {
 	// The last `BuildHistoryLimit` builds. The most recent build is first in the slice.
 	BuildHistory []model.BuildRecord
 
+	// The events of the most recent successful build, while we wait for the
+	// resource to become ready so that we can report its latency.
+	PendingLatency model.LatencyEvents
+
 	// The container IDs that we've run a LiveUpdate on, if any. Their contents have
 	// diverged from the image they are built on. If these container don't appear on
 	// the pod, we've lost that state and need to rebuild.
@@ -493,6 +573,10 @@ type ManifestState struct {
 
 	// If the build was manually triggered, record why.
 	TriggerReason model.BuildReason
+
+	// Warnings about this resource from the last successful Tiltfile load.
+	// For a Tiltfile, the warnings that aren't about one of its resources.
+	TiltfileWarnings []model.TiltfileWarning
 }
 
 func NewState() *EngineState {
@@ -517,6 +601,7 @@ func NewState() *EngineState {
 		},
 	}
 	ret.TiltfileConfigPaths = map[model.ManifestName][]string{}
+	ret.TriggerModeOverrides = make(map[model.ManifestName]TriggerModeOverride)
 
 	if ok, _ := tiltanalytics.IsAnalyticsDisabledFromEnv(); ok {
 		ret.AnalyticsEnvOpt = analytics.OptOut
@@ -531,6 +616,7 @@ func NewState() *EngineState {
 	ret.UIResources = make(map[string]*v1alpha1.UIResource)
 	ret.ConfigMaps = make(map[string]*v1alpha1.ConfigMap)
 	ret.LiveUpdates = make(map[string]*v1alpha1.LiveUpdate)
+	ret.PortForwards = make(map[string]*v1alpha1.PortForward)
 
 	return ret
 }
@@ -547,6 +633,8 @@ func newManifestState(m model.Manifest) *ManifestState {
 		ms.RuntimeState = NewK8sRuntimeState(m)
 	} else if m.IsLocal() {
 		ms.RuntimeState = LocalRuntimeState{}
+	} else if m.IsSync() {
+		ms.RuntimeState = SyncRuntimeState{}
 	}
 
 	// For historical reasons, DC state is initialized differently.
@@ -785,6 +873,9 @@ func (ms *ManifestState) UpdateStatus(triggerMode model.TriggerMode) v1alpha1.Up
 		return v1alpha1.UpdateStatusInProgress
 	} else if hasPendingBuild {
 		return v1alpha1.UpdateStatusPending
+	} else if lastBuild.Canceled {
+		// A canceled build didn't succeed, but it didn't fail either.
+		return v1alpha1.UpdateStatusNone
 	} else if lastBuildError {
 		return v1alpha1.UpdateStatusError
 	} else if !lastBuild.Empty() {
@@ -795,7 +886,17 @@ func (ms *ManifestState) UpdateStatus(triggerMode model.TriggerMode) v1alpha1.Up
 
 var _ model.TargetStatus = &ManifestState{}
 
-func ManifestTargetEndpoints(mt *ManifestTarget) (endpoints []model.Link) {
+// The links to show in the UI: the target's endpoints, followed by any
+// links attached to the resource as a whole.
+func ManifestTargetEndpoints(mt *ManifestTarget) []model.Link {
+	endpoints := targetEndpoints(mt)
+	if len(mt.Manifest.Links) > 0 {
+		endpoints = append(append([]model.Link{}, endpoints...), mt.Manifest.Links...)
+	}
+	return endpoints
+}
+
+func targetEndpoints(mt *ManifestTarget) (endpoints []model.Link) {
 	if mt.Manifest.IsK8s() {
 		k8sTarg := mt.Manifest.K8sTarget()
 		endpoints = append(endpoints, k8sTarg.Links...)
@@ -811,16 +912,7 @@ func ManifestTargetEndpoints(mt *ManifestTarget) (endpoints []model.Link) {
 			return endpoints
 		}
 
-		lbEndpoints := []model.Link{}
-		for _, u := range mt.State.K8sRuntimeState().LBs {
-			if u != nil {
-				lbEndpoints = append(lbEndpoints, model.Link{URL: u})
-			}
-		}
-		// Sort so the ordering of LB endpoints is deterministic
-		// (otherwise it's not, because they live in a map)
-		sort.Sort(model.ByURL(lbEndpoints))
-		endpoints = append(endpoints, lbEndpoints...)
+		endpoints = append(endpoints, lbLinks(mt)...)
 	}
 
 	localResourceLinks := mt.Manifest.LocalTarget().Links
@@ -829,41 +921,67 @@ func ManifestTargetEndpoints(mt *ManifestTarget) (endpoints []model.Link) {
 	}
 
 	if mt.Manifest.IsDC() {
-		hostPorts := make(map[int]bool)
-		publishedPorts := mt.Manifest.DockerComposeTarget().PublishedPorts()
-		for _, p := range publishedPorts {
-			if p == 0 || hostPorts[p] {
-				continue
-			}
-			hostPorts[p] = true
-			endpoints = append(endpoints, model.MustNewLink(fmt.Sprintf("http://localhost:%d/", p), ""))
-		}
+		endpoints = append(endpoints, dcPortLinks(mt)...)
+		endpoints = append(endpoints, mt.Manifest.DockerComposeTarget().Links...)
+	}
 
-		for _, bindings := range mt.State.DCRuntimeState().Ports {
-			// Docker usually contains multiple bindings for each port - one for ipv4 (0.0.0.0)
-			// and one for ipv6 (::1).
-			for _, binding := range bindings {
-				pstring := binding.HostPort
-				p, err := strconv.Atoi(pstring)
-				if err != nil || p == 0 || hostPorts[p] {
-					continue
-				}
-				hostPorts[p] = true
-				endpoints = append(endpoints, model.MustNewLink(fmt.Sprintf("http://localhost:%d/", p), ""))
-			}
+	return endpoints
+}
+
+// URLs of the load balancers in front of a Kubernetes resource.
+func lbLinks(mt *ManifestTarget) []model.Link {
+	links := []model.Link{}
+	for _, u := range mt.State.K8sRuntimeState().LBs {
+		if u != nil {
+			links = append(links, model.Link{URL: u})
 		}
+	}
+	// Sort so the ordering of LB endpoints is deterministic
+	// (otherwise it's not, because they live in a map)
+	sort.Sort(model.ByURL(links))
+	return links
+}
 
-		endpoints = append(endpoints, mt.Manifest.DockerComposeTarget().Links...)
+// URLs of the host ports that a Docker Compose service publishes.
+func dcPortLinks(mt *ManifestTarget) []model.Link {
+	var links []model.Link
+	hostPorts := make(map[int]bool)
+	publishedPorts := mt.Manifest.DockerComposeTarget().PublishedPorts()
+	for _, p := range publishedPorts {
+		if p == 0 || hostPorts[p] {
+			continue
+		}
+		hostPorts[p] = true
+		links = append(links, model.MustNewLink(fmt.Sprintf("http://localhost:%d/", p), ""))
 	}
 
-	return endpoints
+	for _, bindings := range mt.State.DCRuntimeState().Ports {
+		// Docker usually contains multiple bindings for each port - one for ipv4 (0.0.0.0)
+		// and one for ipv6 (::1).
+		for _, binding := range bindings {
+			pstring := binding.HostPort
+			p, err := strconv.Atoi(pstring)
+			if err != nil || p == 0 || hostPorts[p] {
+				continue
+			}
+			hostPorts[p] = true
+			links = append(links, model.MustNewLink(fmt.Sprintf("http://localhost:%d/", p), ""))
+		}
+	}
+	return links
 }
 
 func StateToView(s EngineState, mu *sync.RWMutex) view.View {
-	ret := view.View{}
+	ret := view.View{ReadOnly: s.ReadOnly, Sleeping: s.Sleeping}
+	if s.ClusterConnection.Status == ClusterConnectionDegraded {
+		ret.ClusterConnectionError = s.ClusterConnection.Error
+	}
+	scores := AttentionScores(s, time.Now())
 
-	for _, ms := range s.TiltfileStates {
-		ret.Resources = append(ret.Resources, tiltfileResourceView(ms))
+	for name, ms := range s.TiltfileStates {
+		tr := tiltfileResourceView(ms)
+		tr.AttentionScore = RoundAttentionScore(scores[name])
+		ret.Resources = append(ret.Resources, tr)
 	}
 
 	for _, name := range s.ManifestDefinitionOrder {
@@ -926,7 +1044,9 @@ func StateToView(s EngineState, mu *sync.RWMutex) view.View {
 			PendingBuildReason: mt.NextBuildReason(),
 			CurrentBuild:       currentBuild,
 			Endpoints:          model.LinksToURLStrings(endpoints), // hud can't handle link names, just send URLs
-			ResourceInfo:       resourceInfoView(mt),
+			ResourceInfo:       resourceInfoView(mt, s.ClusterClockSkew),
+			AttentionScore:     RoundAttentionScore(scores[name]),
+			TiltfileWarnings:   ms.TiltfileWarnings,
 		}
 
 		ret.Resources = append(ret.Resources, r)
@@ -942,11 +1062,12 @@ const MainTiltfileManifestName = model.MainTiltfileManifestName
 
 func tiltfileResourceView(ms *ManifestState) view.Resource {
 	tr := view.Resource{
-		Name:         MainTiltfileManifestName,
-		IsTiltfile:   true,
-		CurrentBuild: ms.CurrentBuild,
-		BuildHistory: ms.BuildHistory,
-		ResourceInfo: view.TiltfileResourceInfo{},
+		Name:             MainTiltfileManifestName,
+		IsTiltfile:       true,
+		CurrentBuild:     ms.CurrentBuild,
+		BuildHistory:     ms.BuildHistory,
+		ResourceInfo:     view.TiltfileResourceInfo{},
+		TiltfileWarnings: ms.TiltfileWarnings,
 	}
 	if !ms.CurrentBuild.Empty() {
 		tr.PendingBuildSince = ms.CurrentBuild.StartTime
@@ -956,7 +1077,7 @@ func tiltfileResourceView(ms *ManifestState) view.Resource {
 	return tr
 }
 
-func resourceInfoView(mt *ManifestTarget) view.ResourceInfoView {
+func resourceInfoView(mt *ManifestTarget, skew k8s.ClockSkew) view.ResourceInfoView {
 	runStatus := v1alpha1.RuntimeStatusUnknown
 	if mt.State.RuntimeState != nil {
 		runStatus = mt.State.RuntimeState.RuntimeStatus()
@@ -976,7 +1097,7 @@ func resourceInfoView(mt *ManifestTarget) view.ResourceInfoView {
 		podID := k8s.PodID(pod.Name)
 		return view.K8sResourceInfo{
 			PodName:            pod.Name,
-			PodCreationTime:    pod.CreatedAt.Time,
+			PodCreationTime:    skew.ToLocal(pod.CreatedAt.Time),
 			PodUpdateStartTime: state.UpdateStartTime[podID],
 			PodStatus:          pod.Status,
 			PodRestarts:        int(state.VisiblePodContainerRestarts(podID)),
@@ -242,6 +242,17 @@ func TestStateToViewNonWorkloadYAMLManifest(t *testing.T) {
 	assert.Equal(t, expectedInfo, r.ResourceInfo)
 }
 
+func TestStateToViewClusterConnection(t *testing.T) {
+	state := newState(nil)
+	state.ClusterConnection = ClusterConnection{Status: ClusterConnectionConnected}
+	v := StateToView(*state, &sync.RWMutex{})
+	assert.Equal(t, "", v.ClusterConnectionError)
+
+	state.ClusterConnection = ClusterConnection{Status: ClusterConnectionDegraded, Error: "connection refused"}
+	v = StateToView(*state, &sync.RWMutex{})
+	assert.Equal(t, "connection refused", v.ClusterConnectionError)
+}
+
 func TestMostRecentPod(t *testing.T) {
 	podA := v1alpha1.Pod{Name: "pod-a", CreatedAt: apis.Now()}
 	podB := v1alpha1.Pod{Name: "pod-b", CreatedAt: apis.NewTime(time.Now().Add(time.Minute))}
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"errors"
+)
+
+// Why a session ended with an error.
+//
+// `tilt ci` exits with a different code for each category, so that CI
+// pipelines can tell failures apart (e.g., to retry infrastructure flakes,
+// but not code failures).
+type ExitCategory int
+
+const (
+	// An error that doesn't fit any other category.
+	ExitCategoryUnknown ExitCategory = iota
+
+	// The Tiltfile failed to load, or didn't enable any resources.
+	ExitCategoryTiltfile
+
+	// A resource failed to build or deploy.
+	ExitCategoryBuild
+
+	// A resource's server or job failed after it was deployed.
+	ExitCategoryRuntime
+
+	// Resources didn't become ready before the timeout.
+	ExitCategoryTimeout
+
+	// Tilt lost touch with the cluster or Docker.
+	ExitCategoryConnectivity
+)
+
+// The exit codes for each category.
+//
+// These are a contract with the scripts that run Tilt, so they must never
+// change. Add new categories with new codes.
+//
+// Tiltfile errors exit with 5, like `tilt verify` and
+// `tilt alpha tiltfile-result`.
+const (
+	ExitCodeSuccess      = 0
+	ExitCodeUnknown      = 1
+	ExitCodeTimeout      = 2
+	ExitCodeBuild        = 3
+	ExitCodeRuntime      = 4
+	ExitCodeTiltfile     = 5
+	ExitCodeConnectivity = 6
+)
+
+func (c ExitCategory) ExitCode() int {
+	switch c {
+	case ExitCategoryTiltfile:
+		return ExitCodeTiltfile
+	case ExitCategoryBuild:
+		return ExitCodeBuild
+	case ExitCategoryRuntime:
+		return ExitCodeRuntime
+	case ExitCategoryTimeout:
+		return ExitCodeTimeout
+	case ExitCategoryConnectivity:
+		return ExitCodeConnectivity
+	default:
+		return ExitCodeUnknown
+	}
+}
+
+func (c ExitCategory) String() string {
+	switch c {
+	case ExitCategoryTiltfile:
+		return "tiltfile"
+	case ExitCategoryBuild:
+		return "build"
+	case ExitCategoryRuntime:
+		return "runtime"
+	case ExitCategoryTimeout:
+		return "timeout"
+	case ExitCategoryConnectivity:
+		return "connectivity"
+	default:
+		return "unknown"
+	}
+}
+
+// The error that ended the session, with its category.
+type ExitError struct {
+	Category ExitCategory
+	Err      error
+}
+
+func (e ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e ExitError) Unwrap() error {
+	return e.Err
+}
+
+// The code that Tilt should exit with for an error.
+func ExitCodeForError(err error) int {
+	if err == nil {
+		return ExitCodeSuccess
+	}
+	var exitErr ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Category.ExitCode()
+	}
+	return ExitCodeUnknown
+}
+
+// Marks an error as caused by losing touch with the cluster or Docker,
+// rather than by the user's code or config.
+type ConnectivityError struct {
+	Err error
+}
+
+func NewConnectivityError(err error) error {
+	return ConnectivityError{Err: err}
+}
+
+func (e ConnectivityError) Error() string {
+	return e.Err.Error()
+}
+
+func (e ConnectivityError) Unwrap() error {
+	return e.Err
+}
+
+func IsConnectivityError(err error) bool {
+	var connErr ConnectivityError
+	return errors.As(err, &connErr)
+}
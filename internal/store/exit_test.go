@@ -0,0 +1,31 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCodeForError(t *testing.T) {
+	assert.Equal(t, 0, ExitCodeForError(nil))
+	assert.Equal(t, 1, ExitCodeForError(errors.New("oops")))
+	assert.Equal(t, 1, ExitCodeForError(ExitError{Err: errors.New("oops")}))
+	assert.Equal(t, 5, ExitCodeForError(ExitError{Category: ExitCategoryTiltfile, Err: errors.New("oops")}))
+	assert.Equal(t, 3, ExitCodeForError(ExitError{Category: ExitCategoryBuild, Err: errors.New("oops")}))
+	assert.Equal(t, 4, ExitCodeForError(ExitError{Category: ExitCategoryRuntime, Err: errors.New("oops")}))
+	assert.Equal(t, 2, ExitCodeForError(ExitError{Category: ExitCategoryTimeout, Err: errors.New("oops")}))
+	assert.Equal(t, 6, ExitCodeForError(ExitError{Category: ExitCategoryConnectivity, Err: errors.New("oops")}))
+
+	wrapped := fmt.Errorf("tilt ci: %w", ExitError{Category: ExitCategoryBuild, Err: errors.New("oops")})
+	assert.Equal(t, 3, ExitCodeForError(wrapped))
+	assert.Equal(t, "tilt ci: oops", wrapped.Error())
+}
+
+func TestIsConnectivityError(t *testing.T) {
+	err := fmt.Errorf("building: %w", NewConnectivityError(errors.New("connection refused")))
+	assert.True(t, IsConnectivityError(err))
+	assert.Equal(t, "building: connection refused", err.Error())
+	assert.False(t, IsConnectivityError(errors.New("connection refused")))
+}
@@ -25,8 +25,18 @@ const (
 	HoldReasonBuildingComponent                HoldReason = "building-component"
 	HoldReasonWaitingForDep                    HoldReason = "waiting-for-dep"
 	HoldReasonWaitingForDeploy                 HoldReason = "waiting-for-deploy"
+	HoldReasonWaitingForOutputs                HoldReason = "waiting-for-outputs"
 	HoldReasonDisabled                         HoldReason = "disabled"
 
+	// Another resource is building an image from the same Dockerfile and
+	// context (e.g., a different target stage), so we wait for it to warm
+	// the layer cache rather than building the shared stages twice.
+	HoldReasonWaitingForSharedDockerfile HoldReason = "waiting-for-shared-dockerfile"
+
+	// The user pinned the resource to its current pod, so we're holding
+	// deploys until they unpin it.
+	HoldReasonPinned HoldReason = "pinned"
+
 	// We're waiting for a reconciler to respond to the change,
 	// but don't know yet what it's waiting on.
 	HoldReconciling HoldReason = "reconciling"
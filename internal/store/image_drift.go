@@ -0,0 +1,224 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/distribution/reference"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/tilt-dev/tilt/internal/container"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/store/k8sconv"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+)
+
+// When a container from the current deploy is running an image other than
+// the one Tilt last built for it, the cluster has drifted from what Tilt
+// thinks is running, e.g., someone ran `kubectl set image`, or something
+// rewrote the image ref after Tilt applied it.
+type ImageDrift struct {
+	Pod       k8s.PodID
+	Container container.Name
+
+	// The image the container is running, e.g., "sha256:0123456789ab" or ":v2".
+	Running string
+
+	// The image Tilt last built for the container.
+	Expected reference.NamedTagged
+
+	// When we first saw the container running the wrong image.
+	Since time.Time
+}
+
+func (d ImageDrift) Message() string {
+	return fmt.Sprintf("running image differs from last Tilt build: %s vs expected :%s", d.Running, d.Expected.Tag())
+}
+
+// Re-checks the containers in the current deploy against the images from the
+// last successful build.
+//
+// Keeps the Since time of drifts that we've already seen, and returns the
+// drifts that are new.
+func (ms *ManifestState) UpdateImageDrifts(now time.Time) []ImageDrift {
+	krs, ok := ms.RuntimeState.(K8sRuntimeState)
+	if !ok {
+		return nil
+	}
+
+	var drifts, added []ImageDrift
+	for _, pod := range krs.Pods {
+		// Pods from an earlier deploy are running the images from an earlier
+		// build, and will go away on their own.
+		if pod.Deleting || !k8sconv.HasOKPodTemplateSpecHash(pod, krs.ApplyFilter) {
+			continue
+		}
+
+		podID := k8s.PodID(pod.Name)
+		for _, c := range pod.Containers {
+			drift, ok := ms.imageDrift(podID, c)
+			if !ok {
+				continue
+			}
+
+			drift.Since = now
+			existing, seen := krs.ImageDrift(podID, drift.Container)
+			if seen && existing.Running == drift.Running && existing.Expected.String() == drift.Expected.String() {
+				drift.Since = existing.Since
+			} else {
+				added = append(added, drift)
+			}
+			drifts = append(drifts, drift)
+		}
+	}
+
+	sort.Slice(drifts, func(i, j int) bool {
+		if drifts[i].Pod != drifts[j].Pod {
+			return drifts[i].Pod < drifts[j].Pod
+		}
+		return drifts[i].Container < drifts[j].Container
+	})
+	krs.ImageDrifts = drifts
+	ms.RuntimeState = krs
+	return added
+}
+
+// Checks whether a container is running the image that Tilt last built for it.
+//
+// Container runtimes don't always report the ref from the pod spec, and
+// registries (or admission controllers) may rewrite it. So we find the image
+// Tilt built for the container by name, the same way as TiltBuiltImage, and
+// count the container as up to date if it has the same tag, or if it reports
+// the digest that Tilt's tag was derived from.
+//
+// If the container only reports a digest that doesn't match, we don't know
+// whether it's a different image, or a registry's digest for the same image,
+// so we don't report a drift.
+func (ms *ManifestState) imageDrift(podID k8s.PodID, c v1alpha1.Container) (ImageDrift, bool) {
+	running := parseRunningImage(c)
+	if running.name == "" {
+		return ImageDrift{}, false
+	}
+
+	var candidates []reference.NamedTagged
+	for _, status := range ms.BuildStatuses {
+		result, ok := status.LastResult.(ImageBuildResult)
+		if !ok || result.ImageLocalRef == nil || result.ImageClusterRef == nil {
+			continue
+		}
+
+		for _, builtRef := range []reference.NamedTagged{result.ImageClusterRef, result.ImageLocalRef} {
+			if imageName(builtRef) != running.name {
+				continue
+			}
+			if running.matches(builtRef) {
+				return ImageDrift{}, false
+			}
+			candidates = append(candidates, builtRef)
+		}
+	}
+
+	if len(candidates) == 0 || running.tag == "" {
+		return ImageDrift{}, false
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].String() < candidates[j].String()
+	})
+	return ImageDrift{
+		Pod:       podID,
+		Container: container.Name(c.Name),
+		Running:   running.String(),
+		Expected:  candidates[0],
+	}, true
+}
+
+// What a container runtime told us about a container's image.
+type runningImage struct {
+	// The last component of the image path. Empty if the runtime only gave us a digest.
+	name string
+
+	// Empty if the runtime only gave us a digest.
+	tag string
+
+	digests []digest.Digest
+}
+
+func parseRunningImage(c v1alpha1.Container) runningImage {
+	var result runningImage
+	for i, s := range []string{c.Image, c.ImageID} {
+		// Image IDs may have a scheme, e.g., docker-pullable://my-img@sha256:...
+		if idx := strings.Index(s, "://"); idx != -1 {
+			s = s[idx+len("://"):]
+		}
+
+		ref, err := reference.ParseAnyReference(s)
+		if err != nil {
+			continue
+		}
+
+		if named, ok := ref.(reference.Named); ok && result.name == "" {
+			result.name = imageName(named)
+		}
+		if digested, ok := ref.(reference.Digested); ok {
+			result.digests = append(result.digests, digested.Digest())
+		}
+
+		// Only the image has a tag. A bare name means latest.
+		if i == 0 {
+			if tagged, ok := ref.(reference.Tagged); ok {
+				result.tag = tagged.Tag()
+			} else if _, ok := ref.(reference.Named); ok && len(result.digests) == 0 {
+				result.tag = "latest"
+			}
+		}
+	}
+	return result
+}
+
+func (r runningImage) matches(ref reference.NamedTagged) bool {
+	if r.tag != "" && r.tag == ref.Tag() {
+		return true
+	}
+	for _, d := range r.digests {
+		if digestMatchesTag(d, ref.Tag()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Prefer the digest, because it says exactly what's running.
+func (r runningImage) String() string {
+	if len(r.digests) > 0 {
+		d := r.digests[0]
+		encoded := d.Encoded()
+		if len(encoded) > 12 {
+			encoded = encoded[:12]
+		}
+		return fmt.Sprintf("%s:%s", d.Algorithm(), encoded)
+	}
+	return ":" + r.tag
+}
+
+// Tilt tags images with the first 16 characters of the image ID, after a
+// prefix (see digestAsTag in the build package). Content-based tags won't
+// match, because they're a hash of the inputs, not the image.
+func digestMatchesTag(d digest.Digest, tag string) bool {
+	hash := tag[strings.LastIndex(tag, "-")+1:]
+	if len(hash) < 16 {
+		return false
+	}
+	return strings.HasPrefix(d.Encoded(), hash)
+}
+
+func (s K8sRuntimeState) ImageDrift(podID k8s.PodID, c container.Name) (ImageDrift, bool) {
+	for _, d := range s.ImageDrifts {
+		if d.Pod == podID && d.Container == c {
+			return d, true
+		}
+	}
+	return ImageDrift{}, false
+}
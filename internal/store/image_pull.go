@@ -0,0 +1,87 @@
+package store
+
+import (
+	"strings"
+
+	"github.com/docker/distribution/reference"
+
+	"github.com/tilt-dev/tilt/internal/container"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+)
+
+type ImagePullVerdict string
+
+const (
+	// The image is in the registry. We're giving the kubelet a chance
+	// to retry the pull before we call it a failure.
+	ImagePullVerdictRetrying ImagePullVerdict = "retrying"
+
+	// The pull isn't going to succeed on its own.
+	ImagePullVerdictFailed ImagePullVerdict = "failed"
+)
+
+// When a pod can't pull an image that Tilt just pushed, we check the registry
+// to figure out whether it's a transient failure (e.g., the registry
+// hasn't finished propagating the image) or a real problem.
+type ImagePullCheck struct {
+	// The image the pod is trying to pull.
+	Image string
+
+	Verdict ImagePullVerdict
+
+	// A human-readable diagnosis. Only set when the verdict is Failed.
+	Error string
+}
+
+var imagePullWaitingReasons = map[string]bool{
+	"ErrImagePull":     true,
+	"ImagePullBackOff": true,
+}
+
+func IsImagePullError(c v1alpha1.Container) bool {
+	return c.State.Waiting != nil && imagePullWaitingReasons[c.State.Waiting.Reason]
+}
+
+// Finds the image that Tilt built for this manifest that matches the given image
+// ref from a pod spec.
+//
+// Refs match if they have the same image name and tag, even if they're in
+// different registries, so that we can diagnose when a cluster pulls from
+// somewhere other than where Tilt pushed. Tilt's tags are content-addressed,
+// so this is unlikely to match an image that Tilt didn't build.
+func (ms *ManifestState) TiltBuiltImage(image string) (ImageBuildResult, bool) {
+	ref, err := container.ParseNamed(image)
+	if err != nil {
+		return ImageBuildResult{}, false
+	}
+	tagged, ok := ref.(reference.NamedTagged)
+	if !ok {
+		return ImageBuildResult{}, false
+	}
+
+	for _, status := range ms.BuildStatuses {
+		result, ok := status.LastResult.(ImageBuildResult)
+		if !ok || result.ImageLocalRef == nil || result.ImageClusterRef == nil {
+			continue
+		}
+
+		for _, builtRef := range []reference.NamedTagged{result.ImageClusterRef, result.ImageLocalRef} {
+			if imageName(builtRef) == imageName(tagged) && builtRef.Tag() == tagged.Tag() {
+				return result, true
+			}
+		}
+	}
+	return ImageBuildResult{}, false
+}
+
+// The last component of the image path, e.g., "my-img" in "gcr.io/my-project/my-img".
+func imageName(ref reference.Named) string {
+	path := reference.Path(ref)
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+func (s K8sRuntimeState) ImagePullCheck(podID string, image string) (ImagePullCheck, bool) {
+	check, ok := s.ImagePullChecks[k8s.PodID(podID)][image]
+	return check, ok
+}
@@ -24,6 +24,7 @@ func Pod(ctx context.Context, pod *v1.Pod, ancestorUID types.UID) *v1alpha1.Pod
 		UID:            string(pod.UID),
 		Name:           pod.Name,
 		Namespace:      pod.Namespace,
+		NodeName:       pod.Spec.NodeName,
 		CreatedAt:      apis.NewTime(pod.CreationTimestamp.Time),
 		Phase:          string(pod.Status.Phase),
 		Deleting:       pod.DeletionTimestamp != nil && !pod.DeletionTimestamp.IsZero(),
@@ -89,6 +90,7 @@ func ContainerForStatus(pod *v1.Pod, cStatus v1.ContainerStatus) (v1alpha1.Conta
 		ID:       string(cID),
 		Ready:    cStatus.Ready,
 		Image:    cStatus.Image,
+		ImageID:  cStatus.ImageID,
 		Restarts: cStatus.RestartCount,
 		State:    v1alpha1.ContainerState{},
 		Ports:    ports,
@@ -1,6 +1,12 @@
 package kubernetesapplys
 
-import "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+import (
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
 
 type KubernetesApplyUpsertAction struct {
 	KubernetesApply *v1alpha1.KubernetesApply
@@ -21,3 +27,21 @@ func NewKubernetesApplyDeleteAction(n string) KubernetesApplyDeleteAction {
 }
 
 func (KubernetesApplyDeleteAction) Action() {}
+
+// The user turned a debug override on or off, so the manifest needs to be redeployed.
+type KubernetesApplyDebugOverrideAction struct {
+	ManifestName model.ManifestName
+}
+
+func NewKubernetesApplyDebugOverrideAction(mn model.ManifestName) KubernetesApplyDebugOverrideAction {
+	return KubernetesApplyDebugOverrideAction{ManifestName: mn}
+}
+
+func (KubernetesApplyDebugOverrideAction) Action() {}
+
+var _ store.Summarizer = KubernetesApplyDebugOverrideAction{}
+
+func (a KubernetesApplyDebugOverrideAction) Summarize(s *store.ChangeSummary) {
+	s.TriggerQueue = true
+	s.ManifestStatuses.Add(types.NamespacedName{Name: a.ManifestName.String()})
+}
@@ -0,0 +1,123 @@
+package store
+
+import (
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// The links of every resource, in Tiltfile order.
+//
+// Served to external tools that want to open a resource's URL without
+// scraping the web UI.
+type ResourceLinkList struct {
+	Resources []ResourceLinks `json:"resources"`
+}
+
+type ResourceLinks struct {
+	Name  string                    `json:"name"`
+	Links []v1alpha1.UIResourceLink `json:"links"`
+}
+
+func NewResourceLinkList(s EngineState) ResourceLinkList {
+	result := ResourceLinkList{Resources: []ResourceLinks{}}
+	for _, mt := range s.Targets() {
+		links := ManifestTargetLinks(s, mt)
+		if links == nil {
+			links = []v1alpha1.UIResourceLink{}
+		}
+		result.Resources = append(result.Resources, ResourceLinks{
+			Name:  mt.Manifest.Name.String(),
+			Links: links,
+		})
+	}
+	return result
+}
+
+// Every link Tilt knows about for a resource.
+//
+// Unlike ManifestTargetEndpoints, which picks the links worth showing in the
+// UI, this lists them all, so that external tools (like IDE plugins) can find
+// the right URL without guessing. The order is stable: links declared in the
+// Tiltfile (on the target, then on the resource), then port-forwards, then
+// service URLs.
+func ManifestTargetLinks(s EngineState, mt *ManifestTarget) []v1alpha1.UIResourceLink {
+	var links []v1alpha1.UIResourceLink
+	appendLinks := func(kind v1alpha1.UIResourceLinkKind, ready bool, lns ...model.Link) {
+		for _, ln := range lns {
+			links = append(links, v1alpha1.UIResourceLink{
+				URL:   ln.URLString(),
+				Name:  ln.Name,
+				Kind:  kind,
+				Ready: ready,
+			})
+		}
+	}
+
+	m := mt.Manifest
+	if m.IsK8s() {
+		appendLinks(v1alpha1.UIResourceLinkKindCustom, true, m.K8sTarget().Links...)
+	}
+	if m.IsLocal() {
+		appendLinks(v1alpha1.UIResourceLinkKindCustom, true, m.LocalTarget().Links...)
+	}
+	if m.IsDC() {
+		appendLinks(v1alpha1.UIResourceLinkKindCustom, true, m.DockerComposeTarget().Links...)
+	}
+	appendLinks(v1alpha1.UIResourceLinkKindCustom, true, m.Links...)
+
+	if m.IsK8s() {
+		portForwardSpec := m.K8sTarget().PortForwardTemplateSpec
+		if portForwardSpec != nil {
+			for _, pf := range portForwardSpec.Forwards {
+				ready, pending := portForwardReady(s, m.Name, pf)
+				ln := model.PortForwardToLink(pf)
+				links = append(links, v1alpha1.UIResourceLink{
+					URL:     ln.URLString(),
+					Name:    ln.Name,
+					Kind:    v1alpha1.UIResourceLinkKindPortForward,
+					Ready:   ready,
+					Pending: pending,
+				})
+			}
+		}
+
+		// The service watcher only records a URL once the load balancer has an address.
+		appendLinks(v1alpha1.UIResourceLinkKindService, true, lbLinks(mt)...)
+	}
+
+	if m.IsDC() {
+		ready := mt.State.DCRuntimeState().RuntimeStatus() == v1alpha1.RuntimeStatusOK
+		appendLinks(v1alpha1.UIResourceLinkKindService, ready, dcPortLinks(mt)...)
+	}
+
+	return links
+}
+
+// Whether any PortForward for the resource is currently forwarding the local port.
+//
+// If the forward has verification configured, it's only ready once it passes,
+// and is pending until then (unless it's been turned off).
+func portForwardReady(s EngineState, mn model.ManifestName, fwd v1alpha1.Forward) (ready bool, pending bool) {
+	disabled := false
+	for _, pf := range s.PortForwards {
+		if pf.Annotations[v1alpha1.AnnotationManifest] != mn.String() {
+			continue
+		}
+		for _, status := range pf.Status.ForwardStatuses {
+			if status.LocalPort != fwd.LocalPort {
+				continue
+			}
+			if status.Disabled {
+				disabled = true
+				continue
+			}
+			if status.StartedAt.IsZero() || status.Error != "" {
+				continue
+			}
+			if fwd.Verify == nil || !status.VerifiedAt.IsZero() {
+				return true, false
+			}
+		}
+	}
+	return false, fwd.Verify != nil && !disabled
+}
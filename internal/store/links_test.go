@@ -0,0 +1,164 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/tilt-dev/tilt/pkg/apis"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestManifestTargetLinksAllKinds(t *testing.T) {
+	state := newLinksState()
+	setForwardStatus(state, v1alpha1.ForwardStatus{
+		LocalPort:     8000,
+		ContainerPort: 5000,
+		StartedAt:     apis.NowMicro(),
+	})
+
+	assert.Equal(t, []v1alpha1.UIResourceLink{
+		{URL: "http://www.apple.edu", Name: "apple", Kind: v1alpha1.UIResourceLinkKindCustom, Ready: true},
+		{URL: "http://localhost:8000/", Kind: v1alpha1.UIResourceLinkKindPortForward, Ready: true},
+		{URL: "http://localhost:8001/", Name: "debugger", Kind: v1alpha1.UIResourceLinkKindPortForward},
+		{URL: "http://a.com", Kind: v1alpha1.UIResourceLinkKindService, Ready: true},
+		{URL: "http://b.com", Kind: v1alpha1.UIResourceLinkKindService, Ready: true},
+	}, ManifestTargetLinks(*state, state.ManifestTargets["foo"]))
+}
+
+func TestManifestTargetLinksIncludesResourceLinks(t *testing.T) {
+	state := newLinksState()
+	mt := state.ManifestTargets["foo"]
+	mt.Manifest = mt.Manifest.WithLinks([]model.Link{model.MustNewLink("http://grafana.local", "dashboard")})
+
+	links := ManifestTargetLinks(*state, mt)
+	assert.Equal(t, []v1alpha1.UIResourceLink{
+		{URL: "http://www.apple.edu", Name: "apple", Kind: v1alpha1.UIResourceLinkKindCustom, Ready: true},
+		{URL: "http://grafana.local", Name: "dashboard", Kind: v1alpha1.UIResourceLinkKindCustom, Ready: true},
+	}, links[:2])
+
+	endpoints := ManifestTargetEndpoints(mt)
+	assert.Equal(t, model.MustNewLink("http://grafana.local", "dashboard"), endpoints[len(endpoints)-1])
+}
+
+func TestManifestTargetLinksForwardReconnect(t *testing.T) {
+	state := newLinksState()
+	mt := state.ManifestTargets["foo"]
+
+	setForwardStatus(state, v1alpha1.ForwardStatus{
+		LocalPort:     8000,
+		ContainerPort: 5000,
+		Error:         "connection refused",
+	})
+	assert.False(t, forwardLink(t, ManifestTargetLinks(*state, mt), 8000).Ready)
+
+	setForwardStatus(state, v1alpha1.ForwardStatus{
+		LocalPort:     8000,
+		ContainerPort: 5000,
+		StartedAt:     apis.NowMicro(),
+	})
+	assert.True(t, forwardLink(t, ManifestTargetLinks(*state, mt), 8000).Ready)
+}
+
+func TestManifestTargetLinksForwardPendingUntilVerified(t *testing.T) {
+	state := newLinksState()
+	mt := state.ManifestTargets["foo"]
+	mt.Manifest.K8sTarget().PortForwardTemplateSpec.Forwards[0].Verify = &v1alpha1.ForwardVerify{HTTPPath: "/healthz"}
+
+	// Not connected yet.
+	link := forwardLink(t, ManifestTargetLinks(*state, mt), 8000)
+	assert.False(t, link.Ready)
+	assert.True(t, link.Pending)
+
+	// Connected, but the server isn't answering yet.
+	status := v1alpha1.ForwardStatus{
+		LocalPort:     8000,
+		ContainerPort: 5000,
+		StartedAt:     apis.NowMicro(),
+	}
+	setForwardStatus(state, status)
+	link = forwardLink(t, ManifestTargetLinks(*state, mt), 8000)
+	assert.False(t, link.Ready)
+	assert.True(t, link.Pending)
+
+	status.VerifiedAt = apis.NowMicro()
+	setForwardStatus(state, status)
+	link = forwardLink(t, ManifestTargetLinks(*state, mt), 8000)
+	assert.True(t, link.Ready)
+	assert.False(t, link.Pending)
+
+	// Turned off.
+	setForwardStatus(state, v1alpha1.ForwardStatus{LocalPort: 8000, ContainerPort: 5000, Disabled: true})
+	link = forwardLink(t, ManifestTargetLinks(*state, mt), 8000)
+	assert.False(t, link.Ready)
+	assert.False(t, link.Pending)
+}
+
+func TestResourceLinkListOrder(t *testing.T) {
+	state := newState([]model.Manifest{
+		model.Manifest{Name: "b"}.WithDeployTarget(model.LocalTarget{
+			Links: []model.Link{model.MustNewLink("http://localhost:3000", "")},
+		}),
+		model.Manifest{Name: "a"}.WithDeployTarget(model.LocalTarget{}),
+	})
+
+	assert.Equal(t, ResourceLinkList{
+		Resources: []ResourceLinks{
+			{
+				Name: "b",
+				Links: []v1alpha1.UIResourceLink{
+					{URL: "http://localhost:3000", Kind: v1alpha1.UIResourceLinkKindCustom, Ready: true},
+				},
+			},
+			{Name: "a", Links: []v1alpha1.UIResourceLink{}},
+		},
+	}, NewResourceLinkList(*state))
+}
+
+func newLinksState() *EngineState {
+	m := model.Manifest{
+		Name: "foo",
+	}.WithDeployTarget(model.K8sTarget{
+		KubernetesApplySpec: v1alpha1.KubernetesApplySpec{
+			PortForwardTemplateSpec: &v1alpha1.PortForwardTemplateSpec{
+				Forwards: []v1alpha1.Forward{
+					{LocalPort: 8000, ContainerPort: 5000},
+					{LocalPort: 8001, ContainerPort: 5001, Name: "debugger"},
+				},
+			},
+		},
+		Links: []model.Link{
+			model.MustNewLink("http://www.apple.edu", "apple"),
+		},
+	})
+
+	state := newState(nil)
+	mt := newManifestTargetWithLoadBalancerURLs(m, []string{"http://b.com", "http://a.com"})
+	state.UpsertManifestTarget(mt)
+	return state
+}
+
+func setForwardStatus(state *EngineState, status v1alpha1.ForwardStatus) {
+	state.PortForwards["foo-pod"] = &v1alpha1.PortForward{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "foo-pod",
+			Annotations: map[string]string{v1alpha1.AnnotationManifest: "foo"},
+		},
+		Status: v1alpha1.PortForwardStatus{
+			ForwardStatuses: []v1alpha1.ForwardStatus{status},
+		},
+	}
+}
+
+func forwardLink(t *testing.T, links []v1alpha1.UIResourceLink, localPort int) v1alpha1.UIResourceLink {
+	u := model.PortForwardToLink(v1alpha1.Forward{LocalPort: int32(localPort)}).URLString()
+	for _, l := range links {
+		if l.Kind == v1alpha1.UIResourceLinkKindPortForward && l.URL == u {
+			return l
+		}
+	}
+	t.Fatalf("no port-forward link for %d in %v", localPort, links)
+	return v1alpha1.UIResourceLink{}
+}
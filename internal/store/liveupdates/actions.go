@@ -1,6 +1,9 @@
 package liveupdates
 
-import "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+import (
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+)
 
 type LiveUpdateUpsertAction struct {
 	LiveUpdate *v1alpha1.LiveUpdate
@@ -21,3 +24,25 @@ func NewLiveUpdateDeleteAction(n string) LiveUpdateDeleteAction {
 }
 
 func (LiveUpdateDeleteAction) Action() {}
+
+// Records the update mode Tilt chose at startup.
+type UpdateModeDecidedAction struct {
+	State store.UpdateModeState
+}
+
+func NewUpdateModeDecidedAction(state store.UpdateModeState) UpdateModeDecidedAction {
+	return UpdateModeDecidedAction{State: state}
+}
+
+func (UpdateModeDecidedAction) Action() {}
+
+// Switches to a different update mode while Tilt is running.
+type UpdateModeSwitchAction struct {
+	Mode UpdateMode
+}
+
+func NewUpdateModeSwitchAction(mode UpdateMode) UpdateModeSwitchAction {
+	return UpdateModeSwitchAction{Mode: mode}
+}
+
+func (UpdateModeSwitchAction) Action() {}
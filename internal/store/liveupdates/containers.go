@@ -92,6 +92,7 @@ func RunningContainersForOnePod(selector *v1alpha1.LiveUpdateKubernetesSelector,
 			ContainerID:   container.ID(c.ID),
 			ContainerName: container.Name(c.Name),
 			Namespace:     k8s.Namespace(pod.Namespace),
+			NodeName:      pod.NodeName,
 		})
 	}
 
@@ -113,6 +114,10 @@ type Container struct {
 	ContainerID   container.ID
 	ContainerName container.Name
 	Namespace     k8s.Namespace
+
+	// The node the pod is scheduled on, so that we can check its
+	// container runtime. Empty for Docker Compose containers.
+	NodeName string
 }
 
 func (c Container) Empty() bool {
@@ -1,6 +1,7 @@
 package liveupdates
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/tilt-dev/tilt/internal/container"
@@ -19,6 +20,28 @@ func HandleLiveUpdateDeleteAction(state *store.EngineState, action LiveUpdateDel
 	delete(state.LiveUpdates, action.Name)
 }
 
+func HandleUpdateModeDecidedAction(state *store.EngineState, action UpdateModeDecidedAction) {
+	state.UpdateMode = action.State
+}
+
+// The API server validates the switch before dispatching the action,
+// but we check again in case the state changed in between.
+func HandleUpdateModeSwitchAction(ctx context.Context, state *store.EngineState, action UpdateModeSwitchAction) {
+	err := state.UpdateMode.ValidateSwitch(string(action.Mode))
+	if err != nil {
+		logger.Get(ctx).Warnf("%v", err)
+		return
+	}
+
+	if state.UpdateMode.Mode == string(action.Mode) {
+		return
+	}
+
+	logger.Get(ctx).Infof("Switched update mode from %q to %q. Takes effect on the next build.",
+		state.UpdateMode.Mode, action.Mode)
+	state.UpdateMode.Mode = string(action.Mode)
+}
+
 // If a container crashes, and it's been live-updated in the past,
 // then it needs to enter a special state to indicate that it
 // needs to be rebuilt (because the file system has been reset to the original image).
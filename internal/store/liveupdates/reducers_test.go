@@ -1,17 +1,22 @@
 package liveupdates
 
 import (
+	"context"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/tilt-dev/tilt/internal/container"
+	"github.com/tilt-dev/tilt/internal/docker"
+	"github.com/tilt-dev/tilt/internal/k8s"
 	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
 	"github.com/tilt-dev/tilt/internal/store"
 	"github.com/tilt-dev/tilt/internal/store/k8sconv"
 	"github.com/tilt-dev/tilt/internal/testutils/manifestbuilder"
 	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/logger"
 	"github.com/tilt-dev/tilt/pkg/model"
 )
 
@@ -21,11 +26,11 @@ func TestNeedsCrashRebuildLiveUpdateV1(t *testing.T) {
 	f := tempdir.NewTempDirFixture(t)
 	defer f.TearDown()
 
-	iTarget := imageTarget()
+	iTarget := imageTarget(f)
 	m := manifestbuilder.New(f, model.ManifestName("sancho")).
 		WithK8sYAML(testyaml.SanchoYAML).
 		WithLiveUpdateBAD().
-		WithImageTarget(iTarget).
+		WithImageTargets(iTarget).
 		Build()
 	s := store.NewState()
 	s.KubernetesResources["sancho"] = k8sResource()
@@ -42,11 +47,11 @@ func TestNeedsCrashRebuildLiveUpdateV2(t *testing.T) {
 	f := tempdir.NewTempDirFixture(t)
 	defer f.TearDown()
 
-	iTarget := imageTarget()
+	iTarget := imageTarget(f)
 	iTarget.LiveUpdateReconciler = true
 	m := manifestbuilder.New(f, model.ManifestName("sancho")).
 		WithK8sYAML(testyaml.SanchoYAML).
-		WithImageTarget(iTarget).
+		WithImageTargets(iTarget).
 		Build()
 	s := store.NewState()
 	s.KubernetesResources["sancho"] = k8sResource()
@@ -59,8 +64,9 @@ func TestNeedsCrashRebuildLiveUpdateV2(t *testing.T) {
 	assert.False(t, st.NeedsRebuildFromCrash)
 }
 
-func imageTarget() model.ImageTarget {
-	iTarget := model.MustNewImageTarget(SanchoRef)
+func imageTarget(f *tempdir.TempDirFixture) model.ImageTarget {
+	iTarget := model.MustNewImageTarget(SanchoRef).
+		WithBuildDetails(model.DockerBuild{BuildPath: f.Path()})
 	iTarget.LiveUpdateReconciler = true
 	iTarget.LiveUpdateSpec = v1alpha1.LiveUpdateSpec{
 		Selector: v1alpha1.LiveUpdateSelector{
@@ -93,3 +99,32 @@ func k8sResource() *k8sconv.KubernetesResource {
 		},
 	}
 }
+
+func TestSwitchUpdateMode(t *testing.T) {
+	s := store.NewState()
+	ctx := logger.WithLogger(context.Background(), logger.NewTestLogger(os.Stderr))
+	HandleUpdateModeDecidedAction(s, NewUpdateModeDecidedAction(NewUpdateModeState(
+		UpdateModeFlag(UpdateModeAuto), UpdateModeAuto, k8s.KubeContext("gke-cluster"), docker.ClusterEnv{})))
+	assert.Equal(t, "auto", s.UpdateMode.Mode)
+
+	HandleUpdateModeSwitchAction(ctx, s, NewUpdateModeSwitchAction(UpdateModeImage))
+	assert.Equal(t, "image", s.UpdateMode.Mode)
+	assert.Equal(t, "auto", s.UpdateMode.Flag)
+
+	// Container mode only works with local clusters, so the switch is ignored.
+	HandleUpdateModeSwitchAction(ctx, s, NewUpdateModeSwitchAction(UpdateModeContainer))
+	assert.Equal(t, "image", s.UpdateMode.Mode)
+}
+
+func TestSwitchUpdateModeLocalCluster(t *testing.T) {
+	s := store.NewState()
+	ctx := logger.WithLogger(context.Background(), logger.NewTestLogger(os.Stderr))
+	env := docker.ClusterEnv{BuildToKubeContexts: []string{"docker-desktop"}}
+	HandleUpdateModeDecidedAction(s, NewUpdateModeDecidedAction(NewUpdateModeState(
+		UpdateModeFlag(UpdateModeImage), UpdateModeImage, k8s.KubeContext("docker-desktop"), env)))
+	assert.True(t, s.UpdateMode.WillBuildToKubeContext)
+	assert.Empty(t, s.UpdateMode.IncompatibleModes)
+
+	HandleUpdateModeSwitchAction(ctx, s, NewUpdateModeSwitchAction(UpdateModeContainer))
+	assert.Equal(t, "container", s.UpdateMode.Mode)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/tilt-dev/tilt/internal/docker"
 	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/store"
 )
 
 type UpdateMode string
@@ -25,6 +26,11 @@ var (
 
 	// Use `kubectl exec`
 	UpdateModeKubectlExec UpdateMode = "exec"
+
+	// Run execs from Tilt's in-cluster agent, for clusters where we can't
+	// reach pods directly. Auto mode falls back to this when `kubectl exec`
+	// can't connect.
+	UpdateModeAgent UpdateMode = "agent"
 )
 
 var AllUpdateModes = []UpdateMode{
@@ -32,6 +38,7 @@ var AllUpdateModes = []UpdateMode{
 	UpdateModeImage,
 	UpdateModeContainer,
 	UpdateModeKubectlExec,
+	UpdateModeAgent,
 }
 
 func ProvideUpdateMode(flag UpdateModeFlag, kubeContext k8s.KubeContext, env docker.ClusterEnv) (UpdateMode, error) {
@@ -47,11 +54,53 @@ func ProvideUpdateMode(flag UpdateModeFlag, kubeContext k8s.KubeContext, env doc
 	}
 
 	mode := UpdateMode(flag)
+	err := checkUpdateModeCompatible(mode, kubeContext, env)
+	if err != nil {
+		return "", fmt.Errorf("update mode %q is %v", flag, err)
+	}
+
+	return mode, nil
+}
+
+// Records the update mode we chose, and why.
+func NewUpdateModeState(flag UpdateModeFlag, mode UpdateMode, kubeContext k8s.KubeContext, env docker.ClusterEnv) store.UpdateModeState {
+	state := store.UpdateModeState{
+		Mode:                   string(mode),
+		Flag:                   string(flag),
+		KubeContext:            string(kubeContext),
+		DockerHost:             env.Host,
+		WillBuildToKubeContext: docker.Env(env).WillBuildToKubeContext(kubeContext),
+	}
+	for _, m := range AllUpdateModes {
+		err := checkUpdateModeCompatible(m, kubeContext, env)
+		if err != nil {
+			if state.IncompatibleModes == nil {
+				state.IncompatibleModes = make(map[string]string)
+			}
+			state.IncompatibleModes[string(m)] = err.Error()
+			continue
+		}
+		state.CompatibleModes = append(state.CompatibleModes, string(m))
+	}
+	return state
+}
+
+// Returns the mode in the engine state, or the fallback if
+// Tilt hasn't recorded its decision yet.
+func CurrentUpdateMode(st store.RStore, fallback UpdateMode) UpdateMode {
+	state := st.RLockState()
+	defer st.RUnlockState()
+	if state.UpdateMode.Mode == "" {
+		return fallback
+	}
+	return UpdateMode(state.UpdateMode.Mode)
+}
+
+func checkUpdateModeCompatible(mode UpdateMode, kubeContext k8s.KubeContext, env docker.ClusterEnv) error {
 	if mode == UpdateModeContainer {
 		if !docker.Env(env).WillBuildToKubeContext(kubeContext) {
-			return "", fmt.Errorf("update mode %q is only valid with local Docker clusters like Docker For Mac or Minikube", flag)
+			return fmt.Errorf("only valid with local Docker clusters like Docker For Mac or Minikube")
 		}
 	}
-
-	return mode, nil
+	return nil
 }
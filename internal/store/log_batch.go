@@ -0,0 +1,60 @@
+package store
+
+// Caps how many bytes of log text we fold into one LogBatchAction, so that
+// one huge burst doesn't hold the state lock for too long.
+const logBatchMaxBytes = 256 * 1024
+
+// A run of consecutive LogActions, reduced in a single pass.
+//
+// A service that prints thousands of lines a second would otherwise cost
+// thousands of reducer passes. The store makes these when it drains
+// the action queue; nothing else should need to create one.
+type LogBatchAction struct {
+	Actions []LogAction
+}
+
+func (LogBatchAction) Action() {}
+
+func (LogBatchAction) Summarize(s *ChangeSummary) {
+	s.Log = true
+}
+
+// Folds each run of consecutive LogActions into a LogBatchAction.
+//
+// Every other action keeps its place, so actions are reduced in exactly
+// the order they were dispatched. A run that has only one LogAction
+// is left alone.
+func coalesceLogActions(actions []Action) []Action {
+	result := make([]Action, 0, len(actions))
+	var run []LogAction
+	runBytes := 0
+
+	flush := func() {
+		switch len(run) {
+		case 0:
+		case 1:
+			result = append(result, run[0])
+		default:
+			result = append(result, LogBatchAction{Actions: run})
+		}
+		run = nil
+		runBytes = 0
+	}
+
+	for _, action := range actions {
+		la, ok := action.(LogAction)
+		if !ok {
+			flush()
+			result = append(result, action)
+			continue
+		}
+
+		if len(run) > 0 && runBytes+len(la.msg) > logBatchMaxBytes {
+			flush()
+		}
+		run = append(run, la)
+		runBytes += len(la.msg)
+	}
+	flush()
+	return result
+}
@@ -0,0 +1,165 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+	"github.com/tilt-dev/tilt/pkg/model/logstore"
+)
+
+func TestCoalesceLogActions(t *testing.T) {
+	a := NewLogAction("fe", "fe", logger.InfoLvl, nil, []byte("a\n"))
+	b := NewLogAction("be", "be", logger.InfoLvl, nil, []byte("b\n"))
+	c := NewLogAction("fe", "fe", logger.InfoLvl, nil, []byte("c\n"))
+
+	actions := coalesceLogActions([]Action{
+		a, b, CompletedBuildAction{}, c, CompletedBuildAction{}, a, b, c,
+	})
+	assert.Equal(t, []Action{
+		LogBatchAction{Actions: []LogAction{a, b}},
+		CompletedBuildAction{},
+		c,
+		CompletedBuildAction{},
+		LogBatchAction{Actions: []LogAction{a, b, c}},
+	}, actions)
+}
+
+func TestCoalesceLogActionsByteCap(t *testing.T) {
+	half := NewLogAction("fe", "fe", logger.InfoLvl, nil,
+		[]byte(strings.Repeat("x", logBatchMaxBytes/2)))
+	big := NewLogAction("fe", "fe", logger.InfoLvl, nil,
+		[]byte(strings.Repeat("x", logBatchMaxBytes+1)))
+
+	actions := coalesceLogActions([]Action{half, half, half, big, half})
+	assert.Equal(t, []Action{
+		LogBatchAction{Actions: []LogAction{half, half}},
+		half,
+		big,
+		half,
+	}, actions)
+}
+
+func TestLogBatchMatchesUnbatched(t *testing.T) {
+	logs := logBurst(2000)
+
+	batched, batchedReduces := reduceLogBurst(t, true, logs)
+	unbatched, unbatchedReduces := reduceLogBurst(t, false, logs)
+
+	assert.Equal(t, len(logs), unbatchedReduces)
+	assert.Less(t, batchedReduces, unbatchedReduces)
+
+	require.Equal(t, unbatched.String(), batched.String())
+	for _, mn := range []model.ManifestName{"fe", "be", "db"} {
+		require.Equal(t, unbatched.ManifestLog(mn), batched.ManifestLog(mn))
+	}
+
+	batchedList, err := batched.ToLogList(0)
+	require.NoError(t, err)
+	unbatchedList, err := unbatched.ToLogList(0)
+	require.NoError(t, err)
+	assert.Equal(t, unbatchedList, batchedList)
+}
+
+func TestLogBatchIsLogOnly(t *testing.T) {
+	f := newFixture(t)
+	s := newFakeSubscriber()
+	_ = f.store.AddSubscriber(f.ctx, s)
+	f.Start()
+
+	f.store.actionQueue.add(LogAction{})
+	f.store.actionQueue.add(LogAction{})
+	f.store.drainActions()
+
+	call := <-s.onChange
+	assert.True(t, call.summary.IsLogOnly())
+	close(call.done)
+
+	f.store.Dispatch(DoneAction{})
+	f.WaitUntilDone()
+}
+
+// Compare the reduces/op of a 10k-line burst with:
+//
+//	go test ./internal/store -run=XXX -bench=BenchmarkLogBurst
+func BenchmarkLogBurstBatched(b *testing.B) {
+	benchmarkLogBurst(b, true)
+}
+
+func BenchmarkLogBurstUnbatched(b *testing.B) {
+	benchmarkLogBurst(b, false)
+}
+
+func benchmarkLogBurst(b *testing.B, batchLogs bool) {
+	logs := logBurst(10000)
+	total := 0
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, reduces := reduceLogBurst(b, batchLogs, logs)
+		total += reduces
+	}
+	b.ReportMetric(float64(total)/float64(b.N), "reduces/op")
+}
+
+// Log lines interleaved across a few manifests, with some partial lines
+// so that ordering within a span matters.
+func logBurst(n int) []LogAction {
+	names := []model.ManifestName{"fe", "be", "db"}
+	var result []LogAction
+	for i := 0; i < n; i++ {
+		mn := names[i%len(names)]
+		msg := fmt.Sprintf("line %d\n", i)
+		if i%7 == 0 {
+			msg = fmt.Sprintf("partial %d ", i)
+		}
+		result = append(result, NewLogAction(mn, logstore.SpanID(mn), logger.InfoLvl, nil, []byte(msg)))
+	}
+	return result
+}
+
+// Dispatches the logs through a real store, and returns the resulting
+// log store and how many times the reducer ran.
+func reduceLogBurst(t testing.TB, batchLogs bool, logs []LogAction) (*logstore.LogStore, int) {
+	var reduces int32
+	reducer := Reducer(func(ctx context.Context, s *EngineState, action Action) {
+		atomic.AddInt32(&reduces, 1)
+		switch action := action.(type) {
+		case LogAction:
+			s.LogStore.Append(action, s.Secrets)
+		case LogBatchAction:
+			for _, la := range action.Actions {
+				s.LogStore.Append(la, s.Secrets)
+			}
+		case DoneAction:
+			s.FatalError = context.Canceled
+		}
+	})
+
+	st := NewStore(reducer, false, false)
+	st.batchLogs = batchLogs
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() {
+		done <- st.Loop(ctx)
+	}()
+
+	for _, la := range logs {
+		st.Dispatch(la)
+	}
+	st.Dispatch(DoneAction{})
+	err := <-done
+	if err != nil && err != context.Canceled {
+		t.Fatalf("Loop failed unexpectedly: %v", err)
+	}
+
+	// Don't count the DoneAction.
+	return st.state.LogStore, int(atomic.LoadInt32(&reduces)) - 1
+}
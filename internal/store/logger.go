@@ -49,6 +49,7 @@ func WithObjectLogHandler(ctx context.Context, st RStore, obj metav1.Object) (co
 		store:        st,
 		manifestName: model.ManifestName(mn),
 		spanID:       model.LogSpanID(spanID),
+		runNumber:    obj.GetAnnotations()[v1alpha1.AnnotationRunNumber],
 	}
 	return logger.CtxWithLogHandler(ctx, w), nil
 }
@@ -57,9 +58,17 @@ type apiLogWriter struct {
 	store        RStore
 	manifestName model.ManifestName
 	spanID       model.LogSpanID
+	runNumber    string
 }
 
 func (w apiLogWriter) Write(level logger.Level, fields logger.Fields, p []byte) error {
+	if w.runNumber != "" {
+		withRun := logger.Fields{logger.FieldNameRunNumber: w.runNumber}
+		for k, v := range fields {
+			withRun[k] = v
+		}
+		fields = withRun
+	}
 	w.store.Dispatch(NewLogAction(w.manifestName, w.spanID, level, fields, p))
 	return nil
 }
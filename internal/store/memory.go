@@ -0,0 +1,175 @@
+package store
+
+import (
+	"sort"
+	"time"
+	"unsafe"
+
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// A sample of how much memory the engine is using.
+//
+// Sampled periodically by internal/engine/memory.
+type MemoryReport struct {
+	Time time.Time
+
+	// Bytes of memory the Go runtime has obtained from the OS and not
+	// returned. This is the closest thing we have to the process's footprint.
+	TotalBytes int64
+
+	// Bytes of live heap objects.
+	HeapBytes int64
+
+	Goroutines int
+
+	Accounting MemoryAccounting
+}
+
+func (r MemoryReport) Empty() bool {
+	return r.Time.IsZero()
+}
+
+// Where the engine's memory is going, according to its own bookkeeping.
+//
+// These are estimates of the data the engine holds onto, not exact
+// measurements. They're meant to point at the biggest consumers when Tilt
+// uses more memory than it should.
+type MemoryAccounting struct {
+	LogBytes    int
+	LogSegments int
+	LogSpans    int
+
+	Manifests int
+
+	FileWatches  int
+	WatchedPaths int
+	FileEvents   int
+
+	KubernetesWatches int
+
+	PendingFileChanges int
+
+	BuildRecords     int
+	BuildRecordEdits int
+
+	// The estimated bytes held by each consumer, largest first.
+	Consumers []MemoryConsumer
+}
+
+type MemoryConsumer struct {
+	Name  string
+	Bytes int64
+}
+
+const (
+	MemoryConsumerLogs               = "logs"
+	MemoryConsumerFileWatches        = "file watches"
+	MemoryConsumerPendingFileChanges = "pending file changes"
+	MemoryConsumerBuildHistory       = "build history"
+)
+
+// The sum of the estimates of all consumers.
+func (a MemoryAccounting) EstimatedBytes() int64 {
+	result := int64(0)
+	for _, c := range a.Consumers {
+		result += c.Bytes
+	}
+	return result
+}
+
+// The n largest consumers.
+func (a MemoryAccounting) Largest(n int) []MemoryConsumer {
+	if n > len(a.Consumers) {
+		n = len(a.Consumers)
+	}
+	return a.Consumers[:n]
+}
+
+// The estimated bytes held by each map entry or slice element, on top of
+// the contents of its strings.
+var (
+	stringOverhead            = int64(unsafe.Sizeof(""))
+	pendingFileChangeOverhead = stringOverhead + int64(unsafe.Sizeof(time.Time{}))
+	buildRecordOverhead       = int64(unsafe.Sizeof(model.BuildRecord{}))
+)
+
+// Counts what the engine is holding onto, and estimates how many bytes each
+// kind of data uses.
+func (e *EngineState) MemoryAccounting() MemoryAccounting {
+	result := MemoryAccounting{
+		Manifests:         len(e.ManifestTargets),
+		KubernetesWatches: len(e.KubernetesDiscoverys),
+	}
+
+	if e.LogStore != nil {
+		logs := e.LogStore.MemoryStats()
+		result.LogBytes = logs.TextBytes
+		result.LogSegments = logs.Segments
+		result.LogSpans = logs.Spans
+		result.addConsumer(MemoryConsumerLogs, logs.EstimatedBytes)
+	}
+
+	fileWatchBytes := int64(0)
+	for _, fw := range e.FileWatches {
+		result.FileWatches++
+		result.WatchedPaths += len(fw.Spec.WatchedPaths)
+		fileWatchBytes += stringsBytes(fw.Spec.WatchedPaths)
+		for _, event := range fw.Status.FileEvents {
+			result.FileEvents++
+			fileWatchBytes += int64(unsafe.Sizeof(event)) + stringsBytes(event.SeenFiles)
+		}
+	}
+	result.addConsumer(MemoryConsumerFileWatches, fileWatchBytes)
+
+	pendingBytes := int64(0)
+	historyBytes := int64(0)
+	manifestStates := e.ManifestStates()
+	manifestStates = append(manifestStates, e.GetTiltfileStates()...)
+	for _, ms := range manifestStates {
+		for _, bs := range ms.BuildStatuses {
+			result.PendingFileChanges += len(bs.PendingFileChanges)
+			pendingBytes += bs.pendingFileChangesBytes()
+		}
+		for _, record := range ms.BuildHistory {
+			result.BuildRecords++
+			result.BuildRecordEdits += len(record.Edits)
+		}
+		historyBytes += ms.buildHistoryBytes()
+	}
+	result.addConsumer(MemoryConsumerPendingFileChanges, pendingBytes)
+	result.addConsumer(MemoryConsumerBuildHistory, historyBytes)
+
+	sort.SliceStable(result.Consumers, func(i, j int) bool {
+		return result.Consumers[i].Bytes > result.Consumers[j].Bytes
+	})
+	return result
+}
+
+func (a *MemoryAccounting) addConsumer(name string, bytes int64) {
+	a.Consumers = append(a.Consumers, MemoryConsumer{Name: name, Bytes: bytes})
+}
+
+func (s *BuildStatus) pendingFileChangesBytes() int64 {
+	result := int64(0)
+	for path := range s.PendingFileChanges {
+		result += pendingFileChangeOverhead + int64(len(path))
+	}
+	return result
+}
+
+func (ms *ManifestState) buildHistoryBytes() int64 {
+	result := int64(0)
+	for _, record := range ms.BuildHistory {
+		result += buildRecordOverhead + stringsBytes(record.Edits)
+	}
+	return result
+}
+
+func stringsBytes(strs []string) int64 {
+	result := int64(0)
+	for _, s := range strs {
+		result += stringOverhead + int64(len(s))
+	}
+	return result
+}
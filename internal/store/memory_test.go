@@ -0,0 +1,90 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+	"github.com/tilt-dev/tilt/pkg/model/logstore"
+)
+
+func TestMemoryAccounting(t *testing.T) {
+	m := model.Manifest{Name: "fe"}.WithDeployTarget(model.K8sTarget{})
+	state := newState([]model.Manifest{m})
+	state.LogStore = logstore.NewLogStoreForTesting("hello world\n")
+
+	ms := state.ManifestTargets["fe"].State
+	ms.BuildStatuses[m.DeployTarget.ID()] = &BuildStatus{
+		PendingFileChanges: map[string]time.Time{
+			"/src/main.go": time.Now(),
+			"/src/util.go": time.Now(),
+		},
+	}
+	ms.BuildHistory = []model.BuildRecord{
+		{Edits: []string{"/src/main.go"}},
+		{Edits: []string{"/src/main.go", "/src/util.go"}},
+		{},
+	}
+
+	state.FileWatches["fe"] = &v1alpha1.FileWatch{
+		Spec: v1alpha1.FileWatchSpec{WatchedPaths: []string{"/src", "/Tiltfile"}},
+		Status: v1alpha1.FileWatchStatus{
+			FileEvents: []v1alpha1.FileEvent{
+				{Time: metav1.NowMicro(), SeenFiles: []string{"/src/main.go"}},
+			},
+		},
+	}
+	state.KubernetesDiscoverys["fe"] = &v1alpha1.KubernetesDiscovery{}
+
+	a := state.MemoryAccounting()
+	assert.Equal(t, len("hello world\n"), a.LogBytes)
+	assert.Equal(t, 1, a.LogSegments)
+	assert.Equal(t, 1, a.LogSpans)
+	assert.Equal(t, 1, a.Manifests)
+	assert.Equal(t, 1, a.FileWatches)
+	assert.Equal(t, 2, a.WatchedPaths)
+	assert.Equal(t, 1, a.FileEvents)
+	assert.Equal(t, 1, a.KubernetesWatches)
+	assert.Equal(t, 2, a.PendingFileChanges)
+	assert.Equal(t, 3, a.BuildRecords)
+	assert.Equal(t, 3, a.BuildRecordEdits)
+
+	bytes := make(map[string]int64)
+	for _, c := range a.Consumers {
+		bytes[c.Name] = c.Bytes
+	}
+	assert.Equal(t, 2*pendingFileChangeOverhead+int64(len("/src/main.go")+len("/src/util.go")),
+		bytes[MemoryConsumerPendingFileChanges])
+	assert.Equal(t, 3*buildRecordOverhead+3*stringOverhead+int64(2*len("/src/main.go")+len("/src/util.go")),
+		bytes[MemoryConsumerBuildHistory])
+	assert.Equal(t, state.LogStore.MemoryStats().EstimatedBytes, bytes[MemoryConsumerLogs])
+	assert.Greater(t, bytes[MemoryConsumerFileWatches], int64(len("/src")+len("/Tiltfile")+len("/src/main.go")))
+
+	total := int64(0)
+	for i, c := range a.Consumers {
+		total += c.Bytes
+		if i > 0 {
+			assert.GreaterOrEqual(t, a.Consumers[i-1].Bytes, c.Bytes, "consumers should be sorted largest first")
+		}
+	}
+	assert.Equal(t, total, a.EstimatedBytes())
+}
+
+func TestMemoryAccountingLargest(t *testing.T) {
+	a := MemoryAccounting{
+		Consumers: []MemoryConsumer{{Name: "a", Bytes: 3}, {Name: "b", Bytes: 2}, {Name: "c", Bytes: 1}},
+	}
+	assert.Equal(t, []MemoryConsumer{{Name: "a", Bytes: 3}, {Name: "b", Bytes: 2}}, a.Largest(2))
+	assert.Equal(t, a.Consumers, a.Largest(5))
+	assert.Equal(t, int64(6), a.EstimatedBytes())
+}
+
+func TestMemoryAccountingEmptyState(t *testing.T) {
+	a := NewState().MemoryAccounting()
+	assert.Equal(t, int64(0), a.EstimatedBytes())
+	assert.Equal(t, 0, a.Manifests)
+}
@@ -0,0 +1,25 @@
+package store
+
+import (
+	"github.com/tilt-dev/tilt/internal/k8s"
+)
+
+// When a pod stays Pending for a while, we gather up the reasons from
+// its conditions, its events, and the objects it references, so that
+// users don't have to go digging with kubectl.
+type PendingPodDiagnostic struct {
+	// The reason the pod was Pending when we diagnosed it.
+	// We only re-diagnose when this changes.
+	Reason string
+
+	// A one-line explanation, e.g., `missing PersistentVolumeClaim "data"`.
+	Summary string
+
+	// The full diagnostic, as printed to the resource log.
+	Message string
+}
+
+func (s K8sRuntimeState) PendingPodDiagnostic(podID string) (PendingPodDiagnostic, bool) {
+	diag, ok := s.PendingPodDiagnostics[k8s.PodID(podID)]
+	return diag, ok
+}
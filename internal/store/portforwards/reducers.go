@@ -0,0 +1,14 @@
+package portforwards
+
+import (
+	"github.com/tilt-dev/tilt/internal/store"
+)
+
+func HandlePortForwardUpsertAction(state *store.EngineState, action PortForwardUpsertAction) {
+	n := action.PortForward.Name
+	state.PortForwards[n] = action.PortForward
+}
+
+func HandlePortForwardDeleteAction(state *store.EngineState, action PortForwardDeleteAction) {
+	delete(state.PortForwards, action.Name)
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// A snapshot of which resources are ready.
+//
+// In CI mode, Tilt exits once every resource is ready. This lets us report
+// what we're still waiting on before we get there.
+type ReadinessSummary struct {
+	Resources []ResourceReadiness
+}
+
+type ResourceReadiness struct {
+	Name model.ManifestName
+
+	BuildComplete bool
+	Ready         bool
+
+	// True if the resource's build or runtime failed.
+	Failed bool
+
+	// Why the resource isn't ready yet, e.g., "building" or "pod Pending".
+	// Empty if the resource is ready.
+	Reason string
+}
+
+func (s ReadinessSummary) ReadyCount() int {
+	count := 0
+	for _, r := range s.Resources {
+		if r.Ready {
+			count++
+		}
+	}
+	return count
+}
+
+func (s ReadinessSummary) FailedCount() int {
+	count := 0
+	for _, r := range s.Resources {
+		if r.Failed {
+			count++
+		}
+	}
+	return count
+}
+
+// A compact progress line, like:
+//
+// ready 5/8: waiting on fe (pod Pending), worker (building)
+func (s ReadinessSummary) String() string {
+	result := fmt.Sprintf("ready %d/%d", s.ReadyCount(), len(s.Resources))
+
+	var waiting []string
+	for _, r := range s.Resources {
+		if !r.Ready {
+			waiting = append(waiting, fmt.Sprintf("%s (%s)", r.Name, r.Reason))
+		}
+	}
+	if len(waiting) > 0 {
+		result += ": waiting on " + strings.Join(waiting, ", ")
+	}
+	return result
+}
@@ -68,6 +68,28 @@ func (l LocalRuntimeState) HasEverBeenReadyOrSucceeded() bool {
 	return !l.LastReadyOrSucceededTime.IsZero()
 }
 
+// A sync-only resource doesn't run anything, so it only tracks
+// when it last synced successfully.
+type SyncRuntimeState struct {
+	LastSyncTime time.Time
+}
+
+var _ RuntimeState = SyncRuntimeState{}
+
+func (SyncRuntimeState) RuntimeState() {}
+
+func (s SyncRuntimeState) RuntimeStatus() v1alpha1.RuntimeStatus {
+	return v1alpha1.RuntimeStatusNotApplicable
+}
+
+func (s SyncRuntimeState) RuntimeStatusError() error {
+	return nil
+}
+
+func (s SyncRuntimeState) HasEverBeenReadyOrSucceeded() bool {
+	return !s.LastSyncTime.IsZero()
+}
+
 type K8sRuntimeState struct {
 	// The ancestor that we match pods against to associate them with this manifest.
 	// If we deployed Pod YAML, this will be the Pod UID.
@@ -89,6 +111,19 @@ type K8sRuntimeState struct {
 	// BaselineRestarts is used as a floor for container restarts to avoid alerting on restarts
 	// that happened either before Tilt started or before a Live Update change.
 	BaselineRestarts map[k8s.PodID]int32
+
+	// Registry checks for images that pods failed to pull, indexed by pod, then image.
+	ImagePullChecks map[k8s.PodID]map[string]ImagePullCheck
+
+	// Why pods have been stuck Pending, indexed by pod.
+	PendingPodDiagnostics map[k8s.PodID]PendingPodDiagnostic
+
+	// Containers in the current deploy that are running a different image
+	// than the one Tilt last built for them, sorted by pod and container.
+	ImageDrifts []ImageDrift
+
+	// If this resource is a task, the most recent runs, oldest first.
+	TaskRuns []TaskRun
 }
 
 func (K8sRuntimeState) RuntimeState() {}
@@ -107,11 +142,13 @@ func NewK8sRuntimeStateWithPods(m model.Manifest, pods ...v1alpha1.Pod) K8sRunti
 
 func NewK8sRuntimeState(m model.Manifest) K8sRuntimeState {
 	return K8sRuntimeState{
-		PodReadinessMode: m.PodReadinessMode(),
-		Pods:             PodSet{},
-		LBs:              make(map[k8s.ServiceName]*url.URL),
-		UpdateStartTime:  make(map[k8s.PodID]time.Time),
-		BaselineRestarts: make(map[k8s.PodID]int32),
+		PodReadinessMode:      m.PodReadinessMode(),
+		Pods:                  PodSet{},
+		LBs:                   make(map[k8s.ServiceName]*url.URL),
+		UpdateStartTime:       make(map[k8s.PodID]time.Time),
+		BaselineRestarts:      make(map[k8s.PodID]int32),
+		ImagePullChecks:       make(map[k8s.PodID]map[string]ImagePullCheck),
+		PendingPodDiagnostics: make(map[k8s.PodID]PendingPodDiagnostic),
 	}
 }
 
@@ -120,6 +157,9 @@ func (s K8sRuntimeState) RuntimeStatusError() error {
 	if status != v1alpha1.RuntimeStatusError {
 		return nil
 	}
+	if run, ok := s.LatestTaskRun(); ok {
+		return fmt.Errorf("%s", run.Error)
+	}
 	pod := s.MostRecentPod()
 	return fmt.Errorf("Pod %s in error state: %s", pod.Name, pod.Status)
 }
@@ -133,6 +173,18 @@ func (s K8sRuntimeState) RuntimeStatus() v1alpha1.RuntimeStatus {
 		return v1alpha1.RuntimeStatusOK
 	}
 
+	// A task's status is the status of its latest run, so that
+	// re-running it doesn't show the last run's result.
+	if run, ok := s.LatestTaskRun(); ok {
+		switch run.Status {
+		case TaskRunSucceeded:
+			return v1alpha1.RuntimeStatusOK
+		case TaskRunFailed:
+			return v1alpha1.RuntimeStatusError
+		}
+		return v1alpha1.RuntimeStatusPending
+	}
+
 	pod := s.MostRecentPod()
 
 	switch v1.PodPhase(pod.Phase) {
@@ -185,14 +237,19 @@ func (s K8sRuntimeState) PodList() []v1alpha1.Pod {
 	return pods
 }
 
-func (s K8sRuntimeState) EntityDisplayNames() []string {
+// Names of the objects this resource deployed, plus any objects it shares
+// with a resource that applies them on its behalf.
+func (s K8sRuntimeState) EntityDisplayNames(shared ...v1.ObjectReference) []string {
 	if s.ApplyFilter == nil {
 		return nil
 	}
 
-	entities := make([]k8s.EntityMeta, len(s.ApplyFilter.DeployedRefs))
+	entities := make([]k8s.EntityMeta, 0, len(s.ApplyFilter.DeployedRefs)+len(shared))
 	for i := range s.ApplyFilter.DeployedRefs {
-		entities[i] = objectRefMeta{s.ApplyFilter.DeployedRefs[i]}
+		entities = append(entities, objectRefMeta{s.ApplyFilter.DeployedRefs[i]})
+	}
+	for i := range shared {
+		entities = append(entities, objectRefMeta{shared[i]})
 	}
 
 	// Use a min component count of 2 for computing names,
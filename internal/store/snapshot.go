@@ -0,0 +1,255 @@
+package store
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/docker/go-connections/nat"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tilt-dev/tilt/internal/container"
+	"github.com/tilt-dev/tilt/internal/dockercompose"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/store/k8sconv"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// Makes a copy of the state that subscribers can read without a lock,
+// while the reducer keeps mutating the original.
+//
+// Top-level maps and slices are copied. Manifest states are copied on write:
+// if the change summary says that a manifest didn't change since the previous
+// snapshot, we re-use the previous snapshot's copy.
+//
+// API objects (Cmds, UIResources, etc) are shared with the original. Reducers
+// replace these objects rather than mutating them, so that's safe.
+//
+// Snapshots don't include logs. Subscribers that read logs still need RLockState.
+func (e *EngineState) snapshot(prev *EngineState, summary ChangeSummary) *EngineState {
+	ret := *e
+	ret.LogStore = nil
+
+	ret.ManifestDefinitionOrder = append([]model.ManifestName(nil), e.ManifestDefinitionOrder...)
+	ret.TiltfileDefinitionOrder = append([]model.ManifestName(nil), e.TiltfileDefinitionOrder...)
+	ret.TriggerQueue = append([]model.ManifestName(nil), e.TriggerQueue...)
+	ret.Readiness.Resources = append([]ResourceReadiness(nil), e.Readiness.Resources...)
+
+	ret.ManifestTargets = make(map[model.ManifestName]*ManifestTarget, len(e.ManifestTargets))
+	for mn, mt := range e.ManifestTargets {
+		if prev != nil && !manifestChanged(summary, mn) {
+			prevMT, ok := prev.ManifestTargets[mn]
+			if ok {
+				ret.ManifestTargets[mn] = prevMT
+				continue
+			}
+		}
+		ret.ManifestTargets[mn] = &ManifestTarget{
+			Manifest: mt.Manifest,
+			State:    mt.State.DeepCopy(),
+		}
+	}
+
+	ret.TiltfileStates = make(map[model.ManifestName]*ManifestState, len(e.TiltfileStates))
+	for mn, ms := range e.TiltfileStates {
+		if prev != nil && !manifestChanged(summary, mn) {
+			prevMS, ok := prev.TiltfileStates[mn]
+			if ok {
+				ret.TiltfileStates[mn] = prevMS
+				continue
+			}
+		}
+		ret.TiltfileStates[mn] = ms.DeepCopy()
+	}
+
+	ret.CurrentlyBuilding = make(map[model.ManifestName]bool, len(e.CurrentlyBuilding))
+	for k, v := range e.CurrentlyBuilding {
+		ret.CurrentlyBuilding[k] = v
+	}
+	ret.TriggerModeOverrides = make(map[model.ManifestName]TriggerModeOverride, len(e.TriggerModeOverrides))
+	for k, v := range e.TriggerModeOverrides {
+		ret.TriggerModeOverrides[k] = v
+	}
+	ret.TiltfileConfigPaths = make(map[model.ManifestName][]string, len(e.TiltfileConfigPaths))
+	for k, v := range e.TiltfileConfigPaths {
+		ret.TiltfileConfigPaths[k] = v
+	}
+	ret.Features = make(map[string]bool, len(e.Features))
+	for k, v := range e.Features {
+		ret.Features[k] = v
+	}
+	ret.Secrets = make(model.SecretSet, len(e.Secrets))
+	for k, v := range e.Secrets {
+		ret.Secrets[k] = v
+	}
+	ret.KubernetesResources = make(map[string]*k8sconv.KubernetesResource, len(e.KubernetesResources))
+	for k, v := range e.KubernetesResources {
+		ret.KubernetesResources[k] = v
+	}
+
+	ret.Cmds = make(map[string]*Cmd, len(e.Cmds))
+	for k, v := range e.Cmds {
+		ret.Cmds[k] = v
+	}
+	ret.Tiltfiles = make(map[string]*v1alpha1.Tiltfile, len(e.Tiltfiles))
+	for k, v := range e.Tiltfiles {
+		ret.Tiltfiles[k] = v
+	}
+	ret.FileWatches = make(map[string]*v1alpha1.FileWatch, len(e.FileWatches))
+	for k, v := range e.FileWatches {
+		ret.FileWatches[k] = v
+	}
+	ret.KubernetesApplys = make(map[string]*v1alpha1.KubernetesApply, len(e.KubernetesApplys))
+	for k, v := range e.KubernetesApplys {
+		ret.KubernetesApplys[k] = v
+	}
+	ret.KubernetesDiscoverys = make(map[string]*v1alpha1.KubernetesDiscovery, len(e.KubernetesDiscoverys))
+	for k, v := range e.KubernetesDiscoverys {
+		ret.KubernetesDiscoverys[k] = v
+	}
+	ret.UIResources = make(map[string]*v1alpha1.UIResource, len(e.UIResources))
+	for k, v := range e.UIResources {
+		ret.UIResources[k] = v
+	}
+	ret.ConfigMaps = make(map[string]*v1alpha1.ConfigMap, len(e.ConfigMaps))
+	for k, v := range e.ConfigMaps {
+		ret.ConfigMaps[k] = v
+	}
+	ret.LiveUpdates = make(map[string]*v1alpha1.LiveUpdate, len(e.LiveUpdates))
+	for k, v := range e.LiveUpdates {
+		ret.LiveUpdates[k] = v
+	}
+	ret.PortForwards = make(map[string]*v1alpha1.PortForward, len(e.PortForwards))
+	for k, v := range e.PortForwards {
+		ret.PortForwards[k] = v
+	}
+	return &ret
+}
+
+// Whether a manifest's state may have changed since the last snapshot.
+func manifestChanged(summary ChangeSummary, mn model.ManifestName) bool {
+	if summary.Legacy {
+		return true
+	}
+	nn := types.NamespacedName{Name: mn.String()}
+	return summary.ManifestSpecs.Changes[nn] || summary.ManifestStatuses.Changes[nn]
+}
+
+func (ms *ManifestState) DeepCopy() *ManifestState {
+	if ms == nil {
+		return nil
+	}
+	ret := *ms
+
+	ret.BuildStatuses = make(map[model.TargetID]*BuildStatus, len(ms.BuildStatuses))
+	for id, bs := range ms.BuildStatuses {
+		ret.BuildStatuses[id] = bs.DeepCopy()
+	}
+
+	ret.RuntimeState = copyRuntimeState(ms.RuntimeState)
+	ret.BuildHistory = append([]model.BuildRecord(nil), ms.BuildHistory...)
+	ret.ConfigFilesThatCausedChange = append([]string(nil), ms.ConfigFilesThatCausedChange...)
+
+	if ms.LiveUpdatedContainerIDs != nil {
+		ret.LiveUpdatedContainerIDs = make(map[container.ID]bool, len(ms.LiveUpdatedContainerIDs))
+		for k, v := range ms.LiveUpdatedContainerIDs {
+			ret.LiveUpdatedContainerIDs[k] = v
+		}
+	}
+	return &ret
+}
+
+func (bs *BuildStatus) DeepCopy() *BuildStatus {
+	if bs == nil {
+		return nil
+	}
+	ret := *bs
+	if bs.PendingFileChanges != nil {
+		ret.PendingFileChanges = make(map[string]time.Time, len(bs.PendingFileChanges))
+		for k, v := range bs.PendingFileChanges {
+			ret.PendingFileChanges[k] = v
+		}
+	}
+	if bs.PendingDependencyChanges != nil {
+		ret.PendingDependencyChanges = make(map[model.TargetID]time.Time, len(bs.PendingDependencyChanges))
+		for k, v := range bs.PendingDependencyChanges {
+			ret.PendingDependencyChanges[k] = v
+		}
+	}
+	return &ret
+}
+
+// Runtime states are values, but some of them hold maps.
+func copyRuntimeState(rs RuntimeState) RuntimeState {
+	switch rs := rs.(type) {
+	case K8sRuntimeState:
+		return rs.deepCopy()
+	case dockercompose.State:
+		if rs.Ports != nil {
+			ports := make(nat.PortMap, len(rs.Ports))
+			for k, v := range rs.Ports {
+				ports[k] = append([]nat.PortBinding(nil), v...)
+			}
+			rs.Ports = ports
+		}
+		return rs
+	default:
+		return rs
+	}
+}
+
+func (s K8sRuntimeState) deepCopy() K8sRuntimeState {
+	if s.Pods != nil {
+		pods := make(PodSet, len(s.Pods))
+		for k, v := range s.Pods {
+			pods[k] = v.DeepCopy()
+		}
+		s.Pods = pods
+	}
+	if s.LBs != nil {
+		lbs := make(map[k8s.ServiceName]*url.URL, len(s.LBs))
+		for k, v := range s.LBs {
+			lbs[k] = v
+		}
+		s.LBs = lbs
+	}
+	if s.UpdateStartTime != nil {
+		times := make(map[k8s.PodID]time.Time, len(s.UpdateStartTime))
+		for k, v := range s.UpdateStartTime {
+			times[k] = v
+		}
+		s.UpdateStartTime = times
+	}
+	if s.BaselineRestarts != nil {
+		restarts := make(map[k8s.PodID]int32, len(s.BaselineRestarts))
+		for k, v := range s.BaselineRestarts {
+			restarts[k] = v
+		}
+		s.BaselineRestarts = restarts
+	}
+	if s.ImagePullChecks != nil {
+		checks := make(map[k8s.PodID]map[string]ImagePullCheck, len(s.ImagePullChecks))
+		for podID, byImage := range s.ImagePullChecks {
+			copied := make(map[string]ImagePullCheck, len(byImage))
+			for k, v := range byImage {
+				copied[k] = v
+			}
+			checks[podID] = copied
+		}
+		s.ImagePullChecks = checks
+	}
+	if s.PendingPodDiagnostics != nil {
+		diags := make(map[k8s.PodID]PendingPodDiagnostic, len(s.PendingPodDiagnostics))
+		for k, v := range s.PendingPodDiagnostics {
+			diags[k] = v
+		}
+		s.PendingPodDiagnostics = diags
+	}
+	if s.ImageDrifts != nil {
+		s.ImageDrifts = append([]ImageDrift{}, s.ImageDrifts...)
+	}
+	if s.TaskRuns != nil {
+		s.TaskRuns = append([]TaskRun{}, s.TaskRuns...)
+	}
+	return s
+}
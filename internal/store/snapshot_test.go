@@ -0,0 +1,315 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// Deploys a new pod to every manifest, one manifest at a time.
+type deployAllAction struct {
+	n int
+}
+
+func (deployAllAction) Action() {}
+
+// Marks one manifest as deployed, and says so in its summary.
+type deployOneAction struct {
+	name model.ManifestName
+	n    int
+}
+
+func (deployOneAction) Action() {}
+
+func (a deployOneAction) Summarize(s *ChangeSummary) {
+	s.ManifestStatuses.Add(types.NamespacedName{Name: a.name.String()})
+}
+
+// Starts a task run on every manifest, and finishes the previous one.
+type taskRunAction struct {
+	n int
+}
+
+func (taskRunAction) Action() {}
+
+var snapshotTestReducer = Reducer(func(ctx context.Context, s *EngineState, action Action) {
+	switch action := action.(type) {
+	case deployAllAction:
+		for _, mt := range s.Targets() {
+			deploy(mt.State, action.n)
+		}
+	case deployOneAction:
+		deploy(s.ManifestTargets[action.name].State, action.n)
+	case taskRunAction:
+		for _, mt := range s.Targets() {
+			runTask(mt.State, action.n)
+		}
+	case DoneAction:
+		s.FatalError = context.Canceled
+	}
+})
+
+// Mutates the manifest state in place, the way reducers do.
+func deploy(ms *ManifestState, n int) {
+	ms.LastSuccessfulDeployTime = time.Unix(int64(n), 0)
+	krs := ms.K8sRuntimeState()
+	krs.Pods[k8s.PodID(fmt.Sprintf("pod-%d", n))] = &v1alpha1.Pod{Name: fmt.Sprintf("pod-%d", n)}
+	ms.RuntimeState = krs
+}
+
+// Updates the task runs in place, the way the k8swatch reducers do.
+func runTask(ms *ManifestState, n int) {
+	krs := ms.K8sRuntimeState()
+	prevUID := types.UID(fmt.Sprintf("job-%d", n-1))
+	krs.Pods[k8s.PodID(prevUID)] = &v1alpha1.Pod{Name: string(prevUID), AncestorUID: string(prevUID)}
+	krs.UpdateTaskRunForPod(&v1alpha1.Pod{
+		Name:        string(prevUID),
+		AncestorUID: string(prevUID),
+		Phase:       string(corev1.PodSucceeded),
+	})
+	krs.StartTaskRun(corev1.ObjectReference{Kind: "Job", Name: "job", UID: types.UID(fmt.Sprintf("job-%d", n))}, time.Unix(int64(n), 0))
+	ms.RuntimeState = krs
+}
+
+func newSnapshotStore(names ...model.ManifestName) *Store {
+	st := NewStore(snapshotTestReducer, false, false)
+	state := st.LockMutableStateForTesting()
+	for _, name := range names {
+		m := model.Manifest{Name: name}.WithDeployTarget(model.K8sTarget{})
+		state.UpsertManifestTarget(NewManifestTarget(m))
+	}
+	st.UnlockMutableState()
+	return st
+}
+
+func TestSnapshotIsolatedFromReducer(t *testing.T) {
+	st := newSnapshotStore("fe")
+	f := newFixtureWithStore(t, st)
+	f.Start()
+
+	before := st.Snapshot()
+	assert.Nil(t, before.LogStore)
+	assert.Len(t, before.ManifestTargets["fe"].State.K8sRuntimeState().Pods, 0)
+
+	st.Dispatch(deployAllAction{n: 1})
+	st.Dispatch(DoneAction{})
+	f.WaitUntilDone()
+
+	after := st.Snapshot()
+	assert.Len(t, before.ManifestTargets["fe"].State.K8sRuntimeState().Pods, 0)
+	assert.True(t, before.ManifestTargets["fe"].State.LastSuccessfulDeployTime.IsZero())
+	assert.Len(t, after.ManifestTargets["fe"].State.K8sRuntimeState().Pods, 1)
+	assert.Equal(t, time.Unix(1, 0), after.ManifestTargets["fe"].State.LastSuccessfulDeployTime)
+}
+
+func TestSnapshotCopyOnWrite(t *testing.T) {
+	st := newSnapshotStore("fe", "be")
+	st.stateMu.Lock()
+	defer st.stateMu.Unlock()
+
+	first := st.snapshot.Load().(*EngineState)
+
+	// Logs don't touch manifests, so the copies are re-used.
+	st.publishSnapshot(ChangeSummary{Log: true})
+	second := st.snapshot.Load().(*EngineState)
+	assert.Same(t, first.ManifestTargets["fe"], second.ManifestTargets["fe"])
+	assert.Same(t, first.ManifestTargets["be"], second.ManifestTargets["be"])
+
+	// Only the manifest in the summary gets a new copy.
+	snapshotTestReducer(context.Background(), st.state, deployOneAction{name: "fe", n: 1})
+	summary := ChangeSummary{}
+	deployOneAction{name: "fe"}.Summarize(&summary)
+	st.publishSnapshot(summary)
+	third := st.snapshot.Load().(*EngineState)
+	assert.NotSame(t, second.ManifestTargets["fe"], third.ManifestTargets["fe"])
+	assert.Same(t, second.ManifestTargets["be"], third.ManifestTargets["be"])
+	assert.Len(t, third.ManifestTargets["fe"].State.K8sRuntimeState().Pods, 1)
+
+	// We don't know what legacy actions change, so everything gets a new copy.
+	st.publishSnapshot(LegacyChangeSummary())
+	fourth := st.snapshot.Load().(*EngineState)
+	assert.NotSame(t, third.ManifestTargets["fe"], fourth.ManifestTargets["fe"])
+	assert.NotSame(t, third.ManifestTargets["be"], fourth.ManifestTargets["be"])
+}
+
+// Readers should never see a batch that the reducer has only partly applied.
+//
+// Run with -race to check that readers don't share memory with the reducer.
+func TestSnapshotNeverHalfApplied(t *testing.T) {
+	names := []model.ManifestName{"a", "b", "c", "d"}
+	st := newSnapshotStore(names...)
+	f := newFixtureWithStore(t, st)
+	f.Start()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				state := st.Snapshot()
+				want := state.ManifestTargets["a"].State.LastSuccessfulDeployTime
+				for _, mt := range state.Targets() {
+					if !mt.State.LastSuccessfulDeployTime.Equal(want) {
+						errs <- fmt.Errorf("%s deployed at %s, but a deployed at %s",
+							mt.Manifest.Name, mt.State.LastSuccessfulDeployTime, want)
+						return
+					}
+					_ = mt.State.MostRecentPod()
+				}
+			}
+		}()
+	}
+
+	for n := 1; n <= 100; n++ {
+		st.Dispatch(deployAllAction{n: n})
+	}
+	st.Dispatch(DoneAction{})
+	f.WaitUntilDone()
+	cancel()
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	state := st.Snapshot()
+	assert.Equal(t, time.Unix(100, 0), state.ManifestTargets["d"].State.LastSuccessfulDeployTime)
+}
+
+// Task runs live in a slice that the reducers update in place.
+//
+// Run with -race to check that snapshots get their own copy.
+func TestSnapshotTaskRunsIsolatedFromReducer(t *testing.T) {
+	names := []model.ManifestName{"a", "b"}
+	st := newSnapshotStore(names...)
+	f := newFixtureWithStore(t, st)
+	f.Start()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				state := st.Snapshot()
+				for _, mt := range state.Targets() {
+					krs := mt.State.K8sRuntimeState()
+					_, _ = krs.LatestTaskRun()
+					for _, run := range krs.TaskRuns {
+						_ = run.Status
+					}
+				}
+			}
+		}()
+	}
+
+	before := st.Snapshot()
+	for n := 1; n <= 100; n++ {
+		st.Dispatch(taskRunAction{n: n})
+	}
+	st.Dispatch(DoneAction{})
+	f.WaitUntilDone()
+	cancel()
+	wg.Wait()
+
+	assert.Len(t, before.ManifestTargets["a"].State.K8sRuntimeState().TaskRuns, 0)
+
+	krs := st.Snapshot().ManifestTargets["a"].State.K8sRuntimeState()
+	latest, ok := krs.LatestTaskRun()
+	require.True(t, ok)
+	assert.Equal(t, types.UID("job-100"), latest.JobUID)
+	assert.Equal(t, TaskRunRunning, latest.Status)
+	assert.Equal(t, TaskRunSucceeded, krs.TaskRuns[len(krs.TaskRuns)-2].Status)
+}
+
+// Measures how long the reducer waits for the state lock while subscribers
+// read the state, with a synthetic load of log actions.
+//
+// Compare the lock-wait-ns/op of:
+//   go test ./internal/store -run=XXX -bench=BenchmarkReduceUnder
+func BenchmarkReduceUnderLockedReads(b *testing.B) {
+	benchmarkReduceUnderReads(b, func(st *Store) int {
+		state := st.RLockState()
+		defer st.RUnlockState()
+		return countPods(state)
+	})
+}
+
+func BenchmarkReduceUnderSnapshotReads(b *testing.B) {
+	benchmarkReduceUnderReads(b, func(st *Store) int {
+		return countPods(st.Snapshot())
+	})
+}
+
+func countPods(state EngineState) int {
+	count := 0
+	for _, mt := range state.Targets() {
+		for range mt.State.K8sRuntimeState().Pods {
+			count++
+		}
+	}
+	return count
+}
+
+func benchmarkReduceUnderReads(b *testing.B, read func(st *Store) int) {
+	var names []model.ManifestName
+	for i := 0; i < 150; i++ {
+		names = append(names, model.ManifestName(fmt.Sprintf("m%d", i)))
+	}
+	st := newSnapshotStore(names...)
+	state := st.LockMutableStateForTesting()
+	for i, mt := range state.Targets() {
+		deploy(mt.State, i)
+	}
+	st.UnlockMutableState()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				_ = read(st)
+			}
+		}()
+	}
+
+	log := NewLogAction("m0", "span", logger.InfoLvl, nil, []byte("hello world\n"))
+	var wait time.Duration
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		start := time.Now()
+		st.stateMu.Lock()
+		wait += time.Since(start)
+
+		st.state.LogStore.Append(log, st.state.Secrets)
+		st.publishSnapshot(ChangeSummary{Log: true})
+		st.stateMu.Unlock()
+	}
+	b.StopTimer()
+	b.ReportMetric(float64(wait.Nanoseconds())/float64(b.N), "lock-wait-ns/op")
+
+	cancel()
+	wg.Wait()
+}
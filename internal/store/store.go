@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
@@ -21,6 +22,13 @@ type RStore interface {
 	RLockState() EngineState
 	RUnlockState()
 	StateMutex() *sync.RWMutex
+
+	// The state as of the end of the most recent batch of actions.
+	//
+	// Doesn't take a lock, so it's cheaper than RLockState for subscribers
+	// that walk lots of manifests. The snapshot is read-only, and doesn't
+	// include logs.
+	Snapshot() EngineState
 }
 
 // A central state store, modeled after the Reactive programming UX pattern.
@@ -37,13 +45,22 @@ type Store struct {
 	stateMu     sync.RWMutex
 	reduce      Reducer
 	logActions  bool
+	journal     *actionJournal
+	batchLogs   bool
+
+	// The latest *EngineState snapshot, published after each batch of actions.
+	snapshot atomic.Value
 
 	// TODO(nick): Define Subscribers and Reducers.
 	// The actionChan is an intermediate representation to make the transition easier.
 }
 
-func NewStore(reducer Reducer, logActions LogActionsFlag) *Store {
-	return &Store{
+func NewStore(reducer Reducer, logActions LogActionsFlag, journalActions ActionJournalFlag) *Store {
+	var journal *actionJournal
+	if journalActions {
+		journal = newActionJournal(actionJournalSize)
+	}
+	s := &Store{
 		sleeper:     DefaultSleeper(),
 		state:       NewState(),
 		reduce:      reducer,
@@ -51,7 +68,11 @@ func NewStore(reducer Reducer, logActions LogActionsFlag) *Store {
 		actionCh:    make(chan []Action),
 		subscribers: &subscriberList{},
 		logActions:  bool(logActions),
+		journal:     journal,
+		batchLogs:   true,
 	}
+	s.publishSnapshot(LegacyChangeSummary())
+	return s
 }
 
 // Returns a Store with a fake reducer that saves observed actions and makes
@@ -80,7 +101,7 @@ func NewStoreWithFakeReducer() (st *Store, getActions func() []Action) {
 		defer mu.Unlock()
 		return append([]Action{}, actions...)
 	}
-	return NewStore(reducer, false), getActions
+	return NewStore(reducer, false, false), getActions
 }
 
 func (s *Store) StateMutex() *sync.RWMutex {
@@ -118,14 +139,37 @@ func (s *Store) LockMutableStateForTesting() *EngineState {
 }
 
 func (s *Store) UnlockMutableState() {
+	s.publishSnapshot(LegacyChangeSummary())
 	s.stateMu.Unlock()
 }
 
+func (s *Store) Snapshot() EngineState {
+	return *(s.snapshot.Load().(*EngineState))
+}
+
+// Copies the state for lock-free readers.
+//
+// stateMu must be held before calling.
+func (s *Store) publishSnapshot(summary ChangeSummary) {
+	prev, _ := s.snapshot.Load().(*EngineState)
+	s.snapshot.Store(s.state.snapshot(prev, summary))
+}
+
 func (s *Store) Dispatch(action Action) {
 	s.actionQueue.add(action)
 	go s.drainActions()
 }
 
+// The most recent actions the store reduced, oldest first.
+//
+// Returns false if the journal is turned off.
+func (s *Store) ActionJournal() ([]JournalEntry, bool) {
+	if s.journal == nil {
+		return nil, false
+	}
+	return s.journal.Entries(), true
+}
+
 func (s *Store) Close() {
 	close(s.actionCh)
 }
@@ -169,8 +213,17 @@ func (s *Store) Loop(ctx context.Context) error {
 					oldState = s.cheapCopyState()
 				}
 
+				var start time.Time
+				if s.journal != nil {
+					start = time.Now()
+				}
+
 				s.reduce(ctx, s.state, action)
 
+				if s.journal != nil {
+					s.journal.record(action, start, time.Since(start), s.state.Secrets)
+				}
+
 				if summarizer, ok := action.(Summarizer); ok {
 					summarizer.Summarize(&summary)
 				} else {
@@ -194,6 +247,10 @@ func (s *Store) Loop(ctx context.Context) error {
 				summary.Log = true
 			}
 
+			// Publish the whole batch at once, so that subscribers
+			// never see a half-applied batch.
+			s.publishSnapshot(summary)
+
 			s.stateMu.Unlock()
 			hasStateLock = false
 		}
@@ -243,6 +300,9 @@ func (s *Store) drainActions() {
 	defer s.mu.Unlock()
 
 	actions := s.actionQueue.drain()
+	if s.batchLogs {
+		actions = coalesceLogActions(actions)
+	}
 	if len(actions) > 0 {
 		s.actionCh <- actions
 	}
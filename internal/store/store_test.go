@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/tilt-dev/tilt/pkg/logger"
 
@@ -115,8 +116,11 @@ type fixture struct {
 }
 
 func newFixture(t *testing.T) fixture {
+	return newFixtureWithStore(t, NewStore(TestReducer, LogActionsFlag(false), ActionJournalFlag(false)))
+}
+
+func newFixtureWithStore(t *testing.T, st *Store) fixture {
 	ctx, cancel := context.WithCancel(context.Background())
-	st := NewStore(TestReducer, LogActionsFlag(false))
 	return fixture{
 		t:      t,
 		store:  st,
@@ -157,6 +161,12 @@ type SneakyLoggingAction struct {
 
 func (SneakyLoggingAction) Action() {}
 
+// An action that takes the reducer a while.
+type SlowAction struct {
+}
+
+func (SlowAction) Action() {}
+
 type DoneAction struct {
 }
 
@@ -168,6 +178,8 @@ var TestReducer = Reducer(func(ctx context.Context, s *EngineState, action Actio
 		s.CompletedBuildCount++
 	case SneakyLoggingAction:
 		s.LogStore.Append(NewLogAction("foo", "foo", logger.ErrorLvl, nil, []byte("hi")), s.Secrets)
+	case SlowAction:
+		time.Sleep(SlowReduceThreshold + 10*time.Millisecond)
 	case DoneAction:
 		s.FatalError = context.Canceled
 	}
@@ -57,6 +57,22 @@ type ChangeSummary struct {
 	// Cmds with their specs changed.
 	CmdSpecs ChangeSet
 
+	// Cmds with their statuses changed.
+	CmdStatuses ChangeSet
+
+	// Manifests with their specs changed (e.g., a trigger mode override).
+	// Keyed by manifest name.
+	ManifestSpecs ChangeSet
+
+	// Manifests with their build or runtime status changed.
+	// Keyed by manifest name.
+	ManifestStatuses ChangeSet
+
+	ConfigMaps ChangeSet
+
+	// True if a manifest was added to or removed from the trigger queue.
+	TriggerQueue bool
+
 	// Sessions that have changed.
 	Sessions ChangeSet
 
@@ -72,10 +88,43 @@ func (s ChangeSummary) IsLogOnly() bool {
 	return cmp.Equal(s, ChangeSummary{Log: true})
 }
 
+// Whether any manifest's spec or status may have changed.
+//
+// Subscribers that only read manifests can skip changes where this is false,
+// without locking the state.
+func (s ChangeSummary) ManifestsChanged() bool {
+	return s.Legacy || !s.ManifestSpecs.Empty() || !s.ManifestStatuses.Empty()
+}
+
+// Whether the trigger queue may have changed.
+func (s ChangeSummary) TriggerQueueChanged() bool {
+	return s.Legacy || s.TriggerQueue
+}
+
+// Whether any Cmd's spec or status may have changed.
+func (s ChangeSummary) CmdsChanged() bool {
+	return s.Legacy || !s.CmdSpecs.Empty() || !s.CmdStatuses.Empty()
+}
+
+// Whether any ConfigMap may have changed.
+func (s ChangeSummary) ConfigMapsChanged() bool {
+	return s.Legacy || !s.ConfigMaps.Empty()
+}
+
+// Whether any UIResource may have changed.
+func (s ChangeSummary) UIResourcesChanged() bool {
+	return s.Legacy || !s.UIResources.Empty()
+}
+
 func (s *ChangeSummary) Add(other ChangeSummary) {
 	s.Legacy = s.Legacy || other.Legacy
 	s.Log = s.Log || other.Log
+	s.TriggerQueue = s.TriggerQueue || other.TriggerQueue
 	s.CmdSpecs.AddAll(other.CmdSpecs)
+	s.CmdStatuses.AddAll(other.CmdStatuses)
+	s.ManifestSpecs.AddAll(other.ManifestSpecs)
+	s.ManifestStatuses.AddAll(other.ManifestStatuses)
+	s.ConfigMaps.AddAll(other.ConfigMaps)
 	s.Sessions.AddAll(other.Sessions)
 	s.UISessions.AddAll(other.UISessions)
 	s.UIResources.AddAll(other.UIResources)
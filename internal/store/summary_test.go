@@ -0,0 +1,56 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestSummaryHelpers(t *testing.T) {
+	nn := types.NamespacedName{Name: "fe"}
+
+	empty := ChangeSummary{}
+	assert.False(t, empty.ManifestsChanged())
+	assert.False(t, empty.TriggerQueueChanged())
+	assert.False(t, empty.CmdsChanged())
+	assert.False(t, empty.ConfigMapsChanged())
+	assert.False(t, empty.UIResourcesChanged())
+
+	// We don't know what a legacy action changed, so assume it changed everything.
+	legacy := LegacyChangeSummary()
+	assert.True(t, legacy.ManifestsChanged())
+	assert.True(t, legacy.TriggerQueueChanged())
+	assert.True(t, legacy.CmdsChanged())
+	assert.True(t, legacy.ConfigMapsChanged())
+	assert.True(t, legacy.UIResourcesChanged())
+
+	assert.True(t, ChangeSummary{ManifestSpecs: NewChangeSet(nn)}.ManifestsChanged())
+	assert.True(t, ChangeSummary{ManifestStatuses: NewChangeSet(nn)}.ManifestsChanged())
+	assert.False(t, ChangeSummary{ManifestStatuses: NewChangeSet(nn)}.TriggerQueueChanged())
+	assert.True(t, ChangeSummary{CmdStatuses: NewChangeSet(nn)}.CmdsChanged())
+	assert.False(t, ChangeSummary{Log: true}.ManifestsChanged())
+}
+
+func TestSummaryAdd(t *testing.T) {
+	s := ChangeSummary{Log: true}
+	s.Add(ChangeSummary{
+		TriggerQueue:     true,
+		ManifestStatuses: NewChangeSet(types.NamespacedName{Name: "fe"}),
+		ConfigMaps:       NewChangeSet(types.NamespacedName{Name: "fe-disable"}),
+	})
+	s.Add(ChangeSummary{
+		ManifestSpecs: NewChangeSet(types.NamespacedName{Name: "be"}),
+		CmdStatuses:   NewChangeSet(types.NamespacedName{Name: "fe-serve-1"}),
+	})
+
+	assert.Equal(t, ChangeSummary{
+		Log:              true,
+		TriggerQueue:     true,
+		ManifestSpecs:    NewChangeSet(types.NamespacedName{Name: "be"}),
+		ManifestStatuses: NewChangeSet(types.NamespacedName{Name: "fe"}),
+		CmdStatuses:      NewChangeSet(types.NamespacedName{Name: "fe-serve-1"}),
+		ConfigMaps:       NewChangeSet(types.NamespacedName{Name: "fe-disable"}),
+	}, s)
+	assert.False(t, s.IsLogOnly())
+}
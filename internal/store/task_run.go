@@ -0,0 +1,172 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tilt-dev/tilt/internal/store/k8sconv"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// How many runs of a task we remember for each resource.
+const TaskRunHistoryLimit = 10
+
+type TaskRunStatus string
+
+const (
+	TaskRunRunning   TaskRunStatus = "running"
+	TaskRunSucceeded TaskRunStatus = "succeeded"
+	TaskRunFailed    TaskRunStatus = "failed"
+)
+
+// A Kubernetes resource is a task if it deploys a Job that we wait to
+// succeed (i.e., pod_readiness='succeeded').
+//
+// Every deploy of a task creates a fresh Job, and we track each one as
+// a separate run, so that re-running the task doesn't show the result of
+// the last run.
+type TaskRun struct {
+	// The Job we created for this run.
+	JobName string
+	JobUID  types.UID
+
+	// When we created the Job, and when its pod finished.
+	StartTime  time.Time
+	FinishTime time.Time
+
+	Status TaskRunStatus
+
+	// The exit code of the first container that failed.
+	// 0 if the run hasn't failed.
+	ExitCode int32
+
+	// Why the run failed, if it did.
+	Error string
+}
+
+func (r TaskRun) Duration() time.Duration {
+	if r.FinishTime.IsZero() {
+		return time.Since(r.StartTime)
+	}
+	return r.FinishTime.Sub(r.StartTime)
+}
+
+// Finds the Job we deployed for a task, if any.
+func TaskJobRef(m model.Manifest, filter *k8sconv.KubernetesApplyFilter) (v1.ObjectReference, bool) {
+	if m.PodReadinessMode() != model.PodReadinessSucceeded || filter == nil {
+		return v1.ObjectReference{}, false
+	}
+	for _, ref := range filter.DeployedRefs {
+		if ref.Kind == "Job" && ref.UID != "" {
+			return ref, true
+		}
+	}
+	return v1.ObjectReference{}, false
+}
+
+func (s K8sRuntimeState) IsTask() bool {
+	return len(s.TaskRuns) > 0
+}
+
+func (s K8sRuntimeState) LatestTaskRun() (TaskRun, bool) {
+	if len(s.TaskRuns) == 0 {
+		return TaskRun{}, false
+	}
+	return s.TaskRuns[len(s.TaskRuns)-1], true
+}
+
+// Records a new run for a Job we just created. A re-apply that didn't
+// create a new Job (i.e., the UID didn't change) is not a new run.
+func (s *K8sRuntimeState) StartTaskRun(job v1.ObjectReference, startTime time.Time) {
+	if latest, ok := s.LatestTaskRun(); ok && latest.JobUID == job.UID {
+		return
+	}
+
+	s.TaskRuns = append(s.TaskRuns, TaskRun{
+		JobName:   job.Name,
+		JobUID:    job.UID,
+		StartTime: startTime,
+		Status:    TaskRunRunning,
+	})
+	if len(s.TaskRuns) > TaskRunHistoryLimit {
+		s.TaskRuns = s.TaskRuns[len(s.TaskRuns)-TaskRunHistoryLimit:]
+	}
+}
+
+// Updates the latest run from one of its Job's pods.
+//
+// Pods from older runs are ignored. If the Job retries a failed pod,
+// the run follows the newest pod, so a run can go from failed
+// back to running.
+//
+// Returns true if the run changed.
+func (s *K8sRuntimeState) UpdateTaskRunForPod(pod *v1alpha1.Pod) bool {
+	if len(s.TaskRuns) == 0 {
+		return false
+	}
+	run := &s.TaskRuns[len(s.TaskRuns)-1]
+	if pod.AncestorUID == "" || types.UID(pod.AncestorUID) != run.JobUID {
+		return false
+	}
+
+	// Only follow the newest pod of the Job.
+	for _, other := range s.Pods {
+		if other.AncestorUID == pod.AncestorUID && other.CreatedAt.After(pod.CreatedAt.Time) {
+			return false
+		}
+	}
+
+	old := *run
+	switch v1.PodPhase(pod.Phase) {
+	case v1.PodSucceeded:
+		run.Status = TaskRunSucceeded
+		run.FinishTime = podFinishTime(pod, run.FinishTime)
+		run.ExitCode = 0
+		run.Error = ""
+	case v1.PodFailed:
+		run.Status = TaskRunFailed
+		run.FinishTime = podFinishTime(pod, run.FinishTime)
+		run.ExitCode, run.Error = podFailure(run.JobName, pod)
+	default:
+		run.Status = TaskRunRunning
+		run.FinishTime = time.Time{}
+		run.ExitCode = 0
+		run.Error = ""
+	}
+	return old != *run
+}
+
+func podFinishTime(pod *v1alpha1.Pod, existing time.Time) time.Time {
+	var finish time.Time
+	for _, c := range AllPodContainers(*pod) {
+		t := c.State.Terminated
+		if t != nil && t.FinishedAt.Time.After(finish) {
+			finish = t.FinishedAt.Time
+		}
+	}
+	if !finish.IsZero() {
+		return finish
+	}
+	if !existing.IsZero() {
+		return existing
+	}
+	return time.Now()
+}
+
+func podFailure(jobName string, pod *v1alpha1.Pod) (int32, string) {
+	for _, c := range AllPodContainers(*pod) {
+		t := c.State.Terminated
+		if t != nil && t.ExitCode != 0 {
+			return t.ExitCode, fmt.Sprintf("Job %s failed: container %s exited with code %d",
+				jobName, c.Name, t.ExitCode)
+		}
+	}
+	if pod.Status != "" {
+		return 0, fmt.Sprintf("Job %s failed: %s", jobName, pod.Status)
+	}
+	return 0, fmt.Sprintf("Job %s failed", jobName)
+}
@@ -0,0 +1,138 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestStartTaskRunDedupesByJobUID(t *testing.T) {
+	s := newTaskRuntimeState()
+	start := time.Now()
+
+	s.StartTaskRun(jobRef("job-1"), start)
+	s.StartTaskRun(jobRef("job-1"), start.Add(time.Second))
+	require.Len(t, s.TaskRuns, 1)
+	assert.Equal(t, start, s.TaskRuns[0].StartTime)
+
+	s.StartTaskRun(jobRef("job-2"), start.Add(2*time.Second))
+	require.Len(t, s.TaskRuns, 2)
+	run, _ := s.LatestTaskRun()
+	assert.Equal(t, types.UID("job-2"), run.JobUID)
+	assert.Equal(t, TaskRunRunning, run.Status)
+}
+
+func TestStartTaskRunTrimsHistory(t *testing.T) {
+	s := newTaskRuntimeState()
+	for i := 0; i < TaskRunHistoryLimit+3; i++ {
+		s.StartTaskRun(jobRef(fmt.Sprintf("job-%d", i)), time.Now())
+	}
+	require.Len(t, s.TaskRuns, TaskRunHistoryLimit)
+	assert.Equal(t, types.UID("job-3"), s.TaskRuns[0].JobUID)
+}
+
+func TestUpdateTaskRunIgnoresOldJobPods(t *testing.T) {
+	s := newTaskRuntimeState()
+	s.StartTaskRun(jobRef("job-1"), time.Now())
+	s.StartTaskRun(jobRef("job-2"), time.Now())
+
+	pod := taskPod("pod-1", "job-1", v1.PodSucceeded, 0)
+	s.Pods[k8s.PodID(pod.Name)] = pod
+	assert.False(t, s.UpdateTaskRunForPod(pod))
+
+	run, _ := s.LatestTaskRun()
+	assert.Equal(t, TaskRunRunning, run.Status)
+	assert.Equal(t, v1alpha1.RuntimeStatusPending, s.RuntimeStatus())
+}
+
+func TestUpdateTaskRunFailed(t *testing.T) {
+	s := newTaskRuntimeState()
+	s.StartTaskRun(jobRef("job-1"), time.Now())
+
+	pod := taskPod("pod-1", "job-1", v1.PodFailed, 3)
+	s.Pods[k8s.PodID(pod.Name)] = pod
+	assert.True(t, s.UpdateTaskRunForPod(pod))
+
+	run, _ := s.LatestTaskRun()
+	assert.Equal(t, TaskRunFailed, run.Status)
+	assert.Equal(t, int32(3), run.ExitCode)
+	assert.Equal(t, "Job pi-job-1 failed: container pi exited with code 3", run.Error)
+	assert.Equal(t, v1alpha1.RuntimeStatusError, s.RuntimeStatus())
+	assert.EqualError(t, s.RuntimeStatusError(), run.Error)
+}
+
+func TestUpdateTaskRunFollowsNewestPod(t *testing.T) {
+	s := newTaskRuntimeState()
+	s.StartTaskRun(jobRef("job-1"), time.Now())
+
+	failed := taskPod("pod-1", "job-1", v1.PodFailed, 1)
+	s.Pods[k8s.PodID(failed.Name)] = failed
+	s.UpdateTaskRunForPod(failed)
+
+	retry := taskPod("pod-2", "job-1", v1.PodRunning, 0)
+	retry.CreatedAt = metav1.NewTime(failed.CreatedAt.Add(time.Second))
+	s.Pods[k8s.PodID(retry.Name)] = retry
+	assert.True(t, s.UpdateTaskRunForPod(retry))
+
+	// An update to the older pod doesn't clobber the retry.
+	assert.False(t, s.UpdateTaskRunForPod(failed))
+
+	run, _ := s.LatestTaskRun()
+	assert.Equal(t, TaskRunRunning, run.Status)
+	assert.Equal(t, "", run.Error)
+}
+
+func TestRerunTaskResetsStatus(t *testing.T) {
+	s := newTaskRuntimeState()
+	s.StartTaskRun(jobRef("job-1"), time.Now())
+
+	pod := taskPod("pod-1", "job-1", v1.PodSucceeded, 0)
+	s.Pods[k8s.PodID(pod.Name)] = pod
+	s.UpdateTaskRunForPod(pod)
+	assert.Equal(t, v1alpha1.RuntimeStatusOK, s.RuntimeStatus())
+
+	s.StartTaskRun(jobRef("job-2"), time.Now())
+	assert.Equal(t, v1alpha1.RuntimeStatusPending, s.RuntimeStatus())
+	assert.Len(t, s.TaskRuns, 2)
+	assert.Equal(t, TaskRunSucceeded, s.TaskRuns[0].Status)
+}
+
+func newTaskRuntimeState() *K8sRuntimeState {
+	m := model.Manifest{Name: "pi"}.WithDeployTarget(model.K8sTarget{
+		PodReadinessMode: model.PodReadinessSucceeded,
+	})
+	s := NewK8sRuntimeState(m)
+	s.HasEverDeployedSuccessfully = true
+	return &s
+}
+
+func jobRef(uid string) v1.ObjectReference {
+	return v1.ObjectReference{Kind: "Job", Name: "pi-" + uid, UID: types.UID(uid)}
+}
+
+func taskPod(name, jobUID string, phase v1.PodPhase, exitCode int32) *v1alpha1.Pod {
+	pod := &v1alpha1.Pod{
+		Name:        name,
+		CreatedAt:   metav1.NewTime(time.Now()),
+		Phase:       string(phase),
+		AncestorUID: jobUID,
+		Containers:  []v1alpha1.Container{{Name: "pi"}},
+	}
+	if phase == v1.PodSucceeded || phase == v1.PodFailed {
+		pod.Containers[0].State.Terminated = &v1alpha1.ContainerStateTerminated{
+			ExitCode:   exitCode,
+			FinishedAt: metav1.NewTime(time.Now()),
+		}
+	}
+	return pod
+}
@@ -58,6 +58,13 @@ func (s *TestingStore) RUnlockState() {
 	s.stateMu.RUnlock()
 }
 
+// The testing store has no batches, so every snapshot is a fresh copy.
+func (s *TestingStore) Snapshot() EngineState {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	return *(s.state.snapshot(nil, LegacyChangeSummary()))
+}
+
 func (s *TestingStore) Dispatch(action Action) {
 	s.actionsMu.Lock()
 	defer s.actionsMu.Unlock()
@@ -2,6 +2,8 @@ package tiltfiles
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -18,25 +20,100 @@ type SetTiltfileArgsAction struct {
 
 func (SetTiltfileArgsAction) Action() {}
 
+// The Tiltfile failed to load with new args.
+type ArgsLoadError struct {
+	Args    []string
+	LoadErr string
+
+	// If the Tiltfile had loaded successfully before, Tilt switches back
+	// to the args from that load.
+	Restored     bool
+	RestoredArgs []string
+}
+
+func (e ArgsLoadError) Error() string {
+	if e.Restored {
+		return fmt.Sprintf("Tiltfile failed to load with args %v. Still using args %v.\n%s", e.Args, e.RestoredArgs, e.LoadErr)
+	}
+	return fmt.Sprintf("Tiltfile failed to load with args %v.\n%s", e.Args, e.LoadErr)
+}
+
+func GetTiltfileArgs(ctx context.Context, client client.Client) ([]string, error) {
+	var tf v1alpha1.Tiltfile
+	err := client.Get(ctx, types.NamespacedName{Name: model.MainTiltfileManifestName.String()}, &tf)
+	if err != nil {
+		return nil, err
+	}
+	return tf.Spec.Args, nil
+}
+
 func SetTiltfileArgs(ctx context.Context, st store.RStore, client client.Client, args []string) error {
+	_, err := setTiltfileArgs(ctx, st, client, args)
+	return err
+}
+
+// Sets the Tiltfile args, then waits for the Tiltfile to reload with them.
+//
+// If the Tiltfile fails to load with the new args, returns an ArgsLoadError.
+func SetTiltfileArgsAndWait(ctx context.Context, st store.RStore, client client.Client, args []string) error {
+	// The apiserver stores timestamps with microsecond precision.
+	start := time.Now().Truncate(time.Microsecond)
+	changed, err := setTiltfileArgs(ctx, st, client, args)
+	if err != nil || !changed {
+		return err
+	}
+
+	nn := types.NamespacedName{Name: model.MainTiltfileManifestName.String()}
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		var tf v1alpha1.Tiltfile
+		err := client.Get(ctx, nn, &tf)
+		if err != nil {
+			return err
+		}
+
+		// Wait for a load that started after we changed the args.
+		term := tf.Status.Terminated
+		if term != nil && !term.StartedAt.Time.Before(start) {
+			if term.Error == "" {
+				return nil
+			}
+			loadErr := ArgsLoadError{Args: args, LoadErr: term.Error}
+			if !apicmp.DeepEqual(tf.Spec.Args, args) {
+				loadErr.Restored = true
+				loadErr.RestoredArgs = tf.Spec.Args
+			}
+			return loadErr
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func setTiltfileArgs(ctx context.Context, st store.RStore, client client.Client, args []string) (bool, error) {
 	nn := types.NamespacedName{Name: model.MainTiltfileManifestName.String()}
 	var tf v1alpha1.Tiltfile
 	err := client.Get(ctx, nn, &tf)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	if apicmp.DeepEqual(tf.Spec.Args, args) {
-		return nil
+		return false, nil
 	}
 
 	update := tf.DeepCopy()
 	update.Spec.Args = args
 	err = client.Update(ctx, update)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	st.Dispatch(SetTiltfileArgsAction{args})
-	return nil
+	return true, nil
 }
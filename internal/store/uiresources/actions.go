@@ -1,6 +1,11 @@
 package uiresources
 
-import "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+import (
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+)
 
 type UIResourceUpsertAction struct {
 	UIResource *v1alpha1.UIResource
@@ -12,6 +17,12 @@ func NewUIResourceUpsertAction(obj *v1alpha1.UIResource) UIResourceUpsertAction
 
 func (UIResourceUpsertAction) Action() {}
 
+var _ store.Summarizer = UIResourceUpsertAction{}
+
+func (a UIResourceUpsertAction) Summarize(s *store.ChangeSummary) {
+	s.UIResources.Add(types.NamespacedName{Name: a.UIResource.Name})
+}
+
 type UIResourceDeleteAction struct {
 	Name string
 }
@@ -21,3 +32,9 @@ func NewUIResourceDeleteAction(n string) UIResourceDeleteAction {
 }
 
 func (UIResourceDeleteAction) Action() {}
+
+var _ store.Summarizer = UIResourceDeleteAction{}
+
+func (a UIResourceDeleteAction) Summarize(s *store.ChangeSummary) {
+	s.UIResources.Add(types.NamespacedName{Name: a.Name})
+}
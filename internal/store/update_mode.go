@@ -0,0 +1,47 @@
+package store
+
+import (
+	"fmt"
+)
+
+// The update mode Tilt is using, and the inputs that Tilt used to choose it.
+//
+// Tilt picks a mode at startup from the --update-mode flag and the cluster.
+// Users can switch to another compatible mode while Tilt is running.
+type UpdateModeState struct {
+	// The mode in use. Empty until Tilt records its startup decision.
+	Mode string `json:"mode"`
+
+	// The value of the --update-mode flag.
+	Flag string `json:"flag"`
+
+	KubeContext string `json:"kubeContext"`
+
+	// The Docker daemon that Tilt builds images with for the cluster.
+	DockerHost string `json:"dockerHost"`
+
+	// Whether the local Docker daemon builds images straight into the cluster.
+	WillBuildToKubeContext bool `json:"willBuildToKubeContext"`
+
+	// The modes that work with this cluster.
+	CompatibleModes []string `json:"compatibleModes"`
+
+	// The modes that don't work with this cluster, and why.
+	IncompatibleModes map[string]string `json:"incompatibleModes,omitempty"`
+}
+
+// Returns an error explaining why we can't switch to the given mode.
+func (s UpdateModeState) ValidateSwitch(mode string) error {
+	if s.Mode == "" {
+		return fmt.Errorf("update mode hasn't been chosen yet")
+	}
+	if reason, ok := s.IncompatibleModes[mode]; ok {
+		return fmt.Errorf("can't switch to update mode %q: %s", mode, reason)
+	}
+	for _, m := range s.CompatibleModes {
+		if m == mode {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown update mode %q. Valid values: %v", mode, s.CompatibleModes)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/tilt-dev/tilt/internal/container"
 	"github.com/tilt-dev/tilt/internal/controllers/apis/liveupdate"
 	"github.com/tilt-dev/tilt/internal/k8s"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
@@ -23,6 +24,13 @@ import (
 // - Any filepaths in the manifest are scoped to the
 //   test directory (e.g., we're not trying to watch random directories
 //   outside the test environment).
+// - The manifest passes the same validation that the Tiltfile loader runs,
+//   so a test can't accidentally depend on a manifest that users can't create.
+
+const defaultDockerfile = `
+FROM busybox
+ADD . .
+`
 
 type ManifestBuilder struct {
 	f    Fixture
@@ -32,6 +40,7 @@ type ManifestBuilder struct {
 	k8sYAML            string
 	k8sPodSelectors    []labels.Set
 	k8sImageLocators   []v1alpha1.KubernetesImageLocator
+	dcServiceName      string
 	dcConfigPaths      []string
 	localCmd           string
 	localServeCmd      string
@@ -91,13 +100,29 @@ func (b ManifestBuilder) WithK8sPodSelectors(podSelectors []labels.Set) Manifest
 	return b
 }
 
-func (b ManifestBuilder) WithDockerCompose() ManifestBuilder {
-	b.dcConfigPaths = []string{b.f.JoinPath("docker-compose.yml")}
+// Deploys the manifest as a Docker Compose service.
+//
+// Config paths are relative to the fixture directory.
+// If there are none, uses docker-compose.yml.
+func (b ManifestBuilder) WithDockerCompose(serviceName string, configPaths []string) ManifestBuilder {
+	if len(configPaths) == 0 {
+		configPaths = []string{"docker-compose.yml"}
+	}
+
+	b.dcServiceName = serviceName
+	b.dcConfigPaths = nil
+	for _, p := range configPaths {
+		b.dcConfigPaths = append(b.dcConfigPaths, b.f.JoinPath(p))
+	}
 	return b
 }
 
-func (b ManifestBuilder) WithLocalResource(cmd string, deps []string) ManifestBuilder {
-	b.localCmd = cmd
+// Deploys the manifest as a local resource.
+//
+// Either command may be empty, but not both.
+func (b ManifestBuilder) WithLocalResource(updateCmd string, serveCmd string, deps []string) ManifestBuilder {
+	b.localCmd = updateCmd
+	b.localServeCmd = serveCmd
 	b.localDeps = deps
 	return b
 }
@@ -117,9 +142,19 @@ func (b ManifestBuilder) WithTriggerMode(tm model.TriggerMode) ManifestBuilder {
 	return b
 }
 
-func (b ManifestBuilder) WithImageTarget(iTarg model.ImageTarget) ManifestBuilder {
-	b.iTargets = append(b.iTargets, iTarg)
-	return b
+// Adds a docker_build() of the given image, with the fixture-relative
+// directory as its build context.
+func (b ManifestBuilder) WithImageTarget(ref string, contextDir string) ManifestBuilder {
+	named, err := container.ParseNamed(ref)
+	if err != nil {
+		b.f.T().Fatalf("WithImageTarget: %v", err)
+	}
+
+	iTarg := model.MustNewImageTarget(container.NewRefSelector(named)).WithBuildDetails(model.DockerBuild{
+		Dockerfile: defaultDockerfile,
+		BuildPath:  b.f.JoinPath(contextDir),
+	})
+	return b.WithImageTargets(iTarg)
 }
 
 func (b ManifestBuilder) WithImageTargets(iTargs ...model.ImageTarget) ManifestBuilder {
@@ -127,7 +162,29 @@ func (b ManifestBuilder) WithImageTargets(iTargs ...model.ImageTarget) ManifestB
 	return b
 }
 
-func (b ManifestBuilder) WithLiveUpdate(lu v1alpha1.LiveUpdateSpec) ManifestBuilder {
+// Adds a live_update() to the first image target.
+//
+// Sync paths and fallback paths are relative to the image's build context.
+func (b ManifestBuilder) WithLiveUpdate(syncs []v1alpha1.LiveUpdateSync, runs []v1alpha1.LiveUpdateExec, fallbackPaths []string) ManifestBuilder {
+	if len(b.iTargets) == 0 {
+		b.f.T().Fatalf("WithLiveUpdate: manifest %s has no image targets", b.name)
+	}
+
+	basePath := b.f.Path()
+	if db, ok := b.iTargets[0].BuildDetails.(model.DockerBuild); ok {
+		basePath = db.BuildPath
+	}
+
+	return b.WithLiveUpdateAtIndex(v1alpha1.LiveUpdateSpec{
+		BasePath:  basePath,
+		Syncs:     syncs,
+		Execs:     runs,
+		StopPaths: fallbackPaths,
+	}, 0)
+}
+
+// Adds a fully-specified live update to the first image target.
+func (b ManifestBuilder) WithLiveUpdateSpec(lu v1alpha1.LiveUpdateSpec) ManifestBuilder {
 	return b.WithLiveUpdateAtIndex(lu, 0)
 }
 
@@ -153,6 +210,8 @@ func (b ManifestBuilder) WithResourceDeps(deps ...string) ManifestBuilder {
 func (b ManifestBuilder) Build() model.Manifest {
 	var m model.Manifest
 
+	b.validateTargets()
+
 	// Adjust images to use the live update reconciler or the BuildAndDeployer.
 	// Currently,
 	for index, iTarget := range b.iTargets {
@@ -191,7 +250,7 @@ func (b ManifestBuilder) Build() model.Manifest {
 			model.Manifest{Name: b.name, ResourceDependencies: rds},
 			model.DockerComposeTarget{
 				Spec: model.DockerComposeUpSpec{
-					Service: string(b.name),
+					Service: b.dcServiceName,
 					Project: model.DockerComposeProject{
 						ConfigPaths: b.dcConfigPaths,
 					},
@@ -227,9 +286,42 @@ func (b ManifestBuilder) Build() model.Manifest {
 	m = m.WithTriggerMode(b.triggerMode)
 	err := m.InferLiveUpdateSelectors()
 	require.NoError(b.f.T(), err)
+
+	err = m.Validate()
+	require.NoError(b.f.T(), err, "invalid manifest %s", b.name)
 	return m
 }
 
+// Fails the test on combinations of targets that the Tiltfile can't produce.
+func (b ManifestBuilder) validateTargets() {
+	t := b.f.T()
+	t.Helper()
+
+	deployTargets := 0
+	if b.k8sYAML != "" {
+		deployTargets++
+	}
+	if len(b.dcConfigPaths) > 0 {
+		deployTargets++
+	}
+	isLocal := b.localCmd != "" || b.localServeCmd != ""
+	if isLocal {
+		deployTargets++
+	}
+	if deployTargets > 1 {
+		t.Fatalf("manifest %s has more than one deploy target", b.name)
+	}
+
+	if isLocal && len(b.iTargets) > 0 {
+		t.Fatalf("manifest %s: local resources can't have image targets", b.name)
+	}
+
+	if len(b.dcConfigPaths) > 0 {
+		err := model.ValidateDockerComposeImageTargets(b.iTargets)
+		require.NoError(t, err, "invalid manifest %s", b.name)
+	}
+}
+
 type Fixture interface {
 	T() testing.TB
 	Path() string
@@ -0,0 +1,164 @@
+package manifestbuilder
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/internal/container"
+	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
+	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestLocalResource(t *testing.T) {
+	f := tempdir.NewTempDirFixture(t)
+
+	m := New(f, "fe").WithLocalResource("make", "./server", []string{f.JoinPath("src")}).Build()
+
+	lt := m.LocalTarget()
+	assert.Equal(t, []string{"sh", "-c", "make"}, lt.UpdateCmdSpec.Args)
+	assert.Equal(t, f.Path(), lt.UpdateCmdSpec.Dir)
+	assert.Equal(t, []string{"sh", "-c", "./server"}, lt.ServeCmd.Argv)
+	assert.Equal(t, f.Path(), lt.ServeCmd.Dir)
+	assert.Equal(t, []string{f.JoinPath("src")}, lt.Deps)
+}
+
+func TestDockerCompose(t *testing.T) {
+	f := tempdir.NewTempDirFixture(t)
+
+	m := New(f, "fe").
+		WithDockerCompose("frontend", []string{"docker-compose.yml", "docker-compose.override.yml"}).
+		Build()
+
+	dc := m.DockerComposeTarget()
+	assert.Equal(t, "frontend", dc.Spec.Service)
+	assert.Equal(t, []string{
+		f.JoinPath("docker-compose.yml"),
+		f.JoinPath("docker-compose.override.yml"),
+	}, dc.Spec.Project.ConfigPaths)
+}
+
+func TestImageTargetWithLiveUpdate(t *testing.T) {
+	f := tempdir.NewTempDirFixture(t)
+
+	syncs := []v1alpha1.LiveUpdateSync{{LocalPath: ".", ContainerPath: "/app"}}
+	runs := []v1alpha1.LiveUpdateExec{{Args: []string{"make"}}}
+	m := New(f, "fe").
+		WithK8sYAML(testyaml.SanchoYAML).
+		WithImageTarget(testyaml.SanchoImage, "sancho").
+		WithLiveUpdate(syncs, runs, []string{"package.json"}).
+		Build()
+
+	iTarget := m.ImageTargetAt(0)
+	assert.Equal(t, f.JoinPath("sancho"), iTarget.DockerBuildInfo().BuildPath)
+	assert.True(t, iTarget.LiveUpdateReconciler)
+
+	lu := iTarget.LiveUpdateSpec
+	assert.Equal(t, f.JoinPath("sancho"), lu.BasePath)
+	assert.Equal(t, syncs, lu.Syncs)
+	assert.Equal(t, runs, lu.Execs)
+	assert.Equal(t, []string{"package.json"}, lu.StopPaths)
+	assert.NotNil(t, lu.Selector.Kubernetes)
+
+	assert.Equal(t, []model.TargetID{iTarget.ID()}, m.K8sTarget().DependencyIDs())
+}
+
+func TestInvalidCombinations(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		build    func(f Fixture) model.Manifest
+		expected string
+	}{
+		{
+			name: "two deploy targets",
+			build: func(f Fixture) model.Manifest {
+				return New(f, "fe").WithK8sYAML(testyaml.SanchoYAML).WithDockerCompose("fe", nil).Build()
+			},
+			expected: "manifest fe has more than one deploy target",
+		},
+		{
+			name: "image on local resource",
+			build: func(f Fixture) model.Manifest {
+				return New(f, "fe").WithLocalResource("make", "", nil).WithImageTarget("fe", ".").Build()
+			},
+			expected: "local resources can't have image targets",
+		},
+		{
+			name: "entrypoint on docker compose",
+			build: func(f Fixture) model.Manifest {
+				iTarget := model.MustNewImageTarget(container.MustParseSelector("fe")).
+					WithBuildDetails(model.DockerBuild{BuildPath: f.Path()}).
+					WithOverrideCommand(model.ToHostCmd("sh"))
+				return New(f, "fe").WithDockerCompose("fe", nil).WithImageTargets(iTarget).Build()
+			},
+			expected: "entrypoint not supported for Docker Compose resources",
+		},
+		{
+			name: "live update without image",
+			build: func(f Fixture) model.Manifest {
+				return New(f, "fe").WithK8sYAML(testyaml.SanchoYAML).WithLiveUpdate(nil, nil, nil).Build()
+			},
+			expected: "manifest fe has no image targets",
+		},
+		{
+			name: "fails manifest validation",
+			build: func(f Fixture) model.Manifest {
+				iTarget := model.MustNewImageTarget(container.MustParseSelector("fe")).
+					WithBuildDetails(model.CustomBuild{})
+				return New(f, "fe").WithK8sYAML(testyaml.SanchoYAML).WithImageTargets(iTarget).Build()
+			},
+			expected: "CustomBuild command must not be empty",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := &failFixture{TempDirFixture: tempdir.NewTempDirFixture(t)}
+			f.tb = &failTB{TB: t}
+
+			func() {
+				defer func() {
+					r := recover()
+					require.Equal(t, errTestFailed, r, "expected the builder to fail the test")
+				}()
+				tc.build(f)
+			}()
+
+			assert.Contains(t, f.tb.msg, tc.expected)
+		})
+	}
+}
+
+var errTestFailed = fmt.Errorf("test failed")
+
+// Records test failures instead of failing the real test.
+type failTB struct {
+	testing.TB
+	msg string
+}
+
+func (t *failTB) Helper() {}
+
+func (t *failTB) Errorf(format string, args ...interface{}) {
+	t.msg += fmt.Sprintf(format, args...)
+}
+
+func (t *failTB) Fatalf(format string, args ...interface{}) {
+	t.Errorf(format, args...)
+	t.FailNow()
+}
+
+func (t *failTB) FailNow() {
+	panic(errTestFailed)
+}
+
+type failFixture struct {
+	*tempdir.TempDirFixture
+	tb *failTB
+}
+
+func (f *failFixture) T() testing.TB {
+	return f.tb
+}
@@ -0,0 +1,93 @@
+package tiltengine
+
+import (
+	"fmt"
+
+	"github.com/google/wire"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/tilt-dev/tilt/internal/build"
+	"github.com/tilt-dev/tilt/internal/docker"
+	"github.com/tilt-dev/tilt/internal/k8s"
+)
+
+// The cluster clients for an engine that doesn't talk to a real cluster,
+// e.g., k8s.NewFakeK8sClient and docker.NewFakeClient.
+type Clients struct {
+	K8s        k8s.Client
+	Docker     docker.Client
+	Containerd build.ContainerdClient
+
+	// The kubeconfig that describes the cluster K8s talks to.
+	// Must have a current context.
+	KubeConfig *api.Config
+}
+
+// A kubeconfig with a single context, for use with Clients.
+func NewKubeConfig(context string, namespace string) *api.Config {
+	return &api.Config{
+		CurrentContext: context,
+		Contexts: map[string]*api.Context{
+			context: {Cluster: context, Namespace: namespace},
+		},
+		Clusters: map[string]*api.Cluster{
+			context: {Server: fmt.Sprintf("https://%s.invalid", context)},
+		},
+		AuthInfos: map[string]*api.AuthInfo{},
+	}
+}
+
+// Provides the cluster clients from a Clients struct.
+//
+// The engine never talks to the apiserver in the kubeconfig directly,
+// only through the given k8s.Client.
+var ClientsFieldsWireSet = wire.NewSet(
+	wire.FieldsOf(new(Clients), "K8s", "Docker", "Containerd", "KubeConfig"),
+
+	k8s.ProvideEnv,
+	k8s.ProvideClusterName,
+	k8s.ProvideKubeContext,
+	k8s.ProvideConfigNamespace,
+	k8s.ProvideContainerRuntime,
+	k8s.ProvideOwnerFetcher,
+	provideClientsClientConfig,
+	provideClientsRESTConfig,
+	provideClientsClientset,
+
+	provideClientsDockerLocalClient,
+	provideClientsDockerLocalEnv,
+	provideClientsDockerClusterEnv,
+)
+
+func provideClientsClientConfig(config *api.Config, contextOverride k8s.KubeContextOverride, nsOverride k8s.NamespaceOverride) clientcmd.ClientConfig {
+	overrides := &clientcmd.ConfigOverrides{
+		CurrentContext: string(contextOverride),
+		Context: api.Context{
+			Namespace: string(nsOverride),
+		},
+	}
+	return clientcmd.NewDefaultClientConfig(*config, overrides)
+}
+
+func provideClientsRESTConfig() k8s.RESTConfigOrError {
+	return k8s.RESTConfigOrError{Error: fmt.Errorf("no REST config: the engine was constructed with Clients")}
+}
+
+func provideClientsClientset() k8s.ClientsetOrError {
+	return k8s.ClientsetOrError{Error: fmt.Errorf("no clientset: the engine was constructed with Clients")}
+}
+
+func provideClientsDockerLocalClient(c docker.Client) docker.LocalClient {
+	return docker.LocalClient(c)
+}
+
+func provideClientsDockerLocalEnv(c docker.Client) docker.LocalEnv {
+	return docker.LocalEnv(c.Env())
+}
+
+// Treats the Docker client as the cluster's Docker daemon, so the engine
+// doesn't push images.
+func provideClientsDockerClusterEnv(c docker.Client) docker.ClusterEnv {
+	return docker.ClusterEnv(c.Env())
+}
@@ -0,0 +1,86 @@
+package tiltengine_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/internal/build"
+	"github.com/tilt-dev/tilt/internal/docker"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/testutils"
+	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
+	"github.com/tilt-dev/tilt/internal/tiltengine"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+const exampleYAML = `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: greeting
+data:
+  message: hello
+`
+
+// Embeds the engine against fake clients, deploys a Tiltfile once, and shuts down.
+func TestEngineWithFakeClients(t *testing.T) {
+	f := tempdir.NewTempDirFixture(t)
+	t.Setenv("TILT_DEV_DIR", f.JoinPath(".tilt-dev"))
+
+	f.WriteFile("greeting.yaml", exampleYAML)
+	f.WriteFile("Tiltfile", `
+k8s_yaml('greeting.yaml')
+k8s_resource(new_name='greeting', objects=['greeting:configmap'])
+`)
+
+	kCli := k8s.NewFakeK8sClient(t)
+	defer kCli.TearDown()
+
+	opts := tiltengine.DefaultOptions(model.TiltBuild{Version: "0.0.0", Dev: true})
+	opts.WebPort = 0
+	opts.Exclude = tiltengine.Exclude{HUD: true, WebServer: true}
+
+	ctx, _, ta := testutils.CtxAndAnalyticsForTest()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	e, err := tiltengine.NewWithClients(ctx, ta, "up", opts, tiltengine.Clients{
+		K8s:        kCli,
+		Docker:     docker.NewFakeClient(),
+		Containerd: build.NewFakeContainerdClient(),
+		KubeConfig: tiltengine.NewKubeConfig("kind-kind", "default"),
+	})
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		done <- e.Start(ctx, filepath.Join(f.Path(), "Tiltfile"), nil)
+	}()
+
+	require.Eventually(t, func() bool {
+		state := e.Store.RLockState()
+		defer e.Store.RUnlockState()
+		mt, ok := state.ManifestTargets["greeting"]
+		return ok && !mt.State.LastBuild().Empty()
+	}, 10*time.Second, 10*time.Millisecond, "greeting never deployed")
+
+	state := e.Store.RLockState()
+	buildErr := state.ManifestTargets["greeting"].State.LastBuild().Error
+	e.Store.RUnlockState()
+	assert.NoError(t, buildErr)
+	assert.True(t, strings.Contains(kCli.Yaml, "name: greeting"), "applied YAML: %s", kCli.Yaml)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("engine never shut down")
+	}
+}
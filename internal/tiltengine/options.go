@@ -0,0 +1,120 @@
+package tiltengine
+
+import (
+	"context"
+
+	"github.com/google/wire"
+
+	"github.com/tilt-dev/tilt/internal/analytics"
+	"github.com/tilt-dev/tilt/internal/cloud/cloudurl"
+	"github.com/tilt-dev/tilt/internal/controllers/core/kubernetesapply"
+	ctrltiltfile "github.com/tilt-dev/tilt/internal/controllers/core/tiltfile"
+	"github.com/tilt-dev/tilt/internal/engine"
+	engineanalytics "github.com/tilt-dev/tilt/internal/engine/analytics"
+	"github.com/tilt-dev/tilt/internal/engine/idle"
+	"github.com/tilt-dev/tilt/internal/engine/memory"
+	"github.com/tilt-dev/tilt/internal/engine/resourceprefs"
+	"github.com/tilt-dev/tilt/internal/engine/session"
+	"github.com/tilt-dev/tilt/internal/hud/server"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/store/liveupdates"
+	"github.com/tilt-dev/tilt/internal/tiltfile"
+	"github.com/tilt-dev/tilt/internal/token"
+	"github.com/tilt-dev/tilt/pkg/model"
+	"github.com/tilt-dev/tilt/pkg/model/logstore"
+)
+
+// Options for an embedded engine. Each one corresponds to a `tilt up` or
+// `tilt ci` flag.
+//
+// Use DefaultOptions to get the same defaults as the CLI.
+type Options struct {
+	TiltBuild  model.TiltBuild
+	EngineMode store.EngineMode
+	CmdTags    engineanalytics.CmdTags
+
+	Exclude          Exclude
+	ExtraSubscribers ExtraSubscribers
+
+	WebHost     model.WebHost
+	WebPort     model.WebPort
+	WebMode     model.WebMode
+	WebDevPort  model.WebDevPort
+	WebSecurity server.WebSecurityOptions
+
+	KubeContextOverride k8s.KubeContextOverride
+	NamespaceOverride   k8s.NamespaceOverride
+
+	TiltfileExecLimits     tiltfile.ExecLimits
+	LogLevelFilter         logstore.LevelFilter
+	UpdateMode             liveupdates.UpdateModeFlag
+	LogActions             store.LogActionsFlag
+	ActionJournal          store.ActionJournalFlag
+	AllowEmpty             session.AllowEmptyFlag
+	CITimeout              session.CITimeoutFlag
+	VerboseApply           kubernetesapply.VerboseApplyFlag
+	Fresh                  resourceprefs.FreshFlag
+	ForceBootstrap         ctrltiltfile.ForceBootstrapFlag
+	IdleTimeout            idle.Timeout
+	MemoryWarningThreshold memory.WarningThreshold
+}
+
+func DefaultOptions(b model.TiltBuild) Options {
+	return Options{
+		TiltBuild:              b,
+		EngineMode:             store.EngineModeUp,
+		CmdTags:                engineanalytics.CmdTags{},
+		WebHost:                model.WebHost("localhost"),
+		WebPort:                model.DefaultWebPort,
+		WebMode:                model.DefaultWebMode,
+		WebDevPort:             model.DefaultWebDevPort,
+		TiltfileExecLimits:     tiltfile.DefaultExecLimits(),
+		UpdateMode:             liveupdates.UpdateModeFlag(liveupdates.UpdateModeAuto),
+		MemoryWarningThreshold: memory.DefaultWarningThreshold,
+	}
+}
+
+// Provides the engine options from an Options struct.
+//
+// Doesn't provide the EngineMode or CmdTags, because the CLI picks those per
+// command. See CommandWireSet.
+var OptionsWireSet = wire.NewSet(
+	wire.FieldsOf(new(Options),
+		"TiltBuild", "Exclude", "ExtraSubscribers",
+		"WebHost", "WebPort", "WebDevPort", "WebSecurity",
+		"KubeContextOverride", "NamespaceOverride",
+		"TiltfileExecLimits", "LogLevelFilter", "UpdateMode", "LogActions", "ActionJournal",
+		"AllowEmpty", "CITimeout", "VerboseApply", "Fresh", "ForceBootstrap",
+		"IdleTimeout", "MemoryWarningThreshold"),
+	ProvideWebMode,
+)
+
+// Provides the EngineMode and CmdTags from an Options struct.
+var CommandWireSet = wire.NewSet(
+	wire.FieldsOf(new(Options), "EngineMode", "CmdTags"),
+)
+
+func ProvideWebMode(opts Options) (model.WebMode, error) {
+	return ResolveWebMode(opts.WebMode, opts.TiltBuild)
+}
+
+// A constructed engine, ready to start.
+type Engine struct {
+	Upper        engine.Upper
+	Store        *store.Store
+	TiltBuild    model.TiltBuild
+	Token        token.Token
+	CloudAddress cloudurl.Address
+	Analytics    *analytics.TiltAnalytics
+	Options      Options
+}
+
+// Runs the engine on a Tiltfile until the context is canceled or the engine
+// exits (e.g., when a `tilt ci` run finishes).
+//
+// Logs go to the logger in the context.
+func (e Engine) Start(ctx context.Context, fileName string, args []string) error {
+	return e.Upper.Start(ctx, args, e.TiltBuild, fileName, store.TerminalModeStream,
+		e.Analytics.UserOpt(), e.Token, string(e.CloudAddress), e.Options.WebSecurity.ReadOnly)
+}
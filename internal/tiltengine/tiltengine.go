@@ -0,0 +1,336 @@
+// Package tiltengine constructs the Tilt engine: the store, the subscribers
+// that read from it, and the Upper that runs them.
+//
+// The `tilt` CLI builds the engine from these provider sets, with Options
+// filled in from its command-line flags. Programs that embed Tilt
+// (and tests that want the whole engine) should use New or NewWithClients,
+// or build their own injector from EngineWireSet.
+package tiltengine
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/google/wire"
+	"github.com/jonboulle/clockwork"
+	"github.com/tilt-dev/wmclient/pkg/dirs"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/tilt-dev/tilt/internal/agent"
+	"github.com/tilt-dev/tilt/internal/build"
+	"github.com/tilt-dev/tilt/internal/cloud"
+	"github.com/tilt-dev/tilt/internal/cloud/cloudurl"
+	"github.com/tilt-dev/tilt/internal/compat"
+	"github.com/tilt-dev/tilt/internal/controllers"
+	"github.com/tilt-dev/tilt/internal/controllers/core/debugcontainer"
+	"github.com/tilt-dev/tilt/internal/controllers/core/filewatch/fsevent"
+	"github.com/tilt-dev/tilt/internal/controllers/core/kubernetesapply"
+	"github.com/tilt-dev/tilt/internal/controllers/core/kubernetesdiscovery"
+	"github.com/tilt-dev/tilt/internal/controllers/core/portforward"
+	"github.com/tilt-dev/tilt/internal/docker"
+	"github.com/tilt-dev/tilt/internal/dockercompose"
+	"github.com/tilt-dev/tilt/internal/engine"
+	engineanalytics "github.com/tilt-dev/tilt/internal/engine/analytics"
+	"github.com/tilt-dev/tilt/internal/engine/buildcontrol"
+	"github.com/tilt-dev/tilt/internal/engine/configs"
+	"github.com/tilt-dev/tilt/internal/engine/dcwatch"
+	"github.com/tilt-dev/tilt/internal/engine/dockerprune"
+	"github.com/tilt-dev/tilt/internal/engine/idle"
+	"github.com/tilt-dev/tilt/internal/engine/k8srollout"
+	"github.com/tilt-dev/tilt/internal/engine/k8swatch"
+	"github.com/tilt-dev/tilt/internal/engine/local"
+	"github.com/tilt-dev/tilt/internal/engine/memory"
+	"github.com/tilt-dev/tilt/internal/engine/resourceprefs"
+	"github.com/tilt-dev/tilt/internal/engine/runtimelog"
+	"github.com/tilt-dev/tilt/internal/engine/session"
+	"github.com/tilt-dev/tilt/internal/engine/telemetry"
+	"github.com/tilt-dev/tilt/internal/engine/uiresource"
+	"github.com/tilt-dev/tilt/internal/engine/uisession"
+	"github.com/tilt-dev/tilt/internal/feature"
+	"github.com/tilt-dev/tilt/internal/git"
+	"github.com/tilt-dev/tilt/internal/hud"
+	"github.com/tilt-dev/tilt/internal/hud/prompt"
+	"github.com/tilt-dev/tilt/internal/hud/server"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/localexec"
+	"github.com/tilt-dev/tilt/internal/openurl"
+	"github.com/tilt-dev/tilt/internal/store"
+	"github.com/tilt-dev/tilt/internal/tiltfile"
+	"github.com/tilt-dev/tilt/internal/token"
+	"github.com/tilt-dev/tilt/internal/tracer"
+	"github.com/tilt-dev/tilt/internal/xdg"
+	"github.com/tilt-dev/tilt/pkg/assets"
+	"github.com/tilt-dev/tilt/pkg/model"
+	"github.com/tilt-dev/tilt/web"
+)
+
+// Providers for the connection to the Kubernetes cluster in the user's kubeconfig.
+//
+// Doesn't include the kube context and namespace overrides, which come from
+// the caller (command-line flags in the CLI, Options in New).
+var K8sWireSet = wire.NewSet(
+	k8s.ProvideEnv,
+	k8s.ProvideClusterName,
+	k8s.ProvideKubeContext,
+	k8s.ProvideKubeConfig,
+	k8s.ProvideClientConfig,
+	k8s.ProvideClientset,
+	k8s.ProvideRESTConfig,
+	k8s.ProvidePortForwardClient,
+	k8s.ProvideConfigNamespace,
+	k8s.ProvideContainerRuntime,
+	k8s.ProvideServerVersion,
+	k8s.ProvideK8sClient,
+	k8s.ProvideOwnerFetcher)
+
+// Providers for the Docker and containerd clients that the image builders use.
+var ClientsWireSet = wire.NewSet(
+	docker.SwitchWireSet,
+	build.NewNerdctlClient,
+	wire.Bind(new(build.ContainerdClient), new(build.NerdctlClient)))
+
+// Everything in the engine except the cluster clients and the engine options.
+//
+// Callers provide:
+// - the cluster clients (K8sWireSet and ClientsWireSet, or fakes)
+// - the engine options (OptionsWireSet)
+// - the store.EngineMode and analytics.CmdTags (CommandWireSet, or per-command values)
+// - the subscribers to run (ProvideSubscribers, or a subset)
+var EngineWireSet = wire.NewSet(
+	tiltfile.WireSet,
+	git.ProvideGitRemote,
+
+	localexec.DefaultEnv,
+	localexec.NewProcessExecer,
+	wire.Bind(new(localexec.Execer), new(*localexec.ProcessExecer)),
+
+	dockercompose.NewDockerComposeClient,
+
+	clockwork.NewRealClock,
+	engine.DeployerWireSet,
+	engine.NewBuildController,
+	engine.NewUpdateModeRecorder,
+	local.NewServerController,
+	local.ProvideProcessSignaler,
+	kubernetesdiscovery.NewContainerRestartDetector,
+	k8swatch.NewServiceWatcher,
+	k8swatch.NewEventWatchManager,
+	k8swatch.NewClusterMonitor,
+	k8swatch.NewRegistryResyncer,
+	engine.ProvideClusterResyncers,
+	idle.NewController,
+	memory.NewMonitor,
+	agent.ProvideDialer,
+	agent.NewInstaller,
+	engine.ProvideSleepers,
+	uisession.NewSubscriber,
+	resourceprefs.NewSubscriber,
+	uiresource.NewSubscriber,
+	configs.NewConfigsController,
+	configs.NewTriggerQueueSubscriber,
+	telemetry.NewController,
+	dcwatch.NewEventWatcher,
+	runtimelog.NewDockerComposeLogManager,
+	cloud.WireSet,
+	cloudurl.ProvideAddress,
+	k8srollout.NewPodMonitor,
+	k8srollout.NewImagePullMonitor,
+	k8srollout.NewPendingPodMonitor,
+	k8srollout.NewPinMonitor,
+	k8srollout.NewDockerRegistryChecker,
+	telemetry.NewStartTracker,
+	session.NewController,
+	compat.ProvideVersions,
+
+	build.ProvideClock,
+	ProvideClock,
+	hud.WireSet,
+	prompt.WireSet,
+	wire.Value(openurl.OpenURL(openurl.BrowserOpen)),
+
+	store.NewStore,
+	wire.Bind(new(store.RStore), new(*store.Store)),
+
+	dockerprune.NewDockerPruner,
+
+	engine.NewUpper,
+	engineanalytics.NewAnalyticsUpdater,
+	engineanalytics.ProvideAnalyticsReporter,
+	fsevent.ProvideWatcherMaker,
+	fsevent.ProvideTimerMaker,
+
+	controllers.WireSet,
+
+	ProvideWebVersion,
+	ProvideWebURL,
+	ProvideWebListener,
+	server.WireSet,
+	ProvideAssetServer,
+	wire.Bind(new(server.ManifestDiffer), new(*kubernetesapply.Reconciler)),
+	wire.Bind(new(server.ApplyHistory), new(*kubernetesapply.Reconciler)),
+	wire.Bind(new(server.DebugContainerAttacher), new(*debugcontainer.Reconciler)),
+	wire.Bind(new(server.PortForwardToggler), new(*portforward.Reconciler)),
+
+	tracer.NewSpanCollector,
+	wire.Bind(new(sdktrace.SpanExporter), new(*tracer.SpanCollector)),
+	wire.Bind(new(tracer.SpanSource), new(*tracer.SpanCollector)),
+
+	dirs.UseTiltDevDir,
+	xdg.NewTiltDevBase,
+	token.GetOrCreateToken,
+
+	buildcontrol.NewKINDLoader,
+
+	wire.Value(feature.MainDefaults),
+)
+
+// Parts of the engine that an embedder can leave out.
+type Exclude struct {
+	// Leaves out the terminal HUD, the terminal prompt, and the log stream to stdout.
+	HUD bool
+
+	// Leaves out the web UI and its asset server.
+	//
+	// The API server still runs, because the controllers talk to it.
+	WebServer bool
+}
+
+// Subscribers to run alongside the engine's own, e.g., an embedder's
+// observer of the engine state.
+type ExtraSubscribers []store.Subscriber
+
+// The subscribers that run during `tilt up` and `tilt ci`, minus any exclusions.
+func ProvideSubscribers(
+	exclude Exclude,
+	extra ExtraSubscribers,
+	hudsc *server.HeadsUpServerController,
+	tscm *controllers.TiltServerControllerManager,
+	cb *controllers.ControllerBuilder,
+	h hud.HeadsUpDisplay,
+	ts *hud.TerminalStream,
+	tp *prompt.TerminalPrompt,
+	sw *k8swatch.ServiceWatcher,
+	cm *k8swatch.ClusterMonitor,
+	bc *engine.BuildController,
+	cc *configs.ConfigsController,
+	tqs *configs.TriggerQueueSubscriber,
+	dcw *dcwatch.EventWatcher,
+	dclm *runtimelog.DockerComposeLogManager,
+	ar *engineanalytics.AnalyticsReporter,
+	au *engineanalytics.AnalyticsUpdater,
+	ewm *k8swatch.EventWatchManager,
+	tcum *cloud.CloudStatusManager,
+	dp *dockerprune.DockerPruner,
+	tc *telemetry.Controller,
+	lsc *local.ServerController,
+	podm *k8srollout.PodMonitor,
+	ipm *k8srollout.ImagePullMonitor,
+	ppm *k8srollout.PendingPodMonitor,
+	pinm *k8srollout.PinMonitor,
+	sc *session.Controller,
+	uss *uisession.Subscriber,
+	urs *uiresource.Subscriber,
+	umr *engine.UpdateModeRecorder,
+	rps *resourceprefs.Subscriber,
+	dcr *debugcontainer.Reconciler,
+	ic *idle.Controller,
+	mm *memory.Monitor,
+) []store.Subscriber {
+	subs := engine.ProvideSubscribers(hudsc, tscm, cb, h, ts, tp, sw, cm, bc, cc, tqs, dcw, dclm,
+		ar, au, ewm, tcum, dp, tc, lsc, podm, ipm, ppm, pinm, sc, uss, urs, umr, rps, dcr, ic, mm)
+
+	if exclude.HUD {
+		result := make([]store.Subscriber, 0, len(subs))
+		for _, s := range subs {
+			if s == store.Subscriber(h) || s == store.Subscriber(ts) || s == store.Subscriber(tp) {
+				continue
+			}
+			result = append(result, s)
+		}
+		subs = result
+	}
+
+	return append(subs, extra...)
+}
+
+// Creates the listener for the web UI, or returns nil if the web server is excluded.
+func ProvideWebListener(host model.WebHost, port model.WebPort, exclude Exclude) (server.WebListener, error) {
+	if exclude.WebServer {
+		return nil, nil
+	}
+	return server.ProvideWebListener(host, port)
+}
+
+func ProvideWebURL(webHost model.WebHost, webPort model.WebPort, security server.WebSecurityOptions, exclude Exclude) (model.WebURL, error) {
+	if webPort == 0 || exclude.WebServer {
+		return model.WebURL{}, nil
+	}
+
+	if webHost == "0.0.0.0" {
+		// 0.0.0.0 means "listen on all hosts"
+		// For UI displays, we use 127.0.0.1 (loopback)
+		webHost = "127.0.0.1"
+	}
+
+	scheme := "http"
+	if security.TLS {
+		scheme = "https"
+	}
+
+	u, err := url.Parse(fmt.Sprintf("%s://%s:%d/", scheme, webHost, webPort))
+	if err != nil {
+		return model.WebURL{}, err
+	}
+	return model.WebURL(*u), nil
+}
+
+// Picks the web mode to use when the caller asks for the default.
+func ResolveWebMode(mode model.WebMode, b model.TiltBuild) (model.WebMode, error) {
+	switch mode {
+	case model.LocalWebMode, model.ProdWebMode, model.PrecompiledWebMode:
+		return mode, nil
+	case model.DefaultWebMode:
+		// Set prod web mode from an environment variable. Useful for
+		// running integration tests against dev tilt.
+		webMode := os.Getenv("TILT_WEB_MODE")
+		if webMode == "prod" {
+			return model.ProdWebMode, nil
+		}
+
+		if b.Dev {
+			return model.LocalWebMode, nil
+		} else {
+			return model.ProdWebMode, nil
+		}
+	}
+	return "", model.UnrecognizedWebModeError(string(mode))
+}
+
+func ProvideWebVersion(b model.TiltBuild) model.WebVersion {
+	return b.WebVersion()
+}
+
+func ProvideAssetServer(mode model.WebMode, version model.WebVersion, devPort model.WebDevPort) (assets.Server, error) {
+	if mode == model.ProdWebMode {
+		return assets.NewProdServer(assets.ProdAssetBucket, version)
+	}
+	if mode == model.PrecompiledWebMode || mode == model.LocalWebMode {
+		path, err := web.StaticPath()
+		if err != nil {
+			return nil, err
+		}
+		pkgDir := assets.PackageDir(path)
+		if mode == model.PrecompiledWebMode {
+			return assets.NewPrecompiledServer(pkgDir), nil
+		} else {
+			return assets.NewDevServer(pkgDir, devPort)
+		}
+	}
+	return nil, model.UnrecognizedWebModeError(string(mode))
+}
+
+func ProvideClock() func() time.Time {
+	return time.Now
+}
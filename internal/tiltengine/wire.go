@@ -0,0 +1,42 @@
+// +build wireinject
+// The build tag makes sure the stub is not built in the final build.
+
+package tiltengine
+
+import (
+	"context"
+
+	"github.com/google/wire"
+
+	"github.com/tilt-dev/tilt/internal/analytics"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// Constructs an engine that talks to the cluster in the user's kubeconfig
+// and the Docker daemon in the user's environment.
+//
+// Analytics go to the given TiltAnalytics, so embedders can pass their own sink
+// (or analytics.NewMemoryTiltAnalyticsForTest).
+func New(ctx context.Context, analytics *analytics.TiltAnalytics, subcommand model.TiltSubcommand, opts Options) (Engine, error) {
+	wire.Build(
+		EngineWireSet,
+		K8sWireSet,
+		ClientsWireSet,
+		OptionsWireSet,
+		CommandWireSet,
+		ProvideSubscribers,
+		wire.Struct(new(Engine), "*"))
+	return Engine{}, nil
+}
+
+// Constructs an engine with the given cluster clients, e.g., fakes for tests.
+func NewWithClients(ctx context.Context, analytics *analytics.TiltAnalytics, subcommand model.TiltSubcommand, opts Options, clients Clients) (Engine, error) {
+	wire.Build(
+		EngineWireSet,
+		OptionsWireSet,
+		CommandWireSet,
+		ClientsFieldsWireSet,
+		ProvideSubscribers,
+		wire.Struct(new(Engine), "*"))
+	return Engine{}, nil
+}
@@ -0,0 +1,83 @@
+package tiltfile
+
+import (
+	"fmt"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/tiltfile/value"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// bootstrap(name, cmd=None, yaml=None, ...) declares a setup step that
+// runs once per cluster, before the first build.
+func (s *tiltfileState) bootstrap(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name value.Name
+	var cmdVal, cmdBatVal, dirVal, yamlVal starlark.Value
+	var env value.StringStringMap
+
+	if err := s.unpackArgs(fn.Name(), args, kwargs,
+		"name", &name,
+		"cmd?", &cmdVal,
+		"yaml?", &yamlVal,
+		"cmd_bat?", &cmdBatVal,
+		"dir?", &dirVal,
+		"env?", &env,
+	); err != nil {
+		return nil, err
+	}
+
+	if errs := validation.IsQualifiedName(string(name)); len(errs) > 0 {
+		return nil, fmt.Errorf("%s: invalid name %q: %s", fn.Name(), name, strings.Join(errs, "; "))
+	}
+	for _, t := range s.bootstrapTasks {
+		if t.Name == string(name) {
+			return nil, fmt.Errorf("%s: task named %q already exists", fn.Name(), name)
+		}
+	}
+
+	cmd, err := value.ValueGroupToCmdHelper(thread, cmdVal, cmdBatVal, dirVal, env)
+	if err != nil {
+		return nil, err
+	}
+
+	task := model.BootstrapTask{Name: string(name), Cmd: cmd}
+	if yamlVal != nil && yamlVal != starlark.None {
+		if !cmd.Empty() {
+			return nil, fmt.Errorf("%s: %q must have a cmd or yaml, but not both", fn.Name(), name)
+		}
+
+		entities, err := s.yamlEntitiesFromSkylarkValueOrList(thread, yamlVal)
+		if err != nil {
+			return nil, err
+		}
+		if len(entities) == 0 {
+			return nil, fmt.Errorf("%s: %q has empty yaml", fn.Name(), name)
+		}
+		task.YAML, err = k8s.SerializeSpecYAML(entities)
+		if err != nil {
+			return nil, err
+		}
+	} else if cmd.Empty() {
+		return nil, fmt.Errorf("%s: %q must have a cmd or yaml, but both were empty", fn.Name(), name)
+	}
+
+	s.bootstrapTasks = append(s.bootstrapTasks, task)
+	return starlark.None, nil
+}
+
+// Bootstrap tasks show up in the UI next to resources, so they need
+// names of their own.
+func (s *tiltfileState) validateBootstrapTasks(manifests []model.Manifest) error {
+	for _, t := range s.bootstrapTasks {
+		for _, m := range manifests {
+			if m.Name.String() == t.Name {
+				return fmt.Errorf("%s: %q has the same name as a resource", bootstrapN, t.Name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package tiltfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/internal/k8s/testyaml"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestBootstrapCmd(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+bootstrap("certs", cmd="kubectl apply -f cert-manager.yaml", dir="infra", env={"FOO": "bar"})
+local_resource("fe", "make")
+`)
+
+	f.load()
+	f.assertNextManifest("fe")
+
+	require.Len(t, f.loadResult.BootstrapTasks, 1)
+	task := f.loadResult.BootstrapTasks[0]
+	assert.Equal(t, "certs", task.Name)
+	assert.Equal(t, model.Cmd{
+		Argv: []string{"sh", "-c", "kubectl apply -f cert-manager.yaml"},
+		Dir:  f.JoinPath("infra"),
+		Env:  []string{"FOO=bar"},
+	}, task.Cmd)
+	assert.Equal(t, "", task.YAML)
+}
+
+func TestBootstrapYAML(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("secret.yaml", testyaml.SecretYaml)
+	f.file("Tiltfile", `
+bootstrap("registry-secret", yaml="secret.yaml")
+local_resource("fe", "make")
+`)
+
+	f.load()
+
+	require.Len(t, f.loadResult.BootstrapTasks, 1)
+	task := f.loadResult.BootstrapTasks[0]
+	assert.True(t, task.Cmd.Empty())
+	assert.Contains(t, task.YAML, "name: mysecret")
+}
+
+func TestBootstrapRequiresCmdOrYAML(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+bootstrap("certs")
+`)
+
+	f.loadErrString(`bootstrap: "certs" must have a cmd or yaml, but both were empty`)
+}
+
+func TestBootstrapCmdAndYAML(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("secret.yaml", testyaml.SecretYaml)
+	f.file("Tiltfile", `
+bootstrap("certs", cmd="make certs", yaml="secret.yaml")
+`)
+
+	f.loadErrString(`bootstrap: "certs" must have a cmd or yaml, but not both`)
+}
+
+func TestBootstrapDuplicateName(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+bootstrap("certs", cmd="make certs")
+bootstrap("certs", cmd="make more-certs")
+`)
+
+	f.loadErrString(`bootstrap: task named "certs" already exists`)
+}
+
+func TestBootstrapInvalidName(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+bootstrap("install certs", cmd="make certs")
+`)
+
+	f.loadErrString(`bootstrap: invalid name "install certs"`)
+}
+
+func TestBootstrapNameConflictsWithResource(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+bootstrap("fe", cmd="make certs")
+local_resource("fe", "make")
+`)
+
+	f.loadErrString(`bootstrap: "fe" has the same name as a resource`)
+}
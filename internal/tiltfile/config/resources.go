@@ -66,6 +66,20 @@ func (s Settings) EnabledResources(tf *v1alpha1.Tiltfile, manifests []model.Mani
 	return manifests, nil
 }
 
+// Describes what selects the enabled resources, or "" if all resources are enabled.
+func (s Settings) EnabledResourcesFilter(tf *v1alpha1.Tiltfile) string {
+	if s.enabledResources != nil {
+		if len(s.enabledResources) == 0 {
+			return ""
+		}
+		return "config.set_enabled_resources()"
+	}
+	if len(tf.Spec.Args) > 0 && !s.configParseCalled {
+		return "command-line args"
+	}
+	return ""
+}
+
 // add `manifestToAdd` and all of its transitive deps to `result`
 func addManifestAndDeps(result map[model.ManifestName]bool, allManifestsByName map[model.ManifestName]model.Manifest, manifestToAdd model.ManifestName) {
 	if result[manifestToAdd] {
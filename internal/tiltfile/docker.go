@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/docker/docker/builder/dockerignore"
@@ -13,12 +14,14 @@ import (
 	"go.starlark.net/starlark"
 
 	"github.com/tilt-dev/tilt/internal/container"
+	"github.com/tilt-dev/tilt/internal/controllers/apis/liveupdate"
 	"github.com/tilt-dev/tilt/internal/dockerfile"
 	"github.com/tilt-dev/tilt/internal/ospath"
 	"github.com/tilt-dev/tilt/internal/sliceutils"
 	"github.com/tilt-dev/tilt/internal/tiltfile/io"
 	"github.com/tilt-dev/tilt/internal/tiltfile/starkit"
 	"github.com/tilt-dev/tilt/internal/tiltfile/value"
+	"github.com/tilt-dev/tilt/internal/tiltfile/warnings"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
 	"github.com/tilt-dev/tilt/pkg/model"
 )
@@ -43,6 +46,8 @@ type dockerImage struct {
 	cacheFrom        []string
 	pullParent       bool
 	platform         string
+	contentTag       bool
+	pushMode         model.ImagePushMode
 
 	// Overrides the container args. Used as an escape hatch in case people want the old entrypoint behavior.
 	// See discussion here:
@@ -51,12 +56,16 @@ type dockerImage struct {
 
 	dbDockerfilePath string
 	dbDockerfile     dockerfile.Dockerfile
+	dbInlineContents bool
 	dbBuildPath      string
 	dbBuildArgs      model.DockerBuildArgs
 	customCommand    model.Cmd
 	customDeps       []string
 	customTag        string
 
+	// The build args that the Dockerfile uses, resolved when we assemble images.
+	dbResolvedBuildArgs model.DockerBuildArgs
+
 	// Whether this has been matched up yet to a deploy resource.
 	matched bool
 
@@ -66,9 +75,14 @@ type dockerImage struct {
 	disablePush       bool
 	skipsLocalDocker  bool
 	outputsImageRefTo string
+	customEnv         model.CustomBuildEnv
+	imageStore        model.ImageStore
 
 	liveUpdate v1alpha1.LiveUpdateSpec
 
+	// Watch the image's files in CI, so that its live updates run.
+	watchInCI bool
+
 	// TODO(milas): we should have a better way of passing the Tiltfile path around during resource assembly
 	tiltfilePath string
 }
@@ -108,9 +122,9 @@ func (s *tiltfileState) dockerBuild(thread *starlark.Thread, fn *starlark.Builti
 		onlyVal,
 		entrypoint starlark.Value
 	var buildArgs value.StringStringMap
-	var network, platform value.Stringable
+	var network, platform, pushModeVal value.Stringable
 	var ssh, secret, extraTags, cacheFrom value.StringOrStringList
-	var matchInEnvVars, pullParent bool
+	var matchInEnvVars, pullParent, contentTag, watchInCI bool
 	var overrideArgsVal starlark.Sequence
 	if err := s.unpackArgs(fn.Name(), args, kwargs,
 		"ref", &dockerRef,
@@ -133,6 +147,9 @@ func (s *tiltfileState) dockerBuild(thread *starlark.Thread, fn *starlark.Builti
 		"cache_from?", &cacheFrom,
 		"pull?", &pullParent,
 		"platform?", &platform,
+		"content_tag?", &contentTag,
+		"push_mode?", &pushModeVal,
+		"watch_in_ci?", &watchInCI,
 	); err != nil {
 		return nil, err
 	}
@@ -184,13 +201,22 @@ func (s *tiltfileState) dockerBuild(thread *starlark.Thread, fn *starlark.Builti
 	}
 
 	if cacheVal != nil {
-		s.logger.Warnf("%s", cacheObsoleteWarning)
+		err := warnings.AddFromThread(thread, model.TiltfileWarning{
+			Key:     "docker_build.cache:" + ref.String(),
+			Message: cacheObsoleteWarning,
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	liveUpdate, err := s.liveUpdateFromSteps(thread, liveUpdateVal)
 	if err != nil {
 		return nil, errors.Wrap(err, "live_update")
 	}
+	if watchInCI && liveupdate.IsEmptySpec(liveUpdate) {
+		return nil, fmt.Errorf("watch_in_ci only applies to images with a live_update")
+	}
 
 	ignores, err := parseValuesToStrings(ignoreVal, "ignore")
 	if err != nil {
@@ -223,6 +249,16 @@ func (s *tiltfileState) dockerBuild(thread *starlark.Thread, fn *starlark.Builti
 		}
 	}
 
+	if contentTag && pullParent {
+		// The content tag can't tell when the pulled base image changes.
+		return nil, fmt.Errorf("Cannot specify both pull=True and content_tag=True")
+	}
+
+	pushMode, err := parsePushMode(pushModeVal.Value)
+	if err != nil {
+		return nil, err
+	}
+
 	if platform.Value == "" {
 		// for compatibility with Docker CLI, support the env var fallback
 		// see https://docs.docker.com/engine/reference/commandline/cli/#environment-variables
@@ -233,6 +269,7 @@ func (s *tiltfileState) dockerBuild(thread *starlark.Thread, fn *starlark.Builti
 		workDir:          starkit.CurrentExecPath(thread),
 		dbDockerfilePath: dockerfilePath,
 		dbDockerfile:     dockerfile.Dockerfile(dockerfileContents),
+		dbInlineContents: dockerfileContentsVal != nil,
 		dbBuildPath:      context,
 		configurationRef: container.NewRefSelector(ref),
 		dbBuildArgs:      buildArgs.AsMap(),
@@ -250,6 +287,9 @@ func (s *tiltfileState) dockerBuild(thread *starlark.Thread, fn *starlark.Builti
 		cacheFrom:        cacheFrom.Values,
 		pullParent:       pullParent,
 		platform:         platform.Value,
+		contentTag:       contentTag,
+		pushMode:         pushMode,
+		watchInCI:        watchInCI,
 		tiltfilePath:     starkit.CurrentExecPath(thread),
 	}
 	err = s.buildIndex.addImage(r)
@@ -260,6 +300,18 @@ func (s *tiltfileState) dockerBuild(thread *starlark.Thread, fn *starlark.Builti
 	return starlark.None, nil
 }
 
+func parsePushMode(s string) (model.ImagePushMode, error) {
+	if s == "" {
+		return "", nil
+	}
+	for _, m := range model.AllImagePushModes {
+		if model.ImagePushMode(s) == m {
+			return m, nil
+		}
+	}
+	return "", fmt.Errorf("push_mode must be one of %v, got %q", model.AllImagePushModes, s)
+}
+
 func (s *tiltfileState) parseOnly(val starlark.Value) ([]string, error) {
 	paths, err := parseValuesToStrings(val, "only")
 	if err != nil {
@@ -289,6 +341,12 @@ func (s *tiltfileState) customBuild(thread *starlark.Thread, fn *starlark.Builti
 	var overrideArgsVal starlark.Sequence
 	var skipsLocalDocker bool
 	outputsImageRefTo := value.NewLocalPathUnpacker(thread)
+	var env value.StringStringMap
+	inheritEnv := true
+	var envAllow, envDeny, envSecrets value.StringOrStringList
+	var imageStore string
+	var contentTag, watchInCI bool
+	var pushModeVal value.Stringable
 
 	err := s.unpackArgs(fn.Name(), args, kwargs,
 		"ref", &dockerRef,
@@ -304,6 +362,15 @@ func (s *tiltfileState) customBuild(thread *starlark.Thread, fn *starlark.Builti
 		"container_args?", &overrideArgsVal,
 		"command_bat_val", &commandBatVal,
 		"outputs_image_ref_to", &outputsImageRefTo,
+		"env?", &env,
+		"inherit_env?", &inheritEnv,
+		"env_allow?", &envAllow,
+		"env_deny?", &envDeny,
+		"env_secrets?", &envSecrets,
+		"image_store?", &imageStore,
+		"content_tag?", &contentTag,
+		"push_mode?", &pushModeVal,
+		"watch_in_ci?", &watchInCI,
 
 		// This is a crappy fix for https://github.com/tilt-dev/tilt/issues/4061
 		// so that we don't break things.
@@ -322,6 +389,9 @@ func (s *tiltfileState) customBuild(thread *starlark.Thread, fn *starlark.Builti
 	if err != nil {
 		return nil, errors.Wrap(err, "live_update")
 	}
+	if watchInCI && liveupdate.IsEmptySpec(liveUpdate) {
+		return nil, fmt.Errorf("watch_in_ci only applies to images with a live_update")
+	}
 
 	ignores, err := parseValuesToStrings(ignoreVal, "ignore")
 	if err != nil {
@@ -357,6 +427,61 @@ func (s *tiltfileState) customBuild(thread *starlark.Thread, fn *starlark.Builti
 		return nil, fmt.Errorf("Cannot specify both tag= and outputs_image_ref_to=")
 	}
 
+	switch model.ImageStore(imageStore) {
+	case "", model.ImageStoreDocker, model.ImageStoreContainerd:
+	default:
+		return nil, fmt.Errorf("image_store must be one of %q or %q, got %q",
+			model.ImageStoreDocker, model.ImageStoreContainerd, imageStore)
+	}
+	if imageStore != "" && skipsLocalDocker {
+		return nil, fmt.Errorf("Cannot specify both image_store= and skips_local_docker=True")
+	}
+
+	if contentTag {
+		// Tilt picks the tag, and checks for it in Docker.
+		if tag != "" {
+			return nil, fmt.Errorf("Cannot specify both tag= and content_tag=True")
+		}
+		if outputsImageRefTo.Value != "" {
+			return nil, fmt.Errorf("Cannot specify both outputs_image_ref_to= and content_tag=True")
+		}
+		if skipsLocalDocker || model.ImageStore(imageStore) == model.ImageStoreContainerd {
+			return nil, fmt.Errorf("content_tag=True only works with images in Docker, " +
+				"so it can't be combined with skips_local_docker=True or image_store='containerd'")
+		}
+	}
+
+	pushMode, err := parsePushMode(pushModeVal.Value)
+	if err != nil {
+		return nil, err
+	}
+	if (disablePush || skipsLocalDocker) && (pushMode == model.ImagePushModePush || pushMode == model.ImagePushModeLoad) {
+		// The command handles the push itself, so Tilt never sees the image.
+		return nil, fmt.Errorf("push_mode=%q can't be combined with disable_push=True or skips_local_docker=True", pushMode)
+	}
+
+	if inheritEnv && len(envAllow.Values) > 0 {
+		return nil, fmt.Errorf("env_allow only applies with inherit_env=False")
+	}
+	if !inheritEnv && len(envDeny.Values) > 0 {
+		return nil, fmt.Errorf("env_deny only applies with inherit_env=True")
+	}
+
+	customEnv := model.CustomBuildEnv{
+		Isolated: !inheritEnv,
+		Allow:    envAllow.Values,
+		Deny:     envDeny.Values,
+		Secrets:  envSecrets.Values,
+	}
+	envKeys := make([]string, 0, len(env))
+	for k := range env {
+		envKeys = append(envKeys, k)
+	}
+	sort.Strings(envKeys)
+	for _, k := range envKeys {
+		customEnv.Vars = append(customEnv.Vars, fmt.Sprintf("%s=%s", k, env[k]))
+	}
+
 	img := &dockerImage{
 		workDir:           starkit.AbsWorkingDir(thread),
 		configurationRef:  container.NewRefSelector(ref),
@@ -371,6 +496,11 @@ func (s *tiltfileState) customBuild(thread *starlark.Thread, fn *starlark.Builti
 		entrypoint:        entrypointCmd,
 		overrideArgs:      overrideArgs,
 		outputsImageRefTo: outputsImageRefTo.Value,
+		customEnv:         customEnv,
+		imageStore:        model.ImageStore(imageStore),
+		contentTag:        contentTag,
+		pushMode:          pushMode,
+		watchInCI:         watchInCI,
 		tiltfilePath:      starkit.CurrentExecPath(thread),
 	}
 
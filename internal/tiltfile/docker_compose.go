@@ -6,7 +6,9 @@ import (
 	"io/ioutil"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/compose-spec/compose-go/types"
 	// DANGER: some compose-go types are not friendly to being marshaled with gopkg.in/yaml.v3
@@ -15,11 +17,13 @@ import (
 	composeyaml "gopkg.in/yaml.v2"
 
 	"github.com/docker/distribution/reference"
+	"github.com/looplab/tarjan"
 	"github.com/pkg/errors"
 	"go.starlark.net/starlark"
 
 	"github.com/tilt-dev/tilt/internal/container"
 	"github.com/tilt-dev/tilt/internal/dockercompose"
+	"github.com/tilt-dev/tilt/internal/sliceutils"
 	"github.com/tilt-dev/tilt/internal/tiltfile/io"
 	"github.com/tilt-dev/tilt/internal/tiltfile/links"
 	"github.com/tilt-dev/tilt/internal/tiltfile/starkit"
@@ -103,6 +107,8 @@ func (s *tiltfileState) dcResource(thread *starlark.Thread, fn *starlark.Builtin
 	var resourceDepsVal starlark.Sequence
 	var links links.LinkList
 	var labels value.LabelSet
+	var buildTimeoutSecs value.IntOrNone
+	var watchInCI value.BoolOrNone
 
 	if err := s.unpackArgs(fn.Name(), args, kwargs,
 		"name", &name,
@@ -120,6 +126,8 @@ func (s *tiltfileState) dcResource(thread *starlark.Thread, fn *starlark.Builtin
 		"resource_deps?", &resourceDepsVal,
 		"links?", &links,
 		"labels?", &labels,
+		"build_timeout_secs?", &buildTimeoutSecs,
+		"watch_in_ci?", &watchInCI,
 	); err != nil {
 		return nil, err
 	}
@@ -128,6 +136,11 @@ func (s *tiltfileState) dcResource(thread *starlark.Thread, fn *starlark.Builtin
 		return nil, fmt.Errorf("dc_resource: `name` must not be empty")
 	}
 
+	buildTimeout, err := buildTimeoutFromValue(fn.Name(), buildTimeoutSecs)
+	if err != nil {
+		return nil, err
+	}
+
 	var imageRefAsStr *string
 	switch imageVal := imageVal.(type) {
 	case nil: // optional arg, this is fine
@@ -150,6 +163,14 @@ func (s *tiltfileState) dcResource(thread *starlark.Thread, fn *starlark.Builtin
 
 	svc.Labels = labels.Values
 
+	if buildTimeout != nil {
+		svc.buildTimeout = buildTimeout
+	}
+
+	if watchInCI.IsSet {
+		svc.watchInCI = watchInCI.Value
+	}
+
 	if imageRefAsStr != nil {
 		normalized, err := container.ParseNamed(*imageRefAsStr)
 		if err != nil {
@@ -162,7 +183,7 @@ func (s *tiltfileState) dcResource(thread *starlark.Thread, fn *starlark.Builtin
 	if err != nil {
 		return nil, errors.Wrapf(err, "%s: resource_deps", fn.Name())
 	}
-	svc.resourceDeps = append(svc.resourceDeps, rds...)
+	svc.resourceDeps = sliceutils.AppendWithoutDupes(svc.resourceDeps, rds...)
 
 	return starlark.None, nil
 }
@@ -205,7 +226,15 @@ type dcService struct {
 
 	Labels map[string]string
 
+	// nil uses the global build timeout.
+	buildTimeout *time.Duration
+
+	watchInCI bool
+
 	resourceDeps []string
+
+	// Dependencies from depends_on with condition: service_healthy.
+	healthyDeps []string
 }
 
 func (svc dcService) ImageRef() reference.Named {
@@ -249,6 +278,18 @@ func DockerComposeConfigToService(svcConfig types.ServiceConfig) (dcService, err
 		return dcService{}, err
 	}
 
+	// Translate depends_on into resource_deps, so that a service starts
+	// after its dependencies, like it would under docker-compose.
+	var resourceDeps, healthyDeps []string
+	for dep, cfg := range svcConfig.DependsOn {
+		resourceDeps = append(resourceDeps, dep)
+		if cfg.Condition == types.ServiceConditionHealthy {
+			healthyDeps = append(healthyDeps, dep)
+		}
+	}
+	sort.Strings(resourceDeps)
+	sort.Strings(healthyDeps)
+
 	svc := dcService{
 		Name:             svcConfig.Name,
 		BuildContext:     buildContext,
@@ -257,6 +298,9 @@ func DockerComposeConfigToService(svcConfig types.ServiceConfig) (dcService, err
 
 		ServiceConfig:  rawConfig,
 		PublishedPorts: publishedPorts,
+
+		resourceDeps: resourceDeps,
+		healthyDeps:  healthyDeps,
 	}
 
 	if svcConfig.Image != "" {
@@ -286,6 +330,12 @@ func parseDCConfig(ctx context.Context, dcc dockercompose.DockerComposeClient, s
 		return nil, err
 	}
 
+	// WithServices visits dependencies first, and never returns if they form a cycle.
+	err = validateDependsOn(proj)
+	if err != nil {
+		return nil, err
+	}
+
 	var services []*dcService
 	err = proj.WithServices(proj.ServiceNames(), func(svcConfig types.ServiceConfig) error {
 		svc, err := DockerComposeConfigToService(svcConfig)
@@ -302,6 +352,35 @@ func parseDCConfig(ctx context.Context, dcc dockercompose.DockerComposeClient, s
 	return services, nil
 }
 
+// Returns an error if the services' depends_on form a cycle.
+//
+// Checks links and the other implicit dependencies too, because WithServices
+// follows them.
+func validateDependsOn(proj *types.Project) error {
+	edges := make(map[interface{}][]interface{})
+	for _, svc := range proj.Services {
+		for _, dep := range svc.GetDependencies() {
+			if dep == svc.Name {
+				return fmt.Errorf("docker-compose service %s depends on itself", svc.Name)
+			}
+			edges[svc.Name] = append(edges[svc.Name], dep)
+		}
+	}
+
+	connections := tarjan.Connections(edges)
+	for _, g := range connections {
+		if len(g) > 1 {
+			var nodes []string
+			for i := range g {
+				nodes = append(nodes, g[len(g)-i-1].(string))
+			}
+			nodes = append(nodes, g[len(g)-1].(string))
+			return fmt.Errorf("cycle detected in docker-compose depends_on: %s", strings.Join(nodes, " -> "))
+		}
+	}
+	return nil
+}
+
 func (s *tiltfileState) dcServiceToManifest(service *dcService, dcSet dcResourceSet) (model.Manifest, error) {
 	dcInfo := model.DockerComposeTarget{
 		Name: model.TargetName(service.Name),
@@ -326,10 +405,18 @@ func (s *tiltfileState) dcServiceToManifest(service *dcService, dcSet dcResource
 		mds = append(mds, model.ManifestName(md))
 	}
 
+	var healthyDeps []model.ManifestName
+	for _, md := range service.healthyDeps {
+		healthyDeps = append(healthyDeps, model.ManifestName(md))
+	}
+	dcInfo = dcInfo.WithHealthyDependencies(healthyDeps)
+
 	m := model.Manifest{
 		Name:                 model.ManifestName(service.Name),
 		TriggerMode:          um,
 		ResourceDependencies: mds,
+		WatchInCI:            service.watchInCI,
+		BuildTimeout:         service.buildTimeout,
 	}.WithDeployTarget(dcInfo)
 
 	m = m.WithLabels(service.Labels)
@@ -203,3 +203,224 @@ custom_build('gcr.io/fe', 'export MY_REF="gcr.io/fe:dev" && docker build -t $MY_
 
 	f.loadErrString("Cannot specify both tag= and outputs_image_ref_to=")
 }
+
+func TestCustomBuildEnv(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.yaml("fe.yaml", deployment("fe", image("gcr.io/fe")))
+	f.file("Tiltfile", `
+k8s_yaml('fe.yaml')
+custom_build('gcr.io/fe', 'docker build -t $EXPECTED_REF .', ['src'],
+             env={'NPM_TOKEN': 'secret', 'MODE': 'dev'},
+             inherit_env=False,
+             env_allow=['HOME', 'DOCKER_HOST'],
+             env_secrets=['NPM_TOKEN'])
+`)
+
+	f.load()
+
+	m := f.assertNextManifest("fe")
+	assert.Equal(t, model.CustomBuildEnv{
+		Isolated: true,
+		Allow:    []string{"HOME", "DOCKER_HOST"},
+		Vars:     []string{"MODE=dev", "NPM_TOKEN=secret"},
+		Secrets:  []string{"NPM_TOKEN"},
+	}, m.ImageTargets[0].CustomBuildInfo().Env)
+}
+
+func TestCustomBuildEnvDeny(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.yaml("fe.yaml", deployment("fe", image("gcr.io/fe")))
+	f.file("Tiltfile", `
+k8s_yaml('fe.yaml')
+custom_build('gcr.io/fe', 'docker build -t $EXPECTED_REF .', ['src'],
+             env_deny=['AWS_SECRET_ACCESS_KEY'])
+`)
+
+	f.load()
+
+	m := f.assertNextManifest("fe")
+	assert.Equal(t, model.CustomBuildEnv{
+		Deny: []string{"AWS_SECRET_ACCESS_KEY"},
+	}, m.ImageTargets[0].CustomBuildInfo().Env)
+}
+
+func TestCustomBuildEnvAllowRequiresIsolation(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+custom_build('gcr.io/fe', 'docker build -t $EXPECTED_REF .', ['src'],
+             env_allow=['HOME'])
+`)
+
+	f.loadErrString("env_allow only applies with inherit_env=False")
+}
+
+func TestCustomBuildEnvDenyRequiresInheritance(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+custom_build('gcr.io/fe', 'docker build -t $EXPECTED_REF .', ['src'],
+             inherit_env=False,
+             env_deny=['HOME'])
+`)
+
+	f.loadErrString("env_deny only applies with inherit_env=True")
+}
+
+func TestCustomBuildImageStore(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.yaml("fe.yaml", deployment("fe", image("gcr.io/fe")))
+	f.file("Tiltfile", `
+k8s_yaml('fe.yaml')
+custom_build('gcr.io/fe', 'nerdctl build -t $EXPECTED_REF .', ['src'],
+             image_store='containerd')
+`)
+
+	f.load()
+
+	m := f.assertNextManifest("fe")
+	assert.True(t, m.ImageTargets[0].CustomBuildInfo().UsesContainerd())
+}
+
+func TestCustomBuildImageStoreInvalid(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+custom_build('gcr.io/fe', 'nerdctl build -t $EXPECTED_REF .', ['src'],
+             image_store='podman')
+`)
+
+	f.loadErrString(`image_store must be one of "docker" or "containerd", got "podman"`)
+}
+
+func TestDockerBuildPushMode(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.dockerfile("Dockerfile")
+	f.yaml("fe.yaml", deployment("fe", image("gcr.io/fe")))
+	f.file("Tiltfile", `
+k8s_yaml('fe.yaml')
+docker_build('gcr.io/fe', '.', push_mode='push')
+`)
+
+	f.load()
+
+	m := f.assertNextManifest("fe")
+	assert.Equal(t, model.ImagePushModePush, m.ImageTargets[0].PushMode)
+}
+
+func TestDockerBuildPushModeDefault(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.dockerfile("Dockerfile")
+	f.yaml("fe.yaml", deployment("fe", image("gcr.io/fe")))
+	f.file("Tiltfile", `
+k8s_yaml('fe.yaml')
+docker_build('gcr.io/fe', '.')
+`)
+
+	f.load()
+
+	m := f.assertNextManifest("fe")
+	assert.True(t, m.ImageTargets[0].PushMode.IsAuto())
+}
+
+func TestDockerBuildPushModeInvalid(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.dockerfile("Dockerfile")
+	f.file("Tiltfile", `
+docker_build('gcr.io/fe', '.', push_mode='always')
+`)
+
+	f.loadErrString(`push_mode must be one of [auto push load skip], got "always"`)
+}
+
+func TestCustomBuildPushMode(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.yaml("fe.yaml", deployment("fe", image("gcr.io/fe")))
+	f.file("Tiltfile", `
+k8s_yaml('fe.yaml')
+custom_build('gcr.io/fe', 'docker build -t $EXPECTED_REF .', ['src'], push_mode='load')
+`)
+
+	f.load()
+
+	m := f.assertNextManifest("fe")
+	assert.Equal(t, model.ImagePushModeLoad, m.ImageTargets[0].PushMode)
+}
+
+func TestCustomBuildPushModeConflictsWithDisablePush(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+custom_build('gcr.io/fe', 'docker build -t $EXPECTED_REF .', ['src'],
+             disable_push=True, push_mode='push')
+`)
+
+	f.loadErrString(`push_mode="push" can't be combined with disable_push=True or skips_local_docker=True`)
+}
+
+func TestDockerBuildWatchInCI(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.dockerfile("Dockerfile")
+	f.file("src/main.go", "package main")
+	f.yaml("fe.yaml", deployment("fe", image("gcr.io/fe")))
+	f.file("Tiltfile", `
+k8s_yaml('fe.yaml')
+docker_build('gcr.io/fe', '.', live_update=[sync('src', '/app/src')], watch_in_ci=True)
+`)
+
+	f.load()
+
+	m := f.assertNextManifest("fe")
+	assert.True(t, m.ImageTargets[0].WatchInCI)
+	assert.False(t, m.WatchInCI)
+}
+
+func TestCustomBuildWatchInCI(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("src/main.go", "package main")
+	f.yaml("fe.yaml", deployment("fe", image("gcr.io/fe")))
+	f.file("Tiltfile", `
+k8s_yaml('fe.yaml')
+custom_build('gcr.io/fe', 'docker build -t $EXPECTED_REF .', ['src'],
+             live_update=[sync('src', '/app/src')], watch_in_ci=True)
+`)
+
+	f.load()
+
+	m := f.assertNextManifest("fe")
+	assert.True(t, m.ImageTargets[0].WatchInCI)
+}
+
+func TestWatchInCIRequiresLiveUpdate(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.dockerfile("Dockerfile")
+	f.file("Tiltfile", `
+docker_build('gcr.io/fe', '.', watch_in_ci=True)
+`)
+
+	f.loadErrString("watch_in_ci only applies to images with a live_update")
+}
@@ -0,0 +1,127 @@
+package tiltfile
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/tilt-dev/tilt/internal/tiltfile/starkit"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// Limits on how long a Tiltfile may run, so that an infinite loop
+// or a hung local() doesn't leave Tilt stuck forever.
+type ExecLimits struct {
+	// Aborts the Tiltfile if it runs longer than this. 0 means no limit.
+	Timeout time.Duration
+
+	// Kills a local() command that runs longer than this, unless the call
+	// passes its own timeout. 0 means no limit.
+	LocalTimeout time.Duration
+
+	// Once the Tiltfile has run this long, logs what it's doing,
+	// and keeps logging at this interval. 0 means never.
+	ProgressInterval time.Duration
+}
+
+func DefaultExecLimits() ExecLimits {
+	return ExecLimits{
+		Timeout:          5 * time.Minute,
+		LocalTimeout:     3 * time.Minute,
+		ProgressInterval: 15 * time.Second,
+	}
+}
+
+// After we abort the Tiltfile, how long we wait for it to reach a builtin and stop.
+var execAbortGracePeriod = 2 * time.Second
+
+type execResult struct {
+	manifests []model.Manifest
+	model     starkit.Model
+	err       error
+}
+
+func (l ExecLimits) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if l.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, l.Timeout)
+}
+
+// Runs the Tiltfile on its own goroutine, logging progress while it's slow
+// and aborting it when ctx is done.
+//
+// Starlark can only be stopped when the Tiltfile calls a builtin. A loop that
+// never calls one keeps running in the background. In that case, we return
+// false, and the state must be thrown away.
+func (tfl tiltfileLoader) execWithLimits(ctx context.Context, s *tiltfileState, tf *v1alpha1.Tiltfile) (execResult, bool) {
+	done := make(chan execResult, 1)
+	go func() {
+		manifests, result, err := s.loadManifests(tf)
+		done <- execResult{manifests: manifests, model: result, err: err}
+	}()
+
+	var progressCh <-chan time.Time
+	if tfl.limits.ProgressInterval > 0 {
+		ticker := time.NewTicker(tfl.limits.ProgressInterval)
+		defer ticker.Stop()
+		progressCh = ticker.C
+	}
+
+	start := time.Now()
+	for {
+		select {
+		case r := <-done:
+			return r, true
+		case <-progressCh:
+			s.logger.Infof("Tiltfile still executing (%s): %s",
+				time.Since(start).Round(tfl.limits.ProgressInterval), describeExecLocation(s.progress.Current()))
+		case <-ctx.Done():
+			return tfl.abortExec(ctx, s, done)
+		}
+	}
+}
+
+func (tfl tiltfileLoader) abortExec(ctx context.Context, s *tiltfileState, done chan execResult) (execResult, bool) {
+	reason := fmt.Sprintf("Tiltfile execution aborted: %v", ctx.Err())
+	if ctx.Err() == context.DeadlineExceeded && tfl.limits.Timeout > 0 {
+		reason = fmt.Sprintf("Tiltfile execution timed out after %s and was aborted. "+
+			"To give it longer, run Tilt with --tiltfile-timeout", tfl.limits.Timeout)
+	}
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			// The error has the Starlark backtrace of where the Tiltfile stopped.
+			r.err = fmt.Errorf("%s\n%v", reason, r.err)
+		}
+		return r, true
+	case <-time.After(execAbortGracePeriod):
+	}
+
+	var msg strings.Builder
+	msg.WriteString(reason)
+	loc := s.progress.Current()
+	if loc.IsZero() {
+		msg.WriteString("\nThe Tiltfile didn't stop, and hadn't called any builtins yet.")
+	} else {
+		fmt.Fprintf(&msg, "\nThe Tiltfile didn't stop. It may be in a loop that never calls a builtin. "+
+			"The last builtin it called was %s\n", loc.Call)
+		msg.WriteString(strings.TrimSuffix(loc.CallStack.String(), "\n"))
+	}
+	return execResult{err: errors.New(msg.String())}, false
+}
+
+func describeExecLocation(loc starkit.ExecLocation) string {
+	if loc.IsZero() {
+		return "no builtins called yet"
+	}
+	if loc.Running {
+		return fmt.Sprintf("currently in %s", loc)
+	}
+	return fmt.Sprintf("last called %s", loc)
+}
@@ -0,0 +1,135 @@
+package tiltfile
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no sleep on windows")
+	}
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+local('sleep 10', timeout='100ms')
+`)
+
+	start := time.Now()
+	f.loadErrString(`command "sleep 10" timed out after 100ms and was killed`, "Tiltfile:2")
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestLocalDefaultTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no sleep on windows")
+	}
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.limits.LocalTimeout = 100 * time.Millisecond
+	f.file("Tiltfile", `
+local('sleep 10')
+`)
+
+	f.loadErrString(`command "sleep 10" timed out after 100ms and was killed`)
+}
+
+func TestLocalTimeoutOverridesDefault(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no sleep on windows")
+	}
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.limits.LocalTimeout = 100 * time.Millisecond
+	f.file("Tiltfile", `
+local('sleep 0.3', timeout='10s')
+`)
+
+	f.load()
+}
+
+func TestTiltfileTimeoutKillsLocal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no sleep on windows")
+	}
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.limits.Timeout = 200 * time.Millisecond
+	f.limits.LocalTimeout = 0
+	f.file("Tiltfile", `
+local('sleep 10')
+`)
+
+	start := time.Now()
+	f.loadErrString("Tiltfile execution timed out after 200ms and was aborted",
+		`command "sleep 10" failed`, "Tiltfile:2")
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestTiltfileTimeoutBusyLoop(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.limits.Timeout = 200 * time.Millisecond
+	f.file("Tiltfile", `
+def spin():
+  while True:
+    os.getcwd()
+
+spin()
+`)
+
+	f.loadErrString("Tiltfile execution timed out after 200ms and was aborted",
+		"Traceback (most recent call last)",
+		"Tiltfile:6:5: in <toplevel>",
+		"Tiltfile:4:14: in spin")
+}
+
+func TestTiltfileTimeoutLoopWithoutBuiltins(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	oldGracePeriod := execAbortGracePeriod
+	execAbortGracePeriod = 10 * time.Millisecond
+	defer func() { execAbortGracePeriod = oldGracePeriod }()
+
+	// The loop can't be interrupted, so make sure it ends eventually.
+	f.limits.Timeout = 100 * time.Millisecond
+	f.file("Tiltfile", `
+os.getcwd()
+def spin():
+  for i in range(100000000):
+    pass
+
+spin()
+`)
+
+	f.loadErrString("Tiltfile execution timed out after 100ms and was aborted",
+		"The Tiltfile didn't stop. It may be in a loop that never calls a builtin.",
+		"The last builtin it called was os.getcwd()",
+		"Tiltfile:2:10: in <toplevel>")
+}
+
+func TestTiltfileProgress(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no sleep on windows")
+	}
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.limits.ProgressInterval = 100 * time.Millisecond
+	f.file("Tiltfile", `
+local('sleep 0.5', quiet=True)
+`)
+
+	f.load()
+	assert.Contains(t, f.out.String(), "Tiltfile still executing (100ms): currently in local('sleep 0.5', ...) at "+
+		f.JoinPath("Tiltfile")+":2:6")
+}
@@ -4,6 +4,8 @@ import (
 	"go.starlark.net/starlark"
 
 	"github.com/tilt-dev/tilt/internal/feature"
+	"github.com/tilt-dev/tilt/internal/tiltfile/warnings"
+	"github.com/tilt-dev/tilt/pkg/model"
 )
 
 func (s *tiltfileState) enableFeature(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
@@ -18,7 +20,13 @@ func (s *tiltfileState) enableFeature(thread *starlark.Thread, fn *starlark.Buil
 		if _, ok := err.(feature.ObsoleteError); !ok {
 			return nil, err
 		}
-		s.logger.Warnf("%s", err.Error())
+		err = warnings.AddFromThread(thread, model.TiltfileWarning{
+			Key:     "feature:" + flag,
+			Message: err.Error(),
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return starlark.None, nil
@@ -36,7 +44,13 @@ func (s *tiltfileState) disableFeature(thread *starlark.Thread, fn *starlark.Bui
 		if _, ok := err.(feature.ObsoleteError); !ok {
 			return nil, err
 		}
-		s.logger.Warnf("%s", err.Error())
+		err = warnings.AddFromThread(thread, model.TiltfileWarning{
+			Key:     "feature:" + flag,
+			Message: err.Error(),
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return starlark.None, nil
@@ -53,7 +67,13 @@ func (s *tiltfileState) disableSnapshots(thread *starlark.Thread, fn *starlark.B
 		if _, ok := err.(feature.ObsoleteError); !ok {
 			return nil, err
 		}
-		s.logger.Warnf("%s", err.Error())
+		err = warnings.AddFromThread(thread, model.TiltfileWarning{
+			Key:     "feature:" + feature.Snapshots,
+			Message: err.Error(),
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return starlark.None, nil
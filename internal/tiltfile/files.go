@@ -2,6 +2,7 @@ package tiltfile
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -9,6 +10,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/tilt-dev/tilt/internal/k8s"
 	"github.com/tilt-dev/tilt/internal/localexec"
@@ -33,11 +35,14 @@ type execCommandOptions struct {
 	logCommand bool
 	// logCommandPrefix is a custom prefix before the command (default: "Running: ") used if logCommand is true.
 	logCommandPrefix string
+	// timeout kills the command if it runs longer (default: no timeout).
+	timeout time.Duration
 }
 
 func (s *tiltfileState) local(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 	var commandValue, commandBatValue, commandDirValue starlark.Value
 	var commandEnv value.StringStringMap
+	var timeout value.Duration
 	quiet := false
 	echoOff := false
 	err := s.unpackArgs(fn.Name(), args, kwargs,
@@ -47,6 +52,7 @@ func (s *tiltfileState) local(thread *starlark.Thread, fn *starlark.Builtin, arg
 		"echo_off", &echoOff,
 		"env", &commandEnv,
 		"dir?", &commandDirValue,
+		"timeout?", &timeout,
 	)
 	if err != nil {
 		return nil, err
@@ -57,10 +63,15 @@ func (s *tiltfileState) local(thread *starlark.Thread, fn *starlark.Builtin, arg
 		return nil, err
 	}
 
+	if timeout.IsZero() {
+		timeout = value.Duration(s.localTimeout)
+	}
+
 	out, err := s.execLocalCmd(thread, cmd, execCommandOptions{
 		logOutput:        !quiet,
 		logCommand:       !echoOff,
 		logCommandPrefix: "local:",
+		timeout:          timeout.AsDuration(),
 	})
 	if err != nil {
 		return nil, err
@@ -94,8 +105,20 @@ func (s *tiltfileState) execLocalCmd(t *starlark.Thread, cmd model.Cmd, options
 		runIO.Stderr = &stderrBuf
 	}
 
+	cmdCtx := ctx
+	if options.timeout > 0 {
+		var cancel context.CancelFunc
+		cmdCtx, cancel = context.WithTimeout(ctx, options.timeout)
+		defer cancel()
+	}
+
 	// TODO(nick): Should this also inject any docker.Env overrides?
-	exitCode, err := s.execer.Run(ctx, cmd, runIO)
+	exitCode, err := s.execer.Run(cmdCtx, cmd, runIO)
+	if cmdCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		// The execer kills the whole process group, so no children are left behind.
+		return "", fmt.Errorf("command %q timed out after %s and was killed. "+
+			"To give it longer, pass a timeout, e.g., local(..., timeout='10m')", cmd, options.timeout)
+	}
 	if err != nil || exitCode != 0 {
 		var errMessage strings.Builder
 		errMessage.WriteString(fmt.Sprintf("command %q failed.", cmd))
@@ -159,16 +182,19 @@ func (s *tiltfileState) kustomize(thread *starlark.Thread, fn *starlark.Builtin,
 	if err != nil {
 		return nil, err
 	}
-	deps, err := kustomize.Deps(absKustomizePath)
+	deps, err := kustomize.Resolve(absKustomizePath)
 	if err != nil {
 		return nil, fmt.Errorf("resolving deps: %v", err)
 	}
-	for _, d := range deps {
+	for _, d := range deps.Local {
 		err := tiltfile_io.RecordReadPath(thread, tiltfile_io.WatchRecursive, d)
 		if err != nil {
 			return nil, err
 		}
 	}
+	for _, r := range deps.Remote {
+		s.logger.Debugf("kustomize: not watching remote base %s", r)
+	}
 
 	return tiltfile_io.NewBlob(yaml, fmt.Sprintf("kustomize: %s", absKustomizePath)), nil
 }
@@ -214,7 +240,7 @@ func (s *tiltfileState) helm(thread *starlark.Thread, fn *starlark.Builtin, args
 		return nil, err
 	}
 	for _, d := range deps {
-		err = tiltfile_io.RecordReadPath(thread, tiltfile_io.WatchRecursive, starkit.AbsPath(thread, d))
+		err = tiltfile_io.RecordReadPath(thread, tiltfile_io.WatchRecursive, d)
 		if err != nil {
 			return nil, err
 		}
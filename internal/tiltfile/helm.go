@@ -123,17 +123,50 @@ func unableToFindHelmErrorMessage() error {
 	return fmt.Errorf("Unable to find Helm installation. Make sure `%s` is on your $PATH.", binaryName)
 }
 
+// Returns the directories of all the local subcharts that the chart at
+// chartPath depends on, transitively.
+//
+// Subcharts vendored under charts/ are already inside the chart directory.
+// Subcharts referenced with file:// can live anywhere, relative to the chart
+// that references them.
 func localSubchartDependenciesFromPath(chartPath string) ([]string, error) {
 	var deps []string
-	requirementsPath := filepath.Join(chartPath, "requirements.yaml")
-	dat, err := ioutil.ReadFile(requirementsPath)
-	if os.IsNotExist(err) {
-		return deps, nil
-	} else if err != nil {
-		return deps, err
+	visited := map[string]bool{chartPath: true}
+	queue := []string{chartPath}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		// Helm 2 charts list dependencies in requirements.yaml,
+		// Helm 3 charts list them in Chart.yaml.
+		for _, f := range []string{"requirements.yaml", "Chart.yaml"} {
+			dat, err := ioutil.ReadFile(filepath.Join(current, f))
+			if os.IsNotExist(err) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+
+			subcharts, err := localSubchartDependencies(dat)
+			if err != nil {
+				return nil, fmt.Errorf("reading %s: %v", filepath.Join(current, f), err)
+			}
+			for _, d := range subcharts {
+				abs := d
+				if !filepath.IsAbs(abs) {
+					abs = filepath.Join(current, d)
+				}
+				if visited[abs] {
+					continue
+				}
+				visited[abs] = true
+				deps = append(deps, abs)
+				queue = append(queue, abs)
+			}
+		}
 	}
 
-	return localSubchartDependencies(dat)
+	return deps, nil
 }
 
 type chartDependency struct {
@@ -327,6 +327,37 @@ func TestSubchartRemoteDependencies(t *testing.T) {
 	assert.Empty(t, actual)
 }
 
+func TestLocalSubchartDependenciesTransitive(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("helm/Chart.yaml", `apiVersion: v2
+name: app
+version: 0.1.0
+dependencies:
+- name: db
+  version: 0.1.0
+  repository: file://../charts/db
+- name: redis
+  version: 14.1.0
+  repository: https://charts.bitnami.com/bitnami`)
+	f.file("charts/db/requirements.yaml", `dependencies:
+- name: common
+  version: 0.1.0
+  repository: file://../common`)
+	f.file("charts/common/Chart.yaml", `apiVersion: v2
+name: common
+version: 0.1.0
+dependencies:
+- name: db
+  version: 0.1.0
+  repository: file://../db`)
+
+	actual, err := localSubchartDependenciesFromPath(f.JoinPath("helm"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{f.JoinPath("charts/db"), f.JoinPath("charts/common")}, actual)
+}
+
 func TestHelmReleaseName(t *testing.T) {
 	f := newFixture(t)
 	defer f.TearDown()
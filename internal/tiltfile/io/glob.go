@@ -0,0 +1,171 @@
+package io
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"go.starlark.net/starlark"
+
+	"github.com/tilt-dev/tilt/internal/dockerignore"
+	"github.com/tilt-dev/tilt/internal/tiltfile/starkit"
+	"github.com/tilt-dev/tilt/internal/tiltfile/value"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// A glob registered with watch_glob().
+//
+// Files that match the pattern (and none of the ignores) are config files:
+// changing, creating, or deleting one reloads the Tiltfile.
+type WatchedGlob struct {
+	// An absolute pattern, in .dockerignore syntax. A directory matches
+	// everything under it.
+	Pattern string
+
+	// Absolute patterns for files that don't count, even if they match.
+	Ignores []string
+
+	// The deepest directory that contains every possible match.
+	// Tilt watches it recursively.
+	BaseDir string
+
+	// The files that matched when the Tiltfile was loaded.
+	Matches []string
+}
+
+// A path matcher for the files that count as config files.
+func (g WatchedGlob) Matcher() (model.PathMatcher, error) {
+	return g.matcher()
+}
+
+func (g WatchedGlob) matcher() (globMatcher, error) {
+	include, err := dockerignore.NewDockerPatternMatcher(g.BaseDir, []string{g.Pattern})
+	if err != nil {
+		return globMatcher{}, err
+	}
+
+	var exclude model.PathMatcher = model.EmptyMatcher
+	if len(g.Ignores) > 0 {
+		exclude, err = dockerignore.NewDockerPatternMatcher(g.BaseDir, g.Ignores)
+		if err != nil {
+			return globMatcher{}, err
+		}
+	}
+	return globMatcher{include: include, exclude: exclude}, nil
+}
+
+type globMatcher struct {
+	include model.PathMatcher
+	exclude model.PathMatcher
+}
+
+func (m globMatcher) Matches(f string) (bool, error) {
+	ok, err := m.include.Matches(f)
+	if !ok || err != nil {
+		return false, err
+	}
+	ignored, err := m.exclude.Matches(f)
+	return !ignored, err
+}
+
+func (m globMatcher) MatchesEntireDir(f string) (bool, error) {
+	ok, err := m.include.MatchesEntireDir(f)
+	if !ok || err != nil {
+		return false, err
+	}
+	ignored, err := m.exclude.Matches(f)
+	return !ignored, err
+}
+
+func watchGlob(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var pattern starlark.String
+	var ignore value.StringOrStringList
+	err := starkit.UnpackArgs(thread, fn.Name(), args, kwargs,
+		"pattern", &pattern,
+		"ignore?", &ignore)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := newWatchedGlob(starkit.AbsWorkingDir(thread), pattern.GoString(), ignore.Values)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fn.Name(), err)
+	}
+
+	err = starkit.SetState(thread, func(s ReadState) ReadState {
+		s.Globs = append(s.Globs, g)
+		return s
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return starlark.None, nil
+}
+
+func newWatchedGlob(wd string, pattern string, ignores []string) (WatchedGlob, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return WatchedGlob{}, fmt.Errorf("pattern must not be empty")
+	}
+
+	g := WatchedGlob{Pattern: absPattern(wd, pattern)}
+	g.BaseDir = globBaseDir(g.Pattern)
+	for _, ig := range ignores {
+		g.Ignores = append(g.Ignores, absPattern(wd, ig))
+	}
+
+	m, err := g.matcher()
+	if err != nil {
+		return WatchedGlob{}, err
+	}
+
+	// Walk the tree once now. After this, we only match the paths
+	// that the file watcher tells us about.
+	err = filepath.WalkDir(g.BaseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == g.BaseDir {
+				// Nothing matches yet, but we still watch for new files.
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			ignored, err := m.exclude.MatchesEntireDir(path)
+			if ignored && path != g.BaseDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		ok, err := m.Matches(path)
+		if err != nil {
+			return err
+		}
+		if ok {
+			g.Matches = append(g.Matches, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return WatchedGlob{}, err
+	}
+	return g, nil
+}
+
+func absPattern(wd string, p string) string {
+	if filepath.IsAbs(p) {
+		return filepath.Clean(p)
+	}
+	return filepath.Join(wd, p)
+}
+
+// Returns the path up to the first segment with a wildcard in it.
+func globBaseDir(pattern string) string {
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	for i, s := range segments {
+		if strings.ContainsAny(s, "*?[") {
+			return filepath.Clean(filepath.FromSlash(strings.Join(segments[:i], "/") + "/"))
+		}
+	}
+	return pattern
+}
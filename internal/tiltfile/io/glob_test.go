@@ -0,0 +1,125 @@
+package io
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchGlob(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.File("config/a.yaml", "a")
+	f.File("config/nested/b.yaml", "b")
+	f.File("config/c.json", "c")
+	f.File("Tiltfile", `
+watch_glob('config/**/*.yaml')
+`)
+
+	result, err := f.ExecFile("Tiltfile")
+	require.NoError(t, err)
+
+	globs := MustState(result).Globs
+	require.Len(t, globs, 1)
+	assert.Equal(t, f.JoinPath("config/**/*.yaml"), globs[0].Pattern)
+	assert.Equal(t, f.JoinPath("config"), globs[0].BaseDir)
+	assert.ElementsMatch(t, []string{
+		f.JoinPath("config/a.yaml"),
+		f.JoinPath("config/nested/b.yaml"),
+	}, globs[0].Matches)
+
+	m, err := globs[0].Matcher()
+	require.NoError(t, err)
+	ok, err := m.Matches(f.JoinPath("config/new/d.yaml"))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = m.Matches(f.JoinPath("config/d.json"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestWatchGlobDirectory(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.File("config/a.yaml", "a")
+	f.File("config/nested/b.json", "b")
+	f.File("Tiltfile", `
+watch_glob('config')
+`)
+
+	result, err := f.ExecFile("Tiltfile")
+	require.NoError(t, err)
+
+	globs := MustState(result).Globs
+	require.Len(t, globs, 1)
+	assert.Equal(t, f.JoinPath("config"), globs[0].BaseDir)
+	assert.ElementsMatch(t, []string{
+		f.JoinPath("config/a.yaml"),
+		f.JoinPath("config/nested/b.json"),
+	}, globs[0].Matches)
+}
+
+func TestWatchGlobIgnore(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.File("config/a.yaml", "a")
+	f.File("config/generated/b.yaml", "b")
+	f.File("config/c.yaml.bak", "c")
+	f.File("Tiltfile", `
+watch_glob('config/**/*.yaml*', ignore=['config/generated', '**/*.bak'])
+`)
+
+	result, err := f.ExecFile("Tiltfile")
+	require.NoError(t, err)
+
+	globs := MustState(result).Globs
+	require.Len(t, globs, 1)
+	assert.Equal(t, []string{f.JoinPath("config/a.yaml")}, globs[0].Matches)
+
+	m, err := globs[0].Matcher()
+	require.NoError(t, err)
+	ok, err := m.Matches(f.JoinPath("config/generated/new.yaml"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestWatchGlobMissingBaseDir(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.File("Tiltfile", `
+watch_glob('dne/*.yaml')
+`)
+
+	result, err := f.ExecFile("Tiltfile")
+	require.NoError(t, err)
+
+	globs := MustState(result).Globs
+	require.Len(t, globs, 1)
+	assert.Equal(t, f.JoinPath("dne"), globs[0].BaseDir)
+	assert.Empty(t, globs[0].Matches)
+}
+
+func TestWatchGlobEmptyPattern(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.File("Tiltfile", `
+watch_glob('')
+`)
+
+	_, err := f.ExecFile("Tiltfile")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "watch_glob: pattern must not be empty")
+}
+
+func TestGlobBaseDir(t *testing.T) {
+	assert.Equal(t, "/a/b", globBaseDir("/a/b/*.yaml"))
+	assert.Equal(t, "/a", globBaseDir("/a/b*/c.yaml"))
+	assert.Equal(t, "/a/b", globBaseDir("/a/b/**/[cd].yaml"))
+	assert.Equal(t, "/a/b/c.yaml", globBaseDir("/a/b/c.yaml"))
+}
@@ -46,6 +46,11 @@ func (Plugin) OnStart(e *starkit.Environment) error {
 		return err
 	}
 
+	err = e.AddBuiltin("watch_glob", watchGlob)
+	if err != nil {
+		return err
+	}
+
 	err = e.AddBuiltin("listdir", listdir)
 	if err != nil {
 		return err
@@ -176,6 +181,9 @@ func blob(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kw
 // Track all the paths read while loading
 type ReadState struct {
 	Paths []string
+
+	// Globs registered with watch_glob().
+	Globs []WatchedGlob
 }
 
 func ReadFile(thread *starlark.Thread, p string) ([]byte, error) {
@@ -3,9 +3,11 @@ package tiltfile
 import (
 	"fmt"
 	"net/url"
+	"os"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/docker/distribution/reference"
 	"github.com/pkg/errors"
@@ -22,6 +24,7 @@ import (
 	tiltfile_k8s "github.com/tilt-dev/tilt/internal/tiltfile/k8s"
 	"github.com/tilt-dev/tilt/internal/tiltfile/value"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/logger"
 	"github.com/tilt-dev/tilt/pkg/model"
 )
 
@@ -54,10 +57,23 @@ type k8sResource struct {
 
 	discoveryStrategy v1alpha1.KubernetesDiscoveryStrategy
 
+	applyDiscipline v1alpha1.KubernetesApplyDiscipline
+
+	// if non-empty, the namespace to deploy all the namespaced objects into,
+	// regardless of what the YAML says
+	namespace string
+
+	// how to read log levels from the pod logs
+	logLevelFormat logger.LevelFormat
+
 	dependencyIDs []model.TargetID
 
 	triggerMode triggerMode
 	autoInit    bool
+	watchInCI   bool
+
+	// nil uses the global build timeout.
+	buildTimeout *time.Duration
 
 	resourceDeps []string
 
@@ -79,12 +95,17 @@ type k8sResourceOptions struct {
 	extraPodSelectors []labels.Set
 	triggerMode       triggerMode
 	autoInit          value.BoolOrNone
+	watchInCI         value.BoolOrNone
+	buildTimeout      *time.Duration
 	tiltfilePosition  syntax.Position
 	resourceDeps      []string
 	objects           []string
 	manuallyGrouped   bool
 	podReadinessMode  model.PodReadinessMode
 	discoveryStrategy v1alpha1.KubernetesDiscoveryStrategy
+	applyDiscipline   v1alpha1.KubernetesApplyDiscipline
+	namespace         string
+	logLevelFormat    logger.LevelFormat
 	links             []model.Link
 	labels            map[string]string
 }
@@ -274,8 +295,13 @@ func (s *tiltfileState) k8sResource(thread *starlark.Thread, fn *starlark.Builti
 	var podReadinessMode tiltfile_k8s.PodReadinessMode
 	var links links.LinkList
 	var autoInit = value.BoolOrNone{Value: true}
+	var watchInCI value.BoolOrNone
+	var buildTimeoutSecs value.IntOrNone
 	var labels value.LabelSet
 	var discoveryStrategy tiltfile_k8s.DiscoveryStrategy
+	var applyDiscipline tiltfile_k8s.ApplyDiscipline
+	var namespace string
+	var logLevelFormat string
 
 	if err := s.unpackArgs(fn.Name(), args, kwargs,
 		"workload?", &workload,
@@ -290,10 +316,25 @@ func (s *tiltfileState) k8sResource(thread *starlark.Thread, fn *starlark.Builti
 		"links?", &links,
 		"labels?", &labels,
 		"discovery_strategy?", &discoveryStrategy,
+		"watch_in_ci?", &watchInCI,
+		"apply_discipline?", &applyDiscipline,
+		"namespace?", &namespace,
+		"log_level_format?", &logLevelFormat,
+		"build_timeout_secs?", &buildTimeoutSecs,
 	); err != nil {
 		return nil, err
 	}
 
+	levelFormat, err := logger.ParseLevelFormat(logLevelFormat)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s: log_level_format", fn.Name())
+	}
+
+	buildTimeout, err := buildTimeoutFromValue(fn.Name(), buildTimeoutSecs)
+	if err != nil {
+		return nil, err
+	}
+
 	resourceName := workload.String()
 	manuallyGrouped := false
 	if workload == "" {
@@ -343,6 +384,8 @@ func (s *tiltfileState) k8sResource(thread *starlark.Thread, fn *starlark.Builti
 		tiltfilePosition:  thread.CallFrame(1).Pos,
 		triggerMode:       triggerMode,
 		autoInit:          autoInit,
+		watchInCI:         watchInCI,
+		buildTimeout:      buildTimeout,
 		resourceDeps:      resourceDeps,
 		objects:           objects,
 		manuallyGrouped:   manuallyGrouped,
@@ -350,6 +393,9 @@ func (s *tiltfileState) k8sResource(thread *starlark.Thread, fn *starlark.Builti
 		links:             links.Links,
 		labels:            labelMap,
 		discoveryStrategy: v1alpha1.KubernetesDiscoveryStrategy(discoveryStrategy),
+		applyDiscipline:   v1alpha1.KubernetesApplyDiscipline(applyDiscipline),
+		namespace:         os.ExpandEnv(namespace),
+		logLevelFormat:    levelFormat,
 	})
 
 	return starlark.None, nil
@@ -730,7 +776,8 @@ func convertPortForwards(val starlark.Value) ([]model.PortForward, error) {
 
 func (s *tiltfileState) portForward(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 	var local, container int
-	var name, path, host string
+	var name, path, host, verifyPath string
+	var verify bool
 
 	// TODO: can specify host (see `stringToPortForward` for host validation logic)
 	if err := s.unpackArgs(fn.Name(), args, kwargs,
@@ -738,7 +785,9 @@ func (s *tiltfileState) portForward(thread *starlark.Thread, fn *starlark.Builti
 		"container_port?", &container,
 		"name?", &name,
 		"link_path?", &path,
-		"host?", &host); err != nil {
+		"host?", &host,
+		"verify?", &verify,
+		"verify_path?", &verifyPath); err != nil {
 		return nil, err
 	}
 
@@ -750,8 +799,14 @@ func (s *tiltfileState) portForward(thread *starlark.Thread, fn *starlark.Builti
 			return portForward{}, errors.Wrapf(err, "parsing `path` param")
 		}
 	}
+
+	// A verify_path implies an HTTP check; verify=True on its own is a TCP check.
+	var v *v1alpha1.ForwardVerify
+	if verify || verifyPath != "" {
+		v = &v1alpha1.ForwardVerify{HTTPPath: verifyPath}
+	}
 	return portForward{
-		model.PortForward{LocalPort: local, ContainerPort: container, Host: host, Name: name}.WithPath(parsedPath),
+		model.PortForward{LocalPort: local, ContainerPort: container, Host: host, Name: name, Verify: v}.WithPath(parsedPath),
 	}, nil
 }
 
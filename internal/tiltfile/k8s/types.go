@@ -30,3 +30,25 @@ func (ds *DiscoveryStrategy) Unpack(v starlark.Value) error {
 	*ds = DiscoveryStrategy(kdStrategy)
 	return nil
 }
+
+// Deserializing apply discipline from starlark values.
+type ApplyDiscipline v1alpha1.KubernetesApplyDiscipline
+
+func (ad *ApplyDiscipline) Unpack(v starlark.Value) error {
+	s, ok := value.AsString(v)
+	if !ok {
+		return fmt.Errorf("Must be a string. Got: %s", v.Type())
+	}
+
+	discipline := v1alpha1.KubernetesApplyDiscipline(s)
+	if !(discipline == "" ||
+		discipline == v1alpha1.KubernetesApplyDisciplineDefault ||
+		discipline == v1alpha1.KubernetesApplyDisciplineInfrastructure) {
+		return fmt.Errorf("Invalid. Must be one of: %q, %q",
+			v1alpha1.KubernetesApplyDisciplineDefault,
+			v1alpha1.KubernetesApplyDisciplineInfrastructure)
+	}
+
+	*ad = ApplyDiscipline(discipline)
+	return nil
+}
@@ -0,0 +1,92 @@
+package tiltfile
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"go.starlark.net/starlark"
+
+	"github.com/tilt-dev/tilt/internal/tiltfile/value"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func (s *tiltfileState) k8sDebugOverride(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var resource, container string
+	var commandVal, argsVal, portsVal starlark.Value
+	var removeReadinessProbe bool
+
+	if err := s.unpackArgs(fn.Name(), args, kwargs,
+		"resource", &resource,
+		"command?", &commandVal,
+		"args?", &argsVal,
+		"container?", &container,
+		"ports?", &portsVal,
+		"remove_readiness_probe?", &removeReadinessProbe,
+	); err != nil {
+		return nil, err
+	}
+
+	o := model.K8sDebugOverride{
+		Container:            container,
+		RemoveReadinessProbe: removeReadinessProbe,
+	}
+
+	if commandVal != nil && commandVal != starlark.None {
+		cmd, err := value.ValueToUnixCmd(thread, commandVal, nil, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s: command", fn.Name())
+		}
+		o.Command = cmd.Argv
+		if o.Command == nil {
+			o.Command = []string{}
+		}
+	}
+
+	if argsVal != nil && argsVal != starlark.None {
+		var argList value.StringList
+		if err := argList.Unpack(argsVal); err != nil {
+			return nil, errors.Wrapf(err, "%s: args", fn.Name())
+		}
+		o.Args = append([]string{}, argList...)
+	}
+
+	ports, err := debugOverridePorts(portsVal)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s: ports", fn.Name())
+	}
+	o.Ports = ports
+
+	if _, ok := s.k8sDebugOverrides[resource]; ok {
+		return nil, fmt.Errorf("%s: resource %q already has a debug override", fn.Name(), resource)
+	}
+	s.k8sDebugOverrides[resource] = o
+	return starlark.None, nil
+}
+
+func debugOverridePorts(v starlark.Value) ([]int32, error) {
+	if v == nil || v == starlark.None {
+		return nil, nil
+	}
+
+	seq, ok := v.(starlark.Sequence)
+	if !ok {
+		return nil, fmt.Errorf("expected a list of ints, got %s", v.Type())
+	}
+
+	var result []int32
+	iter := seq.Iterate()
+	defer iter.Done()
+	var item starlark.Value
+	for iter.Next(&item) {
+		var port value.Int32
+		if err := port.Unpack(item); err != nil {
+			return nil, err
+		}
+		p := port.Int32()
+		if p <= 0 || p > 65535 {
+			return nil, fmt.Errorf("invalid port %d", p)
+		}
+		result = append(result, p)
+	}
+	return result, nil
+}
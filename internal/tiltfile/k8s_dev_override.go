@@ -0,0 +1,251 @@
+package tiltfile
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"go.starlark.net/starlark"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/tiltfile/value"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func (s *tiltfileState) k8sDevOverride(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var resource string
+	var replicasVal, tolerationsVal, resourcesVal, containerResourcesVal starlark.Value
+	var nodeSelector value.StringStringMap
+
+	if err := s.unpackArgs(fn.Name(), args, kwargs,
+		"resource", &resource,
+		"replicas?", &replicasVal,
+		"node_selector?", &nodeSelector,
+		"tolerations?", &tolerationsVal,
+		"resources?", &resourcesVal,
+		"container_resources?", &containerResourcesVal,
+	); err != nil {
+		return nil, err
+	}
+
+	o := model.K8sDevOverride{}
+	if len(nodeSelector) > 0 {
+		o.NodeSelector = nodeSelector.AsMap()
+	}
+
+	if replicasVal != nil && replicasVal != starlark.None {
+		var replicas value.Int32
+		if err := replicas.Unpack(replicasVal); err != nil {
+			return nil, errors.Wrapf(err, "%s: replicas", fn.Name())
+		}
+		r := replicas.Int32()
+		if r < 0 {
+			return nil, fmt.Errorf("%s: replicas must not be negative, got %d", fn.Name(), r)
+		}
+		o.Replicas = &r
+	}
+
+	tolerations, err := devOverrideTolerations(tolerationsVal)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s: tolerations", fn.Name())
+	}
+	o.Tolerations = tolerations
+
+	if resourcesVal != nil && resourcesVal != starlark.None {
+		r, err := devOverrideResources(resourcesVal)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s: resources", fn.Name())
+		}
+		o.Resources = &r
+	}
+
+	if containerResourcesVal != nil && containerResourcesVal != starlark.None {
+		d, ok := containerResourcesVal.(*starlark.Dict)
+		if !ok {
+			return nil, fmt.Errorf("%s: container_resources: expected a dict of container names to resources, got %s",
+				fn.Name(), containerResourcesVal.Type())
+		}
+		o.ContainerResources = make(map[string]v1.ResourceRequirements)
+		for _, item := range d.Items() {
+			name, ok := value.AsString(item[0])
+			if !ok {
+				return nil, fmt.Errorf("%s: container_resources: container name is not a string: %s", fn.Name(), item[0])
+			}
+			r, err := devOverrideResources(item[1])
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s: container_resources[%q]", fn.Name(), name)
+			}
+			o.ContainerResources[name] = r
+		}
+	}
+
+	if _, ok := s.k8sDevOverrides[resource]; ok {
+		return nil, fmt.Errorf("%s: resource %q already has a dev override", fn.Name(), resource)
+	}
+	s.k8sDevOverrides[resource] = o
+	return starlark.None, nil
+}
+
+// Patches the resource's objects with its dev override.
+func (s *tiltfileState) injectDevOverride(r *k8sResource, o model.K8sDevOverride) error {
+	containers := make(map[string]bool)
+	entities := make([]k8s.K8sEntity, 0, len(r.entities))
+	for _, e := range r.entities {
+		newEntity, _, err := k8s.InjectDevOverride(e, o)
+		if err != nil {
+			return err
+		}
+		entities = append(entities, newEntity)
+
+		pods, err := k8s.ExtractPods(&newEntity)
+		if err != nil {
+			return err
+		}
+		for _, pod := range pods {
+			for _, c := range pod.InitContainers {
+				containers[c.Name] = true
+			}
+			for _, c := range pod.Containers {
+				containers[c.Name] = true
+			}
+		}
+	}
+
+	var missing []string
+	for name := range o.ContainerResources {
+		if !containers[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("%s: resource %q has no container named %q", k8sDevOverrideN, r.name, missing[0])
+	}
+
+	s.logger.Infof("%s: %s deploys with changes that aren't in its YAML: %s", k8sDevOverrideN, r.name, o)
+	r.entities = entities
+	return nil
+}
+
+// Parses a dict like {'requests': {'cpu': '100m'}, 'limits': {'memory': '1Gi'}}
+func devOverrideResources(v starlark.Value) (v1.ResourceRequirements, error) {
+	d, ok := v.(*starlark.Dict)
+	if !ok {
+		return v1.ResourceRequirements{}, fmt.Errorf("expected a dict with requests and limits, got %s", v.Type())
+	}
+
+	var result v1.ResourceRequirements
+	for _, item := range d.Items() {
+		key, _ := value.AsString(item[0])
+		list, err := devOverrideResourceList(item[1])
+		if err != nil {
+			return v1.ResourceRequirements{}, errors.Wrap(err, key)
+		}
+		switch key {
+		case "requests":
+			result.Requests = list
+		case "limits":
+			result.Limits = list
+		default:
+			return v1.ResourceRequirements{}, fmt.Errorf("unexpected key %s (expected requests or limits)", item[0])
+		}
+	}
+	return result, nil
+}
+
+func devOverrideResourceList(v starlark.Value) (v1.ResourceList, error) {
+	d, ok := v.(*starlark.Dict)
+	if !ok {
+		return nil, fmt.Errorf("expected a dict of resource names to quantities, got %s", v.Type())
+	}
+
+	result := make(v1.ResourceList)
+	for _, item := range d.Items() {
+		name, ok := value.AsString(item[0])
+		if !ok {
+			return nil, fmt.Errorf("resource name is not a string: %s", item[0])
+		}
+
+		var s string
+		switch q := item[1].(type) {
+		case starlark.Int:
+			s = q.String()
+		default:
+			s, ok = value.AsString(q)
+			if !ok {
+				return nil, fmt.Errorf("%s: expected a quantity like '100m' or '1Gi', got %s", name, q.Type())
+			}
+		}
+
+		quantity, err := resource.ParseQuantity(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid quantity %q", name, s)
+		}
+		result[v1.ResourceName(name)] = quantity
+	}
+	return result, nil
+}
+
+// Parses a list of dicts like {'key': 'dedicated', 'operator': 'Exists', 'effect': 'NoSchedule'}
+func devOverrideTolerations(v starlark.Value) ([]v1.Toleration, error) {
+	if v == nil || v == starlark.None {
+		return nil, nil
+	}
+
+	seq, ok := v.(starlark.Sequence)
+	if !ok {
+		return nil, fmt.Errorf("expected a list of dicts, got %s", v.Type())
+	}
+
+	var result []v1.Toleration
+	iter := seq.Iterate()
+	defer iter.Done()
+	var item starlark.Value
+	for iter.Next(&item) {
+		d, ok := item.(*starlark.Dict)
+		if !ok {
+			return nil, fmt.Errorf("expected a dict, got %s", item.Type())
+		}
+
+		var t v1.Toleration
+		for _, kv := range d.Items() {
+			key, _ := value.AsString(kv[0])
+			if key == "toleration_seconds" {
+				var seconds value.Int32
+				if err := seconds.Unpack(kv[1]); err != nil {
+					return nil, errors.Wrap(err, key)
+				}
+				s := int64(seconds.Int32())
+				t.TolerationSeconds = &s
+				continue
+			}
+
+			val, ok := value.AsString(kv[1])
+			if !ok {
+				return nil, fmt.Errorf("%s: expected a string, got %s", key, kv[1].Type())
+			}
+			switch key {
+			case "key":
+				t.Key = val
+			case "operator":
+				t.Operator = v1.TolerationOperator(val)
+			case "value":
+				t.Value = val
+			case "effect":
+				t.Effect = v1.TaintEffect(val)
+			default:
+				return nil, fmt.Errorf("unexpected key %s (expected key, operator, value, effect, or toleration_seconds)", kv[0])
+			}
+		}
+
+		switch t.Operator {
+		case "", v1.TolerationOpEqual, v1.TolerationOpExists:
+		default:
+			return nil, fmt.Errorf("operator must be Equal or Exists, got %q", t.Operator)
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
@@ -0,0 +1,135 @@
+package tiltfile
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+type sharedK8sObject struct {
+	yaml    string
+	owner   model.ManifestName
+	sharers []model.ManifestName
+}
+
+// Some resources intentionally declare the same object, e.g., a ConfigMap
+// that two servers read (with k8s_yaml(..., allow_duplicates=True)).
+//
+// If each resource applied its own copy, Tilt would apply the object several
+// times per update, and the later applies could clobber the earlier ones.
+// So the first resource that declares the object applies it, and the others
+// only record that they share it.
+//
+// Every resource must declare the object identically, so that it doesn't
+// matter which one applies it.
+func shareK8sObjects(l logger.Logger, manifests []model.Manifest) ([]model.Manifest, error) {
+	objects := make(map[k8sObjectID]*sharedK8sObject)
+	var ids []k8sObjectID
+	var names []string
+
+	result := make([]model.Manifest, 0, len(manifests))
+	for _, m := range manifests {
+		if !m.IsK8s() || m.K8sTarget().YAML == "" {
+			result = append(result, m)
+			continue
+		}
+
+		kTarget := m.K8sTarget()
+		entities, err := k8s.ParseYAMLFromString(kTarget.YAML)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing YAML for %s", m.Name)
+		}
+
+		var kept []k8s.K8sEntity
+		var shared []model.SharedK8sObject
+		for _, e := range entities {
+			yaml, err := k8s.SerializeSpecYAML([]k8s.K8sEntity{e})
+			if err != nil {
+				return nil, errors.Wrapf(err, "serializing %s", fullNameFromK8sEntity(e))
+			}
+
+			id := newK8sObjectID(e)
+			obj, ok := objects[id]
+			if !ok {
+				objects[id] = &sharedK8sObject{yaml: yaml, owner: m.Name}
+				ids = append(ids, id)
+				names = append(names, fullNameFromK8sEntity(e))
+				kept = append(kept, e)
+				continue
+			}
+
+			if obj.yaml != yaml {
+				return nil, fmt.Errorf("Resources %q and %q both declare %s, but with different YAML. "+
+					"Objects shared between resources must be identical.",
+					obj.owner, m.Name, fullNameFromK8sEntity(e))
+			}
+
+			if obj.owner == m.Name || m.Name == model.UnresourcedYAMLManifestName {
+				// Another copy in the same resource, or a copy that no resource claimed.
+				continue
+			}
+
+			obj.sharers = append(obj.sharers, m.Name)
+			shared = append(shared, model.SharedK8sObject{Ref: e.ToObjectReference(), Owner: obj.owner})
+		}
+
+		if len(kept) == len(entities) {
+			result = append(result, m)
+			continue
+		}
+
+		if len(kept) == 0 {
+			if m.Name == model.UnresourcedYAMLManifestName {
+				// Only leftover copies of objects that other resources apply.
+				continue
+			}
+			return nil, fmt.Errorf("Resource %q only declares objects that other resources apply. "+
+				"Give it an object of its own, or remove it.", m.Name)
+		}
+
+		kTarget.YAML, err = k8s.SerializeSpecYAML(kept)
+		if err != nil {
+			return nil, errors.Wrapf(err, "serializing YAML for %s", m.Name)
+		}
+		result = append(result, m.WithDeployTarget(kTarget.WithSharedObjects(shared)))
+	}
+
+	for i, id := range ids {
+		obj := objects[id]
+		if len(obj.sharers) == 0 {
+			continue
+		}
+		l.Infof("%s is shared by resources %s and %s. Applying it with %s.",
+			names[i], obj.owner, manifestNameList(obj.sharers), obj.owner)
+	}
+
+	return result, nil
+}
+
+// Drops extra copies of the same object.
+func uniqueK8sObjects(entities []k8s.K8sEntity) []k8s.K8sEntity {
+	seen := make(map[k8sObjectID]bool, len(entities))
+	result := make([]k8s.K8sEntity, 0, len(entities))
+	for _, e := range entities {
+		id := newK8sObjectID(e)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, e)
+	}
+	return result
+}
+
+func manifestNameList(names []model.ManifestName) string {
+	strs := make([]string, len(names))
+	for i, n := range names {
+		strs[i] = n.String()
+	}
+	return strings.Join(strs, ", ")
+}
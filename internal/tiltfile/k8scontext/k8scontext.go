@@ -1,14 +1,20 @@
 package k8scontext
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"go.starlark.net/starlark"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
 
 	"github.com/tilt-dev/tilt/internal/k8s"
 	"github.com/tilt-dev/tilt/internal/tiltfile/starkit"
 	"github.com/tilt-dev/tilt/internal/tiltfile/value"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/logger"
 	"github.com/tilt-dev/tilt/pkg/model"
 )
 
@@ -17,6 +23,14 @@ import (
 type Plugin struct {
 	context k8s.KubeContext
 	env     k8s.Env
+
+	// Reads the kubeconfig as it is now. Nil in tests.
+	loadConfig  func() (*api.Config, error)
+	configPaths []string
+
+	// Filled in by Reload.
+	server            string
+	kubeconfigContext k8s.KubeContext
 }
 
 func NewPlugin(context k8s.KubeContext, env k8s.Env) Plugin {
@@ -26,8 +40,56 @@ func NewPlugin(context k8s.KubeContext, env k8s.Env) Plugin {
 	}
 }
 
+// Returns a plugin that re-reads the kubeconfig on Reload.
+func ProvidePlugin(context k8s.KubeContext, env k8s.Env, clientLoader clientcmd.ClientConfig, contextOverride k8s.KubeContextOverride) Plugin {
+	return Plugin{
+		context: context,
+		env:     env,
+		loadConfig: func() (*api.Config, error) {
+			return k8s.ProvideKubeConfig(clientLoader, contextOverride)
+		},
+		configPaths: clientLoader.ConfigAccess().GetLoadingPrecedence(),
+	}
+}
+
+// Re-reads the kubeconfig, so that the safety check sees edits made
+// since Tilt started (e.g., a renamed cluster).
+//
+// Tilt stays connected to the context it started with, so we classify that
+// context, even if the kubeconfig's current-context has moved on.
+func (e Plugin) Reload(ctx context.Context) Plugin {
+	if e.loadConfig == nil {
+		return e
+	}
+
+	config, err := e.loadConfig()
+	if err != nil {
+		logger.Get(ctx).Debugf("Reloading kubeconfig: %v", err)
+		return e
+	}
+
+	e.kubeconfigContext = k8s.KubeContext(config.CurrentContext)
+
+	connected := *config
+	connected.CurrentContext = string(e.context)
+	e.env = k8s.ProvideEnv(ctx, &connected)
+	e.server = ""
+	if c, ok := connected.Contexts[string(e.context)]; ok {
+		if cluster, ok := connected.Clusters[c.Cluster]; ok {
+			e.server = cluster.Server
+		}
+	}
+	return e
+}
+
 func (e Plugin) NewState() interface{} {
-	return State{context: e.context, env: e.env}
+	return State{
+		context:           e.context,
+		env:               e.env,
+		server:            e.server,
+		kubeconfigContext: e.kubeconfigContext,
+		configPaths:       e.configPaths,
+	}
 }
 
 func (e Plugin) OnStart(env *starkit.Environment) error {
@@ -67,11 +129,8 @@ func (e Plugin) allowK8sContexts(thread *starlark.Thread, fn *starlark.Builtin,
 	}
 
 	err := starkit.SetState(thread, func(existing State) State {
-		return State{
-			context: existing.context,
-			env:     existing.env,
-			allowed: append(newContexts, existing.allowed...),
-		}
+		existing.allowed = append(newContexts, existing.allowed...)
+		return existing
 	})
 
 	return starlark.None, err
@@ -83,12 +142,68 @@ type State struct {
 	context k8s.KubeContext
 	env     k8s.Env
 	allowed []k8s.KubeContext
+
+	server            string
+	kubeconfigContext k8s.KubeContext
+	configPaths       []string
 }
 
 func (s State) KubeContext() k8s.KubeContext {
 	return s.context
 }
 
+// The kubeconfig files to watch while we're refusing this context,
+// so that fixing the kubeconfig re-runs the check.
+func (s State) ConfigPaths() []string {
+	return append([]string(nil), s.configPaths...)
+}
+
+// Explains why we refused a context that might be production, and what
+// to do about it.
+//
+// The headline says what we refused to do. The allow_k8s_contexts line
+// goes `where` in the Tiltfile.
+func (s State) NotAllowedError(headline string, where string) error {
+	var b strings.Builder
+	b.WriteString(headline)
+	b.WriteString("\n\n")
+
+	server := s.server
+	if server == "" {
+		server = "(unknown)"
+	}
+	allowed := "(none)"
+	if len(s.allowed) > 0 {
+		var names []string
+		for _, c := range s.allowed {
+			names = append(names, string(c))
+		}
+		allowed = strings.Join(names, ", ")
+	}
+	fmt.Fprintf(&b, "  Current context:  %s\n", s.context)
+	fmt.Fprintf(&b, "  Cluster server:   %s\n", server)
+	fmt.Fprintf(&b, "  Detected env:     %s (not a known dev cluster)\n", s.env)
+	fmt.Fprintf(&b, "  Allowed contexts: %s\n", allowed)
+
+	if s.kubeconfigContext != "" && s.kubeconfigContext != s.context {
+		fmt.Fprintf(&b, "\nYour kubeconfig now points at '%s', but this Tilt is still connected to '%s'.\n",
+			s.kubeconfigContext, s.context)
+	}
+
+	switchTo := "<dev-context>"
+	if len(s.allowed) > 0 {
+		switchTo = string(s.allowed[0])
+	}
+	fmt.Fprintf(&b, `
+If you're sure you want to use this context, add:
+	allow_k8s_contexts('%s')
+%s. Otherwise, switch k8s contexts and restart Tilt:
+	kubectl config use-context %s
+or pick a context for this session only:
+	tilt up --context=%s`, s.context, where, switchTo, switchTo)
+	return errors.New(b.String())
+}
+
 // Returns whether we're allowed to deploy to this kubecontext.
 //
 // Checks against a manually specified list and a baked-in list
@@ -4,8 +4,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/clientcmd/api"
 
 	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/internal/testutils"
 	"github.com/tilt-dev/tilt/internal/tiltfile/starkit"
 )
 
@@ -37,6 +39,69 @@ func TestForbidK8sContext(t *testing.T) {
 	assert.True(t, MustState(model).IsAllowed(f.Tiltfile()))
 }
 
+func TestNotAllowedError(t *testing.T) {
+	f := NewFixture(t, "gke-blorg", k8s.EnvGKE)
+	f.File("Tiltfile", `
+allow_k8s_contexts(['dev-a', 'dev-b'])
+`)
+	model, err := f.ExecFile("Tiltfile")
+	assert.NoError(t, err)
+
+	err = MustState(model).NotAllowedError("Stop! gke-blorg might be production.", "to your Tiltfile")
+	assert.Equal(t, `Stop! gke-blorg might be production.
+
+  Current context:  gke-blorg
+  Cluster server:   (unknown)
+  Detected env:     gke (not a known dev cluster)
+  Allowed contexts: dev-a, dev-b
+
+If you're sure you want to use this context, add:
+	allow_k8s_contexts('gke-blorg')
+to your Tiltfile. Otherwise, switch k8s contexts and restart Tilt:
+	kubectl config use-context dev-a
+or pick a context for this session only:
+	tilt up --context=dev-a`, err.Error())
+}
+
+func TestReload(t *testing.T) {
+	ctx, _, _ := testutils.CtxAndAnalyticsForTest()
+	config := &api.Config{
+		CurrentContext: "prod",
+		Contexts: map[string]*api.Context{
+			"prod":  {Cluster: "gke_acme_prod"},
+			"dev-a": {Cluster: "kind-dev-a"},
+		},
+		Clusters: map[string]*api.Cluster{
+			"gke_acme_prod": {Server: "https://prod.example.com"},
+			"kind-dev-a":    {Server: "https://127.0.0.1:6443"},
+		},
+	}
+	p := Plugin{
+		context: "prod",
+		env:     k8s.EnvGKE,
+		loadConfig: func() (*api.Config, error) {
+			return config, nil
+		},
+	}
+
+	state := p.Reload(ctx).NewState().(State)
+	assert.Equal(t, "https://prod.example.com", state.server)
+	assert.Equal(t, k8s.EnvGKE, state.env)
+
+	// Switching contexts in the kubeconfig doesn't change what we're connected to.
+	config.CurrentContext = "dev-a"
+	state = p.Reload(ctx).NewState().(State)
+	assert.Equal(t, k8s.EnvGKE, state.env)
+	assert.Contains(t, state.NotAllowedError("Stop!", "to your Tiltfile").Error(),
+		"Your kubeconfig now points at 'dev-a', but this Tilt is still connected to 'prod'")
+
+	// But we see changes to the context we're connected to.
+	config.Contexts["prod"] = &api.Context{Cluster: "kind-dev-a"}
+	state = p.Reload(ctx).NewState().(State)
+	assert.Equal(t, k8s.EnvKIND6, state.env)
+	assert.Equal(t, "https://127.0.0.1:6443", state.server)
+}
+
 func NewFixture(tb testing.TB, ctx k8s.KubeContext, env k8s.Env) *starkit.Fixture {
 	return starkit.NewFixture(tb, NewPlugin(ctx, env))
 }
@@ -58,6 +58,30 @@ func (l liveUpdateFallBackOnStep) Hash() (uint32, error) {
 func (l liveUpdateFallBackOnStep) liveUpdateStep()        {}
 func (l liveUpdateFallBackOnStep) declarationPos() string { return l.position.String() }
 
+type liveUpdateRestartOnlyStep struct {
+	files    []string
+	position syntax.Position
+}
+
+var _ starlark.Value = liveUpdateRestartOnlyStep{}
+var _ liveUpdateStep = liveUpdateRestartOnlyStep{}
+
+func (l liveUpdateRestartOnlyStep) String() string {
+	return fmt.Sprintf("restart_only step: %v'", l.files)
+}
+func (l liveUpdateRestartOnlyStep) Type() string         { return "live_update_restart_only_step" }
+func (l liveUpdateRestartOnlyStep) Freeze()              {}
+func (l liveUpdateRestartOnlyStep) Truth() starlark.Bool { return len(l.files) > 0 }
+func (l liveUpdateRestartOnlyStep) Hash() (uint32, error) {
+	t := starlark.Tuple{}
+	for _, path := range l.files {
+		t = append(t, starlark.String(path))
+	}
+	return t.Hash()
+}
+func (l liveUpdateRestartOnlyStep) liveUpdateStep()        {}
+func (l liveUpdateRestartOnlyStep) declarationPos() string { return l.position.String() }
+
 type liveUpdateSyncStep struct {
 	localPath, remotePath string
 	position              syntax.Position
@@ -146,6 +170,20 @@ func (s *tiltfileState) liveUpdateFallBackOn(thread *starlark.Thread, fn *starla
 	return ret, nil
 }
 
+func (s *tiltfileState) liveUpdateRestartOnly(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	files := value.NewLocalPathListUnpacker(thread)
+	if err := s.unpackArgs(fn.Name(), args, kwargs, "paths", &files); err != nil {
+		return nil, err
+	}
+
+	ret := liveUpdateRestartOnlyStep{
+		files:    files.Value,
+		position: thread.CallFrame(1).Pos,
+	}
+	s.recordLiveUpdateStep(ret)
+	return ret, nil
+}
+
 func (s *tiltfileState) liveUpdateSync(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 	var localPath, remotePath string
 	if err := s.unpackArgs(fn.Name(), args, kwargs, "local_path", &localPath, "remote_path", &remotePath); err != nil {
@@ -246,6 +284,19 @@ func (s *tiltfileState) liveUpdateFromSteps(t *starlark.Thread, maybeSteps starl
 				spec.StopPaths = append(spec.StopPaths, f)
 			}
 
+		case liveUpdateRestartOnlyStep:
+			noMoreFallbacks = true
+
+			for _, f := range x.files {
+				if filepath.IsAbs(f) {
+					f, err = filepath.Rel(basePath, f)
+					if err != nil {
+						return v1alpha1.LiveUpdateSpec{}, err
+					}
+				}
+				spec.RestartPaths = append(spec.RestartPaths, f)
+			}
+
 		case liveUpdateSyncStep:
 			if noMoreRuns {
 				return v1alpha1.LiveUpdateSpec{}, fmt.Errorf("restart container is only valid as the last step")
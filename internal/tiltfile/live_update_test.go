@@ -460,3 +460,53 @@ func newLiveUpdateFixture(t *testing.T) *liveUpdateFixture {
 
 	return f
 }
+
+func TestLiveUpdateRestartOnlyDockerCompose(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+	f.setupFoo()
+	f.file("docker-compose.yml", `version: '3'
+services:
+  foo:
+    image: gcr.io/foo
+`)
+	f.file("Tiltfile", `
+docker_build('gcr.io/foo', 'foo',
+  live_update=[
+    restart_only(['foo/templates', 'foo/static']),
+    sync('foo', '/app'),
+    run('pip install -r requirements.txt', trigger='foo/requirements.txt'),
+  ]
+)
+docker_compose('docker-compose.yml')
+`)
+
+	f.load()
+	lu := v1alpha1.LiveUpdateSpec{
+		BasePath:     f.Path(),
+		RestartPaths: []string{"foo/templates", "foo/static"},
+		Syncs:        []v1alpha1.LiveUpdateSync{{LocalPath: "foo", ContainerPath: "/app"}},
+		Execs: []v1alpha1.LiveUpdateExec{{
+			Args:         []string{"sh", "-c", "pip install -r requirements.txt"},
+			TriggerPaths: []string{"foo/requirements.txt"},
+		}},
+	}
+	f.assertNextManifest("foo", db(image("gcr.io/foo"), lu))
+}
+
+func TestLiveUpdateRestartOnlyErrorK8s(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+
+	f.file("Tiltfile", `
+k8s_yaml('foo.yaml')
+docker_build('gcr.io/foo', './foo',
+  live_update=[
+    restart_only('foo/templates'),
+    sync('foo/bar', '/baz'),
+  ]
+)`)
+	f.loadErrString("`restart_only()` LiveUpdate step in resource(s): [foo]", "only supported for Docker Compose resources")
+}
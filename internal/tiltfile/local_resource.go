@@ -3,16 +3,19 @@ package tiltfile
 import (
 	"fmt"
 	"path/filepath"
+	"time"
 
 	"github.com/pkg/errors"
 	"go.starlark.net/starlark"
 
+	"github.com/tilt-dev/tilt/internal/sliceutils"
 	"github.com/tilt-dev/tilt/internal/tiltfile/links"
 	"github.com/tilt-dev/tilt/internal/tiltfile/probe"
 	"github.com/tilt-dev/tilt/internal/tiltfile/starkit"
 
 	"github.com/tilt-dev/tilt/internal/tiltfile/value"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/logger"
 	"github.com/tilt-dev/tilt/pkg/model"
 )
 
@@ -23,8 +26,11 @@ type localResource struct {
 	// The working directory of the execution thread where the local resource was created.
 	threadDir     string
 	deps          []string
+	outputs       []string
 	triggerMode   triggerMode
 	autoInit      bool
+	watchInCI     bool
+	buildTimeout  *time.Duration
 	repos         []model.LocalGitRepo
 	resourceDeps  []string
 	ignores       []string
@@ -37,6 +43,9 @@ type localResource struct {
 	isTest bool
 
 	readinessProbe *v1alpha1.Probe
+	livenessProbe  *v1alpha1.Probe
+	serveReload    *model.ServeReload
+	logLevelFormat logger.LevelFormat
 }
 
 func (s *tiltfileState) localResource(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
@@ -44,10 +53,16 @@ func (s *tiltfileState) localResource(thread *starlark.Thread, fn *starlark.Buil
 	var updateCmdVal, updateCmdBatVal, serveCmdVal, serveCmdBatVal starlark.Value
 	var updateEnv, serveEnv value.StringStringMap
 	var triggerMode triggerMode
-	var readinessProbe probe.Probe
+	var readinessProbe, livenessProbe probe.Probe
 	var updateCmdDirVal, serveCmdDirVal starlark.Value
 
 	deps := value.NewLocalPathListUnpacker(thread)
+	outputs := value.NewLocalPathListUnpacker(thread)
+	serveReloadFiles := value.NewLocalPathListUnpacker(thread)
+	serveReloadEnvFile := value.NewLocalPathUnpacker(thread)
+	var serveReloadEnvVal starlark.Sequence
+	var serveReloadSignal string
+	var logLevelFormat string
 
 	var resourceDepsVal, tagsVal starlark.Sequence
 	var ignoresVal starlark.Value
@@ -55,6 +70,8 @@ func (s *tiltfileState) localResource(thread *starlark.Thread, fn *starlark.Buil
 	var links links.LinkList
 	var labels value.LabelSet
 	autoInit := true
+	var watchInCI bool
+	var buildTimeoutSecs value.IntOrNone
 
 	var isTest bool
 	if fn.Name() == testN {
@@ -87,14 +104,21 @@ func (s *tiltfileState) localResource(thread *starlark.Thread, fn *starlark.Buil
 		"env?", &updateEnv,
 		"serve_env?", &serveEnv,
 		"readiness_probe?", &readinessProbe,
+		"liveness_probe?", &livenessProbe,
 		"dir?", &updateCmdDirVal,
 		"serve_dir?", &serveCmdDirVal,
+		"watch_in_ci?", &watchInCI,
+		"outputs?", &outputs,
+		"serve_reload_env?", &serveReloadEnvVal,
+		"serve_reload_files?", &serveReloadFiles,
+		"serve_reload_env_file?", &serveReloadEnvFile,
+		"serve_reload_signal?", &serveReloadSignal,
+		"log_level_format?", &logLevelFormat,
+		"build_timeout_secs?", &buildTimeoutSecs,
 	); err != nil {
 		return nil, err
 	}
 
-	repos := reposForPaths(deps.Value)
-
 	resourceDeps, err := value.SequenceToStringSlice(resourceDepsVal)
 	if err != nil {
 		return nil, errors.Wrapf(err, "%s: resource_deps", fn.Name())
@@ -122,14 +146,60 @@ func (s *tiltfileState) localResource(thread *starlark.Thread, fn *starlark.Buil
 		return nil, fmt.Errorf("local_resource must have a cmd and/or a serve_cmd, but both were empty")
 	}
 
+	serveReloadEnv, err := value.SequenceToStringSlice(serveReloadEnvVal)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s: serve_reload_env", fn.Name())
+	}
+	var serveReload *model.ServeReload
+	if len(serveReloadEnv) > 0 || len(serveReloadFiles.Value) > 0 || serveReloadEnvFile.Value != "" || serveReloadSignal != "" {
+		if serveCmd.Empty() {
+			return nil, fmt.Errorf("%s: serve_reload_* arguments require a serve_cmd", fn.Name())
+		}
+		if serveReloadSignal == "" {
+			serveReloadSignal = "SIGHUP"
+		}
+		if !model.IsServeReloadSignal(serveReloadSignal) {
+			return nil, fmt.Errorf("%s: serve_reload_signal must be one of %s, got %q",
+				fn.Name(), sliceutils.QuotedStringList(model.ServeReloadSignals), serveReloadSignal)
+		}
+		serveReload = &model.ServeReload{
+			Env:     serveReloadEnv,
+			Files:   serveReloadFiles.Value,
+			EnvFile: serveReloadEnvFile.Value,
+			Signal:  serveReloadSignal,
+		}
+
+		// Watch the config files, so that editing them reloads the server.
+		deps.Value = append(deps.Value, serveReloadFiles.Value...)
+	}
+
+	if livenessProbe.Spec() != nil && serveCmd.Empty() {
+		return nil, fmt.Errorf("%s: liveness_probe requires a serve_cmd", fn.Name())
+	}
+
+	levelFormat, err := logger.ParseLevelFormat(logLevelFormat)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s: log_level_format", fn.Name())
+	}
+
+	buildTimeout, err := buildTimeoutFromValue(fn.Name(), buildTimeoutSecs)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := reposForPaths(deps.Value)
+
 	res := localResource{
 		name:           string(name),
 		updateCmd:      updateCmd,
 		serveCmd:       serveCmd,
 		threadDir:      filepath.Dir(starkit.CurrentExecPath(thread)),
 		deps:           deps.Value,
+		outputs:        outputs.Value,
 		triggerMode:    triggerMode,
 		autoInit:       autoInit,
+		watchInCI:      watchInCI,
+		buildTimeout:   buildTimeout,
 		repos:          repos,
 		resourceDeps:   resourceDeps,
 		ignores:        ignores,
@@ -139,6 +209,9 @@ func (s *tiltfileState) localResource(thread *starlark.Thread, fn *starlark.Buil
 		tags:           tags,
 		isTest:         isTest,
 		readinessProbe: readinessProbe.Spec(),
+		livenessProbe:  livenessProbe.Spec(),
+		serveReload:    serveReload,
+		logLevelFormat: levelFormat,
 	}
 
 	// check for duplicate resources by name and throw error if found
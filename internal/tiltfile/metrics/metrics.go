@@ -4,7 +4,8 @@ import (
 	"go.starlark.net/starlark"
 
 	"github.com/tilt-dev/tilt/internal/tiltfile/starkit"
-	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/internal/tiltfile/warnings"
+	"github.com/tilt-dev/tilt/pkg/model"
 )
 
 type Plugin struct{}
@@ -18,12 +19,13 @@ func (Plugin) OnStart(env *starkit.Environment) error {
 }
 
 func setMetricsSettings(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
-	ctx, err := starkit.ContextFromThread(thread)
+	err := warnings.AddFromThread(thread, model.TiltfileWarning{
+		Key:     "experimental_metrics_settings",
+		Message: "experimental_metrics_settings() is deprecated",
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	logger.Get(ctx).Warnf("experimental_metrics_settings() is deprecated")
 	return starlark.None, nil
 }
 
@@ -0,0 +1,191 @@
+package os
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.starlark.net/starlark"
+
+	"github.com/tilt-dev/tilt/internal/tiltfile/starkit"
+	"github.com/tilt-dev/tilt/internal/tiltfile/value"
+)
+
+// An environment variable read by the Tiltfile, and the value it saw.
+type EnvRead struct {
+	Name  string
+	Value string
+
+	// False if the variable wasn't set.
+	Set bool
+}
+
+// Track the environment variables read while loading, so that we can
+// reload the Tiltfile when one of them changes.
+type EnvState struct {
+	// The first read of each variable, in the order the Tiltfile read them.
+	Reads []EnvRead
+
+	// Required variables that weren't set, in the order the Tiltfile asked for them.
+	MissingRequired []string
+}
+
+func (s EnvState) hasRead(name string) bool {
+	for _, r := range s.Reads {
+		if r.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports all the missing required variables at once, so that users
+// don't have to fix them one reload at a time.
+func (s EnvState) MissingRequiredError() error {
+	if len(s.MissingRequired) == 0 {
+		return nil
+	}
+	if len(s.MissingRequired) == 1 {
+		return fmt.Errorf("Missing required environment variable: %s", s.MissingRequired[0])
+	}
+	return fmt.Errorf("Missing required environment variables: %s", strings.Join(s.MissingRequired, ", "))
+}
+
+// Returns the names of the variables whose values differ from what the
+// Tiltfile read.
+func ChangedEnv(reads []EnvRead, lookupEnv func(key string) (string, bool)) []string {
+	var result []string
+	for _, r := range reads {
+		v, set := lookupEnv(r.Name)
+		if v != r.Value || set != r.Set {
+			result = append(result, r.Name)
+		}
+	}
+	return result
+}
+
+// Look up the variable, recording that the Tiltfile read it.
+func lookupEnv(t *starlark.Thread, key string) (string, bool, error) {
+	v, set := os.LookupEnv(key)
+	err := starkit.SetState(t, func(s EnvState) EnvState {
+		if !s.hasRead(key) {
+			s.Reads = append(s.Reads, EnvRead{Name: key, Value: v, Set: set})
+		}
+		return s
+	})
+	return v, set, err
+}
+
+const (
+	envTypeString = "string"
+	envTypeBool   = "bool"
+	envTypeInt    = "int"
+)
+
+func validateEnvType(fnName, envType string) error {
+	switch envType {
+	case envTypeString, envTypeBool, envTypeInt:
+		return nil
+	}
+	return fmt.Errorf("%s: for parameter \"type\": must be one of %q, %q, or %q, got %q",
+		fnName, envTypeString, envTypeBool, envTypeInt, envType)
+}
+
+// Convert the value of a variable to the requested type.
+func coerceEnv(fnName, key, v, envType string) (starlark.Value, error) {
+	switch envType {
+	case envTypeBool:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: environment variable %s must be a bool, got %q", fnName, key, v)
+		}
+		return starlark.Bool(b), nil
+	case envTypeInt:
+		i, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("%s: environment variable %s must be an int, got %q", fnName, key, v)
+		}
+		return starlark.MakeInt(i), nil
+	}
+	return starlark.String(v), nil
+}
+
+func getenv(t *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key value.Stringable
+	var defaultVal starlark.Value = starlark.None
+	envType := envTypeString
+	err := starkit.UnpackArgs(t, fn.Name(), args, kwargs,
+		"key", &key,
+		"default?", &defaultVal,
+		"type?", &envType,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateEnvType(fn.Name(), envType); err != nil {
+		return nil, err
+	}
+
+	envVal, found, err := lookupEnv(t, key.Value)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return defaultVal, nil
+	}
+
+	return coerceEnv(fn.Name(), key.Value, envVal, envType)
+}
+
+// Like getenv, but the Tiltfile fails to load if the variable isn't set.
+//
+// We don't fail right away. The missing variable evaluates to None, and
+// we report every missing variable together when execution finishes.
+func requireEnv(t *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key value.Stringable
+	envType := envTypeString
+	err := starkit.UnpackArgs(t, fn.Name(), args, kwargs,
+		"key", &key,
+		"type?", &envType,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateEnvType(fn.Name(), envType); err != nil {
+		return nil, err
+	}
+
+	envVal, found, err := lookupEnv(t, key.Value)
+	if err != nil {
+		return nil, err
+	}
+	if !found || envVal == "" {
+		err := starkit.SetState(t, func(s EnvState) EnvState {
+			for _, m := range s.MissingRequired {
+				if m == key.Value {
+					return s
+				}
+			}
+			s.MissingRequired = append(s.MissingRequired, key.Value)
+			return s
+		})
+		return starlark.None, err
+	}
+
+	return coerceEnv(fn.Name(), key.Value, envVal, envType)
+}
+
+func MustState(model starkit.Model) EnvState {
+	state, err := GetState(model)
+	if err != nil {
+		panic(err)
+	}
+	return state
+}
+
+func GetState(m starkit.Model) (EnvState, error) {
+	var state EnvState
+	err := m.Load(&state)
+	return state, err
+}
@@ -24,6 +24,10 @@ func NewPlugin() Plugin {
 	return Plugin{}
 }
 
+func (e Plugin) NewState() interface{} {
+	return EnvState{}
+}
+
 func (e Plugin) OnStart(env *starkit.Environment) error {
 	err := env.AddBuiltin("os.getcwd", cwd)
 	if err != nil {
@@ -67,6 +71,10 @@ func (e Plugin) OnStart(env *starkit.Environment) error {
 	if err != nil {
 		return err
 	}
+	err = env.AddBuiltin("os.require_env", requireEnv)
+	if err != nil {
+		return err
+	}
 	err = env.AddBuiltin("os.putenv", putenv)
 	if err != nil {
 		return err
@@ -88,25 +96,6 @@ func osName() string {
 	return "posix"
 }
 
-func getenv(t *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
-	var key value.Stringable
-	var defaultVal starlark.Value = starlark.None
-	err := starkit.UnpackArgs(t, fn.Name(), args, kwargs,
-		"key", &key,
-		"default?", &defaultVal,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	envVal, found := os.LookupEnv(key.Value)
-	if !found {
-		return defaultVal, nil
-	}
-
-	return starlark.String(envVal), nil
-}
-
 func putenv(t *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 	var key, v value.Stringable
 	err := starkit.UnpackArgs(t, fn.Name(), args, kwargs,
@@ -134,6 +123,8 @@ func unsetenv(t *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwa
 	return starlark.None, nil
 }
 
+var _ starkit.StatefulPlugin = Plugin{}
+
 // Fetch the working directory of current Tiltfile execution.
 // All built-ins will be executed relative to this directory (e.g., local(), docker_build(), etc)
 // Intended to mirror the API of Python's getcwd
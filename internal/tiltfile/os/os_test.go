@@ -46,6 +46,123 @@ print(os.getenv('FAKE_ENV_VARIABLE_UNSET', 'bar'))
 	assert.Equal(t, "fakeValue\nfakeValue\nbar\n", f.PrintOutput())
 }
 
+func TestGetenvType(t *testing.T) {
+	f := NewFixture(t)
+	t.Setenv("FAKE_ENV_DEBUG", "true")
+	t.Setenv("FAKE_ENV_PORT", " 8080")
+
+	f.File("Tiltfile", `
+print(os.getenv('FAKE_ENV_DEBUG', type='bool'))
+print(os.getenv('FAKE_ENV_PORT', type='int') + 1)
+print(os.getenv('FAKE_ENV_UNSET', default=3000, type='int'))
+`)
+
+	_, err := f.ExecFile("Tiltfile")
+	assert.NoError(t, err)
+	assert.Equal(t, "True\n8081\n3000\n", f.PrintOutput())
+}
+
+func TestGetenvBadValue(t *testing.T) {
+	f := NewFixture(t)
+	t.Setenv("FAKE_ENV_PORT", "eighty")
+
+	f.File("Tiltfile", `
+os.getenv('FAKE_ENV_PORT', type='int')
+`)
+
+	_, err := f.ExecFile("Tiltfile")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `environment variable FAKE_ENV_PORT must be an int, got "eighty"`)
+}
+
+func TestGetenvBadType(t *testing.T) {
+	f := NewFixture(t)
+
+	f.File("Tiltfile", `
+os.getenv('FAKE_ENV_PORT', type='float')
+`)
+
+	_, err := f.ExecFile("Tiltfile")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `for parameter "type": must be one of "string", "bool", or "int", got "float"`)
+}
+
+func TestRequireEnv(t *testing.T) {
+	f := NewFixture(t)
+	t.Setenv("FAKE_ENV_PORT", "8080")
+
+	f.File("Tiltfile", `
+print(os.require_env('FAKE_ENV_PORT', type='int'))
+`)
+
+	model, err := f.ExecFile("Tiltfile")
+	assert.NoError(t, err)
+	assert.Equal(t, "8080\n", f.PrintOutput())
+	assert.NoError(t, MustState(model).MissingRequiredError())
+}
+
+func TestRequireEnvReportsAllMissing(t *testing.T) {
+	f := NewFixture(t)
+	t.Setenv("FAKE_ENV_PORT", "8080")
+	t.Setenv("FAKE_ENV_EMPTY", "")
+
+	f.File("Tiltfile", `
+os.require_env('FAKE_ENV_TOKEN')
+os.require_env('FAKE_ENV_PORT')
+os.require_env('FAKE_ENV_EMPTY')
+os.require_env('FAKE_ENV_TOKEN')
+print(os.require_env('FAKE_ENV_REGION'))
+`)
+
+	model, err := f.ExecFile("Tiltfile")
+	assert.NoError(t, err)
+	assert.Equal(t, "None\n", f.PrintOutput())
+
+	state := MustState(model)
+	assert.Equal(t, []string{"FAKE_ENV_TOKEN", "FAKE_ENV_EMPTY", "FAKE_ENV_REGION"}, state.MissingRequired)
+	assert.EqualError(t, state.MissingRequiredError(),
+		"Missing required environment variables: FAKE_ENV_TOKEN, FAKE_ENV_EMPTY, FAKE_ENV_REGION")
+}
+
+func TestEnvReadsRecorded(t *testing.T) {
+	f := NewFixture(t)
+	t.Setenv("FAKE_ENV_PORT", "8080")
+
+	f.File("Tiltfile", `
+os.getenv('FAKE_ENV_PORT')
+os.getenv('FAKE_ENV_UNSET', 'default')
+os.require_env('FAKE_ENV_PORT')
+os.putenv('FAKE_ENV_PORT', '9090')
+os.getenv('FAKE_ENV_PORT')
+`)
+
+	model, err := f.ExecFile("Tiltfile")
+	assert.NoError(t, err)
+	assert.Equal(t, []EnvRead{
+		{Name: "FAKE_ENV_PORT", Value: "8080", Set: true},
+		{Name: "FAKE_ENV_UNSET"},
+	}, MustState(model).Reads)
+}
+
+func TestChangedEnv(t *testing.T) {
+	reads := []EnvRead{
+		{Name: "PORT", Value: "8080", Set: true},
+		{Name: "DEBUG"},
+		{Name: "EMPTY", Set: true},
+	}
+	env := map[string]string{"PORT": "8080", "EMPTY": ""}
+	lookup := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+	assert.Empty(t, ChangedEnv(reads, lookup))
+
+	env["PORT"] = "9090"
+	env["DEBUG"] = ""
+	delete(env, "EMPTY")
+	assert.Equal(t, []string{"PORT", "DEBUG", "EMPTY"}, ChangedEnv(reads, lookup))
+}
+
 func TestPutenv(t *testing.T) {
 	f := NewFixture(t)
 	os.Setenv("FAKE_ENV_VARIABLE", "fakeValue")
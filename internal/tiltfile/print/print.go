@@ -6,7 +6,9 @@ import (
 	"go.starlark.net/starlark"
 
 	"github.com/tilt-dev/tilt/internal/tiltfile/starkit"
+	"github.com/tilt-dev/tilt/internal/tiltfile/warnings"
 	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
 )
 
 // Additional functions for print output.
@@ -42,20 +44,23 @@ func fail(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kw
 	return nil, errors.New(msg)
 }
 
+// Logs a warning, and shows it on the given resource (or the Tiltfile,
+// if no resource is given).
 func warn(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
-	var msg string
-	err := starkit.UnpackArgs(thread, fn.Name(), args, kwargs, "msg", &msg)
+	var msg, resource string
+	err := starkit.UnpackArgs(thread, fn.Name(), args, kwargs, "msg", &msg, "resource?", &resource)
 	if err != nil {
 		return nil, err
 	}
 
-	ctx, err := starkit.ContextFromThread(thread)
+	err = warnings.AddFromThread(thread, model.TiltfileWarning{
+		Message:      msg,
+		ManifestName: model.ManifestName(resource),
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	logger.Get(ctx).Warnf("%s", msg)
-
 	return starlark.None, nil
 }
 
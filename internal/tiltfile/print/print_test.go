@@ -10,7 +10,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/tilt-dev/tilt/internal/tiltfile/starkit"
+	"github.com/tilt-dev/tilt/internal/tiltfile/warnings"
 	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
 )
 
 func TestWarn(t *testing.T) {
@@ -23,6 +25,27 @@ func TestWarn(t *testing.T) {
 	assert.Contains(t, f.PrintOutput(), "problem 1")
 }
 
+func TestWarnResource(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	c := warnings.NewCollector(nil)
+	ctx := logger.WithLogger(context.Background(), logger.NewLogger(logger.VerboseLvl, bytes.NewBuffer(nil)))
+	f.SetContext(warnings.WithCollector(ctx, c))
+
+	f.File("Tiltfile", "warn('problem 1', resource='fe')\nwarn('problem 2')")
+	_, err := f.ExecFile("Tiltfile")
+	require.NoError(t, err)
+
+	ws := c.Warnings()
+	require.Len(t, ws, 2)
+	assert.Equal(t, model.ManifestName("fe"), ws[0].ManifestName)
+	assert.Equal(t, "problem 1", ws[0].Message)
+	assert.Contains(t, ws[0].Location, "Tiltfile:1:")
+	assert.Equal(t, model.ManifestName(""), ws[1].ManifestName)
+	assert.Contains(t, ws[1].Location, "Tiltfile:2:")
+}
+
 func TestFail(t *testing.T) {
 	f := newFixture(t)
 	defer f.TearDown()
@@ -3,6 +3,7 @@ package probe
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"go.starlark.net/starlark"
@@ -233,22 +234,44 @@ func (h HTTPGetAction) Type() string {
 func (e Plugin) httpGetAction(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 	var host, scheme, path starlark.String
 	var port int
-	// TODO(milas): support headers
+	var headers value.StringStringMap
+	var insecureSkipTLSVerify bool
 	err := starkit.UnpackArgs(thread, fn.Name(), args, kwargs,
 		"port", &port,
 		"host?", &host,
 		"scheme?", &scheme,
 		"path?", &path,
+		"headers?", &headers,
+		"insecure_skip_tls_verify?", &insecureSkipTLSVerify,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	// Sort the headers, so that the spec doesn't change from run to run.
+	var names []string
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var httpHeaders []v1alpha1.HTTPHeader
+	headersDict := starlark.NewDict(len(names))
+	for _, name := range names {
+		httpHeaders = append(httpHeaders, v1alpha1.HTTPHeader{Name: name, Value: headers[name]})
+		err := headersDict.SetKey(starlark.String(name), starlark.String(headers[name]))
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	spec := &v1alpha1.HTTPGetAction{
-		Host:   host.GoString(),
-		Port:   int32(port),
-		Scheme: v1alpha1.URIScheme(strings.ToUpper(scheme.GoString())),
-		Path:   path.GoString(),
+		Host:                  host.GoString(),
+		Port:                  int32(port),
+		Scheme:                v1alpha1.URIScheme(strings.ToUpper(scheme.GoString())),
+		Path:                  path.GoString(),
+		HTTPHeaders:           httpHeaders,
+		InsecureSkipTLSVerify: insecureSkipTLSVerify,
 	}
 
 	return HTTPGetAction{
@@ -257,6 +280,8 @@ func (e Plugin) httpGetAction(thread *starlark.Thread, fn *starlark.Builtin, arg
 			{starlark.String("port"), starlark.MakeInt(port)},
 			{starlark.String("scheme"), scheme},
 			{starlark.String("path"), path},
+			{starlark.String("headers"), headersDict},
+			{starlark.String("insecure_skip_tls_verify"), starlark.Bool(insecureSkipTLSVerify)},
 		}),
 		action: spec,
 	}, nil
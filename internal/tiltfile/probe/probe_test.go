@@ -113,6 +113,25 @@ https
 	require.Contains(t, f.PrintOutput(), expectedOutput)
 }
 
+func TestProbeActions_HTTPGet_HeadersAndTLS(t *testing.T) {
+	f := starkit.NewFixture(t, NewPlugin())
+	defer f.TearDown()
+
+	f.File("Tiltfile", `
+p = probe(http_get=http_get_action(8443, scheme='https',
+                                   headers={'X-Probe': 'yes', 'Host': 'app.localhost'},
+                                   insecure_skip_tls_verify=True))
+
+print(p.http_get.headers['Host'])
+print(p.http_get.insecure_skip_tls_verify)
+`)
+
+	_, err := f.ExecFile("Tiltfile")
+	require.NoError(t, err)
+
+	require.Contains(t, f.PrintOutput(), "app.localhost\nTrue")
+}
+
 func TestProbeActions_HTTPGet_NoHost(t *testing.T) {
 	f := starkit.NewFixture(t, NewPlugin())
 	defer f.TearDown()
@@ -0,0 +1,93 @@
+package tiltfile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/tilt-dev/tilt/internal/tiltfile/links"
+	"github.com/tilt-dev/tilt/internal/tiltfile/value"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// Tilt's own annotations use this prefix, so users can't.
+const reservedAnnotationPrefix = "tilt.dev/"
+
+// Metadata attached to a resource with resource_metadata().
+type resourceMetadata struct {
+	annotations map[string]string
+	links       []model.Link
+}
+
+func (s *tiltfileState) resourceMetadataFn(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name value.Name
+	var annotations value.StringStringMap
+	var links links.LinkList
+
+	if err := s.unpackArgs(fn.Name(), args, kwargs,
+		"name", &name,
+		"annotations?", &annotations,
+		"links?", &links,
+	); err != nil {
+		return nil, err
+	}
+
+	for k := range annotations {
+		err := validateAnnotationKey(k)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", fn.Name(), err)
+		}
+	}
+
+	// Later calls add to (or overwrite) the metadata from earlier calls.
+	md, ok := s.resourceMetadata[name.String()]
+	if !ok {
+		md = &resourceMetadata{annotations: make(map[string]string)}
+		s.resourceMetadata[name.String()] = md
+	}
+	for k, v := range annotations {
+		md.annotations[k] = v
+	}
+	md.links = append(md.links, links.Links...)
+
+	return starlark.None, nil
+}
+
+func validateAnnotationKey(k string) error {
+	errs := validation.IsQualifiedName(k)
+	if len(errs) != 0 {
+		return fmt.Errorf("invalid annotation key %q: %s", k, strings.Join(errs, ", "))
+	}
+	if strings.HasPrefix(k, reservedAnnotationPrefix) {
+		return fmt.Errorf("invalid annotation key %q: the %s prefix is reserved for Tilt", k, reservedAnnotationPrefix)
+	}
+	return nil
+}
+
+// Attaches the metadata from resource_metadata() calls to the manifests.
+func (s *tiltfileState) applyResourceMetadata(manifests []model.Manifest) ([]model.Manifest, error) {
+	used := make(map[string]bool, len(s.resourceMetadata))
+	for i, m := range manifests {
+		md, ok := s.resourceMetadata[m.Name.String()]
+		if !ok {
+			continue
+		}
+		used[m.Name.String()] = true
+		manifests[i] = m.WithAnnotations(md.annotations).WithLinks(md.links)
+	}
+
+	var unused []string
+	for name := range s.resourceMetadata {
+		if !used[name] {
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return nil, fmt.Errorf("%s: no resource named %q", resourceMetadataN, unused[0])
+	}
+	return manifests, nil
+}
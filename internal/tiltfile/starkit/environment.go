@@ -62,6 +62,7 @@ type Environment struct {
 	loadInterceptors []LoadInterceptor
 
 	builtinCalls []BuiltinCall
+	progress     *ExecProgress
 }
 
 func newEnvironment(plugins ...Plugin) *Environment {
@@ -93,8 +94,20 @@ func (e *Environment) StartTiltfile() *v1alpha1.Tiltfile {
 // All builtins will be wrapped to invoke OnBuiltinCall on every plugin.
 //
 // All builtins should use starkit.UnpackArgs to get instrumentation.
+//
+// Once the environment's context is done, builtins fail instead of running.
+// Starlark has no other way to stop a Tiltfile that's in a loop.
 func (e *Environment) AddBuiltin(name string, f Function) error {
 	wrapped := starlark.NewBuiltin(name, func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		if e.ctx != nil && e.ctx.Err() != nil {
+			return nil, errors.Wrap(e.ctx.Err(), "Tiltfile execution aborted")
+		}
+
+		if e.progress != nil {
+			e.progress.enter(thread, name, args, kwargs)
+			defer e.progress.exit()
+		}
+
 		for _, ext := range e.plugins {
 			onBuiltinCallExt, ok := ext.(OnBuiltinCallPlugin)
 			if ok {
@@ -157,6 +170,11 @@ func (e *Environment) SetContext(ctx context.Context) {
 	e.ctx = ctx
 }
 
+// Report the builtins that the Tiltfile calls to the given tracker.
+func (e *Environment) SetExecProgress(p *ExecProgress) {
+	e.progress = p
+}
+
 // Set a fake file system so that we can write tests that don't
 // touch the file system. Expressed as a map from paths to contents.
 func (e *Environment) SetFakeFileSystem(files map[string]string) {
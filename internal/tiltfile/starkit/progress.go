@@ -0,0 +1,117 @@
+package starkit
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.starlark.net/starlark"
+)
+
+// Tracks which builtin a Starlark execution is in, so that another goroutine
+// can report on a slow or stuck Tiltfile.
+//
+// Starlark can't be interrupted in the middle of a statement, so the best we
+// can do is remember the builtins the Tiltfile calls.
+type ExecProgress struct {
+	mu      sync.Mutex
+	running []ExecLocation
+	last    ExecLocation
+}
+
+func NewExecProgress() *ExecProgress {
+	return &ExecProgress{}
+}
+
+// Where execution was, as of the last builtin call.
+type ExecLocation struct {
+	// The builtin call, e.g., local('helm dep update')
+	Call string
+
+	// The Starlark stack that called the builtin.
+	CallStack starlark.CallStack
+
+	// Whether the builtin is still running. If false, the Tiltfile has
+	// returned from the builtin and is somewhere after it.
+	Running bool
+}
+
+func (l ExecLocation) IsZero() bool {
+	return l.Call == ""
+}
+
+// e.g., "local('helm dep update') at Tiltfile:12:6"
+func (l ExecLocation) String() string {
+	if len(l.CallStack) == 0 {
+		return l.Call
+	}
+	return fmt.Sprintf("%s at %s", l.Call, l.CallStack[len(l.CallStack)-1].Pos)
+}
+
+// The innermost builtin that's running, or the last one that ran.
+func (p *ExecProgress) Current() ExecLocation {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.running) > 0 {
+		return p.running[len(p.running)-1]
+	}
+	return p.last
+}
+
+func (p *ExecProgress) enter(t *starlark.Thread, name string, args starlark.Tuple, kwargs []starlark.Tuple) {
+	stack := t.CallStack()
+	if len(stack) > 0 {
+		// Drop the frame of the builtin itself.
+		stack = stack[:len(stack)-1]
+	}
+	loc := ExecLocation{
+		Call:      describeCall(name, args, kwargs),
+		CallStack: stack,
+		Running:   true,
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running = append(p.running, loc)
+}
+
+func (p *ExecProgress) exit() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.running) == 0 {
+		return
+	}
+	last := p.running[len(p.running)-1]
+	last.Running = false
+	p.last = last
+	p.running = p.running[:len(p.running)-1]
+}
+
+const maxCallArgLen = 60
+
+// Shows the builtin with its first argument, which is usually enough
+// to tell which call it is.
+func describeCall(name string, args starlark.Tuple, kwargs []starlark.Tuple) string {
+	var sb strings.Builder
+	sb.WriteString(name)
+	sb.WriteString("(")
+	if len(args) > 0 {
+		var arg string
+		if s, ok := args[0].(starlark.String); ok {
+			arg = "'" + s.GoString() + "'"
+		} else {
+			arg = args[0].String()
+		}
+		if len(arg) > maxCallArgLen {
+			arg = arg[:maxCallArgLen] + "..."
+		}
+		sb.WriteString(arg)
+		if len(args) > 1 || len(kwargs) > 0 {
+			sb.WriteString(", ...")
+		}
+	} else if len(kwargs) > 0 {
+		sb.WriteString("...")
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
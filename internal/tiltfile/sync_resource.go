@@ -0,0 +1,159 @@
+package tiltfile
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"go.starlark.net/starlark"
+
+	"github.com/tilt-dev/tilt/internal/tiltfile/starkit"
+	"github.com/tilt-dev/tilt/internal/tiltfile/value"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+type syncResource struct {
+	name string
+	src  string
+
+	// A path in the container if there's a pod selector,
+	// otherwise an absolute local path.
+	dest string
+
+	podSelector map[string]string
+	container   string
+	namespace   string
+
+	// The working directory of the execution thread where the sync resource was created.
+	threadDir    string
+	triggerMode  triggerMode
+	autoInit     bool
+	resourceDeps []string
+	ignores      []string
+	labels       map[string]string
+}
+
+func (s *tiltfileState) syncResource(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name value.Name
+	src := value.NewLocalPathUnpacker(thread)
+	var dest string
+	var podSelector value.StringStringMap
+	var container, namespace string
+	var triggerMode triggerMode
+	var resourceDepsVal starlark.Sequence
+	var ignoresVal starlark.Value
+	var labels value.LabelSet
+	autoInit := true
+
+	if err := s.unpackArgs(fn.Name(), args, kwargs,
+		"name", &name,
+		"src", &src,
+		"dest", &dest,
+		"pod_selector?", &podSelector,
+		"container?", &container,
+		"namespace?", &namespace,
+		"trigger_mode?", &triggerMode,
+		"resource_deps?", &resourceDepsVal,
+		"ignore?", &ignoresVal,
+		"auto_init?", &autoInit,
+		"labels?", &labels,
+	); err != nil {
+		return nil, err
+	}
+
+	if dest == "" {
+		return nil, fmt.Errorf("%s: dest must not be empty", fn.Name())
+	}
+	if len(podSelector) == 0 {
+		if container != "" || namespace != "" {
+			return nil, fmt.Errorf("%s: container and namespace only apply with a pod_selector", fn.Name())
+		}
+		dest = starkit.AbsPath(thread, dest)
+	} else if !path.IsAbs(dest) {
+		return nil, fmt.Errorf("%s: dest must be an absolute path in the container: %s", fn.Name(), dest)
+	}
+
+	resourceDeps, err := value.SequenceToStringSlice(resourceDepsVal)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s: resource_deps", fn.Name())
+	}
+
+	ignores, err := parseValuesToStrings(ignoresVal, "ignore")
+	if err != nil {
+		return nil, err
+	}
+
+	res := syncResource{
+		name:         string(name),
+		src:          src.Value,
+		dest:         dest,
+		podSelector:  podSelector,
+		container:    container,
+		namespace:    namespace,
+		threadDir:    filepath.Dir(starkit.CurrentExecPath(thread)),
+		triggerMode:  triggerMode,
+		autoInit:     autoInit,
+		resourceDeps: resourceDeps,
+		ignores:      ignores,
+		labels:       labels.Values,
+	}
+
+	for _, elem := range s.syncResources {
+		if elem.name == res.name {
+			return starlark.None, fmt.Errorf("Sync resource %s has been defined multiple times", res.name)
+		}
+	}
+	for _, elem := range s.localResources {
+		if elem.name == res.name {
+			return starlark.None, fmt.Errorf("Sync resource %s has the same name as a local resource", res.name)
+		}
+	}
+	s.syncResources = append(s.syncResources, res)
+
+	return starlark.None, nil
+}
+
+func (s *tiltfileState) translateSync() ([]model.Manifest, error) {
+	var result []model.Manifest
+
+	for _, r := range s.syncResources {
+		mn := model.ManifestName(r.name)
+		tm, err := starlarkTriggerModeToModel(s.triggerModeForResource(r.triggerMode), r.autoInit)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error in resource %s options", mn)
+		}
+
+		var ignores []model.Dockerignore
+		if len(r.ignores) != 0 {
+			ignores = append(ignores, model.Dockerignore{
+				Patterns:  r.ignores,
+				Source:    fmt.Sprintf("sync_resource(%q)", r.name),
+				LocalPath: r.threadDir,
+			})
+		}
+
+		st := model.NewSyncTarget(model.TargetName(r.name), []model.Sync{{LocalPath: r.src, ContainerPath: r.dest}}).
+			WithRepos(reposForPaths([]string{r.src, r.threadDir})).
+			WithIgnores(ignores)
+		if len(r.podSelector) > 0 {
+			st = st.WithPodSelector(r.podSelector, r.container, r.namespace)
+		}
+
+		var mds []model.ManifestName
+		for _, md := range r.resourceDeps {
+			mds = append(mds, model.ManifestName(md))
+		}
+		m := model.Manifest{
+			Name:                 mn,
+			TriggerMode:          tm,
+			ResourceDependencies: mds,
+		}.WithDeployTarget(st)
+
+		m = m.WithLabels(r.labels)
+
+		result = append(result, m)
+	}
+
+	return result, nil
+}
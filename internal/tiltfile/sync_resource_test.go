@@ -0,0 +1,112 @@
+package tiltfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestSyncResourceLocalDest(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+	f.setupFoo()
+
+	f.file("Tiltfile", `
+sync_resource("docs", "foo", "out/docs", trigger_mode=TRIGGER_MODE_MANUAL)
+`)
+
+	f.load()
+
+	m := f.assertNextManifest("docs")
+	require.True(t, m.IsSync())
+	st := m.SyncTarget()
+	assert.True(t, st.IsLocalDest())
+	assert.Equal(t, []model.Sync{{
+		LocalPath:     f.JoinPath("foo"),
+		ContainerPath: f.JoinPath("out/docs"),
+	}}, st.Syncs)
+	assert.Equal(t, model.TriggerModeManualWithAutoInit, m.TriggerMode)
+}
+
+func TestSyncResourcePodSelector(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+	f.setupFoo()
+
+	f.file("Tiltfile", `
+local_resource("frontend", "echo hi")
+sync_resource("assets", "foo", "/app/assets",
+              pod_selector={"app": "frontend"},
+              container="web",
+              namespace="staging",
+              resource_deps=["frontend"])
+`)
+
+	f.load()
+
+	f.assertNextManifest("frontend")
+	m := f.assertNextManifest("assets")
+	st := m.SyncTarget()
+	assert.False(t, st.IsLocalDest())
+	assert.Equal(t, map[string]string{"app": "frontend"}, st.PodSelector)
+	assert.Equal(t, "web", st.Container)
+	assert.Equal(t, "staging", st.Namespace)
+	assert.Equal(t, []model.Sync{{
+		LocalPath:     f.JoinPath("foo"),
+		ContainerPath: "/app/assets",
+	}}, st.Syncs)
+	assert.Equal(t, []model.ManifestName{"frontend"}, m.ResourceDependencies)
+}
+
+func TestSyncResourceRelativeContainerDest(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+	f.setupFoo()
+
+	f.file("Tiltfile", `
+sync_resource("assets", "foo", "app/assets", pod_selector={"app": "frontend"})
+`)
+
+	f.loadErrString("dest must be an absolute path in the container: app/assets")
+}
+
+func TestSyncResourceContainerWithoutPodSelector(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+	f.setupFoo()
+
+	f.file("Tiltfile", `
+sync_resource("assets", "foo", "out", container="web")
+`)
+
+	f.loadErrString("container and namespace only apply with a pod_selector")
+}
+
+func TestSyncResourceDuplicate(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+	f.setupFoo()
+
+	f.file("Tiltfile", `
+sync_resource("assets", "foo", "out")
+sync_resource("assets", "foo", "out2")
+`)
+
+	f.loadErrString("Sync resource assets has been defined multiple times")
+}
+
+func TestSyncResourceSameNameAsLocalResource(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+	f.setupFoo()
+
+	f.file("Tiltfile", `
+local_resource("assets", "echo hi")
+sync_resource("assets", "foo", "out")
+`)
+
+	f.loadErrString("Sync resource assets has the same name as a local resource")
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"sync"
 	"time"
 
 	wmanalytics "github.com/tilt-dev/wmclient/pkg/analytics"
@@ -27,12 +28,14 @@ import (
 	"github.com/tilt-dev/tilt/internal/tiltfile/dockerprune"
 	"github.com/tilt-dev/tilt/internal/tiltfile/io"
 	"github.com/tilt-dev/tilt/internal/tiltfile/k8scontext"
+	tiltfileos "github.com/tilt-dev/tilt/internal/tiltfile/os"
 	"github.com/tilt-dev/tilt/internal/tiltfile/secretsettings"
 	"github.com/tilt-dev/tilt/internal/tiltfile/starkit"
 	"github.com/tilt-dev/tilt/internal/tiltfile/telemetry"
 	"github.com/tilt-dev/tilt/internal/tiltfile/updatesettings"
 	"github.com/tilt-dev/tilt/internal/tiltfile/value"
 	"github.com/tilt-dev/tilt/internal/tiltfile/version"
+	"github.com/tilt-dev/tilt/internal/tiltfile/warnings"
 	"github.com/tilt-dev/tilt/internal/tiltfile/watch"
 	corev1alpha1 "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
 	"github.com/tilt-dev/tilt/pkg/model"
@@ -44,6 +47,7 @@ type TiltfileLoadResult struct {
 	Manifests           []model.Manifest
 	Tiltignore          model.Dockerignore
 	ConfigFiles         []string
+	ConfigGlobs         []io.WatchedGlob
 	FeatureFlags        map[string]bool
 	TeamID              string
 	TelemetrySettings   model.TelemetrySettings
@@ -56,10 +60,49 @@ type TiltfileLoadResult struct {
 	WatchSettings       model.WatchSettings
 	ObjectSet           apiset.ObjectSet
 
+	// Setup steps to run once per cluster, before the first build,
+	// and what happened when the Tiltfile reconciler ran them.
+	BootstrapTasks    []model.BootstrapTask
+	BootstrapStatuses []model.BootstrapTaskStatus
+
+	// The registry that images are pushed to, and where it came from.
+	ImageRegistry model.ImageRegistry
+
+	// The environment variables the Tiltfile read, so that we can reload it
+	// when one of them changes.
+	EnvReads []tiltfileos.EnvRead
+
+	// How many manifests the Tiltfile defined, before filtering down to the
+	// enabled resources, and what did the filtering ("" if nothing did).
+	DefinedManifestCount   int
+	EnabledResourcesFilter string
+
+	// Warnings from Tiltfile execution, in the order they were emitted.
+	Warnings []model.TiltfileWarning
+
 	// For diagnostic purposes only
 	BuiltinCalls []starkit.BuiltinCall `json:"-"`
 }
 
+// Explains why a successfully loaded Tiltfile didn't enable any resources.
+// Returns "" if it enabled some.
+func (r TiltfileLoadResult) NoResourcesReason() string {
+	if r.Error != nil || len(r.Manifests) > 0 {
+		return ""
+	}
+	if r.DefinedManifestCount == 0 {
+		return "Tiltfile defines no resources"
+	}
+	filter := r.EnabledResourcesFilter
+	if filter == "" {
+		filter = "the enabled resources selection"
+	}
+	if r.DefinedManifestCount == 1 {
+		return fmt.Sprintf("1 resource was defined, but it was filtered out by %s", filter)
+	}
+	return fmt.Sprintf("%d resources were defined, but all were filtered out by %s", r.DefinedManifestCount, filter)
+}
+
 func (r TiltfileLoadResult) Orchestrator() model.Orchestrator {
 	for _, manifest := range r.Manifests {
 		if manifest.IsK8s() {
@@ -91,7 +134,8 @@ func ProvideTiltfileLoader(
 	webHost model.WebHost,
 	execer localexec.Execer,
 	fDefaults feature.Defaults,
-	env k8s.Env) TiltfileLoader {
+	env k8s.Env,
+	limits ExecLimits) TiltfileLoader {
 	return tiltfileLoader{
 		analytics:     analytics,
 		kCli:          kCli,
@@ -103,9 +147,30 @@ func ProvideTiltfileLoader(
 		execer:        execer,
 		fDefaults:     fDefaults,
 		env:           env,
+		limits:        limits,
+		lastWarnings:  &warningMemory{byTiltfile: make(map[string][]model.TiltfileWarning)},
 	}
 }
 
+// The warnings from the previous load of each Tiltfile, so that a reload
+// doesn't re-announce them.
+type warningMemory struct {
+	mu         sync.Mutex
+	byTiltfile map[string][]model.TiltfileWarning
+}
+
+func (m *warningMemory) get(name string) []model.TiltfileWarning {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.byTiltfile[name]
+}
+
+func (m *warningMemory) set(name string, ws []model.TiltfileWarning) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.byTiltfile[name] = ws
+}
+
 type tiltfileLoader struct {
 	analytics *analytics.TiltAnalytics
 	kCli      k8s.Client
@@ -118,6 +183,8 @@ type tiltfileLoader struct {
 	configExt     *config.Plugin
 	fDefaults     feature.Defaults
 	env           k8s.Env
+	limits        ExecLimits
+	lastWarnings  *warningMemory
 }
 
 var _ TiltfileLoader = &tiltfileLoader{}
@@ -158,10 +225,26 @@ func (tfl tiltfileLoader) Load(ctx context.Context, tf *corev1alpha1.Tiltfile) T
 
 	localRegistry := tfl.kCli.LocalRegistry(ctx)
 
-	s := newTiltfileState(ctx, tfl.dcCli, tfl.webHost, tfl.execer, tfl.k8sContextExt, tfl.versionExt,
-		tfl.configExt, localRegistry, feature.FromDefaults(tfl.fDefaults))
+	collector := warnings.NewCollector(tfl.lastWarnings.get(tf.Name))
+	ctx = warnings.WithCollector(ctx, collector)
 
-	manifests, result, err := s.loadManifests(tf)
+	execCtx, cancel := tfl.limits.withTimeout(ctx)
+	defer cancel()
+
+	s := newTiltfileState(execCtx, tfl.dcCli, tfl.webHost, tfl.execer, tfl.k8sContextExt.Reload(ctx), tfl.versionExt,
+		tfl.configExt, localRegistry, feature.FromDefaults(tfl.fDefaults))
+	s.localTimeout = tfl.limits.LocalTimeout
+	s.progress = starkit.NewExecProgress()
+
+	execRes, finished := tfl.execWithLimits(execCtx, s, tf)
+	if !finished {
+		// The Tiltfile is still running in the background, so none of its state is safe to read.
+		tlr.Error = execRes.err
+		tlr.Warnings = collector.Warnings()
+		reportTiltfileExecMetrics(ctx, time.Since(start), true)
+		return tlr
+	}
+	manifests, result, err := execRes.manifests, execRes.model, execRes.err
 
 	tlr.BuiltinCalls = result.BuiltinCalls
 
@@ -172,6 +255,9 @@ func (tfl tiltfileLoader) Load(ctx context.Context, tf *corev1alpha1.Tiltfile) T
 	ws, _ := watch.GetState(result)
 	tlr.WatchSettings = ws
 
+	envState, _ := tiltfileos.GetState(result)
+	tlr.EnvReads = envState.Reads
+
 	// NOTE(maia): if/when add secret settings that affect the engine, add them to tlr here
 	ss, _ := secretsettings.GetState(result)
 	s.secretSettings = ss
@@ -179,7 +265,14 @@ func (tfl tiltfileLoader) Load(ctx context.Context, tf *corev1alpha1.Tiltfile) T
 	ioState, _ := io.GetState(result)
 
 	tlr.ConfigFiles = append(tlr.ConfigFiles, ioState.Paths...)
+	tlr.ConfigGlobs = ioState.Globs
 	tlr.ConfigFiles = append(tlr.ConfigFiles, s.postExecReadFiles...)
+
+	// If we refused the kube context, re-check when the kubeconfig changes.
+	kcs, _ := k8scontext.GetState(result)
+	if err != nil && !kcs.IsAllowed(tf) {
+		tlr.ConfigFiles = append(tlr.ConfigFiles, kcs.ConfigPaths()...)
+	}
 	tlr.ConfigFiles = sliceutils.DedupedAndSorted(tlr.ConfigFiles)
 
 	dps, _ := dockerprune.GetState(result)
@@ -192,7 +285,11 @@ func (tfl tiltfileLoader) Load(ctx context.Context, tf *corev1alpha1.Tiltfile) T
 	tlr.FeatureFlags = s.features.ToEnabled()
 	tlr.Error = err
 	tlr.Manifests = manifests
+	tlr.BootstrapTasks = s.bootstrapTasks
+	tlr.DefinedManifestCount = s.definedManifestCount
+	tlr.EnabledResourcesFilter = s.enabledResourcesFilter
 	tlr.TeamID = s.teamID
+	tlr.ImageRegistry = s.imageRegistry
 
 	objectSet, _ := v1alpha1.GetState(result)
 	tlr.ObjectSet = objectSet
@@ -206,6 +303,9 @@ func (tfl tiltfileLoader) Load(ctx context.Context, tf *corev1alpha1.Tiltfile) T
 	us, _ := updatesettings.GetState(result)
 	tlr.UpdateSettings = us
 
+	tlr.Warnings = collector.Warnings()
+	tfl.lastWarnings.set(tf.Name, tlr.Warnings)
+
 	duration := time.Since(start)
 	if tlr.Error == nil {
 		s.logger.Infof("Successfully loaded Tiltfile (%s)", duration)
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -691,6 +692,40 @@ dc_resource("foo", labels="test")
 	f.assertNextManifest("foo", resourceLabels("test"))
 }
 
+func TestDockerComposeBuildTimeout(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.dockerfile(filepath.Join("foo", "Dockerfile"))
+	f.file("docker-compose.yml", simpleConfig)
+	f.file("Tiltfile", `
+docker_compose('docker-compose.yml')
+dc_resource("foo", build_timeout_secs=900)
+`)
+
+	f.load("foo")
+	m := f.assertNextManifest("foo")
+	require.NotNil(t, m.BuildTimeout)
+	assert.Equal(t, 15*time.Minute, *m.BuildTimeout)
+}
+
+func TestDockerComposeWatchInCI(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.dockerfile(filepath.Join("foo", "Dockerfile"))
+	f.file("docker-compose.yml", simpleConfig)
+	f.file("Tiltfile", `
+docker_compose('docker-compose.yml')
+dc_resource("foo", watch_in_ci=True)
+dc_resource("foo", labels=["test"])
+`)
+
+	f.load("foo")
+	m := f.assertNextManifest("foo")
+	assert.True(t, m.WatchInCI)
+}
+
 func TestTriggerModeDC(t *testing.T) {
 	for _, testCase := range []struct {
 		name                string
@@ -777,6 +812,87 @@ dc_resource('bar', resource_deps=['foo'])
 	f.assertNextManifest("bar", resourceDeps("foo"))
 }
 
+func TestDCDependsOnShortForm(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.dockerfile(filepath.Join("foo", "Dockerfile"))
+	f.file("docker-compose.yml", twoServiceConfig)
+	f.file("Tiltfile", `
+docker_compose('docker-compose.yml')
+`)
+
+	f.load()
+	f.assertNextManifest("foo", resourceDeps())
+	m := f.assertNextManifest("bar", resourceDeps("foo"))
+	assert.Empty(t, m.DockerComposeTarget().HealthyDependencies())
+}
+
+func TestDCDependsOnServiceHealthy(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("docker-compose.yml", `version: '3'
+services:
+  db:
+    image: db-image
+    healthcheck:
+      test: ["CMD", "pg_isready"]
+  cache:
+    image: cache-image
+  app:
+    image: app-image
+    depends_on:
+      db:
+        condition: service_healthy
+      cache:
+        condition: service_started
+`)
+	f.file("Tiltfile", `
+docker_compose('docker-compose.yml')
+`)
+
+	f.load()
+
+	// depends_on is a map, so the services that app depends on can load in any order.
+	var app model.Manifest
+	for _, m := range f.loadResult.Manifests {
+		if m.Name == "app" {
+			app = m
+		}
+	}
+	assert.Equal(t, []model.ManifestName{"cache", "db"}, app.ResourceDependencies)
+	assert.Equal(t, []model.ManifestName{"db"}, app.DockerComposeTarget().HealthyDependencies())
+}
+
+func TestDCDependsOnCycle(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("docker-compose.yml", `version: '3'
+services:
+  a:
+    image: a-image
+    depends_on:
+      - b
+  b:
+    image: b-image
+    depends_on:
+      c:
+        condition: service_healthy
+  c:
+    image: c-image
+    depends_on:
+      - a
+`)
+	f.file("Tiltfile", `
+docker_compose('docker-compose.yml')
+`)
+
+	f.loadErrString("cycle detected in docker-compose depends_on")
+	assert.Regexp(t, `(a -> b -> c -> a)|(b -> c -> a -> b)|(c -> a -> b -> c)`, f.loadResult.Error.Error())
+}
+
 func TestDockerComposeVersionWarnings(t *testing.T) {
 	type tc struct {
 		version string
@@ -24,6 +24,7 @@ import (
 	"github.com/tilt-dev/tilt/internal/tiltfile/probe"
 	"github.com/tilt-dev/tilt/internal/tiltfile/sys"
 	"github.com/tilt-dev/tilt/internal/tiltfile/tiltextension"
+	"github.com/tilt-dev/tilt/internal/tiltfile/warnings"
 	"github.com/tilt-dev/tilt/pkg/apis"
 
 	"github.com/tilt-dev/tilt/internal/container"
@@ -51,6 +52,7 @@ import (
 	"github.com/tilt-dev/tilt/internal/tiltfile/telemetry"
 	"github.com/tilt-dev/tilt/internal/tiltfile/updatesettings"
 	tfv1alpha1 "github.com/tilt-dev/tilt/internal/tiltfile/v1alpha1"
+	"github.com/tilt-dev/tilt/internal/tiltfile/value"
 	"github.com/tilt-dev/tilt/internal/tiltfile/version"
 	"github.com/tilt-dev/tilt/internal/tiltfile/watch"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
@@ -83,6 +85,10 @@ type tiltfileState struct {
 	localRegistry container.Registry
 	features      feature.FeatureSet
 
+	// set at creation by the loader
+	localTimeout time.Duration
+	progress     *starkit.ExecProgress
+
 	// added to during execution
 	buildIndex     *buildIndex
 	k8sObjectIndex *tiltfile_k8s.State
@@ -100,11 +106,19 @@ type tiltfileState struct {
 
 	dc                 dcResourceSet // currently only support one d-c.yml
 	k8sResourceOptions []k8sResourceOptions
+	k8sDebugOverrides  map[string]model.K8sDebugOverride
+	k8sDevOverrides    map[string]model.K8sDevOverride
+	resourceMetadata   map[string]*resourceMetadata
 	localResources     []localResource
+	syncResources      []syncResource
+	bootstrapTasks     []model.BootstrapTask
 
 	// ensure that any images are pushed to/pulled from this registry, rewriting names if needed
 	defaultReg container.Registry
 
+	// The registry we decided to push images to, and why.
+	imageRegistry model.ImageRegistry
+
 	k8sKinds map[k8s.ObjectSelector]*tiltfile_k8s.KindInfo
 
 	workloadToResourceFunction workloadToResourceFunction
@@ -133,6 +147,11 @@ type tiltfileState struct {
 
 	teamID string
 
+	// how many manifests the Tiltfile defined before filtering by enabled resources,
+	// and what did the filtering
+	definedManifestCount   int
+	enabledResourcesFilter string
+
 	secretSettings model.SecretSettings
 
 	apiObjects apiset.ObjectSet
@@ -166,12 +185,16 @@ func newTiltfileState(
 		buildIndex:                newBuildIndex(),
 		k8sObjectIndex:            tiltfile_k8s.NewState(),
 		k8sByName:                 make(map[string]*k8sResource),
+		k8sDebugOverrides:         make(map[string]model.K8sDebugOverride),
+		k8sDevOverrides:           make(map[string]model.K8sDevOverride),
+		resourceMetadata:          make(map[string]*resourceMetadata),
 		usedImages:                make(map[string]bool),
 		logger:                    logger.Get(ctx),
 		builtinCallCounts:         make(map[string]int),
 		builtinArgCounts:          make(map[string]map[string]int),
 		unconsumedLiveUpdateSteps: make(map[string]liveUpdateStep),
 		localResources:            []localResource{},
+		syncResources:             []syncResource{},
 		triggerMode:               TriggerModeAuto,
 		features:                  features,
 		secretSettings:            model.DefaultSecretSettings(),
@@ -228,6 +251,13 @@ func (s *tiltfileState) loadManifests(tf *v1alpha1.Tiltfile) ([]model.Manifest,
 		probe.NewPlugin(),
 		tfv1alpha1.NewPlugin(),
 	)
+
+	// A missing required env var often causes a later error (e.g., when the
+	// Tiltfile uses the None it got back), so report the missing vars first.
+	envState, _ := os.GetState(result)
+	if missingErr := envState.MissingRequiredError(); missingErr != nil {
+		return nil, result, missingErr
+	}
 	if err != nil {
 		return nil, result, starkit.UnpackBacktrace(err)
 	}
@@ -244,7 +274,7 @@ func (s *tiltfileState) loadManifests(tf *v1alpha1.Tiltfile) ([]model.Manifest,
 
 	err = s.assertAllImagesMatched(us)
 	if err != nil {
-		s.logger.Warnf("%s", err.Error())
+		warnings.Add(s.ctx, model.TiltfileWarning{Message: err.Error()})
 	}
 
 	var manifests []model.Manifest
@@ -262,10 +292,9 @@ func (s *tiltfileState) loadManifests(tf *v1alpha1.Tiltfile) ([]model.Manifest,
 		isAllowed := k8sContextState.IsAllowed(tf)
 		if !isAllowed {
 			kubeContext := k8sContextState.KubeContext()
-			return nil, result, fmt.Errorf(`Stop! %s might be production.
-If you're sure you want to deploy there, add:
-	allow_k8s_contexts('%s')
-to your Tiltfile. Otherwise, switch k8s contexts and restart Tilt.`, kubeContext, kubeContext)
+			return nil, result, k8sContextState.NotAllowedError(
+				fmt.Sprintf("Stop! %s might be production.", kubeContext),
+				"to your Tiltfile")
 		}
 	} else {
 		if !resources.dc.Empty() {
@@ -296,7 +325,30 @@ to your Tiltfile. Otherwise, switch k8s contexts and restart Tilt.`, kubeContext
 	}
 	manifests = append(manifests, localManifests...)
 
+	syncManifests, err := s.translateSync()
+	if err != nil {
+		return nil, result, err
+	}
+	manifests = append(manifests, syncManifests...)
+
+	manifests, err = s.applyResourceMetadata(manifests)
+	if err != nil {
+		return nil, starkit.Model{}, err
+	}
+
+	err = assignOutputDependencies(manifests)
+	if err != nil {
+		return nil, starkit.Model{}, err
+	}
+
+	err = s.validateBootstrapTasks(manifests)
+	if err != nil {
+		return nil, starkit.Model{}, err
+	}
+
 	configSettings, _ := config.GetState(result)
+	s.definedManifestCount = len(manifests)
+	s.enabledResourcesFilter = configSettings.EnabledResourcesFilter(tf)
 	manifests, err = configSettings.EnabledResources(tf, manifests)
 	if err != nil {
 		return nil, starkit.Model{}, err
@@ -318,6 +370,11 @@ to your Tiltfile. Otherwise, switch k8s contexts and restart Tilt.`, kubeContext
 		manifests = append(manifests, yamlManifest)
 	}
 
+	manifests, err = shareK8sObjects(s.logger, manifests)
+	if err != nil {
+		return nil, starkit.Model{}, err
+	}
+
 	err = validateResourceDependencies(manifests)
 	if err != nil {
 		return nil, starkit.Model{}, err
@@ -364,11 +421,19 @@ const (
 	k8sImageJSONPathN           = "k8s_image_json_path"
 	workloadToResourceFunctionN = "workload_to_resource_function"
 	k8sCustomDeployN            = "k8s_custom_deploy"
+	k8sDebugOverrideN           = "k8s_debug_override"
+	k8sDevOverrideN             = "k8s_dev_override"
 
 	// local resource functions
 	localResourceN = "local_resource"
 	testN          = "test" // test is just a fork of local resource
 
+	// sync resource functions
+	syncResourceN = "sync_resource"
+
+	// bootstrap functions
+	bootstrapN = "bootstrap"
+
 	// file functions
 	localN     = "local"
 	kustomizeN = "kustomize"
@@ -376,6 +441,7 @@ const (
 
 	// live update functions
 	fallBackOnN       = "fall_back_on"
+	restartOnlyN      = "restart_only"
 	syncN             = "sync"
 	runN              = "run"
 	restartContainerN = "restart_container"
@@ -392,7 +458,8 @@ const (
 	disableSnapshotsN = "disable_snapshots"
 
 	// other functions
-	setTeamN = "set_team"
+	setTeamN          = "set_team"
+	resourceMetadataN = "resource_metadata"
 )
 
 type triggerMode int
@@ -458,6 +525,19 @@ func starlarkTriggerModeToModel(triggerMode triggerMode, autoInit bool) (model.T
 	}
 }
 
+// Converts a build_timeout_secs argument into a per-resource override.
+// None returns nil, so the resource uses the update_settings() timeout.
+func buildTimeoutFromValue(fnName string, v value.IntOrNone) (*time.Duration, error) {
+	if !v.IsSet {
+		return nil, nil
+	}
+	if v.Value < 0 {
+		return nil, fmt.Errorf("%s: build_timeout_secs must be >= 0 (got: %d)", fnName, v.Value)
+	}
+	timeout := time.Duration(v.Value) * time.Second
+	return &timeout, nil
+}
+
 // count how many times each Builtin is called, for analytics
 func (s *tiltfileState) OnBuiltinCall(name string, fn *starlark.Builtin) {
 	s.builtinCallCounts[name]++
@@ -489,10 +569,9 @@ func (s *tiltfileState) potentiallyK8sUnsafeBuiltin(f starkit.Function) starkit.
 		isAllowed := k8sContextState.IsAllowed(tf)
 		if !isAllowed {
 			kubeContext := k8sContextState.KubeContext()
-			return nil, fmt.Errorf(`Refusing to run '%s' because %s might be a production kube context.
-If you're sure you want to continue add:
-	allow_k8s_contexts('%s')
-before this function call in your Tiltfile. Otherwise, switch k8s contexts and restart Tilt.`, fn.Name(), kubeContext, kubeContext)
+			return nil, k8sContextState.NotAllowedError(
+				fmt.Sprintf("Refusing to run '%s' because %s might be a production kube context.", fn.Name(), kubeContext),
+				"before this function call in your Tiltfile")
 		}
 
 		return f(thread, fn, args, kwargs)
@@ -531,6 +610,7 @@ func (s *tiltfileState) OnStart(e *starkit.Environment) error {
 	e.SetArgUnpacker(s.unpackArgs)
 	e.SetPrint(s.print)
 	e.SetContext(s.ctx)
+	e.SetExecProgress(s.progress)
 
 	for _, b := range []struct {
 		name    string
@@ -546,8 +626,12 @@ func (s *tiltfileState) OnStart(e *starkit.Environment) error {
 		{filterYamlN, s.filterYaml},
 		{k8sResourceN, s.k8sResource},
 		{k8sCustomDeployN, s.k8sCustomDeploy},
+		{k8sDebugOverrideN, s.k8sDebugOverride},
+		{k8sDevOverrideN, s.k8sDevOverride},
 		{localResourceN, s.localResource},
 		{testN, s.localResource}, // test is just a fork of local resource, w/ some switches based on fn.Name()
+		{syncResourceN, s.syncResource},
+		{bootstrapN, s.potentiallyK8sUnsafeBuiltin(s.bootstrap)},
 		{portForwardN, s.portForward},
 		{k8sKindN, s.k8sKind},
 		{k8sImageJSONPathN, s.k8sImageJsonPath},
@@ -556,6 +640,7 @@ func (s *tiltfileState) OnStart(e *starkit.Environment) error {
 		{helmN, s.helm},
 		{triggerModeN, s.triggerModeFn},
 		{fallBackOnN, s.liveUpdateFallBackOn},
+		{restartOnlyN, s.liveUpdateRestartOnly},
 		{syncN, s.liveUpdateSync},
 		{runN, s.liveUpdateRun},
 		{restartContainerN, s.liveUpdateRestartContainer},
@@ -563,6 +648,7 @@ func (s *tiltfileState) OnStart(e *starkit.Environment) error {
 		{disableFeatureN, s.disableFeature},
 		{disableSnapshotsN, s.disableSnapshots},
 		{setTeamN, s.setTeam},
+		{resourceMetadataN, s.resourceMetadataFn},
 	} {
 		err := e.AddBuiltin(b.name, b.builtin)
 		if err != nil {
@@ -668,10 +754,14 @@ func (s *tiltfileState) assembleImages() error {
 		var depImages []reference.Named
 		if imageBuilder.dbDockerfile != "" {
 			depImages, err = imageBuilder.dbDockerfile.FindImages(imageBuilder.dbBuildArgs)
-		}
+			if err != nil {
+				return err
+			}
 
-		if err != nil {
-			return err
+			imageBuilder.dbResolvedBuildArgs, err = imageBuilder.dbDockerfile.ResolveBuildArgs(imageBuilder.dbBuildArgs)
+			if err != nil {
+				return err
+			}
 		}
 
 		for _, depImage := range depImages {
@@ -746,6 +836,15 @@ func (s *tiltfileState) assembleK8s() error {
 			if opts.discoveryStrategy != "" {
 				r.discoveryStrategy = opts.discoveryStrategy
 			}
+			if opts.applyDiscipline != "" {
+				r.applyDiscipline = opts.applyDiscipline
+			}
+			if opts.namespace != "" {
+				r.namespace = opts.namespace
+			}
+			if opts.logLevelFormat != logger.LevelFormatNone {
+				r.logLevelFormat = opts.logLevelFormat
+			}
 			r.portForwards = append(r.portForwards, opts.portForwards...)
 			if opts.triggerMode != TriggerModeUnset {
 				r.triggerMode = opts.triggerMode
@@ -753,6 +852,12 @@ func (s *tiltfileState) assembleK8s() error {
 			if opts.autoInit.IsSet {
 				r.autoInit = opts.autoInit.Value
 			}
+			if opts.watchInCI.IsSet {
+				r.watchInCI = opts.watchInCI.Value
+			}
+			if opts.buildTimeout != nil {
+				r.buildTimeout = opts.buildTimeout
+			}
 			r.resourceDeps = append(r.resourceDeps, opts.resourceDeps...)
 			r.links = append(r.links, opts.links...)
 			for k, v := range opts.labels {
@@ -781,6 +886,9 @@ func (s *tiltfileState) assembleK8s() error {
 				if !ok || len(entities) == 0 {
 					return fmt.Errorf("No object identified by the fragment %q could be found. Possible objects are: %s", o, sliceutils.QuotedStringList(fullNames))
 				}
+				// Identical copies of a shared object (from k8s_yaml(..., allow_duplicates=True))
+				// all match the same fragment. Each resource claims one copy.
+				entities = uniqueK8sObjects(entities)
 				if len(entities) > 1 {
 					matchingObjects := make([]string, len(entities))
 					for i, e := range entities {
@@ -798,7 +906,7 @@ func (s *tiltfileState) assembleK8s() error {
 					}
 					return fmt.Errorf("No object identified by the fragment %q could be found in remaining YAML. Valid remaining fragments are: %s", o, sliceutils.QuotedStringList(remainingUnresourced))
 				}
-				if len(entitiesToRemove) > 1 {
+				if len(uniqueK8sObjects(entitiesToRemove)) > 1 {
 					panic(fmt.Sprintf("Fragment %q matches %d resources. Each object fragment must match exactly 1 resource. This should NOT be possible at this point in the code, we should have already checked that this fragment was unique", o, len(entitiesToRemove)))
 				}
 
@@ -995,18 +1103,23 @@ func (s *tiltfileState) k8sResourceForName(name string) (*k8sResource, error) {
 	return s.makeK8sResource(name)
 }
 
-// decideRegistry returns the image registry we should use; if detected, a pre-configured
-// local registry; otherwise, the registry specified by the user via default_registry.
-// Otherwise, we'll return the zero value of `s.defaultReg`, which is an empty registry.
-// It has side-effects (a log line) and so should only be called once.
-func (s *tiltfileState) decideRegistry() container.Registry {
+// decideRegistry returns the image registry we should use: the registry specified
+// by the user via default_registry, if any; otherwise, the local registry that
+// the cluster advertises, if any. Otherwise, returns an empty registry.
+func (s *tiltfileState) decideRegistry() model.ImageRegistry {
+	if !s.defaultReg.Empty() {
+		return model.ImageRegistry{
+			Registry: s.defaultReg,
+			Source:   model.ImageRegistrySourceConfigured,
+		}
+	}
 	if s.orchestrator() == model.OrchestratorK8s && !s.localRegistry.Empty() {
-		// If we've found a local registry in the cluster at run-time, use that
-		// instead of the default_registry (if any) declared in the Tiltfile
-		s.logger.Infof("Auto-detected local registry from environment: %s", s.localRegistry)
-		return s.localRegistry
+		return model.ImageRegistry{
+			Registry: s.localRegistry,
+			Source:   model.ImageRegistrySourceDiscovered,
+		}
 	}
-	return s.defaultReg
+	return model.ImageRegistry{}
 }
 
 // Auto-infer the readiness mode
@@ -1062,7 +1175,10 @@ func (s *tiltfileState) inferPodReadinessMode(r *k8sResource) model.PodReadiness
 
 func (s *tiltfileState) translateK8s(resources []*k8sResource, updateSettings model.UpdateSettings) ([]model.Manifest, error) {
 	var result []model.Manifest
-	registry := s.decideRegistry()
+	s.imageRegistry = s.decideRegistry()
+	registry := s.imageRegistry.Registry
+	debugOverridesUsed := make(map[string]bool)
+	devOverridesUsed := make(map[string]bool)
 	for _, r := range resources {
 		mn := model.ManifestName(r.name)
 		tm, err := starlarkTriggerModeToModel(s.triggerModeForResource(r.triggerMode), r.autoInit)
@@ -1078,6 +1194,8 @@ func (s *tiltfileState) translateK8s(resources []*k8sResource, updateSettings mo
 			Name:                 mn,
 			TriggerMode:          tm,
 			ResourceDependencies: mds,
+			WatchInCI:            r.watchInCI,
+			BuildTimeout:         r.buildTimeout,
 		}
 
 		m = m.WithLabels(r.labels)
@@ -1101,20 +1219,48 @@ func (s *tiltfileState) translateK8s(resources []*k8sResource, updateSettings mo
 
 		m = m.WithImageTargets(iTargets)
 
+		if o, ok := s.k8sDevOverrides[r.name]; ok {
+			if err := s.injectDevOverride(r, o); err != nil {
+				return nil, err
+			}
+			devOverridesUsed[r.name] = true
+		}
+
 		k8sTarget, err := s.k8sDeployTarget(mn.TargetName(), r, iTargets, updateSettings)
 		if err != nil {
 			return nil, errors.Wrapf(err, "creating K8s deploy target for %s", r.name)
 		}
 
+		if o, ok := s.k8sDebugOverrides[r.name]; ok {
+			k8sTarget = k8sTarget.WithDebugOverride(&o)
+			debugOverridesUsed[r.name] = true
+		}
+
 		m = m.WithDeployTarget(k8sTarget)
 		result = append(result, m)
 	}
 
+	for name := range s.k8sDebugOverrides {
+		if !debugOverridesUsed[name] {
+			return nil, fmt.Errorf("%s: no Kubernetes resource named %q", k8sDebugOverrideN, name)
+		}
+	}
+	for name := range s.k8sDevOverrides {
+		if !devOverridesUsed[name] {
+			return nil, fmt.Errorf("%s: no Kubernetes resource named %q", k8sDevOverrideN, name)
+		}
+	}
+
 	err := maybeRestartContainerDeprecationError(result)
 	if err != nil {
 		return nil, err
 	}
 
+	err = maybeRestartOnlyError(result)
+	if err != nil {
+		return nil, err
+	}
+
 	return result, nil
 }
 
@@ -1131,6 +1277,8 @@ func (s *tiltfileState) k8sDeployTarget(targetName model.TargetName, r *k8sResou
 		Timeout:                         metav1.Duration{Duration: updateSettings.K8sUpsertTimeout()},
 		PortForwardTemplateSpec:         k8s.PortForwardTemplateSpec(s.defaultedPortForwards(r.portForwards)),
 		DiscoveryStrategy:               r.discoveryStrategy,
+		ApplyDiscipline:                 r.applyDiscipline,
+		Namespace:                       r.namespace,
 		KubernetesDiscoveryTemplateSpec: kdTemplateSpec,
 		PodLogStreamTemplateSpec: &v1alpha1.PodLogStreamTemplateSpec{
 			SinceTime: &sinceTime,
@@ -1138,6 +1286,7 @@ func (s *tiltfileState) k8sDeployTarget(targetName model.TargetName, r *k8sResou
 				string(container.IstioInitContainerName),
 				string(container.IstioSidecarContainerName),
 			},
+			LogLevelFormat: string(r.logLevelFormat),
 		},
 	}
 
@@ -1309,11 +1458,17 @@ func (s *tiltfileState) validateDockerComposeVersion() error {
 			// maintain compatibility with existing tooling
 			downgradeInstructions = "Ensure `docker-compose` in your PATH points to Compose v1 and re-launch Tilt.\n"
 		}
-		logger.Get(s.ctx).Warnf("Support for Docker Compose v2.x is experimental, and you might encounter errors or broken functionality.\n"+
-			"For best results, we recommend using Docker Compose v1.x with Tilt.\n%s", downgradeInstructions)
+		warnings.Add(s.ctx, model.TiltfileWarning{
+			Key: "docker-compose-version:v2",
+			Message: fmt.Sprintf("Support for Docker Compose v2.x is experimental, and you might encounter errors or broken functionality.\n"+
+				"For best results, we recommend using Docker Compose v1.x with Tilt.\n%s", downgradeInstructions),
+		})
 	} else if semver.Prerelease(dcVersion) != "" {
-		logger.Get(s.ctx).Warnf("You are running a pre-release version of Docker Compose (%s), which is unsupported.\n"+
-			"You might encounter errors or broken functionality.", dcVersion)
+		warnings.Add(s.ctx, model.TiltfileWarning{
+			Key: "docker-compose-version:prerelease",
+			Message: fmt.Sprintf("You are running a pre-release version of Docker Compose (%s), which is unsupported.\n"+
+				"You might encounter errors or broken functionality.", dcVersion),
+		})
 	}
 	return nil
 }
@@ -1348,6 +1503,31 @@ func needsRestartContainerDeprecationError(m model.Manifest) bool {
 	return false
 }
 
+// restart_only() relies on native container restarts, which (like
+// restart_container()) we only support for Docker Compose resources.
+func maybeRestartOnlyError(manifests []model.Manifest) error {
+	var needsError []string
+	for _, m := range manifests {
+		if !m.IsK8s() {
+			continue
+		}
+		for _, iTarg := range m.ImageTargets {
+			if len(iTarg.LiveUpdateSpec.RestartPaths) > 0 {
+				needsError = append(needsError, m.Name.String())
+				break
+			}
+		}
+	}
+
+	if len(needsError) > 0 {
+		return fmt.Errorf("Found `restart_only()` LiveUpdate step in resource(s): [%s]. "+
+			"`restart_only()` restarts the container natively, which is only supported for Docker Compose resources. "+
+			"For Kubernetes resources, see https://docs.tilt.dev/live_update_reference.html#restarting-your-process",
+			strings.Join(needsError, ", "))
+	}
+	return nil
+}
+
 // Grabs all image targets for the given references,
 // as well as any of their transitive dependencies.
 func (s *tiltfileState) imgTargetsForDependencyIDs(mn model.ManifestName, ids []model.TargetID, reg container.Registry) ([]model.ImageTarget, error) {
@@ -1396,6 +1576,8 @@ func (s *tiltfileState) imgTargetsForDependencyIDsHelper(mn model.ManifestName,
 				OverrideArgs:    image.overrideArgs,
 			},
 			LiveUpdateSpec: image.liveUpdate,
+			PushMode:       image.pushMode,
+			WatchInCI:      image.watchInCI,
 		}
 		if !liveupdate.IsEmptySpec(image.liveUpdate) {
 			iTarget.LiveUpdateName = liveupdate.GetName(mn, iTarget.ID())
@@ -1403,18 +1585,25 @@ func (s *tiltfileState) imgTargetsForDependencyIDsHelper(mn model.ManifestName,
 
 		switch image.Type() {
 		case DockerBuild:
+			dockerfilePath := image.dbDockerfilePath
+			if image.dbInlineContents {
+				dockerfilePath = ""
+			}
 			iTarget = iTarget.WithBuildDetails(model.DockerBuild{
-				Dockerfile:  image.dbDockerfile.String(),
-				BuildPath:   image.dbBuildPath,
-				BuildArgs:   image.dbBuildArgs,
-				TargetStage: model.DockerBuildTarget(image.targetStage),
-				SSHSpecs:    image.sshSpecs,
-				SecretSpecs: image.secretSpecs,
-				Network:     image.network,
-				CacheFrom:   image.cacheFrom,
-				PullParent:  image.pullParent,
-				Platform:    image.platform,
-				ExtraTags:   image.extraTags,
+				Dockerfile:        image.dbDockerfile.String(),
+				DockerfilePath:    dockerfilePath,
+				BuildPath:         image.dbBuildPath,
+				BuildArgs:         image.dbBuildArgs,
+				ResolvedBuildArgs: image.dbResolvedBuildArgs,
+				TargetStage:       model.DockerBuildTarget(image.targetStage),
+				SSHSpecs:          image.sshSpecs,
+				SecretSpecs:       image.secretSpecs,
+				Network:           image.network,
+				CacheFrom:         image.cacheFrom,
+				PullParent:        image.pullParent,
+				Platform:          image.platform,
+				ExtraTags:         image.extraTags,
+				ContentTag:        image.contentTag,
 			})
 		case CustomBuild:
 			r := model.CustomBuild{
@@ -1425,6 +1614,9 @@ func (s *tiltfileState) imgTargetsForDependencyIDsHelper(mn model.ManifestName,
 				DisablePush:       image.disablePush,
 				SkipsLocalDocker:  image.skipsLocalDocker,
 				OutputsImageRefTo: image.outputsImageRefTo,
+				Env:               image.customEnv,
+				ImageStore:        image.imageStore,
+				ContentTag:        image.contentTag,
 			}
 			iTarget = iTarget.WithBuildDetails(r).
 				MaybeIgnoreRegistry()
@@ -1471,10 +1663,9 @@ func (s *tiltfileState) translateDC(dc dcResourceSet) ([]model.Manifest, error)
 			return nil, errors.Wrapf(err, "getting image build info for %s", svc.Name)
 		}
 
-		for _, iTarg := range iTargets {
-			if iTarg.OverrideCommand != nil {
-				return nil, fmt.Errorf("docker_build/custom_build.entrypoint not supported for Docker Compose resources")
-			}
+		err = model.ValidateDockerComposeImageTargets(iTargets)
+		if err != nil {
+			return nil, err
 		}
 
 		m = m.WithImageTargets(iTargets)
@@ -1553,15 +1744,27 @@ func (s *tiltfileState) translateLocal() ([]model.Manifest, error) {
 				LocalPath: r.threadDir,
 			})
 		}
+		if len(r.outputs) != 0 {
+			// Don't let the cmd's own writes re-trigger it.
+			ignores = append(ignores, model.Dockerignore{
+				Patterns:  r.outputs,
+				Source:    fmt.Sprintf("local_resource(%q) outputs", r.name),
+				LocalPath: r.threadDir,
+			})
+		}
 
 		lt := model.NewLocalTarget(model.TargetName(r.name), r.updateCmd, r.serveCmd, r.deps).
+			WithOutputs(r.outputs).
 			WithRepos(reposForPaths(paths)).
 			WithIgnores(ignores).
 			WithAllowParallel(r.allowParallel).
 			WithLinks(r.links).
 			WithTags(r.tags).
 			WithIsTest(r.isTest).
-			WithReadinessProbe(r.readinessProbe)
+			WithReadinessProbe(r.readinessProbe).
+			WithLivenessProbe(r.livenessProbe).
+			WithServeReload(r.serveReload).
+			WithLogLevelFormat(string(r.logLevelFormat))
 		var mds []model.ManifestName
 		for _, md := range r.resourceDeps {
 			mds = append(mds, model.ManifestName(md))
@@ -1570,6 +1773,8 @@ func (s *tiltfileState) translateLocal() ([]model.Manifest, error) {
 			Name:                 mn,
 			TriggerMode:          tm,
 			ResourceDependencies: mds,
+			WatchInCI:            r.watchInCI,
+			BuildTimeout:         r.buildTimeout,
 		}.WithDeployTarget(lt)
 
 		m = m.WithLabels(r.labels)
@@ -1620,6 +1825,58 @@ func validateResourceDependencies(ms []model.Manifest) error {
 	return nil
 }
 
+// Finds resources whose inputs overlap another resource's declared outputs,
+// and records the producers as OutputDependencies.
+//
+// Returns an error if outputs and inputs form a cycle, because then there's
+// no order we could build them in.
+func assignOutputDependencies(ms []model.Manifest) error {
+	edges := make(map[interface{}][]interface{})
+	for i, consumer := range ms {
+		inputs := consumer.InputPaths()
+		if len(inputs) == 0 {
+			continue
+		}
+
+		var producers []model.ManifestName
+		for _, producer := range ms {
+			if producer.Name == consumer.Name || !producer.IsLocal() {
+				continue
+			}
+			if pathsOverlap(producer.LocalTarget().Outputs, inputs) {
+				producers = append(producers, producer.Name)
+				edges[consumer.Name] = append(edges[consumer.Name], producer.Name)
+			}
+		}
+		ms[i].OutputDependencies = producers
+	}
+
+	connections := tarjan.Connections(edges)
+	for _, g := range connections {
+		if len(g) > 1 {
+			var nodes []string
+			for i := range g {
+				nodes = append(nodes, string(g[len(g)-i-1].(model.ManifestName)))
+			}
+			nodes = append(nodes, string(g[len(g)-1].(model.ManifestName)))
+			return fmt.Errorf("cycle detected between resource outputs and inputs: %s", strings.Join(nodes, " -> "))
+		}
+	}
+	return nil
+}
+
+// Returns true if any output is inside an input, or vice versa.
+func pathsOverlap(outputs []string, inputs []string) bool {
+	for _, o := range outputs {
+		for _, in := range inputs {
+			if ospath.IsChild(in, o) || ospath.IsChild(o, in) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 var _ starkit.Plugin = &tiltfileState{}
 var _ starkit.OnExecPlugin = &tiltfileState{}
 var _ starkit.OnBuiltinCallPlugin = &tiltfileState{}
@@ -19,6 +19,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/clientcmd"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
 
 	tiltanalytics "github.com/tilt-dev/tilt/internal/analytics"
@@ -40,6 +41,7 @@ import (
 	"github.com/tilt-dev/tilt/internal/tiltfile/config"
 	tiltfile_k8s "github.com/tilt-dev/tilt/internal/tiltfile/k8s"
 	"github.com/tilt-dev/tilt/internal/tiltfile/k8scontext"
+	tiltfileos "github.com/tilt-dev/tilt/internal/tiltfile/os"
 	"github.com/tilt-dev/tilt/internal/tiltfile/testdata"
 	"github.com/tilt-dev/tilt/internal/tiltfile/version"
 	"github.com/tilt-dev/tilt/internal/yaml"
@@ -72,6 +74,40 @@ func TestEmpty(t *testing.T) {
 	f.load()
 }
 
+func TestEmptyNoResourcesReason(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", "")
+	f.load()
+	assert.Equal(t, 0, f.loadResult.DefinedManifestCount)
+	assert.Equal(t, "Tiltfile defines no resources", f.loadResult.NoResourcesReason())
+}
+
+func TestEnabledResourcesFilterFromArgs(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+local_resource('foo', 'echo foo')
+local_resource('bar', 'echo bar')
+`)
+	f.load("foo")
+	assert.Len(t, f.loadResult.Manifests, 1)
+	assert.Equal(t, 2, f.loadResult.DefinedManifestCount)
+	assert.Equal(t, "command-line args", f.loadResult.EnabledResourcesFilter)
+	assert.Equal(t, "", f.loadResult.NoResourcesReason())
+}
+
+func TestNoResourcesReasonAllFilteredOut(t *testing.T) {
+	tlr := TiltfileLoadResult{DefinedManifestCount: 2, EnabledResourcesFilter: "config.set_enabled_resources()"}
+	assert.Equal(t, "2 resources were defined, but all were filtered out by config.set_enabled_resources()",
+		tlr.NoResourcesReason())
+
+	tlr.Error = fmt.Errorf("boom")
+	assert.Equal(t, "", tlr.NoResourcesReason())
+}
+
 func TestMissingDockerfile(t *testing.T) {
 	f := newFixture(t)
 	defer f.TearDown()
@@ -533,6 +569,41 @@ k8s_resource("the-deployment", "foo")
 	f.assertConfigFiles("Tiltfile", ".tiltignore", "foo/Dockerfile", "foo/.dockerignore", "configMap.yaml", "deployment.yaml", "kustomization.yaml", "service.yaml")
 }
 
+func TestKustomizeTransitiveBases(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("overlays/dev/kustomization.yaml", `resources:
+- ../../base/app`)
+	f.file("base/app/kustomization.yaml", `resources:
+- ../common
+- deployment.yaml`)
+	f.file("base/app/deployment.yaml", kustomizeDeploymentText)
+	f.file("base/common/kustomization.yaml", `resources:
+- configMap.yaml`)
+	f.file("base/common/configMap.yaml", kustomizeConfigMapText)
+	f.file("Tiltfile", `
+k8s_yaml(kustomize("overlays/dev"))
+`)
+
+	f.load()
+	f.assertConfigFiles("Tiltfile", ".tiltignore",
+		"overlays/dev/kustomization.yaml",
+		"base/app/kustomization.yaml", "base/app/deployment.yaml",
+		"base/common/kustomization.yaml", "base/common/configMap.yaml")
+
+	// Bases added since the last load are picked up on the next one.
+	f.file("base/common/kustomization.yaml", `resources:
+- configMap.yaml
+- service.yaml`)
+	f.file("base/common/service.yaml", kustomizeServiceText)
+	f.load()
+	f.assertConfigFiles("Tiltfile", ".tiltignore",
+		"overlays/dev/kustomization.yaml",
+		"base/app/kustomization.yaml", "base/app/deployment.yaml",
+		"base/common/kustomization.yaml", "base/common/configMap.yaml", "base/common/service.yaml")
+}
+
 func TestKustomizeError(t *testing.T) {
 	f := newFixture(t)
 	defer f.TearDown()
@@ -687,6 +758,72 @@ docker_build("gcr.io/foo", "foo", extra_tag='cherry bomb')
 	f.loadErrString("Argument extra_tag=\"cherry bomb\" not a valid image reference: invalid reference format")
 }
 
+func TestDockerBuildContentTag(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+	f.file("Tiltfile", `
+k8s_yaml('foo.yaml')
+docker_build("gcr.io/foo", "foo", content_tag=True)
+`)
+	f.load()
+	m := f.assertNextManifest("foo")
+	assert.True(t, m.ImageTargets[0].BuildDetails.(model.DockerBuild).ContentTag)
+}
+
+func TestDockerBuildContentTagWithPull(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+	f.file("Tiltfile", `
+k8s_yaml('foo.yaml')
+docker_build("gcr.io/foo", "foo", content_tag=True, pull=True)
+`)
+	f.loadErrString("Cannot specify both pull=True and content_tag=True")
+}
+
+func TestCustomBuildContentTag(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+	f.file("Tiltfile", `
+k8s_yaml('foo.yaml')
+custom_build('gcr.io/foo', 'docker build -t $EXPECTED_REF foo', ['foo'], content_tag=True)
+`)
+	f.load()
+	m := f.assertNextManifest("foo")
+	cb := m.ImageTargets[0].CustomBuildInfo()
+	assert.True(t, cb.ContentTag)
+	assert.Equal(t, "", cb.Tag)
+}
+
+func TestCustomBuildContentTagWithTag(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+	f.file("Tiltfile", `
+k8s_yaml('foo.yaml')
+custom_build('gcr.io/foo', 'docker build -t gcr.io/foo:dev foo', ['foo'], tag='dev', content_tag=True)
+`)
+	f.loadErrString("Cannot specify both tag= and content_tag=True")
+}
+
+func TestCustomBuildContentTagSkipsLocalDocker(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+	f.file("Tiltfile", `
+k8s_yaml('foo.yaml')
+custom_build('gcr.io/foo', 'buildctl build', ['foo'], skips_local_docker=True, content_tag=True)
+`)
+	f.loadErrString("content_tag=True only works with images in Docker")
+}
+
 func TestDockerBuildCache(t *testing.T) {
 	f := newFixture(t)
 	defer f.TearDown()
@@ -829,6 +966,10 @@ func TestPortForward(t *testing.T) {
 		newPortForwardSuccessCase("value_constructor_host", "port_forward(8001, 443, host='elastic.local')",
 			[]model.PortForward{{LocalPort: 8001, ContainerPort: 443, Host: "elastic.local"}}),
 		newPortForwardErrorCase("value_constructor_host_wrong_type", "port_forward(8001, 443, host=54321)", "for parameter \"host\": got int, want string"),
+		newPortForwardSuccessCase("value_constructor_verify", "port_forward(8001, 443, verify=True)",
+			[]model.PortForward{{LocalPort: 8001, ContainerPort: 443, Verify: &v1alpha1.ForwardVerify{}}}),
+		newPortForwardSuccessCase("value_constructor_verify_path", "port_forward(8001, 443, verify_path='/healthz')",
+			[]model.PortForward{{LocalPort: 8001, ContainerPort: 443, Verify: &v1alpha1.ForwardVerify{HTTPPath: "/healthz"}}}),
 
 		// list values
 		newPortForwardSuccessCase("list_mixed", "[8000, port_forward(8001, 443), '8002', '8003:444'],", []model.PortForward{{LocalPort: 8000}, {LocalPort: 8001, ContainerPort: 443}, {LocalPort: 8002}, {LocalPort: 8003, ContainerPort: 444}}),
@@ -1816,6 +1957,100 @@ k8s_resource('foo', discovery_strategy='typo')
 	f.loadErrString("Invalid. Must be one of: \"default\", \"selectors-only\"")
 }
 
+func TestK8sApplyDiscipline(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("config.yaml", `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  foo: "bar"
+`)
+	f.file("Tiltfile", `
+k8s_yaml('config.yaml')
+k8s_resource(new_name='config', objects=['config'], apply_discipline='infrastructure')
+`)
+
+	f.load()
+	m := f.assertNextManifest("config")
+	assert.Equal(t, v1alpha1.KubernetesApplyDisciplineInfrastructure, m.K8sTarget().ApplyDiscipline)
+}
+
+func TestK8sApplyDisciplineInvalid(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.yaml("foo.yaml", deployment("foo", image("gcr.io/foo:stable")))
+	f.file("Tiltfile", `
+k8s_yaml('foo.yaml')
+k8s_resource('foo', apply_discipline='typo')
+`)
+
+	f.loadErrString("Invalid. Must be one of: \"default\", \"infrastructure\"")
+}
+
+func TestK8sNamespace(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+	t.Setenv("TILT_TEST_USER", "alice")
+
+	f.yaml("foo.yaml", deployment("foo", image("gcr.io/foo:stable"), namespace("team")))
+	f.file("Tiltfile", `
+k8s_yaml('foo.yaml')
+k8s_resource('foo', namespace='$TILT_TEST_USER-dev')
+`)
+
+	f.load()
+	m := f.assertNextManifest("foo")
+	assert.Equal(t, "alice-dev", m.K8sTarget().Namespace)
+
+	// The YAML is untouched. The namespace is overridden at apply time.
+	assert.Contains(t, m.K8sTarget().YAML, "namespace: team")
+}
+
+func TestK8sNamespaceInvalid(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.yaml("foo.yaml", deployment("foo", image("gcr.io/foo:stable")))
+	f.file("Tiltfile", `
+k8s_yaml('foo.yaml')
+k8s_resource('foo', namespace='Not_A_Namespace')
+`)
+
+	f.loadErrString("spec.namespace: Invalid value: \"Not_A_Namespace\"")
+}
+
+func TestK8sResourceLogLevelFormat(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.yaml("foo.yaml", deployment("foo", image("gcr.io/foo:stable")))
+	f.file("Tiltfile", `
+k8s_yaml('foo.yaml')
+k8s_resource('foo', log_level_format='zap')
+`)
+
+	f.load()
+	m := f.assertNextManifest("foo")
+	assert.Equal(t, "zap", m.K8sTarget().KubernetesApplySpec.PodLogStreamTemplateSpec.LogLevelFormat)
+}
+
+func TestK8sResourceLogLevelFormatInvalid(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.yaml("foo.yaml", deployment("foo", image("gcr.io/foo:stable")))
+	f.file("Tiltfile", `
+k8s_yaml('foo.yaml')
+k8s_resource('foo', log_level_format='log4j')
+`)
+
+	f.loadErrString(`k8s_resource: log_level_format: Unrecognized log level format "log4j". Allowed values: [prefix logrus zap]`)
+}
+
 func TestPodReadinessOverrideDeployment(t *testing.T) {
 	f := newFixture(t)
 	defer f.TearDown()
@@ -2995,7 +3230,6 @@ func TestLocalRegistry(t *testing.T) {
 
 	f.setupFoo()
 	f.file("Tiltfile", `
-default_registry('bar.com')  # localRegistry should override this
 docker_build('gcr.io/foo', 'foo')
 k8s_yaml('foo.yaml')
 `)
@@ -3005,6 +3239,76 @@ k8s_yaml('foo.yaml')
 	f.assertNextManifest("foo",
 		db(image("gcr.io/foo").withLocalRef("localhost:32000/gcr.io_foo")),
 		deployment("foo"))
+	assert.Equal(t, model.ImageRegistrySourceDiscovered, f.loadResult.ImageRegistry.Source)
+	assert.Equal(t, "localhost:32000", f.loadResult.ImageRegistry.Registry.Host)
+}
+
+func TestLocalRegistryWithHostFromCluster(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	reg, err := container.NewRegistryWithHostFromCluster("localhost:5000", "registry:5000")
+	require.NoError(t, err)
+	f.kCli.Registry = reg
+
+	f.setupFoo()
+	f.file("Tiltfile", `
+docker_build('gcr.io/foo', 'foo')
+k8s_yaml('foo.yaml')
+`)
+
+	f.load()
+
+	// We push to the host's address for the registry,
+	// but the cluster pulls from the cluster's address.
+	f.assertNextManifest("foo",
+		db(image("gcr.io/foo").
+			withLocalRef("localhost:5000/gcr.io_foo").
+			withClusterRef("registry:5000/gcr.io_foo")),
+		deployment("foo"))
+}
+
+func TestDefaultRegistryOverridesLocalRegistry(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	reg, err := container.NewRegistryWithHostFromCluster("localhost:5000", "registry:5000")
+	require.NoError(t, err)
+	f.kCli.Registry = reg
+
+	f.setupFoo()
+	f.file("Tiltfile", `
+default_registry('bar.com')
+docker_build('gcr.io/foo', 'foo')
+k8s_yaml('foo.yaml')
+`)
+
+	f.load()
+
+	f.assertNextManifest("foo",
+		db(image("gcr.io/foo").
+			withLocalRef("bar.com/gcr.io_foo").
+			withClusterRef("bar.com/gcr.io_foo")),
+		deployment("foo"))
+	assert.Equal(t, model.ImageRegistrySourceConfigured, f.loadResult.ImageRegistry.Source)
+}
+
+func TestNoRegistry(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+	f.file("Tiltfile", `
+docker_build('gcr.io/foo', 'foo')
+k8s_yaml('foo.yaml')
+`)
+
+	f.load()
+
+	f.assertNextManifest("foo",
+		db(image("gcr.io/foo")),
+		deployment("foo"))
+	assert.True(t, f.loadResult.ImageRegistry.Empty())
 }
 
 func TestLocalRegistryDockerCompose(t *testing.T) {
@@ -3243,91 +3547,325 @@ k8s_resource('foo', new_name='bar')
 	f.assertNextManifest("bar", deployment("foo"))
 }
 
-func TestK8sResourceRenameTwice(t *testing.T) {
+func TestK8sResourceWatchInCI(t *testing.T) {
 	f := newFixture(t)
 	defer f.TearDown()
 
 	f.setupFoo()
 	f.file("Tiltfile", `
 k8s_yaml('foo.yaml')
-k8s_resource('foo', new_name='bar')
-k8s_resource('bar', new_name='baz')
+k8s_resource('foo', watch_in_ci=True)
+k8s_resource('foo', port_forwards=8000)
 `)
 
 	f.load()
-	f.assertNumManifests(1)
-	f.assertNextManifest("baz", deployment("foo"))
+	m := f.assertNextManifest("foo", deployment("foo"))
+	assert.True(t, m.WatchInCI)
 }
 
-func TestK8sResourceNewNameConflict(t *testing.T) {
+func TestK8sResourceBuildTimeout(t *testing.T) {
 	f := newFixture(t)
 	defer f.TearDown()
 
-	f.setupFooAndBar()
+	f.setupFoo()
 	f.file("Tiltfile", `
-
-k8s_yaml(['foo.yaml', 'bar.yaml'])
-k8s_resource('foo', new_name='bar')
+k8s_yaml('foo.yaml')
+k8s_resource('foo', build_timeout_secs=600)
+k8s_resource('foo', port_forwards=8000)
 `)
 
-	f.loadErrString("\"foo\" to \"bar\"", "already exists a resource with that name")
+	f.load()
+	m := f.assertNextManifest("foo", deployment("foo"))
+	require.NotNil(t, m.BuildTimeout)
+	assert.Equal(t, 10*time.Minute, *m.BuildTimeout)
 }
 
-func TestK8sResourceRenameConflictingNames(t *testing.T) {
+func TestK8sDebugOverride(t *testing.T) {
 	f := newFixture(t)
 	defer f.TearDown()
 
-	f.dockerfile("foo1/Dockerfile")
-	f.dockerfile("foo2/Dockerfile")
-	f.yaml("foo1.yaml", deployment("foo", image("gcr.io/foo1"), namespace("ns1")))
-	f.yaml("foo2.yaml", deployment("foo", image("gcr.io/foo2"), namespace("ns2")))
-
+	f.setupFoo()
 	f.file("Tiltfile", `
-
-k8s_yaml(['foo1.yaml', 'foo2.yaml'])
-docker_build('gcr.io/foo1', 'foo1')
-docker_build('gcr.io/foo2', 'foo2')
-k8s_resource('foo:deployment:ns2', new_name='foo')
+k8s_yaml('foo.yaml')
+k8s_resource('foo', new_name='bar')
+k8s_debug_override('bar', command=['dlv', 'exec', '/app/foo'], args=[], ports=[2345], remove_readiness_probe=True)
 `)
-	f.load("foo:deployment:ns1", "foo")
 
-	f.assertNextManifest("foo:deployment:ns1", db(image("gcr.io/foo1")))
-	f.assertNextManifest("foo", db(image("gcr.io/foo2")))
+	f.load()
+	m := f.assertNextManifest("bar", deployment("foo"))
+	assert.Equal(t, &model.K8sDebugOverride{
+		Command:              []string{"dlv", "exec", "/app/foo"},
+		Args:                 []string{},
+		Ports:                []int32{2345},
+		RemoveReadinessProbe: true,
+	}, m.K8sTarget().DebugOverride)
 }
 
-func TestConflictingNewNames(t *testing.T) {
+func TestK8sDebugOverrideShellCommand(t *testing.T) {
 	f := newFixture(t)
 	defer f.TearDown()
 
-	f.yaml("ns1.yaml", namespace("ns1"))
-	f.yaml("ns2.yaml", namespace("ns2"))
+	f.setupFoo()
 	f.file("Tiltfile", `
-k8s_yaml(['ns1.yaml', 'ns2.yaml'])
-k8s_resource(new_name='foo', objects=['ns1:namespace'])
-k8s_resource(new_name='foo', objects=['ns2:namespace'])
+k8s_yaml('foo.yaml')
+k8s_debug_override('foo', command='sleep infinity', container='app')
 `)
 
-	f.loadErrString("k8s_resource named \"foo\" already exists")
+	f.load()
+	m := f.assertNextManifest("foo", deployment("foo"))
+	assert.Equal(t, &model.K8sDebugOverride{
+		Container: "app",
+		Command:   []string{"sh", "-c", "sleep infinity"},
+	}, m.K8sTarget().DebugOverride)
 }
 
-func TestAdditivePortForwards(t *testing.T) {
+func TestK8sDebugOverrideUnknownResource(t *testing.T) {
 	f := newFixture(t)
 	defer f.TearDown()
 
 	f.setupFoo()
-
 	f.file("Tiltfile", `
-
 k8s_yaml('foo.yaml')
-k8s_resource('foo', port_forwards=8001)
-k8s_resource('foo', port_forwards=8000)
+k8s_debug_override('bar', command=['dlv'])
 `)
 
-	f.load()
-	f.assertNextManifest("foo", []model.PortForward{{LocalPort: 8001}, {LocalPort: 8000}})
+	f.loadErrString(`k8s_debug_override: no Kubernetes resource named "bar"`)
 }
 
-func TestWorkloadToResourceFunction(t *testing.T) {
+func TestK8sDebugOverrideBadPort(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+	f.file("Tiltfile", `
+k8s_yaml('foo.yaml')
+k8s_debug_override('foo', ports=[0])
+`)
+
+	f.loadErrString("k8s_debug_override: ports: invalid port 0")
+}
+
+const devOverrideYAML = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  replicas: 3
+  selector:
+    matchLabels:
+      app: api
+  template:
+    metadata:
+      labels:
+        app: api
+    spec:
+      containers:
+      - name: api
+        image: api
+        resources:
+          requests:
+            cpu: "2"
+            memory: 4Gi
+      - name: proxy
+        image: proxy
+        resources:
+          requests:
+            cpu: 500m
+            memory: 1Gi
+`
+
+func TestK8sDevOverride(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("api.yaml", devOverrideYAML)
+	f.file("Tiltfile", `
+k8s_yaml('api.yaml')
+k8s_dev_override('api',
+  replicas=1,
+  node_selector={'kubernetes.io/os': 'linux'},
+  tolerations=[{'key': 'node-role.kubernetes.io/master', 'operator': 'Exists', 'effect': 'NoSchedule'}],
+  resources={'requests': {'cpu': '100m', 'memory': '256Mi'}},
+  container_resources={'proxy': {'requests': {'cpu': '10m'}, 'limits': {'memory': '128Mi'}}})
+`)
+
+	f.load()
+	m := f.assertNextManifest("api")
+	entities := f.entities(m.K8sTarget().YAML)
+	require.Len(t, entities, 1)
+	d := entities[0].Obj.(*appsv1.Deployment)
+
+	assert.Equal(t, int32(1), *d.Spec.Replicas)
+	pod := d.Spec.Template.Spec
+	assert.Equal(t, map[string]string{"kubernetes.io/os": "linux"}, pod.NodeSelector)
+	assert.Equal(t, []v1.Toleration{
+		{Key: "node-role.kubernetes.io/master", Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoSchedule},
+	}, pod.Tolerations)
+
+	// Every container gets the override for all containers,
+	// except the ones that have their own.
+	assert.Equal(t, "100m", quantity(pod.Containers[0].Resources.Requests, v1.ResourceCPU))
+	assert.Equal(t, "256Mi", quantity(pod.Containers[0].Resources.Requests, v1.ResourceMemory))
+	assert.Equal(t, "10m", quantity(pod.Containers[1].Resources.Requests, v1.ResourceCPU))
+	assert.Equal(t, "128Mi", quantity(pod.Containers[1].Resources.Requests, v1.ResourceMemory))
+	assert.Equal(t, "128Mi", quantity(pod.Containers[1].Resources.Limits, v1.ResourceMemory))
+
+	assert.Contains(t, d.Annotations[k8s.DevOverrideAnnotation], "replicas=1")
+}
+
+func TestK8sDevOverrideRemoved(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("api.yaml", devOverrideYAML)
+	f.file("Tiltfile", `
+k8s_yaml('api.yaml')
+k8s_dev_override('api', replicas=1, resources={'requests': {'cpu': '100m'}})
+`)
+	f.load()
+	d := f.entities(f.assertNextManifest("api").K8sTarget().YAML)[0].Obj.(*appsv1.Deployment)
+	assert.Equal(t, int32(1), *d.Spec.Replicas)
+
+	f.file("Tiltfile", `
+k8s_yaml('api.yaml')
+`)
+	f.load()
+	d = f.entities(f.assertNextManifest("api").K8sTarget().YAML)[0].Obj.(*appsv1.Deployment)
+	assert.Equal(t, int32(3), *d.Spec.Replicas)
+	assert.Equal(t, "2", quantity(d.Spec.Template.Spec.Containers[0].Resources.Requests, v1.ResourceCPU))
+	assert.NotContains(t, d.Annotations, k8s.DevOverrideAnnotation)
+}
+
+func TestK8sDevOverrideUnknownContainer(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("api.yaml", devOverrideYAML)
+	f.file("Tiltfile", `
+k8s_yaml('api.yaml')
+k8s_dev_override('api', container_resources={'sidecar': {'requests': {'cpu': '10m'}}})
+`)
+
+	f.loadErrString(`k8s_dev_override: resource "api" has no container named "sidecar"`)
+}
+
+func TestK8sDevOverrideUnknownResource(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("api.yaml", devOverrideYAML)
+	f.file("Tiltfile", `
+k8s_yaml('api.yaml')
+k8s_dev_override('web', replicas=1)
+`)
+
+	f.loadErrString(`k8s_dev_override: no Kubernetes resource named "web"`)
+}
+
+func TestK8sDevOverrideBadQuantity(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("api.yaml", devOverrideYAML)
+	f.file("Tiltfile", `
+k8s_yaml('api.yaml')
+k8s_dev_override('api', resources={'requests': {'cpu': 'lots'}})
+`)
+
+	f.loadErrString(`k8s_dev_override: resources: requests: cpu: invalid quantity "lots"`)
+}
+
+func quantity(rl v1.ResourceList, name v1.ResourceName) string {
+	q := rl[name]
+	return q.String()
+}
+
+func TestK8sResourceRenameTwice(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+	f.file("Tiltfile", `
+k8s_yaml('foo.yaml')
+k8s_resource('foo', new_name='bar')
+k8s_resource('bar', new_name='baz')
+`)
+
+	f.load()
+	f.assertNumManifests(1)
+	f.assertNextManifest("baz", deployment("foo"))
+}
+
+func TestK8sResourceNewNameConflict(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFooAndBar()
+	f.file("Tiltfile", `
+
+k8s_yaml(['foo.yaml', 'bar.yaml'])
+k8s_resource('foo', new_name='bar')
+`)
+
+	f.loadErrString("\"foo\" to \"bar\"", "already exists a resource with that name")
+}
+
+func TestK8sResourceRenameConflictingNames(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.dockerfile("foo1/Dockerfile")
+	f.dockerfile("foo2/Dockerfile")
+	f.yaml("foo1.yaml", deployment("foo", image("gcr.io/foo1"), namespace("ns1")))
+	f.yaml("foo2.yaml", deployment("foo", image("gcr.io/foo2"), namespace("ns2")))
+
+	f.file("Tiltfile", `
+
+k8s_yaml(['foo1.yaml', 'foo2.yaml'])
+docker_build('gcr.io/foo1', 'foo1')
+docker_build('gcr.io/foo2', 'foo2')
+k8s_resource('foo:deployment:ns2', new_name='foo')
+`)
+	f.load("foo:deployment:ns1", "foo")
+
+	f.assertNextManifest("foo:deployment:ns1", db(image("gcr.io/foo1")))
+	f.assertNextManifest("foo", db(image("gcr.io/foo2")))
+}
+
+func TestConflictingNewNames(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.yaml("ns1.yaml", namespace("ns1"))
+	f.yaml("ns2.yaml", namespace("ns2"))
+	f.file("Tiltfile", `
+k8s_yaml(['ns1.yaml', 'ns2.yaml'])
+k8s_resource(new_name='foo', objects=['ns1:namespace'])
+k8s_resource(new_name='foo', objects=['ns2:namespace'])
+`)
+
+	f.loadErrString("k8s_resource named \"foo\" already exists")
+}
+
+func TestAdditivePortForwards(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+
+	f.file("Tiltfile", `
+
+k8s_yaml('foo.yaml')
+k8s_resource('foo', port_forwards=8001)
+k8s_resource('foo', port_forwards=8000)
+`)
+
+	f.load()
+	f.assertNextManifest("foo", []model.PortForward{{LocalPort: 8001}, {LocalPort: 8000}})
+}
+
+func TestWorkloadToResourceFunction(t *testing.T) {
 	f := newFixture(t)
 	defer f.TearDown()
 
@@ -4029,6 +4567,64 @@ func TestDisableObsoleteFeature(t *testing.T) {
 	f.loadAssertWarnings("Obsolete feature flag: obsoleteflag")
 }
 
+func TestWarningsAttributedToResource(t *testing.T) {
+	f := newFixture(t)
+	f.setupFoo()
+
+	f.file("Tiltfile", `
+docker_build('gcr.io/foo', 'foo', cache='/paths/to/cache')
+k8s_yaml('foo.yaml')
+warn('check your ports', resource='foo')
+enable_feature('obsoleteflag')
+`)
+	f.loadAllowWarnings()
+
+	ws := f.loadResult.Warnings
+	require.Len(t, ws, 3)
+	assert.Equal(t, model.ManifestName(""), ws[0].ManifestName)
+	assert.Equal(t, "docker_build.cache:gcr.io/foo", ws[0].Key)
+	assert.Contains(t, ws[0].Location, "Tiltfile:2:")
+	assert.Equal(t, model.ManifestName("foo"), ws[1].ManifestName)
+	assert.Equal(t, "check your ports", ws[1].Message)
+	assert.Contains(t, ws[1].Location, "Tiltfile:4:")
+	assert.Equal(t, "feature:obsoleteflag", ws[2].Key)
+}
+
+func TestWarningsNotReannouncedOnReload(t *testing.T) {
+	f := newFixture(t)
+
+	loader := f.newTiltfileLoader()
+	load := func() TiltfileLoadResult {
+		f.warnings = nil
+		tlr := loader.Load(f.ctx, ctrltiltfile.MainTiltfile(f.JoinPath("Tiltfile"), nil))
+		require.NoError(t, tlr.Error)
+		return tlr
+	}
+
+	f.file("Tiltfile", `warn('problem 1')`)
+	tlr := load()
+	assert.Len(t, tlr.Warnings, 1)
+	f.assertWarnings("problem 1")
+
+	// The same warning is still reported, but not logged again.
+	f.file("Tiltfile", "warn('problem 1')\nwarn('problem 2')")
+	tlr = load()
+	assert.Len(t, tlr.Warnings, 2)
+	f.assertWarnings("problem 2")
+
+	// Once a reload stops producing a warning, it's cleared,
+	// and announced again if it comes back.
+	f.file("Tiltfile", `warn('problem 2')`)
+	tlr = load()
+	assert.Len(t, tlr.Warnings, 1)
+	f.assertWarnings()
+
+	f.file("Tiltfile", "warn('problem 1')\nwarn('problem 2')")
+	tlr = load()
+	assert.Len(t, tlr.Warnings, 2)
+	f.assertWarnings("problem 1")
+}
+
 func TestDisableSnapshots(t *testing.T) {
 	f := newFixture(t)
 	f.setupFoo()
@@ -4111,6 +4707,66 @@ k8s_yaml('foo.yaml')
 		m.ImageTargets[0].DockerBuildInfo().BuildArgs)
 }
 
+func TestDockerBuild_buildArgChangeInvalidatesImagesThatUseIt(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("foo.dockerfile", "ARG VERSION\nFROM alpine:${VERSION}")
+	f.file("bar.dockerfile", "FROM alpine")
+	f.yaml("foo.yaml", deployment("foo", image("gcr.io/foo")))
+	f.yaml("bar.yaml", deployment("bar", image("gcr.io/bar")))
+	f.file("version.txt", "3.14")
+	f.file("Tiltfile", `
+args = {'VERSION': str(read_file('version.txt')).strip()}
+docker_build('gcr.io/foo', '.', dockerfile='foo.dockerfile', build_args=args)
+docker_build('gcr.io/bar', '.', dockerfile='bar.dockerfile', build_args=args)
+k8s_yaml(['foo.yaml', 'bar.yaml'])
+`)
+
+	f.load()
+	before := f.manifestsByName()
+	assert.Equal(t, model.DockerBuildArgs{"VERSION": "3.14"},
+		before["foo"].ImageTargets[0].DockerBuildInfo().ResolvedBuildArgs)
+	assert.Equal(t, model.DockerBuildArgs{},
+		before["bar"].ImageTargets[0].DockerBuildInfo().ResolvedBuildArgs)
+
+	f.file("version.txt", "3.15")
+	f.load()
+	after := f.manifestsByName()
+
+	assert.True(t, model.ChangesInvalidateBuild(before["foo"], after["foo"]))
+	assert.False(t, model.ChangesInvalidateBuild(before["bar"], after["bar"]))
+}
+
+func TestDockerBuild_buildArgChangesBaseImage(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("base.dockerfile", "FROM alpine")
+	f.file("app.dockerfile", "ARG BASE=gcr.io/base\nFROM ${BASE}")
+	f.yaml("app.yaml", deployment("app", image("gcr.io/app")))
+	f.file("base.txt", "gcr.io/base")
+	f.file("Tiltfile", `
+docker_build('gcr.io/base', '.', dockerfile='base.dockerfile')
+docker_build('gcr.io/app', '.', dockerfile='app.dockerfile',
+             build_args={'BASE': str(read_file('base.txt')).strip()})
+k8s_yaml('app.yaml')
+`)
+
+	f.load()
+	before := f.assertNextManifest("app")
+	assert.Equal(t, []string{"gcr.io_base", "gcr.io_app"}, f.imageTargetNames(before))
+	assert.Equal(t, []string{"gcr.io_base"}, f.idNames(before.ImageTargets[1].DependencyIDs()))
+
+	// The app no longer builds on the base image, so the base image is unused.
+	f.file("base.txt", "alpine")
+	f.loadAllowWarnings()
+	after := f.assertNextManifest("app")
+	assert.Equal(t, []string{"gcr.io_app"}, f.imageTargetNames(after))
+	assert.Equal(t, []string{}, f.idNames(after.ImageTargets[0].DependencyIDs()))
+	assert.True(t, model.ChangesInvalidateBuild(before, after))
+}
+
 func TestCustomBuildEntrypoint(t *testing.T) {
 	f := newFixture(t)
 	defer f.TearDown()
@@ -4255,6 +4911,67 @@ k8s_resource(objects=['foo-service:Service:default'], new_name='my-services')
 	f.loadErrString(tiltfile_k8s.DuplicateYAMLDetectedError("Service foo-service", stack).Error())
 }
 
+func TestSharedYAMLEntityAcrossResources(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupSharedConfigMap("blue", "blue")
+	f.load()
+
+	foo := f.assertNextManifest("foo")
+	assert.Contains(t, foo.K8sTarget().YAML, "shared-config")
+	assert.Empty(t, foo.K8sTarget().SharedObjects)
+
+	bar := f.assertNextManifest("bar")
+	assert.NotContains(t, bar.K8sTarget().YAML, "shared-config")
+	assert.Equal(t, []model.SharedK8sObject{
+		{
+			Ref:   v1.ObjectReference{Kind: "ConfigMap", APIVersion: "v1", Name: "shared-config"},
+			Owner: "foo",
+		},
+	}, bar.K8sTarget().SharedObjects)
+
+	// The leftover copies don't end up in an uncategorized resource.
+	f.assertNoMoreManifests()
+	assert.Contains(t, f.out.String(), "is shared by resources foo and bar. Applying it with foo.")
+}
+
+func TestSharedYAMLEntityWithDifferentDefinitions(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupSharedConfigMap("blue", "green")
+	f.loadErrString(`Resources "foo" and "bar" both declare shared-config`,
+		"but with different YAML")
+}
+
+func (f *fixture) setupSharedConfigMap(fooColor, barColor string) {
+	configMap := func(color string) string {
+		return fmt.Sprintf(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: shared-config
+data:
+  color: %s
+`, color)
+	}
+
+	f.dockerfile("foo/Dockerfile")
+	f.dockerfile("bar/Dockerfile")
+	f.yaml("foo.yaml", deployment("foo", image("gcr.io/foo")))
+	f.yaml("bar.yaml", deployment("bar", image("gcr.io/bar")))
+	f.file("foo-config.yaml", configMap(fooColor))
+	f.file("bar-config.yaml", configMap(barColor))
+	f.file("Tiltfile", `
+docker_build('gcr.io/foo', 'foo')
+docker_build('gcr.io/bar', 'bar')
+k8s_yaml(['foo.yaml', 'bar.yaml', 'foo-config.yaml'])
+k8s_yaml('bar-config.yaml', allow_duplicates=True)
+k8s_resource('foo', objects=['shared-config'])
+k8s_resource('bar', objects=['shared-config'])
+`)
+}
+
 func TestSetTeamID(t *testing.T) {
 	f := newFixture(t)
 	defer f.TearDown()
@@ -4320,6 +5037,66 @@ allow_k8s_contexts("allowed-context")
 	}
 }
 
+func TestK8sContextErrorDetails(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+k8s_yaml("foo.yaml")
+allow_k8s_contexts(["dev-a", "dev-b"])
+`)
+	f.setupFoo()
+
+	f.kubeconfig = f.JoinPath("kubeconfig")
+	f.file("kubeconfig", kubeconfigYAML("prod", "gke_acme_prod"))
+	f.k8sContext = "prod"
+	f.k8sEnv = k8s.EnvGKE
+
+	f.loadErrString(
+		"Stop! prod might be production.",
+		"Current context:  prod",
+		"Cluster server:   https://gke_acme_prod.example.com",
+		"Detected env:     gke",
+		"Allowed contexts: dev-a, dev-b",
+		"allow_k8s_contexts('prod')\nto your Tiltfile",
+		"kubectl config use-context dev-a",
+		"tilt up --context=dev-a")
+	assert.Contains(t, f.loadResult.ConfigFiles, f.kubeconfig)
+
+	// If the user switches contexts, we're still connected to prod.
+	f.file("kubeconfig", kubeconfigYAML("dev-a", "gke_acme_prod"))
+	f.loadErrString("Your kubeconfig now points at 'dev-a', but this Tilt is still connected to 'prod'")
+
+	// Fixing the kubeconfig unblocks the next load.
+	f.file("kubeconfig", kubeconfigYAML("prod", "kind-prod"))
+	f.load()
+	assert.NotContains(t, f.loadResult.ConfigFiles, f.kubeconfig)
+}
+
+// A kubeconfig where the context 'prod' points at the given cluster.
+func kubeconfigYAML(currentContext string, prodCluster string) string {
+	return fmt.Sprintf(`
+apiVersion: v1
+kind: Config
+current-context: %s
+clusters:
+- name: %s
+  cluster:
+    server: https://%s.example.com
+- name: kind-dev-a
+  cluster:
+    server: https://127.0.0.1:6443
+contexts:
+- name: prod
+  context:
+    cluster: %s
+- name: dev-a
+  context:
+    cluster: kind-dev-a
+users: []
+`, currentContext, prodCluster, prodCluster, prodCluster)
+}
+
 // Test for fix to https://github.com/tilt-dev/tilt/issues/4234
 func TestCheckK8SContextWhenOnlyUncategorizedK8s(t *testing.T) {
 	f := newFixture(t)
@@ -4413,6 +5190,39 @@ local_resource("test", serve_cmd="sleep 1000")
 	f.assertConfigFiles("Tiltfile", ".tiltignore")
 }
 
+func TestLocalResourceHTTPReadinessProbe(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+local_resource("test", serve_cmd="sleep 1000",
+               readiness_probe=probe(http_get=http_get_action(
+                   8443, scheme='https', path='/healthz',
+                   headers={'X-Probe': 'yes', 'Host': 'app.localhost'},
+                   insecure_skip_tls_verify=True)))
+`)
+
+	f.load()
+
+	f.assertNextManifest("test", localTarget(
+		serveCmd(f.Path(), "sleep 1000", nil),
+		readinessProbeHelper{probeSpec: &v1alpha1.Probe{
+			Handler: v1alpha1.Handler{
+				HTTPGet: &v1alpha1.HTTPGetAction{
+					Port:   8443,
+					Scheme: v1alpha1.URISchemeHTTPS,
+					Path:   "/healthz",
+					HTTPHeaders: []v1alpha1.HTTPHeader{
+						{Name: "Host", Value: "app.localhost"},
+						{Name: "X-Probe", Value: "yes"},
+					},
+					InsecureSkipTLSVerify: true,
+				},
+			},
+		}},
+	))
+}
+
 func TestLocalResourceUpdateAndServeCmd(t *testing.T) {
 	f := newFixture(t)
 	defer f.TearDown()
@@ -4542,6 +5352,54 @@ local_resource("test", "echo hi", deps=["foo"], ignore=["**/*.a", "foo/bar.d"])
 	}
 }
 
+func TestLocalResourceOutputs(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+	f.file("Tiltfile", `
+local_resource("codegen", "make gen", deps=["api.proto", "foo"], outputs=["foo/gen"])
+docker_build('gcr.io/foo', 'foo')
+k8s_yaml('foo.yaml')
+`)
+	f.load()
+
+	foo := f.assertNextManifest("foo")
+	assert.Equal(t, []model.ManifestName{"codegen"}, foo.OutputDependencies)
+
+	codegen := f.assertNextManifest("codegen")
+	assert.Equal(t, []string{f.JoinPath("foo/gen")}, codegen.LocalTarget().Outputs)
+	assert.Empty(t, codegen.OutputDependencies)
+
+	// The codegen's own writes don't re-trigger it.
+	filter, err := ignore.CreateFileChangeFilter(codegen.LocalTarget())
+	require.NoError(t, err)
+	for _, tc := range []struct {
+		path        string
+		expectMatch bool
+	}{
+		{"foo/gen", true},
+		{"foo/gen/api.pb.go", true},
+		{"foo/main.go", false},
+		{"api.proto", false},
+	} {
+		matches, err := filter.Matches(f.JoinPath(tc.path))
+		require.NoError(t, err)
+		assert.Equal(t, tc.expectMatch, matches, tc.path)
+	}
+}
+
+func TestLocalResourceOutputsCycle(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+local_resource("a", "make a", deps=["b-out"], outputs=["a-out"])
+local_resource("b", "make b", deps=["a-out"], outputs=["b-out"])
+`)
+	f.loadErrString("cycle detected between resource outputs and inputs")
+}
+
 func TestLocalResourceUpdateCmdEnv(t *testing.T) {
 	f := newFixture(t)
 	defer f.TearDown()
@@ -4574,6 +5432,121 @@ local_resource("test", "echo hi", serve_cmd="sleep 1000", serve_env={"KEY1": "va
 	))
 }
 
+func TestLocalResourceServeReload(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("nginx.conf", "worker_processes 1;")
+	f.file("Tiltfile", `
+local_resource("test", serve_cmd="nginx", serve_env={"LOG_LEVEL": "info", "PORT": "8000"},
+               serve_reload_env=["LOG_LEVEL"], serve_reload_files=["nginx.conf"],
+               serve_reload_env_file="reload.env")
+`)
+
+	f.load()
+	lt := f.assertNextManifest("test").LocalTarget()
+	assert.Equal(t, &model.ServeReload{
+		Env:     []string{"LOG_LEVEL"},
+		Files:   []string{f.JoinPath("nginx.conf")},
+		EnvFile: f.JoinPath("reload.env"),
+		Signal:  "SIGHUP",
+	}, lt.ServeReload)
+	assert.Contains(t, lt.Dependencies(), f.JoinPath("nginx.conf"))
+}
+
+func TestLocalResourceServeReloadSignal(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+local_resource("test", serve_cmd="envoy", serve_reload_env=["LOG_LEVEL"], serve_reload_signal="SIGUSR1")
+`)
+
+	f.load()
+	lt := f.assertNextManifest("test").LocalTarget()
+	assert.Equal(t, "SIGUSR1", lt.ServeReload.Signal)
+}
+
+func TestLocalResourceServeReloadInvalidSignal(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+local_resource("test", serve_cmd="envoy", serve_reload_signal="SIGKILL")
+`)
+
+	f.loadErrString(`serve_reload_signal must be one of "SIGHUP", "SIGUSR1", "SIGUSR2", got "SIGKILL"`)
+}
+
+func TestLocalResourceServeReloadWithoutServeCmd(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+local_resource("test", "make", serve_reload_env=["LOG_LEVEL"])
+`)
+
+	f.loadErrString("serve_reload_* arguments require a serve_cmd")
+}
+
+func TestLocalResourceLivenessProbe(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+local_resource("test", serve_cmd="sleep 1000",
+               liveness_probe=probe(period_secs=5, failure_threshold=2,
+                                    exec=exec_action(["curl", "localhost:8000"])))
+`)
+
+	f.load()
+	lt := f.assertNextManifest("test").LocalTarget()
+	assert.Nil(t, lt.ReadinessProbe)
+	assert.Equal(t, &v1alpha1.Probe{
+		PeriodSeconds:    5,
+		FailureThreshold: 2,
+		Handler: v1alpha1.Handler{
+			Exec: &v1alpha1.ExecAction{Command: []string{"curl", "localhost:8000"}},
+		},
+	}, lt.LivenessProbe)
+}
+
+func TestLocalResourceLivenessProbeWithoutServeCmd(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+local_resource("test", "make", liveness_probe=probe(exec=exec_action(["true"])))
+`)
+
+	f.loadErrString("liveness_probe requires a serve_cmd")
+}
+
+func TestLocalResourceLogLevelFormat(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+local_resource("test", "make", serve_cmd="./server", log_level_format="logrus")
+`)
+
+	f.load()
+	lt := f.assertNextManifest("test").LocalTarget()
+	assert.Equal(t, "logrus", lt.LogLevelFormat)
+	assert.Equal(t, "logrus", lt.UpdateCmdSpec.LogLevelFormat)
+}
+
+func TestLocalResourceLogLevelFormatInvalid(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+local_resource("test", serve_cmd="./server", log_level_format="log4j")
+`)
+
+	f.loadErrString(`local_resource: log_level_format: Unrecognized log level format "log4j"`)
+}
+
 func TestLocalResourceUpdateCmdDir(t *testing.T) {
 	f := newFixture(t)
 	defer f.TearDown()
@@ -4672,6 +5645,41 @@ func (f *fixture) assertRepos(expectedLocalPaths []string, repos []model.LocalGi
 	assert.ElementsMatch(f.t, expectedLocalPaths, actualLocalPaths)
 }
 
+func TestRequireEnvReportsAllMissing(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+token = os.require_env('TILT_TEST_TOKEN')
+region = os.require_env('TILT_TEST_REGION')
+local_resource('deploy', 'deploy.sh ' + token + ' ' + region)
+`)
+
+	// The None from the first missing var breaks the string concatenation,
+	// but we still report both missing vars.
+	f.loadErrString("Missing required environment variables: TILT_TEST_TOKEN, TILT_TEST_REGION")
+	assert.Equal(t, []tiltfileos.EnvRead{
+		{Name: "TILT_TEST_TOKEN"},
+		{Name: "TILT_TEST_REGION"},
+	}, f.loadResult.EnvReads)
+}
+
+func TestEnvReadsRecorded(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+	t.Setenv("TILT_TEST_PORT", "8080")
+
+	f.file("Tiltfile", `
+port = os.getenv('TILT_TEST_PORT', type='int')
+local_resource('serve', serve_cmd='serve --port %d' % port)
+`)
+
+	f.load()
+	assert.Equal(t, []tiltfileos.EnvRead{
+		{Name: "TILT_TEST_PORT", Value: "8080", Set: true},
+	}, f.loadResult.EnvReads)
+}
+
 func TestSecretString(t *testing.T) {
 	f := newFixture(t)
 	defer f.TearDown()
@@ -4941,6 +5949,47 @@ local_resource("b", ["echo", "hi"])
 	assert.False(t, b.LocalTarget().AllowParallel)
 }
 
+func TestLocalResourceWatchInCI(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+local_resource("a", ["echo", "hi"], watch_in_ci=True)
+local_resource("b", ["echo", "hi"])
+`)
+
+	f.load()
+	a := f.assertNextManifest("a")
+	assert.True(t, a.WatchInCI)
+	b := f.assertNextManifest("b")
+	assert.False(t, b.WatchInCI)
+}
+
+func TestLocalResourceBuildTimeout(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+update_settings(build_timeout_secs=60)
+local_resource("a", ["echo", "hi"], build_timeout_secs=0)
+local_resource("b", ["echo", "hi"])
+`)
+
+	f.load()
+	a := f.assertNextManifest("a")
+	require.NotNil(t, a.BuildTimeout)
+	assert.Equal(t, time.Duration(0), *a.BuildTimeout)
+	assert.Equal(t, time.Duration(0), f.loadResult.UpdateSettings.BuildTimeoutFor(a))
+	b := f.assertNextManifest("b")
+	assert.Nil(t, b.BuildTimeout)
+	assert.Equal(t, time.Minute, f.loadResult.UpdateSettings.BuildTimeoutFor(b))
+
+	f.file("Tiltfile", `
+local_resource("a", ["echo", "hi"], build_timeout_secs=-1)
+`)
+	f.loadErrString("local_resource: build_timeout_secs must be >= 0")
+}
+
 func TestLocalResourceInvalidName(t *testing.T) {
 	f := newFixture(t)
 	defer f.TearDown()
@@ -5059,6 +6108,102 @@ func TestK8sUpsertTimeout(t *testing.T) {
 	}
 }
 
+func TestK8sDeleteOrphans(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", "print('hello world')")
+	f.load()
+	assert.False(t, f.loadResult.UpdateSettings.K8sDeleteOrphans)
+
+	f.file("Tiltfile", "update_settings(k8s_delete_orphans=True)")
+	f.load()
+	assert.True(t, f.loadResult.UpdateSettings.K8sDeleteOrphans)
+}
+
+func TestRelaxedStartupOrder(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", "print('hello world')")
+	f.load()
+	assert.False(t, f.loadResult.UpdateSettings.RelaxedStartupOrder)
+
+	f.file("Tiltfile", "update_settings(relaxed_startup_order=True)")
+	f.load()
+	assert.True(t, f.loadResult.UpdateSettings.RelaxedStartupOrder)
+}
+
+func TestBuildContextWarnSize(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", "print('hello world')")
+	f.load()
+	assert.Equal(t, int64(model.DefaultBuildContextWarnSize), f.loadResult.UpdateSettings.BuildContextWarnSize)
+
+	f.file("Tiltfile", "update_settings(build_context_warn_size_mb=100)")
+	f.load()
+	assert.Equal(t, int64(100*1000*1000), f.loadResult.UpdateSettings.BuildContextWarnSize)
+
+	f.file("Tiltfile", "update_settings(build_context_warn_size_mb=0)")
+	f.load()
+	assert.Equal(t, int64(0), f.loadResult.UpdateSettings.BuildContextWarnSize)
+
+	f.file("Tiltfile", "update_settings(build_context_warn_size_mb=-1)")
+	f.loadErrString("build context warning size must be >= 0")
+}
+
+func TestBuildTimeout(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", "print('hello world')")
+	f.load()
+	assert.Equal(t, time.Duration(0), f.loadResult.UpdateSettings.BuildTimeout)
+
+	f.file("Tiltfile", "update_settings(build_timeout_secs=300)")
+	f.load()
+	assert.Equal(t, 5*time.Minute, f.loadResult.UpdateSettings.BuildTimeout)
+
+	f.file("Tiltfile", "update_settings(build_timeout_secs=-1)")
+	f.loadErrString("build timeout must be >= 0")
+}
+
+func TestPodLogRateLimit(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", "print('hello world')")
+	f.load()
+	assert.Equal(t, int64(model.DefaultPodLogRateLimit), f.loadResult.UpdateSettings.PodLogRateLimit)
+	assert.Equal(t, int64(model.DefaultPodLogTotalRateLimit), f.loadResult.UpdateSettings.PodLogTotalRateLimit)
+
+	f.file("Tiltfile", "update_settings(pod_log_rate_limit_kb=200, pod_log_total_rate_limit_kb=0)")
+	f.load()
+	assert.Equal(t, int64(200*1000), f.loadResult.UpdateSettings.PodLogRateLimit)
+	assert.Equal(t, int64(0), f.loadResult.UpdateSettings.PodLogTotalRateLimit)
+
+	f.file("Tiltfile", "update_settings(pod_log_rate_limit_kb=-1)")
+	f.loadErrString("pod log rate limit must be >= 0")
+}
+
+func TestDebugContainerImage(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", "print('hello world')")
+	f.load()
+	assert.Equal(t, model.DefaultDebugContainerImage, f.loadResult.UpdateSettings.DebugContainerImage)
+
+	f.file("Tiltfile", "update_settings(debug_container_image='nicolaka/netshoot')")
+	f.load()
+	assert.Equal(t, "nicolaka/netshoot", f.loadResult.UpdateSettings.DebugContainerImage)
+
+	f.file("Tiltfile", "update_settings(debug_container_image='Not An Image')")
+	f.loadErrString("for parameter \"debug_container_image\"")
+}
+
 func TestUpdateSettingsCalledTwice(t *testing.T) {
 	f := newFixture(t)
 	defer f.TearDown()
@@ -5894,6 +7039,72 @@ local_resource("test2", cmd="echo hi2", labels=["bar", "baz"])
 	f.assertNextManifest("test2", resourceLabels("bar", "baz"))
 }
 
+func TestResourceMetadata(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.setupFoo()
+
+	f.file("Tiltfile", `
+k8s_yaml('foo.yaml')
+local_resource("test", cmd="echo hi")
+resource_metadata('foo', annotations={'example.com/team': 'web'}, links=['http://grafana.local'])
+resource_metadata('foo', annotations={'example.com/oncall': 'alice'}, links=[link('http://runbook.local', 'runbook')])
+`)
+
+	f.load()
+	f.assertNumManifests(2)
+	m := f.assertNextManifest("foo")
+	assert.Equal(t, map[string]string{
+		"example.com/team":   "web",
+		"example.com/oncall": "alice",
+	}, m.Annotations)
+	assert.Equal(t, []model.Link{
+		model.MustNewLink("http://grafana.local", ""),
+		model.MustNewLink("http://runbook.local", "runbook"),
+	}, m.Links)
+
+	m = f.assertNextManifest("test")
+	assert.Empty(t, m.Annotations)
+	assert.Empty(t, m.Links)
+}
+
+func TestResourceMetadataInvalidAnnotationKey(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+local_resource("test", cmd="echo hi")
+resource_metadata('test', annotations={'not a key': 'x'})
+`)
+
+	f.loadErrString(`resource_metadata: invalid annotation key "not a key"`)
+}
+
+func TestResourceMetadataReservedAnnotationKey(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+local_resource("test", cmd="echo hi")
+resource_metadata('test', annotations={'tilt.dev/resource': 'x'})
+`)
+
+	f.loadErrString(`resource_metadata: invalid annotation key "tilt.dev/resource": the tilt.dev/ prefix is reserved for Tilt`)
+}
+
+func TestResourceMetadataUnknownResource(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.file("Tiltfile", `
+local_resource("test", cmd="echo hi")
+resource_metadata('tset', annotations={'example.com/team': 'web'})
+`)
+
+	f.loadErrString(`resource_metadata: no resource named "tset"`)
+}
+
 type fixture struct {
 	ctx context.Context
 	out *bytes.Buffer
@@ -5902,8 +7113,10 @@ type fixture struct {
 	kCli       *k8s.FakeK8sClient
 	k8sContext k8s.KubeContext
 	k8sEnv     k8s.Env
+	kubeconfig string
 	webHost    model.WebHost
 	ctrlclient ctrlclient.Client
+	limits     ExecLimits
 
 	ta *tiltanalytics.TiltAnalytics
 	an *analytics.MemoryAnalytics
@@ -5923,11 +7136,17 @@ func (f *fixture) newTiltfileLoader() TiltfileLoader {
 	}
 
 	k8sContextExt := k8scontext.NewPlugin(f.k8sContext, f.k8sEnv)
+	if f.kubeconfig != "" {
+		clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			&clientcmd.ClientConfigLoadingRules{ExplicitPath: f.kubeconfig},
+			&clientcmd.ConfigOverrides{})
+		k8sContextExt = k8scontext.ProvidePlugin(f.k8sContext, f.k8sEnv, clientConfig, "")
+	}
 	versionExt := version.NewPlugin(model.TiltBuild{Version: "0.5.0"})
 	configExt := config.NewPlugin("up")
 	localEnv := localexec.DefaultEnv(12345, f.webHost)
 	execer := localexec.NewProcessExecer(localEnv)
-	return ProvideTiltfileLoader(f.ta, f.kCli, k8sContextExt, versionExt, configExt, dcc, f.webHost, execer, features, f.k8sEnv)
+	return ProvideTiltfileLoader(f.ta, f.kCli, k8sContextExt, versionExt, configExt, dcc, f.webHost, execer, features, f.k8sEnv, f.limits)
 }
 
 func newFixture(t *testing.T) *fixture {
@@ -5950,6 +7169,7 @@ func newFixture(t *testing.T) *fixture {
 		k8sContext:     "fake-context",
 		k8sEnv:         k8s.EnvDockerDesktop,
 		ctrlclient:     ctrlclient,
+		limits:         DefaultExecLimits(),
 	}
 
 	// Collect the warnings
@@ -6170,6 +7390,14 @@ func (f *fixture) assertNextManifestUnresourced(expectedEntities ...string) mode
 type funcOpt func(*testing.T, model.Manifest) bool
 
 // assert functions and helpers
+func (f *fixture) manifestsByName() map[model.ManifestName]model.Manifest {
+	result := make(map[model.ManifestName]model.Manifest, len(f.loadResult.Manifests))
+	for _, m := range f.loadResult.Manifests {
+		result[m.Name] = m
+	}
+	return result
+}
+
 func (f *fixture) assertNextManifest(name model.ManifestName, opts ...interface{}) model.Manifest {
 	f.t.Helper()
 
@@ -6389,6 +7617,7 @@ func (f *fixture) assertNextManifest(name model.ManifestName, opts ...interface{
 						Host:          pf.Host,
 						Name:          pf.Name,
 						Path:          pf.PathForAppend(),
+						Verify:        pf.Verify,
 					})
 				}
 				assert.ElementsMatch(f.t,
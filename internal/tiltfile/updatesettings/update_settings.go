@@ -9,6 +9,7 @@ import (
 
 	"github.com/tilt-dev/tilt/pkg/model"
 
+	"github.com/tilt-dev/tilt/internal/container"
 	"github.com/tilt-dev/tilt/internal/tiltfile/starkit"
 	"github.com/tilt-dev/tilt/internal/tiltfile/value"
 )
@@ -29,12 +30,22 @@ func (e Plugin) OnStart(env *starkit.Environment) error {
 }
 
 func (e *Plugin) updateSettings(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
-	var maxParallelUpdates, k8sUpsertTimeoutSecs starlark.Value
+	var maxParallelUpdates, k8sUpsertTimeoutSecs, buildContextWarnMB, buildTimeoutSecs starlark.Value
+	var podLogRateLimitKB, podLogTotalRateLimitKB starlark.Value
 	var unusedImageWarnings value.StringOrStringList
+	var k8sDeleteOrphans, relaxedStartupOrder value.BoolOrNone
+	var debugContainerImage value.Stringable
 	if err := starkit.UnpackArgs(thread, fn.Name(), args, kwargs,
 		"max_parallel_updates?", &maxParallelUpdates,
 		"k8s_upsert_timeout_secs?", &k8sUpsertTimeoutSecs,
-		"suppress_unused_image_warnings?", &unusedImageWarnings); err != nil {
+		"suppress_unused_image_warnings?", &unusedImageWarnings,
+		"k8s_delete_orphans?", &k8sDeleteOrphans,
+		"build_context_warn_size_mb?", &buildContextWarnMB,
+		"debug_container_image?", &debugContainerImage,
+		"relaxed_startup_order?", &relaxedStartupOrder,
+		"build_timeout_secs?", &buildTimeoutSecs,
+		"pod_log_rate_limit_kb?", &podLogRateLimitKB,
+		"pod_log_total_rate_limit_kb?", &podLogTotalRateLimitKB); err != nil {
 		return nil, err
 	}
 
@@ -56,6 +67,45 @@ func (e *Plugin) updateSettings(thread *starlark.Thread, fn *starlark.Builtin, a
 			k8sUpsertTimeoutSecs)
 	}
 
+	bcwm, bcwmPassed, err := valueToInt(buildContextWarnMB)
+	if err != nil {
+		return nil, errors.Wrap(err, "update_settings: for parameter \"build_context_warn_size_mb\"")
+	}
+	if bcwmPassed && bcwm < 0 {
+		return nil, fmt.Errorf("build context warning size must be >= 0 (got: %d)", bcwm)
+	}
+
+	bts, btsPassed, err := valueToInt(buildTimeoutSecs)
+	if err != nil {
+		return nil, errors.Wrap(err, "update_settings: for parameter \"build_timeout_secs\"")
+	}
+	if btsPassed && bts < 0 {
+		return nil, fmt.Errorf("build timeout must be >= 0 (got: %d)", bts)
+	}
+
+	plrl, plrlPassed, err := valueToInt(podLogRateLimitKB)
+	if err != nil {
+		return nil, errors.Wrap(err, "update_settings: for parameter \"pod_log_rate_limit_kb\"")
+	}
+	if plrlPassed && plrl < 0 {
+		return nil, fmt.Errorf("pod log rate limit must be >= 0 (got: %d)", plrl)
+	}
+
+	pltrl, pltrlPassed, err := valueToInt(podLogTotalRateLimitKB)
+	if err != nil {
+		return nil, errors.Wrap(err, "update_settings: for parameter \"pod_log_total_rate_limit_kb\"")
+	}
+	if pltrlPassed && pltrl < 0 {
+		return nil, fmt.Errorf("pod log total rate limit must be >= 0 (got: %d)", pltrl)
+	}
+
+	if debugContainerImage.Value != "" {
+		_, err := container.ParseNamed(debugContainerImage.Value)
+		if err != nil {
+			return nil, errors.Wrap(err, "update_settings: for parameter \"debug_container_image\"")
+		}
+	}
+
 	err = starkit.SetState(thread, func(settings model.UpdateSettings) model.UpdateSettings {
 		if mpuPassed {
 			settings = settings.WithMaxParallelUpdates(mpu)
@@ -64,6 +114,27 @@ func (e *Plugin) updateSettings(thread *starlark.Thread, fn *starlark.Builtin, a
 			settings = settings.WithK8sUpsertTimeout(time.Duration(kuts) * time.Second)
 		}
 		settings.SuppressUnusedImageWarnings = append(settings.SuppressUnusedImageWarnings, unusedImageWarnings.Values...)
+		if k8sDeleteOrphans.IsSet {
+			settings.K8sDeleteOrphans = k8sDeleteOrphans.Value
+		}
+		if bcwmPassed {
+			settings.BuildContextWarnSize = int64(bcwm) * 1000 * 1000
+		}
+		if debugContainerImage.Value != "" {
+			settings.DebugContainerImage = debugContainerImage.Value
+		}
+		if relaxedStartupOrder.IsSet {
+			settings.RelaxedStartupOrder = relaxedStartupOrder.Value
+		}
+		if btsPassed {
+			settings.BuildTimeout = time.Duration(bts) * time.Second
+		}
+		if plrlPassed {
+			settings.PodLogRateLimit = int64(plrl) * 1000
+		}
+		if pltrlPassed {
+			settings.PodLogTotalRateLimit = int64(pltrl) * 1000
+		}
 		return settings
 	})
 
@@ -168,6 +168,26 @@ v1alpha1.cmd(
 	})
 }
 
+func TestCmdRestartOnUIResource(t *testing.T) {
+	f := newFixture(t)
+	defer f.TearDown()
+
+	f.File("Tiltfile", `
+v1alpha1.cmd(
+  name='smoke-test',
+  args=['./smoke-test.sh'],
+  restart_on=v1alpha1.restart_on_spec(ui_resources=['api']))
+`)
+	result, err := f.ExecFile("Tiltfile")
+	require.NoError(t, err)
+
+	set := MustState(result)
+
+	cmd := set.GetSetForType(&v1alpha1.Cmd{})["smoke-test"].(*v1alpha1.Cmd)
+	require.NotNil(t, cmd)
+	require.Equal(t, &v1alpha1.RestartOnSpec{UIResources: []string{"api"}}, cmd.Spec.RestartOn)
+}
+
 func TestUIButton(t *testing.T) {
 	f := newFixture(t)
 	defer f.TearDown()
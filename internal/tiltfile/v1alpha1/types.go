@@ -66,6 +66,10 @@ func (p Plugin) registerSymbols(env *starkit.Environment) error {
 	if err != nil {
 		return err
 	}
+	err = env.AddBuiltin("v1alpha1.forward_verify", p.forwardVerify)
+	if err != nil {
+		return err
+	}
 	err = env.AddBuiltin("v1alpha1.http_get_action", p.hTTPGetAction)
 	if err != nil {
 		return err
@@ -142,6 +146,10 @@ func (p Plugin) registerSymbols(env *starkit.Environment) error {
 	if err != nil {
 		return err
 	}
+	err = env.AddBuiltin("v1alpha1.ui_choice_input_spec", p.uIChoiceInputSpec)
+	if err != nil {
+		return err
+	}
 	err = env.AddBuiltin("v1alpha1.ui_component_location", p.uIComponentLocation)
 	if err != nil {
 		return err
@@ -340,6 +348,7 @@ func (p Plugin) kubernetesApply(t *starlark.Thread, fn *starlark.Builtin, args s
 	var disableSource DisableSource = DisableSource{t: t}
 	var cmd KubernetesApplyCmd = KubernetesApplyCmd{t: t}
 	var restartOn RestartOnSpec = RestartOnSpec{t: t}
+	var applyDiscipline string
 	var labels value.StringStringMap
 	var annotations value.StringStringMap
 	err = starkit.UnpackArgs(t, fn.Name(), args, kwargs,
@@ -357,6 +366,7 @@ func (p Plugin) kubernetesApply(t *starlark.Thread, fn *starlark.Builtin, args s
 		"disable_source?", &disableSource,
 		"cmd?", &cmd,
 		"restart_on?", &restartOn,
+		"apply_discipline?", &applyDiscipline,
 	)
 	if err != nil {
 		return nil, err
@@ -384,6 +394,7 @@ func (p Plugin) kubernetesApply(t *starlark.Thread, fn *starlark.Builtin, args s
 	if restartOn.isUnpacked {
 		obj.Spec.RestartOn = (*v1alpha1.RestartOnSpec)(&restartOn.Value)
 	}
+	obj.Spec.ApplyDiscipline = v1alpha1.KubernetesApplyDiscipline(applyDiscipline)
 	obj.ObjectMeta.Labels = labels
 	obj.ObjectMeta.Annotations = annotations
 	return p.register(t, obj)
@@ -803,18 +814,20 @@ func (p Plugin) forward(t *starlark.Thread, fn *starlark.Builtin, args starlark.
 	var host starlark.Value
 	var name starlark.Value
 	var path starlark.Value
+	var verify starlark.Value
 	err := starkit.UnpackArgs(t, fn.Name(), args, kwargs,
 		"local_port?", &localPort,
 		"container_port?", &containerPort,
 		"host?", &host,
 		"name?", &name,
 		"path?", &path,
+		"verify?", &verify,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	dict := starlark.NewDict(5)
+	dict := starlark.NewDict(6)
 
 	if localPort != nil {
 		err := dict.SetKey(starlark.String("local_port"), localPort)
@@ -846,6 +859,12 @@ func (p Plugin) forward(t *starlark.Thread, fn *starlark.Builtin, args starlark.
 			return nil, err
 		}
 	}
+	if verify != nil {
+		err := dict.SetKey(starlark.String("verify"), verify)
+		if err != nil {
+			return nil, err
+		}
+	}
 	var obj *Forward = &Forward{t: t}
 	err = obj.Unpack(dict)
 	if err != nil {
@@ -915,6 +934,15 @@ func (o *Forward) Unpack(v starlark.Value) error {
 			obj.Path = string(v)
 			continue
 		}
+		if key == "verify" {
+			v := ForwardVerify{t: o.t}
+			err := v.Unpack(val)
+			if err != nil {
+				return fmt.Errorf("unpacking %s: %v", key, err)
+			}
+			obj.Verify = (*v1alpha1.ForwardVerify)(&v.Value)
+			continue
+		}
 		return fmt.Errorf("Unexpected attribute name: %s", key)
 	}
 
@@ -958,6 +986,110 @@ func (o *ForwardList) Unpack(v starlark.Value) error {
 	return nil
 }
 
+type ForwardVerify struct {
+	*starlark.Dict
+	Value      v1alpha1.ForwardVerify
+	isUnpacked bool
+	t          *starlark.Thread // instantiation thread for computing abspath
+}
+
+func (p Plugin) forwardVerify(t *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var hTTPPath starlark.Value
+	err := starkit.UnpackArgs(t, fn.Name(), args, kwargs,
+		"http_path?", &hTTPPath,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	dict := starlark.NewDict(1)
+
+	if hTTPPath != nil {
+		err := dict.SetKey(starlark.String("http_path"), hTTPPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var obj *ForwardVerify = &ForwardVerify{t: t}
+	err = obj.Unpack(dict)
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (o *ForwardVerify) Unpack(v starlark.Value) error {
+	obj := v1alpha1.ForwardVerify{}
+
+	starlarkObj, ok := v.(*ForwardVerify)
+	if ok {
+		*o = *starlarkObj
+		return nil
+	}
+
+	mapObj, ok := v.(*starlark.Dict)
+	if !ok {
+		return fmt.Errorf("expected dict, actual: %v", v.Type())
+	}
+
+	for _, item := range mapObj.Items() {
+		keyV, val := item[0], item[1]
+		key, ok := starlark.AsString(keyV)
+		if !ok {
+			return fmt.Errorf("key must be string. Got: %s", keyV.Type())
+		}
+
+		if key == "http_path" {
+			v, ok := starlark.AsString(val)
+			if !ok {
+				return fmt.Errorf("Expected string, actual: %s", val.Type())
+			}
+			obj.HTTPPath = string(v)
+			continue
+		}
+		return fmt.Errorf("Unexpected attribute name: %s", key)
+	}
+
+	mapObj.Freeze()
+	o.Dict = mapObj
+	o.Value = obj
+	o.isUnpacked = true
+
+	return nil
+}
+
+type ForwardVerifyList struct {
+	*starlark.List
+	Value []v1alpha1.ForwardVerify
+	t     *starlark.Thread
+}
+
+func (o *ForwardVerifyList) Unpack(v starlark.Value) error {
+	items := []v1alpha1.ForwardVerify{}
+
+	listObj, ok := v.(*starlark.List)
+	if !ok {
+		return fmt.Errorf("expected list, actual: %v", v.Type())
+	}
+
+	for i := 0; i < listObj.Len(); i++ {
+		v := listObj.Index(i)
+
+		item := ForwardVerify{t: o.t}
+		err := item.Unpack(v)
+		if err != nil {
+			return fmt.Errorf("at index %d: %v", i, err)
+		}
+		items = append(items, v1alpha1.ForwardVerify(item.Value))
+	}
+
+	listObj.Freeze()
+	o.List = listObj
+	o.Value = items
+
+	return nil
+}
+
 type HTTPGetAction struct {
 	*starlark.Dict
 	Value      v1alpha1.HTTPGetAction
@@ -971,18 +1103,20 @@ func (p Plugin) hTTPGetAction(t *starlark.Thread, fn *starlark.Builtin, args sta
 	var host starlark.Value
 	var scheme starlark.Value
 	var hTTPHeaders starlark.Value
+	var insecureSkipTLSVerify starlark.Value
 	err := starkit.UnpackArgs(t, fn.Name(), args, kwargs,
 		"path?", &path,
 		"port?", &port,
 		"host?", &host,
 		"scheme?", &scheme,
 		"http_headers?", &hTTPHeaders,
+		"insecure_skip_tls_verify?", &insecureSkipTLSVerify,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	dict := starlark.NewDict(5)
+	dict := starlark.NewDict(6)
 
 	if path != nil {
 		err := dict.SetKey(starlark.String("path"), path)
@@ -1014,6 +1148,12 @@ func (p Plugin) hTTPGetAction(t *starlark.Thread, fn *starlark.Builtin, args sta
 			return nil, err
 		}
 	}
+	if insecureSkipTLSVerify != nil {
+		err := dict.SetKey(starlark.String("insecure_skip_tls_verify"), insecureSkipTLSVerify)
+		if err != nil {
+			return nil, err
+		}
+	}
 	var obj *HTTPGetAction = &HTTPGetAction{t: t}
 	err = obj.Unpack(dict)
 	if err != nil {
@@ -1084,6 +1224,14 @@ func (o *HTTPGetAction) Unpack(v starlark.Value) error {
 			obj.HTTPHeaders = v.Value
 			continue
 		}
+		if key == "insecure_skip_tls_verify" {
+			v, ok := val.(starlark.Bool)
+			if !ok {
+				return fmt.Errorf("Expected bool, got: %v", val.Type())
+			}
+			obj.InsecureSkipTLSVerify = bool(v)
+			continue
+		}
 		return fmt.Errorf("Unexpected attribute name: %s", key)
 	}
 
@@ -3005,15 +3153,17 @@ type RestartOnSpec struct {
 func (p Plugin) restartOnSpec(t *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 	var fileWatches starlark.Value
 	var uiButtons starlark.Value
+	var uiResources starlark.Value
 	err := starkit.UnpackArgs(t, fn.Name(), args, kwargs,
 		"file_watches?", &fileWatches,
 		"ui_buttons?", &uiButtons,
+		"ui_resources?", &uiResources,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	dict := starlark.NewDict(2)
+	dict := starlark.NewDict(3)
 
 	if fileWatches != nil {
 		err := dict.SetKey(starlark.String("file_watches"), fileWatches)
@@ -3027,6 +3177,12 @@ func (p Plugin) restartOnSpec(t *starlark.Thread, fn *starlark.Builtin, args sta
 			return nil, err
 		}
 	}
+	if uiResources != nil {
+		err := dict.SetKey(starlark.String("ui_resources"), uiResources)
+		if err != nil {
+			return nil, err
+		}
+	}
 	var obj *RestartOnSpec = &RestartOnSpec{t: t}
 	err = obj.Unpack(dict)
 	if err != nil {
@@ -3074,6 +3230,15 @@ func (o *RestartOnSpec) Unpack(v starlark.Value) error {
 			obj.UIButtons = v
 			continue
 		}
+		if key == "ui_resources" {
+			var v value.StringList
+			err := v.Unpack(val)
+			if err != nil {
+				return fmt.Errorf("unpacking %s: %v", key, err)
+			}
+			obj.UIResources = v
+			continue
+		}
 		return fmt.Errorf("Unexpected attribute name: %s", key)
 	}
 
@@ -3486,6 +3651,127 @@ func (o *UIBoolInputSpecList) Unpack(v starlark.Value) error {
 	return nil
 }
 
+type UIChoiceInputSpec struct {
+	*starlark.Dict
+	Value      v1alpha1.UIChoiceInputSpec
+	isUnpacked bool
+	t          *starlark.Thread // instantiation thread for computing abspath
+}
+
+func (p Plugin) uIChoiceInputSpec(t *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var choices starlark.Value
+	var defaultValue starlark.Value
+	err := starkit.UnpackArgs(t, fn.Name(), args, kwargs,
+		"choices?", &choices,
+		"default_value?", &defaultValue,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	dict := starlark.NewDict(2)
+
+	if choices != nil {
+		err := dict.SetKey(starlark.String("choices"), choices)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if defaultValue != nil {
+		err := dict.SetKey(starlark.String("default_value"), defaultValue)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var obj *UIChoiceInputSpec = &UIChoiceInputSpec{t: t}
+	err = obj.Unpack(dict)
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (o *UIChoiceInputSpec) Unpack(v starlark.Value) error {
+	obj := v1alpha1.UIChoiceInputSpec{}
+
+	starlarkObj, ok := v.(*UIChoiceInputSpec)
+	if ok {
+		*o = *starlarkObj
+		return nil
+	}
+
+	mapObj, ok := v.(*starlark.Dict)
+	if !ok {
+		return fmt.Errorf("expected dict, actual: %v", v.Type())
+	}
+
+	for _, item := range mapObj.Items() {
+		keyV, val := item[0], item[1]
+		key, ok := starlark.AsString(keyV)
+		if !ok {
+			return fmt.Errorf("key must be string. Got: %s", keyV.Type())
+		}
+
+		if key == "choices" {
+			var v value.StringList
+			err := v.Unpack(val)
+			if err != nil {
+				return fmt.Errorf("unpacking %s: %v", key, err)
+			}
+			obj.Choices = v
+			continue
+		}
+		if key == "default_value" {
+			v, ok := starlark.AsString(val)
+			if !ok {
+				return fmt.Errorf("Expected string, actual: %s", val.Type())
+			}
+			obj.DefaultValue = string(v)
+			continue
+		}
+		return fmt.Errorf("Unexpected attribute name: %s", key)
+	}
+
+	mapObj.Freeze()
+	o.Dict = mapObj
+	o.Value = obj
+	o.isUnpacked = true
+
+	return nil
+}
+
+type UIChoiceInputSpecList struct {
+	*starlark.List
+	Value []v1alpha1.UIChoiceInputSpec
+	t     *starlark.Thread
+}
+
+func (o *UIChoiceInputSpecList) Unpack(v starlark.Value) error {
+	items := []v1alpha1.UIChoiceInputSpec{}
+
+	listObj, ok := v.(*starlark.List)
+	if !ok {
+		return fmt.Errorf("expected list, actual: %v", v.Type())
+	}
+
+	for i := 0; i < listObj.Len(); i++ {
+		v := listObj.Index(i)
+
+		item := UIChoiceInputSpec{t: o.t}
+		err := item.Unpack(v)
+		if err != nil {
+			return fmt.Errorf("at index %d: %v", i, err)
+		}
+		items = append(items, v1alpha1.UIChoiceInputSpec(item.Value))
+	}
+
+	listObj.Freeze()
+	o.List = listObj
+	o.Value = items
+
+	return nil
+}
+
 type UIComponentLocation struct {
 	*starlark.Dict
 	Value      v1alpha1.UIComponentLocation
@@ -3723,18 +4009,20 @@ func (p Plugin) uIInputSpec(t *starlark.Thread, fn *starlark.Builtin, args starl
 	var text starlark.Value
 	var bool starlark.Value
 	var hidden starlark.Value
+	var choice starlark.Value
 	err := starkit.UnpackArgs(t, fn.Name(), args, kwargs,
 		"name?", &name,
 		"label?", &label,
 		"text?", &text,
 		"bool?", &bool,
 		"hidden?", &hidden,
+		"choice?", &choice,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	dict := starlark.NewDict(5)
+	dict := starlark.NewDict(6)
 
 	if name != nil {
 		err := dict.SetKey(starlark.String("name"), name)
@@ -3766,6 +4054,12 @@ func (p Plugin) uIInputSpec(t *starlark.Thread, fn *starlark.Builtin, args starl
 			return nil, err
 		}
 	}
+	if choice != nil {
+		err := dict.SetKey(starlark.String("choice"), choice)
+		if err != nil {
+			return nil, err
+		}
+	}
 	var obj *UIInputSpec = &UIInputSpec{t: t}
 	err = obj.Unpack(dict)
 	if err != nil {
@@ -3838,6 +4132,15 @@ func (o *UIInputSpec) Unpack(v starlark.Value) error {
 			obj.Hidden = (*v1alpha1.UIHiddenInputSpec)(&v.Value)
 			continue
 		}
+		if key == "choice" {
+			v := UIChoiceInputSpec{t: o.t}
+			err := v.Unpack(val)
+			if err != nil {
+				return fmt.Errorf("unpacking %s: %v", key, err)
+			}
+			obj.Choice = (*v1alpha1.UIChoiceInputSpec)(&v.Value)
+			continue
+		}
 		return fmt.Errorf("Unexpected attribute name: %s", key)
 	}
 
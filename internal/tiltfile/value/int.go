@@ -0,0 +1,33 @@
+package value
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+)
+
+// Unpack values that could be Int or None
+type IntOrNone struct {
+	Value int
+	IsSet bool
+}
+
+func (i *IntOrNone) Unpack(v starlark.Value) error {
+	if v == nil {
+		return nil
+	}
+	switch v := v.(type) {
+	case starlark.NoneType:
+		return nil
+	case starlark.Int:
+		n, err := starlark.AsInt32(v)
+		if err != nil {
+			return err
+		}
+		i.Value = n
+		i.IsSet = true
+		return nil
+	}
+
+	return fmt.Errorf("got %s, want int or None", v.Type())
+}
@@ -0,0 +1,310 @@
+package tiltfile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+
+	"github.com/tilt-dev/tilt/internal/dockerfile"
+	"github.com/tilt-dev/tilt/internal/k8s"
+	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+type VerifySeverity string
+
+const (
+	VerifySeverityError   VerifySeverity = "error"
+	VerifySeverityWarning VerifySeverity = "warning"
+)
+
+// A problem found while statically verifying a Tiltfile.
+type VerifyProblem struct {
+	Severity VerifySeverity
+
+	// The resource the problem belongs to, if any.
+	Resource model.ManifestName
+
+	// Where the problem comes from (a file, or a file:line:col position), if known.
+	Source string
+
+	Message string
+}
+
+func (p VerifyProblem) String() string {
+	prefix := string(p.Severity)
+	if p.Source != "" {
+		prefix = fmt.Sprintf("%s: %s", p.Source, prefix)
+	}
+	if p.Resource != "" {
+		return fmt.Sprintf("%s: [%s] %s", prefix, p.Resource, p.Message)
+	}
+	return fmt.Sprintf("%s: %s", prefix, p.Message)
+}
+
+// Matches the innermost frame of a Starlark backtrace, or the position
+// of a Starlark syntax error.
+var backtraceFrameRE = regexp.MustCompile(`(?m)^\s+(\S+:\d+:\d+): in `)
+var syntaxErrorPosRE = regexp.MustCompile(`^(\S+:\d+:\d+): `)
+
+// Statically verifies the result of a Tiltfile load.
+//
+// Never contacts a cluster or a docker daemon, so it's safe to use
+// as a fast CI gate.
+func Verify(tlr TiltfileLoadResult) []VerifyProblem {
+	var problems []VerifyProblem
+	if tlr.Error != nil {
+		problems = append(problems, VerifyProblem{
+			Severity: VerifySeverityError,
+			Source:   errorSource(tlr.Error),
+			Message:  tlr.Error.Error(),
+		})
+		return problems
+	}
+
+	for _, m := range tlr.Manifests {
+		problems = append(problems, verifyImageTargets(m)...)
+		if m.IsK8s() {
+			problems = append(problems, verifyK8sYAML(m)...)
+		}
+	}
+	problems = append(problems, verifyResourceDeps(tlr.Manifests)...)
+	problems = append(problems, verifyPortForwards(tlr.Manifests)...)
+	return problems
+}
+
+// Returns true if any of the problems are errors (rather than warnings).
+func HasVerifyErrors(problems []VerifyProblem) bool {
+	for _, p := range problems {
+		if p.Severity == VerifySeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func errorSource(err error) string {
+	msg := err.Error()
+	frames := backtraceFrameRE.FindAllStringSubmatch(msg, -1)
+	if len(frames) > 0 {
+		return frames[len(frames)-1][1]
+	}
+	if match := syntaxErrorPosRE.FindStringSubmatch(msg); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+func verifyImageTargets(m model.Manifest) []VerifyProblem {
+	var problems []VerifyProblem
+	errorf := func(source string, format string, args ...interface{}) {
+		problems = append(problems, VerifyProblem{
+			Severity: VerifySeverityError,
+			Resource: m.Name,
+			Source:   source,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+	warnf := func(source string, format string, args ...interface{}) {
+		problems = append(problems, VerifyProblem{
+			Severity: VerifySeverityWarning,
+			Resource: m.Name,
+			Source:   source,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, iTarget := range m.ImageTargets {
+		ref := iTarget.Refs.ConfigurationRef.String()
+		err := iTarget.Validate()
+		if err != nil {
+			errorf("", "image %s: %v", ref, err)
+			continue
+		}
+
+		if db, ok := iTarget.BuildDetails.(model.DockerBuild); ok {
+			df := dockerfile.Dockerfile(db.Dockerfile)
+			err := df.Validate()
+			if err == nil {
+				_, err = df.FindImages(db.BuildArgs)
+			}
+			if err != nil {
+				errorf(dockerfileSource(db.DockerfilePath, err), "image %s: invalid Dockerfile: %v", ref, err)
+				continue
+			}
+
+			// The base Dockerfile is everything before the first ADD/COPY.
+			base, _, ok := df.SplitIntoBaseDockerfile()
+			if !ok {
+				base = df
+			}
+			if err := base.ValidateBaseDockerfile(); err != nil {
+				errorf(dockerfileSource(db.DockerfilePath, err), "image %s: invalid base Dockerfile: %v", ref, err)
+			}
+		}
+
+		lu := iTarget.LiveUpdateSpec
+		for _, sync := range lu.Syncs {
+			p := resolveLiveUpdatePath(lu, sync.LocalPath)
+			if _, err := os.Stat(p); err != nil {
+				errorf(p, "image %s: live_update sync path does not exist", ref)
+			}
+		}
+		for _, exec := range lu.Execs {
+			for _, trigger := range exec.TriggerPaths {
+				p := resolveLiveUpdatePath(lu, trigger)
+				if _, err := os.Stat(p); err != nil {
+					warnf(p, "image %s: live_update run trigger path does not exist", ref)
+				}
+			}
+		}
+		for _, restartPath := range lu.RestartPaths {
+			p := resolveLiveUpdatePath(lu, restartPath)
+			if _, err := os.Stat(p); err != nil {
+				warnf(p, "image %s: live_update restart_only path does not exist", ref)
+			}
+		}
+	}
+	return problems
+}
+
+// Returns the Dockerfile path, with the line the error points to
+// if the Dockerfile parser knows it.
+func dockerfileSource(path string, err error) string {
+	if path == "" {
+		return ""
+	}
+	var loc *parser.ErrorLocation
+	if errors.As(err, &loc) && len(loc.Location) > 0 {
+		return fmt.Sprintf("%s:%d", path, loc.Location[0].Start.Line)
+	}
+	return path
+}
+
+func resolveLiveUpdatePath(lu v1alpha1.LiveUpdateSpec, p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(lu.BasePath, p)
+}
+
+func verifyK8sYAML(m model.Manifest) []VerifyProblem {
+	kTarget := m.K8sTarget()
+	if kTarget.YAML == "" {
+		return nil
+	}
+
+	entities, err := k8s.ParseYAMLFromString(kTarget.YAML)
+	if err != nil {
+		return []VerifyProblem{{
+			Severity: VerifySeverityError,
+			Resource: m.Name,
+			Message:  fmt.Sprintf("invalid Kubernetes YAML: %v", err),
+		}}
+	}
+
+	// Unknown kinds are OK (they may be CRDs that we can't check
+	// without a cluster), as long as they're well-formed.
+	var problems []VerifyProblem
+	for _, e := range entities {
+		gvk := e.GVK()
+		meta := e.Meta()
+		if gvk.Kind == "" || gvk.Version == "" {
+			problems = append(problems, VerifyProblem{
+				Severity: VerifySeverityError,
+				Resource: m.Name,
+				Message:  fmt.Sprintf("Kubernetes object %q is missing apiVersion or kind", meta.GetName()),
+			})
+			continue
+		}
+		if meta.GetName() == "" && meta.GetGenerateName() == "" {
+			problems = append(problems, VerifyProblem{
+				Severity: VerifySeverityError,
+				Resource: m.Name,
+				Message:  fmt.Sprintf("Kubernetes %s is missing metadata.name", gvk.Kind),
+			})
+		}
+	}
+	return problems
+}
+
+func verifyResourceDeps(manifests []model.Manifest) []VerifyProblem {
+	known := make(map[model.ManifestName]bool)
+	for _, m := range manifests {
+		known[m.Name] = true
+	}
+
+	var problems []VerifyProblem
+	for _, m := range manifests {
+		for _, dep := range m.ResourceDependencies {
+			if !known[dep] {
+				problems = append(problems, VerifyProblem{
+					Severity: VerifySeverityError,
+					Resource: m.Name,
+					Message:  fmt.Sprintf("resource_deps references unknown resource %q", dep),
+				})
+			}
+		}
+	}
+	return problems
+}
+
+type portForwardKey struct {
+	host string
+	port int32
+}
+
+func verifyPortForwards(manifests []model.Manifest) []VerifyProblem {
+	owners := make(map[portForwardKey][]model.ManifestName)
+	for _, m := range manifests {
+		if !m.IsK8s() {
+			continue
+		}
+		spec := m.K8sTarget().PortForwardTemplateSpec
+		if spec == nil {
+			continue
+		}
+		for _, fwd := range spec.Forwards {
+			// A zero local port means "same as the container port".
+			port := fwd.LocalPort
+			if port == 0 {
+				port = fwd.ContainerPort
+			}
+			host := fwd.Host
+			if host == "" {
+				host = "localhost"
+			}
+			key := portForwardKey{host: host, port: port}
+			owners[key] = append(owners[key], m.Name)
+		}
+	}
+
+	keys := make([]portForwardKey, 0, len(owners))
+	for key, names := range owners {
+		if len(names) > 1 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].host != keys[j].host {
+			return keys[i].host < keys[j].host
+		}
+		return keys[i].port < keys[j].port
+	})
+
+	var problems []VerifyProblem
+	for _, key := range keys {
+		names := owners[key]
+		problems = append(problems, VerifyProblem{
+			Severity: VerifySeverityError,
+			Resource: names[0],
+			Message:  fmt.Sprintf("port forward %s:%d is used more than once (by %v)", key.host, key.port, names),
+		})
+	}
+	return problems
+}
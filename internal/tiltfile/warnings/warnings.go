@@ -0,0 +1,97 @@
+// Package warnings collects structured warnings while a Tiltfile executes.
+//
+// Warnings are logged the first time they appear. If a reload produces the
+// same warning again (by key), it's recorded but not re-announced.
+package warnings
+
+import (
+	"context"
+	"sync"
+
+	"go.starlark.net/starlark"
+
+	"github.com/tilt-dev/tilt/internal/tiltfile/starkit"
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+type collectorKey struct{}
+
+type Collector struct {
+	mu        sync.Mutex
+	announced map[string]bool
+	seen      map[string]bool
+	warnings  []model.TiltfileWarning
+}
+
+// Creates a collector for one Tiltfile load. Warnings with the same key as
+// one of the previous warnings aren't logged again.
+func NewCollector(previous []model.TiltfileWarning) *Collector {
+	announced := make(map[string]bool, len(previous))
+	for _, w := range previous {
+		announced[w.Key] = true
+	}
+	return &Collector{
+		announced: announced,
+		seen:      make(map[string]bool),
+	}
+}
+
+func WithCollector(ctx context.Context, c *Collector) context.Context {
+	return context.WithValue(ctx, collectorKey{}, c)
+}
+
+func collectorFromContext(ctx context.Context) *Collector {
+	c, _ := ctx.Value(collectorKey{}).(*Collector)
+	return c
+}
+
+// Records a warning and logs it if it's new.
+//
+// If the warning has no key, its resource and message identify it.
+// Without a collector in the context, the warning is only logged.
+func Add(ctx context.Context, w model.TiltfileWarning) {
+	if w.Key == "" {
+		w.Key = string(w.ManifestName) + ":" + w.Message
+	}
+
+	c := collectorFromContext(ctx)
+	if c == nil {
+		logger.Get(ctx).Warnf("%s", w.Message)
+		return
+	}
+
+	c.mu.Lock()
+	if c.seen[w.Key] {
+		c.mu.Unlock()
+		return
+	}
+	c.seen[w.Key] = true
+	c.warnings = append(c.warnings, w)
+	announce := !c.announced[w.Key]
+	c.mu.Unlock()
+
+	if announce {
+		logger.Get(ctx).Warnf("%s", w.Message)
+	}
+}
+
+// Records a warning from a builtin, located at the Tiltfile line that called it.
+func AddFromThread(thread *starlark.Thread, w model.TiltfileWarning) error {
+	ctx, err := starkit.ContextFromThread(thread)
+	if err != nil {
+		return err
+	}
+	if w.Location == "" && thread.CallStackDepth() > 1 {
+		w.Location = thread.CallFrame(1).Pos.String()
+	}
+	Add(ctx, w)
+	return nil
+}
+
+// The warnings recorded so far, in the order they were added.
+func (c *Collector) Warnings() []model.TiltfileWarning {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]model.TiltfileWarning(nil), c.warnings...)
+}
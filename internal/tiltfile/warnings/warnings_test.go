@@ -0,0 +1,51 @@
+package warnings
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestAddDedupsWithinLoad(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	ctx := logger.WithLogger(context.Background(), logger.NewLogger(logger.InfoLvl, out))
+	c := NewCollector(nil)
+	ctx = WithCollector(ctx, c)
+
+	Add(ctx, model.TiltfileWarning{Message: "problem 1", ManifestName: "fe"})
+	Add(ctx, model.TiltfileWarning{Message: "problem 1", ManifestName: "fe"})
+	Add(ctx, model.TiltfileWarning{Message: "problem 1"})
+
+	assert.Equal(t, []model.TiltfileWarning{
+		{Key: "fe:problem 1", Message: "problem 1", ManifestName: "fe"},
+		{Key: ":problem 1", Message: "problem 1"},
+	}, c.Warnings())
+	assert.Equal(t, 2, bytes.Count(out.Bytes(), []byte("problem 1")))
+}
+
+func TestAddDoesNotReannounce(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	ctx := logger.WithLogger(context.Background(), logger.NewLogger(logger.InfoLvl, out))
+	c := NewCollector([]model.TiltfileWarning{{Key: "old", Message: "old problem"}})
+	ctx = WithCollector(ctx, c)
+
+	Add(ctx, model.TiltfileWarning{Key: "old", Message: "old problem"})
+	Add(ctx, model.TiltfileWarning{Key: "new", Message: "new problem"})
+
+	assert.Len(t, c.Warnings(), 2)
+	assert.NotContains(t, out.String(), "old problem")
+	assert.Contains(t, out.String(), "new problem")
+}
+
+func TestAddWithoutCollector(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	ctx := logger.WithLogger(context.Background(), logger.NewLogger(logger.InfoLvl, out))
+
+	Add(ctx, model.TiltfileWarning{Message: "problem 1"})
+	assert.Contains(t, out.String(), "problem 1")
+}
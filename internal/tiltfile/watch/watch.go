@@ -1,6 +1,9 @@
 package watch
 
 import (
+	"fmt"
+	"time"
+
 	"go.starlark.net/starlark"
 
 	"github.com/tilt-dev/tilt/internal/tiltfile/starkit"
@@ -26,12 +29,22 @@ func (e Plugin) OnStart(env *starkit.Environment) error {
 func (e Plugin) setWatchSettings(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
 	err := starkit.SetState(thread, func(settings model.WatchSettings) (model.WatchSettings, error) {
 		var ignores value.StringOrStringList
+		settleMs := -1
 		if err := starkit.UnpackArgs(thread, fn.Name(), args, kwargs,
 			"ignore?", &ignores,
+			"tiltfile_settle_ms?", &settleMs,
 		); err != nil {
 			return settings, err
 		}
 
+		if settleMs != -1 {
+			if settleMs < 0 {
+				return settings, fmt.Errorf("%s: for parameter \"tiltfile_settle_ms\": must be non-negative, got %d", fn.Name(), settleMs)
+			}
+			delay := time.Duration(settleMs) * time.Millisecond
+			settings.TiltfileSettleDelay = &delay
+		}
+
 		if len(ignores.Values) != 0 {
 			settings.Ignores = append(settings.Ignores, model.Dockerignore{
 				LocalPath: starkit.AbsWorkingDir(thread),
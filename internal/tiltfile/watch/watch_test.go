@@ -2,6 +2,7 @@ package watch
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -49,6 +50,27 @@ load('./foo/Tiltfile', 'x')
 	}, MustState(result))
 }
 
+func TestTiltfileSettleMs(t *testing.T) {
+	f := NewFixture(t)
+	f.File("Tiltfile", `
+watch_settings(tiltfile_settle_ms=1500)
+`)
+	result, err := f.ExecFile("Tiltfile")
+	require.NoError(t, err)
+	delay := 1500 * time.Millisecond
+	require.Equal(t, model.WatchSettings{TiltfileSettleDelay: &delay}, MustState(result))
+}
+
+func TestTiltfileSettleMsNegative(t *testing.T) {
+	f := NewFixture(t)
+	f.File("Tiltfile", `
+watch_settings(tiltfile_settle_ms=-5)
+`)
+	_, err := f.ExecFile("Tiltfile")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `watch_settings: for parameter "tiltfile_settle_ms": must be non-negative, got -5`)
+}
+
 func NewFixture(tb testing.TB) *starkit.Fixture {
 	return starkit.NewFixture(tb, NewPlugin())
 }
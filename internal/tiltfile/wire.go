@@ -10,7 +10,7 @@ import (
 
 var WireSet = wire.NewSet(
 	ProvideTiltfileLoader,
-	k8scontext.NewPlugin,
+	k8scontext.ProvidePlugin,
 	version.NewPlugin,
 	config.NewPlugin,
 )
@@ -28,22 +28,38 @@ func greatestExistingAncestor(path string) (string, error) {
 	return path, nil
 }
 
+// Convert absolute paths to their native form, dropping any path that refers to
+// the same file as an earlier one (e.g., C:\Repo and c:/repo on Windows).
+func normalizeWatchPaths(s ospath.PathSemantics, paths []string) []string {
+	result := make([]string, 0, len(paths))
+	seen := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		key := s.Key(p)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, s.Native(p))
+	}
+	return result
+}
+
 // If we're recursively watching a path, it doesn't
 // make sense to watch any of its descendants.
-func dedupePathsForRecursiveWatcher(paths []string) []string {
+func dedupePathsForRecursiveWatcher(s ospath.PathSemantics, paths []string) []string {
 	result := []string{}
 	for _, current := range paths {
 		isCovered := false
 		hasRemovals := false
 
 		for i, existing := range result {
-			if ospath.IsChild(existing, current) {
+			if s.IsChild(existing, current) {
 				// The path is already covered, so there's no need to include it
 				isCovered = true
 				break
 			}
 
-			if ospath.IsChild(current, existing) {
+			if s.IsChild(current, existing) {
 				// Mark the element empty fo removal.
 				result[i] = ""
 				hasRemovals = true
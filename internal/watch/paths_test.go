@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/tilt-dev/tilt/internal/ospath"
 	"github.com/tilt-dev/tilt/internal/testutils/tempdir"
 )
 
@@ -28,3 +29,22 @@ func TestGreatestExistingAncestor(t *testing.T) {
 	_, err = greatestExistingAncestor(missingTopLevel)
 	assert.Contains(t, err.Error(), "cannot watch root directory")
 }
+
+func TestNormalizeWatchPathsWindows(t *testing.T) {
+	actual := normalizeWatchPaths(ospath.WindowsSemantics, []string{
+		`C:\Repo\src`,
+		`c:/repo/SRC/`,
+		`C:/Repo/docs`,
+	})
+	assert.Equal(t, []string{`C:\Repo\src`, `C:\Repo\docs`}, actual)
+}
+
+func TestDedupePathsForRecursiveWatcherWindows(t *testing.T) {
+	actual := dedupePathsForRecursiveWatcher(ospath.WindowsSemantics, []string{
+		`C:\Repo\src\pkg`,
+		`c:\repo`,
+		`C:\Repository`,
+		`C:\REPO\docs`,
+	})
+	assert.Equal(t, []string{`c:\repo`, `C:\Repository`}, actual)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/tilt-dev/tilt/internal/ospath"
 	"github.com/tilt-dev/tilt/pkg/logger"
 
 	"github.com/tilt-dev/fsevents"
@@ -127,7 +128,7 @@ func newWatcher(paths []string, ignore PathMatcher, l logger.Logger) (*darwinNot
 		stop:   make(chan struct{}),
 	}
 
-	paths = dedupePathsForRecursiveWatcher(paths)
+	paths = dedupePathsForRecursiveWatcher(ospath.HostSemantics(), paths)
 	for _, path := range paths {
 		path, err := filepath.Abs(path)
 		if err != nil {
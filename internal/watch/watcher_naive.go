@@ -27,6 +27,10 @@ type naiveNotify struct {
 	// in order to fulfill the API promise.
 	notifyList map[string]bool
 
+	// How the OS spells paths. Events are normalized to the native form
+	// before we match them against the notifyList or the ignores.
+	semantics ospath.PathSemantics
+
 	ignore PathMatcher
 	log    logger.Logger
 
@@ -53,7 +57,7 @@ func (d *naiveNotify) Start() error {
 		return err
 	}
 	if d.isWatcherRecursive {
-		pathsToWatch = dedupePathsForRecursiveWatcher(pathsToWatch)
+		pathsToWatch = dedupePathsForRecursiveWatcher(d.semantics, pathsToWatch)
 	}
 
 	for _, name := range pathsToWatch {
@@ -144,6 +148,7 @@ func (d *naiveNotify) loop() {
 		if e.Name == "" {
 			continue
 		}
+		e.Name = d.semantics.Native(e.Name)
 
 		if e.Op&fsnotify.Create != fsnotify.Create {
 			if d.shouldNotify(e.Name) {
@@ -227,7 +232,7 @@ func (d *naiveNotify) shouldNotify(path string) bool {
 	}
 	// TODO(dmiller): maybe use a prefix tree here?
 	for root := range d.notifyList {
-		if ospath.IsChild(root, path) {
+		if d.semantics.IsChild(root, path) {
 			return true
 		}
 	}
@@ -277,20 +282,28 @@ func newWatcher(paths []string, ignore PathMatcher, l logger.Logger) (*naiveNoti
 	isWatcherRecursive := err == nil
 
 	wrappedEvents := make(chan FileEvent)
-	notifyList := make(map[string]bool, len(paths))
-	if isWatcherRecursive {
-		paths = dedupePathsForRecursiveWatcher(paths)
-	}
+	semantics := ospath.HostSemantics()
+	absPaths := make([]string, 0, len(paths))
 	for _, path := range paths {
 		path, err := filepath.Abs(path)
 		if err != nil {
 			return nil, errors.Wrap(err, "newWatcher")
 		}
+		absPaths = append(absPaths, path)
+	}
+	absPaths = normalizeWatchPaths(semantics, absPaths)
+	if isWatcherRecursive {
+		absPaths = dedupePathsForRecursiveWatcher(semantics, absPaths)
+	}
+
+	notifyList := make(map[string]bool, len(absPaths))
+	for _, path := range absPaths {
 		notifyList[path] = true
 	}
 
 	wmw := &naiveNotify{
 		notifyList:         notifyList,
+		semantics:          semantics,
 		ignore:             ignore,
 		log:                l,
 		watcher:            fsw,
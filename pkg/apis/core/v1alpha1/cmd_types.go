@@ -108,6 +108,24 @@ type CmdSpec struct {
 	//
 	// +optional
 	DisableSource *DisableSource `json:"disableSource,omitempty" protobuf:"bytes,7,opt,name=disableSource"`
+
+	// How to read log levels from each line of output, so that logs can be
+	// filtered by level. One of: prefix, logrus, zap.
+	//
+	// If not set, all output is logged at the INFO level.
+	//
+	// +optional
+	LogLevelFormat string `json:"logLevelFormat,omitempty" protobuf:"bytes,8,opt,name=logLevelFormat"`
+
+	// Periodic probe of whether the process is still healthy.
+	//
+	// Unlike the readiness probe, which only reports whether the process is
+	// ready to serve, a process that fails its liveness probe FailureThreshold
+	// times in a row is considered hung, and is marked on its Running state
+	// so that its owner can restart it.
+	//
+	// +optional
+	LivenessProbe *Probe `json:"livenessProbe,omitempty" protobuf:"bytes,9,opt,name=livenessProbe"`
 }
 
 var _ resource.Object = &Cmd{}
@@ -200,6 +218,16 @@ type CmdStateRunning struct {
 
 	// Time at which the command was last started.
 	StartedAt metav1.MicroTime `json:"startedAt,omitempty" protobuf:"bytes,2,opt,name=startedAt"`
+
+	// What triggered this run, if it was restarted by one of its RestartOn
+	// objects (e.g., "api became ready at 12:03:04").
+	// +optional
+	StartReason string `json:"startReason,omitempty" protobuf:"bytes,3,opt,name=startReason"`
+
+	// Set when the process has failed its liveness probe enough times in a
+	// row that it should be restarted. Describes the most recent failure.
+	// +optional
+	LivenessFailure string `json:"livenessFailure,omitempty" protobuf:"bytes,4,opt,name=livenessFailure"`
 }
 
 // CmdStateTerminated is a terminated state of a local command.
@@ -219,6 +247,11 @@ type CmdStateTerminated struct {
 	// (brief) reason the process is terminated
 	// +optional
 	Reason string `json:"reason,omitempty" protobuf:"bytes,5,opt,name=reason"`
+
+	// What triggered this run, if it was restarted by one of its RestartOn
+	// objects (e.g., "api became ready at 12:03:04").
+	// +optional
+	StartReason string `json:"startReason,omitempty" protobuf:"bytes,6,opt,name=startReason"`
 }
 
 // Cmd implements ObjectWithStatusSubResource interface.
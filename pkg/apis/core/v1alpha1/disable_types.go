@@ -25,3 +25,36 @@ type DisableStatus struct {
 	// The reason this status was updated.
 	Reason string `json:"reason" protobuf:"bytes,3,opt,name=reason"`
 }
+
+// Where a change to whether a resource is disabled came from.
+type DisableChangeSource string
+
+const (
+	// The resource's default state when the Tiltfile first loads it.
+	DisableChangeSourceTiltfile DisableChangeSource = "Tiltfile"
+
+	// Someone toggled the resource in the web UI.
+	DisableChangeSourceUI DisableChangeSource = "UI"
+
+	// Someone ran `tilt enable` or `tilt disable`.
+	DisableChangeSourceCLI DisableChangeSource = "CLI"
+
+	// The resource changed along with a resource it depends on
+	// (or that depends on it).
+	DisableChangeSourceCascade DisableChangeSource = "Cascade"
+
+	// Tilt restored a choice saved by a previous run.
+	DisableChangeSourceRestored DisableChangeSource = "Restored"
+)
+
+// A change to whether a resource is disabled.
+type DisableTransition struct {
+	// Whether the resource was disabled (or enabled) by this change.
+	Disabled bool `json:"disabled" protobuf:"varint,1,opt,name=disabled"`
+
+	// Where the change came from.
+	Source DisableChangeSource `json:"source" protobuf:"bytes,2,opt,name=source,casttype=DisableChangeSource"`
+
+	// When the change happened.
+	Time metav1.MicroTime `json:"time" protobuf:"bytes,3,opt,name=time"`
+}
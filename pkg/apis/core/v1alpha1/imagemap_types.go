@@ -199,6 +199,14 @@ type ImageMapStatus struct {
 	// may not be included in the image.
 	BuildStartTime *metav1.MicroTime `json:"buildStartTime,omitempty" protobuf:"bytes,2,opt,name=buildStartTime"`
 
+	// A human-readable note about how the image was produced.
+	//
+	// For example, "unchanged, reused tag" when the builder found an existing
+	// image with a content-addressed tag for the same inputs, and skipped the build.
+	//
+	// +optional
+	Message string `json:"message,omitempty" protobuf:"bytes,3,opt,name=message"`
+
 	// TODO(nick): I'm not totally sure how we should model registries in this system.
 	//
 	// We need to be able to support an image existing at multiple URLs in
@@ -23,6 +23,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
 	"github.com/tilt-dev/tilt-apiserver/pkg/server/builder/resource"
@@ -166,6 +167,24 @@ type KubernetesApplySpec struct {
 	//
 	// +optional
 	RestartOn *RestartOnSpec `json:"restartOn,omitempty" protobuf:"bytes,11,opt,name=restartOn"`
+
+	// ApplyDiscipline describes when we re-apply the YAML to the cluster.
+	//
+	// Objects can also opt into the infrastructure discipline individually
+	// with the annotation 'tilt.dev/apply-discipline: infrastructure'.
+	//
+	// +optional
+	ApplyDiscipline KubernetesApplyDiscipline `json:"applyDiscipline,omitempty" protobuf:"bytes,12,opt,name=applyDiscipline,casttype=KubernetesApplyDiscipline"`
+
+	// Namespace overrides the namespace of every namespaced object in the YAML.
+	//
+	// Cluster-scoped objects are left alone. RoleBinding and ClusterRoleBinding
+	// subjects that point at a namespace in the same YAML are moved too.
+	//
+	// Only supported with YAML, not with a custom apply command.
+	//
+	// +optional
+	Namespace string `json:"namespace,omitempty" protobuf:"bytes,13,opt,name=namespace"`
 }
 
 var _ resource.Object = &KubernetesApply{}
@@ -224,6 +243,34 @@ func (in *KubernetesApply) Validate(ctx context.Context) field.ErrorList {
 			}))
 	}
 
+	discipline := in.Spec.ApplyDiscipline
+	if !(discipline == "" ||
+		discipline == KubernetesApplyDisciplineDefault ||
+		discipline == KubernetesApplyDisciplineInfrastructure) {
+		fieldErrors = append(fieldErrors, field.NotSupported(
+			field.NewPath("spec.applyDiscipline"),
+			discipline,
+			[]string{
+				string(KubernetesApplyDisciplineDefault),
+				string(KubernetesApplyDisciplineInfrastructure),
+			}))
+	}
+
+	if in.Spec.Namespace != "" {
+		for _, msg := range validation.IsDNS1123Label(in.Spec.Namespace) {
+			fieldErrors = append(fieldErrors, field.Invalid(
+				field.NewPath("spec.namespace"),
+				in.Spec.Namespace,
+				msg))
+		}
+		if in.Spec.Cmd != nil {
+			fieldErrors = append(fieldErrors, field.Invalid(
+				field.NewPath("spec.namespace"),
+				in.Spec.Namespace,
+				"namespace overrides aren't supported with .spec.cmd"))
+		}
+	}
+
 	if in.Spec.YAML != "" {
 		if in.Spec.Cmd != nil {
 			fieldErrors = append(fieldErrors, field.Invalid(
@@ -293,6 +340,14 @@ type KubernetesApplyStatus struct {
 	// +optional
 	DisableStatus *DisableStatus `json:"disableStatus,omitempty" protobuf:"bytes,5,opt,name=disableStatus"`
 
+	// The debug override that the last apply injected, if any
+	// (e.g., "container=app, readiness probe removed").
+	//
+	// Empty once an apply restores the original spec.
+	//
+	// +optional
+	DebugOverride string `json:"debugOverride,omitempty" protobuf:"bytes,7,opt,name=debugOverride"`
+
 	// TODO(nick): We should also add some sort of status field to this
 	// status (like waiting, active, done).
 }
@@ -366,6 +421,25 @@ var (
 	KubernetesDiscoveryStrategySelectorsOnly KubernetesDiscoveryStrategy = "selectors-only"
 )
 
+type KubernetesApplyDiscipline string
+
+var (
+	// In the default discipline, we apply every object each time we deploy.
+	KubernetesApplyDisciplineDefault KubernetesApplyDiscipline = "default"
+
+	// In the infrastructure discipline, we apply an object if it's not in
+	// the cluster yet, and afterwards only when its YAML changes.
+	//
+	// Meant for objects that other tools also manage (like CRDs or priority
+	// classes). If someone else changes the object in the cluster, we report
+	// the drift but leave it alone. 'tilt down' doesn't delete these objects.
+	KubernetesApplyDisciplineInfrastructure KubernetesApplyDiscipline = "infrastructure"
+)
+
+// The annotation that puts a single Kubernetes object in the
+// infrastructure discipline.
+const AnnotationApplyDiscipline = "tilt.dev/apply-discipline"
+
 type KubernetesApplyCmd struct {
 	// Args are the command-line arguments for the apply command. Must have length >= 1.
 	Args []string `json:"args" protobuf:"bytes,1,rep,name=args"`
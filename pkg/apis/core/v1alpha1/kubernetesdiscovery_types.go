@@ -147,6 +147,14 @@ type PodLogStreamTemplateSpec struct {
 	//
 	// +optional
 	IgnoreContainers []string `json:"ignoreContainers,omitempty" protobuf:"bytes,3,rep,name=ignoreContainers"`
+
+	// How to read log levels from each line of output, so that logs can be
+	// filtered by level. One of: prefix, logrus, zap.
+	//
+	// If not set, all output is logged at the INFO level.
+	//
+	// +optional
+	LogLevelFormat string `json:"logLevelFormat,omitempty" protobuf:"bytes,4,opt,name=logLevelFormat"`
 }
 
 var _ resource.Object = &KubernetesDiscovery{}
@@ -247,6 +255,10 @@ type Pod struct {
 	Name string `json:"name" protobuf:"bytes,1,opt,name=name"`
 	// Namespace is the Pod namespace within the K8s cluster.
 	Namespace string `json:"namespace" protobuf:"bytes,2,opt,name=namespace"`
+	// NodeName is the node the Pod is scheduled on, if it's been scheduled.
+	//
+	// +optional
+	NodeName string `json:"nodeName,omitempty" protobuf:"bytes,16,opt,name=nodeName"`
 	// CreatedAt is when the Pod was created.
 	CreatedAt metav1.Time `json:"createdAt" protobuf:"bytes,3,opt,name=createdAt"`
 	// Phase is where the Pod is at in its current lifecycle.
@@ -326,6 +338,11 @@ type Container struct {
 	//
 	// This is added by Tilt for convenience when managing port forwards.
 	Ports []int32 `json:"ports" protobuf:"varint,7,rep,name=ports"`
+	// ImageID is the ID of the image the container is running, as reported by
+	// the container runtime (e.g., `docker-pullable://my-img@sha256:...`).
+	//
+	// +optional
+	ImageID string `json:"imageID,omitempty" protobuf:"bytes,8,opt,name=imageID"`
 }
 
 // ContainerState holds a possible state of container.
@@ -109,6 +109,20 @@ type LiveUpdateSpec struct {
 	//
 	// +optional
 	Restart LiveUpdateRestartStrategy `json:"restart,omitempty" protobuf:"bytes,7,opt,name=restart,casttype=LiveUpdateRestartStrategy"`
+
+	// A list of relative paths that only need the container restarted.
+	//
+	// Files that match these paths (and no exec's trigger paths) are synced, then
+	// the container is restarted, without running any execs.
+	//
+	// A file that matches both these paths and an exec's trigger paths runs the exec.
+	//
+	// Note that native restarts are only supported by Docker and Docker Compose.
+	//
+	// Paths are specified relative to the the BasePath of the LiveUpdate.
+	//
+	// +optional
+	RestartPaths []string `json:"restartPaths,omitempty" protobuf:"bytes,10,rep,name=restartPaths"`
 }
 
 var _ resource.Object = &LiveUpdate{}
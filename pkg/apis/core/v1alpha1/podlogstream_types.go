@@ -95,6 +95,14 @@ type PodLogStreamSpec struct {
 	//
 	// +optional
 	IgnoreContainers []string `json:"ignoreContainers,omitempty" protobuf:"bytes,5,rep,name=ignoreContainers"`
+
+	// How to read log levels from each line of output, so that logs can be
+	// filtered by level. One of: prefix, logrus, zap.
+	//
+	// If not set, all output is logged at the INFO level.
+	//
+	// +optional
+	LogLevelFormat string `json:"logLevelFormat,omitempty" protobuf:"bytes,6,opt,name=logLevelFormat"`
 }
 
 var _ resource.Object = &PodLogStream{}
@@ -176,6 +184,17 @@ type ContainerLogStreamStatus struct {
 	//
 	// +optional
 	Error string `json:"error,omitempty" protobuf:"bytes,4,opt,name=error"`
+
+	// True when the container logged faster than its rate limit, so some
+	// lines were dropped. The logs that made it through are a sample.
+	//
+	// +optional
+	RateLimited bool `json:"rateLimited,omitempty" protobuf:"varint,5,opt,name=rateLimited"`
+
+	// The number of log lines dropped by the rate limit.
+	//
+	// +optional
+	DroppedLines int64 `json:"droppedLines,omitempty" protobuf:"varint,6,opt,name=droppedLines"`
 }
 
 // PodLogStream implements ObjectWithStatusSubResource interface.
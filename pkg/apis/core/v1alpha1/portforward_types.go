@@ -93,6 +93,27 @@ type Forward struct {
 	//
 	// +optional
 	Path string `json:"path,omitempty" protobuf:"bytes,7,opt,name=path"`
+
+	// Checks that the forward reaches a server that's listening.
+	//
+	// A forward can connect before the server in the pod is listening.
+	// If set, Tilt probes the local port after each (re)connection, and only
+	// reports the forward as verified once the probe passes.
+	//
+	// +optional
+	Verify *ForwardVerify `json:"verify,omitempty" protobuf:"bytes,8,opt,name=verify"`
+}
+
+// ForwardVerify describes how to check that a forward reaches a server.
+type ForwardVerify struct {
+	// Path for an HTTP GET against the local port. Any response with a
+	// status below 400 passes.
+	//
+	// If empty, Tilt opens a TCP connection to the local port instead, and
+	// the probe passes if the forward doesn't immediately close it.
+	//
+	// +optional
+	HTTPPath string `json:"httpPath,omitempty" protobuf:"bytes,1,opt,name=httpPath"`
 }
 
 var _ resource.Object = &PortForward{}
@@ -200,6 +221,30 @@ type ForwardStatus struct {
 	// Error is a human-readable description if a problem was encountered
 	// while initializing the forward.
 	Error string `json:"error,omitempty" protobuf:"bytes,5,opt,name=error"`
+
+	// Disabled is true if the forward was turned off at runtime.
+	//
+	// Disabled forwards stay off when their pod is replaced, until they're
+	// turned back on or Tilt exits.
+	//
+	// +optional
+	Disabled bool `json:"disabled,omitempty" protobuf:"varint,6,opt,name=disabled"`
+
+	// VerifiedAt is the time the forward passed its verification probe.
+	//
+	// Empty if the forward has no verification configured, or if it hasn't
+	// passed since it last (re)connected.
+	//
+	// +optional
+	VerifiedAt metav1.MicroTime `json:"verifiedAt,omitempty" protobuf:"bytes,7,opt,name=verifiedAt"`
+
+	// Transport is how the forward reaches the cluster: "websocket" or "spdy".
+	//
+	// Tilt tries websockets first, falls back to SPDY, and remembers what
+	// worked for each cluster.
+	//
+	// +optional
+	Transport string `json:"transport,omitempty" protobuf:"bytes,8,opt,name=transport"`
 }
 
 // PortForward implements ObjectWithStatusSubResource interface.
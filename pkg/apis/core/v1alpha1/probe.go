@@ -46,6 +46,10 @@ type HTTPGetAction struct {
 	// Custom headers to set in the request. HTTP allows repeated headers.
 	// +optional
 	HTTPHeaders []HTTPHeader `json:"httpHeaders,omitempty" protobuf:"bytes,5,rep,name=httpHeaders"`
+	// Skip verifying the server's certificate on HTTPS probes. Useful
+	// for local servers with self-signed certificates.
+	// +optional
+	InsecureSkipTLSVerify bool `json:"insecureSkipTLSVerify,omitempty" protobuf:"varint,6,opt,name=insecureSkipTLSVerify"`
 }
 
 // URIScheme identifies the scheme used for connection to a host for Get actions
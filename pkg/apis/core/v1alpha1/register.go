@@ -44,6 +44,10 @@ const AnnotationManifest = "tilt.dev/resource"
 // its logs should appear under.
 const AnnotationSpanID = "tilt.dev/log-span-id"
 
+// An annotation on a Cmd that counts how many times its owner has
+// started a Cmd. Each run logs to its own span.
+const AnnotationRunNumber = "tilt.dev/run-number"
+
 // Denote that the Tiltfile is the owner.
 const OwnerKindTiltfile = "Tiltfile"
 
@@ -11,6 +11,14 @@ type RestartOnSpec struct {
 	// UIButtons that can trigger a restart.
 	// +optional
 	UIButtons []string `json:"uiButtons,omitempty" protobuf:"bytes,2,rep,name=uiButtons"`
+
+	// UIResources that can trigger a restart when they become ready.
+	//
+	// Each time one of these resources goes from not ready to ready, it
+	// counts as a restart event. If a resource flaps between ready and not
+	// ready, the consumer may wait for it to settle and only restart once.
+	// +optional
+	UIResources []string `json:"uiResources,omitempty" protobuf:"bytes,3,rep,name=uiResources"`
 }
 
 // StartOnSpec indicates the set of objects that can trigger a start/restart of this object.
@@ -162,6 +162,109 @@ type SessionStatus struct {
 	//
 	// +optional
 	Error string `json:"error,omitempty" protobuf:"bytes,5,opt,name=error"`
+
+	// KubernetesVersion is the version reported by the Kubernetes server when the Session started.
+	//
+	// Empty if Tilt couldn't reach a Kubernetes server.
+	//
+	// +optional
+	KubernetesVersion string `json:"kubernetesVersion,omitempty" protobuf:"bytes,6,opt,name=kubernetesVersion"`
+
+	// DockerVersion is the Docker engine version reported by the Docker server when the Session started.
+	//
+	// Empty if Tilt couldn't reach a Docker server.
+	//
+	// +optional
+	DockerVersion string `json:"dockerVersion,omitempty" protobuf:"bytes,7,opt,name=dockerVersion"`
+
+	// DockerAPIVersion is the Docker API version reported by the Docker server when the Session started.
+	//
+	// Empty if Tilt couldn't reach a Docker server.
+	//
+	// +optional
+	DockerAPIVersion string `json:"dockerAPIVersion,omitempty" protobuf:"bytes,8,opt,name=dockerAPIVersion"`
+
+	// Phase summarizes the state of the whole Session, so that tools wrapping
+	// Tilt can watch this one field rather than every resource.
+	//
+	// +optional
+	Phase SessionPhase `json:"phase,omitempty" protobuf:"bytes,9,opt,name=phase,casttype=SessionPhase"`
+
+	// EngineMode is how Tilt was started: "up" or "ci".
+	//
+	// +optional
+	EngineMode string `json:"engineMode,omitempty" protobuf:"bytes,10,opt,name=engineMode"`
+
+	// ResourceCounts counts the resources from the Tiltfile by state.
+	//
+	// +optional
+	ResourceCounts SessionResourceCounts `json:"resourceCounts,omitempty" protobuf:"bytes,11,opt,name=resourceCounts"`
+
+	// Resources is the readiness of each resource that's been asked to run,
+	// with the reason it's not ready yet. Uses the same rules as the CI exit
+	// condition.
+	//
+	// +optional
+	Resources []SessionResourceStatus `json:"resources,omitempty" protobuf:"bytes,12,rep,name=resources"`
+}
+
+// SessionPhase summarizes the state of the whole Session.
+type SessionPhase string
+
+const (
+	// SessionPhaseInProgress means the Tiltfile is loading, or some resources
+	// are still building or starting up.
+	SessionPhaseInProgress SessionPhase = "in-progress"
+
+	// SessionPhaseReady means every resource that's been asked to run is ready.
+	SessionPhaseReady SessionPhase = "ready"
+
+	// SessionPhaseError means the Tiltfile or a resource failed, or the
+	// Session exited with an error.
+	SessionPhaseError SessionPhase = "error"
+)
+
+// SessionResourceCounts counts the resources in a Session by state.
+type SessionResourceCounts struct {
+	// Total is the number of resources from the Tiltfile.
+	Total int32 `json:"total" protobuf:"varint,1,opt,name=total"`
+
+	// Ready is the number of resources that are ready.
+	Ready int32 `json:"ready" protobuf:"varint,2,opt,name=ready"`
+
+	// InProgress is the number of resources that are building or starting up.
+	InProgress int32 `json:"inProgress" protobuf:"varint,3,opt,name=inProgress"`
+
+	// Error is the number of resources whose build or runtime failed.
+	Error int32 `json:"error" protobuf:"varint,4,opt,name=error"`
+
+	// Inactive is the number of resources that haven't been asked to run,
+	// e.g., because they're disabled or have auto_init=False.
+	Inactive int32 `json:"inactive" protobuf:"varint,5,opt,name=inactive"`
+}
+
+// SessionResourceStatus is the readiness of one resource in a Session.
+type SessionResourceStatus struct {
+	// Name is the name of the resource.
+	Name string `json:"name" protobuf:"bytes,1,opt,name=name"`
+
+	// BuildComplete is true when the resource's update has finished successfully.
+	BuildComplete bool `json:"buildComplete" protobuf:"varint,2,opt,name=buildComplete"`
+
+	// Ready is true when the resource has done everything the CI exit
+	// condition waits for.
+	Ready bool `json:"ready" protobuf:"varint,3,opt,name=ready"`
+
+	// Failed is true when the resource's build or runtime failed.
+	//
+	// +optional
+	Failed bool `json:"failed,omitempty" protobuf:"varint,4,opt,name=failed"`
+
+	// Reason is why the resource isn't ready yet, e.g., "building" or
+	// "pod Pending". Empty when the resource is ready.
+	//
+	// +optional
+	Reason string `json:"reason,omitempty" protobuf:"bytes,5,opt,name=reason"`
 }
 
 // Target is a server or job whose execution is managed as part of this Session.
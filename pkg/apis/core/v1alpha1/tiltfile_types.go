@@ -188,4 +188,22 @@ type TiltfileStateTerminated struct {
 	// (brief) reason the process is terminated
 	// +optional
 	WarningCount int32 `json:"warningCount,omitempty" protobuf:"varint,5,opt,name=warningCount"`
+
+	// Globs registered with watch_glob(). Files that match them reload
+	// the Tiltfile when they change.
+	// +optional
+	WatchedGlobs []TiltfileWatchedGlob `json:"watchedGlobs,omitempty" protobuf:"bytes,6,rep,name=watchedGlobs"`
+}
+
+// A glob whose matching files are config files of the Tiltfile.
+type TiltfileWatchedGlob struct {
+	// The absolute pattern, in .dockerignore syntax.
+	Pattern string `json:"pattern" protobuf:"bytes,1,opt,name=pattern"`
+
+	// Patterns for files that don't count, even if they match.
+	// +optional
+	Ignores []string `json:"ignores,omitempty" protobuf:"bytes,2,rep,name=ignores"`
+
+	// How many files matched when the Tiltfile was loaded.
+	MatchCount int32 `json:"matchCount" protobuf:"varint,3,opt,name=matchCount"`
 }
@@ -250,6 +250,43 @@ type UIHiddenInputStatus struct {
 	Value string `json:"value" protobuf:"bytes,1,opt,name=value"`
 }
 
+// Describes a choice dropdown input field attached to a button.
+type UIChoiceInputSpec struct {
+	// The values the user can choose from.
+	Choices []string `json:"choices" protobuf:"bytes,1,rep,name=choices"`
+
+	// The initially selected value. Must be one of the choices.
+	// If unspecified, the first choice is selected.
+	//
+	// +optional
+	DefaultValue string `json:"defaultValue,omitempty" protobuf:"bytes,2,opt,name=defaultValue"`
+}
+
+func (in *UIChoiceInputSpec) validate(path *field.Path) field.ErrorList {
+	var fieldErrors field.ErrorList
+	if len(in.Choices) == 0 {
+		fieldErrors = append(fieldErrors, field.Required(path.Child("choices"), "must have at least one choice"))
+	}
+
+	seen := make(map[string]bool)
+	for i, c := range in.Choices {
+		if seen[c] {
+			fieldErrors = append(fieldErrors, field.Duplicate(path.Child("choices").Index(i), c))
+		}
+		seen[c] = true
+	}
+
+	if in.DefaultValue != "" && !seen[in.DefaultValue] {
+		fieldErrors = append(fieldErrors, field.NotSupported(path.Child("defaultValue"), in.DefaultValue, in.Choices))
+	}
+	return fieldErrors
+}
+
+type UIChoiceInputStatus struct {
+	// The selected choice.
+	Value string `json:"value" protobuf:"bytes,1,opt,name=value"`
+}
+
 // Defines an Input to render in the UI.
 // If UIButton is analogous to an HTML <form>,
 // UIInput is analogous to an HTML <input>.
@@ -274,6 +311,10 @@ type UIInputSpec struct {
 	// An input that has a constant value and does not display to the user
 	// +optional
 	Hidden *UIHiddenInputSpec `json:"hidden,omitempty" protobuf:"bytes,5,opt,name=hidden"`
+
+	// A Choice input that takes one of a fixed list of values.
+	// +optional
+	Choice *UIChoiceInputSpec `json:"choice,omitempty" protobuf:"bytes,6,opt,name=choice"`
 }
 
 func (in *UIInputSpec) Validate(_ context.Context, path *field.Path) field.ErrorList {
@@ -289,6 +330,10 @@ func (in *UIInputSpec) Validate(_ context.Context, path *field.Path) field.Error
 	if in.Hidden != nil {
 		numInputTypes += 1
 	}
+	if in.Choice != nil {
+		numInputTypes += 1
+		fieldErrors = append(fieldErrors, in.Choice.validate(path.Child("choice"))...)
+	}
 
 	if numInputTypes != 1 {
 		fieldErrors = append(fieldErrors, field.Invalid(path, in, "must specify exactly one input type"))
@@ -316,6 +361,10 @@ type UIInputStatus struct {
 	// The status of the input, if it's a hidden
 	// +optional
 	Hidden *UIHiddenInputStatus `json:"hidden,omitempty" protobuf:"bytes,4,opt,name=hidden"`
+
+	// The status of the input, if it's a choice
+	// +optional
+	Choice *UIChoiceInputStatus `json:"choice,omitempty" protobuf:"bytes,5,opt,name=choice"`
 }
 
 // UIButtonStatus defines the observed state of UIButton
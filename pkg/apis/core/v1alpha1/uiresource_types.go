@@ -117,6 +117,16 @@ type DisableResourceStatus struct {
 
 	// All unique sources that control the resource's objects' disable status.
 	Sources []DisableSource `json:"sources" protobuf:"bytes,3,rep,name=sources"`
+
+	// Where the most recent change to the resource's disable state came from.
+	//
+	// +optional
+	Source DisableChangeSource `json:"source,omitempty" protobuf:"bytes,4,opt,name=source,casttype=DisableChangeSource"`
+
+	// The most recent changes to the resource's disable state, oldest first.
+	//
+	// +optional
+	Transitions []DisableTransition `json:"transitions,omitempty" protobuf:"bytes,5,rep,name=transitions"`
 }
 
 // UIResourceStatus defines the observed state of UIResource
@@ -198,6 +208,111 @@ type UIResourceStatus struct {
 	//
 	// +optional
 	Waiting *UIResourceStateWaiting `json:"waiting,omitempty" protobuf:"bytes,17,opt,name=waiting"`
+
+	// Every link Tilt knows about for this resource, with where it came from
+	// and whether it's serving.
+	//
+	// Unlike EndpointLinks, this includes service URLs even when the resource
+	// has port-forwards. Links declared in the Tiltfile come first.
+	//
+	// +optional
+	Links []UIResourceLink `json:"links,omitempty" protobuf:"bytes,18,rep,name=links"`
+
+	// The last time the RuntimeStatus changed to ok from any other status.
+	//
+	// Objects that restart when this resource becomes ready (see
+	// RestartOnSpec.UIResources) watch this field.
+	//
+	// +optional
+	LastReadyTime metav1.MicroTime `json:"lastReadyTime,omitempty" protobuf:"bytes,19,opt,name=lastReadyTime"`
+
+	// AttentionScore is a rough measure of how much this resource needs the
+	// user's attention. Higher is more urgent; 0 means there's nothing to look at.
+	//
+	// Current errors count the most, followed by recent build failures, crash
+	// rebuilds, pending changes, and warnings. Past events count less as they age.
+	//
+	// +optional
+	AttentionScore int32 `json:"attentionScore,omitempty" protobuf:"varint,20,opt,name=attentionScore"`
+
+	// AttentionOrder is the position of this resource when all resources are
+	// sorted by AttentionScore, highest first. Starts at 1. Resources with the
+	// same score keep their relative Order.
+	//
+	// UIs can sort by this field to show the resources that need attention first.
+	//
+	// +optional
+	AttentionOrder int32 `json:"attentionOrder,omitempty" protobuf:"varint,21,opt,name=attentionOrder"`
+
+	// Conditions are observations about the resource that don't fit in
+	// UpdateStatus or RuntimeStatus, e.g., warnings about what's running.
+	//
+	// +optional
+	Conditions []UIResourceCondition `json:"conditions,omitempty" protobuf:"bytes,22,rep,name=conditions"`
+
+	// Warnings about this resource from the last successful Tiltfile load.
+	//
+	// On a Tiltfile resource, the warnings that aren't about one of its resources.
+	//
+	// +optional
+	TiltfileWarnings []UITiltfileWarning `json:"tiltfileWarnings,omitempty" protobuf:"bytes,23,rep,name=tiltfileWarnings"`
+}
+
+// UIResourceCondition is an observation about a resource.
+type UIResourceCondition struct {
+	// Type of the condition.
+	Type UIResourceConditionType `json:"type" protobuf:"bytes,1,opt,name=type,casttype=UIResourceConditionType"`
+
+	// Status of the condition, one of True, False, Unknown.
+	Status metav1.ConditionStatus `json:"status" protobuf:"bytes,2,opt,name=status,casttype=k8s.io/apimachinery/pkg/apis/meta/v1.ConditionStatus"`
+
+	// The last time the condition changed from one status to another.
+	// +optional
+	LastTransitionTime metav1.MicroTime `json:"lastTransitionTime,omitempty" protobuf:"bytes,3,opt,name=lastTransitionTime"`
+
+	// A one-word, CamelCase reason for the condition's last transition.
+	// +optional
+	Reason string `json:"reason,omitempty" protobuf:"bytes,4,opt,name=reason"`
+
+	// A human-readable message about the condition, including what the
+	// user can do about it.
+	// +optional
+	Message string `json:"message,omitempty" protobuf:"bytes,5,opt,name=message"`
+}
+
+type UIResourceConditionType string
+
+const (
+	// The resource's containers are running images other than the ones from
+	// the last successful Tilt build (e.g., someone ran `kubectl set image`).
+	//
+	// Triggering an update re-deploys the images that Tilt built.
+	UIResourceImageDrift UIResourceConditionType = "ImageDrift"
+
+	// The resource was deployed with a debug override (e.g., a different
+	// command, or without its readiness probe).
+	//
+	// Turning the override off re-deploys the original spec.
+	UIResourceDebugOverride UIResourceConditionType = "DebugOverride"
+)
+
+// UITiltfileWarning is a warning from Tiltfile execution, e.g., use of a
+// deprecated API.
+type UITiltfileWarning struct {
+	// A human-readable description of the warning.
+	Message string `json:"message" protobuf:"bytes,1,opt,name=message"`
+
+	// Identifies the warning across Tiltfile loads.
+	// +optional
+	Key string `json:"key,omitempty" protobuf:"bytes,2,opt,name=key"`
+
+	// The resource the warning is about, if any.
+	// +optional
+	Resource string `json:"resource,omitempty" protobuf:"bytes,3,opt,name=resource"`
+
+	// Where in the Tiltfile the warning came from, as file:line:col.
+	// +optional
+	Location string `json:"location,omitempty" protobuf:"bytes,4,opt,name=location"`
 }
 
 // UIResource implements ObjectWithStatusSubResource interface.
@@ -223,8 +338,39 @@ type UIResourceLink struct {
 	// The display label on a URL.
 	// +optional
 	Name string `json:"name,omitempty" protobuf:"bytes,2,opt,name=name"`
+
+	// Where the link came from.
+	// +optional
+	Kind UIResourceLinkKind `json:"kind,omitempty" protobuf:"bytes,3,opt,name=kind,casttype=UIResourceLinkKind"`
+
+	// Whether Tilt believes the URL is serving.
+	//
+	// Tilt doesn't check links declared in the Tiltfile, so those are always ready.
+	// +optional
+	Ready bool `json:"ready,omitempty" protobuf:"varint,4,opt,name=ready"`
+
+	// Whether Tilt is still waiting to verify that the URL is serving.
+	//
+	// Only set on port-forwards with verification configured.
+	// +optional
+	Pending bool `json:"pending,omitempty" protobuf:"varint,5,opt,name=pending"`
 }
 
+// UIResourceLinkKind identifies where a link came from.
+type UIResourceLinkKind string
+
+const (
+	// A link declared in the Tiltfile.
+	UIResourceLinkKindCustom UIResourceLinkKind = "custom"
+
+	// A local URL that Tilt port-forwards to a pod.
+	UIResourceLinkKindPortForward UIResourceLinkKind = "port-forward"
+
+	// A URL exposed by the cluster or container runtime, e.g.,
+	// a Kubernetes LoadBalancer IP or a Docker Compose published port.
+	UIResourceLinkKindService UIResourceLinkKind = "service"
+)
+
 // UIResourceTargetType identifies the different categories of
 // task in a resource.
 type UIResourceTargetType string
@@ -300,6 +446,74 @@ type UIBuildTerminated struct {
 	// build+deploy to reset the pod state to what's on disk.
 	// +optional
 	IsCrashRebuild bool `json:"isCrashRebuild,omitempty" protobuf:"varint,6,opt,name=isCrashRebuild"`
+
+	// How long it took from the file change that triggered the build until
+	// the resource was ready again.
+	//
+	// Only populated once Tilt sees the resource become ready.
+	// +optional
+	Latency *UIBuildLatency `json:"latency,omitempty" protobuf:"bytes,7,opt,name=latency"`
+
+	// How each Dockerfile step used the build cache, for each image built.
+	// +optional
+	ImageCache []UIImageBuildCache `json:"imageCache,omitempty" protobuf:"bytes,8,rep,name=imageCache"`
+}
+
+// UIBuildLatency breaks down the time between a file change and a ready resource.
+type UIBuildLatency struct {
+	// The total time, from the triggering file change (or the build start, if
+	// the build wasn't triggered by a file change) until the resource was ready.
+	Total metav1.Duration `json:"total" protobuf:"bytes,1,opt,name=total"`
+
+	// The phases of the update, in order.
+	//
+	// Full deploys go through build, deploy, rollout, and ready.
+	// Live updates go through sync, and restart if the container restarted.
+	// +optional
+	Phases []UIBuildLatencyPhase `json:"phases,omitempty" protobuf:"bytes,2,rep,name=phases"`
+}
+
+// UIBuildLatencyPhase is one phase of an update.
+type UIBuildLatencyPhase struct {
+	// The name of the phase.
+	Name string `json:"name" protobuf:"bytes,1,opt,name=name"`
+
+	// How long the phase took.
+	Duration metav1.Duration `json:"duration" protobuf:"bytes,2,opt,name=duration"`
+}
+
+// UIImageBuildCache is the step-by-step cache breakdown of one Dockerfile build.
+type UIImageBuildCache struct {
+	// The name of the image target.
+	Image string `json:"image" protobuf:"bytes,1,opt,name=image"`
+
+	// The steps of the Dockerfile, in order.
+	// +optional
+	Steps []UIBuildCacheStep `json:"steps,omitempty" protobuf:"bytes,2,rep,name=steps"`
+
+	// The Dockerfile stage that was built, if the image didn't use the last one.
+	// +optional
+	Target string `json:"target,omitempty" protobuf:"bytes,3,opt,name=target"`
+}
+
+// UIBuildCacheStep is one step of a Dockerfile build.
+type UIBuildCacheStep struct {
+	// Where the step falls in the build, e.g., "7/12" or "builder 4/7".
+	Position string `json:"position" protobuf:"bytes,1,opt,name=position"`
+
+	// The Dockerfile instruction, truncated.
+	Text string `json:"text" protobuf:"bytes,2,opt,name=text"`
+
+	// Whether the image builder reused the step from its cache.
+	// +optional
+	Cached bool `json:"cached,omitempty" protobuf:"varint,3,opt,name=cached"`
+
+	// How long the step ran, if it wasn't cached.
+	//
+	// BuildKit runs independent steps in parallel, so durations may overlap
+	// and don't add up to the build time.
+	// +optional
+	Duration metav1.Duration `json:"duration,omitempty" protobuf:"bytes,4,opt,name=duration"`
 }
 
 // UIResourceKubernetes contains status information specific to Kubernetes.
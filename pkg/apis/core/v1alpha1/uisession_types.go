@@ -170,6 +170,25 @@ type UISessionStatus struct {
 	// project in LocalStorage or other persistent storage.
 	// +optional
 	TiltfileKey string `json:"tiltfileKey,omitempty" protobuf:"bytes,11,opt,name=tiltfileKey"`
+
+	// The image registry that Tilt pushes images to, if any.
+	// +optional
+	ImageRegistry *UIImageRegistry `json:"imageRegistry,omitempty" protobuf:"bytes,13,opt,name=imageRegistry"`
+
+	// Sleeping is true when Tilt has gone to sleep after a period of inactivity.
+	// While asleep, Tilt doesn't stream logs or watch cluster events. Any request
+	// to the API server wakes it up.
+	// +optional
+	Sleeping bool `json:"sleeping,omitempty" protobuf:"varint,14,opt,name=sleeping"`
+
+	// How much memory Tilt is using, last we checked.
+	// +optional
+	MemoryUsage *UIMemoryUsage `json:"memoryUsage,omitempty" protobuf:"bytes,15,opt,name=memoryUsage"`
+
+	// The health of Tilt's connection to the Kubernetes cluster.
+	// Not set until Tilt has checked the connection.
+	// +optional
+	ClusterConnection *UIClusterConnection `json:"clusterConnection,omitempty" protobuf:"bytes,16,opt,name=clusterConnection"`
 }
 
 // UISession implements ObjectWithStatusSubResource interface.
@@ -224,6 +243,62 @@ type TiltBuild struct {
 	Dev bool `json:"dev,omitempty" protobuf:"varint,4,opt,name=dev"`
 }
 
+// The image registry that Tilt pushes images to.
+type UIImageRegistry struct {
+	// The host that Tilt pushes images to, e.g., localhost:5000.
+	Host string `json:"host" protobuf:"bytes,1,opt,name=host"`
+
+	// The host that the cluster pulls images from, if it's different from Host
+	// (e.g., registry:5000 for a KIND cluster with a local registry).
+	// +optional
+	HostFromCluster string `json:"hostFromCluster,omitempty" protobuf:"bytes,2,opt,name=hostFromCluster"`
+
+	// Where the registry came from: "configured" if the Tiltfile set it with
+	// default_registry(), or "discovered" if the cluster advertised a local registry.
+	Source string `json:"source" protobuf:"bytes,3,opt,name=source"`
+}
+
+// The health of Tilt's connection to the Kubernetes cluster.
+type UIClusterConnection struct {
+	// "connected", or "degraded" if Tilt has lost touch with the cluster,
+	// and pod and service status may be out of date.
+	Status string `json:"status" protobuf:"bytes,1,opt,name=status"`
+
+	// The error from the most recent failed check, when degraded.
+	// +optional
+	Error string `json:"error,omitempty" protobuf:"bytes,2,opt,name=error"`
+
+	// When the connection entered its current status.
+	// +optional
+	Since metav1.Time `json:"since,omitempty" protobuf:"bytes,3,opt,name=since"`
+}
+
+// How much memory Tilt is using.
+type UIMemoryUsage struct {
+	// When Tilt sampled its memory usage.
+	SampleTime metav1.MicroTime `json:"sampleTime" protobuf:"bytes,1,opt,name=sampleTime"`
+
+	// Bytes of memory Tilt has obtained from the OS and not returned.
+	TotalBytes int64 `json:"totalBytes" protobuf:"varint,2,opt,name=totalBytes"`
+
+	// Bytes of live heap objects.
+	// +optional
+	HeapBytes int64 `json:"heapBytes,omitempty" protobuf:"varint,3,opt,name=heapBytes"`
+
+	// Tilt's estimates of what's using the memory, largest first.
+	// +optional
+	Consumers []UIMemoryConsumer `json:"consumers,omitempty" protobuf:"bytes,4,rep,name=consumers"`
+}
+
+// An estimate of how much memory some of Tilt's data is using.
+type UIMemoryConsumer struct {
+	// What the data is, e.g., "logs" or "build history".
+	Name string `json:"name" protobuf:"bytes,1,opt,name=name"`
+
+	// The estimated bytes the data is using.
+	Bytes int64 `json:"bytes" protobuf:"varint,2,opt,name=bytes"`
+}
+
 // Information about how the Tilt binary handles updates.
 type VersionSettings struct {
 	// Whether version updates have been enabled/disabled from the Tiltfile.
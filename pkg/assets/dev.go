@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"html"
 	"io"
 	"net"
 	"net/http"
@@ -14,6 +15,7 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -39,14 +41,20 @@ const errorDivStyle = `
   flex-direction: column;
 `
 
+// How often we check whether the webpack dev server is accepting connections.
+const devServerHealthCheckInterval = time.Second
+
 type devServer struct {
-	http.Handler
 	packageDir PackageDir
 	port       model.WebDevPort
+	proxy      *httputil.ReverseProxy
+
+	healthCheckInterval time.Duration
 
 	mu       sync.Mutex
 	cmd      *exec.Cmd
 	disposed bool
+	healthy  bool
 }
 
 func NewDevServer(packageDir PackageDir, devPort model.WebDevPort) (*devServer, error) {
@@ -54,32 +62,141 @@ func NewDevServer(packageDir PackageDir, devPort model.WebDevPort) (*devServer,
 	if err != nil {
 		return nil, errors.Wrap(err, "NewDevServer")
 	}
-	handler := httputil.NewSingleHostReverseProxy(loc)
-	handler.ErrorHandler = func(writer http.ResponseWriter, request *http.Request, e error) {
-		extra := ""
-		extraHead := ""
-		if strings.Contains(e.Error(), "connection refused") {
-			extra = `"connection refused" expected on startup with --web-mode=local. Refreshing in a few seconds.`
-			extraHead = `<meta http-equiv="refresh" content="2">`
+
+	s := &devServer{
+		packageDir:          packageDir,
+		port:                devPort,
+		healthCheckInterval: devServerHealthCheckInterval,
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(loc)
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+
+		// The webpack dev server rejects requests (notably the hot-reload
+		// websocket) whose Host header doesn't match the host it's serving on.
+		req.Host = loc.Host
+	}
+	proxy.ErrorHandler = func(writer http.ResponseWriter, request *http.Request, e error) {
+		if isConnectionRefused(e) {
+			s.setHealthy(false)
+			s.serveUnavailable(writer, request)
+			return
 		}
+
+		writer.WriteHeader(http.StatusBadGateway)
 		response := fmt.Sprintf(`
 <html>
-  <head>%s</head>
   <body style="%s">
     <div style="%s">
       Error talking to asset server:<pre>%s</pre>
-      <br>%s
     </div>
   </body>
-</html>`, extraHead, errorBodyStyle, errorDivStyle, e.Error(), extra)
+</html>`, errorBodyStyle, errorDivStyle, html.EscapeString(e.Error()))
 		_, _ = writer.Write([]byte(response))
 	}
+	s.proxy = proxy
+	return s, nil
+}
+
+func (s *devServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.isHealthy() {
+		s.serveUnavailable(w, r)
+		return
+	}
+	s.proxy.ServeHTTP(w, r)
+}
+
+// Explains how to start the dev server, and refreshes until it comes up.
+func (s *devServer) serveUnavailable(w http.ResponseWriter, r *http.Request) {
+	msg := fmt.Sprintf("Tilt webpack dev server is not running on port %d", s.port)
+	if isWebsocketUpgrade(r) {
+		http.Error(w, msg, http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	response := fmt.Sprintf(`
+<html>
+  <head><meta http-equiv="refresh" content="2"></head>
+  <body style="%s">
+    <div style="%s">
+      %s.
+      <br><br>
+      With --web-mode=local, Tilt starts it automatically. This is expected for a few seconds on startup.
+      <br>
+      To start it yourself, run:
+      <pre>cd %s &amp;&amp; PORT=%d yarn start</pre>
+      or point Tilt at a different port with --webdev-port.
+      <br><br>
+      This page will refresh when the dev server comes up.
+    </div>
+  </body>
+</html>`, errorBodyStyle, errorDivStyle, html.EscapeString(msg),
+		html.EscapeString(s.packageDir.String()), s.port)
+	_, _ = w.Write([]byte(response))
+}
+
+func (s *devServer) isHealthy() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.healthy
+}
+
+// Returns true if the health changed.
+func (s *devServer) setHealthy(healthy bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := s.healthy != healthy
+	s.healthy = healthy
+	return changed
+}
+
+// Checks whether the dev server is accepting connections.
+func (s *devServer) checkHealth(ctx context.Context) {
+	d := net.Dialer{Timeout: s.healthCheckInterval}
+	conn, err := d.DialContext(ctx, "tcp", fmt.Sprintf("localhost:%d", s.port))
+	if err == nil {
+		_ = conn.Close()
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	healthy := err == nil
+	if !s.setHealthy(healthy) {
+		return
+	}
+	if healthy {
+		logger.Get(ctx).Debugf("Tilt webpack dev server is up on port %d", s.port)
+	} else {
+		logger.Get(ctx).Debugf("Tilt webpack dev server is down on port %d", s.port)
+	}
+}
+
+func (s *devServer) monitorHealth(ctx context.Context) {
+	ticker := time.NewTicker(s.healthCheckInterval)
+	defer ticker.Stop()
 
-	return &devServer{
-		Handler:    handler,
-		packageDir: packageDir,
-		port:       devPort,
-	}, nil
+	for {
+		s.checkHealth(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func isConnectionRefused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED) || strings.Contains(err.Error(), "connection refused")
+}
+
+func isWebsocketUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
 }
 
 func (s *devServer) TearDown(ctx context.Context) {
@@ -142,20 +259,30 @@ func (s *devServer) start(ctx context.Context, stdout, stderr io.Writer) (*exec.
 }
 
 func (s *devServer) Serve(ctx context.Context) error {
+	go s.monitorHealth(ctx)
+
 	// webpack binds to 0.0.0.0
 	l, err := net.Listen("tcp4", fmt.Sprintf(":%d", int(s.port)))
 	if err != nil {
-		return errors.Wrapf(err, "Cannot start Tilt dev webpack server. "+
-			"Maybe another process is already running on port %d? "+
+		// Maybe someone is already running the dev server by hand, in which
+		// case we proxy to it. If not, the health check will explain.
+		logger.Get(ctx).Infof("Port %d is already in use, so Tilt won't start its webpack dev server. "+
+			"Proxying to whatever is running on that port. "+
 			"Use --webdev-port to set a custom port", s.port)
+		<-ctx.Done()
+		return nil
 	}
 	_ = l.Close()
 
 	stdout := bytes.NewBuffer(nil)
 	stderr := bytes.NewBuffer(nil)
 	cmd, err := s.start(ctx, stdout, stderr)
-	if cmd == nil || err != nil {
-		return err
+	if err != nil {
+		return s.keepServingAfterError(ctx, err)
+	}
+	if cmd == nil {
+		// Torn down before we started.
+		return nil
 	}
 
 	err = cmd.Wait()
@@ -167,10 +294,20 @@ func (s *devServer) Serve(ctx context.Context) error {
 	if err != nil {
 		exitErr, isExit := err.(*exec.ExitError)
 		if isExit {
-			return errors.Wrapf(err, "Running dev web server. Stderr: %s", string(exitErr.Stderr))
+			return s.keepServingAfterError(ctx, errors.Wrapf(err, "Running dev web server. Stderr: %s", string(exitErr.Stderr)))
 		}
-		return errors.Wrap(err, "Running dev web server")
+		return s.keepServingAfterError(ctx, errors.Wrap(err, "Running dev web server"))
+	}
+	return s.keepServingAfterError(ctx, fmt.Errorf("Tilt dev server stopped unexpectedly\nStdout:\n%s\nStderr:\n%s\n",
+		stdout.String(), stderr.String()))
+}
+
+// If the dev server fails, Tilt itself keeps running. We log the error, and
+// keep explaining how to start the dev server until somebody does.
+func (s *devServer) keepServingAfterError(ctx context.Context, err error) error {
+	if err != nil {
+		logger.Get(ctx).Errorf("%v", err)
 	}
-	return fmt.Errorf("Tilt dev server stopped unexpectedly\nStdout:\n%s\nStderr:\n%s\n",
-		stdout.String(), stderr.String())
+	<-ctx.Done()
+	return nil
 }
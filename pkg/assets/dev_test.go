@@ -0,0 +1,130 @@
+package assets
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestDevServerUnavailableThenProxies(t *testing.T) {
+	f := newDevServerFixture(t)
+
+	res := f.get("/")
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+	assert.Contains(t, res.Body.String(), fmt.Sprintf("is not running on port %d", f.port))
+	assert.Contains(t, res.Body.String(), fmt.Sprintf("PORT=%d yarn start", f.port))
+	assert.Contains(t, res.Body.String(), `<meta http-equiv="refresh"`)
+
+	f.startBackend(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello from webpack"))
+	}))
+
+	require.Eventually(t, func() bool {
+		return f.get("/").Body.String() == "hello from webpack"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestDevServerGoesDown(t *testing.T) {
+	f := newDevServerFixture(t)
+	backend := f.startBackend(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello from webpack"))
+	}))
+
+	require.Eventually(t, func() bool {
+		return f.get("/").Body.String() == "hello from webpack"
+	}, time.Second, 10*time.Millisecond)
+
+	backend.Close()
+
+	// Even before the next health check, a refused connection
+	// switches us back to the diagnostic page.
+	res := f.get("/")
+	assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+	assert.Contains(t, res.Body.String(), "is not running on port")
+}
+
+func TestDevServerProxiesWebsockets(t *testing.T) {
+	f := newDevServerFixture(t)
+
+	var host string
+	upgrader := websocket.Upgrader{}
+	f.startBackend(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_ = conn.WriteMessage(websocket.TextMessage, []byte("reload"))
+	}))
+	require.Eventually(t, f.server.isHealthy, time.Second, 10*time.Millisecond)
+
+	tilt := httptest.NewServer(f.server)
+	defer tilt.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(tilt.URL, "http") + "/sockjs-node"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+
+	_, msg, err := conn.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "reload", string(msg))
+	assert.Equal(t, fmt.Sprintf("localhost:%d", f.port), host)
+}
+
+type devServerFixture struct {
+	t      *testing.T
+	ctx    context.Context
+	port   model.WebDevPort
+	server *devServer
+}
+
+func newDevServerFixture(t *testing.T) *devServerFixture {
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = logger.WithLogger(ctx, logger.NewTestLogger(os.Stdout))
+	t.Cleanup(cancel)
+
+	// Find a free port for the dev server to appear on later.
+	l, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	port := model.WebDevPort(l.Addr().(*net.TCPAddr).Port)
+	_ = l.Close()
+
+	s, err := NewDevServer(PackageDir("web"), port)
+	require.NoError(t, err)
+	s.healthCheckInterval = 10 * time.Millisecond
+	go s.monitorHealth(ctx)
+
+	return &devServerFixture{t: t, ctx: ctx, port: port, server: s}
+}
+
+func (f *devServerFixture) startBackend(h http.Handler) *httptest.Server {
+	l, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", f.port))
+	require.NoError(f.t, err)
+
+	backend := &httptest.Server{Listener: l, Config: &http.Server{Handler: h}}
+	backend.Start()
+	f.t.Cleanup(backend.Close)
+	return backend
+}
+
+func (f *devServerFixture) get(path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	res := httptest.NewRecorder()
+	f.server.ServeHTTP(res, req)
+	return res
+}
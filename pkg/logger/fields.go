@@ -8,9 +8,21 @@ type Fields map[string]string
 const FieldNameProgressID = "progressID"
 const FieldNameBuildEvent = "buildEvent"
 
+// Marks logs from a numbered run of a server.
+//
+// When a span with runNumber > 1 begins, the LogStore draws a divider
+// between it and the logs of the previous run.
+const FieldNameRunNumber = "runNumber"
+
 // Most progress lines are optional. For example, if a bunch
 // of little upload updates come in, it's ok to skip some.
 //
 // progressMustPrint="1" indicates that this line must appear in the
 // output - e.g., a line that communicates that the upload finished.
 const FieldNameProgressMustPrint = "progressMustPrint"
+
+// Local files referenced by a log line, as a JSON list,
+// so that the UI can link to them.
+//
+// See logstore.FileRef.
+const FieldNameFileRefs = "fileRefs"
@@ -0,0 +1,196 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// A LevelFormat tells us how to read log levels out of the output
+// of a user's process (a pod or a local command), so that their
+// output can be filtered the same way as Tilt's own logs.
+type LevelFormat string
+
+const (
+	// All output is logged at the default level.
+	LevelFormatNone LevelFormat = ""
+
+	// Lines that start with a level name, like "ERROR: disk full"
+	// or "[WARN] retrying".
+	LevelFormatPrefix LevelFormat = "prefix"
+
+	// Logrus text ("level=warning msg=...") or JSON ({"level":"warning",...}).
+	LevelFormatLogrus LevelFormat = "logrus"
+
+	// Zap JSON ({"level":"warn",...}).
+	LevelFormatZap LevelFormat = "zap"
+)
+
+var LevelFormats = []LevelFormat{LevelFormatPrefix, LevelFormatLogrus, LevelFormatZap}
+
+func ParseLevelFormat(s string) (LevelFormat, error) {
+	if s == "" {
+		return LevelFormatNone, nil
+	}
+	for _, f := range LevelFormats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	return LevelFormatNone, fmt.Errorf("Unrecognized log level format %q. Allowed values: %s", s, LevelFormats)
+}
+
+// Parses a level name, as used on the command line (e.g., --level=warn).
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return DebugLvl, nil
+	case "verbose":
+		return VerboseLvl, nil
+	case "info":
+		return InfoLvl, nil
+	case "warn", "warning":
+		return WarnLvl, nil
+	case "error":
+		return ErrorLvl, nil
+	}
+	return NoneLvl, fmt.Errorf("Unrecognized log level %q. Allowed values: debug, verbose, info, warn, error", s)
+}
+
+// Converts a level from its serialized ID (see ToProtoID).
+func LevelFromProtoID(id int32) Level {
+	for _, l := range []Level{DebugLvl, VerboseLvl, InfoLvl, WarnLvl, ErrorLvl} {
+		if l.id == id {
+			return l
+		}
+	}
+	return NoneLvl
+}
+
+// Maps the level names that common logging libraries use to our levels.
+func levelFromName(name string) (Level, bool) {
+	switch strings.ToLower(name) {
+	case "trace", "debug":
+		return DebugLvl, true
+	case "info", "notice":
+		return InfoLvl, true
+	case "warn", "warning":
+		return WarnLvl, true
+	case "err", "error", "dpanic", "panic", "fatal", "critical":
+		return ErrorLvl, true
+	}
+	return NoneLvl, false
+}
+
+// Only look for a level near the start of the line, after
+// a timestamp or other short preamble.
+var prefixLevelRe = regexp.MustCompile(`^.{0,40}?\b(TRACE|DEBUG|INFO|NOTICE|WARN|WARNING|ERR|ERROR|CRITICAL|FATAL|PANIC)\b`)
+
+var logrusTextLevelRe = regexp.MustCompile(`(?:^|\s)level=("?)(\w+)("?)`)
+
+// Reads the level of a single line of output.
+//
+// Returns false if the line doesn't have a level we recognize.
+func (f LevelFormat) LevelOf(line []byte) (Level, bool) {
+	switch f {
+	case LevelFormatPrefix:
+		m := prefixLevelRe.FindSubmatch(line)
+		if m == nil {
+			return NoneLvl, false
+		}
+		return levelFromName(string(m[1]))
+	case LevelFormatLogrus:
+		if lvl, ok := jsonLevelOf(line); ok {
+			return lvl, true
+		}
+		m := logrusTextLevelRe.FindSubmatch(line)
+		if m == nil {
+			return NoneLvl, false
+		}
+		return levelFromName(string(m[2]))
+	case LevelFormatZap:
+		return jsonLevelOf(line)
+	}
+	return NoneLvl, false
+}
+
+func jsonLevelOf(line []byte) (Level, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return NoneLvl, false
+	}
+
+	entry := struct {
+		Level string `json:"level"`
+	}{}
+	err := json.Unmarshal(line, &entry)
+	if err != nil {
+		return NoneLvl, false
+	}
+	return levelFromName(entry.Level)
+}
+
+// Returns a writer that logs each line of output at the level it was
+// logged at, according to the given format. Lines without a level, or
+// with a level we don't recognize, are logged at the default level.
+//
+// We don't want Tilt to swallow the user's output, so lines below the
+// logger's level are logged at the logger's level. Use a log filter
+// to hide them.
+//
+// A line that arrives in several writes keeps the level of its first write.
+func NewLevelFormatWriter(l Logger, format LevelFormat, defaultLevel Level) io.Writer {
+	if format == LevelFormatNone {
+		return l.Writer(defaultLevel)
+	}
+	return &levelFormatWriter{
+		l:            l,
+		format:       format,
+		defaultLevel: defaultLevel,
+		lineLevel:    defaultLevel,
+		atLineStart:  true,
+	}
+}
+
+type levelFormatWriter struct {
+	l            Logger
+	format       LevelFormat
+	defaultLevel Level
+
+	// The level of the line we're in the middle of.
+	lineLevel   Level
+	atLineStart bool
+}
+
+func (w *levelFormatWriter) Write(b []byte) (int, error) {
+	rest := b
+	for len(rest) > 0 {
+		end := bytes.IndexByte(rest, '\n') + 1
+		if end == 0 {
+			end = len(rest)
+		}
+		line := rest[:end]
+		rest = rest[end:]
+
+		if w.atLineStart {
+			w.lineLevel = w.levelOf(line)
+		}
+		w.l.Write(w.lineLevel, line)
+		w.atLineStart = line[len(line)-1] == '\n'
+	}
+	return len(b), nil
+}
+
+func (w *levelFormatWriter) levelOf(line []byte) Level {
+	level, ok := w.format.LevelOf(line)
+	if !ok {
+		return w.defaultLevel
+	}
+	if !w.l.Level().ShouldDisplay(level) {
+		return w.l.Level()
+	}
+	return level
+}
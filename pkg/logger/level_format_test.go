@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevelOfPrefix(t *testing.T) {
+	for _, tc := range []struct {
+		line     string
+		expected Level
+		ok       bool
+	}{
+		{"ERROR: disk full\n", ErrorLvl, true},
+		{"[WARN] retrying\n", WarnLvl, true},
+		{"2021-06-01T12:00:00Z WARNING slow request\n", WarnLvl, true},
+		{"INFO starting server\n", InfoLvl, true},
+		{"DEBUG cache miss\n", DebugLvl, true},
+		{"INFORMATION is not a level\n", NoneLvl, false},
+		{"hello world\n", NoneLvl, false},
+		{"a very long preamble that goes on and on and on ERROR\n", NoneLvl, false},
+	} {
+		t.Run(tc.line, func(t *testing.T) {
+			lvl, ok := LevelFormatPrefix.LevelOf([]byte(tc.line))
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.expected, lvl)
+		})
+	}
+}
+
+func TestLevelOfJSON(t *testing.T) {
+	for _, tc := range []struct {
+		format   LevelFormat
+		line     string
+		expected Level
+		ok       bool
+	}{
+		{LevelFormatZap, `{"level":"warn","ts":1622548800,"msg":"slow"}`, WarnLvl, true},
+		{LevelFormatZap, `{"level":"error","msg":"boom"}` + "\n", ErrorLvl, true},
+		{LevelFormatZap, `{"level":"dpanic","msg":"boom"}`, ErrorLvl, true},
+		{LevelFormatZap, `  {"level":"debug","msg":"hi"}`, DebugLvl, true},
+		{LevelFormatZap, `{"msg":"no level"}`, NoneLvl, false},
+		{LevelFormatZap, `{"level":"warn", truncated`, NoneLvl, false},
+		{LevelFormatZap, `level=warning msg=text`, NoneLvl, false},
+		{LevelFormatLogrus, `{"level":"warning","msg":"slow","time":"2021-06-01T12:00:00Z"}`, WarnLvl, true},
+		{LevelFormatLogrus, `{"level":"fatal","msg":"boom"}`, ErrorLvl, true},
+		{LevelFormatLogrus, `time="2021-06-01T12:00:00Z" level=warning msg="slow"`, WarnLvl, true},
+		{LevelFormatLogrus, `time="2021-06-01T12:00:00Z" level="error" msg="boom"`, ErrorLvl, true},
+		{LevelFormatLogrus, `loglevel=error`, NoneLvl, false},
+	} {
+		t.Run(fmt.Sprintf("%s %s", tc.format, tc.line), func(t *testing.T) {
+			lvl, ok := tc.format.LevelOf([]byte(tc.line))
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.expected, lvl)
+		})
+	}
+}
+
+func TestLevelFormatWriter(t *testing.T) {
+	var written []string
+	var levels []Level
+	l := NewFuncLogger(false, InfoLvl, func(level Level, fields Fields, b []byte) error {
+		written = append(written, string(b))
+		levels = append(levels, level)
+		return nil
+	})
+
+	w := NewLevelFormatWriter(l, LevelFormatZap, InfoLvl)
+	_, err := w.Write([]byte(`{"level":"error","msg":"a"}` + "\nplain\n" + `{"level":"warn",`))
+	require.NoError(t, err)
+	_, err = w.Write([]byte(`"msg":"b"}` + "\n" + `{"level":"debug","msg":"c"}` + "\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`{"level":"error","msg":"a"}` + "\n",
+		"plain\n",
+		`{"level":"warn",`,
+		`"msg":"b"}` + "\n",
+		`{"level":"debug","msg":"c"}` + "\n",
+	}, written)
+
+	// The split line keeps the level of its first write (which
+	// isn't valid JSON on its own), and debug lines are kept at
+	// the logger's level.
+	assert.Equal(t, []Level{ErrorLvl, InfoLvl, InfoLvl, InfoLvl, InfoLvl}, levels)
+}
+
+func TestParseLevel(t *testing.T) {
+	lvl, err := ParseLevel("WARNING")
+	require.NoError(t, err)
+	assert.Equal(t, WarnLvl, lvl)
+
+	_, err = ParseLevel("loud")
+	assert.EqualError(t, err, `Unrecognized log level "loud". Allowed values: debug, verbose, info, warn, error`)
+}
+
+func TestLevelFromProtoID(t *testing.T) {
+	for _, l := range []Level{DebugLvl, VerboseLvl, InfoLvl, WarnLvl, ErrorLvl} {
+		assert.Equal(t, l, LevelFromProtoID(l.ToProtoID()))
+	}
+	assert.Equal(t, NoneLvl, LevelFromProtoID(42))
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"time"
+)
+
+// The label on bootstrap tasks in the UI, which groups them together.
+const BootstrapLabel = "bootstrap"
+
+// A setup step that a project needs before anything else can work
+// (e.g., installing cert-manager, or creating a registry secret).
+//
+// Unlike a resource, a bootstrap task runs once per cluster, before the
+// first build. Tilt records the hash of the task when it succeeds, and
+// only runs it again when the task changes.
+type BootstrapTask struct {
+	Name string
+
+	// Exactly one of Cmd or YAML is set.
+	Cmd  Cmd
+	YAML string
+}
+
+// A digest of everything that the task does, so that we can tell when
+// the Tiltfile changes it.
+func (t BootstrapTask) Hash() string {
+	h := sha256.New()
+	for _, arg := range t.Cmd.Argv {
+		fmt.Fprintf(h, "argv:%d:%s\n", len(arg), arg)
+	}
+	fmt.Fprintf(h, "dir:%s\n", t.Cmd.Dir)
+	for _, e := range t.Cmd.Env {
+		fmt.Fprintf(h, "env:%d:%s\n", len(e), e)
+	}
+	fmt.Fprintf(h, "yaml:%d:%s\n", len(t.YAML), t.YAML)
+	return fmt.Sprintf("%x", h.Sum(nil))[:16]
+}
+
+// What happened to a bootstrap task on the last Tiltfile load.
+type BootstrapTaskStatus struct {
+	Name string
+
+	// When the task last succeeded, on this cluster or in this session.
+	// Zero if it never has.
+	LastRunTime time.Time
+
+	// True if the task was already done, so we didn't run it.
+	Skipped bool
+
+	// Non-empty if the task failed.
+	Error string
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBootstrapTaskHash(t *testing.T) {
+	a := BootstrapTask{Name: "certs", Cmd: ToHostCmd("install v1")}
+	b := BootstrapTask{Name: "certs", Cmd: ToHostCmd("install v2")}
+	assert.Equal(t, a.Hash(), a.Hash())
+	assert.NotEqual(t, a.Hash(), b.Hash())
+	assert.Len(t, a.Hash(), 16)
+
+	// Renaming a task doesn't change what it does.
+	renamed := a
+	renamed.Name = "tls"
+	assert.Equal(t, a.Hash(), renamed.Hash())
+
+	withEnv := a
+	withEnv.Cmd.Env = []string{"FOO=bar"}
+	assert.NotEqual(t, a.Hash(), withEnv.Hash())
+
+	yaml := BootstrapTask{Name: "certs", YAML: "kind: Secret\n"}
+	assert.NotEqual(t, a.Hash(), yaml.Hash())
+}
@@ -0,0 +1,134 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Phase names for an update that built and deployed new pods.
+const (
+	LatencyPhaseBuild   = "build"
+	LatencyPhaseDeploy  = "deploy"
+	LatencyPhaseRollout = "rollout"
+	LatencyPhaseReady   = "ready"
+)
+
+// Phase names for an update that live-updated running containers.
+const (
+	LatencyPhaseSync    = "sync"
+	LatencyPhaseRestart = "restart"
+)
+
+type LatencyPhase struct {
+	Name     string
+	Duration time.Duration
+}
+
+// BuildLatency is how long an update took to go from the file change that
+// triggered it to a ready resource, broken down by phase.
+type BuildLatency struct {
+	Total  time.Duration
+	Phases []LatencyPhase
+}
+
+func (l BuildLatency) Empty() bool {
+	return len(l.Phases) == 0
+}
+
+// A one-line summary, e.g., "updated in 14.2s: build 9.1s, deploy 1.2s, ready 3.9s"
+func (l BuildLatency) String() string {
+	phases := make([]string, len(l.Phases))
+	for i, p := range l.Phases {
+		phases[i] = fmt.Sprintf("%s %s", p.Name, formatLatency(p.Duration))
+	}
+	return fmt.Sprintf("updated in %s: %s", formatLatency(l.Total), strings.Join(phases, ", "))
+}
+
+func formatLatency(d time.Duration) string {
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
+
+// LatencyEvents are the timestamps of a single update, from the file change
+// that triggered it to the moment the resource was ready again.
+//
+// Every timestamp is read off Tilt's own clock when Tilt observed the event,
+// never off the cluster. Pod timestamps come from a different clock, and even a
+// small skew would swamp the phases we're trying to measure.
+type LatencyEvents struct {
+	// Whether the update live-updated containers rather than replacing pods.
+	LiveUpdate bool
+
+	// When Tilt saw the earliest file change consumed by the build.
+	// Zero if the build wasn't triggered by a file change.
+	Trigger time.Time
+
+	BuildStart  time.Time
+	ApplyStart  time.Time
+	ApplyFinish time.Time
+	BuildFinish time.Time
+
+	// When Tilt first saw a pod from the new deploy.
+	PodObserved time.Time
+
+	// When Tilt saw the resource become ready.
+	Ready time.Time
+}
+
+func (e LatencyEvents) Empty() bool {
+	return e.BuildStart.IsZero()
+}
+
+// Stitch the events together into a total and a per-phase breakdown.
+//
+// Phases whose events were never observed are left out. Events that were
+// observed out of order are clamped to the end of the previous phase.
+func (e LatencyEvents) Stitch() BuildLatency {
+	if e.Empty() {
+		return BuildLatency{}
+	}
+
+	start := e.BuildStart
+	if !e.Trigger.IsZero() && e.Trigger.Before(start) {
+		start = e.Trigger
+	}
+
+	s := latencyStitcher{start: start, cursor: start}
+	if e.LiveUpdate {
+		s.add(LatencyPhaseSync, e.BuildFinish)
+		s.add(LatencyPhaseRestart, e.Ready)
+		return s.latency
+	}
+
+	if e.ApplyStart.IsZero() {
+		s.add(LatencyPhaseBuild, e.BuildFinish)
+	} else {
+		s.add(LatencyPhaseBuild, e.ApplyStart)
+		applyFinish := e.ApplyFinish
+		if applyFinish.IsZero() {
+			applyFinish = e.BuildFinish
+		}
+		s.add(LatencyPhaseDeploy, applyFinish)
+	}
+	s.add(LatencyPhaseRollout, e.PodObserved)
+	s.add(LatencyPhaseReady, e.Ready)
+	return s.latency
+}
+
+type latencyStitcher struct {
+	start   time.Time
+	cursor  time.Time
+	latency BuildLatency
+}
+
+func (s *latencyStitcher) add(name string, end time.Time) {
+	if end.IsZero() {
+		return
+	}
+	if end.Before(s.cursor) {
+		end = s.cursor
+	}
+	s.latency.Phases = append(s.latency.Phases, LatencyPhase{Name: name, Duration: end.Sub(s.cursor)})
+	s.cursor = end
+	s.latency.Total = end.Sub(s.start)
+}
@@ -0,0 +1,147 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStitchLatency(t *testing.T) {
+	t0 := time.Unix(1600000000, 0)
+	at := func(d string) time.Time {
+		dur, err := time.ParseDuration(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return t0.Add(dur)
+	}
+	sec := func(s float64) time.Duration {
+		return time.Duration(s * float64(time.Second))
+	}
+
+	for _, tc := range []struct {
+		name     string
+		events   LatencyEvents
+		expected BuildLatency
+		summary  string
+	}{
+		{
+			name: "full deploy",
+			events: LatencyEvents{
+				Trigger:     at("0s"),
+				BuildStart:  at("200ms"),
+				ApplyStart:  at("9.1s"),
+				ApplyFinish: at("10.3s"),
+				BuildFinish: at("10.3s"),
+				PodObserved: at("11s"),
+				Ready:       at("14.2s"),
+			},
+			expected: BuildLatency{
+				Total: sec(14.2),
+				Phases: []LatencyPhase{
+					{LatencyPhaseBuild, sec(9.1)},
+					{LatencyPhaseDeploy, sec(1.2)},
+					{LatencyPhaseRollout, sec(0.7)},
+					{LatencyPhaseReady, sec(3.2)},
+				},
+			},
+			summary: "updated in 14.2s: build 9.1s, deploy 1.2s, rollout 0.7s, ready 3.2s",
+		},
+		{
+			name: "no file change starts at build",
+			events: LatencyEvents{
+				BuildStart:  at("1s"),
+				BuildFinish: at("3s"),
+				PodObserved: at("4s"),
+				Ready:       at("5s"),
+			},
+			expected: BuildLatency{
+				Total: sec(4),
+				Phases: []LatencyPhase{
+					{LatencyPhaseBuild, sec(2)},
+					{LatencyPhaseRollout, sec(1)},
+					{LatencyPhaseReady, sec(1)},
+				},
+			},
+			summary: "updated in 4.0s: build 2.0s, rollout 1.0s, ready 1.0s",
+		},
+		{
+			name: "not ready yet",
+			events: LatencyEvents{
+				Trigger:     at("0s"),
+				BuildStart:  at("1s"),
+				BuildFinish: at("3s"),
+			},
+			expected: BuildLatency{
+				Total:  sec(3),
+				Phases: []LatencyPhase{{LatencyPhaseBuild, sec(3)}},
+			},
+			summary: "updated in 3.0s: build 3.0s",
+		},
+		{
+			name: "out of order events are clamped",
+			events: LatencyEvents{
+				Trigger:     at("0s"),
+				BuildStart:  at("1s"),
+				BuildFinish: at("3s"),
+				PodObserved: at("2s"),
+				Ready:       at("4s"),
+			},
+			expected: BuildLatency{
+				Total: sec(4),
+				Phases: []LatencyPhase{
+					{LatencyPhaseBuild, sec(3)},
+					{LatencyPhaseRollout, 0},
+					{LatencyPhaseReady, sec(1)},
+				},
+			},
+			summary: "updated in 4.0s: build 3.0s, rollout 0.0s, ready 1.0s",
+		},
+		{
+			name: "live update",
+			events: LatencyEvents{
+				LiveUpdate:  true,
+				Trigger:     at("0s"),
+				BuildStart:  at("100ms"),
+				BuildFinish: at("1.5s"),
+			},
+			expected: BuildLatency{
+				Total:  sec(1.5),
+				Phases: []LatencyPhase{{LatencyPhaseSync, sec(1.5)}},
+			},
+			summary: "updated in 1.5s: sync 1.5s",
+		},
+		{
+			name: "live update with restart",
+			events: LatencyEvents{
+				LiveUpdate:  true,
+				Trigger:     at("0s"),
+				BuildStart:  at("100ms"),
+				BuildFinish: at("1.5s"),
+				Ready:       at("4s"),
+			},
+			expected: BuildLatency{
+				Total: sec(4),
+				Phases: []LatencyPhase{
+					{LatencyPhaseSync, sec(1.5)},
+					{LatencyPhaseRestart, sec(2.5)},
+				},
+			},
+			summary: "updated in 4.0s: sync 1.5s, restart 2.5s",
+		},
+		{
+			name:     "empty",
+			events:   LatencyEvents{},
+			expected: BuildLatency{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := tc.events.Stitch()
+			assert.Equal(t, tc.expected, actual)
+			if tc.summary != "" {
+				assert.Equal(t, tc.summary, actual.String())
+			}
+		})
+	}
+}
@@ -29,6 +29,12 @@ const (
 	// Building manifestA will mark imageB
 	// with changed dependencies.
 	BuildReasonFlagChangedDeps
+
+	// The user turned a debug override on or off.
+	BuildReasonFlagDebugOverride
+
+	// An environment variable that the Tiltfile read has changed.
+	BuildReasonFlagTiltfileEnv
 )
 
 func (r BuildReason) With(flag BuildReason) BuildReason {
@@ -62,12 +68,15 @@ var translations = map[BuildReason]string{
 	BuildReasonFlagTriggerUnknown: "Unknown Trigger",
 	BuildReasonFlagTiltfileArgs:   "Tilt Args",
 	BuildReasonFlagChangedDeps:    "Dependency Updated",
+	BuildReasonFlagDebugOverride:  "Debug Override",
+	BuildReasonFlagTiltfileEnv:    "Env Changed",
 }
 
 var triggerBuildReasons = []BuildReason{
 	BuildReasonFlagTriggerWeb,
 	BuildReasonFlagTriggerCLI,
 	BuildReasonFlagTriggerUnknown,
+	BuildReasonFlagDebugOverride,
 }
 
 var allBuildReasons = []BuildReason{
@@ -80,6 +89,8 @@ var allBuildReasons = []BuildReason{
 	BuildReasonFlagChangedDeps,
 	BuildReasonFlagTriggerUnknown,
 	BuildReasonFlagTiltfileArgs,
+	BuildReasonFlagDebugOverride,
+	BuildReasonFlagTiltfileEnv,
 }
 
 func (r BuildReason) String() string {
@@ -13,10 +13,20 @@ const BuildTypeLiveUpdate BuildType = "live-update"
 const BuildTypeDockerCompose BuildType = "docker-compose"
 const BuildTypeK8s BuildType = "k8s"
 const BuildTypeLocal BuildType = "local"
+const BuildTypeSync BuildType = "sync"
 
 type BuildRecord struct {
-	Edits      []string
-	Error      error
+	Edits []string
+	Error error
+
+	// True if the build stopped because its context was canceled or timed
+	// out (e.g., Tilt is shutting down, or the resource was disabled),
+	// rather than because it failed.
+	//
+	// Error still holds the cancellation error, so that a canceled build is
+	// never mistaken for a successful one.
+	Canceled bool
+
 	StartTime  time.Time
 	FinishTime time.Time // IsZero() == true for in-progress builds
 	Reason     BuildReason
@@ -29,6 +39,31 @@ type BuildRecord struct {
 	// We count the warnings by looking up all the logs with Level=WARNING
 	// in the logstore. We store this number separately for ease of use.
 	WarningCount int
+
+	// Bytes of build context sent to the image builder, summed across
+	// the images built. 0 if no Dockerfile builds ran.
+	ContextSize int64
+
+	// How each Dockerfile step used the build cache, for each image built.
+	// Empty if no Dockerfile builds ran.
+	ImageCache []ImageBuildCache
+
+	// How each image built got to the cluster.
+	ImagePushes []ImagePush
+
+	// How the Kubernetes objects this build applied differ from the
+	// previous apply. nil if the build didn't apply any, or it was the
+	// first apply.
+	ApplyDiff *K8sApplyDiff
+
+	// When Tilt saw the earliest file change that this build consumed.
+	// Zero if the build wasn't triggered by a file change.
+	TriggerTime time.Time
+
+	// How long it took from the triggering change until the resource was
+	// ready again. Filled in after the build finishes, once Tilt sees the
+	// resource become ready.
+	Latency BuildLatency
 }
 
 func (bs BuildRecord) Empty() bool {
@@ -53,3 +88,15 @@ func (r BuildRecord) HasBuildType(bt BuildType) bool {
 	}
 	return false
 }
+
+// How one image got to the cluster in a build.
+type ImagePush struct {
+	// The name of the image target we built.
+	Image string
+
+	// What we did with the image: push, load, or skip. Never auto.
+	Mode ImagePushMode
+
+	// Why we chose that mode.
+	Reason string
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// How long a step's Dockerfile instruction can get before we truncate it.
+const DockerBuildStepTextMax = 80
+
+// DockerBuildStep is one step of a Dockerfile build, and whether the
+// image builder was able to reuse it from the build cache.
+type DockerBuildStep struct {
+	// Where the step falls in the build, as the image builder numbers it,
+	// e.g., "7/12" or "builder 4/7" for a multi-stage BuildKit build.
+	Position string
+
+	// The Dockerfile instruction, e.g., "COPY go.sum .", truncated
+	// to DockerBuildStepTextMax.
+	Text string
+
+	Cached bool
+
+	// How long the step ran, for steps that weren't cached.
+	//
+	// BuildKit runs independent steps in parallel, so these are wall-clock
+	// durations that may overlap. They don't add up to the build time.
+	Duration time.Duration
+}
+
+// The instruction as we display it: on one line, and truncated.
+func DockerBuildStepText(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= DockerBuildStepTextMax {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:DockerBuildStepTextMax-1]) + "…"
+}
+
+// The base image is never "cached" in the way the other steps are,
+// so we don't count it as busting the cache.
+func (s DockerBuildStep) isBaseImage() bool {
+	return strings.HasPrefix(strings.ToUpper(s.Text), "FROM ")
+}
+
+type DockerBuildSteps []DockerBuildStep
+
+// Returns the first step that the image builder had to run, i.e., the step
+// that invalidated the cache. Returns false if every step was cached.
+func (s DockerBuildSteps) FirstExecuted() (DockerBuildStep, bool) {
+	for _, step := range s {
+		if !step.Cached && !step.isBaseImage() {
+			return step, true
+		}
+	}
+	return DockerBuildStep{}, false
+}
+
+// Counts the cached steps, not including the base image.
+func (s DockerBuildSteps) CachedCount() int {
+	count := 0
+	for _, step := range s {
+		if step.Cached && !step.isBaseImage() {
+			count++
+		}
+	}
+	return count
+}
+
+// A one-line summary, e.g.,
+// "cache busted at step 7/12: COPY go.sum . (6 of 11 steps cached)"
+func (s DockerBuildSteps) Summary() string {
+	if len(s) == 0 {
+		return ""
+	}
+
+	total := 0
+	for _, step := range s {
+		if !step.isBaseImage() {
+			total++
+		}
+	}
+
+	first, ok := s.FirstExecuted()
+	if !ok {
+		return "all steps cached"
+	}
+	return fmt.Sprintf("cache busted at step %s: %s (%d of %d steps cached)",
+		first.Position, first.Text, s.CachedCount(), total)
+}
+
+// ImageBuildCache is the step-by-step cache breakdown of one image build.
+type ImageBuildCache struct {
+	// The name of the image target we built.
+	Image string
+
+	// The Dockerfile stage we built, if the image didn't use the last one.
+	Target string
+
+	Steps DockerBuildSteps
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDockerBuildStepText(t *testing.T) {
+	assert.Equal(t, "RUN go build ./...", DockerBuildStepText("RUN  go build\n    ./..."))
+
+	long := DockerBuildStepText("RUN " + strings.Repeat("x", 100))
+	assert.Equal(t, DockerBuildStepTextMax, len([]rune(long)))
+	assert.True(t, strings.HasSuffix(long, "…"))
+}
+
+func TestDockerBuildStepsSummary(t *testing.T) {
+	steps := DockerBuildSteps{
+		{Position: "1/4", Text: "FROM golang:1.16", Duration: time.Second},
+		{Position: "2/4", Text: "COPY go.mod .", Cached: true},
+		{Position: "3/4", Text: "COPY go.sum .", Duration: time.Second},
+		{Position: "4/4", Text: "RUN go build", Duration: time.Minute},
+	}
+	first, ok := steps.FirstExecuted()
+	assert.True(t, ok)
+	assert.Equal(t, "3/4", first.Position)
+	assert.Equal(t, "cache busted at step 3/4: COPY go.sum . (1 of 3 steps cached)", steps.Summary())
+
+	cached := DockerBuildSteps{
+		{Position: "1/2", Text: "FROM alpine"},
+		{Position: "2/2", Text: "COPY . /src", Cached: true},
+	}
+	_, ok = cached.FirstExecuted()
+	assert.False(t, ok)
+	assert.Equal(t, "all steps cached", cached.Summary())
+
+	assert.Equal(t, "", DockerBuildSteps{}.Summary())
+}
@@ -31,6 +31,10 @@ type DockerComposeTarget struct {
 
 	publishedPorts []int
 
+	// Services from depends_on with condition: service_healthy.
+	// This service doesn't start until their healthchecks pass.
+	healthyDeps []ManifestName
+
 	Links []Link
 }
 
@@ -64,6 +68,15 @@ func (t DockerComposeTarget) PublishedPorts() []int {
 	return append([]int{}, t.publishedPorts...)
 }
 
+func (t DockerComposeTarget) HealthyDependencies() []ManifestName {
+	return append([]ManifestName{}, t.healthyDeps...)
+}
+
+func (t DockerComposeTarget) WithHealthyDependencies(deps []ManifestName) DockerComposeTarget {
+	t.healthyDeps = deps
+	return t
+}
+
 func (t DockerComposeTarget) WithLinks(links []Link) DockerComposeTarget {
 	t.Links = links
 	return t
@@ -138,4 +151,15 @@ func (dc DockerComposeTarget) Validate() error {
 	return nil
 }
 
+// Checks that images built for a Docker Compose service only use
+// build options that Docker Compose supports.
+func ValidateDockerComposeImageTargets(iTargets []ImageTarget) error {
+	for _, iTarg := range iTargets {
+		if iTarg.OverrideCommand != nil {
+			return fmt.Errorf("docker_build/custom_build.entrypoint not supported for Docker Compose resources")
+		}
+	}
+	return nil
+}
+
 var _ TargetSpec = DockerComposeTarget{}
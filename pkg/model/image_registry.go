@@ -0,0 +1,44 @@
+package model
+
+import (
+	"fmt"
+
+	"github.com/tilt-dev/tilt/internal/container"
+)
+
+// Where the image registry that Tilt pushes to came from.
+type ImageRegistrySource string
+
+const (
+	ImageRegistrySourceNone ImageRegistrySource = ""
+
+	// The Tiltfile set it with default_registry().
+	ImageRegistrySourceConfigured ImageRegistrySource = "configured"
+
+	// The cluster advertised a local registry, e.g., with the
+	// local-registry-hosting ConfigMap in kube-public.
+	ImageRegistrySourceDiscovered ImageRegistrySource = "discovered"
+)
+
+// The image registry that Tilt pushes images to and rewrites image
+// references for.
+type ImageRegistry struct {
+	Registry container.Registry
+	Source   ImageRegistrySource
+}
+
+func (r ImageRegistry) Empty() bool {
+	return r.Registry.Empty()
+}
+
+// e.g., "localhost:5000 (cluster pulls from registry:5000) [discovered]"
+func (r ImageRegistry) String() string {
+	if r.Empty() {
+		return "none"
+	}
+	result := r.Registry.Host
+	if fromCluster := r.Registry.HostFromCluster(); fromCluster != r.Registry.Host {
+		result = fmt.Sprintf("%s (cluster pulls from %s)", result, fromCluster)
+	}
+	return fmt.Sprintf("%s [%s]", result, r.Source)
+}
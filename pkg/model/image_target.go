@@ -32,6 +32,14 @@ type ImageTarget struct {
 	// firm up how images work in the apiserver.
 	IsLiveUpdateOnly bool
 
+	// How to get the image to the cluster once it's built.
+	// Empty means ImagePushModeAuto.
+	PushMode ImagePushMode
+
+	// Watch this image's files even in modes that normally don't
+	// watch files (like `tilt ci`), so that its live updates run.
+	WatchInCI bool
+
 	// TODO(nick): It might eventually make sense to represent
 	// Tiltfile as a separate nodes in the build graph, rather
 	// than duplicating it in each ImageTarget.
@@ -236,6 +244,11 @@ func (i ImageTarget) TiltFilename() string {
 	return i.tiltFilename
 }
 
+func (i ImageTarget) WithWatchInCI(watchInCI bool) ImageTarget {
+	i.WatchInCI = watchInCI
+	return i
+}
+
 func (i ImageTarget) WithTiltFilename(f string) ImageTarget {
 	i.tiltFilename = f
 	return i
@@ -261,6 +274,17 @@ type DockerBuild struct {
 	BuildArgs   DockerBuildArgs
 	TargetStage DockerBuildTarget
 
+	// The file the Dockerfile was read from. Empty if the Tiltfile
+	// passed the contents inline.
+	DockerfilePath string
+
+	// The build args that the Dockerfile declares, with the values they
+	// resolve to after defaults. Nil if we couldn't parse the Dockerfile.
+	//
+	// Only these can change the image, so they decide whether a change to
+	// BuildArgs should trigger a rebuild.
+	ResolvedBuildArgs DockerBuildArgs
+
 	// Pass SSH secrets to docker so it can clone private repos.
 	// https://docs.docker.com/develop/develop-images/build_enhancements/#using-ssh-to-access-private-data-in-builds
 	SSHSpecs []string
@@ -286,10 +310,23 @@ type DockerBuild struct {
 	// Named 'tag' for consistency with how it's used throughout the docker API,
 	// even though this is really more like a reference.NamedTagged
 	ExtraTags []string
+
+	// Tag the image with a digest of its build inputs, rather than the digest
+	// of the image. If an image with that tag already exists, Tilt skips the build.
+	ContentTag bool
 }
 
 func (DockerBuild) buildDetails() {}
 
+// The build args that decide whether the image needs to be rebuilt: the
+// resolved args if we have them, or else all of BuildArgs.
+func (db DockerBuild) EffectiveBuildArgs() DockerBuildArgs {
+	if db.ResolvedBuildArgs != nil {
+		return db.ResolvedBuildArgs
+	}
+	return db.BuildArgs
+}
+
 type DockerBuildTarget string
 
 func (s DockerBuildTarget) String() string { return string(s) }
@@ -312,10 +349,93 @@ type CustomBuild struct {
 	// We expect the custom build script to print the image ref to this file,
 	// so that Tilt can read it out when we're done.
 	OutputsImageRefTo string
+
+	// Controls which environment variables the command sees.
+	Env CustomBuildEnv
+
+	// Where the command puts the image. Defaults to Docker.
+	ImageStore ImageStore
+
+	// The platform of the cluster the image will run on, like "linux/amd64".
+	// Set by the engine before the build, and exported as $EXPECTED_PLATFORM.
+	Platform string
+
+	// Tag the image with a digest of the command and its deps. The engine
+	// computes the tag and passes it in Tag, and skips the command entirely
+	// if an image with that tag already exists.
+	ContentTag bool
 }
 
 func (CustomBuild) buildDetails() {}
 
+// The image store that a custom build puts its image in.
+type ImageStore string
+
+const (
+	ImageStoreDocker ImageStore = "docker"
+
+	// For builds that use nerdctl or buildctl, which put images
+	// straight in containerd.
+	ImageStoreContainerd ImageStore = "containerd"
+)
+
+// How Tilt gets a built image to the cluster.
+type ImagePushMode string
+
+const (
+	// Decide from the cluster: skip the push if the cluster can already see
+	// the image, load it into KIND clusters without a registry, and push otherwise.
+	ImagePushModeAuto ImagePushMode = "auto"
+
+	// Always push to the image's registry, even if the cluster could see the
+	// image without it.
+	ImagePushModePush ImagePushMode = "push"
+
+	// Load the image onto the cluster's nodes. Only KIND clusters support this.
+	ImagePushModeLoad ImagePushMode = "load"
+
+	// Never push. For images that only run locally, or clusters that
+	// share the local Docker daemon.
+	ImagePushModeSkip ImagePushMode = "skip"
+)
+
+var AllImagePushModes = []ImagePushMode{
+	ImagePushModeAuto,
+	ImagePushModePush,
+	ImagePushModeLoad,
+	ImagePushModeSkip,
+}
+
+func (m ImagePushMode) IsAuto() bool {
+	return m == "" || m == ImagePushModeAuto
+}
+
+func (cb CustomBuild) UsesContainerd() bool {
+	return cb.ImageStore == ImageStoreContainerd
+}
+
+// By default, a custom build command inherits Tilt's environment.
+//
+// Builds that need to be reproducible across machines can isolate the
+// command, so that it only sees PATH, the variables Tilt provides
+// (like EXPECTED_REF), and the variables declared in the Tiltfile.
+type CustomBuildEnv struct {
+	// Start from a clean environment rather than Tilt's environment.
+	Isolated bool
+
+	// When isolated, variables to copy from Tilt's environment.
+	Allow []string
+
+	// When not isolated, variables to remove from Tilt's environment.
+	Deny []string
+
+	// Variables declared in the Tiltfile, in KEY=VALUE form.
+	Vars []string
+
+	// Variables whose values should never be printed.
+	Secrets []string
+}
+
 func (cb CustomBuild) WithTag(t string) CustomBuild {
 	cb.Tag = t
 	return cb
@@ -0,0 +1,53 @@
+package model
+
+// What changed in the Kubernetes objects a build applied, compared to the
+// objects the previous apply sent.
+type K8sApplyDiff struct {
+	// The objects that were added, removed, or changed, in the order we applied them.
+	// Removed objects come last.
+	Objects []K8sObjectDiff
+
+	// How many objects we applied without changes.
+	Unchanged int
+
+	// How many field changes we left out because the diff was too big.
+	// 0 if the diff is complete.
+	TruncatedFields int
+}
+
+type K8sObjectDiffStatus string
+
+const (
+	K8sObjectAdded   K8sObjectDiffStatus = "added"
+	K8sObjectRemoved K8sObjectDiffStatus = "removed"
+	K8sObjectChanged K8sObjectDiffStatus = "changed"
+)
+
+type K8sObjectDiff struct {
+	// The kind and name of the object, e.g., Deployment/fe
+	Name   string
+	Status K8sObjectDiffStatus
+
+	// For changed objects, the fields that changed. May be empty if the
+	// diff was truncated before it got to this object.
+	Fields []K8sFieldDiff
+
+	// True if the object was too big to keep, so we only know that it changed.
+	TooLarge bool
+}
+
+// A field that changed between applies.
+//
+// Old and New are short, human-readable renderings of the values, not the
+// values themselves. Old is empty if the field was added, and New is empty
+// if it was removed.
+type K8sFieldDiff struct {
+	// A dotted path to the field, like spec.template.spec.containers[0].image
+	Path string
+	Old  string
+	New  string
+}
+
+func (d K8sApplyDiff) Empty() bool {
+	return len(d.Objects) == 0
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Temporary changes to a resource's main container that make it easier to
+// attach a debugger (e.g., running the binary under `dlv exec`).
+//
+// The override is only applied while it's enabled, and disabling it
+// restores the original spec.
+type K8sDebugOverride struct {
+	// The name of the container to change. If empty, we change the first
+	// container of each pod template.
+	Container string
+
+	// If non-nil, replaces the container command.
+	Command []string
+
+	// If non-nil, replaces the container args.
+	Args []string
+
+	// Container ports to add (e.g., for the debugger to listen on).
+	Ports []int32
+
+	// If true, removes the container's readiness probe, so that the pod
+	// doesn't get killed or taken out of rotation while paused at a breakpoint.
+	RemoveReadinessProbe bool
+}
+
+func (o K8sDebugOverride) String() string {
+	var parts []string
+	if o.Container != "" {
+		parts = append(parts, fmt.Sprintf("container=%s", o.Container))
+	}
+	if o.Command != nil {
+		parts = append(parts, fmt.Sprintf("command=%q", o.Command))
+	}
+	if o.Args != nil {
+		parts = append(parts, fmt.Sprintf("args=%q", o.Args))
+	}
+	if len(o.Ports) > 0 {
+		parts = append(parts, fmt.Sprintf("ports=%v", o.Ports))
+	}
+	if o.RemoveReadinessProbe {
+		parts = append(parts, "readiness probe removed")
+	}
+	return strings.Join(parts, ", ")
+}
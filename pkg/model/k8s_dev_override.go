@@ -0,0 +1,82 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// Dev-only changes to a resource's workloads, for when the checked-in YAML
+// asks for more than a local cluster has (e.g., production resource requests
+// on a kind cluster).
+//
+// The overrides are applied to the YAML before it's deployed, so removing
+// them from the Tiltfile restores the original spec on the next apply.
+type K8sDevOverride struct {
+	// If non-nil, replaces the replica count of workloads that have one.
+	Replicas *int32
+
+	// Added to each pod's node selector. Replaces labels with the same key.
+	NodeSelector map[string]string
+
+	// Added to each pod's tolerations, unless the pod already has them.
+	Tolerations []v1.Toleration
+
+	// Merged into the requests and limits of every container.
+	Resources *v1.ResourceRequirements
+
+	// Merged into the requests and limits of the containers with these names.
+	// Takes precedence over Resources.
+	ContainerResources map[string]v1.ResourceRequirements
+}
+
+func (o K8sDevOverride) String() string {
+	var parts []string
+	if o.Replicas != nil {
+		parts = append(parts, fmt.Sprintf("replicas=%d", *o.Replicas))
+	}
+	if len(o.NodeSelector) > 0 {
+		var labels []string
+		for k, v := range o.NodeSelector {
+			labels = append(labels, fmt.Sprintf("%s=%s", k, v))
+		}
+		sort.Strings(labels)
+		parts = append(parts, fmt.Sprintf("nodeSelector=[%s]", strings.Join(labels, ",")))
+	}
+	if len(o.Tolerations) > 0 {
+		parts = append(parts, fmt.Sprintf("tolerations=%d", len(o.Tolerations)))
+	}
+	if o.Resources != nil {
+		parts = append(parts, fmt.Sprintf("resources=%s", resourceRequirementsString(*o.Resources)))
+	}
+	var names []string
+	for name := range o.ContainerResources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("resources[%s]=%s", name, resourceRequirementsString(o.ContainerResources[name])))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func resourceRequirementsString(r v1.ResourceRequirements) string {
+	var parts []string
+	for _, list := range []struct {
+		name string
+		rl   v1.ResourceList
+	}{{"requests", r.Requests}, {"limits", r.Limits}} {
+		var names []string
+		for name := range list.rl {
+			names = append(names, string(name))
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			q := list.rl[v1.ResourceName(name)]
+			parts = append(parts, fmt.Sprintf("%s.%s=%s", list.name, name, q.String()))
+		}
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
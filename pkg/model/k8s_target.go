@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"reflect"
 
+	v1 "k8s.io/api/core/v1"
+
 	"github.com/tilt-dev/tilt/internal/sliceutils"
 	"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1"
 )
@@ -45,6 +47,13 @@ type K8sTarget struct {
 	// in addition to any port forwards/LB endpoints)
 	Links []Link
 
+	// A debug override declared in the Tiltfile, which users can turn on and off.
+	DebugOverride *K8sDebugOverride
+
+	// Objects this resource declares, but which another resource applies.
+	// They're not in the YAML, so that they're only applied once per update.
+	SharedObjects []SharedK8sObject
+
 	imageDeps []TargetID
 
 	// pathDependencies are files required by this target.
@@ -158,6 +167,24 @@ func (k8s K8sTarget) WithPathDependencies(paths []string, localRepos []LocalGitR
 	return k8s
 }
 
+func (k8s K8sTarget) WithDebugOverride(o *K8sDebugOverride) K8sTarget {
+	k8s.DebugOverride = o
+	return k8s
+}
+
+func (k8s K8sTarget) WithSharedObjects(objs []SharedK8sObject) K8sTarget {
+	k8s.SharedObjects = objs
+	return k8s
+}
+
+func (k8s K8sTarget) SharedObjectRefs() []v1.ObjectReference {
+	refs := make([]v1.ObjectReference, len(k8s.SharedObjects))
+	for i, obj := range k8s.SharedObjects {
+		refs[i] = obj.Ref
+	}
+	return refs
+}
+
 func (k8s K8sTarget) WithRefInjectCounts(ric map[string]int) K8sTarget {
 	k8s.refInjectCounts = ric
 	return k8s
@@ -165,6 +192,15 @@ func (k8s K8sTarget) WithRefInjectCounts(ric map[string]int) K8sTarget {
 
 var _ TargetSpec = K8sTarget{}
 
+// A Kubernetes object that more than one resource declares, e.g., a ConfigMap
+// that two servers read.
+type SharedK8sObject struct {
+	Ref v1.ObjectReference
+
+	// The resource that applies the object.
+	Owner ManifestName
+}
+
 func ToLiveUpdateOnlyMap(imageTargets []ImageTarget) map[TargetID]bool {
 	result := make(map[TargetID]bool, len(imageTargets))
 	for _, image := range imageTargets {
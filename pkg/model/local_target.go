@@ -15,6 +15,7 @@ type LocalTarget struct {
 	ServeCmd Cmd      // e.g. `python main.py`
 	Links    []Link   // zero+ links assoc'd with this resource (to be displayed in UIs)
 	Deps     []string // a list of ABSOLUTE file paths that are dependencies of this target
+	Outputs  []string // a list of ABSOLUTE file paths that the update cmd writes
 	ignores  []Dockerignore
 
 	repos []LocalGitRepo
@@ -29,8 +30,49 @@ type LocalTarget struct {
 
 	ReadinessProbe *v1alpha1.Probe
 
+	// Restarts the serve_cmd when it fails.
+	LivenessProbe *v1alpha1.Probe
+
 	// Move this to CmdServerSpec when we move CmdServer to API
 	ServeCmdDisableSource *v1alpha1.DisableSource
+
+	// Inputs of the serve_cmd that the server can pick up without a restart.
+	ServeReload *ServeReload
+
+	// How to read log levels from the output of the update and serve cmds.
+	LogLevelFormat string
+}
+
+// The signals a serve_cmd may ask for on reload.
+var ServeReloadSignals = []string{"SIGHUP", "SIGUSR1", "SIGUSR2"}
+
+func IsServeReloadSignal(signal string) bool {
+	for _, s := range ServeReloadSignals {
+		if s == signal {
+			return true
+		}
+	}
+	return false
+}
+
+// Describes how a running serve_cmd reloads its config in place.
+//
+// When only these inputs change, Tilt signals the server instead of
+// restarting it.
+type ServeReload struct {
+	// Names of serve_env vars that the server can reload.
+	Env []string
+
+	// ABSOLUTE paths of config files that the server re-reads on reload.
+	Files []string
+
+	// An ABSOLUTE path to write the reloadable env vars to before signaling,
+	// because a running process can't see changes to its own environment.
+	// Optional.
+	EnvFile string
+
+	// The signal to send, e.g., SIGHUP.
+	Signal string
 }
 
 var _ TargetSpec = LocalTarget{}
@@ -79,6 +121,11 @@ func (lt LocalTarget) WithIgnores(ignores []Dockerignore) LocalTarget {
 	return lt
 }
 
+func (lt LocalTarget) WithOutputs(outputs []string) LocalTarget {
+	lt.Outputs = outputs
+	return lt
+}
+
 func (lt LocalTarget) WithLinks(links []Link) LocalTarget {
 	lt.Links = links
 	return lt
@@ -99,6 +146,26 @@ func (lt LocalTarget) WithReadinessProbe(probeSpec *v1alpha1.Probe) LocalTarget
 	return lt
 }
 
+func (lt LocalTarget) WithLivenessProbe(probeSpec *v1alpha1.Probe) LocalTarget {
+	lt.LivenessProbe = probeSpec
+	return lt
+}
+
+func (lt LocalTarget) WithServeReload(reload *ServeReload) LocalTarget {
+	lt.ServeReload = reload
+	return lt
+}
+
+func (lt LocalTarget) WithLogLevelFormat(format string) LocalTarget {
+	lt.LogLevelFormat = format
+	if lt.UpdateCmdSpec != nil {
+		spec := lt.UpdateCmdSpec.DeepCopy()
+		spec.LogLevelFormat = format
+		lt.UpdateCmdSpec = spec
+	}
+	return lt
+}
+
 func (lt LocalTarget) ID() TargetID {
 	return TargetID{
 		Name: lt.Name,
@@ -117,6 +184,10 @@ func (lt LocalTarget) Validate() error {
 	if !lt.ServeCmd.Empty() && lt.ServeCmd.Dir == "" {
 		return fmt.Errorf("[Validate] LocalTarget serve_cmd missing workdir")
 	}
+	if lt.ServeReload != nil && !IsServeReloadSignal(lt.ServeReload.Signal) {
+		return fmt.Errorf("[Validate] LocalTarget serve reload signal %q must be one of %s",
+			lt.ServeReload.Signal, sliceutils.QuotedStringList(ServeReloadSignals))
+	}
 	return nil
 }
 
@@ -0,0 +1,238 @@
+package logstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tilt-dev/tilt/pkg/logger"
+)
+
+// Maps a path in a container back to the local files it came from,
+// e.g., from a live-update sync or a Dockerfile COPY.
+type PathMapping struct {
+	// An absolute path in the container.
+	ContainerPath string
+
+	// An absolute local path.
+	LocalPath string
+}
+
+// A reference to a local file found in the logs, for the UI and editor
+// integrations to link to.
+type FileRef struct {
+	// The path as it appeared in the logs, e.g., /app/src/main.go
+	ContainerPath string `json:"containerPath"`
+
+	// The local path, relative to the workspace if it's inside it.
+	Path string `json:"path"`
+
+	// The absolute local path.
+	LocalPath string `json:"localPath"`
+
+	Line   int `json:"line"`
+	Column int `json:"column,omitempty"`
+}
+
+// Finds container paths in a resource's logs that we know the local files for,
+// and annotates them with the local path.
+//
+// e.g., `/app/src/main.go:12:5: undefined: foo` becomes
+// `/app/src/main.go:12:5 [src/main.go:12:5]: undefined: foo`.
+//
+// Only absolute paths under a mapped container directory are annotated,
+// and a line is only matched against the patterns if it contains one of those
+// directories.
+type PathMapper struct {
+	workspace string
+
+	// Sorted longest container path first, so that the most specific
+	// mapping wins.
+	mappings []PathMapping
+}
+
+// Matches Go and Node stack traces and compiler errors, e.g.,
+// `/app/main.go:12:5: undefined: foo`, `/app/main.go:12 +0x1d`, and
+// `at Object.<anonymous> (/app/index.js:3:9)`.
+var lineColRefRe = regexp.MustCompile(`(/[\w.@+\-/]+):(\d+)(?::(\d+))?`)
+
+// Matches Python stack traces, e.g.,
+// `File "/app/main.py", line 3, in <module>`.
+var pythonRefRe = regexp.MustCompile(`File "(/[^"]+)", line (\d+)`)
+
+func NewPathMapper(workspace string, mappings []PathMapping) *PathMapper {
+	var valid []PathMapping
+	for _, m := range mappings {
+		if !path.IsAbs(m.ContainerPath) || m.LocalPath == "" {
+			continue
+		}
+		m.ContainerPath = path.Clean(m.ContainerPath)
+		if m.ContainerPath == "/" {
+			// Too broad to tell container paths from anything else.
+			continue
+		}
+		valid = append(valid, m)
+	}
+	sort.SliceStable(valid, func(i, j int) bool {
+		return len(valid[i].ContainerPath) > len(valid[j].ContainerPath)
+	})
+	return &PathMapper{workspace: workspace, mappings: valid}
+}
+
+// Returns the line with the file references annotated, and the references.
+//
+// Returns the line unchanged if it doesn't have any references we can map.
+func (m *PathMapper) Annotate(line []byte) ([]byte, []FileRef) {
+	if m == nil || !m.mightContainRef(line) {
+		return line, nil
+	}
+
+	type match struct {
+		end int // where the annotation goes
+		ref FileRef
+	}
+	var matches []match
+	for _, loc := range pythonRefRe.FindAllSubmatchIndex(line, -1) {
+		ref, ok := m.fileRef(line, loc[2], loc[3], line[loc[4]:loc[5]], nil)
+		if ok {
+			matches = append(matches, match{end: loc[1], ref: ref})
+		}
+	}
+	if len(matches) == 0 {
+		for _, loc := range lineColRefRe.FindAllSubmatchIndex(line, -1) {
+			var col []byte
+			if loc[6] != -1 {
+				col = line[loc[6]:loc[7]]
+			}
+			ref, ok := m.fileRef(line, loc[2], loc[3], line[loc[4]:loc[5]], col)
+			if ok {
+				matches = append(matches, match{end: loc[1], ref: ref})
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return line, nil
+	}
+
+	result := make([]byte, 0, len(line)+32*len(matches))
+	refs := make([]FileRef, 0, len(matches))
+	last := 0
+	for _, match := range matches {
+		result = append(result, line[last:match.end]...)
+		result = append(result, fmt.Sprintf(" [%s]", match.ref.location())...)
+		refs = append(refs, match.ref)
+		last = match.end
+	}
+	result = append(result, line[last:]...)
+	return result, refs
+}
+
+func (m *PathMapper) mightContainRef(line []byte) bool {
+	for _, mapping := range m.mappings {
+		if bytes.Contains(line, []byte(mapping.ContainerPath)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *PathMapper) fileRef(line []byte, start, end int, lineNum []byte, col []byte) (FileRef, bool) {
+	// Make sure we matched the whole path, and not the tail of a relative path
+	// or a URL.
+	if start > 0 {
+		prev := line[start-1]
+		if prev == '/' || prev == '.' || prev == '_' || prev == '-' ||
+			('a' <= prev && prev <= 'z') || ('A' <= prev && prev <= 'Z') || ('0' <= prev && prev <= '9') {
+			return FileRef{}, false
+		}
+	}
+
+	containerPath := string(line[start:end])
+	localPath, ok := m.localPath(containerPath)
+	if !ok {
+		return FileRef{}, false
+	}
+
+	ref := FileRef{
+		ContainerPath: containerPath,
+		Path:          m.displayPath(localPath),
+		LocalPath:     localPath,
+	}
+	ref.Line, _ = strconv.Atoi(string(lineNum))
+	if col != nil {
+		ref.Column, _ = strconv.Atoi(string(col))
+	}
+	return ref, ref.Line > 0
+}
+
+func (m *PathMapper) localPath(containerPath string) (string, bool) {
+	for _, mapping := range m.mappings {
+		if containerPath == mapping.ContainerPath {
+			return mapping.LocalPath, true
+		}
+		if strings.HasPrefix(containerPath, mapping.ContainerPath+"/") {
+			rel := strings.TrimPrefix(containerPath, mapping.ContainerPath+"/")
+			return filepath.Join(mapping.LocalPath, filepath.FromSlash(rel)), true
+		}
+	}
+	return "", false
+}
+
+func (m *PathMapper) displayPath(localPath string) string {
+	if m.workspace == "" {
+		return localPath
+	}
+	rel, err := filepath.Rel(m.workspace, localPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return localPath
+	}
+	return filepath.ToSlash(rel)
+}
+
+func (r FileRef) location() string {
+	if r.Column > 0 {
+		return fmt.Sprintf("%s:%d:%d", r.Path, r.Line, r.Column)
+	}
+	return fmt.Sprintf("%s:%d", r.Path, r.Line)
+}
+
+func encodeFileRefs(refs []FileRef) string {
+	b, err := json.Marshal(refs)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// Decodes the file references that the LogStore attached to a log segment.
+func DecodeFileRefs(s string) ([]FileRef, error) {
+	var refs []FileRef
+	err := json.Unmarshal([]byte(s), &refs)
+	return refs, err
+}
+
+// Annotates the file references in each segment, and returns the new length
+// of the segments.
+func (m *PathMapper) annotateSegments(segments []LogSegment) int {
+	total := 0
+	for i, segment := range segments {
+		text, refs := m.Annotate(segment.Text)
+		if len(refs) > 0 {
+			fields := make(logger.Fields, len(segment.Fields)+1)
+			for k, v := range segment.Fields {
+				fields[k] = v
+			}
+			fields[logger.FieldNameFileRefs] = encodeFileRefs(refs)
+			segments[i].Text = text
+			segments[i].Fields = fields
+		}
+		total += len(segments[i].Text)
+	}
+	return total
+}
@@ -0,0 +1,175 @@
+package logstore
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/pkg/logger"
+)
+
+var testWorkspace = filepath.Join(string(filepath.Separator), "home", "user", "project")
+
+func newTestPathMapper() *PathMapper {
+	return NewPathMapper(testWorkspace, []PathMapping{
+		{ContainerPath: "/app", LocalPath: testWorkspace},
+		{ContainerPath: "/app/web", LocalPath: filepath.Join(testWorkspace, "frontend")},
+		{ContainerPath: "/usr/src/lib", LocalPath: filepath.Join(string(filepath.Separator), "opt", "lib")},
+	})
+}
+
+func TestPathMapperAnnotate(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		line     string
+		expected string
+		refs     []FileRef
+	}{
+		{
+			name:     "go compiler error",
+			line:     "/app/cmd/main.go:12:5: undefined: foo\n",
+			expected: "/app/cmd/main.go:12:5 [cmd/main.go:12:5]: undefined: foo\n",
+			refs: []FileRef{{
+				ContainerPath: "/app/cmd/main.go",
+				Path:          "cmd/main.go",
+				LocalPath:     filepath.Join(testWorkspace, "cmd", "main.go"),
+				Line:          12,
+				Column:        5,
+			}},
+		},
+		{
+			name:     "go panic",
+			line:     "\t/app/server.go:40 +0x1d\n",
+			expected: "\t/app/server.go:40 [server.go:40] +0x1d\n",
+			refs: []FileRef{{
+				ContainerPath: "/app/server.go",
+				Path:          "server.go",
+				LocalPath:     filepath.Join(testWorkspace, "server.go"),
+				Line:          40,
+			}},
+		},
+		{
+			name:     "node stack trace",
+			line:     "    at Object.<anonymous> (/app/web/index.js:3:9)\n",
+			expected: "    at Object.<anonymous> (/app/web/index.js:3:9 [frontend/index.js:3:9])\n",
+			refs: []FileRef{{
+				ContainerPath: "/app/web/index.js",
+				Path:          "frontend/index.js",
+				LocalPath:     filepath.Join(testWorkspace, "frontend", "index.js"),
+				Line:          3,
+				Column:        9,
+			}},
+		},
+		{
+			name:     "python stack trace",
+			line:     "  File \"/app/main.py\", line 7, in <module>\n",
+			expected: "  File \"/app/main.py\", line 7 [main.py:7], in <module>\n",
+			refs: []FileRef{{
+				ContainerPath: "/app/main.py",
+				Path:          "main.py",
+				LocalPath:     filepath.Join(testWorkspace, "main.py"),
+				Line:          7,
+			}},
+		},
+		{
+			name:     "outside the workspace",
+			line:     "/usr/src/lib/util.go:3: oops\n",
+			expected: "/usr/src/lib/util.go:3 [" + filepath.Join(string(filepath.Separator), "opt", "lib", "util.go") + ":3]: oops\n",
+			refs: []FileRef{{
+				ContainerPath: "/usr/src/lib/util.go",
+				Path:          filepath.Join(string(filepath.Separator), "opt", "lib", "util.go"),
+				LocalPath:     filepath.Join(string(filepath.Separator), "opt", "lib", "util.go"),
+				Line:          3,
+			}},
+		},
+		{
+			name:     "unmapped go path",
+			line:     "/usr/local/go/src/runtime/panic.go:965 +0x1b9\n",
+			expected: "/usr/local/go/src/runtime/panic.go:965 +0x1b9\n",
+		},
+		{
+			name:     "unmapped node path",
+			line:     "    at Module._compile (/usr/lib/node/loader.js:1063:30)\n",
+			expected: "    at Module._compile (/usr/lib/node/loader.js:1063:30)\n",
+		},
+		{
+			name:     "unmapped python path",
+			line:     "  File \"/usr/lib/python3.9/runpy.py\", line 197, in _run_module_as_main\n",
+			expected: "  File \"/usr/lib/python3.9/runpy.py\", line 197, in _run_module_as_main\n",
+		},
+		{
+			name:     "similar directory name",
+			line:     "/application/main.go:1: oops\n",
+			expected: "/application/main.go:1: oops\n",
+		},
+		{
+			name:     "relative path that looks mapped",
+			line:     "vendor/app/main.go:1: oops\n",
+			expected: "vendor/app/main.go:1: oops\n",
+		},
+		{
+			name:     "url",
+			line:     "GET http://localhost:8000/app/main.go:1\n",
+			expected: "GET http://localhost:8000/app/main.go:1\n",
+		},
+		{
+			name:     "no line number",
+			line:     "reading /app/config.yaml\n",
+			expected: "reading /app/config.yaml\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, refs := newTestPathMapper().Annotate([]byte(tc.line))
+			assert.Equal(t, tc.expected, string(actual))
+			assert.Equal(t, tc.refs, refs)
+		})
+	}
+}
+
+func TestPathMapperIgnoresRoot(t *testing.T) {
+	m := NewPathMapper(testWorkspace, []PathMapping{{ContainerPath: "/", LocalPath: testWorkspace}})
+	actual, refs := m.Annotate([]byte("/main.go:1: oops\n"))
+	assert.Equal(t, "/main.go:1: oops\n", string(actual))
+	assert.Nil(t, refs)
+}
+
+func TestAppendAnnotatesFileRefs(t *testing.T) {
+	l := NewLogStore()
+	l.SetPathMapper("fe", newTestPathMapper())
+
+	fields := logger.Fields{logger.FieldNameProgressID: "build"}
+	l.Append(testLogEvent{
+		name:    "fe",
+		ts:      time.Now(),
+		fields:  fields,
+		message: "# command-line-arguments\n/app/main.go:3:2: undefined: foo\n",
+	}, nil)
+	l.Append(newTestLogEvent("be", time.Now(), "/app/main.go:3:2: undefined: foo\n"), nil)
+
+	assert.Equal(t, "# command-line-arguments\n/app/main.go:3:2 [main.go:3:2]: undefined: foo\n", l.ManifestLog("fe"))
+	assert.Equal(t, "/app/main.go:3:2: undefined: foo\n", l.ManifestLog("be"))
+	assert.Equal(t, l.computeLen(), l.len)
+
+	list, err := l.ToLogList(0)
+	require.NoError(t, err)
+	require.Len(t, list.Segments, 3)
+	assert.Equal(t, "", list.Segments[0].Fields[logger.FieldNameFileRefs])
+	assert.Equal(t, "build", list.Segments[1].Fields[logger.FieldNameProgressID])
+	assert.Equal(t, "", list.Segments[2].Fields[logger.FieldNameFileRefs])
+
+	refs, err := DecodeFileRefs(list.Segments[1].Fields[logger.FieldNameFileRefs])
+	require.NoError(t, err)
+	assert.Equal(t, []FileRef{{
+		ContainerPath: "/app/main.go",
+		Path:          "main.go",
+		LocalPath:     filepath.Join(testWorkspace, "main.go"),
+		Line:          3,
+		Column:        2,
+	}}, refs)
+
+	// The event's fields are shared, so we shouldn't have changed them.
+	assert.Equal(t, logger.Fields{logger.FieldNameProgressID: "build"}, fields)
+}
@@ -0,0 +1,103 @@
+package logstore
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+// Filters log lines by a minimum level, globally and per resource.
+//
+// The string form is a comma-separated list of a global level
+// and per-resource overrides, e.g., "warn,frontend=debug".
+//
+// The zero value lets everything through.
+type LevelFilter struct {
+	Default    logger.Level
+	ByManifest map[model.ManifestName]logger.Level
+}
+
+func ParseLevelFilter(s string) (LevelFilter, error) {
+	result := LevelFilter{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, levelStr := "", part
+		if i := strings.LastIndex(part, "="); i != -1 {
+			name, levelStr = strings.TrimSpace(part[:i]), part[i+1:]
+			if name == "" {
+				return LevelFilter{}, fmt.Errorf("Invalid log level filter %q: missing resource name", part)
+			}
+		}
+
+		level, err := logger.ParseLevel(levelStr)
+		if err != nil {
+			return LevelFilter{}, err
+		}
+
+		if name == "" {
+			result.Default = level
+			continue
+		}
+		if result.ByManifest == nil {
+			result.ByManifest = make(map[model.ManifestName]logger.Level)
+		}
+		result.ByManifest[model.ManifestName(name)] = level
+	}
+	return result, nil
+}
+
+func (f LevelFilter) IsEmpty() bool {
+	return f.Default == logger.NoneLvl && len(f.ByManifest) == 0
+}
+
+// Whether to show a log line from the given manifest at the given level.
+func (f LevelFilter) Allows(mn model.ManifestName, level logger.Level) bool {
+	// Segments without a level were written before we tracked levels.
+	if level == logger.NoneLvl {
+		level = logger.InfoLvl
+	}
+
+	min, ok := f.ByManifest[mn]
+	if !ok {
+		min = f.Default
+	}
+	return min.ShouldDisplay(level)
+}
+
+func (f LevelFilter) String() string {
+	parts := []string{}
+	if f.Default != logger.NoneLvl {
+		parts = append(parts, levelName(f.Default))
+	}
+
+	names := make([]string, 0, len(f.ByManifest))
+	for mn := range f.ByManifest {
+		names = append(names, mn.String())
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%s", name, levelName(f.ByManifest[model.ManifestName(name)])))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Implements pflag.Value, so that the filter can be passed on the command line.
+func (f *LevelFilter) Set(v string) error {
+	filter, err := ParseLevelFilter(v)
+	if err != nil {
+		return err
+	}
+	*f = filter
+	return nil
+}
+
+func (f *LevelFilter) Type() string {
+	return "LevelFilter"
+}
@@ -0,0 +1,101 @@
+package logstore
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestParseLevelFilter(t *testing.T) {
+	f, err := ParseLevelFilter("warn, fe=debug,be=error")
+	require.NoError(t, err)
+	assert.Equal(t, LevelFilter{
+		Default: logger.WarnLvl,
+		ByManifest: map[model.ManifestName]logger.Level{
+			"fe": logger.DebugLvl,
+			"be": logger.ErrorLvl,
+		},
+	}, f)
+	assert.Equal(t, "warn,be=error,fe=debug", f.String())
+
+	f, err = ParseLevelFilter("")
+	require.NoError(t, err)
+	assert.True(t, f.IsEmpty())
+
+	_, err = ParseLevelFilter("fe=loud")
+	assert.EqualError(t, err, `Unrecognized log level "loud". Allowed values: debug, verbose, info, warn, error`)
+
+	_, err = ParseLevelFilter("=warn")
+	assert.EqualError(t, err, `Invalid log level filter "=warn": missing resource name`)
+}
+
+func TestLevelFilterAllows(t *testing.T) {
+	f := LevelFilter{
+		Default:    logger.WarnLvl,
+		ByManifest: map[model.ManifestName]logger.Level{"fe": logger.DebugLvl},
+	}
+	assert.False(t, f.Allows("be", logger.InfoLvl))
+	assert.False(t, f.Allows("be", logger.NoneLvl))
+	assert.True(t, f.Allows("be", logger.WarnLvl))
+	assert.True(t, f.Allows("be", logger.ErrorLvl))
+	assert.True(t, f.Allows("fe", logger.DebugLvl))
+
+	assert.True(t, LevelFilter{}.Allows("be", logger.DebugLvl))
+}
+
+func TestContinuingLinesLevelFilter(t *testing.T) {
+	l := NewLogStore()
+	l.Append(newLevelTestLogEvent("fe", "fe info\n", logger.InfoLvl), nil)
+	l.Append(newLevelTestLogEvent("fe", "fe warn ", logger.WarnLvl), nil)
+	l.Append(newLevelTestLogEvent("be", "be info\n", logger.InfoLvl), nil)
+	l.Append(newLevelTestLogEvent("fe", "continued\n", logger.WarnLvl), nil)
+	l.Append(newLevelTestLogEvent("be", "be error\n", logger.ErrorLvl), nil)
+
+	filter, err := ParseLevelFilter("warn")
+	require.NoError(t, err)
+	assert.Equal(t,
+		"           fe │ WARNING: fe warn continued\n"+
+			"           be │ ERROR: be error\n",
+		l.ContinuingStringWithOptions(0, LineOptions{Levels: filter}))
+
+	filter, err = ParseLevelFilter("warn,be=info")
+	require.NoError(t, err)
+	assert.Equal(t,
+		"           fe │ WARNING: fe warn continued\n"+
+			"           be │ be info\n"+
+			"           be │ ERROR: be error\n",
+		l.ContinuingStringWithOptions(0, LineOptions{Levels: filter}))
+}
+
+func TestFilteredLogList(t *testing.T) {
+	l := NewLogStore()
+	l.Append(newLevelTestLogEvent("fe", "fe info\n", logger.InfoLvl), nil)
+	l.Append(newLevelTestLogEvent("fe", "fe warn ", logger.WarnLvl), nil)
+	l.Append(newLevelTestLogEvent("be", "be info\n", logger.InfoLvl), nil)
+	l.Append(newLevelTestLogEvent("fe", "continued\n", logger.WarnLvl), nil)
+
+	filter, err := ParseLevelFilter("warn")
+	require.NoError(t, err)
+	list, err := l.ToFilteredLogList(0, filter)
+	require.NoError(t, err)
+
+	texts := []string{}
+	for _, seg := range list.Segments {
+		texts = append(texts, seg.Text)
+	}
+	assert.Equal(t, "fe warn |continued\n", strings.Join(texts, "|"))
+	assert.Equal(t, int32(0), list.FromCheckpoint)
+	assert.Equal(t, int32(4), list.ToCheckpoint)
+}
+
+func newLevelTestLogEvent(name model.ManifestName, message string, level logger.Level) testLogEvent {
+	event := newTestLogEvent(name, time.Now(), message)
+	event.level = level
+	return event
+}
@@ -3,8 +3,10 @@ package logstore
 import (
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unsafe"
 
 	"github.com/golang/protobuf/ptypes"
 	"github.com/pkg/errors"
@@ -55,6 +57,9 @@ type LogSegment struct {
 	//        warning1, line2
 	// Anchor warning2, line1
 	Anchor bool
+
+	// Hidden from the UI because the user cleared the logs of its resource.
+	Cleared bool
 }
 
 // Whether these two log segments may be printed on the same line
@@ -156,6 +161,9 @@ type LogStore struct {
 
 	// If the log is truncated, we need to adjust all checkpoints
 	checkpointOffset Checkpoint
+
+	// Maps container paths in each resource's logs back to local files.
+	pathMappers map[model.ManifestName]*PathMapper
 }
 
 func NewLogStoreForTesting(msg string) *LogStore {
@@ -170,9 +178,21 @@ func NewLogStore() *LogStore {
 		segments:            []LogSegment{},
 		len:                 0,
 		maxLogLengthInBytes: defaultMaxLogLengthInBytes,
+		pathMappers:         make(map[model.ManifestName]*PathMapper),
 	}
 }
 
+// Sets how to map container paths in a resource's logs back to local files.
+//
+// Only applies to logs appended afterwards. A nil mapper turns mapping off.
+func (s *LogStore) SetPathMapper(mn model.ManifestName, m *PathMapper) {
+	if m == nil {
+		delete(s.pathMappers, mn)
+		return
+	}
+	s.pathMappers[mn] = m
+}
+
 func (s *LogStore) Checkpoint() Checkpoint {
 	return s.checkpointFromIndex(len(s.segments))
 }
@@ -243,13 +263,56 @@ func (s *LogStore) ScrubSecretsStartingAt(secrets model.SecretSet, checkpoint Ch
 	s.len = s.computeLen()
 }
 
+// Clears the logs of the given manifests (or all logs, if mns is empty),
+// so that the UI starts fresh. Logs that arrive afterwards show up as usual.
+//
+// If retain is true, the cleared logs are kept (until truncated) so that
+// snapshots can still include them. Otherwise, they're deleted.
+func (s *LogStore) Clear(mns model.ManifestNameSet, retain bool) {
+	if retain {
+		for i, segment := range s.segments {
+			if s.segmentMatchesManifests(segment, mns) {
+				s.segments[i].Cleared = true
+			}
+		}
+		return
+	}
+
+	newSegments := make([]LogSegment, 0, len(s.segments))
+	for _, segment := range s.segments {
+		if !s.segmentMatchesManifests(segment, mns) {
+			newSegments = append(newSegments, segment)
+		}
+	}
+
+	removedCount := len(s.segments) - len(newSegments)
+	if removedCount == 0 {
+		return
+	}
+
+	// Same as truncation: keep the checkpoints of later segments stable,
+	// so that clients streaming from a checkpoint don't miss new logs.
+	s.checkpointOffset += Checkpoint(removedCount)
+	s.segments = newSegments
+	s.recomputeDerivedValues()
+}
+
+func (s *LogStore) segmentMatchesManifests(segment LogSegment, mns model.ManifestNameSet) bool {
+	if len(mns) == 0 {
+		return true
+	}
+	span, ok := s.spans[segment.SpanID]
+	return ok && mns[span.ManifestName]
+}
+
 func (s *LogStore) Append(le LogEvent, secrets model.SecretSet) {
 	spanID := le.SpanID()
 	if spanID == "" && le.ManifestName() != "" {
 		spanID = SpanID(fmt.Sprintf("unknown:%s", le.ManifestName()))
 	}
 	span, ok := s.spans[spanID]
-	if !ok {
+	isNewSpan := !ok
+	if isNewSpan {
 		span = &Span{
 			ManifestName:      le.ManifestName(),
 			LastSegmentIndex:  -1,
@@ -264,20 +327,48 @@ func (s *LogStore) Append(le LogEvent, secrets model.SecretSet) {
 		return
 	}
 
+	addedLen := len(msg)
+	if mapper, ok := s.pathMappers[span.ManifestName]; ok {
+		addedLen = mapper.annotateSegments(added)
+	}
+
 	level := le.Level()
 	if level.AsSevereAs(logger.WarnLvl) {
 		added[0].Anchor = true
 	}
 
+	if isNewSpan {
+		divider, ok := runDivider(spanID, le)
+		if ok {
+			added = append([]LogSegment{divider}, added...)
+			addedLen += divider.Len()
+		}
+	}
+
 	added[0].ContinuesLine = s.computeContinuesLine(added[0], span)
 
 	s.segments = append(s.segments, added...)
 	span.LastSegmentIndex = len(s.segments) - 1
 
-	s.len += len(msg)
+	s.len += addedLen
 	s.ensureMaxLength()
 }
 
+// When a server restarts, its new run logs to a new span.
+// Separate the new run from the old one with a divider line.
+func runDivider(spanID SpanID, le LogEvent) (LogSegment, bool) {
+	runNumber, err := strconv.Atoi(le.Fields()[logger.FieldNameRunNumber])
+	if err != nil || runNumber <= 1 {
+		return LogSegment{}, false
+	}
+	return LogSegment{
+		SpanID: spanID,
+		Time:   le.Time(),
+		Text:   []byte(fmt.Sprintf("── restarting server (run %d) ──\n", runNumber)),
+		Level:  logger.InfoLvl,
+	}, true
+}
+
 func (s *LogStore) Empty() bool {
 	return len(s.segments) == 0
 }
@@ -489,6 +580,7 @@ func (s *LogStore) ContinuingLinesWithOptions(checkpoint Checkpoint, opts LineOp
 		spans:                       spans,
 		showManifestPrefix:          !opts.SuppressPrefix,
 		skipFirstLineManifestPrefix: isSameSpanContinuation,
+		levels:                      opts.Levels,
 	})
 
 	if isSameSpanContinuation {
@@ -508,7 +600,25 @@ func (s *LogStore) ContinuingLinesWithOptions(checkpoint Checkpoint, opts LineOp
 	return result
 }
 
+// Converts the logs since the given checkpoint to their web representation,
+// skipping logs that the user cleared.
 func (s *LogStore) ToLogList(fromCheckpoint Checkpoint) (*webview.LogList, error) {
+	return s.toLogList(fromCheckpoint, false, LevelFilter{})
+}
+
+// Like ToLogList, but skips lines that the filter doesn't allow.
+func (s *LogStore) ToFilteredLogList(fromCheckpoint Checkpoint, levels LevelFilter) (*webview.LogList, error) {
+	return s.toLogList(fromCheckpoint, false, levels)
+}
+
+// Like ToLogList, but includes cleared logs that were retained.
+//
+// Intended for snapshots, which should show everything that happened.
+func (s *LogStore) ToLogListWithCleared(fromCheckpoint Checkpoint) (*webview.LogList, error) {
+	return s.toLogList(fromCheckpoint, true, LevelFilter{})
+}
+
+func (s *LogStore) toLogList(fromCheckpoint Checkpoint, includeCleared bool, levels LevelFilter) (*webview.LogList, error) {
 	spans := make(map[string]*webview.LogSpan, len(s.spans))
 	for spanID, span := range s.spans {
 		spans[string(spanID)] = &webview.LogSpan{
@@ -525,9 +635,26 @@ func (s *LogStore) ToLogList(fromCheckpoint Checkpoint) (*webview.LogList, error
 		}, nil
 	}
 
+	// A line is filtered by the level of its first segment, so track
+	// whether the line that each span is in the middle of was allowed.
+	lineAllowed := make(map[SpanID]bool)
+
 	segments := make([]*webview.LogSegment, 0, len(s.segments)-startIndex)
 	for i := startIndex; i < len(s.segments); i++ {
 		segment := s.segments[i]
+		if segment.Cleared && !includeCleared {
+			continue
+		}
+		if !levels.IsEmpty() {
+			allowed, ok := lineAllowed[segment.SpanID]
+			if segment.StartsLine() || !ok {
+				allowed = levels.Allows(s.manifestNameForSpan(segment.SpanID), segment.Level)
+				lineAllowed[segment.SpanID] = allowed
+			}
+			if !allowed {
+				continue
+			}
+		}
 		time, err := ptypes.TimestampProto(segment.Time)
 		if err != nil {
 			return nil, errors.Wrap(err, "ToLogList")
@@ -557,6 +684,14 @@ func (s *LogStore) String() string {
 	})
 }
 
+func (s *LogStore) manifestNameForSpan(spanID SpanID) model.ManifestName {
+	span, ok := s.spans[spanID]
+	if !ok {
+		return ""
+	}
+	return span.ManifestName
+}
+
 func (s *LogStore) spansForManifest(mn model.ManifestName) map[SpanID]*Span {
 	result := make(map[SpanID]*Span)
 	for spanID, span := range s.spans {
@@ -659,11 +794,13 @@ type logOptions struct {
 	spans                       map[SpanID]*Span // only print logs for these spans
 	showManifestPrefix          bool
 	skipFirstLineManifestPrefix bool
+	levels                      LevelFilter
 }
 
 type LineOptions struct {
 	ManifestNames  model.ManifestNameSet // only print logs for these manifests
 	SuppressPrefix bool
+	Levels         LevelFilter // only print lines that the filter allows
 }
 
 func (s *LogStore) toLogString(options logOptions) string {
@@ -712,6 +849,9 @@ func (s *LogStore) toLogLines(options logOptions) []LogLine {
 		if _, ok := options.spans[spanID]; !ok {
 			continue
 		}
+		if !options.levels.Allows(span.ManifestName, segment.Level) {
+			continue
+		}
 
 		// If the last segment never completed, print a newline now, so that the
 		// logs from different sources don't blend together.
@@ -759,6 +899,42 @@ func (s *LogStore) computeLen() int {
 	return result
 }
 
+// How much memory the log store is using, for the engine's memory accounting.
+type MemoryStats struct {
+	// Bytes of log text.
+	TextBytes int
+
+	Segments int
+	Spans    int
+
+	// An estimate of the total bytes held by the log store, including the
+	// bookkeeping for each segment and span.
+	EstimatedBytes int64
+}
+
+func (s *LogStore) MemoryStats() MemoryStats {
+	result := MemoryStats{
+		TextBytes: s.len,
+		Segments:  len(s.segments),
+		Spans:     len(s.spans),
+	}
+
+	estimate := int64(s.len)
+	estimate += int64(cap(s.segments)) * int64(unsafe.Sizeof(LogSegment{}))
+	for _, segment := range s.segments {
+		// Segments from the same event share their fields, so this
+		// overestimates a bit.
+		for k, v := range segment.Fields {
+			estimate += int64(len(k) + len(v))
+		}
+	}
+	for id := range s.spans {
+		estimate += int64(len(id)) + int64(unsafe.Sizeof(Span{})+unsafe.Sizeof(id)+unsafe.Sizeof(&Span{}))
+	}
+	result.EstimatedBytes = estimate
+	return result
+}
+
 // After a log hits its limit, we need to truncate it to keep it small
 // we do this by cutting a big chunk at a time, so that we have rarer, larger changes, instead of
 // a small change every time new data is written to the log
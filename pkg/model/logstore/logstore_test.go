@@ -12,6 +12,7 @@ import (
 
 	"github.com/tilt-dev/tilt/pkg/logger"
 	"github.com/tilt-dev/tilt/pkg/model"
+	"github.com/tilt-dev/tilt/pkg/webview"
 )
 
 // NOTE(dmiller): set at runtime with:
@@ -559,6 +560,32 @@ func TestBuildEventInit(t *testing.T) {
 	assertSnapshot(t, l.String())
 }
 
+func TestRunDivider(t *testing.T) {
+	l := NewLogStore()
+
+	now := time.Now()
+	for _, run := range []string{"1", "2", "3"} {
+		for _, msg := range []string{"serving run ", "still serving run "} {
+			l.Append(testLogEvent{
+				name:    "fe",
+				spanID:  SpanID("localserve:fe:" + run),
+				message: msg + run + "\n",
+				ts:      now,
+				fields:  map[string]string{logger.FieldNameRunNumber: run},
+			}, nil)
+		}
+	}
+
+	assert.Equal(t, "serving run 1\n"+
+		"still serving run 1\n"+
+		"── restarting server (run 2) ──\n"+
+		"serving run 2\n"+
+		"still serving run 2\n"+
+		"── restarting server (run 3) ──\n"+
+		"serving run 3\n"+
+		"still serving run 3\n", l.ManifestLog("fe"))
+}
+
 func assertSnapshot(t *testing.T, output string) {
 	d1 := []byte(output)
 	gmPath := fmt.Sprintf("testdata/%s_master", t.Name())
@@ -583,3 +610,86 @@ func lineOptionsWithManifests(mns ...model.ManifestName) LineOptions {
 	}
 	return LineOptions{ManifestNames: mnSet}
 }
+
+func TestClearRetainHidesFromFullView(t *testing.T) {
+	l := NewLogStore()
+	l.Append(newTestLogEvent("fe", time.Now(), "fe1\n"), nil)
+	l.Append(newTestLogEvent("be", time.Now(), "be1\n"), nil)
+	clientCheckpoint := l.Checkpoint()
+
+	l.Clear(model.ManifestNameSet{"fe": true}, true)
+	l.Append(newTestLogEvent("fe", time.Now(), "fe2\n"), nil)
+
+	// A client that loads the view afterwards starts fresh.
+	list, err := l.ToLogList(0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"be1\n", "fe2\n"}, segmentTexts(list.Segments))
+	assert.Equal(t, int32(0), list.FromCheckpoint)
+	assert.Equal(t, int32(3), list.ToCheckpoint)
+
+	// A client that's already streaming only gets the new logs.
+	list, err = l.ToLogList(clientCheckpoint)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"fe2\n"}, segmentTexts(list.Segments))
+	assert.Equal(t, int32(2), list.FromCheckpoint)
+
+	// Snapshots still see everything.
+	list, err = l.ToLogListWithCleared(0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"fe1\n", "be1\n", "fe2\n"}, segmentTexts(list.Segments))
+}
+
+func TestClearDeleteKeepsCheckpoints(t *testing.T) {
+	l := NewLogStore()
+	l.Append(newTestLogEvent("fe", time.Now(), "fe1\n"), nil)
+	l.Append(newTestLogEvent("be", time.Now(), "be1\n"), nil)
+	l.Append(newTestLogEvent("fe", time.Now(), "fe2\n"), nil)
+	clientCheckpoint := l.Checkpoint()
+
+	l.Clear(model.ManifestNameSet{"fe": true}, false)
+	assert.Equal(t, clientCheckpoint, l.Checkpoint())
+	assert.Equal(t, "", l.ManifestLog("fe"))
+
+	l.Append(newTestLogEvent("fe", time.Now(), "fe3\n"), nil)
+	list, err := l.ToLogList(clientCheckpoint)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"fe3\n"}, segmentTexts(list.Segments))
+
+	list, err = l.ToLogListWithCleared(0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"be1\n", "fe3\n"}, segmentTexts(list.Segments))
+}
+
+func TestClearAll(t *testing.T) {
+	l := NewLogStore()
+	l.Append(newGlobalTestLogEvent("global\n"), nil)
+	l.Append(newTestLogEvent("fe", time.Now(), "fe1\n"), nil)
+
+	l.Clear(nil, true)
+
+	list, err := l.ToLogList(0)
+	assert.NoError(t, err)
+	assert.Empty(t, list.Segments)
+}
+
+func segmentTexts(segments []*webview.LogSegment) []string {
+	result := make([]string, len(segments))
+	for i, seg := range segments {
+		result[i] = seg.Text
+	}
+	return result
+}
+
+func TestMemoryStats(t *testing.T) {
+	l := NewLogStore()
+	assert.Equal(t, MemoryStats{}, l.MemoryStats())
+
+	l.Append(newTestLogEvent("fe", time.Now(), "hello\nworld\n"), nil)
+	l.Append(newTestLogEvent("be", time.Now(), "goodbye\n"), nil)
+
+	stats := l.MemoryStats()
+	assert.Equal(t, len("hello\nworld\ngoodbye\n"), stats.TextBytes)
+	assert.Equal(t, 3, stats.Segments)
+	assert.Equal(t, 2, stats.Spans)
+	assert.Greater(t, stats.EstimatedBytes, int64(stats.TextBytes))
+}
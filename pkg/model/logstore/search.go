@@ -0,0 +1,139 @@
+package logstore
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/tilt-dev/tilt/pkg/logger"
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+const (
+	DefaultSearchMaxResults = 100
+
+	// Searching holds the state lock, so don't scan the whole log
+	// on every request.
+	DefaultSearchMaxScanBytes = 1000 * 1000
+)
+
+type SearchOptions struct {
+	// Only search the logs of this manifest. If empty, search all logs.
+	ManifestName model.ManifestName
+
+	// A substring to look for, or a regular expression if Regexp is set.
+	Query  string
+	Regexp bool
+
+	// Defaults to DefaultSearchMaxResults.
+	MaxResults int
+
+	// Defaults to DefaultSearchMaxScanBytes.
+	MaxScanBytes int
+}
+
+type SearchMatch struct {
+	SpanID       SpanID             `json:"span_id"`
+	ManifestName model.ManifestName `json:"manifest_name"`
+	Time         time.Time          `json:"time"`
+	Level        string             `json:"level"`
+	Text         string             `json:"text"`
+	Checkpoint   Checkpoint         `json:"checkpoint"`
+}
+
+type SearchResult struct {
+	// Newest first.
+	Matches []SearchMatch `json:"matches"`
+
+	// True if the search stopped before reaching the oldest log, because it
+	// hit the result cap or the scan cap. Older logs may have more matches.
+	Truncated bool `json:"truncated"`
+}
+
+// Searches the log segments, newest first.
+//
+// Skips logs that the user cleared, so that results match what the UI shows.
+func (s *LogStore) Search(opts SearchOptions) (SearchResult, error) {
+	match, err := searchMatcher(opts)
+	if err != nil {
+		return SearchResult{}, err
+	}
+
+	maxResults := opts.MaxResults
+	if maxResults <= 0 {
+		maxResults = DefaultSearchMaxResults
+	}
+	maxScanBytes := opts.MaxScanBytes
+	if maxScanBytes <= 0 {
+		maxScanBytes = DefaultSearchMaxScanBytes
+	}
+
+	result := SearchResult{Matches: []SearchMatch{}}
+	scannedBytes := 0
+	for i := len(s.segments) - 1; i >= 0; i-- {
+		segment := s.segments[i]
+		if segment.Cleared {
+			continue
+		}
+
+		span := s.spans[segment.SpanID]
+		if opts.ManifestName != "" && span.ManifestName != opts.ManifestName {
+			continue
+		}
+
+		if len(result.Matches) >= maxResults || scannedBytes+segment.Len() > maxScanBytes {
+			result.Truncated = true
+			break
+		}
+		scannedBytes += segment.Len()
+
+		if !match(segment.Text) {
+			continue
+		}
+
+		result.Matches = append(result.Matches, SearchMatch{
+			SpanID:       segment.SpanID,
+			ManifestName: span.ManifestName,
+			Time:         segment.Time,
+			Level:        levelName(segment.Level),
+			Text:         string(segment.Text),
+			Checkpoint:   s.checkpointFromIndex(i),
+		})
+	}
+	return result, nil
+}
+
+func searchMatcher(opts SearchOptions) (func([]byte) bool, error) {
+	if opts.Query == "" {
+		return nil, fmt.Errorf("search query must not be empty")
+	}
+
+	if opts.Regexp {
+		re, err := regexp.Compile(opts.Query)
+		if err != nil {
+			return nil, fmt.Errorf("invalid search regexp: %v", err)
+		}
+		return re.Match, nil
+	}
+
+	query := []byte(opts.Query)
+	return func(text []byte) bool {
+		return bytes.Contains(text, query)
+	}, nil
+}
+
+func levelName(level logger.Level) string {
+	switch level {
+	case logger.ErrorLvl:
+		return "error"
+	case logger.WarnLvl:
+		return "warn"
+	case logger.VerboseLvl:
+		return "verbose"
+	case logger.DebugLvl:
+		return "debug"
+	default:
+		return "info"
+	}
+}
@@ -0,0 +1,101 @@
+package logstore
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tilt-dev/tilt/pkg/model"
+)
+
+func TestSearchNewestFirst(t *testing.T) {
+	l := NewLogStore()
+	start := time.Now()
+	l.Append(newTestLogEvent("fe", start, "error: first\n"), nil)
+	l.Append(newTestLogEvent("be", start.Add(time.Second), "error: second\n"), nil)
+	l.Append(newTestLogEvent("fe", start.Add(2*time.Second), "ok\n"), nil)
+	l.Append(newTestLogEvent("fe", start.Add(3*time.Second), "error: third\n"), nil)
+
+	result, err := l.Search(SearchOptions{Query: "error"})
+	require.NoError(t, err)
+	assert.False(t, result.Truncated)
+	assert.Equal(t, []string{"error: third\n", "error: second\n", "error: first\n"}, matchTexts(result))
+	assert.Equal(t, model.ManifestName("fe"), result.Matches[0].ManifestName)
+	assert.Equal(t, Checkpoint(3), result.Matches[0].Checkpoint)
+	assert.Equal(t, "info", result.Matches[0].Level)
+	assert.True(t, result.Matches[0].Time.Equal(start.Add(3*time.Second)))
+}
+
+func TestSearchOneManifest(t *testing.T) {
+	l := NewLogStore()
+	l.Append(newTestLogEvent("fe", time.Now(), "error: fe\n"), nil)
+	l.Append(newTestLogEvent("be", time.Now(), "error: be\n"), nil)
+
+	result, err := l.Search(SearchOptions{ManifestName: "be", Query: "error"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"error: be\n"}, matchTexts(result))
+}
+
+func TestSearchRegexp(t *testing.T) {
+	l := NewLogStore()
+	l.Append(newTestLogEvent("fe", time.Now(), "GET /a 200\n"), nil)
+	l.Append(newTestLogEvent("fe", time.Now(), "GET /b 500\n"), nil)
+
+	result, err := l.Search(SearchOptions{Query: `\s5\d\d\b`, Regexp: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GET /b 500\n"}, matchTexts(result))
+
+	_, err = l.Search(SearchOptions{Query: "(", Regexp: true})
+	assert.Error(t, err)
+
+	_, err = l.Search(SearchOptions{})
+	assert.Error(t, err)
+}
+
+func TestSearchMaxResults(t *testing.T) {
+	l := NewLogStore()
+	for i := 0; i < 5; i++ {
+		l.Append(newTestLogEvent("fe", time.Now(), "match\n"), nil)
+	}
+
+	result, err := l.Search(SearchOptions{Query: "match", MaxResults: 2})
+	require.NoError(t, err)
+	assert.True(t, result.Truncated)
+	require.Len(t, result.Matches, 2)
+	assert.Equal(t, Checkpoint(4), result.Matches[0].Checkpoint)
+	assert.Equal(t, Checkpoint(3), result.Matches[1].Checkpoint)
+}
+
+func TestSearchMaxScanBytes(t *testing.T) {
+	l := NewLogStore()
+	l.Append(newTestLogEvent("fe", time.Now(), "needle\n"), nil)
+	l.Append(newTestLogEvent("fe", time.Now(), strings.Repeat("x", 100)+"\n"), nil)
+	l.Append(newTestLogEvent("fe", time.Now(), "needle again\n"), nil)
+
+	result, err := l.Search(SearchOptions{Query: "needle", MaxScanBytes: 50})
+	require.NoError(t, err)
+	assert.True(t, result.Truncated)
+	assert.Equal(t, []string{"needle again\n"}, matchTexts(result))
+}
+
+func TestSearchSkipsCleared(t *testing.T) {
+	l := NewLogStore()
+	l.Append(newTestLogEvent("fe", time.Now(), "error: old\n"), nil)
+	l.Clear(model.ManifestNameSet{"fe": true}, true)
+	l.Append(newTestLogEvent("fe", time.Now(), "error: new\n"), nil)
+
+	result, err := l.Search(SearchOptions{Query: "error"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"error: new\n"}, matchTexts(result))
+}
+
+func matchTexts(result SearchResult) []string {
+	texts := make([]string, len(result.Matches))
+	for i, m := range result.Matches {
+		texts[i] = m.Text
+	}
+	return texts
+}
@@ -13,6 +13,9 @@ type testLogEvent struct {
 	ts      time.Time
 	fields  logger.Fields
 	message string
+
+	// Defaults to the manifest name.
+	spanID SpanID
 }
 
 func (l testLogEvent) Message() []byte {
@@ -36,6 +39,9 @@ func (l testLogEvent) Fields() logger.Fields {
 }
 
 func (l testLogEvent) SpanID() SpanID {
+	if l.spanID != "" {
+		return l.spanID
+	}
 	return SpanID(l.name)
 }
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/docker/distribution/reference"
 	"github.com/google/go-cmp/cmp"
@@ -60,9 +61,29 @@ type Manifest struct {
 	// ready at least once.
 	ResourceDependencies []ManifestName
 
+	// Resources whose declared outputs overlap this resource's inputs.
+	// When we rebuild one of them, this resource waits for it to finish.
+	OutputDependencies []ManifestName
+
 	SourceTiltfile ManifestName
 
 	Labels map[string]string
+
+	// Watch this manifest's files even in modes that normally don't
+	// watch files (like `tilt ci`), e.g., for live update smoke tests.
+	WatchInCI bool
+
+	// Overrides the build timeout in UpdateSettings for this manifest.
+	// nil uses the global setting. 0 turns the timeout off.
+	BuildTimeout *time.Duration
+
+	// Free-form metadata from the Tiltfile (e.g., an owning team or a
+	// runbook URL). Copied onto the API objects generated for the manifest.
+	Annotations map[string]string
+
+	// Links that belong to the resource as a whole, rather than to
+	// one of its targets, shown alongside its endpoint links.
+	Links []Link
 }
 
 func (m Manifest) ID() TargetID {
@@ -137,6 +158,16 @@ func (m Manifest) IsLocal() bool {
 	return ok
 }
 
+func (m Manifest) SyncTarget() SyncTarget {
+	ret, _ := m.DeployTarget.(SyncTarget)
+	return ret
+}
+
+func (m Manifest) IsSync() bool {
+	_, ok := m.DeployTarget.(SyncTarget)
+	return ok
+}
+
 func (m Manifest) DockerComposeTarget() DockerComposeTarget {
 	ret, _ := m.DeployTarget.(DockerComposeTarget)
 	return ret
@@ -219,6 +250,8 @@ func (m Manifest) LocalPaths() []string {
 		return di.LocalPaths()
 	case LocalTarget:
 		return di.Dependencies()
+	case SyncTarget:
+		return di.Dependencies()
 	case ImageTarget, K8sTarget:
 		// fall through to paths for image targets, below
 	}
@@ -237,6 +270,29 @@ func (m Manifest) WithLabels(labels map[string]string) Manifest {
 	return m
 }
 
+func (m Manifest) WithAnnotations(annotations map[string]string) Manifest {
+	m.Annotations = make(map[string]string)
+	for k, v := range annotations {
+		m.Annotations[k] = v
+	}
+	return m
+}
+
+func (m Manifest) WithLinks(links []Link) Manifest {
+	m.Links = append([]Link{}, links...)
+	return m
+}
+
+func (m Manifest) WithWatchInCI(watchInCI bool) Manifest {
+	m.WatchInCI = watchInCI
+	return m
+}
+
+func (m Manifest) WithBuildTimeout(timeout time.Duration) Manifest {
+	m.BuildTimeout = &timeout
+	return m
+}
+
 func (m Manifest) Validate() error {
 	if m.Name == "" {
 		return fmt.Errorf("[validate] manifest missing name: %+v", m)
@@ -355,6 +411,17 @@ func (m1 Manifest) fieldGroupsEqualForBuildInvalidation(m2 Manifest) (dockerEq,
 	return dockerEq, dcEq, k8sEq, localEq
 }
 
+// All the local paths that this manifest's targets build from.
+func (m Manifest) InputPaths() []string {
+	var result []string
+	for _, t := range m.TargetSpecs() {
+		if t, ok := t.(interface{ Dependencies() []string }); ok {
+			result = append(result, t.Dependencies()...)
+		}
+	}
+	return sliceutils.DedupedAndSorted(result)
+}
+
 func (m Manifest) ManifestName() ManifestName {
 	return m.Name
 }
@@ -431,6 +498,10 @@ type PortForward struct {
 	// want "localhost:xxxx/v1/app")
 	// (Private with getter/setter b/c may be nil.)
 	path *url.URL
+
+	// Optional check that the forward reaches a listening server
+	// before we show its link as ready.
+	Verify *v1alpha1.ForwardVerify
 }
 
 func (pf PortForward) PathForAppend() string {
@@ -527,14 +598,29 @@ var dcTargetAllowUnexported = cmp.AllowUnexported(DockerComposeTarget{})
 var labelRequirementAllowUnexported = cmp.AllowUnexported(labels.Requirement{})
 var k8sTargetAllowUnexported = cmp.AllowUnexported(K8sTarget{})
 var localTargetAllowUnexported = cmp.AllowUnexported(LocalTarget{})
+var syncTargetAllowUnexported = cmp.AllowUnexported(SyncTarget{})
 var selectorAllowUnexported = cmp.AllowUnexported(container.RefSelector{})
 var refSetAllowUnexported = cmp.AllowUnexported(container.RefSet{})
 var registryAllowUnexported = cmp.AllowUnexported(container.Registry{})
 var portForwardPathAllowUnexported = cmp.AllowUnexported(PortForward{})
 var ignoreCustomBuildDepsField = cmpopts.IgnoreFields(CustomBuild{}, "Deps")
-var ignoreLocalTargetDepsField = cmpopts.IgnoreFields(LocalTarget{}, "Deps")
+var ignoreLocalTargetDepsField = cmpopts.IgnoreFields(LocalTarget{}, "Deps", "Outputs")
 var ignoreDockerBuildCacheFrom = cmpopts.IgnoreFields(DockerBuild{}, "CacheFrom")
+var ignoreDockerfilePath = cmpopts.IgnoreFields(DockerBuild{}, "DockerfilePath")
+
+// Compare the build args that can change the image, rather than everything
+// passed to the build.
+var dockerBuildEffectiveArgs = cmpopts.AcyclicTransformer("EffectiveBuildArgs", func(db DockerBuild) DockerBuild {
+	db.BuildArgs = db.EffectiveBuildArgs()
+	db.ResolvedBuildArgs = nil
+	return db
+})
 var ignoreLabels = cmpopts.IgnoreFields(Manifest{}, "Labels")
+var ignoreWatchInCI = cmpopts.IgnoreFields(Manifest{}, "WatchInCI")
+var ignoreImageWatchInCI = cmpopts.IgnoreFields(ImageTarget{}, "WatchInCI")
+var ignoreBuildTimeout = cmpopts.IgnoreFields(Manifest{}, "BuildTimeout")
+var ignoreAnnotations = cmpopts.IgnoreFields(Manifest{}, "Annotations")
+var ignoreK8sDebugOverride = cmpopts.IgnoreFields(K8sTarget{}, "DebugOverride")
 var ignoreDockerComposeProject = cmpopts.IgnoreFields(DockerComposeUpSpec{}, "Project")
 
 // ignoreLinks ignores user-defined links for the purpose of build invalidation
@@ -566,13 +652,14 @@ func equalForBuildInvalidation(x, y interface{}) bool {
 		labelRequirementAllowUnexported,
 		k8sTargetAllowUnexported,
 		localTargetAllowUnexported,
+		syncTargetAllowUnexported,
 		selectorAllowUnexported,
 		refSetAllowUnexported,
 		registryAllowUnexported,
 		portForwardPathAllowUnexported,
 		dockerRefEqual,
 
-		// deps changes don't invalidate a build, so don't compare fields used only for deps
+		// deps and outputs changes don't invalidate a build, so don't compare fields used only for deps
 		ignoreCustomBuildDepsField,
 		ignoreLocalTargetDepsField,
 
@@ -580,8 +667,25 @@ func equalForBuildInvalidation(x, y interface{}) bool {
 		// shouldn't affect the result of the build), so don't compare these fields
 		ignoreDockerBuildCacheFrom,
 
-		// user-added labels don't invalidate a build
+		// we compare the Dockerfile contents, not where they came from
+		ignoreDockerfilePath,
+
+		// build args that the Dockerfile doesn't use don't invalidate a build
+		dockerBuildEffectiveArgs,
+
+		// user-added labels and annotations don't invalidate a build
 		ignoreLabels,
+		ignoreAnnotations,
+
+		// whether we watch files in CI doesn't invalidate a build
+		ignoreWatchInCI,
+		ignoreImageWatchInCI,
+
+		// how long we let a build run doesn't change what it builds
+		ignoreBuildTimeout,
+
+		// debug overrides are toggled at runtime, and trigger their own redeploys
+		ignoreK8sDebugOverride,
 
 		// user-added links don't invalidate a build
 		ignoreLinks,
@@ -102,6 +102,30 @@ var equalitytests = []struct {
 		Manifest{}.WithImageTarget(ImageTarget{}.WithBuildDetails(DockerBuild{BuildArgs: buildArgs1})),
 		false,
 	},
+	{
+		"DockerBuild.BuildArgs unequal but unused",
+		Manifest{}.WithImageTarget(ImageTarget{}.WithBuildDetails(DockerBuild{
+			BuildArgs:         buildArgs1,
+			ResolvedBuildArgs: DockerBuildArgs{"foo": "bar"},
+		})),
+		Manifest{}.WithImageTarget(ImageTarget{}.WithBuildDetails(DockerBuild{
+			BuildArgs:         buildArgs2,
+			ResolvedBuildArgs: DockerBuildArgs{"foo": "bar"},
+		})),
+		false,
+	},
+	{
+		"DockerBuild.ResolvedBuildArgs unequal",
+		Manifest{}.WithImageTarget(ImageTarget{}.WithBuildDetails(DockerBuild{
+			BuildArgs:         buildArgs1,
+			ResolvedBuildArgs: DockerBuildArgs{"foo": "bar"},
+		})),
+		Manifest{}.WithImageTarget(ImageTarget{}.WithBuildDetails(DockerBuild{
+			BuildArgs:         buildArgs1,
+			ResolvedBuildArgs: DockerBuildArgs{"foo": "default"},
+		})),
+		true,
+	},
 	{
 		"ImageTarget.ConfigurationRef unequal",
 		Manifest{}.WithImageTarget(ImageTarget{Refs: container.RefSet{ConfigurationRef: img1}}),
@@ -204,12 +228,30 @@ var equalitytests = []struct {
 		Manifest{}.WithImageTarget(ImageTarget{}.WithBuildDetails(DockerBuild{CacheFrom: []string{"bar", "quux"}})),
 		false,
 	},
+	{
+		"DockerBuild.DockerfilePath unequal and doesn't invalidate",
+		Manifest{}.WithImageTarget(ImageTarget{}.WithBuildDetails(DockerBuild{DockerfilePath: "Dockerfile"})),
+		Manifest{}.WithImageTarget(ImageTarget{}.WithBuildDetails(DockerBuild{DockerfilePath: ""})),
+		false,
+	},
 	{
 		"labels unequal and doesn't invalidate",
 		Manifest{}.WithLabels(map[string]string{"foo": "bar"}),
 		Manifest{}.WithLabels(map[string]string{"foo": "baz"}),
 		false,
 	},
+	{
+		"annotations unequal and doesn't invalidate",
+		Manifest{}.WithAnnotations(map[string]string{"example.com/team": "a"}),
+		Manifest{}.WithAnnotations(map[string]string{"example.com/team": "b"}),
+		false,
+	},
+	{
+		"resource links unequal and doesn't invalidate",
+		Manifest{}.WithLinks([]Link{{Name: "dashboard", URL: mustURL("http://localhost:3000")}}),
+		Manifest{}.WithLinks([]Link{{Name: "dashboard", URL: mustURL("http://localhost:3001")}}),
+		false,
+	},
 	{
 		"Links unequal and doesn't invalidate",
 		Manifest{}.WithDeployTarget(NewLocalTarget("foo", Cmd{}, Cmd{}, nil).WithLinks([]Link{
@@ -0,0 +1,111 @@
+package model
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/tilt-dev/tilt/internal/sliceutils"
+)
+
+// A resource that only syncs files, without building or deploying anything.
+//
+// The destination is either a container in a pod that Tilt doesn't manage
+// (selected by label), or a directory on the local filesystem.
+type SyncTarget struct {
+	Name TargetName
+
+	// Each sync copies an ABSOLUTE local path to a destination path.
+	// The destination is a path in the container, or an ABSOLUTE local path
+	// if the target doesn't have a pod selector.
+	Syncs []Sync
+
+	// Labels that select the pod to sync to. If empty, we sync to a local directory.
+	PodSelector map[string]string
+
+	// The container to sync to. Only needed if the pod has more than one container.
+	Container string
+
+	// The namespace of the pod. Defaults to the default namespace.
+	Namespace string
+
+	ignores []Dockerignore
+	repos   []LocalGitRepo
+}
+
+var _ TargetSpec = SyncTarget{}
+
+func NewSyncTarget(name TargetName, syncs []Sync) SyncTarget {
+	return SyncTarget{
+		Name:  name,
+		Syncs: syncs,
+	}
+}
+
+func (t SyncTarget) WithPodSelector(selector map[string]string, container string, namespace string) SyncTarget {
+	t.PodSelector = selector
+	t.Container = container
+	t.Namespace = namespace
+	return t
+}
+
+func (t SyncTarget) WithRepos(repos []LocalGitRepo) SyncTarget {
+	t.repos = append(append([]LocalGitRepo{}, t.repos...), repos...)
+	return t
+}
+
+func (t SyncTarget) WithIgnores(ignores []Dockerignore) SyncTarget {
+	t.ignores = ignores
+	return t
+}
+
+// Whether we sync to a local directory, rather than into a container.
+func (t SyncTarget) IsLocalDest() bool {
+	return len(t.PodSelector) == 0
+}
+
+func (t SyncTarget) ID() TargetID {
+	return TargetID{
+		Name: t.Name,
+		Type: TargetTypeSync,
+	}
+}
+
+func (t SyncTarget) DependencyIDs() []TargetID {
+	return nil
+}
+
+func (t SyncTarget) Validate() error {
+	if len(t.Syncs) == 0 {
+		return fmt.Errorf("[Validate] SyncTarget %s has no files to sync", t.Name)
+	}
+	for _, s := range t.Syncs {
+		if !filepath.IsAbs(s.LocalPath) {
+			return fmt.Errorf("[Validate] SyncTarget %s: sync source must be an absolute path: %s", t.Name, s.LocalPath)
+		}
+		if t.IsLocalDest() && !filepath.IsAbs(s.ContainerPath) {
+			return fmt.Errorf("[Validate] SyncTarget %s: sync destination must be an absolute path: %s", t.Name, s.ContainerPath)
+		}
+	}
+	return nil
+}
+
+// Implements: engine.WatchableManifest
+func (t SyncTarget) Dependencies() []string {
+	deps := make([]string, 0, len(t.Syncs))
+	for _, s := range t.Syncs {
+		deps = append(deps, s.LocalPath)
+	}
+	return sliceutils.DedupedAndSorted(deps)
+}
+
+func (t SyncTarget) LocalRepos() []LocalGitRepo {
+	return t.repos
+}
+
+func (t SyncTarget) Dockerignores() []Dockerignore {
+	return t.ignores
+}
+
+func (t SyncTarget) IgnoredLocalDirectories() []string {
+	return nil
+}
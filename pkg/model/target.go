@@ -29,6 +29,9 @@ const (
 	// Runs a local command when triggered (manually or via changed dep)
 	TargetTypeLocal TargetType = "local"
 
+	// Syncs files to a container or a local directory when a dep changes
+	TargetTypeSync TargetType = "sync"
+
 	// Aggregation of multiple targets into one UI view.
 	// TODO(nick): Currently used as the type for both Manifest and YAMLManifest, though
 	// we expect YAMLManifest to go away.
@@ -0,0 +1,17 @@
+package model
+
+// A warning from Tiltfile execution, e.g., use of a deprecated API.
+type TiltfileWarning struct {
+	// Identifies the warning across Tiltfile loads, so that we only announce
+	// it once.
+	Key string
+
+	Message string
+
+	// The resource the warning is about, if any. Warnings without a resource
+	// are about the Tiltfile as a whole.
+	ManifestName ManifestName
+
+	// Where in the Tiltfile the warning came from, as file:line:col, if known.
+	Location string
+}
@@ -8,6 +8,19 @@ import (
 
 const (
 	DefaultMaxParallelUpdates = 3
+
+	// Warn when an image build sends a context bigger than this to the builder.
+	DefaultBuildContextWarnSize = 500 * 1000 * 1000
+
+	// The image for debug containers attached to a resource's pod.
+	DefaultDebugContainerImage = "busybox:1.35"
+
+	// Drop container log lines beyond this many bytes per second, per container.
+	DefaultPodLogRateLimit = 1000 * 1000
+
+	// When all the containers together log more than this many bytes per
+	// second, tighten the per-container limits to match.
+	DefaultPodLogTotalRateLimit = 5 * 1000 * 1000
 )
 
 type UpdateSettings struct {
@@ -16,6 +29,33 @@ type UpdateSettings struct {
 
 	// A list of images to suppress the warning for.
 	SuppressUnusedImageWarnings []string
+
+	// Delete Kubernetes objects that Tilt applied for manifests that no longer
+	// exist, rather than only warning about them.
+	K8sDeleteOrphans bool
+
+	// Warn when an image build context is bigger than this many bytes.
+	// 0 turns off the warning.
+	BuildContextWarnSize int64
+
+	// The image for ephemeral debug containers, when the user doesn't pick one.
+	DebugContainerImage string
+
+	// On startup, let a resource start its first update before its
+	// resource_deps are ready, as long as nothing else is ready to update.
+	RelaxedStartupOrder bool
+
+	// Cancel an update that runs longer than this.
+	// 0 means updates can run forever.
+	BuildTimeout time.Duration
+
+	// The most bytes per second of logs to keep from each container.
+	// 0 means no limit.
+	PodLogRateLimit int64
+
+	// The most bytes per second of logs to keep from all containers together.
+	// 0 means no limit.
+	PodLogTotalRateLimit int64
 }
 
 func (us UpdateSettings) MaxParallelUpdates() int {
@@ -52,9 +92,23 @@ func (us UpdateSettings) WithK8sUpsertTimeout(timeout time.Duration) UpdateSetti
 	return us
 }
 
+// The build timeout for the given manifest, taking its override into account.
+// 0 means no timeout.
+func (us UpdateSettings) BuildTimeoutFor(m Manifest) time.Duration {
+	if m.BuildTimeout != nil {
+		return *m.BuildTimeout
+	}
+	return us.BuildTimeout
+}
+
 func DefaultUpdateSettings() UpdateSettings {
 	return UpdateSettings{
 		maxParallelUpdates: DefaultMaxParallelUpdates,
 		k8sUpsertTimeout:   v1alpha1.KubernetesApplyTimeoutDefault,
+
+		BuildContextWarnSize: DefaultBuildContextWarnSize,
+		DebugContainerImage:  DefaultDebugContainerImage,
+		PodLogRateLimit:      DefaultPodLogRateLimit,
+		PodLogTotalRateLimit: DefaultPodLogTotalRateLimit,
 	}
 }
@@ -1,7 +1,20 @@
 package model
 
+import "time"
+
+// By default, wait this long after the last change to the Tiltfile
+// (or a file it loads) before reloading it.
+//
+// Editors often save several files in a row. The settle delay lets us
+// reload once with all the changes, rather than once per file.
+const DefaultTiltfileSettleDelay = 300 * time.Millisecond
+
 type WatchSettings struct {
 	Ignores []Dockerignore
+
+	// How long to wait for changes to stop before reloading the Tiltfile.
+	// If nil, uses DefaultTiltfileSettleDelay.
+	TiltfileSettleDelay *time.Duration
 }
 
 func (ws WatchSettings) Empty() bool {
@@ -74,6 +74,7 @@ var _ flag.Value = &emptyWebMode
 var _ pflag.Value = &emptyWebMode
 
 const DefaultWebPort = 10350
+const DefaultWebDevPort = 46764
 
 type WebHost string
 type WebPort int
@@ -51,6 +51,7 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.DisableResourceStatus":           schema_pkg_apis_core_v1alpha1_DisableResourceStatus(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.DisableSource":                   schema_pkg_apis_core_v1alpha1_DisableSource(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.DisableStatus":                   schema_pkg_apis_core_v1alpha1_DisableStatus(ref),
+		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.DisableTransition":               schema_pkg_apis_core_v1alpha1_DisableTransition(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.ExecAction":                      schema_pkg_apis_core_v1alpha1_ExecAction(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.Extension":                       schema_pkg_apis_core_v1alpha1_Extension(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.ExtensionList":                   schema_pkg_apis_core_v1alpha1_ExtensionList(ref),
@@ -67,6 +68,7 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.FileWatchStatus":                 schema_pkg_apis_core_v1alpha1_FileWatchStatus(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.Forward":                         schema_pkg_apis_core_v1alpha1_Forward(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.ForwardStatus":                   schema_pkg_apis_core_v1alpha1_ForwardStatus(ref),
+		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.ForwardVerify":                   schema_pkg_apis_core_v1alpha1_ForwardVerify(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.HTTPGetAction":                   schema_pkg_apis_core_v1alpha1_HTTPGetAction(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.HTTPHeader":                      schema_pkg_apis_core_v1alpha1_HTTPHeader(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.Handler":                         schema_pkg_apis_core_v1alpha1_Handler(ref),
@@ -120,6 +122,8 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.Session":                         schema_pkg_apis_core_v1alpha1_Session(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.SessionList":                     schema_pkg_apis_core_v1alpha1_SessionList(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.SessionSpec":                     schema_pkg_apis_core_v1alpha1_SessionSpec(ref),
+		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.SessionResourceCounts":           schema_pkg_apis_core_v1alpha1_SessionResourceCounts(ref),
+		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.SessionResourceStatus":           schema_pkg_apis_core_v1alpha1_SessionResourceStatus(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.SessionStatus":                   schema_pkg_apis_core_v1alpha1_SessionStatus(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.StartOnSpec":                     schema_pkg_apis_core_v1alpha1_StartOnSpec(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.StateSource":                     schema_pkg_apis_core_v1alpha1_StateSource(ref),
@@ -137,6 +141,7 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.TiltfileStateTerminated":         schema_pkg_apis_core_v1alpha1_TiltfileStateTerminated(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.TiltfileStateWaiting":            schema_pkg_apis_core_v1alpha1_TiltfileStateWaiting(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.TiltfileStatus":                  schema_pkg_apis_core_v1alpha1_TiltfileStatus(ref),
+		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.TiltfileWatchedGlob":             schema_pkg_apis_core_v1alpha1_TiltfileWatchedGlob(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.ToggleButton":                    schema_pkg_apis_core_v1alpha1_ToggleButton(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.ToggleButtonList":                schema_pkg_apis_core_v1alpha1_ToggleButtonList(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.ToggleButtonSpec":                schema_pkg_apis_core_v1alpha1_ToggleButtonSpec(ref),
@@ -144,20 +149,31 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.ToggleButtonStatus":              schema_pkg_apis_core_v1alpha1_ToggleButtonStatus(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIBoolInputSpec":                 schema_pkg_apis_core_v1alpha1_UIBoolInputSpec(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIBoolInputStatus":               schema_pkg_apis_core_v1alpha1_UIBoolInputStatus(ref),
+		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIBuildCacheStep":                schema_pkg_apis_core_v1alpha1_UIBuildCacheStep(ref),
+		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIBuildLatency":                  schema_pkg_apis_core_v1alpha1_UIBuildLatency(ref),
+		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIBuildLatencyPhase":             schema_pkg_apis_core_v1alpha1_UIBuildLatencyPhase(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIBuildRunning":                  schema_pkg_apis_core_v1alpha1_UIBuildRunning(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIBuildTerminated":               schema_pkg_apis_core_v1alpha1_UIBuildTerminated(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIButton":                        schema_pkg_apis_core_v1alpha1_UIButton(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIButtonList":                    schema_pkg_apis_core_v1alpha1_UIButtonList(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIButtonSpec":                    schema_pkg_apis_core_v1alpha1_UIButtonSpec(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIButtonStatus":                  schema_pkg_apis_core_v1alpha1_UIButtonStatus(ref),
+		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIChoiceInputSpec":               schema_pkg_apis_core_v1alpha1_UIChoiceInputSpec(ref),
+		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIChoiceInputStatus":             schema_pkg_apis_core_v1alpha1_UIChoiceInputStatus(ref),
+		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIClusterConnection":             schema_pkg_apis_core_v1alpha1_UIClusterConnection(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIComponentLocation":             schema_pkg_apis_core_v1alpha1_UIComponentLocation(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIComponentLocationResource":     schema_pkg_apis_core_v1alpha1_UIComponentLocationResource(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIFeatureFlag":                   schema_pkg_apis_core_v1alpha1_UIFeatureFlag(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIHiddenInputSpec":               schema_pkg_apis_core_v1alpha1_UIHiddenInputSpec(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIHiddenInputStatus":             schema_pkg_apis_core_v1alpha1_UIHiddenInputStatus(ref),
+		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIImageBuildCache":               schema_pkg_apis_core_v1alpha1_UIImageBuildCache(ref),
+		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIImageRegistry":                 schema_pkg_apis_core_v1alpha1_UIImageRegistry(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIInputSpec":                     schema_pkg_apis_core_v1alpha1_UIInputSpec(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIInputStatus":                   schema_pkg_apis_core_v1alpha1_UIInputStatus(ref),
+		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIMemoryConsumer":                schema_pkg_apis_core_v1alpha1_UIMemoryConsumer(ref),
+		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIMemoryUsage":                   schema_pkg_apis_core_v1alpha1_UIMemoryUsage(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIResource":                      schema_pkg_apis_core_v1alpha1_UIResource(ref),
+		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIResourceCondition":             schema_pkg_apis_core_v1alpha1_UIResourceCondition(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIResourceKubernetes":            schema_pkg_apis_core_v1alpha1_UIResourceKubernetes(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIResourceLink":                  schema_pkg_apis_core_v1alpha1_UIResourceLink(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIResourceList":                  schema_pkg_apis_core_v1alpha1_UIResourceList(ref),
@@ -173,6 +189,7 @@ func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenA
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UISessionStatus":                 schema_pkg_apis_core_v1alpha1_UISessionStatus(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UITextInputSpec":                 schema_pkg_apis_core_v1alpha1_UITextInputSpec(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UITextInputStatus":               schema_pkg_apis_core_v1alpha1_UITextInputStatus(ref),
+		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UITiltfileWarning":               schema_pkg_apis_core_v1alpha1_UITiltfileWarning(ref),
 		"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.VersionSettings":                 schema_pkg_apis_core_v1alpha1_VersionSettings(ref),
 		"k8s.io/apimachinery/pkg/apis/meta/v1.APIGroup":                                   schema_pkg_apis_meta_v1_APIGroup(ref),
 		"k8s.io/apimachinery/pkg/apis/meta/v1.APIGroupList":                               schema_pkg_apis_meta_v1_APIGroupList(ref),
@@ -393,6 +410,19 @@ func schema_pkg_apis_core_v1alpha1_CmdSpec(ref common.ReferenceCallback) common.
 							Ref:         ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.DisableSource"),
 						},
 					},
+					"logLevelFormat": {
+						SchemaProps: spec.SchemaProps{
+							Description: "How to read log levels from each line of output, so that logs can be filtered by level. One of: prefix, logrus, zap.\n\nIf not set, all output is logged at the INFO level.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"livenessProbe": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Periodic probe of whether the process is still healthy.\n\nUnlike the readiness probe, which only reports whether the process is ready to serve, a process that fails its liveness probe FailureThreshold times in a row is considered hung, and is marked on its Running state so that its owner can restart it.",
+							Ref:         ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.Probe"),
+						},
+					},
 				},
 			},
 		},
@@ -423,6 +453,20 @@ func schema_pkg_apis_core_v1alpha1_CmdStateRunning(ref common.ReferenceCallback)
 							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime"),
 						},
 					},
+					"startReason": {
+						SchemaProps: spec.SchemaProps{
+							Description: "What triggered this run, if it was restarted by one of its RestartOn objects (e.g., \"api became ready at 12:03:04\").",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"livenessFailure": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Set when the process has failed its liveness probe enough times in a row that it should be restarted. Describes the most recent failure.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 				},
 				Required: []string{"pid"},
 			},
@@ -476,6 +520,13 @@ func schema_pkg_apis_core_v1alpha1_CmdStateTerminated(ref common.ReferenceCallba
 							Format:      "",
 						},
 					},
+					"startReason": {
+						SchemaProps: spec.SchemaProps{
+							Description: "What triggered this run, if it was restarted by one of its RestartOn objects (e.g., \"api became ready at 12:03:04\").",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 				},
 				Required: []string{"pid", "exitCode"},
 			},
@@ -794,6 +845,13 @@ func schema_pkg_apis_core_v1alpha1_Container(ref common.ReferenceCallback) commo
 							},
 						},
 					},
+					"imageID": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ImageID is the ID of the image the container is running, as reported by the container runtime (e.g., `docker-pullable://my-img@sha256:...`).",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 				},
 				Required: []string{"name", "id", "ready", "image", "restarts", "state", "ports"},
 			},
@@ -838,6 +896,20 @@ func schema_pkg_apis_core_v1alpha1_ContainerLogStreamStatus(ref common.Reference
 							Format:      "",
 						},
 					},
+					"rateLimited": {
+						SchemaProps: spec.SchemaProps{
+							Description: "True when the container logged faster than its rate limit, so some lines were dropped. The logs that made it through are a sample.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"droppedLines": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The number of log lines dropped by the rate limit.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
 				},
 			},
 		},
@@ -1005,12 +1077,33 @@ func schema_pkg_apis_core_v1alpha1_DisableResourceStatus(ref common.ReferenceCal
 							},
 						},
 					},
+					"source": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Where the most recent change to the resource's disable state came from.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"transitions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The most recent changes to the resource's disable state, oldest first.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.DisableTransition"),
+									},
+								},
+							},
+						},
+					},
 				},
 				Required: []string{"enabledCount", "disabledCount", "sources"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.DisableSource"},
+			"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.DisableSource", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.DisableTransition"},
 	}
 }
 
@@ -1073,6 +1166,45 @@ func schema_pkg_apis_core_v1alpha1_DisableStatus(ref common.ReferenceCallback) c
 	}
 }
 
+func schema_pkg_apis_core_v1alpha1_DisableTransition(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "A change to whether a resource is disabled.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"disabled": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Whether the resource was disabled (or enabled) by this change.",
+							Default:     false,
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"source": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Where the change came from.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"time": {
+						SchemaProps: spec.SchemaProps{
+							Description: "When the change happened.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime"),
+						},
+					},
+				},
+				Required: []string{"disabled", "source", "time"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime"},
+	}
+}
+
 func schema_pkg_apis_core_v1alpha1_ExecAction(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -1721,10 +1853,18 @@ func schema_pkg_apis_core_v1alpha1_Forward(ref common.ReferenceCallback) common.
 							Format:      "",
 						},
 					},
+					"verify": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Checks that the forward reaches a server that's listening.\n\nA forward can connect before the server in the pod is listening. If set, Tilt probes the local port after each (re)connection, and only reports the forward as verified once the probe passes.",
+							Ref:         ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.ForwardVerify"),
+						},
+					},
 				},
 				Required: []string{"containerPort"},
 			},
 		},
+		Dependencies: []string{
+			"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.ForwardVerify"},
 	}
 }
 
@@ -1779,6 +1919,27 @@ func schema_pkg_apis_core_v1alpha1_ForwardStatus(ref common.ReferenceCallback) c
 							Format:      "",
 						},
 					},
+					"disabled": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Disabled is true if the forward was turned off at runtime.\n\nDisabled forwards stay off when their pod is replaced, until they're turned back on or Tilt exits.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"verifiedAt": {
+						SchemaProps: spec.SchemaProps{
+							Description: "VerifiedAt is the time the forward passed its verification probe.\n\nEmpty if the forward has no verification configured, or if it hasn't passed since it last (re)connected.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime"),
+						},
+					},
+					"transport": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Transport is how the forward reaches the cluster: \"websocket\" or \"spdy\".\n\nTilt tries websockets first, falls back to SPDY, and remembers what worked for each cluster.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 				},
 				Required: []string{"localPort", "containerPort", "addresses"},
 			},
@@ -1788,6 +1949,26 @@ func schema_pkg_apis_core_v1alpha1_ForwardStatus(ref common.ReferenceCallback) c
 	}
 }
 
+func schema_pkg_apis_core_v1alpha1_ForwardVerify(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "ForwardVerify describes how to check that a forward reaches a server.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"httpPath": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Path for an HTTP GET against the local port. Any response with a status below 400 passes.\n\nIf empty, Tilt opens a TCP connection to the local port instead, and the probe passes if the forward doesn't immediately close it.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_core_v1alpha1_HTTPGetAction(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -1838,6 +2019,13 @@ func schema_pkg_apis_core_v1alpha1_HTTPGetAction(ref common.ReferenceCallback) c
 							},
 						},
 					},
+					"insecureSkipTLSVerify": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Skip verifying the server's certificate on HTTPS probes. Useful for local servers with self-signed certificates.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
 				},
 				Required: []string{"port"},
 			},
@@ -2178,6 +2366,13 @@ func schema_pkg_apis_core_v1alpha1_ImageMapStatus(ref common.ReferenceCallback)
 							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime"),
 						},
 					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "A human-readable note about how the image was produced.\n\nFor example, \"unchanged, reused tag\" when the builder found an existing image with a content-addressed tag for the same inputs, and skipped the build.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 				},
 				Required: []string{"image"},
 			},
@@ -2427,6 +2622,20 @@ func schema_pkg_apis_core_v1alpha1_KubernetesApplySpec(ref common.ReferenceCallb
 							Ref:         ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.RestartOnSpec"),
 						},
 					},
+					"applyDiscipline": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ApplyDiscipline describes when we re-apply the YAML to the cluster.\n\nObjects can also opt into the infrastructure discipline individually with the annotation 'tilt.dev/apply-discipline: infrastructure'.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"namespace": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Namespace overrides the namespace of every namespaced object in the YAML.\n\nCluster-scoped objects are left alone. RoleBinding and ClusterRoleBinding subjects that point at a namespace in the same YAML are moved too.\n\nOnly supported with YAML, not with a custom apply command.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 				},
 			},
 		},
@@ -2483,6 +2692,13 @@ func schema_pkg_apis_core_v1alpha1_KubernetesApplyStatus(ref common.ReferenceCal
 							Ref:         ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.DisableStatus"),
 						},
 					},
+					"debugOverride": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The debug override that the last apply injected, if any (e.g., \"container=app, readiness probe removed\").\n\nEmpty once an apply restores the original spec.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 				},
 			},
 		},
@@ -3211,6 +3427,21 @@ func schema_pkg_apis_core_v1alpha1_LiveUpdateSpec(ref common.ReferenceCallback)
 							Format:      "",
 						},
 					},
+					"restartPaths": {
+						SchemaProps: spec.SchemaProps{
+							Description: "A list of relative paths that only need the container restarted.\n\nFiles that match these paths (and no exec's trigger paths) are synced, then the container is restarted, without running any execs.\n\nA file that matches both these paths and an exec's trigger paths runs the exec.\n\nNote that native restarts are only supported by Docker and Docker Compose.\n\nPaths are specified relative to the the BasePath of the LiveUpdate.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
 				},
 				Required: []string{"basePath", "selector"},
 			},
@@ -3393,6 +3624,13 @@ func schema_pkg_apis_core_v1alpha1_Pod(ref common.ReferenceCallback) common.Open
 							Format:      "",
 						},
 					},
+					"nodeName": {
+						SchemaProps: spec.SchemaProps{
+							Description: "NodeName is the node the Pod is scheduled on, if it's been scheduled.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 					"createdAt": {
 						SchemaProps: spec.SchemaProps{
 							Description: "CreatedAt is when the Pod was created.",
@@ -3710,6 +3948,13 @@ func schema_pkg_apis_core_v1alpha1_PodLogStreamSpec(ref common.ReferenceCallback
 							},
 						},
 					},
+					"logLevelFormat": {
+						SchemaProps: spec.SchemaProps{
+							Description: "How to read log levels from each line of output, so that logs can be filtered by level. One of: prefix, logrus, zap.\n\nIf not set, all output is logged at the INFO level.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 				},
 			},
 		},
@@ -3790,6 +4035,13 @@ func schema_pkg_apis_core_v1alpha1_PodLogStreamTemplateSpec(ref common.Reference
 							},
 						},
 					},
+					"logLevelFormat": {
+						SchemaProps: spec.SchemaProps{
+							Description: "How to read log levels from each line of output, so that logs can be filtered by level. One of: prefix, logrus, zap.\n\nIf not set, all output is logged at the INFO level.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
 				},
 			},
 		},
@@ -4102,6 +4354,21 @@ func schema_pkg_apis_core_v1alpha1_RestartOnSpec(ref common.ReferenceCallback) c
 							},
 						},
 					},
+					"uiResources": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UIResources that can trigger a restart when they become ready.\n\nEach time one of these resources goes from not ready to ready, it counts as a restart event. If a resource flaps between ready and not ready, the consumer may wait for it to settle and only restart once.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
 				},
 			},
 		},
@@ -4234,90 +4501,252 @@ func schema_pkg_apis_core_v1alpha1_SessionSpec(ref common.ReferenceCallback) com
 	}
 }
 
-func schema_pkg_apis_core_v1alpha1_SessionStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_core_v1alpha1_SessionResourceCounts(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "SessionStatus defines the observed state of Session",
+				Description: "SessionResourceCounts counts the resources in a Session by state.",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"pid": {
+					"total": {
 						SchemaProps: spec.SchemaProps{
-							Description: "PID is the process identifier for this instance of Tilt.",
+							Description: "Total is the number of resources from the Tiltfile.",
 							Default:     0,
 							Type:        []string{"integer"},
-							Format:      "int64",
+							Format:      "int32",
 						},
 					},
-					"startTime": {
+					"ready": {
 						SchemaProps: spec.SchemaProps{
-							Description: "StartTime is when the Tilt engine was first started.",
-							Default:     map[string]interface{}{},
-							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime"),
+							Description: "Ready is the number of resources that are ready.",
+							Default:     0,
+							Type:        []string{"integer"},
+							Format:      "int32",
 						},
 					},
-					"targets": {
+					"inProgress": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Targets are normalized representations of the servers/jobs managed by this Session.\n\nA resource from a Tiltfile might produce one or more targets. A target can also be shared across multiple resources (e.g. an image referenced by multiple K8s pods).",
-							Type:        []string{"array"},
-							Items: &spec.SchemaOrArray{
-								Schema: &spec.Schema{
-									SchemaProps: spec.SchemaProps{
-										Default: map[string]interface{}{},
-										Ref:     ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.Target"),
-									},
-								},
-							},
+							Description: "InProgress is the number of resources that are building or starting up.",
+							Default:     0,
+							Type:        []string{"integer"},
+							Format:      "int32",
 						},
 					},
-					"done": {
+					"error": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Done indicates whether this Session has completed its work and is ready to exit.",
-							Default:     false,
-							Type:        []string{"boolean"},
-							Format:      "",
+							Description: "Error is the number of resources whose build or runtime failed.",
+							Default:     0,
+							Type:        []string{"integer"},
+							Format:      "int32",
 						},
 					},
-					"error": {
+					"inactive": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Error is a non-empty string when the Session is Done but encountered a failure as defined by the ExitCondition from the SessionSpec.",
-							Type:        []string{"string"},
-							Format:      "",
+							Description: "Inactive is the number of resources that haven't been asked to run, e.g., because they're disabled or have auto_init=False.",
+							Default:     0,
+							Type:        []string{"integer"},
+							Format:      "int32",
 						},
 					},
 				},
-				Required: []string{"pid", "startTime", "targets", "done"},
+				Required: []string{"total", "ready", "inProgress", "error", "inactive"},
 			},
 		},
-		Dependencies: []string{
-			"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.Target", "k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime"},
 	}
 }
 
-func schema_pkg_apis_core_v1alpha1_StartOnSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_core_v1alpha1_SessionResourceStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "StartOnSpec indicates the set of objects that can trigger a start/restart of this object.",
+				Description: "SessionResourceStatus is the readiness of one resource in a Session.",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"startAfter": {
+					"name": {
 						SchemaProps: spec.SchemaProps{
-							Description: "StartAfter indicates that events before this time should be ignored.",
-							Default:     map[string]interface{}{},
-							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+							Description: "Name is the name of the resource.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"uiButtons": {
+					"buildComplete": {
 						SchemaProps: spec.SchemaProps{
-							Description: "UIButtons that can trigger a start/restart.",
-							Type:        []string{"array"},
-							Items: &spec.SchemaOrArray{
-								Schema: &spec.Schema{
-									SchemaProps: spec.SchemaProps{
-										Default: "",
-										Type:    []string{"string"},
-										Format:  "",
+							Description: "BuildComplete is true when the resource's update has finished successfully.",
+							Default:     false,
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"ready": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Ready is true when the resource has done everything the CI exit condition waits for.",
+							Default:     false,
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"failed": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Failed is true when the resource's build or runtime failed.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"reason": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Reason is why the resource isn't ready yet, e.g., \"building\" or \"pod Pending\". Empty when the resource is ready.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"name", "buildComplete", "ready"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_core_v1alpha1_SessionStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "SessionStatus defines the observed state of Session",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"pid": {
+						SchemaProps: spec.SchemaProps{
+							Description: "PID is the process identifier for this instance of Tilt.",
+							Default:     0,
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"startTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "StartTime is when the Tilt engine was first started.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime"),
+						},
+					},
+					"targets": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Targets are normalized representations of the servers/jobs managed by this Session.\n\nA resource from a Tiltfile might produce one or more targets. A target can also be shared across multiple resources (e.g. an image referenced by multiple K8s pods).",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.Target"),
+									},
+								},
+							},
+						},
+					},
+					"done": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Done indicates whether this Session has completed its work and is ready to exit.",
+							Default:     false,
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"error": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Error is a non-empty string when the Session is Done but encountered a failure as defined by the ExitCondition from the SessionSpec.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"kubernetesVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "KubernetesVersion is the version reported by the Kubernetes server when the Session started.\n\nEmpty if Tilt couldn't reach a Kubernetes server.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"dockerVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DockerVersion is the Docker engine version reported by the Docker server when the Session started.\n\nEmpty if Tilt couldn't reach a Docker server.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"dockerAPIVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "DockerAPIVersion is the Docker API version reported by the Docker server when the Session started.\n\nEmpty if Tilt couldn't reach a Docker server.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Phase summarizes the state of the whole Session, so that tools wrapping Tilt can watch this one field rather than every resource.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"engineMode": {
+						SchemaProps: spec.SchemaProps{
+							Description: "EngineMode is how Tilt was started: \"up\" or \"ci\".",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"resourceCounts": {
+						SchemaProps: spec.SchemaProps{
+							Description: "ResourceCounts counts the resources from the Tiltfile by state.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.SessionResourceCounts"),
+						},
+					},
+					"resources": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Resources is the readiness of each resource that's been asked to run, with the reason it's not ready yet. Uses the same rules as the CI exit condition.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.SessionResourceStatus"),
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"pid", "startTime", "targets", "done"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.SessionResourceCounts", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.SessionResourceStatus", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.Target", "k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime"},
+	}
+}
+
+func schema_pkg_apis_core_v1alpha1_StartOnSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "StartOnSpec indicates the set of objects that can trigger a start/restart of this object.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"startAfter": {
+						SchemaProps: spec.SchemaProps{
+							Description: "StartAfter indicates that events before this time should be ignored.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"uiButtons": {
+						SchemaProps: spec.SchemaProps{
+							Description: "UIButtons that can trigger a start/restart.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
 									},
 								},
 							},
@@ -4844,11 +5273,25 @@ func schema_pkg_apis_core_v1alpha1_TiltfileStateTerminated(ref common.ReferenceC
 							Format:      "int32",
 						},
 					},
+					"watchedGlobs": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Globs registered with watch_glob(). Files that match them reload the Tiltfile when they change.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.TiltfileWatchedGlob"),
+									},
+								},
+							},
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime"},
+			"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.TiltfileWatchedGlob", "k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime"},
 	}
 }
 
@@ -4905,6 +5348,51 @@ func schema_pkg_apis_core_v1alpha1_TiltfileStatus(ref common.ReferenceCallback)
 	}
 }
 
+func schema_pkg_apis_core_v1alpha1_TiltfileWatchedGlob(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "A glob whose matching files are config files of the Tiltfile.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"pattern": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The absolute pattern, in .dockerignore syntax.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"ignores": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Patterns for files that don't count, even if they match.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"matchCount": {
+						SchemaProps: spec.SchemaProps{
+							Description: "How many files matched when the Tiltfile was loaded.",
+							Default:     0,
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+				},
+				Required: []string{"pattern", "matchCount"},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_core_v1alpha1_ToggleButton(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -5171,65 +5659,179 @@ func schema_pkg_apis_core_v1alpha1_UIBoolInputStatus(ref common.ReferenceCallbac
 	}
 }
 
-func schema_pkg_apis_core_v1alpha1_UIBuildRunning(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_core_v1alpha1_UIBuildCacheStep(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "UIBuildRunning respresents an in-progress build/update in the user interface.",
+				Description: "UIBuildCacheStep is one step of a Dockerfile build.",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"startTime": {
+					"position": {
 						SchemaProps: spec.SchemaProps{
-							Description: "The time when the build started.",
-							Default:     map[string]interface{}{},
-							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime"),
+							Description: "Where the step falls in the build, e.g., \"7/12\" or \"builder 4/7\".",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
 						},
 					},
-					"spanID": {
+					"text": {
 						SchemaProps: spec.SchemaProps{
-							Description: "The log span where the build logs are stored in the logstore.",
+							Description: "The Dockerfile instruction, truncated.",
+							Default:     "",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
+					"cached": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Whether the image builder reused the step from its cache.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"duration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "How long the step ran, if it wasn't cached.\n\nBuildKit runs independent steps in parallel, so durations may overlap and don't add up to the build time.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+						},
+					},
 				},
+				Required: []string{"position", "text"},
 			},
 		},
 		Dependencies: []string{
-			"k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime"},
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Duration"},
 	}
 }
 
-func schema_pkg_apis_core_v1alpha1_UIBuildTerminated(ref common.ReferenceCallback) common.OpenAPIDefinition {
+func schema_pkg_apis_core_v1alpha1_UIBuildLatency(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
-				Description: "UIBuildRunning respresents a finished build/update in the user interface.",
+				Description: "UIBuildLatency breaks down the time between a file change and a ready resource.",
 				Type:        []string{"object"},
 				Properties: map[string]spec.Schema{
-					"error": {
+					"total": {
 						SchemaProps: spec.SchemaProps{
-							Description: "A non-empty string if the build failed with an error.",
-							Type:        []string{"string"},
-							Format:      "",
+							Description: "The total time, from the triggering file change (or the build start, if the build wasn't triggered by a file change) until the resource was ready.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
 						},
 					},
-					"warnings": {
+					"phases": {
 						SchemaProps: spec.SchemaProps{
-							Description: "A list of warnings encountered while running the build. These warnings will also be printed to the build's log.",
+							Description: "The phases of the update, in order.\n\nFull deploys go through build, deploy, rollout, and ready. Live updates go through sync, and restart if the container restarted.",
 							Type:        []string{"array"},
 							Items: &spec.SchemaOrArray{
 								Schema: &spec.Schema{
 									SchemaProps: spec.SchemaProps{
-										Default: "",
-										Type:    []string{"string"},
-										Format:  "",
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIBuildLatencyPhase"),
 									},
 								},
 							},
 						},
 					},
-					"startTime": {
+				},
+				Required: []string{"total"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIBuildLatencyPhase", "k8s.io/apimachinery/pkg/apis/meta/v1.Duration"},
+	}
+}
+
+func schema_pkg_apis_core_v1alpha1_UIBuildLatencyPhase(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "UIBuildLatencyPhase is one phase of an update.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The name of the phase.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"duration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "How long the phase took.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Duration"),
+						},
+					},
+				},
+				Required: []string{"name", "duration"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Duration"},
+	}
+}
+
+func schema_pkg_apis_core_v1alpha1_UIBuildRunning(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "UIBuildRunning respresents an in-progress build/update in the user interface.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"startTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The time when the build started.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime"),
+						},
+					},
+					"spanID": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The log span where the build logs are stored in the logstore.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime"},
+	}
+}
+
+func schema_pkg_apis_core_v1alpha1_UIBuildTerminated(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "UIBuildRunning respresents a finished build/update in the user interface.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"error": {
+						SchemaProps: spec.SchemaProps{
+							Description: "A non-empty string if the build failed with an error.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"warnings": {
+						SchemaProps: spec.SchemaProps{
+							Description: "A list of warnings encountered while running the build. These warnings will also be printed to the build's log.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"startTime": {
 						SchemaProps: spec.SchemaProps{
 							Description: "The time when the build started.",
 							Default:     map[string]interface{}{},
@@ -5257,11 +5859,31 @@ func schema_pkg_apis_core_v1alpha1_UIBuildTerminated(ref common.ReferenceCallbac
 							Format:      "",
 						},
 					},
+					"latency": {
+						SchemaProps: spec.SchemaProps{
+							Description: "How long it took from the file change that triggered the build until the resource was ready again.\n\nOnly populated once Tilt sees the resource become ready.",
+							Ref:         ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIBuildLatency"),
+						},
+					},
+					"imageCache": {
+						SchemaProps: spec.SchemaProps{
+							Description: "How each Dockerfile step used the build cache, for each image built.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIImageBuildCache"),
+									},
+								},
+							},
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime"},
+			"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIBuildLatency", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIImageBuildCache", "k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime"},
 	}
 }
 
@@ -5470,6 +6092,101 @@ func schema_pkg_apis_core_v1alpha1_UIButtonStatus(ref common.ReferenceCallback)
 	}
 }
 
+func schema_pkg_apis_core_v1alpha1_UIChoiceInputSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "Describes a choice dropdown input field attached to a button.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"choices": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The values the user can choose from.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: "",
+										Type:    []string{"string"},
+										Format:  "",
+									},
+								},
+							},
+						},
+					},
+					"defaultValue": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The initially selected value. Must be one of the choices. If unspecified, the first choice is selected.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"choices"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_core_v1alpha1_UIChoiceInputStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Type: []string{"object"},
+				Properties: map[string]spec.Schema{
+					"value": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The selected choice.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"value"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_core_v1alpha1_UIClusterConnection(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "The health of Tilt's connection to the Kubernetes cluster.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Description: "\"connected\", or \"degraded\" if Tilt has lost touch with the cluster, and pod and service status may be out of date.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"error": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The error from the most recent failed check, when degraded.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"since": {
+						SchemaProps: spec.SchemaProps{
+							Description: "When the connection entered its current status.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+				},
+				Required: []string{"status"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
+
 func schema_pkg_apis_core_v1alpha1_UIComponentLocation(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -5588,6 +6305,88 @@ func schema_pkg_apis_core_v1alpha1_UIHiddenInputStatus(ref common.ReferenceCallb
 	}
 }
 
+func schema_pkg_apis_core_v1alpha1_UIImageBuildCache(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "UIImageBuildCache is the step-by-step cache breakdown of one Dockerfile build.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"image": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The name of the image target.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"steps": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The steps of the Dockerfile, in order.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIBuildCacheStep"),
+									},
+								},
+							},
+						},
+					},
+					"target": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The Dockerfile stage that was built, if the image didn't use the last one.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"image"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIBuildCacheStep"},
+	}
+}
+
+func schema_pkg_apis_core_v1alpha1_UIImageRegistry(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "The image registry that Tilt pushes images to.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"host": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The host that Tilt pushes images to, e.g., localhost:5000.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"hostFromCluster": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The host that the cluster pulls images from, if it's different from Host (e.g., registry:5000 for a KIND cluster with a local registry).",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"source": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Where the registry came from: \"configured\" if the Tiltfile set it with default_registry(), or \"discovered\" if the cluster advertised a local registry.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"host", "source"},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_core_v1alpha1_UIInputSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -5629,12 +6428,18 @@ func schema_pkg_apis_core_v1alpha1_UIInputSpec(ref common.ReferenceCallback) com
 							Ref:         ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIHiddenInputSpec"),
 						},
 					},
+					"choice": {
+						SchemaProps: spec.SchemaProps{
+							Description: "A Choice input that takes one of a fixed list of values.",
+							Ref:         ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIChoiceInputSpec"),
+						},
+					},
 				},
 				Required: []string{"name"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIBoolInputSpec", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIHiddenInputSpec", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UITextInputSpec"},
+			"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIBoolInputSpec", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIChoiceInputSpec", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIHiddenInputSpec", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UITextInputSpec"},
 	}
 }
 
@@ -5671,12 +6476,100 @@ func schema_pkg_apis_core_v1alpha1_UIInputStatus(ref common.ReferenceCallback) c
 							Ref:         ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIHiddenInputStatus"),
 						},
 					},
+					"choice": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The status of the input, if it's a choice",
+							Ref:         ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIChoiceInputStatus"),
+						},
+					},
 				},
 				Required: []string{"name"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIBoolInputStatus", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIHiddenInputStatus", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UITextInputStatus"},
+			"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIBoolInputStatus", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIChoiceInputStatus", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIHiddenInputStatus", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UITextInputStatus"},
+	}
+}
+
+func schema_pkg_apis_core_v1alpha1_UIMemoryConsumer(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "An estimate of how much memory some of Tilt's data is using.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Description: "What the data is, e.g., \"logs\" or \"build history\".",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"bytes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The estimated bytes the data is using.",
+							Default:     0,
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+				},
+				Required: []string{"name", "bytes"},
+			},
+		},
+	}
+}
+
+func schema_pkg_apis_core_v1alpha1_UIMemoryUsage(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "How much memory Tilt is using.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"sampleTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "When Tilt sampled its memory usage.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime"),
+						},
+					},
+					"totalBytes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Bytes of memory Tilt has obtained from the OS and not returned.",
+							Default:     0,
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"heapBytes": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Bytes of live heap objects.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"consumers": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Tilt's estimates of what's using the memory, largest first.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIMemoryConsumer"),
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"sampleTime", "totalBytes"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIMemoryConsumer", "k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime"},
 	}
 }
 
@@ -5727,6 +6620,59 @@ func schema_pkg_apis_core_v1alpha1_UIResource(ref common.ReferenceCallback) comm
 	}
 }
 
+func schema_pkg_apis_core_v1alpha1_UIResourceCondition(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "UIResourceCondition is an observation about a resource.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"type": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Type of the condition.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Status of the condition, one of True, False, Unknown.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"lastTransitionTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The last time the condition changed from one status to another.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime"),
+						},
+					},
+					"reason": {
+						SchemaProps: spec.SchemaProps{
+							Description: "A one-word, CamelCase reason for the condition's last transition.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "A human-readable message about the condition, including what the user can do about it.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"type", "status"},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime"},
+	}
+}
+
 func schema_pkg_apis_core_v1alpha1_UIResourceKubernetes(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -5834,6 +6780,27 @@ func schema_pkg_apis_core_v1alpha1_UIResourceLink(ref common.ReferenceCallback)
 							Format:      "",
 						},
 					},
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Where the link came from.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"ready": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Whether Tilt believes the URL is serving.\n\nTilt doesn't check links declared in the Tiltfile, so those are always ready.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"pending": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Whether Tilt is still waiting to verify that the URL is serving.\n\nOnly set on port-forwards with verification configured.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
 				},
 			},
 		},
@@ -6145,11 +7112,74 @@ func schema_pkg_apis_core_v1alpha1_UIResourceStatus(ref common.ReferenceCallback
 							Ref:         ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIResourceStateWaiting"),
 						},
 					},
+					"links": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Every link Tilt knows about for this resource, with where it came from and whether it's serving.\n\nUnlike EndpointLinks, this includes service URLs even when the resource has port-forwards. Links declared in the Tiltfile come first.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIResourceLink"),
+									},
+								},
+							},
+						},
+					},
+					"lastReadyTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The last time the RuntimeStatus changed to ok from any other status.\n\nObjects that restart when this resource becomes ready (see RestartOnSpec.UIResources) watch this field.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime"),
+						},
+					},
+					"attentionScore": {
+						SchemaProps: spec.SchemaProps{
+							Description: "AttentionScore is a rough measure of how much this resource needs the user's attention. Higher is more urgent; 0 means there's nothing to look at.\n\nCurrent errors count the most, followed by recent build failures, crash rebuilds, pending changes, and warnings. Past events count less as they age.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"attentionOrder": {
+						SchemaProps: spec.SchemaProps{
+							Description: "AttentionOrder is the position of this resource when all resources are sorted by AttentionScore, highest first. Starts at 1. Resources with the same score keep their relative Order.\n\nUIs can sort by this field to show the resources that need attention first.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"conditions": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Conditions are observations about the resource that don't fit in UpdateStatus or RuntimeStatus, e.g., warnings about what's running.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIResourceCondition"),
+									},
+								},
+							},
+						},
+					},
+					"tiltfileWarnings": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Warnings about this resource from the last successful Tiltfile load.\n\nOn a Tiltfile resource, the warnings that aren't about one of its resources.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UITiltfileWarning"),
+									},
+								},
+							},
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.DisableResourceStatus", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIBuildRunning", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIBuildTerminated", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIResourceKubernetes", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIResourceLink", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIResourceLocal", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIResourceStateWaiting", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIResourceTargetSpec", "k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime"},
+			"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.DisableResourceStatus", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIBuildRunning", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIBuildTerminated", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIResourceCondition", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIResourceKubernetes", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIResourceLink", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIResourceLocal", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIResourceStateWaiting", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIResourceTargetSpec", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UITiltfileWarning", "k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime"},
 	}
 }
 
@@ -6392,11 +7422,36 @@ func schema_pkg_apis_core_v1alpha1_UISessionStatus(ref common.ReferenceCallback)
 							Format:      "",
 						},
 					},
+					"imageRegistry": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The image registry that Tilt pushes images to, if any.",
+							Ref:         ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIImageRegistry"),
+						},
+					},
+					"sleeping": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Sleeping is true when Tilt has gone to sleep after a period of inactivity. While asleep, Tilt doesn't stream logs or watch cluster events. Any request to the API server wakes it up.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"memoryUsage": {
+						SchemaProps: spec.SchemaProps{
+							Description: "How much memory Tilt is using, last we checked.",
+							Ref:         ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIMemoryUsage"),
+						},
+					},
+					"clusterConnection": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The health of Tilt's connection to the Kubernetes cluster. Not set until Tilt has checked the connection.",
+							Ref:         ref("github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIClusterConnection"),
+						},
+					},
 				},
 			},
 		},
 		Dependencies: []string{
-			"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.TiltBuild", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIFeatureFlag", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.VersionSettings", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+			"github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.TiltBuild", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIClusterConnection", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIFeatureFlag", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIImageRegistry", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.UIMemoryUsage", "github.com/tilt-dev/tilt/pkg/apis/core/v1alpha1.VersionSettings", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
 
@@ -6448,6 +7503,49 @@ func schema_pkg_apis_core_v1alpha1_UITextInputStatus(ref common.ReferenceCallbac
 	}
 }
 
+func schema_pkg_apis_core_v1alpha1_UITiltfileWarning(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "UITiltfileWarning is a warning from Tiltfile execution, e.g., use of a deprecated API.",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Description: "A human-readable description of the warning.",
+							Default:     "",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"key": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Identifies the warning across Tiltfile loads.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"resource": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The resource the warning is about, if any.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"location": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Where in the Tiltfile the warning came from, as file:line:col.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+				Required: []string{"message"},
+			},
+		},
+	}
+}
+
 func schema_pkg_apis_core_v1alpha1_VersionSettings(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
@@ -3,6 +3,7 @@
 package procutil
 
 import (
+	"fmt"
 	"os"
 	"os/exec"
 	"syscall"
@@ -28,3 +29,21 @@ func GracefullyShutdownProcess(p *os.Process) error {
 
 	return syscall.Kill(-p.Pid, syscall.SIGTERM)
 }
+
+var signalsByName = map[string]syscall.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGUSR1": syscall.SIGUSR1,
+	"SIGUSR2": syscall.SIGUSR2,
+}
+
+// Sends the named signal to the process group led by pid.
+func SignalProcessGroup(pid int, signal string) error {
+	sig, ok := signalsByName[signal]
+	if !ok {
+		return fmt.Errorf("unsupported signal %q", signal)
+	}
+	if pid <= 0 {
+		return fmt.Errorf("invalid pid %d", pid)
+	}
+	return syscall.Kill(-pid, sig)
+}
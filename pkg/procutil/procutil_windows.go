@@ -21,3 +21,7 @@ func KillProcessGroup(cmd *exec.Cmd) {
 func GracefullyShutdownProcess(p *os.Process) error {
 	return exec.Command("TASKKILL", "/T", "/PID", fmt.Sprintf("%d", p.Pid)).Run()
 }
+
+func SignalProcessGroup(pid int, signal string) error {
+	return fmt.Errorf("sending %s is not supported on Windows", signal)
+}
@@ -11,12 +11,14 @@ import {
 } from "./analytics_test_helpers"
 import {
   ApiButton,
+  ApiButtonCancelButton,
   ApiButtonForm,
   ApiButtonInputsToggleButton,
   ApiButtonLabel,
 } from "./ApiButton"
 import {
   boolField,
+  choiceField,
   hiddenField,
   makeUIButton,
   textField,
@@ -244,6 +246,121 @@ describe("ApiButton", () => {
     expect(actualStatus).toEqual(expectedStatus)
   })
 
+  it("submits the selected choice", async () => {
+    const inputSpecs = [choiceField("size", ["small", "medium", "large"])]
+    const root = mountButton(makeUIButton({ inputSpecs: inputSpecs }))
+
+    const optionsButton = root.find(ApiButtonInputsToggleButton)
+    optionsButton.simulate("click")
+    root.update()
+
+    const select = root.find(ApiButtonForm).find("select#size")
+    expect(select.props().value).toEqual("small")
+    select.simulate("change", { target: { value: "large" } })
+    root.update()
+
+    const submit = root.find(ApiButton).find(Button).at(0)
+    await act(async () => {
+      submit.simulate("click")
+      await flushPromises()
+    })
+    root.update()
+
+    const calls = fetchMock
+      .calls()
+      .filter((c) => c[0] !== "http://localhost/api/analytics")
+    expect(calls.length).toEqual(1)
+    const actualStatus: UIButtonStatus = JSON.parse(
+      calls[0][1]!.body!.toString()
+    ).status
+    expect(actualStatus.inputs).toEqual([
+      { name: "size", choice: { value: "large" } },
+    ])
+  })
+
+  it("ignores a saved choice that's no longer an option", async () => {
+    buttonInputsAccessor.set({ size: "huge" })
+    const inputSpecs = [choiceField("size", ["small", "large"], "large")]
+    const root = mountButton(makeUIButton({ inputSpecs: inputSpecs }))
+
+    const submit = root.find(ApiButton).find(Button).at(0)
+    await act(async () => {
+      submit.simulate("click")
+      await flushPromises()
+    })
+
+    const calls = fetchMock
+      .calls()
+      .filter((c) => c[0] !== "http://localhost/api/analytics")
+    expect(calls.length).toEqual(1)
+    const actualStatus: UIButtonStatus = JSON.parse(
+      calls[0][1]!.body!.toString()
+    ).status
+    expect(actualStatus.inputs).toEqual([
+      { name: "size", choice: { value: "large" } },
+    ])
+  })
+
+  it("requires a second click to confirm", async () => {
+    const root = mountButton(makeUIButton({ requiresConfirmation: true }))
+
+    await act(async () => {
+      root.find(ApiButton).find(Button).at(0).simulate("click")
+      await flushPromises()
+    })
+    root.update()
+
+    const apiCalls = () =>
+      fetchMock
+        .calls()
+        .filter((c) => c[0] !== "http://localhost/api/analytics")
+
+    // The first click only asks for confirmation.
+    expect(apiCalls().length).toEqual(0)
+    expect(root.find(ApiButton).find(ApiButtonLabel).text()).toEqual(
+      "Confirm"
+    )
+    expect(root.find(ApiButtonCancelButton).length).toEqual(1)
+
+    await act(async () => {
+      root.find(ApiButton).find(Button).at(0).simulate("click")
+      await flushPromises()
+    })
+    root.update()
+
+    expect(apiCalls().length).toEqual(1)
+    expect(root.find(ApiButton).find(ApiButtonLabel).text()).toEqual(
+      "Click Me!"
+    )
+    expect(root.find(ApiButtonCancelButton).length).toEqual(0)
+  })
+
+  it("doesn't submit if confirmation is canceled", async () => {
+    const root = mountButton(makeUIButton({ requiresConfirmation: true }))
+
+    root.find(ApiButton).find(Button).at(0).simulate("click")
+    root.update()
+    root.find(ApiButtonCancelButton).find("button").simulate("click")
+    root.update()
+
+    expect(root.find(ApiButtonCancelButton).length).toEqual(0)
+    expect(root.find(ApiButton).find(ApiButtonLabel).text()).toEqual(
+      "Click Me!"
+    )
+
+    // After canceling, a single click goes back to asking for confirmation.
+    await act(async () => {
+      root.find(ApiButton).find(Button).at(0).simulate("click")
+      await flushPromises()
+    })
+    root.update()
+
+    const calls = fetchMock
+      .calls()
+      .filter((c) => c[0] !== "http://localhost/api/analytics")
+    expect(calls.length).toEqual(0)
+  })
+
   it("reads options from local storage", () => {
     buttonInputsAccessor.set({
       text1: "text value",
@@ -36,9 +36,25 @@ export function hiddenField(name: string, value: string): UIInputSpec {
   }
 }
 
+export function choiceField(
+  name: string,
+  choices: string[],
+  defaultValue?: string
+): UIInputSpec {
+  return {
+    name: name,
+    label: name,
+    choice: {
+      choices: choices,
+      defaultValue: defaultValue,
+    },
+  }
+}
+
 export function makeUIButton(args?: {
   inputSpecs?: UIInputSpec[]
   inputStatuses?: UIInputStatus[]
+  requiresConfirmation?: boolean
 }): UIButton {
   return {
     metadata: {
@@ -48,6 +64,7 @@ export function makeUIButton(args?: {
       text: "Click Me!",
       iconName: "flight_takeoff",
       inputs: args?.inputSpecs,
+      requiresConfirmation: args?.requiresConfirmation,
     },
     status: {
       inputs: args?.inputStatuses,
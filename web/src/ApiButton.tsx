@@ -6,6 +6,7 @@ import {
   SvgIcon,
 } from "@material-ui/core"
 import ArrowDropDownIcon from "@material-ui/icons/ArrowDropDown"
+import CloseIcon from "@material-ui/icons/Close"
 import moment from "moment"
 import { useSnackbar } from "notistack"
 import React, { useRef, useState } from "react"
@@ -69,6 +70,12 @@ export const ApiButtonInputsToggleButton = styled(InstrumentedButton)`
   }
 `
 
+export const ApiButtonCancelButton = styled(InstrumentedButton)`
+  &&&& {
+    padding: 0 0;
+  }
+`
+
 const svgElement = (src: string): React.ReactElement => {
   const node = convertFromString(src, {
     selector: "svg",
@@ -115,6 +122,26 @@ function ApiButtonInput(props: ApiButtonInputProps) {
         onChange={(_, checked) => props.setValue(props.spec.name!, checked)}
       />
     )
+  } else if (props.spec.choice) {
+    return (
+      <InstrumentedTextField
+        select
+        SelectProps={{ native: true }}
+        label={props.spec.label ?? props.spec.name}
+        id={props.spec.name}
+        value={choiceValue(props.spec, props.value)}
+        onChange={(e) => props.setValue(props.spec.name!, e.target.value)}
+        analyticsName="ui.web.uibutton.inputValue"
+        analyticsTags={{ inputType: "choice" }}
+        fullWidth
+      >
+        {props.spec.choice.choices?.map((c) => (
+          <option key={c} value={c}>
+            {c}
+          </option>
+        ))}
+      </InstrumentedTextField>
+    )
   } else if (props.spec.hidden) {
     return null
   } else {
@@ -156,6 +183,7 @@ export function ApiButtonForm(props: ApiButtonFormProps) {
 
 type ApiButtonWithOptionsProps = {
   submit: JSX.Element
+  cancel: JSX.Element | null
   uiButton: UIButton
   setInputValue: (name: string, value: any) => void
   getInputValue: (name: string) => any | undefined
@@ -168,6 +196,7 @@ function ApiButtonWithOptions(props: ApiButtonWithOptionsProps & ButtonProps) {
 
   const {
     submit,
+    cancel,
     uiButton,
     setInputValue,
     getInputValue,
@@ -183,6 +212,7 @@ function ApiButtonWithOptions(props: ApiButtonWithOptionsProps & ButtonProps) {
         disabled={buttonProps.disabled}
       >
         {props.submit}
+        {props.cancel}
         <ApiButtonInputsToggleButton
           size="small"
           onClick={() => {
@@ -237,6 +267,17 @@ export const ApiIcon: React.FC<ApiIconProps> = (props) => {
   return null
 }
 
+// The selected value of a choice input.
+// A value saved from an earlier version of the button may no longer be one of
+// the choices, so fall back to the default.
+function choiceValue(spec: UIInputSpec, value: any): string {
+  const choices = spec.choice?.choices ?? []
+  if (value !== undefined && choices.includes(value)) {
+    return value
+  }
+  return spec.choice?.defaultValue || choices[0] || ""
+}
+
 // returns metadata + button status w/ the specified input buttons
 function buttonStatusWithInputs(
   button: UIButton,
@@ -268,6 +309,8 @@ function buttonStatusWithInputs(
       }
     } else if (spec.hidden) {
       status.hidden = { value: spec.hidden.value }
+    } else if (spec.choice) {
+      status.choice = { value: choiceValue(spec, value) }
     }
     result.status!.inputs!.push(status)
   })
@@ -305,6 +348,9 @@ export function ApiButton(props: React.PropsWithChildren<ApiButtonProps>) {
   const { className, uiButton, ...buttonProps } = props
 
   const [loading, setLoading] = useState(false)
+  // Buttons that require confirmation take two clicks: the first asks the
+  // user to confirm, and the second submits.
+  const [confirming, setConfirming] = useState(false)
   const [inputValues, setInputValues] = usePersistentState<{
     [name: string]: any
   }>(`apibutton-${uiButton.metadata?.name}`, {})
@@ -315,6 +361,12 @@ export function ApiButton(props: React.PropsWithChildren<ApiButtonProps>) {
   const { setError } = useHudErrorContext()
 
   const onClick = async () => {
+    if (uiButton.spec?.requiresConfirmation && !confirming) {
+      setConfirming(true)
+      return
+    }
+    setConfirming(false)
+
     // TODO(milas): currently the loading state just disables the button for the duration of
     //  the AJAX request to avoid duplicate clicks - there is no progress tracking at the
     //  moment, so there's no fancy spinner animation or propagation of result of action(s)
@@ -360,18 +412,33 @@ export function ApiButton(props: React.PropsWithChildren<ApiButtonProps>) {
       aria-label={`Trigger ${uiButton.spec?.text}`}
       {...buttonProps}
     >
-      {props.children || (
-        <>
-          <ApiIcon
-            iconName={uiButton.spec?.iconName}
-            iconSVG={uiButton.spec?.iconSVG}
-          />
-          <ApiButtonLabel>{uiButton.spec?.text ?? "Button"}</ApiButtonLabel>
-        </>
+      {confirming ? (
+        <ApiButtonLabel>Confirm</ApiButtonLabel>
+      ) : (
+        props.children || (
+          <>
+            <ApiIcon
+              iconName={uiButton.spec?.iconName}
+              iconSVG={uiButton.spec?.iconSVG}
+            />
+            <ApiButtonLabel>{uiButton.spec?.text ?? "Button"}</ApiButtonLabel>
+          </>
+        )
       )}
     </InstrumentedButton>
   )
 
+  const cancelButton = confirming ? (
+    <ApiButtonCancelButton
+      {...buttonProps}
+      analyticsName={"ui.web.uibutton.cancelConfirm"}
+      onClick={() => setConfirming(false)}
+      aria-label={`Cancel ${uiButton.spec?.text}`}
+    >
+      <CloseIcon fontSize="small" />
+    </ApiButtonCancelButton>
+  ) : null
+
   // show the options button if there are any non-hidden inputs
   if (uiButton.spec?.inputs?.filter((i) => !i.hidden)?.length) {
     const setInputValue = (name: string, value: any) => {
@@ -384,6 +451,7 @@ export function ApiButton(props: React.PropsWithChildren<ApiButtonProps>) {
       <ApiButtonWithOptions
         className={className}
         submit={button}
+        cancel={cancelButton}
         uiButton={uiButton}
         setInputValue={setInputValue}
         getInputValue={getInputValue}
@@ -405,6 +473,7 @@ export function ApiButton(props: React.PropsWithChildren<ApiButtonProps>) {
         disabled={disabled}
       >
         {button}
+        {cancelButton}
       </ApiButtonRoot>
     )
   }
@@ -0,0 +1,84 @@
+import { mount } from "enzyme"
+import fetchMock from "fetch-mock"
+import React from "react"
+import { act } from "react-dom/test-utils"
+import {
+  cleanupMockAnalyticsCalls,
+  mockAnalyticsCalls,
+} from "./analytics_test_helpers"
+import DisableButton, { postDisable } from "./DisableButton"
+import FloatDialog from "./FloatDialog"
+
+function lastDisableBody(): any {
+  const calls = fetchMock.calls().filter((c) => c[0] === "/api/disable")
+  return JSON.parse(calls[calls.length - 1][1]?.body?.toString() ?? "")
+}
+
+async function flush() {
+  await act(async () => {
+    await new Promise((resolve) => setTimeout(resolve, 0))
+  })
+}
+
+describe("DisableButton", () => {
+  beforeEach(() => {
+    mockAnalyticsCalls()
+  })
+
+  afterEach(() => {
+    cleanupMockAnalyticsCalls()
+  })
+
+  it("sends the names and options", async () => {
+    fetchMock.post("/api/disable", {
+      changed: ["db"],
+      cascaded: null,
+      conflicts: null,
+      applied: true,
+    })
+
+    const resp = await postDisable(["db"], true, { cascade: true })
+    expect(resp.applied).toBe(true)
+    expect(lastDisableBody()).toEqual({
+      manifest_names: ["db"],
+      disable: true,
+      cascade: true,
+      force: false,
+      source: "UI",
+    })
+  })
+
+  it("shows the dependents when disabling is blocked", async () => {
+    fetchMock.post("/api/disable", {
+      changed: null,
+      cascaded: null,
+      conflicts: [{ name: "db", related: ["api", "fe"] }],
+      applied: false,
+    })
+
+    const root = mount(<DisableButton resourceName="db" isDisabled={false} />)
+    root.find("button").first().simulate("click")
+    await flush()
+    root.update()
+
+    const dialog = root.find(FloatDialog)
+    expect(dialog.prop("open")).toBe(true)
+    expect(dialog.find("li").map((li) => li.text())).toEqual(["api", "fe"])
+  })
+
+  it("enables a disabled resource", async () => {
+    fetchMock.post("/api/disable", {
+      changed: ["db"],
+      cascaded: null,
+      conflicts: null,
+      applied: true,
+    })
+
+    const root = mount(<DisableButton resourceName="db" isDisabled={true} />)
+    expect(root.find("button").first().text()).toEqual("Enable")
+    root.find("button").first().simulate("click")
+    await flush()
+
+    expect(lastDisableBody().disable).toBe(false)
+  })
+})
@@ -0,0 +1,146 @@
+import React, { useState } from "react"
+import styled from "styled-components"
+import FloatDialog from "./FloatDialog"
+import { InstrumentedButton } from "./instrumentedComponents"
+import { OverviewButtonMixin } from "./OverviewButton"
+import { Color, FontSize, SizeUnit } from "./style-helpers"
+
+// A resource that has to change along with the one we asked for.
+export type DisableConflict = {
+  name: string
+  related: string[]
+}
+
+export type DisableResponse = {
+  changed: string[] | null
+  cascaded: string[] | null
+  conflicts: DisableConflict[] | null
+  applied: boolean
+}
+
+export type DisableOptions = {
+  cascade?: boolean
+  force?: boolean
+}
+
+// Disables (or enables) the resources, unless that would leave dependents
+// enabled (or dependencies disabled) and the options don't say what to do
+// about it.
+export async function postDisable(
+  names: string[],
+  disable: boolean,
+  opts: DisableOptions = {}
+): Promise<DisableResponse> {
+  const resp = await fetch("/api/disable", {
+    method: "POST",
+    headers: {
+      Accept: "application/json",
+      "Content-Type": "application/json",
+    },
+    body: JSON.stringify({
+      manifest_names: names,
+      disable: disable,
+      cascade: !!opts.cascade,
+      force: !!opts.force,
+      source: "UI",
+    }),
+  })
+  if (resp.status !== 200) {
+    const body = await resp.text()
+    throw `error changing disable state: ${body}`
+  }
+  return resp.json()
+}
+
+const DisableButtonRoot = styled(InstrumentedButton)`
+  ${OverviewButtonMixin}
+  margin-left: ${SizeUnit(0.5)};
+`
+
+const ConflictList = styled.ul`
+  margin: 0 0 ${SizeUnit(0.5)} 0;
+  padding-left: ${SizeUnit(0.5)};
+`
+
+const DialogActions = styled.div`
+  display: flex;
+  justify-content: flex-end;
+  font-size: ${FontSize.small};
+
+  button + button {
+    margin-left: ${SizeUnit(0.25)};
+  }
+`
+
+const DialogButton = styled(InstrumentedButton)`
+  ${OverviewButtonMixin}
+  color: ${Color.grayDarkest};
+`
+
+type DisableButtonProps = {
+  resourceName: string
+  isDisabled: boolean
+}
+
+export default function DisableButton(props: DisableButtonProps) {
+  const { resourceName, isDisabled } = props
+  const [conflicts, setConflicts] = useState<DisableConflict[] | null>(null)
+  const [anchorEl, setAnchorEl] = useState<Element | null>(null)
+  const disable = !isDisabled
+  const verb = disable ? "Disable" : "Enable"
+
+  const submit = async (opts: DisableOptions, anchor: Element | null) => {
+    const resp = await postDisable([resourceName], disable, opts)
+    if (!resp.applied) {
+      setAnchorEl(anchor)
+      setConflicts(resp.conflicts || [])
+      return
+    }
+    setConflicts(null)
+  }
+
+  const onClose = () => setConflicts(null)
+  const related = disable
+    ? "These enabled resources depend on it:"
+    : "It depends on these disabled resources:"
+
+  return (
+    <>
+      <DisableButtonRoot
+        onClick={(e) => submit({}, e.currentTarget)}
+        analyticsName="ui.web.actionBar.toggleDisable"
+        analyticsTags={{ disable: disable.toString() }}
+      >
+        {verb}
+      </DisableButtonRoot>
+      <FloatDialog
+        id="disable-conflicts"
+        title={`${verb} ${resourceName}?`}
+        open={conflicts !== null}
+        anchorEl={anchorEl}
+        onClose={onClose}
+      >
+        <div>{related}</div>
+        <ConflictList>
+          {(conflicts || []).map((c) =>
+            c.related.map((r) => <li key={`${c.name}-${r}`}>{r}</li>)
+          )}
+        </ConflictList>
+        <DialogActions>
+          <DialogButton
+            onClick={() => submit({ force: true }, anchorEl)}
+            analyticsName="ui.web.disableConflicts.force"
+          >
+            {verb} anyway
+          </DialogButton>
+          <DialogButton
+            onClick={() => submit({ cascade: true }, anchorEl)}
+            analyticsName="ui.web.disableConflicts.cascade"
+          >
+            {verb} all
+          </DialogButton>
+        </DialogActions>
+      </FloatDialog>
+    </>
+  )
+}
@@ -75,6 +75,24 @@ it("renders reconnecting bar", async () => {
   )
 })
 
+it("renders cluster connection bar", async () => {
+  const root = mount(emptyHUD())
+  const hud = root.find(HUD)
+
+  let view = oneResourceView()
+  view.uiSession!.status!.clusterConnection = {
+    status: "degraded",
+    error: "connection refused",
+  }
+  hud.setState({ view: view, socketState: SocketState.Active })
+
+  let socketBar = root.find(SocketBar)
+  expect(socketBar).toHaveLength(1)
+  expect(socketBar.at(0).text()).toEqual(
+    expect.stringContaining("Lost connection to the Kubernetes cluster")
+  )
+})
+
 it("loads logs incrementally", async () => {
   const root = mount(emptyHUD())
   const hud = root.find(HUD).instance() as HUD
@@ -225,7 +225,13 @@ export default class HUD extends Component<HudProps, HudState> {
                 <ResourceNavProvider validateResource={validateResource}>
                   <div className={hudClasses.join(" ")}>
                     <AnalyticsNudge needsNudge={needsNudge} />
-                    <SocketBar state={this.state.socketState} />
+                    <SocketBar
+                      state={this.state.socketState}
+                      sleeping={session?.sleeping ?? false}
+                      clusterDegraded={
+                        session?.clusterConnection?.status === "degraded"
+                      }
+                    />
                     {fatalErrorModal}
                     {errorModal}
                     {shareSnapshotModal}
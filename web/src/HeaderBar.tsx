@@ -3,6 +3,7 @@ import { Link } from "react-router-dom"
 import styled from "styled-components"
 import { ReactComponent as LogoWordmarkSvg } from "./assets/svg/logo-wordmark.svg"
 import { CustomNav } from "./CustomNav"
+import { Flag, useFeatures } from "./feature"
 import { GlobalNav } from "./GlobalNav"
 import { usePathBuilder } from "./PathBuilder"
 import {
@@ -53,6 +54,20 @@ const AllResourcesLink = styled(Link)`
   text-decoration: none;
 `
 
+const ReadOnlyBadge = styled.div`
+  font-family: ${Font.monospace};
+  font-size: ${FontSize.small};
+  color: ${Color.grayDarkest};
+  background-color: ${Color.yellow};
+  border-radius: ${SizeUnit(0.125)};
+  padding: 0 ${SizeUnit(0.25)};
+  margin-left: ${SizeUnit(0.5)};
+`
+
+const readOnlyTitle =
+  "This Tilt was started with --read-only. " +
+  "Triggers, disables, and other changes are turned off."
+
 type HeaderBarProps = {
   view: Proto.webviewView
 }
@@ -83,6 +98,7 @@ export default function HeaderBar(props: HeaderBarProps) {
   }
 
   const pb = usePathBuilder()
+  const isReadOnly = useFeatures().isEnabled(Flag.ReadOnly)
 
   return (
     <HeaderBarRoot>
@@ -93,6 +109,9 @@ export default function HeaderBar(props: HeaderBarProps) {
       <AllResourcesLink to={pb.encpath`/r/(all)/overview`}>
         All Resources
       </AllResourcesLink>
+      {isReadOnly ? (
+        <ReadOnlyBadge title={readOnlyTitle}>Read-only</ReadOnlyBadge>
+      ) : null}
       <AllResourceStatusSummary resources={resources} />
       <CustomNav view={props.view} />
       <GlobalNav {...globalNavProps} />
@@ -17,11 +17,12 @@ import { ReactComponent as CloseSvg } from "./assets/svg/close.svg"
 import { ReactComponent as CopySvg } from "./assets/svg/copy.svg"
 import { ReactComponent as FilterSvg } from "./assets/svg/filter.svg"
 import { ReactComponent as LinkSvg } from "./assets/svg/link.svg"
+import DisableButton from "./DisableButton"
 import {
   InstrumentedButton,
   InstrumentedTextField,
 } from "./instrumentedComponents"
-import { displayURL } from "./links"
+import { displayURL, pendingLinkTitle } from "./links"
 import LogActions from "./LogActions"
 import {
   EMPTY_TERM,
@@ -630,6 +631,11 @@ export let Endpoint = styled.a`
   &:hover {
     color: ${Color.blue};
   }
+
+  &.is-pending {
+    opacity: 0.5;
+    font-style: italic;
+  }
 `
 
 let EndpointIcon = styled(LinkSvg)`
@@ -684,6 +690,8 @@ export default function OverviewActionBar(props: OverviewActionBarProps) {
         // We use ep.url as the target, so that clicking the link re-uses the tab.
         target={ep.url}
         key={ep.url}
+        className={ep.pending ? "is-pending" : ""}
+        title={ep.pending ? pendingLinkTitle : undefined}
       >
         <TruncateText>{ep.name || displayURL(ep)}</TruncateText>
       </Endpoint>
@@ -708,6 +716,12 @@ export default function OverviewActionBar(props: OverviewActionBarProps) {
     topRowEls.push(widgets)
   }
 
+  const isDisabled = (resource?.status?.disableStatus?.disabledCount ?? 0) > 0
+  const disableButton =
+    resource && !isSnapshot ? (
+      <DisableButton resourceName={resourceName} isDisabled={isDisabled} />
+    ) : null
+
   const topRow = topRowEls.length ? (
     <ActionBarTopRow key="top">{topRowEls}</ActionBarTopRow>
   ) : null
@@ -739,6 +753,7 @@ export default function OverviewActionBar(props: OverviewActionBarProps) {
         />
         <FilterTermField termFromUrl={filterSet.term} />
         <LogActions resourceName={resourceName} isSnapshot={isSnapshot} />
+        {disableButton}
       </ActionBarBottomRow>
     </ActionBarRoot>
   )
@@ -36,7 +36,7 @@ import {
   TILTFILE_LABEL,
   UNLABELED_LABEL,
 } from "./labels"
-import { displayURL } from "./links"
+import { displayURL, pendingLinkTitle } from "./links"
 import { LogAlertIndex, useLogAlertIndex } from "./LogStore"
 import { OverviewButtonMixin } from "./OverviewButton"
 import OverviewTableStarResourceButton, {
@@ -268,6 +268,11 @@ const Endpoint = styled.a`
   display: flex;
   align-items: center;
   max-width: 150px;
+
+  &.is-pending {
+    opacity: 0.5;
+    font-style: italic;
+  }
 `
 const DetailText = styled.div`
   overflow: hidden;
@@ -511,9 +516,12 @@ function TableEndpointColumn({ row }: CellProps<RowValues>) {
         // We use ep.url as the target, so that clicking the link re-uses the tab.
         target={ep.url}
         key={ep.url}
+        className={ep.pending ? "is-pending" : ""}
       >
         <StyledLinkSvg />
-        <DetailText title={ep.name || displayURL(ep)}>
+        <DetailText
+          title={ep.pending ? pendingLinkTitle : ep.name || displayURL(ep)}
+        >
           {ep.name || displayURL(ep)}
         </DetailText>
       </Endpoint>
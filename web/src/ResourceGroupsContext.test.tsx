@@ -6,6 +6,7 @@ import {
   expectIncrs,
   mockAnalyticsCalls,
 } from "./analytics_test_helpers"
+import { BOOTSTRAP_LABEL } from "./labels"
 import {
   DEFAULT_GROUP_STATE,
   ResourceGroupsContextProvider,
@@ -146,5 +147,15 @@ describe("ResourceGroupsContext", () => {
 
       expect(labelState()).toBe(JSON.stringify(DEFAULT_GROUP_STATE))
     })
+
+    it("returns a collapsed state for the bootstrap group if it isn't saved yet", () => {
+      wrapper = mount(
+        <ResourceGroupsContextProvider>
+          <TestConsumer labelName={BOOTSTRAP_LABEL} />
+        </ResourceGroupsContextProvider>
+      )
+
+      expect(labelState()).toBe(JSON.stringify({ expanded: false }))
+    })
   })
 })
@@ -1,5 +1,6 @@
 import { createContext, PropsWithChildren, useContext } from "react"
 import { AnalyticsAction, AnalyticsType, incr } from "./analytics"
+import { BOOTSTRAP_LABEL } from "./labels"
 import { usePersistentState } from "./LocalStorage"
 
 export type GroupState = { expanded: boolean }
@@ -19,6 +20,13 @@ export const DEFAULT_GROUP_STATE: GroupState = {
   expanded: DEFAULT_EXPANDED_STATE,
 }
 
+function defaultGroupState(groupLabel: string): GroupState {
+  if (groupLabel === BOOTSTRAP_LABEL) {
+    return { expanded: false }
+  }
+  return { ...DEFAULT_GROUP_STATE }
+}
+
 const resourceGroupsContext = createContext<ResourceGroupsContext>({
   groups: {},
   toggleGroupExpanded: () => {
@@ -44,7 +52,7 @@ export function ResourceGroupsContextProvider(
   )
 
   function toggleGroupExpanded(groupLabel: string, page: AnalyticsType) {
-    const currentGroupState = groups[groupLabel] ?? { ...DEFAULT_GROUP_STATE }
+    const currentGroupState = groups[groupLabel] ?? defaultGroupState(groupLabel)
     const nextGroupState = {
       ...currentGroupState,
       expanded: !currentGroupState.expanded,
@@ -64,7 +72,7 @@ export function ResourceGroupsContextProvider(
   }
 
   function getGroup(groupLabel: string) {
-    return groups[groupLabel] ?? { ...DEFAULT_GROUP_STATE }
+    return groups[groupLabel] ?? defaultGroupState(groupLabel)
   }
 
   const defaultValue: ResourceGroupsContext = {
@@ -9,3 +9,11 @@ export default {
 export const _Reconnecting = () => (
   <SocketBar state={SocketState.Reconnecting} />
 )
+
+export const _Sleeping = () => (
+  <SocketBar state={SocketState.Active} sleeping={true} />
+)
+
+export const _ClusterDegraded = () => (
+  <SocketBar state={SocketState.Active} clusterDegraded={true} />
+)
@@ -5,6 +5,10 @@ import { SocketState } from "./types"
 
 type SocketBarProps = {
   state: SocketState
+  // Set when Tilt has gone to sleep after a period of inactivity.
+  sleeping?: boolean
+  // Set when Tilt has lost touch with the Kubernetes cluster.
+  clusterDegraded?: boolean
 }
 
 let pulse = keyframes`
@@ -43,6 +47,11 @@ export default function SocketBar(props: SocketBarProps) {
     message = "Reconnecting…"
   } else if (state === SocketState.Loading) {
     message = "Connecting…"
+  } else if (props.sleeping) {
+    message = "Tilt is sleeping. The next file change or trigger will wake it up."
+  } else if (props.clusterDegraded) {
+    message =
+      "Lost connection to the Kubernetes cluster. Pod and service status may be out of date."
   }
 
   if (!message) {
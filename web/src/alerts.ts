@@ -80,10 +80,17 @@ function runtimeWarningCount(r: UIResource, alertIndex: LogAlertIndex): number {
   }).length
 }
 
+// Unlike log alerts, Tiltfile warnings stay until a Tiltfile load
+// no longer produces them.
+function tiltfileWarningCount(r: UIResource): number {
+  return r.status?.tiltfileWarnings?.length ?? 0
+}
+
 export {
   combinedAlerts,
   buildAlerts,
   runtimeAlerts,
   buildWarningCount,
   runtimeWarningCount,
+  tiltfileWarningCount,
 }
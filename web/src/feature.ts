@@ -16,6 +16,9 @@ export enum Flag {
   Facets = "facets",
   Labels = "labels",
   DisableResources = "disable_resources",
+
+  // Not a real feature flag. Set by the server when started with --read-only.
+  ReadOnly = "read_only",
 }
 
 export default class Features {
@@ -6,6 +6,9 @@ import { UIResource } from "./types"
 export const UNLABELED_LABEL = "unlabeled"
 export const TILTFILE_LABEL = "Tiltfile"
 
+// Bootstrap tasks only run once, so their group starts collapsed.
+export const BOOTSTRAP_LABEL = "bootstrap"
+
 export type GroupByLabelView<T> = {
   labels: string[]
   labelsToResources: { [key: string]: T[] }
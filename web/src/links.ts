@@ -8,3 +8,6 @@ export function displayURL(li: Link): string {
   url = url?.replace(/^(www\.)/, "")
   return url || ""
 }
+
+// Shown on port-forwards that Tilt hasn't verified yet.
+export const pendingLinkTitle = "Waiting for the server to respond"
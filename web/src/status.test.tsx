@@ -118,6 +118,17 @@ describe("combinedStatus", () => {
     )
   })
 
+  it("warning when the Tiltfile warns about the resource", () => {
+    let ls = new LogStore()
+    let res = emptyResource()
+    res.status!.updateStatus = UpdateStatus.Ok
+    res.status!.runtimeStatus = RuntimeStatus.Ok
+    res.status!.tiltfileWarnings = [{ message: "check your ports" }]
+    expect(combinedStatus(buildStatus(res, ls), runtimeStatus(res, ls))).toBe(
+      ResourceStatus.Warning
+    )
+  })
+
   it("none when n/a runtime status and no builds", () => {
     let ls = new LogStore()
     let res = emptyResource()
@@ -1,4 +1,8 @@
-import { buildWarningCount, runtimeWarningCount } from "./alerts"
+import {
+  buildWarningCount,
+  runtimeWarningCount,
+  tiltfileWarningCount,
+} from "./alerts"
 import { Hold } from "./Hold"
 import { LogAlertIndex } from "./LogStore"
 import { ResourceStatus, RuntimeStatus, UpdateStatus } from "./types"
@@ -19,8 +23,11 @@ function buildStatus(r: UIResource, alertIndex: LogAlertIndex): ResourceStatus {
     return ResourceStatus.None
   } else if (res.updateStatus == UpdateStatus.Error) {
     return ResourceStatus.Unhealthy
-  } else if (buildWarningCount(r, alertIndex) > 0) {
-    // Warnings are derived from the log store, so that clearing
+  } else if (
+    buildWarningCount(r, alertIndex) > 0 ||
+    tiltfileWarningCount(r) > 0
+  ) {
+    // Build warnings are derived from the log store, so that clearing
     // logs clears the warning indicator.
     return ResourceStatus.Warning
   } else if (res.updateStatus == UpdateStatus.Ok) {
@@ -370,6 +370,18 @@ declare namespace Proto {
      */
     value?: string;
   }
+  export interface v1alpha1UITiltfileWarning {
+    /**
+     * A human-readable description of the warning.
+     */
+    message?: string;
+    key?: string;
+    resource?: string;
+    /**
+     * Where in the Tiltfile the warning came from, as file:line:col.
+     */
+    location?: string;
+  }
   export interface v1alpha1UITextInputSpec {
     /**
      * Initial value for this field.
@@ -384,6 +396,25 @@ declare namespace Proto {
      */
     placeholder?: string;
   }
+  export interface v1alpha1UIClusterConnection {
+    /**
+     * "connected", or "degraded" if Tilt has lost touch with the cluster,
+     * and pod and service status may be out of date.
+     */
+    status?: string;
+    /**
+     * The error from the most recent failed check, when degraded.
+     *
+     * +optional
+     */
+    error?: string;
+    /**
+     * When the connection entered its current status.
+     *
+     * +optional
+     */
+    since?: string;
+  }
   export interface v1alpha1UISessionStatus {
     featureFlags?: v1alpha1UIFeatureFlag[];
     needsAnalyticsNudge?: boolean;
@@ -397,6 +428,8 @@ declare namespace Proto {
     fatalError?: string;
     tiltStartTime?: string;
     tiltfileKey?: string;
+    sleeping?: boolean;
+    clusterConnection?: v1alpha1UIClusterConnection;
   }
   export interface v1alpha1UISessionSpec {}
   export interface v1alpha1UISession {
@@ -458,6 +491,14 @@ declare namespace Proto {
      * +optional
      */
     waiting?: v1alpha1UIResourceStateWaiting;
+    /**
+     * Warnings about this resource from the last successful Tiltfile load.
+     *
+     * On a Tiltfile resource, the warnings that aren't about one of its resources.
+     *
+     * +optional
+     */
+    tiltfileWarnings?: v1alpha1UITiltfileWarning[];
   }
   export interface v1alpha1UIResourceStateWaitingOnRef {
     /**
@@ -501,6 +542,13 @@ declare namespace Proto {
   export interface v1alpha1UIResourceLink {
     url?: string;
     name?: string;
+    /**
+     * Whether Tilt is still waiting to verify that the URL is serving.
+     *
+     * Only set on port-forwards with verification configured.
+     * +optional
+     */
+    pending?: boolean;
   }
   export interface v1alpha1UIResourceKubernetes {
     /**
@@ -534,6 +582,7 @@ declare namespace Proto {
     text?: v1alpha1UITextInputStatus;
     bool?: v1alpha1UIBoolInputStatus;
     hidden?: v1alpha1UIHiddenInputStatus;
+    choice?: v1alpha1UIChoiceInputStatus;
   }
   export interface v1alpha1UIInputSpec {
     /**
@@ -544,6 +593,26 @@ declare namespace Proto {
     text?: v1alpha1UITextInputSpec;
     bool?: v1alpha1UIBoolInputSpec;
     hidden?: v1alpha1UIHiddenInputSpec;
+    choice?: v1alpha1UIChoiceInputSpec;
+  }
+  export interface v1alpha1UIChoiceInputStatus {
+    /**
+     * The selected choice.
+     */
+    value?: string;
+  }
+  export interface v1alpha1UIChoiceInputSpec {
+    /**
+     * The values the user can choose from.
+     */
+    choices?: string[];
+    /**
+     * The initially selected value. Must be one of the choices.
+     * If unspecified, the first choice is selected.
+     *
+     * +optional
+     */
+    defaultValue?: string;
   }
   export interface v1alpha1UIHiddenInputStatus {
     value?: string;
@@ -661,6 +730,28 @@ declare namespace Proto {
      * All unique sources that control the resource's objects' disable status.
      */
     sources?: v1alpha1DisableSource[];
+    /**
+     * Where the most recent change to the resource's disable state came from.
+     */
+    source?: string;
+    /**
+     * The most recent changes to the resource's disable state, oldest first.
+     */
+    transitions?: v1alpha1DisableTransition[];
+  }
+  export interface v1alpha1DisableTransition {
+    /**
+     * Whether the resource was disabled (or enabled) by this change.
+     */
+    disabled?: boolean;
+    /**
+     * Where the change came from.
+     */
+    source?: string;
+    /**
+     * When the change happened.
+     */
+    time?: string;
   }
   export interface v1alpha1ConfigMapDisableSource {
     name?: string;